@@ -12,10 +12,32 @@ import (
 	"go.signoz.io/signoz/ee/query-service/license"
 	"go.signoz.io/signoz/ee/query-service/usage"
 	baseapp "go.signoz.io/signoz/pkg/query-service/app"
+	"go.signoz.io/signoz/pkg/query-service/app/alerthistory"
+	"go.signoz.io/signoz/pkg/query-service/app/attributemetadata"
+	"go.signoz.io/signoz/pkg/query-service/app/audit"
+	"go.signoz.io/signoz/pkg/query-service/app/backup"
+	"go.signoz.io/signoz/pkg/query-service/app/cardinality"
+	"go.signoz.io/signoz/pkg/query-service/app/deployments"
+	"go.signoz.io/signoz/pkg/query-service/app/errortracking"
+	"go.signoz.io/signoz/pkg/query-service/app/events"
+	"go.signoz.io/signoz/pkg/query-service/app/heartbeats"
 	"go.signoz.io/signoz/pkg/query-service/app/integrations"
+	"go.signoz.io/signoz/pkg/query-service/app/issues"
+	"go.signoz.io/signoz/pkg/query-service/app/logexports"
+	"go.signoz.io/signoz/pkg/query-service/app/logmetrics"
 	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
+	"go.signoz.io/signoz/pkg/query-service/app/logviews"
+	"go.signoz.io/signoz/pkg/query-service/app/profiles"
+	"go.signoz.io/signoz/pkg/query-service/app/queryhistory"
+	"go.signoz.io/signoz/pkg/query-service/app/reports"
+	"go.signoz.io/signoz/pkg/query-service/app/sampling"
+	"go.signoz.io/signoz/pkg/query-service/app/skipindexes"
+	"go.signoz.io/signoz/pkg/query-service/app/spanmetrics"
+	"go.signoz.io/signoz/pkg/query-service/app/synthetics"
+	"go.signoz.io/signoz/pkg/query-service/app/webhooks"
 	"go.signoz.io/signoz/pkg/query-service/cache"
 	baseint "go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/migrate/schema"
 	basemodel "go.signoz.io/signoz/pkg/query-service/model"
 	rules "go.signoz.io/signoz/pkg/query-service/rules"
 	"go.signoz.io/signoz/pkg/query-service/version"
@@ -25,6 +47,7 @@ type APIHandlerOptions struct {
 	DataConnector                 interfaces.DataConnector
 	SkipConfig                    *basemodel.SkipConfig
 	PreferSpanMetrics             bool
+	OTLPReceiver                  bool
 	MaxIdleConns                  int
 	MaxOpenConns                  int
 	DialTimeout                   time.Duration
@@ -34,7 +57,29 @@ type APIHandlerOptions struct {
 	FeatureFlags                  baseint.FeatureLookup
 	LicenseManager                *license.Manager
 	IntegrationsController        *integrations.Controller
+	ReportManager                 *reports.Manager
+	LogExportManager              *logexports.Manager
+	LogsViewManager               *logviews.Manager
+	SkipIndexManager              *skipindexes.Manager
+	SchemaMigrator                *schema.Migrator
+	BackupManager                 *backup.Manager
+	QueryHistoryManager           *queryhistory.Manager
+	AuditManager                  *audit.Manager
+	WebhookManager                *webhooks.Manager
+	IssueManager                  *issues.Manager
+	ErrorTrackingManager          *errortracking.Manager
+	LogMetricsManager             *logmetrics.Manager
+	DeploymentManager             *deployments.Manager
+	ProfileManager                *profiles.Manager
+	EventManager                  *events.Manager
+	AttributeMetadataManager      *attributemetadata.Manager
+	AlertHistoryManager           *alerthistory.Manager
 	LogsParsingPipelineController *logparsingpipeline.LogParsingPipelineController
+	SpanMetricsController         *spanmetrics.SpanMetricsController
+	SamplingController            *sampling.SamplingController
+	CardinalityController         *cardinality.CardinalityController
+	SyntheticsController          *synthetics.SyntheticsController
+	HeartbeatRepo                 *heartbeats.Repo
 	Cache                         cache.Cache
 	Gateway                       *httputil.ReverseProxy
 	// Querier Influx Interval
@@ -53,6 +98,7 @@ func NewAPIHandler(opts APIHandlerOptions) (*APIHandler, error) {
 		Reader:                        opts.DataConnector,
 		SkipConfig:                    opts.SkipConfig,
 		PreferSpanMetrics:             opts.PreferSpanMetrics,
+		OTLPReceiver:                  opts.OTLPReceiver,
 		MaxIdleConns:                  opts.MaxIdleConns,
 		MaxOpenConns:                  opts.MaxOpenConns,
 		DialTimeout:                   opts.DialTimeout,
@@ -60,7 +106,29 @@ func NewAPIHandler(opts APIHandlerOptions) (*APIHandler, error) {
 		RuleManager:                   opts.RulesManager,
 		FeatureFlags:                  opts.FeatureFlags,
 		IntegrationsController:        opts.IntegrationsController,
+		ReportManager:                 opts.ReportManager,
+		LogExportManager:              opts.LogExportManager,
+		LogsViewManager:               opts.LogsViewManager,
+		SkipIndexManager:              opts.SkipIndexManager,
+		SchemaMigrator:                opts.SchemaMigrator,
+		BackupManager:                 opts.BackupManager,
+		QueryHistoryManager:           opts.QueryHistoryManager,
+		AuditManager:                  opts.AuditManager,
+		WebhookManager:                opts.WebhookManager,
+		IssueManager:                  opts.IssueManager,
+		ErrorTrackingManager:          opts.ErrorTrackingManager,
+		LogMetricsManager:             opts.LogMetricsManager,
+		DeploymentManager:             opts.DeploymentManager,
+		ProfileManager:                opts.ProfileManager,
+		EventManager:                  opts.EventManager,
+		AttributeMetadataManager:      opts.AttributeMetadataManager,
+		AlertHistoryManager:           opts.AlertHistoryManager,
 		LogsParsingPipelineController: opts.LogsParsingPipelineController,
+		SpanMetricsController:         opts.SpanMetricsController,
+		SamplingController:            opts.SamplingController,
+		CardinalityController:         opts.CardinalityController,
+		SyntheticsController:          opts.SyntheticsController,
+		HeartbeatRepo:                 opts.HeartbeatRepo,
 		Cache:                         opts.Cache,
 		FluxInterval:                  opts.FluxInterval,
 	})
@@ -135,6 +203,18 @@ func (ah *APIHandler) RegisterRoutes(router *mux.Router, am *baseapp.AuthMiddlew
 		am.OpenAccess(ah.receiveGoogleAuth)).
 		Methods(http.MethodGet)
 
+	router.HandleFunc("/api/v1/complete/oidc",
+		am.OpenAccess(ah.receiveOIDC)).
+		Methods(http.MethodGet)
+
+	router.HandleFunc("/api/v1/complete/azure",
+		am.OpenAccess(ah.receiveAzureAD)).
+		Methods(http.MethodGet)
+
+	router.HandleFunc("/api/v1/complete/github",
+		am.OpenAccess(ah.receiveGitHub)).
+		Methods(http.MethodGet)
+
 	router.HandleFunc("/api/v1/orgs/{orgId}/domains",
 		am.AdminAccess(ah.listDomainsByOrg)).
 		Methods(http.MethodGet)
@@ -163,13 +243,15 @@ func (ah *APIHandler) RegisterRoutes(router *mux.Router, am *baseapp.AuthMiddlew
 	router.HandleFunc("/api/v1/pats", am.AdminAccess(ah.getPATs)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/pats/{id}", am.AdminAccess(ah.updatePAT)).Methods(http.MethodPut)
 	router.HandleFunc("/api/v1/pats/{id}", am.AdminAccess(ah.revokePAT)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/pats/{id}/rotate", am.AdminAccess(ah.rotatePAT)).Methods(http.MethodPost)
 
 	router.HandleFunc("/api/v1/checkout", am.AdminAccess(ah.checkout)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/billing", am.AdminAccess(ah.getBilling)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/portal", am.AdminAccess(ah.portalSession)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/usage/breakdown", am.AdminAccess(ah.getUsageBreakdown)).Methods(http.MethodGet)
 
-	router.HandleFunc("/api/v1/dashboards/{uuid}/lock", am.EditAccess(ah.lockDashboard)).Methods(http.MethodPut)
-	router.HandleFunc("/api/v1/dashboards/{uuid}/unlock", am.EditAccess(ah.unlockDashboard)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/dashboards/{uuid}/lock", am.Access(basemodel.ResourceDashboards, basemodel.ActionUpdate, ah.lockDashboard)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/dashboards/{uuid}/unlock", am.Access(basemodel.ResourceDashboards, basemodel.ActionUpdate, ah.unlockDashboard)).Methods(http.MethodPut)
 
 	router.HandleFunc("/api/v2/licenses",
 		am.ViewAccess(ah.listLicensesV2)).
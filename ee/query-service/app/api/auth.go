@@ -8,12 +8,15 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 
 	"go.signoz.io/signoz/ee/query-service/constants"
 	"go.signoz.io/signoz/ee/query-service/model"
+	"go.signoz.io/signoz/ee/query-service/sso"
 	baseauth "go.signoz.io/signoz/pkg/query-service/auth"
 	basemodel "go.signoz.io/signoz/pkg/query-service/model"
 )
@@ -38,6 +41,8 @@ func (ah *APIHandler) loginUser(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, model.BadRequest(err), nil)
 		return
 	}
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = baseauth.ClientIP(r)
 
 	ctx := context.Background()
 
@@ -55,9 +60,82 @@ func (ah *APIHandler) loginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the sessions of the users logged in through OIDC are refreshed with the
+	// provider, so the users removed there lose access and their role is synced
+	if req.RefreshToken != "" && ah.CheckFeature(model.SSO) {
+		resp, err = ah.refreshOIDCSession(ctx, resp)
+		if ah.HandleError(w, err, http.StatusUnauthorized) {
+			return
+		}
+	}
+
 	ah.WriteJSON(w, r, resp)
 }
 
+// refreshOIDCSession refreshes the session of the user with its OIDC provider and
+// generates new tokens once the role of the user is synced
+func (ah *APIHandler) refreshOIDCSession(ctx context.Context, resp *basemodel.LoginResponse) (*basemodel.LoginResponse, error) {
+	stored, apierr := ah.AppDao().GetSsoRefreshToken(ctx, resp.UserId)
+	if apierr != nil {
+		return nil, apierr.ToError()
+	}
+	if stored == nil {
+		return resp, nil
+	}
+
+	domainId, err := uuid.Parse(stored.DomainId)
+	if err != nil {
+		return nil, err
+	}
+	domain, apierr := ah.AppDao().GetDomain(ctx, domainId)
+	if apierr != nil || domain == nil || domain.SsoType != model.OIDC || !domain.SsoEnabled {
+		// the domain no longer logs in through OIDC
+		zap.L().Info("dropping the OIDC session of a domain without OIDC", zap.String("userId", resp.UserId))
+		if apierr := ah.AppDao().DeleteSsoRefreshToken(ctx, resp.UserId); apierr != nil {
+			return nil, apierr.ToError()
+		}
+		return resp, nil
+	}
+
+	siteUrl, err := url.Parse(constants.GetDefaultSiteURL())
+	if err != nil {
+		return nil, err
+	}
+	provider, err := domain.PrepareOIDCProvider(siteUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer provider.Cancel()
+
+	identity, err := provider.Refresh(ctx, stored.RefreshToken)
+	if err != nil {
+		zap.L().Info("failed to refresh the OIDC session of the user", zap.String("userId", resp.UserId), zap.Error(err))
+		if apierr := ah.AppDao().DeleteSsoRefreshToken(ctx, resp.UserId); apierr != nil {
+			return nil, apierr.ToError()
+		}
+		return nil, fmt.Errorf("the session has expired, please log in again")
+	}
+
+	stored.RefreshToken = string(identity.ConnectorData)
+	if apierr := ah.AppDao().SetSsoRefreshToken(ctx, *stored); apierr != nil {
+		return nil, apierr.ToError()
+	}
+	if identity.Email == "" {
+		// the provider did not send a new ID token, the role is kept
+		return resp, nil
+	}
+
+	user, apierr := ah.AppDao().SyncSsoUser(ctx, identity.Email, domain.RoleFor(identity.Groups))
+	if apierr != nil {
+		return nil, apierr.ToError()
+	}
+	userJwt, err := baseauth.GenerateJWTForSession(user, resp.SessionId)
+	if err != nil {
+		return nil, err
+	}
+	return &basemodel.LoginResponse{UserJwtObject: userJwt, UserId: user.Id, SessionId: resp.SessionId}, nil
+}
+
 // registerUser registers a user and responds with a precheck
 // so the front-end can decide the login method
 func (ah *APIHandler) registerUser(w http.ResponseWriter, r *http.Request) {
@@ -197,6 +275,11 @@ func handleSsoError(w http.ResponseWriter, r *http.Request, redirectURL string)
 	http.Redirect(w, r, fmt.Sprintf("%s?ssoerror=%s", redirectURL, string(dst)), http.StatusSeeOther)
 }
 
+// ssoClient returns the client of the SSO login, the session of the user is started with it
+func ssoClient(r *http.Request) *basemodel.LoginRequest {
+	return &basemodel.LoginRequest{UserAgent: r.UserAgent(), IPAddress: baseauth.ClientIP(r)}
+}
+
 // receiveGoogleAuth completes google OAuth response and forwards a request
 // to front-end to sign user in
 func (ah *APIHandler) receiveGoogleAuth(w http.ResponseWriter, r *http.Request) {
@@ -253,7 +336,7 @@ func (ah *APIHandler) receiveGoogleAuth(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	nextPage, err := ah.AppDao().PrepareSsoRedirect(ctx, redirectUri, identity.Email)
+	nextPage, err := ah.AppDao().PrepareSsoRedirect(ctx, redirectUri, identity.Email, ssoClient(r))
 	if err != nil {
 		zap.L().Error("[receiveGoogleAuth] failed to generate redirect URI after successful login ", zap.String("domain", domain.String()), zap.Error(err))
 		handleSsoError(w, r, redirectUri)
@@ -263,6 +346,203 @@ func (ah *APIHandler) receiveGoogleAuth(w http.ResponseWriter, r *http.Request)
 	http.Redirect(w, r, nextPage, http.StatusSeeOther)
 }
 
+// receiveOIDC completes the OIDC response, syncs the role of the user from its groups
+// and forwards a request to front-end to sign user in
+func (ah *APIHandler) receiveOIDC(w http.ResponseWriter, r *http.Request) {
+	redirectUri := constants.GetDefaultSiteURL()
+	ctx := context.Background()
+
+	if !ah.CheckFeature(model.SSO) {
+		zap.L().Error("[receiveOIDC] sso requested but feature unavailable in org domain")
+		http.Redirect(w, r, fmt.Sprintf("%s?ssoerror=%s", redirectUri, "feature unavailable, please upgrade your billing plan to access this feature"), http.StatusMovedPermanently)
+		return
+	}
+
+	q := r.URL.Query()
+	if errType := q.Get("error"); errType != "" {
+		zap.L().Error("[receiveOIDC] failed to login with oidc", zap.String("error", errType), zap.String("error_description", q.Get("error_description")))
+		http.Redirect(w, r, fmt.Sprintf("%s?ssoerror=%s", redirectUri, "failed to login through SSO "), http.StatusMovedPermanently)
+		return
+	}
+
+	relayState := q.Get("state")
+	zap.L().Debug("[receiveOIDC] relay state received", zap.String("state", relayState))
+
+	parsedState, err := url.Parse(relayState)
+	if err != nil || relayState == "" {
+		zap.L().Error("[receiveOIDC] failed to process response - invalid response from IDP", zap.Error(err), zap.Any("request", r))
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+
+	// upgrade redirect url from the relay state for better accuracy
+	redirectUri = fmt.Sprintf("%s://%s%s", parsedState.Scheme, parsedState.Host, "/login")
+
+	// fetch domain by parsing relay state.
+	domain, err := ah.AppDao().GetDomainFromSsoResponse(ctx, parsedState)
+	if err != nil {
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+
+	callbackHandler, err := domain.PrepareOIDCProvider(parsedState)
+	if err != nil {
+		zap.L().Error("[receiveOIDC] failed to prepare oidc provider", zap.String("domain", domain.String()), zap.Error(err))
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+	defer callbackHandler.Cancel()
+
+	identity, err := callbackHandler.HandleCallback(r)
+	if err != nil {
+		zap.L().Error("[receiveOIDC] failed to process HandleCallback ", zap.String("domain", domain.String()), zap.Error(err))
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+
+	// the provider may serve other domains, only the users of the domain log in through it
+	if !strings.HasSuffix(strings.ToLower(identity.Email), "@"+strings.ToLower(domain.Name)) {
+		zap.L().Error("[receiveOIDC] email received from the provider is not in the domain", zap.String("domain", domain.String()))
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+
+	user, apierr := ah.AppDao().SyncSsoUser(ctx, identity.Email, domain.RoleFor(identity.Groups))
+	if apierr != nil {
+		zap.L().Error("[receiveOIDC] failed to sync the user after successful login", zap.String("domain", domain.String()), zap.Error(apierr.ToError()))
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+
+	if refreshToken := string(identity.ConnectorData); refreshToken != "" {
+		apierr := ah.AppDao().SetSsoRefreshToken(ctx, model.SsoRefreshToken{
+			UserId:       user.Id,
+			DomainId:     domain.Id.String(),
+			RefreshToken: refreshToken,
+		})
+		if apierr != nil {
+			handleSsoError(w, r, redirectUri)
+			return
+		}
+	}
+
+	nextPage, err := ah.AppDao().PrepareSsoRedirect(ctx, redirectUri, identity.Email, ssoClient(r))
+	if err != nil {
+		zap.L().Error("[receiveOIDC] failed to generate redirect URI after successful login ", zap.String("domain", domain.String()), zap.Error(err))
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+
+	http.Redirect(w, r, nextPage, http.StatusSeeOther)
+}
+
+// receiveAzureAD completes the Azure AD response, syncs the role of the user from its
+// groups and forwards a request to front-end to sign user in
+func (ah *APIHandler) receiveAzureAD(w http.ResponseWriter, r *http.Request) {
+	ah.receiveOAuthWithRoles(w, r, "receiveAzureAD", func(domain *model.OrgDomain, siteUrl *url.URL) (sso.OAuthCallbackProvider, func(), error) {
+		provider, err := domain.PrepareAzureADProvider(siteUrl)
+		if err != nil {
+			return nil, nil, err
+		}
+		return provider, provider.Cancel, nil
+	})
+}
+
+// receiveGitHub completes the GitHub response, syncs the role of the user from its
+// organizations and teams and forwards a request to front-end to sign user in
+func (ah *APIHandler) receiveGitHub(w http.ResponseWriter, r *http.Request) {
+	ah.receiveOAuthWithRoles(w, r, "receiveGitHub", func(domain *model.OrgDomain, siteUrl *url.URL) (sso.OAuthCallbackProvider, func(), error) {
+		provider, err := domain.PrepareGitHubProvider(siteUrl)
+		if err != nil {
+			return nil, nil, err
+		}
+		return provider, func() {}, nil
+	})
+}
+
+// receiveOAuthWithRoles completes the response of an OAuth provider mapping the groups of
+// the users to their role, prepare returns the provider of the domain and its cleanup
+func (ah *APIHandler) receiveOAuthWithRoles(
+	w http.ResponseWriter,
+	r *http.Request,
+	name string,
+	prepare func(domain *model.OrgDomain, siteUrl *url.URL) (sso.OAuthCallbackProvider, func(), error),
+) {
+	redirectUri := constants.GetDefaultSiteURL()
+	ctx := context.Background()
+	tag := fmt.Sprintf("[%s]", name)
+
+	if !ah.CheckFeature(model.SSO) {
+		zap.L().Error(tag + " sso requested but feature unavailable in org domain")
+		http.Redirect(w, r, fmt.Sprintf("%s?ssoerror=%s", redirectUri, "feature unavailable, please upgrade your billing plan to access this feature"), http.StatusMovedPermanently)
+		return
+	}
+
+	q := r.URL.Query()
+	if errType := q.Get("error"); errType != "" {
+		zap.L().Error(tag+" failed to login with the provider", zap.String("error", errType), zap.String("error_description", q.Get("error_description")))
+		http.Redirect(w, r, fmt.Sprintf("%s?ssoerror=%s", redirectUri, "failed to login through SSO "), http.StatusMovedPermanently)
+		return
+	}
+
+	relayState := q.Get("state")
+	zap.L().Debug(tag+" relay state received", zap.String("state", relayState))
+
+	parsedState, err := url.Parse(relayState)
+	if err != nil || relayState == "" {
+		zap.L().Error(tag+" failed to process response - invalid response from IDP", zap.Error(err), zap.Any("request", r))
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+
+	// upgrade redirect url from the relay state for better accuracy
+	redirectUri = fmt.Sprintf("%s://%s%s", parsedState.Scheme, parsedState.Host, "/login")
+
+	// fetch domain by parsing relay state.
+	domain, err := ah.AppDao().GetDomainFromSsoResponse(ctx, parsedState)
+	if err != nil {
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+
+	callbackHandler, cancel, err := prepare(domain, parsedState)
+	if err != nil {
+		zap.L().Error(tag+" failed to prepare the provider", zap.String("domain", domain.String()), zap.Error(err))
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+	defer cancel()
+
+	identity, err := callbackHandler.HandleCallback(r)
+	if err != nil {
+		zap.L().Error(tag+" failed to process HandleCallback ", zap.String("domain", domain.String()), zap.Error(err))
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+
+	// the provider may serve other domains, only the users of the domain log in through it
+	if !strings.HasSuffix(strings.ToLower(identity.Email), "@"+strings.ToLower(domain.Name)) {
+		zap.L().Error(tag+" email received from the provider is not in the domain", zap.String("domain", domain.String()))
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+
+	if _, apierr := ah.AppDao().SyncSsoUser(ctx, identity.Email, domain.RoleFor(identity.Groups)); apierr != nil {
+		zap.L().Error(tag+" failed to sync the user after successful login", zap.String("domain", domain.String()), zap.Error(apierr.ToError()))
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+
+	nextPage, err := ah.AppDao().PrepareSsoRedirect(ctx, redirectUri, identity.Email, ssoClient(r))
+	if err != nil {
+		zap.L().Error(tag+" failed to generate redirect URI after successful login ", zap.String("domain", domain.String()), zap.Error(err))
+		handleSsoError(w, r, redirectUri)
+		return
+	}
+
+	http.Redirect(w, r, nextPage, http.StatusSeeOther)
+}
+
 // receiveSAML completes a SAML request and gets user logged in
 func (ah *APIHandler) receiveSAML(w http.ResponseWriter, r *http.Request) {
 	// this is the source url that initiated the login request
@@ -331,7 +611,7 @@ func (ah *APIHandler) receiveSAML(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	nextPage, err := ah.AppDao().PrepareSsoRedirect(ctx, redirectUri, email)
+	nextPage, err := ah.AppDao().PrepareSsoRedirect(ctx, redirectUri, email, ssoClient(r))
 	if err != nil {
 		zap.L().Error("[receiveSAML] failed to generate redirect URI after successful login ", zap.String("domain", domain.String()), zap.Error(err))
 		handleSsoError(w, r, redirectUri)
@@ -9,10 +9,29 @@ import (
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"go.signoz.io/signoz/ee/query-service/model"
+	baseapp "go.signoz.io/signoz/pkg/query-service/app"
+	baseauth "go.signoz.io/signoz/pkg/query-service/auth"
+	basemodel "go.signoz.io/signoz/pkg/query-service/model"
 )
 
+// checkDomainOrg checks the domain belongs to the org the admin works in
+func (ah *APIHandler) checkDomainOrg(r *http.Request, id uuid.UUID) basemodel.BaseApiError {
+	domain, apierr := ah.AppDao().GetDomain(r.Context(), id)
+	if apierr != nil {
+		return apierr
+	}
+	if apiErr := baseapp.CheckActiveOrg(r, domain.OrgId); apiErr != nil {
+		return apiErr
+	}
+	return nil
+}
+
 func (ah *APIHandler) listDomainsByOrg(w http.ResponseWriter, r *http.Request) {
 	orgId := mux.Vars(r)["orgId"]
+	if apiErr := baseapp.CheckActiveOrg(r, orgId); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 	domains, apierr := ah.AppDao().ListDomains(context.Background(), orgId)
 	if apierr != nil {
 		RespondError(w, apierr, domains)
@@ -35,6 +54,14 @@ func (ah *APIHandler) postDomain(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, model.BadRequest(err), nil)
 		return
 	}
+	if apiErr := baseapp.CheckActiveOrg(r, req.OrgId); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if err := validateDomainRoles(ctx, &req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
 
 	if apierr := ah.AppDao().CreateDomain(ctx, &req); apierr != nil {
 		RespondError(w, apierr, nil)
@@ -54,6 +81,11 @@ func (ah *APIHandler) putDomain(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if apierr := ah.checkDomainOrg(r, domainId); apierr != nil {
+		RespondError(w, apierr, nil)
+		return
+	}
+
 	req := model.OrgDomain{Id: domainId}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		RespondError(w, model.BadRequest(err), nil)
@@ -62,6 +94,19 @@ func (ah *APIHandler) putDomain(w http.ResponseWriter, r *http.Request) {
 	req.Id = domainId
 	if err := req.Valid(nil); err != nil {
 		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if apiErr := baseapp.CheckActiveOrg(r, req.OrgId); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if err := req.ValidConfig(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if err := validateDomainRoles(ctx, &req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
 	}
 
 	if apierr := ah.AppDao().UpdateDomain(ctx, &req); apierr != nil {
@@ -80,6 +125,10 @@ func (ah *APIHandler) deleteDomain(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, model.BadRequest(fmt.Errorf("invalid domain id")), nil)
 		return
 	}
+	if apierr := ah.checkDomainOrg(r, domainId); apierr != nil {
+		RespondError(w, apierr, nil)
+		return
+	}
 
 	apierr := ah.AppDao().DeleteDomain(context.Background(), domainId)
 	if apierr != nil {
@@ -88,3 +137,13 @@ func (ah *APIHandler) deleteDomain(w http.ResponseWriter, r *http.Request) {
 	}
 	ah.Respond(w, nil)
 }
+
+// validateDomainRoles checks the roles the SSO groups are mapped to exist
+func validateDomainRoles(ctx context.Context, domain *model.OrgDomain) error {
+	for _, role := range domain.MappedRoles() {
+		if !baseauth.IsValidRole(ctx, role) {
+			return fmt.Errorf("invalid role %s in the SSO role mapping", role)
+		}
+	}
+	return nil
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/gorilla/mux"
 	"go.signoz.io/signoz/ee/query-service/model"
 	"go.signoz.io/signoz/pkg/query-service/auth"
-	baseconstants "go.signoz.io/signoz/pkg/query-service/constants"
 	basemodel "go.signoz.io/signoz/pkg/query-service/model"
 	"go.uber.org/zap"
 )
@@ -45,9 +44,10 @@ func (ah *APIHandler) createPAT(w http.ResponseWriter, r *http.Request) {
 	pat := model.PAT{
 		Name:      req.Name,
 		Role:      req.Role,
+		Scopes:    req.Scopes,
 		ExpiresAt: req.ExpiresInDays,
 	}
-	err = validatePATRequest(pat)
+	err = validatePATRequest(ctx, pat)
 	if err != nil {
 		RespondError(w, model.BadRequest(err), nil)
 		return
@@ -75,8 +75,8 @@ func (ah *APIHandler) createPAT(w http.ResponseWriter, r *http.Request) {
 	ah.Respond(w, &pat)
 }
 
-func validatePATRequest(req model.PAT) error {
-	if req.Role == "" || (req.Role != baseconstants.ViewerGroup && req.Role != baseconstants.EditorGroup && req.Role != baseconstants.AdminGroup) {
+func validatePATRequest(ctx context.Context, req model.PAT) error {
+	if req.Role == "" || !auth.IsValidRole(ctx, req.Role) {
 		return fmt.Errorf("valid role is required")
 	}
 	if req.ExpiresAt < 0 {
@@ -85,7 +85,7 @@ func validatePATRequest(req model.PAT) error {
 	if req.Name == "" {
 		return fmt.Errorf("valid name is required")
 	}
-	return nil
+	return req.Scopes.Validate()
 }
 
 func (ah *APIHandler) updatePAT(w http.ResponseWriter, r *http.Request) {
@@ -106,7 +106,7 @@ func (ah *APIHandler) updatePAT(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = validatePATRequest(req)
+	err = validatePATRequest(r.Context(), req)
 	if err != nil {
 		RespondError(w, model.BadRequest(err), nil)
 		return
@@ -114,6 +114,15 @@ func (ah *APIHandler) updatePAT(w http.ResponseWriter, r *http.Request) {
 
 	req.UpdatedByUserID = user.Id
 	id := mux.Vars(r)["id"]
+	// the scopes are kept when omitted so the updates of older clients don't widen the access of the PAT
+	if req.Scopes == nil {
+		existing, apierr := ah.AppDao().GetPATByID(ctx, id)
+		if apierr != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no active PAT found with id %s", id)}, nil)
+			return
+		}
+		req.Scopes = existing.Scopes
+	}
 	req.UpdatedAt = time.Now().Unix()
 	zap.L().Info("Got Update PAT request", zap.Any("pat", req))
 	var apierr basemodel.BaseApiError
@@ -125,6 +134,59 @@ func (ah *APIHandler) updatePAT(w http.ResponseWriter, r *http.Request) {
 	ah.Respond(w, map[string]string{"data": "pat updated successfully"})
 }
 
+// rotatePAT replaces the token of the PAT, the previous token stops working right away
+func (ah *APIHandler) rotatePAT(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+
+	req := model.RotatePATRequestBody{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			RespondError(w, model.BadRequest(err), nil)
+			return
+		}
+	}
+	if req.ExpiresInDays != nil && *req.ExpiresInDays < 0 {
+		RespondError(w, model.BadRequest(fmt.Errorf("valid expiresInDays is required")), nil)
+		return
+	}
+
+	user, err := auth.GetUserFromRequest(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{
+			Typ: model.ErrorUnauthorized,
+			Err: err,
+		}, nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	pat, apierr := ah.AppDao().GetPATByID(ctx, id)
+	if apierr != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no active PAT found with id %s", id)}, nil)
+		return
+	}
+
+	now := time.Now().Unix()
+	if req.ExpiresInDays != nil {
+		pat.ExpiresAt = 0
+		if *req.ExpiresInDays != 0 {
+			pat.ExpiresAt = now + (*req.ExpiresInDays * 24 * 60 * 60)
+		}
+	}
+	pat.Token = generatePATToken()
+	pat.UpdatedAt = now
+	pat.UpdatedByUserID = user.Id
+	pat.LastUsed = 0
+
+	zap.L().Info("Got Rotate PAT request", zap.String("id", id))
+	if apierr := ah.AppDao().RotatePAT(ctx, *pat, id); apierr != nil {
+		RespondError(w, apierr, nil)
+		return
+	}
+
+	ah.Respond(w, pat)
+}
+
 func (ah *APIHandler) getPATs(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 	user, err := auth.GetUserFromRequest(r)
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.signoz.io/signoz/ee/query-service/model"
+	"go.signoz.io/signoz/ee/query-service/usage"
+)
+
+// parseUsageBreakdownParams reads the range in epoch milliseconds (the last 7 days by default)
+// and the step in seconds (a day by default)
+func parseUsageBreakdownParams(r *http.Request) (*model.UsageBreakdownParams, error) {
+	q := r.URL.Query()
+	end := time.Now()
+	start := end.AddDate(0, 0, -7)
+	step := 24 * time.Hour
+
+	parseInt := func(name string) (int64, bool, error) {
+		v := q.Get(name)
+		if v == "" {
+			return 0, false, nil
+		}
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid %s %q: %v", name, v, err)
+		}
+		return i, true, nil
+	}
+	if v, ok, err := parseInt("start"); err != nil {
+		return nil, err
+	} else if ok {
+		start = time.UnixMilli(v)
+	}
+	if v, ok, err := parseInt("end"); err != nil {
+		return nil, err
+	} else if ok {
+		end = time.UnixMilli(v)
+	}
+	if v, ok, err := parseInt("step"); err != nil {
+		return nil, err
+	} else if ok {
+		step = time.Duration(v) * time.Second
+	}
+
+	teamLabel := q.Get("teamLabel")
+	if teamLabel == "" {
+		teamLabel = usage.DefaultTeamLabel
+	}
+	return &model.UsageBreakdownParams{
+		Start:     start,
+		End:       end,
+		Step:      step,
+		Signal:    q.Get("signal"),
+		TeamLabel: teamLabel,
+	}, nil
+}
+
+// getUsageBreakdown returns the ingested volume by signal, service and team for internal chargeback
+func (ah *APIHandler) getUsageBreakdown(w http.ResponseWriter, r *http.Request) {
+	params, err := parseUsageBreakdownParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	breakdown, apiErr := ah.UM().Breakdown(r.Context(), params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, breakdown)
+}
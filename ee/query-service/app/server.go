@@ -35,17 +35,45 @@ import (
 
 	"go.signoz.io/signoz/pkg/query-service/agentConf"
 	baseapp "go.signoz.io/signoz/pkg/query-service/app"
+	"go.signoz.io/signoz/pkg/query-service/app/access"
+	"go.signoz.io/signoz/pkg/query-service/app/alerthistory"
+	"go.signoz.io/signoz/pkg/query-service/app/attributemetadata"
+	"go.signoz.io/signoz/pkg/query-service/app/audit"
+	"go.signoz.io/signoz/pkg/query-service/app/backup"
+	"go.signoz.io/signoz/pkg/query-service/app/cardinality"
+	basechr "go.signoz.io/signoz/pkg/query-service/app/clickhouseReader"
 	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/app/deployments"
+	"go.signoz.io/signoz/pkg/query-service/app/errortracking"
+	"go.signoz.io/signoz/pkg/query-service/app/events"
 	baseexplorer "go.signoz.io/signoz/pkg/query-service/app/explorer"
+	"go.signoz.io/signoz/pkg/query-service/app/heartbeats"
 	"go.signoz.io/signoz/pkg/query-service/app/integrations"
+	"go.signoz.io/signoz/pkg/query-service/app/issues"
+	"go.signoz.io/signoz/pkg/query-service/app/logexports"
+	"go.signoz.io/signoz/pkg/query-service/app/logmetrics"
 	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
+	"go.signoz.io/signoz/pkg/query-service/app/logviews"
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/remotewrite"
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/rollup"
 	"go.signoz.io/signoz/pkg/query-service/app/opamp"
 	opAmpModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/app/profiles"
+	"go.signoz.io/signoz/pkg/query-service/app/queryhistory"
+	"go.signoz.io/signoz/pkg/query-service/app/reports"
+	"go.signoz.io/signoz/pkg/query-service/app/sampling"
+	"go.signoz.io/signoz/pkg/query-service/app/share"
+	"go.signoz.io/signoz/pkg/query-service/app/skipindexes"
+	"go.signoz.io/signoz/pkg/query-service/app/spanmetrics"
+	"go.signoz.io/signoz/pkg/query-service/app/synthetics"
+	"go.signoz.io/signoz/pkg/query-service/app/webhooks"
 	"go.signoz.io/signoz/pkg/query-service/cache"
+	"go.signoz.io/signoz/pkg/query-service/common"
 	baseconst "go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/healthcheck"
 	basealm "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
 	baseint "go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/migrate/schema"
 	basemodel "go.signoz.io/signoz/pkg/query-service/model"
 	pqle "go.signoz.io/signoz/pkg/query-service/pqlEngine"
 	rules "go.signoz.io/signoz/pkg/query-service/rules"
@@ -65,19 +93,39 @@ type ServerOptions struct {
 	DisableRules      bool
 	RuleRepoURL       string
 	PreferSpanMetrics bool
+	OTLPReceiver      bool
 	MaxIdleConns      int
 	MaxOpenConns      int
 	DialTimeout       time.Duration
 	CacheConfigPath   string
-	FluxInterval      string
-	Cluster           string
-	GatewayUrl        string
+	// ClickHouseSettingsPath is the config of the clickhouse connection pool and of the
+	// settings profiles of the interactive, alerts and export queries
+	ClickHouseSettingsPath string
+	FluxInterval           string
+	Cluster                string
+	GatewayUrl             string
 }
 
 // Server runs HTTP api service
 type Server struct {
-	serverOptions *ServerOptions
-	ruleManager   *rules.Manager
+	serverOptions  *ServerOptions
+	ruleManager    *rules.Manager
+	reportManager  *reports.Manager
+	exportManager  *logexports.Manager
+	viewManager    *logviews.Manager
+	historyManager *queryhistory.Manager
+	auditManager   *audit.Manager
+	webhookManager *webhooks.Manager
+	issueManager   *issues.Manager
+	errorTracking  *errortracking.Manager
+	logMetrics     *logmetrics.Manager
+	attrMetadata   *attributemetadata.Manager
+	deployments    *deployments.Manager
+	rollupManager  *rollup.Manager
+	alertHistory   *alerthistory.Manager
+	schemaMigrator *schema.Migrator
+
+	cardinalityController *cardinality.CardinalityController
 
 	// public http router
 	httpConn   net.Listener
@@ -116,6 +164,14 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		return nil, err
 	}
 
+	if err := share.InitWithDB(localDB); err != nil {
+		return nil, err
+	}
+
+	if err := access.InitWithDB(localDB); err != nil {
+		return nil, err
+	}
+
 	localDB.SetMaxOpenConns(10)
 
 	gatewayFeature := basemodel.Feature{
@@ -157,6 +213,13 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 	storage := os.Getenv("STORAGE")
 	if storage == "clickhouse" {
 		zap.L().Info("Using ClickHouse as datastore ...")
+		var querySettings *basechr.QuerySettingsConfig
+		if serverOptions.ClickHouseSettingsPath != "" {
+			querySettings, err = basechr.LoadQuerySettingsConfig(serverOptions.ClickHouseSettingsPath)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't load the clickhouse settings config: %w", err)
+			}
+		}
 		qb := db.NewDataConnector(
 			localDB,
 			serverOptions.PromConfigPath,
@@ -165,6 +228,7 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 			serverOptions.MaxOpenConns,
 			serverOptions.DialTimeout,
 			serverOptions.Cluster,
+			querySettings,
 		)
 		go qb.Start(readerReady)
 		reader = qb
@@ -206,6 +270,142 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		)
 	}
 
+	reportManager, err := reports.NewManager(reports.ManagerOptions{
+		DB:          localDB,
+		FrontendURL: serverOptions.RuleRepoURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create report manager: %w", err)
+	}
+
+	exportManager, err := logexports.NewManager(logexports.ManagerOptions{
+		DB:   localDB,
+		Conn: reader.GetConn(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create log export manager: %w", err)
+	}
+
+	viewManager, err := logviews.NewManager(logviews.ManagerOptions{
+		DB:      localDB,
+		Conn:    reader.GetConn(),
+		Cluster: serverOptions.Cluster,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create logs view manager: %w", err)
+	}
+
+	historyManager, err := queryhistory.NewManager(queryhistory.ManagerOptions{
+		DB:                 localDB,
+		SlowQueryThreshold: baseconst.SlowQueryThreshold,
+		Retention:          time.Duration(baseconst.QueryHistoryRetentionDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create query history manager: %w", err)
+	}
+
+	skipIndexManager, err := skipindexes.NewManager(skipindexes.ManagerOptions{
+		DB:           localDB,
+		Conn:         reader.GetConn(),
+		Cluster:      serverOptions.Cluster,
+		QueryHistory: historyManager,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create skip index manager: %w", err)
+	}
+
+	auditManager, err := audit.NewManager(audit.ManagerOptions{
+		DB:        localDB,
+		Retention: time.Duration(baseconst.AuditLogRetentionDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create audit manager: %w", err)
+	}
+
+	webhookManager, err := webhooks.NewManager(webhooks.ManagerOptions{
+		DB:        localDB,
+		Retention: time.Duration(baseconst.WebhookDeliveryRetentionDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create webhook manager: %w", err)
+	}
+	rm.AddNotifyListener(webhookManager.NotifyAlerts)
+
+	issueManager, err := issues.NewManager(issues.ManagerOptions{
+		DB:          localDB,
+		FrontendURL: serverOptions.RuleRepoURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create issue manager: %w", err)
+	}
+	rm.AddNotifyListener(issueManager.NotifyAlerts)
+
+	errorTracking, err := errortracking.NewManager(errortracking.ManagerOptions{
+		DB:     localDB,
+		Reader: reader,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create error tracking manager: %w", err)
+	}
+
+	logMetrics, err := logmetrics.NewManager(logmetrics.ManagerOptions{
+		DB:     localDB,
+		Reader: reader,
+		Writer: remotewrite.NewWriter(reader.GetConn()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create log metrics manager: %w", err)
+	}
+
+	deploymentManager, err := deployments.NewManager(deployments.ManagerOptions{
+		DB:     localDB,
+		Reader: reader,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create deployment manager: %w", err)
+	}
+	rm.AddAnnotator(deploymentManager.AnnotateAlerts)
+
+	eventManager := events.NewManager(events.ManagerOptions{
+		Conn: reader.GetConn(),
+	})
+	rm.AddAnnotator(eventManager.AnnotateAlerts)
+
+	attributeMetadata, err := attributemetadata.NewManager(attributemetadata.ManagerOptions{
+		DB:   localDB,
+		Conn: reader.GetConn(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create attribute metadata manager: %w", err)
+	}
+
+	rollupManager := rollup.NewManager(rollup.ManagerOptions{
+		Conn:    reader.GetConn(),
+		Cluster: serverOptions.Cluster,
+	})
+
+	profileManager := profiles.NewManager(profiles.ManagerOptions{
+		Conn: reader.GetConn(),
+	})
+
+	alertHistoryManager := alerthistory.NewManager(alerthistory.ManagerOptions{
+		Conn: reader.GetConn(),
+	})
+	rm.AddStateListener(alertHistoryManager.Record)
+
+	basealm.SetOnCallLookup(access.TeamOnCallEmails)
+
+	backupManager := backup.NewManager(backup.ManagerOptions{DB: localDB})
+
+	schemaMigrator, err := schema.NewMigrator(schema.Options{
+		DB:      localDB,
+		Conn:    reader.GetConn(),
+		Cluster: serverOptions.Cluster,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create schema migrator: %w", err)
+	}
+
 	// ingestion pipelines manager
 	logParsingPipelineController, err := logparsingpipeline.NewLogParsingPipelinesController(
 		localDB, "sqlite", integrationsController.GetPipelinesForInstalledIntegrations,
@@ -214,11 +414,42 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		return nil, err
 	}
 
+	// span metrics config manager
+	spanMetricsController, err := spanmetrics.NewSpanMetricsController(localDB, "sqlite")
+	if err != nil {
+		return nil, err
+	}
+
+	// tail sampling rules manager
+	samplingController, err := sampling.NewSamplingController(localDB, "sqlite")
+	if err != nil {
+		return nil, err
+	}
+
+	cardinalityController, err := cardinality.NewCardinalityController(localDB, "sqlite", reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// synthetic checks run by the agents
+	syntheticsController, err := synthetics.NewSyntheticsController(localDB, "sqlite")
+	if err != nil {
+		return nil, err
+	}
+
+	// heartbeat monitors alerting on missing signals
+	heartbeatRepo, err := heartbeats.NewRepo(localDB)
+	if err != nil {
+		return nil, err
+	}
+
 	// initiate agent config handler
 	agentConfMgr, err := agentConf.Initiate(&agentConf.ManagerOptions{
-		DB:            localDB,
-		DBEngine:      AppDbEngine,
-		AgentFeatures: []agentConf.AgentFeature{logParsingPipelineController},
+		DB:       localDB,
+		DBEngine: AppDbEngine,
+		AgentFeatures: []agentConf.AgentFeature{
+			logParsingPipelineController, spanMetricsController, samplingController, cardinalityController, syntheticsController,
+		},
 	})
 	if err != nil {
 		return nil, err
@@ -233,6 +464,9 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 	if err != nil {
 		return nil, err
 	}
+	if apiErr := usage.ProvisionDashboard(context.Background(), lm); apiErr != nil {
+		zap.L().Error("failed to provision the usage dashboard", zap.Error(apiErr.Err))
+	}
 
 	telemetry.GetInstance().SetReader(reader)
 	telemetry.GetInstance().SetSaasOperator(constants.SaasSegmentKey)
@@ -256,6 +490,7 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		DataConnector:                 reader,
 		SkipConfig:                    skipConfig,
 		PreferSpanMetrics:             serverOptions.PreferSpanMetrics,
+		OTLPReceiver:                  serverOptions.OTLPReceiver,
 		MaxIdleConns:                  serverOptions.MaxIdleConns,
 		MaxOpenConns:                  serverOptions.MaxOpenConns,
 		DialTimeout:                   serverOptions.DialTimeout,
@@ -265,7 +500,29 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		FeatureFlags:                  lm,
 		LicenseManager:                lm,
 		IntegrationsController:        integrationsController,
+		ReportManager:                 reportManager,
+		LogExportManager:              exportManager,
+		LogsViewManager:               viewManager,
+		SkipIndexManager:              skipIndexManager,
+		QueryHistoryManager:           historyManager,
+		AuditManager:                  auditManager,
+		WebhookManager:                webhookManager,
+		IssueManager:                  issueManager,
+		ErrorTrackingManager:          errorTracking,
+		LogMetricsManager:             logMetrics,
+		DeploymentManager:             deploymentManager,
+		ProfileManager:                profileManager,
+		EventManager:                  eventManager,
+		AttributeMetadataManager:      attributeMetadata,
+		AlertHistoryManager:           alertHistoryManager,
+		SchemaMigrator:                schemaMigrator,
+		BackupManager:                 backupManager,
 		LogsParsingPipelineController: logParsingPipelineController,
+		SpanMetricsController:         spanMetricsController,
+		SamplingController:            samplingController,
+		CardinalityController:         cardinalityController,
+		SyntheticsController:          syntheticsController,
+		HeartbeatRepo:                 heartbeatRepo,
 		Cache:                         c,
 		FluxInterval:                  fluxInterval,
 		Gateway:                       gatewayProxy,
@@ -279,10 +536,25 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 	s := &Server{
 		// logger: logger,
 		// tracer: tracer,
-		ruleManager:        rm,
-		serverOptions:      serverOptions,
-		unavailableChannel: make(chan healthcheck.Status),
-		usageManager:       usageManager,
+		ruleManager:           rm,
+		reportManager:         reportManager,
+		exportManager:         exportManager,
+		viewManager:           viewManager,
+		historyManager:        historyManager,
+		auditManager:          auditManager,
+		webhookManager:        webhookManager,
+		issueManager:          issueManager,
+		errorTracking:         errorTracking,
+		logMetrics:            logMetrics,
+		attrMetadata:          attributeMetadata,
+		deployments:           deploymentManager,
+		rollupManager:         rollupManager,
+		alertHistory:          alertHistoryManager,
+		schemaMigrator:        schemaMigrator,
+		cardinalityController: cardinalityController,
+		serverOptions:         serverOptions,
+		unavailableChannel:    make(chan healthcheck.Status),
+		usageManager:          usageManager,
 	}
 
 	httpServer, err := s.createPublicServer(apiHandler)
@@ -345,20 +617,24 @@ func (s *Server) createPublicServer(apiHandler *api.APIHandler) (*http.Server, e
 	am := baseapp.NewAuthMiddleware(getUserFromRequest)
 
 	r.Use(baseapp.LogCommentEnricher)
+	r.Use(baseapp.QueryIdMiddleware)
 	r.Use(setTimeoutMiddleware)
 	r.Use(s.analyticsMiddleware)
 	r.Use(loggingMiddleware)
+	r.Use(s.auditManager.Middleware(getUserFromRequest))
 
 	apiHandler.RegisterRoutes(r, am)
 	apiHandler.RegisterLogsRoutes(r, am)
 	apiHandler.RegisterIntegrationRoutes(r, am)
 	apiHandler.RegisterQueryRangeV3Routes(r, am)
 	apiHandler.RegisterQueryRangeV4Routes(r, am)
+	apiHandler.RegisterV5Routes(r, am)
 
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
 		AllowedMethods: []string{"GET", "DELETE", "POST", "PUT", "PATCH", "OPTIONS"},
-		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "cache-control"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "cache-control", common.QueryIdHeader},
+		ExposedHeaders: []string{common.QueryIdHeader},
 	})
 
 	handler := c.Handler(r)
@@ -608,6 +884,63 @@ func (s *Server) Start() error {
 		zap.L().Info("msg: Rules disabled as rules.disable is set to TRUE")
 	}
 
+	if err := s.reportManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.exportManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.viewManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.historyManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.auditManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.webhookManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.issueManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.errorTracking.Start(); err != nil {
+		return err
+	}
+
+	if err := s.logMetrics.Start(); err != nil {
+		return err
+	}
+
+	if err := s.attrMetadata.Start(); err != nil {
+		return err
+	}
+
+	if err := s.deployments.Start(); err != nil {
+		return err
+	}
+
+	s.cardinalityController.Start()
+
+	// the raw samples are read until the rollups are available
+	if err := s.rollupManager.Start(); err != nil {
+		zap.L().Error("failed to start metric rollups", zap.Error(err))
+	}
+
+	// the tables the query service owns in clickhouse, like the profiles tables
+	if _, err := s.schemaMigrator.Up(context.Background(), schema.DatabaseClickHouse, false); err != nil {
+		zap.L().Error("failed to migrate the clickhouse schema", zap.Error(err))
+	}
+	s.alertHistory.Start()
+
 	err := s.initListeners()
 	if err != nil {
 		return err
@@ -690,6 +1023,62 @@ func (s *Server) Stop() error {
 		s.ruleManager.Stop()
 	}
 
+	if s.reportManager != nil {
+		s.reportManager.Stop()
+	}
+
+	if s.exportManager != nil {
+		s.exportManager.Stop()
+	}
+
+	if s.viewManager != nil {
+		s.viewManager.Stop()
+	}
+
+	if s.historyManager != nil {
+		s.historyManager.Stop()
+	}
+
+	if s.auditManager != nil {
+		s.auditManager.Stop()
+	}
+
+	if s.webhookManager != nil {
+		s.webhookManager.Stop()
+	}
+
+	if s.issueManager != nil {
+		s.issueManager.Stop()
+	}
+
+	if s.errorTracking != nil {
+		s.errorTracking.Stop()
+	}
+
+	if s.logMetrics != nil {
+		s.logMetrics.Stop()
+	}
+
+	if s.attrMetadata != nil {
+		s.attrMetadata.Stop()
+	}
+
+	if s.deployments != nil {
+		s.deployments.Stop()
+	}
+
+	if s.cardinalityController != nil {
+		s.cardinalityController.Stop()
+	}
+
+	if s.rollupManager != nil {
+		s.rollupManager.Stop()
+	}
+
+	if s.alertHistory != nil {
+		s.alertHistory.Stop()
+	}
+
 	// stop usage manager
 	s.usageManager.Stop()
 
@@ -28,6 +28,10 @@ func GetUserFromRequest(r *http.Request, apiHandler *api.APIHandler) (*basemodel
 				zap.L().Info("PAT has expired: ", zap.Any("pat", pat))
 				return nil, fmt.Errorf("PAT has expired")
 			}
+			if !pat.Scopes.Allows(r.Method, r.URL.Path) {
+				zap.L().Info("PAT scopes do not allow the request", zap.String("id", pat.Id), zap.String("path", r.URL.Path))
+				return nil, fmt.Errorf("the scopes of the PAT do not allow %s %s", r.Method, r.URL.Path)
+			}
 			group, apiErr := dao.GetGroupByName(ctx, pat.Role)
 			if apiErr != nil {
 				zap.L().Error("Error while getting group for PAT: ", zap.Any("apiErr", apiErr))
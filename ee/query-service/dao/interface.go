@@ -22,8 +22,12 @@ type ModelDao interface {
 
 	// auth methods
 	CanUsePassword(ctx context.Context, email string) (bool, basemodel.BaseApiError)
-	PrepareSsoRedirect(ctx context.Context, redirectUri, email string) (redirectURL string, apierr basemodel.BaseApiError)
+	PrepareSsoRedirect(ctx context.Context, redirectUri, email string, client *basemodel.LoginRequest) (redirectURL string, apierr basemodel.BaseApiError)
 	GetDomainFromSsoResponse(ctx context.Context, relayState *url.URL) (*model.OrgDomain, error)
+	SyncSsoUser(ctx context.Context, email, role string) (*basemodel.User, basemodel.BaseApiError)
+	SetSsoRefreshToken(ctx context.Context, t model.SsoRefreshToken) basemodel.BaseApiError
+	GetSsoRefreshToken(ctx context.Context, userId string) (*model.SsoRefreshToken, basemodel.BaseApiError)
+	DeleteSsoRefreshToken(ctx context.Context, userId string) basemodel.BaseApiError
 
 	// org domain (auth domains) CRUD ops
 	ListDomains(ctx context.Context, orgId string) ([]model.OrgDomain, basemodel.BaseApiError)
@@ -35,6 +39,7 @@ type ModelDao interface {
 
 	CreatePAT(ctx context.Context, p model.PAT) (model.PAT, basemodel.BaseApiError)
 	UpdatePAT(ctx context.Context, p model.PAT, id string) basemodel.BaseApiError
+	RotatePAT(ctx context.Context, p model.PAT, id string) basemodel.BaseApiError
 	GetPAT(ctx context.Context, pat string) (*model.PAT, basemodel.BaseApiError)
 	UpdatePATLastUsed(ctx context.Context, pat string, lastUsed int64) basemodel.BaseApiError
 	GetPATByID(ctx context.Context, id string) (*model.PAT, basemodel.BaseApiError)
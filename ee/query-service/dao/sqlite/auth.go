@@ -17,7 +17,7 @@ import (
 	"go.uber.org/zap"
 )
 
-func (m *modelDao) createUserForSAMLRequest(ctx context.Context, email string) (*basemodel.User, basemodel.BaseApiError) {
+func (m *modelDao) createUserForSAMLRequest(ctx context.Context, email, role string) (*basemodel.User, basemodel.BaseApiError) {
 	// get auth domain from email domain
 	domain, apierr := m.GetDomainByEmail(ctx, email)
 
@@ -32,11 +32,14 @@ func (m *modelDao) createUserForSAMLRequest(ctx context.Context, email string) (
 		return nil, model.InternalErrorStr("failed to generate password hash")
 	}
 
-	group, apiErr := m.GetGroupByName(ctx, baseconst.ViewerGroup)
+	group, apiErr := m.GetGroupByName(ctx, role)
 	if apiErr != nil {
 		zap.L().Error("GetGroupByName failed", zap.Error(apiErr))
 		return nil, apiErr
 	}
+	if group == nil {
+		return nil, model.InternalErrorStr(fmt.Sprintf("role %s not found", role))
+	}
 
 	user := &basemodel.User{
 		Id:                uuid.NewString(),
@@ -60,8 +63,9 @@ func (m *modelDao) createUserForSAMLRequest(ctx context.Context, email string) (
 }
 
 // PrepareSsoRedirect prepares redirect page link after SSO response
-// is successfully parsed (i.e. valid email is available)
-func (m *modelDao) PrepareSsoRedirect(ctx context.Context, redirectUri, email string) (redirectURL string, apierr basemodel.BaseApiError) {
+// is successfully parsed (i.e. valid email is available). The login starts
+// a session of the user, with the user agent and address of the client
+func (m *modelDao) PrepareSsoRedirect(ctx context.Context, redirectUri, email string, client *basemodel.LoginRequest) (redirectURL string, apierr basemodel.BaseApiError) {
 
 	userPayload, apierr := m.GetUserByEmail(ctx, email)
 	if !apierr.IsNil() {
@@ -72,7 +76,7 @@ func (m *modelDao) PrepareSsoRedirect(ctx context.Context, redirectUri, email st
 	user := &basemodel.User{}
 
 	if userPayload == nil {
-		newUser, apiErr := m.createUserForSAMLRequest(ctx, email)
+		newUser, apiErr := m.createUserForSAMLRequest(ctx, email, baseconst.ViewerGroup)
 		user = newUser
 		if apiErr != nil {
 			zap.L().Error("failed to create user with email received from auth provider", zap.Error(apiErr))
@@ -82,7 +86,7 @@ func (m *modelDao) PrepareSsoRedirect(ctx context.Context, redirectUri, email st
 		user = &userPayload.User
 	}
 
-	tokenStore, err := baseauth.GenerateJWTForUser(user)
+	tokenStore, err := baseauth.StartSession(ctx, user, client)
 	if err != nil {
 		zap.L().Error("failed to generate token for SSO login user", zap.Error(err))
 		return "", model.InternalErrorStr("failed to generate token for the user")
@@ -95,6 +99,75 @@ func (m *modelDao) PrepareSsoRedirect(ctx context.Context, redirectUri, email st
 		tokenStore.RefreshJwt), nil
 }
 
+// SyncSsoUser creates the user logged in through SSO with the role, or updates the
+// role of the existing user. The existing users keep their role when role is empty
+func (m *modelDao) SyncSsoUser(ctx context.Context, email, role string) (*basemodel.User, basemodel.BaseApiError) {
+	userPayload, apierr := m.GetUserByEmail(ctx, email)
+	if !apierr.IsNil() {
+		zap.L().Error("failed to get user with email received from auth provider", zap.String("error", apierr.Error()))
+		return nil, model.BadRequestStr("invalid user email received from the auth provider")
+	}
+
+	if userPayload == nil {
+		if role == "" {
+			role = baseconst.ViewerGroup
+		}
+		return m.createUserForSAMLRequest(ctx, email, role)
+	}
+
+	user := &userPayload.User
+	if role == "" || userPayload.Role == role {
+		return user, nil
+	}
+
+	group, apiErr := m.GetGroupByName(ctx, role)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if group == nil {
+		return nil, model.InternalErrorStr(fmt.Sprintf("role %s not found", role))
+	}
+	if apiErr := m.UpdateUserGroup(ctx, user.Id, group.Id); apiErr != nil {
+		zap.L().Error("failed to update the role of the SSO user", zap.String("email", email), zap.Error(apiErr))
+		return nil, apiErr
+	}
+	user.GroupId = group.Id
+	return user, nil
+}
+
+func (m *modelDao) SetSsoRefreshToken(ctx context.Context, t model.SsoRefreshToken) basemodel.BaseApiError {
+	_, err := m.DB().ExecContext(ctx,
+		"INSERT OR REPLACE INTO sso_refresh_tokens (user_id, domain_id, refresh_token, updated_at) VALUES ($1, $2, $3, $4)",
+		t.UserId, t.DomainId, t.RefreshToken, time.Now().Unix())
+	if err != nil {
+		zap.L().Error("Failed to store SSO refresh token in db", zap.Error(err))
+		return model.InternalError(fmt.Errorf("SSO refresh token insertion failed"))
+	}
+	return nil
+}
+
+// GetSsoRefreshToken returns the refresh token of the user, nil when the user did not log in through OIDC
+func (m *modelDao) GetSsoRefreshToken(ctx context.Context, userId string) (*model.SsoRefreshToken, basemodel.BaseApiError) {
+	tokens := []model.SsoRefreshToken{}
+	err := m.DB().SelectContext(ctx, &tokens, "SELECT user_id, domain_id, refresh_token, updated_at FROM sso_refresh_tokens WHERE user_id=$1", userId)
+	if err != nil {
+		zap.L().Error("Failed to fetch SSO refresh token from db", zap.Error(err))
+		return nil, model.InternalError(fmt.Errorf("failed to fetch SSO refresh token"))
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return &tokens[0], nil
+}
+
+func (m *modelDao) DeleteSsoRefreshToken(ctx context.Context, userId string) basemodel.BaseApiError {
+	if _, err := m.DB().ExecContext(ctx, "DELETE FROM sso_refresh_tokens WHERE user_id=$1", userId); err != nil {
+		zap.L().Error("Failed to delete SSO refresh token from db", zap.Error(err))
+		return model.InternalError(fmt.Errorf("failed to delete SSO refresh token"))
+	}
+	return nil
+}
+
 func (m *modelDao) CanUsePassword(ctx context.Context, email string) (bool, basemodel.BaseApiError) {
 	domain, apierr := m.GetDomainByEmail(ctx, email)
 	if apierr != nil {
@@ -99,6 +99,12 @@ func InitDB(dataSourceName string) (*modelDao, error) {
 		updated_by_user_id TEXT NOT NULL,
 		FOREIGN KEY(user_id) REFERENCES users(id)
 	);
+	CREATE TABLE IF NOT EXISTS sso_refresh_tokens (
+		user_id TEXT PRIMARY KEY,
+		domain_id TEXT NOT NULL,
+		refresh_token TEXT NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
 	`
 
 	_, err = m.DB().Exec(table_schema)
@@ -136,6 +142,12 @@ func InitDB(dataSourceName string) (*modelDao, error) {
 			return nil, fmt.Errorf("error in adding column: %v", err.Error())
 		}
 	}
+	if !columnExists(m.DB(), "personal_access_tokens", "scopes") {
+		_, err = m.DB().Exec("ALTER TABLE personal_access_tokens ADD COLUMN scopes TEXT NOT NULL DEFAULT '';")
+		if err != nil {
+			return nil, fmt.Errorf("error in adding column: %v", err.Error())
+		}
+	}
 	return m, nil
 }
 
@@ -13,7 +13,7 @@ import (
 
 func (m *modelDao) CreatePAT(ctx context.Context, p model.PAT) (model.PAT, basemodel.BaseApiError) {
 	result, err := m.DB().ExecContext(ctx,
-		"INSERT INTO personal_access_tokens (user_id, token, role, name, created_at, expires_at, updated_at, updated_by_user_id, last_used, revoked) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		"INSERT INTO personal_access_tokens (user_id, token, role, name, created_at, expires_at, updated_at, updated_by_user_id, last_used, revoked, scopes) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
 		p.UserID,
 		p.Token,
 		p.Role,
@@ -24,6 +24,7 @@ func (m *modelDao) CreatePAT(ctx context.Context, p model.PAT) (model.PAT, basem
 		p.UpdatedByUserID,
 		p.LastUsed,
 		p.Revoked,
+		p.Scopes,
 	)
 	if err != nil {
 		zap.L().Error("Failed to insert PAT in db, err: %v", zap.Error(err))
@@ -55,11 +56,12 @@ func (m *modelDao) CreatePAT(ctx context.Context, p model.PAT) (model.PAT, basem
 
 func (m *modelDao) UpdatePAT(ctx context.Context, p model.PAT, id string) basemodel.BaseApiError {
 	_, err := m.DB().ExecContext(ctx,
-		"UPDATE personal_access_tokens SET role=$1, name=$2, updated_at=$3, updated_by_user_id=$4 WHERE id=$5 and revoked=false;",
+		"UPDATE personal_access_tokens SET role=$1, name=$2, updated_at=$3, updated_by_user_id=$4, scopes=$5 WHERE id=$6 and revoked=false;",
 		p.Role,
 		p.Name,
 		p.UpdatedAt,
 		p.UpdatedByUserID,
+		p.Scopes,
 		id)
 	if err != nil {
 		zap.L().Error("Failed to update PAT in db, err: %v", zap.Error(err))
@@ -68,6 +70,25 @@ func (m *modelDao) UpdatePAT(ctx context.Context, p model.PAT, id string) basemo
 	return nil
 }
 
+// RotatePAT replaces the token of the PAT, the previous token stops working right away
+func (m *modelDao) RotatePAT(ctx context.Context, p model.PAT, id string) basemodel.BaseApiError {
+	result, err := m.DB().ExecContext(ctx,
+		"UPDATE personal_access_tokens SET token=$1, expires_at=$2, updated_at=$3, updated_by_user_id=$4, last_used=0 WHERE id=$5 and revoked=false;",
+		p.Token,
+		p.ExpiresAt,
+		p.UpdatedAt,
+		p.UpdatedByUserID,
+		id)
+	if err != nil {
+		zap.L().Error("Failed to rotate PAT in db, err: %v", zap.Error(err))
+		return model.InternalError(fmt.Errorf("PAT rotation failed"))
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return model.NewApiError(model.ErrorNotFound, fmt.Errorf("no active PAT found with id %s", id))
+	}
+	return nil
+}
+
 func (m *modelDao) UpdatePATLastUsed(ctx context.Context, token string, lastUsed int64) basemodel.BaseApiError {
 	_, err := m.DB().ExecContext(ctx,
 		"UPDATE personal_access_tokens SET last_used=$1 WHERE token=$2 and revoked=false;",
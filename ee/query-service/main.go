@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -13,6 +14,7 @@ import (
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.signoz.io/signoz/ee/query-service/app"
+	"go.signoz.io/signoz/pkg/query-service/app/backup"
 	"go.signoz.io/signoz/pkg/query-service/auth"
 	baseconst "go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/migrate"
@@ -88,8 +90,10 @@ func main() {
 	var cluster string
 
 	var cacheConfigPath, fluxInterval string
+	var clickhouseSettingsPath string
 	var enableQueryServiceLogOTLPExport bool
 	var preferSpanMetrics bool
+	var otlpReceiver bool
 
 	var maxIdleConns int
 	var maxOpenConns int
@@ -100,11 +104,13 @@ func main() {
 	flag.StringVar(&skipTopLvlOpsPath, "skip-top-level-ops", "", "(config file to skip top level operations)")
 	flag.BoolVar(&disableRules, "rules.disable", false, "(disable rule evaluation)")
 	flag.BoolVar(&preferSpanMetrics, "prefer-span-metrics", false, "(prefer span metrics for service level metrics)")
+	flag.BoolVar(&otlpReceiver, "otlp-receiver", false, "(receive the OTLP/HTTP traces, logs and metrics on /v1/traces, /v1/logs and /v1/metrics, for the installs without a collector)")
 	flag.IntVar(&maxIdleConns, "max-idle-conns", 50, "(number of connections to maintain in the pool.)")
 	flag.IntVar(&maxOpenConns, "max-open-conns", 100, "(max connections for use at any time.)")
 	flag.DurationVar(&dialTimeout, "dial-timeout", 5*time.Second, "(the maximum time to establish a connection.)")
 	flag.StringVar(&ruleRepoURL, "rules.repo-url", baseconst.AlertHelpPage, "(host address used to build rule link in alert messages)")
 	flag.StringVar(&cacheConfigPath, "experimental.cache-config", "", "(cache config to use)")
+	flag.StringVar(&clickhouseSettingsPath, "clickhouse-settings-config", "", "(clickhouse connection pool and query settings profiles config to use)")
 	flag.StringVar(&fluxInterval, "flux-interval", "5m", "(the interval to exclude data from being cached to avoid incorrect cache for data in motion)")
 	flag.BoolVar(&enableQueryServiceLogOTLPExport, "enable.query.service.log.otlp.export", false, "(enable query service log otlp export)")
 	flag.StringVar(&cluster, "cluster", "cluster", "(cluster name - defaults to 'cluster')")
@@ -112,6 +118,15 @@ func main() {
 
 	flag.Parse()
 
+	// the backup and restore commands run on the metadata store without the server
+	if backup.IsCommand(flag.Args()) {
+		if err := backup.RunCommand(flag.Args(), baseconst.RELATIONAL_DATASOURCE_PATH, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	loggerMgr := initZapLog(enableQueryServiceLogOTLPExport)
 
 	zap.ReplaceGlobals(loggerMgr)
@@ -120,20 +135,22 @@ func main() {
 	version.PrintVersion()
 
 	serverOptions := &app.ServerOptions{
-		HTTPHostPort:      baseconst.HTTPHostPort,
-		PromConfigPath:    promConfigPath,
-		SkipTopLvlOpsPath: skipTopLvlOpsPath,
-		PreferSpanMetrics: preferSpanMetrics,
-		PrivateHostPort:   baseconst.PrivateHostPort,
-		DisableRules:      disableRules,
-		RuleRepoURL:       ruleRepoURL,
-		MaxIdleConns:      maxIdleConns,
-		MaxOpenConns:      maxOpenConns,
-		DialTimeout:       dialTimeout,
-		CacheConfigPath:   cacheConfigPath,
-		FluxInterval:      fluxInterval,
-		Cluster:           cluster,
-		GatewayUrl:        gatewayUrl,
+		HTTPHostPort:           baseconst.HTTPHostPort,
+		PromConfigPath:         promConfigPath,
+		SkipTopLvlOpsPath:      skipTopLvlOpsPath,
+		PreferSpanMetrics:      preferSpanMetrics,
+		OTLPReceiver:           otlpReceiver,
+		PrivateHostPort:        baseconst.PrivateHostPort,
+		DisableRules:           disableRules,
+		RuleRepoURL:            ruleRepoURL,
+		MaxIdleConns:           maxIdleConns,
+		MaxOpenConns:           maxOpenConns,
+		DialTimeout:            dialTimeout,
+		CacheConfigPath:        cacheConfigPath,
+		ClickHouseSettingsPath: clickhouseSettingsPath,
+		FluxInterval:           fluxInterval,
+		Cluster:                cluster,
+		GatewayUrl:             gatewayUrl,
 	}
 
 	// Read the jwt secret key
@@ -10,3 +10,12 @@ type GettableInvitation struct {
 	*basemodel.InvitationResponseObject
 	Precheck *basemodel.PrecheckResponse `json:"precheck"`
 }
+
+// SsoRefreshToken is the refresh token of the OIDC provider a user logged in
+// with, the login session of the user is refreshed with the provider
+type SsoRefreshToken struct {
+	UserId       string `db:"user_id"`
+	DomainId     string `db:"domain_id"`
+	RefreshToken string `db:"refresh_token"`
+	UpdatedAt    int64  `db:"updated_at"`
+}
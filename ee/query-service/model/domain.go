@@ -20,6 +20,9 @@ type SSOType string
 const (
 	SAML       SSOType = "SAML"
 	GoogleAuth SSOType = "GOOGLE_AUTH"
+	OIDC       SSOType = "OIDC"
+	AzureAD    SSOType = "AZURE_AD"
+	GitHub     SSOType = "GITHUB"
 )
 
 // OrgDomain identify org owned web domains for auth and other purposes
@@ -32,6 +35,9 @@ type OrgDomain struct {
 
 	SamlConfig       *SamlConfig        `json:"samlConfig"`
 	GoogleAuthConfig *GoogleOAuthConfig `json:"googleAuthConfig"`
+	OIDCConfig       *OIDCConfig        `json:"oidcConfig"`
+	AzureADConfig    *AzureADConfig     `json:"azureAdConfig"`
+	GitHubConfig     *GitHubConfig      `json:"githubConfig"`
 
 	Org *basemodel.Organization
 }
@@ -65,9 +71,65 @@ func (od *OrgDomain) ValidNew() error {
 		return fmt.Errorf("name is required")
 	}
 
+	return od.ValidConfig()
+}
+
+// ValidConfig checks the SSO config of the domain is complete
+func (od *OrgDomain) ValidConfig() error {
+	if od.SsoType == OIDC && od.OIDCConfig == nil {
+		return fmt.Errorf("oidcConfig is required for OIDC")
+	}
+	if od.SsoType == AzureAD && od.AzureADConfig == nil {
+		return fmt.Errorf("azureAdConfig is required for AZURE_AD")
+	}
+	if od.SsoType == GitHub && od.GitHubConfig == nil {
+		return fmt.Errorf("githubConfig is required for GITHUB")
+	}
+	if od.OIDCConfig != nil {
+		if err := od.OIDCConfig.Validate(); err != nil {
+			return err
+		}
+	}
+	if od.AzureADConfig != nil {
+		if err := od.AzureADConfig.Validate(); err != nil {
+			return err
+		}
+	}
+	if od.GitHubConfig != nil {
+		return od.GitHubConfig.Validate()
+	}
 	return nil
 }
 
+// MappedRoles returns the roles the groups of the SSO configs are mapped to
+func (od *OrgDomain) MappedRoles() []string {
+	roles := []string{}
+	if od.OIDCConfig != nil {
+		roles = append(roles, od.OIDCConfig.Roles()...)
+	}
+	if od.AzureADConfig != nil {
+		roles = append(roles, od.AzureADConfig.Roles()...)
+	}
+	if od.GitHubConfig != nil {
+		roles = append(roles, od.GitHubConfig.Roles()...)
+	}
+	return roles
+}
+
+// RoleFor returns the role of a user in the groups with the role mapping of the SSO
+// type of the domain. Empty when no role applies
+func (od *OrgDomain) RoleFor(groups []string) string {
+	switch {
+	case od.SsoType == OIDC && od.OIDCConfig != nil:
+		return od.OIDCConfig.RoleFor(groups)
+	case od.SsoType == AzureAD && od.AzureADConfig != nil:
+		return od.AzureADConfig.RoleFor(groups)
+	case od.SsoType == GitHub && od.GitHubConfig != nil:
+		return od.GitHubConfig.RoleFor(groups)
+	}
+	return ""
+}
+
 // LoadConfig loads config params from json text
 func (od *OrgDomain) LoadConfig(jsondata string) error {
 	d := *od
@@ -110,6 +172,36 @@ func (od *OrgDomain) PrepareGoogleOAuthProvider(siteUrl *url.URL) (sso.OAuthCall
 	return od.GoogleAuthConfig.GetProvider(od.Name, siteUrl)
 }
 
+// PrepareOIDCProvider creates the OIDC provider used in requesting
+// the login and in processing the response from the provider
+func (od *OrgDomain) PrepareOIDCProvider(siteUrl *url.URL) (*sso.OIDCProvider, error) {
+	if od.OIDCConfig == nil {
+		return nil, fmt.Errorf("OIDC is not setup correctly for this domain")
+	}
+
+	return od.OIDCConfig.GetProvider(siteUrl)
+}
+
+// PrepareAzureADProvider creates the Azure AD provider used in requesting
+// the login and in processing the response from azure
+func (od *OrgDomain) PrepareAzureADProvider(siteUrl *url.URL) (*sso.AzureADProvider, error) {
+	if od.AzureADConfig == nil {
+		return nil, fmt.Errorf("AZURE AD is not setup correctly for this domain")
+	}
+
+	return od.AzureADConfig.GetProvider(siteUrl)
+}
+
+// PrepareGitHubProvider creates the GitHub provider used in requesting
+// the login and in processing the response from github
+func (od *OrgDomain) PrepareGitHubProvider(siteUrl *url.URL) (*sso.GitHubProvider, error) {
+	if od.GitHubConfig == nil {
+		return nil, fmt.Errorf("GITHUB is not setup correctly for this domain")
+	}
+
+	return od.GitHubConfig.GetProvider(od.Name, siteUrl), nil
+}
+
 // PrepareSamlRequest creates a request accordingly gosaml2
 func (od *OrgDomain) PrepareSamlRequest(siteUrl *url.URL) (*saml2.SAMLServiceProvider, error) {
 
@@ -173,6 +265,31 @@ func (od *OrgDomain) BuildSsoUrl(siteUrl *url.URL) (ssoUrl string, err error) {
 		}
 		return googleProvider.BuildAuthURL(relayState)
 
+	case OIDC:
+
+		oidcProvider, err := od.PrepareOIDCProvider(siteUrl)
+		if err != nil {
+			return "", err
+		}
+		return oidcProvider.BuildAuthURL(relayState)
+
+	case AzureAD:
+
+		azureProvider, err := od.PrepareAzureADProvider(siteUrl)
+		if err != nil {
+			return "", err
+		}
+		defer azureProvider.Cancel()
+		return azureProvider.BuildAuthURL(relayState)
+
+	case GitHub:
+
+		githubProvider, err := od.PrepareGitHubProvider(siteUrl)
+		if err != nil {
+			return "", err
+		}
+		return githubProvider.BuildAuthURL(relayState)
+
 	default:
 		zap.L().Error("found unsupported SSO config for the org domain", zap.String("orgDomain", od.Name))
 		return "", fmt.Errorf("unsupported SSO config for the domain")
@@ -83,6 +83,7 @@ var (
 	ErrorUnauthorized          basemodel.ErrorType = "unauthorized"
 	ErrorForbidden             basemodel.ErrorType = "forbidden"
 	ErrorConflict              basemodel.ErrorType = "conflict"
+	ErrorPreconditionFailed    basemodel.ErrorType = "precondition_failed"
 	ErrorStreamingNotSupported basemodel.ErrorType = "streaming is not supported"
 )
 
@@ -99,6 +100,7 @@ func init() {
 	ErrorUnauthorized = basemodel.ErrorUnauthorized
 	ErrorForbidden = basemodel.ErrorForbidden
 	ErrorConflict = basemodel.ErrorConflict
+	ErrorPreconditionFailed = basemodel.ErrorPreconditionFailed
 	ErrorStreamingNotSupported = basemodel.ErrorStreamingNotSupported
 }
 
@@ -1,5 +1,12 @@
 package model
 
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
 type User struct {
 	Id                string `json:"id" db:"id"`
 	Name              string `json:"name" db:"name"`
@@ -10,23 +17,185 @@ type User struct {
 }
 
 type CreatePATRequestBody struct {
-	Name          string `json:"name"`
-	Role          string `json:"role"`
-	ExpiresInDays int64  `json:"expiresInDays"`
+	Name          string    `json:"name"`
+	Role          string    `json:"role"`
+	Scopes        PATScopes `json:"scopes"`
+	ExpiresInDays int64     `json:"expiresInDays"`
+}
+
+// RotatePATRequestBody sets the expiry of the rotated PAT, the expiry is kept when unset
+// and the PAT never expires when 0
+type RotatePATRequestBody struct {
+	ExpiresInDays *int64 `json:"expiresInDays"`
 }
 
 type PAT struct {
-	Id              string `json:"id" db:"id"`
-	UserID          string `json:"userId" db:"user_id"`
-	CreatedByUser   User   `json:"createdByUser"`
-	UpdatedByUser   User   `json:"updatedByUser"`
-	Token           string `json:"token" db:"token"`
-	Role            string `json:"role" db:"role"`
-	Name            string `json:"name" db:"name"`
-	CreatedAt       int64  `json:"createdAt" db:"created_at"`
-	ExpiresAt       int64  `json:"expiresAt" db:"expires_at"`
-	UpdatedAt       int64  `json:"updatedAt" db:"updated_at"`
-	LastUsed        int64  `json:"lastUsed" db:"last_used"`
-	Revoked         bool   `json:"revoked" db:"revoked"`
-	UpdatedByUserID string `json:"updatedByUserId" db:"updated_by_user_id"`
+	Id              string    `json:"id" db:"id"`
+	UserID          string    `json:"userId" db:"user_id"`
+	CreatedByUser   User      `json:"createdByUser"`
+	UpdatedByUser   User      `json:"updatedByUser"`
+	Token           string    `json:"token" db:"token"`
+	Role            string    `json:"role" db:"role"`
+	Scopes          PATScopes `json:"scopes" db:"scopes"`
+	Name            string    `json:"name" db:"name"`
+	CreatedAt       int64     `json:"createdAt" db:"created_at"`
+	ExpiresAt       int64     `json:"expiresAt" db:"expires_at"`
+	UpdatedAt       int64     `json:"updatedAt" db:"updated_at"`
+	LastUsed        int64     `json:"lastUsed" db:"last_used"`
+	Revoked         bool      `json:"revoked" db:"revoked"`
+	UpdatedByUserID string    `json:"updatedByUserId" db:"updated_by_user_id"`
+}
+
+// PATScope limits the APIs a PAT can call on top of its role
+type PATScope string
+
+const (
+	// PATScopeQueryRead allows querying the telemetry and reading the dashboards
+	PATScopeQueryRead PATScope = "query:read"
+	// PATScopeAlertsWrite allows managing the alerts and their notification channels
+	PATScopeAlertsWrite PATScope = "alerts:write"
+	// PATScopeIngestConfig allows managing the ingestion config: pipelines, keys, sampling and retention
+	PATScopeIngestConfig PATScope = "ingest:config"
+)
+
+// scopeRule matches the requests of a scope, any method matches when methods is empty
+type scopeRule struct {
+	methods  []string
+	prefixes []string
+	excluded []string
+}
+
+var patScopeRules = map[PATScope][]scopeRule{
+	PATScopeQueryRead: {
+		{
+			methods: []string{http.MethodGet},
+			prefixes: []string{"/api/v1/query_range", "/api/v1/query", "/api/v1/query_history", "/api/v1/services",
+				"/api/v1/service", "/api/v1/dependency_graph", "/api/v1/metrics", "/api/v1/logs", "/api/v1/traces",
+				"/api/v1/errorFromErrorID", "/api/v1/errorFromGroupID", "/api/v1/nextPrevErrorIDs", "/api/v1/dashboards",
+				"/api/v1/deployments", "/api/v1/profiles", "/api/v1/synthetics", "/api/v1/variables", "/api/v2/variables",
+				"/api/v1/explorer", "/api/v3", "/api/v4", "/api/prom", "/api/jaeger", "/api/es",
+				"/loki/api/v1/query_range", "/loki/api/v1/labels", "/loki/api/v1/label", "/api/v5/dashboards", "/api/v5/views"},
+			excluded: []string{"/api/v1/logs/pipelines", "/api/v1/logs/exports"},
+		},
+		{
+			methods: []string{http.MethodPost},
+			prefixes: []string{"/api/v3/query_range", "/api/v4/query_range", "/api/v1/services", "/api/v1/service",
+				"/api/v1/dependency_graph", "/api/v1/getSpanFilters", "/api/v1/getTagFilters", "/api/v1/getFilteredSpans",
+				"/api/v1/getTagValues", "/api/v1/listErrors", "/api/v1/countErrors", "/api/v2/variables/query",
+				"/api/v1/variables/resolve", "/api/prom", "/loki/api/v1/query_range", "/api/es", "/api/v5/services",
+				"/api/v1/traces/funnel", "/api/v1/traces/aggregate", "/api/v1/k8s", "/api/v1/hosts",
+				"/api/v1/profiles/flamegraph", "/api/v1/profiles/diff", "/api/v1/rum/web_vitals", "/api/v1/rum/page_loads"},
+		},
+	},
+	PATScopeAlertsWrite: {
+		{
+			prefixes: []string{"/api/v1/rules", "/api/v1/testRule", "/api/v1/alerts", "/api/v1/channels", "/api/v1/testChannel",
+				"/api/v1/notification_templates", "/api/v1/downtime_schedules", "/api/v1/escalation_policies",
+				"/api/v1/recording_rules", "/api/v1/slos", "/api/v1/heartbeats", "/api/v5/rules"},
+		},
+	},
+	PATScopeIngestConfig: {
+		{
+			prefixes: []string{"/api/v1/logs/pipelines", "/api/v1/settings/ingestion_key", "/api/v1/settings/sampling_rules",
+				"/api/v1/settings/cardinality_limits", "/api/v1/settings/span_metrics", "/api/v1/settings/ttl",
+				"/api/v1/metrics/cardinality", "/api/gateway"},
+		},
+	},
+}
+
+// matchPathPrefix checks if the path is the prefix or is under it
+func matchPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func (r scopeRule) allows(method, path string) bool {
+	if len(r.methods) > 0 {
+		found := false
+		for _, m := range r.methods {
+			found = found || m == method
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, prefix := range r.excluded {
+		if matchPathPrefix(path, prefix) {
+			return false
+		}
+	}
+	for _, prefix := range r.prefixes {
+		if matchPathPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s PATScope) Valid() bool {
+	_, ok := patScopeRules[s]
+	return ok
+}
+
+// Allows checks if the scope allows the request with the method to the path
+func (s PATScope) Allows(method, path string) bool {
+	for _, rule := range patScopeRules[s] {
+		if rule.allows(method, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// PATScopes are the scopes of a PAT, the PATs without scopes can call all the APIs their role allows.
+// They are stored comma separated
+type PATScopes []PATScope
+
+func (s PATScopes) Validate() error {
+	for _, scope := range s {
+		if !scope.Valid() {
+			return fmt.Errorf("invalid scope %q, must be one of %s, %s or %s", scope, PATScopeQueryRead, PATScopeAlertsWrite, PATScopeIngestConfig)
+		}
+	}
+	return nil
+}
+
+// Allows checks if any of the scopes allows the request
+func (s PATScopes) Allows(method, path string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, scope := range s {
+		if scope.Allows(method, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s PATScopes) Value() (driver.Value, error) {
+	scopes := make([]string, len(s))
+	for i, scope := range s {
+		scopes[i] = string(scope)
+	}
+	return strings.Join(scopes, ","), nil
+}
+
+func (s *PATScopes) Scan(src interface{}) error {
+	var value string
+	switch v := src.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	case nil:
+	default:
+		return fmt.Errorf("unsupported type %T for PAT scopes", src)
+	}
+	*s = PATScopes{}
+	for _, scope := range strings.Split(value, ",") {
+		if scope != "" {
+			*s = append(*s, PATScope(scope))
+		}
+	}
+	return nil
 }
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPATScopesAllows(t *testing.T) {
+	testCases := []struct {
+		name     string
+		scopes   PATScopes
+		method   string
+		path     string
+		expected bool
+	}{
+		{"no scopes allow everything", nil, http.MethodDelete, "/api/v1/dashboards/abc", true},
+		{"query read allows queries", PATScopes{PATScopeQueryRead}, http.MethodPost, "/api/v3/query_range", true},
+		{"query read allows reading dashboards", PATScopes{PATScopeQueryRead}, http.MethodGet, "/api/v1/dashboards/abc", true},
+		{"query read allows the prometheus API", PATScopes{PATScopeQueryRead}, http.MethodPost, "/api/prom/api/v1/query_range", true},
+		{"query read allows the loki queries", PATScopes{PATScopeQueryRead}, http.MethodGet, "/loki/api/v1/label/job/values", true},
+		{"query read denies the loki push", PATScopes{PATScopeQueryRead}, http.MethodPost, "/loki/api/v1/push", false},
+		{"query read allows the elasticsearch searches", PATScopes{PATScopeQueryRead}, http.MethodPost, "/api/es/logs-*/_search", true},
+		{"query read allows the v5 dashboards", PATScopes{PATScopeQueryRead}, http.MethodGet, "/api/v5/dashboards/abc", true},
+		{"query read denies the v5 rules", PATScopes{PATScopeQueryRead}, http.MethodGet, "/api/v5/rules", false},
+		{"query read denies dashboard changes", PATScopes{PATScopeQueryRead}, http.MethodPut, "/api/v1/dashboards/abc", false},
+		{"query read denies the pipelines", PATScopes{PATScopeQueryRead}, http.MethodGet, "/api/v1/logs/pipelines/latest", false},
+		{"query read denies the PATs", PATScopes{PATScopeQueryRead}, http.MethodGet, "/api/v1/pats", false},
+		{"prefixes match whole segments", PATScopes{PATScopeQueryRead}, http.MethodGet, "/api/v1/queryable", false},
+		{"alerts write manages rules", PATScopes{PATScopeAlertsWrite}, http.MethodDelete, "/api/v1/rules/1", true},
+		{"alerts write denies queries", PATScopes{PATScopeAlertsWrite}, http.MethodPost, "/api/v3/query_range", false},
+		{"ingest config manages pipelines", PATScopes{PATScopeIngestConfig}, http.MethodPost, "/api/v1/logs/pipelines", true},
+		{"any scope allows", PATScopes{PATScopeAlertsWrite, PATScopeIngestConfig}, http.MethodPost, "/api/v1/settings/sampling_rules", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.scopes.Allows(tc.method, tc.path))
+		})
+	}
+}
+
+func TestPATScopesStorage(t *testing.T) {
+	scopes := PATScopes{PATScopeQueryRead, PATScopeAlertsWrite}
+	value, err := scopes.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "query:read,alerts:write", value)
+
+	var scanned PATScopes
+	require.NoError(t, scanned.Scan(value))
+	assert.Equal(t, scopes, scanned)
+	require.NoError(t, scanned.Scan(""))
+	assert.Empty(t, scanned)
+
+	assert.NoError(t, scopes.Validate())
+	assert.Error(t, PATScopes{"admin"}.Validate())
+}
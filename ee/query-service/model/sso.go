@@ -2,11 +2,14 @@ package model
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"context"
 	"net/url"
 	"golang.org/x/oauth2"
 	"github.com/coreos/go-oidc/v3/oidc"
 	"go.signoz.io/signoz/ee/query-service/sso"
+	baseconst "go.signoz.io/signoz/pkg/query-service/constants"
 )
 
 // SamlConfig contans SAML params to generate and respond to the requests 
@@ -66,3 +69,308 @@ func (g *GoogleOAuthConfig) GetProvider(domain string, siteUrl *url.URL) (sso.OA
 	}, nil
 }
 
+
+// OIDCConfig contains the params of a generic OpenID Connect provider
+type OIDCConfig struct {
+	// DiscoveryURL is the issuer URL of the provider, or its
+	// .well-known/openid-configuration URL
+	DiscoveryURL string `json:"discoveryUrl"`
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	// Scopes requested on top of openid, defaults to email, profile and offline_access
+	// so a refresh token is issued
+	Scopes []string `json:"scopes"`
+	// GroupsClaim is the claim of the ID token listing the groups of the user,
+	// defaults to groups
+	GroupsClaim string `json:"groupsClaim"`
+	// RoleMapping maps the groups of the users to their role in SigNoz, the roles
+	// of the users are synced at each login when set
+	RoleMapping map[string]string `json:"roleMapping"`
+	// DefaultRole is the role of the users in none of the mapped groups, the
+	// existing users keep their role when unset
+	DefaultRole string `json:"defaultRole"`
+	// AllowUnverifiedEmail logs in the users whose email the provider has not
+	// verified, for the providers that don't send the email_verified claim
+	AllowUnverifiedEmail bool `json:"allowUnverifiedEmail"`
+}
+
+const (
+	oidcDiscoveryPath  = "/.well-known/openid-configuration"
+	defaultGroupsClaim = "groups"
+)
+
+var defaultOIDCScopes = []string{"email", "profile", "offline_access"}
+
+// Validate checks the config is complete, the roles are validated against the roles of the org by the caller
+func (c *OIDCConfig) Validate() error {
+	if c.DiscoveryURL == "" || c.ClientID == "" || c.ClientSecret == "" {
+		return fmt.Errorf("discoveryUrl, clientId and clientSecret are required for OIDC")
+	}
+	if _, err := url.ParseRequestURI(c.DiscoveryURL); err != nil {
+		return fmt.Errorf("invalid OIDC discoveryUrl: %v", err)
+	}
+	return nil
+}
+
+// Roles returns the roles the config maps the users to
+func (c *OIDCConfig) Roles() []string {
+	return mappedRoles(c.RoleMapping, c.DefaultRole)
+}
+
+// IssuerURL returns the issuer URL the provider configuration is discovered from
+func (c *OIDCConfig) IssuerURL() string {
+	return strings.TrimSuffix(strings.TrimSuffix(c.DiscoveryURL, "/"), oidcDiscoveryPath)
+}
+
+// RoleFor returns the role of a user in the groups, the most privileged role wins
+// when the user is in several mapped groups. Empty when no role applies
+func (c *OIDCConfig) RoleFor(groups []string) string {
+	return roleFor(c.RoleMapping, c.DefaultRole, groups)
+}
+
+// mappedRoles returns the roles of the role mapping and the default role
+func mappedRoles(roleMapping map[string]string, defaultRole string) []string {
+	roles := []string{}
+	for _, role := range roleMapping {
+		roles = append(roles, role)
+	}
+	if defaultRole != "" {
+		roles = append(roles, defaultRole)
+	}
+	return roles
+}
+
+// roleFor returns the most privileged role the groups are mapped to, or the default role
+func roleFor(roleMapping map[string]string, defaultRole string, groups []string) string {
+	mapped := []string{}
+	for _, group := range groups {
+		if role, ok := roleMapping[group]; ok {
+			mapped = append(mapped, role)
+		}
+	}
+	if len(mapped) == 0 {
+		return defaultRole
+	}
+
+	rank := func(role string) int {
+		switch role {
+		case baseconst.AdminGroup:
+			return 3
+		case baseconst.EditorGroup:
+			return 2
+		case baseconst.ViewerGroup:
+			return 0
+		}
+		// the custom roles sit between the editors and the viewers
+		return 1
+	}
+	sort.Slice(mapped, func(i, j int) bool {
+		if rank(mapped[i]) != rank(mapped[j]) {
+			return rank(mapped[i]) > rank(mapped[j])
+		}
+		return mapped[i] < mapped[j]
+	})
+	return mapped[0]
+}
+
+func (c *OIDCConfig) GetProvider(siteUrl *url.URL) (*sso.OIDCProvider, error) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	provider, err := oidc.NewProvider(ctx, c.IssuerURL())
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to get provider: %v", err)
+	}
+
+	scopes := []string{oidc.ScopeOpenID}
+	configured := c.Scopes
+	if len(configured) == 0 {
+		configured = defaultOIDCScopes
+	}
+	for _, scope := range configured {
+		if scope != oidc.ScopeOpenID {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	groupsClaim := c.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = defaultGroupsClaim
+	}
+
+	// this is the url the provider will call after login completion
+	redirectURL := fmt.Sprintf("%s://%s/%s",
+		siteUrl.Scheme,
+		siteUrl.Host,
+		"api/v1/complete/oidc")
+
+	return &sso.OIDCProvider{
+		OAuth2Config: &oauth2.Config{
+			ClientID:     c.ClientID,
+			ClientSecret: c.ClientSecret,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+			RedirectURL:  redirectURL,
+		},
+		Verifier: provider.Verifier(
+			&oidc.Config{ClientID: c.ClientID},
+		),
+		GroupsClaim:          groupsClaim,
+		AllowUnverifiedEmail: c.AllowUnverifiedEmail,
+		Cancel:               cancel,
+	}, nil
+}
+
+// AzureADConfig contains the params of an Azure AD (Microsoft Entra ID) app registration
+type AzureADConfig struct {
+	// TenantID is the directory (tenant) id of the organization
+	TenantID     string `json:"tenantId"`
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	// RoleMapping maps the object ids of the groups of the users to their role in
+	// SigNoz, the groups claim must be enabled in the token configuration of the app
+	RoleMapping map[string]string `json:"roleMapping"`
+	// DefaultRole is the role of the users in none of the mapped groups, the
+	// existing users keep their role when unset
+	DefaultRole string `json:"defaultRole"`
+}
+
+const (
+	azureIssuerURL = "https://login.microsoftonline.com/%s/v2.0"
+	azureGraphURL  = "https://graph.microsoft.com/v1.0"
+)
+
+// the multi-tenant endpoints have no fixed issuer to verify the ID tokens with
+var azureMultiTenants = map[string]struct{}{"common": {}, "organizations": {}, "consumers": {}}
+
+func (c *AzureADConfig) Validate() error {
+	if c.TenantID == "" || c.ClientID == "" || c.ClientSecret == "" {
+		return fmt.Errorf("tenantId, clientId and clientSecret are required for Azure AD")
+	}
+	if _, ok := azureMultiTenants[strings.ToLower(c.TenantID)]; ok {
+		return fmt.Errorf("tenantId must be the id of the tenant, %s is not supported", c.TenantID)
+	}
+	return nil
+}
+
+// Roles returns the roles the config maps the users to
+func (c *AzureADConfig) Roles() []string {
+	return mappedRoles(c.RoleMapping, c.DefaultRole)
+}
+
+// RoleFor returns the role of a user in the groups, see OIDCConfig.RoleFor
+func (c *AzureADConfig) RoleFor(groups []string) string {
+	return roleFor(c.RoleMapping, c.DefaultRole, groups)
+}
+
+func (c *AzureADConfig) GetProvider(siteUrl *url.URL) (*sso.AzureADProvider, error) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	provider, err := oidc.NewProvider(ctx, fmt.Sprintf(azureIssuerURL, c.TenantID))
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to get provider: %v", err)
+	}
+
+	// this is the url azure will call after login completion
+	redirectURL := fmt.Sprintf("%s://%s/%s",
+		siteUrl.Scheme,
+		siteUrl.Host,
+		"api/v1/complete/azure")
+
+	return &sso.AzureADProvider{
+		OAuth2Config: &oauth2.Config{
+			ClientID:     c.ClientID,
+			ClientSecret: c.ClientSecret,
+			Endpoint:     provider.Endpoint(),
+			// User.Read lets the groups overage be read from microsoft graph
+			Scopes:      []string{oidc.ScopeOpenID, "email", "profile", "User.Read"},
+			RedirectURL: redirectURL,
+		},
+		Verifier: provider.Verifier(
+			&oidc.Config{ClientID: c.ClientID},
+		),
+		GraphURL: azureGraphURL,
+		Cancel:   cancel,
+	}, nil
+}
+
+// GitHubConfig contains the params of a GitHub OAuth app
+type GitHubConfig struct {
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	// EnterpriseURL is the url of the GitHub Enterprise server, github.com when unset
+	EnterpriseURL string `json:"enterpriseUrl"`
+	// AllowedOrganizations restricts the login to the members of the organizations
+	AllowedOrganizations []string `json:"allowedOrganizations"`
+	// RoleMapping maps the organizations and the teams, as org/team-slug, of the
+	// users to their role in SigNoz
+	RoleMapping map[string]string `json:"roleMapping"`
+	// DefaultRole is the role of the users in none of the mapped teams, the
+	// existing users keep their role when unset
+	DefaultRole string `json:"defaultRole"`
+}
+
+const (
+	githubURL    = "https://github.com"
+	githubAPIURL = "https://api.github.com"
+)
+
+func (c *GitHubConfig) Validate() error {
+	if c.ClientID == "" || c.ClientSecret == "" {
+		return fmt.Errorf("clientId and clientSecret are required for GitHub")
+	}
+	if c.EnterpriseURL != "" {
+		if _, err := url.ParseRequestURI(c.EnterpriseURL); err != nil {
+			return fmt.Errorf("invalid GitHub enterpriseUrl: %v", err)
+		}
+	}
+	return nil
+}
+
+// Roles returns the roles the config maps the users to
+func (c *GitHubConfig) Roles() []string {
+	return mappedRoles(c.RoleMapping, c.DefaultRole)
+}
+
+// RoleFor returns the role of a user in the organizations and teams, see OIDCConfig.RoleFor
+func (c *GitHubConfig) RoleFor(groups []string) string {
+	return roleFor(c.RoleMapping, c.DefaultRole, groups)
+}
+
+// URLs returns the url of the web and of the REST API of the GitHub server
+func (c *GitHubConfig) URLs() (webURL string, apiURL string) {
+	if c.EnterpriseURL == "" {
+		return githubURL, githubAPIURL
+	}
+	webURL = strings.TrimSuffix(c.EnterpriseURL, "/")
+	return webURL, webURL + "/api/v3"
+}
+
+func (c *GitHubConfig) GetProvider(domain string, siteUrl *url.URL) *sso.GitHubProvider {
+	webURL, apiURL := c.URLs()
+
+	// this is the url github will call after login completion
+	redirectURL := fmt.Sprintf("%s://%s/%s",
+		siteUrl.Scheme,
+		siteUrl.Host,
+		"api/v1/complete/github")
+
+	return &sso.GitHubProvider{
+		OAuth2Config: &oauth2.Config{
+			ClientID:     c.ClientID,
+			ClientSecret: c.ClientSecret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  webURL + "/login/oauth/authorize",
+				TokenURL: webURL + "/login/oauth/access_token",
+			},
+			Scopes:      []string{"read:user", "user:email", "read:org"},
+			RedirectURL: redirectURL,
+		},
+		APIURL:               apiURL,
+		Domain:               domain,
+		AllowedOrganizations: c.AllowedOrganizations,
+	}
+}
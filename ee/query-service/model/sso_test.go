@@ -0,0 +1,76 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCConfigRoleFor(t *testing.T) {
+	config := &OIDCConfig{
+		RoleMapping: map[string]string{
+			"signoz-admins":  "ADMIN",
+			"signoz-editors": "EDITOR",
+			"oncall":         "ONCALL",
+			"everyone":       "VIEWER",
+		},
+	}
+
+	assert.Equal(t, "ADMIN", config.RoleFor([]string{"everyone", "signoz-admins", "signoz-editors"}))
+	assert.Equal(t, "ONCALL", config.RoleFor([]string{"everyone", "oncall"}))
+	assert.Equal(t, "VIEWER", config.RoleFor([]string{"everyone"}))
+	// the users in no mapped group keep their role unless a default role is set
+	assert.Equal(t, "", config.RoleFor([]string{"other"}))
+	config.DefaultRole = "VIEWER"
+	assert.Equal(t, "VIEWER", config.RoleFor(nil))
+}
+
+func TestOIDCConfigIssuerURL(t *testing.T) {
+	for _, discoveryURL := range []string{
+		"https://keycloak.example.com/realms/signoz",
+		"https://keycloak.example.com/realms/signoz/",
+		"https://keycloak.example.com/realms/signoz/.well-known/openid-configuration",
+	} {
+		config := &OIDCConfig{DiscoveryURL: discoveryURL, ClientID: "signoz", ClientSecret: "secret"}
+		assert.NoError(t, config.Validate())
+		assert.Equal(t, "https://keycloak.example.com/realms/signoz", config.IssuerURL())
+	}
+	assert.Error(t, (&OIDCConfig{DiscoveryURL: "https://keycloak.example.com"}).Validate())
+}
+
+func TestOrgDomainRoleFor(t *testing.T) {
+	domain := &OrgDomain{
+		SsoType: GitHub,
+		GitHubConfig: &GitHubConfig{
+			ClientID: "signoz", ClientSecret: "secret",
+			RoleMapping: map[string]string{"acme/sre": "ADMIN", "acme": "VIEWER"},
+		},
+		AzureADConfig: &AzureADConfig{
+			TenantID: "tenant", ClientID: "signoz", ClientSecret: "secret",
+			RoleMapping: map[string]string{"6f1c0e5a": "EDITOR"}, DefaultRole: "VIEWER",
+		},
+	}
+	assert.NoError(t, domain.ValidConfig())
+	assert.Equal(t, "ADMIN", domain.RoleFor([]string{"acme", "acme/sre"}))
+	assert.Equal(t, "", domain.RoleFor([]string{"6f1c0e5a"}))
+	assert.ElementsMatch(t, []string{"ADMIN", "VIEWER", "EDITOR", "VIEWER"}, domain.MappedRoles())
+
+	domain.SsoType = AzureAD
+	assert.Equal(t, "EDITOR", domain.RoleFor([]string{"6f1c0e5a"}))
+	assert.Equal(t, "VIEWER", domain.RoleFor([]string{"acme/sre"}))
+
+	domain.AzureADConfig.TenantID = "common"
+	assert.Error(t, domain.ValidConfig())
+	domain.AzureADConfig = nil
+	assert.Error(t, domain.ValidConfig())
+}
+
+func TestGitHubConfigURLs(t *testing.T) {
+	webURL, apiURL := (&GitHubConfig{}).URLs()
+	assert.Equal(t, "https://github.com", webURL)
+	assert.Equal(t, "https://api.github.com", apiURL)
+
+	webURL, apiURL = (&GitHubConfig{EnterpriseURL: "https://github.example.com/"}).URLs()
+	assert.Equal(t, "https://github.example.com", webURL)
+	assert.Equal(t, "https://github.example.com/api/v3", apiURL)
+}
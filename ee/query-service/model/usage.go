@@ -32,3 +32,40 @@ type UsageDB struct {
 	Tenant      string    `ch:"tenant" json:"tenant"`
 	Data        string    `ch:"data" json:"data"`
 }
+
+// UsageBreakdownParams selects the usage to break down, the team is the value of the
+// TeamLabel resource attribute of the data
+type UsageBreakdownParams struct {
+	Start time.Time
+	End   time.Time
+	// Step is the size of the time buckets
+	Step      time.Duration
+	Signal    string
+	TeamLabel string
+}
+
+// UsageBreakdownItem is the volume ingested for a signal by a service of a team in a time
+// bucket. Count is the number of log records, spans or samples and Bytes the approximate
+// size of the log records, the size of the spans and samples is not recorded
+type UsageBreakdownItem struct {
+	Timestamp time.Time `ch:"ts" json:"timestamp"`
+	Signal    string    `ch:"-" json:"signal"`
+	Service   string    `ch:"service" json:"service"`
+	Team      string    `ch:"team" json:"team"`
+	Count     uint64    `ch:"count" json:"count"`
+	Bytes     uint64    `ch:"bytes" json:"bytes"`
+}
+
+// UsageTeamTotal is the volume ingested for a signal by a team over the whole range
+type UsageTeamTotal struct {
+	Team   string `json:"team"`
+	Signal string `json:"signal"`
+	Count  uint64 `json:"count"`
+	Bytes  uint64 `json:"bytes"`
+}
+
+type UsageBreakdown struct {
+	TeamLabel string               `json:"teamLabel"`
+	Items     []UsageBreakdownItem `json:"items"`
+	Teams     []UsageTeamTotal     `json:"teams"`
+}
@@ -0,0 +1,121 @@
+package sso
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// AzureADProvider logs users in with Azure AD (Microsoft Entra ID). The groups of the
+// users are the object ids of their groups, read from the groups claim of the ID token
+// or from Microsoft Graph when the user is in too many groups for the token
+type AzureADProvider struct {
+	OAuth2Config *oauth2.Config
+	Verifier     *oidc.IDTokenVerifier
+	// GraphURL is the Microsoft Graph API the groups overage is resolved with
+	GraphURL string
+	Cancel   context.CancelFunc
+}
+
+func (p *AzureADProvider) BuildAuthURL(state string) (string, error) {
+	return p.OAuth2Config.AuthCodeURL(state), nil
+}
+
+func (p *AzureADProvider) HandleCallback(r *http.Request) (identity *SSOIdentity, err error) {
+	q := r.URL.Query()
+	if errType := q.Get("error"); errType != "" {
+		return identity, &oauth2Error{errType, q.Get("error_description")}
+	}
+
+	token, err := p.OAuth2Config.Exchange(r.Context(), q.Get("code"))
+	if err != nil {
+		return identity, fmt.Errorf("azure: failed to get token: %v", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return identity, errors.New("azure: no id_token in token response")
+	}
+	idToken, err := p.Verifier.Verify(r.Context(), rawIDToken)
+	if err != nil {
+		return identity, fmt.Errorf("azure: failed to verify ID Token: %v", err)
+	}
+
+	var claims struct {
+		Username          string            `json:"name"`
+		PreferredUsername string            `json:"preferred_username"`
+		Email             string            `json:"email"`
+		Groups            []string          `json:"groups"`
+		ClaimNames        map[string]string `json:"_claim_names"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return identity, fmt.Errorf("azure: failed to decode claims: %v", err)
+	}
+
+	// the email claim is optional in azure, the user principal name is an email
+	email := claims.Email
+	if email == "" {
+		email = claims.PreferredUsername
+	}
+	if email == "" {
+		return identity, errors.New("azure: no email or preferred_username claim in ID token")
+	}
+
+	groups := claims.Groups
+	if _, overage := claims.ClaimNames["groups"]; overage {
+		groups, err = p.memberGroups(r.Context(), token)
+		if err != nil {
+			return identity, err
+		}
+	}
+	if groups == nil {
+		groups = []string{}
+	}
+
+	return &SSOIdentity{
+		UserID:            idToken.Subject,
+		Username:          claims.Username,
+		PreferredUsername: claims.PreferredUsername,
+		Email:             email,
+		EmailVerified:     true,
+		Groups:            groups,
+		ConnectorData:     []byte(token.RefreshToken),
+	}, nil
+}
+
+// memberGroups reads the groups of the user from Microsoft Graph, azure leaves the groups
+// out of the ID token when the user is in more than 200 groups
+func (p *AzureADProvider) memberGroups(ctx context.Context, token *oauth2.Token) ([]string, error) {
+	body, err := json.Marshal(map[string]bool{"securityEnabledOnly": false})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.GraphURL+"/me/getMemberGroups", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.OAuth2Config.Client(ctx, token).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("azure: failed to get the groups of the user: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("azure: failed to get the groups of the user: %s", resp.Status)
+	}
+
+	var result struct {
+		Value []string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("azure: failed to decode the groups of the user: %v", err)
+	}
+	return result.Value, nil
+}
@@ -0,0 +1,188 @@
+package sso
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// githubPageSize is the size of the pages of the organizations and the teams
+const githubPageSize = 100
+
+// GitHubProvider logs users in with GitHub OAuth apps. The groups of the users are their
+// organizations and their teams as org/team-slug, the users must be in one of the allowed
+// organizations when set
+type GitHubProvider struct {
+	OAuth2Config *oauth2.Config
+	// APIURL is the REST API of github.com or of the GitHub Enterprise server
+	APIURL string
+	// Domain is the domain of the email of the users, the verified email of the
+	// user in the domain is preferred to its primary email
+	Domain               string
+	AllowedOrganizations []string
+}
+
+func (p *GitHubProvider) BuildAuthURL(state string) (string, error) {
+	return p.OAuth2Config.AuthCodeURL(state), nil
+}
+
+func (p *GitHubProvider) HandleCallback(r *http.Request) (identity *SSOIdentity, err error) {
+	q := r.URL.Query()
+	if errType := q.Get("error"); errType != "" {
+		return identity, &oauth2Error{errType, q.Get("error_description")}
+	}
+
+	token, err := p.OAuth2Config.Exchange(r.Context(), q.Get("code"))
+	if err != nil {
+		return identity, fmt.Errorf("github: failed to get token: %v", err)
+	}
+	return p.createIdentity(r.Context(), p.OAuth2Config.Client(r.Context(), token))
+}
+
+type githubUser struct {
+	Id    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+type githubOrg struct {
+	Login string `json:"login"`
+}
+
+type githubTeam struct {
+	Slug         string    `json:"slug"`
+	Organization githubOrg `json:"organization"`
+}
+
+func (p *GitHubProvider) createIdentity(ctx context.Context, client *http.Client) (identity *SSOIdentity, err error) {
+	var user githubUser
+	if err := p.get(ctx, client, "/user", &user); err != nil {
+		return identity, err
+	}
+
+	var emails []githubEmail
+	if err := p.get(ctx, client, "/user/emails", &emails); err != nil {
+		return identity, err
+	}
+	email := p.email(emails)
+	if email == "" {
+		return identity, errors.New("github: the user has no verified email")
+	}
+
+	orgs, err := getPages[githubOrg](ctx, p, client, "/user/orgs")
+	if err != nil {
+		return identity, err
+	}
+	teams, err := getPages[githubTeam](ctx, p, client, "/user/teams")
+	if err != nil {
+		return identity, err
+	}
+
+	groups := githubGroups(orgs, teams)
+	if !p.allowed(orgs) {
+		return identity, fmt.Errorf("github: the user %s is in none of the allowed organizations", user.Login)
+	}
+
+	return &SSOIdentity{
+		UserID:            strconv.FormatInt(user.Id, 10),
+		Username:          user.Name,
+		PreferredUsername: user.Login,
+		Email:             email,
+		EmailVerified:     true,
+		Groups:            groups,
+	}, nil
+}
+
+// email returns the verified email of the user in the domain, or its verified primary email
+func (p *GitHubProvider) email(emails []githubEmail) string {
+	primary := ""
+	for _, e := range emails {
+		if !e.Verified {
+			continue
+		}
+		if p.Domain != "" && strings.HasSuffix(strings.ToLower(e.Email), "@"+strings.ToLower(p.Domain)) {
+			return e.Email
+		}
+		if e.Primary {
+			primary = e.Email
+		}
+	}
+	return primary
+}
+
+// allowed returns true when the user is in one of the allowed organizations, or when
+// no organization is required
+func (p *GitHubProvider) allowed(orgs []githubOrg) bool {
+	if len(p.AllowedOrganizations) == 0 {
+		return true
+	}
+	for _, org := range orgs {
+		for _, allowed := range p.AllowedOrganizations {
+			if strings.EqualFold(org.Login, allowed) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// githubGroups returns the groups of a user in the organizations and the teams, the
+// organizations by their login and the teams as org/team-slug
+func githubGroups(orgs []githubOrg, teams []githubTeam) []string {
+	groups := []string{}
+	for _, org := range orgs {
+		groups = append(groups, org.Login)
+	}
+	for _, team := range teams {
+		groups = append(groups, team.Organization.Login+"/"+team.Slug)
+	}
+	return groups
+}
+
+func (p *GitHubProvider) get(ctx context.Context, client *http.Client, path string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.APIURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("github: failed to get %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("github: failed to get %s: %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		return fmt.Errorf("github: failed to decode %s: %v", path, err)
+	}
+	return nil
+}
+
+// getPages gets the pages of the list until a page is not full
+func getPages[T any](ctx context.Context, p *GitHubProvider, client *http.Client, path string) ([]T, error) {
+	items := []T{}
+	for i := 1; ; i++ {
+		page := []T{}
+		if err := p.get(ctx, client, fmt.Sprintf("%s?per_page=%d&page=%d", path, githubPageSize, i), &page); err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+		if len(page) < githubPageSize {
+			return items, nil
+		}
+	}
+}
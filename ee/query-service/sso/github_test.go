@@ -0,0 +1,65 @@
+package sso
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubProviderCreateIdentity(t *testing.T) {
+	teams := []githubTeam{}
+	for i := 0; i < githubPageSize+1; i++ {
+		teams = append(teams, githubTeam{Slug: fmt.Sprintf("team-%d", i), Organization: githubOrg{Login: "acme"}})
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body interface{}
+		switch r.URL.Path {
+		case "/user":
+			body = githubUser{Id: 42, Login: "jdoe", Name: "J Doe"}
+		case "/user/emails":
+			body = []githubEmail{
+				{Email: "jdoe@users.noreply.github.com", Primary: true, Verified: true},
+				{Email: "jdoe@example.com", Verified: true},
+			}
+		case "/user/orgs":
+			body = []githubOrg{{Login: "acme"}}
+		case "/user/teams":
+			if r.URL.Query().Get("page") == "1" {
+				body = teams[:githubPageSize]
+			} else {
+				body = teams[githubPageSize:]
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	defer server.Close()
+
+	p := &GitHubProvider{APIURL: server.URL, Domain: "example.com", AllowedOrganizations: []string{"ACME"}}
+	identity, err := p.createIdentity(context.Background(), server.Client())
+	require.NoError(t, err)
+	assert.Equal(t, "42", identity.UserID)
+	assert.Equal(t, "jdoe", identity.PreferredUsername)
+	assert.Equal(t, "jdoe@example.com", identity.Email)
+	assert.Len(t, identity.Groups, githubPageSize+2)
+	assert.Equal(t, "acme", identity.Groups[0])
+	assert.Equal(t, "acme/team-100", identity.Groups[githubPageSize+1])
+
+	// the primary email is used outside of the domain
+	p.Domain = "other.com"
+	identity, err = p.createIdentity(context.Background(), server.Client())
+	require.NoError(t, err)
+	assert.Equal(t, "jdoe@users.noreply.github.com", identity.Email)
+
+	p.AllowedOrganizations = []string{"globex"}
+	_, err = p.createIdentity(context.Background(), server.Client())
+	assert.Error(t, err)
+}
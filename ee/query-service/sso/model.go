@@ -11,6 +11,8 @@ type SSOIdentity struct {
 	PreferredUsername string
 	Email             string
 	EmailVerified     bool
+	// Groups are the groups of the user, sent by the providers supporting it
+	Groups        []string
 	ConnectorData []byte
 }
 
@@ -0,0 +1,120 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCProvider logs users in with a generic OpenID Connect provider
+// (e.g. Keycloak, Okta, Auth0) using the authorization code flow
+type OIDCProvider struct {
+	OAuth2Config *oauth2.Config
+	Verifier     *oidc.IDTokenVerifier
+	// GroupsClaim is the claim of the ID token listing the groups of the user
+	GroupsClaim string
+	// AllowUnverifiedEmail accepts the ID tokens whose email is not verified, they are
+	// rejected by default so a user can't log in as the owner of an email they set
+	AllowUnverifiedEmail bool
+	Cancel               context.CancelFunc
+}
+
+func (p *OIDCProvider) BuildAuthURL(state string) (string, error) {
+	return p.OAuth2Config.AuthCodeURL(state), nil
+}
+
+func (p *OIDCProvider) HandleCallback(r *http.Request) (identity *SSOIdentity, err error) {
+	q := r.URL.Query()
+	if errType := q.Get("error"); errType != "" {
+		return identity, &oauth2Error{errType, q.Get("error_description")}
+	}
+
+	token, err := p.OAuth2Config.Exchange(r.Context(), q.Get("code"))
+	if err != nil {
+		return identity, fmt.Errorf("oidc: failed to get token: %v", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return identity, errors.New("oidc: no id_token in token response")
+	}
+	return p.createIdentity(r.Context(), rawIDToken, token.RefreshToken)
+}
+
+// Refresh exchanges the refresh token of the user with the provider, it fails once the
+// session of the user is revoked. The identity is refreshed from the new ID token,
+// only its refresh token is set when the provider does not send one
+func (p *OIDCProvider) Refresh(ctx context.Context, refreshToken string) (identity *SSOIdentity, err error) {
+	token, err := p.OAuth2Config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		return identity, fmt.Errorf("oidc: failed to refresh token: %v", err)
+	}
+
+	// the providers may not rotate the refresh token
+	if token.RefreshToken != "" {
+		refreshToken = token.RefreshToken
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return &SSOIdentity{ConnectorData: []byte(refreshToken)}, nil
+	}
+	return p.createIdentity(ctx, rawIDToken, refreshToken)
+}
+
+func (p *OIDCProvider) createIdentity(ctx context.Context, rawIDToken, refreshToken string) (identity *SSOIdentity, err error) {
+	idToken, err := p.Verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return identity, fmt.Errorf("oidc: failed to verify ID Token: %v", err)
+	}
+
+	var claims struct {
+		Username          string `json:"name"`
+		PreferredUsername string `json:"preferred_username"`
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return identity, fmt.Errorf("oidc: failed to decode claims: %v", err)
+	}
+	if claims.Email == "" {
+		return identity, errors.New("oidc: no email claim in ID token")
+	}
+	if !claims.EmailVerified && !p.AllowUnverifiedEmail {
+		return identity, fmt.Errorf("oidc: the email %s is not verified by the provider", claims.Email)
+	}
+
+	var rawClaims map[string]interface{}
+	if err := idToken.Claims(&rawClaims); err != nil {
+		return identity, fmt.Errorf("oidc: failed to decode claims: %v", err)
+	}
+
+	return &SSOIdentity{
+		UserID:            idToken.Subject,
+		Username:          claims.Username,
+		PreferredUsername: claims.PreferredUsername,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		Groups:            groupsClaim(rawClaims[p.GroupsClaim]),
+		ConnectorData:     []byte(refreshToken),
+	}, nil
+}
+
+// groupsClaim reads the groups from a claim, the providers send either a list or a single group
+func groupsClaim(claim interface{}) []string {
+	groups := []string{}
+	switch v := claim.(type) {
+	case string:
+		groups = append(groups, v)
+	case []interface{}:
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	}
+	return groups
+}
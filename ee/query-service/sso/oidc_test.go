@@ -0,0 +1,55 @@
+package sso
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idToken returns an unsigned ID token with the claims, the signatures are not checked by the
+// verifier of the tests
+func idToken(t *testing.T, claims map[string]interface{}) string {
+	claims["iss"] = "https://idp.example.com"
+	claims["aud"] = "signoz"
+	claims["sub"] = "jdoe"
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(header) + "." + enc.EncodeToString(payload) + "." + enc.EncodeToString([]byte("signature"))
+}
+
+func TestOIDCProviderCreateIdentity(t *testing.T) {
+	p := &OIDCProvider{
+		Verifier: oidc.NewVerifier("https://idp.example.com", &oidc.StaticKeySet{}, &oidc.Config{
+			ClientID:                   "signoz",
+			InsecureSkipSignatureCheck: true,
+		}),
+		GroupsClaim: "groups",
+	}
+	ctx := context.Background()
+
+	identity, err := p.createIdentity(ctx, idToken(t, map[string]interface{}{
+		"email": "jdoe@example.com", "email_verified": true, "groups": []string{"admins"},
+	}), "refresh")
+	require.NoError(t, err)
+	assert.Equal(t, "jdoe@example.com", identity.Email)
+	assert.Equal(t, []string{"admins"}, identity.Groups)
+
+	// the unverified emails are rejected unless the domain allows them
+	unverified := idToken(t, map[string]interface{}{"email": "jdoe@example.com"})
+	_, err = p.createIdentity(ctx, unverified, "refresh")
+	assert.ErrorContains(t, err, "not verified")
+	p.AllowUnverifiedEmail = true
+	identity, err = p.createIdentity(ctx, unverified, "refresh")
+	require.NoError(t, err)
+	assert.False(t, identity.EmailVerified)
+}
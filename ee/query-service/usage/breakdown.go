@@ -0,0 +1,157 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.signoz.io/signoz/ee/query-service/model"
+	basemodel "go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	// DefaultTeamLabel is the resource attribute the usage is attributed to a team with
+	DefaultTeamLabel = "team"
+	// maxBreakdownBuckets limits the number of time buckets of a breakdown
+	maxBreakdownBuckets = 1000
+)
+
+// the breakdown queries take the bucket size in seconds, the team label, then the start and
+// the end in nanoseconds, twice for the metrics
+var breakdownQueries = map[string]string{
+	basemodel.IngestionSignalLogs: `
+		SELECT toStartOfInterval(fromUnixTimestamp64Nano(timestamp), INTERVAL ? SECOND) AS ts,
+			resources_string_value[indexOf(resources_string_key, 'service.name')] AS service,
+			resources_string_value[indexOf(resources_string_key, ?)] AS team,
+			count() AS count,
+			sum(length(body) + arraySum(arrayMap(v -> length(v), attributes_string_value))
+				+ arraySum(arrayMap(v -> length(v), resources_string_value))) AS bytes
+		FROM signoz_logs.distributed_logs
+		WHERE timestamp >= ? AND timestamp < ?
+		GROUP BY ts, service, team
+		ORDER BY ts`,
+	basemodel.IngestionSignalTraces: `
+		SELECT toStartOfInterval(timestamp, INTERVAL ? SECOND) AS ts,
+			serviceName AS service,
+			resourceTagsMap[?] AS team,
+			count() AS count,
+			toUInt64(0) AS bytes
+		FROM signoz_traces.distributed_signoz_index_v2
+		WHERE timestamp >= fromUnixTimestamp64Nano(?) AND timestamp < fromUnixTimestamp64Nano(?)
+		GROUP BY ts, service, team
+		ORDER BY ts`,
+	// the time series are bucketed by hour, the samples are matched with the series of their hour
+	basemodel.IngestionSignalMetrics: `
+		SELECT toStartOfInterval(toDateTime(intDiv(s.unix_milli, 1000)), INTERVAL ? SECOND) AS ts,
+			t.service AS service,
+			t.team AS team,
+			count() AS count,
+			toUInt64(0) AS bytes
+		FROM signoz_metrics.distributed_samples_v4 AS s
+		GLOBAL INNER JOIN (
+			SELECT DISTINCT fingerprint,
+				JSONExtractString(labels, 'service_name') AS service,
+				JSONExtractString(labels, ?) AS team
+			FROM signoz_metrics.distributed_time_series_v4
+			WHERE unix_milli >= intDiv(?, 3600000000000) * 3600000 AND unix_milli < intDiv(?, 1000000)
+		) AS t ON s.fingerprint = t.fingerprint
+		WHERE s.unix_milli >= intDiv(?, 1000000) AND s.unix_milli < intDiv(?, 1000000)
+		GROUP BY ts, service, team
+		ORDER BY ts`,
+}
+
+func validateBreakdownParams(params *model.UsageBreakdownParams) error {
+	if !params.End.After(params.Start) {
+		return fmt.Errorf("end must be after start")
+	}
+	if params.Step < time.Minute {
+		return fmt.Errorf("step must be at least a minute")
+	}
+	if params.End.Sub(params.Start)/params.Step > maxBreakdownBuckets {
+		return fmt.Errorf("the range can not have more than %d steps", maxBreakdownBuckets)
+	}
+	if params.Signal != "" {
+		if _, ok := breakdownQueries[params.Signal]; !ok {
+			return fmt.Errorf("invalid signal %q, expected one of logs, metrics and traces", params.Signal)
+		}
+	}
+	if params.TeamLabel == "" {
+		params.TeamLabel = DefaultTeamLabel
+	}
+	return nil
+}
+
+// Breakdown returns the volume ingested by service and team over time for the signal, or
+// for all the signals when not set, so that the teams can be charged for their usage
+func (lm *Manager) Breakdown(ctx context.Context, params *model.UsageBreakdownParams) (*model.UsageBreakdown, *basemodel.ApiError) {
+	if err := validateBreakdownParams(params); err != nil {
+		return nil, basemodel.BadRequest(err)
+	}
+
+	signals := []string{basemodel.IngestionSignalLogs, basemodel.IngestionSignalMetrics, basemodel.IngestionSignalTraces}
+	if params.Signal != "" {
+		signals = []string{params.Signal}
+	}
+
+	step := int64(params.Step / time.Second)
+	start, end := params.Start.UnixNano(), params.End.UnixNano()
+	items := []model.UsageBreakdownItem{}
+	for _, signal := range signals {
+		args := []interface{}{step, params.TeamLabel, start, end}
+		if signal == basemodel.IngestionSignalMetrics {
+			// the metrics labels have underscores instead of dots
+			args = []interface{}{step, strings.ReplaceAll(params.TeamLabel, ".", "_"), start, end, start, end}
+		}
+
+		signalItems := []model.UsageBreakdownItem{}
+		err := lm.clickhouseConn.Select(ctx, &signalItems, breakdownQueries[signal], args...)
+		if err != nil && !strings.Contains(err.Error(), "doesn't exist") {
+			return nil, &basemodel.ApiError{Typ: basemodel.ErrorExec, Err: fmt.Errorf("failed to get the %s usage: %v", signal, err)}
+		}
+		for _, item := range signalItems {
+			item.Signal = signal
+			items = append(items, item)
+		}
+	}
+
+	return &model.UsageBreakdown{
+		TeamLabel: params.TeamLabel,
+		Items:     items,
+		Teams:     teamTotals(items),
+	}, nil
+}
+
+// teamTotals sums the usage of the teams by signal, the largest first
+func teamTotals(items []model.UsageBreakdownItem) []model.UsageTeamTotal {
+	totals := map[[2]string]*model.UsageTeamTotal{}
+	for _, item := range items {
+		key := [2]string{item.Team, item.Signal}
+		total, ok := totals[key]
+		if !ok {
+			total = &model.UsageTeamTotal{Team: item.Team, Signal: item.Signal}
+			totals[key] = total
+		}
+		total.Count += item.Count
+		total.Bytes += item.Bytes
+	}
+
+	teams := make([]model.UsageTeamTotal, 0, len(totals))
+	for _, total := range totals {
+		teams = append(teams, *total)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		if teams[i].Signal != teams[j].Signal {
+			return teams[i].Signal < teams[j].Signal
+		}
+		if teams[i].Bytes != teams[j].Bytes {
+			return teams[i].Bytes > teams[j].Bytes
+		}
+		if teams[i].Count != teams[j].Count {
+			return teams[i].Count > teams[j].Count
+		}
+		return teams[i].Team < teams[j].Team
+	})
+	return teams
+}
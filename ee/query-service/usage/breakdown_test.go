@@ -0,0 +1,38 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/ee/query-service/model"
+)
+
+func TestValidateBreakdownParams(t *testing.T) {
+	end := time.Now()
+	params := &model.UsageBreakdownParams{Start: end.AddDate(0, 0, -7), End: end, Step: 24 * time.Hour}
+	require.NoError(t, validateBreakdownParams(params))
+	assert.Equal(t, DefaultTeamLabel, params.TeamLabel)
+
+	assert.Error(t, validateBreakdownParams(&model.UsageBreakdownParams{Start: end, End: end, Step: time.Hour}))
+	assert.Error(t, validateBreakdownParams(&model.UsageBreakdownParams{Start: end.AddDate(0, 0, -1), End: end, Step: time.Second}))
+	assert.Error(t, validateBreakdownParams(&model.UsageBreakdownParams{Start: end.AddDate(0, 0, -30), End: end, Step: time.Minute}))
+	assert.Error(t, validateBreakdownParams(&model.UsageBreakdownParams{Start: end.AddDate(0, 0, -1), End: end, Step: time.Hour, Signal: "profiles"}))
+}
+
+func TestTeamTotals(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	items := []model.UsageBreakdownItem{
+		{Timestamp: day, Signal: "logs", Service: "api", Team: "payments", Count: 10, Bytes: 1000},
+		{Timestamp: day, Signal: "logs", Service: "web", Team: "frontend", Count: 5, Bytes: 2000},
+		{Timestamp: day.AddDate(0, 0, 1), Signal: "logs", Service: "worker", Team: "payments", Count: 10, Bytes: 1500},
+		{Timestamp: day, Signal: "traces", Service: "api", Team: "payments", Count: 42},
+	}
+
+	assert.Equal(t, []model.UsageTeamTotal{
+		{Team: "payments", Signal: "logs", Count: 20, Bytes: 2500},
+		{Team: "frontend", Signal: "logs", Count: 5, Bytes: 2000},
+		{Team: "payments", Signal: "traces", Count: 42},
+	}, teamTotals(items))
+}
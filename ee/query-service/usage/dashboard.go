@@ -0,0 +1,18 @@
+package usage
+
+import (
+	"context"
+	_ "embed"
+
+	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	basemodel "go.signoz.io/signoz/pkg/query-service/model"
+)
+
+//go:embed dashboards/usage.json
+var usageDashboard []byte
+
+// ProvisionDashboard creates, or updates, the built-in dashboard of the usage by team and service
+func ProvisionDashboard(ctx context.Context, fm interfaces.FeatureLookup) *basemodel.ApiError {
+	return dashboards.ProvisionBuiltIn(ctx, "usage.json", usageDashboard, fm)
+}
@@ -0,0 +1,20 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// Login logs the user in with the email and the password and authenticates the next requests
+// of the client with the access token of the user. The TOTP code is only required for the users
+// enrolled in two-factor authentication
+func (c *Client) Login(ctx context.Context, req model.LoginRequest) (*model.LoginResponse, error) {
+	var resp model.LoginResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/login", body: req, raw: true}, &resp); err != nil {
+		return nil, err
+	}
+	c.token = resp.AccessJwt
+	return &resp, nil
+}
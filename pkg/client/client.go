@@ -0,0 +1,246 @@
+// Package client is the Go client of the SigNoz API. It authenticates the requests, retries
+// the requests which failed before being processed and pages through the list queries. The
+// requests and the responses are the types of the query service, the client follows the API
+// as it changes in the repository
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// APIKeyHeader is the header of the personal access tokens
+	APIKeyHeader = "SIGNOZ-API-KEY"
+
+	defaultMaxRetries = 3
+	defaultRetryWait  = 500 * time.Millisecond
+	maxRetryWait      = 10 * time.Second
+)
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("signoz: status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("signoz: status %d: %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// IsNotFound checks if the error is the response of a missing resource
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// response is the envelope of the responses of the API, a few routes respond with the errors
+// of the structured responses
+type response struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType"`
+	Error     string          `json:"error"`
+	Errors    []struct {
+		Msg string `json:"msg"`
+	} `json:"errors"`
+}
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	apiKey     string
+	maxRetries int
+	retryWait  time.Duration
+}
+
+type Option func(*Client)
+
+// WithToken authenticates the requests with the access token of a user, as returned by Login
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithAPIKey authenticates the requests with a personal access token
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets the number of retries of the failed requests and the wait before the
+// first retry, the wait doubles with each retry
+func WithRetries(maxRetries int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryWait = wait
+	}
+}
+
+// New returns a client of the SigNoz API at the URL, e.g. https://signoz.example.com
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		maxRetries: defaultMaxRetries,
+		retryWait:  defaultRetryWait,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// retryable checks if the request can be retried after the status, the requests rejected
+// before being processed can always be retried, the others only when they are idempotent
+func retryable(status int, idempotent bool) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// retryAfter returns the wait of the Retry-After header, in seconds, or the default wait
+func retryAfter(resp *http.Response, wait time.Duration) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait > maxRetryWait {
+		return maxRetryWait
+	}
+	return wait
+}
+
+// request is a request of the API, the GET, PUT and DELETE requests are idempotent
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+	// idempotent is set for the POST requests which can be retried, e.g. the queries
+	idempotent bool
+	// raw is set for the responses which are not in the envelope of the API
+	raw bool
+}
+
+// do sends the request and decodes the data of the response into result, when not nil
+func (c *Client) do(ctx context.Context, r request, result interface{}) error {
+	var encoded []byte
+	if r.body != nil {
+		var err error
+		if encoded, err = json.Marshal(r.body); err != nil {
+			return fmt.Errorf("signoz: failed to encode the request: %w", err)
+		}
+	}
+	u := c.baseURL + r.path
+	if len(r.query) > 0 {
+		u += "?" + r.query.Encode()
+	}
+	idempotent := r.idempotent || r.method == http.MethodGet || r.method == http.MethodPut || r.method == http.MethodDelete
+
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, r.method, u, bytes.NewReader(encoded))
+		if err != nil {
+			return fmt.Errorf("signoz: %w", err)
+		}
+		if r.body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		if c.apiKey != "" {
+			req.Header.Set(APIKeyHeader, c.apiKey)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		retry := attempt < c.maxRetries
+		if err != nil {
+			if !retry || !idempotent || ctx.Err() != nil {
+				return fmt.Errorf("signoz: %s %s: %w", r.method, r.path, err)
+			}
+		} else {
+			if !retry || !retryable(resp.StatusCode, idempotent) {
+				defer resp.Body.Close()
+				return decodeResponse(resp, result, r.raw)
+			}
+			wait = retryAfter(resp, wait)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("signoz: %s %s: %w", r.method, r.path, ctx.Err())
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > maxRetryWait {
+			wait = maxRetryWait
+		}
+	}
+}
+
+// decodeResponse decodes the data of the response, or the whole response when raw is set.
+// The errors are in the envelope of the API for all the routes
+func decodeResponse(resp *http.Response, result interface{}, raw bool) error {
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("signoz: failed to read the response: %w", err)
+	}
+	if raw && resp.StatusCode < http.StatusBadRequest {
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(b, result); err != nil {
+			return fmt.Errorf("signoz: failed to decode the response: %w", err)
+		}
+		return nil
+	}
+	var envelope response
+	if err := json.Unmarshal(b, &envelope); err != nil {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(b))}
+		}
+		return fmt.Errorf("signoz: failed to decode the response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest || envelope.Status == "error" {
+		message := envelope.Error
+		if message == "" && len(envelope.Errors) > 0 {
+			message = envelope.Errors[0].Msg
+		}
+		return &Error{StatusCode: resp.StatusCode, Type: envelope.ErrorType, Message: message}
+	}
+	if result == nil || len(envelope.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Data, result); err != nil {
+		return fmt.Errorf("signoz: failed to decode the data of the response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func respond(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestRetries(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		method   string
+		status   int
+		attempts int
+	}{
+		{"unavailable", http.MethodPost, http.StatusServiceUnavailable, 3},
+		{"bad gateway of an idempotent request", http.MethodDelete, http.StatusBadGateway, 3},
+		{"bad gateway of a request which is not idempotent", http.MethodPost, http.StatusBadGateway, 1},
+		{"bad request", http.MethodGet, http.StatusBadRequest, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				respond(w, tc.status, map[string]string{"status": "error", "errorType": "bad_data", "error": "failed"})
+			}))
+			defer server.Close()
+
+			c := New(server.URL, WithRetries(2, time.Millisecond))
+			err := c.do(context.Background(), request{method: tc.method, path: "/api/v1/rules/1"}, nil)
+			var apiErr *Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tc.status, apiErr.StatusCode)
+			assert.Equal(t, "failed", apiErr.Message)
+			assert.Equal(t, tc.attempts, attempts)
+		})
+	}
+}
+
+func TestRetrySucceeds(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "key", r.Header.Get(APIKeyHeader))
+		if attempts++; attempts == 1 {
+			respond(w, http.StatusTooManyRequests, map[string]string{"status": "error", "error": "rate limited"})
+			return
+		}
+		respond(w, http.StatusOK, map[string]interface{}{"status": "success", "data": map[string]interface{}{"rules": []map[string]string{{"id": "1"}}}})
+	}))
+	defer server.Close()
+
+	c := New(server.URL+"/", WithToken("token"), WithAPIKey("key"), WithRetries(1, time.Millisecond))
+	rules, err := c.ListRules(context.Background())
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "1", rules[0].Id)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/dashboards/missing":
+			respond(w, http.StatusNotFound, map[string]string{"status": "error", "errorType": "not_found", "error": "no dashboard found with uuid: missing"})
+		case "/api/v1/login":
+			respond(w, http.StatusUnauthorized, map[string]interface{}{"errors": []map[string]interface{}{{"code": 401, "msg": "invalid credentials"}}})
+		default:
+			http.Error(w, "404 page not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	_, err := c.GetDashboard(context.Background(), "missing")
+	assert.True(t, IsNotFound(err))
+	assert.EqualError(t, err, "signoz: status 404: not_found: no dashboard found with uuid: missing")
+
+	_, err = c.Login(context.Background(), model.LoginRequest{Email: "admin@signoz.io", Password: "password"})
+	assert.EqualError(t, err, "signoz: status 401: invalid credentials")
+
+	_, err = c.GetRule(context.Background(), "1")
+	assert.EqualError(t, err, "signoz: status 404: 404 page not found")
+}
+
+func TestLogin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/login" {
+			var req model.LoginRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "admin@signoz.io", req.Email)
+			// the response of the login is not in the envelope
+			respond(w, http.StatusOK, model.LoginResponse{UserJwtObject: model.UserJwtObject{AccessJwt: "jwt"}, UserId: "user"})
+			return
+		}
+		assert.Equal(t, "Bearer jwt", r.Header.Get("Authorization"))
+		respond(w, http.StatusOK, map[string]interface{}{"status": "success", "data": nil})
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	resp, err := c.Login(context.Background(), model.LoginRequest{Email: "admin@signoz.io", Password: "password"})
+	require.NoError(t, err)
+	assert.Equal(t, "user", resp.UserId)
+	require.NoError(t, c.DeleteRule(context.Background(), "1"))
+}
+
+func TestListPages(t *testing.T) {
+	var offsets []uint64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params v3.QueryRangeParamsV3
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&params))
+		query := params.CompositeQuery.BuilderQueries["A"]
+		offsets = append(offsets, query.Offset)
+		// 5 rows, the pages are cut at the limit of the query as by the query service
+		count := query.PageSize
+		if query.Offset+count > 5 {
+			count = 5 - query.Offset
+		}
+		if query.Limit > 0 && query.Offset+count > query.Limit {
+			count = query.Limit - query.Offset
+		}
+		rows := make([]*v3.Row, count)
+		for i := range rows {
+			rows[i] = &v3.Row{Data: map[string]interface{}{"i": float64(query.Offset) + float64(i)}}
+		}
+		respond(w, http.StatusOK, map[string]interface{}{"status": "success", "data": v3.QueryRangeResponse{Result: []*v3.Result{{QueryName: "A", List: rows}}}})
+	}))
+	defer server.Close()
+	c := New(server.URL)
+
+	for _, tc := range []struct {
+		limit   uint64
+		offsets []uint64
+		rows    int
+	}{
+		{0, []uint64{0, 2, 4}, 5},
+		{3, []uint64{0, 2}, 3},
+		{4, []uint64{0, 2}, 4},
+	} {
+		offsets = nil
+		query := &v3.BuilderQuery{QueryName: "A", DataSource: v3.DataSourceLogs, PageSize: 2, Limit: tc.limit}
+		params := &v3.QueryRangeParamsV3{CompositeQuery: &v3.CompositeQuery{
+			PanelType:      v3.PanelTypeList,
+			QueryType:      v3.QueryTypeBuilder,
+			BuilderQueries: map[string]*v3.BuilderQuery{"A": query},
+		}}
+		var rows []*v3.Row
+		require.NoError(t, c.ListPages(context.Background(), params, "A", func(page []*v3.Row) error {
+			rows = append(rows, page...)
+			return nil
+		}))
+		assert.Equal(t, tc.offsets, offsets)
+		assert.Len(t, rows, tc.rows)
+		assert.Equal(t, uint64(0), query.Offset)
+	}
+
+	err := c.ListPages(context.Background(), &v3.QueryRangeParamsV3{CompositeQuery: &v3.CompositeQuery{
+		PanelType:      v3.PanelTypeList,
+		BuilderQueries: map[string]*v3.BuilderQuery{"A": {QueryName: "A"}},
+	}}, "A", nil)
+	assert.EqualError(t, err, "signoz: the builder query A has no page size")
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+)
+
+// ListDashboards lists the dashboards the user can view, with any of the tags when set
+func (c *Client) ListDashboards(ctx context.Context, tags ...string) ([]dashboards.Dashboard, error) {
+	var result []dashboards.Dashboard
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/dashboards", query: url.Values{"tags": tags}}, &result)
+	return result, err
+}
+
+func (c *Client) GetDashboard(ctx context.Context, uuid string) (*dashboards.Dashboard, error) {
+	var result dashboards.Dashboard
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/dashboards/" + url.PathEscape(uuid)}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateDashboard creates a dashboard with the data, e.g. the title, the widgets and the layout
+func (c *Client) CreateDashboard(ctx context.Context, data dashboards.Data) (*dashboards.Dashboard, error) {
+	var result dashboards.Dashboard
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/dashboards", body: data}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UpdateDashboard replaces the data of the dashboard
+func (c *Client) UpdateDashboard(ctx context.Context, uuid string, data dashboards.Data) (*dashboards.Dashboard, error) {
+	var result dashboards.Dashboard
+	if err := c.do(ctx, request{method: http.MethodPut, path: "/api/v1/dashboards/" + url.PathEscape(uuid), body: data}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) DeleteDashboard(ctx context.Context, uuid string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/api/v1/dashboards/" + url.PathEscape(uuid)}, nil)
+}
@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
+)
+
+// GetPipelines returns the logs pipelines of the version of the agent config, or of the latest
+// version when the version is negative, with the history of the versions
+func (c *Client) GetPipelines(ctx context.Context, version int) (*logparsingpipeline.PipelinesResponse, error) {
+	v := "latest"
+	if version >= 0 {
+		v = strconv.Itoa(version)
+	}
+	var result logparsingpipeline.PipelinesResponse
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/logs/pipelines/" + v}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SavePipelines replaces the logs pipelines, it creates a new version of the agent config
+func (c *Client) SavePipelines(ctx context.Context, pipelines logparsingpipeline.PostablePipelines) (*logparsingpipeline.PipelinesResponse, error) {
+	var result logparsingpipeline.PipelinesResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/logs/pipelines", body: pipelines}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// QueryRange runs the queries of the v3 query range API. The queries do not change data, they
+// are retried as the GET requests
+func (c *Client) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3) (*v3.QueryRangeResponse, error) {
+	return c.queryRange(ctx, "/api/v3/query_range", params)
+}
+
+// QueryRangeV4 runs the queries of the v4 query range API, the metrics queries use the
+// temporality of the metrics
+func (c *Client) QueryRangeV4(ctx context.Context, params *v3.QueryRangeParamsV3) (*v3.QueryRangeResponse, error) {
+	return c.queryRange(ctx, "/api/v4/query_range", params)
+}
+
+func (c *Client) queryRange(ctx context.Context, path string, params *v3.QueryRangeParamsV3) (*v3.QueryRangeResponse, error) {
+	var result v3.QueryRangeResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, body: params, idempotent: true}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListPages pages through the rows of the list query of the builder query of the name, e.g.
+// the logs of a logs query. The query must have a page size, the pages start at the offset of
+// the query and end with its limit when set. fn is called with the rows of each page until
+// the last page or an error of fn. The params are not modified
+func (c *Client) ListPages(ctx context.Context, params *v3.QueryRangeParamsV3, queryName string, fn func(rows []*v3.Row) error) error {
+	if params.CompositeQuery == nil || params.CompositeQuery.PanelType != v3.PanelTypeList {
+		return errors.New("signoz: the pages are only listed for the list queries")
+	}
+	query, ok := params.CompositeQuery.BuilderQueries[queryName]
+	if !ok {
+		return fmt.Errorf("signoz: the builder query %s is missing", queryName)
+	}
+	if query.PageSize == 0 {
+		return fmt.Errorf("signoz: the builder query %s has no page size", queryName)
+	}
+
+	// the page is a copy of the params with only the query
+	page := *params
+	composite := *params.CompositeQuery
+	pageQuery := *query
+	composite.BuilderQueries = map[string]*v3.BuilderQuery{queryName: &pageQuery}
+	page.CompositeQuery = &composite
+
+	for query.Limit == 0 || pageQuery.Offset < query.Limit {
+		resp, err := c.QueryRange(ctx, &page)
+		if err != nil {
+			return err
+		}
+		var rows []*v3.Row
+		for _, result := range resp.Result {
+			if result.QueryName == queryName {
+				rows = result.List
+			}
+		}
+		if len(rows) > 0 {
+			if err := fn(rows); err != nil {
+				return err
+			}
+		}
+		if uint64(len(rows)) < pageQuery.PageSize {
+			return nil
+		}
+		pageQuery.Offset += uint64(len(rows))
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"go.signoz.io/signoz/pkg/query-service/rules"
+)
+
+// ListRules lists the alert rules with their state
+func (c *Client) ListRules(ctx context.Context) ([]*rules.GettableRule, error) {
+	var result rules.GettableRules
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/rules"}, &result); err != nil {
+		return nil, err
+	}
+	return result.Rules, nil
+}
+
+func (c *Client) GetRule(ctx context.Context, id string) (*rules.GettableRule, error) {
+	var result rules.GettableRule
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/rules/" + url.PathEscape(id)}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) CreateRule(ctx context.Context, rule rules.PostableRule) (*rules.GettableRule, error) {
+	var result rules.GettableRule
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/rules", body: rule}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UpdateRule replaces the rule, the API does not return the rule updated
+func (c *Client) UpdateRule(ctx context.Context, id string, rule rules.PostableRule) error {
+	return c.do(ctx, request{method: http.MethodPut, path: "/api/v1/rules/" + url.PathEscape(id), body: rule}, nil)
+}
+
+func (c *Client) DeleteRule(ctx context.Context, id string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/api/v1/rules/" + url.PathEscape(id)}, nil)
+}
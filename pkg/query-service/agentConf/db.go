@@ -144,8 +144,9 @@ func (r *Repo) insertConfig(
 		))
 	}
 
-	// allowing empty elements for logs - use case is deleting all pipelines
-	if len(elements) == 0 && c.ElementType != ElementTypeLogPipelines {
+	// allowing empty elements for logs, sampling rules, cardinality limits and synthetic checks - use case is deleting all of them
+	if len(elements) == 0 && c.ElementType != ElementTypeLogPipelines && c.ElementType != ElementTypeSamplingRules &&
+		c.ElementType != ElementTypeCardinalityLimits && c.ElementType != ElementTypeSyntheticChecks {
 		zap.L().Error("insert config called with no elements ", zap.String("ElementType", string(c.ElementType)))
 		return model.BadRequest(fmt.Errorf("config must have atleast one element"))
 	}
@@ -13,6 +13,9 @@ const (
 	ElementTypeDropRules     ElementTypeDef = "drop_rules"
 	ElementTypeLogPipelines  ElementTypeDef = "log_pipelines"
 	ElementTypeLbExporter    ElementTypeDef = "lb_exporter"
+
+	ElementTypeCardinalityLimits ElementTypeDef = "cardinality_limits"
+	ElementTypeSyntheticChecks   ElementTypeDef = "synthetic_checks"
 )
 
 type DeployStatus string
@@ -0,0 +1,231 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// The resources the access lists apply to
+const (
+	ACLResourceDashboard = "dashboard"
+	ACLResourceRule      = "rule"
+)
+
+// The principals of the access list entries, the users are identified by their email
+const (
+	ACLPrincipalUser = "user"
+	ACLPrincipalTeam = "team"
+)
+
+// The access levels of the entries, editors can also view the resource
+const (
+	ACLLevelViewer = "viewer"
+	ACLLevelEditor = "editor"
+)
+
+// ACL restricts a dashboard or an alert to its owner and the users and teams of its
+// entries, the resources without an access list are accessible according to the roles
+type ACL struct {
+	ResourceType string     `json:"resourceType" db:"resource_type"`
+	ResourceId   string     `json:"resourceId" db:"resource_id"`
+	Owner        string     `json:"owner" db:"owner"`
+	Entries      []ACLEntry `json:"entries" db:"-"`
+	UpdatedAt    time.Time  `json:"updatedAt" db:"updated_at"`
+	UpdatedBy    string     `json:"updatedBy" db:"updated_by"`
+}
+
+type ACLEntry struct {
+	PrincipalType string `json:"principalType" db:"principal_type"`
+	Principal     string `json:"principal" db:"principal"`
+	Level         string `json:"level" db:"level"`
+}
+
+func (a *ACL) validate(ctx context.Context) *model.ApiError {
+	if a.ResourceType != ACLResourceDashboard && a.ResourceType != ACLResourceRule {
+		return model.BadRequest(fmt.Errorf("invalid resource type %q", a.ResourceType))
+	}
+	if a.ResourceId == "" {
+		return model.BadRequest(fmt.Errorf("resource id is required"))
+	}
+	seen := map[ACLEntry]bool{}
+	for _, entry := range a.Entries {
+		if entry.Principal == "" {
+			return model.BadRequest(fmt.Errorf("principal of the access list entry is required"))
+		}
+		if entry.Level != ACLLevelViewer && entry.Level != ACLLevelEditor {
+			return model.BadRequest(fmt.Errorf("invalid access level %q, must be one of viewer or editor", entry.Level))
+		}
+		switch entry.PrincipalType {
+		case ACLPrincipalUser:
+		case ACLPrincipalTeam:
+			if _, apiErr := GetTeam(ctx, entry.Principal); apiErr != nil {
+				return model.BadRequest(apiErr.Err)
+			}
+		default:
+			return model.BadRequest(fmt.Errorf("invalid principal type %q, must be one of user or team", entry.PrincipalType))
+		}
+		key := ACLEntry{PrincipalType: entry.PrincipalType, Principal: entry.Principal}
+		if seen[key] {
+			return model.BadRequest(fmt.Errorf("%s %s is listed more than once", entry.PrincipalType, entry.Principal))
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// IsOwner checks if the user with the email owns the resource
+func (a *ACL) IsOwner(email string) bool {
+	return a.Owner == email
+}
+
+// Allows checks if the user with the email and in the teams can view, or edit, the resource
+func (a *ACL) Allows(email string, teams []string, edit bool) bool {
+	if a.IsOwner(email) {
+		return true
+	}
+	for _, entry := range a.Entries {
+		if edit && entry.Level != ACLLevelEditor {
+			continue
+		}
+		if entry.PrincipalType == ACLPrincipalUser && entry.Principal == email {
+			return true
+		}
+		if entry.PrincipalType == ACLPrincipalTeam {
+			for _, team := range teams {
+				if entry.Principal == team {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+const aclColumns = "resource_type, resource_id, owner, updated_at, updated_by"
+
+// GetACL returns the access list of the resource, nil when the resource has none
+func GetACL(ctx context.Context, resourceType, resourceId string) (*ACL, *model.ApiError) {
+	acls := []*ACL{}
+	err := db.Select(&acls, "SELECT "+aclColumns+" FROM resource_acls WHERE resource_type=? AND resource_id=?", resourceType, resourceId)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	if len(acls) == 0 {
+		return nil, nil
+	}
+	acl := acls[0]
+	acl.Entries = []ACLEntry{}
+	err = db.Select(&acl.Entries, "SELECT principal_type, principal, level FROM resource_acl_entries WHERE resource_type=? AND resource_id=? ORDER BY principal_type, principal",
+		resourceType, resourceId)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return acl, nil
+}
+
+// GetACLs returns the access lists of the resources of the type by resource id
+func GetACLs(ctx context.Context, resourceType string) (map[string]*ACL, *model.ApiError) {
+	acls := []*ACL{}
+	if err := db.Select(&acls, "SELECT "+aclColumns+" FROM resource_acls WHERE resource_type=?", resourceType); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	byId := map[string]*ACL{}
+	for _, acl := range acls {
+		acl.Entries = []ACLEntry{}
+		byId[acl.ResourceId] = acl
+	}
+
+	type row struct {
+		ResourceId string `db:"resource_id"`
+		ACLEntry
+	}
+	rows := []row{}
+	err := db.Select(&rows, "SELECT resource_id, principal_type, principal, level FROM resource_acl_entries WHERE resource_type=? ORDER BY principal_type, principal", resourceType)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	for _, r := range rows {
+		if acl, ok := byId[r.ResourceId]; ok {
+			acl.Entries = append(acl.Entries, r.ACLEntry)
+		}
+	}
+	return byId, nil
+}
+
+// SetACL replaces the access list of the resource, the user setting it owns the resource
+// when no owner is given
+func SetACL(ctx context.Context, acl *ACL) (*ACL, *model.ApiError) {
+	if acl.Entries == nil {
+		acl.Entries = []ACLEntry{}
+	}
+	if apiErr := acl.validate(ctx); apiErr != nil {
+		return nil, apiErr
+	}
+
+	var userEmail string
+	if user := common.GetUserFromContext(ctx); user != nil {
+		userEmail = user.Email
+	}
+	if acl.Owner == "" {
+		acl.Owner = userEmail
+	}
+	if acl.Owner == "" {
+		return nil, model.BadRequest(fmt.Errorf("owner of the resource is required"))
+	}
+	acl.UpdatedAt = time.Now()
+	acl.UpdatedBy = userEmail
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT OR REPLACE INTO resource_acls ("+aclColumns+") VALUES ($1, $2, $3, $4, $5)",
+		acl.ResourceType, acl.ResourceId, acl.Owner, acl.UpdatedAt, acl.UpdatedBy)
+	if err != nil {
+		zap.L().Error("Error in setting access list", zap.String("resource", acl.ResourceType), zap.String("id", acl.ResourceId), zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	if _, err := tx.Exec("DELETE FROM resource_acl_entries WHERE resource_type=$1 AND resource_id=$2", acl.ResourceType, acl.ResourceId); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	for _, entry := range acl.Entries {
+		_, err := tx.Exec("INSERT INTO resource_acl_entries (resource_type, resource_id, principal_type, principal, level) VALUES ($1, $2, $3, $4, $5)",
+			acl.ResourceType, acl.ResourceId, entry.PrincipalType, entry.Principal, entry.Level)
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return acl, nil
+}
+
+// DeleteACL removes the access list of the resource, making it accessible according to the roles
+func DeleteACL(ctx context.Context, resourceType, resourceId string) *model.ApiError {
+	tx, err := db.Beginx()
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM resource_acl_entries WHERE resource_type=$1 AND resource_id=$2",
+		"DELETE FROM resource_acls WHERE resource_type=$1 AND resource_id=$2",
+	} {
+		if _, err := tx.Exec(query, resourceType, resourceId); err != nil {
+			return &model.ApiError{Typ: model.ErrorExec, Err: err}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return nil
+}
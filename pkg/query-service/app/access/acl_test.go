@@ -0,0 +1,108 @@
+package access
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestACLAllows(t *testing.T) {
+	acl := &ACL{
+		Owner: "owner@signoz.io",
+		Entries: []ACLEntry{
+			{PrincipalType: ACLPrincipalUser, Principal: "viewer@signoz.io", Level: ACLLevelViewer},
+			{PrincipalType: ACLPrincipalUser, Principal: "editor@signoz.io", Level: ACLLevelEditor},
+			{PrincipalType: ACLPrincipalTeam, Principal: "billing", Level: ACLLevelViewer},
+		},
+	}
+
+	if !acl.Allows("owner@signoz.io", nil, true) {
+		t.Error("expected the owner to be allowed to edit")
+	}
+	if !acl.Allows("viewer@signoz.io", nil, false) || acl.Allows("viewer@signoz.io", nil, true) {
+		t.Error("expected the viewer to be allowed to view only")
+	}
+	if !acl.Allows("editor@signoz.io", nil, true) {
+		t.Error("expected the editor to be allowed to edit")
+	}
+	if !acl.Allows("member@signoz.io", []string{"billing"}, false) {
+		t.Error("expected the team member to be allowed to view")
+	}
+	if acl.Allows("other@signoz.io", []string{"security"}, false) {
+		t.Error("expected the other users to be denied")
+	}
+}
+
+func TestACLs(t *testing.T) {
+	if err := InitWithDB(sqlx.MustOpen("sqlite3", filepath.Join(t.TempDir(), "signoz.db"))); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	team, apiErr := CreateTeam(ctx, &Team{Name: "billing", Members: []string{"bob@signoz.io", "alice@signoz.io", "bob@signoz.io"}})
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	if len(team.Members) != 2 {
+		t.Errorf("expected the duplicated members to be dropped, got %v", team.Members)
+	}
+	teams, apiErr := GetUserTeams(ctx, "alice@signoz.io")
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	if len(teams) != 1 || teams[0] != team.Id {
+		t.Errorf("unexpected teams %v", teams)
+	}
+
+	// the resources without access list are not restricted
+	acl, apiErr := GetACL(ctx, ACLResourceDashboard, "dashboard")
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	if acl != nil {
+		t.Errorf("expected no access list, got %v", acl)
+	}
+
+	if _, apiErr := SetACL(ctx, &ACL{ResourceType: ACLResourceDashboard, ResourceId: "dashboard"}); apiErr == nil {
+		t.Error("expected error when setting an access list without owner")
+	}
+	if _, apiErr := SetACL(ctx, &ACL{ResourceType: ACLResourceDashboard, ResourceId: "dashboard", Owner: "owner@signoz.io",
+		Entries: []ACLEntry{{PrincipalType: ACLPrincipalTeam, Principal: "unknown", Level: ACLLevelViewer}}}); apiErr == nil {
+		t.Error("expected error when sharing with an unknown team")
+	}
+	_, apiErr = SetACL(ctx, &ACL{ResourceType: ACLResourceDashboard, ResourceId: "dashboard", Owner: "owner@signoz.io",
+		Entries: []ACLEntry{{PrincipalType: ACLPrincipalTeam, Principal: team.Id, Level: ACLLevelEditor}}})
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+
+	acls, apiErr := GetACLs(ctx, ACLResourceDashboard)
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	if len(acls) != 1 || len(acls["dashboard"].Entries) != 1 || !acls["dashboard"].Allows("alice@signoz.io", teams, true) {
+		t.Errorf("unexpected access lists %v", acls)
+	}
+
+	// deleting the team removes it from the access lists
+	if apiErr := DeleteTeam(ctx, team.Id); apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	acl, apiErr = GetACL(ctx, ACLResourceDashboard, "dashboard")
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	if len(acl.Entries) != 0 {
+		t.Errorf("expected the entries of the deleted team to be removed, got %v", acl.Entries)
+	}
+
+	if apiErr := DeleteACL(ctx, ACLResourceDashboard, "dashboard"); apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	if acl, _ := GetACL(ctx, ACLResourceDashboard, "dashboard"); acl != nil {
+		t.Errorf("expected the access list to be deleted, got %v", acl)
+	}
+}
@@ -0,0 +1,84 @@
+package access
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var db *sqlx.DB
+
+// InitWithDB sets the db of the teams, the on-call schedules and the access lists of the
+// dashboards and alerts and creates their tables
+func InitWithDB(sqlDB *sqlx.DB) error {
+	db = sqlDB
+
+	tableSchema := `CREATE TABLE IF NOT EXISTS teams (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL
+	);`
+	_, err := db.Exec(tableSchema)
+	if err != nil {
+		return fmt.Errorf("error in creating teams table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS team_members (
+		team_id TEXT NOT NULL,
+		user_email TEXT NOT NULL,
+		PRIMARY KEY (team_id, user_email)
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return fmt.Errorf("error in creating team_members table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS oncall_schedules (
+		id TEXT PRIMARY KEY,
+		team_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		timezone TEXT NOT NULL,
+		rotations TEXT NOT NULL,
+		overrides TEXT NOT NULL,
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL,
+		UNIQUE(team_id, name)
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return fmt.Errorf("error in creating oncall_schedules table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS resource_acls (
+		resource_type TEXT NOT NULL,
+		resource_id TEXT NOT NULL,
+		owner TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL,
+		PRIMARY KEY (resource_type, resource_id)
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return fmt.Errorf("error in creating resource_acls table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS resource_acl_entries (
+		resource_type TEXT NOT NULL,
+		resource_id TEXT NOT NULL,
+		principal_type TEXT NOT NULL,
+		principal TEXT NOT NULL,
+		level TEXT NOT NULL,
+		PRIMARY KEY (resource_type, resource_id, principal_type, principal)
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return fmt.Errorf("error in creating resource_acl_entries table: %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,359 @@
+package access
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const onCallScheduleColumns = "id, team_id, name, timezone, rotations, overrides, created_at, created_by, updated_at, updated_by"
+
+// OnCallSchedule rotates the members of a team through on-call shifts. Each rotation has
+// one member on call at a time, the overrides hand the shifts over to another member
+type OnCallSchedule struct {
+	Id     string `json:"id" db:"id"`
+	TeamId string `json:"teamId" db:"team_id"`
+	Name   string `json:"name" db:"name"`
+	// Timezone is the time zone the handoffs happen in, the shifts keep their time of the
+	// day across the daylight saving time changes
+	Timezone  string          `json:"timezone" db:"timezone"`
+	Rotations OnCallRotations `json:"rotations" db:"rotations"`
+	Overrides OnCallOverrides `json:"overrides" db:"overrides"`
+	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
+	CreatedBy string          `json:"createdBy" db:"created_by"`
+	UpdatedAt time.Time       `json:"updatedAt" db:"updated_at"`
+	UpdatedBy string          `json:"updatedBy" db:"updated_by"`
+}
+
+// OnCallRotation hands the shifts over to its participants in turn, e.g. a primary and a
+// secondary rotation page two members at once
+type OnCallRotation struct {
+	Name string `json:"name"`
+	// Participants are the emails of the members taking the shifts, in order
+	Participants []string `json:"participants"`
+	// Start is the first handoff, the next handoffs are at the same time of the day
+	Start time.Time `json:"start"`
+	// ShiftDays is how many days a shift lasts
+	ShiftDays int `json:"shiftDays"`
+	// End stops the rotation, it never stops when zero
+	End time.Time `json:"end,omitempty"`
+}
+
+// OnCallOverride puts a member on call in place of the participants of a rotation, or of
+// all the rotations when it names none
+type OnCallOverride struct {
+	Rotation string    `json:"rotation,omitempty"`
+	User     string    `json:"user"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+}
+
+// OnCallShift is a member on call in a rotation of a schedule
+type OnCallShift struct {
+	ScheduleId string    `json:"scheduleId"`
+	Rotation   string    `json:"rotation"`
+	User       string    `json:"user"`
+	Start      time.Time `json:"start"`
+	// End is zero when the shift never ends
+	End      time.Time `json:"end"`
+	Override bool      `json:"override"`
+}
+
+type OnCallRotations []OnCallRotation
+
+func (r *OnCallRotations) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, r)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), r)
+	}
+	return nil
+}
+
+func (r OnCallRotations) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+type OnCallOverrides []OnCallOverride
+
+func (o *OnCallOverrides) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, o)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), o)
+	}
+	return nil
+}
+
+func (o OnCallOverrides) Value() (driver.Value, error) {
+	return json.Marshal(o)
+}
+
+// validate checks the schedule, the participants and the overriding users must be in members
+func (s *OnCallSchedule) validate(members []string) *model.ApiError {
+	if s.Name == "" {
+		return model.BadRequest(fmt.Errorf("schedule name is required"))
+	}
+	if s.Timezone == "" {
+		return model.BadRequest(fmt.Errorf("schedule timezone is required"))
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return model.BadRequest(fmt.Errorf("invalid timezone %q", s.Timezone))
+	}
+	if len(s.Rotations) == 0 {
+		return model.BadRequest(fmt.Errorf("schedule must have at least one rotation"))
+	}
+
+	isMember := map[string]bool{}
+	for _, m := range members {
+		isMember[m] = true
+	}
+	rotations := map[string]bool{}
+	for _, r := range s.Rotations {
+		if r.Name == "" {
+			return model.BadRequest(fmt.Errorf("rotation name is required"))
+		}
+		if rotations[r.Name] {
+			return model.BadRequest(fmt.Errorf("duplicate rotation %q", r.Name))
+		}
+		rotations[r.Name] = true
+		if len(r.Participants) == 0 {
+			return model.BadRequest(fmt.Errorf("rotation %q has no participants", r.Name))
+		}
+		for _, p := range r.Participants {
+			if !isMember[p] {
+				return model.BadRequest(fmt.Errorf("participant %s of rotation %q is not a member of the team", p, r.Name))
+			}
+		}
+		if r.Start.IsZero() {
+			return model.BadRequest(fmt.Errorf("rotation %q has no start", r.Name))
+		}
+		if r.ShiftDays < 1 {
+			return model.BadRequest(fmt.Errorf("shifts of rotation %q must last at least a day", r.Name))
+		}
+		if !r.End.IsZero() && !r.End.After(r.Start) {
+			return model.BadRequest(fmt.Errorf("rotation %q must end after its start", r.Name))
+		}
+	}
+	for _, o := range s.Overrides {
+		if o.Rotation != "" && !rotations[o.Rotation] {
+			return model.BadRequest(fmt.Errorf("override of unknown rotation %q", o.Rotation))
+		}
+		if !isMember[o.User] {
+			return model.BadRequest(fmt.Errorf("overriding user %s is not a member of the team", o.User))
+		}
+		if o.Start.IsZero() || !o.End.After(o.Start) {
+			return model.BadRequest(fmt.Errorf("override of %s must have a start before its end", o.User))
+		}
+	}
+	return nil
+}
+
+// shift returns the participant of the rotation on call at t
+func (r *OnCallRotation) shift(loc *time.Location, t time.Time) (OnCallShift, bool) {
+	if len(r.Participants) == 0 || r.ShiftDays < 1 || t.Before(r.Start) || (!r.End.IsZero() && !t.Before(r.End)) {
+		return OnCallShift{}, false
+	}
+	start := r.Start.In(loc)
+	handoff := func(n int) time.Time {
+		return time.Date(start.Year(), start.Month(), start.Day()+n*r.ShiftDays,
+			start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), loc)
+	}
+	// the days are 23 or 25 hours long across the daylight saving time changes, the estimate
+	// is off by one shift at most
+	n := int(t.Sub(start).Hours()/24) / r.ShiftDays
+	for n > 0 && handoff(n).After(t) {
+		n--
+	}
+	for !handoff(n + 1).After(t) {
+		n++
+	}
+
+	s := OnCallShift{Rotation: r.Name, User: r.Participants[n%len(r.Participants)], Start: handoff(n), End: handoff(n + 1)}
+	if !r.End.IsZero() && r.End.Before(s.End) {
+		s.End = r.End
+	}
+	return s, true
+}
+
+// OnCallAt returns the shifts of the schedule at t, one per rotation on call. The latest
+// override covering t replaces the participant of its rotations
+func (s *OnCallSchedule) OnCallAt(t time.Time) []OnCallShift {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	var overrides []OnCallOverride
+	for _, o := range s.Overrides {
+		if !t.Before(o.Start) && t.Before(o.End) {
+			overrides = append(overrides, o)
+		}
+	}
+	override := func(shift *OnCallShift) {
+		for i := len(overrides) - 1; i >= 0; i-- {
+			o := overrides[i]
+			if o.Rotation == "" || o.Rotation == shift.Rotation {
+				shift.User, shift.Start, shift.End, shift.Override = o.User, o.Start, o.End, true
+				return
+			}
+		}
+	}
+
+	shifts := []OnCallShift{}
+	for i := range s.Rotations {
+		shift, ok := s.Rotations[i].shift(loc, t)
+		if !ok {
+			continue
+		}
+		shift.ScheduleId = s.Id
+		override(&shift)
+		shifts = append(shifts, shift)
+	}
+	// an override of all the rotations puts its user on call even when no rotation is
+	if len(shifts) == 0 {
+		shift := OnCallShift{ScheduleId: s.Id}
+		override(&shift)
+		if shift.Override {
+			shifts = append(shifts, shift)
+		}
+	}
+	return shifts
+}
+
+func GetOnCallSchedules(ctx context.Context, teamId string) ([]OnCallSchedule, *model.ApiError) {
+	schedules := []OnCallSchedule{}
+	query := "SELECT " + onCallScheduleColumns + " FROM oncall_schedules"
+	args := []interface{}{}
+	if teamId != "" {
+		query += " WHERE team_id=?"
+		args = append(args, teamId)
+	}
+	if err := db.Select(&schedules, query+" ORDER BY name", args...); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return schedules, nil
+}
+
+func GetOnCallSchedule(ctx context.Context, id string) (*OnCallSchedule, *model.ApiError) {
+	schedule := OnCallSchedule{}
+	if err := db.Get(&schedule, "SELECT "+onCallScheduleColumns+" FROM oncall_schedules WHERE id=?", id); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no on-call schedule found with id: %s", id)}
+	}
+	return &schedule, nil
+}
+
+func CreateOnCallSchedule(ctx context.Context, schedule *OnCallSchedule) (*OnCallSchedule, *model.ApiError) {
+	team, apiErr := GetTeam(ctx, schedule.TeamId)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := schedule.validate(team.Members); apiErr != nil {
+		return nil, apiErr
+	}
+
+	var userEmail string
+	if user := common.GetUserFromContext(ctx); user != nil {
+		userEmail = user.Email
+	}
+	schedule.Id = uuid.New().String()
+	schedule.CreatedAt = time.Now()
+	schedule.CreatedBy = userEmail
+	schedule.UpdatedAt = schedule.CreatedAt
+	schedule.UpdatedBy = userEmail
+
+	_, err := db.Exec("INSERT INTO oncall_schedules ("+onCallScheduleColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		schedule.Id, schedule.TeamId, schedule.Name, schedule.Timezone, schedule.Rotations, schedule.Overrides,
+		schedule.CreatedAt, schedule.CreatedBy, schedule.UpdatedAt, schedule.UpdatedBy)
+	if err != nil {
+		zap.L().Error("Error in inserting on-call schedule", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return schedule, nil
+}
+
+// UpdateOnCallSchedule replaces the rotations and the overrides of the schedule, the
+// schedule stays with its team
+func UpdateOnCallSchedule(ctx context.Context, id string, update *OnCallSchedule) (*OnCallSchedule, *model.ApiError) {
+	schedule, apiErr := GetOnCallSchedule(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	team, apiErr := GetTeam(ctx, schedule.TeamId)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := update.validate(team.Members); apiErr != nil {
+		return nil, apiErr
+	}
+
+	schedule.Name = update.Name
+	schedule.Timezone = update.Timezone
+	schedule.Rotations = update.Rotations
+	schedule.Overrides = update.Overrides
+	schedule.UpdatedAt = time.Now()
+	if user := common.GetUserFromContext(ctx); user != nil {
+		schedule.UpdatedBy = user.Email
+	}
+
+	_, err := db.Exec("UPDATE oncall_schedules SET name=$1, timezone=$2, rotations=$3, overrides=$4, updated_at=$5, updated_by=$6 WHERE id=$7",
+		schedule.Name, schedule.Timezone, schedule.Rotations, schedule.Overrides, schedule.UpdatedAt, schedule.UpdatedBy, id)
+	if err != nil {
+		zap.L().Error("Error in updating on-call schedule", zap.String("id", id), zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return schedule, nil
+}
+
+func DeleteOnCallSchedule(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := GetOnCallSchedule(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	if _, err := db.Exec("DELETE FROM oncall_schedules WHERE id=$1", id); err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return nil
+}
+
+// GetTeamOnCall returns the shifts of the schedules of the team at t
+func GetTeamOnCall(ctx context.Context, teamId string, t time.Time) ([]OnCallShift, *model.ApiError) {
+	if _, apiErr := GetTeam(ctx, teamId); apiErr != nil {
+		return nil, apiErr
+	}
+	schedules, apiErr := GetOnCallSchedules(ctx, teamId)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	shifts := []OnCallShift{}
+	for i := range schedules {
+		shifts = append(shifts, schedules[i].OnCallAt(t)...)
+	}
+	return shifts, nil
+}
+
+// TeamOnCallEmails returns the emails of the members of the team on call at t, it is the
+// on-call lookup of the notification channels
+func TeamOnCallEmails(ctx context.Context, teamId string, t time.Time) ([]string, error) {
+	shifts, apiErr := GetTeamOnCall(ctx, teamId, t)
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	var emails []string
+	seen := map[string]bool{}
+	for _, s := range shifts {
+		if !seen[s.User] {
+			seen[s.User] = true
+			emails = append(emails, s.User)
+		}
+	}
+	return emails, nil
+}
@@ -0,0 +1,158 @@
+package access
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestOnCallAt(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	schedule := &OnCallSchedule{
+		Id:       "schedule",
+		Timezone: "Europe/Paris",
+		Rotations: OnCallRotations{
+			{
+				Name:         "primary",
+				Participants: []string{"alice@signoz.io", "bob@signoz.io", "carol@signoz.io"},
+				Start:        time.Date(2024, 3, 25, 9, 0, 0, 0, loc),
+				ShiftDays:    1,
+			},
+			{
+				Name:         "secondary",
+				Participants: []string{"dave@signoz.io"},
+				Start:        time.Date(2024, 3, 25, 9, 0, 0, 0, loc),
+				ShiftDays:    7,
+				End:          time.Date(2024, 4, 1, 9, 0, 0, 0, loc),
+			},
+		},
+		Overrides: OnCallOverrides{
+			{Rotation: "primary", User: "erin@signoz.io", Start: time.Date(2024, 3, 28, 12, 0, 0, 0, loc), End: time.Date(2024, 3, 28, 18, 0, 0, 0, loc)},
+			{User: "frank@signoz.io", Start: time.Date(2024, 4, 10, 0, 0, 0, 0, loc), End: time.Date(2024, 4, 11, 0, 0, 0, 0, loc)},
+		},
+	}
+
+	users := func(at time.Time) map[string]string {
+		res := map[string]string{}
+		for _, s := range schedule.OnCallAt(at) {
+			res[s.Rotation] = s.User
+		}
+		return res
+	}
+
+	if shifts := schedule.OnCallAt(time.Date(2024, 3, 25, 8, 0, 0, 0, loc)); len(shifts) != 0 {
+		t.Errorf("expected no shift before the rotations start, got %v", shifts)
+	}
+	if got := users(time.Date(2024, 3, 25, 10, 0, 0, 0, loc)); got["primary"] != "alice@signoz.io" || got["secondary"] != "dave@signoz.io" {
+		t.Errorf("unexpected on call %v", got)
+	}
+	if got := users(time.Date(2024, 3, 26, 8, 59, 0, 0, loc)); got["primary"] != "alice@signoz.io" {
+		t.Errorf("expected alice until the handoff, got %v", got)
+	}
+	if got := users(time.Date(2024, 3, 26, 9, 0, 0, 0, loc)); got["primary"] != "bob@signoz.io" {
+		t.Errorf("expected bob after the handoff, got %v", got)
+	}
+
+	// the clocks move forward on the 31st of march, the handoffs stay at 9:00 in Paris
+	shifts := schedule.OnCallAt(time.Date(2024, 3, 31, 8, 30, 0, 0, loc))
+	for _, s := range shifts {
+		if s.Rotation != "primary" {
+			continue
+		}
+		if s.User != "carol@signoz.io" || !s.Start.Equal(time.Date(2024, 3, 30, 9, 0, 0, 0, loc)) ||
+			!s.End.Equal(time.Date(2024, 3, 31, 9, 0, 0, 0, loc)) || s.End.Sub(s.Start) != 23*time.Hour {
+			t.Errorf("unexpected shift across the time change %+v", s)
+		}
+	}
+	if got := users(time.Date(2024, 3, 31, 9, 30, 0, 0, loc)); got["primary"] != "alice@signoz.io" {
+		t.Errorf("expected alice after the handoff following the time change, got %v", got)
+	}
+	if got := users(time.Date(2024, 4, 1, 9, 30, 0, 0, loc)); got["primary"] != "bob@signoz.io" || got["secondary"] != "" {
+		t.Errorf("expected bob alone once the secondary rotation ended, got %v", got)
+	}
+
+	shifts = schedule.OnCallAt(time.Date(2024, 3, 28, 13, 0, 0, 0, loc))
+	if got := users(time.Date(2024, 3, 28, 13, 0, 0, 0, loc)); got["primary"] != "erin@signoz.io" || got["secondary"] != "dave@signoz.io" {
+		t.Errorf("expected the override of the primary rotation only, got %v", got)
+	}
+	for _, s := range shifts {
+		if s.Rotation == "primary" && (!s.Override || !s.End.Equal(time.Date(2024, 3, 28, 18, 0, 0, 0, loc))) {
+			t.Errorf("unexpected override shift %+v", s)
+		}
+	}
+	if got := users(time.Date(2024, 4, 10, 13, 0, 0, 0, loc)); got["primary"] != "frank@signoz.io" || len(got) != 1 {
+		t.Errorf("expected the override of all the rotations, got %v", got)
+	}
+}
+
+func TestOnCallSchedules(t *testing.T) {
+	if err := InitWithDB(sqlx.MustOpen("sqlite3", filepath.Join(t.TempDir(), "signoz.db"))); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	team, apiErr := CreateTeam(ctx, &Team{Name: "payments", Members: []string{"alice@signoz.io", "bob@signoz.io"}})
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	start := time.Now().Add(-time.Hour)
+	schedule := &OnCallSchedule{
+		TeamId:   team.Id,
+		Name:     "weekly",
+		Timezone: "UTC",
+		Rotations: OnCallRotations{
+			{Name: "primary", Participants: []string{"alice@signoz.io", "bob@signoz.io"}, Start: start, ShiftDays: 7},
+		},
+	}
+
+	invalid := *schedule
+	invalid.Rotations = OnCallRotations{{Name: "primary", Participants: []string{"mallory@signoz.io"}, Start: start, ShiftDays: 7}}
+	if _, apiErr := CreateOnCallSchedule(ctx, &invalid); apiErr == nil {
+		t.Error("expected the participants outside of the team to be rejected")
+	}
+
+	created, apiErr := CreateOnCallSchedule(ctx, schedule)
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	emails, err := TeamOnCallEmails(ctx, team.Id, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 1 || emails[0] != "alice@signoz.io" {
+		t.Errorf("expected alice on call, got %v", emails)
+	}
+
+	created.Overrides = OnCallOverrides{{User: "bob@signoz.io", Start: start, End: time.Now().Add(time.Hour)}}
+	if _, apiErr := UpdateOnCallSchedule(ctx, created.Id, created); apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	stored, apiErr := GetOnCallSchedule(ctx, created.Id)
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	if len(stored.Overrides) != 1 || len(stored.Rotations) != 1 || stored.Rotations[0].ShiftDays != 7 {
+		t.Errorf("unexpected stored schedule %+v", stored)
+	}
+	emails, err = TeamOnCallEmails(ctx, team.Id, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 1 || emails[0] != "bob@signoz.io" {
+		t.Errorf("expected bob on call with the override, got %v", emails)
+	}
+
+	if apiErr := DeleteTeam(ctx, team.Id); apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	if schedules, _ := GetOnCallSchedules(ctx, team.Id); len(schedules) != 0 {
+		t.Errorf("expected the schedules to be deleted with the team, got %v", schedules)
+	}
+}
@@ -0,0 +1,202 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// Team is a group of users the dashboards and alerts can be shared with
+type Team struct {
+	Id   string `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	// Members are the emails of the users of the team
+	Members   []string  `json:"members" db:"-"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	CreatedBy string    `json:"createdBy" db:"created_by"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	UpdatedBy string    `json:"updatedBy" db:"updated_by"`
+}
+
+func (t *Team) validate() *model.ApiError {
+	if t.Name == "" {
+		return model.BadRequest(fmt.Errorf("team name is required"))
+	}
+	seen := map[string]bool{}
+	members := []string{}
+	for _, member := range t.Members {
+		if member == "" {
+			return model.BadRequest(fmt.Errorf("team member email is required"))
+		}
+		if !seen[member] {
+			seen[member] = true
+			members = append(members, member)
+		}
+	}
+	sort.Strings(members)
+	t.Members = members
+	return nil
+}
+
+const teamColumns = "id, name, created_at, created_by, updated_at, updated_by"
+
+func GetTeams(ctx context.Context) ([]Team, *model.ApiError) {
+	teams := []Team{}
+	if err := db.Select(&teams, "SELECT "+teamColumns+" FROM teams ORDER BY name"); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	for i := range teams {
+		members, apiErr := getTeamMembers(ctx, teams[i].Id)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		teams[i].Members = members
+	}
+	return teams, nil
+}
+
+func GetTeam(ctx context.Context, id string) (*Team, *model.ApiError) {
+	team := Team{}
+	if err := db.Get(&team, "SELECT "+teamColumns+" FROM teams WHERE id=?", id); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no team found with id: %s", id)}
+	}
+	members, apiErr := getTeamMembers(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	team.Members = members
+	return &team, nil
+}
+
+func getTeamMembers(ctx context.Context, id string) ([]string, *model.ApiError) {
+	members := []string{}
+	if err := db.Select(&members, "SELECT user_email FROM team_members WHERE team_id=? ORDER BY user_email", id); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return members, nil
+}
+
+// GetUserTeams returns the ids of the teams of the user
+func GetUserTeams(ctx context.Context, email string) ([]string, *model.ApiError) {
+	teams := []string{}
+	if err := db.Select(&teams, "SELECT team_id FROM team_members WHERE user_email=?", email); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return teams, nil
+}
+
+func CreateTeam(ctx context.Context, team *Team) (*Team, *model.ApiError) {
+	if apiErr := team.validate(); apiErr != nil {
+		return nil, apiErr
+	}
+
+	var userEmail string
+	if user := common.GetUserFromContext(ctx); user != nil {
+		userEmail = user.Email
+	}
+	team.Id = uuid.New().String()
+	team.CreatedAt = time.Now()
+	team.CreatedBy = userEmail
+	team.UpdatedAt = team.CreatedAt
+	team.UpdatedBy = userEmail
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO teams ("+teamColumns+") VALUES ($1, $2, $3, $4, $5, $6)",
+		team.Id, team.Name, team.CreatedAt, team.CreatedBy, team.UpdatedAt, team.UpdatedBy)
+	if err != nil {
+		zap.L().Error("Error in inserting team", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	for _, member := range team.Members {
+		if _, err := tx.Exec("INSERT INTO team_members (team_id, user_email) VALUES ($1, $2)", team.Id, member); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return team, nil
+}
+
+// UpdateTeam renames the team and replaces its members
+func UpdateTeam(ctx context.Context, id string, update *Team) (*Team, *model.ApiError) {
+	team, apiErr := GetTeam(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := update.validate(); apiErr != nil {
+		return nil, apiErr
+	}
+
+	team.Name = update.Name
+	team.Members = update.Members
+	team.UpdatedAt = time.Now()
+	if user := common.GetUserFromContext(ctx); user != nil {
+		team.UpdatedBy = user.Email
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE teams SET name=$1, updated_at=$2, updated_by=$3 WHERE id=$4",
+		team.Name, team.UpdatedAt, team.UpdatedBy, id)
+	if err != nil {
+		zap.L().Error("Error in updating team", zap.String("id", id), zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	if _, err := tx.Exec("DELETE FROM team_members WHERE team_id=$1", id); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	for _, member := range team.Members {
+		if _, err := tx.Exec("INSERT INTO team_members (team_id, user_email) VALUES ($1, $2)", id, member); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return team, nil
+}
+
+// DeleteTeam deletes the team along with its entries in the access lists and its on-call
+// schedules
+func DeleteTeam(ctx context.Context, id string) *model.ApiError {
+	if _, apiErr := GetTeam(ctx, id); apiErr != nil {
+		return apiErr
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM resource_acl_entries WHERE principal_type='" + ACLPrincipalTeam + "' AND principal=$1",
+		"DELETE FROM team_members WHERE team_id=$1",
+		"DELETE FROM oncall_schedules WHERE team_id=$1",
+		"DELETE FROM teams WHERE id=$1",
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return &model.ApiError{Typ: model.ErrorExec, Err: err}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return nil
+}
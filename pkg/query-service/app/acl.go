@@ -0,0 +1,242 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/access"
+	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// checkACL checks if the user of the request can view, or edit, the resource according
+// to its access list, the admins and the resources without access list are not restricted
+func (aH *APIHandler) checkACL(r *http.Request, resourceType, resourceId string, edit bool) *model.ApiError {
+	allowed, apiErr := aH.aclFilter(r, resourceType, edit)
+	if apiErr != nil {
+		return apiErr
+	}
+	if !allowed(resourceId) {
+		return &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("the %s is restricted by its access list", resourceType)}
+	}
+	return nil
+}
+
+// aclFilter returns a func checking if the user of the request can view, or edit, the
+// resources of the type, the access lists are read once to filter the lists of resources.
+// The rules of the other orgs are filtered out
+func (aH *APIHandler) aclFilter(r *http.Request, resourceType string, edit bool) (func(resourceId string) bool, *model.ApiError) {
+	allowed, apiErr := aH.accessListFilter(r, resourceType, edit)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if resourceType != access.ACLResourceRule || aH.ruleManager == nil {
+		return allowed, nil
+	}
+
+	// the alerts and the history of the rules are read by rule id, they are restricted to
+	// the rules of the org of the user
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if scope == nil {
+		return allowed, nil
+	}
+	storedRules, err := aH.ruleManager.RuleDB().GetStoredRules(r.Context())
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	orgRules := make(map[string]struct{}, len(storedRules))
+	for _, rule := range storedRules {
+		orgRules[strconv.Itoa(rule.Id)] = struct{}{}
+	}
+	return func(resourceId string) bool {
+		_, ok := orgRules[resourceId]
+		return ok && allowed(resourceId)
+	}, nil
+}
+
+// accessListFilter filters the resources by their access lists only
+func (aH *APIHandler) accessListFilter(r *http.Request, resourceType string, edit bool) (func(resourceId string) bool, *model.ApiError) {
+	user := common.GetUserFromContext(r.Context())
+	if user == nil {
+		return nil, &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("unauthorized")}
+	}
+	if auth.IsAdmin(user) {
+		return func(string) bool { return true }, nil
+	}
+
+	acls, apiErr := access.GetACLs(r.Context(), resourceType)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if len(acls) == 0 {
+		return func(string) bool { return true }, nil
+	}
+	teams, apiErr := access.GetUserTeams(r.Context(), user.Email)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return func(resourceId string) bool {
+		acl, ok := acls[resourceId]
+		return !ok || acl.Allows(user.Email, teams, edit)
+	}, nil
+}
+
+// checkACLOwner checks if the user of the request can change the access list of the resource,
+// only the admins and the owner can change an existing access list
+func (aH *APIHandler) checkACLOwner(r *http.Request, resourceType, resourceId string) *model.ApiError {
+	user := common.GetUserFromContext(r.Context())
+	if user == nil {
+		return &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("unauthorized")}
+	}
+	if auth.IsAdmin(user) {
+		return nil
+	}
+	acl, apiErr := access.GetACL(r.Context(), resourceType, resourceId)
+	if apiErr != nil {
+		return apiErr
+	}
+	if acl != nil && !acl.IsOwner(user.Email) {
+		return &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("only the owner of the %s or an admin can change its access list", resourceType)}
+	}
+	return nil
+}
+
+// checkACLResource checks if the resource exists and if the user can edit it
+func (aH *APIHandler) checkACLResource(r *http.Request, resourceType, resourceId string) *model.ApiError {
+	if resourceType == access.ACLResourceDashboard {
+		if _, apiErr := dashboards.GetDashboard(r.Context(), resourceId); apiErr != nil {
+			return apiErr
+		}
+		return aH.checkDashboardAccess(r, resourceId, true)
+	}
+	if _, err := aH.ruleManager.GetRule(r.Context(), resourceId); err != nil {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: err}
+	}
+	return aH.checkACL(r, resourceType, resourceId, true)
+}
+
+func (aH *APIHandler) getResourceACL(resourceType, idVar string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)[idVar]
+		if apiErr := aH.checkACL(r, resourceType, id, false); apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		acl, apiErr := access.GetACL(r.Context(), resourceType, id)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		aH.Respond(w, acl)
+	}
+}
+
+func (aH *APIHandler) setResourceACL(resourceType, idVar string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)[idVar]
+		var acl access.ACL
+		if err := json.NewDecoder(r.Body).Decode(&acl); err != nil {
+			RespondError(w, model.BadRequest(err), nil)
+			return
+		}
+		if apiErr := aH.checkACLResource(r, resourceType, id); apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		if apiErr := aH.checkACLOwner(r, resourceType, id); apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+
+		acl.ResourceType = resourceType
+		acl.ResourceId = id
+		// only the admins can hand the resource over, it stays owned by the user otherwise
+		if user := common.GetUserFromContext(r.Context()); !auth.IsAdmin(user) {
+			acl.Owner = user.Email
+		}
+		updated, apiErr := access.SetACL(r.Context(), &acl)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		aH.Respond(w, updated)
+	}
+}
+
+// deleteResourceACL removes the access list, the resource is then accessible according to the roles
+func (aH *APIHandler) deleteResourceACL(resourceType, idVar string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)[idVar]
+		if apiErr := aH.checkACLOwner(r, resourceType, id); apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		if apiErr := access.DeleteACL(r.Context(), resourceType, id); apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		aH.Respond(w, nil)
+	}
+}
+
+func (aH *APIHandler) listTeams(w http.ResponseWriter, r *http.Request) {
+	teams, apiErr := access.GetTeams(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, teams)
+}
+
+func (aH *APIHandler) getTeam(w http.ResponseWriter, r *http.Request) {
+	team, apiErr := access.GetTeam(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, team)
+}
+
+func (aH *APIHandler) createTeam(w http.ResponseWriter, r *http.Request) {
+	var team access.Team
+	if err := json.NewDecoder(r.Body).Decode(&team); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	created, apiErr := access.CreateTeam(r.Context(), &team)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, created)
+}
+
+func (aH *APIHandler) updateTeam(w http.ResponseWriter, r *http.Request) {
+	var team access.Team
+	if err := json.NewDecoder(r.Body).Decode(&team); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	updated, apiErr := access.UpdateTeam(r.Context(), mux.Vars(r)["id"], &team)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, updated)
+}
+
+func (aH *APIHandler) deleteTeam(w http.ResponseWriter, r *http.Request) {
+	if apiErr := access.DeleteTeam(r.Context(), mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
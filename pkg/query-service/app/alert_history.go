@@ -0,0 +1,171 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/app/access"
+	"go.signoz.io/signoz/pkg/query-service/app/alerthistory"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// parseHistoryRange parses the range of the alert history requests, in milliseconds
+func parseHistoryRange(query url.Values) (time.Time, time.Time, *model.ApiError) {
+	var start, end time.Time
+	for param, value := range map[string]*time.Time{"start": &start, "end": &end} {
+		if s := query.Get(param); s != "" {
+			ms, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return start, end, model.BadRequest(fmt.Errorf("%s param is not in correct timestamp format", param))
+			}
+			*value = time.UnixMilli(ms)
+		}
+	}
+	return start, end, nil
+}
+
+func parseHistoryInt(query url.Values, param string) (int, *model.ApiError) {
+	s := query.Get(param)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, model.BadRequest(fmt.Errorf("invalid %s %q", param, s))
+	}
+	return v, nil
+}
+
+// listAlertHistory lists the state changes of the alerts in the range, the latest first.
+// The changes can be restricted to a rule, a state and an alert by its fingerprint
+func (aH *APIHandler) listAlertHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	start, end, apiErr := parseHistoryRange(query)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	filter := alerthistory.Filter{Start: start, End: end, RuleId: query.Get("ruleId"), State: query.Get("state")}
+	if s := query.Get("fingerprint"); s != "" {
+		fingerprint, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			RespondError(w, model.BadRequest(fmt.Errorf("invalid fingerprint %q", s)), nil)
+			return
+		}
+		filter.Fingerprint = fingerprint
+	}
+	if filter.Limit, apiErr = parseHistoryInt(query, "limit"); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if filter.Offset, apiErr = parseHistoryInt(query, "offset"); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if err := filter.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	allowed, apiErr := aH.aclFilter(r, access.ACLResourceRule, false)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if filter.RuleId != "" && !allowed(filter.RuleId) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("the %s is restricted by its access list", access.ACLResourceRule)}, nil)
+		return
+	}
+
+	entries, err := aH.AlertHistoryManager.History(r.Context(), &filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	viewable := entries[:0]
+	for _, e := range entries {
+		if allowed(e.RuleId) {
+			viewable = append(viewable, e)
+		}
+	}
+	aH.Respond(w, viewable)
+}
+
+// getAlertStats returns how many times the alerts of each rule fired in the range and how
+// long they took to be resolved
+func (aH *APIHandler) getAlertStats(w http.ResponseWriter, r *http.Request) {
+	start, end, apiErr := parseHistoryRange(r.URL.Query())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if err := alerthistory.ValidateRange(start, end); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	allowed, apiErr := aH.aclFilter(r, access.ACLResourceRule, false)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	stats, err := aH.AlertHistoryManager.Stats(r.Context(), start, end)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	viewable := stats[:0]
+	for _, s := range stats {
+		if allowed(s.RuleId) {
+			viewable = append(viewable, s)
+		}
+	}
+	aH.Respond(w, viewable)
+}
+
+// getNoisiestAlerts returns the alerts that fired the most in the range
+func (aH *APIHandler) getNoisiestAlerts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	start, end, apiErr := parseHistoryRange(query)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if err := alerthistory.ValidateRange(start, end); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	limit, apiErr := parseHistoryInt(query, "limit")
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if limit < 0 || limit > alerthistory.MaxLimit {
+		RespondError(w, model.BadRequest(fmt.Errorf("limit must be between 0 and %d", alerthistory.MaxLimit)), nil)
+		return
+	}
+	if limit == 0 {
+		limit = alerthistory.DefaultLimit
+	}
+
+	allowed, apiErr := aH.aclFilter(r, access.ACLResourceRule, false)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	alerts, err := aH.AlertHistoryManager.Noisiest(r.Context(), start, end, limit)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	viewable := alerts[:0]
+	for _, a := range alerts {
+		if allowed(a.RuleId) {
+			viewable = append(viewable, a)
+		}
+	}
+	aH.Respond(w, viewable)
+}
@@ -0,0 +1,223 @@
+package alerthistory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.uber.org/zap"
+)
+
+const (
+	historyDB    = "signoz_analytics"
+	historyTable = "distributed_rule_state_history"
+
+	// queueSize is how many state changes are buffered, the changes are dropped when the
+	// writes can't keep up
+	queueSize = 10000
+	// batchSize is how many state changes are written at once at most
+	batchSize = 1000
+	// flushInterval is how often the buffered state changes are written
+	flushInterval = 5 * time.Second
+	writeTimeout  = 30 * time.Second
+)
+
+type ManagerOptions struct {
+	Conn clickhouse.Conn
+}
+
+// Manager keeps the state changes of the alerts in ClickHouse and reports on them, e.g. the
+// mean time to resolve the alerts of the rules and the noisiest alerts
+type Manager struct {
+	conn  clickhouse.Conn
+	queue chan *Entry
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewManager(opts ManagerOptions) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		conn:   opts.Conn,
+		queue:  make(chan *Entry, queueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start writes the state changes recorded in the background
+func (m *Manager) Start() {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		batch := make([]*Entry, 0, batchSize)
+		for {
+			select {
+			case <-m.ctx.Done():
+				for {
+					select {
+					case e := <-m.queue:
+						batch = append(batch, e)
+					default:
+						m.write(batch)
+						return
+					}
+				}
+			case e := <-m.queue:
+				batch = append(batch, e)
+				if len(batch) < batchSize {
+					continue
+				}
+			case <-ticker.C:
+			}
+			m.write(batch)
+			batch = batch[:0]
+		}
+	}()
+}
+
+// Stop writes the state changes buffered and stops
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// stateName names the state the alert of the change went to
+func stateName(c *rules.StateChange) string {
+	if c.Resolved() {
+		return StateResolved
+	}
+	return c.To.String()
+}
+
+// Record buffers the state changes of the alerts of a rule, it is a state listener of
+// the rules manager and never blocks
+func (m *Manager) Record(ctx context.Context, changes ...rules.StateChange) {
+	for i := range changes {
+		c := &changes[i]
+		value := c.Value
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			value = 0
+		}
+		e := &Entry{
+			UnixMilli:   c.At.UnixMilli(),
+			RuleId:      c.RuleId,
+			RuleName:    c.RuleName,
+			Fingerprint: c.Labels.Hash(),
+			Labels:      c.Labels.Map(),
+			StateFrom:   c.From.String(),
+			State:       stateName(c),
+			Value:       value,
+			DurationMs:  c.Duration.Milliseconds(),
+		}
+		select {
+		case m.queue <- e:
+		default:
+			zap.L().Warn("the alert state history queue is full, dropping a state change", zap.String("rule", c.RuleId))
+		}
+	}
+}
+
+func (m *Manager) write(entries []*Entry) {
+	if len(entries) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	statement, err := m.conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (unix_milli, rule_id, rule_name, fingerprint, "+
+		"labels, state_from, state, value, duration_ms)", historyDB, historyTable))
+	if err != nil {
+		zap.L().Error("couldn't prepare the alert state history batch", zap.Error(err))
+		return
+	}
+	defer statement.Abort()
+	for _, e := range entries {
+		if err := statement.Append(e.UnixMilli, e.RuleId, e.RuleName, e.Fingerprint, e.Labels,
+			e.StateFrom, e.State, e.Value, e.DurationMs); err != nil {
+			zap.L().Error("couldn't append an alert state change", zap.Error(err))
+			return
+		}
+	}
+	if err := statement.Send(); err != nil {
+		zap.L().Error("couldn't write the alert state history", zap.Int("changes", len(entries)), zap.Error(err))
+	}
+}
+
+// History lists the state changes of the filter, the latest first
+func (m *Manager) History(ctx context.Context, f *Filter) ([]Entry, error) {
+	conditions := []string{"unix_milli >= $1", "unix_milli <= $2"}
+	args := []interface{}{f.Start.UnixMilli(), f.End.UnixMilli()}
+	condition := func(column string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	if f.RuleId != "" {
+		condition("rule_id", f.RuleId)
+	}
+	if f.State != "" {
+		condition("state", f.State)
+	}
+	if f.Fingerprint != 0 {
+		condition("fingerprint", f.Fingerprint)
+	}
+
+	query := fmt.Sprintf(`SELECT unix_milli, rule_id, rule_name, fingerprint, labels, toString(state_from) AS state_from,
+		toString(state) AS state, value, duration_ms
+		FROM %s.%s WHERE %s ORDER BY unix_milli DESC LIMIT %d OFFSET %d`,
+		historyDB, historyTable, strings.Join(conditions, " AND "), f.Limit, f.Offset)
+
+	entries := []Entry{}
+	if err := m.conn.Select(ctx, &entries, query, args...); err != nil {
+		zap.L().Error("Error while reading the alert state history", zap.Error(err))
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Stats sums up how the rules fired in the range, the rules firing the most first
+func (m *Manager) Stats(ctx context.Context, start, end time.Time) ([]RuleStats, error) {
+	query := fmt.Sprintf(`SELECT rule_id, anyLast(rule_name) AS rule_name,
+		countIf(state = '%[3]s') AS fired, countIf(state = '%[4]s') AS resolved,
+		ifNotFinite(avgIf(duration_ms, state = '%[4]s') / 1000, 0) AS mttr_seconds,
+		sumIf(duration_ms, state = '%[4]s') / 1000 AS firing_seconds,
+		uniqExactIf(fingerprint, state = '%[3]s') AS alerts
+		FROM %[1]s.%[2]s WHERE unix_milli >= $1 AND unix_milli <= $2
+		GROUP BY rule_id ORDER BY fired DESC, rule_id`,
+		historyDB, historyTable, StateFiring, StateResolved)
+
+	stats := []RuleStats{}
+	if err := m.conn.Select(ctx, &stats, query, start.UnixMilli(), end.UnixMilli()); err != nil {
+		zap.L().Error("Error while reading the alert stats", zap.Error(err))
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Noisiest returns the alerts that fired the most in the range
+func (m *Manager) Noisiest(ctx context.Context, start, end time.Time, limit int) ([]NoisyAlert, error) {
+	query := fmt.Sprintf(`SELECT rule_id, anyLast(rule_name) AS rule_name, fingerprint, anyLast(labels) AS labels,
+		countIf(state = '%[3]s') AS fired, count() AS transitions,
+		sumIf(duration_ms, state = '%[4]s') / 1000 AS firing_seconds,
+		maxIf(unix_milli, state = '%[3]s') AS last_fired
+		FROM %[1]s.%[2]s WHERE unix_milli >= $1 AND unix_milli <= $2
+		GROUP BY rule_id, fingerprint HAVING fired > 0
+		ORDER BY fired DESC, transitions DESC LIMIT %[5]d`,
+		historyDB, historyTable, StateFiring, StateResolved, limit)
+
+	alerts := []NoisyAlert{}
+	if err := m.conn.Select(ctx, &alerts, query, start.UnixMilli(), end.UnixMilli()); err != nil {
+		zap.L().Error("Error while reading the noisiest alerts", zap.Error(err))
+		return nil, err
+	}
+	return alerts, nil
+}
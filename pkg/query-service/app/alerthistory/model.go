@@ -0,0 +1,101 @@
+package alerthistory
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+
+	// the states of the history, an alert that stops firing is resolved
+	StatePending  = "pending"
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+	StateInactive = "inactive"
+)
+
+// Entry is a state change of an alert
+type Entry struct {
+	UnixMilli   int64             `json:"unixMilli" ch:"unix_milli"`
+	RuleId      string            `json:"ruleId" ch:"rule_id"`
+	RuleName    string            `json:"ruleName" ch:"rule_name"`
+	Fingerprint uint64            `json:"fingerprint" ch:"fingerprint"`
+	Labels      map[string]string `json:"labels" ch:"labels"`
+	StateFrom   string            `json:"stateFrom" ch:"state_from"`
+	State       string            `json:"state" ch:"state"`
+	Value       float64           `json:"value" ch:"value"`
+	// DurationMs is how long the alert was in the state it left
+	DurationMs int64 `json:"durationMs" ch:"duration_ms"`
+}
+
+// Filter selects the state changes in the range, the latest first
+type Filter struct {
+	Start time.Time
+	End   time.Time
+	// RuleId, State and Fingerprint are ignored when empty
+	RuleId      string
+	State       string
+	Fingerprint uint64
+	Limit       int
+	Offset      int
+}
+
+func (f *Filter) Validate() error {
+	if err := ValidateRange(f.Start, f.End); err != nil {
+		return err
+	}
+	switch f.State {
+	case "", StatePending, StateFiring, StateResolved, StateInactive:
+	default:
+		return fmt.Errorf("invalid state %q", f.State)
+	}
+	if f.Limit < 0 || f.Limit > MaxLimit {
+		return fmt.Errorf("limit must be between 0 and %d", MaxLimit)
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultLimit
+	}
+	if f.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+	return nil
+}
+
+// ValidateRange checks the range of the history and the reports
+func ValidateRange(start, end time.Time) error {
+	if start.IsZero() || end.IsZero() || !start.Before(end) {
+		return fmt.Errorf("start and end are required and start must be before end")
+	}
+	return nil
+}
+
+// RuleStats sums up how a rule fired in a range
+type RuleStats struct {
+	RuleId   string `json:"ruleId" ch:"rule_id"`
+	RuleName string `json:"ruleName" ch:"rule_name"`
+	// Fired is how many times an alert of the rule started firing
+	Fired    uint64 `json:"fired" ch:"fired"`
+	Resolved uint64 `json:"resolved" ch:"resolved"`
+	// MTTRSeconds is the mean time an alert of the rule fired for before being resolved
+	MTTRSeconds float64 `json:"mttrSeconds" ch:"mttr_seconds"`
+	// FiringSeconds is how long the alerts resolved in the range fired in total
+	FiringSeconds float64 `json:"firingSeconds" ch:"firing_seconds"`
+	// Alerts is how many alerts, i.e. label sets, of the rule fired
+	Alerts uint64 `json:"alerts" ch:"alerts"`
+}
+
+// NoisyAlert is an alert with how often it fired in a range
+type NoisyAlert struct {
+	RuleId      string            `json:"ruleId" ch:"rule_id"`
+	RuleName    string            `json:"ruleName" ch:"rule_name"`
+	Fingerprint uint64            `json:"fingerprint" ch:"fingerprint"`
+	Labels      map[string]string `json:"labels" ch:"labels"`
+	Fired       uint64            `json:"fired" ch:"fired"`
+	// Transitions is how many times the alert changed state, a high count with a short
+	// firing time is a flapping alert
+	Transitions   uint64  `json:"transitions" ch:"transitions"`
+	FiringSeconds float64 `json:"firingSeconds" ch:"firing_seconds"`
+	LastFired     int64   `json:"lastFired" ch:"last_fired"`
+}
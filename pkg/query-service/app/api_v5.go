@@ -0,0 +1,193 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/app/access"
+	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/app/explorer"
+	"go.signoz.io/signoz/pkg/query-service/app/openapi"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.signoz.io/signoz/pkg/query-service/version"
+)
+
+// apiV5 is the version of the routes under /api/v5, returned in their responses
+const apiV5 = "v5"
+
+// V5Response is the envelope of the responses of the /api/v5 routes, with the data of the
+// successful responses or the error
+type V5Response struct {
+	Version string      `json:"version"`
+	Status  status      `json:"status"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   *V5Error    `json:"error,omitempty"`
+}
+
+type V5Error struct {
+	Type    model.ErrorType `json:"type"`
+	Message string          `json:"message"`
+}
+
+// v5HandlerFunc handles a request of a /api/v5 route, it returns the data of the response
+// or the error
+type v5HandlerFunc func(r *http.Request) (interface{}, *model.ApiError)
+
+func writeV5Response(w http.ResponseWriter, code int, response V5Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		zap.L().Error("error writing response", zap.Error(err))
+	}
+}
+
+func respondV5Error(w http.ResponseWriter, apiErr *model.ApiError) {
+	writeV5Response(w, errorStatusCode(apiErr.Type()), V5Response{
+		Version: apiV5,
+		Status:  statusError,
+		Error:   &V5Error{Type: apiErr.Type(), Message: apiErr.Error()},
+	})
+}
+
+// v5Access checks the role of the user grants the action on the resource, or that the user
+// has a role when the resource is empty, and responds in the envelope of the v5 routes
+func v5Access(am *AuthMiddleware, resource model.Resource, action model.Action, f v5HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := am.GetUserFromRequest(r)
+		if err != nil {
+			respondV5Error(w, &model.ApiError{Typ: model.ErrorUnauthorized, Err: err})
+			return
+		}
+		if resource == "" && !auth.HasRole(r.Context(), user) {
+			respondV5Error(w, &model.ApiError{Typ: model.ErrorForbidden, Err: errors.New("API is accessible to the users with a role")})
+			return
+		}
+		if resource != "" && !auth.HasPermission(r.Context(), user, resource, action) {
+			respondV5Error(w, &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("API requires the permission to %s %s", action, resource)})
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), constants.ContextUserKey, user))
+
+		data, apiErr := f(r)
+		if apiErr != nil {
+			respondV5Error(w, apiErr)
+			return
+		}
+		writeV5Response(w, http.StatusOK, V5Response{Version: apiV5, Status: statusSuccess, Data: data})
+	}
+}
+
+// newOpenAPIRegistry returns the registry of the routes, with the envelopes of the responses
+func newOpenAPIRegistry() *openapi.Registry {
+	registry := openapi.NewRegistry()
+	registry.Envelope("/api", ApiResponse{})
+	registry.Envelope("/api/v5", V5Response{})
+	return registry
+}
+
+// v5Router registers the /api/v5 routes and describes them in the OpenAPI document
+type v5Router struct {
+	router   *mux.Router
+	am       *AuthMiddleware
+	registry *openapi.Registry
+}
+
+func (v *v5Router) handle(method, path string, resource model.Resource, route openapi.Route, f v5HandlerFunc) {
+	v.router.HandleFunc("/api/"+apiV5+path, v5Access(v.am, resource, model.ActionRead, f)).Methods(method)
+	v.registry.Describe(method, "/api/"+apiV5+path, route)
+}
+
+// RegisterV5Routes registers the /api/v5 routes, which respond in the V5Response envelope,
+// and the OpenAPI document of all the routes of the router. The router must be the router
+// of the public server, the document lists the routes registered on it when requested
+func (aH *APIHandler) RegisterV5Routes(router *mux.Router, am *AuthMiddleware) {
+	router.HandleFunc("/api/v5/openapi.json", am.OpenAccess(aH.getOpenAPIDocument(router))).Methods(http.MethodGet)
+
+	v5 := &v5Router{router: router, am: am, registry: aH.openAPI}
+	v5.handle(http.MethodGet, "/dashboards", model.ResourceDashboards, openapi.Route{
+		Summary: "List the dashboards", Tags: []string{"dashboards"}, Query: []string{"tags"}, Response: []dashboards.Dashboard{},
+	}, func(r *http.Request) (interface{}, *model.ApiError) {
+		return aH.listDashboards(r, r.URL.Query()["tags"])
+	})
+	v5.handle(http.MethodGet, "/dashboards/{uuid}", model.ResourceDashboards, openapi.Route{
+		Summary: "Get a dashboard", Tags: []string{"dashboards"}, Response: dashboards.Dashboard{},
+	}, func(r *http.Request) (interface{}, *model.ApiError) {
+		return aH.viewableDashboard(r, mux.Vars(r)["uuid"])
+	})
+	v5.handle(http.MethodGet, "/rules", model.ResourceAlerts, openapi.Route{
+		Summary: "List the alert rules", Tags: []string{"rules"}, Response: []*rules.GettableRule{},
+	}, func(r *http.Request) (interface{}, *model.ApiError) {
+		states, apiErr := aH.viewableRules(r)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		return states.Rules, nil
+	})
+	v5.handle(http.MethodGet, "/rules/{id}", model.ResourceAlerts, openapi.Route{
+		Summary: "Get an alert rule", Tags: []string{"rules"}, Response: rules.GettableRule{},
+	}, func(r *http.Request) (interface{}, *model.ApiError) {
+		id := mux.Vars(r)["id"]
+		if apiErr := aH.checkACL(r, access.ACLResourceRule, id, false); apiErr != nil {
+			return nil, apiErr
+		}
+		rule, err := aH.ruleManager.GetRule(r.Context(), id)
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		return rule, nil
+	})
+	v5.handle(http.MethodGet, "/views", model.ResourceSavedViews, openapi.Route{
+		Summary: "List the saved views", Tags: []string{"views"}, Query: []string{"sourcePage", "name", "category"}, Response: []*v3.SavedView{},
+	}, func(r *http.Request) (interface{}, *model.ApiError) {
+		query := r.URL.Query()
+		views, err := explorer.GetViewsForFilters(r.Context(), query.Get("sourcePage"), query.Get("name"), query.Get("category"))
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		return views, nil
+	})
+	v5.handle(http.MethodGet, "/views/{uuid}", model.ResourceSavedViews, openapi.Route{
+		Summary: "Get a saved view", Tags: []string{"views"}, Response: v3.SavedView{},
+	}, func(r *http.Request) (interface{}, *model.ApiError) {
+		view, err := explorer.GetView(r.Context(), mux.Vars(r)["uuid"])
+		if err != nil {
+			return nil, savedViewApiError(err)
+		}
+		return view, nil
+	})
+	v5.handle(http.MethodPost, "/services", "", openapi.Route{
+		Summary: "List the services with their RED metrics", Tags: []string{"services"},
+		Request: model.GetServicesParams{}, Response: []model.ServiceItem{},
+	}, func(r *http.Request) (interface{}, *model.ApiError) {
+		query, err := parseGetServicesRequest(r)
+		if err != nil {
+			return nil, model.BadRequest(err)
+		}
+		return aH.reader.GetServices(r.Context(), query, aH.skipConfig)
+	})
+}
+
+// getOpenAPIDocument responds with the OpenAPI document of the routes of the router
+func (aH *APIHandler) getOpenAPIDocument(router *mux.Router) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := aH.openAPI.Generate(router, openapi.Info{Title: "SigNoz", Version: version.GetVersion()})
+		if err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(doc); err != nil {
+			zap.L().Error("error writing response", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/openapi"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestV5Access(t *testing.T) {
+	am := NewAuthMiddleware(func(r *http.Request) (*model.UserPayload, error) {
+		return nil, errors.New("no token")
+	})
+	handler := v5Access(am, model.ResourceDashboards, model.ActionRead, func(r *http.Request) (interface{}, *model.ApiError) {
+		return "unreachable", nil
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/api/v5/dashboards", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"version": "v5", "status": "error", "error": {"type": "unauthorized", "message": "no token"}}`, w.Body.String())
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	aH := &APIHandler{openAPI: newOpenAPIRegistry()}
+	router := mux.NewRouter()
+	am := NewAuthMiddleware(func(r *http.Request) (*model.UserPayload, error) {
+		return nil, errors.New("no token")
+	})
+	router.HandleFunc("/api/v1/rules", am.ViewAccess(aH.listRules)).Methods(http.MethodGet)
+	aH.RegisterV5Routes(router, am)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v5/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var doc openapi.Document
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+
+	assert.Contains(t, doc.Paths, "/api/v1/rules")
+	assert.Contains(t, doc.Paths, "/api/v5/openapi.json")
+	rule := doc.Paths["/api/v5/rules/{id}"]["get"]
+	require.NotNil(t, rule)
+	envelope := rule.Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, &openapi.Schema{Ref: "#/components/schemas/rules.GettableRule"}, envelope.Properties["data"])
+	assert.Equal(t, &openapi.Schema{Type: "string"}, envelope.Properties["version"])
+	assert.Contains(t, doc.Components.Schemas, "rules.GettableRule")
+	assert.Contains(t, doc.Paths["/api/v5/services"], "post")
+}
@@ -0,0 +1,334 @@
+package attributemetadata
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	metadataDB  = "signoz_metadata"
+	keysTable   = "distributed_attribute_keys"
+	valuesTable = "distributed_attribute_values"
+
+	logsDB         = "signoz_logs"
+	logsLocalTable = "logs"
+
+	syncInterval = time.Minute
+	// syncDelay is how far behind now the attributes are synced, so the attributes still
+	// being written are not missed
+	syncDelay = time.Minute
+	// backfillWindow is the window of the first sync, the attribute tables of the signals
+	// keep two days
+	backfillWindow = 48 * time.Hour
+
+	// maxValueLength is the length of the longest values kept, the longer ones are not
+	// useful to autocomplete
+	maxValueLength = 256
+	defaultLimit   = 50
+)
+
+// source is a signal with the statements copying the keys and the values of its attributes
+// seen in a range into the metadata tables
+type source struct {
+	signal v3.DataSource
+	keys   string
+	values string
+}
+
+var sources = []source{
+	{
+		signal: v3.DataSourceLogs,
+		keys: fmt.Sprintf(`INSERT INTO %s.%s (signal, key, type, data_type, is_column, count, last_seen)
+			SELECT 'logs', tagKey, toString(tagType), toString(tagDataType), 0, count(), max(timestamp)
+			FROM signoz_logs.distributed_tag_attributes WHERE timestamp > $1 AND timestamp <= $2
+			GROUP BY tagKey, tagType, tagDataType`, metadataDB, keysTable),
+		values: fmt.Sprintf(`INSERT INTO %s.%s (signal, key, type, data_type, value, count, last_seen)
+			SELECT 'logs', tagKey, toString(tagType), toString(tagDataType),
+			multiIf(tagDataType = 'int64', ifNull(toString(int64TagValue), ''), tagDataType = 'float64', ifNull(toString(float64TagValue), ''), stringTagValue) AS tagValue,
+			count(), max(timestamp)
+			FROM signoz_logs.distributed_tag_attributes WHERE timestamp > $1 AND timestamp <= $2 AND tagDataType != 'bool'
+			GROUP BY tagKey, tagType, tagDataType, tagValue HAVING tagValue != '' AND length(tagValue) <= %d`, metadataDB, valuesTable, maxValueLength),
+	},
+	{
+		signal: v3.DataSourceTraces,
+		keys: fmt.Sprintf(`INSERT INTO %s.%s (signal, key, type, data_type, is_column, count, last_seen)
+			SELECT 'traces', tagKey, toString(tagType), toString(dataType), toUInt8(max(isColumn)), count(), max(timestamp)
+			FROM signoz_traces.distributed_span_attributes WHERE timestamp > $1 AND timestamp <= $2
+			GROUP BY tagKey, tagType, dataType`, metadataDB, keysTable),
+		values: fmt.Sprintf(`INSERT INTO %s.%s (signal, key, type, data_type, value, count, last_seen)
+			SELECT 'traces', tagKey, toString(tagType), toString(dataType),
+			if(dataType = 'float64', ifNull(toString(float64TagValue), ''), stringTagValue) AS tagValue,
+			count(), max(timestamp)
+			FROM signoz_traces.distributed_span_attributes WHERE timestamp > $1 AND timestamp <= $2 AND dataType != 'bool'
+			GROUP BY tagKey, tagType, dataType, tagValue HAVING tagValue != '' AND length(tagValue) <= %d`, metadataDB, valuesTable, maxValueLength),
+	},
+}
+
+type ManagerOptions struct {
+	DB   *sqlx.DB
+	Conn clickhouse.Conn
+}
+
+// Manager maintains the metadata tables of the attributes of the logs and the spans and
+// serves their autocomplete from them, instead of scanning the attribute tables of the
+// signals. Every minute the manager copies the keys and the values seen since the last sync,
+// with how often and when they were seen, into the metadata tables which merge them
+type Manager struct {
+	repo *repo
+	conn clickhouse.Conn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	if err := InitSqliteDBIfNeeded(opts.DB); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		repo:   &repo{db: opts.DB},
+		conn:   opts.Conn,
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Start syncs the metadata every minute
+func (m *Manager) Start() error {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(syncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.sync(m.ctx, time.Now().UTC()); err != nil {
+					zap.L().Error("failed to sync the attribute metadata", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// sync copies the attributes seen since the last sync of each signal. A sync failing after
+// copying the keys copies them again, it only inflates their counts
+func (m *Manager) sync(ctx context.Context, now time.Time) error {
+	until := now.Add(-syncDelay).Truncate(time.Second)
+	for _, s := range sources {
+		start, err := m.repo.syncedUntil(ctx, string(s.signal))
+		if err != nil {
+			return err
+		}
+		if start.IsZero() {
+			start = until.Add(-backfillWindow)
+		}
+		if !until.After(start) {
+			continue
+		}
+		if err := m.conn.Exec(ctx, s.keys, start, until); err != nil {
+			return fmt.Errorf("couldn't sync the attribute keys of the %s: %w", s.signal, err)
+		}
+		if err := m.conn.Exec(ctx, s.values, start, until); err != nil {
+			return fmt.Errorf("couldn't sync the attribute values of the %s: %w", s.signal, err)
+		}
+		if err := m.repo.setSyncedUntil(ctx, string(s.signal), until); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Serves tells whether the autocomplete of the key of the data source, of all its keys when
+// empty, is served from the metadata. The metrics, the top level fields of the logs and the
+// signals not synced yet are served from their own tables
+func (m *Manager) Serves(ctx context.Context, dataSource v3.DataSource, key string) bool {
+	if m == nil {
+		return false
+	}
+	switch dataSource {
+	case v3.DataSourceLogs:
+		if _, ok := constants.StaticFieldsLogsV3[key]; ok && key != "" {
+			return false
+		}
+	case v3.DataSourceTraces:
+	default:
+		return false
+	}
+	until, err := m.repo.syncedUntil(ctx, string(dataSource))
+	return err == nil && !until.IsZero()
+}
+
+// likePattern returns the pattern matching the strings containing the text
+func likePattern(text string) string {
+	text = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+	return "%" + text + "%"
+}
+
+// keysQuery returns the query of the keys containing the search text, the keys starting with
+// it first and then the keys seen the most
+func keysQuery(limit int) string {
+	return fmt.Sprintf(`SELECT key, type, data_type, max(is_column) AS is_column
+		FROM %s.%s WHERE signal = $1 AND key ILIKE $2
+		GROUP BY key, type, data_type
+		ORDER BY startsWith(lower(key), lower($3)) DESC, sum(count) DESC, max(last_seen) DESC, key
+		LIMIT %d`, metadataDB, keysTable, limit)
+}
+
+// valuesQuery returns the query of the values of a key containing the search text, ranked
+// as the keys
+func valuesQuery(limit int) string {
+	return fmt.Sprintf(`SELECT value
+		FROM %s.%s WHERE signal = $1 AND key = $2 AND type = $3 AND data_type = $4 AND value ILIKE $5
+		GROUP BY value
+		ORDER BY startsWith(lower(value), lower($6)) DESC, sum(count) DESC, max(last_seen) DESC, value
+		LIMIT %d`, metadataDB, valuesTable, limit)
+}
+
+type valueRow struct {
+	Value string `ch:"value"`
+}
+
+type keyRow struct {
+	Key      string `ch:"key"`
+	Type     string `ch:"type"`
+	DataType string `ch:"data_type"`
+	IsColumn uint8  `ch:"is_column"`
+}
+
+// Keys returns the attribute keys of the data source containing the search text
+func (m *Manager) Keys(ctx context.Context, req *v3.FilterAttributeKeyRequest) (*v3.FilterAttributeKeyResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	rows := []keyRow{}
+	err := m.conn.Select(ctx, &rows, keysQuery(limit), string(req.DataSource), likePattern(req.SearchText), req.SearchText)
+	if err != nil {
+		zap.L().Error("Error while reading the attribute keys", zap.Error(err))
+		return nil, fmt.Errorf("error while executing query: %s", err.Error())
+	}
+
+	// the materialized attributes of the logs are the columns of their table
+	var logsSchema string
+	if req.DataSource == v3.DataSourceLogs {
+		statements := []model.ShowCreateTableStatement{}
+		if err := m.conn.Select(ctx, &statements, fmt.Sprintf("SHOW CREATE TABLE %s.%s", logsDB, logsLocalTable)); err != nil {
+			return nil, fmt.Errorf("error while fetching logs schema: %s", err.Error())
+		}
+		if len(statements) > 0 {
+			logsSchema = statements[0].Statement
+		}
+	}
+
+	response := &v3.FilterAttributeKeyResponse{}
+	for _, row := range rows {
+		key := v3.AttributeKey{
+			Key:      row.Key,
+			DataType: v3.AttributeKeyDataType(row.DataType),
+			Type:     v3.AttributeKeyType(row.Type),
+			IsColumn: row.IsColumn > 0,
+		}
+		switch req.DataSource {
+		case v3.DataSourceLogs:
+			key.IsColumn = strings.Contains(logsSchema, utils.GetClickhouseColumnName(row.Type, row.DataType, row.Key)+" ")
+		case v3.DataSourceTraces:
+			key.Key = traceKey(row.Key)
+		}
+		response.AttributeKeys = append(response.AttributeKeys, key)
+	}
+
+	if req.DataSource == v3.DataSourceLogs {
+		for _, f := range constants.StaticFieldsLogsV3 {
+			if (v3.AttributeKey{} == f) {
+				continue
+			}
+			if len(req.SearchText) == 0 || strings.Contains(f.Key, req.SearchText) {
+				response.AttributeKeys = append(response.AttributeKeys, f)
+			}
+		}
+	}
+	return response, nil
+}
+
+// traceKey returns the key of the fixed columns of the spans whose name changed in the
+// attribute tables
+func traceKey(key string) string {
+	switch key {
+	case "traceId":
+		return "traceID"
+	case "spanId":
+		return "spanID"
+	case "parentSpanId":
+		return "parentSpanID"
+	}
+	return key
+}
+
+// Values returns the values of the attribute key containing the search text
+func (m *Manager) Values(ctx context.Context, req *v3.FilterAttributeValueRequest) (*v3.FilterAttributeValueResponse, error) {
+	response := &v3.FilterAttributeValueResponse{}
+	if len(req.FilterAttributeKeyDataType) == 0 || len(req.TagType) == 0 || req.FilterAttributeKey == "body" {
+		return response, nil
+	}
+	if req.FilterAttributeKeyDataType == v3.AttributeKeyDataTypeBool {
+		response.BoolAttributeValues = []bool{true, false}
+		return response, nil
+	}
+
+	// the numbers of the spans are all float64
+	dataType := req.FilterAttributeKeyDataType
+	if req.DataSource == v3.DataSourceTraces && dataType == v3.AttributeKeyDataTypeInt64 {
+		dataType = v3.AttributeKeyDataTypeFloat64
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	rows := []valueRow{}
+	err := m.conn.Select(ctx, &rows, valuesQuery(limit), string(req.DataSource), req.FilterAttributeKey, string(req.TagType),
+		string(dataType), likePattern(req.SearchText), req.SearchText)
+	if err != nil {
+		zap.L().Error("Error while reading the attribute values", zap.Error(err))
+		return nil, fmt.Errorf("error while executing query: %s", err.Error())
+	}
+
+	for _, row := range rows {
+		value := row.Value
+		switch dataType {
+		case v3.AttributeKeyDataTypeInt64:
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				response.NumberAttributeValues = append(response.NumberAttributeValues, n)
+			}
+		case v3.AttributeKeyDataTypeFloat64:
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				response.NumberAttributeValues = append(response.NumberAttributeValues, n)
+			}
+		default:
+			response.StringAttributeValues = append(response.StringAttributeValues, value)
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,52 @@
+package attributemetadata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+func TestServes(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewManager(ManagerOptions{DB: utils.NewQueryServiceDBForTests(t)})
+	require.NoError(t, err)
+
+	// the signals are served from their own tables until synced
+	require.False(t, m.Serves(ctx, v3.DataSourceLogs, ""))
+	require.NoError(t, m.repo.setSyncedUntil(ctx, string(v3.DataSourceLogs), time.Now().UTC()))
+	require.True(t, m.Serves(ctx, v3.DataSourceLogs, ""))
+	require.True(t, m.Serves(ctx, v3.DataSourceLogs, "http.method"))
+	require.False(t, m.Serves(ctx, v3.DataSourceLogs, "severity_text"))
+	require.False(t, m.Serves(ctx, v3.DataSourceTraces, ""))
+	require.False(t, m.Serves(ctx, v3.DataSourceMetrics, ""))
+
+	until := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, m.repo.setSyncedUntil(ctx, string(v3.DataSourceLogs), until))
+	synced, err := m.repo.syncedUntil(ctx, string(v3.DataSourceLogs))
+	require.NoError(t, err)
+	require.True(t, synced.Equal(until))
+
+	var nilManager *Manager
+	require.False(t, nilManager.Serves(ctx, v3.DataSourceLogs, ""))
+}
+
+func TestLikePattern(t *testing.T) {
+	require.Equal(t, "%%", likePattern(""))
+	require.Equal(t, "%http.method%", likePattern("http.method"))
+	require.Equal(t, `%k8s\_pod\%%`, likePattern("k8s_pod%"))
+}
+
+func TestKeysQuery(t *testing.T) {
+	require.Equal(t, `SELECT key, type, data_type, max(is_column) AS is_column
+		FROM signoz_metadata.distributed_attribute_keys WHERE signal = $1 AND key ILIKE $2
+		GROUP BY key, type, data_type
+		ORDER BY startsWith(lower(key), lower($3)) DESC, sum(count) DESC, max(last_seen) DESC, key
+		LIMIT 50`, keysQuery(50))
+	require.Equal(t, "traceID", traceKey("traceId"))
+	require.Equal(t, "http.method", traceKey("http.method"))
+}
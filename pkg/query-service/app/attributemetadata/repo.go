@@ -0,0 +1,55 @@
+package attributemetadata
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS attribute_metadata_syncs (
+		signal TEXT PRIMARY KEY,
+		synced_until datetime NOT NULL
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating attribute_metadata_syncs table: %s", err.Error())
+	}
+	return nil
+}
+
+type repo struct {
+	db *sqlx.DB
+}
+
+// syncedUntil returns the end of the last sync of the signal, the zero time when it was
+// never synced
+func (r *repo) syncedUntil(ctx context.Context, signal string) (time.Time, error) {
+	var until time.Time
+	err := r.db.GetContext(ctx, &until, "SELECT synced_until FROM attribute_metadata_syncs WHERE signal=$1", signal)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return time.Time{}, err
+	}
+	return until, nil
+}
+
+func (r *repo) setSyncedUntil(ctx context.Context, signal string, until time.Time) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO attribute_metadata_syncs (signal, synced_until) VALUES ($1, $2) "+
+		"ON CONFLICT(signal) DO UPDATE SET synced_until=excluded.synced_until", signal, until)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	// pruneInterval is the interval at which the entries past the retention are deleted
+	pruneInterval = time.Hour
+	// queueSize is the number of entries waiting to be written past which they are dropped
+	queueSize = 1000
+
+	defaultListLimit = 100
+	maxListLimit     = 1000
+	// maxExportEntries is the number of entries past which the exports are truncated
+	maxExportEntries = 100000
+)
+
+type ManagerOptions struct {
+	DB *sqlx.DB
+	// Retention is how long the entries are kept
+	Retention time.Duration
+}
+
+// Manager records the administrative actions of the users, the entries are written in
+// the background so the requests don't wait on the db
+type Manager struct {
+	repo      *repo
+	retention time.Duration
+
+	queue  chan *Entry
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	if err := InitSqliteDBIfNeeded(opts.DB); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		repo:      &repo{db: opts.DB},
+		retention: opts.Retention,
+		queue:     make(chan *Entry, queueSize),
+		ctx:       ctx,
+		cancel:    cancel,
+	}, nil
+}
+
+// Start writes the recorded entries and prunes the entries past the retention
+func (m *Manager) Start() error {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.ctx.Done():
+				m.drain()
+				return
+			case e := <-m.queue:
+				m.write(e)
+			case <-ticker.C:
+				m.prune()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop writes the entries left in the queue and stops the manager
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+func (m *Manager) drain() {
+	for {
+		select {
+		case e := <-m.queue:
+			m.write(e)
+		default:
+			return
+		}
+	}
+}
+
+func (m *Manager) write(e *Entry) {
+	if err := m.repo.insert(context.Background(), e); err != nil {
+		zap.L().Error("failed to record action in the audit log", zap.Error(err))
+	}
+}
+
+func (m *Manager) prune() {
+	if m.retention <= 0 {
+		return
+	}
+	if err := m.repo.prune(context.Background(), time.Now().Add(-m.retention)); err != nil {
+		zap.L().Error("failed to prune the audit log", zap.Error(err))
+	}
+}
+
+// Record adds the action to the audit log. The entry is dropped, and logged, when the
+// queue is full rather than slowing down the requests
+func (m *Manager) Record(e *Entry) {
+	select {
+	case m.queue <- e:
+	default:
+		zap.L().Warn("audit log queue is full, dropping the action",
+			zap.String("userEmail", e.UserEmail),
+			zap.String("action", e.Action),
+			zap.String("resource", e.Resource),
+			zap.String("path", e.Path),
+		)
+	}
+}
+
+// List returns the entries of the audit log selected by the filter, the newest first
+func (m *Manager) List(ctx context.Context, f Filter) ([]*Entry, error) {
+	if f.Limit <= 0 {
+		f.Limit = defaultListLimit
+	}
+	if f.Limit > maxListLimit {
+		f.Limit = maxListLimit
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	return m.repo.list(ctx, f)
+}
+
+// Export returns all the entries of the audit log selected by the filter, the newest first,
+// the limit and offset of the filter are ignored
+func (m *Manager) Export(ctx context.Context, f Filter) ([]*Entry, error) {
+	f.Limit = maxExportEntries
+	f.Offset = 0
+	return m.repo.list(ctx, f)
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+func TestManagerRecordAndList(t *testing.T) {
+	db := utils.NewQueryServiceDBForTests(t)
+	m, err := NewManager(ManagerOptions{DB: db, Retention: time.Hour})
+	require.NoError(t, err)
+	require.NoError(t, m.Start())
+
+	now := time.Now()
+	m.Record(&Entry{OrgId: "org", UserId: "alice", Action: ActionLogin, Resource: ResourceSession, StatusCode: 200, CreatedAt: now.Add(-3 * time.Minute)})
+	m.Record(&Entry{OrgId: "org", UserId: "alice", Action: ActionUpdate, Resource: ResourceRole, ResourceId: "bob", StatusCode: 200, CreatedAt: now.Add(-2 * time.Minute)})
+	m.Record(&Entry{OrgId: "org", UserId: "bob", Action: ActionCreate, Resource: ResourceAPIKey, StatusCode: 403, CreatedAt: now.Add(-time.Minute)})
+	m.Record(&Entry{UserEmail: "unknown@signoz.io", Action: ActionLogin, Resource: ResourceSession, StatusCode: 401, CreatedAt: now})
+	m.Record(&Entry{OrgId: "other", UserId: "carol", Action: ActionDelete, Resource: ResourceDashboard, StatusCode: 200, CreatedAt: now})
+	// the recorded entries are written once stopped
+	m.Stop()
+
+	ctx := context.Background()
+	entries, err := m.List(ctx, Filter{OrgId: "org"})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	require.Equal(t, "unknown@signoz.io", entries[0].UserEmail)
+	require.Equal(t, "alice", entries[3].UserId)
+
+	entries, err = m.List(ctx, Filter{OrgId: "org", UserId: "alice", Resource: ResourceRole})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "bob", entries[0].ResourceId)
+
+	entries, err = m.List(ctx, Filter{OrgId: "org", Action: ActionLogin})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	entries, err = m.List(ctx, Filter{OrgId: "org", Start: now.Add(-150 * time.Second), End: now.Add(-30 * time.Second)})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	entries, err = m.List(ctx, Filter{OrgId: "org", Limit: 3, Offset: 3})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	entries, err = m.Export(ctx, Filter{OrgId: "org", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+}
+
+func TestManagerPrune(t *testing.T) {
+	db := utils.NewQueryServiceDBForTests(t)
+	m, err := NewManager(ManagerOptions{DB: db, Retention: time.Hour})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, m.repo.insert(ctx, &Entry{OrgId: "org", UserId: "alice", CreatedAt: time.Now().Add(-2 * time.Hour)}))
+	require.NoError(t, m.repo.insert(ctx, &Entry{OrgId: "org", UserId: "bob", CreatedAt: time.Now()}))
+	m.prune()
+
+	entries, err := m.List(ctx, Filter{OrgId: "org"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "bob", entries[0].UserId)
+}
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		method   string
+		path     string
+		resource string
+		action   string
+		ok       bool
+	}{
+		{http.MethodPost, "/api/v1/login", ResourceSession, ActionLogin, true},
+		{http.MethodPut, "/api/v1/rbac/role/{id}", ResourceRole, ActionUpdate, true},
+		{http.MethodPatch, "/api/v1/rules/{id}", ResourceAlert, ActionUpdate, true},
+		{http.MethodDelete, "/api/v1/dashboards/{uuid}", ResourceDashboard, ActionDelete, true},
+		{http.MethodPost, "/api/v1/logs/pipelines", ResourcePipeline, ActionCreate, true},
+		{http.MethodPost, "/api/v1/pats", ResourceAPIKey, ActionCreate, true},
+		{http.MethodPost, "/api/v1/settings/ttl", ResourceSettings, ActionCreate, true},
+		{http.MethodPost, "/api/v1/settings/ttl/preview", "", "", false},
+		{http.MethodGet, "/api/v1/rules/{id}", "", "", false},
+		{http.MethodPost, "/api/v1/rules/test", "", "", false},
+		{http.MethodPost, "/api/v3/query_range", "", "", false},
+	}
+
+	for _, tc := range testCases {
+		resource, action, ok := classify(tc.method, tc.path)
+		require.Equal(t, tc.ok, ok, "%s %s", tc.method, tc.path)
+		if tc.ok {
+			require.Equal(t, tc.resource, resource, "%s %s", tc.method, tc.path)
+			require.Equal(t, tc.action, action, "%s %s", tc.method, tc.path)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const loginPath = "/api/v1/login"
+
+// auditedRoutes are the path templates of the routes whose changes are recorded, with the
+// resource they change. The action is the login for the login route, otherwise it is
+// taken from the method of the request
+var auditedRoutes = map[string]string{
+	loginPath:                                    ResourceSession,
+	"/api/v1/register":                           ResourceUser,
+	"/api/v1/user/{id}":                          ResourceUser,
+	"/api/v1/changePassword/{id}":                ResourceUser,
+	"/api/v1/resetPassword":                      ResourceUser,
+	"/api/v1/user/{id}/sessions":                 ResourceSession,
+	"/api/v1/user/{id}/sessions/{sessionId}":     ResourceSession,
+	"/api/v1/user/{id}/totp":                     ResourceUser,
+	"/api/v1/user/{id}/totp/verify":              ResourceUser,
+	"/api/v1/user/{id}/totp/recovery_codes":      ResourceUser,
+	"/api/v1/invite":                             ResourceInvite,
+	"/api/v1/invite/{email}":                     ResourceInvite,
+	"/api/v1/rbac/role/{id}":                     ResourceRole,
+	"/api/v1/rbac/roles":                         ResourceRole,
+	"/api/v1/rbac/roles/{id}":                    ResourceRole,
+	"/api/v1/teams":                              ResourceTeam,
+	"/api/v1/teams/{id}":                         ResourceTeam,
+	"/api/v1/oncall/schedules":                   ResourceOnCallSchedule,
+	"/api/v1/oncall/schedules/{id}":              ResourceOnCallSchedule,
+	"/api/v1/rules":                              ResourceAlert,
+	"/api/v1/rules/{id}":                         ResourceAlert,
+	"/api/v1/rules/import/prometheus":            ResourceAlert,
+	"/api/v1/rules/{id}/acl":                     ResourceAccessList,
+	"/api/v1/channels":                           ResourceChannel,
+	"/api/v1/channels/{id}":                      ResourceChannel,
+	"/api/v1/dashboards":                         ResourceDashboard,
+	"/api/v1/dashboards/{uuid}":                  ResourceDashboard,
+	"/api/v1/dashboards/{uuid}/folder":           ResourceDashboard,
+	"/api/v1/dashboards/{uuid}/lock":             ResourceDashboard,
+	"/api/v1/dashboards/{uuid}/unlock":           ResourceDashboard,
+	"/api/v1/dashboards/grafana/import":          ResourceDashboard,
+	"/api/v1/dashboards/{uuid}/share_links":      ResourceDashboard,
+	"/api/v1/dashboards/{uuid}/share_links/{id}": ResourceDashboard,
+	"/api/v1/dashboards/{uuid}/acl":              ResourceAccessList,
+	"/api/v1/logs/pipelines":                     ResourcePipeline,
+	"/api/v1/pats":                               ResourceAPIKey,
+	"/api/v1/pats/{id}":                          ResourceAPIKey,
+	"/api/v1/pats/{id}/rotate":                   ResourceAPIKey,
+	"/api/v1/settings/ingestion_key":             ResourceAPIKey,
+	"/api/v1/settings/ingestion_key/{id}":        ResourceAPIKey,
+	"/api/v1/org":                                ResourceOrg,
+	"/api/v1/org/{id}":                           ResourceOrg,
+	"/api/v1/org/{id}/members":                   ResourceOrg,
+	"/api/v1/org/{id}/members/{userId}":          ResourceOrg,
+	"/api/v1/org/{id}/two_factor":                ResourceSettings,
+	"/api/v1/domains":                            ResourceDomain,
+	"/api/v1/domains/{id}":                       ResourceDomain,
+	"/api/v1/licenses":                           ResourceLicense,
+	"/api/v1/webhooks":                           ResourceWebhook,
+	"/api/v1/webhooks/{id}":                      ResourceWebhook,
+	"/api/v1/webhooks/{id}/test":                 ResourceWebhook,
+	"/api/v1/issue_trackers":                     ResourceIssueTracker,
+	"/api/v1/issue_trackers/{id}":                ResourceIssueTracker,
+	"/api/v1/issue_trackers/{id}/issues":         ResourceIssue,
+	"/api/v1/issues/{id}/close":                  ResourceIssue,
+	"/api/v1/errors/groups/{fingerprint}":        ResourceErrorGroup,
+	"/api/v1/errors/ignore_rules":                ResourceErrorIgnore,
+	"/api/v1/errors/ignore_rules/{id}":           ResourceErrorIgnore,
+	"/api/v1/deployments":                        ResourceDeployment,
+	"/api/v1/deployments/{id}":                   ResourceDeployment,
+	"/api/v1/logs/metric_rules":                  ResourceLogMetricRule,
+	"/api/v1/logs/metric_rules/{id}":             ResourceLogMetricRule,
+}
+
+// settingsPrefix is the prefix of the settings routes, all their changes are recorded
+// but for the previews
+const settingsPrefix = "/api/v1/settings/"
+
+// classify returns the resource and the action of the request, ok is false when the
+// request is not recorded
+func classify(method, pathTemplate string) (resource, action string, ok bool) {
+	switch method {
+	case http.MethodPost:
+		action = ActionCreate
+	case http.MethodPut, http.MethodPatch:
+		action = ActionUpdate
+	case http.MethodDelete:
+		action = ActionDelete
+	default:
+		return "", "", false
+	}
+
+	resource, ok = auditedRoutes[pathTemplate]
+	if !ok && strings.HasPrefix(pathTemplate, settingsPrefix) && !strings.HasSuffix(pathTemplate, "/preview") {
+		resource, ok = ResourceSettings, true
+	}
+	if pathTemplate == loginPath {
+		action = ActionLogin
+	}
+	return resource, action, ok
+}
+
+// resourceId returns the id of the resource in the path of the request, the innermost
+// resource of the nested routes
+func resourceId(vars map[string]string) string {
+	for _, name := range []string{"id", "uuid", "email"} {
+		if v, ok := vars[name]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.statusCode = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Middleware records the administrative actions, once served, in the audit log. The user
+// is read with getUser, the user of a login is looked up from the email of the request
+func (m *Manager) Middleware(getUser func(*http.Request) (*model.UserPayload, error)) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := mux.CurrentRoute(r)
+			if route == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			path, _ := route.GetPathTemplate()
+			resource, action, ok := classify(r.Method, path)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var loginEmail string
+			if action == ActionLogin {
+				loginEmail = peekLoginEmail(r)
+			}
+
+			startedAt := time.Now()
+			sw := &statusWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(sw, r)
+
+			entry := &Entry{
+				UserEmail:  loginEmail,
+				Action:     action,
+				Resource:   resource,
+				ResourceId: resourceId(mux.Vars(r)),
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				StatusCode: sw.statusCode,
+				RemoteAddr: r.RemoteAddr,
+				CreatedAt:  startedAt,
+			}
+
+			var user *model.UserPayload
+			if action == ActionLogin {
+				if loginEmail != "" {
+					user, _ = dao.DB().GetUserByEmail(r.Context(), loginEmail)
+				}
+			} else {
+				user, _ = getUser(r)
+			}
+			if user != nil {
+				entry.OrgId = user.OrgId
+				entry.UserId = user.Id
+				entry.UserEmail = user.Email
+			}
+			m.Record(entry)
+		})
+	}
+}
+
+// peekLoginEmail reads the email of the login request, the body is restored for the handler
+func peekLoginEmail(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	var req model.LoginRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return req.Email
+}
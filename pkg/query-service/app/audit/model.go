@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"time"
+)
+
+// Actions of the entries
+const (
+	ActionLogin  = "login"
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Resources of the entries
+const (
+	ResourceSession        = "session"
+	ResourceUser           = "user"
+	ResourceInvite         = "invite"
+	ResourceRole           = "role"
+	ResourceTeam           = "team"
+	ResourceOnCallSchedule = "oncall_schedule"
+	ResourceAlert          = "alert"
+	ResourceChannel        = "channel"
+	ResourceDashboard      = "dashboard"
+	ResourcePipeline       = "pipeline"
+	ResourceAPIKey         = "api_key"
+	ResourceSettings       = "settings"
+	ResourceOrg            = "org"
+	ResourceDomain         = "domain"
+	ResourceLicense        = "license"
+	ResourceAccessList     = "access_list"
+	ResourceNotifications  = "notifications"
+	ResourceWebhook        = "webhook"
+	ResourceIssueTracker   = "issue_tracker"
+	ResourceIssue          = "issue"
+	ResourceErrorGroup     = "error_group"
+	ResourceErrorIgnore    = "error_ignore_rule"
+	ResourceDeployment     = "deployment"
+	ResourceLogMetricRule  = "log_metric_rule"
+)
+
+// Entry is an administrative action done by a user
+type Entry struct {
+	Id        int64  `json:"id" db:"id"`
+	OrgId     string `json:"orgId" db:"org_id"`
+	UserId    string `json:"userId" db:"user_id"`
+	UserEmail string `json:"userEmail" db:"user_email"`
+	Action    string `json:"action" db:"action"`
+	Resource  string `json:"resource" db:"resource"`
+	// ResourceId is the id of the resource in the path of the request, if any
+	ResourceId string `json:"resourceId" db:"resource_id"`
+	Method     string `json:"method" db:"method"`
+	Path       string `json:"path" db:"path"`
+	// StatusCode is the status code of the response, the failed actions are recorded as well
+	StatusCode int       `json:"statusCode" db:"status_code"`
+	RemoteAddr string    `json:"remoteAddr" db:"remote_addr"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+}
+
+// Filter selects the entries of the audit log of an org, the newest first
+type Filter struct {
+	OrgId     string
+	UserId    string
+	UserEmail string
+	Action    string
+	Resource  string
+	// Start and End select the entries created in the range, they are ignored when zero
+	Start  time.Time
+	End    time.Time
+	Limit  int
+	Offset int
+}
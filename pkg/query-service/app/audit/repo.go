@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		org_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		user_email TEXT NOT NULL,
+		action TEXT NOT NULL,
+		resource TEXT NOT NULL,
+		resource_id TEXT NOT NULL,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		status_code INTEGER NOT NULL,
+		remote_addr TEXT NOT NULL,
+		created_at datetime NOT NULL
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating audit_logs table: %s", err.Error())
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);`)
+	if err != nil {
+		return fmt.Errorf("error in creating audit_logs index: %s", err.Error())
+	}
+	return nil
+}
+
+const entryColumns = "id, org_id, user_id, user_email, action, resource, resource_id, method, path, status_code, remote_addr, created_at"
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func (r *repo) insert(ctx context.Context, e *Entry) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO audit_logs (org_id, user_id, user_email, action, resource, resource_id, method, path, status_code, remote_addr, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+		e.OrgId, e.UserId, e.UserEmail, e.Action, e.Resource, e.ResourceId, e.Method, e.Path, e.StatusCode, e.RemoteAddr, e.CreatedAt)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *repo) list(ctx context.Context, f Filter) ([]*Entry, error) {
+	// the failed logins of unknown users have no org, they are listed with the entries of every org
+	conditions := []string{"(org_id = ? OR org_id = '')"}
+	args := []interface{}{f.OrgId}
+	if f.UserId != "" {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, f.UserId)
+	}
+	if f.UserEmail != "" {
+		conditions = append(conditions, "user_email = ?")
+		args = append(args, f.UserEmail)
+	}
+	if f.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, f.Action)
+	}
+	if f.Resource != "" {
+		conditions = append(conditions, "resource = ?")
+		args = append(args, f.Resource)
+	}
+	if !f.Start.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, f.Start)
+	}
+	if !f.End.IsZero() {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, f.End)
+	}
+	args = append(args, f.Limit, f.Offset)
+
+	entries := []*Entry{}
+	query := "SELECT " + entryColumns + " FROM audit_logs WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?"
+	if err := r.db.SelectContext(ctx, &entries, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return entries, nil
+}
+
+// prune deletes the entries older than before
+func (r *repo) prune(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM audit_logs WHERE created_at < $1", before)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
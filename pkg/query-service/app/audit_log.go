@@ -0,0 +1,141 @@
+package app
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/app/audit"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+var auditLogCSVHeader = []string{"id", "createdAt", "userId", "userEmail", "action", "resource", "resourceId", "method", "path", "statusCode", "remoteAddr"}
+
+// listAuditLogs lists the administrative actions done in the org of the user
+func (aH *APIHandler) listAuditLogs(w http.ResponseWriter, r *http.Request) {
+	user := common.GetUserFromContext(r.Context())
+	if user == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("failed to get user from context")}, nil)
+		return
+	}
+
+	filter, err := parseAuditLogFilter(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	filter.OrgId = user.OrgId
+
+	entries, err := aH.AuditManager.List(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, entries)
+}
+
+// exportAuditLogs downloads the administrative actions selected by the filter as csv, or as json
+func (aH *APIHandler) exportAuditLogs(w http.ResponseWriter, r *http.Request) {
+	user := common.GetUserFromContext(r.Context())
+	if user == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("failed to get user from context")}, nil)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		RespondError(w, model.BadRequest(fmt.Errorf("invalid format %q, supported formats are csv and json", format)), nil)
+		return
+	}
+	filter, err := parseAuditLogFilter(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	filter.OrgId = user.OrgId
+
+	entries, err := aH.AuditManager.Export(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	filename := fmt.Sprintf("audit_logs_%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(entries); err != nil {
+			zap.L().Error("failed to write the audit log export", zap.Error(err))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	cw := csv.NewWriter(w)
+	_ = cw.Write(auditLogCSVHeader)
+	for _, e := range entries {
+		_ = cw.Write([]string{
+			strconv.FormatInt(e.Id, 10),
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			e.UserId,
+			e.UserEmail,
+			e.Action,
+			e.Resource,
+			e.ResourceId,
+			e.Method,
+			e.Path,
+			strconv.Itoa(e.StatusCode),
+			e.RemoteAddr,
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		zap.L().Error("failed to write the audit log export", zap.Error(err))
+	}
+}
+
+// parseAuditLogFilter reads the filter of the audit log, start and end are in milliseconds
+func parseAuditLogFilter(r *http.Request) (audit.Filter, error) {
+	query := r.URL.Query()
+	filter := audit.Filter{
+		UserId:    query.Get("userId"),
+		UserEmail: query.Get("userEmail"),
+		Action:    query.Get("action"),
+		Resource:  query.Get("resource"),
+	}
+
+	var err error
+	if v := query.Get("start"); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid start %q", v)
+		}
+		filter.Start = time.UnixMilli(ms)
+	}
+	if v := query.Get("end"); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid end %q", v)
+		}
+		filter.End = time.UnixMilli(ms)
+	}
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return filter, fmt.Errorf("invalid limit %q", v)
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if filter.Offset, err = strconv.Atoi(v); err != nil {
+			return filter, fmt.Errorf("invalid offset %q", v)
+		}
+	}
+	return filter, nil
+}
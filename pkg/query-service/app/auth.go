@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -38,10 +39,34 @@ func (am *AuthMiddleware) ViewAccess(f func(http.ResponseWriter, *http.Request))
 			return
 		}
 
-		if !(auth.IsViewer(user) || auth.IsEditor(user) || auth.IsAdmin(user)) {
+		if !auth.HasRole(r.Context(), user) {
 			RespondError(w, &model.ApiError{
 				Typ: model.ErrorForbidden,
-				Err: errors.New("API is accessible to viewers/editors/admins"),
+				Err: errors.New("API is accessible to the users with a role"),
+			}, nil)
+			return
+		}
+		ctx := context.WithValue(r.Context(), constants.ContextUserKey, user)
+		r = r.WithContext(ctx)
+		f(w, r)
+	}
+}
+
+// Access checks the role of the user grants the action on the resource
+func (am *AuthMiddleware) Access(resource model.Resource, action model.Action, f func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := am.GetUserFromRequest(r)
+		if err != nil {
+			RespondError(w, &model.ApiError{
+				Typ: model.ErrorUnauthorized,
+				Err: err,
+			}, nil)
+			return
+		}
+		if !auth.HasPermission(r.Context(), user, resource, action) {
+			RespondError(w, &model.ApiError{
+				Typ: model.ErrorForbidden,
+				Err: fmt.Errorf("API requires the permission to %s %s", action, resource),
 			}, nil)
 			return
 		}
@@ -94,6 +119,13 @@ func (am *AuthMiddleware) SelfAccess(f func(http.ResponseWriter, *http.Request))
 		}
 		ctx := context.WithValue(r.Context(), constants.ContextUserKey, user)
 		r = r.WithContext(ctx)
+		// the admins only access the users of the org they work in
+		if !auth.IsSelfAccessRequest(user, id) {
+			if _, apiErr := userInActiveOrg(r, id); apiErr != nil {
+				RespondError(w, apiErr, nil)
+				return
+			}
+		}
 		f(w, r)
 	}
 }
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/app/backup"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// exportBackup downloads the metadata store, or the sections of it selected, as a gzipped tar archive
+func (aH *APIHandler) exportBackup(w http.ResponseWriter, r *http.Request) {
+	sections, err := backup.ParseSections(r.URL.Query().Get("sections"))
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	filename := fmt.Sprintf("signoz_metadata_%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if _, err := aH.BackupManager.Export(r.Context(), sections, w); err != nil {
+		// the archive may be partly written already
+		zap.L().Error("failed to write the metadata backup", zap.Error(err))
+	}
+}
+
+// restoreBackup restores the sections selected of the archive posted, the query service
+// has to be restarted for all of it to be used
+func (aH *APIHandler) restoreBackup(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	sections, err := backup.ParseSections(query.Get("sections"))
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	dryRun := false
+	if v := query.Get("dryRun"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			RespondError(w, model.BadRequest(fmt.Errorf("invalid dryRun %q", v)), nil)
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, backup.MaxArchiveBytes)
+	result, err := aH.BackupManager.Restore(r.Context(), body, sections, dryRun)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	aH.Respond(w, result)
+}
@@ -0,0 +1,317 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/version"
+	"go.uber.org/zap"
+)
+
+const (
+	manifestFile = "manifest.json"
+	tablesDir    = "tables"
+
+	// MaxArchiveBytes is the max size of an archive restored
+	MaxArchiveBytes = 256 << 20
+
+	// timestampFormat is the format the sqlite driver writes the times in, the times are
+	// restored in it so that they compare with the times written by the query service
+	timestampFormat = "2006-01-02 15:04:05.999999999-07:00"
+)
+
+// tableData is the content of a table in an archive
+type tableData struct {
+	Columns []string        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+func existingTables(ctx context.Context, q sqlx.QueryerContext) (map[string]struct{}, error) {
+	names := []string{}
+	if err := sqlx.SelectContext(ctx, q, &names, "SELECT name FROM sqlite_master WHERE type = 'table'"); err != nil {
+		return nil, errors.Wrap(err, "failed to list the tables")
+	}
+	tables := map[string]struct{}{}
+	for _, name := range names {
+		tables[name] = struct{}{}
+	}
+	return tables, nil
+}
+
+func readTable(ctx context.Context, tx *sqlx.Tx, table string) (*tableData, error) {
+	rows, err := tx.QueryxContext(ctx, fmt.Sprintf("SELECT * FROM %s", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	data := &tableData{Columns: columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			switch value := v.(type) {
+			case []byte:
+				values[i] = string(value)
+			case time.Time:
+				values[i] = value.Format(timestampFormat)
+			}
+		}
+		data.Rows = append(data.Rows, values)
+	}
+	return data, rows.Err()
+}
+
+// Export writes the tables of the sections to w as a gzipped tar archive, they are read
+// in a single transaction so that they are consistent with each other
+func Export(ctx context.Context, db *sqlx.DB, selected []Section, w io.Writer) (*Manifest, error) {
+	tx, err := db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin the backup")
+	}
+	defer tx.Rollback()
+
+	existing, err := existingTables(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	manifest := &Manifest{Version: version.GetVersion(), CreatedAt: time.Now(), Tables: []ManifestTable{}}
+	for _, sec := range sections {
+		if !contains(selected, sec.section) {
+			continue
+		}
+		for _, table := range sec.tables {
+			if _, ok := existing[table]; !ok {
+				continue
+			}
+			data, err := readTable(ctx, tx, table)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read the table %s", table)
+			}
+			if err := writeFile(tw, path.Join(tablesDir, table+".json"), data); err != nil {
+				return nil, err
+			}
+			manifest.Tables = append(manifest.Tables, ManifestTable{Name: table, Section: sec.section, Rows: len(data.Rows)})
+		}
+	}
+	if err := writeFile(tw, manifestFile, manifest); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, gz.Close()
+}
+
+func writeFile(tw *tar.Writer, name string, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), ModTime: time.Now()}); err != nil {
+		return err
+	}
+	_, err = tw.Write(content)
+	return err
+}
+
+// readArchive reads the manifest and the tables of an archive
+func readArchive(r io.Reader) (*Manifest, map[string]*tableData, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "the archive isn't gzipped")
+	}
+	tr := tar.NewReader(gz)
+
+	var manifest *Manifest
+	tables := map[string]*tableData{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to read the archive")
+		}
+		var content bytes.Buffer
+		if _, err := io.Copy(&content, tr); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to read the archive")
+		}
+
+		decoder := json.NewDecoder(&content)
+		// the integers are kept as they are rather than as floats
+		decoder.UseNumber()
+		switch {
+		case header.Name == manifestFile:
+			manifest = &Manifest{}
+			err = decoder.Decode(manifest)
+		case path.Dir(header.Name) == tablesDir && strings.HasSuffix(header.Name, ".json"):
+			data := &tableData{}
+			err = decoder.Decode(data)
+			tables[strings.TrimSuffix(path.Base(header.Name), ".json")] = data
+		}
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid %s in the archive", header.Name)
+		}
+	}
+	if manifest == nil {
+		return nil, nil, fmt.Errorf("the archive has no %s", manifestFile)
+	}
+	return manifest, tables, nil
+}
+
+// Restore replaces the tables of the selected sections by those of the archive. The
+// tables are restored in a single transaction, they are all restored or none is. The
+// columns of the archive a table no longer has are dropped, those it has but the archive
+// hasn't get their default. A dry run restores the tables and rolls the transaction back
+func Restore(ctx context.Context, db *sqlx.DB, r io.Reader, selected []Section, dryRun bool) (*RestoreResult, error) {
+	manifest, tables, err := readArchive(r)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin the restore")
+	}
+	defer tx.Rollback()
+	// the rows referred to are inserted back before the transaction ends
+	if _, err := tx.ExecContext(ctx, "PRAGMA defer_foreign_keys = ON"); err != nil {
+		return nil, err
+	}
+	existing, err := existingTables(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RestoreResult{Manifest: manifest, Tables: []*RestoredTable{}, DryRun: dryRun}
+	restore := []*RestoredTable{}
+	for _, sec := range sections {
+		if !contains(selected, sec.section) {
+			continue
+		}
+		for _, table := range sec.tables {
+			data, ok := tables[table]
+			if !ok {
+				continue
+			}
+			restored := &RestoredTable{Name: table, Section: sec.section, Rows: len(data.Rows)}
+			result.Tables = append(result.Tables, restored)
+			if _, ok := existing[table]; !ok {
+				restored.Skipped = "the metadata store has no such table"
+				continue
+			}
+			restore = append(restore, restored)
+		}
+	}
+
+	// the tables referring to others are emptied first
+	for i := len(restore) - 1; i >= 0; i-- {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", restore[i].Name)); err != nil {
+			return nil, errors.Wrapf(err, "failed to empty the table %s", restore[i].Name)
+		}
+	}
+	for _, restored := range restore {
+		if err := insertTable(ctx, tx, restored, tables[restored.Name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "failed to commit the restore")
+	}
+	result.RestartRequired = len(restore) > 0
+	zap.L().Info("restored the metadata store", zap.String("version", manifest.Version), zap.Time("createdAt", manifest.CreatedAt), zap.Int("tables", len(restore)))
+	return result, nil
+}
+
+// insertTable inserts the rows of the archive in the columns the table still has
+func insertTable(ctx context.Context, tx *sqlx.Tx, restored *RestoredTable, data *tableData) error {
+	names := []string{}
+	if err := tx.SelectContext(ctx, &names, fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", restored.Name)); err != nil {
+		return errors.Wrapf(err, "failed to get the columns of the table %s", restored.Name)
+	}
+	current := map[string]struct{}{}
+	for _, name := range names {
+		current[name] = struct{}{}
+	}
+
+	kept, columns := []int{}, []string{}
+	for i, column := range data.Columns {
+		if _, ok := current[column]; !ok {
+			restored.DroppedColumns = append(restored.DroppedColumns, column)
+			continue
+		}
+		kept = append(kept, i)
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	stmt, err := tx.PreparexContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", restored.Name, strings.Join(columns, ", "), placeholders))
+	if err != nil {
+		return errors.Wrapf(err, "failed to restore the table %s", restored.Name)
+	}
+	defer stmt.Close()
+
+	for _, row := range data.Rows {
+		if len(row) != len(data.Columns) {
+			return fmt.Errorf("a row of the table %s has %d values for %d columns", restored.Name, len(row), len(data.Columns))
+		}
+		args := make([]interface{}, 0, len(kept))
+		for _, i := range kept {
+			args = append(args, value(row[i]))
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return errors.Wrapf(err, "failed to restore a row of the table %s", restored.Name)
+		}
+	}
+	return nil
+}
+
+// value converts a value decoded from the archive to a value of the driver
+func value(v interface{}) interface{} {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+func contains(selected []Section, section Section) bool {
+	for _, s := range selected {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDB(t *testing.T) *sqlx.DB {
+	db, err := sqlx.Open("sqlite3", filepath.Join(t.TempDir(), "signoz.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec(`
+		CREATE TABLE dashboards (id INTEGER PRIMARY KEY AUTOINCREMENT, uuid TEXT NOT NULL UNIQUE, created_at datetime NOT NULL, data TEXT NOT NULL);
+		CREATE TABLE rules (id INTEGER PRIMARY KEY AUTOINCREMENT, updated_at datetime NOT NULL, data TEXT NOT NULL);
+		CREATE TABLE apdex_settings (service_name TEXT PRIMARY KEY, threshold FLOAT NOT NULL, exclude_status_codes TEXT NOT NULL);
+	`)
+	require.NoError(t, err)
+	return db
+}
+
+func TestExportRestore(t *testing.T) {
+	ctx := context.Background()
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	source := newTestDB(t)
+	_, err := source.Exec("INSERT INTO dashboards (uuid, created_at, data) VALUES ('d1', $1, '{\"title\":\"api\"}')", createdAt)
+	require.NoError(t, err)
+	_, err = source.Exec("INSERT INTO rules (updated_at, data) VALUES ($1, '{\"alert\":\"errors\"}')", createdAt)
+	require.NoError(t, err)
+	_, err = source.Exec("INSERT INTO apdex_settings VALUES ('frontend', 0.5, '')")
+	require.NoError(t, err)
+
+	var archive bytes.Buffer
+	all, err := ParseSections("")
+	require.NoError(t, err)
+	manifest, err := Export(ctx, source, all, &archive)
+	require.NoError(t, err)
+	require.Equal(t, []ManifestTable{
+		{Name: "dashboards", Section: SectionDashboards, Rows: 1},
+		{Name: "rules", Section: SectionAlerts, Rows: 1},
+		{Name: "apdex_settings", Section: SectionPreferences, Rows: 1},
+	}, manifest.Tables)
+
+	// the target has a dashboard of its own, a newer column and no apdex settings table
+	target := newTestDB(t)
+	_, err = target.Exec("ALTER TABLE dashboards ADD COLUMN locked INTEGER NOT NULL DEFAULT 0")
+	require.NoError(t, err)
+	_, err = target.Exec("DROP TABLE apdex_settings")
+	require.NoError(t, err)
+	_, err = target.Exec("INSERT INTO dashboards (uuid, created_at, data) VALUES ('d2', $1, '{}')", createdAt)
+	require.NoError(t, err)
+
+	result, err := Restore(ctx, target, bytes.NewReader(archive.Bytes()), all, true)
+	require.NoError(t, err)
+	require.False(t, result.RestartRequired)
+	var uuids []string
+	require.NoError(t, target.Select(&uuids, "SELECT uuid FROM dashboards"))
+	require.Equal(t, []string{"d2"}, uuids)
+
+	selected, err := ParseSections("dashboards,preferences")
+	require.NoError(t, err)
+	result, err = Restore(ctx, target, bytes.NewReader(archive.Bytes()), selected, false)
+	require.NoError(t, err)
+	require.True(t, result.RestartRequired)
+	require.Len(t, result.Tables, 2)
+	require.Empty(t, result.Tables[0].Skipped)
+	require.NotEmpty(t, result.Tables[1].Skipped)
+
+	var dashboard struct {
+		Id        int64     `db:"id"`
+		Uuid      string    `db:"uuid"`
+		CreatedAt time.Time `db:"created_at"`
+		Data      string    `db:"data"`
+		Locked    int       `db:"locked"`
+	}
+	require.NoError(t, target.Get(&dashboard, "SELECT * FROM dashboards"))
+	require.Equal(t, int64(1), dashboard.Id)
+	require.Equal(t, "d1", dashboard.Uuid)
+	require.True(t, createdAt.Equal(dashboard.CreatedAt))
+	require.Equal(t, `{"title":"api"}`, dashboard.Data)
+
+	var before int
+	require.NoError(t, target.Get(&before, "SELECT count(*) FROM dashboards WHERE created_at < $1", createdAt.Add(time.Second)))
+	require.Equal(t, 1, before)
+
+	var rules int
+	require.NoError(t, target.Get(&rules, "SELECT count(*) FROM rules"))
+	require.Zero(t, rules)
+
+	_, err = ParseSections("dashboards,logs")
+	require.Error(t, err)
+}
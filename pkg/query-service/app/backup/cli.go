@@ -0,0 +1,89 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// IsCommand tells whether the arguments of the query service run a backup command rather
+// than the server
+func IsCommand(args []string) bool {
+	return len(args) > 0 && (args[0] == "backup" || args[0] == "restore")
+}
+
+// RunCommand runs the backup or restore command on the metadata store at dsn:
+//
+//	query-service backup -out signoz-metadata.tar.gz [-sections dashboards,alerts]
+//	query-service restore -in signoz-metadata.tar.gz [-sections dashboards] [-dry-run]
+//
+// The restore is meant to be run while the query service is stopped
+func RunCommand(args []string, dsn string, out io.Writer) error {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	var file string
+	var dryRun bool
+	if args[0] == "restore" {
+		fs.StringVar(&file, "in", "", "(the archive restored, stdin when empty)")
+		fs.BoolVar(&dryRun, "dry-run", false, "(check the archive restores without changing the metadata store)")
+	} else {
+		fs.StringVar(&file, "out", "", "(the archive written, stdout when empty)")
+	}
+	sectionList := fs.String("sections", "", "(comma separated sections among users, dashboards, alerts, channels, pipelines and preferences, all when empty)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	selected, err := ParseSections(*sectionList)
+	if err != nil {
+		return err
+	}
+
+	db, err := sqlx.Open("sqlite3", dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open the metadata store: %w", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	var result interface{}
+	if args[0] == "backup" {
+		w := out
+		if file != "" {
+			f, err := os.Create(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		manifest, err := Export(ctx, db, selected, w)
+		if err != nil {
+			return err
+		}
+		if file == "" {
+			return nil
+		}
+		result = manifest
+	} else {
+		var r io.Reader = os.Stdin
+		if file != "" {
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		if result, err = Restore(ctx, db, r, selected, dryRun); err != nil {
+			return err
+		}
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
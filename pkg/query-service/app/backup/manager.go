@@ -0,0 +1,29 @@
+package backup
+
+import (
+	"context"
+	"io"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ManagerOptions struct {
+	DB *sqlx.DB
+}
+
+// Manager backs up and restores the metadata store of the running query service
+type Manager struct {
+	db *sqlx.DB
+}
+
+func NewManager(opts ManagerOptions) *Manager {
+	return &Manager{db: opts.DB}
+}
+
+func (m *Manager) Export(ctx context.Context, selected []Section, w io.Writer) (*Manifest, error) {
+	return Export(ctx, m.db, selected, w)
+}
+
+func (m *Manager) Restore(ctx context.Context, r io.Reader, selected []Section, dryRun bool) (*RestoreResult, error) {
+	return Restore(ctx, m.db, r, selected, dryRun)
+}
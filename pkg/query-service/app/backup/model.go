@@ -0,0 +1,100 @@
+package backup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Section is a group of tables of the metadata store backed up and restored together
+type Section string
+
+const (
+	SectionUsers       Section = "users"
+	SectionDashboards  Section = "dashboards"
+	SectionAlerts      Section = "alerts"
+	SectionChannels    Section = "channels"
+	SectionPipelines   Section = "pipelines"
+	SectionPreferences Section = "preferences"
+)
+
+// sections are the tables of each section, in the order they are restored in. The tables
+// referred to by foreign keys come first. The tables missing in a store are skipped, like
+// those of the enterprise edition
+var sections = []struct {
+	section Section
+	tables  []string
+}{
+	{SectionUsers, []string{"organizations", "groups", "group_permissions", "users", "invites", "org_members",
+		"user_totp", "teams", "team_members", "oncall_schedules", "org_domains", "personal_access_tokens"}},
+	{SectionDashboards, []string{"dashboard_folders", "dashboards", "saved_views", "saved_view_defaults",
+		"resource_acls", "resource_acl_entries"}},
+	{SectionAlerts, []string{"rules", "recording_rules", "planned_maintenance", "escalation_policies", "slos",
+		"heartbeat_monitors", "silences"}},
+	{SectionChannels, []string{"notification_channels", "webhook_subscriptions"}},
+	{SectionPipelines, []string{"agent_config_versions", "agent_config_elements", "pipelines"}},
+	{SectionPreferences, []string{"user_flags", "apdex_settings"}},
+}
+
+// ParseSections parses a comma separated list of sections, all the sections are selected
+// when it is empty
+func ParseSections(s string) ([]Section, error) {
+	if strings.TrimSpace(s) == "" {
+		all := []Section{}
+		for _, sec := range sections {
+			all = append(all, sec.section)
+		}
+		return all, nil
+	}
+
+	selected := []Section{}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, sec := range sections {
+			if string(sec.section) == name {
+				selected = append(selected, sec.section)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown section %q", name)
+		}
+	}
+	return selected, nil
+}
+
+// Manifest describes the content of an archive
+type Manifest struct {
+	// Version is the version of the query service the archive was made with
+	Version   string          `json:"version"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Tables    []ManifestTable `json:"tables"`
+}
+
+type ManifestTable struct {
+	Name    string  `json:"name"`
+	Section Section `json:"section"`
+	Rows    int     `json:"rows"`
+}
+
+// RestoredTable is a table of the archive with how it was restored
+type RestoredTable struct {
+	Name    string  `json:"name"`
+	Section Section `json:"section"`
+	Rows    int     `json:"rows"`
+	// Skipped tells why the table wasn't restored, when it wasn't
+	Skipped string `json:"skipped,omitempty"`
+	// DroppedColumns are the columns of the archive the table no longer has
+	DroppedColumns []string `json:"droppedColumns,omitempty"`
+}
+
+type RestoreResult struct {
+	Manifest *Manifest        `json:"manifest"`
+	Tables   []*RestoredTable `json:"tables"`
+	DryRun   bool             `json:"dryRun"`
+	// RestartRequired is set once tables were restored, the query service keeps the rules,
+	// the channels and the users in memory until it restarts
+	RestartRequired bool `json:"restartRequired"`
+}
@@ -0,0 +1,130 @@
+package cardinality
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	filterProcessor    = "filter/cardinality_limits"
+	transformProcessor = "metricstransform/cardinality_limits"
+)
+
+// FilterConfig compiles the active drop limits into the filter processor config
+func FilterConfig(limits []Limit) map[string]interface{} {
+	names := []interface{}{}
+	for _, limit := range limits {
+		if limit.Active() && limit.Action == ActionDrop {
+			names = append(names, limit.MetricName)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return map[string]interface{}{
+		"metrics": map[string]interface{}{
+			"exclude": map[string]interface{}{
+				"match_type":   "strict",
+				"metric_names": names,
+			},
+		},
+	}
+}
+
+// TransformConfig compiles the active aggregate limits into the metrics transform
+// processor config, the series are aggregated on the kept labels only
+func TransformConfig(limits []Limit) map[string]interface{} {
+	transforms := []interface{}{}
+	for _, limit := range limits {
+		if !limit.Active() || limit.Action != ActionAggregate {
+			continue
+		}
+		labels := make([]interface{}, len(limit.KeepLabels))
+		for i, label := range limit.KeepLabels {
+			labels[i] = label
+		}
+		transforms = append(transforms, map[string]interface{}{
+			"include":    limit.MetricName,
+			"match_type": "strict",
+			"action":     "update",
+			"operations": []interface{}{
+				map[string]interface{}{
+					"action":           "aggregate_labels",
+					"label_set":        labels,
+					"aggregation_type": limit.AggregationType,
+				},
+			},
+		})
+	}
+	if len(transforms) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"transforms": transforms}
+}
+
+// metricsPipelineProcessors places the enabled processors before the batch processor
+func metricsPipelineProcessors(current []interface{}, enabled []interface{}) []interface{} {
+	processors := []interface{}{}
+	for _, name := range current {
+		if name != filterProcessor && name != transformProcessor {
+			processors = append(processors, name)
+		}
+	}
+	if len(enabled) == 0 {
+		return processors
+	}
+	for i, name := range processors {
+		if name == "batch" {
+			return append(processors[:i], append(enabled, processors[i:]...)...)
+		}
+	}
+	return append(processors, enabled...)
+}
+
+// GenerateCollectorConfigWithLimits adds the processors enforcing the active limits to the
+// metrics pipeline, the processors are removed when no limit of their action is active
+func GenerateCollectorConfigWithLimits(config []byte, limits []Limit) ([]byte, *model.ApiError) {
+	var c map[string]interface{}
+	if err := yaml.Unmarshal(config, &c); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	service, _ := c["service"].(map[string]interface{})
+	pipelines, _ := service["pipelines"].(map[string]interface{})
+	metrics, ok := pipelines["metrics"].(map[string]interface{})
+	if !ok {
+		// the collector does not receive metrics
+		return config, nil
+	}
+
+	processors, _ := c["processors"].(map[string]interface{})
+	if processors == nil {
+		processors = map[string]interface{}{}
+	}
+	enabled := []interface{}{}
+	for _, p := range []struct {
+		name string
+		conf map[string]interface{}
+	}{
+		{filterProcessor, FilterConfig(limits)},
+		{transformProcessor, TransformConfig(limits)},
+	} {
+		if p.conf != nil {
+			processors[p.name] = p.conf
+			enabled = append(enabled, p.name)
+		} else {
+			delete(processors, p.name)
+		}
+	}
+	c["processors"] = processors
+
+	current, _ := metrics["processors"].([]interface{})
+	metrics["processors"] = metricsPipelineProcessors(current, enabled)
+
+	updatedConf, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, model.BadRequest(err)
+	}
+	return updatedConf, nil
+}
@@ -0,0 +1,86 @@
+package cardinality
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testCollectorConf = `
+processors:
+  batch: {}
+  resourcedetection: {}
+service:
+  pipelines:
+    metrics:
+      receivers: [otlp]
+      processors: [resourcedetection, batch]
+      exporters: [clickhousemetricswrite]
+`
+
+func parseConf(t *testing.T, conf []byte) map[string]interface{} {
+	var c map[string]interface{}
+	require.Nil(t, yaml.Unmarshal(conf, &c))
+	return c
+}
+
+func metricsProcessors(c map[string]interface{}) []interface{} {
+	pipelines := c["service"].(map[string]interface{})["pipelines"].(map[string]interface{})
+	return pipelines["metrics"].(map[string]interface{})["processors"].([]interface{})
+}
+
+func TestGenerateCollectorConfigWithLimits(t *testing.T) {
+	limits := []Limit{
+		{MetricName: "http_requests", Action: ActionAggregate, KeepLabels: Labels{"service", "status"}, AggregationType: "sum", Enabled: true, Exceeded: true},
+		{MetricName: "debug_events", Action: ActionDrop, Enabled: true, Exceeded: true},
+		{MetricName: "not_exceeded", Action: ActionDrop, Enabled: true},
+		{MetricName: "disabled", Action: ActionDrop, Exceeded: true},
+	}
+
+	updated, apiErr := GenerateCollectorConfigWithLimits([]byte(testCollectorConf), limits)
+	require.Nil(t, apiErr)
+	c := parseConf(t, updated)
+
+	require.Equal(t, []interface{}{"resourcedetection", filterProcessor, transformProcessor, "batch"}, metricsProcessors(c))
+
+	processors := c["processors"].(map[string]interface{})
+	filter := processors[filterProcessor].(map[string]interface{})["metrics"].(map[string]interface{})["exclude"].(map[string]interface{})
+	require.Equal(t, []interface{}{"debug_events"}, filter["metric_names"])
+
+	transforms := processors[transformProcessor].(map[string]interface{})["transforms"].([]interface{})
+	require.Len(t, transforms, 1)
+	transform := transforms[0].(map[string]interface{})
+	require.Equal(t, "http_requests", transform["include"])
+	operation := transform["operations"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, "aggregate_labels", operation["action"])
+	require.Equal(t, []interface{}{"service", "status"}, operation["label_set"])
+
+	// resetting the limits removes the processors
+	for i := range limits {
+		limits[i].Exceeded = false
+	}
+	updated, apiErr = GenerateCollectorConfigWithLimits(updated, limits)
+	require.Nil(t, apiErr)
+	c = parseConf(t, updated)
+	require.Equal(t, []interface{}{"resourcedetection", "batch"}, metricsProcessors(c))
+	require.NotContains(t, c["processors"], filterProcessor)
+	require.NotContains(t, c["processors"], transformProcessor)
+}
+
+func TestPostableLimitValidate(t *testing.T) {
+	limit := PostableLimit{MetricName: "http_requests", MaxSeries: 1000, Action: ActionAggregate, KeepLabels: Labels{"service"}}
+	require.Nil(t, limit.Validate())
+	require.Equal(t, "sum", limit.AggregationType)
+
+	invalid := []PostableLimit{
+		{MaxSeries: 1000, Action: ActionDrop},
+		{MetricName: "http_requests", Action: ActionDrop},
+		{MetricName: "http_requests", MaxSeries: 1000, Action: "sample"},
+		{MetricName: "http_requests", MaxSeries: 1000, Action: ActionAggregate},
+		{MetricName: "http_requests", MaxSeries: 1000, Action: ActionAggregate, KeepLabels: Labels{"service"}, AggregationType: "median"},
+	}
+	for _, l := range invalid {
+		require.NotNil(t, l.Validate(), "%+v", l)
+	}
+}
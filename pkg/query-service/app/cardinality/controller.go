@@ -0,0 +1,236 @@
+package cardinality
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// checkInterval is the interval at which the series of the limited metrics are counted
+	checkInterval = 5 * time.Minute
+	// checkWindow is the window the series of the limited metrics are counted over
+	checkWindow = time.Hour
+)
+
+// CardinalityController counts the series of the limited metrics at regular intervals,
+// the limits exceeded are pushed to the collectors which drop or aggregate the metrics
+type CardinalityController struct {
+	Repo
+	reader interfaces.Reader
+
+	done chan struct{}
+}
+
+func NewCardinalityController(db *sqlx.DB, engine string, reader interfaces.Reader) (*CardinalityController, error) {
+	repo := NewRepo(db)
+	err := repo.InitDB(engine)
+	return &CardinalityController{Repo: repo, reader: reader, done: make(chan struct{})}, err
+}
+
+// Start counts the series of the limited metrics every checkInterval until Stop is called
+func (cc *CardinalityController) Start() {
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-cc.done:
+				return
+			case <-ticker.C:
+				if apiErr := cc.check(context.Background()); apiErr != nil {
+					zap.L().Error("failed to check cardinality limits", zap.Error(apiErr.ToError()))
+				}
+			}
+		}
+	}()
+}
+
+func (cc *CardinalityController) Stop() {
+	close(cc.done)
+}
+
+// check records the series of the metrics of the enabled limits and
+// deploys the limits when some of them have just been exceeded
+func (cc *CardinalityController) check(ctx context.Context) *model.ApiError {
+	limits, apiErr := cc.getLimits(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+	metricNames := []string{}
+	for _, limit := range limits {
+		if limit.Enabled {
+			metricNames = append(metricNames, limit.MetricName)
+		}
+	}
+	if len(metricNames) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	counts, apiErr := cc.reader.GetMetricsSeriesCount(ctx, metricNames, now.Add(-checkWindow), now)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	exceeded := false
+	for _, limit := range limits {
+		if !limit.Enabled {
+			continue
+		}
+		series := counts[limit.MetricName]
+		over := series > limit.MaxSeries
+		if over && !limit.Exceeded {
+			zap.L().Info("cardinality limit exceeded", zap.String("metric", limit.MetricName), zap.Uint64("series", series))
+			exceeded = true
+		}
+		if apiErr := cc.recordCheck(ctx, limit.Id, series, over, now); apiErr != nil {
+			return apiErr
+		}
+	}
+	if !exceeded {
+		return nil
+	}
+	return cc.deploy(ctx, "")
+}
+
+// ListLimits responds with the limits along with the config version history
+func (cc *CardinalityController) ListLimits(ctx context.Context, limit int) (*LimitsResponse, *model.ApiError) {
+	limits, apiErr := cc.getLimits(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeCardinalityLimits)
+	if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+		return nil, model.WrapApiError(apiErr, "failed to get latest cardinality limits version")
+	}
+	history, apiErr := agentConf.GetConfigHistory(ctx, agentConf.ElementTypeCardinalityLimits, limit)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to get cardinality limits history")
+	}
+	return &LimitsResponse{ConfigVersion: latest, Limits: limits, History: history}, nil
+}
+
+func (cc *CardinalityController) CreateLimit(ctx context.Context, postable *PostableLimit) (*Limit, *model.ApiError) {
+	if err := postable.Validate(); err != nil {
+		return nil, model.BadRequest(errors.Wrap(err, "cardinality limit is not valid"))
+	}
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	// a new limit is not exceeded yet, the collectors are updated once it is
+	return cc.insertLimit(ctx, userId, postable)
+}
+
+func (cc *CardinalityController) UpdateLimit(ctx context.Context, id string, postable *PostableLimit) (*Limit, *model.ApiError) {
+	if err := postable.Validate(); err != nil {
+		return nil, model.BadRequest(errors.Wrap(err, "cardinality limit is not valid"))
+	}
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	if apiErr := cc.updateLimit(ctx, userId, id, postable); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := cc.deploy(ctx, userId); apiErr != nil {
+		return nil, apiErr
+	}
+	return cc.GetLimit(ctx, id)
+}
+
+// ResetLimit stops applying the action of an exceeded limit
+func (cc *CardinalityController) ResetLimit(ctx context.Context, id string) (*Limit, *model.ApiError) {
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	if apiErr := cc.resetLimit(ctx, userId, id); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := cc.deploy(ctx, userId); apiErr != nil {
+		return nil, apiErr
+	}
+	return cc.GetLimit(ctx, id)
+}
+
+func (cc *CardinalityController) DeleteLimit(ctx context.Context, id string) *model.ApiError {
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	if apiErr := cc.deleteLimit(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	return cc.deploy(ctx, userId)
+}
+
+// deploy starts a new config version with the active limits
+func (cc *CardinalityController) deploy(ctx context.Context, userId string) *model.ApiError {
+	limits, apiErr := cc.getLimits(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+	elements := []string{}
+	for _, limit := range limits {
+		if limit.Active() {
+			elements = append(elements, limit.Id)
+		}
+	}
+
+	_, apiErr = agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeCardinalityLimits, elements)
+	return apiErr
+}
+
+// Implements agentConf.AgentFeature interface.
+func (cc *CardinalityController) AgentFeatureType() agentConf.AgentFeatureType {
+	return CardinalityLimitsFeatureType
+}
+
+// Implements agentConf.AgentFeature interface.
+func (cc *CardinalityController) RecommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	// the collectors are left as is until a limit is exceeded
+	if configVersion == nil {
+		return currentConfYaml, "", nil
+	}
+
+	limits, apiErr := cc.getLimits(context.Background())
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+
+	updatedConf, apiErr := GenerateCollectorConfigWithLimits(currentConfYaml, limits)
+	if apiErr != nil {
+		return nil, "", model.WrapApiError(apiErr, "could not marshal yaml for updated conf")
+	}
+
+	settings, err := yaml.Marshal(map[string]interface{}{
+		filterProcessor:    FilterConfig(limits),
+		transformProcessor: TransformConfig(limits),
+	})
+	if err != nil {
+		return nil, "", model.BadRequest(errors.Wrap(err, "could not serialize cardinality limits config"))
+	}
+	return updatedConf, string(settings), nil
+}
@@ -0,0 +1,164 @@
+package cardinality
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// Repo handles DDL and DML ops on cardinality limits
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) Repo {
+	return Repo{
+		db: db,
+	}
+}
+
+func (r *Repo) InitDB(engine string) error {
+	switch engine {
+	case "sqlite3", "sqlite":
+	default:
+		return fmt.Errorf("unsupported db")
+	}
+
+	tableSchema := `CREATE TABLE IF NOT EXISTS cardinality_limits(
+		id TEXT PRIMARY KEY,
+		metric_name TEXT NOT NULL UNIQUE,
+		max_series INTEGER NOT NULL,
+		action TEXT NOT NULL,
+		keep_labels TEXT NOT NULL DEFAULT '[]',
+		aggregation_type TEXT NOT NULL DEFAULT '',
+		enabled BOOLEAN,
+		exceeded BOOLEAN NOT NULL DEFAULT 0,
+		exceeded_at TIMESTAMP,
+		last_series INTEGER NOT NULL DEFAULT 0,
+		last_checked_at TIMESTAMP,
+		created_by TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`
+	if _, err := r.db.Exec(tableSchema); err != nil {
+		return errors.Wrap(err, "Error in creating cardinality limits table")
+	}
+	return nil
+}
+
+func (r *Repo) getLimits(ctx context.Context) ([]Limit, *model.ApiError) {
+	limits := []Limit{}
+	err := r.db.SelectContext(ctx, &limits, `SELECT * FROM cardinality_limits ORDER BY created_at`)
+	if err != nil {
+		zap.L().Error("failed to get cardinality limits from db", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to get cardinality limits from db"))
+	}
+	return limits, nil
+}
+
+func (r *Repo) GetLimit(ctx context.Context, id string) (*Limit, *model.ApiError) {
+	var limit Limit
+	err := r.db.GetContext(ctx, &limit, `SELECT * FROM cardinality_limits WHERE id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, model.NotFoundError(fmt.Errorf("no cardinality limit found with id %s", id))
+	}
+	if err != nil {
+		zap.L().Error("failed to get cardinality limit from db", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to get cardinality limit from db"))
+	}
+	return &limit, nil
+}
+
+func (r *Repo) insertLimit(ctx context.Context, userId string, postable *PostableLimit) (*Limit, *model.ApiError) {
+	now := time.Now()
+	limit := &Limit{
+		Id:              uuid.NewString(),
+		MetricName:      postable.MetricName,
+		MaxSeries:       postable.MaxSeries,
+		Action:          postable.Action,
+		KeepLabels:      postable.KeepLabels,
+		AggregationType: postable.AggregationType,
+		Enabled:         postable.Enabled,
+		CreatedBy:       userId,
+		CreatedAt:       now,
+		UpdatedBy:       userId,
+		UpdatedAt:       now,
+	}
+
+	_, err := r.db.ExecContext(ctx, `INSERT INTO cardinality_limits
+		(id, metric_name, max_series, action, keep_labels, aggregation_type, enabled, created_by, created_at, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		limit.Id, limit.MetricName, limit.MaxSeries, limit.Action, limit.KeepLabels, limit.AggregationType, limit.Enabled,
+		limit.CreatedBy, limit.CreatedAt, limit.UpdatedBy, limit.UpdatedAt)
+	if err != nil {
+		zap.L().Error("error in inserting cardinality limit", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to insert cardinality limit"))
+	}
+	return limit, nil
+}
+
+// updateLimit replaces the limit, the limit is reset as its threshold may have changed
+func (r *Repo) updateLimit(ctx context.Context, userId string, id string, postable *PostableLimit) *model.ApiError {
+	result, err := r.db.ExecContext(ctx, `UPDATE cardinality_limits SET
+		metric_name = $1, max_series = $2, action = $3, keep_labels = $4, aggregation_type = $5, enabled = $6,
+		exceeded = 0, exceeded_at = NULL, updated_by = $7, updated_at = $8 WHERE id = $9`,
+		postable.MetricName, postable.MaxSeries, postable.Action, postable.KeepLabels, postable.AggregationType, postable.Enabled,
+		userId, time.Now(), id)
+	if err != nil {
+		zap.L().Error("error in updating cardinality limit", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to update cardinality limit"))
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return model.NotFoundError(fmt.Errorf("no cardinality limit found with id %s", id))
+	}
+	return nil
+}
+
+func (r *Repo) resetLimit(ctx context.Context, userId string, id string) *model.ApiError {
+	result, err := r.db.ExecContext(ctx, `UPDATE cardinality_limits SET
+		exceeded = 0, exceeded_at = NULL, updated_by = $1, updated_at = $2 WHERE id = $3`,
+		userId, time.Now(), id)
+	if err != nil {
+		zap.L().Error("error in resetting cardinality limit", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to reset cardinality limit"))
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return model.NotFoundError(fmt.Errorf("no cardinality limit found with id %s", id))
+	}
+	return nil
+}
+
+func (r *Repo) deleteLimit(ctx context.Context, id string) *model.ApiError {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM cardinality_limits WHERE id = $1`, id)
+	if err != nil {
+		zap.L().Error("error in deleting cardinality limit", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to delete cardinality limit"))
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return model.NotFoundError(fmt.Errorf("no cardinality limit found with id %s", id))
+	}
+	return nil
+}
+
+// recordCheck records the series of the metric of the limit, the limit
+// is marked as exceeded the first time the series go over it
+func (r *Repo) recordCheck(ctx context.Context, id string, series uint64, exceeded bool, at time.Time) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `UPDATE cardinality_limits SET
+		last_series = $1, last_checked_at = $2,
+		exceeded_at = CASE WHEN $3 AND NOT exceeded THEN $2 ELSE exceeded_at END,
+		exceeded = exceeded OR $3 WHERE id = $4`,
+		series, at, exceeded, id)
+	if err != nil {
+		zap.L().Error("error in recording cardinality limit check", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to record cardinality limit check"))
+	}
+	return nil
+}
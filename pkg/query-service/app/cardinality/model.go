@@ -0,0 +1,114 @@
+package cardinality
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+)
+
+const CardinalityLimitsFeatureType = agentConf.AgentFeatureType(agentConf.ElementTypeCardinalityLimits)
+
+type Action string
+
+const (
+	// ActionDrop drops the metric at ingestion
+	ActionDrop Action = "drop"
+	// ActionAggregate aggregates the series of the metric on the kept labels at ingestion
+	ActionAggregate Action = "aggregate"
+)
+
+var aggregationTypes = map[string]bool{"sum": true, "mean": true, "min": true, "max": true}
+
+// Labels are stored as a json array
+type Labels []string
+
+func (l *Labels) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, l)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), l)
+	}
+	return nil
+}
+
+func (l Labels) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	return string(data), err
+}
+
+// PostableLimit is a cardinality limit as received from the API
+type PostableLimit struct {
+	MetricName string `json:"metricName"`
+	MaxSeries  uint64 `json:"maxSeries"`
+	Action     Action `json:"action"`
+	// KeepLabels are the labels the series are aggregated on
+	KeepLabels      Labels `json:"keepLabels,omitempty"`
+	AggregationType string `json:"aggregationType,omitempty"`
+	Enabled         bool   `json:"enabled"`
+}
+
+// Limit is a stored cardinality limit. The action of the limit applies once the
+// series of the metric have exceeded the limit, until the limit is reset
+type Limit struct {
+	Id              string     `json:"id" db:"id"`
+	MetricName      string     `json:"metricName" db:"metric_name"`
+	MaxSeries       uint64     `json:"maxSeries" db:"max_series"`
+	Action          Action     `json:"action" db:"action"`
+	KeepLabels      Labels     `json:"keepLabels" db:"keep_labels"`
+	AggregationType string     `json:"aggregationType,omitempty" db:"aggregation_type"`
+	Enabled         bool       `json:"enabled" db:"enabled"`
+	Exceeded        bool       `json:"exceeded" db:"exceeded"`
+	ExceededAt      *time.Time `json:"exceededAt,omitempty" db:"exceeded_at"`
+	LastSeries      uint64     `json:"lastSeries" db:"last_series"`
+	LastCheckedAt   *time.Time `json:"lastCheckedAt,omitempty" db:"last_checked_at"`
+	CreatedBy       string     `json:"createdBy" db:"created_by"`
+	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedBy       string     `json:"updatedBy" db:"updated_by"`
+	UpdatedAt       time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// Active reports whether the action of the limit is applied at ingestion
+func (l *Limit) Active() bool {
+	return l.Enabled && l.Exceeded
+}
+
+// LimitsResponse is used to prepare the http response of the cardinality limits requests
+type LimitsResponse struct {
+	*agentConf.ConfigVersion
+
+	Limits  []Limit                   `json:"limits"`
+	History []agentConf.ConfigVersion `json:"history"`
+}
+
+func (l *PostableLimit) Validate() error {
+	if strings.TrimSpace(l.MetricName) == "" {
+		return fmt.Errorf("metric name is required")
+	}
+	if l.MaxSeries == 0 {
+		return fmt.Errorf("max series must be positive")
+	}
+	switch l.Action {
+	case ActionDrop:
+	case ActionAggregate:
+		if len(l.KeepLabels) == 0 {
+			return fmt.Errorf("the labels to keep are required to aggregate the series")
+		}
+		if l.AggregationType == "" {
+			l.AggregationType = "sum"
+		}
+		if !aggregationTypes[l.AggregationType] {
+			return fmt.Errorf("unsupported aggregation type: %s", l.AggregationType)
+		}
+	default:
+		return fmt.Errorf("unsupported action: %s", l.Action)
+	}
+	return nil
+}
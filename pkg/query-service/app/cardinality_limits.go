@@ -0,0 +1,100 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/cardinality"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// cardinalityHistoryLimit is the number of config versions listed with the limits
+const cardinalityHistoryLimit = 10
+
+func (aH *APIHandler) getMetricsCardinality(w http.ResponseWriter, r *http.Request) {
+	params, err := parseMetricsCardinalityParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	params.OrgScope = scope
+
+	result, apiErr := aH.reader.GetMetricsCardinality(r.Context(), params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, result)
+}
+
+func (aH *APIHandler) listCardinalityLimits(w http.ResponseWriter, r *http.Request) {
+	payload, apiErr := aH.CardinalityController.ListLimits(r.Context(), cardinalityHistoryLimit)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, payload)
+}
+
+func (aH *APIHandler) getCardinalityLimit(w http.ResponseWriter, r *http.Request) {
+	limit, apiErr := aH.CardinalityController.GetLimit(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, limit)
+}
+
+func (aH *APIHandler) createCardinalityLimit(w http.ResponseWriter, r *http.Request) {
+	var postable cardinality.PostableLimit
+	if err := json.NewDecoder(r.Body).Decode(&postable); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	limit, apiErr := aH.CardinalityController.CreateLimit(r.Context(), &postable)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, limit)
+}
+
+func (aH *APIHandler) updateCardinalityLimit(w http.ResponseWriter, r *http.Request) {
+	var postable cardinality.PostableLimit
+	if err := json.NewDecoder(r.Body).Decode(&postable); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	limit, apiErr := aH.CardinalityController.UpdateLimit(r.Context(), mux.Vars(r)["id"], &postable)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, limit)
+}
+
+func (aH *APIHandler) resetCardinalityLimit(w http.ResponseWriter, r *http.Request) {
+	limit, apiErr := aH.CardinalityController.ResetLimit(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, limit)
+}
+
+func (aH *APIHandler) deleteCardinalityLimit(w http.ResponseWriter, r *http.Request) {
+	if apiErr := aH.CardinalityController.DeleteLimit(r.Context(), mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
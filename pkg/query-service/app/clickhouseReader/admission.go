@@ -0,0 +1,267 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/google/uuid"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	chErrors "go.signoz.io/signoz/pkg/query-service/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	// defaultQueryQueueTimeout is how long a query waits for a slot when no timeout is set
+	defaultQueryQueueTimeout = 30 * time.Second
+	// maxInFlightQueryLength is the length the queries in flight are truncated to
+	maxInFlightQueryLength = 2000
+)
+
+// queryAdmission tracks the queries made on behalf of the users so they can be listed and
+// canceled, and limits the queries of each user and each org running concurrently.
+// The queries over the limits wait for a slot in order until the queue timeout,
+// the background queries are neither tracked nor limited
+type queryAdmission struct {
+	maxPerOrg    int
+	maxPerUser   int
+	queueTimeout time.Duration
+
+	// kill stops the query on clickhouse once its context is canceled
+	kill func(queryId string)
+
+	mtx       sync.Mutex
+	orgSlots  map[string]chan struct{}
+	userSlots map[string]chan struct{}
+	inFlight  map[string]*inFlightQuery
+}
+
+type inFlightQuery struct {
+	model.QueryInFlight
+	cancel context.CancelFunc
+}
+
+// newQueryAdmission parses the limits, an empty or zero limit disables it
+func newQueryAdmission(maxPerOrg, maxPerUser, queueTimeout string) (*queryAdmission, error) {
+	a := &queryAdmission{
+		queueTimeout: defaultQueryQueueTimeout,
+		orgSlots:     map[string]chan struct{}{},
+		userSlots:    map[string]chan struct{}{},
+		inFlight:     map[string]*inFlightQuery{},
+	}
+	var err error
+	if maxPerOrg != "" {
+		if a.maxPerOrg, err = strconv.Atoi(maxPerOrg); err != nil {
+			return nil, fmt.Errorf("invalid max concurrent queries per org %q: %w", maxPerOrg, err)
+		}
+	}
+	if maxPerUser != "" {
+		if a.maxPerUser, err = strconv.Atoi(maxPerUser); err != nil {
+			return nil, fmt.Errorf("invalid max concurrent queries per user %q: %w", maxPerUser, err)
+		}
+	}
+	if queueTimeout != "" {
+		if a.queueTimeout, err = time.ParseDuration(queueTimeout); err != nil {
+			return nil, fmt.Errorf("invalid query queue timeout %q: %w", queueTimeout, err)
+		}
+	}
+	return a, nil
+}
+
+// slots returns the semaphore of the key, nil when there is no limit
+func (a *queryAdmission) slots(slots map[string]chan struct{}, key string, limit int) chan struct{} {
+	if limit <= 0 {
+		return nil
+	}
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	s, ok := slots[key]
+	if !ok {
+		s = make(chan struct{}, limit)
+		slots[key] = s
+	}
+	return s
+}
+
+// acquire waits for a slot of the user and of its org, the query is to be run with the returned
+// context which carries its clickhouse query id and is canceled when the query is canceled.
+// The returned release func must be called once the query is done
+func (a *queryAdmission) acquire(ctx context.Context, query string) (context.Context, func(), error) {
+	user := common.GetUserFromContext(ctx)
+	if a == nil || user == nil {
+		return ctx, func() {}, nil
+	}
+
+	if len(query) > maxInFlightQueryLength {
+		query = query[:maxInFlightQueryLength]
+	}
+	queryCtx, cancel := context.WithCancel(ctx)
+	entry := &inFlightQuery{
+		QueryInFlight: model.QueryInFlight{
+			Id:        uuid.NewString(),
+			RequestId: requestId(ctx),
+			OrgId:     user.OrgId,
+			UserId:    user.Id,
+			UserEmail: user.Email,
+			Query:     query,
+			Status:    model.QueryStatusQueued,
+			QueuedAt:  time.Now(),
+		},
+		cancel: cancel,
+	}
+	a.mtx.Lock()
+	a.inFlight[entry.Id] = entry
+	a.mtx.Unlock()
+
+	var acquired []chan struct{}
+	stopKill := func() bool { return true }
+	release := func() {
+		stopKill()
+		cancel()
+		for _, s := range acquired {
+			<-s
+		}
+		a.mtx.Lock()
+		delete(a.inFlight, entry.Id)
+		a.mtx.Unlock()
+	}
+
+	timer := time.NewTimer(a.queueTimeout)
+	defer timer.Stop()
+	// the user slot is taken first so a user waiting on its own queries doesn't hold a slot of the org
+	for _, s := range []chan struct{}{
+		a.slots(a.userSlots, user.Id, a.maxPerUser),
+		a.slots(a.orgSlots, user.OrgId, a.maxPerOrg),
+	} {
+		if s == nil {
+			continue
+		}
+		select {
+		case s <- struct{}{}:
+			acquired = append(acquired, s)
+		case <-queryCtx.Done():
+			release()
+			return nil, nil, queryCtx.Err()
+		case <-timer.C:
+			release()
+			return nil, nil, chErrors.ErrQueryQueueTimeout
+		}
+	}
+
+	now := time.Now()
+	a.mtx.Lock()
+	entry.Status = model.QueryStatusRunning
+	entry.StartedAt = &now
+	a.mtx.Unlock()
+
+	// the query is killed when canceled before it is done, be it by the user or
+	// because the client went away, instead of running on in clickhouse
+	if a.kill != nil {
+		stopKill = context.AfterFunc(queryCtx, func() { a.kill(entry.Id) })
+	}
+
+	var once sync.Once
+	return clickhouse.Context(queryCtx, clickhouse.WithQueryID(entry.Id)), func() { once.Do(release) }, nil
+}
+
+// lookup returns the queries of the org with the id or made for the API request with the id
+func (a *queryAdmission) lookup(orgId, id string) []model.QueryInFlight {
+	queries := []model.QueryInFlight{}
+	if a == nil {
+		return queries
+	}
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	for _, entry := range a.inFlight {
+		if entry.OrgId == orgId && (entry.Id == id || entry.RequestId == id) {
+			queries = append(queries, entry.QueryInFlight)
+		}
+	}
+	return queries
+}
+
+// cancel cancels the queries, the queries already done are skipped
+func (a *queryAdmission) cancel(queries []model.QueryInFlight) {
+	a.mtx.Lock()
+	entries := []*inFlightQuery{}
+	for _, q := range queries {
+		if entry, ok := a.inFlight[q.Id]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	a.mtx.Unlock()
+	for _, entry := range entries {
+		entry.cancel()
+	}
+}
+
+// requestId returns the id of the API request the query is made for
+func requestId(ctx context.Context) string {
+	id, _ := ctx.Value(common.QueryIdKey).(string)
+	return id
+}
+
+// queriesInFlight returns the queued and running queries of the org, all of them when orgId is empty
+func (a *queryAdmission) queriesInFlight(orgId string) *model.QueriesInFlightResponse {
+	response := &model.QueriesInFlightResponse{Queries: []model.QueryInFlight{}}
+	if a == nil {
+		return response
+	}
+	response.MaxConcurrentPerOrg = a.maxPerOrg
+	response.MaxConcurrentPerUser = a.maxPerUser
+	response.QueueTimeoutMs = a.queueTimeout.Milliseconds()
+
+	a.mtx.Lock()
+	for _, q := range a.inFlight {
+		if orgId == "" || q.OrgId == orgId {
+			response.Queries = append(response.Queries, q.QueryInFlight)
+		}
+	}
+	a.mtx.Unlock()
+
+	sort.Slice(response.Queries, func(i, j int) bool {
+		return response.Queries[i].QueuedAt.Before(response.Queries[j].QueuedAt)
+	})
+	return response
+}
+
+// admittedRows releases the slot of the query once the rows are read or closed
+type admittedRows struct {
+	driver.Rows
+	release func()
+}
+
+func (r *admittedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.release()
+	return false
+}
+
+func (r *admittedRows) Close() error {
+	err := r.Rows.Close()
+	r.release()
+	return err
+}
+
+// admittedRow releases the slot of the query once the row is read
+type admittedRow struct {
+	driver.Row
+	release func()
+}
+
+func (r *admittedRow) Scan(dest ...any) error {
+	defer r.release()
+	return r.Row.Scan(dest...)
+}
+
+func (r *admittedRow) ScanStruct(dest any) error {
+	defer r.release()
+	return r.Row.ScanStruct(dest)
+}
@@ -0,0 +1,150 @@
+package clickhouseReader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	chErrors "go.signoz.io/signoz/pkg/query-service/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func userContext(userId, orgId string) context.Context {
+	return context.WithValue(context.Background(), constants.ContextUserKey, &model.UserPayload{
+		User: model.User{Id: userId, OrgId: orgId, Email: userId + "@signoz.io"},
+	})
+}
+
+func TestQueryAdmissionUserLimit(t *testing.T) {
+	a, err := newQueryAdmission("", "1", "50ms")
+	require.NoError(t, err)
+
+	_, release, err := a.acquire(userContext("alice", "org"), "SELECT 1")
+	require.NoError(t, err)
+
+	// another user of the org is not limited by alice's queries
+	_, releaseBob, err := a.acquire(userContext("bob", "org"), "SELECT 2")
+	require.NoError(t, err)
+	releaseBob()
+
+	_, _, err = a.acquire(userContext("alice", "org"), "SELECT 3")
+	require.ErrorIs(t, err, chErrors.ErrQueryQueueTimeout)
+	require.True(t, chErrors.IsResourceLimitError(err))
+
+	// the slot is given back once released, releasing twice is a no-op
+	release()
+	release()
+	_, release, err = a.acquire(userContext("alice", "org"), "SELECT 4")
+	require.NoError(t, err)
+	release()
+}
+
+func TestQueryAdmissionOrgLimitQueues(t *testing.T) {
+	a, err := newQueryAdmission("1", "", "1s")
+	require.NoError(t, err)
+
+	_, release, err := a.acquire(userContext("alice", "org"), "SELECT 1")
+	require.NoError(t, err)
+
+	admitted := make(chan error)
+	go func() {
+		_, releaseBob, err := a.acquire(userContext("bob", "org"), "SELECT 2")
+		if err == nil {
+			releaseBob()
+		}
+		admitted <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(a.queriesInFlight("org").Queries) == 2
+	}, time.Second, 5*time.Millisecond)
+	queries := a.queriesInFlight("org").Queries
+	require.Equal(t, model.QueryStatusRunning, queries[0].Status)
+	require.Equal(t, "alice", queries[0].UserId)
+	require.Equal(t, model.QueryStatusQueued, queries[1].Status)
+	require.Equal(t, "bob", queries[1].UserId)
+	require.Empty(t, a.queriesInFlight("other").Queries)
+
+	// bob's query runs once alice's is done
+	release()
+	require.NoError(t, <-admitted)
+	require.Empty(t, a.queriesInFlight("").Queries)
+}
+
+func TestQueryAdmissionCanceled(t *testing.T) {
+	a, err := newQueryAdmission("1", "", "")
+	require.NoError(t, err)
+
+	_, release, err := a.acquire(userContext("alice", "org"), "SELECT 1")
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(userContext("bob", "org"))
+	cancel()
+	_, _, err = a.acquire(ctx, "SELECT 2")
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, a.queriesInFlight("org").Queries, 1)
+}
+
+func TestQueryAdmissionUnlimited(t *testing.T) {
+	a, err := newQueryAdmission("", "", "")
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, release, err := a.acquire(userContext("alice", "org"), "SELECT 1")
+		require.NoError(t, err)
+		defer release()
+	}
+	require.Len(t, a.queriesInFlight("org").Queries, 10)
+
+	// the background queries are never limited
+	a, err = newQueryAdmission("1", "1", "")
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, _, err := a.acquire(context.Background(), "SELECT 1")
+		require.NoError(t, err)
+	}
+
+	_, err = newQueryAdmission("many", "", "")
+	require.Error(t, err)
+}
+
+func TestQueryAdmissionCancel(t *testing.T) {
+	a, err := newQueryAdmission("", "", "")
+	require.NoError(t, err)
+	killed := make(chan string, 2)
+	a.kill = func(queryId string) { killed <- queryId }
+
+	requestCtx := context.WithValue(userContext("alice", "org"), common.QueryIdKey, "request")
+	ctx, release, err := a.acquire(requestCtx, "SELECT 1")
+	require.NoError(t, err)
+	defer release()
+	_, releaseOther, err := a.acquire(requestCtx, "SELECT 2")
+	require.NoError(t, err)
+	defer releaseOther()
+
+	// the queries are found by their id or by the id of their request, in their org only
+	queries := a.lookup("org", "request")
+	require.Len(t, queries, 2)
+	require.Equal(t, "request", queries[0].RequestId)
+	require.Len(t, a.lookup("org", queries[0].Id), 1)
+	require.Empty(t, a.lookup("other", "request"))
+
+	// the canceled queries are killed on clickhouse
+	a.cancel(queries)
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+	require.ElementsMatch(t, []string{queries[0].Id, queries[1].Id}, []string{<-killed, <-killed})
+}
+
+func TestQueryAdmissionReleaseDoesNotKill(t *testing.T) {
+	a, err := newQueryAdmission("", "", "")
+	require.NoError(t, err)
+	a.kill = func(queryId string) { t.Errorf("query %s killed once done", queryId) }
+
+	_, release, err := a.acquire(userContext("alice", "org"), "SELECT 1")
+	require.NoError(t, err)
+	release()
+	require.Empty(t, a.queriesInFlight("org").Queries)
+}
@@ -0,0 +1,90 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// seriesWindowFilter filters the time series of the window, the rows of the
+// time series table are bucketed by the hour so the start is truncated to it.
+// The series without the org id label belong to the default org
+func seriesWindowFilter(start, end time.Time, metricNames []string, scope *model.OrgScope) (string, []interface{}) {
+	filter := "unix_milli >= @start AND unix_milli <= @end"
+	args := []interface{}{
+		clickhouse.Named("start", start.Truncate(time.Hour).UnixMilli()),
+		clickhouse.Named("end", end.UnixMilli()),
+	}
+	if len(metricNames) > 0 {
+		filter += " AND has(@metricNames, metric_name)"
+		args = append(args, clickhouse.Named("metricNames", metricNames))
+	}
+	if scope != nil {
+		not := ""
+		if scope.Exclude {
+			not = "NOT "
+		}
+		filter += fmt.Sprintf(" AND JSONExtractString(labels, @orgIdLabel) %sIN @orgIds", not)
+		args = append(args, clickhouse.Named("orgIdLabel", constants.OrgIdLabel), clickhouse.Named("orgIds", scope.OrgIds))
+	}
+	return filter, args
+}
+
+func (r *ClickHouseReader) topMetricsBySeries(ctx context.Context, start, end time.Time, metricNames []string, scope *model.OrgScope, limit int) ([]model.MetricCardinality, *model.ApiError) {
+	filter, args := seriesWindowFilter(start, end, metricNames, scope)
+	query := fmt.Sprintf("SELECT metric_name, uniq(fingerprint) AS series FROM %s.%s WHERE %s GROUP BY metric_name ORDER BY series DESC LIMIT %d",
+		signozMetricDBName, signozTSTableNameV4, filter, limit)
+
+	metrics := []model.MetricCardinality{}
+	if err := r.db.Select(ctx, &metrics, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error while getting metrics cardinality. Err=%v", err)}
+	}
+	return metrics, nil
+}
+
+// GetMetricsCardinality returns the metrics with the most series and the
+// label keys with the most values over the window of the params
+func (r *ClickHouseReader) GetMetricsCardinality(ctx context.Context, params *model.MetricsCardinalityParams) (*model.MetricsCardinalityResponse, *model.ApiError) {
+	metrics, apiErr := r.topMetricsBySeries(ctx, *params.Start, *params.End, params.MetricNames, params.OrgScope, params.Limit)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	filter, args := seriesWindowFilter(*params.Start, *params.End, params.MetricNames, params.OrgScope)
+	// the labels starting with __ such as __name__ and __temporality__ are internal
+	query := fmt.Sprintf("SELECT kv.1 AS key, uniq(kv.2) AS value_count, uniq(metric_name) AS metric_count FROM %s.%s "+
+		"ARRAY JOIN JSONExtractKeysAndValues(labels, 'String') AS kv WHERE %s AND NOT startsWith(kv.1, '__') "+
+		"GROUP BY key ORDER BY value_count DESC LIMIT %d",
+		signozMetricDBName, signozTSTableNameV4, filter, params.Limit)
+
+	labels := []model.LabelCardinality{}
+	if err := r.db.Select(ctx, &labels, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error while getting labels cardinality. Err=%v", err)}
+	}
+
+	return &model.MetricsCardinalityResponse{Metrics: metrics, Labels: labels}, nil
+}
+
+// GetMetricsSeriesCount returns the number of series of each of the metrics over the window,
+// in all the orgs
+func (r *ClickHouseReader) GetMetricsSeriesCount(ctx context.Context, metricNames []string, start, end time.Time) (map[string]uint64, *model.ApiError) {
+	counts := map[string]uint64{}
+	if len(metricNames) == 0 {
+		return counts, nil
+	}
+	metrics, apiErr := r.topMetricsBySeries(ctx, start, end, metricNames, nil, len(metricNames))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for _, m := range metrics {
+		counts[m.MetricName] = m.Series
+	}
+	return counts, nil
+}
@@ -0,0 +1,28 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// GetServiceVersions returns the versions of the services seen in the range per org, from the
+// service.version resource attribute of the spans, the first seen first
+func (r *ClickHouseReader) GetServiceVersions(ctx context.Context, start, end time.Time) ([]model.ServiceVersion, *model.ApiError) {
+	query := fmt.Sprintf(`SELECT serviceName, resourceTagsMap['service.version'] AS version,
+		resourceTagsMap['deployment.environment'] AS environment, resourceTagsMap[$3] AS orgId,
+		min(timestamp) AS firstSeen
+		FROM %s.%s WHERE timestamp >= $1 AND timestamp <= $2 AND version != ''
+		GROUP BY serviceName, version, environment, orgId ORDER BY firstSeen`, r.TraceDB, r.indexTable)
+
+	versions := []model.ServiceVersion{}
+	if err := r.db.Select(ctx, &versions, query, start, end, constants.OrgIdAttribute); err != nil {
+		zap.L().Error("Error while listing the service versions", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return versions, nil
+}
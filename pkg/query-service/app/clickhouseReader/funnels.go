@@ -0,0 +1,47 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+// GetFunnelTraces returns the traces with a span matching the first step of the funnel in the
+// range, with the start times of their spans matching each step. Up to Limit+1 traces are
+// returned so the caller can tell when the traces were limited
+func (r *ClickHouseReader) GetFunnelTraces(ctx context.Context, params *v3.FunnelParams) ([]v3.FunnelTrace, *model.ApiError) {
+	keys, err := r.GetSpanAttributeKeys(ctx)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	conditions := make([]string, len(params.Steps))
+	steps := make([]string, len(params.Steps))
+	for i, step := range params.Steps {
+		condition, err := tracesV3.BuildFilterCondition(step.Filters, keys)
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("step %d: %w", i+1, err)}
+		}
+		conditions[i] = condition
+		steps[i] = fmt.Sprintf("arraySort(groupArrayIf(toUnixTimestamp64Nano(timestamp), %s))", condition)
+	}
+
+	query := fmt.Sprintf(`SELECT traceID, [%s] AS steps FROM %s.%s
+		WHERE timestamp >= toDateTime64(%d, 9) AND timestamp <= toDateTime64(%d, 9) AND (%s)
+		GROUP BY traceID HAVING countIf(%s) > 0 LIMIT %d`,
+		strings.Join(steps, ", "), r.TraceDB, r.indexTable,
+		params.Start*1000000, params.End*1000000, strings.Join(conditions, " OR "),
+		conditions[0], params.Limit+1)
+
+	traces := []v3.FunnelTrace{}
+	if err := r.db.Select(ctx, &traces, query); err != nil {
+		zap.L().Error("Error while fetching funnel traces", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return traces, nil
+}
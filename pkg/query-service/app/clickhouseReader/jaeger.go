@@ -0,0 +1,136 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mailru/easyjson"
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+// GetSpanOperations returns the operations of the service in the range, with the kind of their spans
+func (r *ClickHouseReader) GetSpanOperations(ctx context.Context, service string, start, end time.Time, scope *model.OrgScope) ([]model.SpanOperation, *model.ApiError) {
+	operations := []model.SpanOperation{}
+	conditions := "serviceName = $1 AND timestamp >= $2 AND timestamp <= $3"
+	args := []interface{}{service, start, end}
+	if scope != nil {
+		conditions += fmt.Sprintf(" AND %shas($4, %s[$5])", orgNot(scope), model.ResourceTagMapCol)
+		args = append(args, scope.OrgIds, constants.OrgIdAttribute)
+	}
+	query := fmt.Sprintf("SELECT DISTINCT name, kind FROM %s.%s WHERE %s ORDER BY name, kind",
+		r.TraceDB, r.indexTable, conditions)
+	if err := r.db.Select(ctx, &operations, query, args...); err != nil {
+		zap.L().Error("Error while fetching span operations", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return operations, nil
+}
+
+// findTracesConditions returns the conditions selecting the spans of the params
+func findTracesConditions(params *model.FindTracesParams) string {
+	conditions := []string{fmt.Sprintf("timestamp >= toDateTime64(%d, 9) AND timestamp <= toDateTime64(%d, 9)",
+		params.Start.UnixNano(), params.End.UnixNano())}
+	if params.Service != "" {
+		conditions = append(conditions, fmt.Sprintf("serviceName = %s", utils.ClickHouseFormattedValue(params.Service)))
+	}
+	if params.Operation != "" {
+		conditions = append(conditions, fmt.Sprintf("name = %s", utils.ClickHouseFormattedValue(params.Operation)))
+	}
+	if params.MinDuration > 0 {
+		conditions = append(conditions, fmt.Sprintf("durationNano >= %d", params.MinDuration.Nanoseconds()))
+	}
+	if params.MaxDuration > 0 {
+		conditions = append(conditions, fmt.Sprintf("durationNano <= %d", params.MaxDuration.Nanoseconds()))
+	}
+	for _, key := range sortedKeys(params.Tags) {
+		key, value := utils.ClickHouseFormattedValue(key), utils.ClickHouseFormattedValue(params.Tags[key])
+		conditions = append(conditions, fmt.Sprintf("(stringTagMap[%s] = %s OR resourceTagsMap[%s] = %s)", key, value, key, value))
+	}
+	if params.OrgScope != nil {
+		orgIds := make([]interface{}, 0, len(params.OrgScope.OrgIds))
+		for _, orgId := range params.OrgScope.OrgIds {
+			orgIds = append(orgIds, orgId)
+		}
+		conditions = append(conditions, fmt.Sprintf("%shas(%s, resourceTagsMap[%s])", orgNot(params.OrgScope),
+			utils.ClickHouseFormattedValue(orgIds), utils.ClickHouseFormattedValue(constants.OrgIdAttribute)))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// orgNot negates the conditions on the orgs of the scope which excludes them
+func orgNot(scope *model.OrgScope) string {
+	if scope.Exclude {
+		return "NOT "
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FindTraceIDs returns the ids of the latest traces with a span matching the params
+func (r *ClickHouseReader) FindTraceIDs(ctx context.Context, params *model.FindTracesParams) ([]string, *model.ApiError) {
+	query := fmt.Sprintf("SELECT traceID FROM %s.%s WHERE %s GROUP BY traceID ORDER BY max(timestamp) DESC LIMIT %d",
+		r.TraceDB, r.indexTable, findTracesConditions(params), params.Limit)
+
+	traceIDs := []string{}
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		zap.L().Error("Error while finding traces", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var traceID string
+		if err := rows.Scan(&traceID); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		traceIDs = append(traceIDs, traceID)
+	}
+	return traceIDs, nil
+}
+
+// GetTracesSpans returns the spans of the traces, up to maxSpans spans
+func (r *ClickHouseReader) GetTracesSpans(ctx context.Context, traceIDs []string, maxSpans int, scope *model.OrgScope) ([]model.SearchSpanResponseItem, *model.ApiError) {
+	spans := []model.SearchSpanResponseItem{}
+	if len(traceIDs) == 0 {
+		return spans, nil
+	}
+
+	var items []model.SearchSpanDBResponseItem
+	query := fmt.Sprintf("SELECT timestamp, traceID, model FROM %s.%s WHERE traceID IN $1 LIMIT %d", r.TraceDB, r.SpansTable, maxSpans+1)
+	if err := r.db.Select(ctx, &items, query, traceIDs); err != nil {
+		zap.L().Error("Error while fetching spans", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	if len(items) > maxSpans {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("the traces have more than %d spans", maxSpans)}
+	}
+
+	for _, item := range items {
+		var span model.SearchSpanResponseItem
+		if err := easyjson.Unmarshal([]byte(item.Model), &span); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		// the spans of the other orgs sharing the trace ids are left out
+		if !scope.Allows(span.TagMap[constants.OrgIdAttribute]) {
+			continue
+		}
+		span.TimeUnixNano = uint64(item.Timestamp.UnixNano())
+		spans = append(spans, span)
+	}
+	return spans, nil
+}
@@ -0,0 +1,29 @@
+package clickhouseReader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestFindTracesConditions(t *testing.T) {
+	params := &model.FindTracesParams{
+		Service:     "frontend",
+		Operation:   "GET /api",
+		Tags:        map[string]string{"http.status_code": "500", "env": "it's"},
+		Start:       time.Unix(1700000000, 0),
+		End:         time.Unix(1700003600, 0),
+		MinDuration: 100 * time.Millisecond,
+	}
+	assert.Equal(t, "timestamp >= toDateTime64(1700000000000000000, 9) AND timestamp <= toDateTime64(1700003600000000000, 9) AND "+
+		"serviceName = 'frontend' AND name = 'GET /api' AND durationNano >= 100000000 AND "+
+		"(stringTagMap['env'] = 'it\\'s' OR resourceTagsMap['env'] = 'it\\'s') AND "+
+		"(stringTagMap['http.status_code'] = '500' OR resourceTagsMap['http.status_code'] = '500')", findTracesConditions(params))
+
+	// the traces of the other orgs are left out
+	params = &model.FindTracesParams{Start: time.Unix(1700000000, 0), End: time.Unix(1700003600, 0), OrgScope: &model.OrgScope{OrgIds: []string{"org-b"}, Exclude: true}}
+	assert.Equal(t, "timestamp >= toDateTime64(1700000000000000000, 9) AND timestamp <= toDateTime64(1700003600000000000, 9) AND "+
+		"NOT has(['org-b'], resourceTagsMap['signoz.org.id'])", findTracesConditions(params))
+}
@@ -50,6 +50,7 @@ type namespaceConfig struct {
 	MaxIdleConns            int
 	MaxOpenConns            int
 	DialTimeout             time.Duration
+	ConnMaxLifetime         time.Duration
 	TraceDB                 string
 	OperationsTable         string
 	IndexTable              string
@@ -94,6 +95,9 @@ func defaultConnector(cfg *namespaceConfig) (clickhouse.Conn, error) {
 	if options.DialTimeout == 0 {
 		options.DialTimeout = cfg.DialTimeout
 	}
+	if cfg.ConnMaxLifetime != 0 {
+		options.ConnMaxLifetime = cfg.ConnMaxLifetime
+	}
 
 	zap.L().Info("Connecting to Clickhouse", zap.String("at", options.Addr[0]), zap.Int("MaxIdleConns", options.MaxIdleConns), zap.Int("MaxOpenConns", options.MaxOpenConns), zap.Duration("DialTimeout", options.DialTimeout))
 	db, err := clickhouse.Open(options)
@@ -113,6 +117,8 @@ type Options struct {
 	primary *namespaceConfig
 
 	others map[string]*namespaceConfig
+
+	querySettings *QuerySettingsConfig
 }
 
 // NewOptions creates a new Options struct.
@@ -195,3 +201,12 @@ func NewOptions(
 func (opt *Options) getPrimary() *namespaceConfig {
 	return opt.primary
 }
+
+// setQuerySettings sizes the connection pool of the primary namespace with the settings,
+// the profiles of the settings are applied to the queries of the reader
+func (opt *Options) setQuerySettings(settings *QuerySettingsConfig) {
+	opt.querySettings = settings
+	if settings != nil {
+		settings.Pool.apply(opt.primary)
+	}
+}
@@ -0,0 +1,153 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"gopkg.in/yaml.v3"
+)
+
+// QuerySettingsConfig is the clickhouse connection pool and the settings profiles of
+// the classes of queries, read from the clickhouse settings config file
+type QuerySettingsConfig struct {
+	Pool     PoolConfig                          `yaml:"pool"`
+	Profiles map[common.QueryClass]*QueryProfile `yaml:"profiles"`
+}
+
+// PoolConfig sizes the connection pool in place of the flags, the zero values keep the
+// flags and the options set in the DSN take precedence
+type PoolConfig struct {
+	MaxIdleConns    int           `yaml:"maxIdleConns"`
+	MaxOpenConns    int           `yaml:"maxOpenConns"`
+	DialTimeout     time.Duration `yaml:"dialTimeout"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"`
+}
+
+// QueryProfile is what the queries of a class may use, the zero values keep the
+// settings of the environment
+type QueryProfile struct {
+	// MaxExecutionTime is the max_execution_time of the queries
+	MaxExecutionTime time.Duration `yaml:"maxExecutionTime"`
+	// MaxBytesToRead is the max_bytes_to_read of the queries
+	MaxBytesToRead uint64 `yaml:"maxBytesToRead"`
+	// MaxConcurrentQueries is how many queries of the class run at once, the other
+	// queries of the class wait for one of them to be done
+	MaxConcurrentQueries int `yaml:"maxConcurrentQueries"`
+	// Settings are the other clickhouse settings of the queries, e.g. priority or max_threads
+	Settings map[string]string `yaml:"settings"`
+
+	slots chan struct{}
+}
+
+// LoadQuerySettingsConfig loads the clickhouse settings from the given YAML config file
+func LoadQuerySettingsConfig(configFile string) (*QuerySettingsConfig, error) {
+	bytes, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	var config QuerySettingsConfig
+	if err := yaml.Unmarshal(bytes, &config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (c *QuerySettingsConfig) Validate() error {
+	if c.Pool.MaxIdleConns < 0 || c.Pool.MaxOpenConns < 0 || c.Pool.DialTimeout < 0 || c.Pool.ConnMaxLifetime < 0 {
+		return fmt.Errorf("the connection pool settings can't be negative")
+	}
+	if c.Pool.MaxOpenConns > 0 && c.Pool.MaxIdleConns > c.Pool.MaxOpenConns {
+		return fmt.Errorf("maxIdleConns %d is more than maxOpenConns %d", c.Pool.MaxIdleConns, c.Pool.MaxOpenConns)
+	}
+	for class, profile := range c.Profiles {
+		switch class {
+		case common.QueryClassInteractive, common.QueryClassAlerts, common.QueryClassExport:
+		default:
+			return fmt.Errorf("unknown query class %q, expected one of %s, %s, %s", class, common.QueryClassInteractive, common.QueryClassAlerts, common.QueryClassExport)
+		}
+		if profile == nil {
+			continue
+		}
+		if profile.MaxExecutionTime < 0 || profile.MaxConcurrentQueries < 0 {
+			return fmt.Errorf("the settings of the %s queries can't be negative", class)
+		}
+		if profile.MaxExecutionTime > 0 && profile.MaxExecutionTime < time.Second {
+			return fmt.Errorf("the max execution time of the %s queries must be at least a second", class)
+		}
+	}
+	return nil
+}
+
+// apply overrides the pool options of the namespace with the configured ones
+func (p PoolConfig) apply(cfg *namespaceConfig) {
+	if p.MaxIdleConns > 0 {
+		cfg.MaxIdleConns = p.MaxIdleConns
+	}
+	if p.MaxOpenConns > 0 {
+		cfg.MaxOpenConns = p.MaxOpenConns
+	}
+	if p.DialTimeout > 0 {
+		cfg.DialTimeout = p.DialTimeout
+	}
+	if p.ConnMaxLifetime > 0 {
+		cfg.ConnMaxLifetime = p.ConnMaxLifetime
+	}
+}
+
+// queryProfiles returns the profiles of the config by class, with the slots of the
+// classes limiting their concurrent queries
+func (c *QuerySettingsConfig) queryProfiles() map[common.QueryClass]*QueryProfile {
+	profiles := map[common.QueryClass]*QueryProfile{}
+	if c == nil {
+		return profiles
+	}
+	for class, profile := range c.Profiles {
+		if profile == nil {
+			continue
+		}
+		p := *profile
+		if p.MaxConcurrentQueries > 0 {
+			p.slots = make(chan struct{}, p.MaxConcurrentQueries)
+		}
+		profiles[class] = &p
+	}
+	return profiles
+}
+
+// settings adds the settings of the profile to the settings of the query
+func (p *QueryProfile) settings(settings clickhouse.Settings) {
+	if p == nil {
+		return
+	}
+	if p.MaxExecutionTime > 0 {
+		settings["max_execution_time"] = int(p.MaxExecutionTime.Seconds())
+	}
+	if p.MaxBytesToRead > 0 {
+		settings["max_bytes_to_read"] = strconv.FormatUint(p.MaxBytesToRead, 10)
+	}
+	for k, v := range p.Settings {
+		settings[k] = v
+	}
+}
+
+// acquire waits until the class has a query less than its limit running, the returned
+// release func must be called once the query is done
+func (p *QueryProfile) acquire(ctx context.Context) (func(), error) {
+	if p == nil || p.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return func() { <-p.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
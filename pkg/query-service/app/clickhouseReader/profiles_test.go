@@ -0,0 +1,87 @@
+package clickhouseReader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/common"
+)
+
+func TestLoadQuerySettingsConfig(t *testing.T) {
+	config, err := LoadQuerySettingsConfig("../../config/clickhouse-settings.yml")
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, config.Pool.ConnMaxLifetime)
+	require.Equal(t, 10*time.Minute, config.Profiles[common.QueryClassExport].MaxExecutionTime)
+	require.Equal(t, 2, config.Profiles[common.QueryClassExport].MaxConcurrentQueries)
+
+	options := NewOptions("", 5, 10, time.Second, primaryNamespace)
+	options.setQuerySettings(config)
+	require.Equal(t, 50, options.getPrimary().MaxIdleConns)
+	require.Equal(t, 100, options.getPrimary().MaxOpenConns)
+	require.Equal(t, time.Hour, options.getPrimary().ConnMaxLifetime)
+}
+
+func TestQuerySettingsConfigValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		config QuerySettingsConfig
+	}{
+		{name: "unknown class", config: QuerySettingsConfig{Profiles: map[common.QueryClass]*QueryProfile{"batch": {}}}},
+		{name: "sub second execution time", config: QuerySettingsConfig{Profiles: map[common.QueryClass]*QueryProfile{common.QueryClassAlerts: {MaxExecutionTime: time.Millisecond}}}},
+		{name: "more idle than open conns", config: QuerySettingsConfig{Pool: PoolConfig{MaxIdleConns: 10, MaxOpenConns: 5}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Error(t, c.config.Validate())
+		})
+	}
+}
+
+func TestQueryProfileSettings(t *testing.T) {
+	config := &QuerySettingsConfig{Profiles: map[common.QueryClass]*QueryProfile{
+		common.QueryClassAlerts: {MaxExecutionTime: 30 * time.Second, MaxBytesToRead: 1000, Settings: map[string]string{"priority": "2"}},
+	}}
+	wrap := clickhouseConnWrapper{
+		settings: ClickhouseQuerySettings{MaxBytesToRead: "5000", MaxExecutionTimeLeaf: "10"},
+		profiles: config.queryProfiles(),
+	}
+
+	// the interactive queries keep the settings of the environment
+	interactive := wrap.querySettings(context.Background(), "SELECT 1")
+	require.Equal(t, "5000", interactive["max_bytes_to_read"])
+	require.NotContains(t, interactive, "max_execution_time")
+
+	alerts := wrap.querySettings(common.WithQueryClass(context.Background(), common.QueryClassAlerts), "SELECT 1")
+	require.Equal(t, "1000", alerts["max_bytes_to_read"])
+	require.Equal(t, 30, alerts["max_execution_time"])
+	require.Equal(t, "10", alerts["max_execution_time_leaf"])
+	require.Equal(t, "2", alerts["priority"])
+}
+
+func TestQueryProfileConcurrency(t *testing.T) {
+	config := &QuerySettingsConfig{Profiles: map[common.QueryClass]*QueryProfile{
+		common.QueryClassExport: {MaxConcurrentQueries: 1},
+	}}
+	wrap := clickhouseConnWrapper{profiles: config.queryProfiles()}
+	exportCtx := common.WithQueryClass(context.Background(), common.QueryClassExport)
+
+	_, release, err := wrap.acquire(exportCtx, "SELECT 1")
+	require.NoError(t, err)
+
+	// the interactive queries don't wait for the exports
+	_, releaseInteractive, err := wrap.acquire(context.Background(), "SELECT 2")
+	require.NoError(t, err)
+	releaseInteractive()
+
+	ctx, cancel := context.WithTimeout(exportCtx, 50*time.Millisecond)
+	defer cancel()
+	_, _, err = wrap.acquire(ctx, "SELECT 3")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+	_, release, err = wrap.acquire(exportCtx, "SELECT 4")
+	require.NoError(t, err)
+	release()
+}
@@ -0,0 +1,220 @@
+package clickhouseReader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+// promInternalLabels are the labels added by SigNoz to the series, they are not exposed in
+// the Prometheus API
+var promInternalLabels = map[string]bool{"__temporality__": true}
+
+// promSeriesTable returns the time series table for the range and the start rounded down to
+// the granularity of the table, as the v4 metrics queries
+func promSeriesTable(start, end time.Time) (string, int64) {
+	startMs, rangeMs := start.UnixMilli(), end.Sub(start).Milliseconds()
+	switch {
+	case rangeMs <= 6*time.Hour.Milliseconds():
+		return signozTSTableNameV4, startMs - startMs%time.Hour.Milliseconds()
+	case rangeMs <= 24*time.Hour.Milliseconds():
+		return signozTSTableNameV46Hrs, startMs - startMs%(6*time.Hour.Milliseconds())
+	default:
+		return signozTSTableNameV41Day, startMs - startMs%(24*time.Hour.Milliseconds())
+	}
+}
+
+// promMatcherCondition converts a label matcher to a condition on the time series table
+func promMatcherCondition(m *labels.Matcher) string {
+	column := fmt.Sprintf("JSONExtractString(labels, %s)", utils.ClickHouseFormattedValue(m.Name))
+	if m.Name == labels.MetricName {
+		column = "metric_name"
+	}
+	value := utils.ClickHouseFormattedValue(m.Value)
+	switch m.Type {
+	case labels.MatchNotEqual:
+		return fmt.Sprintf("%s != %s", column, value)
+	case labels.MatchRegexp:
+		// the Prometheus regexes are anchored
+		return fmt.Sprintf("match(%s, %s)", column, utils.ClickHouseFormattedValue("^(?:"+m.Value+")$"))
+	case labels.MatchNotRegexp:
+		return fmt.Sprintf("NOT match(%s, %s)", column, utils.ClickHouseFormattedValue("^(?:"+m.Value+")$"))
+	default:
+		return fmt.Sprintf("%s = %s", column, value)
+	}
+}
+
+// promSeriesConditions returns the conditions selecting the series of the params
+func promSeriesConditions(params *model.PromSeriesParams) (string, string) {
+	table, start := promSeriesTable(params.Start, params.End)
+	conditions := []string{fmt.Sprintf("unix_milli >= %d AND unix_milli <= %d", start, params.End.UnixMilli())}
+
+	selectors := []string{}
+	for _, matchers := range params.Matchers {
+		selector := []string{}
+		for _, m := range matchers {
+			selector = append(selector, promMatcherCondition(m))
+		}
+		if len(selector) > 0 {
+			selectors = append(selectors, "("+strings.Join(selector, " AND ")+")")
+		}
+	}
+	if len(selectors) > 0 {
+		conditions = append(conditions, "("+strings.Join(selectors, " OR ")+")")
+	}
+	return table, strings.Join(conditions, " AND ")
+}
+
+func promLimit(limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" LIMIT %d", limit)
+}
+
+// GetPromLabelNames returns the names of the labels of the series, for the labels API
+func (r *ClickHouseReader) GetPromLabelNames(ctx context.Context, params *model.PromSeriesParams) ([]string, *model.ApiError) {
+	table, conditions := promSeriesConditions(params)
+	query := fmt.Sprintf("SELECT DISTINCT arrayJoin(JSONExtractKeys(labels)) AS name FROM %s.%s WHERE %s ORDER BY name%s",
+		signozMetricDBName, table, conditions, promLimit(params.Limit))
+
+	names := []string{}
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		zap.L().Error("Error while fetching label names", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		if !promInternalLabels[name] {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// GetPromLabelValues returns the values of the label in the series, for the label values API
+func (r *ClickHouseReader) GetPromLabelValues(ctx context.Context, name string, params *model.PromSeriesParams) ([]string, *model.ApiError) {
+	if promInternalLabels[name] {
+		return []string{}, nil
+	}
+	column := fmt.Sprintf("JSONExtractString(labels, %s)", utils.ClickHouseFormattedValue(name))
+	if name == labels.MetricName {
+		column = "metric_name"
+	}
+	table, conditions := promSeriesConditions(params)
+	query := fmt.Sprintf("SELECT DISTINCT %s AS value FROM %s.%s WHERE %s AND value != '' ORDER BY value%s",
+		column, signozMetricDBName, table, conditions, promLimit(params.Limit))
+
+	values := []string{}
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		zap.L().Error("Error while fetching label values", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// GetPromSeries returns the label sets of the series, for the series API
+func (r *ClickHouseReader) GetPromSeries(ctx context.Context, params *model.PromSeriesParams) ([]map[string]string, *model.ApiError) {
+	table, conditions := promSeriesConditions(params)
+	query := fmt.Sprintf("SELECT DISTINCT fingerprint, any(labels) FROM %s.%s WHERE %s GROUP BY fingerprint ORDER BY fingerprint%s",
+		signozMetricDBName, table, conditions, promLimit(params.Limit))
+
+	series := []map[string]string{}
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		zap.L().Error("Error while fetching series", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fingerprint uint64
+		var encoded string
+		if err := rows.Scan(&fingerprint, &encoded); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		labelSet := map[string]string{}
+		if err := json.Unmarshal([]byte(encoded), &labelSet); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		for name := range promInternalLabels {
+			delete(labelSet, name)
+		}
+		series = append(series, labelSet)
+	}
+	return series, nil
+}
+
+// promMetricType returns the Prometheus type of the metrics of the type
+func promMetricType(typ string, isMonotonic bool) string {
+	switch v3.MetricType(typ) {
+	case v3.MetricTypeSum:
+		if isMonotonic {
+			return "counter"
+		}
+		return "gauge"
+	case v3.MetricTypeGauge:
+		return "gauge"
+	case v3.MetricTypeHistogram, v3.MetricTypeExponentialHistogram:
+		return "histogram"
+	case v3.MetricTypeSummary:
+		return "summary"
+	default:
+		return "unknown"
+	}
+}
+
+// GetPromMetricMetadata returns the metadata of the metrics seen in the last day, or of
+// the metric when set, for the metadata API
+func (r *ClickHouseReader) GetPromMetricMetadata(ctx context.Context, metric string, matchers []*labels.Matcher, limit int) (map[string][]model.PromMetricMetadata, *model.ApiError) {
+	end := time.Now()
+	table, start := promSeriesTable(end.Add(-24*time.Hour), end)
+	conditions := fmt.Sprintf("unix_milli >= %d", start)
+	if metric != "" {
+		conditions += fmt.Sprintf(" AND metric_name = %s", utils.ClickHouseFormattedValue(metric))
+	}
+	for _, m := range matchers {
+		conditions += " AND " + promMatcherCondition(m)
+	}
+	query := fmt.Sprintf("SELECT metric_name, any(type), any(is_monotonic), any(description), any(unit) FROM %s.%s WHERE %s GROUP BY metric_name ORDER BY metric_name%s",
+		signozMetricDBName, table, conditions, promLimit(limit))
+
+	metadata := map[string][]model.PromMetricMetadata{}
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		zap.L().Error("Error while fetching metric metadata", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, typ, description, unit string
+		var isMonotonic bool
+		if err := rows.Scan(&name, &typ, &isMonotonic, &description, &unit); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		metadata[name] = []model.PromMetricMetadata{{Type: promMetricType(typ, isMonotonic), Help: description, Unit: unit}}
+	}
+	return metadata, nil
+}
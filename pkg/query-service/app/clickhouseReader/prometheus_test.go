@@ -0,0 +1,47 @@
+package clickhouseReader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestPromSeriesConditions(t *testing.T) {
+	assert := assert.New(t)
+	end := time.UnixMilli(1700000000000)
+
+	params := &model.PromSeriesParams{
+		Start: end.Add(-time.Hour),
+		End:   end,
+		Matchers: [][]*labels.Matcher{
+			{
+				labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "http_requests_total"),
+				labels.MustNewMatcher(labels.MatchNotRegexp, "code", "5.."),
+			},
+			{labels.MustNewMatcher(labels.MatchNotEqual, "job", "it's")},
+		},
+	}
+	table, conditions := promSeriesConditions(params)
+	assert.Equal(signozTSTableNameV4, table)
+	assert.Equal("unix_milli >= 1699995600000 AND unix_milli <= 1700000000000 AND "+
+		"((metric_name = 'http_requests_total' AND NOT match(JSONExtractString(labels, 'code'), '^(?:5..)$')) OR "+
+		"(JSONExtractString(labels, 'job') != 'it\\'s'))", conditions)
+
+	// the longer ranges read the coarser tables
+	table, _ = promSeriesConditions(&model.PromSeriesParams{Start: end.Add(-12 * time.Hour), End: end})
+	assert.Equal(signozTSTableNameV46Hrs, table)
+	table, conditions = promSeriesConditions(&model.PromSeriesParams{Start: end.Add(-7 * 24 * time.Hour), End: end})
+	assert.Equal(signozTSTableNameV41Day, table)
+	assert.Equal("unix_milli >= 1699315200000 AND unix_milli <= 1700000000000", conditions)
+}
+
+func TestPromMetricType(t *testing.T) {
+	assert.Equal(t, "counter", promMetricType("Sum", true))
+	assert.Equal(t, "gauge", promMetricType("Sum", false))
+	assert.Equal(t, "gauge", promMetricType("Gauge", false))
+	assert.Equal(t, "histogram", promMetricType("Histogram", false))
+	assert.Equal(t, "summary", promMetricType("Summary", false))
+}
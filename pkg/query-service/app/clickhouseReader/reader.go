@@ -43,7 +43,9 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
 	"go.signoz.io/signoz/pkg/query-service/app/explorer"
 	"go.signoz.io/signoz/pkg/query-service/app/logs"
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/rollup"
 	"go.signoz.io/signoz/pkg/query-service/app/services"
+	"go.signoz.io/signoz/pkg/query-service/app/traces/criticalpath"
 	"go.signoz.io/signoz/pkg/query-service/auth"
 	"go.signoz.io/signoz/pkg/query-service/common"
 	"go.signoz.io/signoz/pkg/query-service/constants"
@@ -138,16 +140,26 @@ func NewReader(
 	maxOpenConns int,
 	dialTimeout time.Duration,
 	cluster string,
+	querySettings *QuerySettingsConfig,
 ) *ClickHouseReader {
 
 	datasource := os.Getenv("ClickHouseUrl")
 	options := NewOptions(datasource, maxIdleConns, maxOpenConns, dialTimeout, primaryNamespace, archiveNamespace)
+	options.setQuerySettings(querySettings)
 	db, err := initialize(options)
 
 	if err != nil {
 		zap.L().Fatal("failed to initialize ClickHouse", zap.Error(err))
 	}
 
+	// the reads are spread over the replicas, the writes stay on the primary datasource
+	if replicas := os.Getenv("ClickHouseReplicaUrls"); replicas != "" {
+		db, err = connectReplicas(options.getPrimary(), db, replicas)
+		if err != nil {
+			zap.L().Fatal("failed to initialize the ClickHouse replicas", zap.Error(err))
+		}
+	}
+
 	return NewReaderFromClickhouseConnection(db, options, localDB, configFile, featureFlag, cluster)
 }
 
@@ -176,8 +188,23 @@ func NewReaderFromClickhouseConnection(
 		}
 	}
 
+	admission, err := newQueryAdmission(
+		os.Getenv("ClickHouseMaxConcurrentQueriesPerOrg"),
+		os.Getenv("ClickHouseMaxConcurrentQueriesPerUser"),
+		os.Getenv("ClickHouseQueryQueueTimeout"),
+	)
+	if err != nil {
+		zap.L().Error("Incorrect query admission settings", zap.Error(err))
+		os.Exit(1)
+	}
+	admission.kill = func(queryId string) {
+		killQuery(db, cluster, queryId)
+	}
+
 	wrap := clickhouseConnWrapper{
-		conn: db,
+		conn:      db,
+		admission: admission,
+		profiles:  options.querySettings.queryProfiles(),
 		settings: ClickhouseQuerySettings{
 			MaxExecutionTimeLeaf:                os.Getenv("ClickHouseMaxExecutionTimeLeaf"),
 			TimeoutBeforeCheckingExecutionSpeed: os.Getenv("ClickHouseTimeoutBeforeCheckingExecutionSpeed"),
@@ -378,6 +405,105 @@ func connect(cfg *namespaceConfig) (clickhouse.Conn, error) {
 	return cfg.Connector(cfg)
 }
 
+// GetQueriesInFlight returns the queries of the org queued and running on behalf of the users
+func (r *ClickHouseReader) GetQueriesInFlight(ctx context.Context, orgId string) *model.QueriesInFlightResponse {
+	wrap, ok := r.db.(clickhouseConnWrapper)
+	if !ok {
+		return &model.QueriesInFlightResponse{Queries: []model.QueryInFlight{}}
+	}
+	return wrap.admission.queriesInFlight(orgId)
+}
+
+// CancelQuery cancels the query of the org queued or running on behalf of a user, or all the
+// queries of the API request when the id is the one of a request. Only the user who made the
+// queries or an admin can cancel them
+func (r *ClickHouseReader) CancelQuery(ctx context.Context, user *model.UserPayload, id string) ([]model.QueryInFlight, *model.ApiError) {
+	wrap, ok := r.db.(clickhouseConnWrapper)
+	if !ok {
+		return nil, model.NotFoundError(fmt.Errorf("no query found with id %s", id))
+	}
+
+	queries := wrap.admission.lookup(user.OrgId, id)
+	if len(queries) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("no query found with id %s", id))
+	}
+	for _, query := range queries {
+		if query.UserId != user.Id && !auth.IsAdmin(user) {
+			return nil, model.ForbiddenError(fmt.Errorf("only admins can cancel the queries of other users"))
+		}
+	}
+	wrap.admission.cancel(queries)
+	return queries, nil
+}
+
+// killQuery kills the query and its distributed sub-queries on all the clickhouse nodes
+func killQuery(conn clickhouse.Conn, cluster string, queryId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	query := fmt.Sprintf("KILL QUERY ON CLUSTER %s WHERE initial_query_id = '%s' ASYNC", cluster, queryId)
+	if err := conn.Exec(ctx, query); err != nil {
+		zap.L().Error("failed to kill query", zap.String("queryId", queryId), zap.Error(err))
+	}
+}
+
+// EstimateQueryCost explains the query for the parts, marks and rows it would read, the bytes
+// are estimated from the average size of the rows of the tables read
+func (r *ClickHouseReader) EstimateQueryCost(ctx context.Context, query string) (*model.QueryCostEstimate, *model.ApiError) {
+	type tableEstimate struct {
+		Database string `ch:"database"`
+		Table    string `ch:"table"`
+		Parts    uint64 `ch:"parts"`
+		Rows     uint64 `ch:"rows"`
+		Marks    uint64 `ch:"marks"`
+	}
+	tables := []tableEstimate{}
+	if err := r.db.Select(ctx, &tables, "EXPLAIN ESTIMATE "+query); err != nil {
+		zap.L().Error("error while estimating query cost", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("error while estimating query cost: %w", err)}
+	}
+
+	estimate := &model.QueryCostEstimate{}
+	if len(tables) == 0 {
+		estimate.CostClass = model.GetQueryCostClass(0)
+		return estimate, nil
+	}
+
+	// the sizes of the rows of all the tables read are queried at once
+	tuples := make([]string, 0, len(tables))
+	args := make([]interface{}, 0, 2*len(tables))
+	for _, t := range tables {
+		tuples = append(tuples, "(?, ?)")
+		args = append(args, t.Database, t.Table)
+	}
+	type tableSize struct {
+		Database    string  `ch:"database"`
+		Table       string  `ch:"table"`
+		BytesPerRow float64 `ch:"bytes_per_row"`
+	}
+	sizes := []tableSize{}
+	err := r.db.Select(ctx, &sizes,
+		"SELECT database, table, sum(data_uncompressed_bytes) / greatest(sum(rows), 1) AS bytes_per_row FROM system.parts "+
+			"WHERE active AND (database, table) IN ("+strings.Join(tuples, ", ")+") GROUP BY database, table",
+		args...)
+	if err != nil {
+		zap.L().Error("error while reading the size of the table rows", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	bytesPerRow := map[string]float64{}
+	for _, size := range sizes {
+		bytesPerRow[size.Database+"."+size.Table] = size.BytesPerRow
+	}
+
+	for _, t := range tables {
+		estimate.Parts += t.Parts
+		estimate.Marks += t.Marks
+		estimate.Rows += t.Rows
+		estimate.Bytes += uint64(float64(t.Rows) * bytesPerRow[t.Database+"."+t.Table])
+	}
+	estimate.CostClass = model.GetQueryCostClass(estimate.Bytes)
+	return estimate, nil
+}
+
 func (r *ClickHouseReader) GetConn() clickhouse.Conn {
 	return r.db
 }
@@ -407,12 +533,13 @@ func (r *ClickHouseReader) LoadChannel(channel *model.ChannelItem) *model.ApiErr
 	return nil
 }
 
-func (r *ClickHouseReader) GetChannel(id string) (*model.ChannelItem, *model.ApiError) {
+func (r *ClickHouseReader) GetChannel(ctx context.Context, id string) (*model.ChannelItem, *model.ApiError) {
 
 	idInt, _ := strconv.Atoi(id)
 	channel := model.ChannelItem{}
 
-	query := "SELECT id, created_at, updated_at, name, type, data data FROM notification_channels WHERE id=? "
+	condition, args := common.OrgCondition(ctx)
+	query := "SELECT id, created_at, updated_at, name, type, data data, org_id FROM notification_channels WHERE id=? AND " + condition
 
 	stmt, err := r.localDB.Preparex(query)
 
@@ -421,8 +548,11 @@ func (r *ClickHouseReader) GetChannel(id string) (*model.ChannelItem, *model.Api
 		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
 	}
 
-	err = stmt.Get(&channel, idInt)
+	err = stmt.Get(&channel, append([]interface{}{idInt}, args...)...)
 
+	if err == sql.ErrNoRows {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no channel found with id: %s", id)}
+	}
 	if err != nil {
 		zap.L().Error("Error in getting channel with id", zap.Int("id", idInt), zap.Error(err))
 		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
@@ -432,11 +562,11 @@ func (r *ClickHouseReader) GetChannel(id string) (*model.ChannelItem, *model.Api
 
 }
 
-func (r *ClickHouseReader) DeleteChannel(id string) *model.ApiError {
+func (r *ClickHouseReader) DeleteChannel(ctx context.Context, id string) *model.ApiError {
 
 	idInt, _ := strconv.Atoi(id)
 
-	channelToDelete, apiErrorObj := r.GetChannel(id)
+	channelToDelete, apiErrorObj := r.GetChannel(ctx, id)
 
 	if apiErrorObj != nil {
 		return apiErrorObj
@@ -479,13 +609,14 @@ func (r *ClickHouseReader) DeleteChannel(id string) *model.ApiError {
 
 }
 
-func (r *ClickHouseReader) GetChannels() (*[]model.ChannelItem, *model.ApiError) {
+func (r *ClickHouseReader) GetChannels(ctx context.Context) (*[]model.ChannelItem, *model.ApiError) {
 
 	channels := []model.ChannelItem{}
 
-	query := "SELECT id, created_at, updated_at, name, type, data data FROM notification_channels"
+	condition, args := common.OrgCondition(ctx)
+	query := "SELECT id, created_at, updated_at, name, type, data data, org_id FROM notification_channels WHERE " + condition
 
-	err := r.localDB.Select(&channels, query)
+	err := r.localDB.Select(&channels, query, args...)
 
 	zap.L().Info(query)
 
@@ -530,14 +661,26 @@ func getChannelType(receiver *am.Receiver) string {
 	if receiver.MSTeamsConfigs != nil {
 		return "msteams"
 	}
+	if receiver.TelegramConfigs != nil {
+		return "telegram"
+	}
+	if receiver.GoogleChatConfigs != nil {
+		return "googlechat"
+	}
+	if receiver.PagerDutyEventsConfigs != nil {
+		return "pagerduty_events"
+	}
+	if receiver.OpsgenieAlertConfigs != nil {
+		return "opsgenie_alert"
+	}
 	return ""
 }
 
-func (r *ClickHouseReader) EditChannel(receiver *am.Receiver, id string) (*am.Receiver, *model.ApiError) {
+func (r *ClickHouseReader) EditChannel(ctx context.Context, receiver *am.Receiver, id string) (*am.Receiver, *model.ApiError) {
 
 	idInt, _ := strconv.Atoi(id)
 
-	channel, apiErrObj := r.GetChannel(id)
+	channel, apiErrObj := r.GetChannel(ctx, id)
 
 	if apiErrObj != nil {
 		return nil, apiErrObj
@@ -594,7 +737,7 @@ func (r *ClickHouseReader) EditChannel(receiver *am.Receiver, id string) (*am.Re
 
 }
 
-func (r *ClickHouseReader) CreateChannel(receiver *am.Receiver) (*am.Receiver, *model.ApiError) {
+func (r *ClickHouseReader) CreateChannel(ctx context.Context, receiver *am.Receiver) (*am.Receiver, *model.ApiError) {
 
 	channel_type := getChannelType(receiver)
 
@@ -612,7 +755,7 @@ func (r *ClickHouseReader) CreateChannel(receiver *am.Receiver) (*am.Receiver, *
 	}
 
 	{
-		stmt, err := tx.Prepare(`INSERT INTO notification_channels (created_at, updated_at, name, type, data) VALUES($1,$2,$3,$4,$5);`)
+		stmt, err := tx.Prepare(`INSERT INTO notification_channels (created_at, updated_at, name, type, data, org_id) VALUES($1,$2,$3,$4,$5,$6);`)
 		if err != nil {
 			zap.L().Error("Error in preparing statement for INSERT to notification_channels", zap.Error(err))
 			tx.Rollback()
@@ -620,7 +763,7 @@ func (r *ClickHouseReader) CreateChannel(receiver *am.Receiver) (*am.Receiver, *
 		}
 		defer stmt.Close()
 
-		if _, err := stmt.Exec(time.Now(), time.Now(), receiver.Name, channel_type, string(receiverString)); err != nil {
+		if _, err := stmt.Exec(time.Now(), time.Now(), receiver.Name, channel_type, string(receiverString), common.GetOrgIdFromContext(ctx)); err != nil {
 			zap.L().Error("Error in Executing prepared statement for INSERT to notification_channels", zap.Error(err))
 			tx.Rollback() // return an error too, we may want to wrap them
 			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
@@ -681,12 +824,13 @@ func (r *ClickHouseReader) GetQueryRangeResult(ctx context.Context, query *model
 	return res, &qs, nil
 }
 
-func (r *ClickHouseReader) GetServicesList(ctx context.Context) (*[]string, error) {
+func (r *ClickHouseReader) GetServicesList(ctx context.Context, scope *model.OrgScope) (*[]string, error) {
 
 	services := []string{}
-	query := fmt.Sprintf(`SELECT DISTINCT serviceName FROM %s.%s WHERE toDate(timestamp) > now() - INTERVAL 1 DAY`, r.TraceDB, r.indexTable)
+	orgCond, orgArgs := orgCondition(scope)
+	query := fmt.Sprintf(`SELECT DISTINCT serviceName FROM %s.%s WHERE toDate(timestamp) > now() - INTERVAL 1 DAY%s`, r.TraceDB, r.indexTable, orgCond)
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := r.db.Query(ctx, query, orgArgs...)
 
 	zap.L().Info(query)
 
@@ -1045,6 +1189,9 @@ func (r *ClickHouseReader) GetSpanFilters(ctx context.Context, queryParams *mode
 	}
 
 	query = getStatusFilters(query, queryParams.Status, excludeMap)
+	orgCond, orgArgs := orgCondition(queryParams.OrgScope)
+	query += orgCond
+	args = append(args, orgArgs...)
 
 	traceFilterReponse := model.SpanFiltersResponse{
 		Status:             map[string]uint64{},
@@ -1656,6 +1803,9 @@ func (r *ClickHouseReader) GetTagFilters(ctx context.Context, queryParams *model
 	}
 
 	query = getStatusFilters(query, queryParams.Status, excludeMap)
+	orgCond, orgArgs := orgCondition(queryParams.OrgScope)
+	query += orgCond
+	args = append(args, orgArgs...)
 
 	tagFilters := []model.TagFilters{}
 
@@ -1768,6 +1918,9 @@ func (r *ClickHouseReader) GetTagValues(ctx context.Context, queryParams *model.
 	}
 
 	query = getStatusFilters(query, queryParams.Status, excludeMap)
+	orgCond, orgArgs := orgCondition(queryParams.OrgScope)
+	query += orgCond
+	args = append(args, orgArgs...)
 
 	tagValues := []model.TagValues{}
 
@@ -1861,12 +2014,15 @@ func (r *ClickHouseReader) GetUsage(ctx context.Context, queryParams *model.GetU
 		clickhouse.Named("start", strconv.FormatInt(queryParams.Start.UnixNano(), 10)),
 		clickhouse.Named("end", strconv.FormatInt(queryParams.End.UnixNano(), 10)),
 	}
+	// the usage is aggregated by service, the services of the other orgs are left out
+	orgCond, orgArgs := r.orgServicesCondition(queryParams.OrgScope, *queryParams.Start, *queryParams.End, "service_name")
+	namedArgs = append(namedArgs, orgArgs...)
 	var query string
 	if len(queryParams.ServiceName) != 0 {
 		namedArgs = append(namedArgs, clickhouse.Named("serviceName", queryParams.ServiceName))
-		query = fmt.Sprintf("SELECT toStartOfInterval(timestamp, INTERVAL @interval HOUR) as time, sum(count) as count FROM %s.%s WHERE service_name=@serviceName AND timestamp>=@start AND timestamp<=@end GROUP BY time ORDER BY time ASC", r.TraceDB, r.usageExplorerTable)
+		query = fmt.Sprintf("SELECT toStartOfInterval(timestamp, INTERVAL @interval HOUR) as time, sum(count) as count FROM %s.%s WHERE service_name=@serviceName AND timestamp>=@start AND timestamp<=@end%s GROUP BY time ORDER BY time ASC", r.TraceDB, r.usageExplorerTable, orgCond)
 	} else {
-		query = fmt.Sprintf("SELECT toStartOfInterval(timestamp, INTERVAL @interval HOUR) as time, sum(count) as count FROM %s.%s WHERE timestamp>=@start AND timestamp<=@end GROUP BY time ORDER BY time ASC", r.TraceDB, r.usageExplorerTable)
+		query = fmt.Sprintf("SELECT toStartOfInterval(timestamp, INTERVAL @interval HOUR) as time, sum(count) as count FROM %s.%s WHERE timestamp>=@start AND timestamp<=@end%s GROUP BY time ORDER BY time ASC", r.TraceDB, r.usageExplorerTable, orgCond)
 	}
 
 	err := r.db.Select(ctx, &usageItems, query, namedArgs...)
@@ -1942,16 +2098,23 @@ func (r *ClickHouseReader) SearchTraces(ctx context.Context, params *model.Searc
 	zap.L().Debug("getTraceSQLQuery took: ", zap.Duration("duration", end.Sub(start)))
 	searchSpansResult := []model.SearchSpansResult{{
 		Columns:   []string{"__time", "SpanId", "TraceId", "ServiceName", "Name", "Kind", "DurationNano", "TagsKeys", "TagsValues", "References", "Events", "HasError", "StatusMessage", "StatusCodeString", "SpanKind"},
-		Events:    make([][]interface{}, len(searchScanResponses)),
 		IsSubTree: false,
 	},
 	}
 
 	searchSpanResponses := []model.SearchSpanResponseItem{}
+	// the spans with their start times in ns, the critical path is computed on them
+	spansNano := make([]model.SearchSpanResponseItem, 0, len(searchScanResponses))
 	start = time.Now()
 	for _, item := range searchScanResponses {
 		var jsonItem model.SearchSpanResponseItem
 		easyjson.Unmarshal([]byte(item.Model), &jsonItem)
+		// the spans of the other orgs sharing the trace id are left out
+		if !params.OrgScope.Allows(jsonItem.TagMap[constants.OrgIdAttribute]) {
+			continue
+		}
+		jsonItem.TimeUnixNano = uint64(item.Timestamp.UnixNano())
+		spansNano = append(spansNano, jsonItem)
 		jsonItem.TimeUnixNano = uint64(item.Timestamp.UnixNano() / 1000000)
 		searchSpanResponses = append(searchSpanResponses, jsonItem)
 		if startTime == 0 || jsonItem.TimeUnixNano < startTime {
@@ -1969,7 +2132,8 @@ func (r *ClickHouseReader) SearchTraces(ctx context.Context, params *model.Searc
 
 	err = r.featureFlags.CheckFeature(model.SmartTraceDetail)
 	smartAlgoEnabled := err == nil
-	if len(searchScanResponses) > params.SpansRenderLimit && smartAlgoEnabled {
+	searchSpansResult[0].Events = make([][]interface{}, len(searchSpanResponses))
+	if len(searchSpanResponses) > params.SpansRenderLimit && smartAlgoEnabled {
 		start = time.Now()
 		searchSpansResult, err = smartTraceAlgorithm(searchSpanResponses, params.SpanID, params.LevelUp, params.LevelDown, params.SpansRenderLimit)
 		if err != nil {
@@ -1980,7 +2144,7 @@ func (r *ClickHouseReader) SearchTraces(ctx context.Context, params *model.Searc
 		userEmail, err := auth.GetEmailFromJwt(ctx)
 		if err == nil {
 			data := map[string]interface{}{
-				"traceSize":        len(searchScanResponses),
+				"traceSize":        len(searchSpanResponses),
 				"spansRenderLimit": params.SpansRenderLimit,
 			}
 			telemetry.GetInstance().SendEvent(telemetry.TELEMETRY_EVENT_LARGE_TRACE_OPENED, data, userEmail, true, false)
@@ -1994,10 +2158,50 @@ func (r *ClickHouseReader) SearchTraces(ctx context.Context, params *model.Searc
 
 	searchSpansResult[0].StartTimestampMillis = startTime - (durationNano / 1000000)
 	searchSpansResult[0].EndTimestampMillis = endTime + (durationNano / 1000000)
+	// the critical path is computed on all the spans, the smart trace algorithm only returns
+	// some of them
+	searchSpansResult[0].CriticalPath = criticalpath.Compute(spansNano)
 
 	return &searchSpansResult, nil
 }
 
+func (r *ClickHouseReader) GetTraceServices(ctx context.Context, traceID string, spanID string, scope *model.OrgScope) ([]model.TraceServiceSummary, *model.ApiError) {
+	if r.indexTable == "" {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: ErrNoIndexTable}
+	}
+
+	query := fmt.Sprintf(`SELECT
+			serviceName,
+			toUInt64(min(toUnixTimestamp64Nano(timestamp))) AS startTimeUnixNano,
+			toUInt64(max(toUnixTimestamp64Nano(timestamp) + durationNano)) AS endTimeUnixNano,
+			count() AS spanCount,
+			countIf(hasError = true) AS errorCount,
+			any(resourceTagsMap) AS resources,
+			argMax(spanID, durationNano) AS slowestSpanID,
+			argMax(name, durationNano) AS slowestOperation,
+			max(durationNano) AS slowestDurationNano,
+			toUInt64(argMax(toUnixTimestamp64Nano(timestamp), durationNano)) AS slowestTimeUnixNano
+		FROM %s.%s WHERE traceID = @traceID`, r.TraceDB, r.indexTable)
+	args := []interface{}{clickhouse.Named("traceID", traceID)}
+	if spanID != "" {
+		query += " AND spanID = @spanID"
+		args = append(args, clickhouse.Named("spanID", spanID))
+	}
+	orgCond, orgArgs := orgCondition(scope)
+	query += orgCond + " GROUP BY serviceName ORDER BY startTimeUnixNano"
+	args = append(args, orgArgs...)
+
+	services := []model.TraceServiceSummary{}
+	if err := r.db.Select(ctx, &services, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error in processing sql query: %w", err)}
+	}
+	if len(services) == 0 {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("trace %s not found", traceID)}
+	}
+	return services, nil
+}
+
 func (r *ClickHouseReader) GetDependencyGraph(ctx context.Context, queryParams *model.GetServicesParams) (*[]model.ServiceMapDependencyResponseItem, error) {
 
 	response := []model.ServiceMapDependencyResponseItem{}
@@ -2031,8 +2235,11 @@ func (r *ClickHouseReader) GetDependencyGraph(ctx context.Context, queryParams *
 
 	tags := createTagQueryFromTagQueryParams(queryParams.Tags)
 	filterQuery, filterArgs := services.BuildServiceMapQuery(tags)
-	query += filterQuery + " GROUP BY src, dest;"
+	// the edges are aggregated by service, the edges of the services of the other orgs are left out
+	orgCond, orgArgs := r.orgServicesCondition(queryParams.OrgScope, *queryParams.Start, *queryParams.End, "src", "dest")
+	query += filterQuery + orgCond + " GROUP BY src, dest;"
 	args = append(args, filterArgs...)
+	args = append(args, orgArgs...)
 
 	zap.L().Debug("GetDependencyGraph query", zap.String("query", query), zap.Any("args", args))
 
@@ -2261,6 +2468,10 @@ func (r *ClickHouseReader) SetTTL(ctx context.Context,
 				return nil, &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf("TTL is already running")}
 			}
 		}
+		overrides, apiErr := r.saveTTLOverrides(params)
+		if apiErr != nil {
+			return nil, apiErr
+		}
 		for _, tableName := range tableNameArray {
 			tableName := getLocalTableName(tableName)
 			// TODO: DB queries should be implemented with transactional statements but currently clickhouse doesn't support them. Issue: https://github.com/ClickHouse/ClickHouse/issues/22086
@@ -2270,9 +2481,14 @@ func (r *ClickHouseReader) SetTTL(ctx context.Context,
 					zap.L().Error("Error in inserting to ttl_status table", zap.Error(dbErr))
 					return
 				}
+				// the other tables have no resource attributes, they keep the default retention
+				tableOverrides := []model.TTLOverride{}
+				if tableName == signozTraceDBName+"."+signozTraceLocalTableName {
+					tableOverrides = overrides
+				}
 				req := fmt.Sprintf(
-					"ALTER TABLE %v ON CLUSTER %s MODIFY TTL toDateTime(timestamp) + INTERVAL %v SECOND DELETE",
-					tableName, r.cluster, params.DelDuration)
+					"ALTER TABLE %v ON CLUSTER %s MODIFY TTL %s",
+					tableName, r.cluster, strings.Join(deleteTTLRules(params.Type, "toDateTime(timestamp)", params.DelDuration, tableOverrides), ", "))
 				if len(params.ColdStorageVolume) > 0 {
 					req += fmt.Sprintf(", toDateTime(timestamp) + INTERVAL %v SECOND TO VOLUME '%s'",
 						params.ToColdStorageDuration, params.ColdStorageVolume)
@@ -2312,6 +2528,9 @@ func (r *ClickHouseReader) SetTTL(ctx context.Context,
 
 	case constants.MetricsTTL:
 		tableNames := []string{signozMetricDBName + "." + signozSampleLocalTableName, signozMetricDBName + "." + signozTSLocalTableNameV4, signozMetricDBName + "." + signozTSLocalTableNameV46Hrs, signozMetricDBName + "." + signozTSLocalTableNameV41Day}
+		for _, res := range rollup.Resolutions {
+			tableNames = append(tableNames, signozMetricDBName+"."+res.LocalTable)
+		}
 		for _, tableName := range tableNames {
 			statusItem, err := r.checkTTLStatusItem(ctx, tableName)
 			if err != nil {
@@ -2383,6 +2602,10 @@ func (r *ClickHouseReader) SetTTL(ctx context.Context,
 		if statusItem.Status == constants.StatusPending {
 			return nil, &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf("TTL is already running")}
 		}
+		overrides, apiErr := r.saveTTLOverrides(params)
+		if apiErr != nil {
+			return nil, apiErr
+		}
 		go func(tableName string) {
 			_, dbErr := r.localDB.Exec("INSERT INTO ttl_status (transaction_id, created_at, updated_at, table_name, ttl, status, cold_storage_ttl) VALUES (?, ?, ?, ?, ?, ?, ?)", uuid, time.Now(), time.Now(), tableName, params.DelDuration, constants.StatusPending, coldStorageDuration)
 			if dbErr != nil {
@@ -2390,8 +2613,8 @@ func (r *ClickHouseReader) SetTTL(ctx context.Context,
 				return
 			}
 			req := fmt.Sprintf(
-				"ALTER TABLE %v ON CLUSTER %s MODIFY TTL toDateTime(timestamp / 1000000000) + "+
-					"INTERVAL %v SECOND DELETE", tableName, r.cluster, params.DelDuration)
+				"ALTER TABLE %v ON CLUSTER %s MODIFY TTL %s", tableName, r.cluster,
+				strings.Join(deleteTTLRules(params.Type, "toDateTime(timestamp / 1000000000)", params.DelDuration, overrides), ", "))
 			if len(params.ColdStorageVolume) > 0 {
 				req += fmt.Sprintf(", toDateTime(timestamp / 1000000000)"+
 					" + INTERVAL %v SECOND TO VOLUME '%s'",
@@ -2645,8 +2868,13 @@ func (r *ClickHouseReader) GetTTL(ctx context.Context, ttlParams *model.GetTTLPa
 			ttlQuery.ColdStorageTtl = ttlQuery.ColdStorageTtl / 3600 // convert to hours
 		}
 
+		overrides, err := r.getTTLOverrides(ttlParams.Type)
+		if err != nil {
+			return nil, err
+		}
+
 		delTTL, moveTTL := parseTTL(dbResp.EngineFull)
-		return &model.GetTTLResponseItem{TracesTime: delTTL, TracesMoveTime: moveTTL, ExpectedTracesTime: ttlQuery.TTL, ExpectedTracesMoveTime: ttlQuery.ColdStorageTtl, Status: status}, nil
+		return &model.GetTTLResponseItem{TracesTime: delTTL, TracesMoveTime: moveTTL, ExpectedTracesTime: ttlQuery.TTL, ExpectedTracesMoveTime: ttlQuery.ColdStorageTtl, Status: status, Overrides: overrides}, nil
 
 	case constants.MetricsTTL:
 		tableNameArray := []string{signozMetricDBName + "." + signozSampleTableName}
@@ -2691,8 +2919,13 @@ func (r *ClickHouseReader) GetTTL(ctx context.Context, ttlParams *model.GetTTLPa
 			ttlQuery.ColdStorageTtl = ttlQuery.ColdStorageTtl / 3600 // convert to hours
 		}
 
+		overrides, err := r.getTTLOverrides(ttlParams.Type)
+		if err != nil {
+			return nil, err
+		}
+
 		delTTL, moveTTL := parseTTL(dbResp.EngineFull)
-		return &model.GetTTLResponseItem{LogsTime: delTTL, LogsMoveTime: moveTTL, ExpectedLogsTime: ttlQuery.TTL, ExpectedLogsMoveTime: ttlQuery.ColdStorageTtl, Status: status}, nil
+		return &model.GetTTLResponseItem{LogsTime: delTTL, LogsMoveTime: moveTTL, ExpectedLogsTime: ttlQuery.TTL, ExpectedLogsMoveTime: ttlQuery.ColdStorageTtl, Status: status, Overrides: overrides}, nil
 
 	default:
 		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error while getting ttl. ttl type should be metrics|traces, got %v",
@@ -2716,8 +2949,14 @@ func (r *ClickHouseReader) ListErrors(ctx context.Context, queryParams *model.Li
 	} else {
 		query = query + ", any(exceptionType) as exceptionType"
 	}
+	if queryParams.ByOrg {
+		query = query + ", resourceTagsMap[@orgIdKey] as orgId"
+	}
 	query += fmt.Sprintf(" FROM %s.%s WHERE timestamp >= @timestampL AND timestamp <= @timestampU", r.TraceDB, r.errorTable)
 	args := []interface{}{clickhouse.Named("timestampL", strconv.FormatInt(queryParams.Start.UnixNano(), 10)), clickhouse.Named("timestampU", strconv.FormatInt(queryParams.End.UnixNano(), 10))}
+	if queryParams.ByOrg {
+		args = append(args, clickhouse.Named("orgIdKey", constants.OrgIdAttribute))
+	}
 
 	if len(queryParams.ServiceName) != 0 {
 		query = query + " AND serviceName ilike @serviceName"
@@ -2745,6 +2984,9 @@ func (r *ClickHouseReader) ListErrors(ctx context.Context, queryParams *model.Li
 	if len(queryParams.ExceptionType) != 0 {
 		query = query + ", exceptionType"
 	}
+	if queryParams.ByOrg {
+		query = query + ", orgId"
+	}
 	if len(queryParams.OrderParam) != 0 {
 		if queryParams.Order == constants.Descending {
 			query = query + " ORDER BY " + queryParams.OrderParam + " DESC"
@@ -2810,7 +3052,42 @@ func (r *ClickHouseReader) CountErrors(ctx context.Context, queryParams *model.C
 	return errorCount, nil
 }
 
+// orgCondition is the condition of the queries of the spans and errors tables on the orgs of
+// the user, the rows without the org id belong to the default org
+func orgCondition(scope *model.OrgScope) (string, []interface{}) {
+	if scope == nil {
+		return "", nil
+	}
+	not := ""
+	if scope.Exclude {
+		not = "NOT "
+	}
+	return fmt.Sprintf(" AND %s[@orgIdKey] %sIN @orgIds", model.ResourceTagMapCol, not),
+		[]interface{}{clickhouse.Named("orgIdKey", constants.OrgIdAttribute), clickhouse.Named("orgIds", scope.OrgIds)}
+}
+
+// orgServicesCondition is the condition of the queries of the tables aggregated by service,
+// which have no org id, on the services with spans in the orgs of the user in the range
+func (r *ClickHouseReader) orgServicesCondition(scope *model.OrgScope, start, end time.Time, columns ...string) (string, []interface{}) {
+	if scope == nil {
+		return "", nil
+	}
+	orgCond, args := orgCondition(scope)
+	services := fmt.Sprintf("SELECT DISTINCT serviceName FROM %s.%s WHERE timestamp >= @orgStart AND timestamp <= @orgEnd%s",
+		r.TraceDB, r.indexTable, orgCond)
+	condition := ""
+	for _, column := range columns {
+		condition += fmt.Sprintf(" AND %s IN (%s)", column, services)
+	}
+	args = append(args,
+		clickhouse.Named("orgStart", strconv.FormatInt(start.UnixNano(), 10)),
+		clickhouse.Named("orgEnd", strconv.FormatInt(end.UnixNano(), 10)),
+	)
+	return condition, args
+}
+
 func (r *ClickHouseReader) GetErrorFromErrorID(ctx context.Context, queryParams *model.GetErrorParams) (*model.ErrorWithSpan, *model.ApiError) {
+	orgCond, orgArgs := orgCondition(queryParams.OrgScope)
 
 	if queryParams.ErrorID == "" {
 		zap.L().Error("errorId missing from params")
@@ -2818,8 +3095,9 @@ func (r *ClickHouseReader) GetErrorFromErrorID(ctx context.Context, queryParams
 	}
 	var getErrorWithSpanReponse []model.ErrorWithSpan
 
-	query := fmt.Sprintf("SELECT errorID, exceptionType, exceptionStacktrace, exceptionEscaped, exceptionMessage, timestamp, spanID, traceID, serviceName, groupID FROM %s.%s WHERE timestamp = @timestamp AND groupID = @groupID AND errorID = @errorID LIMIT 1", r.TraceDB, r.errorTable)
+	query := fmt.Sprintf("SELECT errorID, exceptionType, exceptionStacktrace, exceptionEscaped, exceptionMessage, timestamp, spanID, traceID, serviceName, groupID FROM %s.%s WHERE timestamp = @timestamp AND groupID = @groupID AND errorID = @errorID%s LIMIT 1", r.TraceDB, r.errorTable, orgCond)
 	args := []interface{}{clickhouse.Named("errorID", queryParams.ErrorID), clickhouse.Named("groupID", queryParams.GroupID), clickhouse.Named("timestamp", strconv.FormatInt(queryParams.Timestamp.UnixNano(), 10))}
+	args = append(args, orgArgs...)
 
 	err := r.db.Select(ctx, &getErrorWithSpanReponse, query, args...)
 	zap.L().Info(query)
@@ -2838,11 +3116,13 @@ func (r *ClickHouseReader) GetErrorFromErrorID(ctx context.Context, queryParams
 }
 
 func (r *ClickHouseReader) GetErrorFromGroupID(ctx context.Context, queryParams *model.GetErrorParams) (*model.ErrorWithSpan, *model.ApiError) {
+	orgCond, orgArgs := orgCondition(queryParams.OrgScope)
 
 	var getErrorWithSpanReponse []model.ErrorWithSpan
 
-	query := fmt.Sprintf("SELECT errorID, exceptionType, exceptionStacktrace, exceptionEscaped, exceptionMessage, timestamp, spanID, traceID, serviceName, groupID FROM %s.%s WHERE timestamp = @timestamp AND groupID = @groupID LIMIT 1", r.TraceDB, r.errorTable)
+	query := fmt.Sprintf("SELECT errorID, exceptionType, exceptionStacktrace, exceptionEscaped, exceptionMessage, timestamp, spanID, traceID, serviceName, groupID FROM %s.%s WHERE timestamp = @timestamp AND groupID = @groupID%s LIMIT 1", r.TraceDB, r.errorTable, orgCond)
 	args := []interface{}{clickhouse.Named("groupID", queryParams.GroupID), clickhouse.Named("timestamp", strconv.FormatInt(queryParams.Timestamp.UnixNano(), 10))}
+	args = append(args, orgArgs...)
 
 	err := r.db.Select(ctx, &getErrorWithSpanReponse, query, args...)
 
@@ -2886,11 +3166,13 @@ func (r *ClickHouseReader) GetNextPrevErrorIDs(ctx context.Context, queryParams
 }
 
 func (r *ClickHouseReader) getNextErrorID(ctx context.Context, queryParams *model.GetErrorParams) (string, time.Time, *model.ApiError) {
+	orgCond, orgArgs := orgCondition(queryParams.OrgScope)
 
 	var getNextErrorIDReponse []model.NextPrevErrorIDsDBResponse
 
-	query := fmt.Sprintf("SELECT errorID as nextErrorID, timestamp as nextTimestamp FROM %s.%s WHERE groupID = @groupID AND timestamp >= @timestamp AND errorID != @errorID ORDER BY timestamp ASC LIMIT 2", r.TraceDB, r.errorTable)
+	query := fmt.Sprintf("SELECT errorID as nextErrorID, timestamp as nextTimestamp FROM %s.%s WHERE groupID = @groupID AND timestamp >= @timestamp AND errorID != @errorID%s ORDER BY timestamp ASC LIMIT 2", r.TraceDB, r.errorTable, orgCond)
 	args := []interface{}{clickhouse.Named("errorID", queryParams.ErrorID), clickhouse.Named("groupID", queryParams.GroupID), clickhouse.Named("timestamp", strconv.FormatInt(queryParams.Timestamp.UnixNano(), 10))}
+	args = append(args, orgArgs...)
 
 	err := r.db.Select(ctx, &getNextErrorIDReponse, query, args...)
 
@@ -2910,8 +3192,9 @@ func (r *ClickHouseReader) getNextErrorID(ctx context.Context, queryParams *mode
 		if getNextErrorIDReponse[0].Timestamp.UnixNano() == getNextErrorIDReponse[1].Timestamp.UnixNano() {
 			var getNextErrorIDReponse []model.NextPrevErrorIDsDBResponse
 
-			query := fmt.Sprintf("SELECT errorID as nextErrorID, timestamp as nextTimestamp FROM %s.%s WHERE groupID = @groupID AND timestamp = @timestamp AND errorID > @errorID ORDER BY errorID ASC LIMIT 1", r.TraceDB, r.errorTable)
+			query := fmt.Sprintf("SELECT errorID as nextErrorID, timestamp as nextTimestamp FROM %s.%s WHERE groupID = @groupID AND timestamp = @timestamp AND errorID > @errorID%s ORDER BY errorID ASC LIMIT 1", r.TraceDB, r.errorTable, orgCond)
 			args := []interface{}{clickhouse.Named("errorID", queryParams.ErrorID), clickhouse.Named("groupID", queryParams.GroupID), clickhouse.Named("timestamp", strconv.FormatInt(queryParams.Timestamp.UnixNano(), 10))}
+			args = append(args, orgArgs...)
 
 			err := r.db.Select(ctx, &getNextErrorIDReponse, query, args...)
 
@@ -2924,8 +3207,9 @@ func (r *ClickHouseReader) getNextErrorID(ctx context.Context, queryParams *mode
 			if len(getNextErrorIDReponse) == 0 {
 				var getNextErrorIDReponse []model.NextPrevErrorIDsDBResponse
 
-				query := fmt.Sprintf("SELECT errorID as nextErrorID, timestamp as nextTimestamp FROM %s.%s WHERE groupID = @groupID AND timestamp > @timestamp ORDER BY timestamp ASC LIMIT 1", r.TraceDB, r.errorTable)
+				query := fmt.Sprintf("SELECT errorID as nextErrorID, timestamp as nextTimestamp FROM %s.%s WHERE groupID = @groupID AND timestamp > @timestamp%s ORDER BY timestamp ASC LIMIT 1", r.TraceDB, r.errorTable, orgCond)
 				args := []interface{}{clickhouse.Named("errorID", queryParams.ErrorID), clickhouse.Named("groupID", queryParams.GroupID), clickhouse.Named("timestamp", strconv.FormatInt(queryParams.Timestamp.UnixNano(), 10))}
+				args = append(args, orgArgs...)
 
 				err := r.db.Select(ctx, &getNextErrorIDReponse, query, args...)
 
@@ -2955,11 +3239,13 @@ func (r *ClickHouseReader) getNextErrorID(ctx context.Context, queryParams *mode
 }
 
 func (r *ClickHouseReader) getPrevErrorID(ctx context.Context, queryParams *model.GetErrorParams) (string, time.Time, *model.ApiError) {
+	orgCond, orgArgs := orgCondition(queryParams.OrgScope)
 
 	var getPrevErrorIDReponse []model.NextPrevErrorIDsDBResponse
 
-	query := fmt.Sprintf("SELECT errorID as prevErrorID, timestamp as prevTimestamp FROM %s.%s WHERE groupID = @groupID AND timestamp <= @timestamp AND errorID != @errorID ORDER BY timestamp DESC LIMIT 2", r.TraceDB, r.errorTable)
+	query := fmt.Sprintf("SELECT errorID as prevErrorID, timestamp as prevTimestamp FROM %s.%s WHERE groupID = @groupID AND timestamp <= @timestamp AND errorID != @errorID%s ORDER BY timestamp DESC LIMIT 2", r.TraceDB, r.errorTable, orgCond)
 	args := []interface{}{clickhouse.Named("errorID", queryParams.ErrorID), clickhouse.Named("groupID", queryParams.GroupID), clickhouse.Named("timestamp", strconv.FormatInt(queryParams.Timestamp.UnixNano(), 10))}
+	args = append(args, orgArgs...)
 
 	err := r.db.Select(ctx, &getPrevErrorIDReponse, query, args...)
 
@@ -2979,8 +3265,9 @@ func (r *ClickHouseReader) getPrevErrorID(ctx context.Context, queryParams *mode
 		if getPrevErrorIDReponse[0].Timestamp.UnixNano() == getPrevErrorIDReponse[1].Timestamp.UnixNano() {
 			var getPrevErrorIDReponse []model.NextPrevErrorIDsDBResponse
 
-			query := fmt.Sprintf("SELECT errorID as prevErrorID, timestamp as prevTimestamp FROM %s.%s WHERE groupID = @groupID AND timestamp = @timestamp AND errorID < @errorID ORDER BY errorID DESC LIMIT 1", r.TraceDB, r.errorTable)
+			query := fmt.Sprintf("SELECT errorID as prevErrorID, timestamp as prevTimestamp FROM %s.%s WHERE groupID = @groupID AND timestamp = @timestamp AND errorID < @errorID%s ORDER BY errorID DESC LIMIT 1", r.TraceDB, r.errorTable, orgCond)
 			args := []interface{}{clickhouse.Named("errorID", queryParams.ErrorID), clickhouse.Named("groupID", queryParams.GroupID), clickhouse.Named("timestamp", strconv.FormatInt(queryParams.Timestamp.UnixNano(), 10))}
+			args = append(args, orgArgs...)
 
 			err := r.db.Select(ctx, &getPrevErrorIDReponse, query, args...)
 
@@ -2993,8 +3280,9 @@ func (r *ClickHouseReader) getPrevErrorID(ctx context.Context, queryParams *mode
 			if len(getPrevErrorIDReponse) == 0 {
 				var getPrevErrorIDReponse []model.NextPrevErrorIDsDBResponse
 
-				query := fmt.Sprintf("SELECT errorID as prevErrorID, timestamp as prevTimestamp FROM %s.%s WHERE groupID = @groupID AND timestamp < @timestamp ORDER BY timestamp DESC LIMIT 1", r.TraceDB, r.errorTable)
+				query := fmt.Sprintf("SELECT errorID as prevErrorID, timestamp as prevTimestamp FROM %s.%s WHERE groupID = @groupID AND timestamp < @timestamp%s ORDER BY timestamp DESC LIMIT 1", r.TraceDB, r.errorTable, orgCond)
 				args := []interface{}{clickhouse.Named("errorID", queryParams.ErrorID), clickhouse.Named("groupID", queryParams.GroupID), clickhouse.Named("timestamp", strconv.FormatInt(queryParams.Timestamp.UnixNano(), 10))}
+				args = append(args, orgArgs...)
 
 				err := r.db.Select(ctx, &getPrevErrorIDReponse, query, args...)
 
@@ -3658,6 +3946,33 @@ func (r *ClickHouseReader) GetLogs(ctx context.Context, params *model.LogsFilter
 	return &response, nil
 }
 
+// GetLogsSample returns the timestamp and body of a pseudo random sample of the
+// logs matching the params, the sample is stable for the same time range
+func (r *ClickHouseReader) GetLogsSample(ctx context.Context, params *model.LogsFilterParams) ([]model.LogSample, *model.ApiError) {
+	response := []model.LogSample{}
+	fields, apiErr := r.GetLogFields(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	filterSql, _, err := logs.GenerateSQLWhere(fields, params)
+	if err != nil {
+		return nil, &model.ApiError{Err: err, Typ: model.ErrorBadData}
+	}
+
+	query := fmt.Sprintf("SELECT timestamp, body FROM %s.%s", r.logsDB, r.logsTable)
+	if filterSql != "" {
+		query = fmt.Sprintf("%s WHERE %s", query, filterSql)
+	}
+	query = fmt.Sprintf("%s ORDER BY cityHash64(id) LIMIT %d", query, params.Limit)
+
+	err = r.db.Select(ctx, &response, query)
+	if err != nil {
+		return nil, &model.ApiError{Err: err, Typ: model.ErrorInternal}
+	}
+	return response, nil
+}
+
 func (r *ClickHouseReader) TailLogs(ctx context.Context, client *model.LogsTailClient) {
 
 	fields, apiErr := r.GetLogFields(ctx)
@@ -3667,7 +3982,8 @@ func (r *ClickHouseReader) TailLogs(ctx context.Context, client *model.LogsTailC
 	}
 
 	filterSql, lenFilters, err := logs.GenerateSQLWhere(fields, &model.LogsFilterParams{
-		Query: client.Filter.Query,
+		Query:    client.Filter.Query,
+		OrgScope: client.Filter.OrgScope,
 	})
 
 	data := map[string]interface{}{
@@ -3756,7 +4072,8 @@ func (r *ClickHouseReader) AggregateLogs(ctx context.Context, params *model.Logs
 	}
 
 	filterSql, lenFilters, err := logs.GenerateSQLWhere(fields, &model.LogsFilterParams{
-		Query: params.Query,
+		Query:    params.Query,
+		OrgScope: params.OrgScope,
 	})
 	if err != nil {
 		return nil, &model.ApiError{Err: err, Typ: model.ErrorBadData}
@@ -3955,11 +4272,22 @@ func (r *ClickHouseReader) GetMetricAttributeValues(ctx context.Context, req *v3
 	var rows driver.Rows
 	var attributeValues v3.FilterAttributeValueResponse
 
-	query = fmt.Sprintf("SELECT JSONExtractString(labels, $1) AS tagValue FROM %s.%s WHERE metric_name=$2 AND JSONExtractString(labels, $3) ILIKE $4 AND unix_milli >= $5 GROUP BY tagValue", signozMetricDBName, signozTSTableNameV41Day)
+	args := []interface{}{req.FilterAttributeKey, req.AggregateAttribute, req.FilterAttributeKey, fmt.Sprintf("%%%s%%", req.SearchText), common.PastDayRoundOff()}
+	orgCond := ""
+	if req.OrgScope != nil {
+		// the series without the org id label belong to the default org
+		not := ""
+		if req.OrgScope.Exclude {
+			not = "NOT "
+		}
+		orgCond = fmt.Sprintf(" AND JSONExtractString(labels, $6) %sIN $7", not)
+		args = append(args, constants.OrgIdLabel, req.OrgScope.OrgIds)
+	}
+	query = fmt.Sprintf("SELECT JSONExtractString(labels, $1) AS tagValue FROM %s.%s WHERE metric_name=$2 AND JSONExtractString(labels, $3) ILIKE $4 AND unix_milli >= $5%s GROUP BY tagValue", signozMetricDBName, signozTSTableNameV41Day, orgCond)
 	if req.Limit != 0 {
 		query = query + fmt.Sprintf(" LIMIT %d;", req.Limit)
 	}
-	rows, err = r.db.Query(ctx, query, req.FilterAttributeKey, req.AggregateAttribute, req.FilterAttributeKey, fmt.Sprintf("%%%s%%", req.SearchText), common.PastDayRoundOff())
+	rows, err = r.db.Query(ctx, query, args...)
 
 	if err != nil {
 		zap.L().Error("Error while executing query", zap.Error(err))
@@ -4576,6 +4904,32 @@ func logCommentKVs(ctx context.Context) map[string]string {
 }
 
 // GetTimeSeriesResultV3 runs the query and returns list of time series
+func (r *ClickHouseReader) GetExemplars(ctx context.Context, query string) ([]v3.Exemplar, error) {
+	var rows []struct {
+		UnixMilli int64             `ch:"unix_milli"`
+		Value     float64           `ch:"value"`
+		TraceId   string            `ch:"trace_id"`
+		SpanId    string            `ch:"span_id"`
+		Labels    map[string]string `ch:"exemplar_labels"`
+	}
+	if err := r.db.Select(ctx, &rows, query); err != nil {
+		zap.L().Error("error while fetching exemplars", zap.Error(err))
+		return nil, err
+	}
+
+	exemplars := make([]v3.Exemplar, 0, len(rows))
+	for _, row := range rows {
+		exemplars = append(exemplars, v3.Exemplar{
+			Timestamp: row.UnixMilli,
+			Value:     row.Value,
+			TraceId:   row.TraceId,
+			SpanId:    row.SpanId,
+			Labels:    row.Labels,
+		})
+	}
+	return exemplars, nil
+}
+
 func (r *ClickHouseReader) GetTimeSeriesResultV3(ctx context.Context, query string) ([]*v3.Series, error) {
 
 	ctxArgs := map[string]interface{}{"query": query}
@@ -0,0 +1,346 @@
+package clickhouseReader
+
+import (
+	"context"
+	sqldriver "database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.uber.org/zap"
+)
+
+const (
+	// replicaHealthCheckInterval is how often the unhealthy replicas are pinged
+	replicaHealthCheckInterval = 10 * time.Second
+	// replicaPingTimeout is how long the ping of a replica may take
+	replicaPingTimeout = 5 * time.Second
+	// replicaLatencyDecay is the weight of the latest query in the average latency of a replica
+	replicaLatencyDecay = 0.2
+)
+
+// retryableExceptions are the codes of the clickhouse exceptions raised by an overloaded
+// or unreachable replica, the same query may succeed on another replica
+var retryableExceptions = map[int32]struct{}{
+	3:   {}, // UNEXPECTED_END_OF_FILE
+	202: {}, // TOO_MANY_SIMULTANEOUS_QUERIES
+	209: {}, // SOCKET_TIMEOUT
+	210: {}, // NETWORK_ERROR
+	279: {}, // ALL_CONNECTION_TRIES_FAILED
+}
+
+// idempotentQuery matches the read only queries, only they are retried on another replica
+var idempotentQuery = regexp.MustCompile(`(?i)^\s*(\(\s*)*(SELECT|WITH|SHOW|DESCRIBE|DESC|EXPLAIN|EXISTS)\b`)
+
+type replica struct {
+	conn clickhouse.Conn
+	addr string
+
+	mtx sync.Mutex
+	// inFlight is the number of queries running on the replica
+	inFlight int
+	// latency is the moving average of the duration of the queries
+	latency time.Duration
+	healthy bool
+}
+
+// load is the expected wait of a new query on the replica, the replicas without
+// latency yet are expected to answer in a millisecond
+func (r *replica) load() time.Duration {
+	latency := max(r.latency, time.Millisecond)
+	return latency * time.Duration(r.inFlight+1)
+}
+
+// begin counts the query in flight on the replica, the returned func ends it once
+func (r *replica) begin() func(err error) {
+	start := time.Now()
+	r.mtx.Lock()
+	r.inFlight++
+	r.mtx.Unlock()
+
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			r.mtx.Lock()
+			defer r.mtx.Unlock()
+			r.inFlight--
+			if err == nil {
+				elapsed := time.Since(start)
+				if r.latency == 0 {
+					r.latency = elapsed
+				} else {
+					r.latency = time.Duration(replicaLatencyDecay*float64(elapsed) + (1-replicaLatencyDecay)*float64(r.latency))
+				}
+			}
+		})
+	}
+}
+
+func (r *replica) setHealthy(healthy bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.healthy != healthy {
+		zap.L().Info("clickhouse replica health changed", zap.String("replica", r.addr), zap.Bool("healthy", healthy))
+	}
+	r.healthy = healthy
+}
+
+// replicaPool routes the reads to the least loaded healthy replica and retries the read
+// only queries failing on a replica on the other replicas. The writes go to the first
+// replica. The replicas failing a query are left out until they answer a ping again
+type replicaPool struct {
+	replicas []*replica
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newReplicaPool(conns []clickhouse.Conn, addrs []string) *replicaPool {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &replicaPool{cancel: cancel}
+	for i, conn := range conns {
+		p.replicas = append(p.replicas, &replica{conn: conn, addr: addrs[i], healthy: true})
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(replicaHealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.checkHealth(ctx)
+			}
+		}
+	}()
+	return p
+}
+
+// checkHealth pings the unhealthy replicas
+func (p *replicaPool) checkHealth(ctx context.Context) {
+	for _, r := range p.replicas {
+		r.mtx.Lock()
+		healthy := r.healthy
+		r.mtx.Unlock()
+		if healthy {
+			continue
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		if err := r.conn.Ping(pingCtx); err == nil {
+			r.setHealthy(true)
+		}
+		cancel()
+	}
+}
+
+// order returns the replicas to run a read on, the healthy replicas by load first. The
+// unhealthy replicas are tried last so a read is attempted even when all are down
+func (p *replicaPool) order() []*replica {
+	type candidate struct {
+		r       *replica
+		healthy bool
+		load    time.Duration
+	}
+	candidates := make([]candidate, len(p.replicas))
+	for i, r := range p.replicas {
+		r.mtx.Lock()
+		candidates[i] = candidate{r: r, healthy: r.healthy, load: r.load()}
+		r.mtx.Unlock()
+	}
+	// insertion sort keeps the order of the replicas on ties, the pools are small
+	for i := 1; i < len(candidates); i++ {
+		for j := i; j > 0; j-- {
+			a, b := candidates[j-1], candidates[j]
+			if a.healthy == b.healthy && a.load <= b.load || a.healthy && !b.healthy {
+				break
+			}
+			candidates[j-1], candidates[j] = b, a
+		}
+	}
+	ordered := make([]*replica, len(candidates))
+	for i, c := range candidates {
+		ordered[i] = c.r
+	}
+	return ordered
+}
+
+// isRetryable returns true when the error is caused by the replica rather than the query
+func isRetryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var exception *clickhouse.Exception
+	if errors.As(err, &exception) {
+		_, ok := retryableExceptions[exception.Code]
+		return ok
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, sqldriver.ErrBadConn) || errors.Is(err, clickhouse.ErrAcquireConnTimeout)
+}
+
+// read runs the query on the replicas in order until it succeeds or fails on the query,
+// the queries which are not read only are only run on the first replica
+func (p *replicaPool) read(ctx context.Context, query string, run func(r *replica) error) error {
+	replicas := p.order()
+	if !idempotentQuery.MatchString(query) {
+		replicas = replicas[:1]
+	}
+	var err error
+	for i, r := range replicas {
+		if err = run(r); !isRetryable(ctx, err) {
+			return err
+		}
+		r.setHealthy(false)
+		if i < len(replicas)-1 {
+			zap.L().Warn("retrying the query on another clickhouse replica", zap.String("replica", r.addr), zap.Error(err))
+		}
+	}
+	return err
+}
+
+func (p *replicaPool) primary() *replica {
+	return p.replicas[0]
+}
+
+func (p *replicaPool) Contributors() []string {
+	return p.primary().conn.Contributors()
+}
+
+func (p *replicaPool) ServerVersion() (*driver.ServerVersion, error) {
+	return p.primary().conn.ServerVersion()
+}
+
+func (p *replicaPool) Select(ctx context.Context, dest any, query string, args ...any) error {
+	return p.read(ctx, query, func(r *replica) error {
+		// the rows of a failed attempt are dropped
+		if v := reflect.ValueOf(dest); v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Slice {
+			v.Elem().SetLen(0)
+		}
+		end := r.begin()
+		err := r.conn.Select(ctx, dest, query, args...)
+		end(err)
+		return err
+	})
+}
+
+func (p *replicaPool) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	var rows driver.Rows
+	err := p.read(ctx, query, func(r *replica) error {
+		end := r.begin()
+		res, err := r.conn.Query(ctx, query, args...)
+		if err != nil {
+			end(err)
+			return err
+		}
+		rows = &admittedRows{Rows: res, release: func() { end(nil) }}
+		return nil
+	})
+	return rows, err
+}
+
+func (p *replicaPool) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	var row driver.Row
+	_ = p.read(ctx, query, func(r *replica) error {
+		end := r.begin()
+		row = r.conn.QueryRow(ctx, query, args...)
+		end(row.Err())
+		return row.Err()
+	})
+	return row
+}
+
+func (p *replicaPool) PrepareBatch(ctx context.Context, query string, opts ...driver.PrepareBatchOption) (driver.Batch, error) {
+	return p.primary().conn.PrepareBatch(ctx, query, opts...)
+}
+
+func (p *replicaPool) Exec(ctx context.Context, query string, args ...any) error {
+	return p.primary().conn.Exec(ctx, query, args...)
+}
+
+func (p *replicaPool) AsyncInsert(ctx context.Context, query string, wait bool, args ...any) error {
+	return p.primary().conn.AsyncInsert(ctx, query, wait, args...)
+}
+
+// Ping succeeds when a replica answers
+func (p *replicaPool) Ping(ctx context.Context) error {
+	var errs []string
+	for _, r := range p.replicas {
+		err := r.conn.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", r.addr, err))
+	}
+	return fmt.Errorf("no clickhouse replica is reachable: %s", strings.Join(errs, "; "))
+}
+
+// Stats returns the sum of the connection stats of the replicas
+func (p *replicaPool) Stats() driver.Stats {
+	stats := driver.Stats{}
+	for _, r := range p.replicas {
+		s := r.conn.Stats()
+		stats.MaxOpenConns += s.MaxOpenConns
+		stats.MaxIdleConns += s.MaxIdleConns
+		stats.Open += s.Open
+		stats.Idle += s.Idle
+	}
+	return stats
+}
+
+func (p *replicaPool) Close() error {
+	p.cancel()
+	p.wg.Wait()
+	var errs []error
+	for _, r := range p.replicas {
+		if err := r.conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// connectReplicas connects to the replicas of the comma separated datasources, the pool
+// has the primary connection first
+func connectReplicas(cfg *namespaceConfig, primary clickhouse.Conn, datasources string) (*replicaPool, error) {
+	conns := []clickhouse.Conn{primary}
+	addrs := []string{datasourceAddr(cfg.Datasource)}
+	for _, datasource := range strings.Split(datasources, ",") {
+		datasource = strings.TrimSpace(datasource)
+		if datasource == "" || datasource == cfg.Datasource {
+			continue
+		}
+		replicaCfg := *cfg
+		replicaCfg.Datasource = datasource
+		conn, err := connect(&replicaCfg)
+		if err != nil {
+			for _, c := range conns[1:] {
+				c.Close()
+			}
+			return nil, fmt.Errorf("error connecting to replica %s: %v", datasourceAddr(datasource), err)
+		}
+		conns = append(conns, conn)
+		addrs = append(addrs, datasourceAddr(datasource))
+	}
+	return newReplicaPool(conns, addrs), nil
+}
+
+// datasourceAddr returns the address of the datasource, without its credentials
+func datasourceAddr(datasource string) string {
+	options, err := clickhouse.ParseDSN(datasource)
+	if err != nil || len(options.Addr) == 0 {
+		return "unknown"
+	}
+	return strings.Join(options.Addr, ",")
+}
@@ -0,0 +1,92 @@
+package clickhouseReader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn answers the selects with its name, or with its error
+type fakeConn struct {
+	driver.Conn
+	name    string
+	err     error
+	selects int
+}
+
+func (c *fakeConn) Select(ctx context.Context, dest any, query string, args ...any) error {
+	c.selects++
+	if c.err != nil {
+		return c.err
+	}
+	*dest.(*[]string) = append(*dest.(*[]string), c.name)
+	return nil
+}
+
+func (c *fakeConn) Ping(ctx context.Context) error {
+	return c.err
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func TestReplicaPoolRouting(t *testing.T) {
+	a, b := &fakeConn{name: "a"}, &fakeConn{name: "b"}
+	p := newReplicaPool([]clickhouse.Conn{a, b}, []string{"a", "b"})
+	defer p.Close()
+
+	// the busier replica is avoided
+	end := p.replicas[0].begin()
+	var dest []string
+	require.NoError(t, p.Select(context.Background(), &dest, "SELECT 1"))
+	require.Equal(t, []string{"b"}, dest)
+	end(nil)
+
+	// the slower replica is avoided
+	p.replicas[0].latency = 10 * time.Millisecond
+	p.replicas[1].latency = 100 * time.Millisecond
+	dest = nil
+	require.NoError(t, p.Select(context.Background(), &dest, "SELECT 1"))
+	require.Equal(t, []string{"a"}, dest)
+}
+
+func TestReplicaPoolRetry(t *testing.T) {
+	a, b := &fakeConn{name: "a", err: io.EOF}, &fakeConn{name: "b"}
+	p := newReplicaPool([]clickhouse.Conn{a, b}, []string{"a", "b"})
+	defer p.Close()
+
+	var dest []string
+	require.NoError(t, p.Select(context.Background(), &dest, "SELECT 1"))
+	require.Equal(t, []string{"b"}, dest)
+	require.False(t, p.replicas[0].healthy)
+
+	// the unhealthy replica is tried last
+	dest = nil
+	require.NoError(t, p.Select(context.Background(), &dest, "SELECT 1"))
+	require.Equal(t, 1, a.selects)
+
+	// it is back once it answers the ping
+	a.err = nil
+	p.checkHealth(context.Background())
+	require.True(t, p.replicas[0].healthy)
+
+	// the errors of the query are not retried
+	a.err = &clickhouse.Exception{Code: 62, Message: "Syntax error"}
+	b.err = &clickhouse.Exception{Code: 62, Message: "Syntax error"}
+	before := a.selects + b.selects
+	err := p.Select(context.Background(), &dest, "SELECT")
+	var exception *clickhouse.Exception
+	require.True(t, errors.As(err, &exception))
+	require.Equal(t, before+1, a.selects+b.selects)
+
+	// neither are the queries which are not read only
+	a.err, b.err = io.EOF, nil
+	require.Error(t, p.Select(context.Background(), &dest, "INSERT INTO t SELECT 1"))
+}
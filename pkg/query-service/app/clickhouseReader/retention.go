@@ -0,0 +1,215 @@
+package clickhouseReader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.uber.org/zap"
+)
+
+// ttlOverrideColumn returns the column of the resource attribute the overrides
+// of the signal match on, the overrides are only supported for logs and traces
+func ttlOverrideColumn(signal string, key string) string {
+	switch signal {
+	case constants.LogsTTL:
+		return fmt.Sprintf("resources_string_value[indexOf(resources_string_key, %s)]", utils.ClickHouseFormattedValue(key))
+	case constants.TraceTTL:
+		return fmt.Sprintf("resourceTagsMap[%s]", utils.ClickHouseFormattedValue(key))
+	}
+	return ""
+}
+
+// ttlOverrideCondition matches the rows of the resources of the override
+func ttlOverrideCondition(signal string, o model.TTLOverride) string {
+	values := make([]interface{}, 0, len(o.Values))
+	for _, v := range o.Values {
+		values = append(values, v)
+	}
+	return fmt.Sprintf("has(%s, %s)", utils.ClickHouseFormattedValue(values), ttlOverrideColumn(signal, o.Key))
+}
+
+// deleteTTLRules returns the delete rules of the TTL of a table. The rows matching
+// an override are deleted after its duration and the other rows after delDuration
+func deleteTTLRules(signal string, timeExpr string, delDuration int64, overrides []model.TTLOverride) []string {
+	if len(overrides) == 0 {
+		return []string{fmt.Sprintf("%s + INTERVAL %v SECOND DELETE", timeExpr, delDuration)}
+	}
+
+	conditions := make([]string, 0, len(overrides))
+	for _, o := range overrides {
+		conditions = append(conditions, ttlOverrideCondition(signal, o))
+	}
+	// the default rule comes first, GetTTL reads the retention from the first interval
+	rules := []string{fmt.Sprintf("%s + INTERVAL %v SECOND DELETE WHERE NOT (%s)", timeExpr, delDuration, strings.Join(conditions, " OR "))}
+	for i, o := range overrides {
+		rules = append(rules, fmt.Sprintf("%s + INTERVAL %v SECOND DELETE WHERE %s", timeExpr, o.DelDuration(), conditions[i]))
+	}
+	return rules
+}
+
+// getTTLOverrides returns the overrides stored for the signal
+func (r *ClickHouseReader) getTTLOverrides(signal string) ([]model.TTLOverride, *model.ApiError) {
+	var rows []struct {
+		Key         string `db:"attribute_key"`
+		Values      string `db:"attribute_values"`
+		DurationHrs int    `db:"ttl_hrs"`
+	}
+	err := r.localDB.Select(&rows, "SELECT attribute_key, attribute_values, ttl_hrs FROM ttl_overrides WHERE type = ? ORDER BY id", signal)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error in processing ttl_overrides sql query")}
+	}
+
+	overrides := []model.TTLOverride{}
+	for _, row := range rows {
+		o := model.TTLOverride{Key: row.Key, DurationHrs: row.DurationHrs}
+		if err := json.Unmarshal([]byte(row.Values), &o.Values); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, nil
+}
+
+// setTTLOverrides replaces the overrides stored for the signal
+func (r *ClickHouseReader) setTTLOverrides(signal string, overrides []model.TTLOverride) *model.ApiError {
+	tx, err := r.localDB.Beginx()
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM ttl_overrides WHERE type = ?", signal); err != nil {
+		zap.L().Error("Error in processing ttl_overrides delete sql query", zap.Error(err))
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	for _, o := range overrides {
+		values, err := json.Marshal(o.Values)
+		if err != nil {
+			return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		_, err = tx.Exec("INSERT INTO ttl_overrides (type, attribute_key, attribute_values, ttl_hrs) VALUES (?, ?, ?, ?)", signal, o.Key, string(values), o.DurationHrs)
+		if err != nil {
+			zap.L().Error("Error in processing ttl_overrides insert sql query", zap.Error(err))
+			return &model.ApiError{Typ: model.ErrorExec, Err: err}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return nil
+}
+
+// resolveTTLOverrides returns the overrides given with the params, or the
+// stored overrides of the signal when the params leave them unset
+func (r *ClickHouseReader) resolveTTLOverrides(params *model.TTLParams) ([]model.TTLOverride, *model.ApiError) {
+	overrides := []model.TTLOverride{}
+	if params.Overrides != nil {
+		overrides = *params.Overrides
+	} else if params.Type != constants.MetricsTTL {
+		stored, apiErr := r.getTTLOverrides(params.Type)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		overrides = stored
+	}
+
+	if len(overrides) > 0 && ttlOverrideColumn(params.Type, "") == "" {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("ttl overrides are not supported for %s", params.Type)}
+	}
+	for _, o := range overrides {
+		// only the index of the spans has the resource attributes, the spans
+		// of a trace would be lost before the index if it was kept longer
+		if params.Type == constants.TraceTTL && o.DelDuration() >= params.DelDuration {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("ttl overrides of traces should be shorter than the ttl of traces")}
+		}
+	}
+	return overrides, nil
+}
+
+// saveTTLOverrides resolves the overrides of the params and stores them
+func (r *ClickHouseReader) saveTTLOverrides(params *model.TTLParams) ([]model.TTLOverride, *model.ApiError) {
+	overrides, apiErr := r.resolveTTLOverrides(params)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if params.Overrides != nil {
+		if apiErr := r.setTTLOverrides(params.Type, overrides); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	return overrides, nil
+}
+
+// PreviewTTL returns the rows and the bytes the TTL of the params would delete
+// from the main table of the signal if it was applied now. The bytes of a rule
+// are estimated from the share of the rows of the table it deletes
+func (r *ClickHouseReader) PreviewTTL(ctx context.Context, params *model.TTLParams) (*model.TTLPreviewResponse, *model.ApiError) {
+	overrides, apiErr := r.resolveTTLOverrides(params)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	var database, table, localTable, timeExpr string
+	switch params.Type {
+	case constants.TraceTTL:
+		database, table, localTable, timeExpr = signozTraceDBName, signozTraceTableName, signozTraceLocalTableName, "toDateTime(timestamp)"
+	case constants.MetricsTTL:
+		database, table, localTable, timeExpr = signozMetricDBName, signozSampleTableName, signozSampleLocalTableName, "toDateTime(toUInt32(unix_milli / 1000), 'UTC')"
+	case constants.LogsTTL:
+		database, table, localTable, timeExpr = r.logsDB, r.logsTable, r.logsLocalTable, "toDateTime(timestamp / 1000000000)"
+	default:
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("ttl type should be metrics|traces|logs, got %v", params.Type)}
+	}
+
+	rules := []model.TTLPreviewRule{{DurationHrs: int(params.DelDuration / 3600)}}
+	conditions := make([]string, 0, len(overrides))
+	for _, o := range overrides {
+		conditions = append(conditions, ttlOverrideCondition(params.Type, o))
+		rules = append(rules, model.TTLPreviewRule{Key: o.Key, Values: o.Values, DurationHrs: o.DurationHrs})
+	}
+
+	expired := fmt.Sprintf("%s + INTERVAL %v SECOND < now()", timeExpr, params.DelDuration)
+	if len(conditions) > 0 {
+		expired = fmt.Sprintf("%s AND NOT (%s)", expired, strings.Join(conditions, " OR "))
+	}
+	columns := []string{"count()", fmt.Sprintf("countIf(%s)", expired)}
+	for i, o := range overrides {
+		columns = append(columns, fmt.Sprintf("countIf(%s + INTERVAL %v SECOND < now() AND %s)", timeExpr, o.DelDuration(), conditions[i]))
+	}
+
+	resp := &model.TTLPreviewResponse{}
+	dest := []interface{}{&resp.TotalRows}
+	for i := range rules {
+		dest = append(dest, &rules[i].ExpiredRows)
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s.%s", strings.Join(columns, ", "), database, table)
+	zap.L().Info("Executing TTL preview query: ", zap.String("query", query))
+	if err := r.db.QueryRow(ctx, query).Scan(dest...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error while previewing ttl. Err=%v", err)}
+	}
+
+	// cluster() reads a single replica of every shard so the replicas are not counted twice
+	bytesQuery := fmt.Sprintf("SELECT sum(bytes_on_disk) FROM cluster(%s, system.parts) WHERE active AND database = %s AND table = %s",
+		utils.ClickHouseFormattedValue(r.cluster), utils.ClickHouseFormattedValue(database), utils.ClickHouseFormattedValue(localTable))
+	if err := r.db.QueryRow(ctx, bytesQuery).Scan(&resp.TotalBytes); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error while previewing ttl. Err=%v", err)}
+	}
+
+	for i := range rules {
+		if resp.TotalRows > 0 {
+			rules[i].ExpiredBytes = uint64(float64(resp.TotalBytes) * float64(rules[i].ExpiredRows) / float64(resp.TotalRows))
+		}
+		resp.ExpiredRows += rules[i].ExpiredRows
+		resp.ExpiredBytes += rules[i].ExpiredBytes
+	}
+	resp.Rules = rules
+	return resp, nil
+}
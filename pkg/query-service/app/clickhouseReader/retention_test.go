@@ -0,0 +1,34 @@
+package clickhouseReader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestDeleteTTLRules(t *testing.T) {
+	assert := assert.New(t)
+	timeExpr := "toDateTime(timestamp / 1000000000)"
+
+	assert.Equal([]string{"toDateTime(timestamp / 1000000000) + INTERVAL 1296000 SECOND DELETE"},
+		deleteTTLRules("logs", timeExpr, 1296000, nil))
+
+	overrides := []model.TTLOverride{
+		{Key: "k8s.namespace.name", Values: []string{"dev", "staging"}, DurationHrs: 72},
+		{Key: "team", Values: []string{"payments"}, DurationHrs: 2160},
+	}
+	assert.Equal([]string{
+		"toDateTime(timestamp / 1000000000) + INTERVAL 1296000 SECOND DELETE WHERE NOT (" +
+			"has(['dev','staging'], resources_string_value[indexOf(resources_string_key, 'k8s.namespace.name')]) OR " +
+			"has(['payments'], resources_string_value[indexOf(resources_string_key, 'team')]))",
+		"toDateTime(timestamp / 1000000000) + INTERVAL 259200 SECOND DELETE WHERE " +
+			"has(['dev','staging'], resources_string_value[indexOf(resources_string_key, 'k8s.namespace.name')])",
+		"toDateTime(timestamp / 1000000000) + INTERVAL 7776000 SECOND DELETE WHERE " +
+			"has(['payments'], resources_string_value[indexOf(resources_string_key, 'team')])",
+	}, deleteTTLRules("logs", timeExpr, 1296000, overrides))
+
+	assert.Equal("has(['o\\'brien'], resourceTagsMap['team'])",
+		ttlOverrideCondition("traces", model.TTLOverride{Key: "team", Values: []string{"o'brien"}}))
+	assert.Equal("", ttlOverrideColumn("metrics", "team"))
+}
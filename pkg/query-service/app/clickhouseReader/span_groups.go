@@ -0,0 +1,38 @@
+package clickhouseReader
+
+import (
+	"context"
+
+	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+// GetSpanGroups returns the number of spans, the errors and the latencies of the spans of the
+// params grouped on the values of the group by attributes
+func (r *ClickHouseReader) GetSpanGroups(ctx context.Context, params *v3.SpanGroupsParams) ([]v3.SpanGroup, *model.ApiError) {
+	keys, err := r.GetSpanAttributeKeys(ctx)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	query, err := tracesV3.PrepareSpanGroupsQuery(params, keys)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+
+	groups := []v3.SpanGroup{}
+	if err := r.db.Select(ctx, &groups, query); err != nil {
+		zap.L().Error("Error while grouping spans", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	for i := range groups {
+		groups[i].Labels = make(map[string]string, len(params.GroupBy))
+		for j, key := range params.GroupBy {
+			if j < len(groups[i].Values) {
+				groups[i].Labels[key.Key] = groups[i].Values[j]
+			}
+		}
+	}
+	return groups, nil
+}
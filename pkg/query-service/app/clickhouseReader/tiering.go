@@ -0,0 +1,336 @@
+package clickhouseReader
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// tieredStoragePolicy is the storage policy holding the cold volumes, it is
+// the same policy the cold storage of the TTL settings relies on
+const tieredStoragePolicy = "tiered"
+
+// tierTable is a local table whose rows are moved by the TTL on timeExpr
+type tierTable struct {
+	database string
+	name     string
+	timeExpr string
+}
+
+var moveTTLExp = regexp.MustCompile(`toInterval(Second|Day)\(([0-9]+)\) TO VOLUME '([^']+)'`)
+
+// tierTables returns the local tables of the signal, they are the tables SetTTL updates
+func (r *ClickHouseReader) tierTables(signal string) []tierTable {
+	tables := []tierTable{}
+	switch signal {
+	case constants.TraceTTL:
+		for _, name := range []string{signozTraceTableName, signozDurationMVTable, signozSpansTable, signozErrorIndexTable, signozUsageExplorerTable, defaultDependencyGraphTable} {
+			tables = append(tables, tierTable{signozTraceDBName, strings.TrimPrefix(name, "distributed_"), "toDateTime(timestamp)"})
+		}
+	case constants.MetricsTTL:
+		for _, name := range []string{signozSampleLocalTableName, signozTSLocalTableNameV4, signozTSLocalTableNameV46Hrs, signozTSLocalTableNameV41Day} {
+			tables = append(tables, tierTable{signozMetricDBName, name, "toDateTime(toUInt32(unix_milli / 1000), 'UTC')"})
+		}
+	case constants.LogsTTL:
+		tables = append(tables, tierTable{r.logsDB, r.logsLocalTable, "toDateTime(timestamp / 1000000000)"})
+	}
+	return tables
+}
+
+// parseTTLClause returns the rules of the TTL clause of the engine of a table
+func parseTTLClause(engineFull string) []string {
+	idx := strings.Index(engineFull, " TTL ")
+	if idx < 0 {
+		return nil
+	}
+	clause := engineFull[idx+len(" TTL "):]
+	if end := strings.Index(clause, " SETTINGS "); end >= 0 {
+		clause = clause[:end]
+	}
+
+	rules := []string{}
+	depth, quoted, start := 0, false, 0
+	for i, c := range clause {
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			rules = append(rules, strings.TrimSpace(clause[start:i]))
+			start = i + 1
+		}
+	}
+	if rule := strings.TrimSpace(clause[start:]); rule != "" {
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func isMoveTTL(rule string) bool {
+	return strings.Contains(rule, " TO VOLUME ") || strings.Contains(rule, " TO DISK ")
+}
+
+// parseMoveTTL returns the move to a volume of the TTL rules, if any
+func parseMoveTTL(rules []string) *model.StorageTierMove {
+	for _, rule := range rules {
+		m := moveTTLExp.FindStringSubmatch(rule)
+		if len(m) == 0 {
+			continue
+		}
+		value, err := strconv.Atoi(m[2])
+		if err != nil {
+			continue
+		}
+		if m[1] == "Second" {
+			value = value / 86400
+		}
+		return &model.StorageTierMove{AfterDays: value, Volume: m[3]}
+	}
+	return nil
+}
+
+// tierTTLQuery returns the statement replacing the move TTL of the table,
+// the other rules of the current TTL of the table are kept
+func tierTTLQuery(table tierTable, cluster string, rules []string, params *model.StorageTierParams) string {
+	newRules := []string{}
+	for _, rule := range rules {
+		if !isMoveTTL(rule) {
+			newRules = append(newRules, rule)
+		}
+	}
+	if params.MoveAfterDays > 0 {
+		newRules = append(newRules, fmt.Sprintf("%s + INTERVAL %d SECOND TO VOLUME '%s'",
+			table.timeExpr, params.MoveAfterDays*86400, params.Volume))
+	}
+
+	tableName := table.database + "." + table.name
+	if len(newRules) == 0 {
+		return fmt.Sprintf("ALTER TABLE %s ON CLUSTER %s REMOVE TTL SETTINGS distributed_ddl_task_timeout = -1", tableName, cluster)
+	}
+	// the existing parts are moved by MoveExisting, materializing the TTL would rewrite all of them
+	return fmt.Sprintf("ALTER TABLE %s ON CLUSTER %s MODIFY TTL %s SETTINGS distributed_ddl_task_timeout = -1, materialize_ttl_after_modify = 0",
+		tableName, cluster, strings.Join(newRules, ", "))
+}
+
+type tableEngine struct {
+	EngineFull    string `ch:"engine_full"`
+	StoragePolicy string `ch:"storage_policy"`
+}
+
+func (r *ClickHouseReader) getTableEngine(ctx context.Context, table tierTable) (*tableEngine, *model.ApiError) {
+	var resp []tableEngine
+	query := "SELECT engine_full, storage_policy FROM system.tables WHERE database = @database AND name = @name"
+	err := r.db.Select(ctx, &resp, query, clickhouse.Named("database", table.database), clickhouse.Named("name", table.name))
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error while getting table engine. Err=%v", err)}
+	}
+	if len(resp) == 0 {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("table %s.%s not found", table.database, table.name)}
+	}
+	return &resp[0], nil
+}
+
+// volumeDisks returns the disks of the volumes of the storage policy
+func (r *ClickHouseReader) volumeDisks(ctx context.Context, policy string) (map[string][]string, *model.ApiError) {
+	var resp []struct {
+		Volume string   `ch:"volume_name"`
+		Disks  []string `ch:"disks"`
+	}
+	query := "SELECT volume_name, disks FROM system.storage_policies WHERE policy_name = @policy"
+	if err := r.db.Select(ctx, &resp, query, clickhouse.Named("policy", policy)); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error while getting storage policies. Err=%v", err)}
+	}
+	volumes := map[string][]string{}
+	for _, v := range resp {
+		volumes[v.Volume] = v.Disks
+	}
+	return volumes, nil
+}
+
+// GetStorageTiers returns the move TTL of the tables of every signal
+// along with the data of the tables stored on each disk
+func (r *ClickHouseReader) GetStorageTiers(ctx context.Context) ([]model.StorageTierTable, *model.ApiError) {
+	var usage []struct {
+		Database string    `ch:"database"`
+		Table    string    `ch:"table"`
+		Disk     string    `ch:"disk_name"`
+		DiskType string    `ch:"disk_type"`
+		Bytes    uint64    `ch:"bytes"`
+		Rows     uint64    `ch:"rows"`
+		Parts    uint64    `ch:"parts"`
+		MinDate  time.Time `ch:"min_date"`
+		MaxDate  time.Time `ch:"max_date"`
+	}
+	query := "SELECT p.database AS database, p.table AS table, p.disk_name AS disk_name, toString(any(d.type)) AS disk_type, " +
+		"sum(p.bytes_on_disk) AS bytes, sum(p.rows) AS rows, count() AS parts, min(p.min_date) AS min_date, max(p.max_date) AS max_date " +
+		"FROM system.parts AS p INNER JOIN system.disks AS d ON p.disk_name = d.name " +
+		"WHERE p.active AND has(@databases, p.database) GROUP BY database, table, disk_name ORDER BY database, table, disk_name"
+	databases := []string{signozTraceDBName, signozMetricDBName, r.logsDB}
+	if err := r.db.Select(ctx, &usage, query, clickhouse.Named("databases", databases)); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error while getting storage usage. Err=%v", err)}
+	}
+
+	policies := map[string]map[string][]string{}
+	tables := []model.StorageTierTable{}
+	for _, signal := range []string{constants.TraceTTL, constants.MetricsTTL, constants.LogsTTL} {
+		for _, table := range r.tierTables(signal) {
+			engine, apiErr := r.getTableEngine(ctx, table)
+			if apiErr != nil {
+				if apiErr.Typ == model.ErrorNotFound {
+					continue
+				}
+				return nil, apiErr
+			}
+			if _, ok := policies[engine.StoragePolicy]; !ok {
+				volumes, apiErr := r.volumeDisks(ctx, engine.StoragePolicy)
+				if apiErr != nil {
+					return nil, apiErr
+				}
+				policies[engine.StoragePolicy] = volumes
+			}
+
+			item := model.StorageTierTable{
+				Signal:        signal,
+				Database:      table.database,
+				Table:         table.name,
+				StoragePolicy: engine.StoragePolicy,
+				Move:          parseMoveTTL(parseTTLClause(engine.EngineFull)),
+				Tiers:         []model.StorageTierUsage{},
+			}
+			for _, u := range usage {
+				if u.Database != table.database || u.Table != table.name {
+					continue
+				}
+				item.Tiers = append(item.Tiers, model.StorageTierUsage{
+					Volume:     diskVolume(policies[engine.StoragePolicy], u.Disk),
+					Disk:       u.Disk,
+					DiskType:   u.DiskType,
+					Bytes:      u.Bytes,
+					Rows:       u.Rows,
+					Parts:      u.Parts,
+					OldestDate: u.MinDate,
+					NewestDate: u.MaxDate,
+				})
+			}
+			tables = append(tables, item)
+		}
+	}
+	return tables, nil
+}
+
+func diskVolume(volumes map[string][]string, disk string) string {
+	for volume, disks := range volumes {
+		for _, d := range disks {
+			if d == disk {
+				return volume
+			}
+		}
+	}
+	return ""
+}
+
+// SetStorageTierPolicy replaces the move TTL of the tables of the signal. It
+// shares the ttl_status check of SetTTL so that both never alter a table at once
+func (r *ClickHouseReader) SetStorageTierPolicy(ctx context.Context, params *model.StorageTierParams) (*model.SetTTLResponseItem, *model.ApiError) {
+	tables := []tierTable{}
+	for _, table := range r.tierTables(params.Signal) {
+		if params.Table == "" || params.Table == table.name {
+			tables = append(tables, table)
+		}
+	}
+	if len(tables) == 0 {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("table %s is not a table of %s", params.Table, params.Signal)}
+	}
+
+	var disks []string
+	if params.MoveAfterDays > 0 {
+		volumes, apiErr := r.volumeDisks(ctx, tieredStoragePolicy)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		var ok bool
+		if disks, ok = volumes[params.Volume]; !ok {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("volume %s is not a volume of the %s storage policy", params.Volume, tieredStoragePolicy)}
+		}
+	}
+
+	for _, table := range tables {
+		statusItem, apiErr := r.checkTTLStatusItem(ctx, table.database+"."+table.name)
+		if apiErr != nil {
+			return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error in processing ttl_status check sql query")}
+		}
+		if statusItem.Status == constants.StatusPending {
+			return nil, &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf("TTL is already running")}
+		}
+	}
+
+	for _, table := range tables {
+		engine, apiErr := r.getTableEngine(ctx, table)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		tableName := table.database + "." + table.name
+		if params.MoveAfterDays > 0 {
+			if apiErr := r.setColdStorage(ctx, tableName, params.Volume); apiErr != nil {
+				return nil, apiErr
+			}
+		}
+
+		req := tierTTLQuery(table, r.cluster, parseTTLClause(engine.EngineFull), params)
+		zap.L().Info("Executing storage tier request: ", zap.String("request", req))
+		if err := r.db.Exec(ctx, req); err != nil {
+			zap.L().Error("error while setting storage tier", zap.Error(err))
+			return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error while setting storage tier of %s. Err=%v", tableName, err)}
+		}
+
+		if params.MoveAfterDays > 0 && params.MoveExisting {
+			go r.moveExpiredPartitions(context.Background(), table, params.MoveAfterDays, params.Volume, disks)
+		}
+	}
+
+	return &model.SetTTLResponseItem{Message: "storage tier has been successfully set up"}, nil
+}
+
+// moveExpiredPartitions moves the partitions older than the days which
+// still have parts outside of the disks of the volume
+func (r *ClickHouseReader) moveExpiredPartitions(ctx context.Context, table tierTable, days int, volume string, disks []string) {
+	var partitions []struct {
+		PartitionId string `ch:"partition_id"`
+	}
+	query := "SELECT partition_id FROM system.parts WHERE active AND database = @database AND table = @table " +
+		"GROUP BY partition_id HAVING max(max_date) < today() - @days AND countIf(NOT has(@disks, disk_name)) > 0 ORDER BY partition_id"
+	err := r.db.Select(ctx, &partitions, query,
+		clickhouse.Named("database", table.database),
+		clickhouse.Named("table", table.name),
+		clickhouse.Named("days", days),
+		clickhouse.Named("disks", disks),
+	)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return
+	}
+
+	for _, p := range partitions {
+		req := fmt.Sprintf("ALTER TABLE %s.%s ON CLUSTER %s MOVE PARTITION ID '%s' TO VOLUME '%s' SETTINGS distributed_ddl_task_timeout = -1",
+			table.database, table.name, r.cluster, p.PartitionId, volume)
+		zap.L().Info("Executing partition move request: ", zap.String("request", req))
+		if err := r.db.Exec(ctx, req); err != nil {
+			zap.L().Error("error while moving partition", zap.String("table", table.name), zap.String("partition", p.PartitionId), zap.Error(err))
+		}
+	}
+}
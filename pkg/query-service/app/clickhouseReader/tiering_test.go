@@ -0,0 +1,52 @@
+package clickhouseReader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const logsEngineFull = "MergeTree PARTITION BY toDate(timestamp / 1000000000) ORDER BY (ts_bucket_start, id) " +
+	"TTL toDateTime(timestamp / 1000000000) + toIntervalSecond(1296000), " +
+	"toDateTime(timestamp / 1000000000) + toIntervalSecond(259200) TO VOLUME 'cold' " +
+	"SETTINGS index_granularity = 8192, storage_policy = 'tiered'"
+
+func TestParseTTLClause(t *testing.T) {
+	assert := assert.New(t)
+
+	rules := parseTTLClause(logsEngineFull)
+	assert.Equal([]string{
+		"toDateTime(timestamp / 1000000000) + toIntervalSecond(1296000)",
+		"toDateTime(timestamp / 1000000000) + toIntervalSecond(259200) TO VOLUME 'cold'",
+	}, rules)
+	assert.Equal(&model.StorageTierMove{AfterDays: 3, Volume: "cold"}, parseMoveTTL(rules))
+
+	rules = parseTTLClause("MergeTree ORDER BY (env, temporality, metric_name) " +
+		"TTL toDateTime(toUInt32(unix_milli / 1000), 'UTC') + toIntervalSecond(2592000) SETTINGS index_granularity = 8192")
+	assert.Equal([]string{"toDateTime(toUInt32(unix_milli / 1000), 'UTC') + toIntervalSecond(2592000)"}, rules)
+	assert.Nil(parseMoveTTL(rules))
+
+	assert.Empty(parseTTLClause("MergeTree ORDER BY id SETTINGS index_granularity = 8192"))
+}
+
+func TestTierTTLQuery(t *testing.T) {
+	assert := assert.New(t)
+	table := tierTable{"signoz_logs", "logs", "toDateTime(timestamp / 1000000000)"}
+	rules := parseTTLClause(logsEngineFull)
+
+	query := tierTTLQuery(table, "cluster", rules, &model.StorageTierParams{Signal: "logs", Volume: "s3", MoveAfterDays: 7})
+	assert.Equal("ALTER TABLE signoz_logs.logs ON CLUSTER cluster MODIFY TTL "+
+		"toDateTime(timestamp / 1000000000) + toIntervalSecond(1296000), "+
+		"toDateTime(timestamp / 1000000000) + INTERVAL 604800 SECOND TO VOLUME 's3' "+
+		"SETTINGS distributed_ddl_task_timeout = -1, materialize_ttl_after_modify = 0", query)
+
+	// removing the move keeps the delete rule
+	query = tierTTLQuery(table, "cluster", rules, &model.StorageTierParams{Signal: "logs"})
+	assert.Equal("ALTER TABLE signoz_logs.logs ON CLUSTER cluster MODIFY TTL "+
+		"toDateTime(timestamp / 1000000000) + toIntervalSecond(1296000) "+
+		"SETTINGS distributed_ddl_task_timeout = -1, materialize_ttl_after_modify = 0", query)
+
+	query = tierTTLQuery(table, "cluster", rules[1:], &model.StorageTierParams{Signal: "logs"})
+	assert.Equal("ALTER TABLE signoz_logs.logs ON CLUSTER cluster REMOVE TTL SETTINGS distributed_ddl_task_timeout = -1", query)
+}
@@ -0,0 +1,42 @@
+package clickhouseReader
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// GetBaselineTraceID returns the trace, other than the excluded one, whose root span of the
+// operation of the service in the range lasts the closest to the median duration of those
+// spans. It returns an empty id when there is no such trace
+func (r *ClickHouseReader) GetBaselineTraceID(ctx context.Context, service, operation, excluded string, start, end time.Time, scope *model.OrgScope) (string, *model.ApiError) {
+	orgCond, args := orgCondition(scope)
+	conditions := "serviceName = @service AND name = @operation AND parentSpanID = '' AND traceID != @excluded AND timestamp >= @start AND timestamp <= @end" + orgCond
+	args = append(args,
+		clickhouse.Named("service", service),
+		clickhouse.Named("operation", operation),
+		clickhouse.Named("excluded", excluded),
+		clickhouse.Named("start", start),
+		clickhouse.Named("end", end),
+	)
+	query := fmt.Sprintf(`WITH (SELECT quantile(0.5)(durationNano) FROM %s.%s WHERE %s) AS median
+		SELECT traceID FROM %s.%s WHERE %s ORDER BY abs(toFloat64(durationNano) - median) LIMIT 1`,
+		r.TraceDB, r.indexTable, conditions, r.TraceDB, r.indexTable, conditions)
+
+	var traceID string
+	err := r.db.QueryRow(ctx, query, args...).Scan(&traceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		zap.L().Error("Error while finding the baseline trace", zap.Error(err))
+		return "", &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return traceID, nil
+}
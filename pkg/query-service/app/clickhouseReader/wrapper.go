@@ -19,8 +19,11 @@ type ClickhouseQuerySettings struct {
 }
 
 type clickhouseConnWrapper struct {
-	conn     clickhouse.Conn
-	settings ClickhouseQuerySettings
+	conn      clickhouse.Conn
+	settings  ClickhouseQuerySettings
+	admission *queryAdmission
+	// profiles are the settings of the queries by class
+	profiles map[common.QueryClass]*QueryProfile
 }
 
 func (c clickhouseConnWrapper) Close() error {
@@ -36,6 +39,20 @@ func (c clickhouseConnWrapper) Stats() driver.Stats {
 }
 
 func (c clickhouseConnWrapper) addClickHouseSettings(ctx context.Context, query string) context.Context {
+	opts := []clickhouse.QueryOption{clickhouse.WithSettings(c.querySettings(ctx, query))}
+	// the rows read are reported in increments while the query runs
+	if stats := common.GetQueryStats(ctx); stats != nil {
+		opts = append(opts, clickhouse.WithProgress(func(p *clickhouse.Progress) {
+			stats.Add(p.Rows, p.Bytes)
+		}))
+	}
+
+	ctx = clickhouse.Context(ctx, opts...)
+	return ctx
+}
+
+// querySettings returns the clickhouse settings of the query
+func (c clickhouseConnWrapper) querySettings(ctx context.Context, query string) clickhouse.Settings {
 	settings := clickhouse.Settings{}
 
 	logComment := c.getLogComment(ctx)
@@ -60,8 +77,9 @@ func (c clickhouseConnWrapper) addClickHouseSettings(ctx context.Context, query
 		settings["optimize_read_in_order"] = 0
 	}
 
-	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(settings))
-	return ctx
+	// the profile of the class of the query has the last word
+	c.profiles[common.GetQueryClass(ctx)].settings(settings)
+	return settings
 }
 
 func (c clickhouseConnWrapper) getLogComment(ctx context.Context) string {
@@ -81,19 +99,65 @@ func (c clickhouseConnWrapper) getLogComment(ctx context.Context) string {
 	return string(logComment)
 }
 
+// acquire waits for a slot of the class of the query and then for the slots of the user,
+// the returned release func frees both
+func (c clickhouseConnWrapper) acquire(ctx context.Context, query string) (context.Context, func(), error) {
+	releaseClass, err := c.profiles[common.GetQueryClass(ctx)].acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, release, err := c.admission.acquire(ctx, query)
+	if err != nil {
+		releaseClass()
+		return nil, nil, err
+	}
+	return ctx, func() {
+		release()
+		releaseClass()
+	}, nil
+}
+
 func (c clickhouseConnWrapper) Query(ctx context.Context, query string, args ...interface{}) (driver.Rows, error) {
-	return c.conn.Query(c.addClickHouseSettings(ctx, query), query, args...)
+	ctx, release, err := c.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := c.conn.Query(c.addClickHouseSettings(ctx, query), query, args...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &admittedRows{Rows: rows, release: release}, nil
 }
 
 func (c clickhouseConnWrapper) QueryRow(ctx context.Context, query string, args ...interface{}) driver.Row {
-	return c.conn.QueryRow(c.addClickHouseSettings(ctx, query), query, args...)
+	ctx, release, err := c.acquire(ctx, query)
+	if err != nil {
+		return errorRow{err: err}
+	}
+	row := c.conn.QueryRow(c.addClickHouseSettings(ctx, query), query, args...)
+	if row.Err() != nil {
+		release()
+		return row
+	}
+	return &admittedRow{Row: row, release: release}
 }
 
 func (c clickhouseConnWrapper) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, release, err := c.acquire(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer release()
 	return c.conn.Select(c.addClickHouseSettings(ctx, query), dest, query, args...)
 }
 
 func (c clickhouseConnWrapper) Exec(ctx context.Context, query string, args ...interface{}) error {
+	release, err := c.profiles[common.GetQueryClass(ctx)].acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
 	return c.conn.Exec(c.addClickHouseSettings(ctx, query), query, args...)
 }
 
@@ -112,3 +176,20 @@ func (c clickhouseConnWrapper) ServerVersion() (*driver.ServerVersion, error) {
 func (c clickhouseConnWrapper) Contributors() []string {
 	return c.conn.Contributors()
 }
+
+// errorRow is the row of a query that could not be run
+type errorRow struct {
+	err error
+}
+
+func (r errorRow) Err() error {
+	return r.err
+}
+
+func (r errorRow) Scan(dest ...any) error {
+	return r.err
+}
+
+func (r errorRow) ScanStruct(dest any) error {
+	return r.err
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// comparison is a query range request over the range shifted back by the offset
+type comparison struct {
+	offset string
+	// shift is the offset in milliseconds
+	shift  int64
+	params *v3.QueryRangeParamsV3
+}
+
+// comparisonParams returns the requests of the comparisons of the query range params. The
+// params are copied before they are run, the queries are prepared in place
+func comparisonParams(queryRangeParams *v3.QueryRangeParamsV3) ([]comparison, *model.ApiError) {
+	if len(queryRangeParams.Comparisons) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(queryRangeParams)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	comparisons := make([]comparison, 0, len(queryRangeParams.Comparisons))
+	for _, offset := range queryRangeParams.Comparisons {
+		duration, err := v3.ParseComparisonOffset(offset)
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		}
+		var params *v3.QueryRangeParamsV3
+		if err := json.Unmarshal(data, &params); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		shift := duration.Milliseconds()
+		params.Version = queryRangeParams.Version
+		params.Start -= shift
+		params.End -= shift
+		params.Comparisons = nil
+		comparisons = append(comparisons, comparison{offset: offset, shift: shift, params: params})
+	}
+	return comparisons, nil
+}
+
+// runComparisons runs the queries of the comparisons and moves the timestamps of their
+// results forward by the offsets, so they overlay the results of the requested range
+func (aH *APIHandler) runComparisons(ctx context.Context, comparisons []comparison) ([]v3.ComparisonResult, map[string]error, *model.ApiError) {
+	results := make([]v3.ComparisonResult, 0, len(comparisons))
+	for _, c := range comparisons {
+		result, errQuriesByName, apiErr := aH.runQueryRangeV4(ctx, c.params)
+		if apiErr != nil {
+			apiErr.Err = fmt.Errorf("comparison %s: %w", c.offset, apiErr.Err)
+			return nil, errQuriesByName, apiErr
+		}
+		for _, r := range result {
+			for _, series := range r.Series {
+				for idx := range series.Points {
+					series.Points[idx].Timestamp += c.shift
+				}
+			}
+		}
+		results = append(results, v3.ComparisonResult{Offset: c.offset, Result: result})
+	}
+	return results, nil, nil
+}
@@ -0,0 +1,199 @@
+package correlation
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const (
+	// windowPadding widens the window of the trace so that the logs emitted
+	// around the spans and the metric points covering them are included
+	windowPadding = time.Minute
+	// metricsStep is the step of the metric pivots, the rates use twice the step
+	metricsStep = 60
+
+	DefaultLogsLimit = 100
+	MaxLogsLimit     = 1000
+
+	latencyMetric = "signoz_latency_bucket"
+)
+
+// latencyBuckets are the default bucket bounds in ms of the span metrics latency histogram
+var latencyBuckets = []float64{0.1, 1, 2, 6, 10, 50, 100, 250, 500, 1000, 1400, 2000, 5000, 10000, 20000, 40000, 60000}
+
+// Response holds the pivots from a trace or one of its spans to the logs and
+// metrics of the same window and resources
+type Response struct {
+	TraceId  string                      `json:"traceId"`
+	SpanId   string                      `json:"spanId,omitempty"`
+	Start    int64                       `json:"start"`
+	End      int64                       `json:"end"`
+	Services []model.TraceServiceSummary `json:"services"`
+	Logs     *Logs                       `json:"logs"`
+	Metrics  []*ServiceMetrics           `json:"metrics"`
+}
+
+// Logs holds the logs query of the trace and its first rows
+type Logs struct {
+	Query *v3.QueryRangeParamsV3 `json:"query"`
+	Rows  []*v3.Row              `json:"rows"`
+	Error string                 `json:"error,omitempty"`
+}
+
+// ServiceMetrics holds the RED metrics query of a service and the exemplars
+// which link the metric points to the spans of the trace
+type ServiceMetrics struct {
+	ServiceName string                 `json:"serviceName"`
+	Resources   map[string]string      `json:"resources"`
+	Query       *v3.QueryRangeParamsV3 `json:"query"`
+	Exemplars   []Exemplar             `json:"exemplars"`
+}
+
+// Exemplar is a metric point which was recorded for a span of the trace
+type Exemplar struct {
+	Metric    string            `json:"metric"`
+	Labels    map[string]string `json:"labels"`
+	Timestamp int64             `json:"timestamp"`
+	Value     float64           `json:"value"`
+	TraceId   string            `json:"traceId"`
+	SpanId    string            `json:"spanId"`
+}
+
+// Window returns the padded window in ms covering the spans of the services
+func Window(services []model.TraceServiceSummary) (int64, int64) {
+	var start, end uint64
+	for i, service := range services {
+		if i == 0 || service.StartTimeUnixNano < start {
+			start = service.StartTimeUnixNano
+		}
+		if service.EndTimeUnixNano > end {
+			end = service.EndTimeUnixNano
+		}
+	}
+	return time.Unix(0, int64(start)).Add(-windowPadding).UnixMilli(),
+		time.Unix(0, int64(end)).Add(windowPadding).UnixMilli()
+}
+
+// LogsQuery returns the list query of the logs of the trace, the logs are
+// narrowed down to the span when the span id is not empty
+func LogsQuery(traceId, spanId string, start, end int64, limit uint64) *v3.QueryRangeParamsV3 {
+	items := []v3.FilterItem{{
+		Key:      v3.AttributeKey{Key: "trace_id", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeUnspecified, IsColumn: true},
+		Operator: v3.FilterOperatorEqual,
+		Value:    traceId,
+	}}
+	if spanId != "" {
+		items = append(items, v3.FilterItem{
+			Key:      v3.AttributeKey{Key: "span_id", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeUnspecified, IsColumn: true},
+			Operator: v3.FilterOperatorEqual,
+			Value:    spanId,
+		})
+	}
+
+	return &v3.QueryRangeParamsV3{
+		Start: start,
+		End:   end,
+		Step:  metricsStep,
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeBuilder,
+			PanelType: v3.PanelTypeList,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {
+					QueryName:         "A",
+					StepInterval:      metricsStep,
+					DataSource:        v3.DataSourceLogs,
+					AggregateOperator: v3.AggregateOperatorNoOp,
+					Expression:        "A",
+					Filters:           &v3.FilterSet{Operator: "AND", Items: items},
+					Limit:             limit,
+					PageSize:          limit,
+					OrderBy:           []v3.OrderBy{{ColumnName: "timestamp", Order: "asc"}},
+				},
+			},
+		},
+	}
+}
+
+// escapeLabelValue escapes the value for a double quoted promql label matcher
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
+
+// MetricsQuery returns the p99 latency, request rate and error rate
+// queries of the span metrics of the service
+func MetricsQuery(serviceName string, start, end int64) *v3.QueryRangeParamsV3 {
+	selector := fmt.Sprintf(`service_name="%s"`, escapeLabelValue(serviceName))
+	rateWindow := fmt.Sprintf("%ds", 2*metricsStep)
+
+	queries := map[string]string{
+		"A": fmt.Sprintf(`histogram_quantile(0.99, sum(rate(%s{%s}[%s])) by (le))`, latencyMetric, selector, rateWindow),
+		"B": fmt.Sprintf(`sum(rate(signoz_calls_total{%s}[%s]))`, selector, rateWindow),
+		"C": fmt.Sprintf(`sum(rate(signoz_calls_total{%s,status_code="STATUS_CODE_ERROR"}[%s]))`, selector, rateWindow),
+	}
+	legends := map[string]string{"A": "p99 latency", "B": "request rate", "C": "error rate"}
+
+	promQueries := map[string]*v3.PromQuery{}
+	for name, query := range queries {
+		promQueries[name] = &v3.PromQuery{Query: query, Legend: legends[name]}
+	}
+	return &v3.QueryRangeParamsV3{
+		Start: start,
+		End:   end,
+		Step:  metricsStep,
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType:   v3.QueryTypePromQL,
+			PanelType:   v3.PanelTypeGraph,
+			PromQueries: promQueries,
+		},
+	}
+}
+
+// latencyBucket returns the le label of the latency bucket of the duration
+func latencyBucket(durationNano uint64) string {
+	ms := float64(durationNano) / float64(time.Millisecond)
+	for _, bound := range latencyBuckets {
+		if ms <= bound {
+			return strconv.FormatFloat(bound, 'f', -1, 64)
+		}
+	}
+	return strconv.FormatFloat(math.Inf(1), 'f', -1, 64)
+}
+
+// Exemplars links the slowest span of the service to the latency bucket it was counted in
+func Exemplars(traceId string, service model.TraceServiceSummary) []Exemplar {
+	if service.SlowestSpanID == "" {
+		return []Exemplar{}
+	}
+	return []Exemplar{{
+		Metric: latencyMetric,
+		Labels: map[string]string{
+			"service_name": service.ServiceName,
+			"operation":    service.SlowestOperation,
+			"le":           latencyBucket(service.SlowestDurationNano),
+		},
+		Timestamp: time.Unix(0, int64(service.SlowestTimeUnixNano)).UnixMilli(),
+		Value:     float64(service.SlowestDurationNano) / float64(time.Millisecond),
+		TraceId:   traceId,
+		SpanId:    service.SlowestSpanID,
+	}}
+}
+
+// ServicesMetrics returns the metric pivots of the services of the trace
+func ServicesMetrics(traceId string, services []model.TraceServiceSummary, start, end int64) []*ServiceMetrics {
+	metrics := make([]*ServiceMetrics, 0, len(services))
+	for _, service := range services {
+		metrics = append(metrics, &ServiceMetrics{
+			ServiceName: service.ServiceName,
+			Resources:   service.Resources,
+			Query:       MetricsQuery(service.ServiceName, start, end),
+			Exemplars:   Exemplars(traceId, service),
+		})
+	}
+	return metrics
+}
@@ -0,0 +1,97 @@
+package correlation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/promql/parser"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func testServices() []model.TraceServiceSummary {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	return []model.TraceServiceSummary{
+		{
+			ServiceName:         "frontend",
+			StartTimeUnixNano:   uint64(start.UnixNano()),
+			EndTimeUnixNano:     uint64(start.Add(2 * time.Second).UnixNano()),
+			SlowestSpanID:       "a1",
+			SlowestOperation:    "GET /checkout",
+			SlowestDurationNano: uint64(2 * time.Second),
+			SlowestTimeUnixNano: uint64(start.UnixNano()),
+		},
+		{
+			ServiceName:         `pay"ments`,
+			StartTimeUnixNano:   uint64(start.Add(time.Second).UnixNano()),
+			EndTimeUnixNano:     uint64(start.Add(3 * time.Second).UnixNano()),
+			SlowestSpanID:       "b2",
+			SlowestOperation:    "charge",
+			SlowestDurationNano: uint64(40 * time.Millisecond),
+			SlowestTimeUnixNano: uint64(start.Add(time.Second).UnixNano()),
+		},
+	}
+}
+
+func TestWindow(t *testing.T) {
+	start, end := Window(testServices())
+	expectedStart := time.Date(2024, 3, 1, 9, 59, 0, 0, time.UTC).UnixMilli()
+	expectedEnd := time.Date(2024, 3, 1, 10, 1, 3, 0, time.UTC).UnixMilli()
+	if start != expectedStart || end != expectedEnd {
+		t.Errorf("expected window [%d, %d], got [%d, %d]", expectedStart, expectedEnd, start, end)
+	}
+}
+
+func TestLogsQuery(t *testing.T) {
+	params := LogsQuery("t1", "s1", 1, 2, 10)
+	query := params.CompositeQuery.BuilderQueries["A"]
+	if query.DataSource != v3.DataSourceLogs || params.CompositeQuery.PanelType != v3.PanelTypeList {
+		t.Fatalf("expected logs list query, got %v", query)
+	}
+	if len(query.Filters.Items) != 2 || query.Filters.Items[0].Value != "t1" || query.Filters.Items[1].Value != "s1" {
+		t.Errorf("expected trace and span filters, got %v", query.Filters.Items)
+	}
+	if err := params.CompositeQuery.Validate(); err != nil {
+		t.Errorf("invalid logs query: %v", err)
+	}
+
+	params = LogsQuery("t1", "", 1, 2, 10)
+	if items := params.CompositeQuery.BuilderQueries["A"].Filters.Items; len(items) != 1 {
+		t.Errorf("expected trace filter only, got %v", items)
+	}
+}
+
+func TestMetricsQuery(t *testing.T) {
+	params := MetricsQuery(`pay"ments`, 1, 2)
+	if len(params.CompositeQuery.PromQueries) != 3 {
+		t.Fatalf("expected 3 queries, got %d", len(params.CompositeQuery.PromQueries))
+	}
+	for name, query := range params.CompositeQuery.PromQueries {
+		if _, err := parser.ParseExpr(query.Query); err != nil {
+			t.Errorf("invalid query %s %s: %v", name, query.Query, err)
+		}
+		if !strings.Contains(query.Query, `service_name="pay\"ments"`) {
+			t.Errorf("expected escaped service name, got %s", query.Query)
+		}
+	}
+}
+
+func TestExemplars(t *testing.T) {
+	services := testServices()
+	metrics := ServicesMetrics("t1", services, 1, 2)
+	if len(metrics) != 2 {
+		t.Fatalf("expected metrics of 2 services, got %d", len(metrics))
+	}
+
+	exemplar := metrics[0].Exemplars[0]
+	if exemplar.SpanId != "a1" || exemplar.TraceId != "t1" || exemplar.Labels["le"] != "2000" || exemplar.Value != 2000 {
+		t.Errorf("unexpected exemplar %v", exemplar)
+	}
+	if le := metrics[1].Exemplars[0].Labels["le"]; le != "50" {
+		t.Errorf("expected 40ms in the 50ms bucket, got %s", le)
+	}
+	if le := latencyBucket(uint64(2 * time.Minute)); le != "+Inf" {
+		t.Errorf("expected +Inf bucket, got %s", le)
+	}
+}
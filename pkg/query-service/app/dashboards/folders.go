@@ -0,0 +1,279 @@
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// maxFolderDepth bounds the nesting of the folders
+const maxFolderDepth = 10
+
+// Folder groups dashboards and sub folders. The roles required to view
+// and edit the dashboards of a folder are inherited from the parent
+// folders when unset
+type Folder struct {
+	Uuid       string  `json:"uuid" db:"uuid"`
+	Name       string  `json:"name" db:"name"`
+	ParentUuid *string `json:"parentUuid" db:"parent_uuid"`
+	// ViewRole and EditRole are the minimum roles (VIEWER, EDITOR or ADMIN)
+	ViewRole  string    `json:"viewRole" db:"view_role"`
+	EditRole  string    `json:"editRole" db:"edit_role"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	CreatedBy string    `json:"createdBy" db:"created_by"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	UpdatedBy string    `json:"updatedBy" db:"updated_by"`
+}
+
+// FolderPermissions are the roles required to access the dashboards of a folder
+type FolderPermissions struct {
+	ViewRole string `json:"viewRole"`
+	EditRole string `json:"editRole"`
+}
+
+var roleRank = map[string]int{
+	constants.ViewerGroup: 1,
+	constants.EditorGroup: 2,
+	constants.AdminGroup:  3,
+}
+
+// RoleAllows checks if the role is at least the required role
+func RoleAllows(role, required string) bool {
+	if required == "" {
+		return true
+	}
+	return roleRank[role] >= roleRank[required]
+}
+
+func validRole(role string) bool {
+	_, ok := roleRank[role]
+	return role == "" || ok
+}
+
+func (f *Folder) validate() *model.ApiError {
+	if f.Name == "" {
+		return model.BadRequest(fmt.Errorf("folder name is required"))
+	}
+	if !validRole(f.ViewRole) || !validRole(f.EditRole) {
+		return model.BadRequest(fmt.Errorf("invalid folder role, must be one of VIEWER, EDITOR or ADMIN"))
+	}
+	return nil
+}
+
+const folderColumns = "uuid, name, parent_uuid, view_role, edit_role, created_at, created_by, updated_at, updated_by"
+
+func GetFolders(ctx context.Context) ([]Folder, *model.ApiError) {
+	folders := []Folder{}
+	err := db.Select(&folders, "SELECT "+folderColumns+" FROM dashboard_folders ORDER BY name")
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return folders, nil
+}
+
+func GetFolder(ctx context.Context, uuid string) (*Folder, *model.ApiError) {
+	folder := Folder{}
+	err := db.Get(&folder, "SELECT "+folderColumns+" FROM dashboard_folders WHERE uuid=?", uuid)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no folder found with uuid: %s", uuid)}
+	}
+	return &folder, nil
+}
+
+// folderPath returns the folder followed by its ancestors
+func folderPath(ctx context.Context, uuid string) ([]*Folder, *model.ApiError) {
+	var path []*Folder
+	for next := &uuid; next != nil && *next != ""; {
+		if len(path) > maxFolderDepth {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: fmt.Errorf("folder %s is nested too deep", uuid)}
+		}
+		folder, apiErr := GetFolder(ctx, *next)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		path = append(path, folder)
+		next = folder.ParentUuid
+	}
+	return path, nil
+}
+
+// GetFolderPermissions resolves the roles required to access the dashboards of the
+// folder, the dashboards outside of folders are viewable by viewers and editable by editors
+func GetFolderPermissions(ctx context.Context, folderUuid *string) (*FolderPermissions, *model.ApiError) {
+	perms := &FolderPermissions{}
+	if folderUuid != nil {
+		path, apiErr := folderPath(ctx, *folderUuid)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		for _, folder := range path {
+			if perms.ViewRole == "" {
+				perms.ViewRole = folder.ViewRole
+			}
+			if perms.EditRole == "" {
+				perms.EditRole = folder.EditRole
+			}
+		}
+	}
+	if perms.ViewRole == "" {
+		perms.ViewRole = constants.ViewerGroup
+	}
+	if perms.EditRole == "" {
+		perms.EditRole = constants.EditorGroup
+	}
+	// editing implies viewing
+	if !RoleAllows(perms.EditRole, perms.ViewRole) {
+		perms.EditRole = perms.ViewRole
+	}
+	return perms, nil
+}
+
+func CreateFolder(ctx context.Context, folder *Folder) (*Folder, *model.ApiError) {
+	if apiErr := folder.validate(); apiErr != nil {
+		return nil, apiErr
+	}
+	if folder.ParentUuid != nil && *folder.ParentUuid != "" {
+		path, apiErr := folderPath(ctx, *folder.ParentUuid)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if len(path) >= maxFolderDepth {
+			return nil, model.BadRequest(fmt.Errorf("folders cannot be nested more than %d levels", maxFolderDepth))
+		}
+	} else {
+		folder.ParentUuid = nil
+	}
+
+	var userEmail string
+	if user := common.GetUserFromContext(ctx); user != nil {
+		userEmail = user.Email
+	}
+	folder.Uuid = uuid.New().String()
+	folder.CreatedAt = time.Now()
+	folder.CreatedBy = userEmail
+	folder.UpdatedAt = folder.CreatedAt
+	folder.UpdatedBy = userEmail
+
+	_, err := db.Exec("INSERT INTO dashboard_folders ("+folderColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		folder.Uuid, folder.Name, folder.ParentUuid, folder.ViewRole, folder.EditRole, folder.CreatedAt, folder.CreatedBy, folder.UpdatedAt, folder.UpdatedBy)
+	if err != nil {
+		zap.L().Error("Error in inserting folder", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return folder, nil
+}
+
+// UpdateFolder renames, moves or changes the roles of the folder
+func UpdateFolder(ctx context.Context, uuid string, update *Folder) (*Folder, *model.ApiError) {
+	folder, apiErr := GetFolder(ctx, uuid)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := update.validate(); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if update.ParentUuid != nil && *update.ParentUuid != "" {
+		// the folder cannot be moved into itself or its sub folders
+		path, apiErr := folderPath(ctx, *update.ParentUuid)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		for _, ancestor := range path {
+			if ancestor.Uuid == uuid {
+				return nil, model.BadRequest(fmt.Errorf("folder cannot be moved into itself or its sub folders"))
+			}
+		}
+		if len(path) >= maxFolderDepth {
+			return nil, model.BadRequest(fmt.Errorf("folders cannot be nested more than %d levels", maxFolderDepth))
+		}
+	} else {
+		update.ParentUuid = nil
+	}
+
+	folder.Name = update.Name
+	folder.ParentUuid = update.ParentUuid
+	folder.ViewRole = update.ViewRole
+	folder.EditRole = update.EditRole
+	folder.UpdatedAt = time.Now()
+	if user := common.GetUserFromContext(ctx); user != nil {
+		folder.UpdatedBy = user.Email
+	}
+
+	_, err := db.Exec("UPDATE dashboard_folders SET name=$1, parent_uuid=$2, view_role=$3, edit_role=$4, updated_at=$5, updated_by=$6 WHERE uuid=$7",
+		folder.Name, folder.ParentUuid, folder.ViewRole, folder.EditRole, folder.UpdatedAt, folder.UpdatedBy, uuid)
+	if err != nil {
+		zap.L().Error("Error in updating folder", zap.String("uuid", uuid), zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return folder, nil
+}
+
+// DeleteFolder deletes an empty folder
+func DeleteFolder(ctx context.Context, uuid string) *model.ApiError {
+	if _, apiErr := GetFolder(ctx, uuid); apiErr != nil {
+		return apiErr
+	}
+
+	var count int
+	err := db.Get(&count, "SELECT (SELECT COUNT(*) FROM dashboard_folders WHERE parent_uuid=$1) + (SELECT COUNT(*) FROM dashboards WHERE folder_uuid=$1)", uuid)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	if count > 0 {
+		return model.BadRequest(fmt.Errorf("folder is not empty, move or delete its dashboards and sub folders first"))
+	}
+
+	if _, err := db.Exec("DELETE FROM dashboard_folders WHERE uuid=$1", uuid); err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return nil
+}
+
+// GetFolderDashboards returns the dashboards of the folder, the dashboards
+// outside of any folder are returned when folderUuid is empty. Only the dashboards of the
+// org of the user are returned
+func GetFolderDashboards(ctx context.Context, folderUuid string) ([]Dashboard, *model.ApiError) {
+	dashboards := []Dashboard{}
+	condition, args := common.OrgCondition(ctx)
+	var err error
+	if folderUuid == "" {
+		err = db.Select(&dashboards, "SELECT * FROM dashboards WHERE folder_uuid IS NULL AND "+condition, args...)
+	} else {
+		err = db.Select(&dashboards, "SELECT * FROM dashboards WHERE folder_uuid=? AND "+condition, append([]interface{}{folderUuid}, args...)...)
+	}
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return dashboards, nil
+}
+
+// MoveDashboard moves the dashboard into the folder, the dashboard is
+// moved out of its folder when folderUuid is empty
+func MoveDashboard(ctx context.Context, uuid string, folderUuid string) *model.ApiError {
+	var folder *string
+	if folderUuid != "" {
+		if _, apiErr := GetFolder(ctx, folderUuid); apiErr != nil {
+			return apiErr
+		}
+		folder = &folderUuid
+	}
+	if _, apiErr := GetDashboard(ctx, uuid); apiErr != nil {
+		return apiErr
+	}
+
+	result, err := db.Exec("UPDATE dashboards SET folder_uuid=$1 WHERE uuid=$2", folder, uuid)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no dashboard found with uuid: %s", uuid)}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package dashboards
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+)
+
+func TestRoleAllows(t *testing.T) {
+	if !RoleAllows(constants.AdminGroup, constants.EditorGroup) {
+		t.Error("expected admin to be allowed editor access")
+	}
+	if RoleAllows(constants.ViewerGroup, constants.EditorGroup) {
+		t.Error("expected viewer to be denied editor access")
+	}
+	if RoleAllows("", constants.ViewerGroup) {
+		t.Error("expected unknown role to be denied")
+	}
+}
+
+func TestFolders(t *testing.T) {
+	if _, err := InitDB(filepath.Join(t.TempDir(), "signoz.db")); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	parent, apiErr := CreateFolder(ctx, &Folder{Name: "infra", EditRole: constants.AdminGroup})
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	child, apiErr := CreateFolder(ctx, &Folder{Name: "k8s", ParentUuid: &parent.Uuid})
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+
+	// the roles are inherited from the parent folder
+	perms, apiErr := GetFolderPermissions(ctx, &child.Uuid)
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	if perms.ViewRole != constants.ViewerGroup || perms.EditRole != constants.AdminGroup {
+		t.Errorf("unexpected permissions %v", perms)
+	}
+
+	// the parent cannot be moved into its sub folder
+	if _, apiErr := UpdateFolder(ctx, parent.Uuid, &Folder{Name: "infra", ParentUuid: &child.Uuid}); apiErr == nil {
+		t.Error("expected error when moving a folder into its sub folder")
+	}
+
+	if apiErr := DeleteFolder(ctx, parent.Uuid); apiErr == nil {
+		t.Error("expected error when deleting a folder that is not empty")
+	}
+	if apiErr := DeleteFolder(ctx, child.Uuid); apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+}
@@ -0,0 +1,597 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	mixedDatasource = "-- Mixed --"
+	rowPanelType    = "row"
+)
+
+// grafanaPanelTypes maps the grafana panel types to the signoz ones
+var grafanaPanelTypes = map[string]string{
+	"graph":                  "graph",
+	"timeseries":             "graph",
+	"stat":                   "value",
+	"singlestat":             "value",
+	"gauge":                  "value",
+	"table":                  "table",
+	"table-old":              "table",
+	"bargauge":               "bar",
+	"barchart":               "bar",
+	"piechart":               "pie",
+	"grafana-piechart-panel": "pie",
+	"histogram":              "histogram",
+	"heatmap":                "histogram",
+}
+
+// grafanaGlobalVariables are replaced by fixed values as signoz doesn't provide them
+var grafanaGlobalVariables = map[string]string{
+	"__rate_interval": "5m",
+	"__interval":      "1m",
+	"__interval_ms":   "60000",
+	"__range":         "1h",
+	"__range_s":       "3600",
+	"__range_ms":      "3600000",
+}
+
+// prometheus receiver in collector maps job,instance as service_name,service_instance_id
+var grafanaLabelNames = map[string]string{
+	"job":      "service_name",
+	"instance": "service_instance_id",
+}
+
+var (
+	grafanaVariableRE = regexp.MustCompile(`\$\{(\w+)(?::\w+)?\}|\[\[(\w+)(?::\w+)?\]\]|\$(\w+)`)
+	labelValuesRE     = regexp.MustCompile(`^\s*label_values\(\s*(?:(.+?)\s*,\s*)?(\w+)\s*\)\s*$`)
+)
+
+// GrafanaImportIssue is a part of the grafana dashboard that couldn't be
+// converted or was converted with a different behaviour
+type GrafanaImportIssue struct {
+	// Kind is one of panel, target, variable, annotation or link
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// GrafanaImportReport reports what couldn't be converted from a grafana dashboard
+type GrafanaImportReport struct {
+	ConvertedPanels    int                  `json:"convertedPanels"`
+	ConvertedVariables int                  `json:"convertedVariables"`
+	Skipped            []GrafanaImportIssue `json:"skipped"`
+	Warnings           []GrafanaImportIssue `json:"warnings"`
+}
+
+func (r *GrafanaImportReport) skip(kind, name, format string, args ...interface{}) {
+	r.Skipped = append(r.Skipped, GrafanaImportIssue{Kind: kind, Name: name, Reason: fmt.Sprintf(format, args...)})
+}
+
+func (r *GrafanaImportReport) warn(kind, name, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, GrafanaImportIssue{Kind: kind, Name: name, Reason: fmt.Sprintf(format, args...)})
+}
+
+type grafanaWidgetQuery struct {
+	ID         string                     `json:"id"`
+	QueryType  string                     `json:"queryType"`
+	PromQL     []model.PromQueryDashboard `json:"promql"`
+	ClickHouse []map[string]interface{}   `json:"clickhouse_sql"`
+	Builder    map[string]interface{}     `json:"builder"`
+}
+
+type grafanaWidget struct {
+	ID             string              `json:"id"`
+	PanelTypes     string              `json:"panelTypes"`
+	Title          string              `json:"title"`
+	Description    string              `json:"description"`
+	IsStacked      bool                `json:"isStacked,omitempty"`
+	NullZeroValues string              `json:"nullZeroValues,omitempty"`
+	Opacity        string              `json:"opacity,omitempty"`
+	TimePreferance string              `json:"timePreferance,omitempty"`
+	YAxisUnit      string              `json:"yAxisUnit,omitempty"`
+	SoftMin        *float64            `json:"softMin"`
+	SoftMax        *float64            `json:"softMax"`
+	Query          *grafanaWidgetQuery `json:"query,omitempty"`
+}
+
+type grafanaPanelGroup struct {
+	Widgets   []model.Layout `json:"widgets"`
+	Collapsed bool           `json:"collapsed"`
+}
+
+type grafanaVariable struct {
+	ID            string      `json:"id"`
+	Name          string      `json:"name"`
+	Order         int         `json:"order"`
+	Description   string      `json:"description"`
+	Type          string      `json:"type"`
+	QueryValue    string      `json:"queryValue"`
+	CustomValue   string      `json:"customValue"`
+	TextboxValue  string      `json:"textboxValue"`
+	Sort          string      `json:"sort"`
+	MultiSelect   bool        `json:"multiSelect"`
+	ShowALLOption bool        `json:"showALLOption"`
+	AllSelected   bool        `json:"allSelected"`
+	SelectedValue interface{} `json:"selectedValue"`
+}
+
+type grafanaDashboardData struct {
+	Title     string                       `json:"title"`
+	Tags      []string                     `json:"tags"`
+	Layout    []model.Layout               `json:"layout"`
+	PanelMap  map[string]grafanaPanelGroup `json:"panelMap"`
+	Widgets   []grafanaWidget              `json:"widgets"`
+	Variables map[string]grafanaVariable   `json:"variables"`
+}
+
+type grafanaConverter struct {
+	report    *GrafanaImportReport
+	variables map[string]bool
+	data      grafanaDashboardData
+}
+
+// ParseGrafanaJSON parses a grafana dashboard, the dashboards exported with
+// the grafana api are wrapped in a dashboard key
+func ParseGrafanaJSON(b []byte) (model.GrafanaJSON, error) {
+	var wrapped struct {
+		Dashboard *json.RawMessage `json:"dashboard"`
+	}
+	if err := json.Unmarshal(b, &wrapped); err == nil && wrapped.Dashboard != nil {
+		b = *wrapped.Dashboard
+	}
+
+	var grafanaJSON model.GrafanaJSON
+	if err := json.Unmarshal(b, &grafanaJSON); err != nil {
+		return grafanaJSON, fmt.Errorf("invalid grafana dashboard: %w", err)
+	}
+	if grafanaJSON.Title == "" {
+		return grafanaJSON, fmt.Errorf("invalid grafana dashboard: title is missing")
+	}
+	return grafanaJSON, nil
+}
+
+// ConvertGrafanaDashboard converts the prometheus panels, template variables and rows
+// of a grafana dashboard into a signoz dashboard and reports what couldn't be converted
+func ConvertGrafanaDashboard(grafanaJSON model.GrafanaJSON) (Data, *GrafanaImportReport) {
+	c := &grafanaConverter{
+		report:    &GrafanaImportReport{Skipped: []GrafanaImportIssue{}, Warnings: []GrafanaImportIssue{}},
+		variables: map[string]bool{},
+		data: grafanaDashboardData{
+			Title:     grafanaJSON.Title,
+			Tags:      grafanaJSON.Tags,
+			Layout:    []model.Layout{},
+			PanelMap:  map[string]grafanaPanelGroup{},
+			Widgets:   []grafanaWidget{},
+			Variables: map[string]grafanaVariable{},
+		},
+	}
+	if c.data.Tags == nil {
+		c.data.Tags = []string{}
+	}
+
+	for _, template := range grafanaJSON.Templating.List {
+		c.variables[template.Name] = true
+	}
+	c.convertVariables(grafanaJSON)
+	c.convertPanels(grafanaJSON.Panels)
+
+	for _, annotation := range grafanaJSON.Annotations.List {
+		if annotation.BuiltIn == 1 {
+			continue
+		}
+		c.report.skip("annotation", annotation.Name, "annotations are not supported")
+	}
+	for _, link := range grafanaJSON.Links {
+		c.report.skip("link", link.Title, "dashboard links are not supported")
+	}
+
+	// the dashboard data is stored as a generic map
+	data := Data{}
+	b, _ := json.Marshal(c.data)
+	_ = json.Unmarshal(b, &data)
+	return data, c.report
+}
+
+// scaleGrid converts a grafana grid unit (30px) to a signoz one (45px)
+func scaleGrid(v int) int {
+	return (v*2 + 1) / 3
+}
+
+func grafanaLayout(id string, panel model.Panels, yOffset int) model.Layout {
+	layout := model.Layout{
+		I: id,
+		X: panel.GridPos.X / 2,
+		Y: scaleGrid(panel.GridPos.Y) + yOffset,
+		W: panel.GridPos.W / 2,
+		H: scaleGrid(panel.GridPos.H),
+	}
+	if layout.W < 1 {
+		layout.W = 6
+	}
+	if layout.H < 1 {
+		layout.H = 1
+	}
+	return layout
+}
+
+func sortPanels(panels []model.Panels) []model.Panels {
+	sorted := append([]model.Panels{}, panels...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].GridPos.Y != sorted[j].GridPos.Y {
+			return sorted[i].GridPos.Y < sorted[j].GridPos.Y
+		}
+		return sorted[i].GridPos.X < sorted[j].GridPos.X
+	})
+	return sorted
+}
+
+func (c *grafanaConverter) convertPanels(panels []model.Panels) {
+	for _, panel := range sortPanels(panels) {
+		if panel.Type != rowPanelType {
+			if widget := c.convertPanel(panel); widget != nil {
+				c.data.Layout = append(c.data.Layout, grafanaLayout(widget.ID, panel, 0))
+			}
+			continue
+		}
+
+		row := grafanaWidget{ID: uuid.New().String(), PanelTypes: rowPanelType, Title: panel.Title, Description: panel.Description}
+		rowLayout := model.Layout{I: row.ID, X: 0, Y: scaleGrid(panel.GridPos.Y), W: 12, H: 1}
+		c.data.Widgets = append(c.data.Widgets, row)
+		c.data.Layout = append(c.data.Layout, rowLayout)
+
+		// the panels of the collapsed rows are nested in the row, the ones
+		// of the expanded rows follow the row in the dashboard panels
+		group := grafanaPanelGroup{Widgets: []model.Layout{}, Collapsed: panel.Collapsed}
+		for _, inner := range sortPanels(panel.Panels) {
+			widget := c.convertPanel(inner)
+			if widget == nil {
+				continue
+			}
+			// keep the nested panels below the row
+			offset := rowLayout.Y + rowLayout.H - scaleGrid(panel.GridPos.Y+panel.GridPos.H)
+			if offset < 0 {
+				offset = 0
+			}
+			layout := grafanaLayout(widget.ID, inner, offset)
+			if panel.Collapsed {
+				group.Widgets = append(group.Widgets, layout)
+			} else {
+				c.data.Layout = append(c.data.Layout, layout)
+			}
+		}
+		c.data.PanelMap[row.ID] = group
+	}
+}
+
+func datasourceType(ds interface{}) (typ string, uid string) {
+	switch v := ds.(type) {
+	case string:
+		return "", v
+	case map[string]interface{}:
+		var result model.Datasource
+		if err := mapstructure.Decode(v, &result); err == nil {
+			return result.Type, result.UID
+		}
+	}
+	return "", ""
+}
+
+// isMixedDatasource checks if the datasource is set per target
+func isMixedDatasource(ds interface{}) bool {
+	_, uid := datasourceType(ds)
+	return uid == mixedDatasource
+}
+
+// isPrometheusDatasource checks if the datasource is prometheus, the unset datasource
+// is the default one of grafana and the datasource variables are assumed to be prometheus
+func isPrometheusDatasource(ds interface{}) bool {
+	if ds == nil {
+		return true
+	}
+	typ, uid := datasourceType(ds)
+	if typ != "" {
+		return typ == "prometheus"
+	}
+	uid = strings.ToLower(uid)
+	return strings.Contains(uid, "prometheus") || strings.HasPrefix(uid, "$") || uid == "default"
+}
+
+func (c *grafanaConverter) convertPanel(panel model.Panels) *grafanaWidget {
+	panelType, ok := grafanaPanelTypes[panel.Type]
+	if !ok {
+		c.report.skip("panel", panel.Title, "panel type %s is not supported", panel.Type)
+		return nil
+	}
+	mixed := isMixedDatasource(panel.Datasource)
+	if !mixed && !isPrometheusDatasource(panel.Datasource) {
+		c.report.skip("panel", panel.Title, "only the prometheus datasource is supported")
+		return nil
+	}
+	if panel.Type == "heatmap" {
+		c.report.warn("panel", panel.Title, "heatmap is converted to a histogram")
+	}
+	if panel.Repeat != "" {
+		c.report.warn("panel", panel.Title, "repeated panels are converted to a single panel")
+	}
+
+	queries := []model.PromQueryDashboard{}
+	for i, target := range panel.Targets {
+		name := target.RefID
+		if name == "" {
+			name = string(rune('A' + i%26))
+		}
+		targetName := fmt.Sprintf("%s/%s", panel.Title, name)
+		if mixed && !isPrometheusDatasource(target.Datasource) {
+			c.report.skip("target", targetName, "only the prometheus datasource is supported")
+			continue
+		}
+		if strings.TrimSpace(target.Expr) == "" {
+			c.report.skip("target", targetName, "target has no promql expression")
+			continue
+		}
+
+		legend := target.LegendFormat
+		if legend == "__auto" {
+			legend = ""
+		}
+		queries = append(queries, model.PromQueryDashboard{
+			Name:     name,
+			Query:    c.convertExpr("target", targetName, target.Expr),
+			Legend:   legend,
+			Disabled: target.Hide,
+		})
+	}
+	if len(queries) == 0 {
+		c.report.skip("panel", panel.Title, "panel has no prometheus query")
+		return nil
+	}
+
+	unit := panel.FieldConfig.Defaults.Unit
+	if unit == "" {
+		unit = "none"
+	}
+	widget := grafanaWidget{
+		ID:             uuid.New().String(),
+		PanelTypes:     panelType,
+		Title:          panel.Title,
+		Description:    panel.Description,
+		NullZeroValues: "zero",
+		Opacity:        "1",
+		TimePreferance: "GLOBAL_TIME",
+		YAxisUnit:      unit,
+		Query: &grafanaWidgetQuery{
+			ID:         uuid.New().String(),
+			QueryType:  "promql",
+			PromQL:     queries,
+			ClickHouse: []map[string]interface{}{{"name": "A", "query": "", "legend": "", "disabled": false}},
+			Builder:    map[string]interface{}{"queryData": []interface{}{}, "queryFormulas": []interface{}{}},
+		},
+	}
+	c.data.Widgets = append(c.data.Widgets, widget)
+	c.report.ConvertedPanels++
+	return &widget
+}
+
+// convertExpr replaces the grafana variables of the expression with the signoz ones
+func (c *grafanaConverter) convertExpr(kind, name, expr string) string {
+	replaced := map[string]bool{}
+	expr = grafanaVariableRE.ReplaceAllStringFunc(expr, func(match string) string {
+		groups := grafanaVariableRE.FindStringSubmatch(match)
+		variable := groups[1] + groups[2] + groups[3]
+		if value, ok := grafanaGlobalVariables[variable]; ok {
+			if !replaced[variable] {
+				replaced[variable] = true
+				c.report.warn(kind, name, "$%s is replaced by %s", variable, value)
+			}
+			return value
+		}
+		if c.variables[variable] {
+			return "{{." + variable + "}}"
+		}
+		return match
+	})
+
+	expr = instanceEQRE.ReplaceAllString(expr, "service_instance_id=\"{{.instance}}\"")
+	expr = nodeEQRE.ReplaceAllString(expr, "service_instance_id=\"{{.node}}\"")
+	expr = jobEQRE.ReplaceAllString(expr, "service_name=\"{{.job}}\"")
+	expr = instanceRERE.ReplaceAllString(expr, "service_instance_id=~\"{{.instance}}\"")
+	expr = nodeRERE.ReplaceAllString(expr, "service_instance_id=~\"{{.node}}\"")
+	expr = jobRERE.ReplaceAllString(expr, "service_name=~\"{{.job}}\"")
+	return expr
+}
+
+func grafanaSort(sort int) string {
+	switch {
+	case sort == 0:
+		return "DISABLED"
+	case sort%2 == 1:
+		return "ASC"
+	default:
+		return "DESC"
+	}
+}
+
+// grafanaValues returns the values of a current variable selection
+func grafanaValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := []string{}
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// grafanaCustomValues converts the comma separated values of a custom
+// variable, the "text : value" items are converted to their value
+func grafanaCustomValues(query string) string {
+	values := []string{}
+	for _, item := range strings.Split(query, ",") {
+		if idx := strings.Index(item, " : "); idx >= 0 {
+			item = item[idx+3:]
+		}
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+func grafanaQueryString(query interface{}) string {
+	switch v := query.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if s, ok := v["query"].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+func signozLabelName(label string) string {
+	if name, ok := grafanaLabelNames[label]; ok {
+		return name
+	}
+	return label
+}
+
+func quoteClickHouse(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
+
+// labelValuesQuery converts a label_values query of a grafana variable into
+// a clickhouse query on the time series of the metrics
+func (c *grafanaConverter) labelValuesQuery(name, query string) (string, error) {
+	groups := labelValuesRE.FindStringSubmatch(query)
+	if groups == nil {
+		return "", fmt.Errorf("only label_values queries are supported")
+	}
+	label := signozLabelName(groups[2])
+
+	conditions := []string{}
+	if selector := groups[1]; selector != "" {
+		// the variables are replaced after parsing the selector
+		matchers, err := parser.ParseMetricSelector(grafanaVariableRE.ReplaceAllStringFunc(selector, func(match string) string {
+			groups := grafanaVariableRE.FindStringSubmatch(match)
+			return "__grafana_var_" + groups[1] + groups[2] + groups[3]
+		}))
+		if err != nil {
+			return "", fmt.Errorf("invalid metric selector %s: %w", selector, err)
+		}
+		for _, matcher := range matchers {
+			value := c.convertExpr("variable", name, strings.ReplaceAll(matcher.Value, "__grafana_var_", "$"))
+			if matcher.Name == labels.MetricName {
+				if matcher.Type != labels.MatchEqual {
+					return "", fmt.Errorf("only metric names matched by equality are supported")
+				}
+				conditions = append(conditions, "metric_name = "+quoteClickHouse(value))
+				continue
+			}
+
+			column := fmt.Sprintf("JSONExtractString(labels, %s)", quoteClickHouse(signozLabelName(matcher.Name)))
+			switch matcher.Type {
+			case labels.MatchEqual:
+				conditions = append(conditions, column+" = "+quoteClickHouse(value))
+			case labels.MatchNotEqual:
+				conditions = append(conditions, column+" != "+quoteClickHouse(value))
+			case labels.MatchRegexp:
+				conditions = append(conditions, fmt.Sprintf("match(%s, %s)", column, quoteClickHouse("^(?:"+value+")$")))
+			case labels.MatchNotRegexp:
+				conditions = append(conditions, fmt.Sprintf("NOT match(%s, %s)", column, quoteClickHouse("^(?:"+value+")$")))
+			}
+		}
+	}
+
+	sql := fmt.Sprintf("SELECT JSONExtractString(labels, %s) AS %s\nFROM signoz_metrics.distributed_time_series_v4_1day", quoteClickHouse(label), label)
+	if len(conditions) > 0 {
+		sql += "\nWHERE " + strings.Join(conditions, " AND ")
+	}
+	sql += "\nGROUP BY " + label
+	return sql, nil
+}
+
+func (c *grafanaConverter) convertVariables(grafanaJSON model.GrafanaJSON) {
+	for order, template := range grafanaJSON.Templating.List {
+		variable := grafanaVariable{
+			ID:            uuid.New().String(),
+			Name:          template.Name,
+			Order:         order,
+			Description:   template.Label,
+			Sort:          grafanaSort(template.Sort),
+			MultiSelect:   template.Multi,
+			ShowALLOption: template.IncludeAll,
+		}
+		query := grafanaQueryString(template.Query)
+
+		switch template.Type {
+		case "query":
+			if !isPrometheusDatasource(template.Datasource) {
+				c.report.skip("variable", template.Name, "only the prometheus datasource is supported")
+				continue
+			}
+			sql, err := c.labelValuesQuery(template.Name, query)
+			if err != nil {
+				c.report.skip("variable", template.Name, "%s", err.Error())
+				continue
+			}
+			variable.Type = "QUERY"
+			variable.QueryValue = sql
+			if template.Regex != "" {
+				c.report.warn("variable", template.Name, "regex %s is not applied", template.Regex)
+			}
+		case "custom", "interval":
+			variable.Type = "CUSTOM"
+			variable.CustomValue = grafanaCustomValues(query)
+		case "textbox", "constant":
+			variable.Type = "TEXTBOX"
+			variable.TextboxValue = query
+			if template.Type == "constant" {
+				c.report.warn("variable", template.Name, "constant is converted to a textbox")
+			}
+		case "datasource":
+			c.report.skip("variable", template.Name, "datasource variables are not needed, the metrics are queried from signoz")
+			continue
+		default:
+			c.report.skip("variable", template.Name, "variable type %s is not supported", template.Type)
+			continue
+		}
+
+		values := grafanaValues(template.Current.Value)
+		for _, value := range values {
+			if value == "$__all" {
+				variable.AllSelected = true
+			}
+		}
+		switch {
+		case variable.AllSelected:
+		case template.Multi:
+			variable.SelectedValue = values
+		case len(values) > 0:
+			variable.SelectedValue = values[0]
+		}
+		if variable.Type == "TEXTBOX" && variable.TextboxValue == "" && len(values) > 0 {
+			variable.TextboxValue = values[0]
+		}
+
+		c.data.Variables[variable.ID] = variable
+		c.report.ConvertedVariables++
+	}
+}
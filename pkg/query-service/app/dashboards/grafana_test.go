@@ -0,0 +1,113 @@
+package dashboards
+
+import (
+	"strings"
+	"testing"
+)
+
+const testGrafanaDashboard = `{
+  "dashboard": {
+    "title": "Node Exporter",
+    "tags": ["linux"],
+    "annotations": {"list": [{"builtIn": 1, "name": "Annotations & Alerts"}, {"name": "deploys"}]},
+    "templating": {
+      "list": [
+        {"name": "job", "type": "query", "datasource": {"type": "prometheus", "uid": "prom"}, "query": {"query": "label_values(node_uname_info, job)"}, "multi": true, "sort": 1, "current": {"value": ["$__all"]}},
+        {"name": "instance", "type": "query", "datasource": "${DS_PROMETHEUS}", "query": "label_values(node_uname_info{job=\"$job\"}, instance)", "current": {"value": "host-1"}},
+        {"name": "quantile", "type": "custom", "query": "p50 : 0.5, p99 : 0.99", "current": {"value": "0.99"}},
+        {"name": "ds", "type": "datasource", "query": "prometheus"},
+        {"name": "metrics", "type": "query", "datasource": "prometheus", "query": "metrics(node_)"}
+      ]
+    },
+    "panels": [
+      {"type": "timeseries", "title": "CPU", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 9}, "datasource": {"type": "prometheus", "uid": "prom"},
+       "fieldConfig": {"defaults": {"unit": "percentunit"}},
+       "targets": [{"refId": "A", "expr": "rate(node_cpu_seconds_total{instance=\"$instance\"}[$__rate_interval])", "legendFormat": "{{cpu}}"}, {"refId": "B", "expr": ""}]},
+      {"type": "text", "title": "Notes", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 9}},
+      {"type": "row", "title": "Disk", "collapsed": true, "gridPos": {"x": 0, "y": 9, "w": 24, "h": 1},
+       "panels": [{"type": "stat", "title": "Disk usage", "gridPos": {"x": 0, "y": 10, "w": 6, "h": 6}, "datasource": "Prometheus",
+                   "targets": [{"refId": "A", "expr": "node_filesystem_avail_bytes{job=~\"$job\"}"}]}]},
+      {"type": "graph", "title": "Logs", "gridPos": {"x": 0, "y": 10, "w": 24, "h": 6}, "datasource": {"type": "loki", "uid": "loki"},
+       "targets": [{"refId": "A", "expr": "{app=\"api\"}"}]}
+    ]
+  }
+}`
+
+func TestConvertGrafanaDashboard(t *testing.T) {
+	grafanaJSON, err := ParseGrafanaJSON([]byte(testGrafanaDashboard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, report := ConvertGrafanaDashboard(grafanaJSON)
+
+	if report.ConvertedPanels != 2 || report.ConvertedVariables != 3 {
+		t.Errorf("expected 2 panels and 3 variables, got %d and %d", report.ConvertedPanels, report.ConvertedVariables)
+	}
+	skipped := map[string]bool{}
+	for _, issue := range report.Skipped {
+		skipped[issue.Kind+"/"+issue.Name] = true
+	}
+	for _, name := range []string{"panel/Notes", "panel/Logs", "target/CPU/B", "variable/ds", "variable/metrics", "annotation/deploys"} {
+		if !skipped[name] {
+			t.Errorf("expected %s to be reported as skipped, got %v", name, report.Skipped)
+		}
+	}
+
+	widgets := data["widgets"].([]interface{})
+	if len(widgets) != 3 {
+		t.Fatalf("expected 2 panels and a row, got %d widgets", len(widgets))
+	}
+	cpu := widgets[0].(map[string]interface{})
+	if cpu["panelTypes"] != "graph" || cpu["yAxisUnit"] != "percentunit" {
+		t.Errorf("unexpected widget %v", cpu)
+	}
+	promql := cpu["query"].(map[string]interface{})["promql"].([]interface{})
+	query := promql[0].(map[string]interface{})["query"]
+	if query != `rate(node_cpu_seconds_total{service_instance_id="{{.instance}}"}[5m])` {
+		t.Errorf("unexpected query %v", query)
+	}
+
+	// the panels of the collapsed row are kept in the panel map
+	row := widgets[1].(map[string]interface{})
+	if row["panelTypes"] != "row" {
+		t.Fatalf("expected row widget, got %v", row["panelTypes"])
+	}
+	group := data["panelMap"].(map[string]interface{})[row["id"].(string)].(map[string]interface{})
+	if group["collapsed"] != true || len(group["widgets"].([]interface{})) != 1 {
+		t.Errorf("unexpected row %v", group)
+	}
+	if layout := data["layout"].([]interface{}); len(layout) != 2 {
+		t.Errorf("expected the collapsed panel to be out of the layout, got %v", layout)
+	}
+}
+
+func TestConvertGrafanaVariables(t *testing.T) {
+	grafanaJSON, err := ParseGrafanaJSON([]byte(testGrafanaDashboard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ConvertGrafanaDashboard(grafanaJSON)
+
+	variables := map[string]map[string]interface{}{}
+	for _, v := range data["variables"].(map[string]interface{}) {
+		variable := v.(map[string]interface{})
+		variables[variable["name"].(string)] = variable
+	}
+
+	job := variables["job"]
+	if job["type"] != "QUERY" || job["allSelected"] != true || job["sort"] != "ASC" {
+		t.Errorf("unexpected job variable %v", job)
+	}
+	if !strings.Contains(job["queryValue"].(string), "JSONExtractString(labels, 'service_name')") {
+		t.Errorf("expected job to be mapped to service_name, got %v", job["queryValue"])
+	}
+
+	instance := variables["instance"]["queryValue"].(string)
+	if !strings.Contains(instance, "metric_name = 'node_uname_info'") || !strings.Contains(instance, "JSONExtractString(labels, 'service_name') = '{{.job}}'") {
+		t.Errorf("unexpected instance query %s", instance)
+	}
+
+	if variables["quantile"]["customValue"] != "0.5,0.99" || variables["quantile"]["selectedValue"] != "0.99" {
+		t.Errorf("unexpected custom variable %v", variables["quantile"])
+	}
+}
@@ -5,16 +5,14 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"reflect"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gosimple/slug"
 	"github.com/jmoiron/sqlx"
-	"github.com/mitchellh/mapstructure"
+	"go.signoz.io/signoz/pkg/query-service/app/access"
 	"go.signoz.io/signoz/pkg/query-service/common"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
@@ -99,6 +97,83 @@ func InitDB(dataSourceName string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("error in creating planned_maintenance table: %s", err.Error())
 	}
 
+	tableSchema = `CREATE TABLE IF NOT EXISTS escalation_policies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		description TEXT,
+		steps TEXT NOT NULL,
+		alert_ids TEXT,
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating escalation_policies table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS escalation_states (
+		rule_id TEXT NOT NULL,
+		labels_hash TEXT NOT NULL,
+		step INTEGER NOT NULL,
+		acked_at datetime NOT NULL,
+		acked_by TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (rule_id, labels_hash)
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating escalation_states table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS recording_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		data TEXT NOT NULL,
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL,
+		org_id TEXT NOT NULL DEFAULT '',
+		UNIQUE(name, org_id)
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating recording_rules table: %s", err.Error())
+	}
+	if err := migrateRecordingRules(db); err != nil {
+		return nil, fmt.Errorf("error in adding column org_id to recording_rules table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS slos (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		data TEXT NOT NULL,
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating slos table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS silences (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		matchers TEXT NOT NULL,
+		comment TEXT NOT NULL,
+		schedule TEXT NOT NULL,
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating silences table: %s", err.Error())
+	}
+
 	table_schema = `CREATE TABLE IF NOT EXISTS ttl_status (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		transaction_id TEXT NOT NULL,
@@ -115,7 +190,26 @@ func InitDB(dataSourceName string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("error in creating ttl_status table: %s", err.Error())
 	}
 
+	table_schema = `CREATE TABLE IF NOT EXISTS ttl_overrides (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		attribute_key TEXT NOT NULL,
+		attribute_values TEXT NOT NULL,
+		ttl_hrs INTEGER NOT NULL
+	);`
+
+	_, err = db.Exec(table_schema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating ttl_overrides table: %s", err.Error())
+	}
+
 	// sqlite does not support "IF NOT EXISTS"
+	matchers := `ALTER TABLE planned_maintenance ADD COLUMN matchers TEXT;`
+	_, err = db.Exec(matchers)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column matchers to planned_maintenance table: %s", err.Error())
+	}
+
 	createdAt := `ALTER TABLE rules ADD COLUMN created_at datetime;`
 	_, err = db.Exec(createdAt)
 	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
@@ -152,6 +246,44 @@ func InitDB(dataSourceName string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("error in adding column locked to dashboards table: %s", err.Error())
 	}
 
+	tableSchema = `CREATE TABLE IF NOT EXISTS dashboard_folders (
+		uuid TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		parent_uuid TEXT,
+		view_role TEXT NOT NULL DEFAULT '',
+		edit_role TEXT NOT NULL DEFAULT '',
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating dashboard_folders table: %s", err.Error())
+	}
+
+	folderUuid := `ALTER TABLE dashboards ADD COLUMN folder_uuid TEXT;`
+	_, err = db.Exec(folderUuid)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column folder_uuid to dashboards table: %s", err.Error())
+	}
+
+	provisionedFrom := `ALTER TABLE dashboards ADD COLUMN provisioned_from TEXT;`
+	_, err = db.Exec(provisionedFrom)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column provisioned_from to dashboards table: %s", err.Error())
+	}
+
+	// the dashboards, rules, channels, silences and slos belong to an org, the ones created before the orgs
+	// were added belong to the default org
+	for _, table := range []string{"dashboards", "rules", "notification_channels", "silences", "slos"} {
+		orgId := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN org_id TEXT NOT NULL DEFAULT '';`, table)
+		_, err = db.Exec(orgId)
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return nil, fmt.Errorf("error in adding column org_id to %s table: %s", table, err.Error())
+		}
+	}
+
 	return db, nil
 }
 
@@ -166,6 +298,13 @@ type Dashboard struct {
 	Title     string    `json:"-" db:"-"`
 	Data      Data      `json:"data" db:"data"`
 	Locked    *int      `json:"isLocked" db:"locked"`
+	// FolderUuid is the folder of the dashboard, unset for the top level dashboards
+	FolderUuid *string `json:"folderUuid" db:"folder_uuid"`
+	// ProvisionedFrom is the file of the provisioned dashboards, they can only be changed through the file
+	ProvisionedFrom *string `json:"provisionedFrom,omitempty" db:"provisioned_from"`
+	// OrgId is the org of the dashboard, empty for the dashboards of the default org created
+	// before the orgs were added and for the provisioned dashboards
+	OrgId string `json:"-" db:"org_id"`
 }
 
 type Data map[string]interface{}
@@ -200,6 +339,7 @@ func CreateDashboard(ctx context.Context, data map[string]interface{}, fm interf
 	if user := common.GetUserFromContext(ctx); user != nil {
 		userEmail = user.Email
 	}
+	dash.OrgId = common.GetOrgIdFromContext(ctx)
 	dash.CreatedAt = time.Now()
 	dash.CreateBy = &userEmail
 	dash.UpdatedAt = time.Now()
@@ -208,6 +348,9 @@ func CreateDashboard(ctx context.Context, data map[string]interface{}, fm interf
 	dash.Uuid = uuid.New().String()
 	if data["uuid"] != nil {
 		dash.Uuid = data["uuid"].(string)
+		if _, apiErr := GetDashboard(ctx, dash.Uuid); apiErr == nil {
+			return nil, &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf("a dashboard with uuid %s already exists", dash.Uuid)}
+		}
 	}
 
 	mapData, err := json.Marshal(dash.Data)
@@ -224,8 +367,8 @@ func CreateDashboard(ctx context.Context, data map[string]interface{}, fm interf
 		}
 	}
 
-	result, err := db.Exec("INSERT INTO dashboards (uuid, created_at, created_by, updated_at, updated_by, data) VALUES ($1, $2, $3, $4, $5, $6)",
-		dash.Uuid, dash.CreatedAt, userEmail, dash.UpdatedAt, userEmail, mapData)
+	result, err := db.Exec("INSERT INTO dashboards (uuid, created_at, created_by, updated_at, updated_by, data, org_id) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		dash.Uuid, dash.CreatedAt, userEmail, dash.UpdatedAt, userEmail, mapData, dash.OrgId)
 
 	if err != nil {
 		zap.L().Error("Error in inserting dashboard data: ", zap.Any("dashboard", dash), zap.Error(err))
@@ -245,12 +388,14 @@ func CreateDashboard(ctx context.Context, data map[string]interface{}, fm interf
 	return dash, nil
 }
 
+// GetDashboards returns the dashboards of the org of the user
 func GetDashboards(ctx context.Context) ([]Dashboard, *model.ApiError) {
 
 	dashboards := []Dashboard{}
-	query := `SELECT * FROM dashboards`
+	condition, args := common.OrgCondition(ctx)
+	query := `SELECT * FROM dashboards WHERE ` + condition
 
-	err := db.Select(&dashboards, query)
+	err := db.Select(&dashboards, query, args...)
 	if err != nil {
 		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
 	}
@@ -267,6 +412,9 @@ func DeleteDashboard(ctx context.Context, uuid string, fm interfaces.FeatureLook
 	}
 
 	if user := common.GetUserFromContext(ctx); user != nil {
+		if dashboard.ProvisionedFrom != nil {
+			return model.BadRequest(fmt.Errorf("dashboard is provisioned from %s, please remove the file to delete it", *dashboard.ProvisionedFrom))
+		}
 		if dashboard.Locked != nil && *dashboard.Locked == 1 {
 			return model.BadRequest(fmt.Errorf("dashboard is locked, please unlock the dashboard to be able to delete it"))
 		}
@@ -287,6 +435,10 @@ func DeleteDashboard(ctx context.Context, uuid string, fm interfaces.FeatureLook
 		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no dashboard found with uuid: %s", uuid)}
 	}
 
+	if apiErr := access.DeleteACL(ctx, access.ACLResourceDashboard, uuid); apiErr != nil {
+		zap.L().Error("failed to delete the access list of the deleted dashboard", zap.String("uuid", uuid), zap.Error(apiErr.Err))
+	}
+
 	traceAndLogsPanelUsage, _ := countTraceAndLogsPanel(dashboard.Data)
 	if traceAndLogsPanelUsage > 0 {
 		updateFeatureUsage(fm, -traceAndLogsPanelUsage)
@@ -295,12 +447,14 @@ func DeleteDashboard(ctx context.Context, uuid string, fm interfaces.FeatureLook
 	return nil
 }
 
+// GetDashboard returns the dashboard when it belongs to the org of the user
 func GetDashboard(ctx context.Context, uuid string) (*Dashboard, *model.ApiError) {
 
 	dashboard := Dashboard{}
-	query := `SELECT * FROM dashboards WHERE uuid=?`
+	condition, args := common.OrgCondition(ctx)
+	query := `SELECT * FROM dashboards WHERE uuid=? AND ` + condition
 
-	err := db.Get(&dashboard, query, uuid)
+	err := db.Get(&dashboard, query, append([]interface{}{uuid}, args...)...)
 	if err != nil {
 		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no dashboard found with uuid: %s", uuid)}
 	}
@@ -309,6 +463,16 @@ func GetDashboard(ctx context.Context, uuid string) (*Dashboard, *model.ApiError
 }
 
 func UpdateDashboard(ctx context.Context, uuid string, data map[string]interface{}, fm interfaces.FeatureLookup) (*Dashboard, *model.ApiError) {
+	return updateDashboard(ctx, uuid, data, fm, false)
+}
+
+// ReplaceDashboard replaces the data of the dashboard as a whole, the panels missing from
+// the data are deleted. The callers check the dashboard has not changed since it was read
+func ReplaceDashboard(ctx context.Context, uuid string, data map[string]interface{}, fm interfaces.FeatureLookup) (*Dashboard, *model.ApiError) {
+	return updateDashboard(ctx, uuid, data, fm, true)
+}
+
+func updateDashboard(ctx context.Context, uuid string, data map[string]interface{}, fm interfaces.FeatureLookup, replace bool) (*Dashboard, *model.ApiError) {
 
 	mapData, err := json.Marshal(data)
 	if err != nil {
@@ -322,8 +486,12 @@ func UpdateDashboard(ctx context.Context, uuid string, data map[string]interface
 	}
 
 	var userEmail string
-	if user := common.GetUserFromContext(ctx); user != nil {
+	user := common.GetUserFromContext(ctx)
+	if user != nil {
 		userEmail = user.Email
+		if dashboard.ProvisionedFrom != nil {
+			return nil, model.BadRequest(fmt.Errorf("dashboard is provisioned from %s, please update the file to edit it", *dashboard.ProvisionedFrom))
+		}
 		if dashboard.Locked != nil && *dashboard.Locked == 1 {
 			return nil, model.BadRequest(fmt.Errorf("dashboard is locked, please unlock the dashboard to be able to edit it"))
 		}
@@ -339,9 +507,9 @@ func UpdateDashboard(ctx context.Context, uuid string, data map[string]interface
 		}
 	}
 
-	if existingTotal > newTotal && existingTotal-newTotal > 1 {
+	if user != nil && !replace && existingTotal > newTotal && existingTotal-newTotal > 1 {
 		// if the total count of panels has reduced by more than 1,
-		// return error
+		// return error, the provisioned dashboards are replaced as a whole
 		existingIds := getWidgetIds(dashboard.Data)
 		newIds := getWidgetIds(data)
 
@@ -372,6 +540,14 @@ func UpdateDashboard(ctx context.Context, uuid string, data map[string]interface
 }
 
 func LockUnlockDashboard(ctx context.Context, uuid string, lock bool) *model.ApiError {
+	dashboard, apiErr := GetDashboard(ctx, uuid)
+	if apiErr != nil {
+		return apiErr
+	}
+	if !lock && dashboard.ProvisionedFrom != nil {
+		return model.BadRequest(fmt.Errorf("dashboard is provisioned from %s and cannot be unlocked", *dashboard.ProvisionedFrom))
+	}
+
 	var query string
 	if lock {
 		query = `UPDATE dashboards SET locked=1 WHERE uuid=?;`
@@ -469,239 +645,6 @@ func SlugifyTitle(title string) string {
 	return s
 }
 
-func widgetFromPanel(panel model.Panels, idx int, variables map[string]model.Variable) *model.Widget {
-	widget := model.Widget{
-		Description:    panel.Description,
-		ID:             strconv.Itoa(idx),
-		IsStacked:      false,
-		NullZeroValues: "zero",
-		Opacity:        "1",
-		PanelTypes:     "TIME_SERIES", // TODO: Need to figure out how to get this
-		Query: model.Query{
-			ClickHouse: []model.ClickHouseQueryDashboard{
-				{
-					Disabled: false,
-					Legend:   "",
-					Name:     "A",
-					Query:    "",
-				},
-			},
-			MetricsBuilder: model.MetricsBuilder{
-				Formulas: []string{},
-				QueryBuilder: []model.QueryBuilder{
-					{
-						AggregateOperator: 1,
-						Disabled:          false,
-						GroupBy:           []string{},
-						Legend:            "",
-						MetricName:        "",
-						Name:              "A",
-						ReduceTo:          1,
-					},
-				},
-			},
-			PromQL:    []model.PromQueryDashboard{},
-			QueryType: int(model.PROM),
-		},
-		QueryData: model.QueryDataDashboard{
-			Data: model.Data{
-				QueryData: []interface{}{},
-			},
-		},
-		Title:     panel.Title,
-		YAxisUnit: panel.FieldConfig.Defaults.Unit,
-		QueryType: int(model.PROM), // TODO: Supprot for multiple query types
-	}
-	for _, target := range panel.Targets {
-		if target.Expr != "" {
-			for name := range variables {
-				target.Expr = strings.ReplaceAll(target.Expr, "$"+name, "{{"+"."+name+"}}")
-				target.Expr = strings.ReplaceAll(target.Expr, "$"+"__rate_interval", "5m")
-			}
-
-			// prometheus receiver in collector maps job,instance as service_name,service_instance_id
-			target.Expr = instanceEQRE.ReplaceAllString(target.Expr, "service_instance_id=\"{{.instance}}\"")
-			target.Expr = nodeEQRE.ReplaceAllString(target.Expr, "service_instance_id=\"{{.node}}\"")
-			target.Expr = jobEQRE.ReplaceAllString(target.Expr, "service_name=\"{{.job}}\"")
-			target.Expr = instanceRERE.ReplaceAllString(target.Expr, "service_instance_id=~\"{{.instance}}\"")
-			target.Expr = nodeRERE.ReplaceAllString(target.Expr, "service_instance_id=~\"{{.node}}\"")
-			target.Expr = jobRERE.ReplaceAllString(target.Expr, "service_name=~\"{{.job}}\"")
-
-			widget.Query.PromQL = append(
-				widget.Query.PromQL,
-				model.PromQueryDashboard{
-					Disabled: false,
-					Legend:   target.LegendFormat,
-					Name:     target.RefID,
-					Query:    target.Expr,
-				},
-			)
-		}
-	}
-	return &widget
-}
-
-func TransformGrafanaJSONToSignoz(grafanaJSON model.GrafanaJSON) model.DashboardData {
-	var toReturn model.DashboardData
-	toReturn.Title = grafanaJSON.Title
-	toReturn.Tags = grafanaJSON.Tags
-	toReturn.Variables = make(map[string]model.Variable)
-
-	for templateIdx, template := range grafanaJSON.Templating.List {
-		var sort, typ, textboxValue, customValue, queryValue string
-		if template.Sort == 1 {
-			sort = "ASC"
-		} else if template.Sort == 2 {
-			sort = "DESC"
-		} else {
-			sort = "DISABLED"
-		}
-
-		if template.Type == "query" {
-			if template.Datasource == nil {
-				zap.L().Warn("Skipping panel as it has no datasource", zap.Int("templateIdx", templateIdx))
-				continue
-			}
-			// Skip if the source is not prometheus
-			source, stringOk := template.Datasource.(string)
-			if stringOk && !strings.Contains(strings.ToLower(source), "prometheus") {
-				zap.L().Warn("Skipping template as it is not prometheus", zap.Int("templateIdx", templateIdx))
-				continue
-			}
-			var result model.Datasource
-			var structOk bool
-			if reflect.TypeOf(template.Datasource).Kind() == reflect.Map {
-				err := mapstructure.Decode(template.Datasource, &result)
-				if err == nil {
-					structOk = true
-				}
-			}
-			if result.Type != "prometheus" && result.Type != "" {
-				zap.L().Warn("Skipping template as it is not prometheus", zap.Int("templateIdx", templateIdx))
-				continue
-			}
-
-			if !stringOk && !structOk {
-				zap.L().Warn("Didn't recognize source, skipping")
-				continue
-			}
-			typ = "QUERY"
-		} else if template.Type == "custom" {
-			typ = "CUSTOM"
-		} else if template.Type == "textbox" {
-			typ = "TEXTBOX"
-			text, ok := template.Current.Text.(string)
-			if ok {
-				textboxValue = text
-			}
-			array, ok := template.Current.Text.([]string)
-			if ok {
-				textboxValue = strings.Join(array, ",")
-			}
-		} else {
-			continue
-		}
-
-		var selectedValue string
-		text, ok := template.Current.Value.(string)
-		if ok {
-			selectedValue = text
-		}
-		array, ok := template.Current.Value.([]string)
-		if ok {
-			selectedValue = strings.Join(array, ",")
-		}
-
-		toReturn.Variables[template.Name] = model.Variable{
-			AllSelected:   false,
-			CustomValue:   customValue,
-			Description:   template.Label,
-			MultiSelect:   template.Multi,
-			QueryValue:    queryValue,
-			SelectedValue: selectedValue,
-			ShowALLOption: template.IncludeAll,
-			Sort:          sort,
-			TextboxValue:  textboxValue,
-			Type:          typ,
-		}
-	}
-
-	row := 0
-	idx := 0
-	for _, panel := range grafanaJSON.Panels {
-		if panel.Type == "row" {
-			if panel.Panels != nil && len(panel.Panels) > 0 {
-				for _, innerPanel := range panel.Panels {
-					if idx%3 == 0 {
-						row++
-					}
-					toReturn.Layout = append(
-						toReturn.Layout,
-						model.Layout{
-							X: idx % 3 * 4,
-							Y: row * 3,
-							W: 4,
-							H: 3,
-							I: strconv.Itoa(idx),
-						},
-					)
-
-					toReturn.Widgets = append(toReturn.Widgets, *widgetFromPanel(innerPanel, idx, toReturn.Variables))
-					idx++
-				}
-			}
-			continue
-		}
-		if panel.Datasource == nil {
-			zap.L().Warn("Skipping panel as it has no datasource", zap.Int("idx", idx))
-			continue
-		}
-		// Skip if the datasource is not prometheus
-		source, stringOk := panel.Datasource.(string)
-		if stringOk && !strings.Contains(strings.ToLower(source), "prometheus") {
-			zap.L().Warn("Skipping panel as it is not prometheus", zap.Int("idx", idx))
-			continue
-		}
-		var result model.Datasource
-		var structOk bool
-		if reflect.TypeOf(panel.Datasource).Kind() == reflect.Map {
-			err := mapstructure.Decode(panel.Datasource, &result)
-			if err == nil {
-				structOk = true
-			}
-		}
-		if result.Type != "prometheus" && result.Type != "" {
-			zap.L().Warn("Skipping panel as it is not prometheus", zap.Int("idx", idx))
-			continue
-		}
-
-		if !stringOk && !structOk {
-			zap.L().Warn("Didn't recognize source, skipping")
-			continue
-		}
-
-		// Create a panel from "gridPos"
-
-		if idx%3 == 0 {
-			row++
-		}
-		toReturn.Layout = append(
-			toReturn.Layout,
-			model.Layout{
-				X: idx % 3 * 4,
-				Y: row * 3,
-				W: 4,
-				H: 3,
-				I: strconv.Itoa(idx),
-			},
-		)
-
-		toReturn.Widgets = append(toReturn.Widgets, *widgetFromPanel(panel, idx, toReturn.Variables))
-		idx++
-	}
-	return toReturn
-}
-
 func countTraceAndLogsPanel(data map[string]interface{}) (int64, int64) {
 	count := int64(0)
 	totalPanels := int64(0)
@@ -787,3 +730,42 @@ func getIdDifference(existingIds []string, newIds []string) []string {
 
 	return difference
 }
+
+// migrateRecordingRules adds the org to the recording_rules tables created before the orgs
+// were added, the names are unique per org and sqlite can't change the unique constraint of
+// a table so the table is rebuilt
+func migrateRecordingRules(db *sqlx.DB) error {
+	var migrated bool
+	if err := db.Get(&migrated, "SELECT COUNT(*) > 0 FROM pragma_table_info('recording_rules') WHERE name = 'org_id'"); err != nil {
+		return err
+	}
+	if migrated {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range []string{
+		"ALTER TABLE recording_rules RENAME TO recording_rules_old",
+		`CREATE TABLE recording_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			data TEXT NOT NULL,
+			created_at datetime NOT NULL,
+			created_by TEXT NOT NULL,
+			updated_at datetime NOT NULL,
+			updated_by TEXT NOT NULL,
+			org_id TEXT NOT NULL DEFAULT '',
+			UNIQUE(name, org_id)
+		)`,
+		"INSERT INTO recording_rules (id, name, data, created_at, created_by, updated_at, updated_by) SELECT id, name, data, created_at, created_by, updated_at, updated_by FROM recording_rules_old",
+		"DROP TABLE recording_rules_old",
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
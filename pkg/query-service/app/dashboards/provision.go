@@ -2,79 +2,292 @@ package dashboards
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 
 	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
 )
 
-func readCurrentDir(dir string, fm interfaces.FeatureLookup) error {
-	file, err := os.Open(dir)
-	if err != nil {
-		zap.L().Warn("failed opening directory", zap.Error(err))
+// provisionNamespace is used to derive the uuid of the provisioned dashboards without one
+var provisionNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("signoz.io/dashboards/provisioning"))
+
+// Provisioner keeps the dashboards in sync with the JSON and YAML files of a
+// directory, the provisioned dashboards are locked and can only be changed
+// through their files
+type Provisioner struct {
+	dir      string
+	interval time.Duration
+	fm       interfaces.FeatureLookup
+
+	mu sync.Mutex
+	// checksums and dashboard uuids of the provisioned files by path relative to dir
+	checksums map[string]string
+	uuids     map[string]string
+
+	done chan struct{}
+}
+
+func NewProvisioner(dir string, interval time.Duration, fm interfaces.FeatureLookup) *Provisioner {
+	return &Provisioner{
+		dir:       dir,
+		interval:  interval,
+		fm:        fm,
+		checksums: map[string]string{},
+		uuids:     map[string]string{},
+		done:      make(chan struct{}),
+	}
+}
+
+// Start watches the directory for changes, the directory is polled as
+// the files of the kubernetes config maps are replaced through symlinks
+func (p *Provisioner) Start() {
+	if p.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				if err := p.Sync(context.Background()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					zap.L().Warn("failed to provision dashboards", zap.String("dir", p.dir), zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+func (p *Provisioner) Stop() {
+	close(p.done)
+}
+
+// dashboardFiles lists the dashboard files of the directory, the hidden
+// entries such as the ..data directories of config maps are skipped
+func (p *Provisioner) dashboardFiles() ([]string, error) {
+	files := []string{}
+	err := filepath.WalkDir(p.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != p.dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json", ".yaml", ".yml":
+			files = append(files, path)
+		}
 		return nil
+	})
+	return files, err
+}
+
+func parseDashboardFile(path string, content []byte) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var raw map[string]interface{}
+		if err := yaml.Unmarshal(content, &raw); err != nil {
+			return nil, err
+		}
+		// the dashboards are stored as JSON
+		b, err := json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &data); err != nil {
+			return nil, err
+		}
+	default:
+		if err := json.Unmarshal(content, &data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := IsPostDataSane(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Sync provisions the new and changed files and deletes the dashboards of the removed files
+func (p *Provisioner) Sync(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := os.Stat(p.dir); err != nil {
+		// keep the provisioned dashboards when the directory is not mounted
+		return fmt.Errorf("failed opening directory: %w", err)
+	}
+	files, err := p.dashboardFiles()
+	if err != nil {
+		return err
 	}
-	defer file.Close()
 
-	list, _ := file.Readdirnames(0) // 0 to read all files and folders
-	for _, filename := range list {
-		zap.L().Info("Provisioning dashboard: ", zap.String("filename", filename))
+	seen := map[string]bool{}
+	for _, path := range files {
+		rel, _ := filepath.Rel(p.dir, path)
+		seen[rel] = true
 
-		// using filepath.Join for platform specific path creation
-		// which is equivalent to "dir+/+filename" (on unix based systems) but cleaner
-		plan, err := os.ReadFile(filepath.Join(dir, filename))
+		content, err := os.ReadFile(path)
 		if err != nil {
-			zap.L().Error("Creating Dashboards: Error in reading json fron file", zap.String("filename", filename), zap.Error(err))
+			zap.L().Error("Provisioning dashboards: error in reading file", zap.String("filename", rel), zap.Error(err))
 			continue
 		}
-		var data map[string]interface{}
-		err = json.Unmarshal(plan, &data)
-		if err != nil {
-			zap.L().Error("Creating Dashboards: Error in unmarshalling json from file", zap.String("filename", filename), zap.Error(err))
+		sum := sha256.Sum256(content)
+		checksum := hex.EncodeToString(sum[:])
+		if p.checksums[rel] == checksum {
 			continue
 		}
-		err = IsPostDataSane(&data)
+
+		data, err := parseDashboardFile(path, content)
 		if err != nil {
-			zap.L().Info("Creating Dashboards: Error in file", zap.String("filename", filename), zap.Error(err))
+			zap.L().Error("Provisioning dashboards: error in file", zap.String("filename", rel), zap.Error(err))
 			continue
 		}
+		id, ok := data["uuid"].(string)
+		if !ok || id == "" {
+			id = uuid.NewSHA1(provisionNamespace, []byte(rel)).String()
+			data["uuid"] = id
+		}
 
-		id := data["uuid"]
-		if id == nil {
-			_, apiErr := CreateDashboard(context.Background(), data, fm)
-			if apiErr != nil {
-				zap.L().Error("Creating Dashboards: Error in file", zap.String("filename", filename), zap.Error(apiErr.Err))
-			}
+		if apiErr := p.upsertDashboard(ctx, id, data, rel); apiErr != nil {
+			zap.L().Error("Provisioning dashboards: error upserting dashboard", zap.String("filename", rel), zap.Error(apiErr.Err))
 			continue
 		}
+		p.checksums[rel] = checksum
+		p.uuids[rel] = id
+		zap.L().Info("Provisioned dashboard", zap.String("filename", rel), zap.String("uuid", id))
+	}
 
-		apiErr := upsertDashboard(id.(string), data, filename, fm)
-		if apiErr != nil {
-			zap.L().Error("Creating Dashboards: Error upserting dashboard", zap.String("filename", filename), zap.Error(apiErr.Err))
+	provisioned, apiErr := getProvisionedDashboards(ctx)
+	if apiErr != nil {
+		return apiErr.Err
+	}
+	for id, rel := range provisioned {
+		// the file was kept and still provisions the same dashboard
+		if seen[rel] && (p.uuids[rel] == "" || p.uuids[rel] == id) {
+			continue
+		}
+		if apiErr := DeleteDashboard(ctx, id, p.fm); apiErr != nil {
+			zap.L().Error("Provisioning dashboards: error deleting dashboard of removed file", zap.String("filename", rel), zap.Error(apiErr.Err))
+			continue
+		}
+		if !seen[rel] {
+			delete(p.checksums, rel)
+			delete(p.uuids, rel)
 		}
+		zap.L().Info("Deleted provisioned dashboard of removed file", zap.String("filename", rel), zap.String("uuid", id))
 	}
 	return nil
 }
 
-func upsertDashboard(uuid string, data map[string]interface{}, filename string, fm interfaces.FeatureLookup) *model.ApiError {
-	_, apiErr := GetDashboard(context.Background(), uuid)
+func (p *Provisioner) upsertDashboard(ctx context.Context, uuid string, data map[string]interface{}, filename string) *model.ApiError {
+	return upsertProvisionedDashboard(ctx, uuid, data, filename, p.fm)
+}
+
+func upsertProvisionedDashboard(ctx context.Context, uuid string, data map[string]interface{}, filename string, fm interfaces.FeatureLookup) *model.ApiError {
+	dashboard, apiErr := GetDashboard(ctx, uuid)
 	if apiErr == nil {
-		zap.S().Infof("Creating Dashboards: Already exists: %s\t%s", filename, "Dashboard already present in database, Updating dashboard")
-		_, apiErr := UpdateDashboard(context.Background(), uuid, data, fm)
+		if dashboard.ProvisionedFrom != nil && *dashboard.ProvisionedFrom != filename {
+			return model.BadRequest(fmt.Errorf("dashboard %s is already provisioned from %s", uuid, *dashboard.ProvisionedFrom))
+		}
+		_, apiErr = UpdateDashboard(ctx, uuid, data, fm)
+	} else {
+		_, apiErr = CreateDashboard(ctx, data, fm)
+	}
+	if apiErr != nil {
 		return apiErr
 	}
+	return markProvisioned(uuid, filename)
+}
+
+// markProvisioned locks the dashboard so that it is read only in the UI
+func markProvisioned(uuid string, filename string) *model.ApiError {
+	_, err := db.Exec("UPDATE dashboards SET provisioned_from=$1, locked=1 WHERE uuid=$2", filename, uuid)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return nil
+}
+
+// getProvisionedDashboards returns the files of the provisioned dashboards by uuid, the
+// built-in dashboards are not provisioned from files
+func getProvisionedDashboards(ctx context.Context) (map[string]string, *model.ApiError) {
+	rows := []struct {
+		Uuid            string `db:"uuid"`
+		ProvisionedFrom string `db:"provisioned_from"`
+	}{}
+	err := db.Select(&rows, "SELECT uuid, provisioned_from FROM dashboards WHERE provisioned_from IS NOT NULL AND provisioned_from NOT LIKE $1",
+		builtInPrefix+"%")
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+
+	provisioned := map[string]string{}
+	for _, row := range rows {
+		provisioned[row.Uuid] = row.ProvisionedFrom
+	}
+	return provisioned, nil
+}
+
+// builtInPrefix prefixes the name of the built-in dashboards in place of their file
+const builtInPrefix = "builtin:"
+
+// ProvisionBuiltIn creates, or updates, a dashboard shipped with SigNoz from its JSON. The
+// dashboard is locked like the provisioned dashboards and its uuid is derived from its name
+func ProvisionBuiltIn(ctx context.Context, name string, content []byte, fm interfaces.FeatureLookup) *model.ApiError {
+	var data map[string]interface{}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return model.BadRequest(fmt.Errorf("invalid built-in dashboard %s: %v", name, err))
+	}
+	if err := IsPostDataSane(&data); err != nil {
+		return model.BadRequest(fmt.Errorf("invalid built-in dashboard %s: %v", name, err))
+	}
 
-	zap.S().Infof("Creating Dashboards: UUID not found: %s\t%s", filename, "Dashboard not present in database, Creating dashboard")
-	_, apiErr = CreateDashboard(context.Background(), data, fm)
-	return apiErr
+	source := builtInPrefix + name
+	id := uuid.NewSHA1(provisionNamespace, []byte(source)).String()
+	data["uuid"] = id
+	return upsertProvisionedDashboard(ctx, id, data, source, fm)
 }
 
+// LoadDashboardFiles provisions the dashboards of DASHBOARDS_PATH and watches
+// it for changes every DASHBOARDS_PROVISIONING_INTERVAL, 0 disables watching
 func LoadDashboardFiles(fm interfaces.FeatureLookup) error {
 	dashboardsPath := constants.GetOrDefaultEnv("DASHBOARDS_PATH", "./config/dashboards")
-	return readCurrentDir(dashboardsPath, fm)
+	interval, err := time.ParseDuration(constants.GetOrDefaultEnv("DASHBOARDS_PROVISIONING_INTERVAL", "30s"))
+	if err != nil {
+		zap.L().Warn("invalid dashboards provisioning interval, watching is disabled", zap.Error(err))
+		interval = 0
+	}
+
+	provisioner := NewProvisioner(dashboardsPath, interval, fm)
+	if err := provisioner.Sync(context.Background()); err != nil {
+		zap.L().Warn("failed to provision dashboards", zap.String("dir", dashboardsPath), zap.Error(err))
+	}
+	provisioner.Start()
+	return nil
 }
@@ -0,0 +1,118 @@
+package dashboards
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"go.signoz.io/signoz/pkg/query-service/app/access"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestProvisioner(t *testing.T) {
+	db, err := InitDB(filepath.Join(t.TempDir(), "signoz.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := access.InitWithDB(db); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("api.json", `{"uuid": "api-dashboard", "title": "API"}`)
+	write("hosts.yaml", "title: Hosts\ntags: [infra]\n")
+	// the config map data directories are skipped
+	if err := os.Mkdir(filepath.Join(dir, "..data"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write("..data/api.json", `{"uuid": "duplicate", "title": "API"}`)
+
+	p := NewProvisioner(dir, 0, nil)
+	if err := p.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	provisioned, apiErr := getProvisionedDashboards(ctx)
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	if len(provisioned) != 2 || provisioned["api-dashboard"] != "api.json" {
+		t.Fatalf("unexpected provisioned dashboards %v", provisioned)
+	}
+
+	dashboard, apiErr := GetDashboard(ctx, "api-dashboard")
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	if dashboard.Locked == nil || *dashboard.Locked != 1 {
+		t.Error("expected provisioned dashboard to be locked")
+	}
+
+	// the users cannot change the provisioned dashboards
+	userCtx := context.WithValue(ctx, constants.ContextUserKey, &model.UserPayload{User: model.User{Email: "admin@signoz.io"}})
+	if _, apiErr := UpdateDashboard(userCtx, "api-dashboard", map[string]interface{}{"title": "Edited"}, nil); apiErr == nil {
+		t.Error("expected error when editing a provisioned dashboard")
+	}
+	if apiErr := LockUnlockDashboard(userCtx, "api-dashboard", false); apiErr == nil {
+		t.Error("expected error when unlocking a provisioned dashboard")
+	}
+
+	write("api.json", `{"uuid": "api-dashboard", "title": "API v2"}`)
+	if err := os.Remove(filepath.Join(dir, "hosts.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	dashboard, _ = GetDashboard(ctx, "api-dashboard")
+	if dashboard.Data["title"] != "API v2" {
+		t.Errorf("expected dashboard to be updated, got %v", dashboard.Data["title"])
+	}
+	if provisioned, _ := getProvisionedDashboards(ctx); len(provisioned) != 1 {
+		t.Errorf("expected the dashboard of the removed file to be deleted, got %v", provisioned)
+	}
+}
+
+func TestProvisionBuiltIn(t *testing.T) {
+	db, err := InitDB(filepath.Join(t.TempDir(), "signoz.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := access.InitWithDB(db); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if apiErr := ProvisionBuiltIn(ctx, "usage.json", []byte(`{"description": "no title"}`), nil); apiErr == nil {
+		t.Error("expected error when provisioning a dashboard without title")
+	}
+	if apiErr := ProvisionBuiltIn(ctx, "usage.json", []byte(`{"title": "Usage"}`), nil); apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	// provisioning again updates the same dashboard
+	if apiErr := ProvisionBuiltIn(ctx, "usage.json", []byte(`{"title": "Usage v2"}`), nil); apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	dashboards, apiErr := GetDashboards(ctx)
+	if apiErr != nil {
+		t.Fatal(apiErr.Err)
+	}
+	if len(dashboards) != 1 || dashboards[0].Data["title"] != "Usage v2" || dashboards[0].Locked == nil || *dashboards[0].Locked != 1 {
+		t.Fatalf("unexpected dashboards %v", dashboards)
+	}
+
+	// the built-in dashboards are kept when syncing the files
+	if err := NewProvisioner(t.TempDir(), 0, nil).Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, apiErr := GetDashboard(ctx, dashboards[0].Uuid); apiErr != nil {
+		t.Errorf("expected the built-in dashboard to be kept, got %v", apiErr.Err)
+	}
+}
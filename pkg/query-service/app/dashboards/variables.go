@@ -0,0 +1,219 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const (
+	VariableTypeQuery   = "QUERY"
+	VariableTypeBuilder = "BUILDER"
+	VariableTypeCustom  = "CUSTOM"
+	VariableTypeTextbox = "TEXTBOX"
+)
+
+var (
+	ErrMissingVariables   = errors.New("at least one variable is required")
+	ErrMissingVariableKey = errors.New("builder variables require a group by attribute to list the values of")
+)
+
+// Variable is a dashboard variable to resolve, the options of the QUERY and BUILDER
+// variables may depend on the selected values of other variables
+type Variable struct {
+	Type        string `json:"type"`
+	QueryValue  string `json:"queryValue,omitempty"`
+	CustomValue string `json:"customValue,omitempty"`
+	// BuilderQuery lists the distinct values of its first group by attribute,
+	// its filters may reference the other variables
+	BuilderQuery  *v3.BuilderQuery `json:"builderQuery,omitempty"`
+	MultiSelect   bool             `json:"multiSelect"`
+	AllSelected   bool             `json:"allSelected"`
+	SelectedValue interface{}      `json:"selectedValue"`
+}
+
+// ResolveVariablesRequest resolves the options of the variables by name over the time range
+type ResolveVariablesRequest struct {
+	Start     int64                `json:"start"`
+	End       int64                `json:"end"`
+	Variables map[string]*Variable `json:"variables"`
+}
+
+// ResolvedVariable holds the options of a variable and its selection, the
+// selection is reset when the selected values are not part of the options
+type ResolvedVariable struct {
+	Options       []interface{} `json:"options"`
+	SelectedValue interface{}   `json:"selectedValue"`
+	DependsOn     []string      `json:"dependsOn"`
+	Error         string        `json:"error,omitempty"`
+}
+
+func (v *Variable) Validate() error {
+	switch v.Type {
+	case VariableTypeQuery:
+		if strings.TrimSpace(v.QueryValue) == "" {
+			return fmt.Errorf("query is required")
+		}
+	case VariableTypeBuilder:
+		if v.BuilderQuery == nil || len(v.BuilderQuery.GroupBy) == 0 {
+			return ErrMissingVariableKey
+		}
+	case VariableTypeCustom, VariableTypeTextbox:
+	default:
+		return fmt.Errorf("unsupported variable type: %s", v.Type)
+	}
+	return nil
+}
+
+func (r *ResolveVariablesRequest) Validate() error {
+	if len(r.Variables) == 0 {
+		return ErrMissingVariables
+	}
+	if r.Start <= 0 || r.End <= r.Start {
+		return fmt.Errorf("invalid time range, start must be before end")
+	}
+	for name, v := range r.Variables {
+		if err := v.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid variable %s", name)
+		}
+	}
+	return nil
+}
+
+// definition returns the parts of the variable which may reference other variables
+func (v *Variable) definition() string {
+	switch v.Type {
+	case VariableTypeQuery:
+		return v.QueryValue
+	case VariableTypeBuilder:
+		if v.BuilderQuery.Filters == nil {
+			return ""
+		}
+		b, _ := json.Marshal(v.BuilderQuery.Filters)
+		return string(b)
+	}
+	return ""
+}
+
+func variableReferenceRE(name string) *regexp.Regexp {
+	quoted := regexp.QuoteMeta(name)
+	return regexp.MustCompile(`\{\{\s*\.` + quoted + `\s*\}\}|\[\[` + quoted + `\]\]|\$` + quoted + `\b`)
+}
+
+// VariableDependencies returns the variables referenced by each variable
+func VariableDependencies(variables map[string]*Variable) map[string][]string {
+	deps := map[string][]string{}
+	for name, v := range variables {
+		deps[name] = []string{}
+		definition := v.definition()
+		if definition == "" {
+			continue
+		}
+		for other := range variables {
+			if other != name && variableReferenceRE(other).MatchString(definition) {
+				deps[name] = append(deps[name], other)
+			}
+		}
+		sort.Strings(deps[name])
+	}
+	return deps
+}
+
+// VariableOrder sorts the variables so that each variable comes after
+// the variables it depends on, the chains must not have cycles
+func VariableOrder(variables map[string]*Variable) ([]string, error) {
+	deps := VariableDependencies(variables)
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	order := []string{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("variables have a cyclic dependency: %s", strings.Join(append(path, name), " -> "))
+		}
+		state[name] = visiting
+		for _, dep := range deps[name] {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// CustomOptions returns the comma separated options of a custom variable
+func CustomOptions(customValue string) []interface{} {
+	options := []interface{}{}
+	for _, option := range strings.Split(customValue, ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
+// Select returns the selection of the variable among the options, all the options are
+// selected when ALL is selected and the first one when no selected value is an option
+func (v *Variable) Select(options []interface{}) interface{} {
+	if v.Type == VariableTypeTextbox {
+		return v.SelectedValue
+	}
+	if v.AllSelected {
+		return options
+	}
+
+	valid := map[string]bool{}
+	for _, option := range options {
+		valid[fmt.Sprint(option)] = true
+	}
+
+	if v.MultiSelect {
+		selected := []interface{}{}
+		if values, ok := v.SelectedValue.([]interface{}); ok {
+			for _, value := range values {
+				if valid[fmt.Sprint(value)] {
+					selected = append(selected, value)
+				}
+			}
+		} else if v.SelectedValue != nil && valid[fmt.Sprint(v.SelectedValue)] {
+			selected = append(selected, v.SelectedValue)
+		}
+		if len(selected) == 0 && len(options) > 0 {
+			selected = append(selected, options[0])
+		}
+		return selected
+	}
+
+	if v.SelectedValue != nil && valid[fmt.Sprint(v.SelectedValue)] {
+		return v.SelectedValue
+	}
+	if len(options) > 0 {
+		return options[0]
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package dashboards
+
+import (
+	"reflect"
+	"testing"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func builderVariable(key string, filters ...v3.FilterItem) *Variable {
+	return &Variable{
+		Type: VariableTypeBuilder,
+		BuilderQuery: &v3.BuilderQuery{
+			DataSource: v3.DataSourceTraces,
+			GroupBy:    []v3.AttributeKey{{Key: key}},
+			Filters:    &v3.FilterSet{Operator: "AND", Items: filters},
+		},
+	}
+}
+
+func TestVariableOrder(t *testing.T) {
+	variables := map[string]*Variable{
+		"status": builderVariable("status_code",
+			v3.FilterItem{Key: v3.AttributeKey{Key: "serviceName"}, Operator: v3.FilterOperatorIn, Value: "{{.service}}"},
+			v3.FilterItem{Key: v3.AttributeKey{Key: "name"}, Operator: v3.FilterOperatorIn, Value: "$endpoint"},
+		),
+		"endpoint": builderVariable("name", v3.FilterItem{Key: v3.AttributeKey{Key: "serviceName"}, Operator: v3.FilterOperatorIn, Value: "{{ .service }}"}),
+		"service":  {Type: VariableTypeQuery, QueryValue: "SELECT DISTINCT serviceName FROM signoz_traces.distributed_top_level_operations"},
+	}
+
+	deps := VariableDependencies(variables)
+	if !reflect.DeepEqual(deps["status"], []string{"endpoint", "service"}) || len(deps["service"]) != 0 {
+		t.Errorf("unexpected dependencies %v", deps)
+	}
+
+	order, err := VariableOrder(variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(order, []string{"service", "endpoint", "status"}) {
+		t.Errorf("unexpected order %v", order)
+	}
+
+	// the service variable depending on the status makes a cycle
+	variables["service"] = builderVariable("serviceName", v3.FilterItem{Key: v3.AttributeKey{Key: "status_code"}, Operator: v3.FilterOperatorEqual, Value: "[[status]]"})
+	if _, err := VariableOrder(variables); err == nil {
+		t.Error("expected cyclic dependency to be rejected")
+	}
+}
+
+func TestVariableSelect(t *testing.T) {
+	options := []interface{}{"checkout", "frontend"}
+
+	single := &Variable{Type: VariableTypeQuery, SelectedValue: "cart"}
+	if selected := single.Select(options); selected != "checkout" {
+		t.Errorf("expected selection to be reset to the first option, got %v", selected)
+	}
+	single.SelectedValue = "frontend"
+	if selected := single.Select(options); selected != "frontend" {
+		t.Errorf("expected selection to be kept, got %v", selected)
+	}
+
+	multi := &Variable{Type: VariableTypeQuery, MultiSelect: true, SelectedValue: []interface{}{"cart", "frontend"}}
+	if selected := multi.Select(options); !reflect.DeepEqual(selected, []interface{}{"frontend"}) {
+		t.Errorf("expected the missing values to be dropped, got %v", selected)
+	}
+
+	all := &Variable{Type: VariableTypeQuery, MultiSelect: true, AllSelected: true}
+	if selected := all.Select(options); !reflect.DeepEqual(selected, options) {
+		t.Errorf("expected all the options, got %v", selected)
+	}
+
+	if options := CustomOptions("a, b,,c"); len(options) != 3 {
+		t.Errorf("unexpected custom options %v", options)
+	}
+}
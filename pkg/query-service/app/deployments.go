@@ -0,0 +1,82 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/deployments"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// listDeployments lists the deployments, the latest first. The range is in milliseconds and
+// serviceName can be repeated
+func (aH *APIHandler) listDeployments(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := deployments.Filter{
+		ServiceNames: query["serviceName"],
+		Environment:  query.Get("environment"),
+	}
+	for param, value := range map[string]*time.Time{"start": &filter.Start, "end": &filter.End} {
+		if s := query.Get(param); s != "" {
+			ms, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				RespondError(w, model.BadRequest(fmt.Errorf("%s param is not in correct timestamp format", param)), nil)
+				return
+			}
+			*value = time.UnixMilli(ms)
+		}
+	}
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil {
+			RespondError(w, model.BadRequest(fmt.Errorf("invalid limit %q", s)), nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	list, err := aH.DeploymentManager.Deployments(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, list)
+}
+
+// createDeployment records a deployment, e.g. from the pipeline which rolled it out
+func (aH *APIHandler) createDeployment(w http.ResponseWriter, r *http.Request) {
+	var req deployments.DeploymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	deployment, err := aH.DeploymentManager.Record(r.Context(), &req, webhookUser(r))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, deployment)
+}
+
+func (aH *APIHandler) deleteDeployment(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	deleted, err := aH.DeploymentManager.DeleteDeployment(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if !deleted {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("deployment %s not found", id)}, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
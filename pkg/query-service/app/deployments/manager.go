@@ -0,0 +1,379 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+const (
+	detectInterval = time.Minute
+	// detectDelay is how far behind now the versions are detected, so the spans still being
+	// written are not skipped
+	detectDelay = time.Minute
+	// maxDetectWindow is the window of the detection after a downtime
+	maxDetectWindow = 24 * time.Hour
+
+	defaultListLimit = 100
+	maxListLimit     = 1000
+	// MaxMarkers is the number of deployments returned with the query range results
+	MaxMarkers = 100
+
+	// CorrelationWindow is how long before an alert the deployments of its service are
+	// annotated on the alert
+	CorrelationWindow = 30 * time.Minute
+)
+
+// serviceKeys are the attributes and labels holding the service name
+var serviceKeys = []string{"service.name", "service_name", "serviceName"}
+
+// VersionReader lists the versions of the services seen in the spans
+type VersionReader interface {
+	GetServiceVersions(ctx context.Context, start, end time.Time) ([]model.ServiceVersion, *model.ApiError)
+}
+
+type ManagerOptions struct {
+	DB     *sqlx.DB
+	Reader VersionReader
+}
+
+// Manager records the deployments of the services, through the API or by detecting the new
+// versions in the service.version resource attribute of the spans. The detection records a
+// version once, the rollbacks to a version already seen are only recorded through the API
+type Manager struct {
+	repo   *repo
+	reader VersionReader
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	if err := InitSqliteDBIfNeeded(opts.DB); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		repo:   &repo{db: opts.DB},
+		reader: opts.Reader,
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Start detects the new versions every minute
+func (m *Manager) Start() error {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(detectInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.detect(m.ctx, time.Now().UTC()); err != nil {
+					zap.L().Error("failed to detect the deployments", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// detect records the deployments of the versions seen for the first time since the last
+// detection. The first detection only learns the running versions
+func (m *Manager) detect(ctx context.Context, now time.Time) error {
+	end := now.Add(-detectDelay)
+	start, err := m.repo.detectedUntil(ctx)
+	if err != nil {
+		return err
+	}
+	first := start.IsZero()
+	if first || end.Sub(start) > maxDetectWindow {
+		start = end.Add(-maxDetectWindow)
+	} else {
+		start = start.Add(time.Nanosecond)
+	}
+	if !end.After(start) {
+		return nil
+	}
+
+	versions, apiErr := m.reader.GetServiceVersions(ctx, start, end)
+	if apiErr != nil {
+		return apiErr.Err
+	}
+	defaultOrgId, err := m.repo.defaultOrgId(ctx)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		// the spans sent before the orgs were added have no org and belong to the default org
+		if v.OrgId == defaultOrgId {
+			v.OrgId = ""
+		}
+		added, err := m.repo.addServiceVersion(ctx, v.OrgId, v.ServiceName, v.Environment, v.Version, v.FirstSeen.UTC())
+		if err != nil {
+			return err
+		}
+		if !added || first {
+			continue
+		}
+		// the deployment may have been recorded through the API already
+		recorded, err := m.repo.hasDeployment(ctx, v.OrgId, v.ServiceName, v.Environment, v.Version)
+		if err != nil {
+			return err
+		}
+		if recorded {
+			continue
+		}
+		d := &Deployment{
+			Id:          uuid.NewString(),
+			ServiceName: v.ServiceName,
+			Version:     v.Version,
+			Environment: v.Environment,
+			Timestamp:   v.FirstSeen.UTC(),
+			Source:      SourceDetected,
+			Metadata:    Metadata{},
+			CreatedAt:   now,
+			OrgId:       v.OrgId,
+		}
+		if err := m.repo.insertDeployment(ctx, d); err != nil {
+			return err
+		}
+	}
+	return m.repo.setDetectedUntil(ctx, end)
+}
+
+// Record records the deployment in the org of the user, the version is not detected again in
+// the org
+func (m *Manager) Record(ctx context.Context, req *DeploymentRequest, by string) (*Deployment, error) {
+	defaultOrgId, err := m.repo.defaultOrgId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orgId := common.GetOrgIdFromContext(ctx)
+	if orgId == defaultOrgId {
+		orgId = ""
+	}
+	now := time.Now().UTC()
+	timestamp := now
+	if req.Timestamp > 0 {
+		timestamp = time.UnixMilli(req.Timestamp).UTC()
+	}
+	if req.Metadata == nil {
+		req.Metadata = Metadata{}
+	}
+	d := &Deployment{
+		Id:          uuid.NewString(),
+		ServiceName: req.ServiceName,
+		Version:     req.Version,
+		Environment: req.Environment,
+		Timestamp:   timestamp,
+		Source:      SourceAPI,
+		Metadata:    req.Metadata,
+		CreatedAt:   now,
+		CreatedBy:   by,
+		OrgId:       orgId,
+	}
+	if err := m.repo.insertDeployment(ctx, d); err != nil {
+		return nil, err
+	}
+	if _, err := m.repo.addServiceVersion(ctx, orgId, d.ServiceName, d.Environment, d.Version, timestamp); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Deployments returns the deployments of the org of the user selected by the filter, the
+// latest first
+func (m *Manager) Deployments(ctx context.Context, f Filter) ([]*Deployment, error) {
+	if f.Limit <= 0 {
+		f.Limit = defaultListLimit
+	}
+	f.Limit = min(f.Limit, maxListLimit)
+	return m.repo.listDeployments(ctx, f)
+}
+
+// DeleteDeployment deletes the deployment, it returns false when it does not exist in the org
+// of the user
+func (m *Manager) DeleteDeployment(ctx context.Context, id string) (bool, error) {
+	return m.repo.deleteDeployment(ctx, id)
+}
+
+// Markers returns the deployments of the org of the user, in the range of the query, of the
+// services the builder queries filter on, of all the services when a query is not filtered
+// on its services
+func (m *Manager) Markers(ctx context.Context, params *v3.QueryRangeParamsV3) []v3.DeploymentMarker {
+	if m == nil {
+		return nil
+	}
+	deployments, err := m.repo.listDeployments(ctx, Filter{
+		ServiceNames: servicesOf(params),
+		Start:        time.UnixMilli(params.Start),
+		End:          time.UnixMilli(params.End),
+		Limit:        MaxMarkers,
+	})
+	if err != nil {
+		zap.L().Error("failed to list the deployment markers", zap.Error(err))
+		return nil
+	}
+	markers := make([]v3.DeploymentMarker, 0, len(deployments))
+	for _, d := range deployments {
+		markers = append(markers, v3.DeploymentMarker{
+			Id:          d.Id,
+			ServiceName: d.ServiceName,
+			Version:     d.Version,
+			Environment: d.Environment,
+			Timestamp:   d.Timestamp.UnixMilli(),
+			Source:      d.Source,
+		})
+	}
+	return markers
+}
+
+// servicesOf returns the services the builder queries filter on with = or in, nil when a
+// query is not filtered on its services
+func servicesOf(params *v3.QueryRangeParamsV3) []string {
+	if params.CompositeQuery == nil || params.CompositeQuery.QueryType != v3.QueryTypeBuilder ||
+		len(params.CompositeQuery.BuilderQueries) == 0 {
+		return nil
+	}
+	services := []string{}
+	for _, query := range params.CompositeQuery.BuilderQueries {
+		filtered := false
+		if query.Filters != nil && query.Filters.Operator != "OR" {
+			for _, item := range query.Filters.Items {
+				if !isServiceKey(item.Key.Key) {
+					continue
+				}
+				if item.Operator != v3.FilterOperatorEqual && item.Operator != v3.FilterOperatorIn {
+					continue
+				}
+				switch value := item.Value.(type) {
+				case string:
+					services = append(services, value)
+					filtered = true
+				case []interface{}:
+					for _, v := range value {
+						services = append(services, fmt.Sprint(v))
+					}
+					filtered = true
+				case []string:
+					services = append(services, value...)
+					filtered = true
+				}
+			}
+		}
+		if !filtered {
+			return nil
+		}
+	}
+	return services
+}
+
+func isServiceKey(key string) bool {
+	for _, k := range serviceKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// AnnotateAlerts annotates the alerts with the latest deployment of their service in the
+// correlation window before they became active, it is a rules.NotifyFunc
+func (m *Manager) AnnotateAlerts(ctx context.Context, expr string, alerts ...*rules.Alert) {
+	if m == nil || len(alerts) == 0 {
+		return
+	}
+	services := map[string]struct{}{}
+	var start, end time.Time
+	for _, alert := range alerts {
+		service := serviceOf(alert)
+		if service == "" || alert.ActiveAt.IsZero() {
+			continue
+		}
+		services[service] = struct{}{}
+		if start.IsZero() || alert.ActiveAt.Add(-CorrelationWindow).Before(start) {
+			start = alert.ActiveAt.Add(-CorrelationWindow)
+		}
+		if alert.ActiveAt.After(end) {
+			end = alert.ActiveAt
+		}
+	}
+	if len(services) == 0 {
+		return
+	}
+	f := Filter{Start: start, End: end, Limit: maxListLimit}
+	for service := range services {
+		f.ServiceNames = append(f.ServiceNames, service)
+	}
+	deployments, err := m.repo.listDeployments(ctx, f)
+	if err != nil {
+		zap.L().Error("failed to list the deployments of the alerts", zap.Error(err))
+		return
+	}
+
+	for _, alert := range alerts {
+		service := serviceOf(alert)
+		if service == "" || alert.ActiveAt.IsZero() {
+			continue
+		}
+		annotations := map[string]string{}
+		if alert.Annotations != nil {
+			annotations = alert.Annotations.Map()
+		}
+		delete(annotations, labels.AlertDeploymentLabel)
+		// the deployments are the latest first
+		for _, d := range deployments {
+			if d.ServiceName != service || d.Timestamp.After(alert.ActiveAt) || d.Timestamp.Before(alert.ActiveAt.Add(-CorrelationWindow)) {
+				continue
+			}
+			annotations[labels.AlertDeploymentLabel] = fmt.Sprintf("version %s of %s deployed %s before",
+				d.Version, d.ServiceName, humanizeDuration(alert.ActiveAt.Sub(d.Timestamp)))
+			break
+		}
+		alert.Annotations = labels.FromMap(annotations)
+	}
+}
+
+func serviceOf(alert *rules.Alert) string {
+	if alert.Labels == nil {
+		return ""
+	}
+	for _, key := range serviceKeys {
+		if service := alert.Labels.Get(key); service != "" {
+			return service
+		}
+	}
+	return ""
+}
+
+func humanizeDuration(d time.Duration) string {
+	switch minutes := int(d.Minutes()); {
+	case minutes < 1:
+		return "less than a minute"
+	case minutes == 1:
+		return "1 minute"
+	default:
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+}
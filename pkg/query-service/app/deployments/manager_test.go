@@ -0,0 +1,187 @@
+package deployments
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// reader returns the versions first seen in the detected window
+type reader struct {
+	versions []model.ServiceVersion
+}
+
+func (r *reader) GetServiceVersions(ctx context.Context, start, end time.Time) ([]model.ServiceVersion, *model.ApiError) {
+	result := []model.ServiceVersion{}
+	for _, v := range r.versions {
+		if !v.FirstSeen.Before(start) && !v.FirstSeen.After(end) {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+func newTestManager(t *testing.T, r *reader) *Manager {
+	db := utils.NewQueryServiceDBForTests(t)
+	m, err := NewManager(ManagerOptions{DB: db, Reader: r})
+	require.NoError(t, err)
+	return m
+}
+
+func TestManagerDetect(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	r := &reader{versions: []model.ServiceVersion{
+		{ServiceName: "frontend", Version: "1.0.0", Environment: "prod", FirstSeen: now.Add(-time.Hour)},
+	}}
+	m := newTestManager(t, r)
+
+	// the first detection learns the running versions
+	require.NoError(t, m.detect(ctx, now))
+	list, err := m.Deployments(ctx, Filter{})
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	_, err = m.Record(ctx, &DeploymentRequest{ServiceName: "cart", Version: "2.0.0", Environment: "prod",
+		Timestamp: now.UnixMilli(), Metadata: Metadata{"commit": "abc123"}}, "ci@signoz.io")
+	require.NoError(t, err)
+
+	r.versions = append(r.versions,
+		model.ServiceVersion{ServiceName: "frontend", Version: "1.1.0", Environment: "prod", FirstSeen: now.Add(-30 * time.Second)},
+		model.ServiceVersion{ServiceName: "cart", Version: "2.0.0", Environment: "prod", FirstSeen: now.Add(-20 * time.Second)},
+	)
+	require.NoError(t, m.detect(ctx, now.Add(time.Minute)))
+
+	list, err = m.Deployments(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, "cart", list[0].ServiceName)
+	require.Equal(t, SourceAPI, list[0].Source)
+	require.Equal(t, "abc123", list[0].Metadata["commit"])
+	require.Equal(t, "frontend", list[1].ServiceName)
+	require.Equal(t, "1.1.0", list[1].Version)
+	require.Equal(t, SourceDetected, list[1].Source)
+	require.True(t, list[1].Timestamp.Equal(now.Add(-30*time.Second)))
+
+	// the versions are detected once
+	require.NoError(t, m.detect(ctx, now.Add(2*time.Minute)))
+	list, err = m.Deployments(ctx, Filter{ServiceNames: []string{"frontend"}})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	deleted, err := m.DeleteDeployment(ctx, list[0].Id)
+	require.NoError(t, err)
+	require.True(t, deleted)
+}
+
+func TestManagerOrgs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	r := &reader{}
+	m := newTestManager(t, r)
+	defaultOrg, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "default"})
+	require.Nil(t, apiErr)
+	org, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "org"})
+	require.Nil(t, apiErr)
+	require.NoError(t, m.detect(ctx, now))
+
+	// the same version is deployed once in each org, the spans without org and those of the
+	// default org are the same org
+	r.versions = []model.ServiceVersion{
+		{ServiceName: "cart", Version: "2.0.0", FirstSeen: now.Add(-30 * time.Second)},
+		{ServiceName: "cart", Version: "2.0.0", FirstSeen: now.Add(-20 * time.Second), OrgId: defaultOrg.Id},
+		{ServiceName: "cart", Version: "2.0.0", FirstSeen: now.Add(-10 * time.Second), OrgId: org.Id},
+	}
+	require.NoError(t, m.detect(ctx, now.Add(time.Minute)))
+
+	list, err := m.Deployments(common.WithOrg(ctx, defaultOrg.Id), Filter{})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.True(t, list[0].Timestamp.Equal(now.Add(-30*time.Second)))
+	list, err = m.Deployments(common.WithOrg(ctx, org.Id), Filter{})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.True(t, list[0].Timestamp.Equal(now.Add(-10*time.Second)))
+
+	// the deployments of an org can't be deleted from the other orgs
+	deleted, err := m.DeleteDeployment(common.WithOrg(ctx, defaultOrg.Id), list[0].Id)
+	require.NoError(t, err)
+	require.False(t, deleted)
+
+	// the recorded deployments are in the org of the user
+	_, err = m.Record(common.WithOrg(ctx, org.Id), &DeploymentRequest{ServiceName: "cart", Version: "2.1.0"}, "ci@signoz.io")
+	require.NoError(t, err)
+	list, err = m.Deployments(common.WithOrg(ctx, defaultOrg.Id), Filter{})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+}
+
+func TestManagerMarkers(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	m := newTestManager(t, &reader{})
+	for _, service := range []string{"frontend", "cart"} {
+		_, err := m.Record(ctx, &DeploymentRequest{ServiceName: service, Version: "1.0.0", Timestamp: now.Add(-time.Minute).UnixMilli()}, "")
+		require.NoError(t, err)
+	}
+
+	params := &v3.QueryRangeParamsV3{
+		Start: now.Add(-time.Hour).UnixMilli(),
+		End:   now.UnixMilli(),
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeBuilder,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+					{Key: v3.AttributeKey{Key: "service.name"}, Operator: v3.FilterOperatorIn, Value: []interface{}{"cart"}},
+				}}},
+			},
+		},
+	}
+	markers := m.Markers(ctx, params)
+	require.Len(t, markers, 1)
+	require.Equal(t, "cart", markers[0].ServiceName)
+
+	// the queries without service filter show the deployments of all the services
+	params.CompositeQuery.BuilderQueries["B"] = &v3.BuilderQuery{}
+	require.Len(t, m.Markers(ctx, params), 2)
+
+	params.Start = now.Add(-30 * time.Second).UnixMilli()
+	require.Empty(t, m.Markers(ctx, params))
+
+	var nilManager *Manager
+	require.Nil(t, nilManager.Markers(ctx, params))
+}
+
+func TestManagerAnnotateAlerts(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	m := newTestManager(t, &reader{})
+	_, err := m.Record(ctx, &DeploymentRequest{ServiceName: "frontend", Version: "1.4.2", Timestamp: now.Add(-5 * time.Minute).UnixMilli()}, "")
+	require.NoError(t, err)
+
+	alert := func(service string, activeAt time.Time) *rules.Alert {
+		return &rules.Alert{
+			State:       rules.StateFiring,
+			Labels:      labels.FromMap(map[string]string{"service.name": service}),
+			Annotations: labels.FromMap(map[string]string{"summary": "latency is high"}),
+			ActiveAt:    activeAt,
+		}
+	}
+	recent, other, late := alert("frontend", now), alert("cart", now), alert("frontend", now.Add(time.Hour))
+	m.AnnotateAlerts(ctx, "", recent, other, late)
+
+	require.Equal(t, "version 1.4.2 of frontend deployed 5 minutes before", recent.Annotations.Get(labels.AlertDeploymentLabel))
+	require.Equal(t, "latency is high", recent.Annotations.Get("summary"))
+	require.Empty(t, other.Annotations.Get(labels.AlertDeploymentLabel))
+	require.Empty(t, late.Annotations.Get(labels.AlertDeploymentLabel))
+}
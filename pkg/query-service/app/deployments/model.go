@@ -0,0 +1,86 @@
+package deployments
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Sources of the deployments
+const (
+	SourceAPI      = "api"
+	SourceDetected = "detected"
+)
+
+// Deployment is the rollout of a version of a service, recorded through the API or detected
+// from the service.version resource attribute of the spans
+type Deployment struct {
+	Id          string `json:"id" db:"id"`
+	ServiceName string `json:"serviceName" db:"service_name"`
+	Version     string `json:"version" db:"version"`
+	Environment string `json:"environment" db:"environment"`
+	// Timestamp is the time of the deployment, the first span of the version when detected
+	Timestamp time.Time `json:"timestamp" db:"timestamp"`
+	Source    string    `json:"source" db:"source"`
+	// Metadata are free form details, e.g. the commit or the pipeline of the deployment
+	Metadata  Metadata  `json:"metadata" db:"metadata"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	CreatedBy string    `json:"createdBy" db:"created_by"`
+	// OrgId is the org of the deployment, empty for the default org
+	OrgId string `json:"-" db:"org_id"`
+}
+
+// DeploymentRequest records a deployment, the timestamp is in milliseconds and now by default
+type DeploymentRequest struct {
+	ServiceName string   `json:"serviceName"`
+	Version     string   `json:"version"`
+	Environment string   `json:"environment"`
+	Timestamp   int64    `json:"timestamp"`
+	Metadata    Metadata `json:"metadata"`
+}
+
+func (r *DeploymentRequest) Validate() error {
+	if r.ServiceName == "" {
+		return fmt.Errorf("serviceName is required")
+	}
+	if r.Version == "" {
+		return fmt.Errorf("version is required")
+	}
+	if r.Timestamp < 0 {
+		return fmt.Errorf("timestamp can't be negative")
+	}
+	return nil
+}
+
+type Metadata map[string]string
+
+func (m *Metadata) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, m)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), m)
+	}
+	return nil
+}
+
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		m = Metadata{}
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Filter selects the deployments, the zero times leave the range open
+type Filter struct {
+	ServiceNames []string
+	Environment  string
+	Start        time.Time
+	End          time.Time
+	Limit        int
+}
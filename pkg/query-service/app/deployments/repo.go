@@ -0,0 +1,236 @@
+package deployments
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.uber.org/zap"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS deployments (
+		id TEXT PRIMARY KEY,
+		service_name TEXT NOT NULL,
+		version TEXT NOT NULL,
+		environment TEXT NOT NULL DEFAULT '',
+		timestamp datetime NOT NULL,
+		source TEXT NOT NULL,
+		metadata TEXT NOT NULL DEFAULT '{}',
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL DEFAULT ''
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating deployments table: %s", err.Error())
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_deployments_service_timestamp ON deployments (service_name, timestamp);`)
+	if err != nil {
+		return fmt.Errorf("error in creating deployments index: %s", err.Error())
+	}
+	_, err = db.Exec(`ALTER TABLE deployments ADD COLUMN org_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return fmt.Errorf("error in adding column org_id to deployments table: %s", err.Error())
+	}
+
+	// service_versions are the versions seen in the spans of each org, a version is detected
+	// as deployed the first time it is seen in the org
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS service_versions (
+		service_name TEXT NOT NULL,
+		environment TEXT NOT NULL,
+		version TEXT NOT NULL,
+		first_seen datetime NOT NULL,
+		org_id TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (service_name, environment, version, org_id)
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating service_versions table: %s", err.Error())
+	}
+	if err := migrateServiceVersions(db); err != nil {
+		return fmt.Errorf("error in adding column org_id to service_versions table: %s", err.Error())
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS deployment_detection (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		detected_until datetime NOT NULL
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating deployment_detection table: %s", err.Error())
+	}
+	return nil
+}
+
+// migrateServiceVersions adds the org to the key of the service_versions tables created
+// before the orgs were added, sqlite can't change the primary key of a table so the table is
+// rebuilt
+func migrateServiceVersions(db *sqlx.DB) error {
+	var migrated bool
+	if err := db.Get(&migrated, "SELECT COUNT(*) > 0 FROM pragma_table_info('service_versions') WHERE name = 'org_id'"); err != nil {
+		return err
+	}
+	if migrated {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range []string{
+		"ALTER TABLE service_versions RENAME TO service_versions_old",
+		`CREATE TABLE service_versions (
+			service_name TEXT NOT NULL,
+			environment TEXT NOT NULL,
+			version TEXT NOT NULL,
+			first_seen datetime NOT NULL,
+			org_id TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (service_name, environment, version, org_id)
+		)`,
+		"INSERT INTO service_versions (service_name, environment, version, first_seen) SELECT service_name, environment, version, first_seen FROM service_versions_old",
+		"DROP TABLE service_versions_old",
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+const deploymentColumns = "id, service_name, version, environment, timestamp, source, metadata, created_at, created_by, org_id"
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func (r *repo) insertDeployment(ctx context.Context, d *Deployment) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO deployments ("+deploymentColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		d.Id, d.ServiceName, d.Version, d.Environment, d.Timestamp, d.Source, d.Metadata, d.CreatedAt, d.CreatedBy, d.OrgId)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// listDeployments returns the deployments of the org of the user selected by the filter, the
+// latest first
+func (r *repo) listDeployments(ctx context.Context, f Filter) ([]*Deployment, error) {
+	condition, args := common.OrgCondition(ctx)
+	conditions := []string{condition}
+	if len(f.ServiceNames) > 0 {
+		conditions = append(conditions, "service_name IN (?"+strings.Repeat(", ?", len(f.ServiceNames)-1)+")")
+		for _, name := range f.ServiceNames {
+			args = append(args, name)
+		}
+	}
+	if f.Environment != "" {
+		conditions = append(conditions, "environment = ?")
+		args = append(args, f.Environment)
+	}
+	if !f.Start.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, f.Start.UTC())
+	}
+	if !f.End.IsZero() {
+		conditions = append(conditions, "timestamp <= ?")
+		args = append(args, f.End.UTC())
+	}
+
+	deployments := []*Deployment{}
+	query := "SELECT " + deploymentColumns + " FROM deployments WHERE " + strings.Join(conditions, " AND ") + " ORDER BY timestamp DESC LIMIT ?"
+	args = append(args, f.Limit)
+	if err := r.db.SelectContext(ctx, &deployments, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return deployments, nil
+}
+
+// deleteDeployment deletes the deployment, it returns false when it does not exist in the org
+// of the user
+func (r *repo) deleteDeployment(ctx context.Context, id string) (bool, error) {
+	condition, args := common.OrgCondition(ctx)
+	res, err := r.db.ExecContext(ctx, "DELETE FROM deployments WHERE id = ? AND "+condition, append([]interface{}{id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// hasDeployment checks if a deployment of the version of the service was recorded in the org
+func (r *repo) hasDeployment(ctx context.Context, orgId, serviceName, environment, version string) (bool, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count,
+		"SELECT count(*) FROM deployments WHERE service_name = $1 AND environment = $2 AND version = $3 AND org_id = $4",
+		serviceName, environment, version, orgId)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// addServiceVersion records the version of the service in the org, it returns false when the
+// version was already seen in the org
+func (r *repo) addServiceVersion(ctx context.Context, orgId, serviceName, environment, version string, firstSeen time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		"INSERT OR IGNORE INTO service_versions (service_name, environment, version, first_seen, org_id) VALUES ($1, $2, $3, $4, $5)",
+		serviceName, environment, version, firstSeen, orgId)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// detectedUntil returns the time up to which the versions were detected, zero before the
+// first detection
+func (r *repo) detectedUntil(ctx context.Context) (time.Time, error) {
+	var until time.Time
+	err := r.db.GetContext(ctx, &until, "SELECT detected_until FROM deployment_detection WHERE id = 1")
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return time.Time{}, err
+	}
+	return until, nil
+}
+
+// defaultOrgId returns the id of the default org, the first org created, empty before the
+// first user registers
+func (r *repo) defaultOrgId(ctx context.Context) (string, error) {
+	var id string
+	err := r.db.GetContext(ctx, &id, "SELECT id FROM organizations ORDER BY created_at, rowid LIMIT 1")
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return "", err
+	}
+	return id, nil
+}
+
+func (r *repo) setDetectedUntil(ctx context.Context, until time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO deployment_detection (id, detected_until) VALUES (1, $1) ON CONFLICT(id) DO UPDATE SET detected_until = excluded.detected_until",
+		until)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/app/logs/elasticsearch"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// elasticsearchVersion is the version of Elasticsearch reported to the clients, the clients
+// check the version of the cluster before sending the requests
+const elasticsearchVersion = "7.10.2"
+
+// registerElasticsearchAPIRoutes registers the subset of the Elasticsearch search API on the
+// logs, for the scripts and the integrations migrating from Elasticsearch. The URL of the
+// clients is the SigNoz URL followed by /api/es, the searches of any index search the logs
+func (aH *APIHandler) registerElasticsearchAPIRoutes(router *mux.Router, am *AuthMiddleware) {
+	router.HandleFunc("/api/es", am.ViewAccess(aH.getElasticsearchInfo)).Methods(http.MethodGet)
+	subRouter := router.PathPrefix("/api/es").Subrouter()
+	subRouter.HandleFunc("/", am.ViewAccess(aH.getElasticsearchInfo)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/_search", am.ViewAccess(aH.elasticsearchSearch)).Methods(http.MethodGet, http.MethodPost)
+	subRouter.HandleFunc("/{index}/_search", am.ViewAccess(aH.elasticsearchSearch)).Methods(http.MethodGet, http.MethodPost)
+}
+
+func respondElasticsearchError(w http.ResponseWriter, apiErr *model.ApiError) {
+	code := errorStatusCode(apiErr.Type())
+	typ := "exception"
+	if apiErr.Type() == model.ErrorBadData {
+		typ = "parsing_exception"
+	}
+	writeElasticsearchResponse(w, code, elasticsearch.NewErrorResponse(code, typ, apiErr.Error()))
+}
+
+func writeElasticsearchResponse(w http.ResponseWriter, code int, response interface{}) {
+	// the clients of Elasticsearch 8 check the product of the responses
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		zap.L().Error("error writing response", zap.Error(err))
+	}
+}
+
+func (aH *APIHandler) getElasticsearchInfo(w http.ResponseWriter, r *http.Request) {
+	writeElasticsearchResponse(w, http.StatusOK, map[string]interface{}{
+		"name":         "signoz",
+		"cluster_name": "signoz",
+		"version": map[string]interface{}{
+			"number":       elasticsearchVersion,
+			"build_flavor": "default",
+		},
+		"tagline": "You Know, for Search",
+	})
+}
+
+func (aH *APIHandler) elasticsearchSearch(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, elasticsearch.MaxRequestSize))
+	if err != nil {
+		respondElasticsearchError(w, model.BadRequest(err))
+		return
+	}
+	req, err := elasticsearch.ParseSearchRequest(body, time.Now())
+	if err != nil {
+		respondElasticsearchError(w, model.BadRequest(err))
+		return
+	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		respondElasticsearchError(w, apiErr)
+		return
+	}
+	if scope != nil {
+		req.Resources = &elasticsearch.ResourceFilter{Key: constants.OrgIdAttribute, Values: scope.OrgIds, Exclude: scope.Exclude}
+	}
+
+	index := mux.Vars(r)["index"]
+	if index == "" {
+		index = "logs"
+	}
+	response, err := aH.esQuerier.Search(r.Context(), index, req)
+	if err != nil {
+		respondElasticsearchError(w, &model.ApiError{Typ: model.ErrorExec, Err: err})
+		return
+	}
+	writeElasticsearchResponse(w, http.StatusOK, response)
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/errortracking"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// listErrorGroups lists the groups of exceptions with their status and assignee
+func (aH *APIHandler) listErrorGroups(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := errortracking.Filter{
+		Status:        query.Get("status"),
+		Assignee:      query.Get("assignee"),
+		ServiceName:   query.Get("serviceName"),
+		ExceptionType: query.Get("exceptionType"),
+		OrderBy:       query.Get("orderBy"),
+	}
+	for param, value := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if s := query.Get(param); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				RespondError(w, model.BadRequest(fmt.Errorf("invalid %s %q", param, s)), nil)
+				return
+			}
+			*value = n
+		}
+	}
+	if err := filter.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	groups, err := aH.ErrorTrackingManager.Groups(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, groups)
+}
+
+func (aH *APIHandler) getErrorGroup(w http.ResponseWriter, r *http.Request) {
+	fingerprint := mux.Vars(r)["fingerprint"]
+	group, err := aH.ErrorTrackingManager.Group(r.Context(), fingerprint)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if group == nil {
+		RespondError(w, errorGroupNotFound(fingerprint), nil)
+		return
+	}
+	aH.Respond(w, group)
+}
+
+// patchErrorGroup resolves, ignores, unresolves or assigns the group
+func (aH *APIHandler) patchErrorGroup(w http.ResponseWriter, r *http.Request) {
+	fingerprint := mux.Vars(r)["fingerprint"]
+	var update errortracking.GroupUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := update.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	group, err := aH.ErrorTrackingManager.UpdateGroup(r.Context(), fingerprint, &update, webhookUser(r))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if group == nil {
+		RespondError(w, errorGroupNotFound(fingerprint), nil)
+		return
+	}
+	aH.Respond(w, group)
+}
+
+func errorGroupNotFound(fingerprint string) *model.ApiError {
+	return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("error group %s not found", fingerprint)}
+}
+
+func (aH *APIHandler) listErrorIgnoreRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := aH.ErrorTrackingManager.IgnoreRules(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, rules)
+}
+
+// createErrorIgnoreRule creates the rule, the unresolved groups it matches are ignored
+func (aH *APIHandler) createErrorIgnoreRule(w http.ResponseWriter, r *http.Request) {
+	var rule errortracking.IgnoreRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := rule.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	created, err := aH.ErrorTrackingManager.CreateIgnoreRule(r.Context(), &rule, webhookUser(r))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, created)
+}
+
+// deleteErrorIgnoreRule deletes the rule, the groups it ignored are unresolved
+func (aH *APIHandler) deleteErrorIgnoreRule(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	deleted, err := aH.ErrorTrackingManager.DeleteIgnoreRule(r.Context(), id, webhookUser(r))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if !deleted {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("ignore rule %s not found", id)}, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
@@ -0,0 +1,39 @@
+package errortracking
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+)
+
+var (
+	uuidRegex   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	hexRegex    = regexp.MustCompile(`\b(0x[0-9a-fA-F]+|[0-9a-fA-F]{16,})\b`)
+	quotedRegex = regexp.MustCompile(`'[^']*'|"[^"]*"`)
+	numberRegex = regexp.MustCompile(`\d+`)
+)
+
+// Normalize replaces the ids, the quoted values and the numbers of the message with
+// placeholders so the messages of the same error share a fingerprint
+func Normalize(message string) string {
+	message = uuidRegex.ReplaceAllString(message, "<uuid>")
+	message = hexRegex.ReplaceAllString(message, "<hex>")
+	message = quotedRegex.ReplaceAllString(message, "<str>")
+	return numberRegex.ReplaceAllString(message, "<num>")
+}
+
+// Fingerprint returns the fingerprint of the exceptions of the service with the type and the
+// normalized message
+func Fingerprint(serviceName, exceptionType, message string) string {
+	sum := sha256.Sum256([]byte(serviceName + "\n" + exceptionType + "\n" + Normalize(message)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// orgFingerprint returns the fingerprint of the exceptions of an org, the exceptions of the
+// default org keep the fingerprint they had before the orgs were added
+func orgFingerprint(orgId, serviceName, exceptionType, message string) string {
+	if orgId == "" {
+		return Fingerprint(serviceName, exceptionType, message)
+	}
+	return Fingerprint(orgId+"\n"+serviceName, exceptionType, message)
+}
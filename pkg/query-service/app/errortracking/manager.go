@@ -0,0 +1,303 @@
+package errortracking
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	syncInterval = time.Minute
+	// syncDelay is how far behind now the exceptions are synced, so the exceptions still
+	// being written are not skipped
+	syncDelay = time.Minute
+	// maxSyncWindow is the window of the first sync, and of the sync after a downtime
+	maxSyncWindow = 24 * time.Hour
+
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// ErrorReader lists the exceptions grouped by the collector
+type ErrorReader interface {
+	ListErrors(ctx context.Context, params *model.ListErrorsParams) (*[]model.Error, *model.ApiError)
+}
+
+type ManagerOptions struct {
+	DB     *sqlx.DB
+	Reader ErrorReader
+}
+
+// Manager tracks the lifecycle of the groups of exceptions. The exceptions are periodically
+// synced from the groups of the collector into the groups of their fingerprint, which keep
+// their occurrences, status and assignee
+type Manager struct {
+	repo   *repo
+	reader ErrorReader
+
+	// mtx serializes the syncs and the updates of the groups
+	mtx sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	if err := InitSqliteDBIfNeeded(opts.DB); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		repo:   &repo{db: opts.DB},
+		reader: opts.Reader,
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Start syncs the exceptions every minute
+func (m *Manager) Start() error {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(syncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.sync(m.ctx, time.Now().UTC()); err != nil {
+					zap.L().Error("failed to sync the exceptions", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// sync records the exceptions seen since the last sync in their groups
+func (m *Manager) sync(ctx context.Context, now time.Time) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	end := now.Add(-syncDelay)
+	start, err := m.repo.syncedUntil(ctx)
+	if err != nil {
+		return err
+	}
+	if start.IsZero() || end.Sub(start) > maxSyncWindow {
+		start = end.Add(-maxSyncWindow)
+	} else {
+		start = start.Add(time.Nanosecond)
+	}
+	if !end.After(start) {
+		return nil
+	}
+
+	errs, apiErr := m.reader.ListErrors(ctx, &model.ListErrorsParams{Start: &start, End: &end, ByOrg: true})
+	if apiErr != nil {
+		return apiErr.Err
+	}
+	rules, err := m.repo.listIgnoreRules(ctx)
+	if err != nil {
+		return err
+	}
+	defaultOrgId, err := m.repo.defaultOrgId(ctx)
+	if err != nil {
+		return err
+	}
+	if errs != nil {
+		for i := range *errs {
+			e := &(*errs)[i]
+			// the exceptions sent before the orgs were added have no org and belong to the
+			// default org
+			if e.OrgId == defaultOrgId {
+				e.OrgId = ""
+			}
+			if err := m.record(ctx, e, rules, now); err != nil {
+				return err
+			}
+		}
+	}
+	return m.repo.setSyncedUntil(ctx, end)
+}
+
+// record adds the exceptions of a group of the collector to the group of their fingerprint.
+// The new groups matching an ignore rule are ignored and the resolved groups seen again
+// after their resolution regress
+func (m *Manager) record(ctx context.Context, e *model.Error, rules []*IgnoreRule, now time.Time) error {
+	fingerprint, err := m.repo.fingerprintOf(ctx, e.GroupID, e.OrgId)
+	if err != nil {
+		return err
+	}
+	known := fingerprint != ""
+	if !known {
+		fingerprint = orgFingerprint(e.OrgId, e.ServiceName, e.ExceptionType, e.ExceptionMsg)
+	}
+
+	g, err := m.repo.getGroup(ctx, fingerprint)
+	if err != nil {
+		return err
+	}
+	if g == nil {
+		g = &Group{
+			Fingerprint:      fingerprint,
+			ServiceName:      e.ServiceName,
+			ExceptionType:    e.ExceptionType,
+			ExceptionMessage: e.ExceptionMsg,
+			FirstSeen:        e.FirstSeen.UTC(),
+			LastSeen:         e.LastSeen.UTC(),
+			Occurrences:      int64(e.ExceptionCount),
+			Status:           StatusUnresolved,
+			UpdatedAt:        now,
+			OrgId:            e.OrgId,
+		}
+		for _, rule := range rules {
+			if rule.matches(g) {
+				g.Status, g.IgnoreRuleId = StatusIgnored, rule.Id
+				break
+			}
+		}
+		if err := m.repo.insertGroup(ctx, g); err != nil {
+			return err
+		}
+	} else {
+		g.Occurrences += int64(e.ExceptionCount)
+		if e.FirstSeen.Before(g.FirstSeen) {
+			g.FirstSeen = e.FirstSeen.UTC()
+		}
+		if e.LastSeen.After(g.LastSeen) {
+			g.LastSeen, g.ExceptionMessage = e.LastSeen.UTC(), e.ExceptionMsg
+		}
+		if g.Status == StatusResolved && g.ResolvedAt != nil && e.LastSeen.After(*g.ResolvedAt) {
+			regressedAt := e.LastSeen.UTC()
+			g.Status, g.Regressed, g.RegressedAt = StatusUnresolved, true, &regressedAt
+			g.UpdatedAt, g.UpdatedBy = now, ""
+		}
+		if err := m.repo.updateGroup(ctx, g); err != nil {
+			return err
+		}
+	}
+
+	if !known {
+		return m.repo.insertGroupId(ctx, e.GroupID, e.OrgId, fingerprint)
+	}
+	return nil
+}
+
+// Groups returns the groups selected by the filter
+func (m *Manager) Groups(ctx context.Context, f Filter) ([]*Group, error) {
+	if f.Limit == 0 {
+		f.Limit = defaultListLimit
+	}
+	f.Limit = min(f.Limit, maxListLimit)
+	return m.repo.listGroups(ctx, f)
+}
+
+// Group returns the group with the fingerprint and the ids of its groups of the collector,
+// nil when it does not exist
+func (m *Manager) Group(ctx context.Context, fingerprint string) (*Group, error) {
+	g, err := m.repo.getGroup(ctx, fingerprint)
+	if err != nil || g == nil {
+		return nil, err
+	}
+	if g.GroupIds, err = m.repo.groupIds(ctx, fingerprint); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// UpdateGroup changes the status or the assignee of the group, it returns nil when the group
+// does not exist. Resolving a group clears its regression, the group regresses when its
+// exceptions are seen after the resolution
+func (m *Manager) UpdateGroup(ctx context.Context, fingerprint string, u *GroupUpdate, by string) (*Group, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	g, err := m.repo.getGroup(ctx, fingerprint)
+	if err != nil || g == nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if u.Status != nil && *u.Status != g.Status {
+		g.Status, g.IgnoreRuleId = *u.Status, ""
+		switch g.Status {
+		case StatusResolved:
+			g.ResolvedAt, g.ResolvedBy = &now, by
+			g.Regressed, g.RegressedAt = false, nil
+		case StatusUnresolved:
+			g.ResolvedAt, g.ResolvedBy = nil, ""
+		}
+	}
+	if u.Assignee != nil {
+		g.Assignee = *u.Assignee
+	}
+	g.UpdatedAt, g.UpdatedBy = now, by
+	if err := m.repo.updateGroup(ctx, g); err != nil {
+		return nil, err
+	}
+	return m.Group(ctx, fingerprint)
+}
+
+func (m *Manager) IgnoreRules(ctx context.Context) ([]*IgnoreRule, error) {
+	return m.repo.listIgnoreRules(ctx)
+}
+
+// CreateIgnoreRule creates the rule in the org of the user and ignores the unresolved groups
+// of the org it matches
+func (m *Manager) CreateIgnoreRule(ctx context.Context, rule *IgnoreRule, by string) (*IgnoreRule, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	defaultOrgId, err := m.repo.defaultOrgId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	rule.Id = uuid.NewString()
+	rule.CreatedAt, rule.CreatedBy = now, by
+	if rule.OrgId = common.GetOrgIdFromContext(ctx); rule.OrgId == defaultOrgId {
+		rule.OrgId = ""
+	}
+	if err := m.repo.insertIgnoreRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	// a negative limit lists all the groups
+	unresolved, err := m.repo.listGroups(ctx, Filter{Status: StatusUnresolved, Limit: -1})
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range unresolved {
+		if !rule.matches(g) {
+			continue
+		}
+		g.Status, g.IgnoreRuleId = StatusIgnored, rule.Id
+		g.UpdatedAt, g.UpdatedBy = now, by
+		if err := m.repo.updateGroup(ctx, g); err != nil {
+			return nil, err
+		}
+	}
+	return rule, nil
+}
+
+// DeleteIgnoreRule deletes the rule and unresolves the groups it ignored, it returns false
+// when the rule does not exist
+func (m *Manager) DeleteIgnoreRule(ctx context.Context, id string, by string) (bool, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.repo.deleteIgnoreRule(ctx, id, time.Now().UTC(), by)
+}
@@ -0,0 +1,221 @@
+package errortracking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+// reader returns the exceptions of the collector groups seen in the synced window
+type reader struct {
+	errors []model.Error
+	params []*model.ListErrorsParams
+}
+
+func (r *reader) ListErrors(ctx context.Context, params *model.ListErrorsParams) (*[]model.Error, *model.ApiError) {
+	r.params = append(r.params, params)
+	result := []model.Error{}
+	for _, e := range r.errors {
+		if !e.LastSeen.Before(*params.Start) && !e.FirstSeen.After(*params.End) {
+			result = append(result, e)
+		}
+	}
+	return &result, nil
+}
+
+func newTestManager(t *testing.T, r *reader) *Manager {
+	db := utils.NewQueryServiceDBForTests(t)
+	m, err := NewManager(ManagerOptions{DB: db, Reader: r})
+	require.NoError(t, err)
+	return m
+}
+
+func exception(groupId, message string, count uint64, at time.Time) model.Error {
+	return model.Error{
+		GroupID:        groupId,
+		ServiceName:    "checkout",
+		ExceptionType:  "TimeoutError",
+		ExceptionMsg:   message,
+		ExceptionCount: count,
+		FirstSeen:      at,
+		LastSeen:       at,
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	require.Equal(t,
+		"order <num> of user <uuid> timed out after <num>ms at <hex> with key <str>",
+		Normalize(`order 1234 of user 3f2b8c1e-2a4b-4c6d-8e9f-0a1b2c3d4e5f timed out after 500ms at 0x7ffde4 with key "abc"`))
+	require.Equal(t, Fingerprint("checkout", "TimeoutError", "order 1 failed"), Fingerprint("checkout", "TimeoutError", "order 2 failed"))
+	require.NotEqual(t, Fingerprint("checkout", "TimeoutError", "order 1 failed"), Fingerprint("cart", "TimeoutError", "order 1 failed"))
+}
+
+func TestManagerGroups(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	r := &reader{}
+	m := newTestManager(t, r)
+
+	// the collector groups of the messages with different order ids share a group
+	r.errors = []model.Error{
+		exception("g1", "order 1 timed out", 3, now.Add(-30*time.Minute)),
+		exception("g2", "order 2 timed out", 2, now.Add(-20*time.Minute)),
+	}
+	require.NoError(t, m.sync(ctx, now))
+
+	groups, err := m.Groups(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Equal(t, int64(5), groups[0].Occurrences)
+	require.Equal(t, StatusUnresolved, groups[0].Status)
+	require.True(t, groups[0].FirstSeen.Equal(now.Add(-30*time.Minute)))
+	require.True(t, groups[0].LastSeen.Equal(now.Add(-20*time.Minute)))
+	require.Equal(t, "order 2 timed out", groups[0].ExceptionMessage)
+
+	fingerprint := groups[0].Fingerprint
+	g, err := m.Group(ctx, fingerprint)
+	require.NoError(t, err)
+	require.Equal(t, []string{"g1", "g2"}, g.GroupIds)
+
+	// the next sync only counts the exceptions seen since the last one
+	require.NoError(t, m.sync(ctx, now.Add(time.Minute)))
+	require.True(t, r.params[1].Start.After(*r.params[0].End))
+	g, err = m.Group(ctx, fingerprint)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), g.Occurrences)
+
+	resolved := StatusResolved
+	assignee := "dev@signoz.io"
+	g, err = m.UpdateGroup(ctx, fingerprint, &GroupUpdate{Status: &resolved, Assignee: &assignee}, "admin@signoz.io")
+	require.NoError(t, err)
+	require.Equal(t, StatusResolved, g.Status)
+	require.Equal(t, "admin@signoz.io", g.ResolvedBy)
+	require.Equal(t, assignee, g.Assignee)
+
+	assigned, err := m.Groups(ctx, Filter{Assignee: assignee})
+	require.NoError(t, err)
+	require.Len(t, assigned, 1)
+
+	// the exceptions seen after the resolution regress the group
+	r.errors = append(r.errors, exception("g1", "order 1 timed out", 1, g.ResolvedAt.Add(time.Minute)))
+	require.NoError(t, m.sync(ctx, g.ResolvedAt.Add(3*time.Minute)))
+	g, err = m.Group(ctx, fingerprint)
+	require.NoError(t, err)
+	require.Equal(t, StatusUnresolved, g.Status)
+	require.True(t, g.Regressed)
+	require.NotNil(t, g.RegressedAt)
+	require.Equal(t, int64(6), g.Occurrences)
+
+	missing, err := m.UpdateGroup(ctx, "unknown", &GroupUpdate{Status: &resolved}, "")
+	require.NoError(t, err)
+	require.Nil(t, missing)
+}
+
+func TestManagerOrgs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	r := &reader{}
+	m := newTestManager(t, r)
+	defaultOrg, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "default"})
+	require.Nil(t, apiErr)
+	org, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "org"})
+	require.Nil(t, apiErr)
+
+	// the collectors of the orgs group the same exceptions under the same id, the exceptions
+	// without org and those of the default org share a group
+	older := exception("g1", "order 1 timed out", 1, now.Add(-30*time.Minute))
+	inDefault := exception("g1", "order 1 timed out", 2, now.Add(-20*time.Minute))
+	inDefault.OrgId = defaultOrg.Id
+	inOrg := exception("g1", "order 1 timed out", 4, now.Add(-10*time.Minute))
+	inOrg.OrgId = org.Id
+	r.errors = []model.Error{older, inDefault, inOrg}
+	require.NoError(t, m.sync(ctx, now))
+	require.True(t, r.params[0].ByOrg)
+
+	groups, err := m.Groups(common.WithOrg(ctx, defaultOrg.Id), Filter{})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Equal(t, int64(3), groups[0].Occurrences)
+	groups, err = m.Groups(common.WithOrg(ctx, org.Id), Filter{})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Equal(t, int64(4), groups[0].Occurrences)
+
+	// the groups and the ignore rules of an org are not visible in the other orgs
+	g, err := m.Group(common.WithOrg(ctx, defaultOrg.Id), groups[0].Fingerprint)
+	require.NoError(t, err)
+	require.Nil(t, g)
+	rule, err := m.CreateIgnoreRule(common.WithOrg(ctx, org.Id), &IgnoreRule{Name: "timeouts", ExceptionType: "TimeoutError"}, "admin@signoz.io")
+	require.NoError(t, err)
+	ignored, err := m.Groups(ctx, Filter{Status: StatusIgnored})
+	require.NoError(t, err)
+	require.Len(t, ignored, 1)
+	require.Equal(t, org.Id, ignored[0].OrgId)
+	rules, err := m.IgnoreRules(common.WithOrg(ctx, defaultOrg.Id))
+	require.NoError(t, err)
+	require.Empty(t, rules)
+	deleted, err := m.DeleteIgnoreRule(common.WithOrg(ctx, defaultOrg.Id), rule.Id, "admin@signoz.io")
+	require.NoError(t, err)
+	require.False(t, deleted)
+}
+
+func TestManagerIgnoreRules(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	r := &reader{errors: []model.Error{exception("g1", "order 1 timed out", 1, now.Add(-10*time.Minute))}}
+	m := newTestManager(t, r)
+	require.NoError(t, m.sync(ctx, now))
+
+	// the rule ignores the existing groups it matches
+	rule := &IgnoreRule{Name: "timeouts", ExceptionType: "TimeoutError", MessagePattern: "^order"}
+	require.NoError(t, rule.Validate())
+	rule, err := m.CreateIgnoreRule(ctx, rule, "admin@signoz.io")
+	require.NoError(t, err)
+
+	groups, err := m.Groups(ctx, Filter{Status: StatusIgnored})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Equal(t, rule.Id, groups[0].IgnoreRuleId)
+
+	// and the new groups it matches
+	r.errors = append(r.errors,
+		exception("g2", "order lookup failed", 1, now.Add(30*time.Second)),
+		exception("g3", "payment declined", 1, now.Add(30*time.Second)))
+	require.NoError(t, m.sync(ctx, now.Add(2*time.Minute)))
+	groups, err = m.Groups(ctx, Filter{Status: StatusIgnored})
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	groups, err = m.Groups(ctx, Filter{Status: StatusUnresolved})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Equal(t, "payment declined", groups[0].ExceptionMessage)
+
+	deleted, err := m.DeleteIgnoreRule(ctx, rule.Id, "admin@signoz.io")
+	require.NoError(t, err)
+	require.True(t, deleted)
+	groups, err = m.Groups(ctx, Filter{Status: StatusUnresolved})
+	require.NoError(t, err)
+	require.Len(t, groups, 3)
+
+	deleted, err = m.DeleteIgnoreRule(ctx, rule.Id, "admin@signoz.io")
+	require.NoError(t, err)
+	require.False(t, deleted)
+}
+
+func TestValidate(t *testing.T) {
+	require.Error(t, (&IgnoreRule{Name: "r"}).Validate())
+	require.Error(t, (&IgnoreRule{Name: "r", MessagePattern: "("}).Validate())
+	require.Error(t, (&IgnoreRule{ServiceName: "checkout"}).Validate())
+
+	status := "closed"
+	require.Error(t, (&GroupUpdate{}).Validate())
+	require.Error(t, (&GroupUpdate{Status: &status}).Validate())
+	require.Error(t, (&Filter{OrderBy: "name"}).Validate())
+}
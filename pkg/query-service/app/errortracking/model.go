@@ -0,0 +1,155 @@
+package errortracking
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Statuses of the error groups
+const (
+	StatusUnresolved = "unresolved"
+	StatusResolved   = "resolved"
+	StatusIgnored    = "ignored"
+)
+
+// Orders of the error groups
+const (
+	OrderLastSeen    = "lastSeen"
+	OrderFirstSeen   = "firstSeen"
+	OrderOccurrences = "occurrences"
+)
+
+// Group is a group of exceptions with the same fingerprint. The exceptions grouped by the
+// collector whose messages only differ in their ids and numbers share a group
+type Group struct {
+	Fingerprint   string `json:"fingerprint" db:"fingerprint"`
+	ServiceName   string `json:"serviceName" db:"service_name"`
+	ExceptionType string `json:"exceptionType" db:"exception_type"`
+	// ExceptionMessage is the last seen message of the exceptions of the group
+	ExceptionMessage string    `json:"exceptionMessage" db:"exception_message"`
+	FirstSeen        time.Time `json:"firstSeen" db:"first_seen"`
+	LastSeen         time.Time `json:"lastSeen" db:"last_seen"`
+	Occurrences      int64     `json:"occurrences" db:"occurrences"`
+	Status           string    `json:"status" db:"status"`
+	// Regressed is set when exceptions are seen after the group was resolved, the group is
+	// unresolved again until it is resolved
+	Regressed   bool       `json:"regressed" db:"regressed"`
+	RegressedAt *time.Time `json:"regressedAt" db:"regressed_at"`
+	ResolvedAt  *time.Time `json:"resolvedAt" db:"resolved_at"`
+	ResolvedBy  string     `json:"resolvedBy" db:"resolved_by"`
+	// IgnoreRuleId is the rule which ignored the group, empty when it was ignored by a user
+	IgnoreRuleId string    `json:"ignoreRuleId" db:"ignore_rule_id"`
+	Assignee     string    `json:"assignee" db:"assignee"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+	UpdatedBy    string    `json:"updatedBy" db:"updated_by"`
+	// OrgId is the org the exceptions were sent for, empty for the default org
+	OrgId string `json:"-" db:"org_id"`
+	// GroupIds are the ids of the groups of the collector, they open the exceptions in the
+	// error details, they are only set on a single group
+	GroupIds []string `json:"groupIds,omitempty" db:"-"`
+}
+
+// GroupUpdate changes the status or the assignee of a group, the fields left out are kept
+type GroupUpdate struct {
+	Status *string `json:"status"`
+	// Assignee is the email of the user the group is assigned to, empty to unassign it
+	Assignee *string `json:"assignee"`
+}
+
+func (u *GroupUpdate) Validate() error {
+	if u.Status == nil && u.Assignee == nil {
+		return fmt.Errorf("status or assignee is required")
+	}
+	if u.Status != nil && !validStatus(*u.Status) {
+		return fmt.Errorf("invalid status %q", *u.Status)
+	}
+	return nil
+}
+
+func validStatus(status string) bool {
+	return status == StatusUnresolved || status == StatusResolved || status == StatusIgnored
+}
+
+// Filter selects the groups, the groups seen last come first by default
+type Filter struct {
+	Status        string
+	Assignee      string
+	ServiceName   string
+	ExceptionType string
+	OrderBy       string
+	Limit         int
+	Offset        int
+}
+
+func (f *Filter) Validate() error {
+	if f.Status != "" && !validStatus(f.Status) {
+		return fmt.Errorf("invalid status %q", f.Status)
+	}
+	switch f.OrderBy {
+	case "", OrderLastSeen, OrderFirstSeen, OrderOccurrences:
+	default:
+		return fmt.Errorf("invalid orderBy %q", f.OrderBy)
+	}
+	if f.Limit < 0 || f.Offset < 0 {
+		return fmt.Errorf("limit and offset can't be negative")
+	}
+	return nil
+}
+
+// IgnoreRule ignores the new groups it matches, the groups ignored by a rule are unresolved
+// again when the rule is deleted
+type IgnoreRule struct {
+	Id   string `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	// ServiceName and ExceptionType match the groups with the same service and type
+	ServiceName   string `json:"serviceName" db:"service_name"`
+	ExceptionType string `json:"exceptionType" db:"exception_type"`
+	// MessagePattern is a regular expression matched against the messages of the groups
+	MessagePattern string    `json:"messagePattern" db:"message_pattern"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+	CreatedBy      string    `json:"createdBy" db:"created_by"`
+	// OrgId is the org of the rule, empty for the default org, the rule only matches the
+	// groups of its org
+	OrgId string `json:"-" db:"org_id"`
+
+	pattern *regexp.Regexp
+}
+
+func (r *IgnoreRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.ServiceName == "" && r.ExceptionType == "" && r.MessagePattern == "" {
+		return fmt.Errorf("serviceName, exceptionType or messagePattern is required")
+	}
+	if r.MessagePattern != "" {
+		if _, err := regexp.Compile(r.MessagePattern); err != nil {
+			return fmt.Errorf("invalid messagePattern: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *IgnoreRule) matches(g *Group) bool {
+	if r.OrgId != g.OrgId {
+		return false
+	}
+	if r.ServiceName != "" && r.ServiceName != g.ServiceName {
+		return false
+	}
+	if r.ExceptionType != "" && r.ExceptionType != g.ExceptionType {
+		return false
+	}
+	if r.MessagePattern != "" {
+		if r.pattern == nil {
+			pattern, err := regexp.Compile(r.MessagePattern)
+			if err != nil {
+				return false
+			}
+			r.pattern = pattern
+		}
+		return r.pattern.MatchString(g.ExceptionMessage)
+	}
+	return true
+}
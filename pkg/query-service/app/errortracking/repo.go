@@ -0,0 +1,337 @@
+package errortracking
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.uber.org/zap"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS error_groups (
+		fingerprint TEXT PRIMARY KEY,
+		service_name TEXT NOT NULL,
+		exception_type TEXT NOT NULL,
+		exception_message TEXT NOT NULL,
+		first_seen datetime NOT NULL,
+		last_seen datetime NOT NULL,
+		occurrences INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL,
+		regressed BOOLEAN NOT NULL DEFAULT FALSE,
+		regressed_at datetime,
+		resolved_at datetime,
+		resolved_by TEXT NOT NULL DEFAULT '',
+		ignore_rule_id TEXT NOT NULL DEFAULT '',
+		assignee TEXT NOT NULL DEFAULT '',
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL DEFAULT ''
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating error_groups table: %s", err.Error())
+	}
+
+	_, err = db.Exec(`ALTER TABLE error_groups ADD COLUMN org_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return fmt.Errorf("error in adding column org_id to error_groups table: %s", err.Error())
+	}
+
+	// the collectors of the orgs group the same exceptions under the same ids, the ids are
+	// unique per org
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS error_group_ids (
+		group_id TEXT NOT NULL,
+		org_id TEXT NOT NULL DEFAULT '',
+		fingerprint TEXT NOT NULL,
+		PRIMARY KEY (group_id, org_id),
+		FOREIGN KEY(fingerprint) REFERENCES error_groups(fingerprint) ON DELETE CASCADE
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating error_group_ids table: %s", err.Error())
+	}
+	if err := migrateGroupIds(db); err != nil {
+		return fmt.Errorf("error in adding column org_id to error_group_ids table: %s", err.Error())
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS error_ignore_rules (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		service_name TEXT NOT NULL DEFAULT '',
+		exception_type TEXT NOT NULL DEFAULT '',
+		message_pattern TEXT NOT NULL DEFAULT '',
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating error_ignore_rules table: %s", err.Error())
+	}
+	_, err = db.Exec(`ALTER TABLE error_ignore_rules ADD COLUMN org_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return fmt.Errorf("error in adding column org_id to error_ignore_rules table: %s", err.Error())
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS error_tracking_sync (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		synced_until datetime NOT NULL
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating error_tracking_sync table: %s", err.Error())
+	}
+	return nil
+}
+
+// migrateGroupIds adds the org to the key of the error_group_ids tables created before the
+// orgs were added, sqlite can't change the primary key of a table so the table is rebuilt
+func migrateGroupIds(db *sqlx.DB) error {
+	var migrated bool
+	if err := db.Get(&migrated, "SELECT COUNT(*) > 0 FROM pragma_table_info('error_group_ids') WHERE name = 'org_id'"); err != nil {
+		return err
+	}
+	if migrated {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range []string{
+		"ALTER TABLE error_group_ids RENAME TO error_group_ids_old",
+		`CREATE TABLE error_group_ids (
+			group_id TEXT NOT NULL,
+			org_id TEXT NOT NULL DEFAULT '',
+			fingerprint TEXT NOT NULL,
+			PRIMARY KEY (group_id, org_id),
+			FOREIGN KEY(fingerprint) REFERENCES error_groups(fingerprint) ON DELETE CASCADE
+		)`,
+		"INSERT INTO error_group_ids (group_id, fingerprint) SELECT group_id, fingerprint FROM error_group_ids_old",
+		"DROP TABLE error_group_ids_old",
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+const groupColumns = "fingerprint, service_name, exception_type, exception_message, first_seen, last_seen, occurrences, status, regressed, regressed_at, resolved_at, resolved_by, ignore_rule_id, assignee, updated_at, updated_by, org_id"
+
+const ignoreRuleColumns = "id, name, service_name, exception_type, message_pattern, created_at, created_by, org_id"
+
+var orderColumns = map[string]string{
+	OrderLastSeen:    "last_seen",
+	OrderFirstSeen:   "first_seen",
+	OrderOccurrences: "occurrences",
+}
+
+type repo struct {
+	db *sqlx.DB
+}
+
+// getGroup returns the group with the fingerprint, nil when it does not exist in the org of
+// the user
+func (r *repo) getGroup(ctx context.Context, fingerprint string) (*Group, error) {
+	g := &Group{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.GetContext(ctx, g, "SELECT "+groupColumns+" FROM error_groups WHERE fingerprint = ? AND "+condition,
+		append([]interface{}{fingerprint}, args...)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return g, nil
+}
+
+func (r *repo) listGroups(ctx context.Context, f Filter) ([]*Group, error) {
+	condition, args := common.OrgCondition(ctx)
+	conditions := []string{condition}
+	if f.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, f.Status)
+	}
+	if f.Assignee != "" {
+		conditions = append(conditions, "assignee = ?")
+		args = append(args, f.Assignee)
+	}
+	if f.ServiceName != "" {
+		conditions = append(conditions, "service_name = ?")
+		args = append(args, f.ServiceName)
+	}
+	if f.ExceptionType != "" {
+		conditions = append(conditions, "exception_type = ?")
+		args = append(args, f.ExceptionType)
+	}
+	order, ok := orderColumns[f.OrderBy]
+	if !ok {
+		order = orderColumns[OrderLastSeen]
+	}
+
+	groups := []*Group{}
+	query := "SELECT " + groupColumns + " FROM error_groups WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY " + order + " DESC, fingerprint LIMIT ? OFFSET ?"
+	args = append(args, f.Limit, f.Offset)
+	if err := r.db.SelectContext(ctx, &groups, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *repo) insertGroup(ctx context.Context, g *Group) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO error_groups ("+groupColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)",
+		g.Fingerprint, g.ServiceName, g.ExceptionType, g.ExceptionMessage, g.FirstSeen, g.LastSeen, g.Occurrences, g.Status,
+		g.Regressed, g.RegressedAt, g.ResolvedAt, g.ResolvedBy, g.IgnoreRuleId, g.Assignee, g.UpdatedAt, g.UpdatedBy, g.OrgId)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *repo) updateGroup(ctx context.Context, g *Group) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE error_groups SET exception_message = $1, first_seen = $2, last_seen = $3, occurrences = $4, status = $5, regressed = $6, regressed_at = $7, "+
+			"resolved_at = $8, resolved_by = $9, ignore_rule_id = $10, assignee = $11, updated_at = $12, updated_by = $13 WHERE fingerprint = $14",
+		g.ExceptionMessage, g.FirstSeen, g.LastSeen, g.Occurrences, g.Status, g.Regressed, g.RegressedAt,
+		g.ResolvedAt, g.ResolvedBy, g.IgnoreRuleId, g.Assignee, g.UpdatedAt, g.UpdatedBy, g.Fingerprint)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// fingerprintOf returns the fingerprint of the group of the collector of the org, empty when
+// the group was not seen yet
+func (r *repo) fingerprintOf(ctx context.Context, groupId, orgId string) (string, error) {
+	var fingerprint string
+	err := r.db.GetContext(ctx, &fingerprint, "SELECT fingerprint FROM error_group_ids WHERE group_id = $1 AND org_id = $2", groupId, orgId)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return "", err
+	}
+	return fingerprint, nil
+}
+
+func (r *repo) insertGroupId(ctx context.Context, groupId, orgId, fingerprint string) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO error_group_ids (group_id, org_id, fingerprint) VALUES ($1, $2, $3)", groupId, orgId, fingerprint)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *repo) groupIds(ctx context.Context, fingerprint string) ([]string, error) {
+	ids := []string{}
+	err := r.db.SelectContext(ctx, &ids, "SELECT group_id FROM error_group_ids WHERE fingerprint = $1 ORDER BY group_id", fingerprint)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *repo) listIgnoreRules(ctx context.Context) ([]*IgnoreRule, error) {
+	rules := []*IgnoreRule{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.SelectContext(ctx, &rules, "SELECT "+ignoreRuleColumns+" FROM error_ignore_rules WHERE "+condition+" ORDER BY created_at", args...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *repo) insertIgnoreRule(ctx context.Context, rule *IgnoreRule) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO error_ignore_rules ("+ignoreRuleColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		rule.Id, rule.Name, rule.ServiceName, rule.ExceptionType, rule.MessagePattern, rule.CreatedAt, rule.CreatedBy, rule.OrgId)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// deleteIgnoreRule deletes the rule and unresolves the groups it ignored, it returns false
+// when the rule does not exist in the org of the user
+func (r *repo) deleteIgnoreRule(ctx context.Context, id string, at time.Time, by string) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	condition, args := common.OrgCondition(ctx)
+	res, err := tx.ExecContext(ctx, "DELETE FROM error_ignore_rules WHERE id = ? AND "+condition, append([]interface{}{id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE error_groups SET status = $1, ignore_rule_id = '', updated_at = $2, updated_by = $3 WHERE ignore_rule_id = $4",
+		StatusUnresolved, at, by, id)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// syncedUntil returns the time up to which the exceptions were synced, zero before the
+// first sync
+func (r *repo) syncedUntil(ctx context.Context) (time.Time, error) {
+	var until time.Time
+	err := r.db.GetContext(ctx, &until, "SELECT synced_until FROM error_tracking_sync WHERE id = 1")
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return time.Time{}, err
+	}
+	return until, nil
+}
+
+// defaultOrgId returns the id of the default org, the first org created, empty before the
+// first user registers
+func (r *repo) defaultOrgId(ctx context.Context) (string, error) {
+	var id string
+	err := r.db.GetContext(ctx, &id, "SELECT id FROM organizations ORDER BY created_at, rowid LIMIT 1")
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return "", err
+	}
+	return id, nil
+}
+
+func (r *repo) setSyncedUntil(ctx context.Context, until time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO error_tracking_sync (id, synced_until) VALUES (1, $1) ON CONFLICT(id) DO UPDATE SET synced_until = excluded.synced_until",
+		until)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// etagOf returns the entity tag of the JSON encoding of the value, the values of the same
+// content have the same tag
+func etagOf(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b = []byte(fmt.Sprintf("%v", v))
+	}
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// matchesETag checks if the value of an If-Match or If-None-Match header, a list of entity
+// tags or *, matches the tag of the resource, empty when the resource does not exist
+func matchesETag(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if etag != "" && (tag == "*" || tag == etag) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPreconditions evaluates the If-Match and If-None-Match headers of a request changing
+// the resource of the tag, empty when the resource does not exist. If-None-Match: * only
+// creates the resource
+func checkPreconditions(r *http.Request, etag string) *model.ApiError {
+	if header := r.Header.Get("If-Match"); header != "" && !matchesETag(header, etag) {
+		return &model.ApiError{Typ: model.ErrorPreconditionFailed, Err: fmt.Errorf("the resource has changed, its entity tag does not match If-Match")}
+	}
+	if header := r.Header.Get("If-None-Match"); header != "" && matchesETag(header, etag) {
+		return &model.ApiError{Typ: model.ErrorPreconditionFailed, Err: fmt.Errorf("the resource exists, its entity tag matches If-None-Match")}
+	}
+	return nil
+}
+
+// setETag sets the entity tag of the resource of the response
+func setETag(w http.ResponseWriter, etag string) {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+}
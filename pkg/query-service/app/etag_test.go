@@ -0,0 +1,43 @@
+package app
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestCheckPreconditions(t *testing.T) {
+	etag := etagOf(map[string]interface{}{"title": "API"})
+	assert.Equal(t, etag, etagOf(map[string]interface{}{"title": "API"}))
+	assert.NotEqual(t, etag, etagOf(map[string]interface{}{"title": "Hosts"}))
+
+	for _, tc := range []struct {
+		name    string
+		headers map[string]string
+		etag    string
+		failed  bool
+	}{
+		{"no preconditions", nil, etag, false},
+		{"matching tag", map[string]string{"If-Match": `"other", ` + etag}, etag, false},
+		{"changed resource", map[string]string{"If-Match": `"other"`}, etag, true},
+		{"any existing resource", map[string]string{"If-Match": "*"}, etag, false},
+		{"missing resource", map[string]string{"If-Match": "*"}, "", true},
+		{"create only", map[string]string{"If-None-Match": "*"}, "", false},
+		{"create only an existing resource", map[string]string{"If-None-Match": "*"}, etag, true},
+	} {
+		r := httptest.NewRequest("PUT", "/api/v1/dashboards/a", nil)
+		for name, value := range tc.headers {
+			r.Header.Set(name, value)
+		}
+		apiErr := checkPreconditions(r, tc.etag)
+		if !tc.failed {
+			assert.Nil(t, apiErr, tc.name)
+			continue
+		}
+		if assert.NotNil(t, apiErr, tc.name) {
+			assert.Equal(t, model.ErrorPreconditionFailed, apiErr.Type(), tc.name)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/app/events"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// ingestEvents receives a batch of structured events, e.g. the deploys from a pipeline or the
+// business events of a service. The senders authenticate with an ingestion key allowed to
+// send events
+func (aH *APIHandler) ingestEvents(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, events.MaxRequestSize))
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	key, apiErr := authorizeIngestion(r, model.IngestionSignalEvents, int64(len(body)))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	var reqs []events.EventRequest
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if len(reqs) == 0 || len(reqs) > events.MaxBatchSize {
+		RespondError(w, model.BadRequest(fmt.Errorf("a batch must have between 1 and %d events", events.MaxBatchSize)), nil)
+		return
+	}
+	for i := range reqs {
+		if err := reqs[i].Validate(); err != nil {
+			RespondError(w, model.BadRequest(fmt.Errorf("invalid event %d: %w", i, err)), nil)
+			return
+		}
+		if reqs[i].Attributes == nil {
+			reqs[i].Attributes = map[string]string{}
+		}
+		reqs[i].Attributes[constants.OrgIdAttribute] = key.OrgId
+	}
+
+	ids, err := aH.EventManager.Write(r.Context(), reqs, time.Now())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, map[string][]string{"ids": ids})
+}
+
+// listEvents lists the events, the latest first. The range is in milliseconds, name,
+// category and serviceName can be repeated and the attributes (key=value, repeated) must all
+// match
+func (aH *APIHandler) listEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	attributes, err := events.ParseAttributes(query["attribute"])
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	filter := events.Filter{EventFilter: v3.EventFilter{
+		Names:        query["name"],
+		Categories:   query["category"],
+		ServiceNames: query["serviceName"],
+		Environment:  query.Get("environment"),
+		Attributes:   attributes,
+	}}
+	for param, value := range map[string]*time.Time{"start": &filter.Start, "end": &filter.End} {
+		if s := query.Get(param); s != "" {
+			ms, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				RespondError(w, model.BadRequest(fmt.Errorf("%s param is not in correct timestamp format", param)), nil)
+				return
+			}
+			*value = time.UnixMilli(ms)
+		}
+	}
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil {
+			RespondError(w, model.BadRequest(fmt.Errorf("invalid limit %q", s)), nil)
+			return
+		}
+		filter.Limit = limit
+	}
+	if err := filter.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	filter.OrgScope = scope
+
+	list, err := aH.EventManager.Events(r.Context(), &filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, list)
+}
+
+// aggregateEvents counts the events, or aggregates the values of one of their attributes,
+// by step
+func (aH *APIHandler) aggregateEvents(w http.ResponseWriter, r *http.Request) {
+	var req events.AggregateQuery
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	req.Filter.OrgScope = scope
+
+	series, err := aH.EventManager.Aggregate(r.Context(), &req)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, map[string]interface{}{"step": req.Step, "series": series})
+}
@@ -0,0 +1,333 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+const (
+	eventsDB    = "signoz_events"
+	eventsTable = "distributed_events"
+
+	// MaxMarkers is the number of events returned with the query range results
+	MaxMarkers = 200
+
+	// CorrelationWindow is how long before an alert the events of its service are annotated
+	// on the alert
+	CorrelationWindow = 30 * time.Minute
+)
+
+// serviceKeys are the labels of the alerts holding the service name
+var serviceKeys = []string{"service.name", "service_name", "serviceName"}
+
+type ManagerOptions struct {
+	Conn clickhouse.Conn
+}
+
+// Manager stores the structured events sent by the services in ClickHouse, lists and
+// aggregates them, overlays them on the charts and annotates the alerts with them
+type Manager struct {
+	conn clickhouse.Conn
+}
+
+func NewManager(opts ManagerOptions) *Manager {
+	return &Manager{conn: opts.Conn}
+}
+
+// Write stores the events, the events without a timestamp are timestamped now. It returns
+// the ids of the events
+func (m *Manager) Write(ctx context.Context, reqs []EventRequest, now time.Time) ([]string, error) {
+	statement, err := m.conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (timestamp, id, name, category, "+
+		"service_name, environment, message, attributes)", eventsDB, eventsTable))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't prepare the events batch: %w", err)
+	}
+	defer statement.Abort()
+
+	ids := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		timestamp := now
+		if req.Timestamp > 0 {
+			timestamp = time.UnixMilli(req.Timestamp)
+		}
+		attributes := req.Attributes
+		if attributes == nil {
+			attributes = map[string]string{}
+		}
+		id := uuid.NewString()
+		if err := statement.Append(timestamp, id, req.Name, req.Category, req.ServiceName, req.Environment,
+			req.Message, attributes); err != nil {
+			return nil, fmt.Errorf("couldn't append an event: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := statement.Send(); err != nil {
+		return nil, fmt.Errorf("couldn't write the events: %w", err)
+	}
+	return ids, nil
+}
+
+// where returns the conditions selecting the events of the filter in the range, with their
+// arguments
+func where(f *v3.EventFilter, start, end time.Time) (string, []interface{}) {
+	conditions := []string{"timestamp >= $1", "timestamp <= $2"}
+	args := []interface{}{start, end}
+	in := func(column string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		args = append(args, values)
+		conditions = append(conditions, fmt.Sprintf("has($%d, %s)", len(args), column))
+	}
+	in("name", f.Names)
+	in("category", f.Categories)
+	in("service_name", f.ServiceNames)
+	if f.Environment != "" {
+		args = append(args, f.Environment)
+		conditions = append(conditions, fmt.Sprintf("environment = $%d", len(args)))
+	}
+	keys := make([]string, 0, len(f.Attributes))
+	for key := range f.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, key, f.Attributes[key])
+		conditions = append(conditions, fmt.Sprintf("attributes[$%d] = $%d", len(args)-1, len(args)))
+	}
+	if f.OrgScope != nil {
+		// the events sent before the orgs were added have no org and belong to the default org
+		not := ""
+		if f.OrgScope.Exclude {
+			not = "NOT "
+		}
+		args = append(args, f.OrgScope.OrgIds, constants.OrgIdAttribute)
+		conditions = append(conditions, fmt.Sprintf("%shas($%d, attributes[$%d])", not, len(args)-1, len(args)))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// Events lists the events of the filter, the latest first
+func (m *Manager) Events(ctx context.Context, f *Filter) ([]Event, error) {
+	conditions, args := where(&f.EventFilter, f.Start, f.End)
+	query := fmt.Sprintf(`SELECT id, timestamp, name, toString(category) AS category, toString(service_name) AS service_name,
+		toString(environment) AS environment, message, attributes
+		FROM %s.%s WHERE %s ORDER BY timestamp DESC LIMIT %d`, eventsDB, eventsTable, conditions, f.Limit)
+
+	events := []Event{}
+	if err := m.conn.Select(ctx, &events, query, args...); err != nil {
+		zap.L().Error("Error while listing the events", zap.Error(err))
+		return nil, err
+	}
+	return events, nil
+}
+
+// aggregateQuery returns the query aggregating the events by step and by the keys of the
+// group by, with its arguments
+func aggregateQuery(q *AggregateQuery) (string, []interface{}) {
+	conditions, args := where(&q.Filter, time.UnixMilli(q.Start), time.UnixMilli(q.End))
+	attribute := func(key string) string {
+		args = append(args, key)
+		return fmt.Sprintf("attributes[$%d]", len(args))
+	}
+
+	value := "toFloat64(count())"
+	if q.Aggregation != AggregationCount {
+		expr := attribute(q.Attribute)
+		value = fmt.Sprintf("%s(toFloat64OrZero(%s))", q.Aggregation, expr)
+		conditions += fmt.Sprintf(" AND toFloat64OrNull(%s) IS NOT NULL", expr)
+	}
+
+	selects := []string{fmt.Sprintf("toStartOfInterval(timestamp, INTERVAL %d SECOND) AS ts", q.Step)}
+	groupBy := []string{"ts"}
+	for i, key := range q.GroupBy {
+		expr, ok := fields[key]
+		if ok {
+			expr = fmt.Sprintf("toString(%s)", expr)
+		} else {
+			expr = attribute(key)
+		}
+		selects = append(selects, fmt.Sprintf("%s AS g%d", expr, i))
+		groupBy = append(groupBy, fmt.Sprintf("g%d", i))
+	}
+	selects = append(selects, value+" AS value")
+
+	return fmt.Sprintf("SELECT %s FROM %s.%s WHERE %s GROUP BY %s ORDER BY %s",
+		strings.Join(selects, ", "), eventsDB, eventsTable, conditions,
+		strings.Join(groupBy, ", "), strings.Join(groupBy, ", ")), args
+}
+
+// Aggregate aggregates the events of the query by step, a series per values of the group by
+func (m *Manager) Aggregate(ctx context.Context, q *AggregateQuery) ([]*v3.Series, error) {
+	query, args := aggregateQuery(q)
+	rows, err := m.conn.Query(ctx, query, args...)
+	if err != nil {
+		zap.L().Error("Error while aggregating the events", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	seriesByKey := map[string]*v3.Series{}
+	series := []*v3.Series{}
+	for rows.Next() {
+		var ts time.Time
+		var value float64
+		groups := make([]string, len(q.GroupBy))
+		dest := []interface{}{&ts}
+		for i := range groups {
+			dest = append(dest, &groups[i])
+		}
+		dest = append(dest, &value)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		key := strings.Join(groups, "\x00")
+		s, ok := seriesByKey[key]
+		if !ok {
+			s = &v3.Series{Labels: map[string]string{}, Points: []v3.Point{}}
+			for i, k := range q.GroupBy {
+				s.Labels[k] = groups[i]
+				s.LabelsArray = append(s.LabelsArray, map[string]string{k: groups[i]})
+			}
+			seriesByKey[key] = s
+			series = append(series, s)
+		}
+		s.Points = append(s.Points, v3.Point{Timestamp: ts.UnixMilli(), Value: value})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// Markers returns the events selected by the events filter of the query range request in
+// its range, none when the request has no events filter
+func (m *Manager) Markers(ctx context.Context, params *v3.QueryRangeParamsV3) []v3.EventMarker {
+	if m == nil || params.Events == nil {
+		return nil
+	}
+	events, err := m.Events(ctx, &Filter{
+		EventFilter: *params.Events,
+		Start:       time.UnixMilli(params.Start),
+		End:         time.UnixMilli(params.End),
+		Limit:       MaxMarkers,
+	})
+	if err != nil {
+		zap.L().Error("failed to list the event markers", zap.Error(err))
+		return nil
+	}
+	markers := make([]v3.EventMarker, 0, len(events))
+	for _, e := range events {
+		markers = append(markers, v3.EventMarker{
+			Id:          e.Id,
+			Name:        e.Name,
+			Category:    e.Category,
+			ServiceName: e.ServiceName,
+			Environment: e.Environment,
+			Message:     e.Message,
+			Attributes:  e.Attributes,
+			Timestamp:   e.Timestamp.UnixMilli(),
+		})
+	}
+	return markers
+}
+
+// AnnotateAlerts annotates the alerts with the latest event of their service in the
+// correlation window before they became active, it is a rules.NotifyFunc
+func (m *Manager) AnnotateAlerts(ctx context.Context, expr string, alerts ...*rules.Alert) {
+	if m == nil || len(alerts) == 0 {
+		return
+	}
+	services := map[string]struct{}{}
+	var start, end time.Time
+	for _, alert := range alerts {
+		service := serviceOf(alert)
+		if service == "" || alert.ActiveAt.IsZero() {
+			continue
+		}
+		services[service] = struct{}{}
+		if start.IsZero() || alert.ActiveAt.Add(-CorrelationWindow).Before(start) {
+			start = alert.ActiveAt.Add(-CorrelationWindow)
+		}
+		if alert.ActiveAt.After(end) {
+			end = alert.ActiveAt
+		}
+	}
+	if len(services) == 0 {
+		return
+	}
+	f := &Filter{Start: start, End: end, Limit: MaxLimit}
+	for service := range services {
+		f.ServiceNames = append(f.ServiceNames, service)
+	}
+	events, err := m.Events(ctx, f)
+	if err != nil {
+		zap.L().Error("failed to list the events of the alerts", zap.Error(err))
+		return
+	}
+
+	for _, alert := range alerts {
+		service := serviceOf(alert)
+		if service == "" || alert.ActiveAt.IsZero() {
+			continue
+		}
+		annotations := map[string]string{}
+		if alert.Annotations != nil {
+			annotations = alert.Annotations.Map()
+		}
+		delete(annotations, labels.AlertEventLabel)
+		if e := latestEvent(events, service, alert.ActiveAt); e != nil {
+			annotations[labels.AlertEventLabel] = fmt.Sprintf("%s event %s of %s %s before",
+				e.Category, e.Name, e.ServiceName, humanizeDuration(alert.ActiveAt.Sub(e.Timestamp)))
+		}
+		alert.Annotations = labels.FromMap(annotations)
+	}
+}
+
+// latestEvent returns the latest of the events, the latest first, of the service in the
+// correlation window before the time
+func latestEvent(events []Event, service string, at time.Time) *Event {
+	for i := range events {
+		e := &events[i]
+		if e.ServiceName != service || e.Timestamp.After(at) || e.Timestamp.Before(at.Add(-CorrelationWindow)) {
+			continue
+		}
+		return e
+	}
+	return nil
+}
+
+func serviceOf(alert *rules.Alert) string {
+	if alert.Labels == nil {
+		return ""
+	}
+	for _, key := range serviceKeys {
+		if service := alert.Labels.Get(key); service != "" {
+			return service
+		}
+	}
+	return ""
+}
+
+func humanizeDuration(d time.Duration) string {
+	switch minutes := int(d.Minutes()); {
+	case minutes < 1:
+		return "less than a minute"
+	case minutes == 1:
+		return "1 minute"
+	default:
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestWhere(t *testing.T) {
+	start, end := time.UnixMilli(1000), time.UnixMilli(2000)
+	conditions, args := where(&v3.EventFilter{
+		Names:       []string{"order.placed", "order.cancelled"},
+		Environment: "prod",
+		Attributes:  map[string]string{"region": "eu", "plan": "pro"},
+	}, start, end)
+
+	require.Equal(t, "timestamp >= $1 AND timestamp <= $2 AND has($3, name) AND environment = $4 AND "+
+		"attributes[$5] = $6 AND attributes[$7] = $8", conditions)
+	require.Equal(t, []interface{}{start, end, []string{"order.placed", "order.cancelled"}, "prod",
+		"plan", "pro", "region", "eu"}, args)
+
+	// the default org reads the events which are not sent for another org
+	conditions, args = where(&v3.EventFilter{
+		OrgScope: &model.OrgScope{Exclude: true, OrgIds: []string{"org1"}},
+	}, start, end)
+	require.Equal(t, "timestamp >= $1 AND timestamp <= $2 AND NOT has($3, attributes[$4])", conditions)
+	require.Equal(t, []interface{}{start, end, []string{"org1"}, "signoz.org.id"}, args)
+}
+
+func TestAggregateQuery(t *testing.T) {
+	q := &AggregateQuery{
+		Start:       1000,
+		End:         3600 * 1000,
+		Filter:      v3.EventFilter{Categories: []string{CategoryBusiness}},
+		Aggregation: AggregationSum,
+		Attribute:   "amount",
+		GroupBy:     []string{"serviceName", "plan"},
+	}
+	require.NoError(t, q.Validate())
+	require.Equal(t, int64(60), q.Step)
+
+	query, args := aggregateQuery(q)
+	require.Equal(t, "SELECT toStartOfInterval(timestamp, INTERVAL 60 SECOND) AS ts, toString(service_name) AS g0, "+
+		"attributes[$5] AS g1, sum(toFloat64OrZero(attributes[$4])) AS value FROM signoz_events.distributed_events "+
+		"WHERE timestamp >= $1 AND timestamp <= $2 AND has($3, category) AND toFloat64OrNull(attributes[$4]) IS NOT NULL "+
+		"GROUP BY ts, g0, g1 ORDER BY ts, g0, g1", query)
+	require.Equal(t, []interface{}{time.UnixMilli(1000), time.UnixMilli(3600 * 1000), []string{CategoryBusiness}, "amount", "plan"}, args)
+}
+
+func TestValidate(t *testing.T) {
+	req := &EventRequest{Name: "checkout.release"}
+	require.NoError(t, req.Validate())
+	require.Equal(t, CategoryCustom, req.Category)
+	require.Error(t, (&EventRequest{Name: "checkout.release", Category: "incident"}).Validate())
+	require.Error(t, (&EventRequest{Category: CategoryRelease}).Validate())
+
+	require.Error(t, (&AggregateQuery{Start: 1000, End: 2000, Aggregation: AggregationAvg}).Validate())
+	require.Error(t, (&AggregateQuery{Start: 2000, End: 1000}).Validate())
+
+	// the step is raised to bound the points
+	q := &AggregateQuery{Start: 1000, End: 1000 + 30*24*3600*1000, Step: 60}
+	require.NoError(t, q.Validate())
+	require.Equal(t, int64(1728), q.Step)
+	require.Equal(t, AggregationCount, q.Aggregation)
+}
+
+func TestLatestEvent(t *testing.T) {
+	at := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Id: "4", ServiceName: "checkout", Timestamp: at.Add(time.Minute)},
+		{Id: "3", ServiceName: "cart", Timestamp: at.Add(-time.Minute)},
+		{Id: "2", ServiceName: "checkout", Timestamp: at.Add(-10 * time.Minute)},
+		{Id: "1", ServiceName: "checkout", Timestamp: at.Add(-20 * time.Minute)},
+	}
+	require.Equal(t, "2", latestEvent(events, "checkout", at).Id)
+	require.Nil(t, latestEvent(events, "checkout", at.Add(-time.Hour)))
+	require.Nil(t, latestEvent(events, "payment", at))
+}
@@ -0,0 +1,171 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+
+	// MaxBatchSize is the most events of an ingestion request
+	MaxBatchSize = 1000
+	// MaxRequestSize is the size limit of the ingestion requests
+	MaxRequestSize = 5 << 20
+
+	// maxPoints bounds the points of the series of an aggregation, the step is raised above it
+	maxPoints = 1500
+)
+
+// Categories of the events
+const (
+	CategoryDeploy   = "deploy"
+	CategoryRelease  = "release"
+	CategoryBusiness = "business"
+	CategoryCustom   = "custom"
+)
+
+// Aggregations of the events
+const (
+	AggregationCount = "count"
+	AggregationSum   = "sum"
+	AggregationAvg   = "avg"
+	AggregationMin   = "min"
+	AggregationMax   = "max"
+)
+
+// fields are the columns of the events the filters and the group by can refer to, the other
+// keys are attributes
+var fields = map[string]string{
+	"name":        "name",
+	"category":    "category",
+	"serviceName": "service_name",
+	"environment": "environment",
+}
+
+// Event is a discrete structured event, e.g. a deploy, the release of a feature flag or an
+// order placed
+type Event struct {
+	Id          string            `json:"id" ch:"id"`
+	Timestamp   time.Time         `json:"timestamp" ch:"timestamp"`
+	Name        string            `json:"name" ch:"name"`
+	Category    string            `json:"category" ch:"category"`
+	ServiceName string            `json:"serviceName" ch:"service_name"`
+	Environment string            `json:"environment" ch:"environment"`
+	Message     string            `json:"message" ch:"message"`
+	Attributes  map[string]string `json:"attributes" ch:"attributes"`
+}
+
+// EventRequest is an event sent by a service, the timestamp is in milliseconds and now by
+// default
+type EventRequest struct {
+	Name        string            `json:"name"`
+	Category    string            `json:"category"`
+	ServiceName string            `json:"serviceName"`
+	Environment string            `json:"environment"`
+	Message     string            `json:"message"`
+	Attributes  map[string]string `json:"attributes"`
+	Timestamp   int64             `json:"timestamp"`
+}
+
+func (r *EventRequest) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch r.Category {
+	case "":
+		r.Category = CategoryCustom
+	case CategoryDeploy, CategoryRelease, CategoryBusiness, CategoryCustom:
+	default:
+		return fmt.Errorf("invalid category %q, expected one of deploy, release, business and custom", r.Category)
+	}
+	if r.Timestamp < 0 {
+		return fmt.Errorf("timestamp can't be negative")
+	}
+	return nil
+}
+
+// ParseAttributes parses the attribute filters of the list requests, in the key=value format
+func ParseAttributes(values []string) (map[string]string, error) {
+	attributes := map[string]string{}
+	for _, value := range values {
+		key, v, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid attribute %q, must be key=value", value)
+		}
+		attributes[key] = v
+	}
+	return attributes, nil
+}
+
+// Filter lists the events in the range, the latest first
+type Filter struct {
+	v3.EventFilter
+	Start time.Time
+	End   time.Time
+	Limit int
+}
+
+func (f *Filter) Validate() error {
+	if f.Start.IsZero() || f.End.IsZero() || !f.Start.Before(f.End) {
+		return fmt.Errorf("start and end are required and start must be before end")
+	}
+	if f.Limit < 0 || f.Limit > MaxLimit {
+		return fmt.Errorf("limit must be between 0 and %d", MaxLimit)
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultLimit
+	}
+	return nil
+}
+
+// AggregateQuery aggregates the events selected by the filter by step, the range is in
+// milliseconds and the step in seconds
+type AggregateQuery struct {
+	Start  int64          `json:"start"`
+	End    int64          `json:"end"`
+	Step   int64          `json:"step"`
+	Filter v3.EventFilter `json:"filter"`
+	// Aggregation is count by default, the others aggregate the numeric values of the
+	// attribute
+	Aggregation string `json:"aggregation"`
+	Attribute   string `json:"attribute,omitempty"`
+	// GroupBy are fields, e.g. name or serviceName, or attributes the series are split by
+	GroupBy []string `json:"groupBy,omitempty"`
+}
+
+func (q *AggregateQuery) Validate() error {
+	if q.Start <= 0 || q.End <= 0 || q.Start >= q.End {
+		return fmt.Errorf("start and end are required and start must be before end")
+	}
+	switch q.Aggregation {
+	case "":
+		q.Aggregation = AggregationCount
+	case AggregationCount:
+	case AggregationSum, AggregationAvg, AggregationMin, AggregationMax:
+		if q.Attribute == "" {
+			return fmt.Errorf("attribute is required for the %s aggregation", q.Aggregation)
+		}
+	default:
+		return fmt.Errorf("invalid aggregation %q, expected one of count, sum, avg, min and max", q.Aggregation)
+	}
+	if q.Step < 0 {
+		return fmt.Errorf("step can't be negative")
+	}
+	if minStep := (q.End - q.Start) / 1000 / maxPoints; q.Step < minStep {
+		q.Step = minStep
+	}
+	if q.Step < 60 {
+		q.Step = 60
+	}
+	for _, key := range q.GroupBy {
+		if key == "" {
+			return fmt.Errorf("group by keys can't be empty")
+		}
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package explorer
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 )
 
@@ -27,8 +30,16 @@ type SavedView struct {
 	Tags       string    `json:"tags" db:"tags"`
 	Data       string    `json:"data" db:"data"`
 	ExtraData  string    `json:"extra_data" db:"extra_data"`
+	Visibility string    `json:"visibility" db:"visibility"`
+	Columns    string    `json:"columns" db:"columns"`
+	TimeRange  string    `json:"time_range" db:"time_range"`
+	// OrgId is the org of the view, empty for the views of the default org created before
+	// the orgs were added
+	OrgId string `json:"org_id" db:"org_id"`
 }
 
+var ErrViewNotFound = errors.New("saved view not found")
+
 // InitWithDSN sets up setting up the connection pool global variable.
 func InitWithDSN(dataSourceName string) (*sqlx.DB, error) {
 	var err error
@@ -57,6 +68,44 @@ func InitWithDSN(dataSourceName string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("error in creating saved views table: %s", err.Error())
 	}
 
+	// sqlite does not support "IF NOT EXISTS", the views created before the
+	// visibility was introduced stay shared with the org
+	visibility := `ALTER TABLE saved_views ADD COLUMN visibility TEXT NOT NULL DEFAULT 'org';`
+	_, err = db.Exec(visibility)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column visibility to saved_views table: %s", err.Error())
+	}
+
+	columns := `ALTER TABLE saved_views ADD COLUMN columns TEXT NOT NULL DEFAULT '[]';`
+	_, err = db.Exec(columns)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column columns to saved_views table: %s", err.Error())
+	}
+
+	timeRange := `ALTER TABLE saved_views ADD COLUMN time_range TEXT NOT NULL DEFAULT '';`
+	_, err = db.Exec(timeRange)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column time_range to saved_views table: %s", err.Error())
+	}
+
+	orgId := `ALTER TABLE saved_views ADD COLUMN org_id TEXT NOT NULL DEFAULT '';`
+	_, err = db.Exec(orgId)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column org_id to saved_views table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS saved_view_defaults (
+		user_email TEXT NOT NULL,
+		source_page TEXT NOT NULL,
+		view_uuid TEXT NOT NULL,
+		PRIMARY KEY (user_email, source_page)
+	);`
+
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating saved view defaults table: %s", err.Error())
+	}
+
 	return db, nil
 }
 
@@ -64,6 +113,71 @@ func InitWithDB(sqlDB *sqlx.DB) {
 	db = sqlDB
 }
 
+func toSavedView(view SavedView) (*v3.SavedView, error) {
+	var compositeQuery v3.CompositeQuery
+	err := json.Unmarshal([]byte(view.Data), &compositeQuery)
+	if err != nil {
+		return nil, fmt.Errorf("error in unmarshalling explorer query data: %s", err.Error())
+	}
+
+	columns := []v3.AttributeKey{}
+	if view.Columns != "" {
+		err = json.Unmarshal([]byte(view.Columns), &columns)
+		if err != nil {
+			return nil, fmt.Errorf("error in unmarshalling saved view columns: %s", err.Error())
+		}
+	}
+
+	var timeRange *v3.SavedViewTimeRange
+	if view.TimeRange != "" {
+		timeRange = &v3.SavedViewTimeRange{}
+		err = json.Unmarshal([]byte(view.TimeRange), timeRange)
+		if err != nil {
+			return nil, fmt.Errorf("error in unmarshalling saved view time range: %s", err.Error())
+		}
+	}
+
+	return &v3.SavedView{
+		UUID:           view.UUID,
+		Name:           view.Name,
+		Category:       view.Category,
+		CreatedAt:      view.CreatedAt,
+		CreatedBy:      view.CreatedBy,
+		UpdatedAt:      view.UpdatedAt,
+		UpdatedBy:      view.UpdatedBy,
+		SourcePage:     view.SourcePage,
+		Tags:           strings.Split(view.Tags, ","),
+		CompositeQuery: &compositeQuery,
+		ExtraData:      view.ExtraData,
+		Visibility:     v3.SavedViewVisibility(view.Visibility),
+		Columns:        columns,
+		TimeRange:      timeRange,
+	}, nil
+}
+
+// marshalViewSettings serializes the columns and the time range of the view for storage
+func marshalViewSettings(view v3.SavedView) (string, string, error) {
+	columns := view.Columns
+	if columns == nil {
+		columns = []v3.AttributeKey{}
+	}
+	columnsData, err := json.Marshal(columns)
+	if err != nil {
+		return "", "", fmt.Errorf("error in marshalling saved view columns: %s", err.Error())
+	}
+
+	timeRange := ""
+	if view.TimeRange != nil {
+		timeRangeData, err := json.Marshal(view.TimeRange)
+		if err != nil {
+			return "", "", fmt.Errorf("error in marshalling saved view time range: %s", err.Error())
+		}
+		timeRange = string(timeRangeData)
+	}
+	return string(columnsData), timeRange, nil
+}
+
+// GetViews returns the views of all the users
 func GetViews() ([]*v3.SavedView, error) {
 	var views []SavedView
 	err := db.Select(&views, "SELECT * FROM saved_views")
@@ -73,59 +187,49 @@ func GetViews() ([]*v3.SavedView, error) {
 
 	var savedViews []*v3.SavedView
 	for _, view := range views {
-		var compositeQuery v3.CompositeQuery
-		err = json.Unmarshal([]byte(view.Data), &compositeQuery)
+		savedView, err := toSavedView(view)
 		if err != nil {
-			return nil, fmt.Errorf("error in unmarshalling explorer query data: %s", err.Error())
+			return nil, err
 		}
-		savedViews = append(savedViews, &v3.SavedView{
-			UUID:           view.UUID,
-			Name:           view.Name,
-			Category:       view.Category,
-			CreatedAt:      view.CreatedAt,
-			CreatedBy:      view.CreatedBy,
-			UpdatedAt:      view.UpdatedAt,
-			UpdatedBy:      view.UpdatedBy,
-			Tags:           strings.Split(view.Tags, ","),
-			SourcePage:     view.SourcePage,
-			CompositeQuery: &compositeQuery,
-			ExtraData:      view.ExtraData,
-		})
+		savedViews = append(savedViews, savedView)
 	}
 	return savedViews, nil
 }
 
-func GetViewsForFilters(sourcePage string, name string, category string) ([]*v3.SavedView, error) {
+// GetViewsForFilters returns the views shared with the org of the user and the private views
+// of the user in the org, the default view of the user for the source page is marked
+func GetViewsForFilters(ctx context.Context, sourcePage string, name string, category string) ([]*v3.SavedView, error) {
+	email, err := auth.GetEmailFromJwt(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var views []SavedView
-	var err error
+	condition, args := common.OrgCondition(ctx)
 	if len(category) == 0 {
-		err = db.Select(&views, "SELECT * FROM saved_views WHERE source_page = ? AND name LIKE ?", sourcePage, "%"+name+"%")
+		err = db.Select(&views, "SELECT * FROM saved_views WHERE source_page = ? AND name LIKE ? AND (visibility = ? OR created_by = ?) AND "+condition,
+			append([]interface{}{sourcePage, "%" + name + "%", v3.SavedViewVisibilityOrg, email}, args...)...)
 	} else {
-		err = db.Select(&views, "SELECT * FROM saved_views WHERE source_page = ? AND category LIKE ? AND name LIKE ?", sourcePage, "%"+category+"%", "%"+name+"%")
+		err = db.Select(&views, "SELECT * FROM saved_views WHERE source_page = ? AND category LIKE ? AND name LIKE ? AND (visibility = ? OR created_by = ?) AND "+condition,
+			append([]interface{}{sourcePage, "%" + category + "%", "%" + name + "%", v3.SavedViewVisibilityOrg, email}, args...)...)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("error in getting saved views: %s", err.Error())
 	}
 
+	defaultUUID, err := getDefaultViewUUID(email, sourcePage)
+	if err != nil {
+		return nil, err
+	}
+
 	var savedViews []*v3.SavedView
 	for _, view := range views {
-		var compositeQuery v3.CompositeQuery
-		err = json.Unmarshal([]byte(view.Data), &compositeQuery)
+		savedView, err := toSavedView(view)
 		if err != nil {
-			return nil, fmt.Errorf("error in unmarshalling explorer query data: %s", err.Error())
+			return nil, err
 		}
-		savedViews = append(savedViews, &v3.SavedView{
-			UUID:           view.UUID,
-			Name:           view.Name,
-			CreatedAt:      view.CreatedAt,
-			CreatedBy:      view.CreatedBy,
-			UpdatedAt:      view.UpdatedAt,
-			UpdatedBy:      view.UpdatedBy,
-			Tags:           strings.Split(view.Tags, ","),
-			SourcePage:     view.SourcePage,
-			CompositeQuery: &compositeQuery,
-			ExtraData:      view.ExtraData,
-		})
+		savedView.IsDefault = savedView.UUID == defaultUUID
+		savedViews = append(savedViews, savedView)
 	}
 	return savedViews, nil
 }
@@ -135,6 +239,10 @@ func CreateView(ctx context.Context, view v3.SavedView) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("error in marshalling explorer query data: %s", err.Error())
 	}
+	columns, timeRange, err := marshalViewSettings(view)
+	if err != nil {
+		return "", err
+	}
 
 	uuid_ := view.UUID
 
@@ -153,7 +261,7 @@ func CreateView(ctx context.Context, view v3.SavedView) (string, error) {
 	updatedBy := email
 
 	_, err = db.Exec(
-		"INSERT INTO saved_views (uuid, name, category, created_at, created_by, updated_at, updated_by, source_page, tags, data, extra_data) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		"INSERT INTO saved_views (uuid, name, category, created_at, created_by, updated_at, updated_by, source_page, tags, data, extra_data, visibility, columns, time_range, org_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		uuid_,
 		view.Name,
 		view.Category,
@@ -165,6 +273,10 @@ func CreateView(ctx context.Context, view v3.SavedView) (string, error) {
 		strings.Join(view.Tags, ","),
 		data,
 		view.ExtraData,
+		view.Visibility,
+		columns,
+		timeRange,
+		common.GetOrgIdFromContext(ctx),
 	)
 	if err != nil {
 		return "", fmt.Errorf("error in creating saved view: %s", err.Error())
@@ -172,31 +284,49 @@ func CreateView(ctx context.Context, view v3.SavedView) (string, error) {
 	return uuid_, nil
 }
 
-func GetView(uuid_ string) (*v3.SavedView, error) {
+// GetView returns the view when it is shared with the org of the user or owned by the user
+func GetView(ctx context.Context, uuid_ string) (*v3.SavedView, error) {
+	email, err := auth.GetEmailFromJwt(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	view, err := getVisibleView(ctx, uuid_, email)
+	if err != nil {
+		return nil, err
+	}
+
+	savedView, err := toSavedView(*view)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultUUID, err := getDefaultViewUUID(email, savedView.SourcePage)
+	if err != nil {
+		return nil, err
+	}
+	savedView.IsDefault = savedView.UUID == defaultUUID
+	return savedView, nil
+}
+
+// getVisibleView returns the view of the org of the user when it is shared with the org or
+// owned by the user
+func getVisibleView(ctx context.Context, uuid_ string, email string) (*SavedView, error) {
 	var view SavedView
-	err := db.Get(&view, "SELECT * FROM saved_views WHERE uuid = ?", uuid_)
+	condition, args := common.OrgCondition(ctx)
+	err := db.Get(&view, "SELECT * FROM saved_views WHERE uuid = ? AND "+condition, append([]interface{}{uuid_}, args...)...)
+	if err == sql.ErrNoRows {
+		return nil, ErrViewNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error in getting saved view: %s", err.Error())
 	}
 
-	var compositeQuery v3.CompositeQuery
-	err = json.Unmarshal([]byte(view.Data), &compositeQuery)
-	if err != nil {
-		return nil, fmt.Errorf("error in unmarshalling explorer query data: %s", err.Error())
+	// the private views of the other users are not visible
+	if view.Visibility == string(v3.SavedViewVisibilityPrivate) && view.CreatedBy != email {
+		return nil, ErrViewNotFound
 	}
-	return &v3.SavedView{
-		UUID:           view.UUID,
-		Name:           view.Name,
-		Category:       view.Category,
-		CreatedAt:      view.CreatedAt,
-		CreatedBy:      view.CreatedBy,
-		UpdatedAt:      view.UpdatedAt,
-		UpdatedBy:      view.UpdatedBy,
-		SourcePage:     view.SourcePage,
-		Tags:           strings.Split(view.Tags, ","),
-		CompositeQuery: &compositeQuery,
-		ExtraData:      view.ExtraData,
-	}, nil
+	return &view, nil
 }
 
 func UpdateView(ctx context.Context, uuid_ string, view v3.SavedView) error {
@@ -204,27 +334,115 @@ func UpdateView(ctx context.Context, uuid_ string, view v3.SavedView) error {
 	if err != nil {
 		return fmt.Errorf("error in marshalling explorer query data: %s", err.Error())
 	}
+	columns, timeRange, err := marshalViewSettings(view)
+	if err != nil {
+		return err
+	}
 
 	email, err := auth.GetEmailFromJwt(ctx)
 	if err != nil {
 		return err
 	}
 
+	if _, err := getVisibleView(ctx, uuid_, email); err != nil {
+		return err
+	}
+
 	updatedAt := time.Now()
 	updatedBy := email
 
-	_, err = db.Exec("UPDATE saved_views SET updated_at = ?, updated_by = ?, name = ?, category = ?, source_page = ?, tags = ?, data = ?, extra_data = ? WHERE uuid = ?",
-		updatedAt, updatedBy, view.Name, view.Category, view.SourcePage, strings.Join(view.Tags, ","), data, view.ExtraData, uuid_)
+	_, err = db.Exec("UPDATE saved_views SET updated_at = ?, updated_by = ?, name = ?, category = ?, source_page = ?, tags = ?, data = ?, extra_data = ?, visibility = ?, columns = ?, time_range = ? WHERE uuid = ?",
+		updatedAt, updatedBy, view.Name, view.Category, view.SourcePage, strings.Join(view.Tags, ","), data, view.ExtraData, view.Visibility, columns, timeRange, uuid_)
 	if err != nil {
 		return fmt.Errorf("error in updating saved view: %s", err.Error())
 	}
 	return nil
 }
 
-func DeleteView(uuid_ string) error {
-	_, err := db.Exec("DELETE FROM saved_views WHERE uuid = ?", uuid_)
+func DeleteView(ctx context.Context, uuid_ string) error {
+	email, err := auth.GetEmailFromJwt(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := getVisibleView(ctx, uuid_, email); err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DELETE FROM saved_views WHERE uuid = ?", uuid_)
 	if err != nil {
 		return fmt.Errorf("error in deleting explorer query: %s", err.Error())
 	}
+
+	_, err = db.Exec("DELETE FROM saved_view_defaults WHERE view_uuid = ?", uuid_)
+	if err != nil {
+		return fmt.Errorf("error in deleting saved view defaults: %s", err.Error())
+	}
+	return nil
+}
+
+func getDefaultViewUUID(email string, sourcePage string) (string, error) {
+	var viewUUID string
+	err := db.Get(&viewUUID, "SELECT view_uuid FROM saved_view_defaults WHERE user_email = ? AND source_page = ?", email, sourcePage)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error in getting default saved view: %s", err.Error())
+	}
+	return viewUUID, nil
+}
+
+// GetDefaultView returns the default view of the user for the source page, nil when there is none
+func GetDefaultView(ctx context.Context, sourcePage string) (*v3.SavedView, error) {
+	email, err := auth.GetEmailFromJwt(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	viewUUID, err := getDefaultViewUUID(email, sourcePage)
+	if err != nil || viewUUID == "" {
+		return nil, err
+	}
+
+	view, err := GetView(ctx, viewUUID)
+	if errors.Is(err, ErrViewNotFound) {
+		// the view was made private by its creator
+		return nil, nil
+	}
+	return view, err
+}
+
+// SetDefaultView makes the view the default view of the user for its source page
+func SetDefaultView(ctx context.Context, uuid_ string) error {
+	email, err := auth.GetEmailFromJwt(ctx)
+	if err != nil {
+		return err
+	}
+
+	view, err := getVisibleView(ctx, uuid_, email)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("INSERT INTO saved_view_defaults (user_email, source_page, view_uuid) VALUES (?, ?, ?) ON CONFLICT (user_email, source_page) DO UPDATE SET view_uuid = excluded.view_uuid",
+		email, view.SourcePage, view.UUID)
+	if err != nil {
+		return fmt.Errorf("error in setting default saved view: %s", err.Error())
+	}
+	return nil
+}
+
+// ClearDefaultView removes the default view of the user for the source page
+func ClearDefaultView(ctx context.Context, sourcePage string) error {
+	email, err := auth.GetEmailFromJwt(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DELETE FROM saved_view_defaults WHERE user_email = ? AND source_page = ?", email, sourcePage)
+	if err != nil {
+		return fmt.Errorf("error in clearing default saved view: %s", err.Error())
+	}
 	return nil
 }
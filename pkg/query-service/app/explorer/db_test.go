@@ -0,0 +1,118 @@
+package explorer
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func userContext(t *testing.T, email string) context.Context {
+	jwt, err := auth.GenerateJWTForUser(&model.User{Id: email, Email: email})
+	require.Nil(t, err)
+	return context.WithValue(context.Background(), auth.AccessJwtKey, jwt.AccessJwt)
+}
+
+func testView(name string, visibility v3.SavedViewVisibility) v3.SavedView {
+	view := v3.SavedView{
+		Name:       name,
+		SourcePage: "logs",
+		Visibility: visibility,
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeBuilder,
+			PanelType: v3.PanelTypeList,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {
+					QueryName:         "A",
+					DataSource:        v3.DataSourceLogs,
+					AggregateOperator: v3.AggregateOperatorNoOp,
+					Expression:        "A",
+				},
+			},
+		},
+		Columns: []v3.AttributeKey{
+			{Key: "service.name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource},
+		},
+		TimeRange: &v3.SavedViewTimeRange{Type: v3.SavedViewTimeRangeRelative, Relative: "15m"},
+	}
+	return view
+}
+
+func TestSavedViewsVisibilityAndDefaults(t *testing.T) {
+	_, err := InitWithDSN(filepath.Join(t.TempDir(), "signoz.db"))
+	require.Nil(t, err)
+
+	alice := userContext(t, "alice@signoz.io")
+	bob := userContext(t, "bob@signoz.io")
+
+	shared := testView("errors", v3.SavedViewVisibilityOrg)
+	require.Nil(t, shared.Validate())
+	sharedID, err := CreateView(alice, shared)
+	require.Nil(t, err)
+
+	private := testView("my errors", v3.SavedViewVisibilityPrivate)
+	require.Nil(t, private.Validate())
+	privateID, err := CreateView(alice, private)
+	require.Nil(t, err)
+
+	views, err := GetViewsForFilters(alice, "logs", "", "")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(views))
+
+	views, err = GetViewsForFilters(bob, "logs", "", "")
+	require.Nil(t, err)
+	require.Equal(t, 1, len(views))
+	require.Equal(t, sharedID, views[0].UUID)
+	require.Equal(t, "service.name", views[0].Columns[0].Key)
+	require.Equal(t, "15m", views[0].TimeRange.Relative)
+
+	_, err = GetView(bob, privateID)
+	require.ErrorIs(t, err, ErrViewNotFound)
+	require.ErrorIs(t, DeleteView(bob, privateID), ErrViewNotFound)
+	require.ErrorIs(t, SetDefaultView(bob, privateID), ErrViewNotFound)
+
+	// the defaults are per user
+	require.Nil(t, SetDefaultView(bob, sharedID))
+	view, err := GetDefaultView(bob, "logs")
+	require.Nil(t, err)
+	require.Equal(t, sharedID, view.UUID)
+	require.True(t, view.IsDefault)
+
+	view, err = GetDefaultView(alice, "logs")
+	require.Nil(t, err)
+	require.Nil(t, view)
+
+	// making the view private hides it from the default of the other users
+	shared.Visibility = v3.SavedViewVisibilityPrivate
+	require.Nil(t, UpdateView(alice, sharedID, shared))
+	view, err = GetDefaultView(bob, "logs")
+	require.Nil(t, err)
+	require.Nil(t, view)
+
+	require.Nil(t, SetDefaultView(alice, sharedID))
+	require.Nil(t, DeleteView(alice, sharedID))
+	view, err = GetDefaultView(alice, "logs")
+	require.Nil(t, err)
+	require.Nil(t, view)
+}
+
+func TestSavedViewValidate(t *testing.T) {
+	view := testView("errors", "")
+	require.Nil(t, view.Validate())
+	require.Equal(t, v3.SavedViewVisibilityOrg, view.Visibility)
+
+	view = testView("errors", "public")
+	require.NotNil(t, view.Validate())
+
+	view = testView("errors", v3.SavedViewVisibilityOrg)
+	view.TimeRange = &v3.SavedViewTimeRange{Type: v3.SavedViewTimeRangeRelative, Relative: "15 minutes"}
+	require.NotNil(t, view.Validate())
+
+	view.TimeRange = &v3.SavedViewTimeRange{Type: v3.SavedViewTimeRangeAbsolute, Start: 2000, End: 1000}
+	require.NotNil(t, view.Validate())
+}
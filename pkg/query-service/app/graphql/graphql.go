@@ -0,0 +1,452 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// QueryType is the name of the root type of the queries
+const QueryType = "Query"
+
+// Resolver returns the result of a field of the query type for its arguments. The result is
+// converted to JSON and the fields are selected on the JSON objects, by their JSON names
+type Resolver func(ctx context.Context, args map[string]interface{}) (interface{}, error)
+
+// Field is a field of the query type
+type Field struct {
+	// Type is the name of the type of the results, returned for __typename
+	Type string
+	// Arguments are the arguments of the field, true for the required arguments
+	Arguments map[string]bool
+	Resolve   Resolver
+}
+
+// Schema is the fields of the query type, by name. The fields of the results are not
+// typed, the fields missing from the results are null and the objects selected without
+// fields are returned whole
+type Schema map[string]Field
+
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Response is the result of a request, the data is not set when the request is invalid
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// orderedMap is an object of the response, its fields are in the order of the selections
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: map[string]interface{}{}}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		encodedValue, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(encodedValue)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// collectedField is a field of a selection set, the fields with the same response key are
+// merged and their selections combined
+type collectedField struct {
+	key        string
+	name       string
+	arguments  map[string]interface{}
+	selections []*selection
+}
+
+type executor struct {
+	doc       *document
+	variables map[string]interface{}
+	errors    []Error
+}
+
+// Execute executes the query of the request, the errors of the fields are reported with
+// their path and the other fields are still resolved
+func (s Schema) Execute(ctx context.Context, req Request) Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return errorResponse(err)
+	}
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if op.kind != "query" {
+		return errorResponse(fmt.Errorf("only the queries are supported, not the %ss", op.kind))
+	}
+	variables, err := coerceVariables(op, req.Variables)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	e := &executor{doc: doc, variables: variables}
+	fields, err := e.collectFields(op.selections, map[string]bool{})
+	if err != nil {
+		return errorResponse(err)
+	}
+	arguments := make([]map[string]interface{}, len(fields))
+	for i, f := range fields {
+		if f.name == "__typename" {
+			continue
+		}
+		field, ok := s[f.name]
+		if !ok {
+			return errorResponse(fmt.Errorf("cannot query field %q on type %q", f.name, QueryType))
+		}
+		if arguments[i], err = e.arguments(f, field); err != nil {
+			return errorResponse(err)
+		}
+		if err := e.validate(f.selections); err != nil {
+			return errorResponse(err)
+		}
+	}
+
+	data := newOrderedMap()
+	for i, f := range fields {
+		if f.name == "__typename" {
+			data.set(f.key, QueryType)
+			continue
+		}
+		field := s[f.name]
+		path := []interface{}{f.key}
+		result, err := field.Resolve(ctx, arguments[i])
+		if err != nil {
+			data.set(f.key, nil)
+			e.errors = append(e.errors, Error{Message: err.Error(), Path: path})
+			continue
+		}
+		value, err := normalize(result)
+		if err != nil {
+			data.set(f.key, nil)
+			e.errors = append(e.errors, Error{Message: err.Error(), Path: path})
+			continue
+		}
+		data.set(f.key, e.complete(value, field.Type, f.selections, path))
+	}
+	return Response{Data: data, Errors: e.errors}
+}
+
+func errorResponse(err error) Response {
+	return Response{Errors: []Error{{Message: err.Error()}}}
+}
+
+// operation returns the operation to execute, the name is required when the document has
+// several operations
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, fmt.Errorf("the operation name is required for the queries with several operations")
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// coerceVariables applies the default values of the variables and checks the required
+// variables are set
+func coerceVariables(op *operation, values map[string]interface{}) (map[string]interface{}, error) {
+	variables := map[string]interface{}{}
+	for _, v := range op.variables {
+		value, ok := values[v.name]
+		if !ok && v.hasDefault {
+			value = v.defaultValue
+		}
+		if value == nil && v.required {
+			return nil, fmt.Errorf("the variable $%s is required", v.name)
+		}
+		variables[v.name] = value
+	}
+	return variables, nil
+}
+
+// value replaces the variables of the value by their values
+func (e *executor) value(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case variable:
+		value, ok := e.variables[string(v)]
+		if !ok {
+			return nil, fmt.Errorf("the variable $%s is not defined", v)
+		}
+		return value, nil
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			value, err := e.value(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case map[string]interface{}:
+		values := map[string]interface{}{}
+		for key, item := range v {
+			value, err := e.value(item)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = value
+		}
+		return values, nil
+	default:
+		return v, nil
+	}
+}
+
+// arguments returns the values of the arguments of the field, after checking them against the
+// arguments of the field of the schema
+func (e *executor) arguments(f *collectedField, field Field) (map[string]interface{}, error) {
+	arguments := map[string]interface{}{}
+	for name, v := range f.arguments {
+		if _, ok := field.Arguments[name]; !ok {
+			return nil, fmt.Errorf("unknown argument %q on the field %q", name, f.name)
+		}
+		value, err := e.value(v)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			arguments[name] = value
+		}
+	}
+	for name, required := range field.Arguments {
+		if _, ok := arguments[name]; required && !ok {
+			return nil, fmt.Errorf("the argument %q of the field %q is required", name, f.name)
+		}
+	}
+	return arguments, nil
+}
+
+// included checks the skip and include directives of a selection
+func (e *executor) included(directives []directive) (bool, error) {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			return false, fmt.Errorf("unknown directive @%s", d.name)
+		}
+		value, err := e.value(d.arguments["if"])
+		if err != nil {
+			return false, err
+		}
+		condition, ok := value.(bool)
+		if !ok {
+			return false, fmt.Errorf("the argument if of the directive @%s must be a boolean", d.name)
+		}
+		if condition == (d.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// collectFields returns the fields of a selection set, with the fields of the fragments
+func (e *executor) collectFields(selections []*selection, visited map[string]bool) ([]*collectedField, error) {
+	fields := []*collectedField{}
+	byKey := map[string]*collectedField{}
+	var collect func(selections []*selection) error
+	collect = func(selections []*selection) error {
+		for _, s := range selections {
+			ok, err := e.included(s.directives)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			switch {
+			case s.inline:
+				if err := collect(s.selections); err != nil {
+					return err
+				}
+			case s.fragment != "":
+				f, ok := e.doc.fragments[s.fragment]
+				if !ok {
+					return fmt.Errorf("unknown fragment %q", s.fragment)
+				}
+				if visited[s.fragment] {
+					return fmt.Errorf("the fragment %q spreads itself", s.fragment)
+				}
+				visited[s.fragment] = true
+				err := collect(f.selections)
+				delete(visited, s.fragment)
+				if err != nil {
+					return err
+				}
+			default:
+				key := s.responseKey()
+				if field, ok := byKey[key]; ok {
+					if field.name != s.name {
+						return fmt.Errorf("the fields %q and %q conflict on the response key %q", field.name, s.name, key)
+					}
+					field.selections = append(field.selections, s.selections...)
+					continue
+				}
+				field := &collectedField{key: key, name: s.name, arguments: s.arguments, selections: s.selections}
+				byKey[key] = field
+				fields = append(fields, field)
+			}
+		}
+		return nil
+	}
+	if err := collect(selections); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// validate checks the selections of the results, their fields have no arguments
+func (e *executor) validate(selections []*selection) error {
+	if len(selections) == 0 {
+		return nil
+	}
+	fields, err := e.collectFields(selections, map[string]bool{})
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if len(f.arguments) > 0 {
+			return fmt.Errorf("the field %q has no arguments", f.name)
+		}
+		if err := e.validate(f.selections); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalize converts the result of a resolver to its JSON value
+func normalize(result interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the result: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode the result: %v", err)
+	}
+	return value, nil
+}
+
+// complete selects the fields of the value, the lists are completed item by item. The type
+// is only known for the results of the fields of the query type
+func (e *executor) complete(value interface{}, typ string, selections []*selection, path []interface{}) interface{} {
+	if len(selections) == 0 || value == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]interface{}, 0, len(v))
+		for i, item := range v {
+			items = append(items, e.complete(item, typ, selections, appendPath(path, i)))
+		}
+		return items
+	case map[string]interface{}:
+		fields, err := e.collectFields(selections, map[string]bool{})
+		if err != nil {
+			e.errors = append(e.errors, Error{Message: err.Error(), Path: path})
+			return nil
+		}
+		result := newOrderedMap()
+		for _, f := range fields {
+			if f.name == "__typename" && typ != "" {
+				result.set(f.key, typ)
+				continue
+			}
+			result.set(f.key, e.complete(v[f.name], "", f.selections, appendPath(path, f.key)))
+		}
+		return result
+	default:
+		e.errors = append(e.errors, Error{Message: "the fields of a scalar value can not be selected", Path: path})
+		return nil
+	}
+}
+
+// appendPath copies the path, the paths of the fields share their prefix
+func appendPath(path []interface{}, element interface{}) []interface{} {
+	result := make([]interface{}, 0, len(path)+1)
+	return append(append(result, path...), element)
+}
+
+// StringArgument returns the value of a string argument, empty when it is not set
+func StringArgument(args map[string]interface{}, name string) (string, error) {
+	value, ok := args[name]
+	if !ok {
+		return "", nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("the argument %q must be a string", name)
+	}
+	return s, nil
+}
+
+// StringListArgument returns the value of a list of strings argument, a single string is
+// a list of one string
+func StringListArgument(args map[string]interface{}, name string) ([]string, error) {
+	value, ok := args[name]
+	if !ok {
+		return nil, nil
+	}
+	if s, ok := value.(string); ok {
+		return []string{s}, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the argument %q must be a list of strings", name)
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("the argument %q must be a list of strings", name)
+		}
+		values = append(values, s)
+	}
+	return values, nil
+}
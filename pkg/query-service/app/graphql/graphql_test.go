@@ -0,0 +1,156 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testDashboard struct {
+	Uuid  string                 `json:"uuid"`
+	Title string                 `json:"title"`
+	Data  map[string]interface{} `json:"data"`
+}
+
+var testSchema = Schema{
+	"dashboards": {
+		Type:      "Dashboard",
+		Arguments: map[string]bool{"tags": false},
+		Resolve: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			tags, err := StringListArgument(args, "tags")
+			if err != nil {
+				return nil, err
+			}
+			dashboards := []testDashboard{
+				{Uuid: "a", Title: "API", Data: map[string]interface{}{"tags": []string{"api"}, "version": "v4"}},
+				{Uuid: "b", Title: "Hosts", Data: map[string]interface{}{"tags": []string{"infra"}}},
+			}
+			if len(tags) == 0 {
+				return dashboards, nil
+			}
+			return dashboards[:1], nil
+		},
+	},
+	"dashboard": {
+		Type:      "Dashboard",
+		Arguments: map[string]bool{"uuid": true},
+		Resolve: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			uuid, err := StringArgument(args, "uuid")
+			if err != nil {
+				return nil, err
+			}
+			if uuid != "a" {
+				return nil, errors.New("dashboard not found")
+			}
+			return testDashboard{Uuid: "a", Title: "API"}, nil
+		},
+	},
+}
+
+func execute(t *testing.T, req Request) string {
+	response := testSchema.Execute(context.Background(), req)
+	encoded, err := json.Marshal(response)
+	require.NoError(t, err)
+	return string(encoded)
+}
+
+func TestExecute(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		req      Request
+		expected string
+	}{
+		{
+			"selects the fields in order",
+			Request{Query: `{ dashboards { title uuid } }`},
+			`{"data":{"dashboards":[{"title":"API","uuid":"a"},{"title":"Hosts","uuid":"b"}]}}`,
+		},
+		{
+			"aliases and arguments",
+			Request{Query: `query Q { api: dashboards(tags: ["api"]) { uuid } all: dashboards { uuid } }`},
+			`{"data":{"api":[{"uuid":"a"}],"all":[{"uuid":"a"},{"uuid":"b"}]}}`,
+		},
+		{
+			"nested fields and whole objects",
+			Request{Query: `{ dashboards(tags: "api") { data { version missing } } d: dashboards(tags: "api") { data } }`},
+			`{"data":{"dashboards":[{"data":{"version":"v4","missing":null}}],"d":[{"data":{"tags":["api"],"version":"v4"}}]}}`,
+		},
+		{
+			"variables and directives",
+			Request{
+				Query:     `query($uuid: String!, $withTitle: Boolean = false) { dashboard(uuid: $uuid) { uuid title @include(if: $withTitle) } }`,
+				Variables: map[string]interface{}{"uuid": "a"},
+			},
+			`{"data":{"dashboard":{"uuid":"a"}}}`,
+		},
+		{
+			"fragments and typename",
+			Request{Query: `{ __typename dashboard(uuid: "a") { ...fields ... on Dashboard { uuid } } } fragment fields on Dashboard { __typename title }`},
+			`{"data":{"__typename":"Query","dashboard":{"__typename":"Dashboard","title":"API","uuid":"a"}}}`,
+		},
+		{
+			"the errors of the fields",
+			Request{Query: `{ dashboard(uuid: "c") { uuid } dashboards { uuid { id } } }`},
+			`{"data":{"dashboard":null,"dashboards":[{"uuid":null},{"uuid":null}]},"errors":[{"message":"dashboard not found","path":["dashboard"]},` +
+				`{"message":"the fields of a scalar value can not be selected","path":["dashboards",0,"uuid"]},` +
+				`{"message":"the fields of a scalar value can not be selected","path":["dashboards",1,"uuid"]}]}`,
+		},
+		{
+			"operation name",
+			Request{Query: `query A { a: dashboard(uuid: "a") { uuid } } query B { b: dashboard(uuid: "a") { title } }`, OperationName: "B"},
+			`{"data":{"b":{"title":"API"}}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.JSONEq(t, tc.expected, execute(t, tc.req))
+			// the fields are in the order of the selections
+			if tc.name == "selects the fields in order" {
+				assert.Equal(t, tc.expected, execute(t, tc.req))
+			}
+		})
+	}
+}
+
+func TestExecuteInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		req     Request
+		message string
+	}{
+		{Request{Query: `{ dashboards { uuid }`}, `expected a name, found the end of the query`},
+		{Request{Query: `mutation { dashboards { uuid } }`}, `only the queries are supported, not the mutations`},
+		{Request{Query: `{ users { id } }`}, `cannot query field "users" on type "Query"`},
+		{Request{Query: `{ dashboard { uuid } }`}, `the argument "uuid" of the field "dashboard" is required`},
+		{Request{Query: `{ dashboard(id: "a") { uuid } }`}, `unknown argument "id" on the field "dashboard"`},
+		{Request{Query: `query($uuid: String!) { dashboard(uuid: $uuid) { uuid } }`}, `the variable $uuid is required`},
+		{Request{Query: `{ dashboard(uuid: $uuid) { uuid } }`}, `the variable $uuid is not defined`},
+		{Request{Query: `{ dashboards { ...f } } fragment f on Dashboard { ...f }`}, `the fragment "f" spreads itself`},
+		{Request{Query: `query A { dashboards { uuid } } query B { dashboards { uuid } }`}, `the operation name is required for the queries with several operations`},
+		{Request{Query: `{ a: dashboards { uuid } a: dashboard(uuid: "a") { uuid } }`}, `the fields "dashboards" and "dashboard" conflict on the response key "a"`},
+		{Request{Query: `{ dashboards { data(key: "tags") } }`}, `the field "data" has no arguments`},
+	} {
+		response := testSchema.Execute(context.Background(), tc.req)
+		assert.Nil(t, response.Data, tc.req.Query)
+		require.Len(t, response.Errors, 1, tc.req.Query)
+		assert.Equal(t, tc.message, response.Errors[0].Message, tc.req.Query)
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	doc, err := parse(`query($v: [String!] = ["x"]) { f(a: -1, b: 2.5e3, c: "q\"uote", d: ENUM, e: null, f: [1, $v], g: {h: true}) }`)
+	require.NoError(t, err)
+	op := doc.operations[0]
+	assert.Equal(t, []variableDefinition{{name: "v", defaultValue: []interface{}{"x"}, hasDefault: true}}, op.variables)
+	assert.Equal(t, map[string]interface{}{
+		"a": int64(-1),
+		"b": 2500.0,
+		"c": `q"uote`,
+		"d": "ENUM",
+		"e": nil,
+		"f": []interface{}{int64(1), variable("v")},
+		"g": map[string]interface{}{"h": true},
+	}, op.selections[0].arguments)
+}
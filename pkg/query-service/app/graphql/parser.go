@@ -0,0 +1,512 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	tokenName        = "name"
+	tokenInt         = "int"
+	tokenFloat       = "float"
+	tokenString      = "string"
+	tokenPunctuation = "punctuation"
+	tokenEOF         = "end of the query"
+)
+
+type token struct {
+	kind  string
+	value string
+	pos   int
+}
+
+// document is a parsed query document, made of operations and fragments
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string
+	name       string
+	variables  []variableDefinition
+	selections []*selection
+}
+
+type variableDefinition struct {
+	name         string
+	required     bool
+	defaultValue interface{}
+	hasDefault   bool
+}
+
+type fragment struct {
+	name       string
+	selections []*selection
+}
+
+// selection is a field, a fragment spread when fragment is set or an inline fragment when
+// inline is set. The type conditions of the fragments are not checked, the fields of the
+// schema each return a single type
+type selection struct {
+	alias      string
+	name       string
+	arguments  map[string]interface{}
+	directives []directive
+	selections []*selection
+	fragment   string
+	inline     bool
+}
+
+type directive struct {
+	name      string
+	arguments map[string]interface{}
+}
+
+// variable is a reference to a variable in the values of the arguments
+type variable string
+
+// responseKey is the key of the field in the response
+func (s *selection) responseKey() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+// parse parses a query document of the supported subset of GraphQL: the operations, the
+// variables, the aliases, the arguments, the fragments and the skip and include directives
+func parse(query string) (*document, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	doc := &document{fragments: map[string]*fragment{}}
+	for p.peek().kind != tokenEOF {
+		if p.peek().kind == tokenName && p.peek().value == "fragment" {
+			f, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[f.name]; ok {
+				return nil, fmt.Errorf("there can be only one fragment named %q", f.name)
+			}
+			doc.fragments[f.name] = f
+			continue
+		}
+		op, err := p.parseOperation()
+		if err != nil {
+			return nil, err
+		}
+		doc.operations = append(doc.operations, op)
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("the query has no operation")
+	}
+	return doc, nil
+}
+
+// tokenize splits the query in tokens, the commas and the comments are ignored
+func tokenize(query string) ([]token, error) {
+	tokens := []token{}
+	for pos := 0; pos < len(query); {
+		c := query[pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			pos++
+		case c == '#':
+			for pos < len(query) && query[pos] != '\n' {
+				pos++
+			}
+		case strings.HasPrefix(query[pos:], "..."):
+			tokens = append(tokens, token{kind: tokenPunctuation, value: "...", pos: pos})
+			pos += 3
+		case strings.ContainsRune("!$():=@[]{}", rune(c)):
+			tokens = append(tokens, token{kind: tokenPunctuation, value: string(c), pos: pos})
+			pos++
+		case c == '_' || isLetter(c):
+			start := pos
+			for pos < len(query) && (query[pos] == '_' || isLetter(query[pos]) || isDigit(query[pos])) {
+				pos++
+			}
+			tokens = append(tokens, token{kind: tokenName, value: query[start:pos], pos: start})
+		case c == '-' || isDigit(c):
+			start := pos
+			kind := tokenInt
+			if c == '-' {
+				pos++
+			}
+			for pos < len(query) && isDigit(query[pos]) {
+				pos++
+			}
+			if pos < len(query) && query[pos] == '.' {
+				kind = tokenFloat
+				for pos++; pos < len(query) && isDigit(query[pos]); pos++ {
+				}
+			}
+			if pos < len(query) && (query[pos] == 'e' || query[pos] == 'E') {
+				kind = tokenFloat
+				pos++
+				if pos < len(query) && (query[pos] == '+' || query[pos] == '-') {
+					pos++
+				}
+				for pos < len(query) && isDigit(query[pos]) {
+					pos++
+				}
+			}
+			tokens = append(tokens, token{kind: kind, value: query[start:pos], pos: start})
+		case c == '"':
+			if strings.HasPrefix(query[pos:], `"""`) {
+				return nil, fmt.Errorf("block strings are not supported, at position %d", pos)
+			}
+			end := pos + 1
+			for end < len(query) && query[end] != '"' && query[end] != '\n' {
+				if query[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(query) || query[end] != '"' {
+				return nil, fmt.Errorf("unterminated string at position %d", pos)
+			}
+			value, err := strconv.Unquote(query[pos : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %v", pos, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value, pos: pos})
+			pos = end + 1
+		default:
+			r, _ := utf8.DecodeRuneInString(query[pos:])
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, pos)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(query)}), nil
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// consume skips the punctuation when it is next
+func (p *parser) consume(punctuation string) bool {
+	if t := p.peek(); t.kind == tokenPunctuation && t.value == punctuation {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(punctuation string) error {
+	if !p.consume(punctuation) {
+		return p.unexpected(fmt.Sprintf("%q", punctuation))
+	}
+	return nil
+}
+
+func (p *parser) name() (string, error) {
+	if p.peek().kind != tokenName {
+		return "", p.unexpected("a name")
+	}
+	return p.next().value, nil
+}
+
+func (p *parser) unexpected(expected string) error {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return fmt.Errorf("expected %s, found the %s", expected, tokenEOF)
+	}
+	return fmt.Errorf("expected %s, found %q at position %d", expected, t.value, t.pos)
+}
+
+func (p *parser) parseOperation() (*operation, error) {
+	op := &operation{kind: "query"}
+	if p.peek().kind == tokenName {
+		op.kind = p.next().value
+		if op.kind != "query" && op.kind != "mutation" && op.kind != "subscription" {
+			return nil, fmt.Errorf("unknown operation type %q", op.kind)
+		}
+		if p.peek().kind == tokenName {
+			op.name = p.next().value
+		}
+		if p.consume("(") {
+			for !p.consume(")") {
+				v, err := p.parseVariableDefinition()
+				if err != nil {
+					return nil, err
+				}
+				op.variables = append(op.variables, v)
+			}
+		}
+		if p.peek().value == "@" {
+			return nil, fmt.Errorf("the directives of the operations are not supported")
+		}
+	}
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = selections
+	return op, nil
+}
+
+// parseVariableDefinition parses a variable, its type is only used to know if it is required
+func (p *parser) parseVariableDefinition() (variableDefinition, error) {
+	v := variableDefinition{}
+	if err := p.expect("$"); err != nil {
+		return v, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return v, err
+	}
+	v.name = name
+	if err := p.expect(":"); err != nil {
+		return v, err
+	}
+	if p.consume("[") {
+		if _, err := p.name(); err != nil {
+			return v, err
+		}
+		p.consume("!")
+		if err := p.expect("]"); err != nil {
+			return v, err
+		}
+	} else if _, err := p.name(); err != nil {
+		return v, err
+	}
+	v.required = p.consume("!")
+	if p.consume("=") {
+		if v.defaultValue, err = p.parseValue(true); err != nil {
+			return v, err
+		}
+		v.hasDefault = true
+	}
+	return v, nil
+}
+
+func (p *parser) parseFragment() (*fragment, error) {
+	p.next()
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, fmt.Errorf("a fragment can not be named on")
+	}
+	if on, err := p.name(); err != nil || on != "on" {
+		return nil, fmt.Errorf("expected the type condition of the fragment %q", name)
+	}
+	if _, err := p.name(); err != nil {
+		return nil, err
+	}
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name, selections: selections}, nil
+}
+
+func (p *parser) parseSelectionSet() ([]*selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	selections := []*selection{}
+	for !p.consume("}") {
+		s, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("empty selection set at position %d", p.tokens[p.pos-1].pos)
+	}
+	return selections, nil
+}
+
+func (p *parser) parseSelection() (*selection, error) {
+	s := &selection{}
+	var err error
+	if p.consume("...") {
+		switch t := p.peek(); {
+		case t.kind == tokenName && t.value == "on":
+			p.next()
+			if _, err := p.name(); err != nil {
+				return nil, err
+			}
+			s.inline = true
+		case t.kind == tokenName:
+			s.fragment = p.next().value
+		default:
+			s.inline = true
+		}
+		if s.directives, err = p.parseDirectives(); err != nil {
+			return nil, err
+		}
+		if s.inline {
+			if s.selections, err = p.parseSelectionSet(); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	}
+
+	if s.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if p.consume(":") {
+		s.alias = s.name
+		if s.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if s.arguments, err = p.parseArguments(); err != nil {
+		return nil, err
+	}
+	if s.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if p.peek().value == "{" && p.peek().kind == tokenPunctuation {
+		if s.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) parseArguments() (map[string]interface{}, error) {
+	arguments := map[string]interface{}{}
+	if !p.consume("(") {
+		return arguments, nil
+	}
+	for !p.consume(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := arguments[name]; ok {
+			return nil, fmt.Errorf("there can be only one argument named %q", name)
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if arguments[name], err = p.parseValue(false); err != nil {
+			return nil, err
+		}
+	}
+	return arguments, nil
+}
+
+func (p *parser) parseDirectives() ([]directive, error) {
+	directives := []directive{}
+	for p.consume("@") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, directive{name: name, arguments: arguments})
+	}
+	return directives, nil
+}
+
+// parseValue parses a value, the enum values are kept as strings. The default values of
+// the variables are constant, they can not reference variables
+func (p *parser) parseValue(constant bool) (interface{}, error) {
+	if p.peek().kind == tokenEOF {
+		return nil, p.unexpected("a value")
+	}
+	t := p.next()
+	switch t.kind {
+	case tokenInt:
+		value, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int %q at position %d", t.value, t.pos)
+		}
+		return value, nil
+	case tokenFloat:
+		value, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %q at position %d", t.value, t.pos)
+		}
+		return value, nil
+	case tokenString:
+		return t.value, nil
+	case tokenName:
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return t.value, nil
+	case tokenPunctuation:
+		switch t.value {
+		case "$":
+			if constant {
+				return nil, fmt.Errorf("unexpected variable at position %d", t.pos)
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			return variable(name), nil
+		case "[":
+			values := []interface{}{}
+			for !p.consume("]") {
+				value, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value)
+			}
+			return values, nil
+		case "{":
+			values := map[string]interface{}{}
+			for !p.consume("}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if values[name], err = p.parseValue(constant); err != nil {
+					return nil, err
+				}
+			}
+			return values, nil
+		}
+	}
+	p.pos--
+	return nil, p.unexpected("a value")
+}
@@ -0,0 +1,258 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/app/access"
+	"go.signoz.io/signoz/pkg/query-service/app/explorer"
+	"go.signoz.io/signoz/pkg/query-service/app/graphql"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	// graphQLMaxRequestSize is the largest body of the GraphQL requests
+	graphQLMaxRequestSize = 1 << 20
+	// graphQLMaxBatchSize is the largest number of requests of a batch
+	graphQLMaxBatchSize = 20
+)
+
+// registerGraphQLRoutes registers the GraphQL endpoint of the metadata. A request is a query,
+// or a batch of queries sent as a JSON array, answered with the array of their responses
+func (aH *APIHandler) registerGraphQLRoutes(router *mux.Router, am *AuthMiddleware) {
+	router.HandleFunc("/api/v1/graphql", am.ViewAccess(aH.graphQL)).Methods(http.MethodGet, http.MethodPost)
+}
+
+func (aH *APIHandler) graphQL(w http.ResponseWriter, r *http.Request) {
+	requests, batch, err := parseGraphQLRequests(w, r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	schema := aH.graphQLSchema(r)
+	responses := make([]graphql.Response, 0, len(requests))
+	for _, req := range requests {
+		responses = append(responses, schema.Execute(r.Context(), req))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	var response interface{} = responses[0]
+	if batch {
+		response = responses
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		zap.L().Error("error writing response", zap.Error(err))
+	}
+}
+
+// parseGraphQLRequests reads the request from the query parameters of the GET requests, or
+// the request or the batch of requests of the body of the POST requests
+func parseGraphQLRequests(w http.ResponseWriter, r *http.Request) ([]graphql.Request, bool, error) {
+	if r.Method == http.MethodGet {
+		req := graphql.Request{Query: r.URL.Query().Get("query"), OperationName: r.URL.Query().Get("operationName")}
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return nil, false, fmt.Errorf("invalid variables: %v", err)
+			}
+		}
+		return []graphql.Request{req}, false, nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, graphQLMaxRequestSize))
+	if err != nil {
+		return nil, false, err
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var requests []graphql.Request
+		if err := json.Unmarshal(body, &requests); err != nil {
+			return nil, false, fmt.Errorf("invalid batch of requests: %v", err)
+		}
+		if len(requests) == 0 || len(requests) > graphQLMaxBatchSize {
+			return nil, false, fmt.Errorf("a batch must have between 1 and %d requests", graphQLMaxBatchSize)
+		}
+		return requests, true, nil
+	}
+	var req graphql.Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, false, fmt.Errorf("invalid request: %v", err)
+	}
+	return []graphql.Request{req}, false, nil
+}
+
+// graphQLSchema returns the fields of the metadata for the user of the request, the fields
+// return what the REST APIs return and check the same permissions
+func (aH *APIHandler) graphQLSchema(r *http.Request) graphql.Schema {
+	return graphql.Schema{
+		"dashboards": {
+			Type:      "Dashboard",
+			Arguments: map[string]bool{"tags": false},
+			Resolve: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				if err := requirePermission(r, model.ResourceDashboards); err != nil {
+					return nil, err
+				}
+				tags, err := graphql.StringListArgument(args, "tags")
+				if err != nil {
+					return nil, err
+				}
+				result, apiErr := aH.listDashboards(r, tags)
+				if apiErr != nil {
+					return nil, apiErr
+				}
+				return result, nil
+			},
+		},
+		"dashboard": {
+			Type:      "Dashboard",
+			Arguments: map[string]bool{"uuid": true},
+			Resolve: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				if err := requirePermission(r, model.ResourceDashboards); err != nil {
+					return nil, err
+				}
+				uuid, err := graphql.StringArgument(args, "uuid")
+				if err != nil {
+					return nil, err
+				}
+				dashboard, apiErr := aH.viewableDashboard(r, uuid)
+				if apiErr != nil {
+					return nil, apiErr
+				}
+				return dashboard, nil
+			},
+		},
+		"alertRules": {
+			Type: "AlertRule",
+			Resolve: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				if err := requirePermission(r, model.ResourceAlerts); err != nil {
+					return nil, err
+				}
+				rules, apiErr := aH.viewableRules(r)
+				if apiErr != nil {
+					return nil, apiErr
+				}
+				return rules.Rules, nil
+			},
+		},
+		"alertRule": {
+			Type:      "AlertRule",
+			Arguments: map[string]bool{"id": true},
+			Resolve: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				if err := requirePermission(r, model.ResourceAlerts); err != nil {
+					return nil, err
+				}
+				id, err := graphql.StringArgument(args, "id")
+				if err != nil {
+					return nil, err
+				}
+				if apiErr := aH.checkACL(r, access.ACLResourceRule, id, false); apiErr != nil {
+					return nil, apiErr
+				}
+				return aH.ruleManager.GetRule(ctx, id)
+			},
+		},
+		"services": {
+			Type:      "Service",
+			Arguments: map[string]bool{"start": true, "end": true},
+			Resolve: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				params, err := graphQLServicesParams(args)
+				if err != nil {
+					return nil, err
+				}
+				result, apiErr := aH.reader.GetServices(ctx, params, aH.skipConfig)
+				if apiErr != nil {
+					return nil, apiErr
+				}
+				return result, nil
+			},
+		},
+		"savedViews": {
+			Type:      "SavedView",
+			Arguments: map[string]bool{"sourcePage": false, "name": false, "category": false},
+			Resolve: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				if err := requirePermission(r, model.ResourceSavedViews); err != nil {
+					return nil, err
+				}
+				filters := map[string]string{}
+				for _, name := range []string{"sourcePage", "name", "category"} {
+					value, err := graphql.StringArgument(args, name)
+					if err != nil {
+						return nil, err
+					}
+					filters[name] = value
+				}
+				return explorer.GetViewsForFilters(ctx, filters["sourcePage"], filters["name"], filters["category"])
+			},
+		},
+		"savedView": {
+			Type:      "SavedView",
+			Arguments: map[string]bool{"uuid": true},
+			Resolve: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				if err := requirePermission(r, model.ResourceSavedViews); err != nil {
+					return nil, err
+				}
+				uuid, err := graphql.StringArgument(args, "uuid")
+				if err != nil {
+					return nil, err
+				}
+				view, err := explorer.GetView(ctx, uuid)
+				if err != nil {
+					return nil, savedViewApiError(err)
+				}
+				return view, nil
+			},
+		},
+	}
+}
+
+// requirePermission checks the role of the user grants reading the resource, as the routes
+// of the resource do
+func requirePermission(r *http.Request, resource model.Resource) error {
+	user := common.GetUserFromContext(r.Context())
+	if user == nil || !auth.HasPermission(r.Context(), user, resource, model.ActionRead) {
+		return fmt.Errorf("the query requires the permission to %s %s", model.ActionRead, resource)
+	}
+	return nil
+}
+
+// graphQLServicesParams returns the parameters of the services for the start and end
+// arguments, in nanoseconds as in the services API
+func graphQLServicesParams(args map[string]interface{}) (*model.GetServicesParams, error) {
+	params := &model.GetServicesParams{}
+	for _, name := range []string{"start", "end"} {
+		value := ""
+		switch v := args[name].(type) {
+		case string:
+			value = v
+		case int64:
+			value = strconv.FormatInt(v, 10)
+		default:
+			return nil, fmt.Errorf("the argument %q must be a timestamp in nanoseconds", name)
+		}
+		if name == "start" {
+			params.StartTime = value
+		} else {
+			params.EndTime = value
+		}
+	}
+
+	var err error
+	if params.Start, err = parseTimeStr(params.StartTime, "start"); err != nil {
+		return nil, err
+	}
+	if params.End, err = parseTimeMinusBufferStr(params.EndTime, "end"); err != nil {
+		return nil, err
+	}
+	params.Period = int(params.End.Unix() - params.Start.Unix())
+	return params, nil
+}
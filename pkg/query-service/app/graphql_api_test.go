@@ -0,0 +1,56 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/graphql"
+)
+
+func TestParseGraphQLRequests(t *testing.T) {
+	parse := func(r *http.Request) ([]graphql.Request, bool, error) {
+		return parseGraphQLRequests(httptest.NewRecorder(), r)
+	}
+
+	requests, batch, err := parse(httptest.NewRequest(http.MethodPost, "/api/v1/graphql",
+		strings.NewReader(`{"query": "{ dashboards { uuid } }", "variables": {"tag": "api"}}`)))
+	require.NoError(t, err)
+	assert.False(t, batch)
+	assert.Equal(t, []graphql.Request{{Query: "{ dashboards { uuid } }", Variables: map[string]interface{}{"tag": "api"}}}, requests)
+
+	requests, batch, err = parse(httptest.NewRequest(http.MethodPost, "/api/v1/graphql",
+		strings.NewReader(` [{"query": "{ alertRules { id } }"}, {"query": "{ savedViews { name } }"}]`)))
+	require.NoError(t, err)
+	assert.True(t, batch)
+	assert.Len(t, requests, 2)
+
+	requests, batch, err = parse(httptest.NewRequest(http.MethodGet, "/api/v1/graphql?query="+
+		url.QueryEscape("query Q($id: String!) { alertRule(id: $id) { id } }")+"&operationName=Q&variables="+url.QueryEscape(`{"id": "1"}`), nil))
+	require.NoError(t, err)
+	assert.False(t, batch)
+	assert.Equal(t, []graphql.Request{{Query: "query Q($id: String!) { alertRule(id: $id) { id } }", OperationName: "Q",
+		Variables: map[string]interface{}{"id": "1"}}}, requests)
+
+	for _, body := range []string{`[]`, `[` + strings.Repeat(`{"query": "{ a }"},`, graphQLMaxBatchSize) + `{"query": "{ a }"}]`, `{"query": `} {
+		_, _, err := parse(httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(body)))
+		assert.Error(t, err, body)
+	}
+}
+
+func TestGraphQLServicesParams(t *testing.T) {
+	params, err := graphQLServicesParams(map[string]interface{}{"start": "1700000000000000000", "end": int64(1700003600000000000)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000000000000), params.Start.UnixNano())
+	assert.Equal(t, int64(1700003600000000000), params.End.UnixNano())
+	assert.Equal(t, 3600, params.Period)
+
+	_, err = graphQLServicesParams(map[string]interface{}{"start": "yesterday", "end": "1700003600000000000"})
+	assert.Error(t, err)
+	_, err = graphQLServicesParams(map[string]interface{}{"start": 1.5, "end": "1700003600000000000"})
+	assert.Error(t, err)
+}
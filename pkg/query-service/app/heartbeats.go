@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/app/heartbeats"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// heartbeatAlertState returns the state of the alert rule of the monitor
+func (aH *APIHandler) heartbeatAlertState(ctx context.Context, monitor *heartbeats.Monitor) string {
+	rule, err := aH.ruleManager.GetRule(ctx, monitor.AlertId)
+	if err != nil {
+		zap.L().Error("failed to get the alert of the heartbeat monitor", zap.String("monitor", monitor.Id), zap.String("rule", monitor.AlertId), zap.Error(err))
+		return ""
+	}
+	return rule.State
+}
+
+// listHeartbeatMonitors lists the monitors with the state of their alert
+func (aH *APIHandler) listHeartbeatMonitors(w http.ResponseWriter, r *http.Request) {
+	monitors, apiErr := aH.HeartbeatRepo.ListMonitors(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	statuses := make([]*heartbeats.MonitorStatus, len(monitors))
+	for i := range monitors {
+		statuses[i] = &heartbeats.MonitorStatus{Monitor: &monitors[i], AlertState: aH.heartbeatAlertState(r.Context(), &monitors[i])}
+	}
+	aH.Respond(w, statuses)
+}
+
+// getHeartbeatMonitor returns the monitor with the last time its signal arrived
+func (aH *APIHandler) getHeartbeatMonitor(w http.ResponseWriter, r *http.Request) {
+	monitor, apiErr := aH.HeartbeatRepo.GetMonitor(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	now := time.Now()
+	params := heartbeats.StatusParams(monitor, now)
+	if apiErr := scopeQueryToOrg(r.Context(), params); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	results, _, err := aH.querierV2.QueryRange(r.Context(), params, nil)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, heartbeats.Status(monitor, aH.heartbeatAlertState(r.Context(), monitor), results, now))
+}
+
+func decodeHeartbeatMonitor(r *http.Request) (*heartbeats.PostableMonitor, *model.ApiError) {
+	var postable heartbeats.PostableMonitor
+	if err := json.NewDecoder(r.Body).Decode(&postable); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	if err := postable.Validate(); err != nil {
+		return nil, model.BadRequest(errors.Wrap(err, "heartbeat monitor is not valid"))
+	}
+	return &postable, nil
+}
+
+// createHeartbeatMonitor creates the monitor along with its alert rule
+func (aH *APIHandler) createHeartbeatMonitor(w http.ResponseWriter, r *http.Request) {
+	postable, apiErr := decodeHeartbeatMonitor(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	userId, err := auth.ExtractUserIdFromContext(r.Context())
+	if err != nil {
+		RespondError(w, model.UnauthorizedError(errors.Wrap(err, "failed to get userId from context")), nil)
+		return
+	}
+
+	monitor := heartbeats.NewMonitor(userId, postable)
+	data, err := json.Marshal(heartbeats.AlertRule(monitor))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	rule, err := aH.ruleManager.CreateRule(r.Context(), string(data))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	monitor.AlertId = rule.Id
+	if apiErr := aH.HeartbeatRepo.InsertMonitor(r.Context(), monitor); apiErr != nil {
+		if err := aH.ruleManager.DeleteRule(r.Context(), rule.Id); err != nil {
+			zap.L().Error("failed to delete the alert of the heartbeat monitor", zap.String("rule", rule.Id), zap.Error(err))
+		}
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, monitor)
+}
+
+// updateHeartbeatMonitor updates the monitor along with its alert rule
+func (aH *APIHandler) updateHeartbeatMonitor(w http.ResponseWriter, r *http.Request) {
+	postable, apiErr := decodeHeartbeatMonitor(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	userId, err := auth.ExtractUserIdFromContext(r.Context())
+	if err != nil {
+		RespondError(w, model.UnauthorizedError(errors.Wrap(err, "failed to get userId from context")), nil)
+		return
+	}
+	monitor, apiErr := aH.HeartbeatRepo.GetMonitor(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	updated := monitor.Updated(userId, postable)
+	data, err := json.Marshal(heartbeats.AlertRule(updated))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if err := aH.ruleManager.EditRule(r.Context(), string(data), updated.AlertId); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if apiErr := aH.HeartbeatRepo.UpdateMonitor(r.Context(), updated); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, updated)
+}
+
+// deleteHeartbeatMonitor deletes the monitor along with its alert rule
+func (aH *APIHandler) deleteHeartbeatMonitor(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	monitor, apiErr := aH.HeartbeatRepo.GetMonitor(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if apiErr := aH.HeartbeatRepo.DeleteMonitor(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if err := aH.ruleManager.DeleteRule(r.Context(), monitor.AlertId); err != nil {
+		zap.L().Error("failed to delete the alert of the heartbeat monitor", zap.String("monitor", id), zap.String("rule", monitor.AlertId), zap.Error(err))
+	}
+	aH.Respond(w, nil)
+}
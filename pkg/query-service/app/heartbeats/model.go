@@ -0,0 +1,168 @@
+package heartbeats
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const (
+	DefaultGracePeriodMinutes = 5
+	MaxGracePeriodMinutes     = 7 * 24 * 60
+)
+
+type Signal string
+
+const (
+	// SignalMetrics expects the samples of a metric
+	SignalMetrics Signal = "metrics"
+	// SignalLogs expects the logs of a service
+	SignalLogs Signal = "logs"
+	// SignalTraces expects the spans of a service, or of an endpoint of the service
+	SignalTraces Signal = "traces"
+)
+
+var severities = map[string]struct{}{
+	"critical": {}, "error": {}, "warning": {}, "info": {},
+}
+
+// PostableMonitor is a heartbeat monitor as received from the API. The monitor alerts
+// when no data of the signal matching the service, the endpoint and the filters arrived
+// for the grace period
+type PostableMonitor struct {
+	Name               string   `json:"name"`
+	Description        string   `json:"description,omitempty"`
+	Signal             Signal   `json:"signal"`
+	MetricName         string   `json:"metricName,omitempty"`
+	ServiceName        string   `json:"serviceName,omitempty"`
+	Endpoint           string   `json:"endpoint,omitempty"`
+	Filters            *Filters `json:"filters,omitempty"`
+	GracePeriodMinutes int      `json:"gracePeriodMinutes"`
+	Severity           string   `json:"severity,omitempty"`
+	PreferredChannels  Channels `json:"preferredChannels,omitempty"`
+	Disabled           bool     `json:"disabled"`
+}
+
+// Monitor is a stored heartbeat monitor, AlertId is the id of the alert rule of the monitor
+type Monitor struct {
+	Id                 string    `json:"id" db:"id"`
+	Name               string    `json:"name" db:"name"`
+	Description        string    `json:"description,omitempty" db:"description"`
+	Signal             Signal    `json:"signal" db:"signal"`
+	MetricName         string    `json:"metricName,omitempty" db:"metric_name"`
+	ServiceName        string    `json:"serviceName,omitempty" db:"service_name"`
+	Endpoint           string    `json:"endpoint,omitempty" db:"endpoint"`
+	Filters            *Filters  `json:"filters,omitempty" db:"filters"`
+	GracePeriodMinutes int       `json:"gracePeriodMinutes" db:"grace_period_minutes"`
+	Severity           string    `json:"severity" db:"severity"`
+	PreferredChannels  Channels  `json:"preferredChannels,omitempty" db:"preferred_channels"`
+	Disabled           bool      `json:"disabled" db:"disabled"`
+	AlertId            string    `json:"alertId" db:"alert_id"`
+	CreatedBy          string    `json:"createdBy" db:"created_by"`
+	CreatedAt          time.Time `json:"createdAt" db:"created_at"`
+	UpdatedBy          string    `json:"updatedBy" db:"updated_by"`
+	UpdatedAt          time.Time `json:"updatedAt" db:"updated_at"`
+	// OrgId is the org of the monitor, it watches the data of the org
+	OrgId string `json:"-" db:"org_id"`
+}
+
+// MonitorStatus is the state of the monitor, Missing is true while the signal is not
+// arriving. LastSeen is the end of the last minute with data in the lookback window
+type MonitorStatus struct {
+	*Monitor
+
+	AlertState string     `json:"alertState"`
+	Missing    bool       `json:"missing"`
+	LastSeen   *time.Time `json:"lastSeen"`
+}
+
+// Validate checks the monitor and sets the defaults of the grace period and the severity
+func (m *PostableMonitor) Validate() error {
+	if strings.TrimSpace(m.Name) == "" {
+		return fmt.Errorf("monitor name is required")
+	}
+	switch m.Signal {
+	case SignalMetrics:
+		if m.MetricName == "" {
+			return fmt.Errorf("the metrics monitors require the metric name")
+		}
+		if m.ServiceName != "" || m.Endpoint != "" {
+			return fmt.Errorf("the metrics monitors filter the service with the filters")
+		}
+	case SignalLogs:
+		if m.MetricName != "" || m.Endpoint != "" {
+			return fmt.Errorf("the metric name and the endpoint are not supported by the logs monitors")
+		}
+	case SignalTraces:
+		if m.MetricName != "" {
+			return fmt.Errorf("the metric name is not supported by the traces monitors")
+		}
+		if m.Endpoint != "" && m.ServiceName == "" {
+			return fmt.Errorf("the endpoint requires the service name")
+		}
+	default:
+		return fmt.Errorf("unsupported signal: %s", m.Signal)
+	}
+	if err := (*v3.FilterSet)(m.Filters).Validate(); err != nil {
+		return fmt.Errorf("filters are invalid: %w", err)
+	}
+	if m.Filters != nil && m.Filters.Operator == "OR" && (m.ServiceName != "" || m.Endpoint != "") {
+		return fmt.Errorf("the OR filters can not be combined with the service name and the endpoint")
+	}
+
+	if m.GracePeriodMinutes == 0 {
+		m.GracePeriodMinutes = DefaultGracePeriodMinutes
+	}
+	if m.GracePeriodMinutes < 1 || m.GracePeriodMinutes > MaxGracePeriodMinutes {
+		return fmt.Errorf("grace period must be between 1 and %d minutes", MaxGracePeriodMinutes)
+	}
+	if m.Severity == "" {
+		m.Severity = "critical"
+	}
+	if _, ok := severities[m.Severity]; !ok {
+		return fmt.Errorf("unsupported severity: %s", m.Severity)
+	}
+	return nil
+}
+
+// Filters and Channels are stored as JSON
+
+type Filters v3.FilterSet
+
+func (f *Filters) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return json.Marshal(f)
+}
+
+func (f *Filters) Scan(src interface{}) error {
+	return scanJSON(src, f)
+}
+
+type Channels []string
+
+func (c Channels) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *Channels) Scan(src interface{}) error {
+	return scanJSON(src, c)
+}
+
+func scanJSON(src interface{}, dest interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, dest)
+	case string:
+		return json.Unmarshal([]byte(data), dest)
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("unsupported type %T", src)
+	}
+}
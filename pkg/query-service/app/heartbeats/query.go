@@ -0,0 +1,188 @@
+package heartbeats
+
+import (
+	"fmt"
+	"time"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+)
+
+const (
+	queryName = "A"
+	// stepInterval is the step of the queries in seconds, the last seen time of the signal
+	// is precise to the step
+	stepInterval = 60
+	// minLookback is the window the status looks for the last data of the monitors in
+	minLookback = time.Hour
+	// absentForMinutes is how long the alert waits after the first evaluation finding no
+	// data over the grace period before firing
+	absentForMinutes = 1
+)
+
+func stringKey(key string, typ v3.AttributeKeyType, isColumn bool) v3.AttributeKey {
+	return v3.AttributeKey{Key: key, DataType: v3.AttributeKeyDataTypeString, Type: typ, IsColumn: isColumn}
+}
+
+// filters returns the filters of the monitor along with the filters of its service and endpoint
+func filters(m *Monitor) *v3.FilterSet {
+	set := &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{}}
+	if m.Filters != nil {
+		if m.Filters.Operator != "" {
+			set.Operator = m.Filters.Operator
+		}
+		set.Items = append(set.Items, m.Filters.Items...)
+	}
+	switch m.Signal {
+	case SignalLogs:
+		if m.ServiceName != "" {
+			set.Items = append(set.Items, v3.FilterItem{
+				Key: stringKey("service.name", v3.AttributeKeyTypeResource, false), Operator: v3.FilterOperatorEqual, Value: m.ServiceName,
+			})
+		}
+	case SignalTraces:
+		if m.ServiceName != "" {
+			set.Items = append(set.Items, v3.FilterItem{
+				Key: stringKey("serviceName", v3.AttributeKeyTypeTag, true), Operator: v3.FilterOperatorEqual, Value: m.ServiceName,
+			})
+		}
+		if m.Endpoint != "" {
+			set.Items = append(set.Items, v3.FilterItem{
+				Key: stringKey("name", v3.AttributeKeyTypeTag, true), Operator: v3.FilterOperatorEqual, Value: m.Endpoint,
+			})
+		}
+	}
+	return set
+}
+
+// Query returns the query counting the data of the signal of the monitor per minute
+func Query(m *Monitor) *v3.BuilderQuery {
+	query := &v3.BuilderQuery{
+		QueryName:         queryName,
+		Expression:        queryName,
+		DataSource:        v3.DataSource(m.Signal),
+		AggregateOperator: v3.AggregateOperatorCount,
+		Filters:           filters(m),
+		StepInterval:      stepInterval,
+	}
+	if m.Signal == SignalMetrics {
+		query.AggregateAttribute = v3.AttributeKey{
+			Key:      m.MetricName,
+			DataType: v3.AttributeKeyDataTypeFloat64,
+			Type:     v3.AttributeKeyTypeUnspecified,
+			IsColumn: true,
+		}
+		query.Temporality = v3.Unspecified
+		query.TimeAggregation = v3.TimeAggregationCount
+		query.SpaceAggregation = v3.SpaceAggregationSum
+	}
+	return query
+}
+
+// Lookback returns the window the status of the monitor looks for its last data in
+func Lookback(m *Monitor) time.Duration {
+	return max(minLookback, 2*time.Duration(m.GracePeriodMinutes)*time.Minute)
+}
+
+// StatusParams returns the query range params of the status of the monitor at now
+func StatusParams(m *Monitor, now time.Time) *v3.QueryRangeParamsV3 {
+	return &v3.QueryRangeParamsV3{
+		Start:   now.Add(-Lookback(m)).UnixMilli(),
+		End:     now.UnixMilli(),
+		Step:    stepInterval,
+		Version: "v4",
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType:      v3.QueryTypeBuilder,
+			PanelType:      v3.PanelTypeGraph,
+			BuilderQueries: map[string]*v3.BuilderQuery{queryName: Query(m)},
+		},
+	}
+}
+
+// Status returns the status of the monitor at now from the results of the query of
+// StatusParams, the signal is missing when no data arrived for the grace period
+func Status(m *Monitor, alertState string, results []*v3.Result, now time.Time) *MonitorStatus {
+	status := &MonitorStatus{Monitor: m, AlertState: alertState}
+	var lastSeen int64
+	for _, result := range results {
+		if result.QueryName != queryName {
+			continue
+		}
+		for _, series := range result.Series {
+			for _, p := range series.Points {
+				if p.Value > 0 && p.Timestamp > lastSeen {
+					lastSeen = p.Timestamp
+				}
+			}
+		}
+	}
+	grace := time.Duration(m.GracePeriodMinutes) * time.Minute
+	if lastSeen == 0 {
+		status.Missing = true
+		return status
+	}
+	// the points are at the start of their step
+	seen := time.UnixMilli(lastSeen).Add(stepInterval * time.Second).UTC()
+	if seen.After(now) {
+		seen = now.UTC()
+	}
+	status.LastSeen = &seen
+	status.Missing = now.Sub(seen) > grace
+	return status
+}
+
+// AlertRule returns the rule alerting when the signal of the monitor stopped arriving for
+// the grace period. The rule counts the data over the grace period and fires on the absence
+// of the count
+func AlertRule(m *Monitor) *rules.PostableRule {
+	grace := time.Duration(m.GracePeriodMinutes) * time.Minute
+	target := float64(0)
+	description := m.Description
+	if description == "" {
+		description = fmt.Sprintf("No %s arrived for the heartbeat monitor %s in the last %s", m.Signal, m.Name, grace)
+	}
+
+	return &rules.PostableRule{
+		AlertName:   fmt.Sprintf("Heartbeat %s is missing", m.Name),
+		AlertType:   alertType(m.Signal),
+		Description: description,
+		RuleType:    rules.RuleTypeThreshold,
+		EvalWindow:  rules.Duration(grace),
+		Frequency:   rules.Duration(time.Minute),
+		RuleCondition: &rules.RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType:      v3.QueryTypeBuilder,
+				PanelType:      v3.PanelTypeGraph,
+				BuilderQueries: map[string]*v3.BuilderQuery{queryName: Query(m)},
+			},
+			// the count is never below zero, the rule only fires on the absence of data
+			CompareOp:     rules.ValueIsBelow,
+			Target:        &target,
+			MatchType:     rules.AtleastOnce,
+			SelectedQuery: queryName,
+			AlertOnAbsent: true,
+			AbsentFor:     absentForMinutes,
+		},
+		Labels: map[string]string{
+			"severity":     m.Severity,
+			"heartbeat_id": m.Id,
+		},
+		Annotations: map[string]string{
+			"description": description,
+		},
+		Disabled:          m.Disabled,
+		PreferredChannels: m.PreferredChannels,
+		Version:           "v4",
+	}
+}
+
+func alertType(signal Signal) string {
+	switch signal {
+	case SignalLogs:
+		return "LOGS_BASED_ALERT"
+	case SignalTraces:
+		return "TRACES_BASED_ALERT"
+	default:
+		return "METRIC_BASED_ALERT"
+	}
+}
@@ -0,0 +1,95 @@
+package heartbeats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		monitor PostableMonitor
+		wantErr bool
+	}{
+		{name: "metrics", monitor: PostableMonitor{Name: "backup", Signal: SignalMetrics, MetricName: "backup_runs_total"}},
+		{name: "logs of service", monitor: PostableMonitor{Name: "cron", Signal: SignalLogs, ServiceName: "cron"}},
+		{name: "endpoint", monitor: PostableMonitor{Name: "checkout", Signal: SignalTraces, ServiceName: "shop", Endpoint: "POST /checkout"}},
+		{name: "no name", monitor: PostableMonitor{Signal: SignalLogs}, wantErr: true},
+		{name: "unknown signal", monitor: PostableMonitor{Name: "x", Signal: "events"}, wantErr: true},
+		{name: "metrics without metric", monitor: PostableMonitor{Name: "x", Signal: SignalMetrics}, wantErr: true},
+		{name: "endpoint without service", monitor: PostableMonitor{Name: "x", Signal: SignalTraces, Endpoint: "GET /"}, wantErr: true},
+		{name: "grace period too long", monitor: PostableMonitor{Name: "x", Signal: SignalLogs, GracePeriodMinutes: MaxGracePeriodMinutes + 1}, wantErr: true},
+		{name: "unknown severity", monitor: PostableMonitor{Name: "x", Signal: SignalLogs, Severity: "page"}, wantErr: true},
+		{
+			name: "or filters with service",
+			monitor: PostableMonitor{Name: "x", Signal: SignalLogs, ServiceName: "cron", Filters: &Filters{Operator: "OR", Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "job", Type: v3.AttributeKeyTypeTag, DataType: v3.AttributeKeyDataTypeString}, Operator: v3.FilterOperatorEqual, Value: "a"},
+			}}},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.monitor.Validate()
+			if c.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, DefaultGracePeriodMinutes, c.monitor.GracePeriodMinutes)
+			require.Equal(t, "critical", c.monitor.Severity)
+		})
+	}
+}
+
+func TestAlertRule(t *testing.T) {
+	postable := &PostableMonitor{Name: "checkout", Signal: SignalTraces, ServiceName: "shop", Endpoint: "POST /checkout", GracePeriodMinutes: 30}
+	require.NoError(t, postable.Validate())
+	monitor := NewMonitor("user", postable)
+
+	data, err := json.Marshal(AlertRule(monitor))
+	require.NoError(t, err)
+	rule, errs := rules.ParsePostableRule(data)
+	require.Empty(t, errs)
+
+	require.Equal(t, rules.Duration(30*time.Minute), rule.EvalWindow)
+	require.True(t, rule.RuleCondition.AlertOnAbsent)
+	require.Equal(t, monitor.Id, rule.Labels["heartbeat_id"])
+
+	query := rule.RuleCondition.CompositeQuery.BuilderQueries[queryName]
+	require.Equal(t, v3.DataSourceTraces, query.DataSource)
+	require.Len(t, query.Filters.Items, 2)
+	require.Equal(t, "serviceName", query.Filters.Items[0].Key.Key)
+	require.Equal(t, "name", query.Filters.Items[1].Key.Key)
+}
+
+func TestStatus(t *testing.T) {
+	monitor := &Monitor{Name: "cron", Signal: SignalLogs, GracePeriodMinutes: 10}
+	now := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	result := func(minutesAgo ...int) []*v3.Result {
+		series := &v3.Series{}
+		for _, m := range minutesAgo {
+			series.Points = append(series.Points, v3.Point{Timestamp: now.Truncate(time.Minute).Add(-time.Duration(m) * time.Minute).UnixMilli(), Value: 1})
+		}
+		// the empty steps are filled with zeros
+		series.Points = append(series.Points, v3.Point{Timestamp: now.Truncate(time.Minute).UnixMilli(), Value: 0})
+		return []*v3.Result{{QueryName: queryName, Series: []*v3.Series{series}}}
+	}
+
+	status := Status(monitor, "inactive", result(5, 40), now)
+	require.False(t, status.Missing)
+	require.Equal(t, time.Date(2024, 5, 1, 11, 56, 0, 0, time.UTC), *status.LastSeen)
+
+	status = Status(monitor, "firing", result(20), now)
+	require.True(t, status.Missing)
+	require.Equal(t, time.Date(2024, 5, 1, 11, 41, 0, 0, time.UTC), *status.LastSeen)
+
+	status = Status(monitor, "firing", nil, now)
+	require.True(t, status.Missing)
+	require.Nil(t, status.LastSeen)
+}
@@ -0,0 +1,165 @@
+package heartbeats
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// Repo handles DDL and DML ops on heartbeat monitors
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) (*Repo, error) {
+	tableSchema := `CREATE TABLE IF NOT EXISTS heartbeat_monitors(
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		signal TEXT NOT NULL,
+		metric_name TEXT NOT NULL DEFAULT '',
+		service_name TEXT NOT NULL DEFAULT '',
+		endpoint TEXT NOT NULL DEFAULT '',
+		filters TEXT,
+		grace_period_minutes INTEGER NOT NULL,
+		severity TEXT NOT NULL,
+		preferred_channels TEXT NOT NULL DEFAULT '[]',
+		disabled BOOLEAN,
+		alert_id TEXT NOT NULL DEFAULT '',
+		created_by TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`
+	if _, err := db.Exec(tableSchema); err != nil {
+		return nil, errors.Wrap(err, "Error in creating heartbeat monitors table")
+	}
+	_, err := db.Exec(`ALTER TABLE heartbeat_monitors ADD COLUMN org_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, errors.Wrap(err, "Error in adding column org_id to heartbeat monitors table")
+	}
+	return &Repo{db: db}, nil
+}
+
+// ListMonitors returns the monitors of the org of the user of the context
+func (r *Repo) ListMonitors(ctx context.Context) ([]Monitor, *model.ApiError) {
+	monitors := []Monitor{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.SelectContext(ctx, &monitors, `SELECT * FROM heartbeat_monitors WHERE `+condition+` ORDER BY created_at`, args...)
+	if err != nil {
+		zap.L().Error("failed to get heartbeat monitors from db", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to get heartbeat monitors from db"))
+	}
+	return monitors, nil
+}
+
+func (r *Repo) GetMonitor(ctx context.Context, id string) (*Monitor, *model.ApiError) {
+	var monitor Monitor
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.GetContext(ctx, &monitor, `SELECT * FROM heartbeat_monitors WHERE id = ? AND `+condition, append([]interface{}{id}, args...)...)
+	if err == sql.ErrNoRows {
+		return nil, model.NotFoundError(fmt.Errorf("no heartbeat monitor found with id %s", id))
+	}
+	if err != nil {
+		zap.L().Error("failed to get heartbeat monitor from db", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to get heartbeat monitor from db"))
+	}
+	return &monitor, nil
+}
+
+// NewMonitor returns the monitor of the validated postable monitor, it is stored by
+// InsertMonitor once its alert rule is created
+func NewMonitor(userId string, postable *PostableMonitor) *Monitor {
+	now := time.Now()
+	monitor := &Monitor{
+		Id:        uuid.NewString(),
+		CreatedBy: userId,
+		CreatedAt: now,
+	}
+	monitor.set(userId, now, postable)
+	return monitor
+}
+
+// set copies the fields of the postable monitor to the monitor
+func (m *Monitor) set(userId string, now time.Time, postable *PostableMonitor) {
+	m.Name = postable.Name
+	m.Description = postable.Description
+	m.Signal = postable.Signal
+	m.MetricName = postable.MetricName
+	m.ServiceName = postable.ServiceName
+	m.Endpoint = postable.Endpoint
+	m.Filters = postable.Filters
+	m.GracePeriodMinutes = postable.GracePeriodMinutes
+	m.Severity = postable.Severity
+	m.PreferredChannels = postable.PreferredChannels
+	if m.PreferredChannels == nil {
+		m.PreferredChannels = Channels{}
+	}
+	m.Disabled = postable.Disabled
+	m.UpdatedBy = userId
+	m.UpdatedAt = now
+}
+
+// Updated returns a copy of the monitor with the fields of the validated postable monitor
+func (m *Monitor) Updated(userId string, postable *PostableMonitor) *Monitor {
+	updated := *m
+	updated.set(userId, time.Now(), postable)
+	return &updated
+}
+
+// InsertMonitor stores the monitor in the org of the user of the context
+func (r *Repo) InsertMonitor(ctx context.Context, m *Monitor) *model.ApiError {
+	m.OrgId = common.GetOrgIdFromContext(ctx)
+	_, err := r.db.ExecContext(ctx, `INSERT INTO heartbeat_monitors
+		(id, name, description, signal, metric_name, service_name, endpoint, filters, grace_period_minutes, severity,
+		preferred_channels, disabled, alert_id, created_by, created_at, updated_by, updated_at, org_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`,
+		m.Id, m.Name, m.Description, m.Signal, m.MetricName, m.ServiceName, m.Endpoint, m.Filters, m.GracePeriodMinutes, m.Severity,
+		m.PreferredChannels, m.Disabled, m.AlertId, m.CreatedBy, m.CreatedAt, m.UpdatedBy, m.UpdatedAt, m.OrgId)
+	if err != nil {
+		zap.L().Error("error in inserting heartbeat monitor", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to insert heartbeat monitor"))
+	}
+	return nil
+}
+
+func (r *Repo) UpdateMonitor(ctx context.Context, m *Monitor) *model.ApiError {
+	condition, args := common.OrgCondition(ctx)
+	result, err := r.db.ExecContext(ctx, `UPDATE heartbeat_monitors SET
+		name = ?, description = ?, signal = ?, metric_name = ?, service_name = ?, endpoint = ?, filters = ?,
+		grace_period_minutes = ?, severity = ?, preferred_channels = ?, disabled = ?, alert_id = ?,
+		updated_by = ?, updated_at = ? WHERE id = ? AND `+condition,
+		append([]interface{}{m.Name, m.Description, m.Signal, m.MetricName, m.ServiceName, m.Endpoint, m.Filters,
+			m.GracePeriodMinutes, m.Severity, m.PreferredChannels, m.Disabled, m.AlertId,
+			m.UpdatedBy, m.UpdatedAt, m.Id}, args...)...)
+	if err != nil {
+		zap.L().Error("error in updating heartbeat monitor", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to update heartbeat monitor"))
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return model.NotFoundError(fmt.Errorf("no heartbeat monitor found with id %s", m.Id))
+	}
+	return nil
+}
+
+func (r *Repo) DeleteMonitor(ctx context.Context, id string) *model.ApiError {
+	condition, args := common.OrgCondition(ctx)
+	result, err := r.db.ExecContext(ctx, `DELETE FROM heartbeat_monitors WHERE id = ? AND `+condition, append([]interface{}{id}, args...)...)
+	if err != nil {
+		zap.L().Error("error in deleting heartbeat monitor", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to delete heartbeat monitor"))
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return model.NotFoundError(fmt.Errorf("no heartbeat monitor found with id %s", id))
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.signoz.io/signoz/pkg/query-service/app/hosts"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// listHosts lists the hosts with their CPU, memory, disk and network from the hostmetrics
+// metrics
+func (aH *APIHandler) listHosts(w http.ResponseWriter, r *http.Request) {
+	var req hosts.ListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	ctx := r.Context()
+	params := hosts.BuildQuery(&req)
+	if apiErr := scopeQueryToOrg(ctx, params); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if err := aH.populateTemporality(ctx, params); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	results, _, err := aH.querierV2.QueryRange(ctx, params, nil)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, hosts.Rows(&req, results))
+}
+
+// getHostDetails returns the values of a host over the range with its top processes and its
+// latest logs
+func (aH *APIHandler) getHostDetails(w http.ResponseWriter, r *http.Request) {
+	var req hosts.DetailsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	ctx := r.Context()
+	params := hosts.BuildHostQuery(&req)
+	if apiErr := scopeQueryToOrg(ctx, params); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if err := aH.populateTemporality(ctx, params); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	results, _, err := aH.querierV2.QueryRange(ctx, params, nil)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	resp := hosts.Details(&req, results)
+
+	// the host is returned without the processes or the logs which couldn't be queried
+	params = hosts.BuildProcessesQuery(&req)
+	if apiErr := scopeQueryToOrg(ctx, params); apiErr != nil {
+		zap.L().Error("failed to scope the processes of the host", zap.Error(apiErr.Err))
+	} else if err := aH.populateTemporality(ctx, params); err != nil {
+		zap.L().Error("failed to get the temporality of the process metrics", zap.Error(err))
+	} else if results, _, err := aH.querierV2.QueryRange(ctx, params, nil); err != nil {
+		zap.L().Error("failed to query the processes of the host", zap.String("host", req.HostName), zap.Error(err))
+	} else {
+		resp.Processes = hosts.TopProcesses(results, req.ProcessesLimit)
+	}
+
+	params = hosts.BuildLogsQuery(&req)
+	if apiErr := scopeQueryToOrg(ctx, params); apiErr != nil {
+		zap.L().Error("failed to scope the logs of the host", zap.Error(apiErr.Err))
+	} else if results, _, err = aH.querierV2.QueryRange(ctx, params, nil); err != nil {
+		zap.L().Error("failed to query the logs of the host", zap.String("host", req.HostName), zap.Error(err))
+	} else if len(results) > 0 && results[0].List != nil {
+		resp.Logs = results[0].List
+	}
+	aH.Respond(w, resp)
+}
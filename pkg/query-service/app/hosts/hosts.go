@@ -0,0 +1,441 @@
+package hosts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+const (
+	DefaultLimit          = 50
+	MaxLimit              = 500
+	DefaultProcessesLimit = 10
+	DefaultLogsLimit      = 20
+	MaxDetailsLimit       = 100
+
+	// stepInterval is the step, in seconds, of the metrics queries, the values of the hosts
+	// are the values of the last step
+	stepInterval = 60
+)
+
+// Attributes of the hostmetrics metrics
+const (
+	HostNameKey       = "host_name"
+	ProcessPidKey     = "process_pid"
+	ProcessNameKey    = "process_executable_name"
+	ProcessCommandKey = "process_command"
+
+	// logsHostNameKey is the resource attribute of the logs with the name of their host
+	logsHostNameKey = "host.name"
+)
+
+// Column is a value of the hosts, computed from a hostmetrics metric
+type Column struct {
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+
+	metric           string
+	timeAggregation  v3.TimeAggregation
+	spaceAggregation v3.SpaceAggregation
+	// filter keeps the points of the metric with the state or the direction of the column
+	filter *v3.FilterItem
+}
+
+func attributeFilter(key string, op v3.FilterOperator, value string) *v3.FilterItem {
+	return &v3.FilterItem{Key: stringKey(key, v3.AttributeKeyTypeTag), Operator: op, Value: value}
+}
+
+// Columns are the values of the hosts. The CPU is in cores, the busy cores and all of them
+var Columns = []Column{
+	{Name: "cpu", Unit: "cores", metric: "system_cpu_time", timeAggregation: v3.TimeAggregationRate, spaceAggregation: v3.SpaceAggregationSum, filter: attributeFilter("state", v3.FilterOperatorNotEqual, "idle")},
+	{Name: "cpuTotal", Unit: "cores", metric: "system_cpu_time", timeAggregation: v3.TimeAggregationRate, spaceAggregation: v3.SpaceAggregationSum},
+	{Name: "load15", metric: "system_cpu_load_average_15m", timeAggregation: v3.TimeAggregationAvg, spaceAggregation: v3.SpaceAggregationMax},
+	{Name: "memory", Unit: "bytes", metric: "system_memory_usage", timeAggregation: v3.TimeAggregationAvg, spaceAggregation: v3.SpaceAggregationSum, filter: attributeFilter("state", v3.FilterOperatorEqual, "used")},
+	{Name: "memoryTotal", Unit: "bytes", metric: "system_memory_usage", timeAggregation: v3.TimeAggregationAvg, spaceAggregation: v3.SpaceAggregationSum},
+	{Name: "disk", Unit: "bytes", metric: "system_filesystem_usage", timeAggregation: v3.TimeAggregationAvg, spaceAggregation: v3.SpaceAggregationSum, filter: attributeFilter("state", v3.FilterOperatorEqual, "used")},
+	{Name: "diskTotal", Unit: "bytes", metric: "system_filesystem_usage", timeAggregation: v3.TimeAggregationAvg, spaceAggregation: v3.SpaceAggregationSum},
+	{Name: "networkReceive", Unit: "bytes/s", metric: "system_network_io", timeAggregation: v3.TimeAggregationRate, spaceAggregation: v3.SpaceAggregationSum, filter: attributeFilter("direction", v3.FilterOperatorEqual, "receive")},
+	{Name: "networkTransmit", Unit: "bytes/s", metric: "system_network_io", timeAggregation: v3.TimeAggregationRate, spaceAggregation: v3.SpaceAggregationSum, filter: attributeFilter("direction", v3.FilterOperatorEqual, "transmit")},
+}
+
+func columnIndex(name string) int {
+	for i, c := range Columns {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func validateRange(start, end int64) error {
+	if start <= 0 || end <= 0 || start >= end {
+		return fmt.Errorf("start and end are required and start must be before end")
+	}
+	return nil
+}
+
+// ListRequest lists the hosts, the range is in milliseconds
+type ListRequest struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	// Filters filter the metrics on their attributes, e.g. os_type
+	Filters *v3.FilterSet `json:"filters"`
+	// OrderBy is the column the hosts are sorted on, cpu by default
+	OrderBy string `json:"orderBy"`
+	Order   string `json:"order"`
+	Limit   int    `json:"limit"`
+	Offset  int    `json:"offset"`
+}
+
+func (r *ListRequest) Validate() error {
+	if err := validateRange(r.Start, r.End); err != nil {
+		return err
+	}
+	if r.Filters != nil {
+		if err := r.Filters.Validate(); err != nil {
+			return fmt.Errorf("invalid filters: %w", err)
+		}
+		// the filters of the columns are added to the filters
+		if r.Filters.Operator != "" && !strings.EqualFold(r.Filters.Operator, "AND") {
+			return fmt.Errorf("invalid filters operator %q, must be AND", r.Filters.Operator)
+		}
+	}
+	if r.OrderBy != "" && columnIndex(r.OrderBy) < 0 {
+		return fmt.Errorf("invalid orderBy %q", r.OrderBy)
+	}
+	if r.Order != "" && r.Order != "asc" && r.Order != "desc" {
+		return fmt.Errorf("invalid order %q, must be asc or desc", r.Order)
+	}
+	if r.Limit < 0 || r.Limit > MaxLimit {
+		return fmt.Errorf("limit must be between 0 and %d", MaxLimit)
+	}
+	if r.Offset < 0 {
+		return fmt.Errorf("offset can't be negative")
+	}
+	return nil
+}
+
+// DetailsRequest gets the values, the top processes and the latest logs of a host
+type DetailsRequest struct {
+	HostName string `json:"hostName"`
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	// ProcessesLimit is the number of processes, the processes using the most CPU first
+	ProcessesLimit int `json:"processesLimit"`
+	LogsLimit      int `json:"logsLimit"`
+}
+
+func (r *DetailsRequest) Validate() error {
+	if r.HostName == "" {
+		return fmt.Errorf("hostName is required")
+	}
+	if err := validateRange(r.Start, r.End); err != nil {
+		return err
+	}
+	if r.ProcessesLimit < 0 || r.ProcessesLimit > MaxDetailsLimit {
+		return fmt.Errorf("processesLimit must be between 0 and %d", MaxDetailsLimit)
+	}
+	if r.LogsLimit < 0 || r.LogsLimit > MaxDetailsLimit {
+		return fmt.Errorf("logsLimit must be between 0 and %d", MaxDetailsLimit)
+	}
+	if r.ProcessesLimit == 0 {
+		r.ProcessesLimit = DefaultProcessesLimit
+	}
+	if r.LogsLimit == 0 {
+		r.LogsLimit = DefaultLogsLimit
+	}
+	return nil
+}
+
+// Row is a host with the values of the columns
+type Row struct {
+	HostName string             `json:"hostName"`
+	Values   map[string]float64 `json:"values"`
+}
+
+type ListResponse struct {
+	Columns []Column `json:"columns"`
+	Rows    []*Row   `json:"rows"`
+	// Total is the number of hosts before the pagination
+	Total int `json:"total"`
+}
+
+// Process is a process of a host, the CPU is in cores and the memory is the resident memory
+type Process struct {
+	Pid     string  `json:"pid"`
+	Name    string  `json:"name"`
+	Command string  `json:"command,omitempty"`
+	CPU     float64 `json:"cpu"`
+	Memory  float64 `json:"memory"`
+}
+
+type DetailsResponse struct {
+	HostName string             `json:"hostName"`
+	Columns  []Column           `json:"columns"`
+	Values   map[string]float64 `json:"values"`
+	// Series are the points of the columns over the range, for the charts of the host
+	Series    map[string][]v3.Point `json:"series"`
+	Processes []*Process            `json:"processes"`
+	// Logs are the latest logs of the host
+	Logs []*v3.Row `json:"logs"`
+}
+
+func stringKey(key string, typ v3.AttributeKeyType) v3.AttributeKey {
+	return v3.AttributeKey{Key: key, DataType: v3.AttributeKeyDataTypeString, Type: typ}
+}
+
+// queryName returns the name of the query of the ith column, A for the first
+func queryName(i int) string {
+	return string(rune('A' + i))
+}
+
+// withFilter returns the filters with the item added
+func withFilter(filters *v3.FilterSet, item *v3.FilterItem) *v3.FilterSet {
+	result := &v3.FilterSet{Operator: "AND"}
+	if filters != nil {
+		result.Items = append(result.Items, filters.Items...)
+	}
+	if item != nil {
+		result.Items = append(result.Items, *item)
+	}
+	return result
+}
+
+func hostFilter(hostName string) *v3.FilterSet {
+	return withFilter(nil, attributeFilter(HostNameKey, v3.FilterOperatorEqual, hostName))
+}
+
+func metricsQuery(name, metric string, timeAggregation v3.TimeAggregation, spaceAggregation v3.SpaceAggregation, filters *v3.FilterSet, groupBy []v3.AttributeKey) *v3.BuilderQuery {
+	return &v3.BuilderQuery{
+		QueryName:  name,
+		Expression: name,
+		DataSource: v3.DataSourceMetrics,
+		AggregateAttribute: v3.AttributeKey{
+			Key:      metric,
+			DataType: v3.AttributeKeyDataTypeFloat64,
+			Type:     v3.AttributeKeyTypeUnspecified,
+			IsColumn: true,
+		},
+		TimeAggregation:  timeAggregation,
+		SpaceAggregation: spaceAggregation,
+		Filters:          filters,
+		GroupBy:          groupBy,
+		StepInterval:     stepInterval,
+	}
+}
+
+func graphParams(start, end int64, queries map[string]*v3.BuilderQuery) *v3.QueryRangeParamsV3 {
+	return &v3.QueryRangeParamsV3{
+		Start:   start,
+		End:     end,
+		Step:    stepInterval,
+		Version: "v4",
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType:      v3.QueryTypeBuilder,
+			PanelType:      v3.PanelTypeGraph,
+			BuilderQueries: queries,
+		},
+	}
+}
+
+// buildColumnsQuery returns one metrics query per column, the temporality of the metrics is
+// left to the caller
+func buildColumnsQuery(start, end int64, filters *v3.FilterSet) *v3.QueryRangeParamsV3 {
+	groupBy := []v3.AttributeKey{stringKey(HostNameKey, v3.AttributeKeyTypeTag)}
+	queries := map[string]*v3.BuilderQuery{}
+	for i, c := range Columns {
+		name := queryName(i)
+		queries[name] = metricsQuery(name, c.metric, c.timeAggregation, c.spaceAggregation, withFilter(filters, c.filter), groupBy)
+	}
+	return graphParams(start, end, queries)
+}
+
+// BuildQuery returns the query of the values of the hosts
+func BuildQuery(req *ListRequest) *v3.QueryRangeParamsV3 {
+	return buildColumnsQuery(req.Start, req.End, req.Filters)
+}
+
+// BuildHostQuery returns the query of the values of the host of the request
+func BuildHostQuery(req *DetailsRequest) *v3.QueryRangeParamsV3 {
+	return buildColumnsQuery(req.Start, req.End, hostFilter(req.HostName))
+}
+
+// columnSeries calls fn with the column and the series of the results of the query built by
+// buildColumnsQuery
+func columnSeries(results []*v3.Result, fn func(c Column, series *v3.Series)) {
+	for _, result := range results {
+		if len(result.QueryName) != 1 {
+			continue
+		}
+		i := int(result.QueryName[0] - 'A')
+		if i < 0 || i >= len(Columns) {
+			continue
+		}
+		for _, series := range result.Series {
+			if len(series.Points) > 0 {
+				fn(Columns[i], series)
+			}
+		}
+	}
+}
+
+// Rows returns the page of the hosts from the results of the query built by BuildQuery, the
+// hosts are sorted on the column of the request
+func Rows(req *ListRequest, results []*v3.Result) *ListResponse {
+	byHost := map[string]*Row{}
+	columnSeries(results, func(c Column, series *v3.Series) {
+		hostName := series.Labels[HostNameKey]
+		row, ok := byHost[hostName]
+		if !ok {
+			row = &Row{HostName: hostName, Values: map[string]float64{}}
+			byHost[hostName] = row
+		}
+		row.Values[c.Name] = last(series.Points).Value
+	})
+
+	rows := make([]*Row, 0, len(byHost))
+	for _, row := range byHost {
+		rows = append(rows, row)
+	}
+	sortRows(rows, req.OrderBy, req.Order)
+
+	resp := &ListResponse{Columns: Columns, Total: len(rows)}
+	limit := req.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	start := min(req.Offset, len(rows))
+	end := min(start+limit, len(rows))
+	resp.Rows = rows[start:end]
+	return resp
+}
+
+// Details returns the host from the results of the query built by BuildHostQuery, without
+// its processes and its logs
+func Details(req *DetailsRequest, results []*v3.Result) *DetailsResponse {
+	resp := &DetailsResponse{
+		HostName:  req.HostName,
+		Columns:   Columns,
+		Values:    map[string]float64{},
+		Series:    map[string][]v3.Point{},
+		Processes: []*Process{},
+		Logs:      []*v3.Row{},
+	}
+	columnSeries(results, func(c Column, series *v3.Series) {
+		points := append([]v3.Point{}, series.Points...)
+		sort.Slice(points, func(i, j int) bool { return points[i].Timestamp < points[j].Timestamp })
+		resp.Series[c.Name] = points
+		resp.Values[c.Name] = points[len(points)-1].Value
+	})
+	return resp
+}
+
+// last returns the latest point of the series
+func last(points []v3.Point) v3.Point {
+	latest := points[0]
+	for _, p := range points[1:] {
+		if p.Timestamp > latest.Timestamp {
+			latest = p
+		}
+	}
+	return latest
+}
+
+// sortRows sorts the rows on the column, the highest first by default, the rows without
+// value last. The ties are sorted on the host names
+func sortRows(rows []*Row, orderBy, order string) {
+	if orderBy == "" {
+		orderBy = Columns[0].Name
+	}
+	utils.SortByValue(rows,
+		func(row *Row) map[string]float64 { return row.Values },
+		func(row *Row) string { return row.HostName },
+		orderBy, order == "asc",
+	)
+}
+
+// BuildProcessesQuery returns the query of the CPU and the memory of the processes of the
+// host, the temporality of the metrics is left to the caller
+func BuildProcessesQuery(req *DetailsRequest) *v3.QueryRangeParamsV3 {
+	filters := hostFilter(req.HostName)
+	groupBy := []v3.AttributeKey{
+		stringKey(ProcessPidKey, v3.AttributeKeyTypeTag),
+		stringKey(ProcessNameKey, v3.AttributeKeyTypeTag),
+		stringKey(ProcessCommandKey, v3.AttributeKeyTypeTag),
+	}
+	return graphParams(req.Start, req.End, map[string]*v3.BuilderQuery{
+		"cpu":    metricsQuery("cpu", "process_cpu_time", v3.TimeAggregationRate, v3.SpaceAggregationSum, filters, groupBy),
+		"memory": metricsQuery("memory", "process_memory_usage", v3.TimeAggregationAvg, v3.SpaceAggregationSum, filters, groupBy),
+	})
+}
+
+// TopProcesses returns the processes using the most CPU from the results of the query built
+// by BuildProcessesQuery
+func TopProcesses(results []*v3.Result, limit int) []*Process {
+	byPid := map[string]*Process{}
+	for _, result := range results {
+		for _, series := range result.Series {
+			pid := series.Labels[ProcessPidKey]
+			if pid == "" || len(series.Points) == 0 {
+				continue
+			}
+			p, ok := byPid[pid]
+			if !ok {
+				p = &Process{Pid: pid, Name: series.Labels[ProcessNameKey], Command: series.Labels[ProcessCommandKey]}
+				byPid[pid] = p
+			}
+			switch result.QueryName {
+			case "cpu":
+				p.CPU = last(series.Points).Value
+			case "memory":
+				p.Memory = last(series.Points).Value
+			}
+		}
+	}
+
+	processes := make([]*Process, 0, len(byPid))
+	for _, p := range byPid {
+		processes = append(processes, p)
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		if processes[i].CPU != processes[j].CPU {
+			return processes[i].CPU > processes[j].CPU
+		}
+		if processes[i].Memory != processes[j].Memory {
+			return processes[i].Memory > processes[j].Memory
+		}
+		return processes[i].Pid < processes[j].Pid
+	})
+	return processes[:min(limit, len(processes))]
+}
+
+// BuildLogsQuery returns the query of the latest logs of the host
+func BuildLogsQuery(req *DetailsRequest) *v3.QueryRangeParamsV3 {
+	filters := withFilter(nil, &v3.FilterItem{
+		Key:      stringKey(logsHostNameKey, v3.AttributeKeyTypeResource),
+		Operator: v3.FilterOperatorEqual,
+		Value:    req.HostName,
+	})
+	return &v3.QueryRangeParamsV3{
+		Start: req.Start,
+		End:   req.End,
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeBuilder,
+			PanelType: v3.PanelTypeList,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {
+					QueryName:         "A",
+					Expression:        "A",
+					DataSource:        v3.DataSourceLogs,
+					AggregateOperator: v3.AggregateOperatorNoOp,
+					Filters:           filters,
+					OrderBy:           []v3.OrderBy{{ColumnName: "timestamp", Order: "desc"}},
+					PageSize:          uint64(req.LogsLimit),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package hosts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func series(labels map[string]string, values ...float64) *v3.Series {
+	s := &v3.Series{Labels: labels}
+	for i, value := range values {
+		s.Points = append(s.Points, v3.Point{Timestamp: int64(i + 1), Value: value})
+	}
+	return s
+}
+
+func host(name string) map[string]string {
+	return map[string]string{HostNameKey: name}
+}
+
+func TestBuildQuery(t *testing.T) {
+	filters := &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+		{Key: stringKey("os_type", v3.AttributeKeyTypeTag), Operator: v3.FilterOperatorEqual, Value: "linux"},
+	}}
+	req := &ListRequest{Start: 1700000000000, End: 1700003600000, Filters: filters}
+	require.NoError(t, req.Validate())
+
+	params := BuildQuery(req)
+	require.Len(t, params.CompositeQuery.BuilderQueries, len(Columns))
+	require.NoError(t, params.CompositeQuery.Validate())
+
+	// the filter of the column is added to the filters of the request
+	cpu := params.CompositeQuery.BuilderQueries["A"]
+	require.Equal(t, "system_cpu_time", cpu.AggregateAttribute.Key)
+	require.Len(t, cpu.Filters.Items, 2)
+	require.Equal(t, "state", cpu.Filters.Items[1].Key.Key)
+	require.Len(t, params.CompositeQuery.BuilderQueries["B"].Filters.Items, 1)
+	require.Len(t, filters.Items, 1)
+
+	require.Error(t, (&ListRequest{Start: 1, End: 2, OrderBy: "restarts"}).Validate())
+	require.Error(t, (&ListRequest{Start: 1, End: 2, Filters: &v3.FilterSet{Operator: "OR"}}).Validate())
+	require.NoError(t, (&ListRequest{Start: 1, End: 2, OrderBy: "memory", Order: "asc"}).Validate())
+}
+
+func TestRows(t *testing.T) {
+	results := []*v3.Result{
+		{QueryName: "A", Series: []*v3.Series{
+			series(host("web-1"), 2, 0.5),
+			series(host("web-2"), 1.5),
+			series(host("db-1"), 3.5),
+		}},
+		{QueryName: "D", Series: []*v3.Series{
+			series(host("web-1"), 1024),
+			series(host("db-1"), 4096),
+		}},
+	}
+
+	resp := Rows(&ListRequest{}, results)
+	require.Equal(t, 3, resp.Total)
+	require.Equal(t, []string{"db-1", "web-2", "web-1"}, []string{resp.Rows[0].HostName, resp.Rows[1].HostName, resp.Rows[2].HostName})
+	require.Equal(t, map[string]float64{"cpu": 0.5, "memory": 1024}, resp.Rows[2].Values)
+
+	// the hosts without memory come last
+	resp = Rows(&ListRequest{OrderBy: "memory", Order: "asc", Limit: 2, Offset: 1}, results)
+	require.Len(t, resp.Rows, 2)
+	require.Equal(t, "db-1", resp.Rows[0].HostName)
+	require.Equal(t, "web-2", resp.Rows[1].HostName)
+}
+
+func TestDetails(t *testing.T) {
+	req := &DetailsRequest{HostName: "web-1", Start: 1700000000000, End: 1700003600000}
+	require.NoError(t, req.Validate())
+	require.Equal(t, DefaultProcessesLimit, req.ProcessesLimit)
+
+	for _, params := range []*v3.QueryRangeParamsV3{BuildHostQuery(req), BuildProcessesQuery(req), BuildLogsQuery(req)} {
+		require.NoError(t, params.CompositeQuery.Validate())
+	}
+	require.Equal(t, v3.PanelTypeList, BuildLogsQuery(req).CompositeQuery.PanelType)
+
+	resp := Details(req, []*v3.Result{{QueryName: "A", Series: []*v3.Series{series(host("web-1"), 1, 2, 0.5)}}})
+	require.Equal(t, 0.5, resp.Values["cpu"])
+	require.Len(t, resp.Series["cpu"], 3)
+
+	labels := func(pid, name string) map[string]string {
+		return map[string]string{HostNameKey: "web-1", ProcessPidKey: pid, ProcessNameKey: name}
+	}
+	processes := TopProcesses([]*v3.Result{
+		{QueryName: "cpu", Series: []*v3.Series{series(labels("1", "init"), 0.01), series(labels("42", "java"), 1.2), series(labels("7", "nginx"), 0.3)}},
+		{QueryName: "memory", Series: []*v3.Series{series(labels("42", "java"), 2048), series(labels("9", "sshd"), 512)}},
+	}, 2)
+	require.Len(t, processes, 2)
+	require.Equal(t, &Process{Pid: "42", Name: "java", CPU: 1.2, Memory: 2048}, processes[0])
+	require.Equal(t, "nginx", processes[1].Name)
+
+	require.Error(t, (&DetailsRequest{Start: 1, End: 2}).Validate())
+}
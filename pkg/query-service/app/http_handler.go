@@ -3,6 +3,7 @@ package app
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"regexp"
 	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,22 +21,57 @@ import (
 	"github.com/gorilla/mux"
 	jsoniter "github.com/json-iterator/go"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/prometheus/promql"
 
 	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/app/access"
+	"go.signoz.io/signoz/pkg/query-service/app/alerthistory"
+	"go.signoz.io/signoz/pkg/query-service/app/attributemetadata"
+	"go.signoz.io/signoz/pkg/query-service/app/audit"
+	"go.signoz.io/signoz/pkg/query-service/app/backup"
+	"go.signoz.io/signoz/pkg/query-service/app/cardinality"
+	"go.signoz.io/signoz/pkg/query-service/app/correlation"
 	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/app/deployments"
+	"go.signoz.io/signoz/pkg/query-service/app/errortracking"
+	"go.signoz.io/signoz/pkg/query-service/app/events"
 	"go.signoz.io/signoz/pkg/query-service/app/explorer"
+	"go.signoz.io/signoz/pkg/query-service/app/heartbeats"
 	"go.signoz.io/signoz/pkg/query-service/app/integrations"
+	"go.signoz.io/signoz/pkg/query-service/app/issues"
+	"go.signoz.io/signoz/pkg/query-service/app/logexports"
+	"go.signoz.io/signoz/pkg/query-service/app/logmetrics"
 	"go.signoz.io/signoz/pkg/query-service/app/logs"
+	"go.signoz.io/signoz/pkg/query-service/app/logs/elasticsearch"
+	"go.signoz.io/signoz/pkg/query-service/app/logs/loki"
 	logsv3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
+	"go.signoz.io/signoz/pkg/query-service/app/logviews"
 	"go.signoz.io/signoz/pkg/query-service/app/metrics"
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/remotewrite"
 	metricsv3 "go.signoz.io/signoz/pkg/query-service/app/metrics/v3"
+	"go.signoz.io/signoz/pkg/query-service/app/openapi"
+	"go.signoz.io/signoz/pkg/query-service/app/otlp"
+	"go.signoz.io/signoz/pkg/query-service/app/profiles"
 	"go.signoz.io/signoz/pkg/query-service/app/querier"
 	querierV2 "go.signoz.io/signoz/pkg/query-service/app/querier/v2"
 	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	"go.signoz.io/signoz/pkg/query-service/app/queryhistory"
+	"go.signoz.io/signoz/pkg/query-service/app/reports"
+	"go.signoz.io/signoz/pkg/query-service/app/sampling"
+	"go.signoz.io/signoz/pkg/query-service/app/services"
+	"go.signoz.io/signoz/pkg/query-service/app/share"
+	"go.signoz.io/signoz/pkg/query-service/app/skipindexes"
+	"go.signoz.io/signoz/pkg/query-service/app/spanmetrics"
+	"go.signoz.io/signoz/pkg/query-service/app/synthetics"
+	"go.signoz.io/signoz/pkg/query-service/app/traces/funnels"
+	"go.signoz.io/signoz/pkg/query-service/app/traces/rum"
 	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
+	"go.signoz.io/signoz/pkg/query-service/app/traces/zipkin"
+	"go.signoz.io/signoz/pkg/query-service/app/webhooks"
 	"go.signoz.io/signoz/pkg/query-service/auth"
 	"go.signoz.io/signoz/pkg/query-service/cache"
+	"go.signoz.io/signoz/pkg/query-service/common"
 	"go.signoz.io/signoz/pkg/query-service/constants"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/postprocess"
@@ -42,13 +79,16 @@ import (
 	"go.uber.org/zap"
 
 	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
+	"go.signoz.io/signoz/pkg/query-service/app/logpatterns"
 	"go.signoz.io/signoz/pkg/query-service/dao"
 	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
 	signozio "go.signoz.io/signoz/pkg/query-service/integrations/signozio"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/migrate/schema"
 	"go.signoz.io/signoz/pkg/query-service/model"
 	"go.signoz.io/signoz/pkg/query-service/rules"
 	"go.signoz.io/signoz/pkg/query-service/telemetry"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 	"go.signoz.io/signoz/pkg/query-service/version"
 )
 
@@ -93,6 +133,69 @@ type APIHandler struct {
 
 	LogsParsingPipelineController *logparsingpipeline.LogParsingPipelineController
 
+	SpanMetricsController *spanmetrics.SpanMetricsController
+
+	SamplingController *sampling.SamplingController
+
+	CardinalityController *cardinality.CardinalityController
+
+	SyntheticsController *synthetics.SyntheticsController
+
+	HeartbeatRepo *heartbeats.Repo
+
+	ReportManager *reports.Manager
+
+	LogExportManager *logexports.Manager
+
+	LogsViewManager *logviews.Manager
+
+	SkipIndexManager *skipindexes.Manager
+
+	SchemaMigrator *schema.Migrator
+
+	BackupManager *backup.Manager
+
+	QueryHistoryManager *queryhistory.Manager
+
+	AuditManager *audit.Manager
+
+	WebhookManager *webhooks.Manager
+
+	IssueManager *issues.Manager
+
+	ErrorTrackingManager *errortracking.Manager
+
+	LogMetricsManager *logmetrics.Manager
+
+	DeploymentManager *deployments.Manager
+
+	ProfileManager *profiles.Manager
+
+	EventManager *events.Manager
+
+	AttributeMetadataManager *attributemetadata.Manager
+
+	AlertHistoryManager *alerthistory.Manager
+
+	// remoteWriter writes the samples received from the Prometheus servers
+	remoteWriter *remotewrite.Writer
+	// lokiWriter and lokiQuerier serve the Loki compatible API on the logs table
+	lokiWriter  *loki.Writer
+	lokiQuerier *loki.Querier
+	// esQuerier serves the Elasticsearch compatible search API on the logs table
+	esQuerier *elasticsearch.Querier
+	// zipkinWriter writes the spans received from the Zipkin libraries
+	zipkinWriter *zipkin.Writer
+	// rumWriter and rumQuerier serve the page views of the browsers, stored as spans
+	rumWriter  *rum.Writer
+	rumQuerier *rum.Querier
+	// otlpWriter writes the telemetry received from the OpenTelemetry SDKs, only set when
+	// the OTLP receiver is enabled
+	otlpWriter *otlp.Writer
+
+	// openAPI describes the routes in the OpenAPI document served by the API
+	openAPI *openapi.Registry
+
 	// SetupCompleted indicates if SigNoz is ready for general use.
 	// at the moment, we mark the app ready when the first user
 	// is registers.
@@ -108,6 +211,9 @@ type APIHandlerOpts struct {
 
 	PreferSpanMetrics bool
 
+	// OTLPReceiver serves the OTLP/HTTP endpoints, for the installs without a collector
+	OTLPReceiver bool
+
 	MaxIdleConns int
 	MaxOpenConns int
 	DialTimeout  time.Duration
@@ -127,6 +233,72 @@ type APIHandlerOpts struct {
 	// Log parsing pipelines
 	LogsParsingPipelineController *logparsingpipeline.LogParsingPipelineController
 
+	// Span metrics dimensions and buckets
+	SpanMetricsController *spanmetrics.SpanMetricsController
+
+	// Tail sampling rules
+	SamplingController *sampling.SamplingController
+
+	// Metric cardinality limits
+	CardinalityController *cardinality.CardinalityController
+
+	// Synthetic checks run by the agents
+	SyntheticsController *synthetics.SyntheticsController
+
+	// Heartbeat monitors alerting on missing signals
+	HeartbeatRepo *heartbeats.Repo
+
+	// Scheduled dashboard reports
+	ReportManager *reports.Manager
+
+	// Scheduled log exports to object storage
+	LogExportManager *logexports.Manager
+
+	// Materialized views and projections of the logs
+	LogsViewManager *logviews.Manager
+
+	// Skip indexes on the attributes of the logs and the spans
+	SkipIndexManager *skipindexes.Manager
+
+	// Versioned migrations of the sqlite and clickhouse schemas
+	SchemaMigrator *schema.Migrator
+
+	// Backup and restore of the metadata store
+	BackupManager *backup.Manager
+
+	// History of the queries run by the users
+	QueryHistoryManager *queryhistory.Manager
+
+	// Audit log of the administrative actions
+	AuditManager *audit.Manager
+
+	// Outbound webhooks of the lifecycle events
+	WebhookManager *webhooks.Manager
+
+	// Jira and GitHub issues of the alerts and the exceptions
+	IssueManager *issues.Manager
+
+	// Status, assignee and ignore rules of the groups of exceptions
+	ErrorTrackingManager *errortracking.Manager
+
+	// Rules deriving metrics from the logs
+	LogMetricsManager *logmetrics.Manager
+
+	// Deployments of the services overlaid on the charts
+	DeploymentManager *deployments.Manager
+
+	// Samples of the pprof profiles of the services
+	ProfileManager *profiles.Manager
+
+	// Structured events sent by the services
+	EventManager *events.Manager
+
+	// Metadata of the attributes of the logs and the spans the autocomplete is served from
+	AttributeMetadataManager *attributemetadata.Manager
+
+	// State changes of the alerts
+	AlertHistoryManager *alerthistory.Manager
+
 	// cache
 	Cache cache.Cache
 
@@ -174,9 +346,44 @@ func NewAPIHandler(opts APIHandlerOpts) (*APIHandler, error) {
 		ruleManager:                   opts.RuleManager,
 		featureFlags:                  opts.FeatureFlags,
 		IntegrationsController:        opts.IntegrationsController,
+		ReportManager:                 opts.ReportManager,
+		LogExportManager:              opts.LogExportManager,
+		LogsViewManager:               opts.LogsViewManager,
+		SkipIndexManager:              opts.SkipIndexManager,
+		SchemaMigrator:                opts.SchemaMigrator,
+		BackupManager:                 opts.BackupManager,
+		QueryHistoryManager:           opts.QueryHistoryManager,
+		AuditManager:                  opts.AuditManager,
+		WebhookManager:                opts.WebhookManager,
+		IssueManager:                  opts.IssueManager,
+		ErrorTrackingManager:          opts.ErrorTrackingManager,
+		LogMetricsManager:             opts.LogMetricsManager,
+		DeploymentManager:             opts.DeploymentManager,
+		ProfileManager:                opts.ProfileManager,
+		EventManager:                  opts.EventManager,
+		AttributeMetadataManager:      opts.AttributeMetadataManager,
+		AlertHistoryManager:           opts.AlertHistoryManager,
 		LogsParsingPipelineController: opts.LogsParsingPipelineController,
+		SpanMetricsController:         opts.SpanMetricsController,
+		SamplingController:            opts.SamplingController,
+		CardinalityController:         opts.CardinalityController,
+		SyntheticsController:          opts.SyntheticsController,
+		HeartbeatRepo:                 opts.HeartbeatRepo,
 		querier:                       querier,
 		querierV2:                     querierv2,
+		openAPI:                       newOpenAPIRegistry(),
+	}
+	if opts.Reader != nil {
+		aH.remoteWriter = remotewrite.NewWriter(opts.Reader.GetConn())
+		aH.lokiWriter = loki.NewWriter(opts.Reader.GetConn())
+		aH.lokiQuerier = loki.NewQuerier(opts.Reader.GetConn())
+		aH.esQuerier = elasticsearch.NewQuerier(opts.Reader.GetConn())
+		aH.zipkinWriter = zipkin.NewWriter(opts.Reader.GetConn())
+		aH.rumWriter = rum.NewWriter(opts.Reader.GetConn())
+		aH.rumQuerier = rum.NewQuerier(opts.Reader.GetConn())
+		if opts.OTLPReceiver {
+			aH.otlpWriter = otlp.NewWriter(opts.Reader.GetConn())
+		}
 	}
 
 	builderOpts := queryBuilder.QueryBuilderOptions{
@@ -240,32 +447,41 @@ func RespondError(w http.ResponseWriter, apiErr model.BaseApiError, data interfa
 		return
 	}
 
-	var code int
-	switch apiErr.Type() {
+	code := errorStatusCode(apiErr.Type())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if n, err := w.Write(b); err != nil {
+		zap.L().Error("error writing response", zap.Int("bytesWritten", n), zap.Error(err))
+	}
+}
+
+// errorStatusCode returns the status code of the responses of the errors of the type
+func errorStatusCode(typ model.ErrorType) int {
+	switch typ {
 	case model.ErrorBadData:
-		code = http.StatusBadRequest
+		return http.StatusBadRequest
 	case model.ErrorExec:
-		code = 422
+		return 422
 	case model.ErrorCanceled, model.ErrorTimeout:
-		code = http.StatusServiceUnavailable
+		return http.StatusServiceUnavailable
 	case model.ErrorInternal:
-		code = http.StatusInternalServerError
+		return http.StatusInternalServerError
 	case model.ErrorNotFound:
-		code = http.StatusNotFound
+		return http.StatusNotFound
 	case model.ErrorNotImplemented:
-		code = http.StatusNotImplemented
+		return http.StatusNotImplemented
 	case model.ErrorUnauthorized:
-		code = http.StatusUnauthorized
+		return http.StatusUnauthorized
 	case model.ErrorForbidden:
-		code = http.StatusForbidden
+		return http.StatusForbidden
+	case model.ErrorConflict:
+		return http.StatusConflict
+	case model.ErrorPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case model.ErrorTooManyRequests:
+		return http.StatusTooManyRequests
 	default:
-		code = http.StatusInternalServerError
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	if n, err := w.Write(b); err != nil {
-		zap.L().Error("error writing response", zap.Int("bytesWritten", n), zap.Error(err))
+		return http.StatusInternalServerError
 	}
 }
 
@@ -296,8 +512,11 @@ func (aH *APIHandler) RegisterQueryRangeV3Routes(router *mux.Router, am *AuthMid
 		withCacheControl(AutoCompleteCacheControlAge, aH.autoCompleteAttributeKeys))).Methods(http.MethodGet)
 	subRouter.HandleFunc("/autocomplete/attribute_values", am.ViewAccess(
 		withCacheControl(AutoCompleteCacheControlAge, aH.autoCompleteAttributeValues))).Methods(http.MethodGet)
+	subRouter.HandleFunc("/autocomplete/filter_operators", am.ViewAccess(
+		withCacheControl(AutoCompleteCacheControlAge, aH.autoCompleteFilterOperators))).Methods(http.MethodGet)
 	subRouter.HandleFunc("/query_range", am.ViewAccess(aH.QueryRangeV3)).Methods(http.MethodPost)
 	subRouter.HandleFunc("/query_range/format", am.ViewAccess(aH.QueryRangeV3Format)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/query_range/estimate", am.ViewAccess(aH.estimateQueryRangeCost)).Methods(http.MethodPost)
 
 	// live logs
 	subRouter.HandleFunc("/logs/livetail", am.ViewAccess(aH.liveTailLogs)).Methods(http.MethodGet)
@@ -307,6 +526,10 @@ func (aH *APIHandler) RegisterQueryRangeV4Routes(router *mux.Router, am *AuthMid
 	subRouter := router.PathPrefix("/api/v4").Subrouter()
 	subRouter.HandleFunc("/query_range", am.ViewAccess(aH.QueryRangeV4)).Methods(http.MethodPost)
 	subRouter.HandleFunc("/metric/metric_metadata", am.ViewAccess(aH.getMetricMetadata)).Methods(http.MethodGet)
+	aH.openAPI.Describe(http.MethodPost, "/api/v4/query_range", openapi.Route{
+		Summary: "Query the metrics, the traces and the logs", Tags: []string{"query"},
+		Request: v3.QueryRangeParamsV3{}, Response: v3.QueryRangeResponse{},
+	})
 }
 
 func (aH *APIHandler) Respond(w http.ResponseWriter, data interface{}) {
@@ -316,28 +539,46 @@ func (aH *APIHandler) Respond(w http.ResponseWriter, data interface{}) {
 // RegisterPrivateRoutes registers routes for this handler on the given router
 func (aH *APIHandler) RegisterPrivateRoutes(router *mux.Router) {
 	router.HandleFunc("/api/v1/channels", aH.listChannels).Methods(http.MethodGet)
+	// the self monitoring metrics, like those of the rule evaluations
+	router.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
 }
 
 // RegisterRoutes registers routes for this handler on the given router
 func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 	router.HandleFunc("/api/v1/query_range", am.ViewAccess(aH.queryRangeMetrics)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/query", am.ViewAccess(aH.queryMetrics)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/channels", am.ViewAccess(aH.listChannels)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/channels/{id}", am.ViewAccess(aH.getChannel)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/channels/{id}", am.AdminAccess(aH.editChannel)).Methods(http.MethodPut)
-	router.HandleFunc("/api/v1/channels/{id}", am.AdminAccess(aH.deleteChannel)).Methods(http.MethodDelete)
-	router.HandleFunc("/api/v1/channels", am.EditAccess(aH.createChannel)).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/testChannel", am.EditAccess(aH.testChannel)).Methods(http.MethodPost)
-
-	router.HandleFunc("/api/v1/alerts", am.ViewAccess(aH.getAlerts)).Methods(http.MethodGet)
-
-	router.HandleFunc("/api/v1/rules", am.ViewAccess(aH.listRules)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/rules/{id}", am.ViewAccess(aH.getRule)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/rules", am.EditAccess(aH.createRule)).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.editRule)).Methods(http.MethodPut)
-	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.deleteRule)).Methods(http.MethodDelete)
-	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.patchRule)).Methods(http.MethodPatch)
-	router.HandleFunc("/api/v1/testRule", am.EditAccess(aH.testRule)).Methods(http.MethodPost)
+	aH.registerPromAPIRoutes(router, am)
+	aH.registerLokiAPIRoutes(router, am)
+	aH.registerJaegerAPIRoutes(router, am)
+	aH.registerElasticsearchAPIRoutes(router, am)
+	aH.registerGraphQLRoutes(router, am)
+	router.HandleFunc("/api/v1/channels", am.Access(model.ResourceChannels, model.ActionRead, aH.listChannels)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/channels/{id}", am.Access(model.ResourceChannels, model.ActionRead, aH.getChannel)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/channels/{id}", am.Access(model.ResourceChannels, model.ActionUpdate, aH.editChannel)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/channels/{id}", am.Access(model.ResourceChannels, model.ActionDelete, aH.deleteChannel)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/channels", am.Access(model.ResourceChannels, model.ActionCreate, aH.createChannel)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/testChannel", am.Access(model.ResourceChannels, model.ActionCreate, aH.testChannel)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/channels/{id}/test", am.Access(model.ResourceChannels, model.ActionCreate, aH.testSavedChannel)).Methods(http.MethodPost)
+	// the incident management tools call back the channels, the callbacks are authenticated
+	// with the secrets of the channel
+	router.HandleFunc("/api/v1/channels/{id}/callbacks/pagerduty", am.OpenAccess(aH.pagerDutyCallback)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/channels/{id}/callbacks/opsgenie", am.OpenAccess(aH.opsgenieCallback)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/notification_templates/preview", am.Access(model.ResourceChannels, model.ActionCreate, aH.previewNotificationTemplate)).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/alerts", am.Access(model.ResourceAlerts, model.ActionRead, aH.getAlerts)).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/v1/rules", am.Access(model.ResourceAlerts, model.ActionRead, aH.listRules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/test", am.Access(model.ResourceAlerts, model.ActionCreate, aH.previewRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/evaluations", am.Access(model.ResourceAlerts, model.ActionRead, aH.listRuleEvaluations)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}", am.Access(model.ResourceAlerts, model.ActionRead, aH.getRule)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules", am.Access(model.ResourceAlerts, model.ActionCreate, aH.createRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}", am.Access(model.ResourceAlerts, model.ActionUpdate, aH.editRule)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/rules/{id}", am.Access(model.ResourceAlerts, model.ActionDelete, aH.deleteRule)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/rules/{id}", am.Access(model.ResourceAlerts, model.ActionUpdate, aH.patchRule)).Methods(http.MethodPatch)
+	router.HandleFunc("/api/v1/testRule", am.Access(model.ResourceAlerts, model.ActionCreate, aH.testRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}/acl", am.Access(model.ResourceAlerts, model.ActionRead, aH.getResourceACL(access.ACLResourceRule, "id"))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}/acl", am.Access(model.ResourceAlerts, model.ActionUpdate, aH.setResourceACL(access.ACLResourceRule, "id"))).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/rules/{id}/acl", am.Access(model.ResourceAlerts, model.ActionUpdate, aH.deleteResourceACL(access.ACLResourceRule, "id"))).Methods(http.MethodDelete)
 
 	router.HandleFunc("/api/v1/downtime_schedules", am.OpenAccess(aH.listDowntimeSchedules)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.OpenAccess(aH.getDowntimeSchedule)).Methods(http.MethodGet)
@@ -345,20 +586,114 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.OpenAccess(aH.editDowntimeSchedule)).Methods(http.MethodPut)
 	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.OpenAccess(aH.deleteDowntimeSchedule)).Methods(http.MethodDelete)
 
-	router.HandleFunc("/api/v1/dashboards", am.ViewAccess(aH.getDashboards)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/dashboards", am.EditAccess(aH.createDashboards)).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/dashboards/grafana", am.EditAccess(aH.createDashboardsTransform)).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/dashboards/{uuid}", am.ViewAccess(aH.getDashboard)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/dashboards/{uuid}", am.EditAccess(aH.updateDashboard)).Methods(http.MethodPut)
-	router.HandleFunc("/api/v1/dashboards/{uuid}", am.EditAccess(aH.deleteDashboard)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/escalation_policies", am.Access(model.ResourceAlerts, model.ActionRead, aH.listEscalationPolicies)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/escalation_policies/{id}", am.Access(model.ResourceAlerts, model.ActionRead, aH.getEscalationPolicy)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/escalation_policies", am.Access(model.ResourceAlerts, model.ActionCreate, aH.createEscalationPolicy)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/escalation_policies/{id}", am.Access(model.ResourceAlerts, model.ActionUpdate, aH.editEscalationPolicy)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/escalation_policies/{id}", am.Access(model.ResourceAlerts, model.ActionDelete, aH.deleteEscalationPolicy)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/alerts/ack", am.Access(model.ResourceAlerts, model.ActionUpdate, aH.ackAlerts)).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/alert_history", am.Access(model.ResourceAlerts, model.ActionRead, aH.listAlertHistory)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/alert_history/stats", am.Access(model.ResourceAlerts, model.ActionRead, aH.getAlertStats)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/alert_history/noisiest", am.Access(model.ResourceAlerts, model.ActionRead, aH.getNoisiestAlerts)).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/v1/silences", am.Access(model.ResourceAlerts, model.ActionRead, aH.listSilences)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/silences/{id}", am.Access(model.ResourceAlerts, model.ActionRead, aH.getSilence)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/silences", am.Access(model.ResourceAlerts, model.ActionCreate, aH.createSilence)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/silences/{id}", am.Access(model.ResourceAlerts, model.ActionUpdate, aH.editSilence)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/silences/{id}/expire", am.Access(model.ResourceAlerts, model.ActionUpdate, aH.expireSilence)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/silences/{id}", am.Access(model.ResourceAlerts, model.ActionDelete, aH.deleteSilence)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/api/v1/recording_rules", am.Access(model.ResourceAlerts, model.ActionRead, aH.listRecordingRules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/recording_rules/{id}", am.Access(model.ResourceAlerts, model.ActionRead, aH.getRecordingRule)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/recording_rules", am.Access(model.ResourceAlerts, model.ActionCreate, aH.createRecordingRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/recording_rules/{id}", am.Access(model.ResourceAlerts, model.ActionUpdate, aH.editRecordingRule)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/recording_rules/{id}", am.Access(model.ResourceAlerts, model.ActionDelete, aH.deleteRecordingRule)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/slos", am.Access(model.ResourceAlerts, model.ActionRead, aH.listSLOs)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/slos/{id}", am.Access(model.ResourceAlerts, model.ActionRead, aH.getSLO)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/slos", am.Access(model.ResourceAlerts, model.ActionCreate, aH.createSLO)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/slos/{id}", am.Access(model.ResourceAlerts, model.ActionUpdate, aH.editSLO)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/slos/{id}", am.Access(model.ResourceAlerts, model.ActionDelete, aH.deleteSLO)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/slos/{id}/status", am.Access(model.ResourceAlerts, model.ActionRead, aH.getSLOStatus)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/slos/{id}/burn_rates", am.Access(model.ResourceAlerts, model.ActionRead, aH.getSLOBurnRates)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/slos/{id}/alerts", am.Access(model.ResourceAlerts, model.ActionCreate, aH.generateSLOAlerts)).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/rules/import/prometheus", am.Access(model.ResourceAlerts, model.ActionCreate, aH.importPromRules)).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/reports", am.Access(model.ResourceReports, model.ActionRead, aH.listReportSchedules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/reports/{id}", am.Access(model.ResourceReports, model.ActionRead, aH.getReportSchedule)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/reports", am.Access(model.ResourceReports, model.ActionCreate, aH.createReportSchedule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/reports/{id}", am.Access(model.ResourceReports, model.ActionUpdate, aH.editReportSchedule)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/reports/{id}", am.Access(model.ResourceReports, model.ActionDelete, aH.deleteReportSchedule)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/reports/{id}/run", am.Access(model.ResourceReports, model.ActionUpdate, aH.runReportSchedule)).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/logs/exports", am.Access(model.ResourceLogExports, model.ActionRead, aH.listLogExports)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/logs/exports/{id}", am.Access(model.ResourceLogExports, model.ActionRead, aH.getLogExport)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/logs/exports", am.Access(model.ResourceLogExports, model.ActionCreate, aH.createLogExport)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/logs/exports/{id}", am.Access(model.ResourceLogExports, model.ActionUpdate, aH.editLogExport)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/logs/exports/{id}", am.Access(model.ResourceLogExports, model.ActionDelete, aH.deleteLogExport)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/logs/exports/{id}/run", am.Access(model.ResourceLogExports, model.ActionUpdate, aH.runLogExport)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/logs/metric_rules", am.Access(model.ResourceLogMetrics, model.ActionRead, aH.listLogMetricRules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/logs/metric_rules", am.Access(model.ResourceLogMetrics, model.ActionCreate, aH.createLogMetricRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/logs/metric_rules/{id}", am.Access(model.ResourceLogMetrics, model.ActionRead, aH.getLogMetricRule)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/logs/metric_rules/{id}", am.Access(model.ResourceLogMetrics, model.ActionUpdate, aH.editLogMetricRule)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/logs/metric_rules/{id}", am.Access(model.ResourceLogMetrics, model.ActionDelete, aH.deleteLogMetricRule)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/logs/views", am.AdminAccess(defaultOrgOnly(aH.listLogsViews))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/logs/views/{id}", am.AdminAccess(defaultOrgOnly(aH.getLogsView))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/logs/views", am.AdminAccess(defaultOrgOnly(aH.createLogsView))).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/logs/views/{id}", am.AdminAccess(defaultOrgOnly(aH.deleteLogsView))).Methods(http.MethodDelete)
+
+	router.HandleFunc("/api/v1/indexes", am.AdminAccess(defaultOrgOnly(aH.listSkipIndexes))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/indexes", am.AdminAccess(defaultOrgOnly(aH.createSkipIndex))).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/indexes/estimate", am.AdminAccess(defaultOrgOnly(aH.estimateSkipIndex))).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/indexes/{id}", am.AdminAccess(defaultOrgOnly(aH.getSkipIndex))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/indexes/{id}", am.AdminAccess(defaultOrgOnly(aH.deleteSkipIndex))).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/indexes/{id}/materialize", am.AdminAccess(defaultOrgOnly(aH.materializeSkipIndex))).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/migrations", am.AdminAccess(defaultOrgOnly(aH.getSchemaMigrations))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/migrations/up", am.AdminAccess(defaultOrgOnly(aH.applySchemaMigrations))).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/migrations/down", am.AdminAccess(defaultOrgOnly(aH.rollbackSchemaMigrations))).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/backup", am.AdminAccess(defaultOrgOnly(aH.exportBackup))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/backup/restore", am.AdminAccess(defaultOrgOnly(aH.restoreBackup))).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/dashboards", am.Access(model.ResourceDashboards, model.ActionRead, aH.getDashboards)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/dashboards", am.Access(model.ResourceDashboards, model.ActionCreate, aH.createDashboards)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/dashboards/grafana", am.Access(model.ResourceDashboards, model.ActionCreate, aH.createDashboardsTransform)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/dashboards/grafana/import", am.Access(model.ResourceDashboards, model.ActionCreate, aH.importGrafanaDashboard)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/dashboards/folders", am.Access(model.ResourceDashboards, model.ActionRead, aH.listDashboardFolders)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/dashboards/folders", am.Access(model.ResourceDashboards, model.ActionCreate, aH.createDashboardFolder)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/dashboards/folders/{id}", am.Access(model.ResourceDashboards, model.ActionUpdate, aH.updateDashboardFolder)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/dashboards/folders/{id}", am.Access(model.ResourceDashboards, model.ActionDelete, aH.deleteDashboardFolder)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/dashboards/folders/{id}/dashboards", am.Access(model.ResourceDashboards, model.ActionRead, aH.listFolderDashboards)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/dashboards/{uuid}", am.Access(model.ResourceDashboards, model.ActionRead, aH.getDashboard)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/dashboards/{uuid}", am.Access(model.ResourceDashboards, model.ActionUpdate, aH.updateDashboard)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/dashboards/{uuid}", am.Access(model.ResourceDashboards, model.ActionDelete, aH.deleteDashboard)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/dashboards/{uuid}/folder", am.Access(model.ResourceDashboards, model.ActionUpdate, aH.moveDashboard)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/dashboards/{uuid}/acl", am.Access(model.ResourceDashboards, model.ActionRead, aH.getResourceACL(access.ACLResourceDashboard, "uuid"))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/dashboards/{uuid}/acl", am.Access(model.ResourceDashboards, model.ActionUpdate, aH.setResourceACL(access.ACLResourceDashboard, "uuid"))).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/dashboards/{uuid}/acl", am.Access(model.ResourceDashboards, model.ActionUpdate, aH.deleteResourceACL(access.ACLResourceDashboard, "uuid"))).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/dashboards/{uuid}/share_links", am.Access(model.ResourceDashboards, model.ActionRead, aH.listShareLinks)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/dashboards/{uuid}/share_links", am.Access(model.ResourceDashboards, model.ActionUpdate, aH.createShareLink)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/dashboards/{uuid}/share_links/{id}", am.Access(model.ResourceDashboards, model.ActionUpdate, aH.revokeShareLink)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/dashboards/{uuid}/snapshots", am.Access(model.ResourceDashboards, model.ActionRead, aH.listSnapshots)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/dashboards/{uuid}/snapshots", am.Access(model.ResourceDashboards, model.ActionUpdate, aH.createSnapshot)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/dashboards/{uuid}/snapshots/{id}", am.Access(model.ResourceDashboards, model.ActionUpdate, aH.deleteSnapshot)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/public/snapshots/{token}", am.OpenAccess(aH.getPublicSnapshot)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/public/dashboards/{token}", am.OpenAccess(aH.getPublicDashboard)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/public/dashboards/{token}/query_range", am.OpenAccess(aH.queryPublicDashboard)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/variables/query", am.ViewAccess(aH.queryDashboardVars)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v2/variables/query", am.ViewAccess(aH.queryDashboardVarsV2)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/variables/resolve", am.ViewAccess(aH.resolveDashboardVariables)).Methods(http.MethodPost)
 
-	router.HandleFunc("/api/v1/explorer/views", am.ViewAccess(aH.getSavedViews)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/explorer/views", am.EditAccess(aH.createSavedViews)).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/explorer/views/{viewId}", am.ViewAccess(aH.getSavedView)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/explorer/views/{viewId}", am.EditAccess(aH.updateSavedView)).Methods(http.MethodPut)
-	router.HandleFunc("/api/v1/explorer/views/{viewId}", am.EditAccess(aH.deleteSavedView)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/explorer/views", am.Access(model.ResourceSavedViews, model.ActionRead, aH.getSavedViews)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/explorer/views", am.Access(model.ResourceSavedViews, model.ActionCreate, aH.createSavedViews)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/explorer/views/default", am.ViewAccess(aH.getDefaultSavedView)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/explorer/views/default", am.ViewAccess(aH.clearDefaultSavedView)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/explorer/views/{viewId}", am.Access(model.ResourceSavedViews, model.ActionRead, aH.getSavedView)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/explorer/views/{viewId}", am.Access(model.ResourceSavedViews, model.ActionUpdate, aH.updateSavedView)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/explorer/views/{viewId}", am.Access(model.ResourceSavedViews, model.ActionDelete, aH.deleteSavedView)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/explorer/views/{viewId}/default", am.ViewAccess(aH.setDefaultSavedView)).Methods(http.MethodPut)
 
 	router.HandleFunc("/api/v1/feedback", am.OpenAccess(aH.submitFeedback)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/event", am.ViewAccess(aH.registerEvent)).Methods(http.MethodPost)
@@ -370,14 +705,50 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 	router.HandleFunc("/api/v1/service/top_operations", am.ViewAccess(aH.getTopOperations)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/service/top_level_operations", am.ViewAccess(aH.getServicesTopLevelOps)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/traces/{traceId}", am.ViewAccess(aH.SearchTraces)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/traces/funnel", am.ViewAccess(aH.getTraceFunnel)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/traces/aggregate", am.ViewAccess(aH.getSpanGroups)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/traces/{traceId}/correlations", am.ViewAccess(aH.getTraceCorrelations)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/traces/{traceId}/diff", am.ViewAccess(aH.getTraceDiff)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/usage", am.ViewAccess(aH.getUsage)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/dependency_graph", am.ViewAccess(aH.dependencyGraph)).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/settings/ttl", am.AdminAccess(aH.setTTL)).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/settings/ttl", am.ViewAccess(aH.getTTL)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/settings/apdex", am.AdminAccess(aH.setApdexSettings)).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/settings/apdex", am.ViewAccess(aH.getApdexSettings)).Methods(http.MethodGet)
-	router.HandleFunc("/api/v1/settings/ingestion_key", am.AdminAccess(aH.insertIngestionKey)).Methods(http.MethodPost)
-	router.HandleFunc("/api/v1/settings/ingestion_key", am.ViewAccess(aH.getIngestionKeys)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/ttl", am.Access(model.ResourceSettings, model.ActionUpdate, defaultOrgOnly(aH.setTTL))).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/settings/ttl", am.Access(model.ResourceSettings, model.ActionRead, defaultOrgOnly(aH.getTTL))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/ttl/preview", am.Access(model.ResourceSettings, model.ActionUpdate, defaultOrgOnly(aH.previewTTL))).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/settings/apdex", am.Access(model.ResourceSettings, model.ActionUpdate, defaultOrgOnly(aH.setApdexSettings))).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/settings/apdex", am.Access(model.ResourceSettings, model.ActionRead, defaultOrgOnly(aH.getApdexSettings))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/span_metrics", am.Access(model.ResourceSettings, model.ActionUpdate, defaultOrgOnly(aH.applySpanMetricsConfig))).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/settings/span_metrics", am.Access(model.ResourceSettings, model.ActionRead, defaultOrgOnly(aH.getSpanMetricsConfig))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/span_metrics/{version}", am.Access(model.ResourceSettings, model.ActionRead, defaultOrgOnly(aH.getSpanMetricsConfig))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/sampling_rules", am.Access(model.ResourceSettings, model.ActionRead, defaultOrgOnly(aH.listSamplingRules))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/sampling_rules", am.Access(model.ResourceSettings, model.ActionCreate, defaultOrgOnly(aH.createSamplingRule))).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/settings/sampling_rules/{id}", am.Access(model.ResourceSettings, model.ActionRead, defaultOrgOnly(aH.getSamplingRule))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/sampling_rules/{id}", am.Access(model.ResourceSettings, model.ActionUpdate, defaultOrgOnly(aH.updateSamplingRule))).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/settings/sampling_rules/{id}", am.Access(model.ResourceSettings, model.ActionDelete, defaultOrgOnly(aH.deleteSamplingRule))).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/metrics/cardinality", am.ViewAccess(aH.getMetricsCardinality)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/cardinality_limits", am.Access(model.ResourceSettings, model.ActionRead, defaultOrgOnly(aH.listCardinalityLimits))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/cardinality_limits", am.Access(model.ResourceSettings, model.ActionCreate, defaultOrgOnly(aH.createCardinalityLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/settings/cardinality_limits/{id}", am.Access(model.ResourceSettings, model.ActionRead, defaultOrgOnly(aH.getCardinalityLimit))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/cardinality_limits/{id}", am.Access(model.ResourceSettings, model.ActionUpdate, defaultOrgOnly(aH.updateCardinalityLimit))).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/settings/cardinality_limits/{id}", am.Access(model.ResourceSettings, model.ActionDelete, defaultOrgOnly(aH.deleteCardinalityLimit))).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/settings/cardinality_limits/{id}/reset", am.Access(model.ResourceSettings, model.ActionUpdate, defaultOrgOnly(aH.resetCardinalityLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/settings/ingestion_key", am.Access(model.ResourceSettings, model.ActionCreate, aH.insertIngestionKey)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/settings/ingestion_key", am.Access(model.ResourceSettings, model.ActionRead, aH.getIngestionKeys)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/ingestion_key/usage", am.Access(model.ResourceSettings, model.ActionRead, aH.getIngestionKeyUsage)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/ingestion_key/{id}", am.Access(model.ResourceSettings, model.ActionUpdate, aH.updateIngestionKey)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/settings/ingestion_key/{id}", am.Access(model.ResourceSettings, model.ActionDelete, aH.revokeIngestionKey)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/settings/ingestion_key/{id}/usage", am.Access(model.ResourceSettings, model.ActionRead, aH.getIngestionKeyUsage)).Methods(http.MethodGet)
+	// the ingestion gateway and the Prometheus servers authenticate with an ingestion key
+	router.HandleFunc("/api/v1/ingestion_key/check", am.OpenAccess(aH.checkIngestionKey)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/prom/write", am.OpenAccess(aH.promRemoteWrite)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v2/spans", am.OpenAccess(aH.zipkinSpans)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/profiles/ingest", am.OpenAccess(aH.ingestProfile)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/events/ingest", am.OpenAccess(aH.ingestEvents)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rum", am.OpenAccess(aH.rumIngest)).Methods(http.MethodPost)
+	if aH.otlpWriter != nil {
+		router.HandleFunc("/v1/traces", am.OpenAccess(aH.otlpTraces)).Methods(http.MethodPost)
+		router.HandleFunc("/v1/logs", am.OpenAccess(aH.otlpLogs)).Methods(http.MethodPost)
+		router.HandleFunc("/v1/metrics", am.OpenAccess(aH.otlpMetrics)).Methods(http.MethodPost)
+	}
 
 	router.HandleFunc("/api/v1/version", am.OpenAccess(aH.getVersion)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/featureFlags", am.OpenAccess(aH.getFeatureFlags)).Methods(http.MethodGet)
@@ -395,8 +766,74 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 	router.HandleFunc("/api/v1/errorFromErrorID", am.ViewAccess(aH.getErrorFromErrorID)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/errorFromGroupID", am.ViewAccess(aH.getErrorFromGroupID)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/nextPrevErrorIDs", am.ViewAccess(aH.getNextPrevErrorIDs)).Methods(http.MethodGet)
-
-	router.HandleFunc("/api/v1/disks", am.ViewAccess(aH.getDisks)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/errors/groups", am.Access(model.ResourceErrors, model.ActionRead, aH.listErrorGroups)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/errors/groups/{fingerprint}", am.Access(model.ResourceErrors, model.ActionRead, aH.getErrorGroup)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/errors/groups/{fingerprint}", am.Access(model.ResourceErrors, model.ActionUpdate, aH.patchErrorGroup)).Methods(http.MethodPatch)
+	router.HandleFunc("/api/v1/errors/ignore_rules", am.Access(model.ResourceErrors, model.ActionRead, aH.listErrorIgnoreRules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/errors/ignore_rules", am.Access(model.ResourceErrors, model.ActionCreate, aH.createErrorIgnoreRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/errors/ignore_rules/{id}", am.Access(model.ResourceErrors, model.ActionDelete, aH.deleteErrorIgnoreRule)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/api/v1/deployments", am.Access(model.ResourceDeployments, model.ActionRead, aH.listDeployments)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/deployments", am.Access(model.ResourceDeployments, model.ActionCreate, aH.createDeployment)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/deployments/{id}", am.Access(model.ResourceDeployments, model.ActionDelete, aH.deleteDeployment)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/api/v1/k8s/{kind:nodes|pods|namespaces}/list", am.ViewAccess(aH.listK8sEntities)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/hosts/list", am.ViewAccess(aH.listHosts)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/hosts/details", am.ViewAccess(aH.getHostDetails)).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/profiles", am.ViewAccess(aH.listProfiles)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/profiles/flamegraph", am.ViewAccess(aH.getFlamegraph)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/profiles/diff", am.ViewAccess(aH.diffFlamegraphs)).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/events", am.ViewAccess(aH.listEvents)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/events/aggregate", am.ViewAccess(aH.aggregateEvents)).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/rum/web_vitals", am.ViewAccess(aH.getWebVitals)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rum/page_loads", am.ViewAccess(aH.getPageLoads)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rum/page_loads/slowest", am.ViewAccess(aH.getSlowestPageLoads)).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/synthetics/checks", am.Access(model.ResourceSynthetics, model.ActionRead, aH.listSyntheticChecks)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/synthetics/checks", am.Access(model.ResourceSynthetics, model.ActionCreate, aH.createSyntheticCheck)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/synthetics/checks/{id}", am.Access(model.ResourceSynthetics, model.ActionRead, aH.getSyntheticCheck)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/synthetics/checks/{id}", am.Access(model.ResourceSynthetics, model.ActionUpdate, aH.updateSyntheticCheck)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/synthetics/checks/{id}", am.Access(model.ResourceSynthetics, model.ActionDelete, aH.deleteSyntheticCheck)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/synthetics/checks/{id}/uptime", am.ViewAccess(aH.getSyntheticCheckUptime)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/synthetics/checks/{id}/alert", am.Access(model.ResourceAlerts, model.ActionCreate, aH.createSyntheticCheckAlert)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/synthetics/uptime", am.ViewAccess(aH.getSyntheticsUptime)).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/v1/heartbeats", am.ViewAccess(aH.listHeartbeatMonitors)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/heartbeats", am.Access(model.ResourceAlerts, model.ActionCreate, aH.createHeartbeatMonitor)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/heartbeats/{id}", am.ViewAccess(aH.getHeartbeatMonitor)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/heartbeats/{id}", am.Access(model.ResourceAlerts, model.ActionUpdate, aH.updateHeartbeatMonitor)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/heartbeats/{id}", am.Access(model.ResourceAlerts, model.ActionDelete, aH.deleteHeartbeatMonitor)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/api/v1/disks", am.ViewAccess(defaultOrgOnly(aH.getDisks))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/storage_tiers", am.Access(model.ResourceSettings, model.ActionRead, defaultOrgOnly(aH.getStorageTiers))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/settings/storage_tiers", am.Access(model.ResourceSettings, model.ActionUpdate, defaultOrgOnly(aH.setStorageTierPolicy))).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/query_history", am.ViewAccess(aH.listQueryHistory)).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/v1/audit_logs", am.AdminAccess(aH.listAuditLogs)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/audit_logs/export", am.AdminAccess(aH.exportAuditLogs)).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/v1/webhooks", am.AdminAccess(aH.listWebhooks)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/webhooks", am.AdminAccess(aH.createWebhook)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/webhooks/{id}", am.AdminAccess(aH.getWebhook)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/webhooks/{id}", am.AdminAccess(aH.editWebhook)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/webhooks/{id}", am.AdminAccess(aH.deleteWebhook)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/webhooks/{id}/test", am.AdminAccess(aH.testWebhook)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/webhooks/{id}/deliveries", am.AdminAccess(aH.listWebhookDeliveries)).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/v1/issue_trackers", am.ViewAccess(aH.listIssueTrackers)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/issue_trackers", am.AdminAccess(aH.createIssueTracker)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/issue_trackers/{id}", am.ViewAccess(aH.getIssueTracker)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/issue_trackers/{id}", am.AdminAccess(aH.editIssueTracker)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/issue_trackers/{id}", am.AdminAccess(aH.deleteIssueTracker)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/issue_trackers/{id}/issues", am.Access(model.ResourceIssues, model.ActionCreate, aH.openIssue)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/issues", am.Access(model.ResourceIssues, model.ActionRead, aH.listIssues)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/issues/{id}/close", am.Access(model.ResourceIssues, model.ActionUpdate, aH.closeIssue)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/queries/in_flight", am.AdminAccess(defaultOrgOnly(aH.getQueriesInFlight))).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/query/{id}", am.ViewAccess(defaultOrgOnly(aH.cancelQuery))).Methods(http.MethodDelete)
 
 	// === Authentication APIs ===
 	router.HandleFunc("/api/v1/invite", am.AdminAccess(aH.inviteUser)).Methods(http.MethodPost)
@@ -415,12 +852,48 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 
 	router.HandleFunc("/api/v1/user/{id}/flags", am.SelfAccess(aH.patchUserFlag)).Methods(http.MethodPatch)
 
+	router.HandleFunc("/api/v1/user/{id}/sessions", am.SelfAccess(aH.listSessions)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/user/{id}/sessions", am.SelfAccess(aH.revokeSessions)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/user/{id}/sessions/{sessionId}", am.SelfAccess(aH.revokeSession)).Methods(http.MethodDelete)
+
+	router.HandleFunc("/api/v1/user/{id}/totp", am.SelfAccess(aH.getTOTPStatus)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/user/{id}/totp", am.SelfAccess(aH.enrollTOTP)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/user/{id}/totp", am.SelfAccess(aH.disableTOTP)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/user/{id}/totp/verify", am.SelfAccess(aH.verifyTOTP)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/user/{id}/totp/recovery_codes", am.SelfAccess(aH.regenerateRecoveryCodes)).Methods(http.MethodPost)
+
 	router.HandleFunc("/api/v1/rbac/role/{id}", am.SelfAccess(aH.getRole)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/rbac/role/{id}", am.AdminAccess(aH.editRole)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/rbac/roles", am.ViewAccess(aH.listRoles)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rbac/roles", am.AdminAccess(aH.createRole)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rbac/roles/{id}", am.ViewAccess(aH.getRoleDefinition)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rbac/roles/{id}", am.AdminAccess(aH.updateRole)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/rbac/roles/{id}", am.AdminAccess(aH.deleteRole)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/rbac/permissions", am.ViewAccess(aH.listPermissions)).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/v1/teams", am.ViewAccess(aH.listTeams)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/teams", am.AdminAccess(aH.createTeam)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/teams/{id}", am.ViewAccess(aH.getTeam)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/teams/{id}", am.AdminAccess(aH.updateTeam)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/teams/{id}", am.AdminAccess(aH.deleteTeam)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/teams/{id}/oncall", am.Access(model.ResourceOnCall, model.ActionRead, aH.getTeamOnCall)).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/v1/oncall/schedules", am.Access(model.ResourceOnCall, model.ActionRead, aH.listOnCallSchedules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/oncall/schedules", am.Access(model.ResourceOnCall, model.ActionCreate, aH.createOnCallSchedule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/oncall/schedules/{id}", am.Access(model.ResourceOnCall, model.ActionRead, aH.getOnCallSchedule)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/oncall/schedules/{id}", am.Access(model.ResourceOnCall, model.ActionUpdate, aH.updateOnCallSchedule)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/oncall/schedules/{id}", am.Access(model.ResourceOnCall, model.ActionDelete, aH.deleteOnCallSchedule)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/oncall/schedules/{id}/shifts", am.Access(model.ResourceOnCall, model.ActionRead, aH.getOnCallScheduleShifts)).Methods(http.MethodGet)
 
 	router.HandleFunc("/api/v1/org", am.AdminAccess(aH.getOrgs)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/org", am.AdminAccess(aH.createOrg)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/org/{id}/members", am.AdminAccess(aH.addOrgMember)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/org/{id}/members/{userId}", am.AdminAccess(aH.removeOrgMember)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/org/{id}/switch", am.ViewAccess(aH.switchOrg)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/user/{id}/orgs", am.SelfAccess(aH.listUserOrgs)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/org/{id}", am.AdminAccess(aH.getOrg)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/org/{id}", am.AdminAccess(aH.editOrg)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/org/{id}/two_factor", am.AdminAccess(aH.setTwoFactorPreference)).Methods(http.MethodPut)
 	router.HandleFunc("/api/v1/orgUsers/{id}", am.AdminAccess(aH.getOrgUsers)).Methods(http.MethodGet)
 
 	router.HandleFunc("/api/v1/getResetPasswordToken/{id}", am.AdminAccess(aH.getResetPasswordToken)).Methods(http.MethodGet)
@@ -445,14 +918,93 @@ func Intersection(a, b []int) (c []int) {
 
 func (aH *APIHandler) getRule(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if apiErr := aH.checkACL(r, access.ACLResourceRule, id, false); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 	ruleResponse, err := aH.ruleManager.GetRule(r.Context(), id)
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		RespondError(w, ruleApiError(id, err), nil)
 		return
 	}
+	setETag(w, etagOf(ruleResponse.PostableRule))
 	aH.Respond(w, ruleResponse)
 }
 
+func ruleApiError(id string, err error) *model.ApiError {
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no rule found with id: %s", id)}
+	}
+	return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+}
+
+// checkRulePreconditions checks the rule exists and the preconditions of the request on
+// the rule
+func (aH *APIHandler) checkRulePreconditions(r *http.Request, id string) *model.ApiError {
+	rule, err := aH.ruleManager.GetRule(r.Context(), id)
+	if err != nil {
+		return ruleApiError(id, err)
+	}
+	return checkPreconditions(r, etagOf(rule.PostableRule))
+}
+
+// ruleByExternalId returns the rule of the org with the external id of the posted rule, nil
+// when the posted rule has no external id or there is no such rule
+func (aH *APIHandler) ruleByExternalId(r *http.Request, body []byte) (*rules.GettableRule, *model.ApiError) {
+	var rule struct {
+		ExternalId string `json:"externalId"`
+	}
+	if err := json.Unmarshal(body, &rule); err != nil || rule.ExternalId == "" {
+		// the rule manager reports the invalid rules
+		return nil, nil
+	}
+	existing, err := aH.ruleManager.GetRuleByExternalId(r.Context(), rule.ExternalId)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return existing, nil
+}
+
+// checkRuleChannels checks the rule does not send its alerts to the channels of the other
+// orgs, the alerts of the rules without preferred channels are sent to the channels of their
+// org
+func (aH *APIHandler) checkRuleChannels(r *http.Request, body []byte) *model.ApiError {
+	var rule struct {
+		PreferredChannels *[]string `json:"preferredChannels"`
+	}
+	if err := json.Unmarshal(body, &rule); err != nil {
+		// the rule manager reports the invalid rules
+		return nil
+	}
+	if rule.PreferredChannels == nil || len(*rule.PreferredChannels) == 0 {
+		return nil
+	}
+
+	orgChannels, apiErr := aH.reader.GetChannels(r.Context())
+	if apiErr != nil {
+		return apiErr
+	}
+	allChannels, apiErr := aH.reader.GetChannels(context.Background())
+	if apiErr != nil {
+		return apiErr
+	}
+	for _, name := range *rule.PreferredChannels {
+		inOrg := slices.ContainsFunc(*orgChannels, func(c model.ChannelItem) bool { return c.Name == name })
+		exists := slices.ContainsFunc(*allChannels, func(c model.ChannelItem) bool { return c.Name == name })
+		if exists && !inOrg {
+			return model.BadRequest(fmt.Errorf("no channel named %s found", name))
+		}
+	}
+	return nil
+}
+
+// setRuleETag sets the entity tag of the rule changed by the request
+func (aH *APIHandler) setRuleETag(w http.ResponseWriter, r *http.Request, id string) {
+	if rule, err := aH.ruleManager.GetRule(r.Context(), id); err == nil {
+		setETag(w, etagOf(rule.PostableRule))
+	}
+}
+
 // populateTemporality adds the temporality to the query if it is not present
 func (aH *APIHandler) populateTemporality(ctx context.Context, qp *v3.QueryRangeParamsV3) error {
 
@@ -604,257 +1156,1490 @@ func (aH *APIHandler) deleteDowntimeSchedule(w http.ResponseWriter, r *http.Requ
 	aH.Respond(w, nil)
 }
 
-func (aH *APIHandler) listRules(w http.ResponseWriter, r *http.Request) {
-
-	rules, err := aH.ruleManager.ListRuleStates(r.Context())
+func (aH *APIHandler) listEscalationPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := aH.ruleManager.RuleDB().GetAllEscalationPolicies(r.Context())
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
-
-	// todo(amol): need to add sorter
-
-	aH.Respond(w, rules)
+	aH.Respond(w, policies)
 }
 
-func (aH *APIHandler) getDashboards(w http.ResponseWriter, r *http.Request) {
-
-	allDashboards, err := dashboards.GetDashboards(r.Context())
+func (aH *APIHandler) getEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	policy, err := aH.ruleManager.RuleDB().GetEscalationPolicyByID(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no escalation policy found with id: %s", id)}, nil)
+		return
+	}
 	if err != nil {
-		RespondError(w, err, nil)
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
+	aH.Respond(w, policy)
+}
 
-	ic := aH.IntegrationsController
-	installedIntegrationDashboards, err := ic.GetDashboardsForInstalledIntegrations(r.Context())
+func (aH *APIHandler) createEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	var policy rules.EscalationPolicy
+	err := json.NewDecoder(r.Body).Decode(&policy)
 	if err != nil {
-		zap.L().Error("failed to get dashboards for installed integrations", zap.Error(err))
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
 	}
-	allDashboards = append(allDashboards, installedIntegrationDashboards...)
-
-	tagsFromReq, ok := r.URL.Query()["tags"]
-	if !ok || len(tagsFromReq) == 0 || tagsFromReq[0] == "" {
-		aH.Respond(w, allDashboards)
+	if err := policy.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
 		return
 	}
 
-	tags2Dash := make(map[string][]int)
-	for i := 0; i < len(allDashboards); i++ {
-		tags, ok := (allDashboards)[i].Data["tags"].([]interface{})
-		if !ok {
-			continue
-		}
-
-		tagsArray := make([]string, len(tags))
-		for i, v := range tags {
-			tagsArray[i] = v.(string)
-		}
-
-		for _, tag := range tagsArray {
-			tags2Dash[tag] = append(tags2Dash[tag], i)
-		}
-
+	_, err = aH.ruleManager.RuleDB().CreateEscalationPolicy(r.Context(), policy)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
 	}
+	aH.Respond(w, nil)
+}
 
-	inter := make([]int, len(allDashboards))
-	for i := range inter {
-		inter[i] = i
+func (aH *APIHandler) editEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var policy rules.EscalationPolicy
+	err := json.NewDecoder(r.Body).Decode(&policy)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
 	}
-
-	for _, tag := range tagsFromReq {
-		inter = Intersection(inter, tags2Dash[tag])
+	if err := policy.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
 	}
-
-	filteredDashboards := []dashboards.Dashboard{}
-	for _, val := range inter {
-		dash := (allDashboards)[val]
-		filteredDashboards = append(filteredDashboards, dash)
+	err = aH.ruleManager.RuleDB().EditEscalationPolicy(r.Context(), policy, id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
 	}
-
-	aH.Respond(w, filteredDashboards)
-
+	aH.Respond(w, nil)
 }
-func (aH *APIHandler) deleteDashboard(w http.ResponseWriter, r *http.Request) {
-
-	uuid := mux.Vars(r)["uuid"]
-	err := dashboards.DeleteDashboard(r.Context(), uuid, aH.featureFlags)
 
+func (aH *APIHandler) deleteEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	err := aH.ruleManager.RuleDB().DeleteEscalationPolicy(r.Context(), id)
 	if err != nil {
-		RespondError(w, err, nil)
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
-
 	aH.Respond(w, nil)
-
 }
 
-func (aH *APIHandler) queryDashboardVars(w http.ResponseWriter, r *http.Request) {
-
-	query := r.URL.Query().Get("query")
-	if query == "" {
-		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("query is required")}, nil)
+// ackAlerts acknowledges the firing alerts of a rule to stop their escalation
+func (aH *APIHandler) ackAlerts(w http.ResponseWriter, r *http.Request) {
+	var req rules.AckAlertsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
 		return
 	}
-	if strings.Contains(strings.ToLower(query), "alter table") {
-		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("query shouldn't alter data")}, nil)
+	if req.RuleId == "" {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("ruleId is required")}, nil)
 		return
 	}
-	dashboardVars, err := aH.reader.QueryDashboardVars(r.Context(), query)
-	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+	if apiErr := aH.checkACL(r, access.ACLResourceRule, req.RuleId, false); apiErr != nil {
+		RespondError(w, apiErr, nil)
 		return
 	}
-	aH.Respond(w, dashboardVars)
-}
 
-func prepareQuery(r *http.Request) (string, error) {
-	var postData *model.DashboardVars
+	userEmail, _ := auth.GetEmailFromJwt(r.Context())
+	count := aH.ruleManager.Escalator().Acknowledge(r.Context(), &req, userEmail, time.Now())
+	aH.Respond(w, rules.AckAlertsResponse{Acknowledged: count})
+}
 
-	if err := json.NewDecoder(r.Body).Decode(&postData); err != nil {
-		return "", fmt.Errorf("failed to decode request body: %v", err)
+func (aH *APIHandler) listRecordingRules(w http.ResponseWriter, r *http.Request) {
+	recordingRules, err := aH.ruleManager.RuleDB().GetAllRecordingRules(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
 	}
+	aH.Respond(w, recordingRules)
+}
 
-	query := strings.TrimSpace(postData.Query)
-
-	if query == "" {
-		return "", fmt.Errorf("query is required")
+func (aH *APIHandler) getRecordingRule(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	recordingRule, err := aH.ruleManager.RuleDB().GetRecordingRule(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
 	}
+	aH.Respond(w, recordingRule)
+}
 
-	notAllowedOps := []string{
-		"alter table",
-		"drop table",
-		"truncate table",
-		"drop database",
-		"drop view",
-		"drop function",
+func (aH *APIHandler) createRecordingRule(w http.ResponseWriter, r *http.Request) {
+	var recordingRule rules.RecordingRule
+	err := json.NewDecoder(r.Body).Decode(&recordingRule)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
 	}
-
-	for _, op := range notAllowedOps {
-		if strings.Contains(strings.ToLower(query), op) {
-			return "", fmt.Errorf("operation %s is not allowed", op)
-		}
+	if err := recordingRule.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
 	}
 
-	vars := make(map[string]string)
-	for k, v := range postData.Variables {
-		vars[k] = metrics.FormattedValue(v)
-	}
-	tmpl := template.New("dashboard-vars")
-	tmpl, tmplErr := tmpl.Parse(query)
-	if tmplErr != nil {
-		return "", tmplErr
-	}
-	var queryBuf bytes.Buffer
-	tmplErr = tmpl.Execute(&queryBuf, vars)
-	if tmplErr != nil {
-		return "", tmplErr
+	created, err := aH.ruleManager.CreateRecordingRule(r.Context(), &recordingRule)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
 	}
-	return queryBuf.String(), nil
+	aH.Respond(w, created)
 }
 
-func (aH *APIHandler) queryDashboardVarsV2(w http.ResponseWriter, r *http.Request) {
-	query, err := prepareQuery(r)
+func (aH *APIHandler) editRecordingRule(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var recordingRule rules.RecordingRule
+	err := json.NewDecoder(r.Body).Decode(&recordingRule)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
 		return
 	}
+	if err := recordingRule.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
 
-	dashboardVars, err := aH.reader.QueryDashboardVars(r.Context(), query)
+	edited, err := aH.ruleManager.EditRecordingRule(r.Context(), &recordingRule, id)
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
-	aH.Respond(w, dashboardVars)
+	aH.Respond(w, edited)
 }
 
-func (aH *APIHandler) updateDashboard(w http.ResponseWriter, r *http.Request) {
-
-	uuid := mux.Vars(r)["uuid"]
-
-	var postData map[string]interface{}
+func (aH *APIHandler) deleteRecordingRule(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	err := aH.ruleManager.DeleteRecordingRule(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+func (aH *APIHandler) listSLOs(w http.ResponseWriter, r *http.Request) {
+	slos, err := aH.ruleManager.RuleDB().GetAllSLOs(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, slos)
+}
+
+func (aH *APIHandler) getSLO(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	slo, err := aH.ruleManager.RuleDB().GetSLO(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, slo)
+}
+
+func (aH *APIHandler) createSLO(w http.ResponseWriter, r *http.Request) {
+	var slo rules.SLO
+	err := json.NewDecoder(r.Body).Decode(&slo)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := slo.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	created, err := aH.ruleManager.CreateSLO(r.Context(), &slo)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, created)
+}
+
+func (aH *APIHandler) editSLO(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var slo rules.SLO
+	err := json.NewDecoder(r.Body).Decode(&slo)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := slo.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	edited, err := aH.ruleManager.EditSLO(r.Context(), &slo, id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, edited)
+}
+
+func (aH *APIHandler) deleteSLO(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	err := aH.ruleManager.DeleteSLO(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+func (aH *APIHandler) getSLOStatus(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	slo, err := aH.ruleManager.RuleDB().GetSLO(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: err}, nil)
+		return
+	}
+
+	status, err := aH.ruleManager.SLOStatus(r.Context(), slo, time.Now())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, status)
+}
+
+// getSLOBurnRates returns the burn rates of the slo over the comma separated
+// windows of the query, e.g. ?windows=5m,1h,1d
+func (aH *APIHandler) getSLOBurnRates(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	windows, err := rules.ParseBurnRateWindows(r.URL.Query().Get("windows"))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	slo, err := aH.ruleManager.RuleDB().GetSLO(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: err}, nil)
+		return
+	}
+
+	burnRates, err := aH.ruleManager.SLOBurnRates(r.Context(), slo, windows, time.Now())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, burnRates)
+}
+
+// generateSLOAlerts replaces the burn rate alerts of the slo
+func (aH *APIHandler) generateSLOAlerts(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	slo, err := aH.ruleManager.GenerateSLOAlerts(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, slo)
+}
+
+// importPromRules converts the prometheus rules file in the body into alerting
+// and recording rules, the rules are only validated when dryRun is set
+func (aH *APIHandler) importPromRules(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		zap.L().Error("Error in getting req body of importPromRules API", zap.Error(err))
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+	res, err := aH.ruleManager.ImportPromRules(r.Context(), body, dryRun)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, res)
+}
+
+func (aH *APIHandler) listRules(w http.ResponseWriter, r *http.Request) {
+
+	rules, apiErr := aH.viewableRules(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	// todo(amol): need to add sorter
+
+	aH.Respond(w, rules)
+}
+
+// viewableRules returns the rules with their state, without the rules restricted by their
+// access list
+func (aH *APIHandler) viewableRules(r *http.Request) (*rules.GettableRules, *model.ApiError) {
+
+	states, err := aH.ruleManager.ListRuleStates(r.Context())
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	allowed, apiErr := aH.aclFilter(r, access.ACLResourceRule, false)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	viewable := states.Rules[:0]
+	for _, rule := range states.Rules {
+		if allowed(rule.Id) {
+			viewable = append(viewable, rule)
+		}
+	}
+	states.Rules = viewable
+	return states, nil
+}
+
+func (aH *APIHandler) listReportSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := aH.ReportManager.List(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, schedules)
+}
+
+func (aH *APIHandler) getReportSchedule(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	schedule, err := aH.ReportManager.Get(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, schedule)
+}
+
+func (aH *APIHandler) createReportSchedule(w http.ResponseWriter, r *http.Request) {
+	var schedule reports.Schedule
+	err := json.NewDecoder(r.Body).Decode(&schedule)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := schedule.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	created, err := aH.ReportManager.Create(r.Context(), &schedule)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, created)
+}
+
+func (aH *APIHandler) editReportSchedule(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var schedule reports.Schedule
+	err := json.NewDecoder(r.Body).Decode(&schedule)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := schedule.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	edited, err := aH.ReportManager.Edit(r.Context(), &schedule, id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, edited)
+}
+
+func (aH *APIHandler) deleteReportSchedule(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	err := aH.ReportManager.Delete(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// runReportSchedule sends the report right away, outside of its schedule
+func (aH *APIHandler) runReportSchedule(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	err := aH.ReportManager.Run(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// logExportApiError keeps the type of the validation errors of the log exports
+func logExportApiError(err error) *model.ApiError {
+	var apiErr *model.ApiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+}
+
+func (aH *APIHandler) listLogExports(w http.ResponseWriter, r *http.Request) {
+	exports, err := aH.LogExportManager.List(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, exports)
+}
+
+func (aH *APIHandler) getLogExport(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	export, err := aH.LogExportManager.Get(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, export)
+}
+
+func (aH *APIHandler) createLogExport(w http.ResponseWriter, r *http.Request) {
+	var export logexports.Export
+	err := json.NewDecoder(r.Body).Decode(&export)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	created, err := aH.LogExportManager.Create(r.Context(), &export)
+	if err != nil {
+		RespondError(w, logExportApiError(err), nil)
+		return
+	}
+	aH.Respond(w, created)
+}
+
+func (aH *APIHandler) editLogExport(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var export logexports.Export
+	err := json.NewDecoder(r.Body).Decode(&export)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	edited, err := aH.LogExportManager.Edit(r.Context(), &export, id)
+	if err != nil {
+		RespondError(w, logExportApiError(err), nil)
+		return
+	}
+	aH.Respond(w, edited)
+}
+
+func (aH *APIHandler) deleteLogExport(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	err := aH.LogExportManager.Delete(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// runLogExport exports the logs right away, outside of the schedule
+func (aH *APIHandler) runLogExport(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	err := aH.LogExportManager.Run(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+func (aH *APIHandler) getDashboards(w http.ResponseWriter, r *http.Request) {
+
+	allDashboards, err := aH.listDashboards(r, r.URL.Query()["tags"])
+	if err != nil {
+		RespondError(w, err, nil)
+		return
+	}
+	aH.Respond(w, allDashboards)
+
+}
+
+// listDashboards returns the dashboards the user can view, with the installed integration
+// dashboards, filtered to the dashboards with all the tags when tags are given
+func (aH *APIHandler) listDashboards(r *http.Request, tagsFromReq []string) ([]dashboards.Dashboard, *model.ApiError) {
+
+	allDashboards, err := dashboards.GetDashboards(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	ic := aH.IntegrationsController
+	installedIntegrationDashboards, err := ic.GetDashboardsForInstalledIntegrations(r.Context())
+	if err != nil {
+		zap.L().Error("failed to get dashboards for installed integrations", zap.Error(err))
+	}
+	allDashboards = append(allDashboards, installedIntegrationDashboards...)
+	allDashboards = aH.viewableDashboards(r, allDashboards)
+
+	if len(tagsFromReq) == 0 || tagsFromReq[0] == "" {
+		return allDashboards, nil
+	}
+
+	tags2Dash := make(map[string][]int)
+	for i := 0; i < len(allDashboards); i++ {
+		tags, ok := (allDashboards)[i].Data["tags"].([]interface{})
+		if !ok {
+			continue
+		}
+
+		tagsArray := make([]string, len(tags))
+		for i, v := range tags {
+			tagsArray[i] = v.(string)
+		}
+
+		for _, tag := range tagsArray {
+			tags2Dash[tag] = append(tags2Dash[tag], i)
+		}
+
+	}
+
+	inter := make([]int, len(allDashboards))
+	for i := range inter {
+		inter[i] = i
+	}
+
+	for _, tag := range tagsFromReq {
+		inter = Intersection(inter, tags2Dash[tag])
+	}
+
+	filteredDashboards := []dashboards.Dashboard{}
+	for _, val := range inter {
+		dash := (allDashboards)[val]
+		filteredDashboards = append(filteredDashboards, dash)
+	}
+
+	return filteredDashboards, nil
+}
+
+func (aH *APIHandler) deleteDashboard(w http.ResponseWriter, r *http.Request) {
+
+	uuid := mux.Vars(r)["uuid"]
+	if apiErr := aH.checkDashboardAccess(r, uuid, true); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	var etag string
+	dashboard, apiErr := dashboards.GetDashboard(r.Context(), uuid)
+	if apiErr == nil {
+		etag = etagOf(dashboard.Data)
+	}
+	if apiErr := checkPreconditions(r, etag); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	err := dashboards.DeleteDashboard(r.Context(), uuid, aH.featureFlags)
+
+	if err != nil {
+		RespondError(w, err, nil)
+		return
+	}
+	if dashboard != nil {
+		aH.publishDashboardEvent(r, webhooks.EventDashboardDeleted, dashboard)
+	}
+
+	if err := share.RevokeDashboardLinks(r.Context(), uuid); err != nil {
+		zap.L().Error("failed to revoke the share links of the deleted dashboard", zap.String("uuid", uuid), zap.Error(err.Err))
+	}
+
+	aH.Respond(w, nil)
+
+}
+
+func (aH *APIHandler) listShareLinks(w http.ResponseWriter, r *http.Request) {
+	uuid := mux.Vars(r)["uuid"]
+	if apiErr := aH.checkDashboardAccess(r, uuid, false); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	links, apiErr := share.GetLinks(r.Context(), uuid)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, links)
+}
+
+func (aH *APIHandler) createShareLink(w http.ResponseWriter, r *http.Request) {
+	uuid := mux.Vars(r)["uuid"]
+	var req share.CreateLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	if _, apiErr := dashboards.GetDashboard(r.Context(), uuid); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if apiErr := aH.checkDashboardAccess(r, uuid, true); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	link, apiErr := share.CreateLink(r.Context(), uuid, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, link)
+}
+
+func (aH *APIHandler) revokeShareLink(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if apiErr := aH.checkDashboardAccess(r, vars["uuid"], true); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if apiErr := share.RevokeLink(r.Context(), vars["uuid"], vars["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// PublicDashboard is the read only dashboard of a share link
+type PublicDashboard struct {
+	Data      dashboards.Data `json:"data"`
+	StartTime int64           `json:"startTime"`
+	EndTime   int64           `json:"endTime"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+}
+
+// publicDashboard validates the share token of the request and returns its dashboard
+func (aH *APIHandler) publicDashboard(r *http.Request) (*share.Link, *dashboards.Dashboard, *model.ApiError) {
+	link, apiErr := share.ValidateToken(r.Context(), mux.Vars(r)["token"])
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	dashboard, apiErr := dashboards.GetDashboard(r.Context(), link.DashboardId)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	return link, dashboard, nil
+}
+
+func (aH *APIHandler) getPublicDashboard(w http.ResponseWriter, r *http.Request) {
+	link, dashboard, apiErr := aH.publicDashboard(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	start, end := link.TimeRange(time.Now())
+	aH.Respond(w, PublicDashboard{
+		Data:      dashboard.Data,
+		StartTime: start,
+		EndTime:   end,
+		ExpiresAt: link.ExpiresAt,
+	})
+}
+
+// queryPublicDashboard runs the query of a widget of the shared dashboard,
+// the time range is restricted to the range of the share link
+func (aH *APIHandler) queryPublicDashboard(w http.ResponseWriter, r *http.Request) {
+	link, dashboard, apiErr := aH.publicDashboard(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	// the saved queries hold the variables, the queries are verified
+	// before the variables are replaced
+	var raw v3.QueryRangeParamsV3
+	if err := json.Unmarshal(body, &raw); err != nil || raw.CompositeQuery == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("cannot parse the request body: %v", err)}, nil)
+		return
+	}
+	if err := share.VerifyQuery(dashboard.Data, r.URL.Query().Get("widgetId"), raw.CompositeQuery); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorForbidden, Err: err}, nil)
+		return
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	queryRangeParams, apiErrorObj := ParseQueryRangeParams(r)
+	if apiErrorObj != nil {
+		RespondError(w, apiErrorObj, nil)
+		return
+	}
+	queryRangeParams.Version = "v4"
+	queryRangeParams.Start, queryRangeParams.End = link.ClampTimeRange(time.Now(), queryRangeParams.Start, queryRangeParams.End)
+
+	// the queries read the data of the org of the dashboard
+	ctx := common.WithOrg(r.Context(), dashboard.OrgId)
+	if err := aH.populateTemporality(ctx, queryRangeParams); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	aH.queryRangeV4(ctx, queryRangeParams, w, r)
+}
+
+func (aH *APIHandler) listSnapshots(w http.ResponseWriter, r *http.Request) {
+	uuid := mux.Vars(r)["uuid"]
+	if apiErr := aH.checkDashboardAccess(r, uuid, false); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	snapshots, apiErr := share.GetSnapshots(r.Context(), uuid)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, snapshots)
+}
+
+// createSnapshot runs the queries of the dashboard widgets over the time range
+// of the snapshot and stores their results along with the dashboard
+func (aH *APIHandler) createSnapshot(w http.ResponseWriter, r *http.Request) {
+	uuid := mux.Vars(r)["uuid"]
+	var req share.CreateSnapshotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	dashboard, apiErr := dashboards.GetDashboard(r.Context(), uuid)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if apiErr := aH.checkDashboardAccess(r, uuid, true); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	results := share.WidgetResults{}
+	for widgetId, body := range req.Queries {
+		result, err := aH.snapshotWidget(r, dashboard, widgetId, body, req.StartTime, req.EndTime)
+		if err != nil {
+			zap.L().Warn("failed to capture the widget of the snapshot", zap.String("widgetId", widgetId), zap.Error(err.Err))
+			if err.Typ == model.ErrorForbidden || err.Typ == model.ErrorBadData {
+				RespondError(w, err, nil)
+				return
+			}
+			results[widgetId] = &share.WidgetResult{Error: err.Err.Error()}
+			continue
+		}
+		results[widgetId] = &share.WidgetResult{Result: result}
+	}
+
+	title := req.Title
+	if title == "" {
+		title, _ = dashboard.Data["title"].(string)
+	}
+	snapshot := &share.Snapshot{
+		DashboardId: uuid,
+		Title:       title,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		Data:        share.SnapshotData(dashboard.Data),
+		Results:     results,
+	}
+	if req.ExpiresAt != nil {
+		snapshot.ExpiresAt = *req.ExpiresAt
+	}
+
+	resp, apiErr := share.CreateSnapshot(r.Context(), snapshot)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	// the results are only returned with the snapshot token
+	resp.Data, resp.Results = nil, nil
+	aH.Respond(w, resp)
+}
+
+// snapshotWidget verifies the query range request of the widget and runs it
+// over the time range of the snapshot
+func (aH *APIHandler) snapshotWidget(r *http.Request, dashboard *dashboards.Dashboard, widgetId string, body []byte, start, end int64) ([]*v3.Result, *model.ApiError) {
+	var raw v3.QueryRangeParamsV3
+	if err := json.Unmarshal(body, &raw); err != nil || raw.CompositeQuery == nil {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("cannot parse the query of widget %s: %v", widgetId, err)}
+	}
+	if err := share.VerifyQuery(dashboard.Data, widgetId, raw.CompositeQuery); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorForbidden, Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, r.URL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	queryRangeParams, apiErr := ParseQueryRangeParams(req)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	queryRangeParams.Version = "v4"
+	queryRangeParams.Start, queryRangeParams.End = start, end
+	// the cached results may miss the points of the ongoing intervals
+	queryRangeParams.NoCache = true
+
+	if err := aH.populateTemporality(r.Context(), queryRangeParams); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	result, _, apiErr := aH.runQueryRangeV4(r.Context(), queryRangeParams)
+	if apiErr != nil {
+		// the failed queries are stored as errors of the widget
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: apiErr.Err}
+	}
+	return result, nil
+}
+
+func (aH *APIHandler) deleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if apiErr := aH.checkDashboardAccess(r, vars["uuid"], true); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if apiErr := share.DeleteSnapshot(r.Context(), vars["uuid"], vars["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+func (aH *APIHandler) getPublicSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot, apiErr := share.ValidateSnapshotToken(r.Context(), mux.Vars(r)["token"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, snapshot)
+}
+
+func (aH *APIHandler) queryDashboardVars(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("query is required")}, nil)
+		return
+	}
+	if strings.Contains(strings.ToLower(query), "alter table") {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("query shouldn't alter data")}, nil)
+		return
+	}
+	if apiErr := checkClickHouseQuery(r.Context()); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	dashboardVars, err := aH.reader.QueryDashboardVars(r.Context(), query)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, dashboardVars)
+}
+
+func prepareQuery(r *http.Request) (string, error) {
+	var postData *model.DashboardVars
+
+	if err := json.NewDecoder(r.Body).Decode(&postData); err != nil {
+		return "", fmt.Errorf("failed to decode request body: %v", err)
+	}
+
+	return prepareVariablesQuery(postData.Query, postData.Variables)
+}
+
+// prepareVariablesQuery replaces the variables of the clickhouse query of a dashboard variable
+func prepareVariablesQuery(query string, variables map[string]interface{}) (string, error) {
+	query = strings.TrimSpace(query)
+
+	if query == "" {
+		return "", fmt.Errorf("query is required")
+	}
+
+	notAllowedOps := []string{
+		"alter table",
+		"drop table",
+		"truncate table",
+		"drop database",
+		"drop view",
+		"drop function",
+	}
+
+	for _, op := range notAllowedOps {
+		if strings.Contains(strings.ToLower(query), op) {
+			return "", fmt.Errorf("operation %s is not allowed", op)
+		}
+	}
+
+	vars := make(map[string]string)
+	for k, v := range variables {
+		vars[k] = metrics.FormattedValue(v)
+	}
+	tmpl := template.New("dashboard-vars")
+	tmpl, tmplErr := tmpl.Parse(query)
+	if tmplErr != nil {
+		return "", tmplErr
+	}
+	var queryBuf bytes.Buffer
+	tmplErr = tmpl.Execute(&queryBuf, vars)
+	if tmplErr != nil {
+		return "", tmplErr
+	}
+	return queryBuf.String(), nil
+}
+
+func (aH *APIHandler) queryDashboardVarsV2(w http.ResponseWriter, r *http.Request) {
+	query, err := prepareQuery(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if apiErr := checkClickHouseQuery(r.Context()); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	dashboardVars, err := aH.reader.QueryDashboardVars(r.Context(), query)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, dashboardVars)
+}
+
+// resolveDashboardVariables resolves the options of the variables in the order of their
+// dependencies, the selection of each variable is passed to the variables depending on it
+func (aH *APIHandler) resolveDashboardVariables(w http.ResponseWriter, r *http.Request) {
+	var req dashboards.ResolveVariablesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	order, err := dashboards.VariableOrder(req.Variables)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	deps := dashboards.VariableDependencies(req.Variables)
+	selected := map[string]interface{}{}
+	resolved := map[string]*dashboards.ResolvedVariable{}
+	for _, name := range order {
+		variable := req.Variables[name]
+		result := &dashboards.ResolvedVariable{DependsOn: deps[name]}
+
+		options, err := aH.variableOptions(r.Context(), variable, selected, req.Start, req.End)
+		if err != nil {
+			zap.L().Warn("failed to resolve the options of the variable", zap.String("variable", name), zap.Error(err))
+			result.Error = err.Error()
+			result.SelectedValue = variable.SelectedValue
+		} else {
+			result.Options = options
+			result.SelectedValue = variable.Select(options)
+		}
+		selected[name] = result.SelectedValue
+		resolved[name] = result
+	}
+	aH.Respond(w, resolved)
+}
+
+// variableOptions lists the options of the variable given the selection of the variables it depends on
+func (aH *APIHandler) variableOptions(ctx context.Context, variable *dashboards.Variable, selected map[string]interface{}, start, end int64) ([]interface{}, error) {
+	switch variable.Type {
+	case dashboards.VariableTypeCustom:
+		return dashboards.CustomOptions(variable.CustomValue), nil
+	case dashboards.VariableTypeQuery:
+		if apiErr := checkClickHouseQuery(ctx); apiErr != nil {
+			return nil, apiErr.Err
+		}
+		query, err := prepareVariablesQuery(variable.QueryValue, selected)
+		if err != nil {
+			return nil, err
+		}
+		result, err := aH.reader.QueryDashboardVars(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		return result.VariableValues, nil
+	case dashboards.VariableTypeBuilder:
+		return aH.builderVariableOptions(ctx, variable.BuilderQuery, selected, start, end)
+	}
+	return []interface{}{}, nil
+}
+
+// builderVariableOptions runs the builder query over the whole time range and returns
+// the distinct values of its first group by attribute
+func (aH *APIHandler) builderVariableOptions(ctx context.Context, builderQuery *v3.BuilderQuery, selected map[string]interface{}, start, end int64) ([]interface{}, error) {
+	query := *builderQuery
+	query.QueryName = "A"
+	query.Expression = "A"
+	query.StepInterval = (end - start) / 1000
+	if query.AggregateOperator == v3.AggregateOperatorNoOp || query.AggregateOperator == "" {
+		query.AggregateOperator = v3.AggregateOperatorCount
+	}
+	if query.DataSource == v3.DataSourceMetrics {
+		if query.TimeAggregation == v3.TimeAggregationUnspecified {
+			query.TimeAggregation = v3.TimeAggregationCount
+		}
+		if query.SpaceAggregation == v3.SpaceAggregationUnspecified {
+			query.SpaceAggregation = v3.SpaceAggregationSum
+		}
+	}
+	if query.Limit == 0 {
+		query.Limit = 1000
+	}
+
+	// the variables are replaced while parsing the query range params
+	body, err := json.Marshal(v3.QueryRangeParamsV3{
+		Start:     start,
+		End:       end,
+		Step:      query.StepInterval,
+		Variables: selected,
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType:      v3.QueryTypeBuilder,
+			PanelType:      v3.PanelTypeGraph,
+			BuilderQueries: map[string]*v3.BuilderQuery{"A": &query},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/api/v4/query_range", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	queryRangeParams, apiErr := ParseQueryRangeParams(req)
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	queryRangeParams.Version = "v4"
+	if err := aH.populateTemporality(ctx, queryRangeParams); err != nil {
+		return nil, err
+	}
+
+	results, _, apiErr := aH.runQueryRangeV4(ctx, queryRangeParams)
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+
+	key := builderQuery.GroupBy[0].Key
+	seen := map[string]bool{}
+	options := []interface{}{}
+	for _, result := range results {
+		for _, series := range result.Series {
+			value, ok := series.Labels[key]
+			if !ok || seen[value] {
+				continue
+			}
+			seen[value] = true
+			options = append(options, value)
+		}
+	}
+	sort.Slice(options, func(i, j int) bool {
+		return options[i].(string) < options[j].(string)
+	})
+	return options, nil
+}
+
+func (aH *APIHandler) updateDashboard(w http.ResponseWriter, r *http.Request) {
+
+	uuid := mux.Vars(r)["uuid"]
+
+	var postData map[string]interface{}
 	err := json.NewDecoder(r.Body).Decode(&postData)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, "Error reading request body")
 		return
 	}
-	err = dashboards.IsPostDataSane(&postData)
-	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, "Error reading request body")
+	err = dashboards.IsPostDataSane(&postData)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, "Error reading request body")
+		return
+	}
+
+	existing, apiErr := dashboards.GetDashboard(r.Context(), uuid)
+	if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	var etag string
+	if existing != nil {
+		etag = etagOf(existing.Data)
+	}
+	if apiErr := checkPreconditions(r, etag); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	// the dashboards are created with the uuid of the request when missing, the requests
+	// are repeated with the same result
+	postData["uuid"] = uuid
+	var dashboard *dashboards.Dashboard
+	eventType := webhooks.EventDashboardUpdated
+	if existing == nil {
+		eventType = webhooks.EventDashboardCreated
+		if !auth.HasPermission(r.Context(), common.GetUserFromContext(r.Context()), model.ResourceDashboards, model.ActionCreate) {
+			RespondError(w, &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("API requires the permission to %s %s", model.ActionCreate, model.ResourceDashboards)}, nil)
+			return
+		}
+		dashboard, apiErr = dashboards.CreateDashboard(r.Context(), postData, aH.featureFlags)
+	} else {
+		if apiErr := aH.checkDashboardAccess(r, uuid, true); apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		// the conditional requests replace the dashboard as a whole, they can not overwrite
+		// the changes they have not read
+		if r.Header.Get("If-Match") != "" {
+			dashboard, apiErr = dashboards.ReplaceDashboard(r.Context(), uuid, postData, aH.featureFlags)
+		} else {
+			dashboard, apiErr = dashboards.UpdateDashboard(r.Context(), uuid, postData, aH.featureFlags)
+		}
+	}
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.publishDashboardEvent(r, eventType, dashboard)
+
+	setETag(w, etagOf(dashboard.Data))
+	aH.Respond(w, dashboard)
+
+}
+
+func (aH *APIHandler) getDashboard(w http.ResponseWriter, r *http.Request) {
+
+	uuid := mux.Vars(r)["uuid"]
+
+	dashboard, apiError := aH.viewableDashboard(r, uuid)
+	if apiError != nil {
+		RespondError(w, apiError, nil)
+		return
+	}
+
+	setETag(w, etagOf(dashboard.Data))
+	aH.Respond(w, dashboard)
+
+}
+
+// viewableDashboard returns the dashboard, or the installed integration dashboard, with the
+// uuid when the user can view it
+func (aH *APIHandler) viewableDashboard(r *http.Request, uuid string) (*dashboards.Dashboard, *model.ApiError) {
+
+	dashboard, apiError := dashboards.GetDashboard(r.Context(), uuid)
+
+	if apiError != nil {
+		if apiError.Type() != model.ErrorNotFound {
+			return nil, apiError
+		}
+
+		var integrationErr *model.ApiError
+		dashboard, integrationErr = aH.IntegrationsController.GetInstalledIntegrationDashboardById(
+			r.Context(), uuid,
+		)
+		if integrationErr != nil {
+			if integrationErr.Type() == model.ErrorBadData {
+				// the uuid is not the uuid of an integration dashboard
+				return nil, apiError
+			}
+			return nil, integrationErr
+		}
+
+	}
+
+	if apiErr := aH.checkFolderAccess(r, dashboard.FolderUuid, false); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := aH.checkACL(r, access.ACLResourceDashboard, uuid, false); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return dashboard, nil
+}
+
+// checkFolderAccess checks if the user of the request has the role required by
+// the folder to view or edit its dashboards
+func (aH *APIHandler) checkFolderAccess(r *http.Request, folderUuid *string, edit bool) *model.ApiError {
+	perms, apiErr := dashboards.GetFolderPermissions(r.Context(), folderUuid)
+	if apiErr != nil {
+		return apiErr
+	}
+	required := perms.ViewRole
+	if edit {
+		required = perms.EditRole
+	}
+
+	user := common.GetUserFromContext(r.Context())
+	if user == nil || !dashboards.RoleAllows(auth.GetUserRole(user), required) {
+		return &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("the folder of the dashboard requires the %s role", required)}
+	}
+	return nil
+}
+
+func (aH *APIHandler) checkDashboardAccess(r *http.Request, uuid string, edit bool) *model.ApiError {
+	dashboard, apiErr := dashboards.GetDashboard(r.Context(), uuid)
+	if apiErr != nil {
+		if apiErr.Type() == model.ErrorNotFound {
+			// let the dashboards package report the missing dashboard
+			return nil
+		}
+		return apiErr
+	}
+	if apiErr := aH.checkFolderAccess(r, dashboard.FolderUuid, edit); apiErr != nil {
+		return apiErr
+	}
+	return aH.checkACL(r, access.ACLResourceDashboard, uuid, edit)
+}
+
+// viewableDashboards filters out the dashboards of the folders the user cannot view
+// and the dashboards restricted by their access list
+func (aH *APIHandler) viewableDashboards(r *http.Request, all []dashboards.Dashboard) []dashboards.Dashboard {
+	allowed := map[string]bool{}
+	viewable := []dashboards.Dashboard{}
+	aclAllows, apiErr := aH.aclFilter(r, access.ACLResourceDashboard, false)
+	if apiErr != nil {
+		zap.L().Error("failed to read the access lists of the dashboards", zap.Error(apiErr.Err))
+		return viewable
+	}
+	for _, dashboard := range all {
+		if !aclAllows(dashboard.Uuid) {
+			continue
+		}
+		key := ""
+		if dashboard.FolderUuid != nil {
+			key = *dashboard.FolderUuid
+		}
+		ok, found := allowed[key]
+		if !found {
+			ok = aH.checkFolderAccess(r, dashboard.FolderUuid, false) == nil
+			allowed[key] = ok
+		}
+		if ok {
+			viewable = append(viewable, dashboard)
+		}
+	}
+	return viewable
+}
+
+func (aH *APIHandler) listDashboardFolders(w http.ResponseWriter, r *http.Request) {
+	folders, apiErr := dashboards.GetFolders(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, folders)
+}
+
+func (aH *APIHandler) createDashboardFolder(w http.ResponseWriter, r *http.Request) {
+	var folder dashboards.Folder
+	if err := json.NewDecoder(r.Body).Decode(&folder); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	if folder.ParentUuid != nil && *folder.ParentUuid != "" {
+		if apiErr := aH.checkFolderAccess(r, folder.ParentUuid, true); apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+	}
+
+	created, apiErr := dashboards.CreateFolder(r.Context(), &folder)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, created)
+}
+
+func (aH *APIHandler) updateDashboardFolder(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var folder dashboards.Folder
+	if err := json.NewDecoder(r.Body).Decode(&folder); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	if apiErr := aH.checkFolderAccess(r, &id, true); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if folder.ParentUuid != nil && *folder.ParentUuid != "" {
+		if apiErr := aH.checkFolderAccess(r, folder.ParentUuid, true); apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+	}
+
+	updated, apiErr := dashboards.UpdateFolder(r.Context(), id, &folder)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, updated)
+}
+
+func (aH *APIHandler) deleteDashboardFolder(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if apiErr := aH.checkFolderAccess(r, &id, true); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	if apiErr := dashboards.DeleteFolder(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// listFolderDashboards lists the dashboards of a folder, the id "root" lists
+// the dashboards outside of any folder
+func (aH *APIHandler) listFolderDashboards(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var folderUuid *string
+	if id == "root" {
+		id = ""
+	} else {
+		folderUuid = &id
+	}
+
+	if apiErr := aH.checkFolderAccess(r, folderUuid, false); apiErr != nil {
+		RespondError(w, apiErr, nil)
 		return
 	}
 
-	dashboard, apiError := dashboards.UpdateDashboard(r.Context(), uuid, postData, aH.featureFlags)
-	if apiError != nil {
-		RespondError(w, apiError, nil)
+	folderDashboards, apiErr := dashboards.GetFolderDashboards(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
 		return
 	}
-
-	aH.Respond(w, dashboard)
-
+	aH.Respond(w, aH.viewableDashboards(r, folderDashboards))
 }
 
-func (aH *APIHandler) getDashboard(w http.ResponseWriter, r *http.Request) {
+type moveDashboardRequest struct {
+	FolderUuid string `json:"folderUuid"`
+}
 
+func (aH *APIHandler) moveDashboard(w http.ResponseWriter, r *http.Request) {
 	uuid := mux.Vars(r)["uuid"]
+	var req moveDashboardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
 
-	dashboard, apiError := dashboards.GetDashboard(r.Context(), uuid)
-
-	if apiError != nil {
-		if apiError.Type() != model.ErrorNotFound {
-			RespondError(w, apiError, nil)
-			return
-		}
-
-		dashboard, apiError = aH.IntegrationsController.GetInstalledIntegrationDashboardById(
-			r.Context(), uuid,
-		)
-		if apiError != nil {
-			RespondError(w, apiError, nil)
+	dashboard, apiErr := dashboards.GetDashboard(r.Context(), uuid)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	// the user must be able to edit the dashboard in both folders
+	if apiErr := aH.checkFolderAccess(r, dashboard.FolderUuid, true); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if apiErr := aH.checkACL(r, access.ACLResourceDashboard, uuid, true); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if req.FolderUuid != "" {
+		if apiErr := aH.checkFolderAccess(r, &req.FolderUuid, true); apiErr != nil {
+			RespondError(w, apiErr, nil)
 			return
 		}
+	}
 
+	if apiErr := dashboards.MoveDashboard(r.Context(), uuid, req.FolderUuid); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
 	}
+	aH.Respond(w, nil)
+}
 
-	aH.Respond(w, dashboard)
+func (aH *APIHandler) createDashboardsTransform(w http.ResponseWriter, r *http.Request) {
 
-}
+	defer r.Body.Close()
+	b, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, "Error reading request body")
+		return
+	}
 
-func (aH *APIHandler) saveAndReturn(w http.ResponseWriter, r *http.Request, signozDashboard model.DashboardData) {
-	toSave := make(map[string]interface{})
-	toSave["title"] = signozDashboard.Title
-	toSave["description"] = signozDashboard.Description
-	toSave["tags"] = signozDashboard.Tags
-	toSave["layout"] = signozDashboard.Layout
-	toSave["widgets"] = signozDashboard.Widgets
-	toSave["variables"] = signozDashboard.Variables
+	importData, err := dashboards.ParseGrafanaJSON(b)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, "Error while creating dashboard from grafana json")
+		return
+	}
 
-	dashboard, apiError := dashboards.CreateDashboard(r.Context(), toSave, aH.featureFlags)
+	signozDashboard, _ := dashboards.ConvertGrafanaDashboard(importData)
+	dashboard, apiError := dashboards.CreateDashboard(r.Context(), signozDashboard, aH.featureFlags)
 	if apiError != nil {
 		RespondError(w, apiError, nil)
 		return
 	}
+	aH.publishDashboardEvent(r, webhooks.EventDashboardCreated, dashboard)
 	aH.Respond(w, dashboard)
 }
 
-func (aH *APIHandler) createDashboardsTransform(w http.ResponseWriter, r *http.Request) {
+// GrafanaImportResponse is the converted grafana dashboard with the report
+// of what couldn't be converted, the dashboard is not saved on dry runs
+type GrafanaImportResponse struct {
+	Dashboard *dashboards.Dashboard           `json:"dashboard,omitempty"`
+	Data      dashboards.Data                 `json:"data"`
+	Report    *dashboards.GrafanaImportReport `json:"report"`
+}
 
+func (aH *APIHandler) importGrafanaDashboard(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	b, err := io.ReadAll(r.Body)
-
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, "Error reading request body")
 		return
 	}
 
-	var importData model.GrafanaJSON
-
-	err = json.Unmarshal(b, &importData)
-	if err == nil {
-		signozDashboard := dashboards.TransformGrafanaJSONToSignoz(importData)
-		aH.saveAndReturn(w, r, signozDashboard)
+	importData, err := dashboards.ParseGrafanaJSON(b)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
 		return
 	}
-	RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, "Error while creating dashboard from grafana json")
+
+	data, report := dashboards.ConvertGrafanaDashboard(importData)
+	resp := GrafanaImportResponse{Data: data, Report: report}
+	if r.URL.Query().Get("dryRun") != "true" {
+		dashboard, apiErr := dashboards.CreateDashboard(r.Context(), data, aH.featureFlags)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		aH.publishDashboardEvent(r, webhooks.EventDashboardCreated, dashboard)
+		resp.Dashboard = dashboard
+	}
+	aH.Respond(w, resp)
 }
 
 func (aH *APIHandler) createDashboards(w http.ResponseWriter, r *http.Request) {
@@ -879,7 +2664,9 @@ func (aH *APIHandler) createDashboards(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, apiErr, nil)
 		return
 	}
+	aH.publishDashboardEvent(r, webhooks.EventDashboardCreated, dash)
 
+	setETag(w, etagOf(dash.Data))
 	aH.Respond(w, dash)
 
 }
@@ -894,7 +2681,13 @@ func (aH *APIHandler) testRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	if apiErr := aH.checkRuleChannels(r, body); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	// the test rule keeps the user of the request, its queries are scoped to the org of the user
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), constants.ContextUserKey, r.Context().Value(constants.ContextUserKey)), 1*time.Minute)
 	defer cancel()
 
 	alertCount, apiRrr := aH.ruleManager.TestNotification(ctx, string(body))
@@ -910,9 +2703,37 @@ func (aH *APIHandler) testRule(w http.ResponseWriter, r *http.Request) {
 	aH.Respond(w, response)
 }
 
+// previewRule evaluates a draft rule over a historical window
+func (aH *APIHandler) previewRule(w http.ResponseWriter, r *http.Request) {
+
+	req := &rules.PreviewRuleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		zap.L().Error("Error in parsing req body of preview rule API", zap.Error(err))
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	preview, apiErr := aH.ruleManager.PreviewRule(r.Context(), req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	aH.Respond(w, preview)
+}
+
 func (aH *APIHandler) deleteRule(w http.ResponseWriter, r *http.Request) {
 
 	id := mux.Vars(r)["id"]
+	if apiErr := aH.checkACL(r, access.ACLResourceRule, id, true); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	if apiErr := aH.checkRulePreconditions(r, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 
 	err := aH.ruleManager.DeleteRule(r.Context(), id)
 
@@ -921,6 +2742,10 @@ func (aH *APIHandler) deleteRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if apiErr := access.DeleteACL(r.Context(), access.ACLResourceRule, id); apiErr != nil {
+		zap.L().Error("failed to delete the access list of the deleted rule", zap.String("id", id), zap.Error(apiErr.Err))
+	}
+
 	aH.Respond(w, "rule successfully deleted")
 
 }
@@ -928,6 +2753,10 @@ func (aH *APIHandler) deleteRule(w http.ResponseWriter, r *http.Request) {
 // patchRule updates only requested changes in the rule
 func (aH *APIHandler) patchRule(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if apiErr := aH.checkACL(r, access.ACLResourceRule, id, true); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 
 	defer r.Body.Close()
 	body, err := io.ReadAll(r.Body)
@@ -937,6 +2766,16 @@ func (aH *APIHandler) patchRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if apiErr := aH.checkRuleChannels(r, body); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	if apiErr := aH.checkRulePreconditions(r, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
 	gettableRule, err := aH.ruleManager.PatchRule(r.Context(), string(body), id)
 
 	if err != nil {
@@ -944,11 +2783,21 @@ func (aH *APIHandler) patchRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	aH.setRuleETag(w, r, id)
 	aH.Respond(w, gettableRule)
 }
 
 func (aH *APIHandler) editRule(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	// the rules are only replaced, the missing rules are created with the rules API
+	if apiErr := aH.checkRulePreconditions(r, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if apiErr := aH.checkACL(r, access.ACLResourceRule, id, true); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 
 	defer r.Body.Close()
 	body, err := io.ReadAll(r.Body)
@@ -958,6 +2807,19 @@ func (aH *APIHandler) editRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if apiErr := aH.checkRuleChannels(r, body); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	if existing, apiErr := aH.ruleByExternalId(r, body); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	} else if existing != nil && existing.Id != id {
+		RespondError(w, &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf("the external id %s is the external id of the rule %s", existing.ExternalId, existing.Id)}, nil)
+		return
+	}
+
 	err = aH.ruleManager.EditRule(r.Context(), string(body), id)
 
 	if err != nil {
@@ -965,23 +2827,39 @@ func (aH *APIHandler) editRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	aH.setRuleETag(w, r, id)
 	aH.Respond(w, "rule successfully edited")
 
 }
 
 func (aH *APIHandler) getChannel(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
-	channel, apiErrorObj := aH.reader.GetChannel(id)
+	channel, apiErrorObj := aH.reader.GetChannel(r.Context(), id)
 	if apiErrorObj != nil {
 		RespondError(w, apiErrorObj, nil)
 		return
 	}
+	setETag(w, etagOf(channel.Data))
 	aH.Respond(w, channel)
 }
 
+// checkChannelPreconditions checks the channel exists and the preconditions of the request
+// on the channel
+func (aH *APIHandler) checkChannelPreconditions(r *http.Request, id string) *model.ApiError {
+	channel, apiErr := aH.reader.GetChannel(r.Context(), id)
+	if apiErr != nil {
+		return apiErr
+	}
+	return checkPreconditions(r, etagOf(channel.Data))
+}
+
 func (aH *APIHandler) deleteChannel(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
-	apiErrorObj := aH.reader.DeleteChannel(id)
+	if apiErr := aH.checkChannelPreconditions(r, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	apiErrorObj := aH.reader.DeleteChannel(r.Context(), id)
 	if apiErrorObj != nil {
 		RespondError(w, apiErrorObj, nil)
 		return
@@ -990,7 +2868,7 @@ func (aH *APIHandler) deleteChannel(w http.ResponseWriter, r *http.Request) {
 }
 
 func (aH *APIHandler) listChannels(w http.ResponseWriter, r *http.Request) {
-	channels, apiErrorObj := aH.reader.GetChannels()
+	channels, apiErrorObj := aH.reader.GetChannels(r.Context())
 	if apiErrorObj != nil {
 		RespondError(w, apiErrorObj, nil)
 		return
@@ -1024,6 +2902,46 @@ func (aH *APIHandler) testChannel(w http.ResponseWriter, r *http.Request) {
 	aH.Respond(w, "test alert sent")
 }
 
+// testSavedChannel sends a test alert to an existing channel
+func (aH *APIHandler) testSavedChannel(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	channel, apiErrorObj := aH.reader.GetChannel(r.Context(), id)
+	if apiErrorObj != nil {
+		RespondError(w, apiErrorObj, nil)
+		return
+	}
+
+	receiver := &am.Receiver{}
+	if err := json.Unmarshal([]byte(channel.Data), receiver); err != nil {
+		zap.L().Error("Error in parsing stored channel of testSavedChannel API", zap.Error(err))
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	apiErrorObj = aH.alertManager.TestReceiver(receiver)
+	if apiErrorObj != nil {
+		RespondError(w, apiErrorObj, nil)
+		return
+	}
+	aH.Respond(w, "test alert sent")
+}
+
+// previewNotificationTemplate renders a notification message template with a sample alert
+func (aH *APIHandler) previewNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	req := &am.TemplatePreviewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	resp, err := am.PreviewMessage(req)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, resp)
+}
+
 func (aH *APIHandler) editChannel(w http.ResponseWriter, r *http.Request) {
 
 	id := mux.Vars(r)["id"]
@@ -1043,13 +2961,21 @@ func (aH *APIHandler) editChannel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, apiErrorObj := aH.reader.EditChannel(receiver, id)
+	if apiErr := aH.checkChannelPreconditions(r, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	_, apiErrorObj := aH.reader.EditChannel(r.Context(), receiver, id)
 
 	if apiErrorObj != nil {
 		RespondError(w, apiErrorObj, nil)
 		return
 	}
 
+	if channel, apiErr := aH.reader.GetChannel(r.Context(), id); apiErr == nil {
+		setETag(w, etagOf(channel.Data))
+	}
 	aH.Respond(w, nil)
 
 }
@@ -1071,14 +2997,77 @@ func (aH *APIHandler) createChannel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, apiErrorObj := aH.reader.CreateChannel(receiver)
+	// the names of the channels are unique across the orgs, they name the receivers of
+	// alertmanager. Creating a channel of the org again replaces it for the users allowed
+	// to update the channels, the clients find the channels they created by their names
+	if channel, apiErr := aH.channelByName(context.Background(), receiver.Name); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	} else if channel != nil {
+		aH.replaceChannel(w, r, receiver)
+		return
+	}
+
+	_, apiErrorObj := aH.reader.CreateChannel(r.Context(), receiver)
 
 	if apiErrorObj != nil {
 		RespondError(w, apiErrorObj, nil)
 		return
 	}
 
-	aH.Respond(w, nil)
+	channel, apiErr := aH.channelByName(r.Context(), receiver.Name)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if channel != nil {
+		setETag(w, etagOf(channel.Data))
+	}
+	aH.Respond(w, channel)
+}
+
+// replaceChannel replaces the channel of the org with the name of the receiver
+func (aH *APIHandler) replaceChannel(w http.ResponseWriter, r *http.Request, receiver *am.Receiver) {
+	existing, apiErr := aH.channelByName(r.Context(), receiver.Name)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if existing == nil || !auth.HasPermission(r.Context(), common.GetUserFromContext(r.Context()), model.ResourceChannels, model.ActionUpdate) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf("a channel named %s already exists", receiver.Name)}, nil)
+		return
+	}
+	if apiErr := checkPreconditions(r, etagOf(existing.Data)); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	id := strconv.Itoa(existing.Id)
+	if _, apiErr := aH.reader.EditChannel(r.Context(), receiver, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	channel, apiErr := aH.reader.GetChannel(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	setETag(w, etagOf(channel.Data))
+	aH.Respond(w, channel)
+}
+
+// channelByName returns the channel of the name, nil when there is none
+func (aH *APIHandler) channelByName(ctx context.Context, name string) (*model.ChannelItem, *model.ApiError) {
+	channels, apiErr := aH.reader.GetChannels(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for _, channel := range *channels {
+		if channel.Name == name {
+			return &channel, nil
+		}
+	}
+	return nil, nil
 
 }
 
@@ -1098,9 +3087,59 @@ func (aH *APIHandler) getAlerts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	body, apiErr := aH.viewableAlerts(r, aH.withSilencedAlerts(r, body))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 	aH.Respond(w, string(body))
 }
 
+// viewableAlerts removes the alerts of the rules the user of the request can't view, the
+// rules restricted by their access list and the rules of the other orgs
+func (aH *APIHandler) viewableAlerts(r *http.Request, body []byte) ([]byte, *model.ApiError) {
+	listing := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &listing); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	data, ok := listing["data"]
+	if !ok || string(data) == "null" {
+		return body, nil
+	}
+	alerts := []json.RawMessage{}
+	if err := json.Unmarshal(data, &alerts); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	allowed, apiErr := aH.aclFilter(r, access.ACLResourceRule, false)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	viewable := alerts[:0]
+	for _, alert := range alerts {
+		var a struct {
+			Labels map[string]string `json:"labels"`
+		}
+		if err := json.Unmarshal(alert, &a); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+		if allowed(a.Labels[labels.AlertRuleIdLabel]) {
+			viewable = append(viewable, alert)
+		}
+	}
+
+	data, err := json.Marshal(viewable)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	listing["data"] = data
+	filtered, err := json.Marshal(listing)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return filtered, nil
+}
+
 func (aH *APIHandler) createRule(w http.ResponseWriter, r *http.Request) {
 
 	defer r.Body.Close()
@@ -1111,12 +3150,51 @@ func (aH *APIHandler) createRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if apiErr := aH.checkRuleChannels(r, body); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	// the rules with an external id are created once, creating them again replaces them
+	existing, apiErr := aH.ruleByExternalId(r, body)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if existing != nil {
+		if !auth.HasPermission(r.Context(), common.GetUserFromContext(r.Context()), model.ResourceAlerts, model.ActionUpdate) {
+			RespondError(w, &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("API requires the permission to %s %s", model.ActionUpdate, model.ResourceAlerts)}, nil)
+			return
+		}
+		if apiErr := aH.checkACL(r, access.ACLResourceRule, existing.Id, true); apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		if apiErr := checkPreconditions(r, etagOf(existing.PostableRule)); apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		if err := aH.ruleManager.EditRule(r.Context(), string(body), existing.Id); err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+			return
+		}
+		rule, err := aH.ruleManager.GetRule(r.Context(), existing.Id)
+		if err != nil {
+			RespondError(w, ruleApiError(existing.Id, err), nil)
+			return
+		}
+		setETag(w, etagOf(rule.PostableRule))
+		aH.Respond(w, rule)
+		return
+	}
+
 	rule, err := aH.ruleManager.CreateRule(r.Context(), string(body))
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
 		return
 	}
 
+	aH.setRuleETag(w, r, rule.Id)
 	aH.Respond(w, rule)
 
 }
@@ -1129,6 +3207,10 @@ func (aH *APIHandler) queryRangeMetrics(w http.ResponseWriter, r *http.Request)
 		RespondError(w, apiErrorObj, nil)
 		return
 	}
+	if query.Query, apiErrorObj = scopePromQLToOrg(r.Context(), query.Query); apiErrorObj != nil {
+		RespondError(w, apiErrorObj, nil)
+		return
+	}
 
 	// zap.L().Info(query, apiError)
 
@@ -1159,8 +3241,10 @@ func (aH *APIHandler) queryRangeMetrics(w http.ResponseWriter, r *http.Request)
 		switch res.Err.(type) {
 		case promql.ErrQueryCanceled:
 			RespondError(w, &model.ApiError{Typ: model.ErrorCanceled, Err: res.Err}, nil)
+			return
 		case promql.ErrQueryTimeout:
 			RespondError(w, &model.ApiError{Typ: model.ErrorTimeout, Err: res.Err}, nil)
+			return
 		}
 		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: res.Err}, nil)
 		return
@@ -1184,6 +3268,10 @@ func (aH *APIHandler) queryMetrics(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, apiErrorObj, nil)
 		return
 	}
+	if queryParams.Query, apiErrorObj = scopePromQLToOrg(r.Context(), queryParams.Query); apiErrorObj != nil {
+		RespondError(w, apiErrorObj, nil)
+		return
+	}
 
 	// zap.L().Info(query, apiError)
 
@@ -1214,10 +3302,13 @@ func (aH *APIHandler) queryMetrics(w http.ResponseWriter, r *http.Request) {
 		switch res.Err.(type) {
 		case promql.ErrQueryCanceled:
 			RespondError(w, &model.ApiError{Typ: model.ErrorCanceled, Err: res.Err}, nil)
+			return
 		case promql.ErrQueryTimeout:
 			RespondError(w, &model.ApiError{Typ: model.ErrorTimeout, Err: res.Err}, nil)
+			return
 		}
 		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: res.Err}, nil)
+		return
 	}
 
 	response_data := &model.QueryData{
@@ -1283,6 +3374,12 @@ func (aH *APIHandler) getTopOperations(w http.ResponseWriter, r *http.Request) {
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	tags, apiErr := scopeTagsToOrg(r.Context(), query.Tags)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.Tags = tags
 
 	result, apiErr := aH.reader.GetTopOperations(r.Context(), query)
 
@@ -1300,6 +3397,12 @@ func (aH *APIHandler) getUsage(w http.ResponseWriter, r *http.Request) {
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.OrgScope = scope
 
 	result, err := aH.reader.GetUsage(r.Context(), query)
 	if aH.HandleError(w, err, http.StatusBadRequest) {
@@ -1316,6 +3419,12 @@ func (aH *APIHandler) getServiceOverview(w http.ResponseWriter, r *http.Request)
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	tags, apiErr := scopeTagsToOrg(r.Context(), query.Tags)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.Tags = tags
 
 	result, apiErr := aH.reader.GetServiceOverview(r.Context(), query, aH.skipConfig)
 	if apiErr != nil && aH.HandleError(w, apiErr.Err, http.StatusInternalServerError) {
@@ -1335,6 +3444,25 @@ func (aH *APIHandler) getServicesTopLevelOps(w http.ResponseWriter, r *http.Requ
 		RespondError(w, apiErr, nil)
 		return
 	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if scope != nil {
+		// the operations are aggregated by service, the services of the other orgs are left out
+		services, err := aH.reader.GetServicesList(r.Context(), scope)
+		if aH.HandleError(w, err, http.StatusInternalServerError) {
+			return
+		}
+		operations := make(map[string][]string, len(*services))
+		for _, service := range *services {
+			if ops, ok := (*result)[service]; ok {
+				operations[service] = ops
+			}
+		}
+		result = &operations
+	}
 
 	aH.WriteJSON(w, r, result)
 }
@@ -1345,6 +3473,12 @@ func (aH *APIHandler) getServices(w http.ResponseWriter, r *http.Request) {
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	tags, apiErr := scopeTagsToOrg(r.Context(), query.Tags)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.Tags = tags
 
 	result, apiErr := aH.reader.GetServices(r.Context(), query, aH.skipConfig)
 	if apiErr != nil && aH.HandleError(w, apiErr.Err, http.StatusInternalServerError) {
@@ -1368,22 +3502,59 @@ func (aH *APIHandler) getServices(w http.ResponseWriter, r *http.Request) {
 
 func (aH *APIHandler) dependencyGraph(w http.ResponseWriter, r *http.Request) {
 
-	query, err := parseGetServicesRequest(r)
+	query, err := parseGetDependencyGraphRequest(r)
+	if aH.HandleError(w, err, http.StatusBadRequest) {
+		return
+	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.OrgScope = scope
+
+	edges, err := aH.serviceMapEdges(r.Context(), query, query.GetServicesParams)
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	if query.CompareStart == nil {
+		aH.WriteJSON(w, r, edges)
+		return
+	}
 
-	result, err := aH.reader.GetDependencyGraph(r.Context(), query)
+	// the call rates are per second so the ranges can have different durations
+	compared := query.GetServicesParams
+	compared.Start, compared.End = query.CompareStart, query.CompareEnd
+	compared.Period = int(compared.End.Unix() - compared.Start.Unix())
+	previous, err := aH.serviceMapEdges(r.Context(), query, compared)
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
 
-	aH.WriteJSON(w, r, result)
+	aH.WriteJSON(w, r, services.Compare(edges, previous))
+}
+
+// serviceMapEdges returns the edges of the service map in the range of params, restricted to
+// the neighborhood of the focal service of the query, if any
+func (aH *APIHandler) serviceMapEdges(ctx context.Context, query *model.GetDependencyGraphParams, params model.GetServicesParams) ([]model.ServiceMapDependencyResponseItem, error) {
+	result, err := aH.reader.GetDependencyGraph(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+	if query.Service == "" {
+		return *result, nil
+	}
+	return services.Neighborhood(*result, query.Service, query.Depth), nil
 }
 
 func (aH *APIHandler) getServicesList(w http.ResponseWriter, r *http.Request) {
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 
-	result, err := aH.reader.GetServicesList(r.Context())
+	result, err := aH.reader.GetServicesList(r.Context(), scope)
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
@@ -1409,12 +3580,134 @@ func (aH *APIHandler) SearchTraces(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// getTraceFunnel computes the conversion, the drop-off and the latency between the ordered
+// steps of the funnel over the traces of the range
+func (aH *APIHandler) getTraceFunnel(w http.ResponseWriter, r *http.Request) {
+	params := &v3.FunnelParams{}
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if err := params.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	for i := range params.Steps {
+		params.Steps[i].Filters = withOrgFilter(params.Steps[i].Filters, v3.DataSourceTraces, scope)
+	}
+
+	traces, apiErr := aH.reader.GetFunnelTraces(r.Context(), params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, funnels.Compute(params, traces))
+}
+
+// getSpanGroups aggregates the spans matching the filters on the values of the group by
+// attributes, with their count, their error rate and their latency percentiles
+func (aH *APIHandler) getSpanGroups(w http.ResponseWriter, r *http.Request) {
+	params := &v3.SpanGroupsParams{}
+	if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if err := params.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	params.Filters = withOrgFilter(params.Filters, v3.DataSourceTraces, scope)
+
+	groups, apiErr := aH.reader.GetSpanGroups(r.Context(), params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, groups)
+}
+
+// getTraceCorrelations returns the logs of the trace and the metrics of its
+// services over the window of the trace, or of the span when spanId is set
+func (aH *APIHandler) getTraceCorrelations(w http.ResponseWriter, r *http.Request) {
+	traceId := mux.Vars(r)["traceId"]
+	spanId := r.URL.Query().Get("spanId")
+
+	limit := uint64(correlation.DefaultLogsLimit)
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.ParseUint(l, 10, 64)
+		if err != nil || parsed == 0 || parsed > correlation.MaxLogsLimit {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("limit must be between 1 and %d", correlation.MaxLogsLimit)}, nil)
+			return
+		}
+		limit = parsed
+	}
+
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	services, apiErr := aH.reader.GetTraceServices(r.Context(), traceId, spanId, scope)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	start, end := correlation.Window(services)
+
+	logs := &correlation.Logs{
+		Query: correlation.LogsQuery(traceId, spanId, start, end, limit),
+		Rows:  []*v3.Row{},
+	}
+	// the query is copied as it is enriched with the log fields when run
+	var params v3.QueryRangeParamsV3
+	b, _ := json.Marshal(logs.Query)
+	_ = json.Unmarshal(b, &params)
+	params.Version = "v4"
+	result, _, apiErr := aH.runQueryRangeV4(r.Context(), &params)
+	if apiErr != nil {
+		// the metrics pivots are still useful without the logs
+		logs.Error = apiErr.Err.Error()
+	} else {
+		for _, res := range result {
+			logs.Rows = append(logs.Rows, res.List...)
+		}
+	}
+
+	aH.Respond(w, &correlation.Response{
+		TraceId:  traceId,
+		SpanId:   spanId,
+		Start:    start,
+		End:      end,
+		Services: services,
+		Logs:     logs,
+		Metrics:  correlation.ServicesMetrics(traceId, services, start, end),
+	})
+}
+
 func (aH *APIHandler) listErrors(w http.ResponseWriter, r *http.Request) {
 
 	query, err := parseListErrorsRequest(r)
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	tags, apiErr := scopeTagsToOrg(r.Context(), query.Tags)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.Tags = tags
 	result, apiErr := aH.reader.ListErrors(r.Context(), query)
 	if apiErr != nil && aH.HandleError(w, apiErr.Err, http.StatusInternalServerError) {
 		return
@@ -1429,6 +3722,12 @@ func (aH *APIHandler) countErrors(w http.ResponseWriter, r *http.Request) {
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	tags, apiErr := scopeTagsToOrg(r.Context(), query.Tags)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.Tags = tags
 	result, apiErr := aH.reader.CountErrors(r.Context(), query)
 	if apiErr != nil {
 		RespondError(w, apiErr, nil)
@@ -1444,6 +3743,12 @@ func (aH *APIHandler) getErrorFromErrorID(w http.ResponseWriter, r *http.Request
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.OrgScope = scope
 	result, apiErr := aH.reader.GetErrorFromErrorID(r.Context(), query)
 	if apiErr != nil {
 		RespondError(w, apiErr, nil)
@@ -1459,6 +3764,12 @@ func (aH *APIHandler) getNextPrevErrorIDs(w http.ResponseWriter, r *http.Request
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.OrgScope = scope
 	result, apiErr := aH.reader.GetNextPrevErrorIDs(r.Context(), query)
 	if apiErr != nil {
 		RespondError(w, apiErr, nil)
@@ -1474,6 +3785,12 @@ func (aH *APIHandler) getErrorFromGroupID(w http.ResponseWriter, r *http.Request
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.OrgScope = scope
 	result, apiErr := aH.reader.GetErrorFromGroupID(r.Context(), query)
 	if apiErr != nil {
 		RespondError(w, apiErr, nil)
@@ -1489,6 +3806,12 @@ func (aH *APIHandler) getSpanFilters(w http.ResponseWriter, r *http.Request) {
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.OrgScope = scope
 
 	result, apiErr := aH.reader.GetSpanFilters(r.Context(), query)
 
@@ -1505,6 +3828,12 @@ func (aH *APIHandler) getFilteredSpans(w http.ResponseWriter, r *http.Request) {
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	tags, apiErr := scopeTagsToOrg(r.Context(), query.Tags)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.Tags = tags
 
 	result, apiErr := aH.reader.GetFilteredSpans(r.Context(), query)
 
@@ -1521,6 +3850,12 @@ func (aH *APIHandler) getFilteredSpanAggregates(w http.ResponseWriter, r *http.R
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	tags, apiErr := scopeTagsToOrg(r.Context(), query.Tags)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.Tags = tags
 
 	result, apiErr := aH.reader.GetFilteredSpansAggregates(r.Context(), query)
 
@@ -1537,6 +3872,12 @@ func (aH *APIHandler) getTagFilters(w http.ResponseWriter, r *http.Request) {
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.OrgScope = scope
 
 	result, apiErr := aH.reader.GetTagFilters(r.Context(), query)
 
@@ -1553,6 +3894,12 @@ func (aH *APIHandler) getTagValues(w http.ResponseWriter, r *http.Request) {
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	query.OrgScope = scope
 
 	result, apiErr := aH.reader.GetTagValues(r.Context(), query)
 
@@ -1572,9 +3919,12 @@ func (aH *APIHandler) setTTL(w http.ResponseWriter, r *http.Request) {
 	// Context is not used here as TTL is long duration DB operation
 	result, apiErr := aH.reader.SetTTL(context.Background(), ttlParams)
 	if apiErr != nil {
-		if apiErr.Typ == model.ErrorConflict {
+		switch apiErr.Typ {
+		case model.ErrorConflict:
 			aH.HandleError(w, apiErr.Err, http.StatusConflict)
-		} else {
+		case model.ErrorBadData:
+			aH.HandleError(w, apiErr.Err, http.StatusBadRequest)
+		default:
 			aH.HandleError(w, apiErr.Err, http.StatusInternalServerError)
 		}
 		return
@@ -1584,6 +3934,26 @@ func (aH *APIHandler) setTTL(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// previewTTL takes the same params as setTTL and returns the data it would delete
+func (aH *APIHandler) previewTTL(w http.ResponseWriter, r *http.Request) {
+	ttlParams, err := parseTTLParams(r)
+	if aH.HandleError(w, err, http.StatusBadRequest) {
+		return
+	}
+
+	result, apiErr := aH.reader.PreviewTTL(r.Context(), ttlParams)
+	if apiErr != nil {
+		if apiErr.Typ == model.ErrorBadData {
+			aH.HandleError(w, apiErr.Err, http.StatusBadRequest)
+		} else {
+			aH.HandleError(w, apiErr.Err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	aH.WriteJSON(w, r, result)
+}
+
 func (aH *APIHandler) getTTL(w http.ResponseWriter, r *http.Request) {
 	ttlParams, err := parseGetTTL(r)
 	if aH.HandleError(w, err, http.StatusBadRequest) {
@@ -1607,6 +3977,63 @@ func (aH *APIHandler) getDisks(w http.ResponseWriter, r *http.Request) {
 	aH.WriteJSON(w, r, result)
 }
 
+func (aH *APIHandler) getStorageTiers(w http.ResponseWriter, r *http.Request) {
+	result, apiErr := aH.reader.GetStorageTiers(r.Context())
+	if apiErr != nil && aH.HandleError(w, apiErr.Err, http.StatusInternalServerError) {
+		return
+	}
+
+	aH.WriteJSON(w, r, result)
+}
+
+func (aH *APIHandler) getQueriesInFlight(w http.ResponseWriter, r *http.Request) {
+	user := common.GetUserFromContext(r.Context())
+	if user == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnauthorized, Err: errors.New("failed to get user from context")}, nil)
+		return
+	}
+
+	aH.Respond(w, aH.reader.GetQueriesInFlight(r.Context(), user.OrgId))
+}
+
+func (aH *APIHandler) cancelQuery(w http.ResponseWriter, r *http.Request) {
+	user := common.GetUserFromContext(r.Context())
+	if user == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnauthorized, Err: errors.New("failed to get user from context")}, nil)
+		return
+	}
+
+	queries, apiErr := aH.reader.CancelQuery(r.Context(), user, mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, queries)
+}
+
+func (aH *APIHandler) setStorageTierPolicy(w http.ResponseWriter, r *http.Request) {
+	params, err := parseStorageTierParams(r)
+	if aH.HandleError(w, err, http.StatusBadRequest) {
+		return
+	}
+
+	// Context is not used here as altering the TTL can outlive the request
+	result, apiErr := aH.reader.SetStorageTierPolicy(context.Background(), params)
+	if apiErr != nil {
+		switch apiErr.Typ {
+		case model.ErrorConflict:
+			aH.HandleError(w, apiErr.Err, http.StatusConflict)
+		case model.ErrorBadData:
+			aH.HandleError(w, apiErr.Err, http.StatusBadRequest)
+		default:
+			aH.HandleError(w, apiErr.Err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	aH.WriteJSON(w, r, result)
+}
+
 func (aH *APIHandler) getVersion(w http.ResponseWriter, r *http.Request) {
 	version := version.GetVersion()
 	versionResponse := model.GetVersionResponse{
@@ -1682,6 +4109,12 @@ func (aH *APIHandler) inviteUser(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, &model.ApiError{Err: err, Typ: model.ErrorInternal}, nil)
 		return
 	}
+	aH.WebhookManager.Publish(r.Context(), webhooks.EventUserInvited, webhooks.InviteEvent{
+		Name:      req.Name,
+		Email:     req.Email,
+		Role:      req.Role,
+		InvitedBy: webhookUser(r),
+	})
 	aH.WriteJSON(w, r, resp)
 }
 
@@ -1702,6 +4135,18 @@ func (aH *APIHandler) getInvite(w http.ResponseWriter, r *http.Request) {
 func (aH *APIHandler) revokeInvite(w http.ResponseWriter, r *http.Request) {
 	email := mux.Vars(r)["email"]
 
+	inv, apiErr := dao.DB().GetInviteFromEmail(r.Context(), email)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if inv != nil {
+		if apiErr := CheckActiveOrg(r, inv.OrgId); apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+	}
+
 	if err := auth.RevokeInvite(r.Context(), email); err != nil {
 		RespondError(w, &model.ApiError{Err: err, Typ: model.ErrorInternal}, nil)
 		return
@@ -1709,24 +4154,30 @@ func (aH *APIHandler) revokeInvite(w http.ResponseWriter, r *http.Request) {
 	aH.WriteJSON(w, r, map[string]string{"data": "invite revoked successfully"})
 }
 
-// listPendingInvites is used to list the pending invites.
+// listPendingInvites is used to list the pending invites of the org the user works in.
 func (aH *APIHandler) listPendingInvites(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
+	orgId, apiErr := activeOrgId(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 	invites, err := dao.DB().GetInvites(ctx)
 	if err != nil {
 		RespondError(w, err, nil)
 		return
 	}
+	org, apiErr := dao.DB().GetOrg(ctx, orgId)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 
-	// TODO(Ahsan): Querying org name based on orgId for each invite is not a good idea. Either
-	// we should include org name field in the invite table, or do a join query.
 	var resp []*model.InvitationResponseObject
 	for _, inv := range invites {
-
-		org, apiErr := dao.DB().GetOrg(ctx, inv.OrgId)
-		if apiErr != nil {
-			RespondError(w, apiErr, nil)
+		if inv.OrgId != orgId {
+			continue
 		}
 		resp = append(resp, &model.InvitationResponseObject{
 			Name:         inv.Name,
@@ -1785,6 +4236,8 @@ func (aH *APIHandler) loginUser(w http.ResponseWriter, r *http.Request) {
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	req.UserAgent = r.UserAgent()
+	req.IPAddress = auth.ClientIP(r)
 
 	// c, err := r.Cookie("refresh-token")
 	// if err != nil {
@@ -1814,7 +4267,13 @@ func (aH *APIHandler) loginUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (aH *APIHandler) listUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := dao.DB().GetUsers(context.Background())
+	// the users of the org the user works in
+	orgId, err := activeOrgId(r)
+	if err != nil {
+		RespondError(w, err, nil)
+		return
+	}
+	users, err := dao.DB().GetUsersByOrg(context.Background(), orgId)
 	if err != nil {
 		zap.L().Error("[listUsers] Failed to query list of users", zap.Error(err))
 		RespondError(w, err, nil)
@@ -1854,6 +4313,10 @@ func (aH *APIHandler) getUser(w http.ResponseWriter, r *http.Request) {
 // to not support update of orgId, Password, createdAt for the sucurity reasons.
 func (aH *APIHandler) editUser(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if apiErr := checkUserAccount(r, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 
 	update, err := parseUserRequest(r)
 	if aH.HandleError(w, err, http.StatusBadRequest) {
@@ -1893,9 +4356,13 @@ func (aH *APIHandler) editUser(w http.ResponseWriter, r *http.Request) {
 
 func (aH *APIHandler) deleteUser(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if apiErr := checkUserAccount(r, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 
 	// Query for the user's group, and the admin's group. If the user belongs to the admin group
-	// and is the last user then don't let the deletion happen. Otherwise, the system will become
+	// and is the last user then don't let the deletion happen. Otherwise, the org will become
 	// admin less and hence inaccessible.
 	ctx := context.Background()
 	user, apiErr := dao.DB().GetUser(ctx, id)
@@ -1917,13 +4384,13 @@ func (aH *APIHandler) deleteUser(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, apiErr, "Failed to get admin group")
 		return
 	}
-	adminUsers, apiErr := dao.DB().GetUsersByGroup(ctx, adminGroup.Id)
+	admins, apiErr := countOrgAdmins(r, user.OrgId)
 	if apiErr != nil {
 		RespondError(w, apiErr, "Failed to get admin group users")
 		return
 	}
 
-	if user.GroupId == adminGroup.Id && len(adminUsers) == 1 {
+	if user.GroupId == adminGroup.Id && admins == 1 {
 		RespondError(w, &model.ApiError{
 			Typ: model.ErrorInternal,
 			Err: errors.New("cannot delete the last admin user")}, nil)
@@ -1942,6 +4409,10 @@ func (aH *APIHandler) deleteUser(w http.ResponseWriter, r *http.Request) {
 func (aH *APIHandler) patchUserFlag(w http.ResponseWriter, r *http.Request) {
 	// read user id from path var
 	userId := mux.Vars(r)["id"]
+	if apiErr := checkUserAccount(r, userId); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 
 	// read input into user flag
 	defer r.Body.Close()
@@ -1968,29 +4439,18 @@ func (aH *APIHandler) patchUserFlag(w http.ResponseWriter, r *http.Request) {
 
 	aH.Respond(w, newflags)
 }
-
-func (aH *APIHandler) getRole(w http.ResponseWriter, r *http.Request) {
-	id := mux.Vars(r)["id"]
-
-	user, err := dao.DB().GetUser(context.Background(), id)
-	if err != nil {
-		RespondError(w, err, "Failed to get user's group")
-		return
-	}
-	if user == nil {
-		RespondError(w, &model.ApiError{
-			Typ: model.ErrorNotFound,
-			Err: errors.New("no user found"),
-		}, nil)
-		return
-	}
-	group, err := dao.DB().GetGroup(context.Background(), user.GroupId)
-	if err != nil {
-		RespondError(w, err, "Failed to get group")
+
+// getRole returns the role of the user in the org the user of the request works in
+func (aH *APIHandler) getRole(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	member, apiErr := userInActiveOrg(r, id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
 		return
 	}
 
-	aH.WriteJSON(w, r, &model.UserRole{UserId: id, GroupName: group.Name})
+	aH.WriteJSON(w, r, &model.UserRole{UserId: id, GroupName: member.Role})
 }
 
 func (aH *APIHandler) editRole(w http.ResponseWriter, r *http.Request) {
@@ -2013,21 +4473,22 @@ func (aH *APIHandler) editRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, apiErr := dao.DB().GetUser(ctx, id)
+	// the role of the user in the org the admin works in
+	member, apiErr := userInActiveOrg(r, id)
 	if apiErr != nil {
-		RespondError(w, apiErr, "Failed to fetch user group")
+		RespondError(w, apiErr, nil)
 		return
 	}
 
 	// Make sure that the request is not demoting the last admin user.
-	if user.GroupId == auth.AuthCacheObj.AdminGroupId {
-		adminUsers, apiErr := dao.DB().GetUsersByGroup(ctx, auth.AuthCacheObj.AdminGroupId)
+	if member.GroupId == auth.AuthCacheObj.AdminGroupId {
+		admins, apiErr := countOrgAdmins(r, member.OrgId)
 		if apiErr != nil {
 			RespondError(w, apiErr, "Failed to fetch adminUsers")
 			return
 		}
 
-		if len(adminUsers) == 1 {
+		if admins == 1 {
 			RespondError(w, &model.ApiError{
 				Err: errors.New("cannot demote the last admin"),
 				Typ: model.ErrorInternal}, nil)
@@ -2035,7 +4496,11 @@ func (aH *APIHandler) editRole(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	apiErr = dao.DB().UpdateUserGroup(context.Background(), user.Id, newGroup.Id)
+	if member.Home {
+		apiErr = dao.DB().UpdateUserGroup(context.Background(), id, newGroup.Id)
+	} else {
+		apiErr = dao.DB().AddOrgMember(ctx, &model.OrgMember{OrgId: member.OrgId, UserId: id, GroupId: newGroup.Id, CreatedAt: time.Now().Unix()})
+	}
 	if apiErr != nil {
 		RespondError(w, apiErr, "Failed to add user to group")
 		return
@@ -2043,17 +4508,33 @@ func (aH *APIHandler) editRole(w http.ResponseWriter, r *http.Request) {
 	aH.WriteJSON(w, r, map[string]string{"data": "user group updated successfully"})
 }
 
+// getOrgs lists the org the user works in, the orgs are isolated from each other
 func (aH *APIHandler) getOrgs(w http.ResponseWriter, r *http.Request) {
 	orgs, apiErr := dao.DB().GetOrgs(context.Background())
 	if apiErr != nil {
 		RespondError(w, apiErr, "Failed to fetch orgs from the DB")
 		return
 	}
-	aH.WriteJSON(w, r, orgs)
+	orgId, apiErr := activeOrgId(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	active := []model.Organization{}
+	for _, org := range orgs {
+		if org.Id == orgId {
+			active = append(active, org)
+		}
+	}
+	aH.WriteJSON(w, r, active)
 }
 
 func (aH *APIHandler) getOrg(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if apiErr := CheckActiveOrg(r, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 	org, apiErr := dao.DB().GetOrg(context.Background(), id)
 	if apiErr != nil {
 		RespondError(w, apiErr, "Failed to fetch org from the DB")
@@ -2064,6 +4545,10 @@ func (aH *APIHandler) getOrg(w http.ResponseWriter, r *http.Request) {
 
 func (aH *APIHandler) editOrg(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if apiErr := CheckActiveOrg(r, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 	req, err := parseEditOrgRequest(r)
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
@@ -2091,6 +4576,10 @@ func (aH *APIHandler) editOrg(w http.ResponseWriter, r *http.Request) {
 
 func (aH *APIHandler) getOrgUsers(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if apiErr := CheckActiveOrg(r, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 	users, apiErr := dao.DB().GetUsersByOrg(context.Background(), id)
 	if apiErr != nil {
 		RespondError(w, apiErr, "Failed to fetch org users from the DB")
@@ -2105,6 +4594,10 @@ func (aH *APIHandler) getOrgUsers(w http.ResponseWriter, r *http.Request) {
 
 func (aH *APIHandler) getResetPasswordToken(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if apiErr := checkUserAccount(r, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
 	resp, err := auth.CreateResetPasswordToken(context.Background(), id)
 	if err != nil {
 		RespondError(w, &model.ApiError{
@@ -2197,24 +4690,24 @@ func (ah *APIHandler) RegisterIntegrationRoutes(router *mux.Router, am *AuthMidd
 	subRouter := router.PathPrefix("/api/v1/integrations").Subrouter()
 
 	subRouter.HandleFunc(
-		"/install", am.ViewAccess(ah.InstallIntegration),
+		"/install", am.ViewAccess(defaultOrgOnly(ah.InstallIntegration)),
 	).Methods(http.MethodPost)
 
 	subRouter.HandleFunc(
-		"/uninstall", am.ViewAccess(ah.UninstallIntegration),
+		"/uninstall", am.ViewAccess(defaultOrgOnly(ah.UninstallIntegration)),
 	).Methods(http.MethodPost)
 
 	// Used for polling for status in v0
 	subRouter.HandleFunc(
-		"/{integrationId}/connection_status", am.ViewAccess(ah.GetIntegrationConnectionStatus),
+		"/{integrationId}/connection_status", am.ViewAccess(defaultOrgOnly(ah.GetIntegrationConnectionStatus)),
 	).Methods(http.MethodGet)
 
 	subRouter.HandleFunc(
-		"/{integrationId}", am.ViewAccess(ah.GetIntegration),
+		"/{integrationId}", am.ViewAccess(defaultOrgOnly(ah.GetIntegration)),
 	).Methods(http.MethodGet)
 
 	subRouter.HandleFunc(
-		"", am.ViewAccess(ah.ListIntegrations),
+		"", am.ViewAccess(defaultOrgOnly(ah.ListIntegrations)),
 	).Methods(http.MethodGet)
 }
 
@@ -2506,13 +4999,16 @@ func (aH *APIHandler) RegisterLogsRoutes(router *mux.Router, am *AuthMiddleware)
 	subRouter.HandleFunc("", am.ViewAccess(aH.getLogs)).Methods(http.MethodGet)
 	subRouter.HandleFunc("/tail", am.ViewAccess(aH.tailLogs)).Methods(http.MethodGet)
 	subRouter.HandleFunc("/fields", am.ViewAccess(aH.logFields)).Methods(http.MethodGet)
-	subRouter.HandleFunc("/fields", am.EditAccess(aH.logFieldUpdate)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/fields", am.EditAccess(defaultOrgOnly(aH.logFieldUpdate))).Methods(http.MethodPost)
 	subRouter.HandleFunc("/aggregate", am.ViewAccess(aH.logAggregate)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/patterns", am.ViewAccess(aH.logPatterns)).Methods(http.MethodGet)
 
 	// log pipelines
-	subRouter.HandleFunc("/pipelines/preview", am.ViewAccess(aH.PreviewLogsPipelinesHandler)).Methods(http.MethodPost)
-	subRouter.HandleFunc("/pipelines/{version}", am.ViewAccess(aH.ListLogsPipelinesHandler)).Methods(http.MethodGet)
-	subRouter.HandleFunc("/pipelines", am.EditAccess(aH.CreateLogsPipeline)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines/preview", am.Access(model.ResourcePipelines, model.ActionRead, defaultOrgOnly(aH.PreviewLogsPipelinesHandler))).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines/preview/historical", am.Access(model.ResourcePipelines, model.ActionRead, defaultOrgOnly(aH.PreviewHistoricalLogsPipelinesHandler))).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines/grok_patterns", am.Access(model.ResourcePipelines, model.ActionRead, aH.ListGrokPatternsHandler)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/pipelines/{version}", am.Access(model.ResourcePipelines, model.ActionRead, defaultOrgOnly(aH.ListLogsPipelinesHandler))).Methods(http.MethodGet)
+	subRouter.HandleFunc("/pipelines", am.Access(model.ResourcePipelines, model.ActionCreate, defaultOrgOnly(aH.CreateLogsPipeline))).Methods(http.MethodPost)
 }
 
 func (aH *APIHandler) logFields(w http.ResponseWriter, r *http.Request) {
@@ -2554,6 +5050,12 @@ func (aH *APIHandler) getLogs(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, apiErr, "Incorrect params")
 		return
 	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	params.OrgScope = scope
 	res, apiErr := aH.reader.GetLogs(r.Context(), params)
 	if apiErr != nil {
 		RespondError(w, apiErr, "Failed to fetch logs from the DB")
@@ -2569,6 +5071,12 @@ func (aH *APIHandler) tailLogs(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, apiErr, "Incorrect params")
 		return
 	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	params.OrgScope = scope
 
 	// create the client
 	client := &model.LogsTailClient{Name: r.RemoteAddr, Logs: make(chan *model.SignozLog, 1000), Done: make(chan *bool), Error: make(chan error), Filter: *params}
@@ -2614,6 +5122,12 @@ func (aH *APIHandler) logAggregate(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, apiErr, "Incorrect params")
 		return
 	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	params.OrgScope = scope
 	res, apiErr := aH.reader.AggregateLogs(r.Context(), params)
 	if apiErr != nil {
 		RespondError(w, apiErr, "Failed to fetch logs aggregate from the DB")
@@ -2622,6 +5136,52 @@ func (aH *APIHandler) logAggregate(w http.ResponseWriter, r *http.Request) {
 	aH.WriteJSON(w, r, res)
 }
 
+// logPatterns clusters a sample of the logs of the time range into patterns, the
+// same number of logs is sampled from the previous window for the comparison
+func (aH *APIHandler) logPatterns(w http.ResponseWriter, r *http.Request) {
+	params, err := logs.ParseLogPatternsParams(r)
+	if err != nil {
+		apiErr := &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		RespondError(w, apiErr, "Incorrect params")
+		return
+	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	sample, apiErr := aH.reader.GetLogsSample(r.Context(), &model.LogsFilterParams{
+		Query:          params.Query,
+		TimestampStart: params.TimestampStart,
+		TimestampEnd:   params.TimestampEnd,
+		Limit:          params.SampleSize,
+		OrgScope:       scope,
+	})
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch logs from the DB")
+		return
+	}
+
+	baseline := []model.LogSample{}
+	if params.Compare {
+		window := params.TimestampEnd - params.TimestampStart
+		baseline, apiErr = aH.reader.GetLogsSample(r.Context(), &model.LogsFilterParams{
+			Query:          params.Query,
+			TimestampStart: params.TimestampStart - window,
+			TimestampEnd:   params.TimestampStart - 1,
+			Limit:          params.SampleSize,
+			OrgScope:       scope,
+		})
+		if apiErr != nil {
+			RespondError(w, apiErr, "Failed to fetch logs from the DB")
+			return
+		}
+	}
+
+	aH.WriteJSON(w, r, logpatterns.Mine(params, sample, baseline))
+}
+
 const logPipelines = "log_pipelines"
 
 func parseAgentConfigVersion(r *http.Request) (int, *model.ApiError) {
@@ -2664,6 +5224,38 @@ func (ah *APIHandler) PreviewLogsPipelinesHandler(w http.ResponseWriter, r *http
 	ah.Respond(w, resultLogs)
 }
 
+// ListGrokPatternsHandler lists the built-in patterns available to the grok parsers
+func (ah *APIHandler) ListGrokPatternsHandler(w http.ResponseWriter, r *http.Request) {
+	ah.Respond(w, logparsingpipeline.GrokPatternLibrary())
+}
+
+// PreviewHistoricalLogsPipelinesHandler replays a sample of the stored logs through draft pipelines
+func (ah *APIHandler) PreviewHistoricalLogsPipelinesHandler(w http.ResponseWriter, r *http.Request) {
+	req := logparsingpipeline.HistoricalPreviewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	params, err := req.LogsFilterParams(time.Now())
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	logs, apiErr := ah.reader.GetLogs(r.Context(), params)
+	if apiErr != nil {
+		RespondError(w, apiErr, "Failed to fetch logs")
+		return
+	}
+
+	res, apiErr := logparsingpipeline.PreviewHistoricalLogs(r.Context(), req.Pipelines, *logs)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	ah.Respond(w, res)
+}
+
 func (ah *APIHandler) ListLogsPipelinesHandler(w http.ResponseWriter, r *http.Request) {
 
 	version, err := parseAgentConfigVersion(r)
@@ -2685,9 +5277,21 @@ func (ah *APIHandler) ListLogsPipelinesHandler(w http.ResponseWriter, r *http.Re
 		RespondError(w, apierr, payload)
 		return
 	}
+	if version == -1 {
+		setETag(w, pipelinesETag(payload.ConfigVersion))
+	}
 	ah.Respond(w, payload)
 }
 
+// pipelinesETag returns the entity tag of the pipelines of the config version, nil before the
+// first version, the pipelines change with their version
+func pipelinesETag(version *agentConf.ConfigVersion) string {
+	if version == nil {
+		return `"0"`
+	}
+	return fmt.Sprintf(`"%d"`, version.Version)
+}
+
 // listLogsPipelines lists logs piplines for latest version
 func (ah *APIHandler) listLogsPipelines(ctx context.Context) (
 	*logparsingpipeline.PipelinesResponse, *model.ApiError,
@@ -2764,12 +5368,23 @@ func (ah *APIHandler) CreateLogsPipeline(w http.ResponseWriter, r *http.Request)
 		return ah.LogsParsingPipelineController.ApplyPipelines(ctx, postable)
 	}
 
+	latest, err := agentConf.GetLatestVersion(r.Context(), logPipelines)
+	if err != nil && err.Type() != model.ErrorNotFound {
+		RespondError(w, model.WrapApiError(err, "failed to get latest agent config version"), nil)
+		return
+	}
+	if err := checkPreconditions(r, pipelinesETag(latest)); err != nil {
+		RespondError(w, err, nil)
+		return
+	}
+
 	res, err := createPipeline(r.Context(), req.Pipelines)
 	if err != nil {
 		RespondError(w, err, nil)
 		return
 	}
 
+	setETag(w, pipelinesETag(res.ConfigVersion))
 	ah.Respond(w, res)
 }
 
@@ -2779,7 +5394,7 @@ func (aH *APIHandler) getSavedViews(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	category := r.URL.Query().Get("category")
 
-	queries, err := explorer.GetViewsForFilters(sourcePage, name, category)
+	queries, err := explorer.GetViewsForFilters(r.Context(), sourcePage, name, category)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
@@ -2810,9 +5425,9 @@ func (aH *APIHandler) createSavedViews(w http.ResponseWriter, r *http.Request) {
 
 func (aH *APIHandler) getSavedView(w http.ResponseWriter, r *http.Request) {
 	viewID := mux.Vars(r)["viewId"]
-	view, err := explorer.GetView(viewID)
+	view, err := explorer.GetView(r.Context(), viewID)
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		RespondError(w, savedViewApiError(err), nil)
 		return
 	}
 
@@ -2835,7 +5450,7 @@ func (aH *APIHandler) updateSavedView(w http.ResponseWriter, r *http.Request) {
 
 	err = explorer.UpdateView(r.Context(), viewID, view)
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		RespondError(w, savedViewApiError(err), nil)
 		return
 	}
 
@@ -2845,12 +5460,60 @@ func (aH *APIHandler) updateSavedView(w http.ResponseWriter, r *http.Request) {
 func (aH *APIHandler) deleteSavedView(w http.ResponseWriter, r *http.Request) {
 
 	viewID := mux.Vars(r)["viewId"]
-	err := explorer.DeleteView(viewID)
+	err := explorer.DeleteView(r.Context(), viewID)
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		RespondError(w, savedViewApiError(err), nil)
+		return
+	}
+
+	aH.Respond(w, nil)
+}
+
+func savedViewApiError(err error) *model.ApiError {
+	if errors.Is(err, explorer.ErrViewNotFound) {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: err}
+	}
+	return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+}
+
+// getDefaultSavedView responds with the default view of the user for the source page, null when not set
+func (aH *APIHandler) getDefaultSavedView(w http.ResponseWriter, r *http.Request) {
+	sourcePage := r.URL.Query().Get("sourcePage")
+	if sourcePage == "" {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("sourcePage is required")}, nil)
+		return
+	}
+
+	view, err := explorer.GetDefaultView(r.Context(), sourcePage)
+	if err != nil {
+		RespondError(w, savedViewApiError(err), nil)
+		return
+	}
+	aH.Respond(w, view)
+}
+
+func (aH *APIHandler) setDefaultSavedView(w http.ResponseWriter, r *http.Request) {
+	viewID := mux.Vars(r)["viewId"]
+	err := explorer.SetDefaultView(r.Context(), viewID)
+	if err != nil {
+		RespondError(w, savedViewApiError(err), nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+func (aH *APIHandler) clearDefaultSavedView(w http.ResponseWriter, r *http.Request) {
+	sourcePage := r.URL.Query().Get("sourcePage")
+	if sourcePage == "" {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("sourcePage is required")}, nil)
 		return
 	}
 
+	err := explorer.ClearDefaultView(r.Context(), sourcePage)
+	if err != nil {
+		RespondError(w, savedViewApiError(err), nil)
+		return
+	}
 	aH.Respond(w, nil)
 }
 
@@ -2892,12 +5555,14 @@ func (aH *APIHandler) autoCompleteAttributeKeys(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	switch req.DataSource {
-	case v3.DataSourceMetrics:
+	switch {
+	case aH.AttributeMetadataManager.Serves(r.Context(), req.DataSource, ""):
+		response, err = aH.AttributeMetadataManager.Keys(r.Context(), req)
+	case req.DataSource == v3.DataSourceMetrics:
 		response, err = aH.reader.GetMetricAttributeKeys(r.Context(), req)
-	case v3.DataSourceLogs:
+	case req.DataSource == v3.DataSourceLogs:
 		response, err = aH.reader.GetLogAttributeKeys(r.Context(), req)
-	case v3.DataSourceTraces:
+	case req.DataSource == v3.DataSourceTraces:
 		response, err = aH.reader.GetTraceAttributeKeys(r.Context(), req)
 	default:
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid data source")}, nil)
@@ -2912,6 +5577,23 @@ func (aH *APIHandler) autoCompleteAttributeKeys(w http.ResponseWriter, r *http.R
 	aH.Respond(w, response)
 }
 
+// autoCompleteFilterOperators returns the operators the filters of the data source support on
+// the keys of the data type
+func (aH *APIHandler) autoCompleteFilterOperators(w http.ResponseWriter, r *http.Request) {
+	dataSource := v3.DataSource(r.URL.Query().Get("dataSource"))
+	if err := dataSource.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	dataType := v3.AttributeKeyDataType(r.URL.Query().Get("dataType"))
+	operators := v3.FilterOperators(dataSource, dataType)
+	if operators == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid data type: %s", dataType)}, nil)
+		return
+	}
+	aH.Respond(w, &v3.FilterOperatorsResponse{Operators: operators})
+}
+
 func (aH *APIHandler) autoCompleteAttributeValues(w http.ResponseWriter, r *http.Request) {
 	var response *v3.FilterAttributeValueResponse
 	req, err := parseFilterAttributeValueRequest(r)
@@ -2920,13 +5602,29 @@ func (aH *APIHandler) autoCompleteAttributeValues(w http.ResponseWriter, r *http
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
 		return
 	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	req.OrgScope = scope
 
-	switch req.DataSource {
-	case v3.DataSourceMetrics:
+	switch {
+	case scope != nil && req.DataSource != v3.DataSourceMetrics:
+		// the attribute tables have the values of all the orgs, the values of the org are
+		// read from its logs or spans instead
+		response, apiErr = aH.orgAttributeValues(r.Context(), req)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+	case aH.AttributeMetadataManager.Serves(r.Context(), req.DataSource, req.FilterAttributeKey):
+		response, err = aH.AttributeMetadataManager.Values(r.Context(), req)
+	case req.DataSource == v3.DataSourceMetrics:
 		response, err = aH.reader.GetMetricAttributeValues(r.Context(), req)
-	case v3.DataSourceLogs:
+	case req.DataSource == v3.DataSourceLogs:
 		response, err = aH.reader.GetLogAttributeValues(r.Context(), req)
-	case v3.DataSourceTraces:
+	case req.DataSource == v3.DataSourceTraces:
 		response, err = aH.reader.GetTraceAttributeValues(r.Context(), req)
 	default:
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid data source")}, nil)
@@ -2941,6 +5639,80 @@ func (aH *APIHandler) autoCompleteAttributeValues(w http.ResponseWriter, r *http
 	aH.Respond(w, response)
 }
 
+// orgAttributeValues returns the most frequent values of the attribute in the logs or the
+// spans of the last day of the orgs of the user, with a builder query scoped to the orgs
+func (aH *APIHandler) orgAttributeValues(ctx context.Context, req *v3.FilterAttributeValueRequest) (*v3.FilterAttributeValueResponse, *model.ApiError) {
+	response := &v3.FilterAttributeValueResponse{}
+	if len(req.FilterAttributeKeyDataType) == 0 || len(req.TagType) == 0 || req.FilterAttributeKey == "body" {
+		return response, nil
+	}
+	if req.FilterAttributeKeyDataType == v3.AttributeKeyDataTypeBool {
+		response.BoolAttributeValues = []bool{true, false}
+		return response, nil
+	}
+
+	key := v3.AttributeKey{
+		Key:      req.FilterAttributeKey,
+		DataType: req.FilterAttributeKeyDataType,
+		Type:     v3.AttributeKeyType(req.TagType),
+	}
+	filters := &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{{Key: key, Operator: v3.FilterOperatorExists}}}
+	if req.SearchText != "" && key.DataType == v3.AttributeKeyDataTypeString {
+		filters.Items = append(filters.Items, v3.FilterItem{Key: key, Operator: v3.FilterOperatorContains, Value: req.SearchText})
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	end := time.Now().UnixMilli()
+	step := int64(24 * time.Hour / time.Second)
+	params := &v3.QueryRangeParamsV3{
+		Start:   end - step*1000,
+		End:     end,
+		Step:    step,
+		Version: "v4",
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeBuilder,
+			PanelType: v3.PanelTypeGraph,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {
+					QueryName:         "A",
+					Expression:        "A",
+					DataSource:        req.DataSource,
+					AggregateOperator: v3.AggregateOperatorCount,
+					StepInterval:      step,
+					Filters:           filters,
+					GroupBy:           []v3.AttributeKey{key},
+					OrderBy:           []v3.OrderBy{{ColumnName: constants.SigNozOrderByValue, Order: "desc"}},
+					Limit:             uint64(limit),
+				},
+			},
+		},
+	}
+	results, _, apiErr := aH.runQueryRangeV4(ctx, params)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	for _, result := range results {
+		for _, series := range result.Series {
+			value, ok := series.Labels[key.Key]
+			if !ok {
+				continue
+			}
+			switch key.DataType {
+			case v3.AttributeKeyDataTypeInt64, v3.AttributeKeyDataTypeFloat64:
+				if number, err := strconv.ParseFloat(value, 64); err == nil {
+					response.NumberAttributeValues = append(response.NumberAttributeValues, number)
+				}
+			default:
+				response.StringAttributeValues = append(response.StringAttributeValues, value)
+			}
+		}
+	}
+	return response, nil
+}
+
 func (aH *APIHandler) getLogFieldsV3(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3) (map[string]v3.AttributeKey, error) {
 	data := map[string]v3.AttributeKey{}
 	for _, query := range queryRangeParams.CompositeQuery.BuilderQueries {
@@ -3022,8 +5794,85 @@ func (aH *APIHandler) QueryRangeV3Format(w http.ResponseWriter, r *http.Request)
 	aH.Respond(w, queryRangeParams)
 }
 
+// estimateQueryRangeCost dry runs the builder queries of the query range, the queries are
+// explained against clickhouse for the rows and bytes they would scan without being run
+func (aH *APIHandler) estimateQueryRangeCost(w http.ResponseWriter, r *http.Request) {
+	queryRangeParams, apiErrorObj := ParseQueryRangeParams(r)
+	if apiErrorObj != nil {
+		RespondError(w, apiErrorObj, nil)
+		return
+	}
+	if queryRangeParams.CompositeQuery.QueryType != v3.QueryTypeBuilder {
+		RespondError(w, model.BadRequest(fmt.Errorf("only the builder queries can be estimated")), nil)
+		return
+	}
+	if apiErrorObj = scopeQueryToOrg(r.Context(), queryRangeParams); apiErrorObj != nil {
+		RespondError(w, apiErrorObj, nil)
+		return
+	}
+
+	if err := aH.populateTemporality(r.Context(), queryRangeParams); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if logsv3.EnrichmentRequired(queryRangeParams) {
+		fields, err := aH.getLogFieldsV3(r.Context(), queryRangeParams)
+		if err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+			return
+		}
+		logsv3.Enrich(queryRangeParams, fields)
+	}
+	spanKeys, err := aH.getSpanKeysV3(r.Context(), queryRangeParams)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	queries, err := aH.queryBuilder.PrepareQueries(queryRangeParams, spanKeys)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	// the expressions are left out as they read what their queries read
+	resp := model.QueryRangeCostEstimate{Queries: []model.QueryCostEstimate{}}
+	for name, builderQuery := range queryRangeParams.CompositeQuery.BuilderQueries {
+		query, ok := queries[name]
+		if !ok || builderQuery.Expression != name {
+			continue
+		}
+		estimate, apiErr := aH.reader.EstimateQueryCost(r.Context(), query)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		estimate.QueryName = name
+		resp.Queries = append(resp.Queries, *estimate)
+		resp.Total.Parts += estimate.Parts
+		resp.Total.Marks += estimate.Marks
+		resp.Total.Rows += estimate.Rows
+		resp.Total.Bytes += estimate.Bytes
+	}
+	resp.Total.CostClass = model.GetQueryCostClass(resp.Total.Bytes)
+	sort.Slice(resp.Queries, func(i, j int) bool {
+		return resp.Queries[i].QueryName < resp.Queries[j].QueryName
+	})
+
+	aH.Respond(w, resp)
+}
+
 func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter, r *http.Request) {
 
+	ctx, done := aH.trackQuery(ctx, queryRangeParams, "v3")
+	var apiErrObj *model.ApiError
+	defer func() { done(apiErrObj) }()
+
+	if apiErrObj = scopeQueryToOrg(ctx, queryRangeParams); apiErrObj != nil {
+		RespondError(w, apiErrObj, nil)
+		return
+	}
+
 	var result []*v3.Result
 	var err error
 	var errQuriesByName map[string]error
@@ -3035,7 +5884,7 @@ func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.Que
 			var fields map[string]v3.AttributeKey
 			fields, err = aH.getLogFieldsV3(ctx, queryRangeParams)
 			if err != nil {
-				apiErrObj := &model.ApiError{Typ: model.ErrorInternal, Err: err}
+				apiErrObj = &model.ApiError{Typ: model.ErrorInternal, Err: err}
 				RespondError(w, apiErrObj, errQuriesByName)
 				return
 			}
@@ -3044,7 +5893,7 @@ func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.Que
 
 		spanKeys, err = aH.getSpanKeysV3(ctx, queryRangeParams)
 		if err != nil {
-			apiErrObj := &model.ApiError{Typ: model.ErrorInternal, Err: err}
+			apiErrObj = &model.ApiError{Typ: model.ErrorInternal, Err: err}
 			RespondError(w, apiErrObj, errQuriesByName)
 			return
 		}
@@ -3053,7 +5902,7 @@ func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.Que
 	result, errQuriesByName, err = aH.querier.QueryRange(ctx, queryRangeParams, spanKeys)
 
 	if err != nil {
-		apiErrObj := &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		apiErrObj = &model.ApiError{Typ: model.ErrorBadData, Err: err}
 		RespondError(w, apiErrObj, errQuriesByName)
 		return
 	}
@@ -3081,7 +5930,9 @@ func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.Que
 	}
 
 	resp := v3.QueryRangeResponse{
-		Result: result,
+		Result:      result,
+		Deployments: aH.DeploymentManager.Markers(ctx, queryRangeParams),
+		Events:      aH.EventManager.Markers(ctx, queryRangeParams),
 	}
 
 	// This checks if the time for context to complete has exceeded.
@@ -3188,6 +6039,26 @@ func (aH *APIHandler) QueryRangeV3(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the formulas are joined in ClickHouse by the v3 queries, on their whole group by
+	for _, query := range queryRangeParams.CompositeQuery.BuilderQueries {
+		if query.Join != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("formula joins are only supported by the v4 query range API")}, nil)
+			return
+		}
+		if query.NestedAggregation != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("nested aggregations are only supported by the v4 query range API")}, nil)
+			return
+		}
+		if query.SeriesLimit != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("series limits are only supported by the v4 query range API")}, nil)
+			return
+		}
+	}
+	if len(queryRangeParams.Comparisons) > 0 {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("comparisons are only supported by the v4 query range API")}, nil)
+		return
+	}
+
 	// add temporality for each metric
 	temporalityErr := aH.populateTemporality(r.Context(), queryRangeParams)
 	if temporalityErr != nil {
@@ -3211,6 +6082,10 @@ func (aH *APIHandler) liveTailLogs(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, apiErrorObj, nil)
 		return
 	}
+	if apiErrorObj = scopeQueryToOrg(r.Context(), queryRangeParams); apiErrorObj != nil {
+		RespondError(w, apiErrorObj, nil)
+		return
+	}
 
 	var err error
 	var queryString string
@@ -3291,7 +6166,12 @@ func (aH *APIHandler) getMetricMetadata(w http.ResponseWriter, r *http.Request)
 	aH.WriteJSON(w, r, metricMetadata)
 }
 
-func (aH *APIHandler) queryRangeV4(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter, r *http.Request) {
+// runQueryRangeV4 runs the query range and post processes its results
+func (aH *APIHandler) runQueryRangeV4(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3) ([]*v3.Result, map[string]error, *model.ApiError) {
+
+	if apiErr := scopeQueryToOrg(ctx, queryRangeParams); apiErr != nil {
+		return nil, nil, apiErr
+	}
 
 	var result []*v3.Result
 	var err error
@@ -3304,27 +6184,21 @@ func (aH *APIHandler) queryRangeV4(ctx context.Context, queryRangeParams *v3.Que
 			var fields map[string]v3.AttributeKey
 			fields, err = aH.getLogFieldsV3(ctx, queryRangeParams)
 			if err != nil {
-				apiErrObj := &model.ApiError{Typ: model.ErrorInternal, Err: err}
-				RespondError(w, apiErrObj, errQuriesByName)
-				return
+				return nil, errQuriesByName, &model.ApiError{Typ: model.ErrorInternal, Err: err}
 			}
 			logsv3.Enrich(queryRangeParams, fields)
 		}
 
 		spanKeys, err = aH.getSpanKeysV3(ctx, queryRangeParams)
 		if err != nil {
-			apiErrObj := &model.ApiError{Typ: model.ErrorInternal, Err: err}
-			RespondError(w, apiErrObj, errQuriesByName)
-			return
+			return nil, errQuriesByName, &model.ApiError{Typ: model.ErrorInternal, Err: err}
 		}
 	}
 
 	result, errQuriesByName, err = aH.querierV2.QueryRange(ctx, queryRangeParams, spanKeys)
 
 	if err != nil {
-		apiErrObj := &model.ApiError{Typ: model.ErrorBadData, Err: err}
-		RespondError(w, apiErrObj, errQuriesByName)
-		return
+		return nil, errQuriesByName, &model.ApiError{Typ: model.ErrorBadData, Err: err}
 	}
 
 	if queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeBuilder {
@@ -3335,13 +6209,37 @@ func (aH *APIHandler) queryRangeV4(ctx context.Context, queryRangeParams *v3.Que
 	}
 
 	if err != nil {
-		apiErrObj := &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		return nil, errQuriesByName, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+	return result, errQuriesByName, nil
+}
+
+func (aH *APIHandler) queryRangeV4(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter, r *http.Request) {
+
+	ctx, done := aH.trackQuery(ctx, queryRangeParams, "v4")
+	// the queries are prepared in place, the comparisons are made from the params as requested
+	comparisons, apiErrObj := comparisonParams(queryRangeParams)
+	if apiErrObj != nil {
+		done(apiErrObj)
+		RespondError(w, apiErrObj, nil)
+		return
+	}
+	result, errQuriesByName, apiErrObj := aH.runQueryRangeV4(ctx, queryRangeParams)
+	var comparisonResults []v3.ComparisonResult
+	if apiErrObj == nil {
+		comparisonResults, errQuriesByName, apiErrObj = aH.runComparisons(ctx, comparisons)
+	}
+	done(apiErrObj)
+	if apiErrObj != nil {
 		RespondError(w, apiErrObj, errQuriesByName)
 		return
 	}
 	sendQueryResultEvents(r, result, queryRangeParams)
 	resp := v3.QueryRangeResponse{
-		Result: result,
+		Result:      result,
+		Deployments: aH.DeploymentManager.Markers(ctx, queryRangeParams),
+		Events:      aH.EventManager.Markers(ctx, queryRangeParams),
+		Comparisons: comparisonResults,
 	}
 
 	aH.Respond(w, resp)
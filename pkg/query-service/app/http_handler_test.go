@@ -2,13 +2,23 @@ package app
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	logsv3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
+	metricsv3 "go.signoz.io/signoz/pkg/query-service/app/metrics/v3"
+	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 )
 
 func TestPrepareQuery(t *testing.T) {
@@ -130,3 +140,82 @@ func TestPrepareQuery(t *testing.T) {
 		})
 	}
 }
+
+// costReader estimates the cost of the queries it is asked for
+type costReader struct {
+	interfaces.Reader
+	queries []string
+}
+
+func (r *costReader) EstimateQueryCost(ctx context.Context, query string) (*model.QueryCostEstimate, *model.ApiError) {
+	r.queries = append(r.queries, query)
+	const gb = uint64(1) << 30
+	return &model.QueryCostEstimate{Parts: 2, Marks: 10, Rows: 1000, Bytes: 6 * gb}, nil
+}
+
+func (r *costReader) FetchTemporality(ctx context.Context, metricNames []string) (map[string]map[v3.Temporality]bool, error) {
+	return map[string]map[v3.Temporality]bool{}, nil
+}
+
+func TestEstimateQueryRangeCost(t *testing.T) {
+	reader := &costReader{}
+	fm := featureManager.StartManager()
+	aH := &APIHandler{
+		reader:         reader,
+		featureFlags:   fm,
+		temporalityMap: map[string]map[v3.Temporality]bool{},
+		queryBuilder: queryBuilder.NewQueryBuilder(queryBuilder.QueryBuilderOptions{
+			BuildMetricQuery: metricsv3.PrepareMetricQuery,
+			BuildTraceQuery:  tracesV3.PrepareTracesQuery,
+			BuildLogQuery:    logsv3.PrepareLogsQuery,
+		}, fm),
+	}
+
+	metricQuery := func(name string) map[string]interface{} {
+		return map[string]interface{}{
+			"queryName":          name,
+			"dataSource":         "metrics",
+			"aggregateOperator":  "sum_rate",
+			"aggregateAttribute": map[string]interface{}{"key": "signoz_calls_total"},
+			"temporality":        "Cumulative",
+			"expression":         name,
+			"stepInterval":       60,
+		}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"start": 1700000000000,
+		"end":   1700003600000,
+		"step":  60,
+		"compositeQuery": map[string]interface{}{
+			"queryType": "builder",
+			"panelType": "graph",
+			"builderQueries": map[string]interface{}{
+				"A": metricQuery("A"),
+				"B": metricQuery("B"),
+				"F1": map[string]interface{}{
+					"queryName":  "F1",
+					"expression": "A / B",
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	aH.estimateQueryRangeCost(w, httptest.NewRequest(http.MethodPost, "/api/v3/query_range/estimate", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Data model.QueryRangeCostEstimate `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	// the formula reads what its queries read, it is not estimated
+	assert.Len(t, reader.queries, 2)
+	require.Len(t, resp.Data.Queries, 2)
+	assert.Equal(t, "A", resp.Data.Queries[0].QueryName)
+	assert.Equal(t, "B", resp.Data.Queries[1].QueryName)
+	assert.Equal(t, uint64(2000), resp.Data.Total.Rows)
+	assert.Equal(t, uint64(12)<<30, resp.Data.Total.Bytes)
+	assert.Equal(t, model.QueryCostMedium, resp.Data.Total.CostClass)
+}
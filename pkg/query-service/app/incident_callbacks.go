@@ -0,0 +1,90 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// maxCallbackBodySize is the size of the incident callbacks past which they are rejected
+const maxCallbackBodySize = 1 << 20
+
+// IncidentCallbackResponse tells if the callback acknowledged a firing alert
+type IncidentCallbackResponse struct {
+	Acknowledged bool `json:"acknowledged"`
+}
+
+// pagerDutyCallback receives the webhooks of the PagerDuty incidents of a channel, the
+// acknowledgements and the resolutions stop the escalation of the alerts of the incidents
+func (aH *APIHandler) pagerDutyCallback(w http.ResponseWriter, r *http.Request) {
+	aH.incidentCallback(w, r, func(receiver *am.Receiver, body []byte) (*am.IncidentUpdate, error) {
+		return receiver.PagerDutyIncidentUpdate(r.Header.Get("X-PagerDuty-Signature"), body)
+	})
+}
+
+// opsgenieCallback receives the webhooks of the Opsgenie alerts of a channel, the
+// acknowledgements and the closings stop the escalation of the alerts
+func (aH *APIHandler) opsgenieCallback(w http.ResponseWriter, r *http.Request) {
+	aH.incidentCallback(w, r, func(receiver *am.Receiver, body []byte) (*am.IncidentUpdate, error) {
+		return receiver.OpsgenieIncidentUpdate(r.URL.Query().Get("token"), body)
+	})
+}
+
+// incidentCallback reads the callback of the channel of the request with parse, which
+// authenticates it with the secrets of the channel, and acknowledges the alert of the
+// incident. The alerts keep being evaluated, they notify again once resolved and firing again
+func (aH *APIHandler) incidentCallback(w http.ResponseWriter, r *http.Request, parse func(*am.Receiver, []byte) (*am.IncidentUpdate, error)) {
+	channel, apiErr := aH.reader.GetChannel(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	receiver := am.Receiver{}
+	if err := json.Unmarshal([]byte(channel.Data), &receiver); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBodySize))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	update, err := parse(&receiver, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, am.ErrInvalidCallbackSecret):
+			RespondError(w, &model.ApiError{Typ: model.ErrorUnauthorized, Err: err}, nil)
+		default:
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		}
+		return
+	}
+	if update == nil {
+		aH.Respond(w, IncidentCallbackResponse{})
+		return
+	}
+
+	ruleId, hash, err := am.ParseIncidentKey(update.Key)
+	if err != nil {
+		// the incident was not raised by a SigNoz alert
+		aH.Respond(w, IncidentCallbackResponse{})
+		return
+	}
+	acknowledged := aH.ruleManager.Escalator().AcknowledgeAlert(r.Context(), ruleId, hash, update.By, time.Now())
+	zap.L().Info("incident updated from the incident management tool",
+		zap.String("channel", channel.Name),
+		zap.String("ruleId", ruleId),
+		zap.String("action", update.Action),
+		zap.String("by", update.By),
+		zap.Bool("acknowledged", acknowledged),
+	)
+	aH.Respond(w, IncidentCallbackResponse{Acknowledged: acknowledged})
+}
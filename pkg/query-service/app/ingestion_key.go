@@ -2,32 +2,286 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"go.signoz.io/signoz/pkg/query-service/dao"
 	"go.signoz.io/signoz/pkg/query-service/model"
 )
 
+const (
+	// ingestionKeyHeader is the header the ingestion gateway forwards the key of the batch in
+	ingestionKeyHeader = "signoz-ingestion-key"
+	// maxIngestionKeyUsageDays is the number of days of usage the API returns at most
+	maxIngestionKeyUsageDays = 90
+)
+
+func usageDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+func generateIngestionKey() string {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return base64.RawURLEncoding.EncodeToString(key)
+}
+
+// insertIngestionKey adds a key, the key is generated when not set, e.g. for the self hosted
+// instances whose keys are not created by the cloud
 func (aH *APIHandler) insertIngestionKey(w http.ResponseWriter, r *http.Request) {
 	req, err := parseInsertIngestionKeyRequest(r)
 	if aH.HandleError(w, err, http.StatusBadRequest) {
 		return
 	}
+	if err := req.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if req.KeyId == "" {
+		req.KeyId = uuid.NewString()
+	}
+	if req.IngestionKey == "" {
+		req.IngestionKey = generateIngestionKey()
+	}
+	// the key sends the data of the org it is created in
+	orgId, apiErr := activeOrgId(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	req.OrgId = orgId
 
 	if err := dao.DB().InsertIngestionKey(context.Background(), req); err != nil {
 		RespondError(w, &model.ApiError{Err: err, Typ: model.ErrorInternal}, nil)
 		return
 	}
 
-	aH.WriteJSON(w, r, map[string]string{"data": "ingestion key added successfully"})
+	aH.WriteJSON(w, r, map[string]string{"data": "ingestion key added successfully", "keyId": req.KeyId})
 }
 
+// getIngestionKeys lists the keys of the org the user works in
 func (aH *APIHandler) getIngestionKeys(w http.ResponseWriter, r *http.Request) {
-	ingestionKeys, err := dao.DB().GetIngestionKeys(context.Background())
-	if err != nil {
-		RespondError(w, &model.ApiError{Err: err, Typ: model.ErrorInternal}, nil)
+	ingestionKeys, apiErr := orgIngestionKeys(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
 		return
 	}
 
 	aH.WriteJSON(w, r, ingestionKeys)
 }
+
+// orgIngestionKeys returns the keys of the org the user of the request works in
+func orgIngestionKeys(r *http.Request) ([]model.IngestionKey, *model.ApiError) {
+	ingestionKeys, err := dao.DB().GetIngestionKeys(r.Context())
+	if err != nil {
+		return nil, &model.ApiError{Err: err, Typ: model.ErrorInternal}
+	}
+	orgId, apiErr := activeOrgId(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	defaultOrg, apiErr := defaultOrgId(r.Context())
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	keys := []model.IngestionKey{}
+	for _, key := range ingestionKeys {
+		if key.OrgId == orgId || (key.OrgId == "" && defaultOrg == orgId) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// getOrgIngestionKey returns the key of the route, the keys of the other orgs are not found
+func getOrgIngestionKey(r *http.Request, keyId string) (*model.IngestionKey, *model.ApiError) {
+	key, apiErr := dao.DB().GetIngestionKey(r.Context(), keyId)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	keyOrg, apiErr := ingestionKeyOrgId(r.Context(), key)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	orgId, apiErr := activeOrgId(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if keyOrg != orgId {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("ingestion key not found")}
+	}
+	return key, nil
+}
+
+// ingestionKeyOrgId returns the org the data sent with the key is tagged with, the keys
+// created before the orgs belong to the default org
+func ingestionKeyOrgId(ctx context.Context, key *model.IngestionKey) (string, *model.ApiError) {
+	if key.OrgId != "" {
+		return key.OrgId, nil
+	}
+	return defaultOrgId(ctx)
+}
+
+func (aH *APIHandler) updateIngestionKey(w http.ResponseWriter, r *http.Request) {
+	var req model.UpdateIngestionKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	key, apiErr := getOrgIngestionKey(r, mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if req.Name != "" {
+		key.Name = req.Name
+	}
+	key.Signals = req.Signals
+	key.DailyLimitBytes = req.DailyLimitBytes
+	if err := key.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	if apiErr := dao.DB().UpdateIngestionKey(r.Context(), key); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, key)
+}
+
+func (aH *APIHandler) revokeIngestionKey(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, apiErr := getOrgIngestionKey(r, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if apiErr := dao.DB().RevokeIngestionKey(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, map[string]string{"data": "ingestion key revoked successfully"})
+}
+
+// getIngestionKeyUsage returns the daily usage of the key, or of all the keys of the org, for
+// the last days (30 by default)
+func (aH *APIHandler) getIngestionKeyUsage(w http.ResponseWriter, r *http.Request) {
+	days := 30
+	if v := r.URL.Query().Get("days"); v != "" {
+		var err error
+		days, err = strconv.Atoi(v)
+		if err != nil || days <= 0 || days > maxIngestionKeyUsageDays {
+			RespondError(w, model.BadRequest(fmt.Errorf("days must be between 1 and %d", maxIngestionKeyUsageDays)), nil)
+			return
+		}
+	}
+
+	id := mux.Vars(r)["id"]
+	keys := map[string]bool{}
+	if id != "" {
+		if _, apiErr := getOrgIngestionKey(r, id); apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		keys[id] = true
+	} else {
+		orgKeys, apiErr := orgIngestionKeys(r)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		for _, key := range orgKeys {
+			keys[key.KeyId] = true
+		}
+	}
+	since := usageDay(time.Now().AddDate(0, 0, -(days - 1)))
+	usage, apiErr := dao.DB().GetIngestionKeyUsage(r.Context(), id, since)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	orgUsage := []model.IngestionKeyUsage{}
+	for _, u := range usage {
+		if keys[u.KeyId] {
+			orgUsage = append(orgUsage, u)
+		}
+	}
+	aH.Respond(w, orgUsage)
+}
+
+// ingestionKeyFromRequest reads the key from the ingestion key header, or from the bearer
+// token for the clients which can only set it, e.g. the Prometheus remote write
+func ingestionKeyFromRequest(r *http.Request) string {
+	if value := r.Header.Get(ingestionKeyHeader); value != "" {
+		return value
+	}
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// authorizeIngestion checks if the ingestion key of the request can send the bytes of the
+// signal, the bytes are counted in the usage of the key when allowed. The data is rejected
+// when the key is unknown or revoked, when the key is restricted to other signals or when
+// it exceeds the daily limit of the key. The org of the key is returned, the data is tagged
+// with it, whatever org the senders set
+func authorizeIngestion(r *http.Request, signal string, bytes int64) (*model.IngestionKey, *model.ApiError) {
+	value := ingestionKeyFromRequest(r)
+	if value == "" {
+		return nil, &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("missing ingestion key")}
+	}
+	key, apiErr := dao.DB().GetIngestionKeyByValue(r.Context(), value)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if key == nil || key.Revoked {
+		return nil, &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("invalid ingestion key")}
+	}
+	if !key.Allows(signal) {
+		return nil, &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("the ingestion key can not send %s", signal)}
+	}
+
+	ok, apiErr := dao.DB().AddIngestionKeyUsage(r.Context(), key, usageDay(time.Now()), signal, bytes)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !ok {
+		return nil, &model.ApiError{Typ: model.ErrorTooManyRequests, Err: fmt.Errorf("the ingestion key exceeded its daily limit of %d bytes", key.DailyLimitBytes)}
+	}
+	if key.OrgId, apiErr = ingestionKeyOrgId(r.Context(), key); apiErr != nil {
+		return nil, apiErr
+	}
+	return key, nil
+}
+
+// checkIngestionKey is called by the ingestion gateway, or the auth extension of the collector,
+// before accepting a batch. The batch must be tagged with the org of the key
+func (aH *APIHandler) checkIngestionKey(w http.ResponseWriter, r *http.Request) {
+	var req model.CheckIngestionKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if err := (model.IngestionSignals{req.Signal}).Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if req.Bytes < 0 {
+		RespondError(w, model.BadRequest(fmt.Errorf("bytes can not be negative")), nil)
+		return
+	}
+
+	key, apiErr := authorizeIngestion(r, req.Signal, req.Bytes)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, model.CheckIngestionKeyResponse{KeyId: key.KeyId, OrgId: key.OrgId})
+}
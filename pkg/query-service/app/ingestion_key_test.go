@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestCheckIngestionKey(t *testing.T) {
+	require.NoError(t, dao.InitDao("sqlite", filepath.Join(t.TempDir(), "signoz.db")))
+	ctx := context.Background()
+	aH := &APIHandler{}
+
+	key := &model.IngestionKey{KeyId: "logs-key", Name: "logs", IngestionKey: "secret",
+		Signals: model.IngestionSignals{model.IngestionSignalLogs}, DailyLimitBytes: 100}
+	require.Nil(t, dao.DB().InsertIngestionKey(ctx, key))
+
+	check := func(value, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/ingestion_key/check", strings.NewReader(body))
+		if value != "" {
+			req.Header.Set(ingestionKeyHeader, value)
+		}
+		rr := httptest.NewRecorder()
+		aH.checkIngestionKey(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, check("", `{"signal": "logs", "bytes": 10}`))
+	assert.Equal(t, http.StatusUnauthorized, check("unknown", `{"signal": "logs", "bytes": 10}`))
+	assert.Equal(t, http.StatusBadRequest, check("secret", `{"signal": "spans", "bytes": 10}`))
+	assert.Equal(t, http.StatusForbidden, check("secret", `{"signal": "traces", "bytes": 10}`))
+	assert.Equal(t, http.StatusForbidden, check("secret", `{"signal": "profiles", "bytes": 10}`))
+	assert.Equal(t, http.StatusForbidden, check("secret", `{"signal": "events", "bytes": 10}`))
+	assert.Equal(t, http.StatusOK, check("secret", `{"signal": "logs", "bytes": 60}`))
+	assert.Equal(t, http.StatusOK, check("secret", `{"signal": "logs", "bytes": 40}`))
+	// the rejected batches are not counted in the usage
+	assert.Equal(t, http.StatusTooManyRequests, check("secret", `{"signal": "logs", "bytes": 1}`))
+
+	usage, apiErr := dao.DB().GetIngestionKeyUsage(ctx, key.KeyId, "")
+	require.Nil(t, apiErr)
+	require.Len(t, usage, 1)
+	assert.Equal(t, int64(100), usage[0].Bytes)
+	assert.Equal(t, int64(2), usage[0].Count)
+
+	stored, apiErr := dao.DB().GetIngestionKey(ctx, key.KeyId)
+	require.Nil(t, apiErr)
+	assert.Equal(t, key.Signals, stored.Signals)
+
+	require.Nil(t, dao.DB().RevokeIngestionKey(ctx, key.KeyId))
+	assert.Equal(t, http.StatusUnauthorized, check("secret", `{"signal": "logs", "bytes": 0}`))
+}
+
+func TestIngestionKeyOrgs(t *testing.T) {
+	require.NoError(t, dao.InitDao("sqlite", filepath.Join(t.TempDir(), "signoz.db")))
+	ctx := context.Background()
+	aH := &APIHandler{}
+
+	defaultOrg, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "default"})
+	require.Nil(t, apiErr)
+	org, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "org"})
+	require.Nil(t, apiErr)
+	// the keys created before the orgs send the data of the default org
+	require.Nil(t, dao.DB().InsertIngestionKey(ctx, &model.IngestionKey{KeyId: "legacy", Name: "legacy", IngestionKey: "legacy-secret"}))
+
+	request := func(method, body, orgId string, vars map[string]string) *http.Request {
+		req := httptest.NewRequest(method, "/", strings.NewReader(body))
+		user := &model.UserPayload{User: model.User{Id: "admin", OrgId: orgId}}
+		req = req.WithContext(context.WithValue(req.Context(), constants.ContextUserKey, user))
+		return mux.SetURLVars(req, vars)
+	}
+
+	rr := httptest.NewRecorder()
+	aH.insertIngestionKey(rr, request(http.MethodPost, `{"name": "org", "ingestionKey": "org-secret", "keyId": "org"}`, org.Id, nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	keyIds := func(orgId string) []string {
+		rr := httptest.NewRecorder()
+		aH.getIngestionKeys(rr, request(http.MethodGet, "", orgId, nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var keys []model.IngestionKey
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &keys))
+		ids := []string{}
+		for _, key := range keys {
+			ids = append(ids, key.KeyId)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"legacy"}, keyIds(defaultOrg.Id))
+	assert.Equal(t, []string{"org"}, keyIds(org.Id))
+
+	// the keys of the other orgs are not found
+	rr = httptest.NewRecorder()
+	aH.revokeIngestionKey(rr, request(http.MethodDelete, "", defaultOrg.Id, map[string]string{"keyId": "org"}))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// the gateway tags the data with the org of the key
+	check := func(value string) model.CheckIngestionKeyResponse {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/ingestion_key/check", strings.NewReader(`{"signal": "logs", "bytes": 10}`))
+		req.Header.Set(ingestionKeyHeader, value)
+		rr := httptest.NewRecorder()
+		aH.checkIngestionKey(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp struct {
+			Data model.CheckIngestionKeyResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp.Data
+	}
+	assert.Equal(t, model.CheckIngestionKeyResponse{KeyId: "org", OrgId: org.Id}, check("org-secret"))
+	assert.Equal(t, model.CheckIngestionKeyResponse{KeyId: "legacy", OrgId: defaultOrg.Id}, check("legacy-secret"))
+}
@@ -0,0 +1,219 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/issues"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func issueTrackerNotFound(id string) *model.ApiError {
+	return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("issue tracker %s not found", id)}
+}
+
+func (aH *APIHandler) listIssueTrackers(w http.ResponseWriter, r *http.Request) {
+	trackers, err := aH.IssueManager.ListTrackers(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, trackers)
+}
+
+func (aH *APIHandler) getIssueTracker(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	tracker, err := aH.IssueManager.GetTracker(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if tracker == nil {
+		RespondError(w, issueTrackerNotFound(id), nil)
+		return
+	}
+	aH.Respond(w, tracker)
+}
+
+func (aH *APIHandler) createIssueTracker(w http.ResponseWriter, r *http.Request) {
+	var tracker issues.Tracker
+	if err := json.NewDecoder(r.Body).Decode(&tracker); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := tracker.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	created, err := aH.IssueManager.CreateTracker(r.Context(), &tracker, webhookUser(r))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, created)
+}
+
+// editIssueTracker replaces the tracker, its token is kept when the request has none
+func (aH *APIHandler) editIssueTracker(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var tracker issues.Tracker
+	if err := json.NewDecoder(r.Body).Decode(&tracker); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := tracker.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	updated, err := aH.IssueManager.UpdateTracker(r.Context(), id, &tracker, webhookUser(r))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if updated == nil {
+		RespondError(w, issueTrackerNotFound(id), nil)
+		return
+	}
+	aH.Respond(w, updated)
+}
+
+func (aH *APIHandler) deleteIssueTracker(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := aH.IssueManager.DeleteTracker(r.Context(), id); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// openIssueRequest is the alert, or the group of the exception, the issue is opened for
+type openIssueRequest struct {
+	Alert *struct {
+		RuleId      string            `json:"ruleId"`
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+		// GeneratorURL is the link of the alert, the page of the rule by default
+		GeneratorURL string `json:"generatorURL"`
+	} `json:"alert"`
+	Exception *struct {
+		GroupId string `json:"groupId"`
+		// Timestamp is the time, in nanoseconds, of an exception of the group
+		Timestamp string `json:"timestamp"`
+	} `json:"exception"`
+}
+
+// openIssue opens an issue in the tracker for an alert or a group of exceptions, the open
+// issue of the alert or of the group is returned when there is one
+func (aH *APIHandler) openIssue(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var req openIssueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	var src *issues.Source
+	switch {
+	case req.Alert != nil && req.Exception != nil:
+		RespondError(w, model.BadRequest(fmt.Errorf("either alert or exception is required, not both")), nil)
+		return
+	case req.Alert != nil:
+		if req.Alert.RuleId == "" {
+			RespondError(w, model.BadRequest(fmt.Errorf("alert.ruleId is required")), nil)
+			return
+		}
+		lbls := map[string]string{}
+		for k, v := range req.Alert.Labels {
+			lbls[k] = v
+		}
+		lbls[labels.AlertRuleIdLabel] = req.Alert.RuleId
+		src = aH.IssueManager.AlertSource(lbls, req.Alert.Annotations, req.Alert.GeneratorURL)
+	case req.Exception != nil:
+		if req.Exception.GroupId == "" {
+			RespondError(w, model.BadRequest(fmt.Errorf("exception.groupId is required")), nil)
+			return
+		}
+		timestamp, err := parseTimeStr(req.Exception.Timestamp, "exception.timestamp")
+		if err != nil {
+			RespondError(w, model.BadRequest(err), nil)
+			return
+		}
+		scope, apiErr := common.OrgScope(r.Context())
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		exception, apiErr := aH.reader.GetErrorFromGroupID(r.Context(), &model.GetErrorParams{
+			GroupID:   req.Exception.GroupId,
+			Timestamp: timestamp,
+			OrgScope:  scope,
+		})
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		src = aH.IssueManager.ExceptionSource(exception)
+	default:
+		RespondError(w, model.BadRequest(fmt.Errorf("alert or exception is required")), nil)
+		return
+	}
+
+	tracker, err := aH.IssueManager.Tracker(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if tracker == nil {
+		RespondError(w, issueTrackerNotFound(id), nil)
+		return
+	}
+	issue, _, err := aH.IssueManager.Open(r.Context(), tracker, src, webhookUser(r))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnavailable, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, issue)
+}
+
+// listIssues lists the issues opened for the alerts and the exceptions, the newest first
+func (aH *APIHandler) listIssues(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := issues.Filter{
+		TrackerId: query.Get("trackerId"),
+		RuleId:    query.Get("ruleId"),
+		GroupId:   query.Get("groupId"),
+		State:     query.Get("state"),
+	}
+	if filter.State != "" && filter.State != issues.StateOpen && filter.State != issues.StateClosed {
+		RespondError(w, model.BadRequest(fmt.Errorf("invalid state %q", filter.State)), nil)
+		return
+	}
+
+	list, err := aH.IssueManager.Issues(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, list)
+}
+
+// closeIssue closes the issue in its tracker
+func (aH *APIHandler) closeIssue(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	issue, err := aH.IssueManager.CloseIssue(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnavailable, Err: err}, nil)
+		return
+	}
+	if issue == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("issue %s not found", id)}, nil)
+		return
+	}
+	aH.Respond(w, issue)
+}
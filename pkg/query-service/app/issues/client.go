@@ -0,0 +1,191 @@
+package issues
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultGitHubURL       = "https://api.github.com"
+	defaultJiraIssueType   = "Bug"
+	defaultCloseTransition = "Done"
+)
+
+// client opens and closes the issues of a tracker
+type client interface {
+	// open creates the issue and returns its key and its url
+	open(ctx context.Context, title, body string) (key, url string, err error)
+	close(ctx context.Context, key string) error
+}
+
+func newClient(t *Tracker, httpClient *http.Client) (client, error) {
+	switch t.Type {
+	case TrackerJira:
+		return &jiraClient{config: t.Config, http: httpClient}, nil
+	case TrackerGitHub:
+		return &githubClient{config: t.Config, http: httpClient}, nil
+	}
+	return nil, fmt.Errorf("unsupported tracker type %q", t.Type)
+}
+
+// call sends the request with the body encoded as json and decodes the response in result,
+// when not nil
+func call(ctx context.Context, httpClient *http.Client, req *http.Request, body, result interface{}) error {
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		req.ContentLength = int64(len(data))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s returned %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(message)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+type jiraClient struct {
+	config *TrackerConfig
+	http   *http.Client
+}
+
+func (c *jiraClient) request(method, path string) (*http.Request, error) {
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.config.URL, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.config.Email, c.config.Token)
+	return req, nil
+}
+
+func (c *jiraClient) open(ctx context.Context, title, body string) (string, string, error) {
+	issueType := c.config.IssueType
+	if issueType == "" {
+		issueType = defaultJiraIssueType
+	}
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": c.config.Project},
+		"summary":     title,
+		"description": body,
+		"issuetype":   map[string]string{"name": issueType},
+	}
+	if len(c.config.Labels) > 0 {
+		fields["labels"] = c.config.Labels
+	}
+	req, err := c.request(http.MethodPost, "/rest/api/2/issue")
+	if err != nil {
+		return "", "", err
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := call(ctx, c.http, req, map[string]interface{}{"fields": fields}, &created); err != nil {
+		return "", "", fmt.Errorf("failed to create jira issue: %w", err)
+	}
+	return created.Key, strings.TrimSuffix(c.config.URL, "/") + "/browse/" + created.Key, nil
+}
+
+// close moves the issue through the close transition of the config
+func (c *jiraClient) close(ctx context.Context, key string) error {
+	name := c.config.CloseTransition
+	if name == "" {
+		name = defaultCloseTransition
+	}
+	req, err := c.request(http.MethodGet, "/rest/api/2/issue/"+key+"/transitions")
+	if err != nil {
+		return err
+	}
+	var transitions struct {
+		Transitions []struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"transitions"`
+	}
+	if err := call(ctx, c.http, req, nil, &transitions); err != nil {
+		return fmt.Errorf("failed to get jira transitions: %w", err)
+	}
+	for _, transition := range transitions.Transitions {
+		if !strings.EqualFold(transition.Name, name) {
+			continue
+		}
+		req, err := c.request(http.MethodPost, "/rest/api/2/issue/"+key+"/transitions")
+		if err != nil {
+			return err
+		}
+		body := map[string]interface{}{"transition": map[string]string{"id": transition.Id}}
+		if err := call(ctx, c.http, req, body, nil); err != nil {
+			return fmt.Errorf("failed to close jira issue: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("jira issue %s has no %s transition", key, name)
+}
+
+type githubClient struct {
+	config *TrackerConfig
+	http   *http.Client
+}
+
+func (c *githubClient) request(method, path string) (*http.Request, error) {
+	base := c.config.URL
+	if base == "" {
+		base = defaultGitHubURL
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/issues%s", strings.TrimSuffix(base, "/"), c.config.Owner, c.config.Repo, path)
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	return req, nil
+}
+
+func (c *githubClient) open(ctx context.Context, title, body string) (string, string, error) {
+	issue := map[string]interface{}{"title": title, "body": body}
+	if len(c.config.Labels) > 0 {
+		issue["labels"] = c.config.Labels
+	}
+	req, err := c.request(http.MethodPost, "")
+	if err != nil {
+		return "", "", err
+	}
+	var created struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := call(ctx, c.http, req, issue, &created); err != nil {
+		return "", "", fmt.Errorf("failed to create github issue: %w", err)
+	}
+	return strconv.Itoa(created.Number), created.HTMLURL, nil
+}
+
+func (c *githubClient) close(ctx context.Context, key string) error {
+	req, err := c.request(http.MethodPatch, "/"+key)
+	if err != nil {
+		return err
+	}
+	if err := call(ctx, c.http, req, map[string]string{"state": "closed", "state_reason": "completed"}, nil); err != nil {
+		return fmt.Errorf("failed to close github issue: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+const (
+	// queueSize is the number of batches of alerts waiting to be handled past which they
+	// are dropped
+	queueSize     = 100
+	clientTimeout = 30 * time.Second
+)
+
+type ManagerOptions struct {
+	DB *sqlx.DB
+	// FrontendURL is the base url of the frontend the issues link to
+	FrontendURL string
+	HTTPClient  *http.Client
+}
+
+// Manager opens the issues of the alerts and the exceptions in the trackers and closes the
+// issues of the resolved alerts. The alerts are handled in the background so the rules
+// don't wait on the trackers
+type Manager struct {
+	repo        *repo
+	frontendURL string
+	client      *http.Client
+
+	// mtx serializes the opening of the issues so a source has one open issue per tracker
+	mtx sync.Mutex
+
+	alerts chan *alertBatch
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// alertBatch is the alerts sent by a rule, with the org of the rule
+type alertBatch struct {
+	orgId  string
+	alerts []*rules.Alert
+}
+
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	if err := InitSqliteDBIfNeeded(opts.DB); err != nil {
+		return nil, err
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: clientTimeout}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		repo:        &repo{db: opts.DB},
+		frontendURL: strings.TrimSuffix(opts.FrontendURL, "/"),
+		client:      opts.HTTPClient,
+		alerts:      make(chan *alertBatch, queueSize),
+		ctx:         ctx,
+		cancel:      cancel,
+	}, nil
+}
+
+// Start handles the alerts sent by the rules
+func (m *Manager) Start() error {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case batch := <-m.alerts:
+				ctx := m.ctx
+				if batch.orgId != "" {
+					ctx = common.WithOrg(ctx, batch.orgId)
+				}
+				m.handleAlerts(ctx, batch.alerts)
+			}
+		}
+	}()
+	return nil
+}
+
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// NotifyAlerts opens the issues of the firing alerts of the rules of the trackers and closes
+// the issues of the resolved alerts, it is a rules.NotifyFunc. The trackers are those of the
+// org of the context. The alerts are dropped, and logged, when the queue is full
+func (m *Manager) NotifyAlerts(ctx context.Context, expr string, alerts ...*rules.Alert) {
+	if m == nil || len(alerts) == 0 {
+		return
+	}
+	select {
+	case m.alerts <- &alertBatch{orgId: common.GetOrgIdFromContext(ctx), alerts: alerts}:
+	default:
+		zap.L().Warn("issue queue is full, dropping the alerts", zap.Int("count", len(alerts)))
+	}
+}
+
+func (m *Manager) handleAlerts(ctx context.Context, alerts []*rules.Alert) {
+	trackers, err := m.repo.listTrackers(ctx)
+	if err != nil {
+		zap.L().Error("failed to list the issue trackers", zap.Error(err))
+		return
+	}
+	if len(trackers) == 0 {
+		return
+	}
+	byId := map[string]*Tracker{}
+	for _, t := range trackers {
+		byId[t.Id] = t
+	}
+
+	for _, alert := range alerts {
+		if alert.Labels == nil {
+			continue
+		}
+		src := m.AlertSource(alert.Labels.Map(), annotationsOf(alert), alert.GeneratorURL)
+		if !alert.ResolvedAt.IsZero() {
+			open, err := m.repo.openIssues(ctx, "", src.Key)
+			if err != nil {
+				continue
+			}
+			for _, issue := range open {
+				if t, ok := byId[issue.TrackerId]; ok && t.AutoClose {
+					if err := m.closeIssue(ctx, t, issue); err != nil {
+						zap.L().Error("failed to close the issue of the resolved alert", zap.String("issue", issue.Key), zap.Error(err))
+					}
+				}
+			}
+			continue
+		}
+		if alert.State != rules.StateFiring {
+			continue
+		}
+		for _, t := range trackers {
+			if !t.opensFor(src.RuleId) {
+				continue
+			}
+			if _, _, err := m.Open(ctx, t, src, ""); err != nil {
+				zap.L().Error("failed to open the issue of the firing alert", zap.String("tracker", t.Name), zap.String("ruleId", src.RuleId), zap.Error(err))
+			}
+		}
+	}
+}
+
+func annotationsOf(alert *rules.Alert) map[string]string {
+	if alert.Annotations == nil {
+		return map[string]string{}
+	}
+	return alert.Annotations.Map()
+}
+
+// AlertSource returns the source of the issues of the alert with the labels, the link is the
+// generator url of the alert or the page of its rule
+func (m *Manager) AlertSource(lbls, annotations map[string]string, link string) *Source {
+	ruleId := lbls[labels.AlertRuleIdLabel]
+	if link == "" {
+		link = fmt.Sprintf("%s/alerts/edit?ruleId=%s", m.frontendURL, url.QueryEscape(ruleId))
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	return &Source{
+		Type:        SourceAlert,
+		Key:         fmt.Sprintf("%s:%s:%016x", SourceAlert, ruleId, labels.FromMap(lbls).Hash()),
+		Link:        link,
+		RuleId:      ruleId,
+		AlertName:   lbls[labels.AlertNameLabel],
+		Labels:      lbls,
+		Annotations: annotations,
+	}
+}
+
+// ExceptionSource returns the source of the issues of the group of the exception
+func (m *Manager) ExceptionSource(e *model.ErrorWithSpan) *Source {
+	return &Source{
+		Type: SourceException,
+		Key:  fmt.Sprintf("%s:%s", SourceException, e.GroupID),
+		Link: fmt.Sprintf("%s/error-detail?groupId=%s&timestamp=%d",
+			m.frontendURL, url.QueryEscape(e.GroupID), e.Timestamp.UnixNano()),
+		GroupId:          e.GroupID,
+		ServiceName:      e.ServiceName,
+		ExceptionType:    e.ExceptionType,
+		ExceptionMessage: e.ExceptionMsg,
+		Stacktrace:       e.ExceptionStacktrace,
+	}
+}
+
+// Open opens the issue of the source in the tracker, or returns the open issue of the source
+// with created unset
+func (m *Manager) Open(ctx context.Context, t *Tracker, src *Source, by string) (issue *Issue, created bool, err error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	open, err := m.repo.openIssues(ctx, t.Id, src.Key)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(open) > 0 {
+		return open[0], false, nil
+	}
+
+	title, body, err := t.render(src)
+	if err != nil {
+		return nil, false, err
+	}
+	c, err := newClient(t, m.client)
+	if err != nil {
+		return nil, false, err
+	}
+	key, issueURL, err := c.open(ctx, title, body)
+	if err != nil {
+		return nil, false, err
+	}
+	issue = &Issue{
+		Id:        uuid.NewString(),
+		TrackerId: t.Id,
+		Source:    src.Type,
+		SourceKey: src.Key,
+		RuleId:    src.RuleId,
+		GroupId:   src.GroupId,
+		Key:       key,
+		URL:       issueURL,
+		Title:     title,
+		State:     StateOpen,
+		CreatedAt: time.Now().UTC(),
+		CreatedBy: by,
+	}
+	if err := m.repo.insertIssue(ctx, issue); err != nil {
+		return nil, false, err
+	}
+	return issue, true, nil
+}
+
+func (m *Manager) closeIssue(ctx context.Context, t *Tracker, issue *Issue) error {
+	c, err := newClient(t, m.client)
+	if err != nil {
+		return err
+	}
+	if err := c.close(ctx, issue.Key); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	issue.State, issue.ClosedAt = StateClosed, &now
+	return m.repo.closeIssue(ctx, issue.Id, now)
+}
+
+// CloseIssue closes the issue with the id in its tracker, it returns nil when the issue does
+// not exist
+func (m *Manager) CloseIssue(ctx context.Context, id string) (*Issue, error) {
+	issue, err := m.repo.getIssue(ctx, id)
+	if err != nil || issue == nil {
+		return nil, err
+	}
+	if issue.State == StateClosed {
+		return issue, nil
+	}
+	t, err := m.repo.getTracker(ctx, issue.TrackerId)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("the tracker of the issue does not exist")
+	}
+	if err := m.closeIssue(ctx, t, issue); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+// Issues returns the issues selected by the filter, the newest first
+func (m *Manager) Issues(ctx context.Context, f Filter) ([]*Issue, error) {
+	return m.repo.listIssues(ctx, f, "")
+}
+
+// Tracker returns the tracker with the id, with its token, nil when it does not exist
+func (m *Manager) Tracker(ctx context.Context, id string) (*Tracker, error) {
+	return m.repo.getTracker(ctx, id)
+}
+
+// ListTrackers returns the trackers, without their tokens
+func (m *Manager) ListTrackers(ctx context.Context) ([]*Tracker, error) {
+	trackers, err := m.repo.listTrackers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range trackers {
+		t.Config.Token = ""
+	}
+	return trackers, nil
+}
+
+// GetTracker returns the tracker with the id, without its token, nil when it does not exist
+func (m *Manager) GetTracker(ctx context.Context, id string) (*Tracker, error) {
+	t, err := m.repo.getTracker(ctx, id)
+	if t != nil {
+		t.Config.Token = ""
+	}
+	return t, err
+}
+
+func (m *Manager) CreateTracker(ctx context.Context, t *Tracker, by string) (*Tracker, error) {
+	now := time.Now().UTC()
+	t.Id = uuid.NewString()
+	t.CreatedAt, t.CreatedBy = now, by
+	t.UpdatedAt, t.UpdatedBy = now, by
+	if t.RuleIds == nil {
+		t.RuleIds = &RuleIds{}
+	}
+	if err := m.repo.insertTracker(ctx, t); err != nil {
+		return nil, err
+	}
+	t.Config.Token = ""
+	return t, nil
+}
+
+// UpdateTracker replaces the tracker with the id, the token is kept when the tracker has
+// none. It returns nil when the tracker does not exist
+func (m *Manager) UpdateTracker(ctx context.Context, id string, t *Tracker, by string) (*Tracker, error) {
+	existing, err := m.repo.getTracker(ctx, id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if t.Config.Token == "" {
+		t.Config.Token = existing.Config.Token
+	}
+	if t.RuleIds == nil {
+		t.RuleIds = &RuleIds{}
+	}
+	t.Id = id
+	t.CreatedAt, t.CreatedBy = existing.CreatedAt, existing.CreatedBy
+	t.UpdatedAt, t.UpdatedBy = time.Now().UTC(), by
+	if err := m.repo.updateTracker(ctx, t); err != nil {
+		return nil, err
+	}
+	t.Config.Token = ""
+	return t, nil
+}
+
+// DeleteTracker deletes the tracker and the links of its issues
+func (m *Manager) DeleteTracker(ctx context.Context, id string) error {
+	return m.repo.deleteTracker(ctx, id)
+}
@@ -0,0 +1,312 @@
+package issues
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// github is a fake of the issues API of a GitHub repository
+type github struct {
+	mtx    sync.Mutex
+	issues map[int]map[string]interface{}
+}
+
+func (g *github) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/signoz/signoz/issues":
+		number := len(g.issues) + 1
+		body["state"] = "open"
+		g.issues[number] = body
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"number":   number,
+			"html_url": fmt.Sprintf("https://github.com/signoz/signoz/issues/%d", number),
+		})
+	case r.Method == http.MethodPatch:
+		var number int
+		fmt.Sscanf(r.URL.Path, "/repos/signoz/signoz/issues/%d", &number)
+		issue, ok := g.issues[number]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		issue["state"] = body["state"]
+		w.Write([]byte("{}"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (g *github) state(number int) interface{} {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	return g.issues[number]["state"]
+}
+
+func newTestManager(t *testing.T) *Manager {
+	db := utils.NewQueryServiceDBForTests(t)
+	m, err := NewManager(ManagerOptions{DB: db, FrontendURL: "https://signoz.example.com/"})
+	require.NoError(t, err)
+	return m
+}
+
+func newGitHubTracker(t *testing.T, m *Manager, url string, autoClose bool, ruleIds ...string) *Tracker {
+	ids := RuleIds(ruleIds)
+	tracker := &Tracker{
+		Name:      "github",
+		Type:      TrackerGitHub,
+		Config:    &TrackerConfig{URL: url, Token: "token", Owner: "signoz", Repo: "signoz"},
+		AutoClose: autoClose,
+		RuleIds:   &ids,
+	}
+	require.NoError(t, tracker.Validate())
+	created, err := m.CreateTracker(context.Background(), tracker, "admin@signoz.io")
+	require.NoError(t, err)
+	require.Empty(t, created.Config.Token)
+	return created
+}
+
+func testAlert(ruleId string, state rules.AlertState) *rules.Alert {
+	return &rules.Alert{
+		State: state,
+		Labels: labels.FromMap(map[string]string{
+			labels.AlertNameLabel:   "High latency",
+			labels.AlertRuleIdLabel: ruleId,
+			"service":               "frontend",
+		}),
+		Annotations: labels.FromMap(map[string]string{"summary": "p99 latency above 2s"}),
+	}
+}
+
+func TestManagerAlertIssues(t *testing.T) {
+	ctx := context.Background()
+	g := &github{issues: map[int]map[string]interface{}{}}
+	server := httptest.NewServer(g)
+	defer server.Close()
+
+	m := newTestManager(t)
+	tracker := newGitHubTracker(t, m, server.URL, true, "1")
+
+	// the alerts of the other rules don't open issues
+	m.handleAlerts(ctx, []*rules.Alert{testAlert("2", rules.StateFiring)})
+	require.Empty(t, g.issues)
+
+	firing := testAlert("1", rules.StateFiring)
+	m.handleAlerts(ctx, []*rules.Alert{firing})
+	m.handleAlerts(ctx, []*rules.Alert{firing})
+	require.Len(t, g.issues, 1)
+	require.Equal(t, "[SigNoz] High latency is firing", g.issues[1]["title"])
+	require.Contains(t, g.issues[1]["body"], "p99 latency above 2s")
+	require.Contains(t, g.issues[1]["body"], "https://signoz.example.com/alerts/edit?ruleId=1")
+
+	open, err := m.Issues(ctx, Filter{RuleId: "1", State: StateOpen})
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	require.Equal(t, tracker.Id, open[0].TrackerId)
+	require.Equal(t, "1", open[0].Key)
+	require.Equal(t, "https://github.com/signoz/signoz/issues/1", open[0].URL)
+
+	resolved := testAlert("1", rules.StateInactive)
+	resolved.ResolvedAt = time.Now()
+	m.handleAlerts(ctx, []*rules.Alert{resolved})
+	require.Equal(t, "closed", g.state(1))
+
+	closed, err := m.Issues(ctx, Filter{State: StateClosed})
+	require.NoError(t, err)
+	require.Len(t, closed, 1)
+	require.NotNil(t, closed[0].ClosedAt)
+
+	// the alert opens a new issue when it fires again
+	m.handleAlerts(ctx, []*rules.Alert{firing})
+	require.Len(t, g.issues, 2)
+}
+
+func TestManagerTrackersOrg(t *testing.T) {
+	orgA, orgB := common.WithOrg(context.Background(), "org-a"), common.WithOrg(context.Background(), "org-b")
+	g := &github{issues: map[int]map[string]interface{}{}}
+	server := httptest.NewServer(g)
+	defer server.Close()
+
+	m := newTestManager(t)
+	ids := RuleIds{"1"}
+	tracker, err := m.CreateTracker(orgA, &Tracker{
+		Name:    "github",
+		Type:    TrackerGitHub,
+		Config:  &TrackerConfig{URL: server.URL, Token: "token", Owner: "signoz", Repo: "signoz"},
+		RuleIds: &ids,
+	}, "admin@signoz.io")
+	require.NoError(t, err)
+
+	// the alerts of the rules of the other orgs don't open issues in the tracker
+	m.handleAlerts(orgB, []*rules.Alert{testAlert("1", rules.StateFiring)})
+	require.Empty(t, g.issues)
+	m.handleAlerts(orgA, []*rules.Alert{testAlert("1", rules.StateFiring)})
+	require.Len(t, g.issues, 1)
+
+	// the trackers and the issues of the other orgs are not found
+	trackers, err := m.ListTrackers(orgB)
+	require.NoError(t, err)
+	require.Empty(t, trackers)
+	got, err := m.GetTracker(orgB, tracker.Id)
+	require.NoError(t, err)
+	require.Nil(t, got)
+	issues, err := m.Issues(orgB, Filter{})
+	require.NoError(t, err)
+	require.Empty(t, issues)
+	issues, err = m.Issues(orgA, Filter{})
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	closed, err := m.CloseIssue(orgB, issues[0].Id)
+	require.NoError(t, err)
+	require.Nil(t, closed)
+}
+
+func TestManagerExceptionIssue(t *testing.T) {
+	ctx := context.Background()
+	g := &github{issues: map[int]map[string]interface{}{}}
+	server := httptest.NewServer(g)
+	defer server.Close()
+
+	m := newTestManager(t)
+	tracker := newGitHubTracker(t, m, server.URL, false)
+	tracker, err := m.Tracker(ctx, tracker.Id)
+	require.NoError(t, err)
+
+	src := m.ExceptionSource(&model.ErrorWithSpan{
+		GroupID:             "abc",
+		ServiceName:         "checkout",
+		ExceptionType:       "NullPointerException",
+		ExceptionMsg:        "cart is null",
+		ExceptionStacktrace: "at Checkout.pay(Checkout.java:42)",
+		Timestamp:           time.Unix(0, 1700000000000000000),
+	})
+	issue, created, err := m.Open(ctx, tracker, src, "user@signoz.io")
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Equal(t, "[SigNoz] NullPointerException in checkout", issue.Title)
+	require.Contains(t, g.issues[1]["body"], "https://signoz.example.com/error-detail?groupId=abc&timestamp=1700000000000000000")
+
+	again, created, err := m.Open(ctx, tracker, src, "user@signoz.io")
+	require.NoError(t, err)
+	require.False(t, created)
+	require.Equal(t, issue.Id, again.Id)
+
+	closed, err := m.CloseIssue(ctx, issue.Id)
+	require.NoError(t, err)
+	require.Equal(t, StateClosed, closed.State)
+	require.Equal(t, "closed", g.state(1))
+}
+
+func TestManagerJiraIssue(t *testing.T) {
+	ctx := context.Background()
+	var mtx sync.Mutex
+	var transitioned string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if user, token, ok := r.BasicAuth(); !ok || user != "bot@signoz.io" || token != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+			var body struct {
+				Fields map[string]interface{} `json:"fields"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Fields["project"].(map[string]interface{})["key"] != "OPS" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"key":"OPS-7"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/issue/OPS-7/transitions":
+			w.Write([]byte(`{"transitions":[{"id":"11","name":"In Progress"},{"id":"31","name":"Done"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue/OPS-7/transitions":
+			var body struct {
+				Transition struct {
+					Id string `json:"id"`
+				} `json:"transition"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			transitioned = body.Transition.Id
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	m := newTestManager(t)
+	tracker := &Tracker{
+		Name:          "jira",
+		Type:          TrackerJira,
+		Config:        &TrackerConfig{URL: server.URL, Email: "bot@signoz.io", Token: "token", Project: "OPS"},
+		TitleTemplate: `{{ .AlertName }} on {{ .Labels.service }}`,
+	}
+	require.NoError(t, tracker.Validate())
+	tracker, err := m.CreateTracker(ctx, tracker, "admin@signoz.io")
+	require.NoError(t, err)
+
+	// the token is kept when the update has none
+	tracker.Name = "ops"
+	_, err = m.UpdateTracker(ctx, tracker.Id, tracker, "admin@signoz.io")
+	require.NoError(t, err)
+	tracker, err = m.Tracker(ctx, tracker.Id)
+	require.NoError(t, err)
+	require.Equal(t, "token", tracker.Config.Token)
+
+	alert := testAlert("1", rules.StateFiring)
+	issue, _, err := m.Open(ctx, tracker, m.AlertSource(alert.Labels.Map(), nil, ""), "")
+	require.NoError(t, err)
+	require.Equal(t, "OPS-7", issue.Key)
+	require.Equal(t, server.URL+"/browse/OPS-7", issue.URL)
+	require.Equal(t, "High latency on frontend", issue.Title)
+
+	_, err = m.CloseIssue(ctx, issue.Id)
+	require.NoError(t, err)
+	require.Equal(t, "31", transitioned)
+}
+
+func TestTrackerValidate(t *testing.T) {
+	for _, tracker := range []*Tracker{
+		{Name: "", Type: TrackerGitHub, Config: &TrackerConfig{Owner: "o", Repo: "r"}},
+		{Name: "t", Type: "gitlab", Config: &TrackerConfig{}},
+		{Name: "t", Type: TrackerGitHub, Config: &TrackerConfig{Owner: "o"}},
+		{Name: "t", Type: TrackerJira, Config: &TrackerConfig{URL: "https://jira.example.com", Project: "OPS"}},
+		{Name: "t", Type: TrackerGitHub, Config: &TrackerConfig{URL: "ftp://github", Owner: "o", Repo: "r"}},
+		{Name: "t", Type: TrackerGitHub, Config: &TrackerConfig{Owner: "o", Repo: "r"}, TitleTemplate: "{{ .AlertName "},
+	} {
+		require.Error(t, tracker.Validate())
+	}
+}
+
+func TestRenderTruncatesTitle(t *testing.T) {
+	tracker := &Tracker{TitleTemplate: "{{ .AlertName }}"}
+	title, body, err := tracker.render(&Source{Type: SourceAlert, AlertName: strings.Repeat("a", 300), Link: "https://signoz.example.com"})
+	require.NoError(t, err)
+	require.Len(t, title, maxTitleLength)
+	require.True(t, strings.HasSuffix(title, "..."))
+	require.True(t, strings.HasSuffix(body, "https://signoz.example.com"))
+}
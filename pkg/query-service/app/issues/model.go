@@ -0,0 +1,194 @@
+package issues
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Types of the issue trackers
+const (
+	TrackerJira   = "jira"
+	TrackerGitHub = "github"
+)
+
+// Sources of the issues
+const (
+	SourceAlert     = "alert"
+	SourceException = "exception"
+)
+
+// States of the issues
+const (
+	StateOpen   = "open"
+	StateClosed = "closed"
+)
+
+// Tracker creates the issues of the alerts and the exceptions in a Jira project or a GitHub
+// repository
+type Tracker struct {
+	Id     string         `json:"id" db:"id"`
+	Name   string         `json:"name" db:"name"`
+	Type   string         `json:"type" db:"type"`
+	Config *TrackerConfig `json:"config" db:"config"`
+	// TitleTemplate and BodyTemplate are go templates executed with the Source of the issue,
+	// the default templates are used when empty
+	TitleTemplate string `json:"titleTemplate" db:"title_template"`
+	BodyTemplate  string `json:"bodyTemplate" db:"body_template"`
+	// AutoClose closes the issues of the alerts when the alerts resolve
+	AutoClose bool `json:"autoClose" db:"auto_close"`
+	// RuleIds are the rules whose firing alerts open issues, the issues of the other alerts
+	// and of the exceptions are opened through the API
+	RuleIds   *RuleIds  `json:"ruleIds" db:"rule_ids"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	CreatedBy string    `json:"createdBy" db:"created_by"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	UpdatedBy string    `json:"updatedBy" db:"updated_by"`
+	OrgId     string    `json:"-" db:"org_id"`
+}
+
+// TrackerConfig is the connection to the tracker, the fields depend on the type
+type TrackerConfig struct {
+	// URL is the base url of the Jira site, or of the GitHub API, https://api.github.com by default
+	URL string `json:"url,omitempty"`
+	// Token is the API token of the Jira user or the GitHub token, it is not returned
+	Token string `json:"token,omitempty"`
+
+	// Email is the email of the Jira user of the token
+	Email     string `json:"email,omitempty"`
+	Project   string `json:"project,omitempty"`
+	IssueType string `json:"issueType,omitempty"`
+	// CloseTransition is the name of the Jira transition closing the issues, Done by default
+	CloseTransition string `json:"closeTransition,omitempty"`
+
+	Owner string `json:"owner,omitempty"`
+	Repo  string `json:"repo,omitempty"`
+
+	Labels []string `json:"labels,omitempty"`
+}
+
+func (c *TrackerConfig) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, c)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), c)
+	}
+	return nil
+}
+
+func (c *TrackerConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+type RuleIds []string
+
+func (r *RuleIds) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, r)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), r)
+	}
+	return nil
+}
+
+func (r *RuleIds) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+func (t *Tracker) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if t.Config == nil {
+		return fmt.Errorf("config is required")
+	}
+	if t.Config.URL != "" {
+		u, err := url.Parse(t.Config.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid url %q", t.Config.URL)
+		}
+	}
+	switch t.Type {
+	case TrackerJira:
+		if t.Config.URL == "" || t.Config.Email == "" || t.Config.Project == "" {
+			return fmt.Errorf("url, email and project are required for jira")
+		}
+	case TrackerGitHub:
+		if t.Config.Owner == "" || t.Config.Repo == "" {
+			return fmt.Errorf("owner and repo are required for github")
+		}
+	default:
+		return fmt.Errorf("unsupported tracker type %q, supported types are jira and github", t.Type)
+	}
+	if _, err := parseTemplate(t.TitleTemplate, ""); err != nil {
+		return fmt.Errorf("invalid title template: %w", err)
+	}
+	if _, err := parseTemplate(t.BodyTemplate, ""); err != nil {
+		return fmt.Errorf("invalid body template: %w", err)
+	}
+	return nil
+}
+
+// opensFor checks if the firing alerts of the rule open issues in the tracker
+func (t *Tracker) opensFor(ruleId string) bool {
+	if t.RuleIds == nil {
+		return false
+	}
+	for _, id := range *t.RuleIds {
+		if id == ruleId {
+			return true
+		}
+	}
+	return false
+}
+
+// Issue is an issue opened in a tracker for an alert or an exception
+type Issue struct {
+	Id        string `json:"id" db:"id"`
+	TrackerId string `json:"trackerId" db:"tracker_id"`
+	Source    string `json:"source" db:"source"`
+	// SourceKey identifies the alert, or the exception group, an issue is opened once
+	// for each source while it is open
+	SourceKey string     `json:"sourceKey" db:"source_key"`
+	RuleId    string     `json:"ruleId" db:"rule_id"`
+	GroupId   string     `json:"groupId" db:"group_id"`
+	Key       string     `json:"key" db:"issue_key"`
+	URL       string     `json:"url" db:"url"`
+	Title     string     `json:"title" db:"title"`
+	State     string     `json:"state" db:"state"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	CreatedBy string     `json:"createdBy" db:"created_by"`
+	ClosedAt  *time.Time `json:"closedAt" db:"closed_at"`
+}
+
+// Filter selects the issues, the newest first
+type Filter struct {
+	TrackerId string
+	RuleId    string
+	GroupId   string
+	State     string
+}
+
+// Source is an alert or an exception an issue is opened for, the templates of the trackers
+// are executed with it
+type Source struct {
+	Type string
+	Key  string
+	// Link is the page of the alert rule or of the exception in SigNoz
+	Link string
+
+	RuleId      string
+	AlertName   string
+	Labels      map[string]string
+	Annotations map[string]string
+
+	GroupId          string
+	ServiceName      string
+	ExceptionType    string
+	ExceptionMessage string
+	Stacktrace       string
+}
@@ -0,0 +1,232 @@
+package issues
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.uber.org/zap"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS issue_trackers (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		type TEXT NOT NULL,
+		config TEXT NOT NULL,
+		title_template TEXT NOT NULL DEFAULT '',
+		body_template TEXT NOT NULL DEFAULT '',
+		auto_close BOOLEAN NOT NULL DEFAULT FALSE,
+		rule_ids TEXT NOT NULL DEFAULT '[]',
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating issue_trackers table: %s", err.Error())
+	}
+	_, err = db.Exec(`ALTER TABLE issue_trackers ADD COLUMN org_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return fmt.Errorf("error in adding column org_id to issue_trackers table: %s", err.Error())
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS issues (
+		id TEXT PRIMARY KEY,
+		tracker_id TEXT NOT NULL,
+		source TEXT NOT NULL,
+		source_key TEXT NOT NULL,
+		rule_id TEXT NOT NULL DEFAULT '',
+		group_id TEXT NOT NULL DEFAULT '',
+		issue_key TEXT NOT NULL,
+		url TEXT NOT NULL,
+		title TEXT NOT NULL,
+		state TEXT NOT NULL,
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		closed_at datetime,
+		FOREIGN KEY(tracker_id) REFERENCES issue_trackers(id) ON DELETE CASCADE
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating issues table: %s", err.Error())
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_issues_source_key ON issues (source_key);`)
+	if err != nil {
+		return fmt.Errorf("error in creating issues index: %s", err.Error())
+	}
+	return nil
+}
+
+const trackerColumns = "id, name, type, config, title_template, body_template, auto_close, rule_ids, created_at, created_by, updated_at, updated_by, org_id"
+
+const issueColumns = "id, tracker_id, source, source_key, rule_id, group_id, issue_key, url, title, state, created_at, created_by, closed_at"
+
+type repo struct {
+	db *sqlx.DB
+}
+
+// trackersOfOrg returns the condition, and its arguments, restricting the issues to those of
+// the trackers of the org of the user of the context
+func trackersOfOrg(ctx context.Context) (string, []interface{}) {
+	condition, args := common.OrgCondition(ctx)
+	return "tracker_id IN (SELECT id FROM issue_trackers WHERE " + condition + ")", args
+}
+
+// listTrackers returns the trackers of the org of the user of the context
+func (r *repo) listTrackers(ctx context.Context) ([]*Tracker, error) {
+	trackers := []*Tracker{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.SelectContext(ctx, &trackers, "SELECT "+trackerColumns+" FROM issue_trackers WHERE "+condition+" ORDER BY created_at", args...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return trackers, nil
+}
+
+// getTracker returns the tracker with the id, nil when it does not exist
+func (r *repo) getTracker(ctx context.Context, id string) (*Tracker, error) {
+	t := &Tracker{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.GetContext(ctx, t, "SELECT "+trackerColumns+" FROM issue_trackers WHERE id = ? AND "+condition, append([]interface{}{id}, args...)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return t, nil
+}
+
+// insertTracker stores the tracker in the org of the user of the context
+func (r *repo) insertTracker(ctx context.Context, t *Tracker) error {
+	t.OrgId = common.GetOrgIdFromContext(ctx)
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO issue_trackers ("+trackerColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		t.Id, t.Name, t.Type, t.Config, t.TitleTemplate, t.BodyTemplate, t.AutoClose, t.RuleIds, t.CreatedAt, t.CreatedBy, t.UpdatedAt, t.UpdatedBy, t.OrgId)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *repo) updateTracker(ctx context.Context, t *Tracker) error {
+	condition, args := common.OrgCondition(ctx)
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE issue_trackers SET name = ?, type = ?, config = ?, title_template = ?, body_template = ?, auto_close = ?, rule_ids = ?, updated_at = ?, updated_by = ? WHERE id = ? AND "+condition,
+		append([]interface{}{t.Name, t.Type, t.Config, t.TitleTemplate, t.BodyTemplate, t.AutoClose, t.RuleIds, t.UpdatedAt, t.UpdatedBy, t.Id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// deleteTracker deletes the tracker and its issue links, the issues stay in the tracker. The
+// links are kept when the tracker is not in the org of the user of the context
+func (r *repo) deleteTracker(ctx context.Context, id string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	condition, args := common.OrgCondition(ctx)
+	result, err := tx.ExecContext(ctx, "DELETE FROM issue_trackers WHERE id = ? AND "+condition, append([]interface{}{id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM issues WHERE tracker_id = $1", id); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *repo) insertIssue(ctx context.Context, i *Issue) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO issues ("+issueColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		i.Id, i.TrackerId, i.Source, i.SourceKey, i.RuleId, i.GroupId, i.Key, i.URL, i.Title, i.State, i.CreatedAt, i.CreatedBy, i.ClosedAt)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// getIssue returns the issue with the id, nil when it does not exist or its tracker is not
+// in the org of the user of the context
+func (r *repo) getIssue(ctx context.Context, id string) (*Issue, error) {
+	i := &Issue{}
+	condition, args := trackersOfOrg(ctx)
+	err := r.db.GetContext(ctx, i, "SELECT "+issueColumns+" FROM issues WHERE id = ? AND "+condition, append([]interface{}{id}, args...)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return i, nil
+}
+
+// openIssues returns the open issues of the source, in all the trackers when trackerId is empty
+func (r *repo) openIssues(ctx context.Context, trackerId, sourceKey string) ([]*Issue, error) {
+	return r.listIssues(ctx, Filter{TrackerId: trackerId, State: StateOpen}, sourceKey)
+}
+
+// listIssues returns the issues of the trackers of the org of the user of the context
+func (r *repo) listIssues(ctx context.Context, f Filter, sourceKey string) ([]*Issue, error) {
+	condition, args := trackersOfOrg(ctx)
+	conditions := []string{condition}
+	if f.TrackerId != "" {
+		conditions = append(conditions, "tracker_id = ?")
+		args = append(args, f.TrackerId)
+	}
+	if f.RuleId != "" {
+		conditions = append(conditions, "rule_id = ?")
+		args = append(args, f.RuleId)
+	}
+	if f.GroupId != "" {
+		conditions = append(conditions, "group_id = ?")
+		args = append(args, f.GroupId)
+	}
+	if f.State != "" {
+		conditions = append(conditions, "state = ?")
+		args = append(args, f.State)
+	}
+	if sourceKey != "" {
+		conditions = append(conditions, "source_key = ?")
+		args = append(args, sourceKey)
+	}
+
+	issues := []*Issue{}
+	query := "SELECT " + issueColumns + " FROM issues WHERE " + strings.Join(conditions, " AND ") + " ORDER BY created_at DESC"
+	if err := r.db.SelectContext(ctx, &issues, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return issues, nil
+}
+
+func (r *repo) closeIssue(ctx context.Context, id string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE issues SET state = $1, closed_at = $2 WHERE id = $3", StateClosed, at, id)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package issues
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+const (
+	defaultTitleTemplate = `{{ if eq .Type "alert" }}[SigNoz] {{ .AlertName }} is firing{{ else }}[SigNoz] {{ .ExceptionType }} in {{ .ServiceName }}{{ end }}`
+
+	defaultBodyTemplate = `{{ if eq .Type "alert" -}}
+The alert {{ .AlertName }} is firing.
+{{ with .Annotations.summary }}
+{{ . }}
+{{ end }}{{ with .Annotations.description }}
+{{ . }}
+{{ end }}
+Labels:
+{{ range $k, $v := .Labels }}- {{ $k }}: {{ $v }}
+{{ end }}
+{{- else -}}
+{{ .ExceptionType }}: {{ .ExceptionMessage }}
+
+Service: {{ .ServiceName }}
+{{ with .Stacktrace }}
+{{ . }}
+{{ end }}
+{{- end }}
+{{ .Link }}`
+
+	// maxTitleLength is the length of the titles past which they are truncated, GitHub
+	// rejects the titles longer than 256 characters and Jira the summaries longer than 255
+	maxTitleLength = 255
+)
+
+func parseTemplate(text, fallback string) (*template.Template, error) {
+	if text == "" {
+		text = fallback
+	}
+	return template.New("issue").Option("missingkey=zero").Parse(text)
+}
+
+// render returns the title and the body of the issue of the source
+func (t *Tracker) render(src *Source) (title, body string, err error) {
+	titleTmpl, err := parseTemplate(t.TitleTemplate, defaultTitleTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	bodyTmpl, err := parseTemplate(t.BodyTemplate, defaultBodyTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	var buf bytes.Buffer
+	if err := titleTmpl.Execute(&buf, src); err != nil {
+		return "", "", err
+	}
+	title = strings.Join(strings.Fields(buf.String()), " ")
+	if len(title) > maxTitleLength {
+		title = title[:maxTitleLength-3] + "..."
+	}
+	buf.Reset()
+	if err := bodyTmpl.Execute(&buf, src); err != nil {
+		return "", "", err
+	}
+	return title, strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,216 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/ee/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/app/traces/jaeger"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// maxJaegerTraces is the largest number of traces returned by a search
+const maxJaegerTraces = 1500
+
+// registerJaegerAPIRoutes registers the Jaeger compatible query API backed by the traces store,
+// the URL of a Jaeger datasource in Grafana is the SigNoz URL followed by /api/jaeger
+func (aH *APIHandler) registerJaegerAPIRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/api/jaeger/api").Subrouter()
+	subRouter.HandleFunc("/services", am.ViewAccess(aH.getJaegerServices)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/services/{service}/operations", am.ViewAccess(aH.getJaegerServiceOperations)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/operations", am.ViewAccess(aH.getJaegerOperations)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/traces", am.ViewAccess(aH.findJaegerTraces)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/traces/{traceId}", am.ViewAccess(aH.getJaegerTrace)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/dependencies", am.ViewAccess(aH.getJaegerDependencies)).Methods(http.MethodGet)
+}
+
+// respondJaeger writes the data in the envelope of the Jaeger API responses
+func respondJaeger(w http.ResponseWriter, data interface{}, total int) {
+	writeJaegerResponse(w, http.StatusOK, jaeger.Response{Data: data, Total: total})
+}
+
+func respondJaegerError(w http.ResponseWriter, apiErr *model.ApiError) {
+	code := errorStatusCode(apiErr.Type())
+	writeJaegerResponse(w, code, jaeger.Response{Errors: []jaeger.Error{{Code: code, Msg: apiErr.Error()}}})
+}
+
+func writeJaegerResponse(w http.ResponseWriter, code int, response jaeger.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		zap.L().Error("error writing response", zap.Error(err))
+	}
+}
+
+func (aH *APIHandler) getJaegerServices(w http.ResponseWriter, r *http.Request) {
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		respondJaegerError(w, apiErr)
+		return
+	}
+	services, err := aH.reader.GetServicesList(r.Context(), scope)
+	if err != nil {
+		respondJaegerError(w, &model.ApiError{Typ: model.ErrorExec, Err: err})
+		return
+	}
+	respondJaeger(w, *services, len(*services))
+}
+
+// jaegerOperations returns the operations of the service in the last day, with the kind of their spans
+func (aH *APIHandler) jaegerOperations(r *http.Request, service string) ([]jaeger.Operation, *model.ApiError) {
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	end := time.Now()
+	operations, apiErr := aH.reader.GetSpanOperations(r.Context(), service, end.Add(-24*time.Hour), end, scope)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	result := []jaeger.Operation{}
+	for _, operation := range operations {
+		result = append(result, jaeger.Operation{Name: operation.Name, SpanKind: jaeger.SpanKind(int32(operation.Kind))})
+	}
+	return result, nil
+}
+
+func (aH *APIHandler) getJaegerServiceOperations(w http.ResponseWriter, r *http.Request) {
+	operations, apiErr := aH.jaegerOperations(r, mux.Vars(r)["service"])
+	if apiErr != nil {
+		respondJaegerError(w, apiErr)
+		return
+	}
+	names := []string{}
+	seen := map[string]bool{}
+	for _, operation := range operations {
+		if !seen[operation.Name] {
+			seen[operation.Name] = true
+			names = append(names, operation.Name)
+		}
+	}
+	respondJaeger(w, names, len(names))
+}
+
+func (aH *APIHandler) getJaegerOperations(w http.ResponseWriter, r *http.Request) {
+	service := r.URL.Query().Get("service")
+	if service == "" {
+		respondJaegerError(w, model.BadRequestStr("service is required"))
+		return
+	}
+	operations, apiErr := aH.jaegerOperations(r, service)
+	if apiErr != nil {
+		respondJaegerError(w, apiErr)
+		return
+	}
+	if spanKind := r.URL.Query().Get("spanKind"); spanKind != "" {
+		filtered := []jaeger.Operation{}
+		for _, operation := range operations {
+			if operation.SpanKind == spanKind {
+				filtered = append(filtered, operation)
+			}
+		}
+		operations = filtered
+	}
+	respondJaeger(w, operations, len(operations))
+}
+
+// jaegerTraces returns the spans of the org of the user in the traces of the ids, the traces are
+// limited to the max spans of the trace detail
+func (aH *APIHandler) jaegerTraces(r *http.Request, traceIDs []string) ([]jaeger.Trace, *model.ApiError) {
+	maxSpans, err := strconv.Atoi(constants.MaxSpansInTraceStr)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	spans, apiErr := aH.reader.GetTracesSpans(r.Context(), traceIDs, maxSpans, scope)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return jaeger.FromSpans(traceIDs, spans), nil
+}
+
+func (aH *APIHandler) findJaegerTraces(w http.ResponseWriter, r *http.Request) {
+	params, apiErr := parseJaegerFindTracesRequest(r)
+	if apiErr != nil {
+		respondJaegerError(w, apiErr)
+		return
+	}
+	if params.OrgScope, apiErr = common.OrgScope(r.Context()); apiErr != nil {
+		respondJaegerError(w, apiErr)
+		return
+	}
+	traceIDs, apiErr := aH.reader.FindTraceIDs(r.Context(), params)
+	if apiErr != nil {
+		respondJaegerError(w, apiErr)
+		return
+	}
+	traces, apiErr := aH.jaegerTraces(r, traceIDs)
+	if apiErr != nil {
+		respondJaegerError(w, apiErr)
+		return
+	}
+	respondJaeger(w, traces, len(traces))
+}
+
+func (aH *APIHandler) getJaegerTrace(w http.ResponseWriter, r *http.Request) {
+	traces, apiErr := aH.jaegerTraces(r, []string{jaeger.NormalizeTraceID(mux.Vars(r)["traceId"])})
+	if apiErr != nil {
+		respondJaegerError(w, apiErr)
+		return
+	}
+	if len(traces) == 0 {
+		respondJaegerError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("trace not found")})
+		return
+	}
+	respondJaeger(w, traces, len(traces))
+}
+
+// getJaegerDependencies returns the calls between the services in the lookback, in milliseconds,
+// before the end, in milliseconds since the epoch
+func (aH *APIHandler) getJaegerDependencies(w http.ResponseWriter, r *http.Request) {
+	end := time.Now()
+	if endTs := r.URL.Query().Get("endTs"); endTs != "" {
+		ms, err := strconv.ParseInt(endTs, 10, 64)
+		if err != nil {
+			respondJaegerError(w, model.BadRequestStr("invalid endTs"))
+			return
+		}
+		end = time.UnixMilli(ms)
+	}
+	lookback := 24 * time.Hour
+	if l := r.URL.Query().Get("lookback"); l != "" {
+		ms, err := strconv.ParseInt(l, 10, 64)
+		if err != nil || ms <= 0 {
+			respondJaegerError(w, model.BadRequestStr("invalid lookback"))
+			return
+		}
+		lookback = time.Duration(ms) * time.Millisecond
+	}
+
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		respondJaegerError(w, apiErr)
+		return
+	}
+	start := end.Add(-lookback)
+	items, err := aH.reader.GetDependencyGraph(r.Context(), &model.GetServicesParams{Start: &start, End: &end, OrgScope: scope})
+	if err != nil {
+		respondJaegerError(w, &model.ApiError{Typ: model.ErrorExec, Err: err})
+		return
+	}
+	dependencies := []jaeger.Dependency{}
+	for _, item := range *items {
+		dependencies = append(dependencies, jaeger.Dependency{Parent: item.Parent, Child: item.Child, CallCount: item.CallCount})
+	}
+	respondJaeger(w, dependencies, len(dependencies))
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/k8s"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// listK8sEntities lists the nodes, the pods or the namespaces with their CPU, memory and
+// restarts from the kubeletstats and k8scluster metrics. The pods have the services with
+// spans or logs from them
+func (aH *APIHandler) listK8sEntities(w http.ResponseWriter, r *http.Request) {
+	kind := mux.Vars(r)["kind"]
+	var req k8s.ListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := req.Validate(kind); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	ctx := r.Context()
+	params := k8s.BuildQuery(kind, &req)
+	if apiErr := scopeQueryToOrg(ctx, params); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	results, _, err := aH.querierV2.QueryRange(ctx, params, nil)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	resp := k8s.Rows(kind, &req, results)
+
+	if kind == k8s.KindPods && len(resp.Rows) > 0 {
+		params, keys := k8s.BuildServicesQuery(req.Start, req.End, k8s.PodNames(resp.Rows))
+		if apiErr := scopeQueryToOrg(ctx, params); apiErr != nil {
+			zap.L().Error("failed to scope the services of the pods", zap.Error(apiErr.Err))
+		} else if results, _, err := aH.querierV2.QueryRange(ctx, params, keys); err != nil {
+			// the pods are returned without their services
+			zap.L().Error("failed to query the services of the pods", zap.Error(err))
+		} else {
+			k8s.AddServices(resp.Rows, results)
+		}
+	}
+	aH.Respond(w, resp)
+}
@@ -0,0 +1,369 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+// Kinds of the k8s entities
+const (
+	KindNodes      = "nodes"
+	KindPods       = "pods"
+	KindNamespaces = "namespaces"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+
+	// stepInterval is the step, in seconds, of the metrics queries, the values of the
+	// entities are the values of the last step
+	stepInterval = 60
+)
+
+// Attributes of the kubeletstats and k8scluster metrics
+const (
+	ClusterNameKey   = "k8s_cluster_name"
+	NodeNameKey      = "k8s_node_name"
+	NamespaceNameKey = "k8s_namespace_name"
+	PodNameKey       = "k8s_pod_name"
+)
+
+// Column is a value of the entities, computed from a kubeletstats or k8scluster metric
+type Column struct {
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+
+	metric           string
+	timeAggregation  v3.TimeAggregation
+	spaceAggregation v3.SpaceAggregation
+}
+
+type view struct {
+	groupBy []string
+	columns []Column
+}
+
+var views = map[string]view{
+	KindNodes: {
+		groupBy: []string{NodeNameKey},
+		columns: []Column{
+			{Name: "cpu", Unit: "cores", metric: "k8s_node_cpu_utilization", timeAggregation: v3.TimeAggregationAvg, spaceAggregation: v3.SpaceAggregationSum},
+			{Name: "cpuAllocatable", Unit: "cores", metric: "k8s_node_allocatable_cpu", timeAggregation: v3.TimeAggregationAnyLast, spaceAggregation: v3.SpaceAggregationSum},
+			{Name: "memory", Unit: "bytes", metric: "k8s_node_memory_working_set", timeAggregation: v3.TimeAggregationAvg, spaceAggregation: v3.SpaceAggregationSum},
+			{Name: "memoryAllocatable", Unit: "bytes", metric: "k8s_node_allocatable_memory", timeAggregation: v3.TimeAggregationAnyLast, spaceAggregation: v3.SpaceAggregationSum},
+			{Name: "pods", metric: "k8s_pod_phase", timeAggregation: v3.TimeAggregationAnyLast, spaceAggregation: v3.SpaceAggregationCount},
+		},
+	},
+	KindPods: {
+		groupBy: []string{NamespaceNameKey, PodNameKey},
+		columns: []Column{
+			{Name: "cpu", Unit: "cores", metric: "k8s_pod_cpu_utilization", timeAggregation: v3.TimeAggregationAvg, spaceAggregation: v3.SpaceAggregationSum},
+			{Name: "memory", Unit: "bytes", metric: "k8s_pod_memory_working_set", timeAggregation: v3.TimeAggregationAvg, spaceAggregation: v3.SpaceAggregationSum},
+			{Name: "restarts", metric: "k8s_container_restarts", timeAggregation: v3.TimeAggregationAnyLast, spaceAggregation: v3.SpaceAggregationSum},
+			{Name: "phase", metric: "k8s_pod_phase", timeAggregation: v3.TimeAggregationAnyLast, spaceAggregation: v3.SpaceAggregationMax},
+		},
+	},
+	KindNamespaces: {
+		groupBy: []string{NamespaceNameKey},
+		columns: []Column{
+			{Name: "cpu", Unit: "cores", metric: "k8s_pod_cpu_utilization", timeAggregation: v3.TimeAggregationAvg, spaceAggregation: v3.SpaceAggregationSum},
+			{Name: "memory", Unit: "bytes", metric: "k8s_pod_memory_working_set", timeAggregation: v3.TimeAggregationAvg, spaceAggregation: v3.SpaceAggregationSum},
+			{Name: "restarts", metric: "k8s_container_restarts", timeAggregation: v3.TimeAggregationAnyLast, spaceAggregation: v3.SpaceAggregationSum},
+			{Name: "pods", metric: "k8s_pod_phase", timeAggregation: v3.TimeAggregationAnyLast, spaceAggregation: v3.SpaceAggregationCount},
+		},
+	},
+}
+
+// podPhases are the phases of the values of the k8s_pod_phase metric
+var podPhases = map[int]string{1: "Pending", 2: "Running", 3: "Succeeded", 4: "Failed", 5: "Unknown"}
+
+func ValidKind(kind string) bool {
+	_, ok := views[kind]
+	return ok
+}
+
+// ListRequest lists the entities of a kind, the range is in milliseconds
+type ListRequest struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	// Filters filter the metrics on their attributes, e.g. k8s_cluster_name
+	Filters *v3.FilterSet `json:"filters"`
+	// OrderBy is the column the entities are sorted on, cpu by default
+	OrderBy string `json:"orderBy"`
+	Order   string `json:"order"`
+	Limit   int    `json:"limit"`
+	Offset  int    `json:"offset"`
+}
+
+func (r *ListRequest) Validate(kind string) error {
+	if r.Start <= 0 || r.End <= 0 || r.Start >= r.End {
+		return fmt.Errorf("start and end are required and start must be before end")
+	}
+	if r.Filters != nil {
+		if err := r.Filters.Validate(); err != nil {
+			return fmt.Errorf("invalid filters: %w", err)
+		}
+	}
+	if r.OrderBy != "" && columnIndex(views[kind], r.OrderBy) < 0 {
+		return fmt.Errorf("invalid orderBy %q", r.OrderBy)
+	}
+	if r.Order != "" && r.Order != "asc" && r.Order != "desc" {
+		return fmt.Errorf("invalid order %q, must be asc or desc", r.Order)
+	}
+	if r.Limit < 0 || r.Limit > MaxLimit {
+		return fmt.Errorf("limit must be between 0 and %d", MaxLimit)
+	}
+	if r.Offset < 0 {
+		return fmt.Errorf("offset can't be negative")
+	}
+	return nil
+}
+
+func columnIndex(v view, name string) int {
+	for i, c := range v.columns {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Row is an entity with the values of the columns of its kind
+type Row struct {
+	// Labels are the attributes identifying the entity, e.g. its namespace and its name
+	Labels map[string]string  `json:"labels"`
+	Values map[string]float64 `json:"values"`
+	// Phase is the phase of the pods
+	Phase string `json:"phase,omitempty"`
+	// Services are the services with spans or logs from the pods
+	Services []string `json:"services,omitempty"`
+}
+
+type ListResponse struct {
+	Kind    string   `json:"kind"`
+	Columns []Column `json:"columns"`
+	Rows    []*Row   `json:"rows"`
+	// Total is the number of entities before the pagination
+	Total int `json:"total"`
+}
+
+func stringKey(key string, typ v3.AttributeKeyType) v3.AttributeKey {
+	return v3.AttributeKey{Key: key, DataType: v3.AttributeKeyDataTypeString, Type: typ}
+}
+
+// queryName returns the name of the query of the ith column, A for the first
+func queryName(i int) string {
+	return string(rune('A' + i))
+}
+
+// BuildQuery returns the query of the values of the entities of the kind, one metrics query
+// per column grouped by the attributes identifying the entities
+func BuildQuery(kind string, req *ListRequest) *v3.QueryRangeParamsV3 {
+	v := views[kind]
+	groupBy := make([]v3.AttributeKey, 0, len(v.groupBy))
+	for _, key := range v.groupBy {
+		groupBy = append(groupBy, stringKey(key, v3.AttributeKeyTypeTag))
+	}
+
+	queries := map[string]*v3.BuilderQuery{}
+	for i, c := range v.columns {
+		name := queryName(i)
+		queries[name] = &v3.BuilderQuery{
+			QueryName:  name,
+			Expression: name,
+			DataSource: v3.DataSourceMetrics,
+			AggregateAttribute: v3.AttributeKey{
+				Key:      c.metric,
+				DataType: v3.AttributeKeyDataTypeFloat64,
+				Type:     v3.AttributeKeyTypeUnspecified,
+				IsColumn: true,
+			},
+			Temporality:      v3.Unspecified,
+			TimeAggregation:  c.timeAggregation,
+			SpaceAggregation: c.spaceAggregation,
+			Filters:          req.Filters,
+			GroupBy:          groupBy,
+			StepInterval:     stepInterval,
+		}
+	}
+	return &v3.QueryRangeParamsV3{
+		Start:   req.Start,
+		End:     req.End,
+		Step:    stepInterval,
+		Version: "v4",
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType:      v3.QueryTypeBuilder,
+			PanelType:      v3.PanelTypeGraph,
+			BuilderQueries: queries,
+		},
+	}
+}
+
+// Rows returns the page of the entities of the kind from the results of the query built by
+// BuildQuery, the entities are sorted on the column of the request
+func Rows(kind string, req *ListRequest, results []*v3.Result) *ListResponse {
+	v := views[kind]
+	byKey := map[string]*Row{}
+	for _, result := range results {
+		if len(result.QueryName) != 1 {
+			continue
+		}
+		i := int(result.QueryName[0] - 'A')
+		if i < 0 || i >= len(v.columns) {
+			continue
+		}
+		for _, series := range result.Series {
+			if len(series.Points) == 0 {
+				continue
+			}
+			labels := map[string]string{}
+			parts := make([]string, 0, len(v.groupBy))
+			for _, key := range v.groupBy {
+				labels[key] = series.Labels[key]
+				parts = append(parts, series.Labels[key])
+			}
+			key := strings.Join(parts, "/")
+			row, ok := byKey[key]
+			if !ok {
+				row = &Row{Labels: labels, Values: map[string]float64{}}
+				byKey[key] = row
+			}
+			row.Values[v.columns[i].Name] = last(series.Points).Value
+		}
+	}
+
+	rows := make([]*Row, 0, len(byKey))
+	for _, row := range byKey {
+		if kind == KindPods {
+			if phase, ok := row.Values["phase"]; ok {
+				row.Phase = podPhases[int(phase)]
+			}
+		}
+		rows = append(rows, row)
+	}
+	sortRows(v, rows, req.OrderBy, req.Order)
+
+	resp := &ListResponse{Kind: kind, Columns: v.columns, Total: len(rows)}
+	limit := req.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	start := min(req.Offset, len(rows))
+	end := min(start+limit, len(rows))
+	resp.Rows = rows[start:end]
+	return resp
+}
+
+// last returns the latest point of the series
+func last(points []v3.Point) v3.Point {
+	latest := points[0]
+	for _, p := range points[1:] {
+		if p.Timestamp > latest.Timestamp {
+			latest = p
+		}
+	}
+	return latest
+}
+
+// sortRows sorts the rows on the column, the highest first by default, the rows without
+// value last. The ties are sorted on the labels
+func sortRows(v view, rows []*Row, orderBy, order string) {
+	if orderBy == "" {
+		orderBy = v.columns[0].Name
+	}
+	utils.SortByValue(rows,
+		func(row *Row) map[string]float64 { return row.Values },
+		func(row *Row) string {
+			parts := make([]string, 0, len(v.groupBy))
+			for _, key := range v.groupBy {
+				parts = append(parts, row.Labels[key])
+			}
+			return strings.Join(parts, "/")
+		},
+		orderBy, order == "asc",
+	)
+}
+
+// BuildServicesQuery returns the query of the services with spans or logs from the pods, the
+// keys are the attributes of the spans the query uses
+func BuildServicesQuery(start, end int64, pods []string) (*v3.QueryRangeParamsV3, map[string]v3.AttributeKey) {
+	values := make([]interface{}, 0, len(pods))
+	for _, pod := range pods {
+		values = append(values, pod)
+	}
+	podKey := stringKey("k8s.pod.name", v3.AttributeKeyTypeResource)
+	filters := &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{{Key: podKey, Operator: v3.FilterOperatorIn, Value: values}}}
+	traceService := v3.AttributeKey{Key: "serviceName", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag, IsColumn: true}
+	logService := stringKey("service.name", v3.AttributeKeyTypeResource)
+
+	step := max((end-start)/1000, stepInterval)
+	query := func(name string, source v3.DataSource, service v3.AttributeKey) *v3.BuilderQuery {
+		return &v3.BuilderQuery{
+			QueryName:         name,
+			Expression:        name,
+			DataSource:        source,
+			AggregateOperator: v3.AggregateOperatorCount,
+			Filters:           filters,
+			GroupBy:           []v3.AttributeKey{service, podKey},
+			StepInterval:      step,
+		}
+	}
+	params := &v3.QueryRangeParamsV3{
+		Start:   start,
+		End:     end,
+		Step:    step,
+		Version: "v4",
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeBuilder,
+			PanelType: v3.PanelTypeGraph,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"traces": query("traces", v3.DataSourceTraces, traceService),
+				"logs":   query("logs", v3.DataSourceLogs, logService),
+			},
+		},
+	}
+	keys := map[string]v3.AttributeKey{traceService.Key: traceService, podKey.Key: podKey}
+	return params, keys
+}
+
+// AddServices sets the services of the pods from the results of the query built by
+// BuildServicesQuery
+func AddServices(rows []*Row, results []*v3.Result) {
+	services := map[string]map[string]struct{}{}
+	for _, result := range results {
+		for _, series := range result.Series {
+			pod := series.Labels["k8s.pod.name"]
+			service := series.Labels["serviceName"]
+			if service == "" {
+				service = series.Labels["service.name"]
+			}
+			if pod == "" || service == "" {
+				continue
+			}
+			if services[pod] == nil {
+				services[pod] = map[string]struct{}{}
+			}
+			services[pod][service] = struct{}{}
+		}
+	}
+	for _, row := range rows {
+		for service := range services[row.Labels[PodNameKey]] {
+			row.Services = append(row.Services, service)
+		}
+		sort.Strings(row.Services)
+	}
+}
+
+// PodNames returns the names of the pods of the rows
+func PodNames(rows []*Row) []string {
+	names := make([]string, 0, len(rows))
+	for _, row := range rows {
+		names = append(names, row.Labels[PodNameKey])
+	}
+	return names
+}
@@ -0,0 +1,89 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func series(value float64, labels ...string) *v3.Series {
+	s := &v3.Series{Labels: map[string]string{}, Points: []v3.Point{{Timestamp: 1, Value: -1}, {Timestamp: 2, Value: value}}}
+	for i := 0; i < len(labels); i += 2 {
+		s.Labels[labels[i]] = labels[i+1]
+	}
+	return s
+}
+
+func TestBuildQuery(t *testing.T) {
+	req := &ListRequest{Start: 1700000000000, End: 1700003600000}
+	require.NoError(t, req.Validate(KindPods))
+
+	params := BuildQuery(KindPods, req)
+	require.Len(t, params.CompositeQuery.BuilderQueries, 4)
+	require.NoError(t, params.CompositeQuery.Validate())
+
+	cpu := params.CompositeQuery.BuilderQueries["A"]
+	require.Equal(t, "k8s_pod_cpu_utilization", cpu.AggregateAttribute.Key)
+	require.Equal(t, v3.SpaceAggregationSum, cpu.SpaceAggregation)
+	require.Equal(t, []string{NamespaceNameKey, PodNameKey}, []string{cpu.GroupBy[0].Key, cpu.GroupBy[1].Key})
+
+	require.Error(t, (&ListRequest{Start: 2, End: 1}).Validate(KindPods))
+	require.Error(t, (&ListRequest{Start: 1, End: 2, OrderBy: "pods"}).Validate(KindPods))
+	require.NoError(t, (&ListRequest{Start: 1, End: 2, OrderBy: "pods"}).Validate(KindNamespaces))
+}
+
+func TestRows(t *testing.T) {
+	results := []*v3.Result{
+		{QueryName: "A", Series: []*v3.Series{
+			series(0.5, NamespaceNameKey, "shop", PodNameKey, "cart-1"),
+			series(1.5, NamespaceNameKey, "shop", PodNameKey, "checkout-1"),
+			series(0.1, NamespaceNameKey, "kube-system", PodNameKey, "coredns-1"),
+		}},
+		{QueryName: "C", Series: []*v3.Series{
+			series(3, NamespaceNameKey, "shop", PodNameKey, "cart-1"),
+		}},
+		{QueryName: "D", Series: []*v3.Series{
+			series(2, NamespaceNameKey, "shop", PodNameKey, "cart-1"),
+			series(4, NamespaceNameKey, "shop", PodNameKey, "checkout-1"),
+		}},
+	}
+
+	resp := Rows(KindPods, &ListRequest{}, results)
+	require.Equal(t, 3, resp.Total)
+	require.Len(t, resp.Rows, 3)
+	require.Equal(t, "checkout-1", resp.Rows[0].Labels[PodNameKey])
+	require.Equal(t, "Failed", resp.Rows[0].Phase)
+	require.Equal(t, map[string]float64{"cpu": 0.5, "restarts": 3, "phase": 2}, resp.Rows[1].Values)
+	require.Equal(t, "Running", resp.Rows[1].Phase)
+
+	// the pods without restarts come last
+	resp = Rows(KindPods, &ListRequest{OrderBy: "restarts", Order: "asc", Limit: 2}, results)
+	require.Equal(t, 3, resp.Total)
+	require.Len(t, resp.Rows, 2)
+	require.Equal(t, "cart-1", resp.Rows[0].Labels[PodNameKey])
+	require.Equal(t, "coredns-1", resp.Rows[1].Labels[PodNameKey])
+
+	resp = Rows(KindPods, &ListRequest{Offset: 5}, results)
+	require.Empty(t, resp.Rows)
+}
+
+func TestAddServices(t *testing.T) {
+	rows := []*Row{
+		{Labels: map[string]string{PodNameKey: "cart-1"}},
+		{Labels: map[string]string{PodNameKey: "coredns-1"}},
+	}
+	params, keys := BuildServicesQuery(1700000000000, 1700003600000, PodNames(rows))
+	require.NoError(t, params.CompositeQuery.Validate())
+	require.Contains(t, keys, "serviceName")
+
+	AddServices(rows, []*v3.Result{
+		{QueryName: "traces", Series: []*v3.Series{series(10, "serviceName", "cart", "k8s.pod.name", "cart-1")}},
+		{QueryName: "logs", Series: []*v3.Series{
+			series(5, "service.name", "cart", "k8s.pod.name", "cart-1"),
+			series(5, "service.name", "cart-worker", "k8s.pod.name", "cart-1"),
+		}},
+	})
+	require.Equal(t, []string{"cart", "cart-worker"}, rows[0].Services)
+	require.Empty(t, rows[1].Services)
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/logmetrics"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func (aH *APIHandler) listLogMetricRules(w http.ResponseWriter, r *http.Request) {
+	rules, apiErr := aH.LogMetricsManager.List(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, rules)
+}
+
+func (aH *APIHandler) getLogMetricRule(w http.ResponseWriter, r *http.Request) {
+	rule, apiErr := aH.LogMetricsManager.Get(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, rule)
+}
+
+// createLogMetricRule creates the rule, its metric is written from the next interval on
+func (aH *APIHandler) createLogMetricRule(w http.ResponseWriter, r *http.Request) {
+	var rule logmetrics.Rule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	created, apiErr := aH.LogMetricsManager.Create(r.Context(), &rule)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, created)
+}
+
+func (aH *APIHandler) editLogMetricRule(w http.ResponseWriter, r *http.Request) {
+	var rule logmetrics.Rule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	edited, apiErr := aH.LogMetricsManager.Edit(r.Context(), mux.Vars(r)["id"], &rule)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, edited)
+}
+
+func (aH *APIHandler) deleteLogMetricRule(w http.ResponseWriter, r *http.Request) {
+	if apiErr := aH.LogMetricsManager.Delete(r.Context(), mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
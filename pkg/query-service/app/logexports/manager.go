@@ -0,0 +1,224 @@
+package logexports
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/jmoiron/sqlx"
+	"github.com/robfig/cron/v3"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/cronschedule"
+	"go.uber.org/zap"
+)
+
+type ManagerOptions struct {
+	DB   *sqlx.DB
+	Conn clickhouse.Conn
+}
+
+// Manager runs the scheduled log exports, the logs are written to the
+// bucket by clickhouse itself so they never go through the query service
+type Manager struct {
+	repo *repo
+	conn clickhouse.Conn
+
+	mtx     sync.Mutex
+	cron    *cron.Cron
+	entries map[int64]cron.EntryID
+}
+
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	if err := InitSqliteDBIfNeeded(opts.DB); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		repo:    &repo{db: opts.DB},
+		conn:    opts.Conn,
+		cron:    cron.New(cron.WithParser(cronschedule.Parser)),
+		entries: map[int64]cron.EntryID{},
+	}, nil
+}
+
+// Start schedules the stored exports and starts the scheduler
+func (m *Manager) Start() error {
+	exports, err := m.repo.list(context.Background())
+	if err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for _, e := range exports {
+		m.schedule(e)
+	}
+	m.cron.Start()
+	return nil
+}
+
+// Stop stops the scheduler and waits for the running exports
+func (m *Manager) Stop() {
+	<-m.cron.Stop().Done()
+}
+
+// schedule replaces the cron entry of the export, the caller must hold the lock
+func (m *Manager) schedule(e *Export) {
+	if entry, ok := m.entries[e.Id]; ok {
+		m.cron.Remove(entry)
+		delete(m.entries, e.Id)
+	}
+	if e.Disabled {
+		return
+	}
+
+	sched, err := e.schedule()
+	if err != nil {
+		zap.L().Error("invalid log export schedule", zap.Int64("id", e.Id), zap.Error(err))
+		return
+	}
+	id := strconv.FormatInt(e.Id, 10)
+	m.entries[e.Id] = m.cron.Schedule(sched, cron.FuncJob(func() {
+		if err := m.Run(context.Background(), id); err != nil {
+			zap.L().Error("failed to export logs", zap.String("id", id), zap.Error(err))
+		}
+	}))
+}
+
+func (m *Manager) List(ctx context.Context) ([]*Export, error) {
+	exports, err := m.repo.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range exports {
+		exports[i] = e.Redacted()
+	}
+	return exports, nil
+}
+
+func (m *Manager) Get(ctx context.Context, id string) (*Export, error) {
+	e, err := m.repo.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return e.Redacted(), nil
+}
+
+func (m *Manager) Create(ctx context.Context, e *Export) (*Export, error) {
+	if err := e.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	id, err := m.repo.create(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	e.Id = id
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.schedule(e)
+	return e.Redacted(), nil
+}
+
+// Edit replaces the export, the stored secret access key is kept when the
+// access key id is unchanged and no secret is given
+func (m *Manager) Edit(ctx context.Context, e *Export, id string) (*Export, error) {
+	existing, err := m.repo.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if e.Destination != nil && existing.Destination != nil &&
+		e.Destination.SecretAccessKey == "" && e.Destination.AccessKeyId == existing.Destination.AccessKeyId {
+		e.Destination.SecretAccessKey = existing.Destination.SecretAccessKey
+	}
+	if err := e.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	e.Id = existing.Id
+	e.CreatedAt = existing.CreatedAt
+	e.CreatedBy = existing.CreatedBy
+	e.LastRunAt = existing.LastRunAt
+	e.LastError = existing.LastError
+	e.LastManifest = existing.LastManifest
+	e.OrgId = existing.OrgId
+	if err := m.repo.edit(ctx, e, id); err != nil {
+		return nil, err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.schedule(e)
+	return e.Redacted(), nil
+}
+
+func (m *Manager) Delete(ctx context.Context, id string) error {
+	existing, err := m.repo.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := m.repo.delete(ctx, id); err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if entry, ok := m.entries[existing.Id]; ok {
+		m.cron.Remove(entry)
+		delete(m.entries, existing.Id)
+	}
+	return nil
+}
+
+// Run exports the window of logs ending now, the outcome and the url of
+// the manifest of the run are recorded on the export
+func (m *Manager) Run(ctx context.Context, id string) error {
+	e, err := m.repo.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	run := newRun(e, now)
+	runErr := m.export(ctx, e, run)
+
+	lastError, manifest := "", ""
+	if runErr != nil {
+		lastError = runErr.Error()
+	} else {
+		manifest = e.Destination.objectURL(ManifestPath(run))
+	}
+	if err := m.repo.setLastRun(ctx, e.Id, now, lastError, manifest); err != nil {
+		zap.L().Error("failed to record log export run", zap.Int64("id", e.Id), zap.Error(err))
+	}
+	return runErr
+}
+
+func (m *Manager) export(ctx context.Context, e *Export, run Run) error {
+	if m.conn == nil {
+		return fmt.Errorf("clickhouse connection is not configured")
+	}
+
+	// the logs of the org of the export only are exported, also by the scheduled runs
+	scope, apiErr := common.OrgScope(common.WithOrg(ctx, e.OrgId))
+	if apiErr != nil {
+		return apiErr.Err
+	}
+	dataStmt, manifestStmt, err := exportStatements(e, scope, run)
+	if err != nil {
+		return err
+	}
+	ctx = common.WithQueryClass(ctx, common.QueryClassExport)
+	// the manifest is written last so that its presence marks a complete run
+	if err := m.conn.Exec(ctx, dataStmt); err != nil {
+		return fmt.Errorf("failed to write logs: %w", err)
+	}
+	if err := m.conn.Exec(ctx, manifestStmt); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package logexports
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/cronschedule"
+)
+
+type Provider string
+
+const (
+	ProviderS3  Provider = "s3"
+	ProviderGCS Provider = "gcs"
+)
+
+// Partitioning is the granularity of the directories the files are written to
+type Partitioning string
+
+const (
+	PartitionByDay  Partitioning = "day"
+	PartitionByHour Partitioning = "hour"
+)
+
+const defaultWindow = 24 * time.Hour
+
+var (
+	ErrMissingName        = errors.New("missing name")
+	ErrMissingBucket      = errors.New("missing bucket")
+	ErrInvalidProvider    = errors.New("provider must be s3 or gcs")
+	ErrInvalidPartition   = errors.New("partitioning must be day or hour")
+	ErrMissingCredentials = errors.New("access key id and secret access key must be set together")
+)
+
+// Destination is the bucket the logs are exported to, the credentials are
+// optional for s3 where the credentials of the clickhouse server are used
+// by default. GCS is accessed with HMAC keys through its s3 compatible api
+type Destination struct {
+	Provider Provider `json:"provider"`
+	Bucket   string   `json:"bucket"`
+	Prefix   string   `json:"prefix"`
+	Region   string   `json:"region,omitempty"`
+	// Endpoint overrides the url of the provider, e.g. for minio
+	Endpoint        string `json:"endpoint,omitempty"`
+	AccessKeyId     string `json:"accessKeyId,omitempty"`
+	SecretAccessKey string `json:"secretAccessKey,omitempty"`
+}
+
+func (d *Destination) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, d)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), d)
+	}
+	return nil
+}
+
+func (d *Destination) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+type Filter struct {
+	*v3.FilterSet
+}
+
+func (f *Filter) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, f)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), f)
+	}
+	return nil
+}
+
+func (f *Filter) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+// Duration is stored as nanoseconds and read as a go duration
+// string such as 24h from the api
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Export writes the logs matching the filter to Parquet files in the bucket on
+// a cron schedule, every run exports the window of logs ending at the run time
+type Export struct {
+	Id     int64   `json:"id" db:"id"`
+	Name   string  `json:"name" db:"name"`
+	Filter *Filter `json:"filter" db:"filter"`
+	// Cron is evaluated in the timezone of the export, UTC by default
+	Cron         string       `json:"cron" db:"cron"`
+	Timezone     string       `json:"timezone" db:"timezone"`
+	Window       Duration     `json:"window" db:"window_duration"`
+	Partitioning Partitioning `json:"partitioning" db:"partitioning"`
+	Destination  *Destination `json:"destination" db:"destination"`
+	Disabled     bool         `json:"disabled" db:"disabled"`
+	LastRunAt    *time.Time   `json:"lastRunAt,omitempty" db:"last_run_at"`
+	LastError    string       `json:"lastError,omitempty" db:"last_error"`
+	LastManifest string       `json:"lastManifest,omitempty" db:"last_manifest"`
+	CreatedAt    time.Time    `json:"createdAt" db:"created_at"`
+	CreatedBy    string       `json:"createdBy" db:"created_by"`
+	UpdatedAt    time.Time    `json:"updatedAt" db:"updated_at"`
+	UpdatedBy    string       `json:"updatedBy" db:"updated_by"`
+	// OrgId is the org of the export, only the logs of the org are exported
+	OrgId string `json:"-" db:"org_id"`
+}
+
+func (e *Export) location() (*time.Location, error) {
+	if e.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(e.Timezone)
+}
+
+// schedule parses the cron expression in the timezone of the export
+func (e *Export) schedule() (cron.Schedule, error) {
+	loc, err := e.location()
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	return cronschedule.Parse(e.Cron, loc)
+}
+
+// Redacted returns a copy of the export without the secret access key
+func (e *Export) Redacted() *Export {
+	redacted := *e
+	if e.Destination != nil {
+		destination := *e.Destination
+		destination.SecretAccessKey = ""
+		redacted.Destination = &destination
+	}
+	return &redacted
+}
+
+func (e *Export) Validate() error {
+	if e.Name == "" {
+		return ErrMissingName
+	}
+	if _, err := e.schedule(); err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
+	}
+	if e.Window == 0 {
+		e.Window = Duration(defaultWindow)
+	}
+	if e.Window < 0 {
+		return errors.New("window must be positive")
+	}
+	if e.Partitioning == "" {
+		e.Partitioning = PartitionByDay
+	}
+	if e.Partitioning != PartitionByDay && e.Partitioning != PartitionByHour {
+		return ErrInvalidPartition
+	}
+	if e.Filter == nil {
+		e.Filter = &Filter{}
+	}
+	if e.Filter.FilterSet == nil {
+		e.Filter.FilterSet = &v3.FilterSet{Operator: "AND"}
+	}
+	if err := e.Filter.Validate(); err != nil {
+		return err
+	}
+
+	d := e.Destination
+	if d == nil || d.Bucket == "" {
+		return ErrMissingBucket
+	}
+	if d.Provider == "" {
+		d.Provider = ProviderS3
+	}
+	if d.Provider != ProviderS3 && d.Provider != ProviderGCS {
+		return ErrInvalidProvider
+	}
+	if (d.AccessKeyId == "") != (d.SecretAccessKey == "") {
+		return ErrMissingCredentials
+	}
+	if d.Provider == ProviderGCS && d.AccessKeyId == "" {
+		return errors.New("gcs exports require hmac keys")
+	}
+	d.Prefix = strings.Trim(d.Prefix, "/")
+	return nil
+}
@@ -0,0 +1,155 @@
+package logexports
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func testExport() *Export {
+	return &Export{
+		Name:     "archive",
+		Cron:     "0 1 * * *",
+		Timezone: "Europe/Paris",
+		Filter: &Filter{&v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{
+				Key:      v3.AttributeKey{Key: "service.name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource},
+				Operator: v3.FilterOperatorEqual,
+				Value:    "checkout",
+			},
+		}}},
+		Destination: &Destination{
+			Provider:        ProviderS3,
+			Bucket:          "logs-archive",
+			Prefix:          "/signoz/",
+			Region:          "eu-west-1",
+			AccessKeyId:     "AKID",
+			SecretAccessKey: "secret",
+		},
+	}
+}
+
+func TestExportValidate(t *testing.T) {
+	e := testExport()
+	if err := e.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if e.Window != Duration(defaultWindow) || e.Partitioning != PartitionByDay {
+		t.Errorf("expected defaults, got %v %s", e.Window, e.Partitioning)
+	}
+	if e.Destination.Prefix != "signoz" {
+		t.Errorf("expected trimmed prefix, got %s", e.Destination.Prefix)
+	}
+
+	cases := map[string]func(e *Export){
+		"no name":            func(e *Export) { e.Name = "" },
+		"invalid cron":       func(e *Export) { e.Cron = "every night" },
+		"invalid timezone":   func(e *Export) { e.Timezone = "Mars/Olympus" },
+		"invalid partition":  func(e *Export) { e.Partitioning = "week" },
+		"no destination":     func(e *Export) { e.Destination = nil },
+		"no bucket":          func(e *Export) { e.Destination.Bucket = "" },
+		"invalid provider":   func(e *Export) { e.Destination.Provider = "azure" },
+		"missing secret":     func(e *Export) { e.Destination.SecretAccessKey = "" },
+		"gcs without hmac":   func(e *Export) { e.Destination = &Destination{Provider: ProviderGCS, Bucket: "logs"} },
+		"negative window":    func(e *Export) { e.Window = Duration(-time.Hour) },
+		"invalid filter ops": func(e *Export) { e.Filter.Operator = "XOR" },
+	}
+	for name, mutate := range cases {
+		e := testExport()
+		mutate(e)
+		if err := e.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestExportRedacted(t *testing.T) {
+	e := testExport()
+	redacted := e.Redacted()
+	if redacted.Destination.SecretAccessKey != "" {
+		t.Error("expected the secret to be redacted")
+	}
+	if e.Destination.SecretAccessKey != "secret" {
+		t.Error("expected the export to be left unchanged")
+	}
+}
+
+func TestObjectURL(t *testing.T) {
+	cases := []struct {
+		destination Destination
+		expected    string
+	}{
+		{Destination{Provider: ProviderS3, Bucket: "logs"}, "https://logs.s3.amazonaws.com/a.parquet"},
+		{Destination{Provider: ProviderS3, Bucket: "logs", Region: "eu-west-1", Prefix: "signoz"}, "https://logs.s3.eu-west-1.amazonaws.com/signoz/a.parquet"},
+		{Destination{Provider: ProviderGCS, Bucket: "logs"}, "https://storage.googleapis.com/logs/a.parquet"},
+		{Destination{Provider: ProviderS3, Bucket: "logs", Endpoint: "http://minio:9000/"}, "http://minio:9000/logs/a.parquet"},
+	}
+	for _, c := range cases {
+		if url := c.destination.objectURL("a.parquet"); url != c.expected {
+			t.Errorf("expected %s, got %s", c.expected, url)
+		}
+	}
+}
+
+func TestExportStatements(t *testing.T) {
+	e := testExport()
+	e.Partitioning = PartitionByHour
+	if err := e.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	run := newRun(e, time.Date(2024, 3, 1, 1, 0, 30, 0, time.UTC))
+	if run.Id != "20240301T010000Z" || !run.Start.Equal(run.End.Add(-24*time.Hour)) {
+		t.Fatalf("unexpected run %+v", run)
+	}
+
+	dataStmt, manifestStmt, err := exportStatements(e, nil, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"INSERT INTO FUNCTION s3('https://logs-archive.s3.eu-west-1.amazonaws.com/signoz/logs/dt={_partition_id}/20240301T010000Z.parquet', 'AKID', 'secret', 'Parquet')",
+		"PARTITION BY formatDateTime(toDateTime(intDiv(timestamp, 1000000000)), '%Y-%m-%dT%H')",
+		"resources_string_value[indexOf(resources_string_key, 'service.name')] = 'checkout'",
+		"order by timestamp asc",
+	} {
+		if !strings.Contains(dataStmt, expected) {
+			t.Errorf("expected %q in %s", expected, dataStmt)
+		}
+	}
+	for _, expected := range []string{
+		"INSERT INTO FUNCTION s3('https://logs-archive.s3.eu-west-1.amazonaws.com/signoz/manifests/20240301T010000Z.ndjson', 'AKID', 'secret', 'JSONEachRow')",
+		"GROUP BY partition",
+	} {
+		if !strings.Contains(manifestStmt, expected) {
+			t.Errorf("expected %q in %s", expected, manifestStmt)
+		}
+	}
+}
+
+func TestExportStatementsOrg(t *testing.T) {
+	e := testExport()
+	if err := e.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	run := newRun(e, time.Date(2024, 3, 1, 1, 0, 30, 0, time.UTC))
+
+	dataStmt, _, err := exportStatements(e, &model.OrgScope{OrgIds: []string{"org-2"}}, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"resources_string_value[indexOf(resources_string_key, 'service.name')] = 'checkout'",
+		"resources_string_value[indexOf(resources_string_key, 'signoz.org.id')] = 'org-2'",
+	} {
+		if !strings.Contains(dataStmt, expected) {
+			t.Errorf("expected %q in %s", expected, dataStmt)
+		}
+	}
+	// the filter of the export is left as is
+	if len(e.Filter.FilterSet.Items) != 1 {
+		t.Errorf("expected the filter of the export to be unchanged, got %v", e.Filter.FilterSet.Items)
+	}
+}
@@ -0,0 +1,123 @@
+package logexports
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	logsV3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+// partitionExprs are evaluated on the timestamp of the exported logs, the
+// value replaces the _partition_id placeholder of the file path
+var partitionExprs = map[Partitioning]string{
+	PartitionByDay:  "formatDateTime(toDateTime(intDiv(timestamp, 1000000000)), '%Y-%m-%d')",
+	PartitionByHour: "formatDateTime(toDateTime(intDiv(timestamp, 1000000000)), '%Y-%m-%dT%H')",
+}
+
+// Run is a single execution of an export over the window ending at its time
+type Run struct {
+	Id    string
+	Start time.Time
+	End   time.Time
+}
+
+func newRun(e *Export, ts time.Time) Run {
+	end := ts.UTC().Truncate(time.Minute)
+	return Run{
+		Id:    end.Format("20060102T150405Z"),
+		Start: end.Add(-time.Duration(e.Window)),
+		End:   end,
+	}
+}
+
+// objectURL returns the url of the object at the path of the bucket
+func (d *Destination) objectURL(path string) string {
+	if d.Prefix != "" {
+		path = d.Prefix + "/" + path
+	}
+	if d.Endpoint != "" {
+		return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(d.Endpoint, "/"), d.Bucket, path)
+	}
+	if d.Provider == ProviderGCS {
+		return fmt.Sprintf("https://storage.googleapis.com/%s/%s", d.Bucket, path)
+	}
+	if d.Region != "" {
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", d.Bucket, d.Region, path)
+	}
+	return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", d.Bucket, path)
+}
+
+// tableFunction returns the s3 or gcs table function writing to the path in the format
+func (d *Destination) tableFunction(path string, format string) string {
+	args := []string{utils.ClickHouseFormattedValue(d.objectURL(path))}
+	if d.AccessKeyId != "" {
+		args = append(args,
+			utils.ClickHouseFormattedValue(d.AccessKeyId),
+			utils.ClickHouseFormattedValue(d.SecretAccessKey),
+		)
+	}
+	args = append(args, utils.ClickHouseFormattedValue(format))
+	return fmt.Sprintf("%s(%s)", d.Provider, strings.Join(args, ", "))
+}
+
+// DataPath is the path of the Parquet files of the run, relative to the prefix
+func DataPath(run Run) string {
+	return fmt.Sprintf("logs/dt={_partition_id}/%s.parquet", run.Id)
+}
+
+// ManifestPath is the path of the manifest of the run, relative to the prefix
+func ManifestPath(run Run) string {
+	return fmt.Sprintf("manifests/%s.ndjson", run.Id)
+}
+
+// logsQuery selects the logs of the run window matching the filter of the export, and of
+// the orgs of the scope
+func logsQuery(e *Export, scope *model.OrgScope, run Run) (string, error) {
+	filters := e.Filter.FilterSet
+	if scope != nil {
+		filters = &v3.FilterSet{Operator: "AND"}
+		if e.Filter.FilterSet != nil {
+			filters.Items = append(filters.Items, e.Filter.FilterSet.Items...)
+		}
+		filters.Items = append(filters.Items, common.OrgFilter(v3.DataSourceLogs, scope))
+	}
+	return logsV3.PrepareLogsQuery(run.Start.UnixMilli(), run.End.UnixMilli(), v3.QueryTypeBuilder, v3.PanelTypeList, &v3.BuilderQuery{
+		QueryName:         "A",
+		DataSource:        v3.DataSourceLogs,
+		AggregateOperator: v3.AggregateOperatorNoOp,
+		Expression:        "A",
+		Filters:           filters,
+		OrderBy: []v3.OrderBy{
+			{ColumnName: constants.TIMESTAMP, Order: "asc"},
+		},
+	}, logsV3.Options{})
+}
+
+// exportStatements returns the insert writing the logs of the run to the Parquet files
+// of their partition, and the insert writing the manifest listing the files of the run
+func exportStatements(e *Export, scope *model.OrgScope, run Run) (string, string, error) {
+	query, err := logsQuery(e, scope, run)
+	if err != nil {
+		return "", "", err
+	}
+	partitionExpr := partitionExprs[e.Partitioning]
+
+	dataStmt := fmt.Sprintf("INSERT INTO FUNCTION %s PARTITION BY %s %s",
+		e.Destination.tableFunction(DataPath(run), "Parquet"), partitionExpr, query)
+
+	dataURL := e.Destination.objectURL(DataPath(run))
+	manifestStmt := fmt.Sprintf("INSERT INTO FUNCTION %s "+
+		"SELECT %s AS partition, replaceOne(%s, '{_partition_id}', partition) AS file, "+
+		"count() AS rows, min(timestamp) AS min_timestamp, max(timestamp) AS max_timestamp "+
+		"FROM (%s) GROUP BY partition ORDER BY partition",
+		e.Destination.tableFunction(ManifestPath(run), "JSONEachRow"), partitionExpr,
+		utils.ClickHouseFormattedValue(dataURL), query)
+
+	return dataStmt, manifestStmt, nil
+}
@@ -0,0 +1,130 @@
+package logexports
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.uber.org/zap"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS log_exports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		filter TEXT NOT NULL,
+		cron TEXT NOT NULL,
+		timezone TEXT,
+		window_duration INTEGER NOT NULL,
+		partitioning TEXT NOT NULL,
+		destination TEXT NOT NULL,
+		disabled BOOLEAN NOT NULL DEFAULT 0,
+		last_run_at datetime,
+		last_error TEXT NOT NULL DEFAULT '',
+		last_manifest TEXT NOT NULL DEFAULT '',
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating log_exports table: %s", err.Error())
+	}
+	_, err = db.Exec(`ALTER TABLE log_exports ADD COLUMN org_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return fmt.Errorf("error in adding column org_id to log_exports table: %s", err.Error())
+	}
+	return nil
+}
+
+const exportColumns = "id, name, filter, cron, timezone, window_duration, partitioning, destination, disabled, last_run_at, last_error, last_manifest, created_at, created_by, updated_at, updated_by, org_id"
+
+type repo struct {
+	db *sqlx.DB
+}
+
+// list returns the exports of the org of the user of the context, all the exports for the
+// scheduler
+func (r *repo) list(ctx context.Context) ([]*Export, error) {
+	exports := []*Export{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.SelectContext(ctx, &exports, "SELECT "+exportColumns+" FROM log_exports WHERE "+condition, args...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *repo) get(ctx context.Context, id string) (*Export, error) {
+	export := &Export{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.GetContext(ctx, export, "SELECT "+exportColumns+" FROM log_exports WHERE id=? AND "+condition, append([]interface{}{id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return export, nil
+}
+
+func (r *repo) create(ctx context.Context, e *Export) (int64, error) {
+	email, _ := auth.GetEmailFromJwt(ctx)
+	e.CreatedBy = email
+	e.CreatedAt = time.Now()
+	e.UpdatedBy = email
+	e.UpdatedAt = time.Now()
+	e.OrgId = common.GetOrgIdFromContext(ctx)
+
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO log_exports (name, filter, cron, timezone, window_duration, partitioning, destination, disabled, created_at, created_by, updated_at, updated_by, org_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		e.Name, e.Filter, e.Cron, e.Timezone, e.Window, e.Partitioning, e.Destination, e.Disabled, e.CreatedAt, e.CreatedBy, e.UpdatedAt, e.UpdatedBy, e.OrgId)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (r *repo) edit(ctx context.Context, e *Export, id string) error {
+	email, _ := auth.GetEmailFromJwt(ctx)
+	e.UpdatedBy = email
+	e.UpdatedAt = time.Now()
+
+	condition, args := common.OrgCondition(ctx)
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE log_exports SET name=?, filter=?, cron=?, timezone=?, window_duration=?, partitioning=?, destination=?, disabled=?, updated_at=?, updated_by=? WHERE id=? AND "+condition,
+		append([]interface{}{e.Name, e.Filter, e.Cron, e.Timezone, e.Window, e.Partitioning, e.Destination, e.Disabled, e.UpdatedAt, e.UpdatedBy, id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *repo) delete(ctx context.Context, id string) error {
+	condition, args := common.OrgCondition(ctx)
+	_, err := r.db.ExecContext(ctx, "DELETE FROM log_exports WHERE id=? AND "+condition, append([]interface{}{id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// setLastRun records the outcome of the latest run of the export
+func (r *repo) setLastRun(ctx context.Context, id int64, at time.Time, runErr string, manifest string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE log_exports SET last_run_at=$1, last_error=$2, last_manifest=$3 WHERE id=$4", at, runErr, manifest, id)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package logmetrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/remotewrite"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	syncInterval = time.Minute
+	// syncDelay is how far behind now the logs are aggregated, so the logs still being
+	// written are not missed
+	syncDelay = time.Minute
+	// maxSyncWindow is the most logs aggregated by a sync, after a downtime the older
+	// intervals are skipped
+	maxSyncWindow = 6 * time.Hour
+)
+
+// Reader runs the aggregations of the logs
+type Reader interface {
+	GetTimeSeriesResultV3(ctx context.Context, query string) ([]*v3.Series, error)
+}
+
+// Writer writes the samples of the metrics
+type Writer interface {
+	WriteSeries(ctx context.Context, series []remotewrite.Series) error
+}
+
+type ManagerOptions struct {
+	DB     *sqlx.DB
+	Reader Reader
+	Writer Writer
+}
+
+// Manager derives the metrics of the rules from the logs. Every minute the manager
+// aggregates the logs of the intervals ended since the last sync of each rule and writes
+// them as the samples of its metric, so the alerts and the dashboards on the metric don't
+// scan the logs
+type Manager struct {
+	repo   *repo
+	reader Reader
+	writer Writer
+
+	// mtx serializes the syncs
+	mtx sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	if err := InitSqliteDBIfNeeded(opts.DB); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		repo:   &repo{db: opts.DB},
+		reader: opts.Reader,
+		writer: opts.Writer,
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Start syncs the rules every minute
+func (m *Manager) Start() error {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(syncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.sync(m.ctx, time.Now().UTC()); err != nil {
+					zap.L().Error("failed to sync the log metric rules", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// align returns the start of the interval of the time, the intervals are aligned on the
+// unix epoch as the intervals of the logs queries
+func align(t time.Time, interval time.Duration) time.Time {
+	ms := t.UnixMilli()
+	return time.UnixMilli(ms - ms%interval.Milliseconds()).UTC()
+}
+
+// sync writes the samples of the intervals ended since the last sync of each rule
+func (m *Manager) sync(ctx context.Context, now time.Time) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	rules, err := m.repo.list(ctx)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		until, syncErr := m.syncRule(ctx, rule, now)
+		lastError := ""
+		if syncErr != nil {
+			zap.L().Error("failed to sync the log metric rule", zap.String("metric", rule.MetricName), zap.Error(syncErr))
+			lastError = syncErr.Error()
+		}
+		if err := m.repo.setSynced(ctx, rule.Id, until, lastError); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncRule aggregates the logs of the intervals of the rule ended since its last sync and
+// returns the end of the last interval written
+func (m *Manager) syncRule(ctx context.Context, rule *Rule, now time.Time) (*time.Time, error) {
+	interval := time.Duration(rule.IntervalSeconds) * time.Second
+	end := align(now.Add(-syncDelay), interval)
+	start := end.Add(-interval)
+	if rule.SyncedUntil != nil {
+		start = rule.SyncedUntil.UTC()
+	}
+	if end.Sub(start) > maxSyncWindow {
+		start = align(end.Add(-maxSyncWindow), interval)
+	}
+	if !end.After(start) {
+		return rule.SyncedUntil, nil
+	}
+
+	scope, apiErr := common.OrgScope(common.WithOrg(ctx, rule.OrgId))
+	if apiErr != nil {
+		return rule.SyncedUntil, apiErr.Err
+	}
+	query, err := logsQuery(rule, scope, start, end)
+	if err != nil {
+		return rule.SyncedUntil, err
+	}
+	result, err := m.reader.GetTimeSeriesResultV3(ctx, query)
+	if err != nil {
+		return rule.SyncedUntil, err
+	}
+	if err := m.writer.WriteSeries(ctx, toSeries(rule, scope, result, end)); err != nil {
+		return rule.SyncedUntil, err
+	}
+	return &end, nil
+}
+
+func (m *Manager) List(ctx context.Context) ([]*Rule, *model.ApiError) {
+	rules, err := m.repo.list(ctx)
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+	return rules, nil
+}
+
+func (m *Manager) Get(ctx context.Context, id string) (*Rule, *model.ApiError) {
+	rule, err := m.repo.get(ctx, id)
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+	if rule == nil {
+		return nil, model.NotFoundError(fmt.Errorf("no log metric rule found with id %s", id))
+	}
+	return rule, nil
+}
+
+func (m *Manager) Create(ctx context.Context, rule *Rule) (*Rule, *model.ApiError) {
+	if err := rule.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	email, _ := auth.GetEmailFromJwt(ctx)
+	now := time.Now().UTC()
+	rule.Id = uuid.NewString()
+	rule.SyncedUntil, rule.LastError = nil, ""
+	rule.CreatedAt, rule.CreatedBy = now, email
+	rule.UpdatedAt, rule.UpdatedBy = now, email
+	rule.OrgId = common.GetOrgIdFromContext(ctx)
+
+	if err := m.repo.create(ctx, rule); err != nil {
+		return nil, ruleApiError(err, rule)
+	}
+	return rule, nil
+}
+
+// Edit replaces the rule, the metric keeps being written from the end of the last sync
+func (m *Manager) Edit(ctx context.Context, id string, rule *Rule) (*Rule, *model.ApiError) {
+	existing, apiErr := m.Get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	email, _ := auth.GetEmailFromJwt(ctx)
+	rule.Id = existing.Id
+	rule.SyncedUntil, rule.LastError = existing.SyncedUntil, existing.LastError
+	rule.CreatedAt, rule.CreatedBy = existing.CreatedAt, existing.CreatedBy
+	rule.UpdatedAt, rule.UpdatedBy = time.Now().UTC(), email
+	rule.OrgId = existing.OrgId
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if err := m.repo.edit(ctx, rule); err != nil {
+		return nil, ruleApiError(err, rule)
+	}
+	return rule, nil
+}
+
+// Delete deletes the rule, the samples already written are kept
+func (m *Manager) Delete(ctx context.Context, id string) *model.ApiError {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	deleted, err := m.repo.delete(ctx, id)
+	if err != nil {
+		return model.InternalError(err)
+	}
+	if !deleted {
+		return model.NotFoundError(fmt.Errorf("no log metric rule found with id %s", id))
+	}
+	return nil
+}
+
+// ruleApiError returns a conflict for the metrics already derived by another rule
+func ruleApiError(err error, rule *Rule) *model.ApiError {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf("metric %s is already derived by another rule", rule.MetricName)}
+	}
+	return model.InternalError(err)
+}
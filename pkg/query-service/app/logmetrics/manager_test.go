@@ -0,0 +1,233 @@
+package logmetrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/remotewrite"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+// reader returns the points of a single series for every query
+type reader struct {
+	series  map[string]string
+	points  []v3.Point
+	queries []string
+	err     error
+}
+
+func (r *reader) GetTimeSeriesResultV3(ctx context.Context, query string) ([]*v3.Series, error) {
+	r.queries = append(r.queries, query)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return []*v3.Series{{Labels: r.series, Points: r.points}}, nil
+}
+
+type writer struct {
+	series []remotewrite.Series
+}
+
+func (w *writer) WriteSeries(ctx context.Context, series []remotewrite.Series) error {
+	w.series = append(w.series, series...)
+	return nil
+}
+
+func newTestManager(t *testing.T, r *reader, w *writer) *Manager {
+	db := utils.NewQueryServiceDBForTests(t)
+	m, err := NewManager(ManagerOptions{DB: db, Reader: r, Writer: w})
+	require.NoError(t, err)
+	return m
+}
+
+func countRule(metricName string) *Rule {
+	return &Rule{
+		MetricName:  metricName,
+		Aggregation: v3.AggregateOperatorCount,
+		GroupBy:     GroupBy{{Key: "service.name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource}},
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	rule := countRule("checkout_errors")
+	require.NoError(t, rule.Validate())
+	require.Equal(t, int64(defaultIntervalSeconds), rule.IntervalSeconds)
+
+	rule = countRule("checkout-errors")
+	require.Error(t, rule.Validate())
+
+	rule = countRule("checkout_latency")
+	rule.Aggregation = v3.AggregateOperatorP99
+	require.Error(t, rule.Validate())
+	rule.Attribute = AttributeKey{v3.AttributeKey{Key: "duration", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}}
+	require.Error(t, rule.Validate())
+	rule.Attribute.DataType = v3.AttributeKeyDataTypeFloat64
+	require.NoError(t, rule.Validate())
+
+	rule.IntervalSeconds = 90
+	require.Error(t, rule.Validate())
+	rule.IntervalSeconds = 7200
+	require.Error(t, rule.Validate())
+
+	rule.Aggregation = v3.AggregateOperatorRate
+	rule.IntervalSeconds = 60
+	require.Error(t, rule.Validate())
+}
+
+func TestManagerRules(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, &reader{}, &writer{})
+
+	created, apiErr := m.Create(ctx, countRule("checkout_errors"))
+	require.Nil(t, apiErr)
+	require.NotEmpty(t, created.Id)
+
+	_, apiErr = m.Create(ctx, countRule("checkout_errors"))
+	require.NotNil(t, apiErr)
+	require.Equal(t, model.ErrorConflict, apiErr.Type())
+
+	_, apiErr = m.Create(ctx, countRule("checkout-errors"))
+	require.NotNil(t, apiErr)
+	require.Equal(t, model.ErrorBadData, apiErr.Type())
+
+	edited := countRule("checkout_errors")
+	edited.Description = "errors of the checkout"
+	edited.IntervalSeconds = 300
+	_, apiErr = m.Edit(ctx, created.Id, edited)
+	require.Nil(t, apiErr)
+
+	rule, apiErr := m.Get(ctx, created.Id)
+	require.Nil(t, apiErr)
+	require.Equal(t, "errors of the checkout", rule.Description)
+	require.Equal(t, int64(300), rule.IntervalSeconds)
+	require.Len(t, rule.GroupBy, 1)
+	require.Equal(t, "service.name", rule.GroupBy[0].Key)
+	require.True(t, rule.CreatedAt.Equal(created.CreatedAt))
+
+	rules, apiErr := m.List(ctx)
+	require.Nil(t, apiErr)
+	require.Len(t, rules, 1)
+
+	require.Nil(t, m.Delete(ctx, created.Id))
+	_, apiErr = m.Get(ctx, created.Id)
+	require.NotNil(t, apiErr)
+	require.Equal(t, model.ErrorNotFound, apiErr.Type())
+	require.NotNil(t, m.Delete(ctx, created.Id))
+}
+
+func TestManagerSync(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 10, 30, 20, 0, time.UTC)
+	r := &reader{series: map[string]string{"service.name": "checkout"}}
+	w := &writer{}
+	m := newTestManager(t, r, w)
+
+	created, apiErr := m.Create(ctx, countRule("checkout_errors"))
+	require.Nil(t, apiErr)
+
+	// the first sync writes the last interval ended before the sync delay, the point at
+	// its end is dropped
+	r.points = []v3.Point{
+		{Timestamp: time.Date(2024, 3, 1, 10, 28, 0, 0, time.UTC).UnixMilli(), Value: 3},
+		{Timestamp: time.Date(2024, 3, 1, 10, 29, 0, 0, time.UTC).UnixMilli(), Value: 5},
+	}
+	require.NoError(t, m.sync(ctx, now))
+	require.Len(t, w.series, 1)
+	require.Equal(t, "checkout_errors", w.series[0].Labels[nameLabel])
+	require.Equal(t, "checkout", w.series[0].Labels["service.name"])
+	require.Equal(t, v3.MetricTypeSum, w.series[0].Type)
+	require.Equal(t, v3.Delta, w.series[0].Temporality)
+	require.Len(t, w.series[0].Samples, 1)
+	require.Equal(t, float64(3), w.series[0].Samples[0].Value)
+
+	rule, apiErr := m.Get(ctx, created.Id)
+	require.Nil(t, apiErr)
+	require.True(t, rule.SyncedUntil.Equal(time.Date(2024, 3, 1, 10, 29, 0, 0, time.UTC)))
+
+	// the next sync in the same interval has nothing to write
+	require.NoError(t, m.sync(ctx, now.Add(10*time.Second)))
+	require.Len(t, r.queries, 1)
+
+	// after a downtime the sync is limited to the last hours
+	w.series = nil
+	r.points = nil
+	require.NoError(t, m.sync(ctx, now.Add(24*time.Hour)))
+	rule, _ = m.Get(ctx, created.Id)
+	require.True(t, rule.SyncedUntil.Equal(time.Date(2024, 3, 2, 10, 29, 0, 0, time.UTC)))
+	require.Contains(t, r.queries[1], "timestamp >= 1709353740000000000")
+}
+
+func TestManagerSyncError(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 10, 30, 20, 0, time.UTC)
+	r := &reader{err: context.DeadlineExceeded}
+	m := newTestManager(t, r, &writer{})
+
+	created, apiErr := m.Create(ctx, countRule("checkout_errors"))
+	require.Nil(t, apiErr)
+
+	// the failed intervals are retried by the next sync
+	require.NoError(t, m.sync(ctx, now))
+	rule, _ := m.Get(ctx, created.Id)
+	require.Nil(t, rule.SyncedUntil)
+	require.Equal(t, context.DeadlineExceeded.Error(), rule.LastError)
+
+	r.err = nil
+	require.NoError(t, m.sync(ctx, now))
+	rule, _ = m.Get(ctx, created.Id)
+	require.NotNil(t, rule.SyncedUntil)
+	require.Empty(t, rule.LastError)
+}
+
+func TestManagerSyncOrg(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 10, 30, 20, 0, time.UTC)
+	r := &reader{series: map[string]string{"service.name": "checkout"}, points: []v3.Point{
+		{Timestamp: time.Date(2024, 3, 1, 10, 28, 0, 0, time.UTC).UnixMilli(), Value: 3},
+	}}
+	w := &writer{}
+	m := newTestManager(t, r, w)
+
+	defaultOrg, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "default"})
+	require.Nil(t, apiErr)
+	other, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "other"})
+	require.Nil(t, apiErr)
+
+	// the metric names are unique per org
+	_, apiErr = m.Create(common.WithOrg(ctx, defaultOrg.Id), countRule("checkout_errors"))
+	require.Nil(t, apiErr)
+	created, apiErr := m.Create(common.WithOrg(ctx, other.Id), countRule("checkout_errors"))
+	require.Nil(t, apiErr)
+	_, apiErr = m.Get(common.WithOrg(ctx, defaultOrg.Id), created.Id)
+	require.NotNil(t, apiErr)
+	require.Equal(t, model.ErrorNotFound, apiErr.Type())
+
+	// the rules aggregate the logs of their org, the series of the other org are labelled
+	require.NoError(t, m.sync(ctx, now))
+	require.Len(t, r.queries, 2)
+	require.Len(t, w.series, 2)
+	labelled := 0
+	for i, query := range r.queries {
+		require.Contains(t, query, "signoz.org.id")
+		if orgId, ok := w.series[i].Labels[constants.OrgIdLabel]; ok {
+			require.Equal(t, other.Id, orgId)
+			labelled++
+		}
+	}
+	require.Equal(t, 1, labelled)
+}
+
+func TestAlign(t *testing.T) {
+	at := time.Date(2024, 3, 1, 10, 37, 20, 0, time.UTC)
+	require.True(t, align(at, time.Minute).Equal(time.Date(2024, 3, 1, 10, 37, 0, 0, time.UTC)))
+	require.True(t, align(at, 5*time.Minute).Equal(time.Date(2024, 3, 1, 10, 35, 0, 0, time.UTC)))
+	require.True(t, align(at, time.Hour).Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)))
+}
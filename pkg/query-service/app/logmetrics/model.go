@@ -0,0 +1,152 @@
+package logmetrics
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const (
+	defaultIntervalSeconds = 60
+	minIntervalSeconds     = 60
+	maxIntervalSeconds     = 3600
+)
+
+// metricNameRe matches the names of the metrics, as the Prometheus metric names
+var metricNameRe = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Filter is the filter of the logs of a rule, stored as json
+type Filter struct {
+	*v3.FilterSet
+}
+
+func (f *Filter) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, f)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), f)
+	}
+	return nil
+}
+
+func (f *Filter) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+// AttributeKey is the attribute of the logs the values are aggregated of, stored as json
+type AttributeKey struct {
+	v3.AttributeKey
+}
+
+func (a *AttributeKey) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, a)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), a)
+	}
+	return nil
+}
+
+func (a AttributeKey) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// GroupBy are the attributes of the logs the series are split by, stored as json
+type GroupBy []v3.AttributeKey
+
+func (g *GroupBy) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, g)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), g)
+	}
+	return nil
+}
+
+func (g GroupBy) Value() (driver.Value, error) {
+	return json.Marshal(g)
+}
+
+// Rule derives a metric from the logs matching its filter. Every interval the logs of the
+// interval are counted, or the values of their attribute aggregated, by the group by and
+// the results written as the samples of the metric
+type Rule struct {
+	Id          string `json:"id" db:"id"`
+	MetricName  string `json:"metricName" db:"metric_name"`
+	Description string `json:"description" db:"description"`
+	Filter      Filter `json:"filter" db:"filter"`
+	// Aggregation is count, sum, avg, min, max or a percentile such as p99
+	Aggregation v3.AggregateOperator `json:"aggregation" db:"aggregation"`
+	// Attribute is the numeric attribute aggregated, all but the count need it
+	Attribute       AttributeKey `json:"attribute" db:"attribute"`
+	GroupBy         GroupBy      `json:"groupBy" db:"group_by"`
+	IntervalSeconds int64        `json:"intervalSeconds" db:"interval_seconds"`
+	Disabled        bool         `json:"disabled" db:"disabled"`
+
+	// SyncedUntil is the end of the last interval written
+	SyncedUntil *time.Time `json:"syncedUntil,omitempty" db:"synced_until"`
+	LastError   string     `json:"lastError" db:"last_error"`
+
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	CreatedBy string    `json:"createdBy" db:"created_by"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	UpdatedBy string    `json:"updatedBy" db:"updated_by"`
+	// OrgId is the org of the rule, the metric is derived from the logs of the org
+	OrgId string `json:"-" db:"org_id"`
+}
+
+func (r *Rule) Validate() error {
+	if !metricNameRe.MatchString(r.MetricName) {
+		return fmt.Errorf("metricName %q is not a valid metric name", r.MetricName)
+	}
+	switch r.Aggregation {
+	case v3.AggregateOperatorCount:
+	case v3.AggregateOperatorSum, v3.AggregateOperatorAvg, v3.AggregateOperatorMin, v3.AggregateOperatorMax,
+		v3.AggregateOperatorP50, v3.AggregateOperatorP75, v3.AggregateOperatorP90, v3.AggregateOperatorP95, v3.AggregateOperatorP99:
+		if r.Attribute.Key == "" {
+			return fmt.Errorf("attribute is required for the %s aggregation", r.Aggregation)
+		}
+		if r.Attribute.DataType != v3.AttributeKeyDataTypeInt64 && r.Attribute.DataType != v3.AttributeKeyDataTypeFloat64 {
+			return fmt.Errorf("attribute %s must be numeric", r.Attribute.Key)
+		}
+	default:
+		return fmt.Errorf("aggregation %q is not supported", r.Aggregation)
+	}
+	if r.IntervalSeconds == 0 {
+		r.IntervalSeconds = defaultIntervalSeconds
+	}
+	if r.IntervalSeconds < minIntervalSeconds || r.IntervalSeconds > maxIntervalSeconds || r.IntervalSeconds%minIntervalSeconds != 0 {
+		return fmt.Errorf("intervalSeconds must be a multiple of %d up to %d", minIntervalSeconds, maxIntervalSeconds)
+	}
+	if r.Filter.FilterSet != nil {
+		if err := r.Filter.FilterSet.Validate(); err != nil {
+			return fmt.Errorf("filter is invalid: %w", err)
+		}
+	}
+	for _, groupBy := range r.GroupBy {
+		if err := groupBy.Validate(); err != nil {
+			return fmt.Errorf("group by is invalid: %w", err)
+		}
+	}
+	return nil
+}
+
+// metricType returns the type and the temporality of the metric, the counts and the sums
+// of each interval are delta sums, the other aggregations gauges
+func (r *Rule) metricType() (v3.MetricType, v3.Temporality, bool) {
+	switch r.Aggregation {
+	case v3.AggregateOperatorCount:
+		return v3.MetricTypeSum, v3.Delta, true
+	case v3.AggregateOperatorSum:
+		return v3.MetricTypeSum, v3.Delta, false
+	default:
+		return v3.MetricTypeGauge, v3.Unspecified, false
+	}
+}
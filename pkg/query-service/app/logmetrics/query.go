@@ -0,0 +1,75 @@
+package logmetrics
+
+import (
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	logsV3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/remotewrite"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const nameLabel = "__name__"
+
+// logsQuery aggregates the logs of the orgs of the scope matching the filter of the rule by
+// interval between start and end
+func logsQuery(rule *Rule, scope *model.OrgScope, start, end time.Time) (string, error) {
+	filters := rule.Filter.FilterSet
+	if scope != nil {
+		filters = &v3.FilterSet{Operator: "AND"}
+		if rule.Filter.FilterSet != nil {
+			filters.Items = append(filters.Items, rule.Filter.FilterSet.Items...)
+		}
+		filters.Items = append(filters.Items, common.OrgFilter(v3.DataSourceLogs, scope))
+	}
+	return logsV3.PrepareLogsQuery(start.UnixMilli(), end.UnixMilli(), v3.QueryTypeBuilder, v3.PanelTypeGraph, &v3.BuilderQuery{
+		QueryName:          "A",
+		DataSource:         v3.DataSourceLogs,
+		StepInterval:       rule.IntervalSeconds,
+		AggregateOperator:  rule.Aggregation,
+		AggregateAttribute: rule.Attribute.AttributeKey,
+		Filters:            filters,
+		GroupBy:            rule.GroupBy,
+		Expression:         "A",
+	}, logsV3.Options{})
+}
+
+// toSeries returns the series of the metric of the rule for the result of its logs query.
+// The points at end and after are dropped, the logs of their interval are counted by the
+// next sync. The series of the orgs other than the default one get the label of the org
+func toSeries(rule *Rule, scope *model.OrgScope, result []*v3.Series, end time.Time) []remotewrite.Series {
+	typ, temporality, monotonic := rule.metricType()
+	series := make([]remotewrite.Series, 0, len(result))
+	for _, s := range result {
+		samples := make([]prompb.Sample, 0, len(s.Points))
+		for _, point := range s.Points {
+			if point.Timestamp >= end.UnixMilli() {
+				continue
+			}
+			samples = append(samples, prompb.Sample{Timestamp: point.Timestamp, Value: point.Value})
+		}
+		if len(samples) == 0 {
+			continue
+		}
+		labels := make(map[string]string, len(s.Labels)+1)
+		for k, v := range s.Labels {
+			labels[k] = v
+		}
+		if scope != nil && !scope.Exclude {
+			labels[constants.OrgIdLabel] = scope.OrgIds[0]
+		}
+		labels[nameLabel] = rule.MetricName
+		series = append(series, remotewrite.Series{
+			Labels:      labels,
+			Type:        typ,
+			Temporality: temporality,
+			IsMonotonic: monotonic,
+			Description: rule.Description,
+			Samples:     samples,
+		})
+	}
+	return series
+}
@@ -0,0 +1,174 @@
+package logmetrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.uber.org/zap"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS log_metric_rules (
+		id TEXT PRIMARY KEY,
+		metric_name TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		filter TEXT NOT NULL,
+		aggregation TEXT NOT NULL,
+		attribute TEXT NOT NULL,
+		group_by TEXT NOT NULL,
+		interval_seconds INTEGER NOT NULL,
+		disabled BOOLEAN NOT NULL DEFAULT 0,
+		synced_until datetime,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL,
+		org_id TEXT NOT NULL DEFAULT '',
+		UNIQUE(metric_name, org_id)
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating log_metric_rules table: %s", err.Error())
+	}
+	if err := migrateRules(db); err != nil {
+		return fmt.Errorf("error in adding column org_id to log_metric_rules table: %s", err.Error())
+	}
+	return nil
+}
+
+// migrateRules adds the org to the log_metric_rules tables created before the orgs were
+// added, the metric names are unique per org and sqlite can't change the unique constraint
+// of a table so the table is rebuilt
+func migrateRules(db *sqlx.DB) error {
+	var migrated bool
+	if err := db.Get(&migrated, "SELECT COUNT(*) > 0 FROM pragma_table_info('log_metric_rules') WHERE name = 'org_id'"); err != nil {
+		return err
+	}
+	if migrated {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	columns := "id, metric_name, description, filter, aggregation, attribute, group_by, interval_seconds, disabled, synced_until, last_error, created_at, created_by, updated_at, updated_by"
+	for _, stmt := range []string{
+		"ALTER TABLE log_metric_rules RENAME TO log_metric_rules_old",
+		`CREATE TABLE log_metric_rules (
+			id TEXT PRIMARY KEY,
+			metric_name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			filter TEXT NOT NULL,
+			aggregation TEXT NOT NULL,
+			attribute TEXT NOT NULL,
+			group_by TEXT NOT NULL,
+			interval_seconds INTEGER NOT NULL,
+			disabled BOOLEAN NOT NULL DEFAULT 0,
+			synced_until datetime,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at datetime NOT NULL,
+			created_by TEXT NOT NULL,
+			updated_at datetime NOT NULL,
+			updated_by TEXT NOT NULL,
+			org_id TEXT NOT NULL DEFAULT '',
+			UNIQUE(metric_name, org_id)
+		)`,
+		"INSERT INTO log_metric_rules (" + columns + ") SELECT " + columns + " FROM log_metric_rules_old",
+		"DROP TABLE log_metric_rules_old",
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+const ruleColumns = "id, metric_name, description, filter, aggregation, attribute, group_by, interval_seconds, disabled, synced_until, last_error, created_at, created_by, updated_at, updated_by, org_id"
+
+type repo struct {
+	db *sqlx.DB
+}
+
+// list returns the rules of the org of the user of the context, all the rules for the syncs
+func (r *repo) list(ctx context.Context) ([]*Rule, error) {
+	rules := []*Rule{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.SelectContext(ctx, &rules, "SELECT "+ruleColumns+" FROM log_metric_rules WHERE "+condition+" ORDER BY metric_name", args...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return rules, nil
+}
+
+// get returns the rule, nil when there is none with the id
+func (r *repo) get(ctx context.Context, id string) (*Rule, error) {
+	rule := &Rule{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.GetContext(ctx, rule, "SELECT "+ruleColumns+" FROM log_metric_rules WHERE id=? AND "+condition, append([]interface{}{id}, args...)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *repo) create(ctx context.Context, rule *Rule) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO log_metric_rules (id, metric_name, description, filter, aggregation, attribute, group_by, interval_seconds, disabled, created_at, created_by, updated_at, updated_by, org_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)",
+		rule.Id, rule.MetricName, rule.Description, &rule.Filter, rule.Aggregation, rule.Attribute, rule.GroupBy, rule.IntervalSeconds, rule.Disabled,
+		rule.CreatedAt, rule.CreatedBy, rule.UpdatedAt, rule.UpdatedBy, rule.OrgId)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *repo) edit(ctx context.Context, rule *Rule) error {
+	condition, args := common.OrgCondition(ctx)
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE log_metric_rules SET metric_name=?, description=?, filter=?, aggregation=?, attribute=?, group_by=?, interval_seconds=?, disabled=?, synced_until=?, updated_at=?, updated_by=? WHERE id=? AND "+condition,
+		append([]interface{}{rule.MetricName, rule.Description, &rule.Filter, rule.Aggregation, rule.Attribute, rule.GroupBy, rule.IntervalSeconds, rule.Disabled,
+			rule.SyncedUntil, rule.UpdatedAt, rule.UpdatedBy, rule.Id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// delete deletes the rule and tells whether there was one with the id
+func (r *repo) delete(ctx context.Context, id string) (bool, error) {
+	condition, args := common.OrgCondition(ctx)
+	result, err := r.db.ExecContext(ctx, "DELETE FROM log_metric_rules WHERE id=? AND "+condition, append([]interface{}{id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// setSynced records the end of the last interval written for the rule and the error of
+// the sync, if any
+func (r *repo) setSynced(ctx context.Context, id string, until *time.Time, syncErr string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE log_metric_rules SET synced_until=$1, last_error=$2 WHERE id=$3", until, syncErr, id)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -57,6 +57,26 @@ func (ic *LogParsingPipelineController) ApplyPipelines(
 		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
 	}
 
+	// the aliases name the processors of the pipelines in the collector config, they are
+	// the ids of the pipelines that stay the same across the versions
+	aliases := map[string]bool{}
+	for _, p := range postable {
+		if aliases[p.Alias] {
+			return nil, model.BadRequest(fmt.Errorf("pipeline alias %s is used more than once", p.Alias))
+		}
+		aliases[p.Alias] = true
+	}
+
+	// saving the pipelines of the latest version again does not start a new version, the
+	// requests are repeated with the same result
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeLogPipelines)
+	if apiErr == nil && latest != nil {
+		saved, errs := ic.getPipelinesByVersion(ctx, latest.Version)
+		if len(errs) == 0 && samePipelines(saved, postable) {
+			return ic.GetPipelinesByVersion(ctx, latest.Version)
+		}
+	}
+
 	var pipelines []Pipeline
 
 	// scan through postable pipelines, to select the existing pipelines or insert missing ones
@@ -94,6 +114,34 @@ func (ic *LogParsingPipelineController) ApplyPipelines(
 	return ic.GetPipelinesByVersion(ctx, cfg.Version)
 }
 
+// samePipelines checks if the postable pipelines are the saved pipelines, in the same order,
+// the ids and the order ids are not compared
+func samePipelines(saved []Pipeline, postable []PostablePipeline) bool {
+	if len(saved) != len(postable) {
+		return false
+	}
+	for i, p := range saved {
+		var description string
+		if p.Description != nil {
+			description = *p.Description
+		}
+		savedJSON, err := json.Marshal(PostablePipeline{
+			Name: p.Name, Alias: p.Alias, Description: description, Enabled: p.Enabled, Filter: p.Filter, Config: p.Config,
+		})
+		if err != nil {
+			return false
+		}
+		postablePipeline := postable[i]
+		postablePipeline.Id = ""
+		postablePipeline.OrderId = 0
+		postableJSON, err := json.Marshal(postablePipeline)
+		if err != nil || string(savedJSON) != string(postableJSON) {
+			return false
+		}
+	}
+	return true
+}
+
 // Returns effective list of pipelines including user created
 // pipelines and pipelines for installed integrations
 func (ic *LogParsingPipelineController) getEffectivePipelinesByVersion(
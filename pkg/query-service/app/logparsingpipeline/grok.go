@@ -0,0 +1,141 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/vjeantet/grok"
+)
+
+// grokPatternLibrary holds the logstash patterns which are not part of the
+// default patterns of the collector grok parser, the references to them are
+// expanded before the pattern is sent to the collector
+var grokPatternLibrary = map[string]string{
+	// java
+	"JAVACLASS":          `(?:[a-zA-Z$_][a-zA-Z$_0-9]*\.)*[a-zA-Z$_][a-zA-Z$_0-9]*`,
+	"JAVAFILE":           `(?:[A-Za-z0-9_. -]+)`,
+	"JAVAMETHOD":         `(?:(?:<init>)|[a-zA-Z$_][a-zA-Z$_0-9]*)`,
+	"JAVATHREAD":         `(?:[A-Z]{2}-Processor[\d]+)`,
+	"JAVALOGMESSAGE":     `(?:.*)`,
+	"JAVASTACKTRACEPART": `%{SPACE}at %{JAVACLASS:class}\.%{JAVAMETHOD:method}\(%{JAVAFILE:file}(?::%{NUMBER:line})?\)`,
+	"CATALINA_DATESTAMP": `%{MONTH} %{MONTHDAY}, 20%{YEAR} %{HOUR}:?%{MINUTE}(?::?%{SECOND}) (?:AM|PM)`,
+	"TOMCAT_DATESTAMP":   `20%{YEAR}-%{MONTHNUM}-%{MONTHDAY} %{HOUR}:?%{MINUTE}(?::?%{SECOND}) %{ISO8601_TIMEZONE}`,
+	"CATALINALOG":        `%{CATALINA_DATESTAMP:timestamp} %{JAVACLASS:class} %{JAVALOGMESSAGE:logmessage}`,
+	"TOMCATLOG":          `%{TOMCAT_DATESTAMP:timestamp} \| %{LOGLEVEL:level} \| %{JAVACLASS:class} - %{JAVALOGMESSAGE:logmessage}`,
+
+	// nginx
+	"NGINXACCESS": `%{IPORHOST:remote_addr} - %{DATA:remote_user} \[%{HTTPDATE:time_local}\] "%{WORD:method} %{NOTSPACE:request} HTTP/%{NUMBER:http_version}" %{INT:status:int} %{INT:body_bytes_sent:int} "%{DATA:http_referer}" "%{DATA:http_user_agent}"`,
+	"NGINXERROR":  `(?P<timestamp>%{YEAR}/%{MONTHNUM}/%{MONTHDAY} %{TIME}) \[%{LOGLEVEL:level}\] %{POSINT:pid:int}#%{NUMBER:tid:int}: %{GREEDYDATA:message}`,
+
+	// postgresql
+	"POSTGRESQL": `%{DATESTAMP:timestamp} %{TZ} %{DATA:user_id} %{GREEDYDATA:connection_id} %{POSINT:pid:int}`,
+
+	// redis
+	"REDISTIMESTAMP": `%{MONTHDAY} %{MONTH} %{TIME}`,
+	"REDISLOG":       `\[%{POSINT:pid:int}\] %{REDISTIMESTAMP:timestamp} \* `,
+
+	// ruby
+	"RUBY_LOGLEVEL": `(?:DEBUG|FATAL|ERROR|WARN|INFO)`,
+	"RUBY_LOGGER":   `[DFEWI], \[%{TIMESTAMP_ISO8601:timestamp} #%{POSINT:pid:int}\] *%{RUBY_LOGLEVEL:loglevel} -- +%{DATA:progname}: %{GREEDYDATA:message}`,
+}
+
+// grokReference matches %{NAME}, %{NAME:field} and %{NAME:field:type}
+var grokReference = regexp.MustCompile(`%{(\w+)(?::([\w\-.\[\]]+))?(?::(\w+))?}`)
+
+var grokPatternName = regexp.MustCompile(`^\w+$`)
+
+// the expansion of a pattern gives up after this many nested references
+const maxGrokPatternDepth = 32
+
+type GrokPattern struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// GrokPatternLibrary lists the built-in patterns which can be used in grok
+// parsers on top of the default patterns of the collector
+func GrokPatternLibrary() []GrokPattern {
+	library := make([]GrokPattern, 0, len(grokPatternLibrary))
+	for name, pattern := range grokPatternLibrary {
+		library = append(library, GrokPattern{Name: name, Pattern: pattern})
+	}
+	sort.Slice(library, func(i, j int) bool {
+		return library[i].Name < library[j].Name
+	})
+	return library
+}
+
+// expandGrokPattern inlines the references to the custom patterns and to the
+// patterns of the built-in library, the custom patterns take precedence over
+// the library. The references to the default patterns are left to the collector
+func expandGrokPattern(pattern string, customPatterns map[string]string) (string, error) {
+	for name := range customPatterns {
+		if !grokPatternName.MatchString(name) {
+			return "", fmt.Errorf("invalid custom grok pattern name %q", name)
+		}
+	}
+	return expandGrokReferences(pattern, customPatterns, 0)
+}
+
+func expandGrokReferences(pattern string, customPatterns map[string]string, depth int) (string, error) {
+	if depth > maxGrokPatternDepth {
+		return "", fmt.Errorf("grok pattern is nested too deep, check for recursive custom patterns")
+	}
+
+	var expandErr error
+	expanded := grokReference.ReplaceAllStringFunc(pattern, func(ref string) string {
+		if expandErr != nil {
+			return ref
+		}
+		groups := grokReference.FindStringSubmatch(ref)
+		name, field, fieldType := groups[1], groups[2], groups[3]
+
+		definition, ok := customPatterns[name]
+		if !ok {
+			definition, ok = grokPatternLibrary[name]
+		}
+		if !ok {
+			return ref
+		}
+
+		definition, expandErr = expandGrokReferences(definition, customPatterns, depth+1)
+		if expandErr != nil {
+			return ref
+		}
+		if field == "" {
+			return fmt.Sprintf("(?:%s)", definition)
+		}
+		// the collector only converts the captures of its default patterns
+		if fieldType != "" && fieldType != "string" {
+			expandErr = fmt.Errorf("type conversion is not supported for %%{%s}, convert the value of %s with a separate operator", name, field)
+			return ref
+		}
+		if !grokPatternName.MatchString(field) {
+			expandErr = fmt.Errorf("invalid field name %s in %%{%s}, only letters, digits and underscores are supported", field, name)
+			return ref
+		}
+		return fmt.Sprintf("(?P<%s>%s)", field, definition)
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	return expanded, nil
+}
+
+// validateGrokPattern checks that the pattern compiles the same way it will in the collector
+func validateGrokPattern(pattern string, customPatterns map[string]string) error {
+	expanded, err := expandGrokPattern(pattern, customPatterns)
+	if err != nil {
+		return err
+	}
+
+	g, err := grok.NewWithConfig(&grok.Config{NamedCapturesOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to create grok parser: %w", err)
+	}
+	if _, err := g.Match(expanded, ""); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestExpandGrokPattern(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Pattern        string
+		CustomPatterns map[string]string
+		Expected       string
+		IsValid        bool
+	}{
+		{
+			Name:     "default patterns are left to the collector",
+			Pattern:  "%{WORD:level} %{INT:code:int}",
+			Expected: "%{WORD:level} %{INT:code:int}",
+			IsValid:  true,
+		},
+		{
+			Name:     "library pattern",
+			Pattern:  "%{RUBY_LOGLEVEL:level} %{GREEDYDATA:message}",
+			Expected: "(?P<level>(?:DEBUG|FATAL|ERROR|WARN|INFO)) %{GREEDYDATA:message}",
+			IsValid:  true,
+		},
+		{
+			Name:    "nested custom patterns",
+			Pattern: "%{REQUEST}",
+			CustomPatterns: map[string]string{
+				"REQUEST": "%{METHOD:method} %{URIPATH:path}",
+				"METHOD":  "GET|POST",
+			},
+			Expected: "(?:(?P<method>GET|POST) %{URIPATH:path})",
+			IsValid:  true,
+		},
+		{
+			Name:    "custom patterns take precedence over the library",
+			Pattern: "%{RUBY_LOGLEVEL:level}",
+			CustomPatterns: map[string]string{
+				"RUBY_LOGLEVEL": "DEBUG|INFO",
+			},
+			Expected: "(?P<level>DEBUG|INFO)",
+			IsValid:  true,
+		},
+		{
+			Name:    "recursive custom patterns",
+			Pattern: "%{A}",
+			CustomPatterns: map[string]string{
+				"A": "%{B}",
+				"B": "%{A}",
+			},
+			IsValid: false,
+		},
+		{
+			Name:    "typed custom pattern",
+			Pattern: "%{CODE:code:int}",
+			CustomPatterns: map[string]string{
+				"CODE": "[0-9]{3}",
+			},
+			IsValid: false,
+		},
+		{
+			Name:    "invalid custom pattern name",
+			Pattern: "%{WORD}",
+			CustomPatterns: map[string]string{
+				"MY PATTERN": "[0-9]{3}",
+			},
+			IsValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			expanded, err := expandGrokPattern(tc.Pattern, tc.CustomPatterns)
+			if !tc.IsValid {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tc.Expected, expanded)
+		})
+	}
+}
+
+func TestGrokPatternLibraryCompiles(t *testing.T) {
+	for _, p := range GrokPatternLibrary() {
+		require.Nil(t, validateGrokPattern("%{"+p.Name+":value}", nil), p.Name)
+	}
+}
+
+func TestValidateGrokPattern(t *testing.T) {
+	require.Nil(t, validateGrokPattern("%{TOMCATLOG}", nil))
+	require.NotNil(t, validateGrokPattern("%{UNKNOWN:value}", nil))
+	require.NotNil(t, validateGrokPattern("%{BAD:value}", map[string]string{"BAD": "(unclosed"}))
+}
+
+func TestGrokParserWithCustomPatterns(t *testing.T) {
+	require := require.New(t)
+
+	testPipelines := []Pipeline{
+		{
+			OrderId: 1,
+			Name:    "pipeline1",
+			Alias:   "pipeline1",
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{
+					{
+						Key: v3.AttributeKey{
+							Key:      "method",
+							DataType: v3.AttributeKeyDataTypeString,
+							Type:     v3.AttributeKeyTypeTag,
+						},
+						Operator: "=",
+						Value:    "GET",
+					},
+				},
+			},
+			Config: []PipelineOperator{
+				{
+					OrderId:   1,
+					ID:        "grok",
+					Type:      "grok_parser",
+					Enabled:   true,
+					Name:      "test grok parser",
+					OnError:   "send",
+					ParseFrom: "body",
+					ParseTo:   "attributes",
+					Pattern:   "%{ORDER_ID:order_id} %{RUBY_LOGLEVEL:level} %{GREEDYDATA:message}",
+					CustomPatterns: map[string]string{
+						"ORDER_ID": "ORD-[0-9]+",
+					},
+				},
+			},
+		},
+	}
+
+	testLog := makeTestSignozLog(
+		"ORD-1234 WARN payment retried",
+		map[string]interface{}{
+			"method": "GET",
+		},
+	)
+	result, collectorWarnAndErrorLogs, err := SimulatePipelinesProcessing(
+		context.Background(),
+		testPipelines,
+		[]model.SignozLog{
+			testLog,
+		},
+	)
+
+	require.Nil(err)
+	require.Equal(0, len(collectorWarnAndErrorLogs))
+	require.Equal(1, len(result))
+	processed := result[0]
+
+	require.Equal("ORD-1234", processed.Attributes_string["order_id"])
+	require.Equal("WARN", processed.Attributes_string["level"])
+	require.Equal("payment retried", processed.Attributes_string["message"])
+}
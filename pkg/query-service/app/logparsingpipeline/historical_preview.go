@@ -0,0 +1,200 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	DefaultHistoricalPreviewLimit = 100
+	MaxHistoricalPreviewLimit     = 1000
+
+	defaultHistoricalPreviewWindow = 15 * time.Minute
+)
+
+// HistoricalPreviewRequest replays a sample of the stored logs matching the
+// query over the time range through draft pipelines, start and end are in ns
+type HistoricalPreviewRequest struct {
+	Pipelines []Pipeline `json:"pipelines"`
+	Query     string     `json:"q"`
+	Start     uint64     `json:"start"`
+	End       uint64     `json:"end"`
+	Limit     int        `json:"limit"`
+}
+
+// PreviewedLog holds a stored log and its output, the output is nil when
+// the log was dropped by the pipelines
+type PreviewedLog struct {
+	Input    model.SignozLog  `json:"input"`
+	Output   *model.SignozLog `json:"output"`
+	Modified bool             `json:"modified"`
+}
+
+// HistoricalPreviewStats summarises the processing of the sample, the error
+// rate is the share of the logs for which the collector reported an error
+type HistoricalPreviewStats struct {
+	Logs       int     `json:"logs"`
+	Modified   int     `json:"modified"`
+	Unmodified int     `json:"unmodified"`
+	Dropped    int     `json:"dropped"`
+	Errors     int     `json:"errors"`
+	ErrorRate  float64 `json:"errorRate"`
+}
+
+type HistoricalPreviewResponse struct {
+	Logs          []PreviewedLog         `json:"logs"`
+	Stats         HistoricalPreviewStats `json:"stats"`
+	CollectorLogs []string               `json:"collectorLogs"`
+}
+
+// LogsFilterParams validates the request and returns the params of the sample to
+// fetch, the sample defaults to the latest logs of the last 15 minutes
+func (r *HistoricalPreviewRequest) LogsFilterParams(now time.Time) (*model.LogsFilterParams, error) {
+	if len(r.Pipelines) == 0 {
+		return nil, fmt.Errorf("at least one pipeline is required")
+	}
+	for _, p := range r.Pipelines {
+		if p.Filter == nil {
+			return nil, fmt.Errorf("filter is required for pipeline %s", p.Name)
+		}
+	}
+
+	if r.Limit == 0 {
+		r.Limit = DefaultHistoricalPreviewLimit
+	}
+	if r.Limit < 0 || r.Limit > MaxHistoricalPreviewLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", MaxHistoricalPreviewLimit)
+	}
+	if r.End == 0 {
+		r.End = uint64(now.UnixNano())
+	}
+	if r.Start == 0 {
+		r.Start = r.End - uint64(defaultHistoricalPreviewWindow.Nanoseconds())
+	}
+	if r.Start >= r.End {
+		return nil, fmt.Errorf("start must be before end")
+	}
+
+	return &model.LogsFilterParams{
+		Limit:          r.Limit,
+		OrderBy:        "timestamp",
+		Order:          "desc",
+		Query:          r.Query,
+		TimestampStart: r.Start,
+		TimestampEnd:   r.End,
+	}, nil
+}
+
+func nonNilStrMap(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
+
+// logModified compares the fields which can be changed by the pipeline
+// processors, the trace context is not compared as it is not parsed back
+func logModified(input model.SignozLog, output model.SignozLog) bool {
+	if input.Timestamp != output.Timestamp ||
+		input.Body != output.Body ||
+		input.SeverityText != output.SeverityText ||
+		input.SeverityNumber != output.SeverityNumber {
+		return true
+	}
+	if !reflect.DeepEqual(nonNilStrMap(input.Attributes_string), nonNilStrMap(output.Attributes_string)) ||
+		!reflect.DeepEqual(nonNilStrMap(input.Resources_string), nonNilStrMap(output.Resources_string)) {
+		return true
+	}
+	if len(input.Attributes_int64) != len(output.Attributes_int64) ||
+		len(input.Attributes_float64) != len(output.Attributes_float64) {
+		return true
+	}
+	for k, v := range input.Attributes_int64 {
+		if out, ok := output.Attributes_int64[k]; !ok || out != v {
+			return true
+		}
+	}
+	for k, v := range input.Attributes_float64 {
+		if out, ok := output.Attributes_float64[k]; !ok || out != v {
+			return true
+		}
+	}
+	return false
+}
+
+// PreviewHistoricalLogs processes the stored logs with the pipelines and
+// pairs every log with its output
+func PreviewHistoricalLogs(
+	ctx context.Context,
+	pipelines []Pipeline,
+	logs []model.SignozLog,
+) (*HistoricalPreviewResponse, *model.ApiError) {
+	// the logs are identified by their index during the simulation, which
+	// also adds a temporary attribute to the input logs
+	input := make([]model.SignozLog, len(logs))
+	for i, log := range logs {
+		input[i] = log
+		input[i].ID = strconv.Itoa(i)
+		input[i].Attributes_int64 = map[string]int64{}
+		for k, v := range log.Attributes_int64 {
+			input[i].Attributes_int64[k] = v
+		}
+	}
+
+	output, collectorLogs, apiErr := SimulatePipelinesProcessing(ctx, pipelines, input)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	outputByIdx := map[int]*model.SignozLog{}
+	for i := range output {
+		idx, err := strconv.Atoi(output[i].ID)
+		if err != nil || idx < 0 || idx >= len(logs) {
+			continue
+		}
+		output[i].ID = logs[idx].ID
+		outputByIdx[idx] = &output[i]
+	}
+	return previewResponse(logs, outputByIdx, collectorLogs), nil
+}
+
+func previewResponse(logs []model.SignozLog, outputByIdx map[int]*model.SignozLog, collectorLogs []string) *HistoricalPreviewResponse {
+	response := &HistoricalPreviewResponse{
+		Logs:          make([]PreviewedLog, 0, len(logs)),
+		CollectorLogs: collectorLogs,
+	}
+	if response.CollectorLogs == nil {
+		response.CollectorLogs = []string{}
+	}
+
+	stats := &response.Stats
+	stats.Logs = len(logs)
+	for i, log := range logs {
+		previewed := PreviewedLog{Input: log, Output: outputByIdx[i]}
+		switch {
+		case previewed.Output == nil:
+			stats.Dropped++
+		case logModified(log, *previewed.Output):
+			previewed.Modified = true
+			stats.Modified++
+		default:
+			stats.Unmodified++
+		}
+		response.Logs = append(response.Logs, previewed)
+	}
+
+	// the processors log an error for every log they failed to process
+	stats.Errors = len(collectorLogs)
+	if stats.Errors > stats.Logs {
+		stats.Errors = stats.Logs
+	}
+	if stats.Logs > 0 {
+		stats.ErrorRate = float64(stats.Errors) / float64(stats.Logs)
+	}
+	return response
+}
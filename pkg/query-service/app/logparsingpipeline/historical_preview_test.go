@@ -0,0 +1,101 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestPreviewHistoricalLogs(t *testing.T) {
+	require := require.New(t)
+
+	testPipelines := []Pipeline{
+		{
+			OrderId: 1,
+			Name:    "pipeline1",
+			Alias:   "pipeline1",
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{
+					{
+						Key: v3.AttributeKey{
+							Key:      "method",
+							DataType: v3.AttributeKeyDataTypeString,
+							Type:     v3.AttributeKeyTypeTag,
+						},
+						Operator: "=",
+						Value:    "GET",
+					},
+				},
+			},
+			Config: []PipelineOperator{
+				{
+					OrderId: 1,
+					ID:      "add",
+					Type:    "add",
+					Field:   "attributes.test",
+					Value:   "val",
+					Enabled: true,
+					Name:    "test add",
+				},
+			},
+		},
+	}
+
+	matchingLog := makeTestSignozLog("test log body", map[string]interface{}{"method": "GET"})
+	nonMatchingLog := makeTestSignozLog("test log body", map[string]interface{}{"method": "POST"})
+	logs := []model.SignozLog{matchingLog, nonMatchingLog}
+
+	result, err := PreviewHistoricalLogs(context.Background(), testPipelines, logs)
+	require.Nil(err)
+	require.Equal(2, len(result.Logs))
+	require.True(result.Logs[0].Modified)
+	require.Equal("val", result.Logs[0].Output.Attributes_string["test"])
+	require.False(result.Logs[1].Modified)
+	require.Equal(HistoricalPreviewStats{Logs: 2, Modified: 1, Unmodified: 1}, result.Stats)
+
+	// the stored logs are not changed by the simulation
+	require.Equal(matchingLog, logs[0])
+	require.Empty(logs[0].Attributes_int64)
+}
+
+func TestHistoricalPreviewResponseStats(t *testing.T) {
+	logs := []model.SignozLog{
+		{ID: "1", Body: "a"},
+		{ID: "2", Body: "b"},
+		{ID: "3", Body: "c"},
+	}
+	output := map[int]*model.SignozLog{
+		0: {ID: "1", Body: "a", Attributes_string: map[string]string{}},
+		1: {ID: "2", Body: "b", Attributes_string: map[string]string{"level": "info"}},
+	}
+
+	response := previewResponse(logs, output, []string{"failed to parse"})
+	require.Equal(t, HistoricalPreviewStats{
+		Logs: 3, Modified: 1, Unmodified: 1, Dropped: 1, Errors: 1, ErrorRate: 1.0 / 3,
+	}, response.Stats)
+	require.Nil(t, response.Logs[2].Output)
+}
+
+func TestHistoricalPreviewRequestParams(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	req := HistoricalPreviewRequest{Pipelines: []Pipeline{{Name: "p", Filter: &v3.FilterSet{}}}, Query: "method IN ('GET')"}
+	params, err := req.LogsFilterParams(now)
+	require.Nil(t, err)
+	require.Equal(t, DefaultHistoricalPreviewLimit, params.Limit)
+	require.Equal(t, uint64(now.UnixNano()), params.TimestampEnd)
+	require.Equal(t, uint64(now.Add(-15*time.Minute).UnixNano()), params.TimestampStart)
+	require.Equal(t, "method IN ('GET')", params.Query)
+
+	req.Limit = MaxHistoricalPreviewLimit + 1
+	_, err = req.LogsFilterParams(now)
+	require.NotNil(t, err)
+
+	_, err = (&HistoricalPreviewRequest{}).LogsFilterParams(now)
+	require.NotNil(t, err)
+}
@@ -70,6 +70,10 @@ type PipelineOperator struct {
 	// severity parser fields
 	SeverityMapping       map[string][]string `json:"mapping,omitempty" yaml:"mapping,omitempty"`
 	OverwriteSeverityText bool                `json:"overwrite_text,omitempty" yaml:"overwrite_text,omitempty"`
+
+	// grok parser fields, the custom patterns are expanded into the
+	// pattern as the collector only knows the default patterns
+	CustomPatterns map[string]string `json:"custom_patterns,omitempty" yaml:"-"`
 }
 
 type TimestampParser struct {
@@ -116,6 +116,14 @@ func getOperators(ops []PipelineOperator) ([]PipelineOperator, error) {
 				}
 				operator.If = parseFromNotNilCheck
 
+				pattern, err := expandGrokPattern(operator.Pattern, operator.CustomPatterns)
+				if err != nil {
+					return nil, fmt.Errorf(
+						"couldn't expand pattern of grok op %s: %w", operator.Name, err,
+					)
+				}
+				operator.Pattern = pattern
+
 			} else if operator.Type == "json_parser" {
 				parseFromNotNilCheck, err := fieldNotNilCheck(operator.ParseFrom)
 				if err != nil {
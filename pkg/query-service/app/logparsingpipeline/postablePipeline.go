@@ -19,9 +19,10 @@ type PostablePipelines struct {
 // PostablePipeline captures user inputs in setting the pipeline
 
 type PostablePipeline struct {
-	Id          string             `json:"id"`
-	OrderId     int                `json:"orderId"`
-	Name        string             `json:"name"`
+	Id      string `json:"id"`
+	OrderId int    `json:"orderId"`
+	Name    string `json:"name"`
+	// Alias is the stable id of the pipeline, the id changes with each version
 	Alias       string             `json:"alias"`
 	Description string             `json:"description"`
 	Enabled     bool               `json:"enabled"`
@@ -105,6 +106,9 @@ func isValidOperator(op PipelineOperator) error {
 		if op.Pattern == "" {
 			return fmt.Errorf(fmt.Sprintf("pattern of %s grok operator cannot be empty", op.ID))
 		}
+		if err := validateGrokPattern(op.Pattern, op.CustomPatterns); err != nil {
+			return fmt.Errorf("invalid pattern of %s grok operator: %w", op.ID, err)
+		}
 	case "regex_parser":
 		if op.Regex == "" {
 			return fmt.Errorf(fmt.Sprintf("regex of %s regex operator cannot be empty", op.ID))
@@ -231,6 +231,27 @@ var operatorTest = []struct {
 		},
 		IsValid: false,
 	},
+	{
+		Name: "Grok - custom patterns",
+		Operator: PipelineOperator{
+			ID:             "grok",
+			Type:           "grok_parser",
+			Pattern:        "%{ORDER_ID:order_id} %{GREEDYDATA:message}",
+			CustomPatterns: map[string]string{"ORDER_ID": "ORD-[0-9]+"},
+			ParseTo:        "attributes",
+		},
+		IsValid: true,
+	},
+	{
+		Name: "Grok - unknown pattern",
+		Operator: PipelineOperator{
+			ID:      "grok",
+			Type:    "grok_parser",
+			Pattern: "%{ORDER_ID:order_id} %{GREEDYDATA:message}",
+			ParseTo: "attributes",
+		},
+		IsValid: false,
+	},
 	{
 		Name: "Regex - valid",
 		Operator: PipelineOperator{
@@ -0,0 +1,153 @@
+package logpatterns
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Wildcard replaces the variable tokens of a template
+	Wildcard = "<*>"
+
+	defaultTreeDepth       = 4
+	defaultSimThreshold    = 0.4
+	defaultMaxNodeChildren = 100
+)
+
+// variableToken matches the tokens which are always treated as variables: numbers,
+// durations, sizes, dates, hex values, hashes and uuids
+var variableToken = regexp.MustCompile(`(?i)^(?:[-+]?\d[\d.,:/_\-+tz]*(?:ns|us|µs|ms|s|m|h|d|%|b|kb|mb|gb)?|0x[0-9a-f]+|[0-9a-f]{16,}|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})$`)
+
+// maskToken replaces the variable tokens, and the variable values of key=value tokens
+func maskToken(token string) string {
+	if variableToken.MatchString(token) {
+		return Wildcard
+	}
+	if key, value, ok := strings.Cut(token, "="); ok && key != "" && variableToken.MatchString(strings.Trim(value, `"',;`)) {
+		return key + "=" + Wildcard
+	}
+	return token
+}
+
+func tokenize(body string) []string {
+	tokens := strings.Fields(body)
+	for i, token := range tokens {
+		tokens[i] = maskToken(token)
+	}
+	return tokens
+}
+
+func hasDigit(token string) bool {
+	return strings.ContainsAny(token, "0123456789")
+}
+
+type cluster struct {
+	id       int
+	template []string
+}
+
+func (c *cluster) String() string {
+	return strings.Join(c.template, " ")
+}
+
+// similarity is the share of the tokens equal to the template, the wildcards
+// of the template are not counted so that constant tokens are preferred
+func (c *cluster) similarity(tokens []string) float64 {
+	if len(tokens) == 0 {
+		return 1
+	}
+	matching := 0
+	for i, token := range tokens {
+		if c.template[i] == token && token != Wildcard {
+			matching++
+		}
+	}
+	return float64(matching) / float64(len(tokens))
+}
+
+// merge replaces the tokens of the template which differ from the log by wildcards
+func (c *cluster) merge(tokens []string) {
+	for i, token := range tokens {
+		if c.template[i] != token {
+			c.template[i] = Wildcard
+		}
+	}
+}
+
+type node struct {
+	children map[string]*node
+	clusters []*cluster
+}
+
+func newNode() *node {
+	return &node{children: map[string]*node{}}
+}
+
+// drain clusters the logs into templates with the fixed depth parse tree of
+// the Drain algorithm, the logs are first split by their number of tokens and
+// then by their leading tokens, the leaves hold the candidate clusters
+type drain struct {
+	root            *node
+	depth           int
+	simThreshold    float64
+	maxNodeChildren int
+	clusters        []*cluster
+}
+
+func newDrain() *drain {
+	return &drain{
+		root:            newNode(),
+		depth:           defaultTreeDepth,
+		simThreshold:    defaultSimThreshold,
+		maxNodeChildren: defaultMaxNodeChildren,
+	}
+}
+
+// add returns the cluster of the log, the cluster is created when no template is similar enough
+func (d *drain) add(body string) *cluster {
+	tokens := tokenize(body)
+	leaf := d.leaf(tokens)
+
+	var best *cluster
+	bestSim := -1.0
+	for _, c := range leaf.clusters {
+		if sim := c.similarity(tokens); sim > bestSim {
+			best, bestSim = c, sim
+		}
+	}
+	if best != nil && bestSim >= d.simThreshold {
+		best.merge(tokens)
+		return best
+	}
+
+	c := &cluster{id: len(d.clusters), template: tokens}
+	leaf.clusters = append(leaf.clusters, c)
+	d.clusters = append(d.clusters, c)
+	return c
+}
+
+func (d *drain) leaf(tokens []string) *node {
+	current := d.child(d.root, strconv.Itoa(len(tokens)))
+	// the root, the length and the leaf layers are part of the depth
+	for i := 0; i < d.depth-3 && i < len(tokens); i++ {
+		key := tokens[i]
+		if hasDigit(key) {
+			key = Wildcard
+		}
+		if _, ok := current.children[key]; !ok && len(current.children) >= d.maxNodeChildren {
+			key = Wildcard
+		}
+		current = d.child(current, key)
+	}
+	return current
+}
+
+func (d *drain) child(parent *node, key string) *node {
+	child, ok := parent.children[key]
+	if !ok {
+		child = newNode()
+		parent.children[key] = child
+	}
+	return child
+}
@@ -0,0 +1,111 @@
+package logpatterns
+
+import (
+	"sort"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+type TrendPoint struct {
+	Timestamp int64 `json:"timestamp"`
+	Count     int   `json:"count"`
+}
+
+// Pattern is a template of the logs, the counts are the number of logs of the
+// sample matching the template, the trend is bucketed by the step of the request
+type Pattern struct {
+	Template      string       `json:"template"`
+	Count         int          `json:"count"`
+	Percentage    float64      `json:"percentage"`
+	BaselineCount int          `json:"baselineCount"`
+	New           bool         `json:"new"`
+	Example       string       `json:"example"`
+	FirstSeen     uint64       `json:"firstSeen"`
+	LastSeen      uint64       `json:"lastSeen"`
+	Trend         []TrendPoint `json:"trend"`
+}
+
+// Response holds the top patterns by count, and the patterns which did not
+// occur in the previous window when the comparison is requested
+type Response struct {
+	Patterns     []Pattern `json:"patterns"`
+	NewPatterns  []Pattern `json:"newPatterns"`
+	SampledLogs  int       `json:"sampledLogs"`
+	BaselineLogs int       `json:"baselineLogs"`
+}
+
+// Mine clusters the logs into patterns, the baseline logs are from the window
+// preceding the time range and are only used to find the new patterns
+func Mine(params *model.LogPatternsParams, logs []model.LogSample, baseline []model.LogSample) *Response {
+	d := newDrain()
+
+	baselineCounts := map[int]int{}
+	for _, log := range baseline {
+		baselineCounts[d.add(log.Body).id]++
+	}
+
+	stepNano := uint64(params.StepSeconds) * 1000000000
+	buckets := int((params.TimestampEnd-params.TimestampStart)/stepNano) + 1
+
+	patterns := map[int]*Pattern{}
+	for _, log := range logs {
+		c := d.add(log.Body)
+		p, ok := patterns[c.id]
+		if !ok {
+			p = &Pattern{
+				Example:   log.Body,
+				FirstSeen: log.Timestamp,
+				LastSeen:  log.Timestamp,
+				Trend:     make([]TrendPoint, buckets),
+			}
+			for i := range p.Trend {
+				p.Trend[i].Timestamp = int64(params.TimestampStart + uint64(i)*stepNano)
+			}
+			patterns[c.id] = p
+		}
+
+		p.Count++
+		if log.Timestamp < p.FirstSeen {
+			p.FirstSeen = log.Timestamp
+		}
+		if log.Timestamp > p.LastSeen {
+			p.LastSeen = log.Timestamp
+		}
+		if log.Timestamp >= params.TimestampStart && log.Timestamp <= params.TimestampEnd {
+			p.Trend[(log.Timestamp-params.TimestampStart)/stepNano].Count++
+		}
+	}
+
+	// the templates are only final once all the logs are added
+	all := make([]Pattern, 0, len(patterns))
+	for _, c := range d.clusters {
+		p, ok := patterns[c.id]
+		if !ok {
+			continue
+		}
+		p.Template = c.String()
+		p.Percentage = float64(p.Count) * 100 / float64(len(logs))
+		p.BaselineCount = baselineCounts[c.id]
+		p.New = params.Compare && p.BaselineCount == 0
+		all = append(all, *p)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Count > all[j].Count
+	})
+
+	response := &Response{
+		Patterns:     []Pattern{},
+		NewPatterns:  []Pattern{},
+		SampledLogs:  len(logs),
+		BaselineLogs: len(baseline),
+	}
+	for _, p := range all {
+		if len(response.Patterns) < params.Limit {
+			response.Patterns = append(response.Patterns, p)
+		}
+		if p.New && len(response.NewPatterns) < params.Limit {
+			response.NewPatterns = append(response.NewPatterns, p)
+		}
+	}
+	return response
+}
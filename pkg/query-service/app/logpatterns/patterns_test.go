@@ -0,0 +1,88 @@
+package logpatterns
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestMaskToken(t *testing.T) {
+	cases := map[string]string{
+		"42":                                   Wildcard,
+		"12.5ms":                               Wildcard,
+		"2024-01-02T10:00:00":                  Wildcard,
+		"0xdeadbeef":                           Wildcard,
+		"3fa85f64-5717-4562-b3fc-2c963f66afa6": Wildcard,
+		"user_id=42":                           "user_id=" + Wildcard,
+		"status=ok":                            "status=ok",
+		"payment":                              "payment",
+	}
+	for token, expected := range cases {
+		require.Equal(t, expected, maskToken(token), token)
+	}
+}
+
+func TestDrainClustering(t *testing.T) {
+	d := newDrain()
+	a := d.add("user alice logged in from 10.0.0.1")
+	b := d.add("user bob logged in from 10.0.0.2")
+	c := d.add("payment failed for order 1234")
+	e := d.add("payment failed for order 5678")
+	f := d.add("connection reset by peer")
+
+	require.Equal(t, a, b)
+	require.Equal(t, c, e)
+	require.NotEqual(t, a, c)
+	require.NotEqual(t, c, f)
+	require.Equal(t, "user <*> logged in from <*>", a.String())
+	require.Equal(t, "payment failed for order <*>", c.String())
+	require.Equal(t, 3, len(d.clusters))
+}
+
+func TestMine(t *testing.T) {
+	start := uint64(1700000000000000000)
+	params := &model.LogPatternsParams{
+		TimestampStart: start,
+		TimestampEnd:   start + 120*1000000000,
+		StepSeconds:    60,
+		Limit:          10,
+		Compare:        true,
+	}
+
+	logs := []model.LogSample{}
+	for i := 0; i < 6; i++ {
+		logs = append(logs, model.LogSample{
+			Timestamp: start + uint64(i)*10*1000000000,
+			Body:      fmt.Sprintf("request %d served in %dms", i, i*3),
+		})
+	}
+	logs = append(logs, model.LogSample{
+		Timestamp: start + 90*1000000000,
+		Body:      "database connection pool exhausted",
+	})
+	baseline := []model.LogSample{
+		{Timestamp: start - 1000000000, Body: "request 99 served in 12ms"},
+	}
+
+	response := Mine(params, logs, baseline)
+
+	require.Equal(t, 7, response.SampledLogs)
+	require.Equal(t, 1, response.BaselineLogs)
+	require.Equal(t, 2, len(response.Patterns))
+
+	top := response.Patterns[0]
+	require.Equal(t, "request <*> served in <*>", top.Template)
+	require.Equal(t, 6, top.Count)
+	require.Equal(t, 1, top.BaselineCount)
+	require.False(t, top.New)
+	require.Equal(t, start, top.FirstSeen)
+	require.Equal(t, 3, len(top.Trend))
+	require.Equal(t, 6, top.Trend[0].Count)
+	require.Equal(t, int64(start+60*1000000000), top.Trend[1].Timestamp)
+
+	require.Equal(t, 1, len(response.NewPatterns))
+	require.Equal(t, "database connection pool exhausted", response.NewPatterns[0].Template)
+	require.Equal(t, 1, response.NewPatterns[0].Trend[1].Count)
+}
@@ -0,0 +1,509 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	QueryMatchAll    = "match_all"
+	QueryMatch       = "match"
+	QueryMatchPhrase = "match_phrase"
+	QueryTerm        = "term"
+	QueryTerms       = "terms"
+	QueryRange       = "range"
+	QueryExists      = "exists"
+	QueryBool        = "bool"
+
+	OperatorOr  = "or"
+	OperatorAnd = "and"
+
+	// MaxResultWindow is the largest from + size of a search, as the default of Elasticsearch
+	MaxResultWindow = 10000
+	// MaxRequestSize is the largest search request accepted
+	MaxRequestSize = 1 << 20
+
+	defaultSize            = 10
+	defaultAggregationSize = 10
+)
+
+// SearchRequest is a search request of the supported subset of the query DSL: the match_all,
+// match, match_phrase, term, terms, range, exists and bool queries and the terms aggregations
+type SearchRequest struct {
+	Query *Query
+	From  int
+	Size  int
+	// Ascending sorts the hits from the oldest, the newest hits are first by default
+	Ascending    bool
+	Aggregations map[string]TermsAggregation
+	// Resources restricts the search to the logs of some resources, e.g. of the org of the user
+	Resources *ResourceFilter
+}
+
+// ResourceFilter selects the logs whose resource attribute is one of the values, or none of
+// them with Exclude
+type ResourceFilter struct {
+	Key     string
+	Values  []string
+	Exclude bool
+}
+
+// Query is a query of the DSL, the fields of the kind of the query are set
+type Query struct {
+	Kind  string
+	Field string
+	// Values are the values of the match, match_phrase, term and terms queries, strings,
+	// json.Number or bool
+	Values []interface{}
+	// Operator is the operator between the words of a match query
+	Operator string
+	// Bounds are the bounds of a range query by operator (gt, gte, lt or lte), the bounds
+	// of the timestamp are in nanoseconds
+	Bounds map[string]interface{}
+	// Must, Filter, Should and MustNot are the clauses of a bool query, MinimumShouldMatch is
+	// the number of should clauses the logs must match
+	Must               []*Query
+	Filter             []*Query
+	Should             []*Query
+	MustNot            []*Query
+	MinimumShouldMatch int
+}
+
+type TermsAggregation struct {
+	Field string
+	Size  int
+}
+
+var dateMath = regexp.MustCompile(`^now((?:[+-]\d+[yMwdhHms])*)(?:/([yMwdhHms]))?$`)
+var dateMathOp = regexp.MustCompile(`([+-])(\d+)([yMwdhHms])`)
+
+type rawSearchRequest struct {
+	Query        json.RawMessage            `json:"query"`
+	From         int                        `json:"from"`
+	Size         *int                       `json:"size"`
+	Sort         json.RawMessage            `json:"sort"`
+	Aggs         map[string]json.RawMessage `json:"aggs"`
+	Aggregations map[string]json.RawMessage `json:"aggregations"`
+}
+
+// ParseSearchRequest parses the body of a search request, the relative dates of the range
+// queries are relative to now
+func ParseSearchRequest(body []byte, now time.Time) (*SearchRequest, error) {
+	raw := rawSearchRequest{}
+	if len(bytes.TrimSpace(body)) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("invalid search request: %v", err)
+		}
+	}
+
+	req := &SearchRequest{From: raw.From, Size: defaultSize, Aggregations: map[string]TermsAggregation{}}
+	if raw.Size != nil {
+		req.Size = *raw.Size
+	}
+	if req.From < 0 || req.Size < 0 {
+		return nil, fmt.Errorf("from and size can not be negative")
+	}
+	if req.From+req.Size > MaxResultWindow {
+		return nil, fmt.Errorf("from + size can not be more than %d", MaxResultWindow)
+	}
+
+	req.Query = &Query{Kind: QueryMatchAll}
+	if len(raw.Query) > 0 {
+		q, err := parseQuery(raw.Query, now)
+		if err != nil {
+			return nil, err
+		}
+		req.Query = q
+	}
+
+	ascending, err := parseSort(raw.Sort)
+	if err != nil {
+		return nil, err
+	}
+	req.Ascending = ascending
+
+	for _, aggs := range []map[string]json.RawMessage{raw.Aggs, raw.Aggregations} {
+		for name, agg := range aggs {
+			terms, err := parseAggregation(agg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid aggregation %s: %v", name, err)
+			}
+			req.Aggregations[name] = terms
+		}
+	}
+	return req, nil
+}
+
+// single returns the only key of the object and its value
+func single(raw json.RawMessage, what string) (string, json.RawMessage, error) {
+	object := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &object); err != nil || len(object) != 1 {
+		return "", nil, fmt.Errorf("%s must be an object with a single key", what)
+	}
+	for k, v := range object {
+		return k, v, nil
+	}
+	return "", nil, nil
+}
+
+func decodeValue(raw json.RawMessage) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	switch value.(type) {
+	case string, json.Number, bool:
+		return value, nil
+	default:
+		return nil, fmt.Errorf("the values must be strings, numbers or booleans")
+	}
+}
+
+func parseQuery(raw json.RawMessage, now time.Time) (*Query, error) {
+	kind, body, err := single(raw, "a query")
+	if err != nil {
+		return nil, err
+	}
+	q := &Query{Kind: kind}
+	switch kind {
+	case QueryMatchAll:
+		return q, nil
+	case QueryBool:
+		return parseBool(body, now)
+	case QueryExists:
+		var exists struct {
+			Field string `json:"field"`
+		}
+		if err := json.Unmarshal(body, &exists); err != nil || exists.Field == "" {
+			return nil, fmt.Errorf("the exists query must have a field")
+		}
+		q.Field = exists.Field
+		return q, nil
+	case QueryMatch, QueryMatchPhrase, QueryTerm, QueryTerms, QueryRange:
+	default:
+		return nil, fmt.Errorf("unsupported query %s", kind)
+	}
+
+	field, params, err := single(body, "the "+kind+" query")
+	if err != nil {
+		return nil, err
+	}
+	q.Field = field
+	switch kind {
+	case QueryTerms:
+		var values []json.RawMessage
+		if err := json.Unmarshal(params, &values); err != nil || len(values) == 0 {
+			return nil, fmt.Errorf("the terms query must have a list of values")
+		}
+		for _, v := range values {
+			value, err := decodeValue(v)
+			if err != nil {
+				return nil, err
+			}
+			q.Values = append(q.Values, value)
+		}
+	case QueryRange:
+		if err := q.parseBounds(params, now); err != nil {
+			return nil, err
+		}
+	default:
+		// the value is the query of the match queries and the value of the term query,
+		// when the params are an object
+		value, err := decodeValue(params)
+		if err != nil {
+			var object map[string]json.RawMessage
+			if json.Unmarshal(params, &object) != nil {
+				return nil, err
+			}
+			key := "query"
+			if kind == QueryTerm {
+				key = "value"
+			}
+			if value, err = decodeValue(object[key]); err != nil {
+				return nil, fmt.Errorf("the %s query must have a %s", kind, key)
+			}
+			if operator, ok := object["operator"]; ok && kind == QueryMatch {
+				if err := json.Unmarshal(operator, &q.Operator); err != nil {
+					return nil, fmt.Errorf("invalid operator")
+				}
+				q.Operator = strings.ToLower(q.Operator)
+			}
+		}
+		q.Values = []interface{}{value}
+	}
+	// the values of the timestamp are dates, in nanoseconds
+	if isTimestamp(field) && kind != QueryRange && kind != QueryMatchPhrase {
+		for i, value := range q.Values {
+			date, err := parseDate(value, "", now)
+			if err != nil {
+				return nil, err
+			}
+			q.Values[i] = date.UnixNano()
+		}
+	}
+	if kind == QueryMatch {
+		if q.Operator == "" {
+			q.Operator = OperatorOr
+		}
+		if q.Operator != OperatorOr && q.Operator != OperatorAnd {
+			return nil, fmt.Errorf("invalid operator %s", q.Operator)
+		}
+	}
+	return q, nil
+}
+
+// parseBounds parses the bounds of a range query, the dates of the timestamp are converted
+// to nanoseconds
+func (q *Query) parseBounds(raw json.RawMessage, now time.Time) error {
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return fmt.Errorf("the range query must be an object")
+	}
+	format := ""
+	if f, ok := params["format"]; ok {
+		if err := json.Unmarshal(f, &format); err != nil {
+			return fmt.Errorf("invalid format")
+		}
+	}
+
+	q.Bounds = map[string]interface{}{}
+	for op, v := range params {
+		switch op {
+		case "format", "time_zone":
+			continue
+		case "gt", "gte", "lt", "lte":
+		case "from", "to":
+			return fmt.Errorf("the from and to bounds are not supported, use gte and lte")
+		default:
+			return fmt.Errorf("unsupported range param %s", op)
+		}
+		value, err := decodeValue(v)
+		if err != nil {
+			return err
+		}
+		if isTimestamp(q.Field) {
+			date, err := parseDate(value, format, now)
+			if err != nil {
+				return err
+			}
+			value = date.UnixNano()
+		}
+		q.Bounds[op] = value
+	}
+	if len(q.Bounds) == 0 {
+		return fmt.Errorf("the range query must have a bound")
+	}
+	return nil
+}
+
+// parseDate parses a date of the timestamp, in milliseconds since the epoch, unless the format
+// is epoch_second, an ISO 8601 date or relative to now, e.g. now-15m or now/d
+func parseDate(value interface{}, format string, now time.Time) (time.Time, error) {
+	epoch := ""
+	switch v := value.(type) {
+	case json.Number:
+		epoch = v.String()
+	case string:
+		if format == "epoch_millis" || format == "epoch_second" {
+			epoch = v
+			break
+		}
+		if m := dateMath.FindStringSubmatch(v); m != nil {
+			return evaluateDateMath(now, m[1], m[2]), nil
+		}
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid date %q", v)
+	default:
+		return time.Time{}, fmt.Errorf("invalid date %v", value)
+	}
+
+	n, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", epoch)
+	}
+	if format == "epoch_second" {
+		return time.Unix(n, 0), nil
+	}
+	return time.UnixMilli(n), nil
+}
+
+func evaluateDateMath(now time.Time, ops string, round string) time.Time {
+	t := now.UTC()
+	for _, m := range dateMathOp.FindAllStringSubmatch(ops, -1) {
+		n, _ := strconv.Atoi(m[2])
+		if m[1] == "-" {
+			n = -n
+		}
+		switch m[3] {
+		case "y":
+			t = t.AddDate(n, 0, 0)
+		case "M":
+			t = t.AddDate(0, n, 0)
+		case "w":
+			t = t.AddDate(0, 0, 7*n)
+		case "d":
+			t = t.AddDate(0, 0, n)
+		case "h", "H":
+			t = t.Add(time.Duration(n) * time.Hour)
+		case "m":
+			t = t.Add(time.Duration(n) * time.Minute)
+		case "s":
+			t = t.Add(time.Duration(n) * time.Second)
+		}
+	}
+	switch round {
+	case "y":
+		t = time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	case "M":
+		t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case "w":
+		t = time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case "d":
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case "h", "H":
+		t = t.Truncate(time.Hour)
+	case "m":
+		t = t.Truncate(time.Minute)
+	case "s":
+		t = t.Truncate(time.Second)
+	}
+	return t
+}
+
+func parseBool(raw json.RawMessage, now time.Time) (*Query, error) {
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("the bool query must be an object")
+	}
+	q := &Query{Kind: QueryBool}
+	for key, value := range params {
+		var clauses *[]*Query
+		switch key {
+		case "must":
+			clauses = &q.Must
+		case "filter":
+			clauses = &q.Filter
+		case "should":
+			clauses = &q.Should
+		case "must_not":
+			clauses = &q.MustNot
+		case "minimum_should_match":
+			if err := json.Unmarshal(value, &q.MinimumShouldMatch); err != nil || q.MinimumShouldMatch < 0 {
+				return nil, fmt.Errorf("minimum_should_match must be a positive number")
+			}
+			continue
+		case "boost", "_name":
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported bool param %s", key)
+		}
+
+		// the clauses are a query or a list of queries
+		var list []json.RawMessage
+		if err := json.Unmarshal(value, &list); err != nil {
+			list = []json.RawMessage{value}
+		}
+		for _, clause := range list {
+			parsed, err := parseQuery(clause, now)
+			if err != nil {
+				return nil, err
+			}
+			*clauses = append(*clauses, parsed)
+		}
+	}
+	// the logs must match a should clause when the query has no must or filter clause
+	if _, ok := params["minimum_should_match"]; !ok && len(q.Should) > 0 && len(q.Must) == 0 && len(q.Filter) == 0 {
+		q.MinimumShouldMatch = 1
+	}
+	if q.MinimumShouldMatch > len(q.Should) {
+		return nil, fmt.Errorf("minimum_should_match can not be more than the number of should clauses")
+	}
+	return q, nil
+}
+
+// parseSort parses the sort of the search, only the sort by timestamp is supported
+func parseSort(raw json.RawMessage) (bool, error) {
+	if len(raw) == 0 {
+		return false, nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		list = []json.RawMessage{raw}
+	}
+	ascending := false
+	for _, item := range list {
+		var field string
+		order := "desc"
+		if err := json.Unmarshal(item, &field); err != nil {
+			var params json.RawMessage
+			if field, params, err = single(item, "a sort"); err != nil {
+				return false, err
+			}
+			// the order is the value or the order param
+			if err := json.Unmarshal(params, &order); err != nil {
+				var object struct {
+					Order string `json:"order"`
+				}
+				if err := json.Unmarshal(params, &object); err != nil {
+					return false, fmt.Errorf("invalid sort of %s", field)
+				}
+				order = object.Order
+			}
+		}
+		if field == "_score" || field == "_doc" {
+			continue
+		}
+		if !isTimestamp(field) {
+			return false, fmt.Errorf("unsupported sort by %s, only the sort by @timestamp is supported", field)
+		}
+		switch order {
+		case "asc":
+			ascending = true
+		case "desc", "":
+			ascending = false
+		default:
+			return false, fmt.Errorf("invalid sort order %s", order)
+		}
+	}
+	return ascending, nil
+}
+
+func parseAggregation(raw json.RawMessage) (TermsAggregation, error) {
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return TermsAggregation{}, fmt.Errorf("an aggregation must be an object")
+	}
+	for key := range params {
+		if key != "terms" {
+			return TermsAggregation{}, fmt.Errorf("unsupported %s, only the terms aggregations are supported", key)
+		}
+	}
+	terms := struct {
+		Field string `json:"field"`
+		Size  *int   `json:"size"`
+	}{}
+	if err := json.Unmarshal(params["terms"], &terms); err != nil || terms.Field == "" {
+		return TermsAggregation{}, fmt.Errorf("the terms aggregation must have a field")
+	}
+	agg := TermsAggregation{Field: terms.Field, Size: defaultAggregationSize}
+	if terms.Size != nil {
+		agg.Size = *terms.Size
+	}
+	if agg.Size <= 0 || agg.Size > MaxResultWindow {
+		return TermsAggregation{}, fmt.Errorf("the size must be between 1 and %d", MaxResultWindow)
+	}
+	return agg, nil
+}
@@ -0,0 +1,98 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearchRequest(t *testing.T) {
+	now := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+	req, err := ParseSearchRequest([]byte(`{
+		"size": 50, "from": 10,
+		"query": {"bool": {
+			"must": {"match": {"message": {"query": "connection refused", "operator": "AND"}}},
+			"filter": [
+				{"range": {"@timestamp": {"gte": "now-15m", "lt": "now/h"}}},
+				{"terms": {"service.name": ["api", "web"]}}
+			],
+			"should": [{"term": {"status": 500}}, {"match_phrase": {"message": "timed out"}}],
+			"must_not": {"exists": {"field": "retried"}}
+		}},
+		"sort": [{"@timestamp": {"order": "asc"}}],
+		"aggs": {"services": {"terms": {"field": "service.name", "size": 5}}}
+	}`), now)
+	require.NoError(t, err)
+
+	assert.Equal(t, 10, req.From)
+	assert.Equal(t, 50, req.Size)
+	assert.True(t, req.Ascending)
+	assert.Equal(t, map[string]TermsAggregation{"services": {Field: "service.name", Size: 5}}, req.Aggregations)
+
+	q := req.Query
+	assert.Equal(t, QueryBool, q.Kind)
+	// the should clauses are optional with a must clause
+	assert.Equal(t, 0, q.MinimumShouldMatch)
+	require.Len(t, q.Must, 1)
+	assert.Equal(t, &Query{Kind: QueryMatch, Field: "message", Values: []interface{}{"connection refused"}, Operator: OperatorAnd}, q.Must[0])
+	require.Len(t, q.Filter, 2)
+	assert.Equal(t, map[string]interface{}{"gte": now.Add(-15 * time.Minute).UnixNano(), "lt": now.Truncate(time.Hour).UnixNano()}, q.Filter[0].Bounds)
+	assert.Equal(t, []interface{}{"api", "web"}, q.Filter[1].Values)
+	require.Len(t, q.Should, 2)
+	assert.Equal(t, []interface{}{json.Number("500")}, q.Should[0].Values)
+	assert.Equal(t, &Query{Kind: QueryExists, Field: "retried"}, q.MustNot[0])
+}
+
+func TestParseSearchRequestDefaults(t *testing.T) {
+	req, err := ParseSearchRequest(nil, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, &SearchRequest{Query: &Query{Kind: QueryMatchAll}, Size: 10, Aggregations: map[string]TermsAggregation{}}, req)
+
+	// a bool query with only should clauses must match one of them
+	req, err = ParseSearchRequest([]byte(`{"query": {"bool": {"should": [{"term": {"level": "error"}}, {"term": {"level": "warn"}}]}}}`), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, req.Query.MinimumShouldMatch)
+}
+
+func TestParseSearchRequestErrors(t *testing.T) {
+	for _, body := range []string{
+		`{"query": {"query_string": {"query": "error"}}}`,
+		`{"query": {"match": {"message": "a", "level": "b"}}}`,
+		`{"query": {"match": {"message": {"query": "a", "operator": "xor"}}}}`,
+		`{"query": {"range": {"@timestamp": {"gte": "yesterday"}}}}`,
+		`{"query": {"range": {"duration": {}}}}`,
+		`{"query": {"terms": {"level": []}}}`,
+		`{"query": {"bool": {"should": {"match_all": {}}, "minimum_should_match": 2}}}`,
+		`{"size": 10000, "from": 1}`,
+		`{"sort": [{"level": "asc"}]}`,
+		`{"aggs": {"avg_duration": {"avg": {"field": "duration"}}}}`,
+		`{"aggs": {"levels": {"terms": {"field": "level"}, "aggs": {"services": {"terms": {"field": "service.name"}}}}}}`,
+	} {
+		_, err := ParseSearchRequest([]byte(body), time.Now())
+		assert.Error(t, err, body)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2023, 11, 15, 10, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		value    interface{}
+		format   string
+		expected time.Time
+	}{
+		{json.Number("1700000000000"), "", time.UnixMilli(1700000000000)},
+		{"1700000000", "epoch_second", time.Unix(1700000000, 0)},
+		{"2023-11-14T22:13:20.5Z", "", time.Date(2023, 11, 14, 22, 13, 20, 500000000, time.UTC)},
+		{"2023-11-14", "", time.Date(2023, 11, 14, 0, 0, 0, 0, time.UTC)},
+		{"now-1d/d", "", time.Date(2023, 11, 14, 0, 0, 0, 0, time.UTC)},
+		{"now-1M+2h", "", time.Date(2023, 10, 15, 12, 30, 0, 0, time.UTC)},
+		{"now/w", "", time.Date(2023, 11, 13, 0, 0, 0, 0, time.UTC)},
+	} {
+		date, err := parseDate(tc.value, tc.format, now)
+		require.NoError(t, err)
+		assert.True(t, tc.expected.Equal(date), "%v: %v", tc.value, date)
+	}
+}
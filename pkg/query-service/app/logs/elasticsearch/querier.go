@@ -0,0 +1,409 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+const (
+	logsDB    = "signoz_logs"
+	logsTable = "distributed_logs"
+
+	timestampColumn = "timestamp"
+	bodyColumn      = "body"
+)
+
+// columns are the columns of the logs table by field, the other fields are the attributes
+// or the resource attributes of the logs
+var columns = map[string]string{
+	"@timestamp":      timestampColumn,
+	"timestamp":       timestampColumn,
+	"message":         bodyColumn,
+	"body":            bodyColumn,
+	"_id":             "id",
+	"severity_text":   "severity_text",
+	"severity_number": "severity_number",
+	"trace_id":        "trace_id",
+	"span_id":         "span_id",
+}
+
+func isTimestamp(field string) bool {
+	return columns[field] == timestampColumn
+}
+
+// SearchResponse is the response of a search, the hits are not scored
+type SearchResponse struct {
+	Took         int64                  `json:"took"`
+	TimedOut     bool                   `json:"timed_out"`
+	Shards       Shards                 `json:"_shards"`
+	Hits         Hits                   `json:"hits"`
+	Aggregations map[string]TermsResult `json:"aggregations,omitempty"`
+}
+
+type Shards struct {
+	Total      int `json:"total"`
+	Successful int `json:"successful"`
+	Skipped    int `json:"skipped"`
+	Failed     int `json:"failed"`
+}
+
+type Hits struct {
+	Total    Total    `json:"total"`
+	MaxScore *float64 `json:"max_score"`
+	Hits     []Hit    `json:"hits"`
+}
+
+type Total struct {
+	Value    uint64 `json:"value"`
+	Relation string `json:"relation"`
+}
+
+// Hit is a log, its source has the attributes and the resource attributes of the log next
+// to its fields
+type Hit struct {
+	Index  string                 `json:"_index"`
+	ID     string                 `json:"_id"`
+	Score  *float64               `json:"_score"`
+	Source map[string]interface{} `json:"_source"`
+	Sort   []int64                `json:"sort"`
+}
+
+type TermsResult struct {
+	DocCountErrorUpperBound uint64   `json:"doc_count_error_upper_bound"`
+	SumOtherDocCount        uint64   `json:"sum_other_doc_count"`
+	Buckets                 []Bucket `json:"buckets"`
+}
+
+type Bucket struct {
+	Key      string `json:"key"`
+	DocCount uint64 `json:"doc_count"`
+}
+
+// ErrorResponse is the response of the failed requests
+type ErrorResponse struct {
+	Error  Error `json:"error"`
+	Status int   `json:"status"`
+}
+
+type Error struct {
+	RootCause []Error `json:"root_cause,omitempty"`
+	Type      string  `json:"type"`
+	Reason    string  `json:"reason"`
+}
+
+func NewErrorResponse(status int, typ string, reason string) ErrorResponse {
+	return ErrorResponse{Error: Error{RootCause: []Error{{Type: typ, Reason: reason}}, Type: typ, Reason: reason}, Status: status}
+}
+
+// Querier runs the searches on the SigNoz logs table
+type Querier struct {
+	conn clickhouse.Conn
+}
+
+func NewQuerier(conn clickhouse.Conn) *Querier {
+	return &Querier{conn: conn}
+}
+
+// stringValue returns the value of the field as a string, the attributes of the log are
+// looked up first, then its resource attributes
+func stringValue(field string) string {
+	if column, ok := columns[field]; ok {
+		if column == timestampColumn || column == "severity_number" {
+			return fmt.Sprintf("toString(%s)", column)
+		}
+		return column
+	}
+	key := utils.ClickHouseFormattedValue(field)
+	return fmt.Sprintf("multiIf(has(attributes_string_key, %[1]s), attributes_string_value[indexOf(attributes_string_key, %[1]s)], "+
+		"has(attributes_int64_key, %[1]s), toString(attributes_int64_value[indexOf(attributes_int64_key, %[1]s)]), "+
+		"has(attributes_float64_key, %[1]s), toString(attributes_float64_value[indexOf(attributes_float64_key, %[1]s)]), "+
+		"has(attributes_bool_key, %[1]s), toString(attributes_bool_value[indexOf(attributes_bool_key, %[1]s)]), "+
+		"resources_string_value[indexOf(resources_string_key, %[1]s)])", key)
+}
+
+// numberValue returns the value of the field as a number, null when it is not a number
+func numberValue(field string) string {
+	if column, ok := columns[field]; ok {
+		if column == timestampColumn || column == "severity_number" {
+			return column
+		}
+		return fmt.Sprintf("toFloat64OrNull(%s)", column)
+	}
+	key := utils.ClickHouseFormattedValue(field)
+	return fmt.Sprintf("multiIf(has(attributes_int64_key, %[1]s), toFloat64(attributes_int64_value[indexOf(attributes_int64_key, %[1]s)]), "+
+		"has(attributes_float64_key, %[1]s), attributes_float64_value[indexOf(attributes_float64_key, %[1]s)], toFloat64OrNull(%[2]s))", key, stringValue(field))
+}
+
+func exists(field string) string {
+	if column, ok := columns[field]; ok {
+		if column == timestampColumn || column == "severity_number" {
+			return "true"
+		}
+		return fmt.Sprintf("%s != ''", column)
+	}
+	key := utils.ClickHouseFormattedValue(field)
+	return fmt.Sprintf("(has(attributes_string_key, %[1]s) OR has(attributes_int64_key, %[1]s) OR has(attributes_float64_key, %[1]s) "+
+		"OR has(attributes_bool_key, %[1]s) OR has(resources_string_key, %[1]s))", key)
+}
+
+// literal returns the value as a literal, the numbers are compared as numbers and the other
+// values as strings
+func literal(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.String(), true
+	case int64:
+		return fmt.Sprintf("%d", v), true
+	default:
+		return utils.ClickHouseFormattedValue(fmt.Sprint(v)), false
+	}
+}
+
+// termCondition selects the logs whose field is the value
+func termCondition(field string, value interface{}) string {
+	v, isNumber := literal(value)
+	if isNumber {
+		return fmt.Sprintf("%s = %s", numberValue(field), v)
+	}
+	return fmt.Sprintf("%s = %s", stringValue(field), v)
+}
+
+// condition returns the condition of the query, the match queries on the message select the
+// logs containing the words, or the phrase, case insensitively. On the other fields the match
+// query is a term query and the match_phrase query selects the values containing the phrase
+func condition(q *Query) string {
+	switch q.Kind {
+	case QueryMatch:
+		if columns[q.Field] != bodyColumn {
+			return termCondition(q.Field, q.Values[0])
+		}
+		// the message contains any of the words of the query, or all of them with the and operator
+		words := []string{}
+		for _, word := range strings.Fields(fmt.Sprint(q.Values[0])) {
+			words = append(words, fmt.Sprintf("positionCaseInsensitive(body, %s) > 0", utils.ClickHouseFormattedValue(word)))
+		}
+		if len(words) == 0 {
+			return "false"
+		}
+		return "(" + strings.Join(words, " "+strings.ToUpper(q.Operator)+" ") + ")"
+	case QueryMatchPhrase:
+		return fmt.Sprintf("positionCaseInsensitive(%s, %s) > 0", stringValue(q.Field), utils.ClickHouseFormattedValue(fmt.Sprint(q.Values[0])))
+	case QueryTerm:
+		return termCondition(q.Field, q.Values[0])
+	case QueryTerms:
+		terms := []string{}
+		for _, value := range q.Values {
+			terms = append(terms, termCondition(q.Field, value))
+		}
+		return "(" + strings.Join(terms, " OR ") + ")"
+	case QueryRange:
+		operators := map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+		bounds := []string{}
+		for _, op := range []string{"gt", "gte", "lt", "lte"} {
+			bound, ok := q.Bounds[op]
+			if !ok {
+				continue
+			}
+			v, isNumber := literal(bound)
+			value := stringValue(q.Field)
+			if isNumber {
+				value = numberValue(q.Field)
+			}
+			bounds = append(bounds, fmt.Sprintf("%s %s %s", value, operators[op], v))
+		}
+		return "(" + strings.Join(bounds, " AND ") + ")"
+	case QueryExists:
+		return exists(q.Field)
+	case QueryBool:
+		conditions := []string{}
+		for _, clause := range append(append([]*Query{}, q.Must...), q.Filter...) {
+			conditions = append(conditions, condition(clause))
+		}
+		for _, clause := range q.MustNot {
+			conditions = append(conditions, "NOT "+condition(clause))
+		}
+		if q.MinimumShouldMatch > 0 {
+			should := []string{}
+			for _, clause := range q.Should {
+				should = append(should, condition(clause))
+			}
+			if q.MinimumShouldMatch == 1 {
+				conditions = append(conditions, "("+strings.Join(should, " OR ")+")")
+			} else {
+				// the conditions are 0 or 1, their sum is the number of matching clauses
+				conditions = append(conditions, fmt.Sprintf("((%s) >= %d)", strings.Join(should, ") + ("), q.MinimumShouldMatch))
+			}
+		}
+		if len(conditions) == 0 {
+			return "true"
+		}
+		return "(" + strings.Join(conditions, " AND ") + ")"
+	default:
+		return "true"
+	}
+}
+
+// resourceCondition selects the logs of the resources of the filter, the logs without the
+// resource attribute have an empty value
+func resourceCondition(f *ResourceFilter) string {
+	values := make([]interface{}, 0, len(f.Values))
+	for _, value := range f.Values {
+		values = append(values, value)
+	}
+	not := ""
+	if f.Exclude {
+		not = "NOT "
+	}
+	return fmt.Sprintf("%shas(%s, resources_string_value[indexOf(resources_string_key, %s)])", not,
+		utils.ClickHouseFormattedValue(values), utils.ClickHouseFormattedValue(f.Key))
+}
+
+func hitsQuery(req *SearchRequest, where string) string {
+	order := "DESC"
+	if req.Ascending {
+		order = "ASC"
+	}
+	return fmt.Sprintf("SELECT timestamp, id, trace_id, span_id, severity_text, severity_number, body, resources_string_key, resources_string_value, "+
+		"attributes_string_key, attributes_string_value, attributes_int64_key, attributes_int64_value, attributes_float64_key, attributes_float64_value, "+
+		"attributes_bool_key, attributes_bool_value FROM %s.%s WHERE %s ORDER BY timestamp %s LIMIT %d OFFSET %d",
+		logsDB, logsTable, where, order, req.Size, req.From)
+}
+
+// termsQuery returns the query of the most frequent values of the field
+func termsQuery(agg TermsAggregation, where string) string {
+	return fmt.Sprintf("SELECT %s AS key, count() AS doc_count FROM %s.%s WHERE %s AND %s GROUP BY key ORDER BY doc_count DESC, key ASC LIMIT %d",
+		stringValue(agg.Field), logsDB, logsTable, where, exists(agg.Field), agg.Size)
+}
+
+// Search returns the page of the logs matching the query, with their number and the aggregations
+func (q *Querier) Search(ctx context.Context, index string, req *SearchRequest) (*SearchResponse, error) {
+	started := time.Now()
+	where := condition(req.Query)
+	if req.Resources != nil {
+		where = fmt.Sprintf("%s AND %s", where, resourceCondition(req.Resources))
+	}
+	response := &SearchResponse{
+		Shards: Shards{Total: 1, Successful: 1},
+		Hits:   Hits{Total: Total{Relation: "eq"}, Hits: []Hit{}},
+	}
+
+	if err := q.conn.QueryRow(ctx, fmt.Sprintf("SELECT count() FROM %s.%s WHERE %s", logsDB, logsTable, where)).Scan(&response.Hits.Total.Value); err != nil {
+		return nil, err
+	}
+	if req.Size > 0 && response.Hits.Total.Value > 0 {
+		hits, err := q.hits(ctx, index, req, where)
+		if err != nil {
+			return nil, err
+		}
+		response.Hits.Hits = hits
+	}
+
+	if len(req.Aggregations) > 0 {
+		response.Aggregations = map[string]TermsResult{}
+	}
+	for name, agg := range req.Aggregations {
+		result, err := q.terms(ctx, agg, where)
+		if err != nil {
+			return nil, err
+		}
+		response.Aggregations[name] = result
+	}
+	response.Took = time.Since(started).Milliseconds()
+	return response, nil
+}
+
+func (q *Querier) hits(ctx context.Context, index string, req *SearchRequest, where string) ([]Hit, error) {
+	rows, err := q.conn.Query(ctx, hitsQuery(req, where))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []Hit{}
+	for rows.Next() {
+		var timestamp uint64
+		var id, traceID, spanID, severityText, body string
+		var severityNumber uint8
+		var resourceKeys, resourceValues, stringKeys, stringValues, intKeys, floatKeys, boolKeys []string
+		var intValues []int64
+		var floatValues []float64
+		var boolValues []bool
+		err := rows.Scan(&timestamp, &id, &traceID, &spanID, &severityText, &severityNumber, &body, &resourceKeys, &resourceValues,
+			&stringKeys, &stringValues, &intKeys, &intValues, &floatKeys, &floatValues, &boolKeys, &boolValues)
+		if err != nil {
+			return nil, err
+		}
+
+		// the attributes override the resource attributes, as in the queries
+		source := map[string]interface{}{}
+		for i := 0; i < len(resourceKeys) && i < len(resourceValues); i++ {
+			source[resourceKeys[i]] = resourceValues[i]
+		}
+		for i := 0; i < len(stringKeys) && i < len(stringValues); i++ {
+			source[stringKeys[i]] = stringValues[i]
+		}
+		for i := 0; i < len(intKeys) && i < len(intValues); i++ {
+			source[intKeys[i]] = intValues[i]
+		}
+		for i := 0; i < len(floatKeys) && i < len(floatValues); i++ {
+			source[floatKeys[i]] = floatValues[i]
+		}
+		for i := 0; i < len(boolKeys) && i < len(boolValues); i++ {
+			source[boolKeys[i]] = boolValues[i]
+		}
+		t := time.Unix(0, int64(timestamp)).UTC()
+		source["@timestamp"] = t.Format(time.RFC3339Nano)
+		source["message"] = body
+		source["severity_text"] = severityText
+		source["severity_number"] = severityNumber
+		if traceID != "" {
+			source["trace_id"] = traceID
+		}
+		if spanID != "" {
+			source["span_id"] = spanID
+		}
+		hits = append(hits, Hit{Index: index, ID: id, Source: source, Sort: []int64{t.UnixMilli()}})
+	}
+	return hits, rows.Err()
+}
+
+// terms returns the buckets of the most frequent values of the field, the logs of the other
+// values are counted from the logs having the field
+func (q *Querier) terms(ctx context.Context, agg TermsAggregation, where string) (TermsResult, error) {
+	var total uint64
+	query := fmt.Sprintf("SELECT count() FROM %s.%s WHERE %s AND %s", logsDB, logsTable, where, exists(agg.Field))
+	if err := q.conn.QueryRow(ctx, query).Scan(&total); err != nil {
+		return TermsResult{}, err
+	}
+
+	rows, err := q.conn.Query(ctx, termsQuery(agg, where))
+	if err != nil {
+		return TermsResult{}, err
+	}
+	defer rows.Close()
+
+	result := TermsResult{Buckets: []Bucket{}}
+	counted := uint64(0)
+	for rows.Next() {
+		var bucket Bucket
+		if err := rows.Scan(&bucket.Key, &bucket.DocCount); err != nil {
+			return TermsResult{}, err
+		}
+		counted += bucket.DocCount
+		result.Buckets = append(result.Buckets, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return TermsResult{}, err
+	}
+	if total > counted {
+		result.SumOtherDocCount = total - counted
+	}
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCondition(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		query    *Query
+		expected string
+	}{
+		{"match all", &Query{Kind: QueryMatchAll}, "true"},
+		{
+			"match on the message",
+			&Query{Kind: QueryMatch, Field: "message", Values: []interface{}{"connection refused"}, Operator: OperatorOr},
+			"(positionCaseInsensitive(body, 'connection') > 0 OR positionCaseInsensitive(body, 'refused') > 0)",
+		},
+		{"match on a field", &Query{Kind: QueryMatch, Field: "severity_text", Values: []interface{}{"ERROR"}, Operator: OperatorOr}, "severity_text = 'ERROR'"},
+		{"match phrase", &Query{Kind: QueryMatchPhrase, Field: "body", Values: []interface{}{"timed out"}}, "positionCaseInsensitive(body, 'timed out') > 0"},
+		{"term on the timestamp", &Query{Kind: QueryTerm, Field: "@timestamp", Values: []interface{}{int64(1700000000000000000)}}, "timestamp = 1700000000000000000"},
+		{
+			"range",
+			&Query{Kind: QueryRange, Field: "@timestamp", Bounds: map[string]interface{}{"lt": int64(2), "gte": int64(1)}},
+			"(timestamp >= 1 AND timestamp < 2)",
+		},
+		{"exists", &Query{Kind: QueryExists, Field: "trace_id"}, "trace_id != ''"},
+		{
+			"bool",
+			&Query{Kind: QueryBool,
+				Filter:  []*Query{{Kind: QueryTerm, Field: "severity_number", Values: []interface{}{json.Number("17")}}},
+				MustNot: []*Query{{Kind: QueryExists, Field: "span_id"}},
+				Should: []*Query{
+					{Kind: QueryMatchPhrase, Field: "message", Values: []interface{}{"a"}},
+					{Kind: QueryMatchPhrase, Field: "message", Values: []interface{}{"b"}},
+					{Kind: QueryMatchPhrase, Field: "message", Values: []interface{}{"c"}},
+				},
+				MinimumShouldMatch: 2,
+			},
+			"(severity_number = 17 AND NOT span_id != '' AND ((positionCaseInsensitive(body, 'a') > 0) + (positionCaseInsensitive(body, 'b') > 0) + " +
+				"(positionCaseInsensitive(body, 'c') > 0) >= 2))",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, condition(tc.query))
+		})
+	}
+}
+
+func TestAttributeConditions(t *testing.T) {
+	// the attributes are looked up by type, then in the resource attributes
+	assert.Equal(t, "multiIf(has(attributes_string_key, 'service.name'), attributes_string_value[indexOf(attributes_string_key, 'service.name')], "+
+		"has(attributes_int64_key, 'service.name'), toString(attributes_int64_value[indexOf(attributes_int64_key, 'service.name')]), "+
+		"has(attributes_float64_key, 'service.name'), toString(attributes_float64_value[indexOf(attributes_float64_key, 'service.name')]), "+
+		"has(attributes_bool_key, 'service.name'), toString(attributes_bool_value[indexOf(attributes_bool_key, 'service.name')]), "+
+		"resources_string_value[indexOf(resources_string_key, 'service.name')]) = 'api'",
+		condition(&Query{Kind: QueryTerm, Field: "service.name", Values: []interface{}{"api"}}))
+
+	numeric := condition(&Query{Kind: QueryRange, Field: "duration", Bounds: map[string]interface{}{"gt": json.Number("1.5")}})
+	assert.Contains(t, numeric, "multiIf(has(attributes_int64_key, 'duration'), toFloat64(attributes_int64_value[indexOf(attributes_int64_key, 'duration')])")
+	assert.Contains(t, numeric, ") > 1.5)")
+
+	// the values are escaped
+	assert.Contains(t, condition(&Query{Kind: QueryTerm, Field: "path", Values: []interface{}{`it's`}}), `= 'it\'s'`)
+}
+
+func TestResourceCondition(t *testing.T) {
+	assert.Equal(t, "has(['a','b'], resources_string_value[indexOf(resources_string_key, 'signoz.org.id')])",
+		resourceCondition(&ResourceFilter{Key: "signoz.org.id", Values: []string{"a", "b"}}))
+	assert.Equal(t, "NOT has(['a'], resources_string_value[indexOf(resources_string_key, 'signoz.org.id')])",
+		resourceCondition(&ResourceFilter{Key: "signoz.org.id", Values: []string{"a"}, Exclude: true}))
+}
+
+func TestTermsQuery(t *testing.T) {
+	assert.Equal(t, "SELECT severity_text AS key, count() AS doc_count FROM signoz_logs.distributed_logs WHERE true AND severity_text != '' "+
+		"GROUP BY key ORDER BY doc_count DESC, key ASC LIMIT 5", termsQuery(TermsAggregation{Field: "severity_text", Size: 5}, "true"))
+}
+
+func TestHitsQuery(t *testing.T) {
+	query := hitsQuery(&SearchRequest{From: 20, Size: 10, Ascending: true}, "true")
+	assert.Contains(t, query, "FROM signoz_logs.distributed_logs WHERE true ORDER BY timestamp ASC LIMIT 10 OFFSET 20")
+}
@@ -0,0 +1,283 @@
+package loki
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	promModel "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+const (
+	FilterContains    = "|="
+	FilterNotContains = "!="
+	FilterMatch       = "|~"
+	FilterNotMatch    = "!~"
+
+	FunctionCountOverTime = "count_over_time"
+	FunctionRate          = "rate"
+)
+
+// Query is a query of the supported subset of LogQL: a log query, made of a stream selector
+// followed by line filters, or the count_over_time or rate of a log query, optionally summed
+// by labels, e.g. sum by (level) (count_over_time({job="api"} |= "error" [1m]))
+type Query struct {
+	Matchers []*labels.Matcher
+	Filters  []LineFilter
+	// Metric is set for the metric queries
+	Metric *MetricQuery
+}
+
+type LineFilter struct {
+	Op    string
+	Value string
+}
+
+type MetricQuery struct {
+	Function string
+	Range    time.Duration
+	// Sum is set when the series are summed, by the labels in By
+	Sum bool
+	By  []string
+}
+
+// ParseQuery parses a query of the supported subset of LogQL
+func ParseQuery(query string) (*Query, error) {
+	s := &scanner{input: query}
+	q, err := s.parseQuery()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %v", err)
+	}
+	if s.skipSpaces(); !s.done() {
+		return nil, fmt.Errorf("invalid query: unexpected %q at position %d", s.input[s.pos:], s.pos)
+	}
+	return q, nil
+}
+
+type scanner struct {
+	input string
+	pos   int
+}
+
+func (s *scanner) done() bool {
+	return s.pos >= len(s.input)
+}
+
+func (s *scanner) skipSpaces() {
+	for !s.done() && unicode.IsSpace(rune(s.input[s.pos])) {
+		s.pos++
+	}
+}
+
+// consume skips the token when it is next
+func (s *scanner) consume(token string) bool {
+	s.skipSpaces()
+	if strings.HasPrefix(s.input[s.pos:], token) {
+		s.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (s *scanner) expect(token string) error {
+	if !s.consume(token) {
+		return fmt.Errorf("expected %q at position %d", token, s.pos)
+	}
+	return nil
+}
+
+func (s *scanner) ident() string {
+	s.skipSpaces()
+	start := s.pos
+	for !s.done() && (s.input[s.pos] == '_' || unicode.IsLetter(rune(s.input[s.pos])) || unicode.IsDigit(rune(s.input[s.pos]))) {
+		s.pos++
+	}
+	return s.input[start:s.pos]
+}
+
+// peekIdent returns the next identifier without consuming it
+func (s *scanner) peekIdent() string {
+	pos := s.pos
+	ident := s.ident()
+	s.pos = pos
+	return ident
+}
+
+func (s *scanner) parseQuery() (*Query, error) {
+	switch s.peekIdent() {
+	case "sum":
+		s.ident()
+		by, err := s.parseBy()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.expect("("); err != nil {
+			return nil, err
+		}
+		q, err := s.parseRangeAggregation()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.expect(")"); err != nil {
+			return nil, err
+		}
+		// the grouping can follow the expression, e.g. sum(rate({job="api"}[1m])) by (level)
+		if by == nil {
+			if by, err = s.parseBy(); err != nil {
+				return nil, err
+			}
+		}
+		q.Metric.Sum = true
+		q.Metric.By = by
+		return q, nil
+	case FunctionCountOverTime, FunctionRate:
+		return s.parseRangeAggregation()
+	default:
+		return s.parseLogQuery()
+	}
+}
+
+// parseBy parses the optional by clause of a sum
+func (s *scanner) parseBy() ([]string, error) {
+	if s.peekIdent() != "by" {
+		return nil, nil
+	}
+	s.ident()
+	if err := s.expect("("); err != nil {
+		return nil, err
+	}
+	by := []string{}
+	for !s.consume(")") {
+		if len(by) > 0 {
+			if err := s.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		name := s.ident()
+		if !promModel.LabelName(name).IsValid() {
+			return nil, fmt.Errorf("invalid label name at position %d", s.pos)
+		}
+		by = append(by, name)
+	}
+	return by, nil
+}
+
+func (s *scanner) parseRangeAggregation() (*Query, error) {
+	function := s.ident()
+	if function != FunctionCountOverTime && function != FunctionRate {
+		return nil, fmt.Errorf("unsupported function %q, only %s and %s are supported", function, FunctionCountOverTime, FunctionRate)
+	}
+	if err := s.expect("("); err != nil {
+		return nil, err
+	}
+	q, err := s.parseLogQuery()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.expect("["); err != nil {
+		return nil, err
+	}
+	end := strings.IndexByte(s.input[s.pos:], ']')
+	if end < 0 {
+		return nil, fmt.Errorf("expected \"]\" after position %d", s.pos)
+	}
+	rng, err := promModel.ParseDuration(strings.TrimSpace(s.input[s.pos : s.pos+end]))
+	if err != nil || rng <= 0 {
+		return nil, fmt.Errorf("invalid range %q", s.input[s.pos:s.pos+end])
+	}
+	s.pos += end + 1
+	if err := s.expect(")"); err != nil {
+		return nil, err
+	}
+	q.Metric = &MetricQuery{Function: function, Range: time.Duration(rng)}
+	return q, nil
+}
+
+func (s *scanner) parseLogQuery() (*Query, error) {
+	s.skipSpaces()
+	selector, err := s.selector()
+	if err != nil {
+		return nil, err
+	}
+	matchers, err := parser.ParseMetricSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	q := &Query{Matchers: matchers}
+
+	for {
+		op := ""
+		for _, filter := range []string{FilterContains, FilterNotContains, FilterMatch, FilterNotMatch} {
+			if s.consume(filter) {
+				op = filter
+				break
+			}
+		}
+		if op == "" {
+			return q, nil
+		}
+		value, err := s.str()
+		if err != nil {
+			return nil, err
+		}
+		if op == FilterMatch || op == FilterNotMatch {
+			if _, err := regexp.Compile(value); err != nil {
+				return nil, fmt.Errorf("invalid regex %q: %v", value, err)
+			}
+		}
+		q.Filters = append(q.Filters, LineFilter{Op: op, Value: value})
+	}
+}
+
+// selector returns the stream selector, up to the closing brace out of the quoted values
+func (s *scanner) selector() (string, error) {
+	if s.done() || s.input[s.pos] != '{' {
+		return "", fmt.Errorf("expected a stream selector at position %d", s.pos)
+	}
+	var quote byte
+	for i := s.pos + 1; i < len(s.input); i++ {
+		c := s.input[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '}':
+			selector := s.input[s.pos : i+1]
+			s.pos = i + 1
+			return selector, nil
+		}
+	}
+	return "", fmt.Errorf("unterminated stream selector")
+}
+
+// str parses a double quoted or a raw string
+func (s *scanner) str() (string, error) {
+	s.skipSpaces()
+	if s.done() || (s.input[s.pos] != '"' && s.input[s.pos] != '`') {
+		return "", fmt.Errorf("expected a string at position %d", s.pos)
+	}
+	quote := s.input[s.pos]
+	for i := s.pos + 1; i < len(s.input); i++ {
+		if s.input[i] == '\\' && quote == '"' {
+			i++
+		} else if s.input[i] == quote {
+			value, err := strconv.Unquote(s.input[s.pos : i+1])
+			if err != nil {
+				return "", fmt.Errorf("invalid string at position %d: %v", s.pos, err)
+			}
+			s.pos = i + 1
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("unterminated string at position %d", s.pos)
+}
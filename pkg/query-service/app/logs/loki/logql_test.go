@@ -0,0 +1,50 @@
+package loki
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(`{job="api", env=~"prod|staging", msg!="a}b"} |= "error" != ` + "`timeout`" + ` |~ "status=5\\d\\d"`)
+	require.NoError(t, err)
+	require.Len(t, q.Matchers, 3)
+	assert.Equal(t, labels.MustNewMatcher(labels.MatchNotEqual, "msg", "a}b").String(), q.Matchers[2].String())
+	assert.Equal(t, []LineFilter{
+		{Op: FilterContains, Value: "error"},
+		{Op: FilterNotContains, Value: "timeout"},
+		{Op: FilterMatch, Value: `status=5\d\d`},
+	}, q.Filters)
+	assert.Nil(t, q.Metric)
+
+	q, err = ParseQuery(`sum by (level) (count_over_time({job="api"} |= "error" [5m]))`)
+	require.NoError(t, err)
+	assert.Len(t, q.Filters, 1)
+	assert.Equal(t, &MetricQuery{Function: FunctionCountOverTime, Range: 5 * time.Minute, Sum: true, By: []string{"level"}}, q.Metric)
+
+	q, err = ParseQuery(`sum(rate({job="api"}[1m])) by (host, level)`)
+	require.NoError(t, err)
+	assert.Equal(t, &MetricQuery{Function: FunctionRate, Range: time.Minute, Sum: true, By: []string{"host", "level"}}, q.Metric)
+
+	q, err = ParseQuery(`rate({job="api"}[30s])`)
+	require.NoError(t, err)
+	assert.Equal(t, &MetricQuery{Function: FunctionRate, Range: 30 * time.Second}, q.Metric)
+
+	for _, query := range []string{
+		``,
+		`{job="api"`,
+		`{job="api"} |= error`,
+		`{job="api"} |~ "("`,
+		`{job="api"} | json`,
+		`bytes_over_time({job="api"}[1m])`,
+		`rate({job="api"}[0s])`,
+		`rate({job="api"}[1m]) + 1`,
+	} {
+		_, err := ParseQuery(query)
+		assert.Error(t, err, query)
+	}
+}
@@ -0,0 +1,246 @@
+package loki
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/promql/parser"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MaxRequestSize is the largest push request accepted, before decompression
+const MaxRequestSize = 32 << 20
+
+// ErrInvalidStream is returned for the push requests with streams the entries can not be written for
+var ErrInvalidStream = errors.New("invalid stream")
+
+// PushRequest is a batch of log streams pushed by the Loki clients (e.g. promtail, Grafana Agent)
+type PushRequest struct {
+	Streams []Stream
+}
+
+// Stream is a set of entries sharing the same labels
+type Stream struct {
+	Labels  map[string]string
+	Entries []Entry
+}
+
+type Entry struct {
+	Timestamp time.Time
+	Line      string
+	// Metadata is the structured metadata of the entry
+	Metadata map[string]string
+}
+
+// DecodePushRequest decodes a push request, the JSON requests may be gzip compressed and the
+// protobuf requests, sent by default by the clients, are snappy compressed
+func DecodePushRequest(body []byte, contentType, contentEncoding string) (*PushRequest, error) {
+	if strings.HasPrefix(contentType, "application/json") {
+		if contentEncoding == "gzip" {
+			reader, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decompress push request: %v", err)
+			}
+			if body, err = io.ReadAll(io.LimitReader(reader, 4*MaxRequestSize)); err != nil {
+				return nil, fmt.Errorf("failed to decompress push request: %v", err)
+			}
+		}
+		return decodeJSONPushRequest(body)
+	}
+
+	b, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress push request: %v", err)
+	}
+	return decodeProtoPushRequest(b)
+}
+
+type jsonStream struct {
+	Stream map[string]string   `json:"stream"`
+	Values [][]json.RawMessage `json:"values"`
+}
+
+// decodeJSONPushRequest decodes the JSON push requests, each value of a stream is the
+// timestamp in nanoseconds, the line and optionally the structured metadata
+func decodeJSONPushRequest(b []byte) (*PushRequest, error) {
+	var body struct {
+		Streams []jsonStream `json:"streams"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return nil, fmt.Errorf("failed to decode push request: %v", err)
+	}
+
+	req := &PushRequest{}
+	for _, s := range body.Streams {
+		stream := Stream{Labels: s.Stream}
+		for _, value := range s.Values {
+			if len(value) < 2 || len(value) > 3 {
+				return nil, fmt.Errorf("%w: the values must be a timestamp, a line and optionally the structured metadata", ErrInvalidStream)
+			}
+			var ts, line string
+			if err := json.Unmarshal(value[0], &ts); err != nil {
+				return nil, fmt.Errorf("%w: invalid timestamp: %v", ErrInvalidStream, err)
+			}
+			nanos, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid timestamp %q", ErrInvalidStream, ts)
+			}
+			if err := json.Unmarshal(value[1], &line); err != nil {
+				return nil, fmt.Errorf("%w: invalid line: %v", ErrInvalidStream, err)
+			}
+			entry := Entry{Timestamp: time.Unix(0, nanos), Line: line}
+			if len(value) == 3 {
+				if err := json.Unmarshal(value[2], &entry.Metadata); err != nil {
+					return nil, fmt.Errorf("%w: invalid structured metadata: %v", ErrInvalidStream, err)
+				}
+			}
+			stream.Entries = append(stream.Entries, entry)
+		}
+		req.Streams = append(req.Streams, stream)
+	}
+	return req, nil
+}
+
+// decodeProtoPushRequest decodes the logproto push requests:
+//
+//	PushRequest { repeated Stream streams = 1; }
+//	Stream { string labels = 1; repeated Entry entries = 2; uint64 hash = 3; }
+//	Entry { Timestamp timestamp = 1; string line = 2; repeated LabelPair structuredMetadata = 3; }
+//
+// The labels of a stream are in the Prometheus format, e.g. {job="varlogs"}
+func decodeProtoPushRequest(b []byte) (*PushRequest, error) {
+	req := &PushRequest{}
+	err := decodeMessage(b, func(num protowire.Number, value []byte) error {
+		if num != 1 {
+			return nil
+		}
+		stream, err := decodeProtoStream(value)
+		if err != nil {
+			return err
+		}
+		req.Streams = append(req.Streams, *stream)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode push request: %w", err)
+	}
+	return req, nil
+}
+
+func decodeProtoStream(b []byte) (*Stream, error) {
+	stream := &Stream{}
+	err := decodeMessage(b, func(num protowire.Number, value []byte) error {
+		switch num {
+		case 1:
+			lbls, err := parser.ParseMetric(string(value))
+			if err != nil {
+				return fmt.Errorf("%w: invalid labels %q: %v", ErrInvalidStream, value, err)
+			}
+			stream.Labels = lbls.Map()
+		case 2:
+			entry, err := decodeProtoEntry(value)
+			if err != nil {
+				return err
+			}
+			stream.Entries = append(stream.Entries, *entry)
+		}
+		return nil
+	})
+	return stream, err
+}
+
+func decodeProtoEntry(b []byte) (*Entry, error) {
+	entry := &Entry{}
+	err := decodeMessage(b, func(num protowire.Number, value []byte) error {
+		switch num {
+		case 1:
+			var seconds, nanos int64
+			err := decodeMessage(value, func(num protowire.Number, value []byte) error {
+				v, n := protowire.ConsumeVarint(value)
+				if n < 0 {
+					return protowire.ParseError(n)
+				}
+				switch num {
+				case 1:
+					seconds = int64(v)
+				case 2:
+					nanos = int64(int32(v))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			entry.Timestamp = time.Unix(seconds, nanos)
+		case 2:
+			entry.Line = string(value)
+		case 3:
+			var name, val string
+			err := decodeMessage(value, func(num protowire.Number, value []byte) error {
+				switch num {
+				case 1:
+					name = string(value)
+				case 2:
+					val = string(value)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if entry.Metadata == nil {
+				entry.Metadata = map[string]string{}
+			}
+			entry.Metadata[name] = val
+		}
+		return nil
+	})
+	return entry, err
+}
+
+// decodeMessage calls f with the fields of a protobuf message, the value of the varint
+// fields is passed encoded so the fields of all the types are handled the same way
+func decodeMessage(b []byte, f func(num protowire.Number, value []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var value []byte
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			value, b = v, b[n:]
+		case protowire.VarintType:
+			_, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			value, b = b[:n], b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		if err := f(num, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
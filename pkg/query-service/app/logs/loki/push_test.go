@@ -0,0 +1,80 @@
+package loki
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestDecodeJSONPushRequest(t *testing.T) {
+	body := []byte(`{"streams": [{"stream": {"job": "api"}, "values": [
+		["1700000000000000001", "first"],
+		["1700000000000000002", "second", {"trace_id": "abc"}]
+	]}]}`)
+
+	req, err := DecodePushRequest(body, "application/json", "")
+	require.NoError(t, err)
+	require.Len(t, req.Streams, 1)
+	assert.Equal(t, map[string]string{"job": "api"}, req.Streams[0].Labels)
+	assert.Equal(t, []Entry{
+		{Timestamp: time.Unix(0, 1700000000000000001), Line: "first"},
+		{Timestamp: time.Unix(0, 1700000000000000002), Line: "second", Metadata: map[string]string{"trace_id": "abc"}},
+	}, req.Streams[0].Entries)
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, err = writer.Write(body)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	gzipped, err := DecodePushRequest(compressed.Bytes(), "application/json; charset=utf-8", "gzip")
+	require.NoError(t, err)
+	assert.Equal(t, req, gzipped)
+
+	_, err = DecodePushRequest([]byte(`{"streams": [{"stream": {"job": "api"}, "values": [["now", "line"]]}]}`), "application/json", "")
+	assert.ErrorIs(t, err, ErrInvalidStream)
+}
+
+func TestDecodeProtoPushRequest(t *testing.T) {
+	var timestamp, metadata, entry, stream, body []byte
+	timestamp = protowire.AppendTag(timestamp, 1, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, 1700000000)
+	timestamp = protowire.AppendTag(timestamp, 2, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, 5)
+	metadata = protowire.AppendTag(metadata, 1, protowire.BytesType)
+	metadata = protowire.AppendString(metadata, "trace_id")
+	metadata = protowire.AppendTag(metadata, 2, protowire.BytesType)
+	metadata = protowire.AppendString(metadata, "abc")
+
+	entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+	entry = protowire.AppendBytes(entry, timestamp)
+	entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+	entry = protowire.AppendString(entry, "line")
+	entry = protowire.AppendTag(entry, 3, protowire.BytesType)
+	entry = protowire.AppendBytes(entry, metadata)
+
+	stream = protowire.AppendTag(stream, 1, protowire.BytesType)
+	stream = protowire.AppendString(stream, `{job="api", level="error"}`)
+	stream = protowire.AppendTag(stream, 2, protowire.BytesType)
+	stream = protowire.AppendBytes(stream, entry)
+	stream = protowire.AppendTag(stream, 3, protowire.VarintType)
+	stream = protowire.AppendVarint(stream, 42)
+
+	body = protowire.AppendTag(body, 1, protowire.BytesType)
+	body = protowire.AppendBytes(body, stream)
+
+	req, err := DecodePushRequest(snappy.Encode(nil, body), "application/x-protobuf", "")
+	require.NoError(t, err)
+	assert.Equal(t, &PushRequest{Streams: []Stream{{
+		Labels:  map[string]string{"job": "api", "level": "error"},
+		Entries: []Entry{{Timestamp: time.Unix(1700000000, 5), Line: "line", Metadata: map[string]string{"trace_id": "abc"}}},
+	}}}, req)
+
+	_, err = DecodePushRequest(body, "application/x-protobuf", "")
+	assert.Error(t, err)
+}
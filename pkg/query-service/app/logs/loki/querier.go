@@ -0,0 +1,365 @@
+package loki
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+const (
+	ResultTypeStreams = "streams"
+	ResultTypeMatrix  = "matrix"
+
+	DirectionBackward = "backward"
+	DirectionForward  = "forward"
+
+	// MaxLimit is the largest number of entries returned by a query
+	MaxLimit = 5000
+	// maxBuckets is the largest number of buckets the entries of a series are counted in
+	maxBuckets = 100000
+)
+
+// QueryRangeParams are the params of a query_range request
+type QueryRangeParams struct {
+	Query     *Query
+	Start     time.Time
+	End       time.Time
+	Limit     int
+	Direction string
+	Step      time.Duration
+}
+
+// QueryResponse is the data of a query_range response, the result is a list of streams for
+// the log queries and a matrix for the metric queries
+type QueryResponse struct {
+	ResultType string                 `json:"resultType"`
+	Result     interface{}            `json:"result"`
+	Stats      map[string]interface{} `json:"stats"`
+}
+
+type StreamResult struct {
+	Stream map[string]string `json:"stream"`
+	// Values are the timestamp in nanoseconds and the line of the entries
+	Values [][2]string `json:"values"`
+}
+
+type SeriesResult struct {
+	Metric map[string]string `json:"metric"`
+	Values []Sample          `json:"values"`
+}
+
+type Sample struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// MarshalJSON encodes the sample as the timestamp in seconds and the value as a string
+func (s Sample) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("[%s,%q]", strconv.FormatFloat(float64(s.Timestamp.UnixMilli())/1000, 'f', -1, 64),
+		strconv.FormatFloat(s.Value, 'f', -1, 64))), nil
+}
+
+// Querier runs the LogQL queries on the SigNoz logs table. The labels of the streams are the
+// resource attributes, the dots of their names are replaced with underscores to be valid labels
+type Querier struct {
+	conn clickhouse.Conn
+}
+
+func NewQuerier(conn clickhouse.Conn) *Querier {
+	return &Querier{conn: conn}
+}
+
+// labelName returns the label name of a resource attribute
+func labelName(key string) string {
+	return strings.ReplaceAll(key, ".", "_")
+}
+
+// labelColumn returns the value of the resource attribute of the label
+func labelColumn(name string) string {
+	return fmt.Sprintf("resources_string_value[arrayFirstIndex(k -> replaceAll(k, '.', '_') = %s, resources_string_key)]",
+		utils.ClickHouseFormattedValue(name))
+}
+
+func matcherCondition(m *labels.Matcher) string {
+	column := labelColumn(m.Name)
+	switch m.Type {
+	case labels.MatchNotEqual:
+		return fmt.Sprintf("%s != %s", column, utils.ClickHouseFormattedValue(m.Value))
+	case labels.MatchRegexp:
+		// the label matchers are anchored
+		return fmt.Sprintf("match(%s, %s)", column, utils.ClickHouseFormattedValue("^(?:"+m.Value+")$"))
+	case labels.MatchNotRegexp:
+		return fmt.Sprintf("NOT match(%s, %s)", column, utils.ClickHouseFormattedValue("^(?:"+m.Value+")$"))
+	default:
+		return fmt.Sprintf("%s = %s", column, utils.ClickHouseFormattedValue(m.Value))
+	}
+}
+
+func filterCondition(f LineFilter) string {
+	value := utils.ClickHouseFormattedValue(f.Value)
+	switch f.Op {
+	case FilterNotContains:
+		return fmt.Sprintf("position(body, %s) = 0", value)
+	case FilterMatch:
+		return fmt.Sprintf("match(body, %s)", value)
+	case FilterNotMatch:
+		return fmt.Sprintf("NOT match(body, %s)", value)
+	default:
+		return fmt.Sprintf("position(body, %s) > 0", value)
+	}
+}
+
+// whereClause returns the conditions selecting the entries of the log query in the range
+func whereClause(q *Query, start, end time.Time) string {
+	conditions := []string{streamsCondition(q.Matchers, start, end)}
+	for _, f := range q.Filters {
+		conditions = append(conditions, filterCondition(f))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// streamsCondition returns the conditions selecting the entries of the streams matched in the range
+func streamsCondition(matchers []*labels.Matcher, start, end time.Time) string {
+	conditions := []string{fmt.Sprintf("timestamp >= %d AND timestamp <= %d", start.UnixNano(), end.UnixNano())}
+	for _, m := range matchers {
+		conditions = append(conditions, matcherCondition(m))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+func (q *Querier) QueryRange(ctx context.Context, params *QueryRangeParams) (*QueryResponse, error) {
+	if params.Query.Metric != nil {
+		result, err := q.queryMatrix(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		return &QueryResponse{ResultType: ResultTypeMatrix, Result: result, Stats: map[string]interface{}{}}, nil
+	}
+	result, err := q.queryStreams(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return &QueryResponse{ResultType: ResultTypeStreams, Result: result, Stats: map[string]interface{}{}}, nil
+}
+
+// streamsQuery returns the query of the latest, or earliest, entries of the log query
+func streamsQuery(params *QueryRangeParams) string {
+	order := "DESC"
+	if params.Direction == DirectionForward {
+		order = "ASC"
+	}
+	return fmt.Sprintf("SELECT timestamp, body, resources_string_key, resources_string_value FROM %s.%s WHERE %s ORDER BY timestamp %s LIMIT %d",
+		logsDB, logsTable, whereClause(params.Query, params.Start, params.End), order, params.Limit)
+}
+
+func (q *Querier) queryStreams(ctx context.Context, params *QueryRangeParams) ([]*StreamResult, error) {
+	rows, err := q.conn.Query(ctx, streamsQuery(params))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	streams := []*StreamResult{}
+	byLabels := map[string]*StreamResult{}
+	for rows.Next() {
+		var timestamp uint64
+		var body string
+		var keys, values []string
+		if err := rows.Scan(&timestamp, &body, &keys, &values); err != nil {
+			return nil, err
+		}
+		lbls := map[string]string{}
+		for i, key := range keys {
+			if i < len(values) {
+				lbls[labelName(key)] = values[i]
+			}
+		}
+		id := labels.FromMap(lbls).String()
+		stream, ok := byLabels[id]
+		if !ok {
+			stream = &StreamResult{Stream: lbls, Values: [][2]string{}}
+			byLabels[id] = stream
+			streams = append(streams, stream)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatUint(timestamp, 10), body})
+	}
+	return streams, rows.Err()
+}
+
+// bucketSize returns the size of the buckets the entries are counted in, the counts of the
+// ranges ending at each step are the sums of whole buckets
+func bucketSize(params *QueryRangeParams) (time.Duration, error) {
+	a, b := int64(params.Step/time.Second), int64(params.Query.Metric.Range/time.Second)
+	if a <= 0 || b <= 0 || params.Step%time.Second != 0 || params.Query.Metric.Range%time.Second != 0 {
+		return 0, fmt.Errorf("the step and the range must be whole seconds")
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	size := time.Duration(a) * time.Second
+	if (params.End.Sub(params.Start)+params.Query.Metric.Range)/size > maxBuckets {
+		return 0, fmt.Errorf("too many buckets, use a range that is a multiple of the step")
+	}
+	return size, nil
+}
+
+// matrixQuery returns the query counting the entries of the log query by series and bucket
+func matrixQuery(params *QueryRangeParams, size time.Duration) string {
+	metric := params.Query.Metric
+	keys, values := "resources_string_key", "resources_string_value"
+	if metric.Sum {
+		names, columns := []string{}, []string{}
+		for _, name := range metric.By {
+			names = append(names, utils.ClickHouseFormattedValue(name))
+			columns = append(columns, labelColumn(name))
+		}
+		keys = fmt.Sprintf("CAST([%s] AS Array(String))", strings.Join(names, ", "))
+		values = fmt.Sprintf("CAST([%s] AS Array(String))", strings.Join(columns, ", "))
+	}
+	return fmt.Sprintf("SELECT %s AS keys, %s AS vals, toInt64(intDiv(timestamp, %d)) AS bucket, count() AS count FROM %s.%s WHERE %s GROUP BY keys, vals, bucket",
+		keys, values, size.Nanoseconds(), logsDB, logsTable,
+		whereClause(params.Query, params.Start.Add(-metric.Range), params.End))
+}
+
+func (q *Querier) queryMatrix(ctx context.Context, params *QueryRangeParams) ([]*SeriesResult, error) {
+	size, err := bucketSize(params)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.conn.Query(ctx, matrixQuery(params, size))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type series struct {
+		labels  map[string]string
+		buckets map[int64]uint64
+	}
+	byLabels := map[string]*series{}
+	for rows.Next() {
+		var keys, values []string
+		var bucket int64
+		var count uint64
+		if err := rows.Scan(&keys, &values, &bucket, &count); err != nil {
+			return nil, err
+		}
+		lbls := map[string]string{}
+		for i, key := range keys {
+			// the series without the label are grouped with an empty value, as in LogQL
+			if i < len(values) && values[i] != "" {
+				lbls[labelName(key)] = values[i]
+			}
+		}
+		id := labels.FromMap(lbls).String()
+		s, ok := byLabels[id]
+		if !ok {
+			s = &series{labels: lbls, buckets: map[int64]uint64{}}
+			byLabels[id] = s
+		}
+		s.buckets[bucket] += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(byLabels))
+	for id := range byLabels {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	result := []*SeriesResult{}
+	for _, id := range ids {
+		s := byLabels[id]
+		samples := evaluateSteps(s.buckets, params, size)
+		if len(samples) > 0 {
+			result = append(result, &SeriesResult{Metric: s.labels, Values: samples})
+		}
+	}
+	return result, nil
+}
+
+// evaluateSteps returns the samples of the steps of the range, the value of a step is the
+// count, or the rate, of the entries in the range ending at the step. The steps without
+// entries have no sample
+func evaluateSteps(buckets map[int64]uint64, params *QueryRangeParams, size time.Duration) []Sample {
+	metric := params.Query.Metric
+	n := int64(metric.Range / size)
+	first := params.Start.UnixNano()/size.Nanoseconds() - n
+
+	// sums[i] is the count of the entries in the buckets before the bucket first+i
+	sums := make([]uint64, params.End.UnixNano()/size.Nanoseconds()-first+1)
+	for i := 1; i < len(sums); i++ {
+		sums[i] = sums[i-1] + buckets[first+int64(i)-1]
+	}
+
+	samples := []Sample{}
+	for t := params.Start; !t.After(params.End); t = t.Add(params.Step) {
+		last := t.UnixNano()/size.Nanoseconds() - first
+		count := sums[last] - sums[last-n]
+		if count == 0 {
+			continue
+		}
+		value := float64(count)
+		if metric.Function == FunctionRate {
+			value /= metric.Range.Seconds()
+		}
+		samples = append(samples, Sample{Timestamp: t, Value: value})
+	}
+	return samples
+}
+
+// Labels returns the names of the labels of the streams matched in the range
+func (q *Querier) Labels(ctx context.Context, matchers []*labels.Matcher, start, end time.Time) ([]string, error) {
+	query := fmt.Sprintf("SELECT DISTINCT arrayJoin(resources_string_key) AS key FROM %s.%s WHERE %s",
+		logsDB, logsTable, streamsCondition(matchers, start, end))
+	rows, err := q.conn.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[string]struct{}{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		names[labelName(key)] = struct{}{}
+	}
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, rows.Err()
+}
+
+// LabelValues returns the values of the label in the streams matched in the range
+func (q *Querier) LabelValues(ctx context.Context, name string, matchers []*labels.Matcher, start, end time.Time) ([]string, error) {
+	query := fmt.Sprintf("SELECT DISTINCT %s AS value FROM %s.%s WHERE %s AND value != '' ORDER BY value",
+		labelColumn(name), logsDB, logsTable, streamsCondition(matchers, start, end))
+	rows, err := q.conn.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,64 @@
+package loki
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamsQuery(t *testing.T) {
+	q, err := ParseQuery(`{service_name="api", level!~"debug|info"} != "health"`)
+	require.NoError(t, err)
+	params := &QueryRangeParams{Query: q, Start: time.Unix(1700000000, 0), End: time.Unix(1700003600, 0), Limit: 100, Direction: DirectionForward}
+
+	assert.Equal(t, "SELECT timestamp, body, resources_string_key, resources_string_value FROM signoz_logs.distributed_logs "+
+		"WHERE timestamp >= 1700000000000000000 AND timestamp <= 1700003600000000000 AND "+
+		"resources_string_value[arrayFirstIndex(k -> replaceAll(k, '.', '_') = 'service_name', resources_string_key)] = 'api' AND "+
+		"NOT match(resources_string_value[arrayFirstIndex(k -> replaceAll(k, '.', '_') = 'level', resources_string_key)], '^(?:debug|info)$') AND "+
+		"position(body, 'health') = 0 ORDER BY timestamp ASC LIMIT 100", streamsQuery(params))
+}
+
+func TestMatrixQuery(t *testing.T) {
+	q, err := ParseQuery(`sum by (level) (count_over_time({job="api"}[1m]))`)
+	require.NoError(t, err)
+	params := &QueryRangeParams{Query: q, Start: time.Unix(1700000000, 0), End: time.Unix(1700003600, 0), Step: 20 * time.Second}
+
+	size, err := bucketSize(params)
+	require.NoError(t, err)
+	assert.Equal(t, 20*time.Second, size)
+	assert.Equal(t, "SELECT CAST(['level'] AS Array(String)) AS keys, "+
+		"CAST([resources_string_value[arrayFirstIndex(k -> replaceAll(k, '.', '_') = 'level', resources_string_key)]] AS Array(String)) AS vals, "+
+		"toInt64(intDiv(timestamp, 20000000000)) AS bucket, count() AS count FROM signoz_logs.distributed_logs "+
+		"WHERE timestamp >= 1699999940000000000 AND timestamp <= 1700003600000000000 AND "+
+		"resources_string_value[arrayFirstIndex(k -> replaceAll(k, '.', '_') = 'job', resources_string_key)] = 'api' "+
+		"GROUP BY keys, vals, bucket", matrixQuery(params, size))
+
+	params.Step = 1500 * time.Millisecond
+	_, err = bucketSize(params)
+	assert.Error(t, err)
+}
+
+func TestEvaluateSteps(t *testing.T) {
+	q, err := ParseQuery(`rate({job="api"}[20s])`)
+	require.NoError(t, err)
+	start := time.Unix(1700000000, 0)
+	params := &QueryRangeParams{Query: q, Start: start, End: start.Add(40 * time.Second), Step: 10 * time.Second}
+
+	// the buckets of 10s of the entries, the first one is before the start
+	first := start.Unix()/10 - 2
+	buckets := map[int64]uint64{first: 4, first + 1: 2, first + 3: 6}
+	samples := evaluateSteps(buckets, params, 10*time.Second)
+	assert.Equal(t, []Sample{
+		{Timestamp: start, Value: 0.3},
+		{Timestamp: start.Add(10 * time.Second), Value: 0.1},
+		{Timestamp: start.Add(20 * time.Second), Value: 0.3},
+		{Timestamp: start.Add(30 * time.Second), Value: 0.3},
+	}, samples)
+
+	b, err := json.Marshal(samples[0])
+	require.NoError(t, err)
+	assert.Equal(t, `[1700000000,"0.3"]`, string(b))
+}
@@ -0,0 +1,84 @@
+package loki
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	logsDB    = "signoz_logs"
+	logsTable = "distributed_logs"
+	// levelLabel is the label the severity of the entries is read from
+	levelLabel = "level"
+)
+
+// severityNumbers are the OpenTelemetry severity numbers of the levels
+var severityNumbers = map[string]uint8{
+	"trace":    1,
+	"debug":    5,
+	"info":     9,
+	"warn":     13,
+	"warning":  13,
+	"error":    17,
+	"fatal":    21,
+	"critical": 21,
+}
+
+// Writer writes the entries of the Loki push requests into the SigNoz logs table, the labels
+// of the streams are the resource attributes and the structured metadata the attributes
+type Writer struct {
+	conn clickhouse.Conn
+}
+
+func NewWriter(conn clickhouse.Conn) *Writer {
+	return &Writer{conn: conn}
+}
+
+func (w *Writer) Write(ctx context.Context, req *PushRequest) error {
+	for _, stream := range req.Streams {
+		if len(stream.Labels) == 0 {
+			return fmt.Errorf("%w: the streams must have at least one label", ErrInvalidStream)
+		}
+	}
+
+	statement, err := w.conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (timestamp, observed_timestamp, id, severity_text, severity_number, body, "+
+		"resources_string_key, resources_string_value, attributes_string_key, attributes_string_value) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		logsDB, logsTable))
+	if err != nil {
+		return err
+	}
+	observed := uint64(time.Now().UnixNano())
+	for _, stream := range req.Streams {
+		resourceKeys, resourceValues := sortedPairs(stream.Labels)
+		level := strings.ToLower(stream.Labels[levelLabel])
+		for _, entry := range stream.Entries {
+			attributeKeys, attributeValues := sortedPairs(entry.Metadata)
+			err := statement.Append(uint64(entry.Timestamp.UnixNano()), observed, uuid.NewString(), stream.Labels[levelLabel],
+				severityNumbers[level], entry.Line, resourceKeys, resourceValues, attributeKeys, attributeValues)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return statement.Send()
+}
+
+// sortedPairs returns the keys and values of the map, sorted by key
+func sortedPairs(m map[string]string) ([]string, []string) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]string, 0, len(m))
+	for _, k := range keys {
+		values = append(values, m[k])
+	}
+	return keys, values
+}
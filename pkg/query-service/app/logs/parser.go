@@ -107,6 +107,96 @@ func ParseLiveTailFilterParams(r *http.Request) (*model.LogsFilterParams, error)
 	return &res, nil
 }
 
+const (
+	DefaultLogPatternsLimit      = 20
+	DefaultLogPatternsSampleSize = 10000
+	MaxLogPatternsSampleSize     = 50000
+
+	// the trend of the patterns has around this many points when the step is not set
+	defaultLogPatternsTrendPoints = 30
+)
+
+func ParseLogPatternsParams(r *http.Request) (*model.LogPatternsParams, error) {
+	res := model.LogPatternsParams{
+		Limit:      DefaultLogPatternsLimit,
+		SampleSize: DefaultLogPatternsSampleSize,
+	}
+	params := r.URL.Query()
+	if val, ok := params[TIMESTAMP_START]; ok {
+		ts, err := strconv.Atoi(val[0])
+		if err != nil {
+			return nil, err
+		}
+		res.TimestampStart = uint64(ts)
+	} else {
+		return nil, fmt.Errorf("timestampStart is required")
+	}
+	if val, ok := params[TIMESTAMP_END]; ok {
+		ts, err := strconv.Atoi(val[0])
+		if err != nil {
+			return nil, err
+		}
+		res.TimestampEnd = uint64(ts)
+	} else {
+		return nil, fmt.Errorf("timestampEnd is required")
+	}
+	if res.TimestampStart >= res.TimestampEnd {
+		return nil, fmt.Errorf("timestampStart must be before timestampEnd")
+	}
+
+	if val, ok := params["q"]; ok {
+		res.Query = val[0]
+	}
+
+	if val, ok := params["limit"]; ok {
+		limit, err := strconv.Atoi(val[0])
+		if err != nil {
+			return nil, err
+		}
+		if limit <= 0 {
+			return nil, fmt.Errorf("limit must be positive")
+		}
+		res.Limit = limit
+	}
+
+	if val, ok := params["sampleSize"]; ok {
+		size, err := strconv.Atoi(val[0])
+		if err != nil {
+			return nil, err
+		}
+		if size <= 0 || size > MaxLogPatternsSampleSize {
+			return nil, fmt.Errorf("sampleSize must be between 1 and %d", MaxLogPatternsSampleSize)
+		}
+		res.SampleSize = size
+	}
+
+	if val, ok := params["step"]; ok {
+		step, err := strconv.Atoi(val[0])
+		if err != nil {
+			return nil, err
+		}
+		if step <= 0 {
+			return nil, fmt.Errorf("step must be positive")
+		}
+		res.StepSeconds = step
+	} else {
+		rangeSeconds := int((res.TimestampEnd - res.TimestampStart) / 1000000000)
+		res.StepSeconds = rangeSeconds / defaultLogPatternsTrendPoints
+		if res.StepSeconds < 60 {
+			res.StepSeconds = 60
+		}
+	}
+
+	if val, ok := params["compare"]; ok {
+		compare, err := strconv.ParseBool(val[0])
+		if err != nil {
+			return nil, err
+		}
+		res.Compare = compare
+	}
+	return &res, nil
+}
+
 func ParseLogAggregateParams(r *http.Request) (*model.LogsAggregateParams, error) {
 	res := model.LogsAggregateParams{}
 	params := r.URL.Query()
@@ -328,6 +418,21 @@ func CheckIfPrevousPaginateAndModifyOrder(params *model.LogsFilterParams) (isPag
 	return
 }
 
+// orgFilter is the condition of the logs on the orgs of the scope, the logs without the
+// org id resource attribute belong to the default org
+func orgFilter(scope *model.OrgScope) string {
+	orgIds := make([]string, 0, len(scope.OrgIds))
+	for _, orgId := range scope.OrgIds {
+		orgIds = append(orgIds, fmt.Sprintf("'%s'", utils.QuoteEscapedString(orgId)))
+	}
+	not := ""
+	if scope.Exclude {
+		not = "NOT "
+	}
+	return fmt.Sprintf("resources_string_value[indexOf(resources_string_key, '%s')] %sIN (%s) ",
+		constants.OrgIdAttribute, not, strings.Join(orgIds, ", "))
+}
+
 func GenerateSQLWhere(allFields *model.GetFieldsResponse, params *model.LogsFilterParams) (string, int, error) {
 	var tokens []string
 	var err error
@@ -376,6 +481,13 @@ func GenerateSQLWhere(allFields *model.GetFieldsResponse, params *model.LogsFilt
 		}
 		filterTokens = append(filterTokens, filter)
 	}
+	if params.OrgScope != nil {
+		filter := orgFilter(params.OrgScope)
+		if len(filterTokens) > 0 {
+			filter = "and " + filter
+		}
+		filterTokens = append(filterTokens, filter)
+	}
 
 	lenFilterTokens := len(filterTokens)
 	if lenFilterTokens > 0 {
@@ -423,6 +423,22 @@ var generateSQLQueryTestCases = []struct {
 		},
 		SqlFilter: "( timestamp >= '1657689292000' and timestamp <= '1657689294000' ) and ( trace_id != '' and span_id = '' and trace_flags != 0 and severity_number = 0) ",
 	},
+	{
+		Name: "Check the logs of an org",
+		Filter: model.LogsFilterParams{
+			Query:          "severity_number in (1)",
+			TimestampStart: uint64(1657689292000),
+			OrgScope:       &model.OrgScope{OrgIds: []string{"org1"}},
+		},
+		SqlFilter: "( timestamp >= '1657689292000' and resources_string_value[indexOf(resources_string_key, 'signoz.org.id')] IN ('org1') ) and ( severity_number IN (1) ) ",
+	},
+	{
+		Name: "Check the logs of the default org",
+		Filter: model.LogsFilterParams{
+			OrgScope: &model.OrgScope{Exclude: true, OrgIds: []string{"org1", "org2"}},
+		},
+		SqlFilter: "( resources_string_value[indexOf(resources_string_key, 'signoz.org.id')] NOT IN ('org1', 'org2') ) ",
+	},
 }
 
 func TestGenerateSQLQuery(t *testing.T) {
@@ -482,3 +498,60 @@ func TestParseLogFilterParams(t *testing.T) {
 		})
 	}
 }
+
+var parseLogPatternsParams = []struct {
+	Name           string
+	ReqParams      string
+	ExpectedParams *model.LogPatternsParams
+	IsError        bool
+}{
+	{
+		Name:      "test defaults",
+		ReqParams: "timestampStart=1000000000000&timestampEnd=4600000000000",
+		ExpectedParams: &model.LogPatternsParams{
+			TimestampStart: 1000000000000,
+			TimestampEnd:   4600000000000,
+			Limit:          DefaultLogPatternsLimit,
+			SampleSize:     DefaultLogPatternsSampleSize,
+			StepSeconds:    120,
+		},
+	},
+	{
+		Name:      "test with all params",
+		ReqParams: "timestampStart=1000000000000&timestampEnd=4600000000000&q=service.name='myservice'&limit=5&sampleSize=100&step=300&compare=true",
+		ExpectedParams: &model.LogPatternsParams{
+			Query:          "service.name='myservice'",
+			TimestampStart: 1000000000000,
+			TimestampEnd:   4600000000000,
+			Limit:          5,
+			SampleSize:     100,
+			StepSeconds:    300,
+			Compare:        true,
+		},
+	},
+	{
+		Name:      "test without time range",
+		ReqParams: "limit=5",
+		IsError:   true,
+	},
+	{
+		Name:      "test with sample size above max",
+		ReqParams: "timestampStart=1000000000000&timestampEnd=4600000000000&sampleSize=1000000",
+		IsError:   true,
+	},
+}
+
+func TestParseLogPatternsParams(t *testing.T) {
+	for _, test := range parseLogPatternsParams {
+		Convey(test.Name, t, func() {
+			req := httptest.NewRequest(http.MethodGet, "/logs/patterns?"+test.ReqParams, nil)
+			params, err := ParseLogPatternsParams(req)
+			if test.IsError {
+				So(err, ShouldNotBeNil)
+				return
+			}
+			So(err, ShouldBeNil)
+			So(params, ShouldResemble, test.ExpectedParams)
+		})
+	}
+}
@@ -213,7 +213,7 @@ func parseStrValue(valueStr string, operator v3.FilterOperator) (string, interfa
 	// for the following operators it will always be string
 	if operator == v3.FilterOperatorContains || operator == v3.FilterOperatorNotContains ||
 		operator == v3.FilterOperatorRegex || operator == v3.FilterOperatorNotRegex ||
-		operator == v3.FilterOperatorLike || operator == v3.FilterOperatorNotLike {
+		operator == v3.FilterOperatorLike || operator == v3.FilterOperatorNotLike || isFullTextOperator(operator) {
 		return valueType, valueStr
 	}
 
@@ -0,0 +1,152 @@
+package v3
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+// maxFuzziness is the largest edit distance of the fuzzy terms, as Elasticsearch
+const maxFuzziness = 2
+
+var fuzzyTermRegex = regexp.MustCompile(`^(\w+)(?:~(\d))?$`)
+
+// isFullTextOperator tells whether the operator is one of the full text operators
+func isFullTextOperator(op v3.FilterOperator) bool {
+	switch op {
+	case v3.FilterOperatorPhrase, v3.FilterOperatorNotPhrase,
+		v3.FilterOperatorWildcard, v3.FilterOperatorNotWildcard,
+		v3.FilterOperatorFuzzy, v3.FilterOperatorNotFuzzy:
+		return true
+	}
+	return false
+}
+
+// likeFragment returns the LIKE pattern of the values containing the text, lower cased as
+// the ngram index of the body
+func likeFragment(column, text string) string {
+	text = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(text))
+	return fmt.Sprintf("lower(%s) LIKE %s", column, utils.ClickHouseFormattedValue("%"+text+"%"))
+}
+
+// phraseCondition selects the values with the words of the phrase in order, case
+// insensitively. The LIKE of each word on the lower cased value skips the granules with the
+// ngram index and the match checks the words are whole and in order
+func phraseCondition(column, phrase string) (string, error) {
+	words := strings.Fields(phrase)
+	if len(words) == 0 {
+		return "", fmt.Errorf("the phrase can't be empty")
+	}
+	conditions := make([]string, 0, len(words)+1)
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		conditions = append(conditions, likeFragment(column, word))
+		quoted = append(quoted, regexp.QuoteMeta(word))
+	}
+	last := words[len(words)-1]
+	pattern := `(?i)` + boundary(words[0][0]) + strings.Join(quoted, `\s+`) + boundary(last[len(last)-1])
+	conditions = append(conditions, fmt.Sprintf("match(%s, %s)", column, utils.ClickHouseFormattedValue(pattern)))
+	return "(" + strings.Join(conditions, " AND ") + ")", nil
+}
+
+// wildcardCondition selects the values with a word matching the pattern, case insensitively.
+// The * matches any characters of a word and the ? a single one, conn* is a prefix search
+func wildcardCondition(column, pattern string) (string, error) {
+	pattern = strings.TrimSpace(pattern)
+	if strings.ContainsAny(pattern, " \t\n") {
+		return "", fmt.Errorf("the wildcard pattern must be a single word")
+	}
+	conditions := []string{}
+	for _, fragment := range strings.FieldsFunc(pattern, func(r rune) bool { return r == '*' || r == '?' }) {
+		conditions = append(conditions, likeFragment(column, fragment))
+	}
+	if len(conditions) == 0 {
+		return "", fmt.Errorf("the wildcard pattern must have a character other than * and ?")
+	}
+	var regex strings.Builder
+	regex.WriteString(`(?i)` + boundary(pattern[0]))
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			regex.WriteString(`\w*`)
+		case '?':
+			regex.WriteString(`\w`)
+		default:
+			regex.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	regex.WriteString(boundary(pattern[len(pattern)-1]))
+	conditions = append(conditions, fmt.Sprintf("match(%s, %s)", column, utils.ClickHouseFormattedValue(regex.String())))
+	return "(" + strings.Join(conditions, " AND ") + ")", nil
+}
+
+// fuzzyCondition selects the values with a word within the edit distance of the term, the
+// term~N format sets the distance which defaults to the one of Elasticsearch for the length
+// of the term. The words are compared lower cased, no index skips the granules
+func fuzzyCondition(column, term string) (string, error) {
+	matches := fuzzyTermRegex.FindStringSubmatch(strings.TrimSpace(term))
+	if matches == nil {
+		return "", fmt.Errorf("the fuzzy term must be a single word, optionally followed by ~ and the edit distance")
+	}
+	word := strings.ToLower(matches[1])
+	fuzziness := autoFuzziness(word)
+	if matches[2] != "" {
+		fuzziness, _ = strconv.Atoi(matches[2])
+		if fuzziness > maxFuzziness {
+			return "", fmt.Errorf("the edit distance of the fuzzy term can't be more than %d", maxFuzziness)
+		}
+	}
+	return fmt.Sprintf("arrayExists(w -> editDistance(w, %s) <= %d, splitByNonAlpha(lower(%s)))",
+		utils.ClickHouseFormattedValue(word), fuzziness, column), nil
+}
+
+// autoFuzziness is the edit distance allowed for the term, none for the short terms
+func autoFuzziness(term string) int {
+	switch n := len([]rune(term)); {
+	case n <= 2:
+		return 0
+	case n <= 5:
+		return 1
+	default:
+		return maxFuzziness
+	}
+}
+
+// boundary returns the word boundary matched next to the character of a search, none when it
+// is not a word character, e.g. the / of /api, the wildcards match word characters
+func boundary(c byte) string {
+	if c == '*' || c == '?' || c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') {
+		return `\b`
+	}
+	return ""
+}
+
+// fullTextCondition returns the condition of the full text operator on the column
+func fullTextCondition(op v3.FilterOperator, column string, value interface{}) (string, error) {
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("the value of the %s operator must be a string", op)
+	}
+	var condition string
+	var err error
+	switch op {
+	case v3.FilterOperatorPhrase, v3.FilterOperatorNotPhrase:
+		condition, err = phraseCondition(column, text)
+	case v3.FilterOperatorWildcard, v3.FilterOperatorNotWildcard:
+		condition, err = wildcardCondition(column, text)
+	default:
+		condition, err = fuzzyCondition(column, text)
+	}
+	if err != nil {
+		return "", err
+	}
+	switch op {
+	case v3.FilterOperatorNotPhrase, v3.FilterOperatorNotWildcard, v3.FilterOperatorNotFuzzy:
+		return "NOT " + condition, nil
+	}
+	return condition, nil
+}
@@ -119,14 +119,20 @@ func GetJSONFilter(item v3.FilterItem) (string, error) {
 		dataType = v3.AttributeKeyDataType(val)
 	}
 
+	// non array
+	op := v3.FilterOperator(strings.ToLower(strings.TrimSpace(string(item.Operator))))
+
+	// the regex and contains operators match the text of the values of any data type
+	if !isArray && op.IsStringMatch() {
+		item.Key.DataType = v3.AttributeKeyDataTypeString
+		dataType = v3.AttributeKeyDataTypeString
+	}
+
 	key, err := getJSONFilterKey(item.Key, item.Operator, isArray)
 	if err != nil {
 		return "", err
 	}
 
-	// non array
-	op := v3.FilterOperator(strings.ToLower(strings.TrimSpace(string(item.Operator))))
-
 	var value interface{}
 	if op != v3.FilterOperatorExists && op != v3.FilterOperatorNotExists {
 		value, err = utils.ValidateAndCastValue(item.Value, dataType)
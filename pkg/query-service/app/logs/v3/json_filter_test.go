@@ -287,6 +287,19 @@ var testGetJSONFilterData = []struct {
 		},
 		Filter: "JSON_EXISTS(body, '$.\"message\"') AND match(JSON_VALUE(body, '$.\"message\"'), 'a*')",
 	},
+	{
+		Name: "regex operator on a number",
+		FilterItem: v3.FilterItem{
+			Key: v3.AttributeKey{
+				Key:      "body.status",
+				DataType: "int64",
+				IsJSON:   true,
+			},
+			Operator: "regex",
+			Value:    "^5",
+		},
+		Filter: "JSON_EXISTS(body, '$.\"status\"') AND match(JSON_VALUE(body, '$.\"status\"'), '^5')",
+	},
 	{
 		Name: "contains operator",
 		FilterItem: v3.FilterItem{
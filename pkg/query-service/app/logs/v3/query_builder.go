@@ -124,6 +124,16 @@ func getSelectKeys(aggregatorOperator v3.AggregateOperator, groupBy []v3.Attribu
 	return strings.Join(selectLabels, ",")
 }
 
+// stringColumnName returns the column of the key as a string, for the operators matching the
+// text of the values
+func stringColumnName(key v3.AttributeKey) string {
+	columnName := getClickhouseColumnName(key)
+	if key.DataType == v3.AttributeKeyDataTypeString || key.DataType == v3.AttributeKeyDataTypeUnspecified {
+		return columnName
+	}
+	return fmt.Sprintf("toString(%s)", columnName)
+}
+
 func GetExistsNexistsFilter(op v3.FilterOperator, item v3.FilterItem) string {
 	if item.Key.Type == v3.AttributeKeyTypeUnspecified {
 		top := "!="
@@ -131,9 +141,9 @@ func GetExistsNexistsFilter(op v3.FilterOperator, item v3.FilterItem) string {
 			top = "="
 		}
 		if val, ok := constants.StaticFieldsLogsV3[item.Key.Key]; ok {
-			// skip for timestamp and id
+			// timestamp and id always exist
 			if val.Key == "" {
-				return ""
+				return fmt.Sprintf("%v", op == v3.FilterOperatorExists)
 			}
 
 			columnName := getClickhouseColumnName(item.Key)
@@ -173,25 +183,37 @@ func buildLogsTimeSeriesFilterQuery(fs *v3.FilterSet, groupBy []v3.AttributeKey,
 
 			op := v3.FilterOperator(strings.ToLower(strings.TrimSpace(string(item.Operator))))
 
-			var value interface{}
+			var value interface{} = item.Value
 			var err error
-			if op != v3.FilterOperatorExists && op != v3.FilterOperatorNotExists {
+			// the regex and contains operators match the values of any data type as strings
+			if op.IsStringMatch() && item.Key.DataType != v3.AttributeKeyDataTypeString {
+				value = fmt.Sprintf("%v", item.Value)
+			} else if op != v3.FilterOperatorExists && op != v3.FilterOperatorNotExists {
 				value, err = utils.ValidateAndCastValue(item.Value, item.Key.DataType)
 				if err != nil {
 					return "", fmt.Errorf("failed to validate and cast value for %s: %v", item.Key.Key, err)
 				}
 			}
 
+			if isFullTextOperator(op) {
+				condition, err := fullTextCondition(op, getClickhouseColumnName(item.Key), value)
+				if err != nil {
+					return "", fmt.Errorf("invalid %s filter for %s: %v", op, item.Key.Key, err)
+				}
+				conditions = append(conditions, condition)
+				continue
+			}
+
 			if logsOp, ok := logOperators[op]; ok {
 				switch op {
 				case v3.FilterOperatorExists, v3.FilterOperatorNotExists:
 					conditions = append(conditions, GetExistsNexistsFilter(op, item))
 				case v3.FilterOperatorRegex, v3.FilterOperatorNotRegex:
-					columnName := getClickhouseColumnName(item.Key)
+					columnName := stringColumnName(item.Key)
 					fmtVal := utils.ClickHouseFormattedValue(value)
 					conditions = append(conditions, fmt.Sprintf(logsOp, columnName, fmtVal))
 				case v3.FilterOperatorContains, v3.FilterOperatorNotContains:
-					columnName := getClickhouseColumnName(item.Key)
+					columnName := stringColumnName(item.Key)
 					val := utils.QuoteEscapedString(fmt.Sprintf("%v", item.Value))
 					conditions = append(conditions, fmt.Sprintf("%s %s '%%%s%%'", columnName, logsOp, val))
 				default:
@@ -228,6 +250,9 @@ func buildLogsTimeSeriesFilterQuery(fs *v3.FilterSet, groupBy []v3.AttributeKey,
 }
 
 func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.BuilderQuery, graphLimitQtype string, preferRPM bool) (string, error) {
+	if view := matchAggregateView(panelType, start, end, step, mq); view != nil {
+		return buildLogsViewQuery(view, panelType, start, end, step, mq, graphLimitQtype, preferRPM)
+	}
 
 	filterSubQuery, err := buildLogsTimeSeriesFilterQuery(mq.Filters, mq.GroupBy, mq.AggregateAttribute)
 	if err != nil {
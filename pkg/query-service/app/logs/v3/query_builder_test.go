@@ -287,6 +287,91 @@ var timeSeriesFilterQueryData = []struct {
 		}},
 		ExpectedFilter: "`attribute_int64_status_exists`=false",
 	},
+	{
+		Name: "Test regex on a number attribute",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "status", DataType: v3.AttributeKeyDataTypeInt64, Type: v3.AttributeKeyTypeTag}, Value: "^5..$", Operator: "regex"},
+		}},
+		ExpectedFilter: "match(toString(attributes_int64_value[indexOf(attributes_int64_key, 'status')]), '^5..$')",
+	},
+	{
+		Name: "Test not contains on a number attribute",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "bytes", DataType: v3.AttributeKeyDataTypeFloat64, Type: v3.AttributeKeyTypeTag}, Value: 10, Operator: "ncontains"},
+		}},
+		ExpectedFilter: "toString(attributes_float64_value[indexOf(attributes_float64_key, 'bytes')]) NOT ILIKE '%10%'",
+	},
+	{
+		Name: "Test exists and not exists on timestamp",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "timestamp", DataType: v3.AttributeKeyDataTypeInt64, Type: v3.AttributeKeyTypeUnspecified, IsColumn: true}, Operator: "exists"},
+			{Key: v3.AttributeKey{Key: "id", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeUnspecified, IsColumn: true}, Operator: "nexists"},
+		}},
+		ExpectedFilter: "true AND false",
+	},
+	{
+		Name: "Test phrase",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "body", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Value: "quick  Brown fox", Operator: "phrase"},
+		}},
+		ExpectedFilter: "(lower(body) LIKE '%quick%' AND lower(body) LIKE '%brown%' AND lower(body) LIKE '%fox%' AND match(body, '(?i)\\\\bquick\\\\s+Brown\\\\s+fox\\\\b'))",
+	},
+	{
+		Name: "Test not phrase",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "body", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Value: "/api/v1", Operator: "nphrase"},
+		}},
+		ExpectedFilter: "NOT (lower(body) LIKE '%/api/v1%' AND match(body, '(?i)/api/v1\\\\b'))",
+	},
+	{
+		Name: "Test prefix wildcard",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "body", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Value: "conn*", Operator: "wildcard"},
+		}},
+		ExpectedFilter: "(lower(body) LIKE '%conn%' AND match(body, '(?i)\\\\bconn\\\\w*\\\\b'))",
+	},
+	{
+		Name: "Test not wildcard",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "body", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Value: "t?m*out", Operator: "nwildcard"},
+		}},
+		ExpectedFilter: "NOT (lower(body) LIKE '%t%' AND lower(body) LIKE '%m%' AND lower(body) LIKE '%out%' AND match(body, '(?i)\\\\bt\\\\wm\\\\w*out\\\\b'))",
+	},
+	{
+		Name: "Test fuzzy",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "body", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Value: "Conection", Operator: "fuzzy"},
+		}},
+		ExpectedFilter: "arrayExists(w -> editDistance(w, 'conection') <= 2, splitByNonAlpha(lower(body)))",
+	},
+	{
+		Name: "Test not fuzzy with distance",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "body", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Value: "timeout~1", Operator: "nfuzzy"},
+		}},
+		ExpectedFilter: "NOT arrayExists(w -> editDistance(w, 'timeout') <= 1, splitByNonAlpha(lower(body)))",
+	},
+	{
+		Name: "Test wildcard without characters",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "body", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Value: "*?", Operator: "wildcard"},
+		}},
+		Error: "invalid wildcard filter for body: the wildcard pattern must have a character other than * and ?",
+	},
+	{
+		Name: "Test fuzzy with large distance",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "body", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Value: "timeout~3", Operator: "fuzzy"},
+		}},
+		Error: "invalid fuzzy filter for body: the edit distance of the fuzzy term can't be more than 2",
+	},
+	{
+		Name: "Test empty phrase",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "body", DataType: v3.AttributeKeyDataTypeString, IsColumn: true}, Value: " ", Operator: "phrase"},
+		}},
+		Error: "invalid phrase filter for body: the phrase can't be empty",
+	},
 }
 
 func TestBuildLogsTimeSeriesFilterQuery(t *testing.T) {
@@ -0,0 +1,235 @@
+package v3
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+// AggregateView is a materialized view counting the logs per minute by the values of its keys,
+// the count queries filtering and grouping on the keys only are answered from the view
+type AggregateView struct {
+	Id string
+	// Table is the distributed table of the view
+	Table string
+	Keys  []v3.AttributeKey
+	// From is the time in epoch millisecond the view has the logs since
+	From int64
+}
+
+var aggregateViews struct {
+	sync.RWMutex
+	views []AggregateView
+	onHit func(id string)
+}
+
+// SetAggregateViews replaces the views the logs queries are planned with, onHit is called
+// with the id of the view each time a query is answered from it
+func SetAggregateViews(views []AggregateView, onHit func(id string)) {
+	aggregateViews.Lock()
+	defer aggregateViews.Unlock()
+	aggregateViews.views = views
+	aggregateViews.onHit = onHit
+}
+
+// ViewColumn returns the column of the key in an aggregate view, the attributes are named
+// the same as their materialized columns
+func ViewColumn(key v3.AttributeKey) string {
+	key.IsColumn = true
+	if key.Type == v3.AttributeKeyTypeUnspecified {
+		return "`" + key.Key + "`"
+	}
+	return getClickhouseColumnName(key)
+}
+
+// ViewExpression returns the expression of the value of the key in the logs table
+func ViewExpression(key v3.AttributeKey) string {
+	key.IsColumn = key.Type == v3.AttributeKeyTypeUnspecified
+	return getClickhouseColumnName(key)
+}
+
+// ViewExistsExpression returns the expression telling whether the log has the key, it is
+// empty for the top level fields which are always there
+func ViewExistsExpression(key v3.AttributeKey) string {
+	if key.Type == v3.AttributeKeyTypeUnspecified {
+		return ""
+	}
+	return fmt.Sprintf("has(%s_%s_key, '%s')", getClickhouseLogsColumnType(key.Type), getClickhouseLogsColumnDataType(key.DataType), key.Key)
+}
+
+// has returns true when the key is one of the keys of the view
+func (v *AggregateView) has(key v3.AttributeKey) bool {
+	for _, k := range v.Keys {
+		if k.Key == key.Key && k.Type == key.Type && k.DataType == key.DataType {
+			return true
+		}
+	}
+	return false
+}
+
+// asViewKeys returns the keys as the columns of a view
+func asViewKeys(keys []v3.AttributeKey) []v3.AttributeKey {
+	columns := make([]v3.AttributeKey, len(keys))
+	for i, key := range keys {
+		columns[i] = key
+		columns[i].IsColumn = true
+	}
+	return columns
+}
+
+// matchAggregateView returns the view the query can be answered from, nil when there is none.
+// The view has whole minutes only, the query must count the logs in steps of whole minutes
+// and span at least a whole minute after the view started
+func matchAggregateView(panelType v3.PanelType, start, end, step int64, mq *v3.BuilderQuery) *AggregateView {
+	if mq.AggregateOperator != v3.AggregateOperatorCount && mq.AggregateOperator != v3.AggregateOperatorRate {
+		return nil
+	}
+	if mq.AggregateAttribute.Key != "" {
+		return nil
+	}
+	if panelType != v3.PanelTypeTable && step%60 != 0 {
+		return nil
+	}
+	if panelType != v3.PanelTypeTable && panelType != v3.PanelTypeGraph && panelType != v3.PanelTypeValue {
+		return nil
+	}
+	if mq.Filters != nil && mq.Filters.Operator != "" && strings.ToUpper(mq.Filters.Operator) != "AND" {
+		return nil
+	}
+	if first, last := minuteRange(start, end); last <= first {
+		return nil
+	}
+
+	aggregateViews.RLock()
+	defer aggregateViews.RUnlock()
+views:
+	for i := range aggregateViews.views {
+		view := &aggregateViews.views[i]
+		if start < view.From {
+			continue
+		}
+		if mq.Filters != nil {
+			for _, item := range mq.Filters.Items {
+				if item.Key.IsJSON || !view.has(item.Key) {
+					continue views
+				}
+			}
+		}
+		for _, key := range mq.GroupBy {
+			if !view.has(key) {
+				continue views
+			}
+		}
+		if aggregateViews.onHit != nil {
+			aggregateViews.onHit(view.Id)
+		}
+		return view
+	}
+	return nil
+}
+
+// minuteRange returns the start of the first and the end of the last whole minutes of the time
+// range, in epoch millisecond
+func minuteRange(start, end int64) (int64, int64) {
+	first := (start + 59999) / 60000 * 60000
+	last := end / 60000 * 60000
+	return first, last
+}
+
+// buildLogsViewQuery builds the count query from the whole minutes of the view and the logs of
+// the partial minutes at the edges of the time range, so the counts are the ones of the logs table
+func buildLogsViewQuery(view *AggregateView, panelType v3.PanelType, start, end, step int64, mq *v3.BuilderQuery, graphLimitQtype string, preferRPM bool) (string, error) {
+	first, last := minuteRange(start, end)
+
+	viewFilter, err := buildLogsTimeSeriesFilterQuery(withViewKeys(mq.Filters), asViewKeys(mq.GroupBy), v3.AttributeKey{})
+	if err != nil {
+		return "", err
+	}
+	if viewFilter != "" {
+		viewFilter = " AND " + viewFilter
+	}
+	logsFilter, err := buildLogsTimeSeriesFilterQuery(mq.Filters, mq.GroupBy, v3.AttributeKey{})
+	if err != nil {
+		return "", err
+	}
+	if logsFilter != "" {
+		logsFilter = " AND " + logsFilter
+	}
+
+	viewLabels := getSelectLabels(mq.AggregateOperator, asViewKeys(mq.GroupBy))
+	logsLabels := getSelectLabels(mq.AggregateOperator, mq.GroupBy)
+	keys := getSelectKeys(mq.AggregateOperator, mq.GroupBy)
+
+	viewQuery := fmt.Sprintf("SELECT bucket,%s count AS c from signoz_logs.%s where (bucket >= toDateTime(%d) AND bucket < toDateTime(%d))%s",
+		viewLabels, view.Table, first/1000, last/1000, viewFilter)
+	edgesGroupBy := "bucket"
+	if keys != "" {
+		edgesGroupBy += "," + keys
+	}
+	edgesQuery := fmt.Sprintf("SELECT toStartOfMinute(fromUnixTimestamp64Nano(timestamp)) AS bucket,%s count() AS c from signoz_logs.distributed_logs "+
+		"where ((timestamp >= %d AND timestamp < %d) OR (timestamp >= %d AND timestamp <= %d))%s group by %s",
+		logsLabels, utils.GetEpochNanoSecs(start), utils.GetEpochNanoSecs(first), utils.GetEpochNanoSecs(last), utils.GetEpochNanoSecs(end), logsFilter, edgesGroupBy)
+
+	selectKeys := ""
+	if keys != "" {
+		selectKeys = " " + keys + ","
+	}
+
+	var queryTmpl string
+	if graphLimitQtype == constants.FirstQueryGraphLimit {
+		queryTmpl = "SELECT"
+	} else if panelType == v3.PanelTypeTable {
+		queryTmpl = "SELECT now() as ts,"
+		step = (utils.GetEpochNanoSecs(end) - utils.GetEpochNanoSecs(start)) / 1000000000
+	} else {
+		queryTmpl = fmt.Sprintf("SELECT toStartOfInterval(bucket, INTERVAL %d SECOND) AS ts,", step)
+	}
+
+	value := "toFloat64(sum(c))"
+	if mq.AggregateOperator == v3.AggregateOperatorRate {
+		rate := float64(step)
+		if preferRPM {
+			rate = rate / 60.0
+		}
+		value = fmt.Sprintf("sum(c)/%f", rate)
+	}
+
+	where := ""
+	if graphLimitQtype == constants.SecondQueryGraphLimit {
+		where = fmt.Sprintf(" where (%s) GLOBAL IN (#LIMIT_PLACEHOLDER)", keys)
+	}
+
+	groupBy := groupByAttributeKeyTags(panelType, graphLimitQtype, mq.GroupBy...)
+	if groupBy != "" {
+		groupBy = " group by " + groupBy
+	}
+	having := having(mq.Having)
+	if having != "" {
+		having = " having " + having
+	}
+	orderBy := " order by " + orderByAttributeKeyTags(panelType, mq.OrderBy, mq.GroupBy)
+
+	query := fmt.Sprintf("%s%s %s as value from (%s UNION ALL %s)%s%s%s%s",
+		queryTmpl, selectKeys, value, viewQuery, edgesQuery, where, groupBy, having, orderBy)
+	if graphLimitQtype == constants.FirstQueryGraphLimit {
+		query = "SELECT " + keys + " from (" + query + ")"
+	}
+	return query, nil
+}
+
+// withViewKeys returns the filters on the columns of a view
+func withViewKeys(fs *v3.FilterSet) *v3.FilterSet {
+	if fs == nil {
+		return nil
+	}
+	items := make([]v3.FilterItem, len(fs.Items))
+	for i, item := range fs.Items {
+		items[i] = item
+		items[i].Key.IsColumn = true
+	}
+	return &v3.FilterSet{Operator: fs.Operator, Items: items}
+}
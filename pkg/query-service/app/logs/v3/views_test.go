@@ -0,0 +1,83 @@
+package v3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestAggregateViewPlanning(t *testing.T) {
+	namespace := v3.AttributeKey{Key: "k8s.namespace.name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource}
+	severity := v3.AttributeKey{Key: "severity_text", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeUnspecified, IsColumn: true}
+	method := v3.AttributeKey{Key: "method", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}
+
+	hits := map[string]int{}
+	SetAggregateViews([]AggregateView{
+		{Id: "ns", Table: "distributed_logs_view_ns", Keys: []v3.AttributeKey{namespace, severity}, From: 1680066000000},
+	}, func(id string) { hits[id]++ })
+	defer SetAggregateViews(nil, nil)
+
+	countBy := func(filters []v3.FilterItem, groupBy ...v3.AttributeKey) *v3.BuilderQuery {
+		return &v3.BuilderQuery{
+			QueryName:         "A",
+			StepInterval:      60,
+			AggregateOperator: v3.AggregateOperatorCount,
+			Expression:        "A",
+			Filters:           &v3.FilterSet{Operator: "AND", Items: filters},
+			GroupBy:           groupBy,
+		}
+	}
+	inNamespace := []v3.FilterItem{{Key: namespace, Operator: v3.FilterOperatorEqual, Value: "prod"}}
+
+	t.Run("count on the keys", func(t *testing.T) {
+		query, err := PrepareLogsQuery(1680066360726, 1680066658000, "", v3.PanelTypeGraph, countBy(inNamespace, severity), Options{})
+		require.NoError(t, err)
+		require.Equal(t, "SELECT toStartOfInterval(bucket, INTERVAL 60 SECOND) AS ts, `severity_text`, toFloat64(sum(c)) as value from ("+
+			"SELECT bucket, severity_text as `severity_text`, count AS c from signoz_logs.distributed_logs_view_ns "+
+			"where (bucket >= toDateTime(1680066420) AND bucket < toDateTime(1680066600)) AND `resource_string_k8s$$namespace$$name` = 'prod' "+
+			"UNION ALL "+
+			"SELECT toStartOfMinute(fromUnixTimestamp64Nano(timestamp)) AS bucket, severity_text as `severity_text`, count() AS c from signoz_logs.distributed_logs "+
+			"where ((timestamp >= 1680066360726000000 AND timestamp < 1680066420000000000) OR (timestamp >= 1680066600000000000 AND timestamp <= 1680066658000000000)) "+
+			"AND resources_string_value[indexOf(resources_string_key, 'k8s.namespace.name')] = 'prod' group by bucket,`severity_text`) "+
+			"group by `severity_text`,ts order by value DESC", query)
+		require.Equal(t, 1, hits["ns"])
+	})
+
+	t.Run("limit queries", func(t *testing.T) {
+		query, err := PrepareLogsQuery(1680066000000, 1680069600000, "", v3.PanelTypeGraph, countBy(nil, namespace), Options{GraphLimitQtype: constants.SecondQueryGraphLimit})
+		require.NoError(t, err)
+		require.Contains(t, query, "`resource_string_k8s$$namespace$$name_exists`=true")
+		require.Contains(t, query, "has(resources_string_key, 'k8s.namespace.name')")
+		require.Contains(t, query, " where (`k8s.namespace.name`) GLOBAL IN (#LIMIT_PLACEHOLDER) group by `k8s.namespace.name`,ts")
+	})
+
+	cases := []struct {
+		name      string
+		panelType v3.PanelType
+		start     int64
+		query     *v3.BuilderQuery
+	}{
+		{name: "filter on another key", panelType: v3.PanelTypeGraph, start: 1680066360726, query: countBy([]v3.FilterItem{{Key: method, Operator: v3.FilterOperatorEqual, Value: "GET"}})},
+		{name: "group by another key", panelType: v3.PanelTypeGraph, start: 1680066360726, query: countBy(inNamespace, method)},
+		{name: "before the view", panelType: v3.PanelTypeGraph, start: 1680065000000, query: countBy(inNamespace)},
+		{name: "list", panelType: v3.PanelTypeList, start: 1680066360726, query: countBy(inNamespace)},
+		{name: "sub minute step", panelType: v3.PanelTypeGraph, start: 1680066360726, query: func() *v3.BuilderQuery {
+			q := countBy(inNamespace)
+			q.StepInterval = 30
+			return q
+		}()},
+		{name: "not a count", panelType: v3.PanelTypeGraph, start: 1680066360726, query: func() *v3.BuilderQuery {
+			q := countBy(inNamespace)
+			q.AggregateOperator = v3.AggregateOperatorCountDistinct
+			q.AggregateAttribute = method
+			return q
+		}()},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Nil(t, matchAggregateView(c.panelType, c.start, 1680069600000, c.query.StepInterval, c.query))
+		})
+	}
+}
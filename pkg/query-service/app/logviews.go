@@ -0,0 +1,60 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/app/logviews"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// listLogsViews lists the views of the logs with how many queries used them
+func (aH *APIHandler) listLogsViews(w http.ResponseWriter, r *http.Request) {
+	views, apiErr := aH.LogsViewManager.List(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, views)
+}
+
+func (aH *APIHandler) getLogsView(w http.ResponseWriter, r *http.Request) {
+	view, apiErr := aH.LogsViewManager.Get(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, view)
+}
+
+// createLogsView creates the materialized view or the projection of the logs on the keys
+func (aH *APIHandler) createLogsView(w http.ResponseWriter, r *http.Request) {
+	var postable logviews.PostableView
+	if err := json.NewDecoder(r.Body).Decode(&postable); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	userId, err := auth.ExtractUserIdFromContext(r.Context())
+	if err != nil {
+		RespondError(w, model.UnauthorizedError(errors.Wrap(err, "failed to get userId from context")), nil)
+		return
+	}
+
+	view, apiErr := aH.LogsViewManager.Create(r.Context(), userId, &postable)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, view)
+}
+
+func (aH *APIHandler) deleteLogsView(w http.ResponseWriter, r *http.Request) {
+	if apiErr := aH.LogsViewManager.Delete(r.Context(), mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
@@ -0,0 +1,102 @@
+package logviews
+
+import (
+	"fmt"
+	"strings"
+
+	logsV3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const (
+	logsDB         = "signoz_logs"
+	logsLocalTable = "logs"
+)
+
+var columnTypes = map[v3.AttributeKeyDataType]string{
+	v3.AttributeKeyDataTypeString:  "String",
+	v3.AttributeKeyDataTypeInt64:   "Int64",
+	v3.AttributeKeyDataTypeFloat64: "Float64",
+	v3.AttributeKeyDataTypeBool:    "Bool",
+}
+
+// existsColumn returns the column telling whether the log has the key, empty for the
+// top level fields
+func existsColumn(key v3.AttributeKey) string {
+	if key.Type == v3.AttributeKeyTypeUnspecified {
+		return ""
+	}
+	return strings.TrimSuffix(logsV3.ViewColumn(key), "`") + "_exists`"
+}
+
+// createStatements returns the statements creating the view, they can be run again
+func createStatements(v *View, cluster string) []string {
+	switch v.Kind {
+	case KindProjection:
+		exprs := []string{}
+		for _, key := range v.Keys {
+			exprs = append(exprs, logsV3.ViewExpression(key))
+		}
+		exprs = append(exprs, "timestamp")
+		return []string{
+			fmt.Sprintf("ALTER TABLE %s.%s ON CLUSTER %s ADD PROJECTION IF NOT EXISTS %s (SELECT * ORDER BY (%s))",
+				logsDB, logsLocalTable, cluster, v.projection(), strings.Join(exprs, ", ")),
+			// the parts written before the projection was added get it in the background
+			fmt.Sprintf("ALTER TABLE %s.%s ON CLUSTER %s MATERIALIZE PROJECTION %s",
+				logsDB, logsLocalTable, cluster, v.projection()),
+		}
+	default:
+		columns, sortKey, selects, groupBy := []string{"bucket DateTime CODEC(DoubleDelta, LZ4)"}, []string{}, []string{}, []string{"bucket"}
+		for _, key := range v.Keys {
+			column := logsV3.ViewColumn(key)
+			columns = append(columns, fmt.Sprintf("%s %s CODEC(ZSTD(1))", column, columnTypes[key.DataType]))
+			sortKey = append(sortKey, column)
+			selects = append(selects, fmt.Sprintf("%s AS %s", logsV3.ViewExpression(key), column))
+			groupBy = append(groupBy, column)
+			if exists := existsColumn(key); exists != "" {
+				columns = append(columns, fmt.Sprintf("%s Bool CODEC(ZSTD(1))", exists))
+				sortKey = append(sortKey, exists)
+				selects = append(selects, fmt.Sprintf("%s AS %s", logsV3.ViewExistsExpression(key), exists))
+				groupBy = append(groupBy, exists)
+			}
+		}
+		columns = append(columns, "count UInt64 CODEC(ZSTD(1))")
+		sortKey = append(sortKey, "bucket")
+
+		return []string{
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s ON CLUSTER %s (%s) ENGINE = SummingMergeTree PARTITION BY toDate(bucket) ORDER BY (%s)",
+				logsDB, v.localTable(), cluster, strings.Join(columns, ", "), strings.Join(sortKey, ", ")),
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s ON CLUSTER %s AS %s.%s ENGINE = Distributed('%s', '%s', '%s', rand())",
+				logsDB, v.Table(), cluster, logsDB, v.localTable(), cluster, logsDB, v.localTable()),
+			fmt.Sprintf("CREATE MATERIALIZED VIEW IF NOT EXISTS %s.%s ON CLUSTER %s TO %s.%s AS "+
+				"SELECT toStartOfMinute(fromUnixTimestamp64Nano(timestamp)) AS bucket, %s, count() AS count FROM %s.%s GROUP BY %s",
+				logsDB, v.materializedView(), cluster, logsDB, v.localTable(),
+				strings.Join(selects, ", "), logsDB, logsLocalTable, strings.Join(groupBy, ", ")),
+		}
+	}
+}
+
+// dropStatements returns the statements dropping the view, they can be run again
+func dropStatements(v *View, cluster string) []string {
+	switch v.Kind {
+	case KindProjection:
+		return []string{
+			fmt.Sprintf("ALTER TABLE %s.%s ON CLUSTER %s DROP PROJECTION IF EXISTS %s", logsDB, logsLocalTable, cluster, v.projection()),
+		}
+	default:
+		// the materialized view goes first so that no logs are written to the dropped tables
+		return []string{
+			fmt.Sprintf("DROP VIEW IF EXISTS %s.%s ON CLUSTER %s", logsDB, v.materializedView(), cluster),
+			fmt.Sprintf("DROP TABLE IF EXISTS %s.%s ON CLUSTER %s", logsDB, v.Table(), cluster),
+			fmt.Sprintf("DROP TABLE IF EXISTS %s.%s ON CLUSTER %s", logsDB, v.localTable(), cluster),
+		}
+	}
+}
+
+// projectionUsageQuery returns the query counting the queries clickhouse answered from the
+// projection of a view, and when it last did
+func projectionUsageQuery(v *View, cluster string) string {
+	return fmt.Sprintf("SELECT count() AS hits, max(event_time) AS last_used FROM clusterAllReplicas('%s', system.query_log) "+
+		"WHERE type = 'QueryFinish' AND event_time >= toDateTime(%d) AND has(projections, '%s.%s.%s')",
+		cluster, v.CreatedAt.Unix(), logsDB, logsLocalTable, v.projection())
+}
@@ -0,0 +1,76 @@
+package logviews
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestValidate(t *testing.T) {
+	namespace := v3.AttributeKey{Key: "k8s.namespace.name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource, IsColumn: true}
+	cases := []struct {
+		name    string
+		view    PostableView
+		wantErr bool
+	}{
+		{name: "aggregate", view: PostableView{Name: "by_namespace", Keys: Keys{namespace}}},
+		{name: "static field", view: PostableView{Name: "by_severity", Kind: KindProjection, Keys: Keys{{Key: "severity_text", Type: v3.AttributeKeyTypeUnspecified}}}},
+		{name: "bad name", view: PostableView{Name: "by-namespace", Keys: Keys{namespace}}, wantErr: true},
+		{name: "no keys", view: PostableView{Name: "none"}, wantErr: true},
+		{name: "unknown kind", view: PostableView{Name: "x", Kind: "index", Keys: Keys{namespace}}, wantErr: true},
+		{name: "body", view: PostableView{Name: "x", Keys: Keys{{Key: "body", Type: v3.AttributeKeyTypeUnspecified, DataType: v3.AttributeKeyDataTypeString}}}, wantErr: true},
+		{name: "unknown field", view: PostableView{Name: "x", Keys: Keys{{Key: "namespace", Type: v3.AttributeKeyTypeUnspecified, DataType: v3.AttributeKeyDataTypeString}}}, wantErr: true},
+		{name: "quoted key", view: PostableView{Name: "x", Keys: Keys{{Key: "a'b", Type: v3.AttributeKeyTypeTag, DataType: v3.AttributeKeyDataTypeString}}}, wantErr: true},
+		{name: "repeated key", view: PostableView{Name: "x", Keys: Keys{namespace, namespace}}, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.view.Validate()
+			if c.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for _, key := range c.view.Keys {
+				require.False(t, key.IsColumn)
+			}
+		})
+	}
+}
+
+func TestCreateStatements(t *testing.T) {
+	view := &View{
+		Name: "by_namespace",
+		Kind: KindAggregate,
+		Keys: Keys{
+			{Key: "k8s.namespace.name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource},
+			{Key: "severity_text", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeUnspecified},
+		},
+		CreatedAt: time.Unix(1700000000, 0),
+	}
+	require.Equal(t, []string{
+		"CREATE TABLE IF NOT EXISTS signoz_logs.logs_view_by_namespace ON CLUSTER cluster (bucket DateTime CODEC(DoubleDelta, LZ4), " +
+			"`resource_string_k8s$$namespace$$name` String CODEC(ZSTD(1)), `resource_string_k8s$$namespace$$name_exists` Bool CODEC(ZSTD(1)), " +
+			"`severity_text` String CODEC(ZSTD(1)), count UInt64 CODEC(ZSTD(1))) ENGINE = SummingMergeTree PARTITION BY toDate(bucket) " +
+			"ORDER BY (`resource_string_k8s$$namespace$$name`, `resource_string_k8s$$namespace$$name_exists`, `severity_text`, bucket)",
+		"CREATE TABLE IF NOT EXISTS signoz_logs.distributed_logs_view_by_namespace ON CLUSTER cluster AS signoz_logs.logs_view_by_namespace " +
+			"ENGINE = Distributed('cluster', 'signoz_logs', 'logs_view_by_namespace', rand())",
+		"CREATE MATERIALIZED VIEW IF NOT EXISTS signoz_logs.logs_view_by_namespace_mv ON CLUSTER cluster TO signoz_logs.logs_view_by_namespace AS " +
+			"SELECT toStartOfMinute(fromUnixTimestamp64Nano(timestamp)) AS bucket, " +
+			"resources_string_value[indexOf(resources_string_key, 'k8s.namespace.name')] AS `resource_string_k8s$$namespace$$name`, " +
+			"has(resources_string_key, 'k8s.namespace.name') AS `resource_string_k8s$$namespace$$name_exists`, severity_text AS `severity_text`, " +
+			"count() AS count FROM signoz_logs.logs GROUP BY bucket, `resource_string_k8s$$namespace$$name`, `resource_string_k8s$$namespace$$name_exists`, `severity_text`",
+	}, createStatements(view, "cluster"))
+
+	view.Kind = KindProjection
+	require.Equal(t, []string{
+		"ALTER TABLE signoz_logs.logs ON CLUSTER cluster ADD PROJECTION IF NOT EXISTS view_by_namespace " +
+			"(SELECT * ORDER BY (resources_string_value[indexOf(resources_string_key, 'k8s.namespace.name')], severity_text, timestamp))",
+		"ALTER TABLE signoz_logs.logs ON CLUSTER cluster MATERIALIZE PROJECTION view_by_namespace",
+	}, createStatements(view, "cluster"))
+	require.Equal(t, []string{
+		"ALTER TABLE signoz_logs.logs ON CLUSTER cluster DROP PROJECTION IF EXISTS view_by_namespace",
+	}, dropStatements(view, "cluster"))
+}
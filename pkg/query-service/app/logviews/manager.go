@@ -0,0 +1,242 @@
+package logviews
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	logsV3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// hitsFlushInterval is how often the queries answered from the views are counted in the db
+const hitsFlushInterval = time.Minute
+
+type ManagerOptions struct {
+	DB      *sqlx.DB
+	Conn    clickhouse.Conn
+	Cluster string
+}
+
+// Manager creates and drops the views of the logs and hands the aggregate views to the
+// logs query builder, which answers the count queries on their keys from them
+type Manager struct {
+	repo    *repo
+	conn    clickhouse.Conn
+	cluster string
+
+	mtx      sync.Mutex
+	hits     map[string]int64
+	lastUsed map[string]time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	if err := InitSqliteDBIfNeeded(opts.DB); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		repo:     &repo{db: opts.DB},
+		conn:     opts.Conn,
+		cluster:  opts.Cluster,
+		hits:     map[string]int64{},
+		lastUsed: map[string]time.Time{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Start plans the logs queries with the stored views and counts their hits
+func (m *Manager) Start() error {
+	if err := m.plan(context.Background()); err != nil {
+		return err
+	}
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(hitsFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stop:
+				m.flushHits()
+				return
+			case <-ticker.C:
+				m.flushHits()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops counting the hits of the views
+func (m *Manager) Stop() {
+	close(m.stop)
+	<-m.done
+}
+
+// plan hands the aggregate views to the logs query builder
+func (m *Manager) plan(ctx context.Context) error {
+	views, apiErr := m.repo.list(ctx)
+	if apiErr != nil {
+		return apiErr.Err
+	}
+	aggregates := []logsV3.AggregateView{}
+	for _, v := range views {
+		if v.Kind != KindAggregate {
+			continue
+		}
+		aggregates = append(aggregates, logsV3.AggregateView{Id: v.Id, Table: v.Table(), Keys: v.Keys, From: v.From.UnixMilli()})
+	}
+	logsV3.SetAggregateViews(aggregates, m.hit)
+	return nil
+}
+
+func (m *Manager) hit(id string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.hits[id]++
+	m.lastUsed[id] = time.Now()
+}
+
+func (m *Manager) flushHits() {
+	m.mtx.Lock()
+	hits, lastUsed := m.hits, m.lastUsed
+	m.hits, m.lastUsed = map[string]int64{}, map[string]time.Time{}
+	m.mtx.Unlock()
+
+	for id, count := range hits {
+		if err := m.repo.addHits(context.Background(), id, count, lastUsed[id]); err != nil {
+			zap.L().Error("failed to record the hits of the logs view", zap.String("id", id), zap.Error(err))
+		}
+	}
+}
+
+// usage adds the hits not yet flushed to the aggregate views, and the queries clickhouse
+// answered from the projection to the projection views
+func (m *Manager) usage(ctx context.Context, v *View) {
+	if v.Kind == KindAggregate {
+		m.mtx.Lock()
+		defer m.mtx.Unlock()
+		v.Hits += m.hits[v.Id]
+		if lastUsed, ok := m.lastUsed[v.Id]; ok {
+			v.LastUsedAt = &lastUsed
+		}
+		return
+	}
+
+	var usage struct {
+		Hits     uint64    `ch:"hits"`
+		LastUsed time.Time `ch:"last_used"`
+	}
+	if err := m.conn.QueryRow(ctx, projectionUsageQuery(v, m.cluster)).ScanStruct(&usage); err != nil {
+		zap.L().Error("failed to get the usage of the logs projection", zap.String("id", v.Id), zap.Error(err))
+		return
+	}
+	v.Hits = int64(usage.Hits)
+	if usage.Hits > 0 {
+		v.LastUsedAt = &usage.LastUsed
+	}
+}
+
+func (m *Manager) List(ctx context.Context) ([]*View, *model.ApiError) {
+	views, apiErr := m.repo.list(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for _, v := range views {
+		m.usage(ctx, v)
+	}
+	return views, nil
+}
+
+func (m *Manager) Get(ctx context.Context, id string) (*View, *model.ApiError) {
+	v, apiErr := m.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	m.usage(ctx, v)
+	return v, nil
+}
+
+// Create creates the view in clickhouse, the aggregate views have the logs written from
+// the next minute on. The clickhouse objects of a view failing to be created are dropped
+func (m *Manager) Create(ctx context.Context, userId string, postable *PostableView) (*View, *model.ApiError) {
+	if err := postable.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	exists, apiErr := m.repo.exists(ctx, postable.Name)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if exists {
+		return nil, model.BadRequest(fmt.Errorf("a logs view named %s already exists", postable.Name))
+	}
+
+	now := time.Now()
+	v := &View{
+		Id:          uuid.NewString(),
+		Name:        postable.Name,
+		Description: postable.Description,
+		Kind:        postable.Kind,
+		Keys:        postable.Keys,
+		From:        now,
+		CreatedBy:   userId,
+		CreatedAt:   now,
+	}
+	if v.Kind == KindAggregate {
+		// the minute the view is created in is only partly in it
+		v.From = now.Truncate(time.Minute).Add(time.Minute)
+	}
+
+	for _, stmt := range createStatements(v, m.cluster) {
+		if err := m.conn.Exec(ctx, stmt); err != nil {
+			zap.L().Error("failed to create the logs view", zap.String("name", v.Name), zap.Error(err))
+			m.drop(v)
+			return nil, model.InternalError(fmt.Errorf("failed to create the logs view %s: %w", v.Name, err))
+		}
+	}
+	if apiErr := m.repo.insert(ctx, v); apiErr != nil {
+		m.drop(v)
+		return nil, apiErr
+	}
+	if err := m.plan(ctx); err != nil {
+		zap.L().Error("failed to plan the logs queries with the views", zap.Error(err))
+	}
+	return v, nil
+}
+
+// drop drops what was created of the view
+func (m *Manager) drop(v *View) {
+	for _, stmt := range dropStatements(v, m.cluster) {
+		if err := m.conn.Exec(context.Background(), stmt); err != nil {
+			zap.L().Error("failed to drop the logs view", zap.String("name", v.Name), zap.Error(err))
+		}
+	}
+}
+
+// Delete stops planning the queries on the view and drops it from clickhouse
+func (m *Manager) Delete(ctx context.Context, id string) *model.ApiError {
+	v, apiErr := m.repo.get(ctx, id)
+	if apiErr != nil {
+		return apiErr
+	}
+	if apiErr := m.repo.delete(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	if err := m.plan(ctx); err != nil {
+		zap.L().Error("failed to plan the logs queries with the views", zap.Error(err))
+	}
+	for _, stmt := range dropStatements(v, m.cluster) {
+		if err := m.conn.Exec(ctx, stmt); err != nil {
+			return model.InternalError(fmt.Errorf("failed to drop the logs view %s: %w", v.Name, err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package logviews
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// Kind is how a view speeds up the queries filtering on its keys
+type Kind string
+
+const (
+	// KindAggregate is a materialized view counting the logs per minute by the values of
+	// the keys, the count queries on the keys are planned on it by the query service
+	KindAggregate Kind = "aggregate"
+	// KindProjection is a projection of the logs sorted by the keys, clickhouse reads it
+	// in place of the logs table for the queries filtering on the keys. It takes as much
+	// space as the logs themselves
+	KindProjection Kind = "projection"
+)
+
+// MaxKeys is the max number of keys of a view
+const MaxKeys = 4
+
+var nameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]{0,47}$`)
+
+// unsupportedKeys are the fields the logs can't be grouped by in a view
+var unsupportedKeys = map[string]struct{}{
+	"timestamp": {},
+	"id":        {},
+	"body":      {},
+}
+
+type PostableView struct {
+	// Name names the tables of the view, it is made of lowercase letters, digits and underscores
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Kind        Kind   `json:"kind"`
+	Keys        Keys   `json:"keys"`
+}
+
+func (p *PostableView) Validate() error {
+	if !nameRegex.MatchString(p.Name) {
+		return fmt.Errorf("name %q must start with a lowercase letter followed by up to 47 lowercase letters, digits or underscores", p.Name)
+	}
+	switch p.Kind {
+	case KindAggregate, KindProjection:
+	case "":
+		p.Kind = KindAggregate
+	default:
+		return fmt.Errorf("unknown kind %q, expected %s or %s", p.Kind, KindAggregate, KindProjection)
+	}
+	if len(p.Keys) == 0 || len(p.Keys) > MaxKeys {
+		return fmt.Errorf("a view has between 1 and %d keys", MaxKeys)
+	}
+	seen := map[string]struct{}{}
+	for i, key := range p.Keys {
+		if key.IsJSON {
+			return fmt.Errorf("the JSON key %s can't be a key of a view", key.Key)
+		}
+		if _, ok := unsupportedKeys[key.Key]; ok && key.Type == v3.AttributeKeyTypeUnspecified {
+			return fmt.Errorf("the field %s can't be a key of a view", key.Key)
+		}
+		if err := key.Validate(); err != nil {
+			return err
+		}
+		switch key.Type {
+		case v3.AttributeKeyTypeTag, v3.AttributeKeyTypeResource:
+		case v3.AttributeKeyTypeUnspecified:
+			field, ok := constants.StaticFieldsLogsV3[key.Key]
+			if !ok {
+				return fmt.Errorf("the key %s is neither an attribute, a resource nor a field of the logs", key.Key)
+			}
+			key.DataType = field.DataType
+			p.Keys[i].DataType = field.DataType
+		default:
+			return fmt.Errorf("the key %s has the unsupported type %q", key.Key, key.Type)
+		}
+		if strings.ContainsAny(key.Key, "'`\\") {
+			return fmt.Errorf("the key %s has quotes or backslashes", key.Key)
+		}
+		switch key.DataType {
+		case v3.AttributeKeyDataTypeString, v3.AttributeKeyDataTypeInt64, v3.AttributeKeyDataTypeFloat64, v3.AttributeKeyDataTypeBool:
+		default:
+			return fmt.Errorf("the key %s has the unsupported data type %q", key.Key, key.DataType)
+		}
+		id := fmt.Sprintf("%s|%s|%s", key.Key, key.Type, key.DataType)
+		if _, ok := seen[id]; ok {
+			return fmt.Errorf("the key %s is repeated", key.Key)
+		}
+		seen[id] = struct{}{}
+		// the views are on the values of the keys whether they are materialized or not
+		p.Keys[i].IsColumn = false
+	}
+	return nil
+}
+
+// View is a materialized view or a projection of the logs on the keys
+type View struct {
+	Id          string `json:"id" db:"id"`
+	Name        string `json:"name" db:"name"`
+	Description string `json:"description" db:"description"`
+	Kind        Kind   `json:"kind" db:"kind"`
+	Keys        Keys   `json:"keys" db:"keys"`
+	// From is when the view started to have the logs, the queries on earlier logs
+	// don't use it
+	From time.Time `json:"from" db:"covered_from"`
+	// Hits is how many queries used the view
+	Hits       int64      `json:"hits" db:"hits"`
+	LastUsedAt *time.Time `json:"lastUsedAt" db:"last_used_at"`
+	CreatedBy  string     `json:"createdBy" db:"created_by"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// localTable is the table holding the counts of an aggregate view on each shard
+func (v *View) localTable() string {
+	return "logs_view_" + v.Name
+}
+
+// Table is the distributed table of an aggregate view
+func (v *View) Table() string {
+	return "distributed_logs_view_" + v.Name
+}
+
+// materializedView is the materialized view filling the table of an aggregate view
+func (v *View) materializedView() string {
+	return "logs_view_" + v.Name + "_mv"
+}
+
+// projection is the projection of the logs table of a projection view
+func (v *View) projection() string {
+	return "view_" + v.Name
+}
+
+type Keys []v3.AttributeKey
+
+func (k Keys) Value() (driver.Value, error) {
+	return json.Marshal(k)
+}
+
+func (k *Keys) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, k)
+	case string:
+		return json.Unmarshal([]byte(data), k)
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("unsupported type %T for keys", src)
+	}
+}
@@ -0,0 +1,98 @@
+package logviews
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS logs_views (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		keys TEXT NOT NULL,
+		covered_from datetime NOT NULL,
+		hits INTEGER NOT NULL DEFAULT 0,
+		last_used_at datetime,
+		created_by TEXT NOT NULL,
+		created_at datetime NOT NULL
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating logs_views table: %s", err.Error())
+	}
+	return nil
+}
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func (r *repo) list(ctx context.Context) ([]*View, *model.ApiError) {
+	views := []*View{}
+	err := r.db.SelectContext(ctx, &views, "SELECT * FROM logs_views ORDER BY created_at")
+	if err != nil {
+		zap.L().Error("failed to get logs views from db", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to get logs views from db"))
+	}
+	return views, nil
+}
+
+func (r *repo) get(ctx context.Context, id string) (*View, *model.ApiError) {
+	view := &View{}
+	err := r.db.GetContext(ctx, view, "SELECT * FROM logs_views WHERE id = $1", id)
+	if err == sql.ErrNoRows {
+		return nil, model.NotFoundError(fmt.Errorf("no logs view found with id %s", id))
+	}
+	if err != nil {
+		zap.L().Error("failed to get logs view from db", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to get logs view from db"))
+	}
+	return view, nil
+}
+
+func (r *repo) exists(ctx context.Context, name string) (bool, *model.ApiError) {
+	var count int
+	err := r.db.GetContext(ctx, &count, "SELECT count(*) FROM logs_views WHERE name = $1", name)
+	if err != nil {
+		zap.L().Error("failed to get logs view from db", zap.Error(err))
+		return false, model.InternalError(errors.Wrap(err, "failed to get logs view from db"))
+	}
+	return count > 0, nil
+}
+
+func (r *repo) insert(ctx context.Context, v *View) *model.ApiError {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO logs_views (id, name, description, kind, keys, covered_from, hits, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8)",
+		v.Id, v.Name, v.Description, v.Kind, v.Keys, v.From, v.CreatedBy, v.CreatedAt)
+	if err != nil {
+		zap.L().Error("failed to insert logs view in db", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to insert logs view in db"))
+	}
+	return nil
+}
+
+func (r *repo) delete(ctx context.Context, id string) *model.ApiError {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM logs_views WHERE id = $1", id); err != nil {
+		zap.L().Error("failed to delete logs view from db", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to delete logs view from db"))
+	}
+	return nil
+}
+
+// addHits adds the queries answered from the view since the last time
+func (r *repo) addHits(ctx context.Context, id string, hits int64, lastUsedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE logs_views SET hits = hits + $1, last_used_at = $2 WHERE id = $3", hits, lastUsedAt, id)
+	return err
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"go.signoz.io/signoz/pkg/query-service/app/logs/loki"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// registerLokiAPIRoutes registers the Loki compatible push and query API, the Loki clients
+// (e.g. promtail, Grafana Agent) can push logs to SigNoz and Grafana can use SigNoz as a Loki
+// datasource
+func (aH *APIHandler) registerLokiAPIRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix("/loki/api/v1").Subrouter()
+	subRouter.HandleFunc("/push", am.OpenAccess(aH.lokiPush)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/query_range", am.ViewAccess(aH.lokiQueryRange)).Methods(http.MethodGet, http.MethodPost)
+	subRouter.HandleFunc("/labels", am.ViewAccess(aH.lokiLabels)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/label/{name}/values", am.ViewAccess(aH.lokiLabelValues)).Methods(http.MethodGet)
+}
+
+// lokiPush receives the logs of the Loki clients, the clients authenticate with an ingestion
+// key allowed to send logs, whose usage is the compressed size of the requests
+func (aH *APIHandler) lokiPush(w http.ResponseWriter, r *http.Request) {
+	if aH.lokiWriter == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnavailable, Err: fmt.Errorf("loki push is not available")}, nil)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, loki.MaxRequestSize))
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	key, apiErr := authorizeIngestion(r, model.IngestionSignalLogs, int64(len(body)))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	req, err := loki.DecodePushRequest(body, r.Header.Get("Content-Type"), r.Header.Get("Content-Encoding"))
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	// the labels of the streams are the resources of the logs
+	for i := range req.Streams {
+		if req.Streams[i].Labels == nil {
+			req.Streams[i].Labels = map[string]string{}
+		}
+		req.Streams[i].Labels[constants.OrgIdAttribute] = key.OrgId
+	}
+	if err := aH.lokiWriter.Write(r.Context(), req); err != nil {
+		// the clients retry the requests failing with a server error only
+		if errors.Is(err, loki.ErrInvalidStream) {
+			RespondError(w, model.BadRequest(err), nil)
+			return
+		}
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// scopeLokiMatchersToOrg adds the matcher of the org of the user to the matchers of the streams
+func scopeLokiMatchersToOrg(ctx context.Context, matchers []*labels.Matcher) ([]*labels.Matcher, *model.ApiError) {
+	scope, apiErr := common.OrgScope(ctx)
+	if apiErr != nil || scope == nil {
+		return matchers, apiErr
+	}
+	return append(matchers, common.OrgMatcher(scope)), nil
+}
+
+func (aH *APIHandler) lokiQueryRange(w http.ResponseWriter, r *http.Request) {
+	params, apiErr := parseLokiQueryRangeRequest(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	// the org of the logs is the signoz_org_id label of their stream
+	if params.Query.Matchers, apiErr = scopeLokiMatchersToOrg(r.Context(), params.Query.Matchers); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	response, err := aH.lokiQuerier.QueryRange(r.Context(), params)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, response)
+}
+
+func (aH *APIHandler) lokiLabels(w http.ResponseWriter, r *http.Request) {
+	start, end, apiErr := parseLokiRange(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	matchers, apiErr := scopeLokiMatchersToOrg(r.Context(), nil)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	names, err := aH.lokiQuerier.Labels(r.Context(), matchers, start, end)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, names)
+}
+
+func (aH *APIHandler) lokiLabelValues(w http.ResponseWriter, r *http.Request) {
+	start, end, apiErr := parseLokiRange(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	matchers, apiErr := scopeLokiMatchersToOrg(r.Context(), nil)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	values, err := aH.lokiQuerier.LabelValues(r.Context(), mux.Vars(r)["name"], matchers, start, end)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, values)
+}
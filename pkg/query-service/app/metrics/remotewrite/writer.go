@@ -0,0 +1,384 @@
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/SigNoz/signoz-otel-collector/exporter/clickhousemetricsexporter/utils/timeseries"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const (
+	nameLabel        = "__name__"
+	temporalityLabel = "__temporality__"
+	defaultEnv       = "default"
+	// MaxRequestSize is the largest compressed write request accepted
+	MaxRequestSize = 32 << 20
+)
+
+// ErrInvalidSeries is returned for the requests with series the samples can not be written for
+var ErrInvalidSeries = errors.New("invalid series")
+
+// envLabels are the labels the environment of the series is read from, as the SigNoz exporter
+var envLabels = []string{"deployment.environment", "deployment_environment"}
+
+// Writer writes the samples of the Prometheus remote write requests into the SigNoz
+// metrics tables, the series are written like the SigNoz exporter of the collector
+type Writer struct {
+	conn clickhouse.Conn
+
+	mu sync.Mutex
+	// written holds the series written for the hour, the series rows are bucketed by hour
+	written     map[uint64]struct{}
+	writtenHour int64
+}
+
+func NewWriter(conn clickhouse.Conn) *Writer {
+	return &Writer{conn: conn, written: map[uint64]struct{}{}}
+}
+
+// DecodeWriteRequest decodes a snappy compressed protobuf write request
+func DecodeWriteRequest(compressed []byte) (*prompb.WriteRequest, error) {
+	b, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress write request: %v", err)
+	}
+	var req prompb.WriteRequest
+	if err := req.Unmarshal(b); err != nil {
+		return nil, fmt.Errorf("failed to decode write request: %v", err)
+	}
+	return &req, nil
+}
+
+// Series is a series with the metadata of its metric, written with WriteSeries. The labels
+// include the name of the metric
+type Series struct {
+	Labels      map[string]string
+	Type        v3.MetricType
+	Temporality v3.Temporality
+	IsMonotonic bool
+	Description string
+	Unit        string
+	Samples     []prompb.Sample
+}
+
+// series is a series of a write request with its labels in the SigNoz format
+type series struct {
+	fingerprint uint64
+	labels      []*prompb.Label
+	metricName  string
+	env         string
+	meta        metricMeta
+	samples     []prompb.Sample
+}
+
+// setLabels sets the labels of the series with its temporality, and the fingerprint and the
+// environment read from them
+func (s *series) setLabels(labels []*prompb.Label) {
+	s.env = defaultEnv
+	for _, l := range labels {
+		for _, envLabel := range envLabels {
+			if l.Name == envLabel {
+				s.env = l.Value
+			}
+		}
+	}
+	labels = append(labels, &prompb.Label{Name: temporalityLabel, Value: string(s.meta.temporality)})
+	timeseries.SortLabels(labels)
+	s.labels = labels
+	s.fingerprint = timeseries.Fingerprint(labels)
+}
+
+// toSeries converts the series of the request, the series without name are rejected
+func toSeries(req *prompb.WriteRequest) ([]series, error) {
+	metadata := map[string]prompb.MetricMetadata{}
+	for _, m := range req.Metadata {
+		metadata[m.MetricFamilyName] = m
+	}
+
+	result := make([]series, 0, len(req.Timeseries))
+	for _, ts := range req.Timeseries {
+		s := series{samples: ts.Samples}
+		labels := make([]*prompb.Label, 0, len(ts.Labels)+1)
+		hasLe := false
+		for _, l := range ts.Labels {
+			switch l.Name {
+			case nameLabel:
+				s.metricName = l.Value
+			case temporalityLabel:
+				// the temporality is set from the type of the metric
+				continue
+			case "le":
+				hasLe = true
+			}
+			labels = append(labels, &prompb.Label{Name: l.Name, Value: l.Value})
+		}
+		if s.metricName == "" {
+			return nil, fmt.Errorf("%w: series without %s label", ErrInvalidSeries, nameLabel)
+		}
+
+		s.meta = metaFor(s.metricName, hasLe, metadata)
+		s.setLabels(labels)
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// SetLabel sets a label of all the series of the request, e.g. the org of the ingestion key,
+// the label sent for the series is replaced
+func SetLabel(req *prompb.WriteRequest, name, value string) {
+	for i := range req.Timeseries {
+		series := &req.Timeseries[i]
+		found := false
+		for j := range series.Labels {
+			if series.Labels[j].Name == name {
+				series.Labels[j].Value = value
+				found = true
+			}
+		}
+		if !found {
+			series.Labels = append(series.Labels, prompb.Label{Name: name, Value: value})
+		}
+	}
+}
+
+// Write inserts the samples of the request and the series not written yet for the hour
+func (w *Writer) Write(ctx context.Context, req *prompb.WriteRequest) error {
+	all, err := toSeries(req)
+	if err != nil {
+		return err
+	}
+	return w.write(ctx, all)
+}
+
+// WriteSeries inserts the samples of the series and the series not written yet for the hour,
+// the series without name are rejected
+func (w *Writer) WriteSeries(ctx context.Context, all []Series) error {
+	converted, err := fromSeries(all)
+	if err != nil {
+		return err
+	}
+	return w.write(ctx, converted)
+}
+
+func fromSeries(all []Series) ([]series, error) {
+	result := make([]series, 0, len(all))
+	for _, ts := range all {
+		s := series{
+			metricName: ts.Labels[nameLabel],
+			meta: metricMeta{
+				typ:         ts.Type,
+				temporality: ts.Temporality,
+				isMonotonic: ts.IsMonotonic,
+				description: ts.Description,
+				unit:        ts.Unit,
+			},
+			samples: ts.Samples,
+		}
+		if s.metricName == "" {
+			return nil, fmt.Errorf("%w: series without %s label", ErrInvalidSeries, nameLabel)
+		}
+		labels := make([]*prompb.Label, 0, len(ts.Labels)+1)
+		for name, value := range ts.Labels {
+			if name != temporalityLabel {
+				labels = append(labels, &prompb.Label{Name: name, Value: value})
+			}
+		}
+		s.setLabels(labels)
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+func (w *Writer) write(ctx context.Context, all []series) error {
+	if len(all) == 0 {
+		return nil
+	}
+
+	// the series rows are bucketed by hour, as the SigNoz exporter
+	hour := time.Now().UnixMilli() / 3600000 * 3600000
+	newSeries := w.newSeries(hour, all)
+	if len(newSeries) > 0 {
+		if err := w.writeSeries(ctx, hour, newSeries); err != nil {
+			w.forget(hour, newSeries)
+			return err
+		}
+	}
+	return w.writeSamples(ctx, all)
+}
+
+// newSeries returns the series not written for the hour and marks them as written
+func (w *Writer) newSeries(hour int64, all []series) []series {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.writtenHour != hour {
+		w.written = map[uint64]struct{}{}
+		w.writtenHour = hour
+	}
+	result := []series{}
+	for _, s := range all {
+		if _, ok := w.written[s.fingerprint]; ok {
+			continue
+		}
+		w.written[s.fingerprint] = struct{}{}
+		result = append(result, s)
+	}
+	return result
+}
+
+// forget marks the series as not written, so they are written with the next request
+func (w *Writer) forget(hour int64, failed []series) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.writtenHour != hour {
+		return
+	}
+	for _, s := range failed {
+		delete(w.written, s.fingerprint)
+	}
+}
+
+func (w *Writer) writeSeries(ctx context.Context, hour int64, all []series) error {
+	statement, err := w.conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (env, temporality, metric_name, description, unit, type, is_monotonic, fingerprint, unix_milli, labels) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		constants.SIGNOZ_METRIC_DBNAME, constants.SIGNOZ_TIMESERIES_v4_TABLENAME), driver.WithReleaseConnection())
+	if err != nil {
+		return err
+	}
+	for _, s := range all {
+		err := statement.Append(s.env, string(s.meta.temporality), s.metricName, s.meta.description, s.meta.unit,
+			string(s.meta.typ), s.meta.isMonotonic, s.fingerprint, hour, marshalLabels(s.labels))
+		if err != nil {
+			return err
+		}
+	}
+	return statement.Send()
+}
+
+func (w *Writer) writeSamples(ctx context.Context, all []series) error {
+	statement, err := w.conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (env, temporality, metric_name, fingerprint, unix_milli, value) VALUES (?, ?, ?, ?, ?, ?)",
+		constants.SIGNOZ_METRIC_DBNAME, constants.SIGNOZ_SAMPLES_V4_TABLENAME), driver.WithReleaseConnection())
+	if err != nil {
+		return err
+	}
+	for _, s := range all {
+		for _, sample := range s.samples {
+			err := statement.Append(s.env, string(s.meta.temporality), s.metricName, s.fingerprint, sample.Timestamp, sample.Value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return statement.Send()
+}
+
+// marshalLabels encodes the labels as the JSON object of the labels column
+func marshalLabels(labels []*prompb.Label) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		writeJSONString(&b, l.Name)
+		b.WriteByte(':')
+		writeJSONString(&b, l.Value)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func writeJSONString(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for _, c := range []byte(s) {
+		switch c {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, c)
+				continue
+			}
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+}
+
+// metricMeta is the metadata of the metrics columns of the series
+type metricMeta struct {
+	typ         v3.MetricType
+	temporality v3.Temporality
+	isMonotonic bool
+	description string
+	unit        string
+}
+
+// metaFor returns the metadata of the series, from the metadata of the request when sent,
+// otherwise from the name of the series following the Prometheus naming conventions
+func metaFor(name string, hasLe bool, metadata map[string]prompb.MetricMetadata) metricMeta {
+	counter := metricMeta{typ: v3.MetricTypeSum, temporality: v3.Cumulative, isMonotonic: true}
+	gauge := metricMeta{typ: v3.MetricTypeGauge, temporality: v3.Unspecified}
+
+	family, suffix := name, ""
+	for _, s := range []string{"_bucket", "_count", "_sum", "_total"} {
+		if strings.HasSuffix(name, s) {
+			family, suffix = strings.TrimSuffix(name, s), s
+			break
+		}
+	}
+
+	m, ok := metadata[name]
+	if !ok && suffix != "" {
+		m, ok = metadata[family]
+	}
+	if !ok {
+		switch {
+		case suffix == "_bucket" && hasLe:
+			return metricMeta{typ: v3.MetricTypeHistogram, temporality: v3.Cumulative}
+		case suffix != "":
+			return counter
+		default:
+			return gauge
+		}
+	}
+
+	var meta metricMeta
+	switch m.Type {
+	case prompb.MetricMetadata_COUNTER:
+		meta = counter
+	case prompb.MetricMetadata_HISTOGRAM, prompb.MetricMetadata_GAUGEHISTOGRAM:
+		if suffix == "_bucket" {
+			meta = metricMeta{typ: v3.MetricTypeHistogram, temporality: v3.Cumulative}
+		} else {
+			meta = counter
+		}
+	case prompb.MetricMetadata_SUMMARY:
+		if suffix == "_count" || suffix == "_sum" {
+			meta = counter
+		} else {
+			meta = metricMeta{typ: v3.MetricTypeSummary, temporality: v3.Unspecified}
+		}
+	default:
+		meta = gauge
+	}
+	meta.description = m.Help
+	meta.unit = m.Unit
+	return meta
+}
@@ -0,0 +1,110 @@
+package remotewrite
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestDecodeWriteRequest(t *testing.T) {
+	req := &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{{
+		Labels:  []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "node"}},
+		Samples: []prompb.Sample{{Timestamp: 1700000000000, Value: 1}},
+	}}}
+	b, err := req.Marshal()
+	require.NoError(t, err)
+
+	decoded, err := DecodeWriteRequest(snappy.Encode(nil, b))
+	require.NoError(t, err)
+	assert.Equal(t, req.Timeseries, decoded.Timeseries)
+
+	_, err = DecodeWriteRequest(b)
+	assert.Error(t, err)
+}
+
+func TestToSeries(t *testing.T) {
+	req := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{Labels: []prompb.Label{{Name: "job", Value: "api"}, {Name: "__name__", Value: "http_requests_total"}, {Name: "deployment_environment", Value: "prod"}}},
+			{Labels: []prompb.Label{{Name: "__name__", Value: "http_duration_seconds_bucket"}, {Name: "le", Value: "0.5"}}},
+			{Labels: []prompb.Label{{Name: "__name__", Value: "memory_bytes"}}},
+		},
+		Metadata: []prompb.MetricMetadata{{MetricFamilyName: "memory_bytes", Type: prompb.MetricMetadata_GAUGE, Help: "Memory in use", Unit: "bytes"}},
+	}
+	all, err := toSeries(req)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	assert.Equal(t, "http_requests_total", all[0].metricName)
+	assert.Equal(t, "prod", all[0].env)
+	assert.Equal(t, metricMeta{typ: v3.MetricTypeSum, temporality: v3.Cumulative, isMonotonic: true}, all[0].meta)
+	// the labels are sorted with the temporality, as written by the SigNoz exporter
+	assert.Equal(t, `{"__name__":"http_requests_total","__temporality__":"Cumulative","deployment_environment":"prod","job":"api"}`, marshalLabels(all[0].labels))
+
+	assert.Equal(t, defaultEnv, all[1].env)
+	assert.Equal(t, v3.MetricTypeHistogram, all[1].meta.typ)
+	assert.Equal(t, metricMeta{typ: v3.MetricTypeGauge, temporality: v3.Unspecified, description: "Memory in use", unit: "bytes"}, all[2].meta)
+
+	// the same series have the same fingerprint whatever the order of their labels
+	other, err := toSeries(&prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		{Labels: []prompb.Label{{Name: "deployment_environment", Value: "prod"}, {Name: "__name__", Value: "http_requests_total"}, {Name: "job", Value: "api"}}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, all[0].fingerprint, other[0].fingerprint)
+
+	_, err = toSeries(&prompb.WriteRequest{Timeseries: []prompb.TimeSeries{{Labels: []prompb.Label{{Name: "job", Value: "api"}}}}})
+	assert.True(t, errors.Is(err, ErrInvalidSeries))
+}
+
+func TestFromSeries(t *testing.T) {
+	all, err := fromSeries([]Series{{
+		Labels:      map[string]string{"job": "api", "__name__": "http_requests", "deployment_environment": "prod", "__temporality__": "Cumulative"},
+		Type:        v3.MetricTypeSum,
+		Temporality: v3.Delta,
+		IsMonotonic: true,
+		Unit:        "1",
+	}})
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "http_requests", all[0].metricName)
+	assert.Equal(t, "prod", all[0].env)
+	assert.Equal(t, metricMeta{typ: v3.MetricTypeSum, temporality: v3.Delta, isMonotonic: true, unit: "1"}, all[0].meta)
+	// the temporality label is the temporality of the series
+	assert.Equal(t, `{"__name__":"http_requests","__temporality__":"Delta","deployment_environment":"prod","job":"api"}`, marshalLabels(all[0].labels))
+
+	_, err = fromSeries([]Series{{Labels: map[string]string{"job": "api"}}})
+	assert.True(t, errors.Is(err, ErrInvalidSeries))
+}
+
+func TestWriterNewSeries(t *testing.T) {
+	w := NewWriter(nil)
+	all := []series{{fingerprint: 1}, {fingerprint: 2}}
+	assert.Len(t, w.newSeries(0, all), 2)
+	assert.Empty(t, w.newSeries(0, all))
+	// the failed series are written again
+	w.forget(0, all[:1])
+	assert.Len(t, w.newSeries(0, all), 1)
+	// the series are written again every hour
+	assert.Len(t, w.newSeries(3600000, all), 2)
+}
+
+func TestMarshalLabels(t *testing.T) {
+	labels := []*prompb.Label{{Name: "path", Value: "C:\\\"logs\"\n"}}
+	assert.Equal(t, `{"path":"C:\\\"logs\"\n"}`, marshalLabels(labels))
+}
+
+func TestSetLabel(t *testing.T) {
+	req := &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		{Labels: []prompb.Label{{Name: "__name__", Value: "up"}}},
+		// the label sent by the client is replaced
+		{Labels: []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "signoz_org_id", Value: "other"}}},
+	}}
+	SetLabel(req, "signoz_org_id", "org")
+	assert.Equal(t, []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "signoz_org_id", Value: "org"}}, req.Timeseries[0].Labels)
+	assert.Equal(t, []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "signoz_org_id", Value: "org"}}, req.Timeseries[1].Labels)
+}
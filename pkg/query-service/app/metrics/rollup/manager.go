@@ -0,0 +1,202 @@
+package rollup
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.uber.org/zap"
+)
+
+const (
+	// rollupInterval is the interval at which the completed windows are rolled up
+	rollupInterval = time.Minute
+	// settleDelay is how long the samples of a window are waited for before rolling it up
+	settleDelay = 5 * time.Minute
+	// backfillPeriod is how far back the raw samples are rolled up when a rollup is created
+	backfillPeriod = 30 * 24 * time.Hour
+)
+
+type ManagerOptions struct {
+	Conn    clickhouse.Conn
+	Cluster string
+}
+
+// Manager rolls up the raw samples into the resolutions in the background, the windows
+// are rolled up once complete so the rollups are only ever appended to
+type Manager struct {
+	conn    clickhouse.Conn
+	cluster string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewManager(opts ManagerOptions) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		conn:    opts.Conn,
+		cluster: opts.Cluster,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start creates the rollup tables if needed and rolls up the samples every rollupInterval
+func (m *Manager) Start() error {
+	if err := m.createTables(m.ctx); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, res := range Resolutions {
+		if err := m.loadCoverage(m.ctx, res, now); err != nil {
+			return err
+		}
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(rollupInterval)
+		defer ticker.Stop()
+		for {
+			m.rollupAll(time.Now())
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops the rollups and waits for the running insert
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+func (m *Manager) createTables(ctx context.Context) error {
+	for _, res := range Resolutions {
+		localTable := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s ON CLUSTER %s (
+			metric_name LowCardinality(String) CODEC(ZSTD(1)),
+			fingerprint UInt64 CODEC(Delta(8), ZSTD(1)),
+			unix_milli Int64 CODEC(DoubleDelta, ZSTD(1)),
+			last Float64 CODEC(ZSTD(1)),
+			min Float64 CODEC(ZSTD(1)),
+			max Float64 CODEC(ZSTD(1)),
+			sum Float64 CODEC(ZSTD(1)),
+			count UInt64 CODEC(ZSTD(1))
+		) ENGINE = MergeTree
+		PARTITION BY toDate(unix_milli / 1000)
+		ORDER BY (metric_name, fingerprint, unix_milli)`,
+			constants.SIGNOZ_METRIC_DBNAME, res.LocalTable, m.cluster)
+		if err := m.conn.Exec(ctx, localTable); err != nil {
+			return fmt.Errorf("error in creating %s rollup table: %w", res.Name, err)
+		}
+
+		table := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s ON CLUSTER %s AS %s.%s
+		ENGINE = Distributed('%s', '%s', '%s', cityHash64(metric_name, fingerprint))`,
+			constants.SIGNOZ_METRIC_DBNAME, res.Table, m.cluster, constants.SIGNOZ_METRIC_DBNAME, res.LocalTable,
+			m.cluster, constants.SIGNOZ_METRIC_DBNAME, res.LocalTable)
+		if err := m.conn.Exec(ctx, table); err != nil {
+			return fmt.Errorf("error in creating %s distributed rollup table: %w", res.Name, err)
+		}
+	}
+	return nil
+}
+
+// loadCoverage reads the window rolled up from the rollup table, an empty rollup starts
+// backfillPeriod ago or from the start of its source rollup
+func (m *Manager) loadCoverage(ctx context.Context, res *Resolution, now time.Time) error {
+	var first, last int64
+	var rows uint64
+	query := fmt.Sprintf("SELECT min(unix_milli), max(unix_milli), count() FROM %s.%s", constants.SIGNOZ_METRIC_DBNAME, res.Table)
+	if err := m.conn.QueryRow(ctx, query).Scan(&first, &last, &rows); err != nil {
+		return fmt.Errorf("error in reading %s rollup coverage: %w", res.Name, err)
+	}
+
+	interval := res.Interval.Milliseconds()
+	if rows > 0 {
+		setCoverage(res, first, last+interval)
+		return nil
+	}
+
+	start := now.Add(-backfillPeriod).UnixMilli()
+	if res.Source != nil {
+		if source, ok := getCoverage(res.Source); ok {
+			start = source.start
+		}
+	}
+	start += (interval - start%interval) % interval
+	setCoverage(res, start, start)
+	return nil
+}
+
+func (m *Manager) rollupAll(now time.Time) {
+	for _, res := range Resolutions {
+		if err := m.rollup(res, now); err != nil {
+			zap.L().Error("failed to roll up metric samples", zap.String("resolution", res.Name), zap.Error(err))
+		}
+	}
+}
+
+// rollup rolls up the completed windows of the resolution in batches, the raw samples
+// are settled after settleDelay and a rollup of a rollup waits for its source
+func (m *Manager) rollup(res *Resolution, now time.Time) error {
+	covered, ok := getCoverage(res)
+	if !ok {
+		return nil
+	}
+
+	interval := res.Interval.Milliseconds()
+	limit := now.Add(-settleDelay).UnixMilli()
+	if res.Source != nil {
+		source, ok := getCoverage(res.Source)
+		if !ok {
+			return nil
+		}
+		limit = source.end
+	}
+	limit -= limit % interval
+
+	for end := covered.end; end < limit; {
+		if m.ctx.Err() != nil {
+			return nil
+		}
+		to := end + res.batch.Milliseconds()
+		if to > limit {
+			to = limit
+		}
+		if err := m.conn.Exec(m.ctx, rollupQuery(res, end, to)); err != nil {
+			return err
+		}
+		setCoverage(res, covered.start, to)
+		end = to
+	}
+	return nil
+}
+
+// rollupQuery aggregates the samples between start and end into the intervals of the resolution
+func rollupQuery(res *Resolution, start, end int64) string {
+	interval := res.Interval.Milliseconds()
+	from := constants.SIGNOZ_SAMPLES_V4_TABLENAME
+	aggregates := "argMax(value, unix_milli), min(value), max(value), sum(value), count()"
+	if res.Source != nil {
+		from = res.Source.Table
+		aggregates = "argMax(last, unix_milli), min(min), max(max), sum(sum), sum(count)"
+	}
+	return fmt.Sprintf(
+		"INSERT INTO %s.%s (metric_name, fingerprint, unix_milli, last, min, max, sum, count)"+
+			" SELECT metric_name, fingerprint, intDiv(unix_milli, %d) * %d AS bucket_milli, %s"+
+			" FROM %s.%s WHERE unix_milli >= %d AND unix_milli < %d"+
+			" GROUP BY metric_name, fingerprint, bucket_milli",
+		constants.SIGNOZ_METRIC_DBNAME, res.Table, interval, interval, aggregates,
+		constants.SIGNOZ_METRIC_DBNAME, from, start, end,
+	)
+}
@@ -0,0 +1,147 @@
+package rollup
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+// Resolution is a rollup of the samples aggregated per series over fixed intervals,
+// each row holds the last, min, max, sum and count of the samples of the interval
+type Resolution struct {
+	Name     string
+	Interval time.Duration
+	// MinRange is the smallest query range the resolution is picked for
+	MinRange   time.Duration
+	LocalTable string
+	Table      string
+	// Source is the resolution the rollup is computed from, nil for the raw samples
+	Source *Resolution
+
+	// batch is the longest window rolled up by a single insert
+	batch time.Duration
+}
+
+var (
+	Resolution5m = &Resolution{
+		Name:       "5m",
+		Interval:   5 * time.Minute,
+		MinRange:   24 * time.Hour,
+		LocalTable: constants.SIGNOZ_SAMPLES_V4_AGG_5M_LOCAL_TABLENAME,
+		Table:      constants.SIGNOZ_SAMPLES_V4_AGG_5M_TABLENAME,
+		batch:      time.Hour,
+	}
+	Resolution1h = &Resolution{
+		Name:       "1h",
+		Interval:   time.Hour,
+		MinRange:   7 * 24 * time.Hour,
+		LocalTable: constants.SIGNOZ_SAMPLES_V4_AGG_1H_LOCAL_TABLENAME,
+		Table:      constants.SIGNOZ_SAMPLES_V4_AGG_1H_TABLENAME,
+		Source:     Resolution5m,
+		batch:      24 * time.Hour,
+	}
+
+	// Resolutions are ordered from the finest to the coarsest
+	Resolutions = []*Resolution{Resolution5m, Resolution1h}
+)
+
+// window is the range of time rolled up, start and end are in milliseconds
+type window struct {
+	start, end int64
+}
+
+// coverage holds the window rolled up for each resolution, it is only known
+// once the manager has started so the raw samples are read until then
+var coverage = struct {
+	sync.RWMutex
+	windows map[string]window
+}{windows: map[string]window{}}
+
+func setCoverage(res *Resolution, start, end int64) {
+	coverage.Lock()
+	defer coverage.Unlock()
+	coverage.windows[res.Name] = window{start: start, end: end}
+}
+
+func getCoverage(res *Resolution) (window, bool) {
+	coverage.RLock()
+	defer coverage.RUnlock()
+	w, ok := coverage.windows[res.Name]
+	return w, ok
+}
+
+// Plan is the part of a query read from a rollup
+type Plan struct {
+	Resolution *Resolution
+	// Start and End are the window read from the rollup in milliseconds, the
+	// rest of the query range is aggregated from the raw samples
+	Start, End int64
+}
+
+// Pick returns the coarsest resolution fitting the query along with the window read from it,
+// nil is returned when the raw samples are to be read.
+// A resolution fits when the query range is long enough, the step is not finer than the
+// interval and the rollup covers the start of the range.
+// start and end are in milliseconds, step is in seconds
+func Pick(start, end, step int64) *Plan {
+	for i := len(Resolutions) - 1; i >= 0; i-- {
+		res := Resolutions[i]
+		interval := res.Interval.Milliseconds()
+		if end-start < res.MinRange.Milliseconds() || step*1000 < interval {
+			continue
+		}
+		covered, ok := getCoverage(res)
+		if !ok {
+			continue
+		}
+		// only the intervals lying fully in the range are read from the rollup
+		rollupStart := start + (interval-start%interval)%interval
+		rollupEnd := end - end%interval
+		if covered.end < rollupEnd {
+			rollupEnd = covered.end
+		}
+		if covered.start > rollupStart || rollupEnd <= rollupStart {
+			continue
+		}
+		return &Plan{Resolution: res, Start: rollupStart, End: rollupEnd}
+	}
+	return nil
+}
+
+// SamplesQuery returns the sub-query reading the samples of the metric between start and end,
+// the window of the plan is read from the rollup and the edges are aggregated from the raw
+// samples into the same intervals. The sub-query has the metric_name, fingerprint, unix_milli,
+// last, min, max, sum and count columns, unix_milli being the start of the interval
+func (p *Plan) SamplesQuery(metricName string, start, end int64) string {
+	metric := utils.ClickHouseFormattedValue(metricName)
+	parts := []string{fmt.Sprintf(
+		"SELECT metric_name, fingerprint, unix_milli, last, min, max, sum, count"+
+			" FROM %s.%s WHERE metric_name = %s AND unix_milli >= %d AND unix_milli < %d",
+		constants.SIGNOZ_METRIC_DBNAME, p.Resolution.Table, metric, p.Start, p.End,
+	)}
+	if start < p.Start {
+		parts = append(parts, rawSamplesQuery(p.Resolution, metric, start, p.Start))
+	}
+	if p.End < end {
+		parts = append(parts, rawSamplesQuery(p.Resolution, metric, p.End, end))
+	}
+	return "(" + strings.Join(parts, " UNION ALL ") + ")"
+}
+
+// rawSamplesQuery aggregates the raw samples of the metric into the intervals of the resolution,
+// the columns are named by the rollup query the union starts with
+func rawSamplesQuery(res *Resolution, metric string, start, end int64) string {
+	interval := res.Interval.Milliseconds()
+	return fmt.Sprintf(
+		"SELECT metric_name, fingerprint, intDiv(unix_milli, %d) * %d AS bucket_milli,"+
+			" argMax(value, unix_milli), min(value), max(value), sum(value), count()"+
+			" FROM %s.%s WHERE metric_name = %s AND unix_milli >= %d AND unix_milli < %d"+
+			" GROUP BY metric_name, fingerprint, bucket_milli",
+		interval, interval, constants.SIGNOZ_METRIC_DBNAME, constants.SIGNOZ_SAMPLES_V4_TABLENAME,
+		metric, start, end,
+	)
+}
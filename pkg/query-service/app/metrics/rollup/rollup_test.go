@@ -0,0 +1,122 @@
+package rollup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	hour = int64(time.Hour / time.Millisecond)
+	day  = 24 * hour
+	// 2024-01-01 00:00:00 UTC
+	epoch = int64(1704067200000)
+)
+
+func withCoverage(t *testing.T, windows map[*Resolution]window) {
+	coverage.Lock()
+	coverage.windows = map[string]window{}
+	for res, w := range windows {
+		coverage.windows[res.Name] = w
+	}
+	coverage.Unlock()
+	t.Cleanup(func() {
+		coverage.Lock()
+		coverage.windows = map[string]window{}
+		coverage.Unlock()
+	})
+}
+
+func TestPick(t *testing.T) {
+	withCoverage(t, map[*Resolution]window{
+		Resolution5m: {start: epoch, end: epoch + 40*day},
+		Resolution1h: {start: epoch, end: epoch + 39*day},
+	})
+
+	cases := []struct {
+		name       string
+		start, end int64
+		step       int64
+		expected   *Plan
+	}{
+		{
+			name:  "short range reads the raw samples",
+			start: epoch + 10*day, end: epoch + 10*day + 6*hour, step: 60,
+		},
+		{
+			name:  "step finer than the interval reads the raw samples",
+			start: epoch + 10*day, end: epoch + 12*day, step: 60,
+		},
+		{
+			name:  "day range reads the 5m rollup",
+			start: epoch + 10*day, end: epoch + 12*day, step: 600,
+			expected: &Plan{Resolution: Resolution5m, Start: epoch + 10*day, End: epoch + 12*day},
+		},
+		{
+			name:  "month range reads the 1h rollup",
+			start: epoch + 5*day, end: epoch + 35*day, step: 3600,
+			expected: &Plan{Resolution: Resolution1h, Start: epoch + 5*day, End: epoch + 35*day},
+		},
+		{
+			name:  "month range with a fine step reads the 5m rollup",
+			start: epoch + 5*day, end: epoch + 35*day, step: 1800,
+			expected: &Plan{Resolution: Resolution5m, Start: epoch + 5*day, End: epoch + 35*day},
+		},
+		{
+			name:  "unaligned range reads the intervals within it",
+			start: epoch + 10*day + 7*60000, end: epoch + 12*day + 11*60000, step: 600,
+			expected: &Plan{Resolution: Resolution5m, Start: epoch + 10*day + 10*60000, End: epoch + 12*day + 10*60000},
+		},
+		{
+			name:  "range past the coverage reads the rollup until its end",
+			start: epoch + 35*day, end: epoch + 45*day, step: 3600,
+			expected: &Plan{Resolution: Resolution1h, Start: epoch + 35*day, End: epoch + 39*day},
+		},
+		{
+			name:  "range before the coverage reads the raw samples",
+			start: epoch - 10*day, end: epoch + 10*day, step: 3600,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, Pick(c.start, c.end, c.step))
+		})
+	}
+}
+
+func TestPickWithoutCoverage(t *testing.T) {
+	withCoverage(t, map[*Resolution]window{})
+	assert.Nil(t, Pick(epoch, epoch+30*day, 3600))
+}
+
+func TestSamplesQuery(t *testing.T) {
+	plan := &Plan{Resolution: Resolution5m, Start: epoch + 5*60000, End: epoch + day}
+
+	query := plan.SamplesQuery("http_requests", epoch+60000, epoch+day+120000)
+	expected := "(SELECT metric_name, fingerprint, unix_milli, last, min, max, sum, count" +
+		" FROM signoz_metrics.distributed_samples_v4_agg_5m WHERE metric_name = 'http_requests' AND unix_milli >= 1704067500000 AND unix_milli < 1704153600000" +
+		" UNION ALL SELECT metric_name, fingerprint, intDiv(unix_milli, 300000) * 300000 AS bucket_milli, argMax(value, unix_milli), min(value), max(value), sum(value), count()" +
+		" FROM signoz_metrics.distributed_samples_v4 WHERE metric_name = 'http_requests' AND unix_milli >= 1704067260000 AND unix_milli < 1704067500000 GROUP BY metric_name, fingerprint, bucket_milli" +
+		" UNION ALL SELECT metric_name, fingerprint, intDiv(unix_milli, 300000) * 300000 AS bucket_milli, argMax(value, unix_milli), min(value), max(value), sum(value), count()" +
+		" FROM signoz_metrics.distributed_samples_v4 WHERE metric_name = 'http_requests' AND unix_milli >= 1704153600000 AND unix_milli < 1704153720000 GROUP BY metric_name, fingerprint, bucket_milli)"
+	assert.Equal(t, expected, query)
+
+	// the range aligned to the plan is read from the rollup only
+	query = plan.SamplesQuery("http_requests", plan.Start, plan.End)
+	assert.Equal(t, "(SELECT metric_name, fingerprint, unix_milli, last, min, max, sum, count"+
+		" FROM signoz_metrics.distributed_samples_v4_agg_5m WHERE metric_name = 'http_requests' AND unix_milli >= 1704067500000 AND unix_milli < 1704153600000)", query)
+}
+
+func TestRollupQuery(t *testing.T) {
+	assert.Equal(t, "INSERT INTO signoz_metrics.distributed_samples_v4_agg_5m (metric_name, fingerprint, unix_milli, last, min, max, sum, count)"+
+		" SELECT metric_name, fingerprint, intDiv(unix_milli, 300000) * 300000 AS bucket_milli, argMax(value, unix_milli), min(value), max(value), sum(value), count()"+
+		" FROM signoz_metrics.distributed_samples_v4 WHERE unix_milli >= 1704067200000 AND unix_milli < 1704070800000"+
+		" GROUP BY metric_name, fingerprint, bucket_milli", rollupQuery(Resolution5m, epoch, epoch+hour))
+
+	assert.Equal(t, "INSERT INTO signoz_metrics.distributed_samples_v4_agg_1h (metric_name, fingerprint, unix_milli, last, min, max, sum, count)"+
+		" SELECT metric_name, fingerprint, intDiv(unix_milli, 3600000) * 3600000 AS bucket_milli, argMax(last, unix_milli), min(min), max(max), sum(sum), sum(count)"+
+		" FROM signoz_metrics.distributed_samples_v4_agg_5m WHERE unix_milli >= 1704067200000 AND unix_milli < 1704153600000"+
+		" GROUP BY metric_name, fingerprint, bucket_milli", rollupQuery(Resolution1h, epoch, epoch+day))
+}
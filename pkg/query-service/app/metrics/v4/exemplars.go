@@ -0,0 +1,66 @@
+package v4
+
+import (
+	"fmt"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/v4/helpers"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+// maxExemplars limits the number of exemplars returned for a query
+const maxExemplars = 1000
+
+// ExemplarLabels returns the labels the exemplars of the query are matched to
+// its series with, le is left out as the quantiles are aggregated over the buckets
+func ExemplarLabels(mq *v3.BuilderQuery) []v3.AttributeKey {
+	labels := []v3.AttributeKey{}
+	for _, groupBy := range mq.GroupBy {
+		if groupBy.Key != "le" {
+			labels = append(labels, groupBy)
+		}
+	}
+	return labels
+}
+
+// PrepareExemplarsQuery prepares the query fetching the exemplars recorded for the
+// series of the query, the exemplar with the highest value is kept for each series
+// and step so that the slowest requests are linked from the latency charts
+// start and end are in milliseconds
+func PrepareExemplarsQuery(start, end int64, mq *v3.BuilderQuery) (string, error) {
+	labels := ExemplarLabels(mq)
+
+	filterMQ := *mq
+	filterMQ.GroupBy = labels
+	filterSubQuery, err := helpers.PrepareTimeseriesFilterQuery(start, end, &filterMQ)
+	if err != nil {
+		return "", err
+	}
+
+	mapArgs := []string{}
+	limitBy := []string{}
+	for _, label := range labels {
+		mapArgs = append(mapArgs, fmt.Sprintf("'%s', %s", label.Key, label.Key))
+		limitBy = append(limitBy, label.Key)
+	}
+	step := mq.StepInterval
+	if step <= 0 {
+		step = 60
+	}
+	limitBy = append(limitBy, fmt.Sprintf("intDiv(unix_milli, %d)", step*1000))
+
+	query := fmt.Sprintf(
+		"SELECT unix_milli, value, trace_id, span_id, map(%s) AS exemplar_labels"+
+			" FROM %s.%s INNER JOIN (%s) AS filtered_series USING fingerprint"+
+			" WHERE metric_name = %s AND unix_milli >= %d AND unix_milli < %d"+
+			" ORDER BY value DESC LIMIT 1 BY %s LIMIT %d",
+		strings.Join(mapArgs, ", "),
+		constants.SIGNOZ_METRIC_DBNAME, constants.SIGNOZ_EXEMPLARS_TABLENAME,
+		filterSubQuery,
+		utils.ClickHouseFormattedValue(mq.AggregateAttribute.Key), start, end,
+		strings.Join(limitBy, ", "), maxExemplars,
+	)
+	return query, nil
+}
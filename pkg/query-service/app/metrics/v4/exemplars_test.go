@@ -0,0 +1,52 @@
+package v4
+
+import (
+	"strings"
+	"testing"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestPrepareExemplarsQuery(t *testing.T) {
+	mq := &v3.BuilderQuery{
+		QueryName:          "A",
+		StepInterval:       60,
+		DataSource:         v3.DataSourceMetrics,
+		AggregateAttribute: v3.AttributeKey{Key: "signoz_latency_bucket"},
+		Temporality:        v3.Cumulative,
+		Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "service_name"}, Operator: v3.FilterOperatorEqual, Value: "frontend"},
+		}},
+		GroupBy: []v3.AttributeKey{
+			{Key: "operation", Type: v3.AttributeKeyTypeTag, DataType: v3.AttributeKeyDataTypeString},
+			{Key: "le", Type: v3.AttributeKeyTypeTag, DataType: v3.AttributeKeyDataTypeString},
+		},
+		SpaceAggregation: v3.SpaceAggregationPercentile99,
+	}
+
+	query, err := PrepareExemplarsQuery(1701794980000, 1701796780000, mq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "SELECT unix_milli, value, trace_id, span_id, map('operation', operation) AS exemplar_labels" +
+		" FROM signoz_metrics.distributed_exemplars INNER JOIN (SELECT DISTINCT JSONExtractString(labels, 'operation') as operation, fingerprint" +
+		" FROM signoz_metrics.time_series_v4 WHERE metric_name = 'signoz_latency_bucket' AND temporality = 'Cumulative'" +
+		" AND unix_milli >= 1701792000000 AND unix_milli < 1701796780000 AND JSONExtractString(labels, 'service_name') = 'frontend') AS filtered_series USING fingerprint" +
+		" WHERE metric_name = 'signoz_latency_bucket' AND unix_milli >= 1701794980000 AND unix_milli < 1701796780000" +
+		" ORDER BY value DESC LIMIT 1 BY operation, intDiv(unix_milli, 60000) LIMIT 1000"
+	if query != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, query)
+	}
+	if len(mq.GroupBy) != 2 {
+		t.Errorf("expected the group by of the query to be kept, got %v", mq.GroupBy)
+	}
+
+	mq.GroupBy = nil
+	query, err = PrepareExemplarsQuery(1701794980000, 1701796780000, mq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(query, "map() AS exemplar_labels") || !strings.Contains(query, "LIMIT 1 BY intDiv(unix_milli, 60000)") {
+		t.Errorf("unexpected query without group by %s", query)
+	}
+}
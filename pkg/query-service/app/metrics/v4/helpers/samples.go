@@ -0,0 +1,70 @@
+package helpers
+
+import (
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/rollup"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// SamplesSource is where the samples of a query are read from
+type SamplesSource struct {
+	// Table is the table or the sub-query the samples are read from
+	Table string
+	// Rollup is the rollup read, nil when the raw samples are read
+	Rollup *rollup.Plan
+}
+
+// PrepareSamplesSource picks the rollup the samples are read from based on the query range,
+// the raw samples are read for the short ranges and for the aggregations the rollups can't answer
+// start and end are in milliseconds, step is in seconds
+func PrepareSamplesSource(start, end, step int64, mq *v3.BuilderQuery) SamplesSource {
+	raw := SamplesSource{Table: constants.SIGNOZ_METRIC_DBNAME + "." + constants.SIGNOZ_SAMPLES_V4_TABLENAME}
+	if mq.TimeAggregation == v3.TimeAggregationCountDistinct ||
+		mq.AggregateAttribute.Type == v3.AttributeKeyType(v3.MetricTypeExponentialHistogram) {
+		return raw
+	}
+	plan := rollup.Pick(start, end, step)
+	if plan == nil {
+		return raw
+	}
+	return SamplesSource{Table: plan.SamplesQuery(mq.AggregateAttribute.Key, start, end), Rollup: plan}
+}
+
+// Aggregate returns the expression aggregating the samples for the time aggregation,
+// the rollups are aggregated from the per interval aggregates
+func (s SamplesSource) Aggregate(aggregation v3.TimeAggregation) string {
+	if s.Rollup == nil {
+		switch aggregation {
+		case v3.TimeAggregationAvg:
+			return "avg(value)"
+		case v3.TimeAggregationSum:
+			return "sum(value)"
+		case v3.TimeAggregationMin:
+			return "min(value)"
+		case v3.TimeAggregationMax:
+			return "max(value)"
+		case v3.TimeAggregationCount:
+			return "count(value)"
+		case v3.TimeAggregationCountDistinct:
+			return "count(distinct(value))"
+		case v3.TimeAggregationAnyLast:
+			return "anyLast(value)"
+		}
+		return ""
+	}
+	switch aggregation {
+	case v3.TimeAggregationAvg:
+		return "sum(sum) / sum(count)"
+	case v3.TimeAggregationSum:
+		return "sum(sum)"
+	case v3.TimeAggregationMin:
+		return "min(min)"
+	case v3.TimeAggregationMax:
+		return "max(max)"
+	case v3.TimeAggregationCount:
+		return "sum(count)"
+	case v3.TimeAggregationAnyLast:
+		return "argMax(last, unix_milli)"
+	}
+	return ""
+}
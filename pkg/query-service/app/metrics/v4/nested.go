@@ -0,0 +1,56 @@
+package v4
+
+import (
+	"fmt"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/v4/helpers"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// nestedAggregationOp returns the aggregate function of the nested aggregation
+func nestedAggregationOp(operator v3.SpaceAggregation) string {
+	if v3.IsPercentileOperator(operator) {
+		return fmt.Sprintf("quantile(%.3f)(nested_value)", v3.GetPercentileFromOperator(operator))
+	}
+	return fmt.Sprintf("%s(nested_value)", operator)
+}
+
+// selectLabels returns the labels to select followed by a space, if any
+func selectLabels(tags []v3.AttributeKey) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return helpers.SelectLabels(tags) + " "
+}
+
+// prepareNestedAggregationQuery aggregates the result of the query a second time, either
+// the points of each series over the step interval of the nested aggregation or the series
+// together at each timestamp.
+//
+// The columns of the query are renamed, the aliases of ClickHouse are visible in the WHERE
+// clause and would otherwise refer to the aggregated value
+func prepareNestedAggregationQuery(query string, mq *v3.BuilderQuery, groupBy []v3.AttributeKey) string {
+	nested := mq.NestedAggregation
+
+	outerGroupBy := nested.GroupBy
+	ts := "nested_ts as ts"
+	if nested.OverTime() {
+		outerGroupBy = groupBy
+		ts = fmt.Sprintf("toStartOfInterval(nested_ts, INTERVAL %d SECOND) as ts", nested.StepInterval)
+	}
+
+	where := "isNaN(nested_value) = 0"
+	for _, having := range nested.Having {
+		where += fmt.Sprintf(" AND nested_value %s %v", having.Operator, having.Value)
+	}
+
+	renamed := fmt.Sprintf("SELECT %sts as nested_ts, value as nested_value FROM (%s)", selectLabels(groupBy), query)
+	return strings.Join([]string{
+		fmt.Sprintf("SELECT %s%s, %s as value", selectLabels(outerGroupBy), ts, nestedAggregationOp(nested.Operator)),
+		fmt.Sprintf("FROM (%s)", renamed),
+		fmt.Sprintf("WHERE %s", where),
+		fmt.Sprintf("GROUP BY %s", helpers.GroupByAttributeKeyTags(outerGroupBy...)),
+		fmt.Sprintf("ORDER BY %s", helpers.OrderByAttributeKeyTags(mq.OrderBy, outerGroupBy)),
+	}, " ")
+}
@@ -0,0 +1,78 @@
+package v4
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestPrepareNestedAggregationQuery(t *testing.T) {
+	groupBy := []v3.AttributeKey{
+		{Key: "service_name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+		{Key: "endpoint", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+	}
+	testCases := []struct {
+		name          string
+		nested        *v3.NestedAggregation
+		expectedQuery string
+	}{
+		{
+			name:   "max over time of a rate",
+			nested: &v3.NestedAggregation{Operator: v3.SpaceAggregationMax, StepInterval: 3600},
+			expectedQuery: "SELECT service_name, endpoint, toStartOfInterval(nested_ts, INTERVAL 3600 SECOND) as ts, max(nested_value) as value" +
+				" FROM (SELECT service_name, endpoint, ts as nested_ts, value as nested_value FROM (inner))" +
+				" WHERE isNaN(nested_value) = 0" +
+				" GROUP BY service_name, endpoint, ts" +
+				" ORDER BY service_name ASC, endpoint ASC, ts ASC",
+		},
+		{
+			name: "count of series above a value by service",
+			nested: &v3.NestedAggregation{
+				Operator: v3.SpaceAggregationCount,
+				GroupBy:  groupBy[:1],
+				Having:   []v3.Having{{Operator: v3.HavingOperatorGreaterThan, Value: 0.5}},
+			},
+			expectedQuery: "SELECT service_name, nested_ts as ts, count(nested_value) as value" +
+				" FROM (SELECT service_name, endpoint, ts as nested_ts, value as nested_value FROM (inner))" +
+				" WHERE isNaN(nested_value) = 0 AND nested_value > 0.5" +
+				" GROUP BY service_name, ts" +
+				" ORDER BY service_name ASC, ts ASC",
+		},
+		{
+			name:   "p90 of all the series",
+			nested: &v3.NestedAggregation{Operator: v3.SpaceAggregationPercentile90},
+			expectedQuery: "SELECT nested_ts as ts, quantile(0.900)(nested_value) as value" +
+				" FROM (SELECT service_name, endpoint, ts as nested_ts, value as nested_value FROM (inner))" +
+				" WHERE isNaN(nested_value) = 0" +
+				" GROUP BY ts" +
+				" ORDER BY ts ASC",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mq := &v3.BuilderQuery{
+				QueryName:         "A",
+				StepInterval:      60,
+				DataSource:        v3.DataSourceMetrics,
+				GroupBy:           groupBy,
+				Expression:        "A",
+				NestedAggregation: testCase.nested,
+			}
+			assert.NoError(t, testCase.nested.Validate(mq))
+			assert.Equal(t, testCase.expectedQuery, prepareNestedAggregationQuery("inner", mq, groupBy))
+		})
+	}
+
+	mq := &v3.BuilderQuery{QueryName: "A", StepInterval: 60, GroupBy: groupBy[:1]}
+	for _, invalid := range []*v3.NestedAggregation{
+		{Operator: v3.SpaceAggregationMax, StepInterval: 90},
+		{Operator: v3.SpaceAggregationMax, StepInterval: 120, GroupBy: groupBy[:1]},
+		{Operator: v3.SpaceAggregationSum, GroupBy: groupBy[1:]},
+		{Operator: v3.SpaceAggregationSum, Having: []v3.Having{{Operator: v3.HavingOperatorIn, Value: []interface{}{1.0}}}},
+		{Operator: "rate"},
+	} {
+		assert.Error(t, invalid.Validate(mq))
+	}
+}
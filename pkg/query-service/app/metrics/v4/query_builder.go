@@ -19,6 +19,10 @@ import (
 // step is in seconds
 func PrepareMetricQuery(start, end int64, queryType v3.QueryType, panelType v3.PanelType, mq *v3.BuilderQuery, options metricsV3.Options) (string, error) {
 
+	if mq.NestedAggregation != nil && mq.NestedAggregation.OverTime() {
+		// the first window of the nested aggregation starts with the range
+		start = start - (start % (mq.NestedAggregation.StepInterval * 1000))
+	}
 	start, end = common.AdjustedMetricTimeRange(start, end, mq.StepInterval, *mq)
 
 	var quantile float64
@@ -79,10 +83,32 @@ func PrepareMetricQuery(start, end int64, queryType v3.QueryType, panelType v3.P
 	groupBy := helpers.GroupByAttributeKeyTags(groupByWithoutLe...)
 	orderBy := helpers.OrderByAttributeKeyTags(mq.OrderBy, groupByWithoutLe)
 
-	// fixed-bucket histogram quantiles are calculated with UDF
-	if quantile != 0 && mq.AggregateAttribute.Type != v3.AttributeKeyType(v3.MetricTypeExponentialHistogram) {
+	resultGroupBy := mq.GroupBy
+	if quantile != 0 && mq.AggregateAttribute.Type == v3.AttributeKeyType(v3.MetricTypeExponentialBuckets) {
+		// the buckets of the exponential histograms have their own counts, they are sorted by
+		// their bounds and added up to the cumulative counts the UDF expects, with the total
+		// count at +Inf
+		buckets := fmt.Sprintf(`SELECT %s, arraySort(x -> x.1, groupArray((toFloat64(le), value))) as buckets FROM (%s) GROUP BY %s`, groupBy, query, groupBy)
+		query = fmt.Sprintf(`SELECT %s, histogramQuantile(arrayPushBack(arrayMap(x -> x.1, buckets), inf), arrayPushBack(arrayCumSum(arrayMap(x -> x.2, buckets)), arraySum(arrayMap(x -> x.2, buckets))), %.3f) as value FROM (%s) ORDER BY %s`, groupBy, quantile, buckets, orderBy)
+		mq.SpaceAggregation = percentileOperator
+		resultGroupBy = groupByWithoutLe
+	} else if quantile != 0 && mq.AggregateAttribute.Type != v3.AttributeKeyType(v3.MetricTypeExponentialHistogram) {
+		// fixed-bucket histogram quantiles are calculated with UDF
 		query = fmt.Sprintf(`SELECT %s, histogramQuantile(arrayMap(x -> toFloat64(x), groupArray(le)), groupArray(value), %.3f) as value FROM (%s) GROUP BY %s ORDER BY %s`, groupBy, quantile, query, groupBy, orderBy)
 		mq.SpaceAggregation = percentileOperator
+		resultGroupBy = groupByWithoutLe
+	}
+
+	if mq.NestedAggregation != nil {
+		query = prepareNestedAggregationQuery(query, mq, resultGroupBy)
+		if !mq.NestedAggregation.OverTime() {
+			resultGroupBy = mq.NestedAggregation.GroupBy
+		}
+	}
+
+	// a single series has nothing to limit
+	if mq.SeriesLimit != nil && len(resultGroupBy) > 0 {
+		query = prepareSeriesLimitQuery(query, mq, resultGroupBy)
 	}
 
 	return query, nil
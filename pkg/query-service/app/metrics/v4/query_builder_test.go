@@ -1,6 +1,7 @@
 package v4
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -469,6 +470,32 @@ func TestPrepreMetricQueryDeltaQuantile(t *testing.T) {
 	}
 }
 
+func TestPrepareMetricQueryExponentialBucketsQuantile(t *testing.T) {
+	builderQuery := &v3.BuilderQuery{
+		QueryName:    "A",
+		StepInterval: 60,
+		DataSource:   v3.DataSourceMetrics,
+		AggregateAttribute: v3.AttributeKey{
+			Key:  "latency_bucket",
+			Type: v3.AttributeKeyType(v3.MetricTypeExponentialBuckets),
+		},
+		Temporality: v3.Delta,
+		GroupBy: []v3.AttributeKey{{
+			Key:      "service_name",
+			DataType: v3.AttributeKeyDataTypeString,
+			Type:     v3.AttributeKeyTypeTag,
+		}},
+		Expression:       "A",
+		SpaceAggregation: v3.SpaceAggregationPercentile99,
+	}
+
+	query, err := PrepareMetricQuery(1650991982000, 1651078382000, v3.QueryTypeBuilder, v3.PanelTypeGraph, builderQuery, metricsV3.Options{})
+	assert.Nil(t, err)
+	assert.Contains(t, query, "SELECT service_name, ts, histogramQuantile(arrayPushBack(arrayMap(x -> x.1, buckets), inf), arrayPushBack(arrayCumSum(arrayMap(x -> x.2, buckets)), arraySum(arrayMap(x -> x.2, buckets))), 0.990) as value FROM (SELECT service_name, ts, arraySort(x -> x.1, groupArray((toFloat64(le), value))) as buckets FROM (SELECT service_name, le, toStartOfInterval(toDateTime(intDiv(unix_milli, 1000)), INTERVAL 60 SECOND) as ts, sum(value)/60 as value FROM signoz_metrics.distributed_samples_v4")
+	assert.True(t, strings.HasSuffix(query, "GROUP BY service_name, ts) ORDER BY service_name ASC, ts ASC"))
+	assert.Equal(t, v3.SpaceAggregationPercentile99, builderQuery.SpaceAggregation)
+}
+
 func TestPrepareMetricQueryGauge(t *testing.T) {
 	testCases := []struct {
 		name                  string
@@ -0,0 +1,61 @@
+package v4
+
+import (
+	"fmt"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/v4/helpers"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// otherAggregationOp returns the aggregate function of the other series, the series are
+// aggregated as by the query
+func otherAggregationOp(operator v3.SpaceAggregation) string {
+	switch operator {
+	case v3.SpaceAggregationMin, v3.SpaceAggregationMax, v3.SpaceAggregationAvg:
+		return fmt.Sprintf("%s(limit_value)", operator)
+	default:
+		return "sum(limit_value)"
+	}
+}
+
+// prepareSeriesLimitQuery keeps the series of the query with the highest or the lowest
+// average value over the range, the ties are broken by the labels. The other series are
+// dropped, or aggregated together at each timestamp into a series with the labels set to
+// v3.SeriesLimitOtherValue.
+//
+// The columns of the query are renamed for the other series, the labels of the result are
+// aliases of their values
+func prepareSeriesLimitQuery(query string, mq *v3.BuilderQuery, groupBy []v3.AttributeKey) string {
+	limit := mq.SeriesLimit
+
+	labels := make([]string, 0, len(groupBy))
+	for _, tag := range groupBy {
+		labels = append(labels, tag.Key)
+	}
+	order := "DESC"
+	if limit.Direction == v3.SeriesLimitBottom {
+		order = "ASC"
+	}
+	ranked := fmt.Sprintf("SELECT %s FROM (%s) GROUP BY %s ORDER BY avgIf(value, isFinite(value)) %s, %s LIMIT %d",
+		strings.Join(labels, ", "), query, strings.Join(labels, ", "), order, strings.Join(labels, ", "), limit.K)
+	top := fmt.Sprintf("(%s) IN (%s)", strings.Join(labels, ", "), ranked)
+	orderBy := helpers.OrderByAttributeKeyTags(mq.OrderBy, groupBy)
+
+	if !limit.Other {
+		return fmt.Sprintf("SELECT * FROM (%s) WHERE %s ORDER BY %s", query, top, orderBy)
+	}
+
+	renamed := make([]string, 0, len(labels))
+	others := make([]string, 0, len(labels))
+	for _, label := range labels {
+		renamed = append(renamed, fmt.Sprintf("%s as limit_%s", label, label))
+		others = append(others, fmt.Sprintf("if(limit_top, limit_%s, '%s') as %s", label, v3.SeriesLimitOtherValue, label))
+	}
+	return strings.Join([]string{
+		fmt.Sprintf("SELECT %s, limit_ts as ts, %s as value", strings.Join(others, ", "), otherAggregationOp(mq.ResultOperator())),
+		fmt.Sprintf("FROM (SELECT %s, ts as limit_ts, value as limit_value, %s as limit_top FROM (%s))", strings.Join(renamed, ", "), top, query),
+		fmt.Sprintf("GROUP BY %s", helpers.GroupByAttributeKeyTags(groupBy...)),
+		fmt.Sprintf("ORDER BY %s", orderBy),
+	}, " ")
+}
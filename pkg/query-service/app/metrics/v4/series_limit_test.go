@@ -0,0 +1,62 @@
+package v4
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestPrepareSeriesLimitQuery(t *testing.T) {
+	groupBy := []v3.AttributeKey{
+		{Key: "service_name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+		{Key: "endpoint", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+	}
+	testCases := []struct {
+		name          string
+		limit         *v3.SeriesLimit
+		expectedQuery string
+	}{
+		{
+			name:  "top 10",
+			limit: &v3.SeriesLimit{Direction: v3.SeriesLimitTop, K: 10},
+			expectedQuery: "SELECT * FROM (inner) WHERE (service_name, endpoint) IN" +
+				" (SELECT service_name, endpoint FROM (inner) GROUP BY service_name, endpoint ORDER BY avgIf(value, isFinite(value)) DESC, service_name, endpoint LIMIT 10)" +
+				" ORDER BY service_name ASC, endpoint ASC, ts ASC",
+		},
+		{
+			name:  "bottom 5 with the other series",
+			limit: &v3.SeriesLimit{Direction: v3.SeriesLimitBottom, K: 5, Other: true},
+			expectedQuery: "SELECT if(limit_top, limit_service_name, '__other__') as service_name, if(limit_top, limit_endpoint, '__other__') as endpoint, limit_ts as ts, sum(limit_value) as value" +
+				" FROM (SELECT service_name as limit_service_name, endpoint as limit_endpoint, ts as limit_ts, value as limit_value, (service_name, endpoint) IN" +
+				" (SELECT service_name, endpoint FROM (inner) GROUP BY service_name, endpoint ORDER BY avgIf(value, isFinite(value)) ASC, service_name, endpoint LIMIT 5) as limit_top FROM (inner))" +
+				" GROUP BY service_name, endpoint, ts" +
+				" ORDER BY service_name ASC, endpoint ASC, ts ASC",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mq := &v3.BuilderQuery{
+				QueryName:        "A",
+				StepInterval:     60,
+				DataSource:       v3.DataSourceMetrics,
+				GroupBy:          groupBy,
+				Expression:       "A",
+				SpaceAggregation: v3.SpaceAggregationSum,
+				SeriesLimit:      testCase.limit,
+			}
+			assert.NoError(t, testCase.limit.Validate(mq))
+			assert.Equal(t, testCase.expectedQuery, prepareSeriesLimitQuery("inner", mq, groupBy))
+		})
+	}
+
+	mq := &v3.BuilderQuery{QueryName: "A", StepInterval: 60, GroupBy: groupBy, SpaceAggregation: v3.SpaceAggregationPercentile99}
+	for _, invalid := range []*v3.SeriesLimit{
+		{Direction: "first", K: 10},
+		{Direction: v3.SeriesLimitTop},
+		{Direction: v3.SeriesLimitTop, K: 10, Other: true},
+	} {
+		assert.Error(t, invalid.Validate(mq))
+	}
+}
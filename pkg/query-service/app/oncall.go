@@ -0,0 +1,113 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/access"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// parseOnCallTime reads the time param of the on-call requests in milliseconds, it
+// defaults to now
+func parseOnCallTime(r *http.Request) (time.Time, *model.ApiError) {
+	s := r.URL.Query().Get("time")
+	if s == "" {
+		return time.Now(), nil
+	}
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, model.BadRequest(fmt.Errorf("time param is not in correct timestamp format"))
+	}
+	return time.UnixMilli(ms), nil
+}
+
+func (aH *APIHandler) listOnCallSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, apiErr := access.GetOnCallSchedules(r.Context(), r.URL.Query().Get("teamId"))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, schedules)
+}
+
+func (aH *APIHandler) getOnCallSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, apiErr := access.GetOnCallSchedule(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, schedule)
+}
+
+func (aH *APIHandler) createOnCallSchedule(w http.ResponseWriter, r *http.Request) {
+	var schedule access.OnCallSchedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	created, apiErr := access.CreateOnCallSchedule(r.Context(), &schedule)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, created)
+}
+
+func (aH *APIHandler) updateOnCallSchedule(w http.ResponseWriter, r *http.Request) {
+	var schedule access.OnCallSchedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	updated, apiErr := access.UpdateOnCallSchedule(r.Context(), mux.Vars(r)["id"], &schedule)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, updated)
+}
+
+func (aH *APIHandler) deleteOnCallSchedule(w http.ResponseWriter, r *http.Request) {
+	if apiErr := access.DeleteOnCallSchedule(r.Context(), mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// getOnCallScheduleShifts returns who is on call in each rotation of the schedule at the
+// time param, now by default
+func (aH *APIHandler) getOnCallScheduleShifts(w http.ResponseWriter, r *http.Request) {
+	t, apiErr := parseOnCallTime(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	schedule, apiErr := access.GetOnCallSchedule(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, schedule.OnCallAt(t))
+}
+
+// getTeamOnCall returns who is on call in the schedules of the team at the time param, the
+// on-call channels of the team email them
+func (aH *APIHandler) getTeamOnCall(w http.ResponseWriter, r *http.Request) {
+	t, apiErr := parseOnCallTime(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	shifts, apiErr := access.GetTeamOnCall(r.Context(), mux.Vars(r)["id"], t)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, shifts)
+}
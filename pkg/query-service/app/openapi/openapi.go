@@ -0,0 +1,281 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Version is the version of the OpenAPI specification generated
+const Version = "3.0.3"
+
+// Document is the OpenAPI document of the routes of a router
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem is the operations of a path, by lower case method
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Route describes the requests and the responses of a route, the routes which are not
+// described are listed without their schemas
+type Route struct {
+	Summary string
+	Tags    []string
+	// Query are the names of the query parameters
+	Query []string
+	// Request is a value of the type of the JSON body of the requests, nil without body
+	Request interface{}
+	// Response is a value of the type of the data of the responses
+	Response interface{}
+	// Raw is set for the responses which are not in the envelope of their path
+	Raw bool
+}
+
+// envelope wraps the data of the responses of the paths with the prefix
+type envelope struct {
+	prefix string
+	value  interface{}
+}
+
+// Registry has the descriptions of the routes and the envelopes of their responses
+type Registry struct {
+	routes    map[string]Route
+	envelopes []envelope
+}
+
+func NewRegistry() *Registry {
+	return &Registry{routes: map[string]Route{}}
+}
+
+func routeKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+// Describe describes the route of the method and the path template of the router
+func (r *Registry) Describe(method, path string, route Route) {
+	r.routes[routeKey(method, path)] = route
+}
+
+// Envelope sets the envelope of the responses of the paths under the prefix, a value of a
+// struct type with a data property replaced by the data of the responses. The envelope of
+// the longest prefix of a path applies
+func (r *Registry) Envelope(prefix string, value interface{}) {
+	r.envelopes = append(r.envelopes, envelope{prefix: prefix, value: value})
+	sort.SliceStable(r.envelopes, func(i, j int) bool { return len(r.envelopes[i].prefix) > len(r.envelopes[j].prefix) })
+}
+
+func (r *Registry) envelope(path string) interface{} {
+	for _, e := range r.envelopes {
+		if path == e.prefix || strings.HasPrefix(path, strings.TrimSuffix(e.prefix, "/")+"/") {
+			return e.value
+		}
+	}
+	return nil
+}
+
+// Generate generates the document of the routes of the router, the routes without methods
+// are listed as GET routes
+func (r *Registry) Generate(router *mux.Router, info Info) (*Document, error) {
+	doc := &Document{OpenAPI: Version, Info: info, Paths: map[string]PathItem{}}
+	s := newSchemas()
+	operationIDs := map[string]bool{}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet}
+		}
+		path, params := pathParameters(template)
+		for _, method := range methods {
+			if method == http.MethodOptions || method == http.MethodHead {
+				continue
+			}
+			item, ok := doc.Paths[path]
+			if !ok {
+				item = PathItem{}
+				doc.Paths[path] = item
+			}
+			key := strings.ToLower(method)
+			if _, ok := item[key]; ok {
+				// the first route matches the requests, as in the router
+				continue
+			}
+			op := r.operation(s, method, template, path, params)
+			op.OperationID = uniqueID(operationIDs, operationID(method, path))
+			item[key] = op
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	doc.Components.Schemas = s.components
+	return doc, nil
+}
+
+func (r *Registry) operation(s *schemas, method, template, path string, params []string) *Operation {
+	op := &Operation{Responses: map[string]Response{}}
+	for _, param := range params {
+		op.Parameters = append(op.Parameters, Parameter{Name: param, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	route, ok := r.routes[routeKey(method, template)]
+	if !ok {
+		op.Responses["default"] = Response{Description: "the response of the route"}
+		return op
+	}
+
+	op.Summary = route.Summary
+	op.Tags = route.Tags
+	for _, name := range route.Query {
+		op.Parameters = append(op.Parameters, Parameter{Name: name, In: "query", Schema: &Schema{Type: "string"}})
+	}
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{
+			"application/json": {Schema: s.schema(reflect.TypeOf(route.Request))},
+		}}
+	}
+
+	var data *Schema
+	if route.Response != nil {
+		data = s.schema(reflect.TypeOf(route.Response))
+	}
+	envelope := r.envelope(path)
+	if route.Raw || envelope == nil {
+		op.Responses["200"] = Response{Description: "OK", Content: content(data)}
+		return op
+	}
+	op.Responses["200"] = Response{Description: "OK", Content: content(enveloped(s, envelope, data))}
+	op.Responses["default"] = Response{Description: "the error of the request", Content: content(enveloped(s, envelope, nil))}
+	return op
+}
+
+func content(schema *Schema) map[string]MediaType {
+	if schema == nil {
+		return nil
+	}
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// enveloped returns the schema of the envelope with the data, the envelope without data when
+// data is nil
+func enveloped(s *schemas, envelope interface{}, data *Schema) *Schema {
+	schema := s.structSchema(reflect.TypeOf(envelope))
+	if data == nil {
+		delete(schema.Properties, "data")
+	} else {
+		schema.Properties["data"] = data
+	}
+	return schema
+}
+
+// pathParameters returns the path of the template of the route without the patterns of the
+// variables, e.g. /api/v1/rules/{id:[0-9]+} is /api/v1/rules/{id}, and the variables
+func pathParameters(template string) (string, []string) {
+	var path strings.Builder
+	params := []string{}
+	for i := 0; i < len(template); i++ {
+		if template[i] != '{' {
+			path.WriteByte(template[i])
+			continue
+		}
+		depth, end := 0, i
+		for ; end < len(template); end++ {
+			if template[end] == '{' {
+				depth++
+			} else if template[end] == '}' {
+				if depth--; depth == 0 {
+					break
+				}
+			}
+		}
+		name := strings.SplitN(template[i+1:end], ":", 2)[0]
+		params = append(params, name)
+		fmt.Fprintf(&path, "{%s}", name)
+		i = end
+	}
+	return path.String(), params
+}
+
+// operationID returns the id of the operation of the method and the path, e.g. the id of
+// GET /api/v5/rules/{id} is getApiV5RulesById
+func operationID(method, path string) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") {
+			id.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		}) {
+			id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return id.String()
+}
+
+func uniqueID(ids map[string]bool, id string) string {
+	unique := id
+	for i := 2; ids[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", id, i)
+	}
+	ids[unique] = true
+	return unique
+}
@@ -0,0 +1,117 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEnvelope struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type testBase struct {
+	ID string `json:"id"`
+}
+
+type testRule struct {
+	testBase
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels"`
+	Disabled  *bool             `json:"disabled,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Children  []*testRule       `json:"children"`
+	Raw       []byte            `json:"raw"`
+	Internal  string            `json:"-"`
+	NoTag     int64
+	hidden    string
+}
+
+func TestSchema(t *testing.T) {
+	s := newSchemas()
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/openapi.testRule"}, s.schema(reflect.TypeOf(&testRule{})))
+	assert.Equal(t, &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":        {Type: "string"},
+			"name":      {Type: "string"},
+			"labels":    {Type: "object", AdditionalProperties: &Schema{Type: "string"}, Nullable: true},
+			"disabled":  {Type: "boolean", Nullable: true},
+			"createdAt": {Type: "string", Format: "date-time"},
+			"children":  {Type: "array", Items: &Schema{Ref: "#/components/schemas/openapi.testRule"}, Nullable: true},
+			"raw":       {Type: "string", Format: "byte"},
+			"NoTag":     {Type: "integer", Format: "int64"},
+		},
+	}, s.components["openapi.testRule"])
+}
+
+func TestPathParameters(t *testing.T) {
+	path, params := pathParameters("/api/v1/rules/{id:[0-9]{1,3}}/history/{kind}")
+	assert.Equal(t, "/api/v1/rules/{id}/history/{kind}", path)
+	assert.Equal(t, []string{"id", "kind"}, params)
+
+	assert.Equal(t, "getApiV5RulesById", operationID(http.MethodGet, "/api/v5/rules/{id}"))
+	assert.Equal(t, "postApiV1SettingsSamplingRules", operationID(http.MethodPost, "/api/v1/settings/sampling_rules"))
+}
+
+func TestGenerate(t *testing.T) {
+	handler := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/rules", handler).Methods(http.MethodGet, http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}", handler).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/version", handler)
+	router.HandleFunc("/api/v2/rules/{id}", handler).Methods(http.MethodGet)
+	router.HandleFunc("/metrics", handler).Methods(http.MethodGet)
+
+	registry := NewRegistry()
+	registry.Envelope("/api", testEnvelope{})
+	registry.Describe(http.MethodGet, "/api/v1/rules", Route{Summary: "List the rules", Tags: []string{"rules"}, Query: []string{"state"}, Response: []testRule{}})
+	registry.Describe(http.MethodPost, "/api/v1/rules", Route{Request: testRule{}, Response: testRule{}})
+	registry.Describe(http.MethodGet, "/metrics", Route{Response: map[string]float64{}})
+
+	doc, err := registry.Generate(router, Info{Title: "test", Version: "1.0"})
+	require.NoError(t, err)
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.ElementsMatch(t, []string{"/api/v1/rules", "/api/v1/rules/{id}", "/api/v1/version", "/api/v2/rules/{id}", "/metrics"}, keys(doc.Paths))
+	assert.ElementsMatch(t, []string{"get", "post"}, keys(doc.Paths["/api/v1/rules"]))
+	assert.ElementsMatch(t, []string{"get"}, keys(doc.Paths["/api/v1/version"]))
+
+	list := doc.Paths["/api/v1/rules"]["get"]
+	assert.Equal(t, "getApiV1Rules", list.OperationID)
+	assert.Equal(t, "List the rules", list.Summary)
+	assert.Equal(t, []Parameter{{Name: "state", In: "query", Schema: &Schema{Type: "string"}}}, list.Parameters)
+	assert.Equal(t, &Schema{Type: "object", Properties: map[string]*Schema{
+		"status": {Type: "string"},
+		"data":   {Type: "array", Items: &Schema{Ref: "#/components/schemas/openapi.testRule"}, Nullable: true},
+		"error":  {Type: "string"},
+	}}, list.Responses["200"].Content["application/json"].Schema)
+	assert.NotContains(t, list.Responses["default"].Content["application/json"].Schema.Properties, "data")
+
+	create := doc.Paths["/api/v1/rules"]["post"]
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/openapi.testRule"}, create.RequestBody.Content["application/json"].Schema)
+
+	get := doc.Paths["/api/v1/rules/{id}"]["get"]
+	assert.Equal(t, []Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}}, get.Parameters)
+	assert.Equal(t, map[string]Response{"default": {Description: "the response of the route"}}, get.Responses)
+
+	// the paths out of the envelopes respond with their data
+	metrics := doc.Paths["/metrics"]["get"]
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "number", Format: "double"}, Nullable: true},
+		metrics.Responses["200"].Content["application/json"].Schema)
+	assert.Contains(t, doc.Components.Schemas, "openapi.testRule")
+}
+
+func keys[V any](m map[string]V) []string {
+	result := []string{}
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
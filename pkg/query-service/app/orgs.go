@@ -0,0 +1,266 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// activeOrgId returns the org the user of the request works in. The tokens issued before
+// the orgs could be switched have no org, their users work in the org they were created in
+func activeOrgId(r *http.Request) (string, *model.ApiError) {
+	user := common.GetUserFromContext(r.Context())
+	if user == nil {
+		return "", &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("failed to get user from context")}
+	}
+	if user.OrgId != "" {
+		return user.OrgId, nil
+	}
+	stored, apiErr := dao.DB().GetUser(r.Context(), user.Id)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	if stored == nil {
+		return "", &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("no user found with id: %s", user.Id)}
+	}
+	return stored.OrgId, nil
+}
+
+// CheckActiveOrg checks that the org of the route is the org the user of the request works in,
+// the role of the user only applies to this org
+func CheckActiveOrg(r *http.Request, orgId string) *model.ApiError {
+	active, apiErr := activeOrgId(r)
+	if apiErr != nil {
+		return apiErr
+	}
+	if active != orgId {
+		return &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("switch to the org %s to manage it", orgId)}
+	}
+	return nil
+}
+
+// userInActiveOrg returns the membership of the user of the route in the org the user of the
+// request works in, the admins of an org only manage the users of their org
+func userInActiveOrg(r *http.Request, userId string) (*model.UserOrg, *model.ApiError) {
+	active, apiErr := activeOrgId(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	orgs, apiErr := dao.DB().GetUserOrgs(r.Context(), userId)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for i := range orgs {
+		if orgs[i].OrgId == active {
+			return &orgs[i], nil
+		}
+	}
+	return nil, &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("the user %s is not a member of the org %s", userId, active)}
+}
+
+// checkUserAccount checks that the user of the request can change the account of the user of
+// the route. The account is shared by the orgs of the user, only the admins of the org the
+// user was created in manage it
+func checkUserAccount(r *http.Request, userId string) *model.ApiError {
+	if user := common.GetUserFromContext(r.Context()); user != nil && auth.IsSelfAccessRequest(user, userId) {
+		return nil
+	}
+	member, apiErr := userInActiveOrg(r, userId)
+	if apiErr != nil {
+		return apiErr
+	}
+	if !member.Home {
+		return &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("the account of the user %s is managed by the org the user was created in", userId)}
+	}
+	return nil
+}
+
+// countOrgAdmins returns the number of admins of the org, an org must keep an admin
+func countOrgAdmins(r *http.Request, orgId string) (int, *model.ApiError) {
+	users, apiErr := dao.DB().GetUsersByOrg(r.Context(), orgId)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	admins := 0
+	for _, user := range users {
+		if user.GroupId == auth.AuthCacheObj.AdminGroupId {
+			admins++
+		}
+	}
+	return admins, nil
+}
+
+type createOrgRequest struct {
+	Name string `json:"name"`
+}
+
+// createOrg creates an org, the user creating it is its first admin
+func (aH *APIHandler) createOrg(w http.ResponseWriter, r *http.Request) {
+	user := common.GetUserFromContext(r.Context())
+	if user == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("failed to get user from context")}, nil)
+		return
+	}
+	var req createOrgRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		RespondError(w, model.BadRequest(fmt.Errorf("name is required")), nil)
+		return
+	}
+
+	ctx := r.Context()
+	existing, apiErr := dao.DB().GetOrgByName(ctx, req.Name)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if existing != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf("an org named %s already exists", req.Name)}, nil)
+		return
+	}
+	adminGroup, apiErr := dao.DB().GetGroupByName(ctx, constants.AdminGroup)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	org, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: req.Name})
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	member := &model.OrgMember{OrgId: org.Id, UserId: user.Id, GroupId: adminGroup.Id, CreatedAt: time.Now().Unix()}
+	if apiErr := dao.DB().AddOrgMember(ctx, member); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, org)
+}
+
+type orgMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// addOrgMember adds an existing user to the org with a role, or changes the role of a member
+func (aH *APIHandler) addOrgMember(w http.ResponseWriter, r *http.Request) {
+	orgId := mux.Vars(r)["id"]
+	if apiErr := CheckActiveOrg(r, orgId); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	var req orgMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	ctx := r.Context()
+	member, apiErr := dao.DB().GetUserByEmail(ctx, req.Email)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if member == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no user found with email: %s", req.Email)}, nil)
+		return
+	}
+	if member.OrgId == orgId {
+		RespondError(w, model.BadRequest(fmt.Errorf("the org is the org of the user, change the role of the user instead")), nil)
+		return
+	}
+	if !auth.IsValidRole(ctx, req.Role) {
+		RespondError(w, model.BadRequest(fmt.Errorf("invalid role %q", req.Role)), nil)
+		return
+	}
+	group, apiErr := dao.DB().GetGroupByName(ctx, req.Role)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	orgMember := &model.OrgMember{OrgId: orgId, UserId: member.Id, GroupId: group.Id, CreatedAt: time.Now().Unix()}
+	if apiErr := dao.DB().AddOrgMember(ctx, orgMember); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, orgMember)
+}
+
+func (aH *APIHandler) removeOrgMember(w http.ResponseWriter, r *http.Request) {
+	orgId := mux.Vars(r)["id"]
+	if apiErr := CheckActiveOrg(r, orgId); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if apiErr := dao.DB().RemoveOrgMember(r.Context(), orgId, mux.Vars(r)["userId"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// listUserOrgs lists the orgs the user can switch to
+func (aH *APIHandler) listUserOrgs(w http.ResponseWriter, r *http.Request) {
+	if apiErr := checkUserAccount(r, mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	orgs, apiErr := dao.DB().GetUserOrgs(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, orgs)
+}
+
+// switchOrg issues the tokens of the session of the user for another of its orgs,
+// with the role of the user in the org
+func (aH *APIHandler) switchOrg(w http.ResponseWriter, r *http.Request) {
+	user := common.GetUserFromContext(r.Context())
+	if user == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("failed to get user from context")}, nil)
+		return
+	}
+	orgId := mux.Vars(r)["id"]
+
+	orgs, apiErr := dao.DB().GetUserOrgs(r.Context(), user.Id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	var target *model.UserOrg
+	for i := range orgs {
+		if orgs[i].OrgId == orgId {
+			target = &orgs[i]
+		}
+	}
+	if target == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("the user is not a member of the org %s", orgId)}, nil)
+		return
+	}
+
+	switched := user.User
+	switched.OrgId = target.OrgId
+	switched.GroupId = target.GroupId
+	sessionId := auth.ExtractSessionIdFromRequest(r)
+	userJwt, err := auth.GenerateJWTForSession(&switched, sessionId)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, &model.LoginResponse{UserJwtObject: userJwt, UserId: user.Id, SessionId: sessionId})
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestOrgAdminsManageTheirUsers(t *testing.T) {
+	require.NoError(t, dao.InitDao("sqlite", filepath.Join(t.TempDir(), "signoz.db")))
+	ctx := context.Background()
+	require.NoError(t, auth.InitAuthCache(ctx))
+	aH := &APIHandler{}
+
+	orgA, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "a"})
+	require.Nil(t, apiErr)
+	orgB, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "b"})
+	require.Nil(t, apiErr)
+	newUser := func(id, orgId, groupId string) {
+		_, apiErr := dao.DB().CreateUser(ctx, &model.User{Id: id, Name: id, Email: id + "@signoz.io", OrgId: orgId, GroupId: groupId, CreatedAt: time.Now().Unix()}, false)
+		require.Nil(t, apiErr)
+	}
+	admin, viewer := auth.AuthCacheObj.AdminGroupId, auth.AuthCacheObj.ViewerGroupId
+	newUser("alice", orgA.Id, admin)
+	newUser("bob", orgB.Id, admin)
+	// carol was created in the org b and is a member of the org a
+	newUser("carol", orgB.Id, viewer)
+	require.Nil(t, dao.DB().AddOrgMember(ctx, &model.OrgMember{OrgId: orgA.Id, UserId: "carol", GroupId: viewer}))
+
+	request := func(method, body, userId, orgId string, vars map[string]string) *http.Request {
+		req := httptest.NewRequest(method, "/", strings.NewReader(body))
+		user := &model.UserPayload{User: model.User{Id: userId, OrgId: orgId, GroupId: admin}}
+		req = req.WithContext(context.WithValue(req.Context(), constants.ContextUserKey, user))
+		return mux.SetURLVars(req, vars)
+	}
+	call := func(handler http.HandlerFunc, req *http.Request) int {
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr.Code
+	}
+
+	// the admins only access the users of the org they work in
+	am := NewAuthMiddleware(func(r *http.Request) (*model.UserPayload, error) {
+		return &model.UserPayload{User: model.User{Id: "alice", OrgId: orgA.Id, GroupId: admin}}, nil
+	})
+	assert.Equal(t, http.StatusForbidden, call(am.SelfAccess(aH.getUser), request(http.MethodGet, "", "alice", orgA.Id, map[string]string{"id": "bob"})))
+	assert.Equal(t, http.StatusOK, call(am.SelfAccess(aH.getUser), request(http.MethodGet, "", "alice", orgA.Id, map[string]string{"id": "carol"})))
+	assert.Equal(t, http.StatusForbidden, call(aH.getRole, request(http.MethodGet, "", "alice", orgA.Id, map[string]string{"id": "bob"})))
+	assert.Equal(t, http.StatusOK, call(aH.getRole, request(http.MethodGet, "", "alice", orgA.Id, map[string]string{"id": "carol"})))
+
+	// the role of a member is changed in the org only
+	assert.Equal(t, http.StatusForbidden, call(aH.editRole, request(http.MethodPut, `{"group_name": "EDITOR"}`, "alice", orgA.Id, map[string]string{"id": "bob"})))
+	assert.Equal(t, http.StatusOK, call(aH.editRole, request(http.MethodPut, `{"group_name": "EDITOR"}`, "alice", orgA.Id, map[string]string{"id": "carol"})))
+	orgs, apiErr := dao.DB().GetUserOrgs(ctx, "carol")
+	require.Nil(t, apiErr)
+	for _, org := range orgs {
+		if org.Home {
+			assert.Equal(t, constants.ViewerGroup, org.Role)
+		} else {
+			assert.Equal(t, constants.EditorGroup, org.Role)
+		}
+	}
+
+	// the account of a user is managed by the org it was created in
+	assert.Equal(t, http.StatusForbidden, call(aH.deleteUser, request(http.MethodDelete, "", "alice", orgA.Id, map[string]string{"id": "carol"})))
+	assert.Equal(t, http.StatusForbidden, call(aH.editUser, request(http.MethodPut, `{"name": "mallory"}`, "alice", orgA.Id, map[string]string{"id": "carol"})))
+	assert.Equal(t, http.StatusForbidden, call(aH.revokeSessions, request(http.MethodDelete, "", "alice", orgA.Id, map[string]string{"id": "carol"})))
+	assert.Equal(t, http.StatusForbidden, call(aH.getResetPasswordToken, request(http.MethodGet, "", "alice", orgA.Id, map[string]string{"id": "bob"})))
+	assert.Equal(t, http.StatusOK, call(aH.editUser, request(http.MethodPut, `{"name": "carol"}`, "bob", orgB.Id, map[string]string{"id": "carol"})))
+
+	// an org keeps its last admin
+	assert.Equal(t, http.StatusInternalServerError, call(aH.deleteUser, request(http.MethodDelete, "", "bob", orgB.Id, map[string]string{"id": "bob"})))
+	assert.Equal(t, http.StatusOK, call(aH.deleteUser, request(http.MethodDelete, "", "bob", orgB.Id, map[string]string{"id": "carol"})))
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+
+	"go.signoz.io/signoz/pkg/query-service/app/otlp"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// otlpTraces, otlpLogs and otlpMetrics receive the OTLP/HTTP export requests of the OpenTelemetry
+// SDKs, so the small installs can run without a collector. The SDKs authenticate with an ingestion
+// key allowed to send the signal, whose usage is the compressed size of the requests
+func (aH *APIHandler) otlpTraces(w http.ResponseWriter, r *http.Request) {
+	req := ptraceotlp.NewExportRequest()
+	contentType, orgId, ok := readOTLPRequest(w, r, model.IngestionSignalTraces, req)
+	if !ok {
+		return
+	}
+	otlp.SetTracesResource(req.Traces(), constants.OrgIdAttribute, orgId)
+	rejected, err := aH.otlpWriter.WriteTraces(r.Context(), req.Traces())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	resp := ptraceotlp.NewExportResponse()
+	if rejected.Count > 0 {
+		resp.PartialSuccess().SetRejectedSpans(rejected.Count)
+		resp.PartialSuccess().SetErrorMessage(rejected.Message)
+	}
+	writeOTLPResponse(w, resp, contentType)
+}
+
+func (aH *APIHandler) otlpLogs(w http.ResponseWriter, r *http.Request) {
+	req := plogotlp.NewExportRequest()
+	contentType, orgId, ok := readOTLPRequest(w, r, model.IngestionSignalLogs, req)
+	if !ok {
+		return
+	}
+	otlp.SetLogsResource(req.Logs(), constants.OrgIdAttribute, orgId)
+	if err := aH.otlpWriter.WriteLogs(r.Context(), req.Logs()); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	writeOTLPResponse(w, plogotlp.NewExportResponse(), contentType)
+}
+
+func (aH *APIHandler) otlpMetrics(w http.ResponseWriter, r *http.Request) {
+	req := pmetricotlp.NewExportRequest()
+	contentType, orgId, ok := readOTLPRequest(w, r, model.IngestionSignalMetrics, req)
+	if !ok {
+		return
+	}
+	otlp.SetMetricsResource(req.Metrics(), constants.OrgIdAttribute, orgId)
+	rejected, err := aH.otlpWriter.WriteMetrics(r.Context(), req.Metrics())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	resp := pmetricotlp.NewExportResponse()
+	if rejected.Count > 0 {
+		resp.PartialSuccess().SetRejectedDataPoints(rejected.Count)
+		resp.PartialSuccess().SetErrorMessage(rejected.Message)
+	}
+	writeOTLPResponse(w, resp, contentType)
+}
+
+// readOTLPRequest authorizes and decodes the export request, it returns the content type of the
+// request, which the response is encoded with, and the org of the ingestion key
+func readOTLPRequest(w http.ResponseWriter, r *http.Request, signal string, req otlp.Request) (string, string, bool) {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (contentType != otlp.ContentTypeProtobuf && contentType != otlp.ContentTypeJSON) {
+		RespondError(w, model.BadRequest(fmt.Errorf("unsupported content type %q, the requests must be sent in protobuf or JSON", r.Header.Get("Content-Type"))), nil)
+		return "", "", false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, otlp.MaxRequestSize))
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return "", "", false
+	}
+	key, apiErr := authorizeIngestion(r, signal, int64(len(body)))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return "", "", false
+	}
+	if err := otlp.Decode(req, body, contentType, r.Header.Get("Content-Encoding")); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return "", "", false
+	}
+	return contentType, key.OrgId, true
+}
+
+func writeOTLPResponse(w http.ResponseWriter, resp otlp.Response, contentType string) {
+	b, err := otlp.Encode(resp, contentType)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
@@ -0,0 +1,111 @@
+package otlp
+
+import (
+	"math"
+	"sort"
+
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/remotewrite"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// expHistogramScale is the largest scale the exponential histograms are written with, the
+// buckets of the finer histograms are merged down to it. The bounds of the buckets of all the
+// histograms are on the same grid so the buckets of different series add up. At scale 4 a
+// bucket is 4.4% wide
+const expHistogramScale = 4
+
+// expBucket is a bucket of an exponential histogram by the upper bound of its values
+type expBucket struct {
+	le    float64
+	count uint64
+}
+
+// expBound returns the lower bound of the positive bucket of the index at the scale
+func expBound(index int32, scale int32) float64 {
+	return math.Exp2(float64(index) * math.Exp2(float64(-scale)))
+}
+
+// expBuckets returns the buckets of the data point at the scale of the written histograms.
+//
+// The buckets of an exponential histogram are dense between their offset and the last one, the
+// empty buckets within are kept and an empty bucket is added below the lowest one, so the values
+// of each bucket are interpolated between its bounds. The negative buckets have the values
+// between -base^(index+1) and -base^index, the zero bucket the values around zero
+func expBuckets(point pmetric.ExponentialHistogramDataPoint) []expBucket {
+	scale := point.Scale()
+	shift := int32(0)
+	if scale > expHistogramScale {
+		shift = scale - expHistogramScale
+		scale = expHistogramScale
+	}
+
+	// counts by index at the scale, the indexes are merged by shifting them
+	merge := func(buckets pmetric.ExponentialHistogramDataPointBuckets) (map[int32]uint64, int32, int32) {
+		counts := map[int32]uint64{}
+		lowest, highest := int32(math.MaxInt32), int32(math.MinInt32)
+		for i := 0; i < buckets.BucketCounts().Len(); i++ {
+			index := (buckets.Offset() + int32(i)) >> shift
+			counts[index] += buckets.BucketCounts().At(i)
+			lowest = min(lowest, index)
+			highest = max(highest, index)
+		}
+		return counts, lowest, highest
+	}
+
+	var result []expBucket
+	positive, lowest, highest := merge(point.Positive())
+	if len(positive) > 0 {
+		result = append(result, expBucket{le: expBound(lowest, scale)})
+		for index := lowest; index <= highest; index++ {
+			result = append(result, expBucket{le: expBound(index+1, scale), count: positive[index]})
+		}
+	}
+	if point.ZeroCount() > 0 {
+		result = append(result, expBucket{le: point.ZeroThreshold(), count: point.ZeroCount()})
+	}
+	negative, lowest, highest := merge(point.Negative())
+	if len(negative) > 0 {
+		result = append(result, expBucket{le: -expBound(highest+1, scale)})
+		for index := highest; index >= lowest; index-- {
+			result = append(result, expBucket{le: -expBound(index, scale), count: negative[index]})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].le < result[j].le })
+	// the empty bucket below the positive buckets can be the bound of the zero bucket
+	deduped := result[:0]
+	for _, bucket := range result {
+		if n := len(deduped); n > 0 && deduped[n-1].le == bucket.le {
+			deduped[n-1].count += bucket.count
+			continue
+		}
+		deduped = append(deduped, bucket)
+	}
+	return deduped
+}
+
+// exponentialHistogram returns the series of the buckets of the exponential histogram, with
+// the count of each bucket and not the cumulative count of the classic histograms. The buckets
+// of different series can be added up before the quantiles are estimated
+func (b seriesBuilder) exponentialHistogram(histogram pmetric.ExponentialHistogram) []remotewrite.Series {
+	result := []remotewrite.Series{}
+	temporality := toTemporality(histogram.AggregationTemporality())
+	for i := 0; i < histogram.DataPoints().Len(); i++ {
+		point := histogram.DataPoints().At(i)
+		if point.Flags().NoRecordedValue() {
+			continue
+		}
+		attributes, timestamp := point.Attributes(), point.Timestamp()
+		for _, bucket := range expBuckets(point) {
+			le := map[string]string{"le": formatFloat(bucket.le)}
+			result = append(result, b.series(b.name+"_bucket", v3.MetricTypeExponentialBuckets, temporality, false, attributes, timestamp, float64(bucket.count), le))
+		}
+		result = append(result, b.series(b.name+"_count", v3.MetricTypeSum, temporality, true, attributes, timestamp, float64(point.Count()), nil))
+		if point.HasSum() {
+			result = append(result, b.series(b.name+"_sum", v3.MetricTypeSum, temporality, true, attributes, timestamp, point.Sum(), nil))
+		}
+	}
+	return result
+}
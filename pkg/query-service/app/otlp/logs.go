@@ -0,0 +1,147 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+const (
+	logsDB    = "signoz_logs"
+	logsTable = "distributed_logs"
+)
+
+// logRow is a row of the logs table
+type logRow struct {
+	timestamp         uint64
+	observedTimestamp uint64
+	traceID           string
+	spanID            string
+	traceFlags        uint32
+	severityText      string
+	severityNumber    uint8
+	body              string
+	resources         attributes
+	attributes        attributes
+}
+
+// attributes are the attributes of a log record split by type, in the order of their keys
+type attributes struct {
+	stringKeys   []string
+	stringValues []string
+	intKeys      []string
+	intValues    []int64
+	floatKeys    []string
+	floatValues  []float64
+	boolKeys     []string
+	boolValues   []bool
+}
+
+func newAttributes(m pcommon.Map) attributes {
+	a := attributes{stringKeys: []string{}, stringValues: []string{}, intKeys: []string{}, intValues: []int64{},
+		floatKeys: []string{}, floatValues: []float64{}, boolKeys: []string{}, boolValues: []bool{}}
+	for _, k := range sortedKeys(m) {
+		v, _ := m.Get(k)
+		switch v.Type() {
+		case pcommon.ValueTypeInt:
+			a.intKeys = append(a.intKeys, k)
+			a.intValues = append(a.intValues, v.Int())
+		case pcommon.ValueTypeDouble:
+			a.floatKeys = append(a.floatKeys, k)
+			a.floatValues = append(a.floatValues, v.Double())
+		case pcommon.ValueTypeBool:
+			a.boolKeys = append(a.boolKeys, k)
+			a.boolValues = append(a.boolValues, v.Bool())
+		default:
+			a.stringKeys = append(a.stringKeys, k)
+			a.stringValues = append(a.stringValues, v.AsString())
+		}
+	}
+	return a
+}
+
+// stringAttributes returns the attributes with their values as strings, the resources are strings only
+func stringAttributes(m pcommon.Map) attributes {
+	a := attributes{stringKeys: []string{}, stringValues: []string{}}
+	for _, k := range sortedKeys(m) {
+		v, _ := m.Get(k)
+		a.stringKeys = append(a.stringKeys, k)
+		a.stringValues = append(a.stringValues, v.AsString())
+	}
+	return a
+}
+
+// SetLogsResource sets a resource attribute of all the log records, e.g. the org of the ingestion key
+func SetLogsResource(logs plog.Logs, key, value string) {
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		logs.ResourceLogs().At(i).Resource().Attributes().PutStr(key, value)
+	}
+}
+
+// WriteLogs writes the log records, the records without timestamp get their observed timestamp
+func (w *Writer) WriteLogs(ctx context.Context, logs plog.Logs) error {
+	rows := toLogRows(logs, uint64(time.Now().UnixNano()))
+	if len(rows) == 0 {
+		return nil
+	}
+
+	statement, err := w.conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (timestamp, observed_timestamp, id, trace_id, span_id, trace_flags, "+
+		"severity_text, severity_number, body, resources_string_key, resources_string_value, attributes_string_key, attributes_string_value, "+
+		"attributes_int64_key, attributes_int64_value, attributes_float64_key, attributes_float64_value, attributes_bool_key, attributes_bool_value) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", logsDB, logsTable))
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		err := statement.Append(row.timestamp, row.observedTimestamp, uuid.NewString(), row.traceID, row.spanID, row.traceFlags,
+			row.severityText, row.severityNumber, row.body, row.resources.stringKeys, row.resources.stringValues,
+			row.attributes.stringKeys, row.attributes.stringValues, row.attributes.intKeys, row.attributes.intValues,
+			row.attributes.floatKeys, row.attributes.floatValues, row.attributes.boolKeys, row.attributes.boolValues)
+		if err != nil {
+			return err
+		}
+	}
+	return statement.Send()
+}
+
+func toLogRows(logs plog.Logs, now uint64) []logRow {
+	rows := []logRow{}
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		resourceLogs := logs.ResourceLogs().At(i)
+		resources := stringAttributes(resourceLogs.Resource().Attributes())
+		for j := 0; j < resourceLogs.ScopeLogs().Len(); j++ {
+			scopeLogs := resourceLogs.ScopeLogs().At(j)
+			for k := 0; k < scopeLogs.LogRecords().Len(); k++ {
+				record := scopeLogs.LogRecords().At(k)
+				row := logRow{
+					timestamp:         uint64(record.Timestamp()),
+					observedTimestamp: uint64(record.ObservedTimestamp()),
+					traceFlags:        uint32(record.Flags()),
+					severityText:      record.SeverityText(),
+					severityNumber:    uint8(record.SeverityNumber()),
+					body:              record.Body().AsString(),
+					resources:         resources,
+					attributes:        newAttributes(record.Attributes()),
+				}
+				if row.observedTimestamp == 0 {
+					row.observedTimestamp = now
+				}
+				if row.timestamp == 0 {
+					row.timestamp = row.observedTimestamp
+				}
+				if !record.TraceID().IsEmpty() {
+					row.traceID = record.TraceID().String()
+				}
+				if !record.SpanID().IsEmpty() {
+					row.spanID = record.SpanID().String()
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows
+}
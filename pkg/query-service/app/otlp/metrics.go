@@ -0,0 +1,208 @@
+package otlp
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/prometheus/prompb"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/remotewrite"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const nameLabel = "__name__"
+
+// WriteMetrics writes the data points as the series of the metrics tables, the metrics
+// without type are rejected
+func (w *Writer) WriteMetrics(ctx context.Context, metrics pmetric.Metrics) (Rejected, error) {
+	series, rejected := toSeries(metrics)
+	return rejected, w.metrics.WriteSeries(ctx, series)
+}
+
+// SetMetricsResource sets a resource attribute of all the data points, e.g. the org of the
+// ingestion key. It is written as a label, sanitized like the other resource attributes
+func SetMetricsResource(metrics pmetric.Metrics, key, value string) {
+	for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+		metrics.ResourceMetrics().At(i).Resource().Attributes().PutStr(key, value)
+	}
+}
+
+// toSeries converts the data points to series of one sample, the names of the metrics and of the
+// labels are sanitized and the histograms and summaries split, as the SigNoz exporter
+func toSeries(metrics pmetric.Metrics) ([]remotewrite.Series, Rejected) {
+	result := []remotewrite.Series{}
+	rejected := Rejected{}
+	for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+		resourceMetrics := metrics.ResourceMetrics().At(i)
+		for j := 0; j < resourceMetrics.ScopeMetrics().Len(); j++ {
+			scopeMetrics := resourceMetrics.ScopeMetrics().At(j)
+			for k := 0; k < scopeMetrics.Metrics().Len(); k++ {
+				metric := scopeMetrics.Metrics().At(k)
+				b := seriesBuilder{resources: resourceMetrics.Resource().Attributes(), metric: metric, name: sanitize(metric.Name())}
+				switch {
+				case b.name == "":
+					rejected.add(dataPointCount(metric), "metrics without name")
+				case metric.Type() == pmetric.MetricTypeEmpty:
+					rejected.add(dataPointCount(metric), "metrics without type")
+				default:
+					result = append(result, b.build()...)
+				}
+			}
+		}
+	}
+	return result, rejected
+}
+
+// seriesBuilder builds the series of the data points of a metric
+type seriesBuilder struct {
+	resources pcommon.Map
+	metric    pmetric.Metric
+	name      string
+}
+
+func (b seriesBuilder) build() []remotewrite.Series {
+	result := []remotewrite.Series{}
+	switch b.metric.Type() {
+	case pmetric.MetricTypeGauge:
+		points := b.metric.Gauge().DataPoints()
+		for i := 0; i < points.Len(); i++ {
+			if point := points.At(i); !point.Flags().NoRecordedValue() {
+				result = append(result, b.series(b.name, v3.MetricTypeGauge, v3.Unspecified, false, point.Attributes(), point.Timestamp(), numberValue(point), nil))
+			}
+		}
+	case pmetric.MetricTypeSum:
+		sum := b.metric.Sum()
+		temporality := toTemporality(sum.AggregationTemporality())
+		for i := 0; i < sum.DataPoints().Len(); i++ {
+			if point := sum.DataPoints().At(i); !point.Flags().NoRecordedValue() {
+				result = append(result, b.series(b.name, v3.MetricTypeSum, temporality, sum.IsMonotonic(), point.Attributes(), point.Timestamp(), numberValue(point), nil))
+			}
+		}
+	case pmetric.MetricTypeHistogram:
+		histogram := b.metric.Histogram()
+		temporality := toTemporality(histogram.AggregationTemporality())
+		for i := 0; i < histogram.DataPoints().Len(); i++ {
+			point := histogram.DataPoints().At(i)
+			if point.Flags().NoRecordedValue() {
+				continue
+			}
+			// the buckets are cumulative, as the le buckets of Prometheus
+			cumulative := uint64(0)
+			for j := 0; j < point.ExplicitBounds().Len() && j < point.BucketCounts().Len(); j++ {
+				cumulative += point.BucketCounts().At(j)
+				le := map[string]string{"le": formatFloat(point.ExplicitBounds().At(j))}
+				result = append(result, b.series(b.name+"_bucket", v3.MetricTypeHistogram, temporality, false, point.Attributes(), point.Timestamp(), float64(cumulative), le))
+			}
+			result = append(result,
+				b.series(b.name+"_bucket", v3.MetricTypeHistogram, temporality, false, point.Attributes(), point.Timestamp(), float64(point.Count()), map[string]string{"le": "+Inf"}),
+				b.series(b.name+"_count", v3.MetricTypeSum, temporality, true, point.Attributes(), point.Timestamp(), float64(point.Count()), nil))
+			if point.HasSum() {
+				result = append(result, b.series(b.name+"_sum", v3.MetricTypeSum, temporality, true, point.Attributes(), point.Timestamp(), point.Sum(), nil))
+			}
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		result = append(result, b.exponentialHistogram(b.metric.ExponentialHistogram())...)
+	case pmetric.MetricTypeSummary:
+		points := b.metric.Summary().DataPoints()
+		for i := 0; i < points.Len(); i++ {
+			point := points.At(i)
+			if point.Flags().NoRecordedValue() {
+				continue
+			}
+			for j := 0; j < point.QuantileValues().Len(); j++ {
+				quantile := point.QuantileValues().At(j)
+				labels := map[string]string{"quantile": formatFloat(quantile.Quantile())}
+				result = append(result, b.series(b.name, v3.MetricTypeSummary, v3.Unspecified, false, point.Attributes(), point.Timestamp(), quantile.Value(), labels))
+			}
+			result = append(result,
+				b.series(b.name+"_count", v3.MetricTypeSum, v3.Cumulative, true, point.Attributes(), point.Timestamp(), float64(point.Count()), nil),
+				b.series(b.name+"_sum", v3.MetricTypeSum, v3.Cumulative, true, point.Attributes(), point.Timestamp(), point.Sum(), nil))
+		}
+	}
+	return result
+}
+
+// series returns a series with the labels of the resource, of the data point and the extra labels
+func (b seriesBuilder) series(name string, typ v3.MetricType, temporality v3.Temporality, isMonotonic bool,
+	attributes pcommon.Map, timestamp pcommon.Timestamp, value float64, extra map[string]string) remotewrite.Series {
+	labels := map[string]string{}
+	for _, m := range []pcommon.Map{b.resources, attributes} {
+		m.Range(func(k string, v pcommon.Value) bool {
+			labels[sanitize(k)] = v.AsString()
+			return true
+		})
+	}
+	for k, v := range extra {
+		labels[k] = v
+	}
+	labels[nameLabel] = name
+	return remotewrite.Series{
+		Labels:      labels,
+		Type:        typ,
+		Temporality: temporality,
+		IsMonotonic: isMonotonic,
+		Description: b.metric.Description(),
+		Unit:        b.metric.Unit(),
+		Samples:     []prompb.Sample{{Timestamp: int64(timestamp) / 1000000, Value: value}},
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func numberValue(point pmetric.NumberDataPoint) float64 {
+	if point.ValueType() == pmetric.NumberDataPointValueTypeInt {
+		return float64(point.IntValue())
+	}
+	return point.DoubleValue()
+}
+
+func toTemporality(temporality pmetric.AggregationTemporality) v3.Temporality {
+	switch temporality {
+	case pmetric.AggregationTemporalityDelta:
+		return v3.Delta
+	case pmetric.AggregationTemporalityCumulative:
+		return v3.Cumulative
+	default:
+		return v3.Unspecified
+	}
+}
+
+func dataPointCount(metric pmetric.Metric) int {
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		return metric.Gauge().DataPoints().Len()
+	case pmetric.MetricTypeSum:
+		return metric.Sum().DataPoints().Len()
+	case pmetric.MetricTypeHistogram:
+		return metric.Histogram().DataPoints().Len()
+	case pmetric.MetricTypeExponentialHistogram:
+		return metric.ExponentialHistogram().DataPoints().Len()
+	case pmetric.MetricTypeSummary:
+		return metric.Summary().DataPoints().Len()
+	default:
+		return 0
+	}
+}
+
+// sanitize replaces the characters not allowed in the Prometheus names with underscores,
+// e.g. service.name is written as service_name
+func sanitize(name string) string {
+	if name == "" {
+		return ""
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+	if name[0] >= '0' && name[0] <= '9' {
+		return "key_" + name
+	}
+	return name
+}
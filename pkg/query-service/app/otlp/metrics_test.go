@@ -0,0 +1,118 @@
+package otlp
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/remotewrite"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestToSeries(t *testing.T) {
+	metrics := pmetric.NewMetrics()
+	resourceMetrics := metrics.ResourceMetrics().AppendEmpty()
+	resourceMetrics.Resource().Attributes().PutStr("service.name", "api")
+	scopeMetrics := resourceMetrics.ScopeMetrics().AppendEmpty()
+	timestamp := pcommon.Timestamp(1700000000000000000)
+
+	sum := scopeMetrics.Metrics().AppendEmpty()
+	sum.SetName("http.server.requests")
+	sum.SetUnit("1")
+	sum.SetEmptySum().SetIsMonotonic(true)
+	sum.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	point := sum.Sum().DataPoints().AppendEmpty()
+	point.SetTimestamp(timestamp)
+	point.SetIntValue(7)
+	point.Attributes().PutStr("http.method", "GET")
+
+	histogram := scopeMetrics.Metrics().AppendEmpty()
+	histogram.SetName("http.server.duration")
+	histogram.SetEmptyHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	histogramPoint := histogram.Histogram().DataPoints().AppendEmpty()
+	histogramPoint.SetTimestamp(timestamp)
+	histogramPoint.ExplicitBounds().FromRaw([]float64{0.1, 1})
+	histogramPoint.BucketCounts().FromRaw([]uint64{2, 3, 1})
+	histogramPoint.SetCount(6)
+	histogramPoint.SetSum(4.5)
+
+	gauge := scopeMetrics.Metrics().AppendEmpty()
+	gauge.SetName("queue.size")
+	gaugePoint := gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	gaugePoint.SetTimestamp(timestamp)
+	gaugePoint.SetDoubleValue(3)
+	// the data points without value are not written
+	gauge.Gauge().DataPoints().AppendEmpty().SetFlags(pmetric.DefaultDataPointFlags.WithNoRecordedValue(true))
+
+	exponential := scopeMetrics.Metrics().AppendEmpty()
+	exponential.SetName("latency")
+	exponential.SetEmptyExponentialHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	exponentialPoint := exponential.ExponentialHistogram().DataPoints().AppendEmpty()
+	exponentialPoint.SetTimestamp(timestamp)
+	exponentialPoint.Positive().BucketCounts().FromRaw([]uint64{1, 2})
+	exponentialPoint.SetZeroCount(1)
+	exponentialPoint.SetCount(4)
+
+	// the metrics without type are rejected
+	scopeMetrics.Metrics().AppendEmpty().SetName("untyped")
+
+	series, rejected := toSeries(metrics)
+	assert.Equal(t, Rejected{Count: 0, Message: "metrics without type"}, rejected)
+
+	sample := func(value float64) []prompb.Sample {
+		return []prompb.Sample{{Timestamp: 1700000000000, Value: value}}
+	}
+	labels := func(name string, extra ...string) map[string]string {
+		l := map[string]string{"__name__": name, "service_name": "api"}
+		for i := 0; i+1 < len(extra); i += 2 {
+			l[extra[i]] = extra[i+1]
+		}
+		return l
+	}
+	require.Equal(t, []remotewrite.Series{
+		{Labels: labels("http_server_requests", "http_method", "GET"), Type: v3.MetricTypeSum, Temporality: v3.Delta, IsMonotonic: true, Unit: "1", Samples: sample(7)},
+		{Labels: labels("http_server_duration_bucket", "le", "0.1"), Type: v3.MetricTypeHistogram, Temporality: v3.Cumulative, Samples: sample(2)},
+		{Labels: labels("http_server_duration_bucket", "le", "1"), Type: v3.MetricTypeHistogram, Temporality: v3.Cumulative, Samples: sample(5)},
+		{Labels: labels("http_server_duration_bucket", "le", "+Inf"), Type: v3.MetricTypeHistogram, Temporality: v3.Cumulative, Samples: sample(6)},
+		{Labels: labels("http_server_duration_count"), Type: v3.MetricTypeSum, Temporality: v3.Cumulative, IsMonotonic: true, Samples: sample(6)},
+		{Labels: labels("http_server_duration_sum"), Type: v3.MetricTypeSum, Temporality: v3.Cumulative, IsMonotonic: true, Samples: sample(4.5)},
+		{Labels: labels("queue_size"), Type: v3.MetricTypeGauge, Temporality: v3.Unspecified, Samples: sample(3)},
+		{Labels: labels("latency_bucket", "le", "0"), Type: v3.MetricTypeExponentialBuckets, Temporality: v3.Delta, Samples: sample(1)},
+		{Labels: labels("latency_bucket", "le", "1"), Type: v3.MetricTypeExponentialBuckets, Temporality: v3.Delta, Samples: sample(0)},
+		{Labels: labels("latency_bucket", "le", "2"), Type: v3.MetricTypeExponentialBuckets, Temporality: v3.Delta, Samples: sample(1)},
+		{Labels: labels("latency_bucket", "le", "4"), Type: v3.MetricTypeExponentialBuckets, Temporality: v3.Delta, Samples: sample(2)},
+		{Labels: labels("latency_count"), Type: v3.MetricTypeSum, Temporality: v3.Delta, IsMonotonic: true, Samples: sample(4)},
+	}, series)
+}
+
+func TestExpBuckets(t *testing.T) {
+	point := pmetric.NewExponentialHistogramDataPoint()
+	// scale 5 is merged down to scale 4, the bucket 3 becomes 1 and the buckets 4 and 5 merge into 2
+	point.SetScale(5)
+	point.Positive().SetOffset(3)
+	point.Positive().BucketCounts().FromRaw([]uint64{1, 2, 3})
+	point.SetZeroThreshold(0.5)
+	point.SetZeroCount(4)
+	point.Negative().SetOffset(0)
+	point.Negative().BucketCounts().FromRaw([]uint64{5})
+
+	assert.Equal(t, []expBucket{
+		{le: -expBound(1, 4)},
+		{le: -1, count: 5},
+		{le: 0.5, count: 4},
+		{le: expBound(1, 4), count: 0},
+		{le: expBound(2, 4), count: 1},
+		{le: expBound(3, 4), count: 5},
+	}, expBuckets(point))
+}
+
+func TestSanitize(t *testing.T) {
+	assert.Equal(t, "service_name", sanitize("service.name"))
+	assert.Equal(t, "http_server_duration", sanitize("http.server-duration"))
+	assert.Equal(t, "key_2xx", sanitize("2xx"))
+	assert.Equal(t, "", sanitize(""))
+}
@@ -0,0 +1,114 @@
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/remotewrite"
+	"go.signoz.io/signoz/pkg/query-service/app/traces/spans"
+)
+
+const (
+	// ContentTypeProtobuf and ContentTypeJSON are the encodings of the OTLP/HTTP requests
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeJSON     = "application/json"
+	// MaxRequestSize is the largest export request accepted, before decompression
+	MaxRequestSize = 32 << 20
+)
+
+// Request is an export request of the traces, logs or metrics
+type Request interface {
+	UnmarshalProto(data []byte) error
+	UnmarshalJSON(data []byte) error
+}
+
+// Response is the response of an export request
+type Response interface {
+	MarshalProto() ([]byte, error)
+	MarshalJSON() ([]byte, error)
+}
+
+// Decode decodes an export request in the protobuf or the JSON encoding, optionally gzip compressed
+func Decode(req Request, body []byte, contentType, contentEncoding string) error {
+	if contentEncoding == "gzip" {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to decompress request: %v", err)
+		}
+		if body, err = io.ReadAll(io.LimitReader(reader, 4*MaxRequestSize)); err != nil {
+			return fmt.Errorf("failed to decompress request: %v", err)
+		}
+	}
+
+	var err error
+	switch contentType {
+	case ContentTypeProtobuf:
+		err = req.UnmarshalProto(body)
+	case ContentTypeJSON:
+		err = req.UnmarshalJSON(body)
+	default:
+		return fmt.Errorf("unsupported content type %q", contentType)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode request: %v", err)
+	}
+	return nil
+}
+
+// Encode encodes the response in the encoding of the request
+func Encode(resp Response, contentType string) ([]byte, error) {
+	if contentType == ContentTypeJSON {
+		return resp.MarshalJSON()
+	}
+	return resp.MarshalProto()
+}
+
+// Rejected counts the spans, log records or data points of a request that were not written
+type Rejected struct {
+	Count   int64
+	Message string
+}
+
+func (r *Rejected) add(count int, message string) {
+	r.Count += int64(count)
+	r.Message = message
+}
+
+// Writer writes the OTLP traces, logs and metrics into the SigNoz tables, the data is written
+// like the SigNoz exporters of the collector
+type Writer struct {
+	conn    clickhouse.Conn
+	spans   *spans.Writer
+	metrics *remotewrite.Writer
+}
+
+func NewWriter(conn clickhouse.Conn) *Writer {
+	return &Writer{conn: conn, spans: spans.NewWriter(conn), metrics: remotewrite.NewWriter(conn)}
+}
+
+// stringMap returns the attributes with their values as strings
+func stringMap(attributes pcommon.Map) map[string]string {
+	result := make(map[string]string, attributes.Len())
+	attributes.Range(func(k string, v pcommon.Value) bool {
+		result[k] = v.AsString()
+		return true
+	})
+	return result
+}
+
+// sortedKeys returns the keys of the attributes, the attributes are written in the order of their keys
+func sortedKeys(attributes pcommon.Map) []string {
+	keys := make([]string, 0, attributes.Len())
+	attributes.Range(func(k string, _ pcommon.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,99 @@
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+)
+
+func testLogs() plog.Logs {
+	logs := plog.NewLogs()
+	resourceLogs := logs.ResourceLogs().AppendEmpty()
+	resourceLogs.Resource().Attributes().PutStr("service.name", "api")
+	resourceLogs.Resource().Attributes().PutInt("process.pid", 42)
+	record := resourceLogs.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	record.SetObservedTimestamp(pcommon.Timestamp(1700000000000000000))
+	record.SetSeverityText("ERROR")
+	record.SetSeverityNumber(plog.SeverityNumberError)
+	record.Body().SetStr("request failed")
+	record.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	record.SetFlags(plog.DefaultLogRecordFlags.WithIsSampled(true))
+	record.Attributes().PutStr("http.method", "GET")
+	record.Attributes().PutInt("http.status_code", 500)
+	record.Attributes().PutDouble("duration", 1.5)
+	record.Attributes().PutBool("retried", true)
+	return logs
+}
+
+func TestDecode(t *testing.T) {
+	req := plogotlp.NewExportRequestFromLogs(testLogs())
+	protobuf, err := req.MarshalProto()
+	require.NoError(t, err)
+	json, err := req.MarshalJSON()
+	require.NoError(t, err)
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err = gz.Write(protobuf)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	for _, tc := range []struct {
+		name            string
+		body            []byte
+		contentType     string
+		contentEncoding string
+	}{
+		{"protobuf", protobuf, ContentTypeProtobuf, ""},
+		{"json", json, ContentTypeJSON, ""},
+		{"gzip", compressed.Bytes(), ContentTypeProtobuf, "gzip"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			decoded := plogotlp.NewExportRequest()
+			require.NoError(t, Decode(decoded, tc.body, tc.contentType, tc.contentEncoding))
+			assert.Equal(t, 1, decoded.Logs().LogRecordCount())
+		})
+	}
+
+	assert.Error(t, Decode(plogotlp.NewExportRequest(), json, ContentTypeProtobuf, ""))
+	assert.Error(t, Decode(plogotlp.NewExportRequest(), protobuf, "text/plain", ""))
+	assert.Error(t, Decode(plogotlp.NewExportRequest(), protobuf, ContentTypeProtobuf, "gzip"))
+}
+
+func TestToLogRows(t *testing.T) {
+	rows := toLogRows(testLogs(), 1700000001000000000)
+	require.Len(t, rows, 1)
+	row := rows[0]
+
+	// the records without timestamp get their observed timestamp
+	assert.Equal(t, uint64(1700000000000000000), row.timestamp)
+	assert.Equal(t, uint64(1700000000000000000), row.observedTimestamp)
+	assert.Equal(t, "0102030405060708090a0b0c0d0e0f10", row.traceID)
+	assert.Equal(t, "", row.spanID)
+	assert.Equal(t, uint32(1), row.traceFlags)
+	assert.Equal(t, "ERROR", row.severityText)
+	assert.Equal(t, uint8(17), row.severityNumber)
+	assert.Equal(t, "request failed", row.body)
+
+	assert.Equal(t, []string{"process.pid", "service.name"}, row.resources.stringKeys)
+	assert.Equal(t, []string{"42", "api"}, row.resources.stringValues)
+	assert.Equal(t, []string{"http.method"}, row.attributes.stringKeys)
+	assert.Equal(t, []string{"GET"}, row.attributes.stringValues)
+	assert.Equal(t, []string{"http.status_code"}, row.attributes.intKeys)
+	assert.Equal(t, []int64{500}, row.attributes.intValues)
+	assert.Equal(t, []string{"duration"}, row.attributes.floatKeys)
+	assert.Equal(t, []float64{1.5}, row.attributes.floatValues)
+	assert.Equal(t, []string{"retried"}, row.attributes.boolKeys)
+	assert.Equal(t, []bool{true}, row.attributes.boolValues)
+
+	logs := plog.NewLogs()
+	logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	rows = toLogRows(logs, 1700000001000000000)
+	require.Len(t, rows, 1)
+	assert.Equal(t, uint64(1700000001000000000), rows[0].timestamp)
+}
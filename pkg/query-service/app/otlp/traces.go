@@ -0,0 +1,132 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"go.signoz.io/signoz/pkg/query-service/app/traces/spans"
+)
+
+const (
+	serviceNameAttribute = "service.name"
+	unknownService       = "unknown_service"
+)
+
+// WriteTraces writes the spans, the spans without trace or span id are rejected
+func (w *Writer) WriteTraces(ctx context.Context, traces ptrace.Traces) (Rejected, error) {
+	converted, rejected, err := toSpans(traces)
+	if err != nil {
+		return rejected, err
+	}
+	return rejected, w.spans.Write(ctx, converted)
+}
+
+// SetTracesResource sets a resource attribute of all the spans, e.g. the org of the ingestion key
+func SetTracesResource(traces ptrace.Traces, key, value string) {
+	for i := 0; i < traces.ResourceSpans().Len(); i++ {
+		traces.ResourceSpans().At(i).Resource().Attributes().PutStr(key, value)
+	}
+}
+
+func toSpans(traces ptrace.Traces) ([]*spans.Span, Rejected, error) {
+	result := []*spans.Span{}
+	rejected := Rejected{}
+	for i := 0; i < traces.ResourceSpans().Len(); i++ {
+		resourceSpans := traces.ResourceSpans().At(i)
+		resources := stringMap(resourceSpans.Resource().Attributes())
+		for j := 0; j < resourceSpans.ScopeSpans().Len(); j++ {
+			scopeSpans := resourceSpans.ScopeSpans().At(j)
+			for k := 0; k < scopeSpans.Spans().Len(); k++ {
+				span := scopeSpans.Spans().At(k)
+				if span.TraceID().IsEmpty() || span.SpanID().IsEmpty() {
+					rejected.add(1, "spans without trace or span id")
+					continue
+				}
+				converted, err := toSpan(span, resources)
+				if err != nil {
+					return nil, rejected, err
+				}
+				result = append(result, converted)
+			}
+		}
+	}
+	return result, rejected, nil
+}
+
+// toSpan converts a span as the SigNoz exporter of the collector, the resources are also stored
+// with the attributes
+func toSpan(span ptrace.Span, resources map[string]string) (*spans.Span, error) {
+	serviceName := resources[serviceNameAttribute]
+	if serviceName == "" {
+		serviceName = unknownService
+	}
+	traceID := span.TraceID().String()
+	parentID := ""
+	if !span.ParentSpanID().IsEmpty() {
+		parentID = span.ParentSpanID().String()
+	}
+	duration := uint64(0)
+	if span.EndTimestamp() > span.StartTimestamp() {
+		duration = uint64(span.EndTimestamp() - span.StartTimestamp())
+	}
+
+	result := &spans.Span{
+		TraceID:           traceID,
+		SpanID:            span.SpanID().String(),
+		ParentSpanID:      parentID,
+		Name:              span.Name(),
+		StartTimeUnixNano: uint64(span.StartTimestamp()),
+		DurationNano:      duration,
+		ServiceName:       serviceName,
+		Kind:              int8(span.Kind()),
+		SpanKind:          span.Kind().String(),
+		StatusCode:        int16(span.Status().Code()),
+		StatusCodeString:  span.Status().Code().String(),
+		StatusMessage:     span.Status().Message(),
+		HasError:          span.Status().Code() == ptrace.StatusCodeError,
+		StringTagMap:      map[string]string{},
+		NumberTagMap:      map[string]float64{},
+		BoolTagMap:        map[string]bool{},
+		ResourceTagsMap:   resources,
+		TagMap:            map[string]string{},
+		Events:            []string{},
+		References:        []spans.Ref{{TraceID: traceID, SpanID: parentID, RefType: "CHILD_OF"}},
+	}
+	for i := 0; i < span.Links().Len(); i++ {
+		link := span.Links().At(i)
+		result.References = append(result.References, spans.Ref{TraceID: link.TraceID().String(), SpanID: link.SpanID().String(), RefType: "FOLLOWS_FROM"})
+	}
+
+	span.Attributes().Range(func(k string, v pcommon.Value) bool {
+		switch v.Type() {
+		case pcommon.ValueTypeInt:
+			result.NumberTagMap[k] = float64(v.Int())
+		case pcommon.ValueTypeDouble:
+			result.NumberTagMap[k] = v.Double()
+		case pcommon.ValueTypeBool:
+			result.BoolTagMap[k] = v.Bool()
+		default:
+			result.StringTagMap[k] = v.AsString()
+		}
+		result.TagMap[k] = v.AsString()
+		return true
+	})
+	for k, v := range resources {
+		result.StringTagMap[k] = v
+		result.TagMap[k] = v
+	}
+	spans.PopulateDimensions(result, stringMap(span.Attributes()))
+
+	for i := 0; i < span.Events().Len(); i++ {
+		event := span.Events().At(i)
+		encoded, err := json.Marshal(spans.Event{Name: event.Name(), TimeUnixNano: uint64(event.Timestamp()), AttributeMap: event.Attributes().AsRaw()})
+		if err != nil {
+			return nil, err
+		}
+		result.Events = append(result.Events, string(encoded))
+	}
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package otlp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestToSpans(t *testing.T) {
+	traces := ptrace.NewTraces()
+	resourceSpans := traces.ResourceSpans().AppendEmpty()
+	resourceSpans.Resource().Attributes().PutStr("service.name", "frontend")
+	scopeSpans := resourceSpans.ScopeSpans().AppendEmpty()
+
+	span := scopeSpans.Spans().AppendEmpty()
+	span.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	span.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+	span.SetParentSpanID(pcommon.SpanID{8, 7, 6, 5, 4, 3, 2, 1})
+	span.SetName("GET /api")
+	span.SetKind(ptrace.SpanKindClient)
+	span.SetStartTimestamp(pcommon.Timestamp(1700000000000000000))
+	span.SetEndTimestamp(pcommon.Timestamp(1700000000002500000))
+	span.Status().SetCode(ptrace.StatusCodeError)
+	span.Status().SetMessage("timeout")
+	span.Attributes().PutStr("http.method", "GET")
+	span.Attributes().PutStr("http.url", "http://backend:8080/api")
+	span.Attributes().PutInt("http.status_code", 504)
+	span.Attributes().PutBool("retried", true)
+	event := span.Events().AppendEmpty()
+	event.SetName("exception")
+	event.SetTimestamp(pcommon.Timestamp(1700000000001000000))
+	event.Attributes().PutStr("exception.type", "TimeoutError")
+
+	// the spans without ids are rejected
+	scopeSpans.Spans().AppendEmpty().SetName("no ids")
+
+	result, rejected, err := toSpans(traces)
+	require.NoError(t, err)
+	assert.Equal(t, Rejected{Count: 1, Message: "spans without trace or span id"}, rejected)
+	require.Len(t, result, 1)
+	s := result[0]
+
+	assert.Equal(t, "0102030405060708090a0b0c0d0e0f10", s.TraceID)
+	assert.Equal(t, "0102030405060708", s.SpanID)
+	assert.Equal(t, "0807060504030201", s.ParentSpanID)
+	assert.Equal(t, uint64(2500000), s.DurationNano)
+	assert.Equal(t, "frontend", s.ServiceName)
+	assert.Equal(t, int8(3), s.Kind)
+	assert.Equal(t, "Client", s.SpanKind)
+	assert.True(t, s.HasError)
+	assert.Equal(t, int16(2), s.StatusCode)
+	assert.Equal(t, "Error", s.StatusCodeString)
+	assert.Equal(t, "timeout", s.StatusMessage)
+
+	assert.Equal(t, map[string]string{"http.method": "GET", "http.url": "http://backend:8080/api", "service.name": "frontend"}, s.StringTagMap)
+	assert.Equal(t, map[string]float64{"http.status_code": 504}, s.NumberTagMap)
+	assert.Equal(t, map[string]bool{"retried": true}, s.BoolTagMap)
+	assert.Equal(t, "504", s.TagMap["http.status_code"])
+	assert.Equal(t, map[string]string{"service.name": "frontend"}, s.ResourceTagsMap)
+
+	assert.Equal(t, "GET", s.ExternalHttpMethod)
+	assert.Equal(t, "backend", s.ExternalHttpUrl)
+	assert.Equal(t, "504", s.ResponseStatusCode)
+	assert.Equal(t, []string{`{"name":"exception","timeUnixNano":1700000000001000000,"attributeMap":{"exception.type":"TimeoutError"}}`}, s.Events)
+}
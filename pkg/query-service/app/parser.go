@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"strconv"
@@ -15,11 +16,15 @@ import (
 	"github.com/SigNoz/govaluate"
 	"github.com/gorilla/mux"
 	promModel "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
 	"go.uber.org/multierr"
 
 	"go.signoz.io/signoz/ee/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/app/logs/loki"
 	"go.signoz.io/signoz/pkg/query-service/app/metrics"
 	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	"go.signoz.io/signoz/pkg/query-service/app/services"
 	"go.signoz.io/signoz/pkg/query-service/auth"
 	"go.signoz.io/signoz/pkg/query-service/common"
 	baseconstants "go.signoz.io/signoz/pkg/query-service/constants"
@@ -115,6 +120,210 @@ func parseInstantQueryMetricsRequest(r *http.Request) (*model.InstantQueryMetric
 
 }
 
+// parsePromSeriesRequest parses the params of the labels, label values and series
+// requests of the Prometheus API, the range defaults to the last day
+func parsePromSeriesRequest(r *http.Request) (*model.PromSeriesParams, *model.ApiError) {
+	if err := r.ParseForm(); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+
+	params := &model.PromSeriesParams{End: time.Now()}
+	if end := r.FormValue("end"); end != "" {
+		t, err := parseMetricsTime(end)
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		}
+		params.End = t
+	}
+	params.Start = params.End.Add(-24 * time.Hour)
+	if start := r.FormValue("start"); start != "" {
+		t, err := parseMetricsTime(start)
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		}
+		params.Start = t
+	}
+	if params.End.Before(params.Start) {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: errors.New("end timestamp must not be before start time")}
+	}
+
+	for _, selector := range r.Form["match[]"] {
+		matchers, err := parser.ParseMetricSelector(selector)
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		}
+		params.Matchers = append(params.Matchers, matchers)
+	}
+
+	if limit := r.FormValue("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 0 {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid limit %q", limit)}
+		}
+		params.Limit = l
+	}
+	return params, nil
+}
+
+// isValidLabelName checks the name of a label of the label values request
+func isValidLabelName(name string) bool {
+	return name == labels.MetricName || promModel.LabelName(name).IsValid()
+}
+
+// parseLokiTime parses a time of the Loki API, a time is an RFC3339 date, a number of seconds
+// with a fractional part, or a number of nanoseconds, or seconds when up to 10 digits
+func parseLokiTime(s string, defaultTime time.Time) (time.Time, error) {
+	if s == "" {
+		return defaultTime, nil
+	}
+	if strings.Contains(s, ".") {
+		return parseMetricsTime(s)
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if len(s) <= 10 {
+			return time.Unix(n, 0), nil
+		}
+		return time.Unix(0, n), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q to a valid timestamp", s)
+}
+
+// parseLokiRange parses the range of the Loki API requests, the range defaults to the last hour
+func parseLokiRange(r *http.Request) (time.Time, time.Time, *model.ApiError) {
+	end, err := parseLokiTime(r.FormValue("end"), time.Now())
+	if err != nil {
+		return end, end, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+	start, err := parseLokiTime(r.FormValue("start"), end.Add(-time.Hour))
+	if err != nil {
+		return start, end, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+	if end.Before(start) {
+		return start, end, &model.ApiError{Typ: model.ErrorBadData, Err: errors.New("end timestamp must not be before start time")}
+	}
+	return start, end, nil
+}
+
+// parseLokiQueryRangeRequest parses a query_range request of the Loki API, the step defaults
+// to a 250th of the range as in Loki
+func parseLokiQueryRangeRequest(r *http.Request) (*loki.QueryRangeParams, *model.ApiError) {
+	query, err := loki.ParseQuery(r.FormValue("query"))
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+	start, end, apiErr := parseLokiRange(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	params := &loki.QueryRangeParams{Query: query, Start: start, End: end, Limit: 100, Direction: loki.DirectionBackward}
+
+	if limit := r.FormValue("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l <= 0 || l > loki.MaxLimit {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("the limit must be between 1 and %d", loki.MaxLimit)}
+		}
+		params.Limit = l
+	}
+	if direction := r.FormValue("direction"); direction != "" {
+		if direction != loki.DirectionBackward && direction != loki.DirectionForward {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("the direction must be %s or %s", loki.DirectionBackward, loki.DirectionForward)}
+		}
+		params.Direction = direction
+	}
+
+	params.Step = time.Duration(math.Max(math.Floor(end.Sub(start).Seconds()/250), 1)) * time.Second
+	if step := r.FormValue("step"); step != "" {
+		if params.Step, err = parseMetricsDuration(step); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		}
+		if params.Step <= 0 {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: errors.New("zero or negative query resolution step widths are not accepted. Try a positive integer")}
+		}
+	}
+	if query.Metric != nil && end.Sub(start)/params.Step > 11000 {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: errors.New("exceeded maximum resolution of 11,000 points per timeseries. Try decreasing the query resolution (?step=XX)")}
+	}
+	return params, nil
+}
+
+// parseJaegerFindTracesRequest parses a traces search of the Jaeger API, the times are in
+// microseconds and the range defaults to the lookback, or the last hour, before the end
+func parseJaegerFindTracesRequest(r *http.Request) (*model.FindTracesParams, *model.ApiError) {
+	params := &model.FindTracesParams{
+		Service:   r.FormValue("service"),
+		Operation: r.FormValue("operation"),
+		Tags:      map[string]string{},
+		End:       time.Now(),
+		Limit:     20,
+	}
+
+	if end := r.FormValue("end"); end != "" {
+		us, err := strconv.ParseInt(end, 10, 64)
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid end %q", end)}
+		}
+		params.End = time.UnixMicro(us)
+	}
+	lookback := time.Hour
+	if l := r.FormValue("lookback"); l != "" && l != "custom" {
+		d, err := promModel.ParseDuration(l)
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid lookback %q", l)}
+		}
+		lookback = time.Duration(d)
+	}
+	params.Start = params.End.Add(-lookback)
+	if start := r.FormValue("start"); start != "" {
+		us, err := strconv.ParseInt(start, 10, 64)
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid start %q", start)}
+		}
+		params.Start = time.UnixMicro(us)
+	}
+	if params.End.Before(params.Start) {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: errors.New("end timestamp must not be before start time")}
+	}
+
+	for _, field := range []struct {
+		name     string
+		duration *time.Duration
+	}{{"minDuration", &params.MinDuration}, {"maxDuration", &params.MaxDuration}} {
+		if value := r.FormValue(field.name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid %s %q", field.name, value)}
+			}
+			*field.duration = d
+		}
+	}
+
+	if limit := r.FormValue("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l <= 0 || l > maxJaegerTraces {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("the limit must be between 1 and %d", maxJaegerTraces)}
+		}
+		params.Limit = l
+	}
+
+	// the tags are a JSON object, or repeated key:value tag params for the older clients
+	if tags := r.FormValue("tags"); tags != "" {
+		if err := json.Unmarshal([]byte(tags), &params.Tags); err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid tags: %v", err)}
+		}
+	}
+	for _, tag := range r.Form["tag"] {
+		key, value, ok := strings.Cut(tag, ":")
+		if !ok {
+			return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid tag %q, expected key:value", tag)}
+		}
+		params.Tags[key] = value
+	}
+	return params, nil
+}
+
 func parseQueryRangeRequest(r *http.Request) (*model.QueryRangeParams, *model.ApiError) {
 
 	start, err := parseMetricsTime(r.FormValue("start"))
@@ -238,6 +447,50 @@ func parseGetServicesRequest(r *http.Request) (*model.GetServicesParams, error)
 	return postData, nil
 }
 
+func parseGetDependencyGraphRequest(r *http.Request) (*model.GetDependencyGraphParams, error) {
+
+	var postData *model.GetDependencyGraphParams
+	err := json.NewDecoder(r.Body).Decode(&postData)
+	if err != nil {
+		return nil, err
+	}
+
+	postData.Start, err = parseTimeStr(postData.StartTime, "start")
+	if err != nil {
+		return nil, err
+	}
+	postData.End, err = parseTimeMinusBufferStr(postData.EndTime, "end")
+	if err != nil {
+		return nil, err
+	}
+	postData.Period = int(postData.End.Unix() - postData.Start.Unix())
+
+	if postData.Depth < 0 || postData.Depth > services.MaxDepth {
+		return nil, fmt.Errorf("depth must be between 0 and %d", services.MaxDepth)
+	}
+	if postData.Depth > 0 && postData.Service == "" {
+		return nil, fmt.Errorf("service is required with depth")
+	}
+	if postData.Service != "" && postData.Depth == 0 {
+		postData.Depth = 1
+	}
+
+	if postData.CompareStartTime != "" || postData.CompareEndTime != "" {
+		postData.CompareStart, err = parseTimeStr(postData.CompareStartTime, "compareStart")
+		if err != nil {
+			return nil, err
+		}
+		postData.CompareEnd, err = parseTimeMinusBufferStr(postData.CompareEndTime, "compareEnd")
+		if err != nil {
+			return nil, err
+		}
+		if !postData.CompareEnd.After(*postData.CompareStart) {
+			return nil, fmt.Errorf("compareEnd must be after compareStart")
+		}
+	}
+	return postData, nil
+}
+
 func ParseSearchTracesParams(r *http.Request) (*model.SearchTracesParams, error) {
 	vars := mux.Vars(r)
 	params := &model.SearchTracesParams{}
@@ -277,6 +530,13 @@ func ParseSearchTracesParams(r *http.Request) (*model.SearchTracesParams, error)
 	params.LevelDown = levelDownInt
 	params.SpansRenderLimit = SpanRenderLimitInt
 	params.MaxSpansInTrace = MaxSpansInTraceInt
+
+	// the trace ids are not unique across the orgs
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	params.OrgScope = scope
 	return params, nil
 }
 
@@ -682,11 +942,35 @@ func parseTTLParams(r *http.Request) (*model.TTLParams, error) {
 		}
 	}
 
+	// The overrides are optional and sent in the body, the stored overrides are kept without a body.
+	var body struct {
+		Overrides *[]model.TTLOverride `json:"overrides"`
+	}
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to decode ttl overrides: %v", err)
+		}
+	}
+	if body.Overrides != nil {
+		if typeTTL == baseconstants.MetricsTTL && len(*body.Overrides) > 0 {
+			return nil, fmt.Errorf("ttl overrides are not supported for metrics")
+		}
+		for _, o := range *body.Overrides {
+			if len(o.Key) == 0 || len(o.Values) == 0 {
+				return nil, fmt.Errorf("key and values of a ttl override cannot be empty")
+			}
+			if o.DurationHrs <= 0 {
+				return nil, fmt.Errorf("not a valid ttl override duration %v", o.DurationHrs)
+			}
+		}
+	}
+
 	return &model.TTLParams{
 		Type:                  typeTTL,
 		DelDuration:           int64(durationParsed.Seconds()),
 		ColdStorageVolume:     coldStorage,
 		ToColdStorageDuration: int64(toColdParsed.Seconds()),
+		Overrides:             body.Overrides,
 	}, nil
 }
 
@@ -706,6 +990,58 @@ func parseGetTTL(r *http.Request) (*model.GetTTLParams, error) {
 	return &model.GetTTLParams{Type: typeTTL}, nil
 }
 
+// defaultCardinalityLimit is the number of metrics and labels returned by the cardinality analyzer
+const defaultCardinalityLimit = 20
+
+func parseMetricsCardinalityParams(r *http.Request) (*model.MetricsCardinalityParams, error) {
+	startTime, err := parseTime("start", r)
+	if err != nil {
+		return nil, err
+	}
+	endTime, err := parseTime("end", r)
+	if err != nil {
+		return nil, err
+	}
+	if !startTime.Before(*endTime) {
+		return nil, fmt.Errorf("start should be before end")
+	}
+
+	params := &model.MetricsCardinalityParams{Start: startTime, End: endTime, Limit: defaultCardinalityLimit}
+	if limitStr := r.URL.Query().Get("limit"); len(limitStr) > 0 {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("limit param should be a positive number, got %v", limitStr)
+		}
+		params.Limit = limit
+	}
+	if metricName := r.URL.Query().Get("metricName"); len(metricName) > 0 {
+		params.MetricNames = []string{metricName}
+	}
+	return params, nil
+}
+
+func parseStorageTierParams(r *http.Request) (*model.StorageTierParams, error) {
+	var params model.StorageTierParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return nil, err
+	}
+
+	if params.Signal != baseconstants.TraceTTL && params.Signal != baseconstants.MetricsTTL && params.Signal != baseconstants.LogsTTL {
+		return nil, fmt.Errorf("signal should be metrics|traces|logs, got %v", params.Signal)
+	}
+	if params.MoveAfterDays < 0 {
+		return nil, fmt.Errorf("moveAfterDays cannot be negative")
+	}
+	if params.MoveAfterDays > 0 && len(params.Volume) == 0 {
+		return nil, fmt.Errorf("volume cannot be empty when moveAfterDays is set")
+	}
+	if strings.ContainsAny(params.Volume, "'\\") {
+		return nil, fmt.Errorf("not a valid volume %v", params.Volume)
+	}
+
+	return &params, nil
+}
+
 func parseUserRequest(r *http.Request) (*model.User, error) {
 	var req model.User
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -920,6 +1256,15 @@ func validateQueryRangeParamsV3(qp *v3.QueryRangeParamsV3) error {
 	if len(errs) > 0 {
 		return multierr.Combine(errs...)
 	}
+
+	if len(qp.Comparisons) > v3.MaxComparisons {
+		return fmt.Errorf("at most %d comparisons are allowed", v3.MaxComparisons)
+	}
+	for _, offset := range qp.Comparisons {
+		if _, err := v3.ParseComparisonOffset(offset); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -949,6 +1294,15 @@ func validateExpressions(expressions []string, funcs map[string]govaluate.Expres
 	return errs
 }
 
+// autoStepInterval computes the step interval of a query from the time range and the panel width
+func autoStepInterval(queryRangeParams *v3.QueryRangeParamsV3, dataSource v3.DataSource) int64 {
+	step := common.AutoStepInterval(queryRangeParams.Start, queryRangeParams.End, queryRangeParams.PanelWidth)
+	if dataSource == v3.DataSourceMetrics && step < baseconstants.MinMetricsStepInterval {
+		step = baseconstants.MinMetricsStepInterval
+	}
+	return step
+}
+
 func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiError) {
 
 	var queryRangeParams *v3.QueryRangeParamsV3
@@ -966,6 +1320,11 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
 	}
 
+	// the step of the promql queries is computed the same way when omitted
+	if queryRangeParams.CompositeQuery.QueryType == v3.QueryTypePromQL && queryRangeParams.Step <= 0 {
+		queryRangeParams.Step = autoStepInterval(queryRangeParams, v3.DataSourceMetrics)
+	}
+
 	// prepare the variables for the corresponding query type
 	formattedVars := make(map[string]interface{})
 	for name, value := range queryRangeParams.Variables {
@@ -1018,8 +1377,11 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 				}
 			}
 
-			// If the step interval is less than the minimum allowed step interval, set it to the minimum allowed step interval
-			if minStep := common.MinAllowedStepInterval(queryRangeParams.Start, queryRangeParams.End); query.StepInterval < minStep {
+			// If the step interval is omitted, compute it from the time range and the panel width
+			// else if it is less than the minimum allowed step interval, set it to the minimum allowed step interval
+			if query.StepInterval <= 0 {
+				query.StepInterval = autoStepInterval(queryRangeParams, query.DataSource)
+			} else if minStep := common.MinAllowedStepInterval(queryRangeParams.Start, queryRangeParams.End); query.StepInterval < minStep {
 				query.StepInterval = minStep
 			}
 
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 )
 
@@ -1498,3 +1499,224 @@ func TestParseQueryRangeParamsStepIntervalAdjustment(t *testing.T) {
 		})
 	}
 }
+
+func TestParseQueryRangeParamsAutoStepInterval(t *testing.T) {
+	end := time.Now().UnixMilli()
+	reqCases := []struct {
+		desc       string
+		start      int64
+		panelWidth int64
+		dataSource v3.DataSource
+		expected   int64
+	}{
+		{
+			desc:       "15 minutes of logs",
+			start:      end - (15 * time.Minute).Milliseconds(),
+			dataSource: v3.DataSourceLogs,
+			expected:   5,
+		},
+		{
+			desc:       "15 minutes of metrics",
+			start:      end - (15 * time.Minute).Milliseconds(),
+			dataSource: v3.DataSourceMetrics,
+			expected:   60,
+		},
+		{
+			desc:       "1 day of metrics",
+			start:      end - (24 * time.Hour).Milliseconds(),
+			dataSource: v3.DataSourceMetrics,
+			expected:   300,
+		},
+		{
+			desc:       "1 day of metrics on a narrow panel",
+			start:      end - (24 * time.Hour).Milliseconds(),
+			panelWidth: 100,
+			dataSource: v3.DataSourceMetrics,
+			expected:   900,
+		},
+		{
+			desc:       "1 day of metrics on a wide panel",
+			start:      end - (24 * time.Hour).Milliseconds(),
+			panelWidth: 2000,
+			dataSource: v3.DataSourceMetrics,
+			expected:   300,
+		},
+		{
+			desc:       "90 days of traces",
+			start:      end - (90 * 24 * time.Hour).Milliseconds(),
+			dataSource: v3.DataSourceTraces,
+			expected:   43200,
+		},
+		{
+			desc:       "1 year of metrics",
+			start:      end - (365 * 24 * time.Hour).Milliseconds(),
+			dataSource: v3.DataSourceMetrics,
+			expected:   2 * 86400,
+		},
+	}
+
+	for _, tc := range reqCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			queryRangeParams := &v3.QueryRangeParamsV3{
+				Start:      tc.start,
+				End:        end,
+				PanelWidth: tc.panelWidth,
+				CompositeQuery: &v3.CompositeQuery{
+					PanelType: v3.PanelTypeGraph,
+					QueryType: v3.QueryTypeBuilder,
+					BuilderQueries: map[string]*v3.BuilderQuery{
+						"A": {
+							QueryName:          "A",
+							DataSource:         tc.dataSource,
+							AggregateOperator:  v3.AggregateOperatorCount,
+							AggregateAttribute: v3.AttributeKey{Key: "signoz_calls_total"},
+							Expression:         "A",
+						},
+					},
+				},
+				Variables: map[string]interface{}{},
+			}
+
+			body := &bytes.Buffer{}
+			err := json.NewEncoder(body).Encode(queryRangeParams)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/api/v4/query_range", body)
+
+			p, apiErr := ParseQueryRangeParams(req)
+			if apiErr != nil && apiErr.Err != nil {
+				t.Fatalf("unexpected error %s", apiErr.Err)
+			}
+			step := p.CompositeQuery.BuilderQueries["A"].StepInterval
+			require.Equal(t, tc.expected, step)
+			require.LessOrEqual(t, (p.End-p.Start)/1000/step, int64(constants.MaxAllowedPointsInTimeSeries))
+		})
+	}
+}
+
+func TestParseQueryRangeParamsComparisons(t *testing.T) {
+	end := time.Now().UnixMilli()
+	reqCases := []struct {
+		desc        string
+		comparisons []string
+		expectErr   bool
+	}{
+		{desc: "day and week over week", comparisons: []string{"1d", "1w"}},
+		{desc: "invalid offset", comparisons: []string{"yesterday"}, expectErr: true},
+		{desc: "too many comparisons", comparisons: []string{"1d", "2d", "3d", "4d", "1w"}, expectErr: true},
+	}
+
+	for _, tc := range reqCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			queryRangeParams := &v3.QueryRangeParamsV3{
+				Start:       end - (time.Hour).Milliseconds(),
+				End:         end,
+				Comparisons: tc.comparisons,
+				CompositeQuery: &v3.CompositeQuery{
+					PanelType: v3.PanelTypeGraph,
+					QueryType: v3.QueryTypeBuilder,
+					BuilderQueries: map[string]*v3.BuilderQuery{
+						"A": {
+							QueryName:          "A",
+							DataSource:         v3.DataSourceMetrics,
+							AggregateOperator:  v3.AggregateOperatorSumRate,
+							AggregateAttribute: v3.AttributeKey{Key: "signoz_calls_total"},
+							Expression:         "A",
+						},
+					},
+				},
+				Variables: map[string]interface{}{},
+			}
+
+			body := &bytes.Buffer{}
+			err := json.NewEncoder(body).Encode(queryRangeParams)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/api/v4/query_range", body)
+
+			p, apiErr := ParseQueryRangeParams(req)
+			if tc.expectErr {
+				require.NotNil(t, apiErr)
+				return
+			}
+			require.Nil(t, apiErr)
+
+			comparisons, apiErr := comparisonParams(p)
+			require.Nil(t, apiErr)
+			require.Len(t, comparisons, len(tc.comparisons))
+			require.Equal(t, (24 * time.Hour).Milliseconds(), comparisons[0].shift)
+			require.Equal(t, p.Start-comparisons[0].shift, comparisons[0].params.Start)
+			require.Equal(t, p.End-comparisons[1].shift, comparisons[1].params.End)
+			require.Empty(t, comparisons[1].params.Comparisons)
+			// the queries of the comparisons are copies
+			require.Equal(t, p.CompositeQuery.BuilderQueries["A"].StepInterval, comparisons[0].params.CompositeQuery.BuilderQueries["A"].StepInterval)
+			require.NotSame(t, p.CompositeQuery.BuilderQueries["A"], comparisons[0].params.CompositeQuery.BuilderQueries["A"])
+		})
+	}
+}
+
+func TestParsePromSeriesRequest(t *testing.T) {
+	form := "start=1700000000&end=1700003600&limit=10&match[]=up&match[]=" +
+		"http_requests_total%7Bcode%3D~%225..%22%7D"
+	req := httptest.NewRequest(http.MethodPost, "/api/prom/api/v1/series", strings.NewReader(form))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	params, apiErr := parsePromSeriesRequest(req)
+	require.Nil(t, apiErr)
+	assert.Equal(t, int64(1700000000000), params.Start.UnixMilli())
+	assert.Equal(t, time.Hour, params.End.Sub(params.Start))
+	assert.Equal(t, 10, params.Limit)
+	require.Len(t, params.Matchers, 2)
+	assert.Equal(t, `code=~"5.."`, params.Matchers[1][0].String())
+
+	// the range defaults to the last day
+	params, apiErr = parsePromSeriesRequest(httptest.NewRequest(http.MethodGet, "/api/prom/api/v1/labels", nil))
+	require.Nil(t, apiErr)
+	assert.Equal(t, 24*time.Hour, params.End.Sub(params.Start))
+
+	_, apiErr = parsePromSeriesRequest(httptest.NewRequest(http.MethodGet, "/api/prom/api/v1/series?match[]=%7B", nil))
+	assert.NotNil(t, apiErr)
+}
+
+func TestParseLokiQueryRangeRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/loki/api/v1/query_range?query=%7Bjob%3D%22api%22%7D&start=1700000000&end=1700003600000000000&limit=50", nil)
+	params, apiErr := parseLokiQueryRangeRequest(req)
+	require.Nil(t, apiErr)
+	assert.Equal(t, time.Unix(1700000000, 0), params.Start)
+	assert.Equal(t, time.Unix(1700003600, 0), params.End)
+	assert.Equal(t, 50, params.Limit)
+	assert.Equal(t, "backward", params.Direction)
+	// the step defaults to a 250th of the range
+	assert.Equal(t, 14*time.Second, params.Step)
+
+	for _, query := range []string{
+		"query=%7Bjob%3D%22api%22%7D&limit=10000",
+		"query=%7Bjob%3D%22api%22%7D&direction=up",
+		"query=job",
+		"query=%7Bjob%3D%22api%22%7D&start=2&end=1",
+	} {
+		_, apiErr := parseLokiQueryRangeRequest(httptest.NewRequest(http.MethodGet, "/loki/api/v1/query_range?"+query, nil))
+		assert.NotNil(t, apiErr, query)
+	}
+}
+
+func TestParseJaegerFindTracesRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/jaeger/api/traces?service=frontend&start=1700000000000000&end=1700003600000000"+
+		"&minDuration=1.5ms&limit=5&tags=%7B%22http.status_code%22%3A%22500%22%7D&tag=env:prod", nil)
+	params, apiErr := parseJaegerFindTracesRequest(req)
+	require.Nil(t, apiErr)
+	assert.Equal(t, "frontend", params.Service)
+	assert.Equal(t, time.Unix(1700000000, 0), params.Start)
+	assert.Equal(t, time.Unix(1700003600, 0), params.End)
+	assert.Equal(t, 1500*time.Microsecond, params.MinDuration)
+	assert.Equal(t, 5, params.Limit)
+	assert.Equal(t, map[string]string{"http.status_code": "500", "env": "prod"}, params.Tags)
+
+	params, apiErr = parseJaegerFindTracesRequest(httptest.NewRequest(http.MethodGet, "/api/jaeger/api/traces?lookback=2d", nil))
+	require.Nil(t, apiErr)
+	assert.Equal(t, 48*time.Hour, params.End.Sub(params.Start))
+	assert.Equal(t, 20, params.Limit)
+
+	for _, query := range []string{"limit=0", "tags=x", "tag=env", "maxDuration=1", "start=2&end=1"} {
+		_, apiErr := parseJaegerFindTracesRequest(httptest.NewRequest(http.MethodGet, "/api/jaeger/api/traces?"+query, nil))
+		assert.NotNil(t, apiErr, query)
+	}
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/app/profiles"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// ingestProfile receives a pprof profile of a service, e.g. from the Go or the Pyroscope
+// profilers. The service, its version and environment and the labels (key=value, repeated)
+// are query params. The profilers authenticate with an ingestion key allowed to send profiles
+func (aH *APIHandler) ingestProfile(w http.ResponseWriter, r *http.Request) {
+	if aH.ProfileManager == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnavailable, Err: fmt.Errorf("profiles ingestion is not available")}, nil)
+		return
+	}
+	query := r.URL.Query()
+	labels, err := profiles.ParseLabels(query["label"])
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	meta := &profiles.Metadata{
+		ServiceName: query.Get("service"),
+		Version:     query.Get("version"),
+		Environment: query.Get("environment"),
+		Labels:      labels,
+	}
+	if err := meta.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, profiles.MaxRequestSize))
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	key, apiErr := authorizeIngestion(r, model.IngestionSignalProfiles, int64(len(body)))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	meta.Labels[constants.OrgIdAttribute] = key.OrgId
+
+	profile, err := profiles.Decode(body)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	id, err := aH.ProfileManager.Write(r.Context(), meta, profile, time.Now())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, map[string]string{"id": id})
+}
+
+// listProfiles lists the profiles of a service, the range is in milliseconds
+func (aH *APIHandler) listProfiles(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := profiles.Filter{ServiceName: query.Get("serviceName")}
+	for param, value := range map[string]*time.Time{"start": &filter.Start, "end": &filter.End} {
+		if s := query.Get(param); s != "" {
+			ms, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				RespondError(w, model.BadRequest(fmt.Errorf("%s param is not in correct timestamp format", param)), nil)
+				return
+			}
+			*value = time.UnixMilli(ms)
+		}
+	}
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil {
+			RespondError(w, model.BadRequest(fmt.Errorf("invalid limit %q", s)), nil)
+			return
+		}
+		filter.Limit = limit
+	}
+	if err := filter.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	filter.OrgScope = scope
+
+	list, err := aH.ProfileManager.Profiles(r.Context(), &filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, list)
+}
+
+// getFlamegraph merges the samples of a service in the range into a flamegraph, the samples
+// can be restricted to a version, a profile or labels such as the span_id of a trace
+func (aH *APIHandler) getFlamegraph(w http.ResponseWriter, r *http.Request) {
+	var req profiles.FlamegraphQuery
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	req.OrgScope = scope
+
+	flamegraph, err := aH.ProfileManager.Flamegraph(r.Context(), &req)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, flamegraph)
+}
+
+// diffFlamegraphs compares the flamegraphs of a baseline and a comparison, e.g. before and
+// after a deployment
+func (aH *APIHandler) diffFlamegraphs(w http.ResponseWriter, r *http.Request) {
+	var req profiles.DiffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	req.Baseline.OrgScope, req.Comparison.OrgScope = scope, scope
+
+	diff, err := aH.ProfileManager.Diff(r.Context(), &req)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, diff)
+}
@@ -0,0 +1,139 @@
+package profiles
+
+import (
+	"sort"
+)
+
+// Stack is a stack of the stored samples with the sum of their values
+type Stack struct {
+	Stack []string `ch:"stack"`
+	Value int64    `ch:"value"`
+}
+
+// Node is a function of the flamegraph, Total is the value of the function and its callees
+// and Self the value of the function alone
+type Node struct {
+	Name     string  `json:"name"`
+	Total    int64   `json:"total"`
+	Self     int64   `json:"self"`
+	Children []*Node `json:"children,omitempty"`
+
+	children map[string]*Node
+}
+
+func newNode(name string) *Node {
+	return &Node{Name: name, children: map[string]*Node{}}
+}
+
+func (n *Node) child(name string) *Node {
+	c, ok := n.children[name]
+	if !ok {
+		c = newNode(name)
+		n.children[name] = c
+		n.Children = append(n.Children, c)
+	}
+	return c
+}
+
+// Flamegraph merges the stacks into a tree under a root named total. The functions with less
+// than minFraction of the total are dropped, their value stays in the total of their caller
+func Flamegraph(stacks []Stack, minFraction float64) *Node {
+	root := newNode("total")
+	for _, s := range stacks {
+		if s.Value <= 0 {
+			continue
+		}
+		node := root
+		node.Total += s.Value
+		for _, name := range s.Stack {
+			node = node.child(name)
+			node.Total += s.Value
+		}
+		node.Self += s.Value
+	}
+	finish(root, int64(minFraction*float64(root.Total)))
+	return root
+}
+
+// finish prunes the nodes below the minimum and sorts the children, the largest first
+func finish(n *Node, minimum int64) {
+	kept := n.Children[:0]
+	for _, c := range n.Children {
+		if c.Total > 0 && c.Total >= minimum {
+			kept = append(kept, c)
+		}
+	}
+	n.Children = kept
+	n.children = nil
+	sort.Slice(n.Children, func(i, j int) bool {
+		if n.Children[i].Total != n.Children[j].Total {
+			return n.Children[i].Total > n.Children[j].Total
+		}
+		return n.Children[i].Name < n.Children[j].Name
+	})
+	for _, c := range n.Children {
+		finish(c, minimum)
+	}
+}
+
+// DiffNode is a function of the diff of two flamegraphs with its values in both
+type DiffNode struct {
+	Name            string      `json:"name"`
+	BaselineTotal   int64       `json:"baselineTotal"`
+	BaselineSelf    int64       `json:"baselineSelf"`
+	ComparisonTotal int64       `json:"comparisonTotal"`
+	ComparisonSelf  int64       `json:"comparisonSelf"`
+	Children        []*DiffNode `json:"children,omitempty"`
+}
+
+// Diff merges the baseline and the comparison flamegraphs, the functions missing from one of
+// them have zero values in it
+func Diff(baseline, comparison *Node) *DiffNode {
+	d := &DiffNode{Name: "total"}
+	if baseline != nil {
+		d.Name = baseline.Name
+	} else if comparison != nil {
+		d.Name = comparison.Name
+	}
+	seen := map[string]struct{}{}
+	var order []string
+	add := func(n *Node, isBaseline bool) {
+		if n == nil {
+			return
+		}
+		if isBaseline {
+			d.BaselineTotal, d.BaselineSelf = n.Total, n.Self
+		} else {
+			d.ComparisonTotal, d.ComparisonSelf = n.Total, n.Self
+		}
+		for _, c := range n.Children {
+			if _, ok := seen[c.Name]; !ok {
+				seen[c.Name] = struct{}{}
+				order = append(order, c.Name)
+			}
+		}
+	}
+	add(baseline, true)
+	add(comparison, false)
+
+	for _, name := range order {
+		d.Children = append(d.Children, Diff(findChild(baseline, name), findChild(comparison, name)))
+	}
+	sort.SliceStable(d.Children, func(i, j int) bool {
+		ci, cj := d.Children[i], d.Children[j]
+		return max(ci.BaselineTotal, ci.ComparisonTotal) > max(cj.BaselineTotal, cj.ComparisonTotal)
+	})
+	return d
+}
+
+func findChild(n *Node, name string) *Node {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
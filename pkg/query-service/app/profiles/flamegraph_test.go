@@ -0,0 +1,51 @@
+package profiles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlamegraph(t *testing.T) {
+	root := Flamegraph([]Stack{
+		{Stack: []string{"main", "handle", "parse"}, Value: 60},
+		{Stack: []string{"main", "handle"}, Value: 20},
+		{Stack: []string{"main", "gc"}, Value: 19},
+		{Stack: []string{"main", "log"}, Value: 1},
+	}, 0.05)
+
+	require.Equal(t, "total", root.Name)
+	require.Equal(t, int64(100), root.Total)
+	main := root.Children[0]
+	require.Equal(t, int64(100), main.Total)
+	// log is below 5% of the total
+	require.Len(t, main.Children, 2)
+	require.Equal(t, "handle", main.Children[0].Name)
+	require.Equal(t, int64(80), main.Children[0].Total)
+	require.Equal(t, int64(20), main.Children[0].Self)
+	require.Equal(t, "gc", main.Children[1].Name)
+}
+
+func TestDiff(t *testing.T) {
+	baseline := Flamegraph([]Stack{
+		{Stack: []string{"main", "handle"}, Value: 70},
+		{Stack: []string{"main", "gc"}, Value: 30},
+	}, 0)
+	comparison := Flamegraph([]Stack{
+		{Stack: []string{"main", "handle"}, Value: 40},
+		{Stack: []string{"main", "compress"}, Value: 80},
+	}, 0)
+
+	root := Diff(baseline, comparison)
+	require.Equal(t, int64(100), root.BaselineTotal)
+	require.Equal(t, int64(120), root.ComparisonTotal)
+	main := root.Children[0]
+	require.Len(t, main.Children, 3)
+	require.Equal(t, "compress", main.Children[0].Name)
+	require.Equal(t, int64(0), main.Children[0].BaselineTotal)
+	require.Equal(t, int64(80), main.Children[0].ComparisonTotal)
+	require.Equal(t, "handle", main.Children[1].Name)
+	require.Equal(t, int64(40), main.Children[1].ComparisonSelf)
+	require.Equal(t, "gc", main.Children[2].Name)
+	require.Equal(t, int64(0), main.Children[2].ComparisonTotal)
+}
@@ -0,0 +1,228 @@
+package profiles
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	profilesDB        = "signoz_profiles"
+	samplesLocalTable = "profile_samples"
+	samplesTable      = "distributed_profile_samples"
+
+	// maxStacks is the number of stacks, the largest first, merged into a flamegraph
+	maxStacks = 20000
+)
+
+type ManagerOptions struct {
+	Conn clickhouse.Conn
+}
+
+// Manager stores the samples of the pprof profiles in ClickHouse, one row per stack and
+// sample type of a profile, and merges them into flamegraphs
+type Manager struct {
+	conn clickhouse.Conn
+}
+
+func NewManager(opts ManagerOptions) *Manager {
+	return &Manager{conn: opts.Conn}
+}
+
+// row is a stack of a profile with the sum of the values of its samples
+type row struct {
+	sampleType int
+	stack      []string
+	labels     map[string]string
+	value      int64
+}
+
+// rows merges the samples with the same stack and labels, per sample type
+func rows(p *Profile, extraLabels map[string]string) []*row {
+	byKey := map[string]*row{}
+	var result []*row
+	for _, s := range p.Samples {
+		labels := make(map[string]string, len(s.Labels)+len(extraLabels))
+		for k, v := range s.Labels {
+			labels[k] = v
+		}
+		for k, v := range extraLabels {
+			labels[k] = v
+		}
+		keys := make([]string, 0, len(labels))
+		for k := range labels {
+			keys = append(keys, k+"="+labels[k])
+		}
+		sort.Strings(keys)
+		key := strings.Join(s.Stack, "\x00") + "\x01" + strings.Join(keys, "\x00")
+
+		for i, value := range s.Values {
+			if value == 0 {
+				continue
+			}
+			k := fmt.Sprintf("%d\x01%s", i, key)
+			r, ok := byKey[k]
+			if !ok {
+				r = &row{sampleType: i, stack: s.Stack, labels: labels}
+				byKey[k] = r
+				result = append(result, r)
+			}
+			r.value += value
+		}
+	}
+	return result
+}
+
+// Write stores the samples of the profile, the profile is timestamped when it was taken or
+// now when it has no time. It returns the id of the profile
+func (m *Manager) Write(ctx context.Context, meta *Metadata, p *Profile, now time.Time) (string, error) {
+	id := uuid.NewString()
+	timestamp := now
+	if p.TimeNanos > 0 {
+		timestamp = time.Unix(0, p.TimeNanos)
+	}
+
+	statement, err := m.conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (timestamp, profile_id, service_name, version, "+
+		"environment, sample_type, sample_unit, labels, stack, value)", profilesDB, samplesTable))
+	if err != nil {
+		return "", fmt.Errorf("couldn't prepare the profile samples batch: %w", err)
+	}
+	defer statement.Abort()
+	for _, r := range rows(p, meta.Labels) {
+		sampleType := p.SampleTypes[r.sampleType]
+		if err := statement.Append(timestamp, id, meta.ServiceName, meta.Version, meta.Environment,
+			sampleType.Type, sampleType.Unit, r.labels, r.stack, r.value); err != nil {
+			return "", fmt.Errorf("couldn't append a profile sample: %w", err)
+		}
+	}
+	if err := statement.Send(); err != nil {
+		return "", fmt.Errorf("couldn't write the profile samples: %w", err)
+	}
+	return id, nil
+}
+
+// Profiles lists the profiles of a service in the range, the latest first
+func (m *Manager) Profiles(ctx context.Context, f *Filter) ([]ProfileInfo, error) {
+	conditions := []string{"service_name = $1", "timestamp >= $2", "timestamp <= $3"}
+	args := []interface{}{f.ServiceName, f.Start, f.End}
+	if f.OrgScope != nil {
+		var org string
+		org, args = orgCondition(f.OrgScope, args)
+		conditions = append(conditions, org)
+	}
+	query := fmt.Sprintf(`SELECT profile_id, min(timestamp) AS timestamp, any(service_name) AS service_name,
+		any(version) AS version, any(environment) AS environment,
+		arraySort(groupUniqArray(toString(sample_type))) AS sample_types
+		FROM %s.%s WHERE %s
+		GROUP BY profile_id ORDER BY timestamp DESC LIMIT %d`, profilesDB, samplesTable, strings.Join(conditions, " AND "), f.Limit)
+
+	profiles := []ProfileInfo{}
+	if err := m.conn.Select(ctx, &profiles, query, args...); err != nil {
+		zap.L().Error("Error while listing the profiles", zap.Error(err))
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// orgCondition returns the condition keeping the samples of the orgs of the scope, with its
+// arguments appended to args. The samples sent before the orgs were added have no org label
+// and belong to the default org
+func orgCondition(scope *model.OrgScope, args []interface{}) (string, []interface{}) {
+	not := ""
+	if scope.Exclude {
+		not = "NOT "
+	}
+	args = append(args, scope.OrgIds, constants.OrgIdAttribute)
+	return fmt.Sprintf("%shas($%d, labels[$%d])", not, len(args)-1, len(args)), args
+}
+
+// stackRow is a stack of the flamegraph query with the unit of its values
+type stackRow struct {
+	Stack []string `ch:"stack"`
+	Value int64    `ch:"value"`
+	Unit  string   `ch:"unit"`
+}
+
+// stacks returns the stacks of the samples of the query with the unit of their values
+func (m *Manager) stacks(ctx context.Context, q *FlamegraphQuery) ([]Stack, string, error) {
+	conditions := []string{"service_name = $1", "sample_type = $2", "timestamp >= $3", "timestamp <= $4"}
+	args := []interface{}{q.ServiceName, q.SampleType, time.UnixMilli(q.Start), time.UnixMilli(q.End)}
+	condition := func(expr string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", expr, len(args)))
+	}
+	if q.Version != "" {
+		condition("version", q.Version)
+	}
+	if q.Environment != "" {
+		condition("environment", q.Environment)
+	}
+	if q.ProfileId != "" {
+		condition("profile_id", q.ProfileId)
+	}
+	keys := make([]string, 0, len(q.Labels))
+	for key := range q.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, key)
+		condition(fmt.Sprintf("labels[$%d]", len(args)), q.Labels[key])
+	}
+	if q.OrgScope != nil {
+		var org string
+		org, args = orgCondition(q.OrgScope, args)
+		conditions = append(conditions, org)
+	}
+
+	query := fmt.Sprintf(`SELECT stack, sum(value) AS value, any(toString(sample_unit)) AS unit
+		FROM %s.%s WHERE %s GROUP BY stack ORDER BY value DESC LIMIT %d`,
+		profilesDB, samplesTable, strings.Join(conditions, " AND "), maxStacks)
+
+	var result []stackRow
+	if err := m.conn.Select(ctx, &result, query, args...); err != nil {
+		zap.L().Error("Error while reading the profile stacks", zap.Error(err))
+		return nil, "", err
+	}
+	stacks := make([]Stack, 0, len(result))
+	unit := ""
+	for _, r := range result {
+		stacks = append(stacks, Stack{Stack: r.Stack, Value: r.Value})
+		unit = r.Unit
+	}
+	return stacks, unit, nil
+}
+
+// Flamegraph merges the samples of the query into a flamegraph
+func (m *Manager) Flamegraph(ctx context.Context, q *FlamegraphQuery) (*FlamegraphResponse, error) {
+	stacks, unit, err := m.stacks(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return &FlamegraphResponse{SampleType: q.SampleType, Unit: unit, Root: Flamegraph(stacks, q.MinFraction)}, nil
+}
+
+// Diff compares the flamegraphs of the baseline and the comparison
+func (m *Manager) Diff(ctx context.Context, req *DiffRequest) (*DiffResponse, error) {
+	baseline, err := m.Flamegraph(ctx, &req.Baseline)
+	if err != nil {
+		return nil, err
+	}
+	comparison, err := m.Flamegraph(ctx, &req.Comparison)
+	if err != nil {
+		return nil, err
+	}
+	unit := baseline.Unit
+	if unit == "" {
+		unit = comparison.Unit
+	}
+	return &DiffResponse{SampleType: req.Baseline.SampleType, Unit: unit, Root: Diff(baseline.Root, comparison.Root)}, nil
+}
@@ -0,0 +1,152 @@
+package profiles
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+
+	// DefaultMinFraction is the share of the total below which the functions are dropped from
+	// the flamegraphs
+	DefaultMinFraction = 0.001
+)
+
+// Metadata identifies the service a profile was taken from
+type Metadata struct {
+	ServiceName string
+	Version     string
+	Environment string
+	// Labels are added to the labels of the samples
+	Labels map[string]string
+}
+
+// ParseLabels parses the labels of the ingestion requests, in the key=value format
+func ParseLabels(values []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, value := range values {
+		key, v, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label %q, must be key=value", value)
+		}
+		labels[key] = v
+	}
+	return labels, nil
+}
+
+func (m *Metadata) Validate() error {
+	if m.ServiceName == "" {
+		return fmt.Errorf("service is required")
+	}
+	return nil
+}
+
+// ProfileInfo is a stored profile with the sample types it has
+type ProfileInfo struct {
+	Id          string    `json:"id" ch:"profile_id"`
+	Timestamp   time.Time `json:"timestamp" ch:"timestamp"`
+	ServiceName string    `json:"serviceName" ch:"service_name"`
+	Version     string    `json:"version" ch:"version"`
+	Environment string    `json:"environment" ch:"environment"`
+	SampleTypes []string  `json:"sampleTypes" ch:"sample_types"`
+}
+
+// Filter lists the profiles of a service, the latest first
+type Filter struct {
+	ServiceName string
+	Start       time.Time
+	End         time.Time
+	Limit       int
+	// OrgScope restricts the profiles to those sent with the ingestion keys of the orgs of the user
+	OrgScope *model.OrgScope
+}
+
+func (f *Filter) Validate() error {
+	if f.ServiceName == "" {
+		return fmt.Errorf("serviceName is required")
+	}
+	if f.Start.IsZero() || f.End.IsZero() || !f.Start.Before(f.End) {
+		return fmt.Errorf("start and end are required and start must be before end")
+	}
+	if f.Limit < 0 || f.Limit > MaxLimit {
+		return fmt.Errorf("limit must be between 0 and %d", MaxLimit)
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultLimit
+	}
+	return nil
+}
+
+// FlamegraphQuery selects the samples merged into a flamegraph, the range is in milliseconds
+type FlamegraphQuery struct {
+	ServiceName string `json:"serviceName"`
+	// SampleType is the type of the values, e.g. cpu or inuse_space
+	SampleType  string `json:"sampleType"`
+	Start       int64  `json:"start"`
+	End         int64  `json:"end"`
+	Version     string `json:"version,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	// Labels keeps the samples with the labels, e.g. the span_id of a trace
+	Labels map[string]string `json:"labels,omitempty"`
+	// ProfileId keeps the samples of a profile
+	ProfileId   string  `json:"profileId,omitempty"`
+	MinFraction float64 `json:"minFraction,omitempty"`
+	// OrgScope restricts the samples to those sent with the ingestion keys of the orgs of the user
+	OrgScope *model.OrgScope `json:"-"`
+}
+
+func (q *FlamegraphQuery) Validate() error {
+	if q.ServiceName == "" {
+		return fmt.Errorf("serviceName is required")
+	}
+	if q.SampleType == "" {
+		return fmt.Errorf("sampleType is required")
+	}
+	if q.Start <= 0 || q.End <= 0 || q.Start >= q.End {
+		return fmt.Errorf("start and end are required and start must be before end")
+	}
+	if q.MinFraction < 0 || q.MinFraction >= 1 {
+		return fmt.Errorf("minFraction must be between 0 and 1")
+	}
+	if q.MinFraction == 0 {
+		q.MinFraction = DefaultMinFraction
+	}
+	return nil
+}
+
+type FlamegraphResponse struct {
+	SampleType string `json:"sampleType"`
+	Unit       string `json:"unit"`
+	Root       *Node  `json:"root"`
+}
+
+// DiffRequest compares the flamegraphs of two queries, e.g. two versions or two ranges of a
+// service
+type DiffRequest struct {
+	Baseline   FlamegraphQuery `json:"baseline"`
+	Comparison FlamegraphQuery `json:"comparison"`
+}
+
+func (r *DiffRequest) Validate() error {
+	if err := r.Baseline.Validate(); err != nil {
+		return fmt.Errorf("invalid baseline: %w", err)
+	}
+	if err := r.Comparison.Validate(); err != nil {
+		return fmt.Errorf("invalid comparison: %w", err)
+	}
+	if r.Baseline.SampleType != r.Comparison.SampleType {
+		return fmt.Errorf("the baseline and the comparison must have the same sampleType")
+	}
+	return nil
+}
+
+type DiffResponse struct {
+	SampleType string    `json:"sampleType"`
+	Unit       string    `json:"unit"`
+	Root       *DiffNode `json:"root"`
+}
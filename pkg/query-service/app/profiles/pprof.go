@@ -0,0 +1,374 @@
+package profiles
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MaxRequestSize is the largest profile accepted, before decompression
+const MaxRequestSize = 16 << 20
+
+// ValueType is a type of the values of the samples, e.g. cpu in nanoseconds
+type ValueType struct {
+	Type string
+	Unit string
+}
+
+// Sample is a stack of a profile with its values, one per sample type
+type Sample struct {
+	// Stack are the functions of the stack, the root first
+	Stack  []string
+	Values []int64
+	// Labels are the string labels of the sample, e.g. the span_id set by the OpenTelemetry
+	// profilers
+	Labels map[string]string
+}
+
+// Profile is a decoded pprof profile
+type Profile struct {
+	SampleTypes   []ValueType
+	Samples       []*Sample
+	TimeNanos     int64
+	DurationNanos int64
+}
+
+// Fields of the pprof messages, see profile.proto of github.com/google/pprof
+const (
+	profileSampleType    = 1
+	profileSample        = 2
+	profileLocation      = 4
+	profileFunction      = 5
+	profileStringTable   = 6
+	profileTimeNanos     = 9
+	profileDurationNanos = 10
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationId = 1
+	sampleValue      = 2
+	sampleLabel      = 3
+
+	labelKey = 1
+	labelStr = 2
+
+	locationId      = 1
+	locationAddress = 3
+	locationLine    = 4
+
+	lineFunctionId = 1
+
+	functionId   = 1
+	functionName = 2
+)
+
+// rawSample is a sample before the strings and the locations are resolved, as the string
+// table and the locations can come after the samples
+type rawSample struct {
+	locationIds []uint64
+	values      []int64
+	labels      [][2]int64
+}
+
+type rawLocation struct {
+	address     uint64
+	functionIds []uint64
+}
+
+type decoder struct {
+	sampleTypes [][2]int64
+	samples     []rawSample
+	locations   map[uint64]rawLocation
+	functions   map[uint64]int64
+	strings     []string
+	timeNanos   int64
+	duration    int64
+}
+
+// Decode decodes a pprof profile, gzip compressed or not as the profilers send both
+func Decode(body []byte) (*Profile, error) {
+	if len(body) > 2 && body[0] == 0x1f && body[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress profile: %v", err)
+		}
+		if body, err = io.ReadAll(io.LimitReader(reader, 8*MaxRequestSize)); err != nil {
+			return nil, fmt.Errorf("failed to decompress profile: %v", err)
+		}
+	}
+
+	d := &decoder{locations: map[uint64]rawLocation{}, functions: map[uint64]int64{}}
+	if err := d.profile(body); err != nil {
+		return nil, fmt.Errorf("failed to decode profile: %v", err)
+	}
+	return d.resolve()
+}
+
+// fields calls fn with the fields of the message, fn consumes the value of the field and
+// returns its length, or -1 to skip it
+func fields(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) int) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		n = fn(num, typ, b)
+		if n < 0 {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// bytesField returns the value of a length delimited field
+func bytesField(b []byte, fn func(v []byte) error) (int, error) {
+	v, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return n, nil
+	}
+	return n, fn(v)
+}
+
+// varints consumes a repeated varint field, packed or not
+func varints(typ protowire.Type, b []byte, fn func(v uint64)) int {
+	if typ == protowire.VarintType {
+		v, n := protowire.ConsumeVarint(b)
+		if n >= 0 {
+			fn(v)
+		}
+		return n
+	}
+	packed, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return n
+	}
+	for len(packed) > 0 {
+		v, m := protowire.ConsumeVarint(packed)
+		if m < 0 {
+			return m
+		}
+		fn(v)
+		packed = packed[m:]
+	}
+	return n
+}
+
+func varint(typ protowire.Type, b []byte, v *int64) int {
+	if typ != protowire.VarintType {
+		return -1
+	}
+	x, n := protowire.ConsumeVarint(b)
+	*v = int64(x)
+	return n
+}
+
+func (d *decoder) profile(b []byte) error {
+	var err error
+	parseErr := fields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		if err != nil {
+			return -1
+		}
+		var n int
+		switch {
+		case num == profileSampleType && typ == protowire.BytesType:
+			n, err = bytesField(b, d.sampleType)
+		case num == profileSample && typ == protowire.BytesType:
+			n, err = bytesField(b, d.sample)
+		case num == profileLocation && typ == protowire.BytesType:
+			n, err = bytesField(b, d.location)
+		case num == profileFunction && typ == protowire.BytesType:
+			n, err = bytesField(b, d.function)
+		case num == profileStringTable && typ == protowire.BytesType:
+			n, err = bytesField(b, func(v []byte) error {
+				d.strings = append(d.strings, string(v))
+				return nil
+			})
+		case num == profileTimeNanos:
+			n = varint(typ, b, &d.timeNanos)
+		case num == profileDurationNanos:
+			n = varint(typ, b, &d.duration)
+		default:
+			return -1
+		}
+		return n
+	})
+	if err != nil {
+		return err
+	}
+	return parseErr
+}
+
+func (d *decoder) sampleType(b []byte) error {
+	var vt [2]int64
+	err := fields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case valueTypeType:
+			return varint(typ, b, &vt[0])
+		case valueTypeUnit:
+			return varint(typ, b, &vt[1])
+		}
+		return -1
+	})
+	d.sampleTypes = append(d.sampleTypes, vt)
+	return err
+}
+
+func (d *decoder) sample(b []byte) error {
+	var s rawSample
+	err := fields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case sampleLocationId:
+			return varints(typ, b, func(v uint64) { s.locationIds = append(s.locationIds, v) })
+		case sampleValue:
+			return varints(typ, b, func(v uint64) { s.values = append(s.values, int64(v)) })
+		case sampleLabel:
+			if typ != protowire.BytesType {
+				return -1
+			}
+			n, err := bytesField(b, func(v []byte) error {
+				var label [2]int64
+				err := fields(v, func(num protowire.Number, typ protowire.Type, b []byte) int {
+					switch num {
+					case labelKey:
+						return varint(typ, b, &label[0])
+					case labelStr:
+						return varint(typ, b, &label[1])
+					}
+					return -1
+				})
+				s.labels = append(s.labels, label)
+				return err
+			})
+			if err != nil {
+				return -1
+			}
+			return n
+		}
+		return -1
+	})
+	d.samples = append(d.samples, s)
+	return err
+}
+
+func (d *decoder) location(b []byte) error {
+	var id uint64
+	var loc rawLocation
+	err := fields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case locationId:
+			return varints(typ, b, func(v uint64) { id = v })
+		case locationAddress:
+			return varints(typ, b, func(v uint64) { loc.address = v })
+		case locationLine:
+			if typ != protowire.BytesType {
+				return -1
+			}
+			n, _ := bytesField(b, func(v []byte) error {
+				return fields(v, func(num protowire.Number, typ protowire.Type, b []byte) int {
+					if num != lineFunctionId {
+						return -1
+					}
+					return varints(typ, b, func(v uint64) { loc.functionIds = append(loc.functionIds, v) })
+				})
+			})
+			return n
+		}
+		return -1
+	})
+	d.locations[id] = loc
+	return err
+}
+
+func (d *decoder) function(b []byte) error {
+	var id uint64
+	var name int64
+	err := fields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case functionId:
+			return varints(typ, b, func(v uint64) { id = v })
+		case functionName:
+			return varint(typ, b, &name)
+		}
+		return -1
+	})
+	d.functions[id] = name
+	return err
+}
+
+func (d *decoder) str(i int64) (string, error) {
+	if i < 0 || i >= int64(len(d.strings)) {
+		return "", fmt.Errorf("invalid string index %d", i)
+	}
+	return d.strings[i], nil
+}
+
+// resolve resolves the strings and the stacks of the samples
+func (d *decoder) resolve() (*Profile, error) {
+	p := &Profile{TimeNanos: d.timeNanos, DurationNanos: d.duration}
+	for _, vt := range d.sampleTypes {
+		typ, err := d.str(vt[0])
+		if err != nil {
+			return nil, err
+		}
+		unit, err := d.str(vt[1])
+		if err != nil {
+			return nil, err
+		}
+		p.SampleTypes = append(p.SampleTypes, ValueType{Type: typ, Unit: unit})
+	}
+	if len(p.SampleTypes) == 0 {
+		return nil, fmt.Errorf("the profile has no sample type")
+	}
+
+	for _, raw := range d.samples {
+		if len(raw.values) != len(p.SampleTypes) {
+			return nil, fmt.Errorf("a sample has %d values for %d sample types", len(raw.values), len(p.SampleTypes))
+		}
+		s := &Sample{Values: raw.values, Labels: map[string]string{}}
+		// the first location is the leaf, and the inlined functions of a location come
+		// before their caller
+		for i := len(raw.locationIds) - 1; i >= 0; i-- {
+			loc, ok := d.locations[raw.locationIds[i]]
+			if !ok {
+				return nil, fmt.Errorf("unknown location %d", raw.locationIds[i])
+			}
+			if len(loc.functionIds) == 0 {
+				s.Stack = append(s.Stack, fmt.Sprintf("0x%x", loc.address))
+				continue
+			}
+			for j := len(loc.functionIds) - 1; j >= 0; j-- {
+				name, err := d.str(d.functions[loc.functionIds[j]])
+				if err != nil {
+					return nil, err
+				}
+				s.Stack = append(s.Stack, name)
+			}
+		}
+		for _, label := range raw.labels {
+			// the numeric labels are not kept
+			if label[1] == 0 {
+				continue
+			}
+			key, err := d.str(label[0])
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.str(label[1])
+			if err != nil {
+				return nil, err
+			}
+			s.Labels[key] = value
+		}
+		p.Samples = append(p.Samples, s)
+	}
+	return p, nil
+}
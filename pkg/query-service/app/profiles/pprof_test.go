@@ -0,0 +1,104 @@
+package profiles
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// message encodes the fields of a message, the values are varints, packed varints, strings
+// or nested messages
+func message(fields ...interface{}) []byte {
+	var b []byte
+	for i := 0; i < len(fields); i += 2 {
+		num := protowire.Number(fields[i].(int))
+		switch v := fields[i+1].(type) {
+		case int:
+			b = protowire.AppendTag(b, num, protowire.VarintType)
+			b = protowire.AppendVarint(b, uint64(v))
+		case []int:
+			var packed []byte
+			for _, x := range v {
+				packed = protowire.AppendVarint(packed, uint64(x))
+			}
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			b = protowire.AppendBytes(b, packed)
+		case string:
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			b = protowire.AppendString(b, v)
+		case []byte:
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			b = protowire.AppendBytes(b, v)
+		}
+	}
+	return b
+}
+
+// testProfile is a CPU profile of main calling handle, which calls parse inlined in decode,
+// and of main alone. The string table comes last as it can
+func testProfile() []byte {
+	strings := []string{"", "samples", "count", "cpu", "nanoseconds", "main", "handle", "decode", "parse", "span_id", "abc"}
+	fields := []interface{}{
+		profileSampleType, message(valueTypeType, 1, valueTypeUnit, 2),
+		profileSampleType, message(valueTypeType, 3, valueTypeUnit, 4),
+		profileSample, message(sampleLocationId, []int{3, 2, 1}, sampleValue, []int{2, 20}, sampleLabel, message(labelKey, 9, labelStr, 10)),
+		profileSample, message(sampleLocationId, []int{3, 2, 1}, sampleValue, []int{1, 10}, sampleLabel, message(labelKey, 9, labelStr, 10)),
+		profileSample, message(sampleLocationId, []int{1}, sampleValue, []int{1, 0}),
+		profileLocation, message(locationId, 1, locationLine, message(lineFunctionId, 1)),
+		profileLocation, message(locationId, 2, locationLine, message(lineFunctionId, 2)),
+		profileLocation, message(locationId, 3, locationLine, message(lineFunctionId, 4), locationLine, message(lineFunctionId, 3)),
+		profileLocation, message(locationId, 4, locationAddress, 0xbeef),
+		profileFunction, message(functionId, 1, functionName, 5),
+		profileFunction, message(functionId, 2, functionName, 6),
+		profileFunction, message(functionId, 3, functionName, 7),
+		profileFunction, message(functionId, 4, functionName, 8),
+		profileTimeNanos, 1700000000000000000,
+	}
+	for _, s := range strings {
+		fields = append(fields, profileStringTable, s)
+	}
+	return message(fields...)
+}
+
+func TestDecode(t *testing.T) {
+	var gzipped bytes.Buffer
+	writer := gzip.NewWriter(&gzipped)
+	_, err := writer.Write(testProfile())
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	for _, body := range [][]byte{testProfile(), gzipped.Bytes()} {
+		p, err := Decode(body)
+		require.NoError(t, err)
+		require.Equal(t, []ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}}, p.SampleTypes)
+		require.Equal(t, int64(1700000000000000000), p.TimeNanos)
+		require.Len(t, p.Samples, 3)
+		require.Equal(t, []string{"main", "handle", "decode", "parse"}, p.Samples[0].Stack)
+		require.Equal(t, []int64{2, 20}, p.Samples[0].Values)
+		require.Equal(t, map[string]string{"span_id": "abc"}, p.Samples[0].Labels)
+		require.Equal(t, []string{"main"}, p.Samples[2].Stack)
+	}
+
+	_, err = Decode([]byte("not a profile"))
+	require.Error(t, err)
+	_, err = Decode(message(profileSampleType, message(valueTypeType, 1, valueTypeUnit, 2), profileStringTable, ""))
+	require.Error(t, err)
+}
+
+func TestRows(t *testing.T) {
+	p, err := Decode(testProfile())
+	require.NoError(t, err)
+
+	result := rows(p, map[string]string{"region": "eu"})
+	// the two samples of the same stack are merged and the zero values are skipped
+	require.Len(t, result, 3)
+	require.Equal(t, 0, result[0].sampleType)
+	require.Equal(t, int64(3), result[0].value)
+	require.Equal(t, map[string]string{"span_id": "abc", "region": "eu"}, result[0].labels)
+	require.Equal(t, 1, result[1].sampleType)
+	require.Equal(t, int64(30), result[1].value)
+	require.Equal(t, []string{"main"}, result[2].stack)
+}
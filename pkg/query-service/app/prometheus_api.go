@@ -0,0 +1,143 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/version"
+)
+
+// promAPIPrefix is the prefix of the Prometheus HTTP API, the URL of a Prometheus datasource
+// in Grafana is the SigNoz URL followed by the prefix
+const promAPIPrefix = "/api/prom"
+
+// promBuildInfo is the build info of the Prometheus API, Grafana reads it to detect the
+// features of the datasource
+type promBuildInfo struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	Branch    string `json:"branch"`
+	BuildUser string `json:"buildUser"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+// registerPromAPIRoutes registers the Prometheus compatible read API backed by the metrics
+// store, the Prometheus API clients (e.g. Grafana) can use SigNoz as a Prometheus datasource
+func (aH *APIHandler) registerPromAPIRoutes(router *mux.Router, am *AuthMiddleware) {
+	subRouter := router.PathPrefix(promAPIPrefix + "/api/v1").Subrouter()
+	methods := []string{http.MethodGet, http.MethodPost}
+
+	subRouter.HandleFunc("/query", am.ViewAccess(aH.queryMetrics)).Methods(methods...)
+	subRouter.HandleFunc("/query_range", am.ViewAccess(aH.queryRangeMetrics)).Methods(methods...)
+	subRouter.HandleFunc("/labels", am.ViewAccess(aH.getPromLabelNames)).Methods(methods...)
+	subRouter.HandleFunc("/label/{name}/values", am.ViewAccess(aH.getPromLabelValues)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/series", am.ViewAccess(aH.getPromSeries)).Methods(methods...)
+	subRouter.HandleFunc("/metadata", am.ViewAccess(aH.getPromMetricMetadata)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/status/buildinfo", am.ViewAccess(aH.getPromBuildInfo)).Methods(http.MethodGet)
+}
+
+func (aH *APIHandler) getPromLabelNames(w http.ResponseWriter, r *http.Request) {
+	params, apiErr := parsePromSeriesRequest(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if params.Matchers, apiErr = scopePromMatchersToOrg(r.Context(), params.Matchers); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	names, apiErr := aH.reader.GetPromLabelNames(r.Context(), params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, names)
+}
+
+func (aH *APIHandler) getPromLabelValues(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if !isValidLabelName(name) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid label name: %q", name)}, nil)
+		return
+	}
+	params, apiErr := parsePromSeriesRequest(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if params.Matchers, apiErr = scopePromMatchersToOrg(r.Context(), params.Matchers); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	values, apiErr := aH.reader.GetPromLabelValues(r.Context(), name, params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, values)
+}
+
+func (aH *APIHandler) getPromSeries(w http.ResponseWriter, r *http.Request) {
+	params, apiErr := parsePromSeriesRequest(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if len(params.Matchers) == 0 {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("no match[] parameter provided")}, nil)
+		return
+	}
+	if params.Matchers, apiErr = scopePromMatchersToOrg(r.Context(), params.Matchers); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	series, apiErr := aH.reader.GetPromSeries(r.Context(), params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, series)
+}
+
+func (aH *APIHandler) getPromMetricMetadata(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if l := r.FormValue("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid limit %q", l)}, nil)
+			return
+		}
+	}
+	// the metadata of the metrics is read from their series, scoped as the series API
+	matcherSets, apiErr := scopePromMatchersToOrg(r.Context(), nil)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	var matchers []*labels.Matcher
+	if len(matcherSets) > 0 {
+		matchers = matcherSets[0]
+	}
+	metadata, apiErr := aH.reader.GetPromMetricMetadata(r.Context(), r.FormValue("metric"), matchers, limit)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, metadata)
+}
+
+func (aH *APIHandler) getPromBuildInfo(w http.ResponseWriter, r *http.Request) {
+	aH.Respond(w, promBuildInfo{
+		// the clients read the features from the version, SigNoz serves the API of Prometheus 2.x
+		Version:   "2.45.0",
+		Revision:  version.GetVersion(),
+		GoVersion: runtime.Version(),
+	})
+}
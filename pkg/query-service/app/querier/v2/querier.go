@@ -510,6 +510,9 @@ func (q *querier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3,
 				results, errQueriesByName, err = q.runBuilderListQueries(ctx, params, keys)
 			} else {
 				results, errQueriesByName, err = q.runBuilderQueries(ctx, params, keys)
+				if err == nil && params.Exemplars {
+					q.addExemplars(ctx, params, results)
+				}
 			}
 			// in builder query, the only errors we expose are the ones that exceed the resource limits
 			// everything else is internal error as they are not actionable by the user
@@ -539,6 +542,53 @@ func (q *querier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3,
 	return results, errQueriesByName, err
 }
 
+// addExemplars attaches the exemplars of the metrics builder queries to the series with the
+// same labels, the exemplars are best effort and the results are returned without them on errors
+func (q *querier) addExemplars(ctx context.Context, params *v3.QueryRangeParamsV3, results []*v3.Result) {
+	if params.CompositeQuery.PanelType != v3.PanelTypeGraph || q.reader == nil {
+		return
+	}
+	for _, result := range results {
+		builderQuery, ok := params.CompositeQuery.BuilderQueries[result.QueryName]
+		if !ok || builderQuery.DataSource != v3.DataSourceMetrics || builderQuery.Disabled {
+			continue
+		}
+		query, err := metricsV4.PrepareExemplarsQuery(params.Start, params.End, builderQuery)
+		if err != nil {
+			zap.L().Error("error preparing exemplars query", zap.String("query", result.QueryName), zap.Error(err))
+			continue
+		}
+		exemplars, err := q.reader.GetExemplars(ctx, query)
+		if err != nil {
+			zap.L().Error("error fetching exemplars", zap.String("query", result.QueryName), zap.Error(err))
+			continue
+		}
+		attachExemplars(result.Series, exemplars)
+	}
+}
+
+func attachExemplars(seriesList []*v3.Series, exemplars []v3.Exemplar) {
+	seriesByLabels := map[string]*v3.Series{}
+	for _, series := range seriesList {
+		seriesByLabels[labelsToString(series.Labels)] = series
+	}
+	for _, exemplar := range exemplars {
+		series, ok := seriesByLabels[labelsToString(exemplar.Labels)]
+		if !ok {
+			// the series was left out by the limit of the query
+			continue
+		}
+		// the labels are those of the series
+		exemplar.Labels = nil
+		series.Exemplars = append(series.Exemplars, exemplar)
+	}
+	for _, series := range seriesList {
+		sort.Slice(series.Exemplars, func(i, j int) bool {
+			return series.Exemplars[i].Timestamp < series.Exemplars[j].Timestamp
+		})
+	}
+}
+
 func (q *querier) QueriesExecuted() []string {
 	return q.queriesExecuted
 }
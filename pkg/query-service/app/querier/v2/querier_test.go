@@ -1058,3 +1058,30 @@ func TestV2QueryRangeValueTypePromQL(t *testing.T) {
 		}
 	}
 }
+
+func TestV2AttachExemplars(t *testing.T) {
+	seriesList := []*v3.Series{
+		{Labels: map[string]string{"operation": "GET /cart"}},
+		{Labels: map[string]string{"operation": "POST /checkout"}},
+	}
+	exemplars := []v3.Exemplar{
+		{Timestamp: 2000, Value: 0.4, TraceId: "t2", SpanId: "s2", Labels: map[string]string{"operation": "GET /cart"}},
+		{Timestamp: 1000, Value: 0.9, TraceId: "t1", SpanId: "s1", Labels: map[string]string{"operation": "GET /cart"}},
+		{Timestamp: 1000, Value: 1.2, TraceId: "t3", SpanId: "s3", Labels: map[string]string{"operation": "GET /health"}},
+	}
+
+	attachExemplars(seriesList, exemplars)
+
+	if len(seriesList[0].Exemplars) != 2 {
+		t.Fatalf("expected 2 exemplars, got %v", seriesList[0].Exemplars)
+	}
+	if seriesList[0].Exemplars[0].TraceId != "t1" || seriesList[0].Exemplars[1].TraceId != "t2" {
+		t.Errorf("expected exemplars sorted by timestamp, got %v", seriesList[0].Exemplars)
+	}
+	if seriesList[0].Exemplars[0].Labels != nil {
+		t.Errorf("expected the labels of the series to be left out, got %v", seriesList[0].Exemplars[0].Labels)
+	}
+	if len(seriesList[1].Exemplars) != 0 {
+		t.Errorf("expected no exemplars, got %v", seriesList[1].Exemplars)
+	}
+}
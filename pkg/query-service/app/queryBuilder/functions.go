@@ -236,7 +236,53 @@ func median(values []float64) float64 {
 	return values[medianIndex]
 }
 
-func ApplyFunction(fn v3.Function, result *v3.Result) *v3.Result {
+// funcDerivative returns the per second rate of change between each point and the previous
+// one in a series, the first point has no previous point and is dropped
+func funcDerivative(result *v3.Result) *v3.Result {
+	for _, series := range result.Series {
+		if len(series.Points) == 0 {
+			continue
+		}
+		derivative := make([]v3.Point, 0, len(series.Points)-1)
+		for i := 1; i < len(series.Points); i++ {
+			prev, curr := series.Points[i-1], series.Points[i]
+			value := math.NaN()
+			if elapsed := curr.Timestamp - prev.Timestamp; elapsed > 0 {
+				value = (curr.Value - prev.Value) / (float64(elapsed) / 1000)
+			}
+			derivative = append(derivative, v3.Point{Timestamp: curr.Timestamp, Value: value})
+		}
+		series.Points = derivative
+	}
+	return result
+}
+
+// funcAbsent returns a series with the value 1 at each step between start and end when the
+// result has no series, and no series otherwise.
+// start and end are in milliseconds, step is in seconds
+func funcAbsent(result *v3.Result, start, end, step int64) *v3.Result {
+	if len(result.Series) > 0 {
+		result.Series = []*v3.Series{}
+		return result
+	}
+	if step <= 0 {
+		return result
+	}
+	series := &v3.Series{
+		Labels:      map[string]string{},
+		LabelsArray: []map[string]string{},
+		Points:      []v3.Point{},
+	}
+	for ts := start - (start % (step * 1000)); ts <= end; ts += step * 1000 {
+		series.Points = append(series.Points, v3.Point{Timestamp: ts, Value: 1})
+	}
+	result.Series = []*v3.Series{series}
+	return result
+}
+
+// ApplyFunction applies the function to the result of a query.
+// start and end of the query are in milliseconds, step is in seconds
+func ApplyFunction(fn v3.Function, result *v3.Result, start, end, step int64) *v3.Result {
 
 	switch fn.Name {
 	case v3.FunctionNameCutOffMin, v3.FunctionNameCutOffMax, v3.FunctionNameClampMin, v3.FunctionNameClampMax:
@@ -287,6 +333,10 @@ func ApplyFunction(fn v3.Function, result *v3.Result) *v3.Result {
 			return result
 		}
 		return funcTimeShift(result, shift)
+	case v3.FunctionNameDerivative:
+		return funcDerivative(result)
+	case v3.FunctionNameAbsent:
+		return funcAbsent(result, start, end, step)
 	}
 	return result
 }
@@ -602,3 +602,54 @@ func TestFuncMedian5(t *testing.T) {
 		}
 	}
 }
+
+func TestFuncDerivative(t *testing.T) {
+	result := &v3.Result{
+		Series: []*v3.Series{
+			{
+				Points: []v3.Point{
+					{Timestamp: 0, Value: 10},
+					{Timestamp: 60000, Value: 70},
+					{Timestamp: 120000, Value: 40},
+				},
+			},
+		},
+	}
+	want := []v3.Point{
+		{Timestamp: 60000, Value: 1},
+		{Timestamp: 120000, Value: -0.5},
+	}
+
+	got := funcDerivative(result)
+	if len(got.Series[0].Points) != len(want) {
+		t.Fatalf("funcDerivative() = %v, want %v", got.Series[0].Points, want)
+	}
+	for i := range want {
+		if got.Series[0].Points[i] != want[i] {
+			t.Errorf("funcDerivative() = %v, want %v", got.Series[0].Points[i], want[i])
+		}
+	}
+}
+
+func TestFuncAbsent(t *testing.T) {
+	got := funcAbsent(&v3.Result{}, 90000, 240000, 60)
+	want := []v3.Point{
+		{Timestamp: 60000, Value: 1},
+		{Timestamp: 120000, Value: 1},
+		{Timestamp: 180000, Value: 1},
+		{Timestamp: 240000, Value: 1},
+	}
+	if len(got.Series) != 1 || len(got.Series[0].Points) != len(want) {
+		t.Fatalf("funcAbsent() = %v, want one series of %v", got.Series, want)
+	}
+	for i := range want {
+		if got.Series[0].Points[i] != want[i] {
+			t.Errorf("funcAbsent() = %v, want %v", got.Series[0].Points[i], want[i])
+		}
+	}
+
+	got = funcAbsent(&v3.Result{Series: []*v3.Series{{Points: []v3.Point{{Timestamp: 60000, Value: 5}}}}}, 90000, 240000, 60)
+	if len(got.Series) != 0 {
+		t.Errorf("funcAbsent() = %v, want no series", got.Series)
+	}
+}
@@ -377,6 +377,14 @@ func (c *cacheKeyGenerator) GenerateKeys(params *v3.QueryRangeParamsV3) map[stri
 			key := strings.Join(parts, "&")
 			keys[queryName] = key
 		} else if query.Expression == queryName && query.DataSource == v3.DataSourceMetrics {
+			// the windows of a nested aggregation over time can't be merged with the cached ones
+			if query.NestedAggregation != nil && query.NestedAggregation.OverTime() {
+				continue
+			}
+			// the series kept by a series limit are ranked over the whole range
+			if query.SeriesLimit != nil {
+				continue
+			}
 			var parts []string
 
 			// We need to build uniqe cache query for BuilderQuery
@@ -409,6 +417,16 @@ func (c *cacheKeyGenerator) GenerateKeys(params *v3.QueryRangeParamsV3) map[stri
 				}
 			}
 
+			if query.NestedAggregation != nil {
+				parts = append(parts, fmt.Sprintf("nested=%s", query.NestedAggregation.Operator))
+				for idx, groupBy := range query.NestedAggregation.GroupBy {
+					parts = append(parts, fmt.Sprintf("nestedGroupBy-%d=%s", idx, groupBy.CacheKey()))
+				}
+				for idx, having := range query.NestedAggregation.Having {
+					parts = append(parts, fmt.Sprintf("nestedHaving-%d=%s", idx, having.CacheKey()))
+				}
+			}
+
 			key := strings.Join(parts, "&")
 			keys[queryName] = key
 		}
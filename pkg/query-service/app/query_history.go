@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/app/queryhistory"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// trackQuery collects the stats of the clickhouse queries run with the returned context, the
+// returned func records the query in the history once done. Only the queries of the users are
+// recorded, the queries of the alerts and of the shared dashboards are not
+func (aH *APIHandler) trackQuery(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, version string) (context.Context, func(*model.ApiError)) {
+	user := common.GetUserFromContext(ctx)
+	// the contexts of the shared dashboards only hold the org of the dashboard
+	if aH.QueryHistoryManager == nil || user == nil || user.Id == "" {
+		return ctx, func(*model.ApiError) {}
+	}
+
+	// the params are kept as sent, before the enrichment of the query
+	params, _ := json.Marshal(queryRangeParams)
+	ctx, stats := common.WithQueryStats(ctx)
+	startedAt := time.Now()
+	return ctx, func(apiErr *model.ApiError) {
+		entry := &queryhistory.Entry{
+			OrgId:      user.OrgId,
+			UserId:     user.Id,
+			UserEmail:  user.Email,
+			Version:    version,
+			Params:     string(params),
+			Start:      queryRangeParams.Start,
+			End:        queryRangeParams.End,
+			DurationMs: time.Since(startedAt).Milliseconds(),
+			RowsRead:   stats.RowsRead(),
+			BytesRead:  stats.BytesRead(),
+			CreatedAt:  startedAt,
+		}
+		if queryRangeParams.CompositeQuery != nil {
+			entry.QueryType = string(queryRangeParams.CompositeQuery.QueryType)
+			entry.PanelType = string(queryRangeParams.CompositeQuery.PanelType)
+		}
+		if apiErr != nil {
+			entry.Error = apiErr.Error()
+			if entry.Error == "" {
+				entry.Error = string(apiErr.Typ)
+			}
+		}
+		aH.QueryHistoryManager.Record(entry)
+	}
+}
+
+// listQueryHistory lists the queries run by the user, the admins can list the queries of the whole org
+func (aH *APIHandler) listQueryHistory(w http.ResponseWriter, r *http.Request) {
+	user := common.GetUserFromContext(r.Context())
+	if user == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("failed to get user from context")}, nil)
+		return
+	}
+
+	filter, err := parseQueryHistoryFilter(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	filter.OrgId = user.OrgId
+	if !auth.IsAdmin(user) {
+		filter.UserId = user.Id
+	}
+
+	entries, err := aH.QueryHistoryManager.List(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, entries)
+}
+
+func parseQueryHistoryFilter(r *http.Request) (queryhistory.Filter, error) {
+	query := r.URL.Query()
+	filter := queryhistory.Filter{
+		UserId:    query.Get("userId"),
+		QueryType: query.Get("queryType"),
+	}
+
+	var err error
+	if v := query.Get("slow"); v != "" {
+		if filter.Slow, err = strconv.ParseBool(v); err != nil {
+			return filter, fmt.Errorf("invalid slow %q", v)
+		}
+	}
+	if v := query.Get("failed"); v != "" {
+		if filter.Failed, err = strconv.ParseBool(v); err != nil {
+			return filter, fmt.Errorf("invalid failed %q", v)
+		}
+	}
+	if v := query.Get("minDurationMs"); v != "" {
+		if filter.MinDurationMs, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return filter, fmt.Errorf("invalid minDurationMs %q", v)
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return filter, fmt.Errorf("invalid limit %q", v)
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if filter.Offset, err = strconv.Atoi(v); err != nil {
+			return filter, fmt.Errorf("invalid offset %q", v)
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,148 @@
+package queryhistory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	// pruneInterval is the interval at which the entries past the retention are deleted
+	pruneInterval = time.Hour
+	// queueSize is the number of entries waiting to be written past which they are dropped
+	queueSize = 1000
+
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+type ManagerOptions struct {
+	DB *sqlx.DB
+	// SlowQueryThreshold is the duration from which the queries are logged and listed as slow
+	SlowQueryThreshold time.Duration
+	// Retention is how long the entries are kept
+	Retention time.Duration
+}
+
+// Manager records the queries run by the users, the entries are written in the background
+// so the queries don't wait on the db
+type Manager struct {
+	repo          *repo
+	slowThreshold time.Duration
+	retention     time.Duration
+
+	queue  chan *Entry
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	if err := InitSqliteDBIfNeeded(opts.DB); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		repo:          &repo{db: opts.DB},
+		slowThreshold: opts.SlowQueryThreshold,
+		retention:     opts.Retention,
+		queue:         make(chan *Entry, queueSize),
+		ctx:           ctx,
+		cancel:        cancel,
+	}, nil
+}
+
+// Start writes the recorded entries and prunes the entries past the retention
+func (m *Manager) Start() error {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.ctx.Done():
+				m.drain()
+				return
+			case e := <-m.queue:
+				m.write(e)
+			case <-ticker.C:
+				m.prune()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop writes the entries left in the queue and stops the manager
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+func (m *Manager) drain() {
+	for {
+		select {
+		case e := <-m.queue:
+			m.write(e)
+		default:
+			return
+		}
+	}
+}
+
+func (m *Manager) write(e *Entry) {
+	if err := m.repo.insert(context.Background(), e); err != nil {
+		zap.L().Error("failed to record query in the query history", zap.Error(err))
+	}
+}
+
+func (m *Manager) prune() {
+	if m.retention <= 0 {
+		return
+	}
+	if err := m.repo.prune(context.Background(), time.Now().Add(-m.retention)); err != nil {
+		zap.L().Error("failed to prune the query history", zap.Error(err))
+	}
+}
+
+// Record adds the query to the history, the slow queries are logged as well.
+// The entry is dropped when the queue is full rather than slowing down the queries
+func (m *Manager) Record(e *Entry) {
+	if m.slowThreshold > 0 && e.DurationMs >= m.slowThreshold.Milliseconds() {
+		zap.L().Warn("slow query",
+			zap.String("userEmail", e.UserEmail),
+			zap.String("queryType", e.QueryType),
+			zap.Int64("durationMs", e.DurationMs),
+			zap.Uint64("rowsRead", e.RowsRead),
+			zap.Uint64("bytesRead", e.BytesRead),
+			zap.String("params", e.Params),
+		)
+	}
+
+	select {
+	case m.queue <- e:
+	default:
+		zap.L().Warn("query history queue is full, dropping the query", zap.String("userEmail", e.UserEmail))
+	}
+}
+
+// List returns the entries of the history selected by the filter, the newest first
+func (m *Manager) List(ctx context.Context, f Filter) ([]*Entry, error) {
+	if f.Slow && m.slowThreshold.Milliseconds() > f.MinDurationMs {
+		f.MinDurationMs = m.slowThreshold.Milliseconds()
+	}
+	if f.Limit <= 0 {
+		f.Limit = defaultListLimit
+	}
+	if f.Limit > maxListLimit {
+		f.Limit = maxListLimit
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	return m.repo.list(ctx, f)
+}
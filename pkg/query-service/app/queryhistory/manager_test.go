@@ -0,0 +1,71 @@
+package queryhistory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+func TestManagerRecordAndList(t *testing.T) {
+	db := utils.NewQueryServiceDBForTests(t)
+	m, err := NewManager(ManagerOptions{DB: db, SlowQueryThreshold: time.Second, Retention: time.Hour})
+	require.NoError(t, err)
+	require.NoError(t, m.Start())
+
+	now := time.Now()
+	m.Record(&Entry{OrgId: "org", UserId: "alice", QueryType: "builder", DurationMs: 100, CreatedAt: now.Add(-3 * time.Minute)})
+	m.Record(&Entry{OrgId: "org", UserId: "alice", QueryType: "clickhouse_sql", DurationMs: 2000, CreatedAt: now.Add(-2 * time.Minute)})
+	m.Record(&Entry{OrgId: "org", UserId: "bob", QueryType: "promql", DurationMs: 50, Error: "bad query", CreatedAt: now.Add(-time.Minute)})
+	m.Record(&Entry{OrgId: "other", UserId: "carol", QueryType: "builder", DurationMs: 10, CreatedAt: now})
+	// the recorded entries are written once stopped
+	m.Stop()
+
+	ctx := context.Background()
+	entries, err := m.List(ctx, Filter{OrgId: "org"})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, "bob", entries[0].UserId)
+	require.Equal(t, "alice", entries[2].UserId)
+
+	entries, err = m.List(ctx, Filter{OrgId: "org", UserId: "alice"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	entries, err = m.List(ctx, Filter{OrgId: "org", Slow: true})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "clickhouse_sql", entries[0].QueryType)
+
+	entries, err = m.List(ctx, Filter{OrgId: "org", Failed: true})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "bad query", entries[0].Error)
+
+	entries, err = m.List(ctx, Filter{OrgId: "org", QueryType: "builder", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	entries, err = m.List(ctx, Filter{OrgId: "org", Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestManagerPrune(t *testing.T) {
+	db := utils.NewQueryServiceDBForTests(t)
+	m, err := NewManager(ManagerOptions{DB: db, Retention: time.Hour})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, m.repo.insert(ctx, &Entry{OrgId: "org", UserId: "alice", CreatedAt: time.Now().Add(-2 * time.Hour)}))
+	require.NoError(t, m.repo.insert(ctx, &Entry{OrgId: "org", UserId: "bob", CreatedAt: time.Now()}))
+	m.prune()
+
+	entries, err := m.List(ctx, Filter{OrgId: "org"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "bob", entries[0].UserId)
+}
@@ -0,0 +1,41 @@
+package queryhistory
+
+import (
+	"time"
+)
+
+// Entry is a query range request run on behalf of a user
+type Entry struct {
+	Id        int64  `json:"id" db:"id"`
+	OrgId     string `json:"orgId" db:"org_id"`
+	UserId    string `json:"userId" db:"user_id"`
+	UserEmail string `json:"userEmail" db:"user_email"`
+	// Version is the version of the query range api the query was run with
+	Version   string `json:"version" db:"version"`
+	QueryType string `json:"queryType" db:"query_type"`
+	PanelType string `json:"panelType" db:"panel_type"`
+	// Params are the params of the request, they can be posted again to re-run the query
+	Params     string    `json:"params" db:"params"`
+	Start      int64     `json:"start" db:"start_ms"`
+	End        int64     `json:"end" db:"end_ms"`
+	DurationMs int64     `json:"durationMs" db:"duration_ms"`
+	RowsRead   uint64    `json:"rowsRead" db:"rows_read"`
+	BytesRead  uint64    `json:"bytesRead" db:"bytes_read"`
+	Error      string    `json:"error" db:"error"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+}
+
+// Filter selects the entries of the history of an org, the newest first
+type Filter struct {
+	OrgId     string
+	UserId    string
+	QueryType string
+	// MinDurationMs selects the queries that ran for at least as long
+	MinDurationMs int64
+	// Slow selects the queries slower than the slow query threshold
+	Slow bool
+	// Failed selects the queries that failed
+	Failed bool
+	Limit  int
+	Offset int
+}
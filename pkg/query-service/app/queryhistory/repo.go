@@ -0,0 +1,100 @@
+package queryhistory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS query_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		org_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		user_email TEXT NOT NULL,
+		version TEXT NOT NULL,
+		query_type TEXT NOT NULL,
+		panel_type TEXT NOT NULL,
+		params TEXT NOT NULL,
+		start_ms INTEGER NOT NULL,
+		end_ms INTEGER NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		rows_read INTEGER NOT NULL,
+		bytes_read INTEGER NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		created_at datetime NOT NULL
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating query_history table: %s", err.Error())
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_query_history_org_created_at ON query_history (org_id, created_at);`)
+	if err != nil {
+		return fmt.Errorf("error in creating query_history index: %s", err.Error())
+	}
+	return nil
+}
+
+const entryColumns = "id, org_id, user_id, user_email, version, query_type, panel_type, params, start_ms, end_ms, duration_ms, rows_read, bytes_read, error, created_at"
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func (r *repo) insert(ctx context.Context, e *Entry) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO query_history (org_id, user_id, user_email, version, query_type, panel_type, params, start_ms, end_ms, duration_ms, rows_read, bytes_read, error, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)",
+		e.OrgId, e.UserId, e.UserEmail, e.Version, e.QueryType, e.PanelType, e.Params, e.Start, e.End, e.DurationMs, e.RowsRead, e.BytesRead, e.Error, e.CreatedAt)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *repo) list(ctx context.Context, f Filter) ([]*Entry, error) {
+	conditions := []string{"org_id = ?"}
+	args := []interface{}{f.OrgId}
+	if f.UserId != "" {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, f.UserId)
+	}
+	if f.QueryType != "" {
+		conditions = append(conditions, "query_type = ?")
+		args = append(args, f.QueryType)
+	}
+	if f.MinDurationMs > 0 {
+		conditions = append(conditions, "duration_ms >= ?")
+		args = append(args, f.MinDurationMs)
+	}
+	if f.Failed {
+		conditions = append(conditions, "error != ''")
+	}
+	args = append(args, f.Limit, f.Offset)
+
+	entries := []*Entry{}
+	query := "SELECT " + entryColumns + " FROM query_history WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?"
+	if err := r.db.SelectContext(ctx, &entries, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return entries, nil
+}
+
+// prune deletes the entries older than before
+func (r *repo) prune(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM query_history WHERE created_at < $1", before)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/remotewrite"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// promRemoteWrite receives the samples of the Prometheus servers, the servers authenticate
+// with an ingestion key allowed to send metrics, whose usage is the compressed size of the requests
+func (aH *APIHandler) promRemoteWrite(w http.ResponseWriter, r *http.Request) {
+	if aH.remoteWriter == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnavailable, Err: fmt.Errorf("remote write is not available")}, nil)
+		return
+	}
+
+	compressed, err := io.ReadAll(http.MaxBytesReader(w, r.Body, remotewrite.MaxRequestSize))
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	key, apiErr := authorizeIngestion(r, model.IngestionSignalMetrics, int64(len(compressed)))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	req, err := remotewrite.DecodeWriteRequest(compressed)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	remotewrite.SetLabel(req, constants.OrgIdLabel, key.OrgId)
+	if err := aH.remoteWriter.Write(r.Context(), req); err != nil {
+		// Prometheus retries the requests failing with a server error only
+		if errors.Is(err, remotewrite.ErrInvalidSeries) {
+			RespondError(w, model.BadRequest(err), nil)
+			return
+		}
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,304 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/robfig/cron/v3"
+	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/utils/cronschedule"
+	smtpservice "go.signoz.io/signoz/pkg/query-service/utils/smtpService"
+	"go.uber.org/zap"
+)
+
+const (
+	renderWidth  = 1600
+	renderHeight = 1200
+)
+
+var emailTemplate = template.Must(template.New("report").Parse(
+	`<p>The <a href="{{.Link}}">{{.Dashboard}}</a> dashboard for {{.Start}} to {{.End}} is attached.</p>` +
+		`<p>This report is sent by the {{.Name}} schedule.</p>`))
+
+// Mailer sends the rendered reports
+type Mailer interface {
+	SendEmailWithAttachments(to, subject, body string, attachments ...smtpservice.Attachment) error
+}
+
+type ManagerOptions struct {
+	DB *sqlx.DB
+	// FrontendURL is the base url of the frontend the dashboards are rendered from
+	FrontendURL string
+	Renderer    Renderer
+	Mailer      Mailer
+}
+
+// Manager runs the report schedules
+type Manager struct {
+	repo        *repo
+	frontendURL string
+	renderer    Renderer
+	mailer      Mailer
+
+	mtx     sync.Mutex
+	cron    *cron.Cron
+	entries map[int64]cron.EntryID
+}
+
+func defaultOptions(opts ManagerOptions) ManagerOptions {
+	if opts.Renderer == nil && constants.ReportRendererURL != "" {
+		opts.Renderer = NewHTTPRenderer(constants.ReportRendererURL)
+	}
+	if opts.Mailer == nil && os.Getenv("SMTP_ENABLED") == "true" {
+		opts.Mailer = smtpservice.GetInstance()
+	}
+	return opts
+}
+
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	opts = defaultOptions(opts)
+	if err := InitSqliteDBIfNeeded(opts.DB); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		repo:        &repo{db: opts.DB},
+		frontendURL: strings.TrimSuffix(opts.FrontendURL, "/"),
+		renderer:    opts.Renderer,
+		mailer:      opts.Mailer,
+		cron:        cron.New(cron.WithParser(cronschedule.Parser)),
+		entries:     map[int64]cron.EntryID{},
+	}, nil
+}
+
+// Start schedules the stored reports and starts the scheduler
+func (m *Manager) Start() error {
+	schedules, err := m.repo.list(context.Background())
+	if err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for _, s := range schedules {
+		m.schedule(s)
+	}
+	m.cron.Start()
+	return nil
+}
+
+// Stop stops the scheduler and waits for the running reports
+func (m *Manager) Stop() {
+	<-m.cron.Stop().Done()
+}
+
+// schedule replaces the cron entry of the schedule, the caller must hold the lock
+func (m *Manager) schedule(s *Schedule) {
+	if entry, ok := m.entries[s.Id]; ok {
+		m.cron.Remove(entry)
+		delete(m.entries, s.Id)
+	}
+	if s.Disabled {
+		return
+	}
+
+	sched, err := s.schedule()
+	if err != nil {
+		zap.L().Error("invalid report schedule", zap.Int64("id", s.Id), zap.Error(err))
+		return
+	}
+	id := strconv.FormatInt(s.Id, 10)
+	m.entries[s.Id] = m.cron.Schedule(sched, cron.FuncJob(func() {
+		if err := m.Run(context.Background(), id); err != nil {
+			zap.L().Error("failed to send report", zap.String("id", id), zap.Error(err))
+		}
+	}))
+}
+
+func (m *Manager) List(ctx context.Context) ([]*Schedule, error) {
+	return m.repo.list(ctx)
+}
+
+func (m *Manager) Get(ctx context.Context, id string) (*Schedule, error) {
+	return m.repo.get(ctx, id)
+}
+
+func (m *Manager) Create(ctx context.Context, s *Schedule) (*Schedule, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if _, apiErr := dashboards.GetDashboard(ctx, s.DashboardId); apiErr != nil {
+		return nil, apiErr.Err
+	}
+
+	id, err := m.repo.create(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	s.Id = id
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.schedule(s)
+	return s, nil
+}
+
+func (m *Manager) Edit(ctx context.Context, s *Schedule, id string) (*Schedule, error) {
+	existing, err := m.repo.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if _, apiErr := dashboards.GetDashboard(ctx, s.DashboardId); apiErr != nil {
+		return nil, apiErr.Err
+	}
+
+	s.Id = existing.Id
+	s.CreatedAt = existing.CreatedAt
+	s.CreatedBy = existing.CreatedBy
+	s.LastRunAt = existing.LastRunAt
+	s.LastError = existing.LastError
+	s.OrgId = existing.OrgId
+	if err := m.repo.edit(ctx, s, id); err != nil {
+		return nil, err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.schedule(s)
+	return s, nil
+}
+
+func (m *Manager) Delete(ctx context.Context, id string) error {
+	existing, err := m.repo.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := m.repo.delete(ctx, id); err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if entry, ok := m.entries[existing.Id]; ok {
+		m.cron.Remove(entry)
+		delete(m.entries, existing.Id)
+	}
+	return nil
+}
+
+// Run renders the dashboard of the schedule and emails it to the
+// recipients, the outcome is recorded on the schedule
+func (m *Manager) Run(ctx context.Context, id string) error {
+	s, err := m.repo.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	runErr := m.send(ctx, s, now)
+
+	lastError := ""
+	if runErr != nil {
+		lastError = runErr.Error()
+	}
+	if err := m.repo.setLastRun(ctx, s.Id, now, lastError); err != nil {
+		zap.L().Error("failed to record report run", zap.Int64("id", s.Id), zap.Error(err))
+	}
+	return runErr
+}
+
+// dashboardURL returns the frontend page of the dashboard over the time range ending at ts
+func (m *Manager) dashboardURL(s *Schedule, ts time.Time) string {
+	params := url.Values{}
+	params.Set("startTime", strconv.FormatInt(ts.Add(-time.Duration(s.TimeRange)).UnixMilli(), 10))
+	params.Set("endTime", strconv.FormatInt(ts.UnixMilli(), 10))
+	return fmt.Sprintf("%s/dashboard/%s?%s", m.frontendURL, s.DashboardId, params.Encode())
+}
+
+func (m *Manager) send(ctx context.Context, s *Schedule, ts time.Time) error {
+	if m.renderer == nil {
+		return fmt.Errorf("report renderer is not configured")
+	}
+	if m.mailer == nil {
+		return fmt.Errorf("smtp is not configured")
+	}
+
+	// the scheduled runs read the dashboard in the org of the schedule
+	if s.OrgId != "" {
+		ctx = common.WithOrg(ctx, s.OrgId)
+	}
+	dashboard, apiErr := dashboards.GetDashboard(ctx, s.DashboardId)
+	if apiErr != nil {
+		return apiErr.Err
+	}
+
+	// the dashboard is rendered with the permissions of the schedule owner
+	user, apiErr := dao.DB().GetUserByEmail(ctx, s.CreatedBy)
+	if apiErr != nil {
+		return apiErr.Err
+	}
+	if user == nil {
+		return fmt.Errorf("owner %s of the report no longer exists", s.CreatedBy)
+	}
+	token, err := auth.GenerateRenderJWT(&user.User, renderTimeout+time.Minute)
+	if err != nil {
+		return err
+	}
+
+	link := m.dashboardURL(s, ts)
+	data, err := m.renderer.Render(ctx, RenderRequest{
+		URL:       link,
+		Format:    s.Format,
+		AuthToken: token,
+		Width:     renderWidth,
+		Height:    renderHeight,
+	})
+	if err != nil {
+		return err
+	}
+
+	title, _ := dashboard.Data["title"].(string)
+	if title == "" {
+		title = s.Name
+	}
+
+	var body bytes.Buffer
+	err = emailTemplate.Execute(&body, map[string]string{
+		"Link":      link,
+		"Dashboard": title,
+		"Name":      s.Name,
+		"Start":     ts.Add(-time.Duration(s.TimeRange)).UTC().Format(time.RFC1123),
+		"End":       ts.UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		return err
+	}
+
+	contentType := "application/pdf"
+	if s.Format == FormatPNG {
+		contentType = "image/png"
+	}
+	attachment := smtpservice.Attachment{
+		Filename:    fmt.Sprintf("%s-%s.%s", dashboards.SlugifyTitle(title), ts.UTC().Format("2006-01-02"), s.Format),
+		ContentType: contentType,
+		Data:        data,
+	}
+
+	subject := fmt.Sprintf("%s report: %s", s.Name, ts.UTC().Format("2006-01-02"))
+	return m.mailer.SendEmailWithAttachments(strings.Join(*s.Recipients, ","), subject, body.String(), attachment)
+}
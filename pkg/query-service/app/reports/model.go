@@ -0,0 +1,145 @@
+package reports
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/cronschedule"
+)
+
+type Format string
+
+const (
+	FormatPDF Format = "pdf"
+	FormatPNG Format = "png"
+)
+
+const defaultTimeRange = 24 * time.Hour
+
+var (
+	ErrMissingName       = errors.New("missing name")
+	ErrInvalidName       = errors.New("name must not contain line breaks")
+	ErrMissingDashboard  = errors.New("missing dashboard id")
+	ErrMissingRecipients = errors.New("missing recipients")
+	ErrInvalidFormat     = errors.New("format must be pdf or png")
+)
+
+// Schedule renders a dashboard on a cron schedule and emails
+// the rendered file to the recipients
+type Schedule struct {
+	Id          int64  `json:"id" db:"id"`
+	Name        string `json:"name" db:"name"`
+	DashboardId string `json:"dashboardId" db:"dashboard_id"`
+	// Cron is evaluated in the timezone of the schedule, UTC by default
+	Cron     string `json:"cron" db:"cron"`
+	Timezone string `json:"timezone" db:"timezone"`
+	Format   Format `json:"format" db:"format"`
+	// TimeRange is the time range of the dashboard ending at the run time
+	TimeRange  Duration    `json:"timeRange" db:"time_range"`
+	Recipients *Recipients `json:"recipients" db:"recipients"`
+	Disabled   bool        `json:"disabled" db:"disabled"`
+	LastRunAt  *time.Time  `json:"lastRunAt,omitempty" db:"last_run_at"`
+	LastError  string      `json:"lastError,omitempty" db:"last_error"`
+	CreatedAt  time.Time   `json:"createdAt" db:"created_at"`
+	CreatedBy  string      `json:"createdBy" db:"created_by"`
+	UpdatedAt  time.Time   `json:"updatedAt" db:"updated_at"`
+	UpdatedBy  string      `json:"updatedBy" db:"updated_by"`
+	// OrgId is the org of the schedule, its dashboard is one of the org
+	OrgId string `json:"-" db:"org_id"`
+}
+
+type Recipients []string
+
+func (r *Recipients) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, r)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), r)
+	}
+	return nil
+}
+
+func (r *Recipients) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Duration is stored as nanoseconds and read as a go duration
+// string such as 24h from the api
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (s *Schedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// schedule parses the cron expression in the timezone of the schedule
+func (s *Schedule) schedule() (cron.Schedule, error) {
+	loc, err := s.location()
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	return cronschedule.Parse(s.Cron, loc)
+}
+
+func (s *Schedule) Validate() error {
+	if s.Name == "" {
+		return ErrMissingName
+	}
+	// the name is the subject of the emails
+	if strings.ContainsAny(s.Name, "\r\n") {
+		return ErrInvalidName
+	}
+	if s.DashboardId == "" {
+		return ErrMissingDashboard
+	}
+	if _, err := s.schedule(); err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
+	}
+	if s.Format == "" {
+		s.Format = FormatPDF
+	}
+	if s.Format != FormatPDF && s.Format != FormatPNG {
+		return ErrInvalidFormat
+	}
+	if s.TimeRange == 0 {
+		s.TimeRange = Duration(defaultTimeRange)
+	}
+	if s.TimeRange < 0 {
+		return errors.New("time range must be positive")
+	}
+	if s.Recipients == nil || len(*s.Recipients) == 0 {
+		return ErrMissingRecipients
+	}
+	for _, r := range *s.Recipients {
+		if _, err := mail.ParseAddress(r); err != nil {
+			return fmt.Errorf("invalid recipient %s: %w", r, err)
+		}
+	}
+	return nil
+}
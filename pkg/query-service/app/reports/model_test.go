@@ -0,0 +1,75 @@
+package reports
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSchedule() *Schedule {
+	return &Schedule{
+		Name:        "weekly latency",
+		DashboardId: "6b1f1c3e-3f0a-4d5b-9d8e-0f4a3c2b1a00",
+		Cron:        "0 9 * * 1",
+		Timezone:    "Europe/Paris",
+		Recipients:  &Recipients{"sre@example.com"},
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	s := testSchedule()
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Format != FormatPDF || s.TimeRange != Duration(defaultTimeRange) {
+		t.Errorf("expected defaults, got %s %v", s.Format, s.TimeRange)
+	}
+
+	cases := map[string]func(s *Schedule){
+		"invalid cron":      func(s *Schedule) { s.Cron = "every monday" },
+		"invalid timezone":  func(s *Schedule) { s.Timezone = "Mars/Olympus" },
+		"invalid format":    func(s *Schedule) { s.Format = "svg" },
+		"no recipients":     func(s *Schedule) { s.Recipients = &Recipients{} },
+		"invalid recipient": func(s *Schedule) { s.Recipients = &Recipients{"sre"} },
+		"no dashboard":      func(s *Schedule) { s.DashboardId = "" },
+		"header injection":  func(s *Schedule) { s.Name = "Weekly\r\nBcc: attacker@example.com" },
+	}
+	for name, mutate := range cases {
+		s := testSchedule()
+		mutate(s)
+		if err := s.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestScheduleTimezone(t *testing.T) {
+	s := testSchedule()
+	sched, err := s.schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paris, _ := time.LoadLocation("Europe/Paris")
+	// sunday noon in paris, the next run is monday 9:00 paris time
+	next := sched.Next(time.Date(2024, 6, 2, 12, 0, 0, 0, paris))
+	expected := time.Date(2024, 6, 3, 9, 0, 0, 0, paris)
+	if !next.Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, next)
+	}
+}
+
+func TestDashboardURL(t *testing.T) {
+	m := &Manager{frontendURL: "https://signoz.example.com"}
+	s := testSchedule()
+	s.TimeRange = Duration(time.Hour)
+
+	ts := time.UnixMilli(1717400000000)
+	u := m.dashboardURL(s, ts)
+	if !strings.HasPrefix(u, "https://signoz.example.com/dashboard/"+s.DashboardId+"?") {
+		t.Errorf("unexpected url %s", u)
+	}
+	if !strings.Contains(u, "endTime=1717400000000") || !strings.Contains(u, "startTime=1717396400000") {
+		t.Errorf("expected time range in url %s", u)
+	}
+}
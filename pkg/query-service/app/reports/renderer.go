@@ -0,0 +1,74 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const renderTimeout = 2 * time.Minute
+
+// RenderRequest is the dashboard page to render
+type RenderRequest struct {
+	// URL is the dashboard page of the frontend
+	URL    string
+	Format Format
+	// AuthToken is the render token the renderer uses to load the page, it is sent
+	// in the Authorization header so it is not logged with the URLs
+	AuthToken string
+	Width     int
+	Height    int
+}
+
+// Renderer renders a dashboard page into a pdf or png file
+type Renderer interface {
+	Render(ctx context.Context, req RenderRequest) ([]byte, error)
+}
+
+// httpRenderer renders the pages with a remote headless browser service
+// exposing a grafana image renderer compatible /render endpoint
+type httpRenderer struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPRenderer(rendererURL string) Renderer {
+	return &httpRenderer{
+		url:    rendererURL,
+		client: &http.Client{Timeout: renderTimeout},
+	}
+}
+
+func (r *httpRenderer) Render(ctx context.Context, req RenderRequest) ([]byte, error) {
+	params := url.Values{}
+	params.Set("url", req.URL)
+	params.Set("encoding", string(req.Format))
+	params.Set("width", strconv.Itoa(req.Width))
+	params.Set("height", strconv.Itoa(req.Height))
+	params.Set("timeout", strconv.Itoa(int(renderTimeout.Seconds())))
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url+"/render?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+req.AuthToken)
+
+	resp, err := r.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render dashboard: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("renderer returned %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
@@ -0,0 +1,129 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.uber.org/zap"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS report_schedules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		dashboard_id TEXT NOT NULL,
+		cron TEXT NOT NULL,
+		timezone TEXT,
+		format TEXT NOT NULL,
+		time_range INTEGER NOT NULL,
+		recipients TEXT NOT NULL,
+		disabled BOOLEAN NOT NULL DEFAULT 0,
+		last_run_at datetime,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating report_schedules table: %s", err.Error())
+	}
+	_, err = db.Exec(`ALTER TABLE report_schedules ADD COLUMN org_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return fmt.Errorf("error in adding column org_id to report_schedules table: %s", err.Error())
+	}
+	return nil
+}
+
+const scheduleColumns = "id, name, dashboard_id, cron, timezone, format, time_range, recipients, disabled, last_run_at, last_error, created_at, created_by, updated_at, updated_by, org_id"
+
+type repo struct {
+	db *sqlx.DB
+}
+
+// list returns the schedules of the org of the user of the context, all the schedules for
+// the scheduler
+func (r *repo) list(ctx context.Context) ([]*Schedule, error) {
+	schedules := []*Schedule{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.SelectContext(ctx, &schedules, "SELECT "+scheduleColumns+" FROM report_schedules WHERE "+condition, args...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *repo) get(ctx context.Context, id string) (*Schedule, error) {
+	schedule := &Schedule{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.GetContext(ctx, schedule, "SELECT "+scheduleColumns+" FROM report_schedules WHERE id=? AND "+condition, append([]interface{}{id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (r *repo) create(ctx context.Context, s *Schedule) (int64, error) {
+	email, _ := auth.GetEmailFromJwt(ctx)
+	s.CreatedBy = email
+	s.CreatedAt = time.Now()
+	s.UpdatedBy = email
+	s.UpdatedAt = time.Now()
+	s.OrgId = common.GetOrgIdFromContext(ctx)
+
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO report_schedules (name, dashboard_id, cron, timezone, format, time_range, recipients, disabled, created_at, created_by, updated_at, updated_by, org_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		s.Name, s.DashboardId, s.Cron, s.Timezone, s.Format, s.TimeRange, s.Recipients, s.Disabled, s.CreatedAt, s.CreatedBy, s.UpdatedAt, s.UpdatedBy, s.OrgId)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (r *repo) edit(ctx context.Context, s *Schedule, id string) error {
+	email, _ := auth.GetEmailFromJwt(ctx)
+	s.UpdatedBy = email
+	s.UpdatedAt = time.Now()
+
+	condition, args := common.OrgCondition(ctx)
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE report_schedules SET name=?, dashboard_id=?, cron=?, timezone=?, format=?, time_range=?, recipients=?, disabled=?, updated_at=?, updated_by=? WHERE id=? AND "+condition,
+		append([]interface{}{s.Name, s.DashboardId, s.Cron, s.Timezone, s.Format, s.TimeRange, s.Recipients, s.Disabled, s.UpdatedAt, s.UpdatedBy, id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *repo) delete(ctx context.Context, id string) error {
+	condition, args := common.OrgCondition(ctx)
+	_, err := r.db.ExecContext(ctx, "DELETE FROM report_schedules WHERE id=? AND "+condition, append([]interface{}{id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// setLastRun records the outcome of the latest run of the schedule
+func (r *repo) setLastRun(ctx context.Context, id int64, at time.Time, runErr string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE report_schedules SET last_run_at=$1, last_error=$2 WHERE id=$3", at, runErr, id)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
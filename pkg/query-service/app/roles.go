@@ -0,0 +1,75 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func (aH *APIHandler) listRoles(w http.ResponseWriter, r *http.Request) {
+	roles, apiErr := auth.ListRoles(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, roles)
+}
+
+// getRoleDefinition returns the role with its permissions, the role of a user is returned by getRole
+func (aH *APIHandler) getRoleDefinition(w http.ResponseWriter, r *http.Request) {
+	role, apiErr := auth.GetRole(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, role)
+}
+
+func (aH *APIHandler) createRole(w http.ResponseWriter, r *http.Request) {
+	var req model.PostableRole
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	role, apiErr := auth.CreateRole(r.Context(), &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, role)
+}
+
+func (aH *APIHandler) updateRole(w http.ResponseWriter, r *http.Request) {
+	var req model.PostableRole
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	role, apiErr := auth.EditRole(r.Context(), mux.Vars(r)["id"], &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, role)
+}
+
+func (aH *APIHandler) deleteRole(w http.ResponseWriter, r *http.Request) {
+	if apiErr := auth.DeleteRole(r.Context(), mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// listPermissions lists the resources and the actions the permissions of the roles are made of
+func (aH *APIHandler) listPermissions(w http.ResponseWriter, r *http.Request) {
+	aH.Respond(w, map[string]interface{}{
+		"resources": model.Resources,
+		"actions":   model.Actions,
+	})
+}
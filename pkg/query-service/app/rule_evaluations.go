@@ -0,0 +1,26 @@
+package app
+
+import (
+	"net/http"
+
+	"go.signoz.io/signoz/pkg/query-service/app/access"
+)
+
+// listRuleEvaluations returns the last evaluation of each rule with its latency
+func (aH *APIHandler) listRuleEvaluations(w http.ResponseWriter, r *http.Request) {
+	evaluations := aH.ruleManager.ListRuleEvaluations(r.Context())
+
+	allowed, apiErr := aH.aclFilter(r, access.ACLResourceRule, false)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	viewable := evaluations.Rules[:0]
+	for _, rule := range evaluations.Rules {
+		if allowed(rule.RuleId) {
+			viewable = append(viewable, rule)
+		}
+	}
+	evaluations.Rules = viewable
+	aH.Respond(w, evaluations)
+}
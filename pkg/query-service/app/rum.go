@@ -0,0 +1,127 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.signoz.io/signoz/pkg/query-service/app/traces/rum"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// countryHeaders are the headers with the country of the client set by the CDNs
+var countryHeaders = []string{"CF-IPCountry", "CloudFront-Viewer-Country", "X-Country-Code"}
+
+// rumIngest receives the page views sent by the RUM script of the web applications. The
+// browsers authenticate with an ingestion key allowed to send traces, which can be in the key
+// query param as navigator.sendBeacon can't set headers
+func (aH *APIHandler) rumIngest(w http.ResponseWriter, r *http.Request) {
+	if aH.rumWriter == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnavailable, Err: fmt.Errorf("rum ingestion is not available")}, nil)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, rum.MaxRequestSize))
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if ingestionKeyFromRequest(r) == "" {
+		r.Header.Set(ingestionKeyHeader, r.URL.Query().Get("key"))
+	}
+	key, apiErr := authorizeIngestion(r, model.IngestionSignalTraces, int64(len(body)))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	payload, err := rum.Decode(body, r.Header.Get("Content-Encoding"))
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	client := rum.Client{UserAgent: r.UserAgent()}
+	for _, header := range countryHeaders {
+		if client.Country = r.Header.Get(header); client.Country != "" {
+			break
+		}
+	}
+	if err := aH.rumWriter.Write(r.Context(), payload, client, map[string]string{constants.OrgIdAttribute: key.OrgId}); err != nil {
+		if errors.Is(err, rum.ErrInvalidPayload) {
+			RespondError(w, model.BadRequest(err), nil)
+			return
+		}
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// readRUMParams decodes and validates the params of the RUM queries, scoped to the org of
+// the user
+func readRUMParams(w http.ResponseWriter, r *http.Request) (*rum.Params, bool) {
+	var params rum.Params
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return nil, false
+	}
+	if err := params.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return nil, false
+	}
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return nil, false
+	}
+	params.OrgScope = scope
+	return &params, true
+}
+
+// getWebVitals returns the percentiles of the web vitals of a web application by page route
+// or country, rated on their 75th percentile
+func (aH *APIHandler) getWebVitals(w http.ResponseWriter, r *http.Request) {
+	params, ok := readRUMParams(w, r)
+	if !ok {
+		return
+	}
+	result, err := aH.rumQuerier.WebVitals(r.Context(), params)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, result)
+}
+
+// getPageLoads returns the percentiles of the page loads of a web application by page route
+// or country
+func (aH *APIHandler) getPageLoads(w http.ResponseWriter, r *http.Request) {
+	params, ok := readRUMParams(w, r)
+	if !ok {
+		return
+	}
+	result, err := aH.rumQuerier.PageLoads(r.Context(), params)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, result)
+}
+
+// getSlowestPageLoads returns the slowest page loads with their traces and the backend
+// services in them
+func (aH *APIHandler) getSlowestPageLoads(w http.ResponseWriter, r *http.Request) {
+	params, ok := readRUMParams(w, r)
+	if !ok {
+		return
+	}
+	result, err := aH.rumQuerier.SlowestPageLoads(r.Context(), params)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, result)
+}
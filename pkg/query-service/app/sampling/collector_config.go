@@ -0,0 +1,144 @@
+package sampling
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	tailSamplingProcessor = "tail_sampling"
+
+	// the traces are kept in memory until the decision is made
+	defaultDecisionWait = "10s"
+	defaultNumTraces    = 50000
+)
+
+// rulePolicy compiles the rule into a policy of the tail sampling processor
+func rulePolicy(rule Rule) map[string]interface{} {
+	var policy map[string]interface{}
+	switch rule.Type {
+	case RuleTypeErrors:
+		policy = map[string]interface{}{
+			"name":        rule.Name,
+			"type":        "status_code",
+			"status_code": map[string]interface{}{"status_codes": []interface{}{"ERROR"}},
+		}
+	case RuleTypeLatency:
+		policy = map[string]interface{}{
+			"name":    rule.Name,
+			"type":    "latency",
+			"latency": map[string]interface{}{"threshold_ms": rule.ThresholdMs},
+		}
+	case RuleTypeProbabilistic:
+		policy = map[string]interface{}{
+			"name":          rule.Name,
+			"type":          "probabilistic",
+			"probabilistic": map[string]interface{}{"sampling_percentage": rule.SamplingPercentage},
+		}
+	default:
+		return nil
+	}
+	if rule.ServiceName == "" {
+		return policy
+	}
+
+	// the rules of a service only sample the traces of the service
+	servicePolicy := map[string]interface{}{
+		"name": fmt.Sprintf("%s-service", rule.Name),
+		"type": "string_attribute",
+		"string_attribute": map[string]interface{}{
+			"key":    "service.name",
+			"values": []interface{}{rule.ServiceName},
+		},
+	}
+	return map[string]interface{}{
+		"name": rule.Name,
+		"type": "and",
+		"and": map[string]interface{}{
+			"and_sub_policy": []interface{}{servicePolicy, policy},
+		},
+	}
+}
+
+// ProcessorConfig compiles the enabled rules into the tail sampling processor
+// config, a trace is sampled when any of the rules samples it
+func ProcessorConfig(rules []Rule) map[string]interface{} {
+	policies := []interface{}{}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		if policy := rulePolicy(rule); policy != nil {
+			policies = append(policies, policy)
+		}
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+	return map[string]interface{}{
+		"decision_wait": defaultDecisionWait,
+		"num_traces":    defaultNumTraces,
+		"policies":      policies,
+	}
+}
+
+// tracesPipelineProcessors places the tail sampling processor before the batch
+// processor so that the span metrics are still generated from all the spans
+func tracesPipelineProcessors(current []interface{}, enabled bool) []interface{} {
+	processors := []interface{}{}
+	for _, name := range current {
+		if name != tailSamplingProcessor {
+			processors = append(processors, name)
+		}
+	}
+	if !enabled {
+		return processors
+	}
+	for i, name := range processors {
+		if name == "batch" {
+			return append(processors[:i], append([]interface{}{tailSamplingProcessor}, processors[i:]...)...)
+		}
+	}
+	return append(processors, tailSamplingProcessor)
+}
+
+// GenerateCollectorConfigWithSampling adds the tail sampling processor compiled from
+// the rules to the traces pipeline, the processor is removed when no rule is enabled
+func GenerateCollectorConfigWithSampling(config []byte, rules []Rule) ([]byte, *model.ApiError) {
+	var c map[string]interface{}
+	if err := yaml.Unmarshal(config, &c); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	service, _ := c["service"].(map[string]interface{})
+	pipelines, _ := service["pipelines"].(map[string]interface{})
+	traces, ok := pipelines["traces"].(map[string]interface{})
+	if !ok {
+		// the collector does not receive traces
+		return config, nil
+	}
+
+	processors, _ := c["processors"].(map[string]interface{})
+	if processors == nil {
+		processors = map[string]interface{}{}
+	}
+	processorConf := ProcessorConfig(rules)
+	if processorConf != nil {
+		processors[tailSamplingProcessor] = processorConf
+	} else {
+		delete(processors, tailSamplingProcessor)
+	}
+	c["processors"] = processors
+
+	current, _ := traces["processors"].([]interface{})
+	traces["processors"] = tracesPipelineProcessors(current, processorConf != nil)
+
+	updatedConf, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, model.BadRequest(err)
+	}
+	return updatedConf, nil
+}
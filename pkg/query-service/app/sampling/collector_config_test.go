@@ -0,0 +1,84 @@
+package sampling
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testCollectorConf = `
+processors:
+  batch: {}
+  signozspanmetrics/cumulative: {}
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [signozspanmetrics/cumulative, batch]
+      exporters: [clickhousetraces]
+`
+
+func parseConf(t *testing.T, conf []byte) map[string]interface{} {
+	var c map[string]interface{}
+	require.Nil(t, yaml.Unmarshal(conf, &c))
+	return c
+}
+
+func tracesProcessors(c map[string]interface{}) []interface{} {
+	pipelines := c["service"].(map[string]interface{})["pipelines"].(map[string]interface{})
+	return pipelines["traces"].(map[string]interface{})["processors"].([]interface{})
+}
+
+func TestGenerateCollectorConfigWithSampling(t *testing.T) {
+	rules := []Rule{
+		{Name: "errors", Type: RuleTypeErrors, Enabled: true},
+		{Name: "slow checkout", Type: RuleTypeLatency, ServiceName: "checkout", ThresholdMs: 500, Enabled: true},
+		{Name: "baseline", Type: RuleTypeProbabilistic, SamplingPercentage: 10, Enabled: true},
+		{Name: "disabled", Type: RuleTypeProbabilistic, SamplingPercentage: 100},
+	}
+
+	updated, apiErr := GenerateCollectorConfigWithSampling([]byte(testCollectorConf), rules)
+	require.Nil(t, apiErr)
+	c := parseConf(t, updated)
+
+	require.Equal(t, []interface{}{"signozspanmetrics/cumulative", "tail_sampling", "batch"}, tracesProcessors(c))
+
+	processor := c["processors"].(map[string]interface{})["tail_sampling"].(map[string]interface{})
+	policies := processor["policies"].([]interface{})
+	require.Len(t, policies, 3)
+	require.Equal(t, "status_code", policies[0].(map[string]interface{})["type"])
+
+	serviceRule := policies[1].(map[string]interface{})
+	require.Equal(t, "and", serviceRule["type"])
+	subPolicies := serviceRule["and"].(map[string]interface{})["and_sub_policy"].([]interface{})
+	require.Equal(t, "string_attribute", subPolicies[0].(map[string]interface{})["type"])
+	require.Equal(t, map[string]interface{}{"threshold_ms": 500}, subPolicies[1].(map[string]interface{})["latency"])
+
+	// applying the rules again keeps a single processor in the pipeline
+	updated, apiErr = GenerateCollectorConfigWithSampling(updated, rules)
+	require.Nil(t, apiErr)
+	require.Equal(t, []interface{}{"signozspanmetrics/cumulative", "tail_sampling", "batch"}, tracesProcessors(parseConf(t, updated)))
+
+	// the processor is removed when no rule is enabled
+	updated, apiErr = GenerateCollectorConfigWithSampling(updated, rules[3:])
+	require.Nil(t, apiErr)
+	c = parseConf(t, updated)
+	require.Equal(t, []interface{}{"signozspanmetrics/cumulative", "batch"}, tracesProcessors(c))
+	require.NotContains(t, c["processors"], "tail_sampling")
+}
+
+func TestGenerateCollectorConfigWithoutTraces(t *testing.T) {
+	conf := []byte("service:\n  pipelines:\n    logs:\n      receivers: [otlp]\n")
+	updated, apiErr := GenerateCollectorConfigWithSampling(conf, []Rule{{Name: "errors", Type: RuleTypeErrors, Enabled: true}})
+	require.Nil(t, apiErr)
+	require.Equal(t, conf, updated)
+}
+
+func TestPostableRuleValidate(t *testing.T) {
+	require.Nil(t, (&PostableRule{Name: "errors", Type: RuleTypeErrors}).Validate())
+	require.NotNil(t, (&PostableRule{Type: RuleTypeErrors}).Validate())
+	require.NotNil(t, (&PostableRule{Name: "slow", Type: RuleTypeLatency}).Validate())
+	require.NotNil(t, (&PostableRule{Name: "all", Type: RuleTypeProbabilistic, SamplingPercentage: 120}).Validate())
+	require.NotNil(t, (&PostableRule{Name: "unknown", Type: "rate_limiting"}).Validate())
+}
@@ -0,0 +1,142 @@
+package sampling
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"gopkg.in/yaml.v3"
+)
+
+// SamplingController takes care of the deployment cycle of the tail sampling rules,
+// every change of the rules starts a new config version which is pushed to the collectors
+type SamplingController struct {
+	Repo
+}
+
+func NewSamplingController(db *sqlx.DB, engine string) (*SamplingController, error) {
+	repo := NewRepo(db)
+	err := repo.InitDB(engine)
+	return &SamplingController{Repo: repo}, err
+}
+
+// ListRules responds with the rules along with the config version history
+func (sc *SamplingController) ListRules(ctx context.Context, limit int) (*RulesResponse, *model.ApiError) {
+	rules, apiErr := sc.getRules(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeSamplingRules)
+	if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+		return nil, model.WrapApiError(apiErr, "failed to get latest sampling rules version")
+	}
+	history, apiErr := agentConf.GetConfigHistory(ctx, agentConf.ElementTypeSamplingRules, limit)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to get sampling rules history")
+	}
+	return &RulesResponse{ConfigVersion: latest, Rules: rules, History: history}, nil
+}
+
+func (sc *SamplingController) CreateRule(ctx context.Context, postable *PostableRule) (*Rule, *model.ApiError) {
+	if err := postable.Validate(); err != nil {
+		return nil, model.BadRequest(errors.Wrap(err, "sampling rule is not valid"))
+	}
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	rule, apiErr := sc.insertRule(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := sc.deploy(ctx, userId); apiErr != nil {
+		return nil, apiErr
+	}
+	return rule, nil
+}
+
+func (sc *SamplingController) UpdateRule(ctx context.Context, id string, postable *PostableRule) (*Rule, *model.ApiError) {
+	if err := postable.Validate(); err != nil {
+		return nil, model.BadRequest(errors.Wrap(err, "sampling rule is not valid"))
+	}
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	if apiErr := sc.updateRule(ctx, userId, id, postable); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := sc.deploy(ctx, userId); apiErr != nil {
+		return nil, apiErr
+	}
+	return sc.GetRule(ctx, id)
+}
+
+func (sc *SamplingController) DeleteRule(ctx context.Context, id string) *model.ApiError {
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	if apiErr := sc.deleteRule(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	return sc.deploy(ctx, userId)
+}
+
+// deploy starts a new config version with the current rules
+func (sc *SamplingController) deploy(ctx context.Context, userId string) *model.ApiError {
+	rules, apiErr := sc.getRules(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+	elements := make([]string, len(rules))
+	for i, rule := range rules {
+		elements[i] = rule.Id
+	}
+
+	_, apiErr = agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeSamplingRules, elements)
+	return apiErr
+}
+
+// Implements agentConf.AgentFeature interface.
+func (sc *SamplingController) AgentFeatureType() agentConf.AgentFeatureType {
+	return SamplingFeatureType
+}
+
+// Implements agentConf.AgentFeature interface.
+func (sc *SamplingController) RecommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	// the collectors keep their own sampling until the rules are changed
+	if configVersion == nil {
+		return currentConfYaml, "", nil
+	}
+
+	rules, apiErr := sc.getRules(context.Background())
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+
+	updatedConf, apiErr := GenerateCollectorConfigWithSampling(currentConfYaml, rules)
+	if apiErr != nil {
+		return nil, "", model.WrapApiError(apiErr, "could not marshal yaml for updated conf")
+	}
+
+	processorConf, err := yaml.Marshal(ProcessorConfig(rules))
+	if err != nil {
+		return nil, "", model.BadRequest(errors.Wrap(err, "could not serialize tail sampling config"))
+	}
+	return updatedConf, string(processorConf), nil
+}
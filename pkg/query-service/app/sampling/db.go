@@ -0,0 +1,130 @@
+package sampling
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// Repo handles DDL and DML ops on sampling rules
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) Repo {
+	return Repo{
+		db: db,
+	}
+}
+
+func (r *Repo) InitDB(engine string) error {
+	switch engine {
+	case "sqlite3", "sqlite":
+	default:
+		return fmt.Errorf("unsupported db")
+	}
+
+	tableSchema := `CREATE TABLE IF NOT EXISTS sampling_rules(
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		type TEXT NOT NULL,
+		service_name TEXT NOT NULL DEFAULT '',
+		enabled BOOLEAN,
+		threshold_ms INTEGER NOT NULL DEFAULT 0,
+		sampling_percentage REAL NOT NULL DEFAULT 0,
+		created_by TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`
+	if _, err := r.db.Exec(tableSchema); err != nil {
+		return errors.Wrap(err, "Error in creating sampling rules table")
+	}
+	return nil
+}
+
+func (r *Repo) getRules(ctx context.Context) ([]Rule, *model.ApiError) {
+	rules := []Rule{}
+	err := r.db.SelectContext(ctx, &rules, `SELECT * FROM sampling_rules ORDER BY created_at`)
+	if err != nil {
+		zap.L().Error("failed to get sampling rules from db", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to get sampling rules from db"))
+	}
+	return rules, nil
+}
+
+func (r *Repo) GetRule(ctx context.Context, id string) (*Rule, *model.ApiError) {
+	var rule Rule
+	err := r.db.GetContext(ctx, &rule, `SELECT * FROM sampling_rules WHERE id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, model.NotFoundError(fmt.Errorf("no sampling rule found with id %s", id))
+	}
+	if err != nil {
+		zap.L().Error("failed to get sampling rule from db", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to get sampling rule from db"))
+	}
+	return &rule, nil
+}
+
+func (r *Repo) insertRule(ctx context.Context, userId string, postable *PostableRule) (*Rule, *model.ApiError) {
+	now := time.Now()
+	rule := &Rule{
+		Id:                 uuid.NewString(),
+		Name:               postable.Name,
+		Type:               postable.Type,
+		ServiceName:        postable.ServiceName,
+		Enabled:            postable.Enabled,
+		ThresholdMs:        postable.ThresholdMs,
+		SamplingPercentage: postable.SamplingPercentage,
+		CreatedBy:          userId,
+		CreatedAt:          now,
+		UpdatedBy:          userId,
+		UpdatedAt:          now,
+	}
+
+	_, err := r.db.ExecContext(ctx, `INSERT INTO sampling_rules
+		(id, name, type, service_name, enabled, threshold_ms, sampling_percentage, created_by, created_at, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		rule.Id, rule.Name, rule.Type, rule.ServiceName, rule.Enabled, rule.ThresholdMs, rule.SamplingPercentage,
+		rule.CreatedBy, rule.CreatedAt, rule.UpdatedBy, rule.UpdatedAt)
+	if err != nil {
+		zap.L().Error("error in inserting sampling rule", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to insert sampling rule"))
+	}
+	return rule, nil
+}
+
+func (r *Repo) updateRule(ctx context.Context, userId string, id string, postable *PostableRule) *model.ApiError {
+	result, err := r.db.ExecContext(ctx, `UPDATE sampling_rules SET
+		name = $1, type = $2, service_name = $3, enabled = $4, threshold_ms = $5, sampling_percentage = $6,
+		updated_by = $7, updated_at = $8 WHERE id = $9`,
+		postable.Name, postable.Type, postable.ServiceName, postable.Enabled, postable.ThresholdMs, postable.SamplingPercentage,
+		userId, time.Now(), id)
+	if err != nil {
+		zap.L().Error("error in updating sampling rule", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to update sampling rule"))
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return model.NotFoundError(fmt.Errorf("no sampling rule found with id %s", id))
+	}
+	return nil
+}
+
+func (r *Repo) deleteRule(ctx context.Context, id string) *model.ApiError {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sampling_rules WHERE id = $1`, id)
+	if err != nil {
+		zap.L().Error("error in deleting sampling rule", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to delete sampling rule"))
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return model.NotFoundError(fmt.Errorf("no sampling rule found with id %s", id))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package sampling
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+)
+
+const SamplingFeatureType = agentConf.AgentFeatureType(agentConf.ElementTypeSamplingRules)
+
+type RuleType string
+
+const (
+	// RuleTypeErrors samples the traces with an error span
+	RuleTypeErrors RuleType = "errors"
+	// RuleTypeLatency samples the traces slower than the threshold
+	RuleTypeLatency RuleType = "latency"
+	// RuleTypeProbabilistic samples a percentage of the traces
+	RuleTypeProbabilistic RuleType = "probabilistic"
+)
+
+// PostableRule is a tail sampling rule as received from the API, the rule
+// applies to the traces of the service when the service name is not empty
+type PostableRule struct {
+	Name               string   `json:"name"`
+	Type               RuleType `json:"type"`
+	ServiceName        string   `json:"serviceName"`
+	Enabled            bool     `json:"enabled"`
+	ThresholdMs        int64    `json:"thresholdMs,omitempty"`
+	SamplingPercentage float64  `json:"samplingPercentage,omitempty"`
+}
+
+// Rule is a stored tail sampling rule
+type Rule struct {
+	Id                 string    `json:"id" db:"id"`
+	Name               string    `json:"name" db:"name"`
+	Type               RuleType  `json:"type" db:"type"`
+	ServiceName        string    `json:"serviceName" db:"service_name"`
+	Enabled            bool      `json:"enabled" db:"enabled"`
+	ThresholdMs        int64     `json:"thresholdMs,omitempty" db:"threshold_ms"`
+	SamplingPercentage float64   `json:"samplingPercentage,omitempty" db:"sampling_percentage"`
+	CreatedBy          string    `json:"createdBy" db:"created_by"`
+	CreatedAt          time.Time `json:"createdAt" db:"created_at"`
+	UpdatedBy          string    `json:"updatedBy" db:"updated_by"`
+	UpdatedAt          time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// RulesResponse is used to prepare the http response of the sampling rules requests
+type RulesResponse struct {
+	*agentConf.ConfigVersion
+
+	Rules   []Rule                    `json:"rules"`
+	History []agentConf.ConfigVersion `json:"history"`
+}
+
+func (r *PostableRule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	switch r.Type {
+	case RuleTypeErrors:
+	case RuleTypeLatency:
+		if r.ThresholdMs <= 0 {
+			return fmt.Errorf("latency threshold must be positive")
+		}
+	case RuleTypeProbabilistic:
+		if r.SamplingPercentage <= 0 || r.SamplingPercentage > 100 {
+			return fmt.Errorf("sampling percentage must be between 0 and 100")
+		}
+	default:
+		return fmt.Errorf("unsupported rule type: %s", r.Type)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/sampling"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// samplingHistoryLimit is the number of config versions listed with the rules
+const samplingHistoryLimit = 10
+
+func (aH *APIHandler) listSamplingRules(w http.ResponseWriter, r *http.Request) {
+	payload, apiErr := aH.SamplingController.ListRules(r.Context(), samplingHistoryLimit)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, payload)
+}
+
+func (aH *APIHandler) getSamplingRule(w http.ResponseWriter, r *http.Request) {
+	rule, apiErr := aH.SamplingController.GetRule(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, rule)
+}
+
+func (aH *APIHandler) createSamplingRule(w http.ResponseWriter, r *http.Request) {
+	var postable sampling.PostableRule
+	if err := json.NewDecoder(r.Body).Decode(&postable); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	rule, apiErr := aH.SamplingController.CreateRule(r.Context(), &postable)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, rule)
+}
+
+func (aH *APIHandler) updateSamplingRule(w http.ResponseWriter, r *http.Request) {
+	var postable sampling.PostableRule
+	if err := json.NewDecoder(r.Body).Decode(&postable); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	rule, apiErr := aH.SamplingController.UpdateRule(r.Context(), mux.Vars(r)["id"], &postable)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, rule)
+}
+
+func (aH *APIHandler) deleteSamplingRule(w http.ResponseWriter, r *http.Request) {
+	if apiErr := aH.SamplingController.DeleteRule(r.Context(), mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.signoz.io/signoz/pkg/query-service/migrate/schema"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+type migrateRequest struct {
+	Database schema.Database `json:"database"`
+	// Version is the version rolled back to, the migrations after it are rolled back
+	Version int  `json:"version"`
+	DryRun  bool `json:"dryRun"`
+}
+
+// getSchemaMigrations lists the migrations of the sqlite and clickhouse schemas with whether they were applied
+func (aH *APIHandler) getSchemaMigrations(w http.ResponseWriter, r *http.Request) {
+	statuses, err := aH.SchemaMigrator.Status(r.Context())
+	if err != nil {
+		RespondError(w, model.InternalError(err), nil)
+		return
+	}
+	aH.Respond(w, statuses)
+}
+
+// applySchemaMigrations applies the pending migrations of a database, the dry run returns
+// the statements without running them
+func (aH *APIHandler) applySchemaMigrations(w http.ResponseWriter, r *http.Request) {
+	var req migrateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if err := req.Database.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	steps, err := aH.SchemaMigrator.Up(r.Context(), req.Database, req.DryRun)
+	if err != nil {
+		RespondError(w, model.InternalError(err), steps)
+		return
+	}
+	aH.Respond(w, steps)
+}
+
+// rollbackSchemaMigrations rolls back the migrations of a database applied after the version
+func (aH *APIHandler) rollbackSchemaMigrations(w http.ResponseWriter, r *http.Request) {
+	var req migrateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if err := req.Database.Validate(); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	steps, err := aH.SchemaMigrator.Down(r.Context(), req.Database, req.Version, req.DryRun)
+	if err != nil {
+		RespondError(w, model.InternalError(err), steps)
+		return
+	}
+	aH.Respond(w, steps)
+}
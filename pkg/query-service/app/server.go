@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
@@ -21,12 +22,37 @@ import (
 	"github.com/rs/cors"
 	"github.com/soheilhy/cmux"
 	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/app/access"
+	"go.signoz.io/signoz/pkg/query-service/app/alerthistory"
+	"go.signoz.io/signoz/pkg/query-service/app/attributemetadata"
+	"go.signoz.io/signoz/pkg/query-service/app/audit"
+	"go.signoz.io/signoz/pkg/query-service/app/backup"
+	"go.signoz.io/signoz/pkg/query-service/app/cardinality"
 	"go.signoz.io/signoz/pkg/query-service/app/clickhouseReader"
 	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/app/deployments"
+	"go.signoz.io/signoz/pkg/query-service/app/errortracking"
+	"go.signoz.io/signoz/pkg/query-service/app/events"
+	"go.signoz.io/signoz/pkg/query-service/app/heartbeats"
 	"go.signoz.io/signoz/pkg/query-service/app/integrations"
+	"go.signoz.io/signoz/pkg/query-service/app/issues"
+	"go.signoz.io/signoz/pkg/query-service/app/logexports"
+	"go.signoz.io/signoz/pkg/query-service/app/logmetrics"
 	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
+	"go.signoz.io/signoz/pkg/query-service/app/logviews"
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/remotewrite"
+	"go.signoz.io/signoz/pkg/query-service/app/metrics/rollup"
 	"go.signoz.io/signoz/pkg/query-service/app/opamp"
 	opAmpModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/app/profiles"
+	"go.signoz.io/signoz/pkg/query-service/app/queryhistory"
+	"go.signoz.io/signoz/pkg/query-service/app/reports"
+	"go.signoz.io/signoz/pkg/query-service/app/sampling"
+	"go.signoz.io/signoz/pkg/query-service/app/share"
+	"go.signoz.io/signoz/pkg/query-service/app/skipindexes"
+	"go.signoz.io/signoz/pkg/query-service/app/spanmetrics"
+	"go.signoz.io/signoz/pkg/query-service/app/synthetics"
+	"go.signoz.io/signoz/pkg/query-service/app/webhooks"
 	"go.signoz.io/signoz/pkg/query-service/common"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 
@@ -39,6 +65,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/healthcheck"
 	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/migrate/schema"
 	"go.signoz.io/signoz/pkg/query-service/model"
 	pqle "go.signoz.io/signoz/pkg/query-service/pqlEngine"
 	"go.signoz.io/signoz/pkg/query-service/rules"
@@ -56,18 +83,38 @@ type ServerOptions struct {
 	DisableRules      bool
 	RuleRepoURL       string
 	PreferSpanMetrics bool
+	OTLPReceiver      bool
 	MaxIdleConns      int
 	MaxOpenConns      int
 	DialTimeout       time.Duration
 	CacheConfigPath   string
-	FluxInterval      string
-	Cluster           string
+	// ClickHouseSettingsPath is the config of the clickhouse connection pool and of the
+	// settings profiles of the interactive, alerts and export queries
+	ClickHouseSettingsPath string
+	FluxInterval           string
+	Cluster                string
 }
 
 // Server runs HTTP, Mux and a grpc server
 type Server struct {
-	serverOptions *ServerOptions
-	ruleManager   *rules.Manager
+	serverOptions  *ServerOptions
+	ruleManager    *rules.Manager
+	reportManager  *reports.Manager
+	exportManager  *logexports.Manager
+	viewManager    *logviews.Manager
+	historyManager *queryhistory.Manager
+	auditManager   *audit.Manager
+	webhookManager *webhooks.Manager
+	issueManager   *issues.Manager
+	errorTracking  *errortracking.Manager
+	logMetrics     *logmetrics.Manager
+	attrMetadata   *attributemetadata.Manager
+	deployments    *deployments.Manager
+	rollupManager  *rollup.Manager
+	alertHistory   *alerthistory.Manager
+	schemaMigrator *schema.Migrator
+
+	cardinalityController *cardinality.CardinalityController
 
 	// public http router
 	httpConn   net.Listener
@@ -103,6 +150,14 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 
 	localDB.SetMaxOpenConns(10)
 
+	if err := share.InitWithDB(localDB); err != nil {
+		return nil, err
+	}
+
+	if err := access.InitWithDB(localDB); err != nil {
+		return nil, err
+	}
+
 	// initiate feature manager
 	fm := featureManager.StartManager()
 
@@ -112,6 +167,13 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 	storage := os.Getenv("STORAGE")
 	if storage == "clickhouse" {
 		zap.L().Info("Using ClickHouse as datastore ...")
+		var querySettings *clickhouseReader.QuerySettingsConfig
+		if serverOptions.ClickHouseSettingsPath != "" {
+			querySettings, err = clickhouseReader.LoadQuerySettingsConfig(serverOptions.ClickHouseSettingsPath)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't load the clickhouse settings config: %w", err)
+			}
+		}
 		clickhouseReader := clickhouseReader.NewReader(
 			localDB,
 			serverOptions.PromConfigPath,
@@ -120,6 +182,7 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 			serverOptions.MaxOpenConns,
 			serverOptions.DialTimeout,
 			serverOptions.Cluster,
+			querySettings,
 		)
 		go clickhouseReader.Start(readerReady)
 		reader = clickhouseReader
@@ -160,6 +223,142 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		return nil, fmt.Errorf("couldn't create integrations controller: %w", err)
 	}
 
+	reportManager, err := reports.NewManager(reports.ManagerOptions{
+		DB:          localDB,
+		FrontendURL: serverOptions.RuleRepoURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create report manager: %w", err)
+	}
+
+	exportManager, err := logexports.NewManager(logexports.ManagerOptions{
+		DB:   localDB,
+		Conn: reader.GetConn(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create log export manager: %w", err)
+	}
+
+	viewManager, err := logviews.NewManager(logviews.ManagerOptions{
+		DB:      localDB,
+		Conn:    reader.GetConn(),
+		Cluster: serverOptions.Cluster,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create logs view manager: %w", err)
+	}
+
+	historyManager, err := queryhistory.NewManager(queryhistory.ManagerOptions{
+		DB:                 localDB,
+		SlowQueryThreshold: constants.SlowQueryThreshold,
+		Retention:          time.Duration(constants.QueryHistoryRetentionDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create query history manager: %w", err)
+	}
+
+	skipIndexManager, err := skipindexes.NewManager(skipindexes.ManagerOptions{
+		DB:           localDB,
+		Conn:         reader.GetConn(),
+		Cluster:      serverOptions.Cluster,
+		QueryHistory: historyManager,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create skip index manager: %w", err)
+	}
+
+	auditManager, err := audit.NewManager(audit.ManagerOptions{
+		DB:        localDB,
+		Retention: time.Duration(constants.AuditLogRetentionDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create audit manager: %w", err)
+	}
+
+	webhookManager, err := webhooks.NewManager(webhooks.ManagerOptions{
+		DB:        localDB,
+		Retention: time.Duration(constants.WebhookDeliveryRetentionDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create webhook manager: %w", err)
+	}
+	rm.AddNotifyListener(webhookManager.NotifyAlerts)
+
+	issueManager, err := issues.NewManager(issues.ManagerOptions{
+		DB:          localDB,
+		FrontendURL: serverOptions.RuleRepoURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create issue manager: %w", err)
+	}
+	rm.AddNotifyListener(issueManager.NotifyAlerts)
+
+	errorTracking, err := errortracking.NewManager(errortracking.ManagerOptions{
+		DB:     localDB,
+		Reader: reader,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create error tracking manager: %w", err)
+	}
+
+	logMetrics, err := logmetrics.NewManager(logmetrics.ManagerOptions{
+		DB:     localDB,
+		Reader: reader,
+		Writer: remotewrite.NewWriter(reader.GetConn()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create log metrics manager: %w", err)
+	}
+
+	deploymentManager, err := deployments.NewManager(deployments.ManagerOptions{
+		DB:     localDB,
+		Reader: reader,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create deployment manager: %w", err)
+	}
+	rm.AddAnnotator(deploymentManager.AnnotateAlerts)
+
+	eventManager := events.NewManager(events.ManagerOptions{
+		Conn: reader.GetConn(),
+	})
+	rm.AddAnnotator(eventManager.AnnotateAlerts)
+
+	attributeMetadata, err := attributemetadata.NewManager(attributemetadata.ManagerOptions{
+		DB:   localDB,
+		Conn: reader.GetConn(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create attribute metadata manager: %w", err)
+	}
+
+	rollupManager := rollup.NewManager(rollup.ManagerOptions{
+		Conn:    reader.GetConn(),
+		Cluster: serverOptions.Cluster,
+	})
+
+	profileManager := profiles.NewManager(profiles.ManagerOptions{
+		Conn: reader.GetConn(),
+	})
+
+	alertHistoryManager := alerthistory.NewManager(alerthistory.ManagerOptions{
+		Conn: reader.GetConn(),
+	})
+	rm.AddStateListener(alertHistoryManager.Record)
+
+	am.SetOnCallLookup(access.TeamOnCallEmails)
+
+	backupManager := backup.NewManager(backup.ManagerOptions{DB: localDB})
+
+	schemaMigrator, err := schema.NewMigrator(schema.Options{
+		DB:      localDB,
+		Conn:    reader.GetConn(),
+		Cluster: serverOptions.Cluster,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create schema migrator: %w", err)
+	}
+
 	logParsingPipelineController, err := logparsingpipeline.NewLogParsingPipelinesController(
 		localDB, "sqlite", integrationsController.GetPipelinesForInstalledIntegrations,
 	)
@@ -167,11 +366,37 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		return nil, err
 	}
 
+	spanMetricsController, err := spanmetrics.NewSpanMetricsController(localDB, "sqlite")
+	if err != nil {
+		return nil, err
+	}
+
+	samplingController, err := sampling.NewSamplingController(localDB, "sqlite")
+	if err != nil {
+		return nil, err
+	}
+
+	cardinalityController, err := cardinality.NewCardinalityController(localDB, "sqlite", reader)
+	if err != nil {
+		return nil, err
+	}
+
+	syntheticsController, err := synthetics.NewSyntheticsController(localDB, "sqlite")
+	if err != nil {
+		return nil, err
+	}
+
+	heartbeatRepo, err := heartbeats.NewRepo(localDB)
+	if err != nil {
+		return nil, err
+	}
+
 	telemetry.GetInstance().SetReader(reader)
 	apiHandler, err := NewAPIHandler(APIHandlerOpts{
 		Reader:                        reader,
 		SkipConfig:                    skipConfig,
 		PreferSpanMetrics:             serverOptions.PreferSpanMetrics,
+		OTLPReceiver:                  serverOptions.OTLPReceiver,
 		MaxIdleConns:                  serverOptions.MaxIdleConns,
 		MaxOpenConns:                  serverOptions.MaxOpenConns,
 		DialTimeout:                   serverOptions.DialTimeout,
@@ -179,7 +404,29 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		RuleManager:                   rm,
 		FeatureFlags:                  fm,
 		IntegrationsController:        integrationsController,
+		ReportManager:                 reportManager,
+		LogExportManager:              exportManager,
+		LogsViewManager:               viewManager,
+		SkipIndexManager:              skipIndexManager,
+		QueryHistoryManager:           historyManager,
+		AuditManager:                  auditManager,
+		WebhookManager:                webhookManager,
+		IssueManager:                  issueManager,
+		ErrorTrackingManager:          errorTracking,
+		LogMetricsManager:             logMetrics,
+		DeploymentManager:             deploymentManager,
+		ProfileManager:                profileManager,
+		EventManager:                  eventManager,
+		AttributeMetadataManager:      attributeMetadata,
+		AlertHistoryManager:           alertHistoryManager,
+		SchemaMigrator:                schemaMigrator,
+		BackupManager:                 backupManager,
 		LogsParsingPipelineController: logParsingPipelineController,
+		SpanMetricsController:         spanMetricsController,
+		SamplingController:            samplingController,
+		CardinalityController:         cardinalityController,
+		SyntheticsController:          syntheticsController,
+		HeartbeatRepo:                 heartbeatRepo,
 		Cache:                         c,
 		FluxInterval:                  fluxInterval,
 	})
@@ -190,9 +437,24 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 	s := &Server{
 		// logger: logger,
 		// tracer: tracer,
-		ruleManager:        rm,
-		serverOptions:      serverOptions,
-		unavailableChannel: make(chan healthcheck.Status),
+		ruleManager:           rm,
+		reportManager:         reportManager,
+		exportManager:         exportManager,
+		viewManager:           viewManager,
+		historyManager:        historyManager,
+		auditManager:          auditManager,
+		webhookManager:        webhookManager,
+		issueManager:          issueManager,
+		errorTracking:         errorTracking,
+		logMetrics:            logMetrics,
+		attrMetadata:          attributeMetadata,
+		deployments:           deploymentManager,
+		rollupManager:         rollupManager,
+		alertHistory:          alertHistoryManager,
+		schemaMigrator:        schemaMigrator,
+		cardinalityController: cardinalityController,
+		serverOptions:         serverOptions,
+		unavailableChannel:    make(chan healthcheck.Status),
 	}
 
 	httpServer, err := s.createPublicServer(apiHandler)
@@ -220,6 +482,10 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		DBEngine: "sqlite",
 		AgentFeatures: []agentConf.AgentFeature{
 			logParsingPipelineController,
+			spanMetricsController,
+			samplingController,
+			cardinalityController,
+			syntheticsController,
 		},
 	})
 	if err != nil {
@@ -264,9 +530,11 @@ func (s *Server) createPublicServer(api *APIHandler) (*http.Server, error) {
 	r := NewRouter()
 
 	r.Use(LogCommentEnricher)
+	r.Use(QueryIdMiddleware)
 	r.Use(setTimeoutMiddleware)
 	r.Use(s.analyticsMiddleware)
 	r.Use(loggingMiddleware)
+	r.Use(s.auditManager.Middleware(auth.GetUserFromRequest))
 
 	am := NewAuthMiddleware(auth.GetUserFromRequest)
 
@@ -275,11 +543,13 @@ func (s *Server) createPublicServer(api *APIHandler) (*http.Server, error) {
 	api.RegisterIntegrationRoutes(r, am)
 	api.RegisterQueryRangeV3Routes(r, am)
 	api.RegisterQueryRangeV4Routes(r, am)
+	api.RegisterV5Routes(r, am)
 
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
 		AllowedMethods: []string{"GET", "DELETE", "POST", "PUT", "PATCH", "OPTIONS"},
-		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "cache-control"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "cache-control", common.QueryIdHeader},
+		ExposedHeaders: []string{common.QueryIdHeader},
 	})
 
 	handler := c.Handler(r)
@@ -361,6 +631,21 @@ func LogCommentEnricher(next http.Handler) http.Handler {
 	})
 }
 
+// QueryIdMiddleware tags the clickhouse queries of the request with the query id set by the
+// client, or a generated one, so they can be canceled with the id echoed in the response
+func QueryIdMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queryId := r.Header.Get(common.QueryIdHeader)
+		if queryId == "" || len(queryId) > 64 {
+			queryId = uuid.NewString()
+		}
+		w.Header().Set(common.QueryIdHeader, queryId)
+
+		r = r.WithContext(context.WithValue(r.Context(), common.QueryIdKey, queryId))
+		next.ServeHTTP(w, r)
+	})
+}
+
 // loggingMiddlewarePrivate is used for logging private api calls
 // from internal services like alert manager
 func loggingMiddlewarePrivate(next http.Handler) http.Handler {
@@ -572,6 +857,63 @@ func (s *Server) Start() error {
 		zap.L().Info("msg: Rules disabled as rules.disable is set to TRUE")
 	}
 
+	if err := s.reportManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.exportManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.viewManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.historyManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.auditManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.webhookManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.issueManager.Start(); err != nil {
+		return err
+	}
+
+	if err := s.errorTracking.Start(); err != nil {
+		return err
+	}
+
+	if err := s.logMetrics.Start(); err != nil {
+		return err
+	}
+
+	if err := s.attrMetadata.Start(); err != nil {
+		return err
+	}
+
+	if err := s.deployments.Start(); err != nil {
+		return err
+	}
+
+	s.cardinalityController.Start()
+
+	// the raw samples are read until the rollups are available
+	if err := s.rollupManager.Start(); err != nil {
+		zap.L().Error("failed to start metric rollups", zap.Error(err))
+	}
+
+	// the tables the query service owns in clickhouse, like the profiles tables
+	if _, err := s.schemaMigrator.Up(context.Background(), schema.DatabaseClickHouse, false); err != nil {
+		zap.L().Error("failed to migrate the clickhouse schema", zap.Error(err))
+	}
+	s.alertHistory.Start()
+
 	err := s.initListeners()
 	if err != nil {
 		return err
@@ -654,6 +996,62 @@ func (s *Server) Stop() error {
 		s.ruleManager.Stop()
 	}
 
+	if s.reportManager != nil {
+		s.reportManager.Stop()
+	}
+
+	if s.exportManager != nil {
+		s.exportManager.Stop()
+	}
+
+	if s.viewManager != nil {
+		s.viewManager.Stop()
+	}
+
+	if s.historyManager != nil {
+		s.historyManager.Stop()
+	}
+
+	if s.auditManager != nil {
+		s.auditManager.Stop()
+	}
+
+	if s.webhookManager != nil {
+		s.webhookManager.Stop()
+	}
+
+	if s.issueManager != nil {
+		s.issueManager.Stop()
+	}
+
+	if s.errorTracking != nil {
+		s.errorTracking.Stop()
+	}
+
+	if s.logMetrics != nil {
+		s.logMetrics.Stop()
+	}
+
+	if s.attrMetadata != nil {
+		s.attrMetadata.Stop()
+	}
+
+	if s.deployments != nil {
+		s.deployments.Stop()
+	}
+
+	if s.cardinalityController != nil {
+		s.cardinalityController.Stop()
+	}
+
+	if s.rollupManager != nil {
+		s.rollupManager.Stop()
+	}
+
+	if s.alertHistory != nil {
+		s.alertHistory.Stop()
+	}
+
 	return nil
 }
 
@@ -14,45 +14,61 @@ var (
 		"k8s_cluster_name":       {},
 		"k8s_namespace_name":     {},
 	}
+	// aliases are the short names of the columns the tags can filter on
+	aliases = map[string]string{
+		"environment": "deployment_environment",
+		"cluster":     "k8s_cluster_name",
+		"namespace":   "k8s_namespace_name",
+	}
 )
 
+// MaxDepth is the number of hops from the focal service past which the services are not
+// in its neighborhood
+const MaxDepth = 5
+
 func BuildServiceMapQuery(tags []model.TagQuery) (string, []interface{}) {
 	var filterQuery string
 	var namedArgs []interface{}
-	for _, tag := range tags {
+	for i, tag := range tags {
 		key := strings.ReplaceAll(tag.GetKey(), ".", "_")
+		if column, ok := aliases[key]; ok {
+			key = column
+		}
 		operator := tag.GetOperator()
 		value := tag.GetValues()
 
 		if _, ok := columns[key]; !ok {
 			continue
 		}
+		// the args are named after the position of the tag so a column can be filtered more
+		// than once, e.g. with In and NotIn
+		arg := fmt.Sprintf("%s_%d", key, i)
 
 		switch operator {
 		case model.InOperator:
-			filterQuery += fmt.Sprintf(" AND %s IN @%s", key, key)
-			namedArgs = append(namedArgs, clickhouse.Named(key, value))
+			filterQuery += fmt.Sprintf(" AND %s IN @%s", key, arg)
+			namedArgs = append(namedArgs, clickhouse.Named(arg, value))
 		case model.NotInOperator:
-			filterQuery += fmt.Sprintf(" AND %s NOT IN @%s", key, key)
-			namedArgs = append(namedArgs, clickhouse.Named(key, value))
+			filterQuery += fmt.Sprintf(" AND %s NOT IN @%s", key, arg)
+			namedArgs = append(namedArgs, clickhouse.Named(arg, value))
 		case model.EqualOperator:
-			filterQuery += fmt.Sprintf(" AND %s = @%s", key, key)
-			namedArgs = append(namedArgs, clickhouse.Named(key, value))
+			filterQuery += fmt.Sprintf(" AND %s = @%s", key, arg)
+			namedArgs = append(namedArgs, clickhouse.Named(arg, value))
 		case model.NotEqualOperator:
-			filterQuery += fmt.Sprintf(" AND %s != @%s", key, key)
-			namedArgs = append(namedArgs, clickhouse.Named(key, value))
+			filterQuery += fmt.Sprintf(" AND %s != @%s", key, arg)
+			namedArgs = append(namedArgs, clickhouse.Named(arg, value))
 		case model.ContainsOperator:
-			filterQuery += fmt.Sprintf(" AND %s LIKE @%s", key, key)
-			namedArgs = append(namedArgs, clickhouse.Named(key, fmt.Sprintf("%%%s%%", value)))
+			filterQuery += fmt.Sprintf(" AND %s LIKE @%s", key, arg)
+			namedArgs = append(namedArgs, clickhouse.Named(arg, fmt.Sprintf("%%%s%%", value)))
 		case model.NotContainsOperator:
-			filterQuery += fmt.Sprintf(" AND %s NOT LIKE @%s", key, key)
-			namedArgs = append(namedArgs, clickhouse.Named(key, fmt.Sprintf("%%%s%%", value)))
+			filterQuery += fmt.Sprintf(" AND %s NOT LIKE @%s", key, arg)
+			namedArgs = append(namedArgs, clickhouse.Named(arg, fmt.Sprintf("%%%s%%", value)))
 		case model.StartsWithOperator:
-			filterQuery += fmt.Sprintf(" AND %s LIKE @%s", key, key)
-			namedArgs = append(namedArgs, clickhouse.Named(key, fmt.Sprintf("%s%%", value)))
+			filterQuery += fmt.Sprintf(" AND %s LIKE @%s", key, arg)
+			namedArgs = append(namedArgs, clickhouse.Named(arg, fmt.Sprintf("%s%%", value)))
 		case model.NotStartsWithOperator:
-			filterQuery += fmt.Sprintf(" AND %s NOT LIKE @%s", key, key)
-			namedArgs = append(namedArgs, clickhouse.Named(key, fmt.Sprintf("%s%%", value)))
+			filterQuery += fmt.Sprintf(" AND %s NOT LIKE @%s", key, arg)
+			namedArgs = append(namedArgs, clickhouse.Named(arg, fmt.Sprintf("%s%%", value)))
 		case model.ExistsOperator:
 			filterQuery += fmt.Sprintf(" AND %s IS NOT NULL", key)
 		case model.NotExistsOperator:
@@ -61,3 +77,77 @@ func BuildServiceMapQuery(tags []model.TagQuery) (string, []interface{}) {
 	}
 	return filterQuery, namedArgs
 }
+
+// Neighborhood returns the edges between the services up to depth hops from the service, the
+// hops follow the edges in both directions
+func Neighborhood(edges []model.ServiceMapDependencyResponseItem, service string, depth int) []model.ServiceMapDependencyResponseItem {
+	neighbors := map[string][]string{}
+	for _, edge := range edges {
+		neighbors[edge.Parent] = append(neighbors[edge.Parent], edge.Child)
+		neighbors[edge.Child] = append(neighbors[edge.Child], edge.Parent)
+	}
+
+	hops := map[string]int{service: 0}
+	frontier := []string{service}
+	for hop := 1; hop <= depth && len(frontier) > 0; hop++ {
+		next := []string{}
+		for _, s := range frontier {
+			for _, n := range neighbors[s] {
+				if _, ok := hops[n]; !ok {
+					hops[n] = hop
+					next = append(next, n)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	result := []model.ServiceMapDependencyResponseItem{}
+	for _, edge := range edges {
+		_, parent := hops[edge.Parent]
+		_, child := hops[edge.Child]
+		if parent && child {
+			result = append(result, edge)
+		}
+	}
+	return result
+}
+
+// Compare returns the edges of the current and of the previous ranges with the changes of
+// their RED metrics, in the order of the current edges followed by the removed edges
+func Compare(current, previous []model.ServiceMapDependencyResponseItem) []model.ServiceMapEdgeComparison {
+	type edgeKey struct{ parent, child string }
+	previousEdges := map[edgeKey]*model.ServiceMapDependencyResponseItem{}
+	for i := range previous {
+		previousEdges[edgeKey{previous[i].Parent, previous[i].Child}] = &previous[i]
+	}
+
+	result := []model.ServiceMapEdgeComparison{}
+	seen := map[edgeKey]struct{}{}
+	for i := range current {
+		edge := &current[i]
+		key := edgeKey{edge.Parent, edge.Child}
+		seen[key] = struct{}{}
+		comparison := model.ServiceMapEdgeComparison{Parent: edge.Parent, Child: edge.Child, Current: edge}
+		if prev, ok := previousEdges[key]; ok {
+			comparison.Status = model.EdgeExisting
+			comparison.Previous = prev
+			comparison.CallRateChange = edge.CallRate - prev.CallRate
+			comparison.ErrorRateChange = edge.ErrorRate - prev.ErrorRate
+			comparison.P99Change = edge.P99 - prev.P99
+		} else {
+			comparison.Status = model.EdgeAdded
+		}
+		result = append(result, comparison)
+	}
+	for i := range previous {
+		edge := &previous[i]
+		if _, ok := seen[edgeKey{edge.Parent, edge.Child}]; ok {
+			continue
+		}
+		result = append(result, model.ServiceMapEdgeComparison{
+			Parent: edge.Parent, Child: edge.Child, Status: model.EdgeRemoved, Previous: edge,
+		})
+	}
+	return result
+}
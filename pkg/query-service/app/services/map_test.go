@@ -0,0 +1,80 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func edge(parent, child string, callRate, errorRate, p99 float64) model.ServiceMapDependencyResponseItem {
+	return model.ServiceMapDependencyResponseItem{Parent: parent, Child: child, CallRate: callRate, ErrorRate: errorRate, P99: p99}
+}
+
+func edgeNames(edges []model.ServiceMapDependencyResponseItem) []string {
+	names := []string{}
+	for _, e := range edges {
+		names = append(names, e.Parent+"->"+e.Child)
+	}
+	return names
+}
+
+func TestNeighborhood(t *testing.T) {
+	// frontend -> checkout -> payment -> bank, checkout -> cart -> redis, admin -> cart
+	edges := []model.ServiceMapDependencyResponseItem{
+		edge("frontend", "checkout", 0, 0, 0),
+		edge("checkout", "payment", 0, 0, 0),
+		edge("payment", "bank", 0, 0, 0),
+		edge("checkout", "cart", 0, 0, 0),
+		edge("cart", "redis", 0, 0, 0),
+		edge("admin", "cart", 0, 0, 0),
+	}
+
+	require.Equal(t, []string{"frontend->checkout", "checkout->payment", "checkout->cart"}, edgeNames(Neighborhood(edges, "checkout", 1)))
+	require.Equal(t, []string{"checkout->cart", "cart->redis", "admin->cart"}, edgeNames(Neighborhood(edges, "cart", 1)))
+	require.Equal(t, []string{"frontend->checkout", "checkout->payment", "checkout->cart"}, edgeNames(Neighborhood(edges, "frontend", 2)))
+	require.Len(t, Neighborhood(edges, "frontend", MaxDepth), len(edges))
+	require.Empty(t, Neighborhood(edges, "unknown", 2))
+}
+
+func TestCompare(t *testing.T) {
+	current := []model.ServiceMapDependencyResponseItem{
+		edge("frontend", "checkout", 10, 1, 200),
+		edge("checkout", "payment", 4, 0, 100),
+	}
+	previous := []model.ServiceMapDependencyResponseItem{
+		edge("frontend", "checkout", 8, 3, 150),
+		edge("checkout", "cart", 2, 0, 50),
+	}
+
+	comparisons := Compare(current, previous)
+	require.Len(t, comparisons, 3)
+
+	require.Equal(t, model.EdgeExisting, comparisons[0].Status)
+	require.Equal(t, 2.0, comparisons[0].CallRateChange)
+	require.Equal(t, -2.0, comparisons[0].ErrorRateChange)
+	require.Equal(t, 50.0, comparisons[0].P99Change)
+
+	require.Equal(t, model.EdgeAdded, comparisons[1].Status)
+	require.Nil(t, comparisons[1].Previous)
+	require.Zero(t, comparisons[1].CallRateChange)
+
+	require.Equal(t, model.EdgeRemoved, comparisons[2].Status)
+	require.Equal(t, "cart", comparisons[2].Child)
+	require.Nil(t, comparisons[2].Current)
+}
+
+func TestBuildServiceMapQuery(t *testing.T) {
+	tags := []model.TagQuery{
+		model.NewTagQueryString(model.TagQueryParam{Key: "environment", StringValues: []string{"prod"}, Operator: model.InOperator}),
+		model.NewTagQueryString(model.TagQueryParam{Key: "deployment.environment", StringValues: []string{"prod-eu"}, Operator: model.NotInOperator}),
+		model.NewTagQueryString(model.TagQueryParam{Key: "namespace", StringValues: []string{"shop"}, Operator: model.InOperator}),
+		model.NewTagQueryString(model.TagQueryParam{Key: "http.method", StringValues: []string{"GET"}, Operator: model.InOperator}),
+	}
+
+	query, args := BuildServiceMapQuery(tags)
+	require.Equal(t, " AND deployment_environment IN @deployment_environment_0"+
+		" AND deployment_environment NOT IN @deployment_environment_1"+
+		" AND k8s_namespace_name IN @k8s_namespace_name_2", query)
+	require.Len(t, args, 3)
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// listSessions lists the active sessions of the user, the session of the request is marked as current
+func (aH *APIHandler) listSessions(w http.ResponseWriter, r *http.Request) {
+	if apiErr := checkUserAccount(r, mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	sessions, apiErr := dao.DB().GetUserSessions(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	current := auth.ExtractSessionIdFromRequest(r)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].Id == current
+	}
+	aH.Respond(w, sessions)
+}
+
+// revokeSessions logs the user out of all its sessions, the admins can force the logout of the users
+func (aH *APIHandler) revokeSessions(w http.ResponseWriter, r *http.Request) {
+	if apiErr := checkUserAccount(r, mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if apiErr := dao.DB().DeleteUserSessions(r.Context(), mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// revokeSession logs the user out of one of its sessions
+func (aH *APIHandler) revokeSession(w http.ResponseWriter, r *http.Request) {
+	userId, sessionId := mux.Vars(r)["id"], mux.Vars(r)["sessionId"]
+	if apiErr := checkUserAccount(r, userId); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	session, apiErr := dao.DB().GetSession(r.Context(), sessionId)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if session == nil || session.UserId != userId {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no session found with id: %s", sessionId)}, nil)
+		return
+	}
+
+	if apiErr := dao.DB().DeleteSession(r.Context(), sessionId); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
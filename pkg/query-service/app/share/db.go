@@ -0,0 +1,241 @@
+package share
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+var db *sqlx.DB
+
+// InitWithDB sets the db of the share links and creates their table
+func InitWithDB(sqlDB *sqlx.DB) error {
+	db = sqlDB
+
+	tableSchema := `CREATE TABLE IF NOT EXISTS dashboard_share_links (
+		id TEXT PRIMARY KEY,
+		dashboard_id TEXT NOT NULL,
+		start_time INTEGER NOT NULL DEFAULT 0,
+		end_time INTEGER NOT NULL DEFAULT 0,
+		relative_time INTEGER NOT NULL DEFAULT 0,
+		expires_at datetime NOT NULL,
+		revoked_at datetime,
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL
+	);`
+
+	_, err := db.Exec(tableSchema)
+	if err != nil {
+		return fmt.Errorf("error in creating dashboard_share_links table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS dashboard_snapshots (
+		id TEXT PRIMARY KEY,
+		dashboard_id TEXT NOT NULL,
+		title TEXT NOT NULL,
+		start_time INTEGER NOT NULL,
+		end_time INTEGER NOT NULL,
+		data TEXT NOT NULL,
+		results TEXT NOT NULL,
+		expires_at datetime NOT NULL,
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL
+	);`
+
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return fmt.Errorf("error in creating dashboard_snapshots table: %s", err.Error())
+	}
+	return nil
+}
+
+const linkColumns = "id, dashboard_id, start_time, end_time, relative_time, expires_at, revoked_at, created_at, created_by"
+
+// CreateLink stores a new share link of the dashboard and returns it along with its token
+func CreateLink(ctx context.Context, dashboardId string, req *CreateLinkRequest) (*CreateLinkResponse, *model.ApiError) {
+	if dashboardId == "" {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: ErrMissingDashboard}
+	}
+	if err := req.Validate(); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+
+	link := Link{
+		Id:           uuid.New().String(),
+		DashboardId:  dashboardId,
+		StartTime:    req.StartTime,
+		EndTime:      req.EndTime,
+		RelativeTime: req.RelativeTime,
+		ExpiresAt:    time.Now().Add(defaultExpiry),
+		CreatedAt:    time.Now(),
+	}
+	if req.ExpiresAt != nil {
+		link.ExpiresAt = *req.ExpiresAt
+	}
+	if user := common.GetUserFromContext(ctx); user != nil {
+		link.CreatedBy = user.Email
+	}
+
+	token, err := signToken(&link)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	_, err = db.ExecContext(ctx, "INSERT INTO dashboard_share_links ("+linkColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		link.Id, link.DashboardId, link.StartTime, link.EndTime, link.RelativeTime, link.ExpiresAt, link.RevokedAt, link.CreatedAt, link.CreatedBy)
+	if err != nil {
+		zap.L().Error("Error in inserting share link", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+
+	return &CreateLinkResponse{Link: link, Token: token}, nil
+}
+
+// GetLinks returns the share links of the dashboard, including the revoked and expired ones
+func GetLinks(ctx context.Context, dashboardId string) ([]Link, *model.ApiError) {
+	links := []Link{}
+	err := db.SelectContext(ctx, &links, "SELECT "+linkColumns+" FROM dashboard_share_links WHERE dashboard_id=$1 ORDER BY created_at DESC", dashboardId)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return links, nil
+}
+
+func getLink(ctx context.Context, id string) (*Link, *model.ApiError) {
+	link := Link{}
+	err := db.GetContext(ctx, &link, "SELECT "+linkColumns+" FROM dashboard_share_links WHERE id=$1", id)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no share link found with id: %s", id)}
+	}
+	return &link, nil
+}
+
+// RevokeLink revokes the share link of the dashboard, the tokens of
+// the link are rejected from then on
+func RevokeLink(ctx context.Context, dashboardId, id string) *model.ApiError {
+	result, err := db.ExecContext(ctx, "UPDATE dashboard_share_links SET revoked_at=$1 WHERE id=$2 AND dashboard_id=$3 AND revoked_at IS NULL", time.Now(), id, dashboardId)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no active share link found with id: %s", id)}
+	}
+	return nil
+}
+
+// RevokeDashboardLinks revokes all the share links of the dashboard
+func RevokeDashboardLinks(ctx context.Context, dashboardId string) *model.ApiError {
+	_, err := db.ExecContext(ctx, "UPDATE dashboard_share_links SET revoked_at=$1 WHERE dashboard_id=$2 AND revoked_at IS NULL", time.Now(), dashboardId)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return nil
+}
+
+// ValidateToken verifies the token and returns its active share link
+func ValidateToken(ctx context.Context, token string) (*Link, *model.ApiError) {
+	id, dashboardId, err := parseToken(token)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorUnauthorized, Err: err}
+	}
+
+	link, apiErr := getLink(ctx, id)
+	if apiErr != nil {
+		return nil, &model.ApiError{Typ: model.ErrorUnauthorized, Err: apiErr.Err}
+	}
+	if link.DashboardId != dashboardId {
+		return nil, &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("invalid share token")}
+	}
+	if err := link.Active(time.Now()); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorUnauthorized, Err: err}
+	}
+	return link, nil
+}
+
+const snapshotColumns = "id, dashboard_id, title, start_time, end_time, data, results, expires_at, created_at, created_by"
+
+// snapshotSummaryColumns leave out the dashboard and results of the snapshots
+const snapshotSummaryColumns = "id, dashboard_id, title, start_time, end_time, expires_at, created_at, created_by"
+
+// CreateSnapshot stores the snapshot of the dashboard and returns it along with its token
+func CreateSnapshot(ctx context.Context, snapshot *Snapshot) (*CreateSnapshotResponse, *model.ApiError) {
+	if snapshot.DashboardId == "" {
+		return nil, &model.ApiError{Typ: model.ErrorBadData, Err: ErrMissingDashboard}
+	}
+	snapshot.Id = uuid.New().String()
+	snapshot.CreatedAt = time.Now()
+	if snapshot.ExpiresAt.IsZero() {
+		snapshot.ExpiresAt = snapshot.CreatedAt.Add(defaultSnapshotExpiry)
+	}
+	if user := common.GetUserFromContext(ctx); user != nil {
+		snapshot.CreatedBy = user.Email
+	}
+
+	token, err := signSnapshotToken(snapshot)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	// the expired snapshots are purged when new ones are captured
+	if _, err := db.ExecContext(ctx, "DELETE FROM dashboard_snapshots WHERE expires_at < $1", time.Now()); err != nil {
+		zap.L().Error("Error in purging expired snapshots", zap.Error(err))
+	}
+
+	_, err = db.ExecContext(ctx, "INSERT INTO dashboard_snapshots ("+snapshotColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		snapshot.Id, snapshot.DashboardId, snapshot.Title, snapshot.StartTime, snapshot.EndTime, snapshot.Data, snapshot.Results, snapshot.ExpiresAt, snapshot.CreatedAt, snapshot.CreatedBy)
+	if err != nil {
+		zap.L().Error("Error in inserting snapshot", zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+
+	return &CreateSnapshotResponse{Snapshot: *snapshot, Token: token}, nil
+}
+
+// GetSnapshots returns the snapshots of the dashboard without their data
+func GetSnapshots(ctx context.Context, dashboardId string) ([]Snapshot, *model.ApiError) {
+	snapshots := []Snapshot{}
+	err := db.SelectContext(ctx, &snapshots, "SELECT "+snapshotSummaryColumns+" FROM dashboard_snapshots WHERE dashboard_id=$1 ORDER BY created_at DESC", dashboardId)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return snapshots, nil
+}
+
+// DeleteSnapshot deletes the snapshot of the dashboard, its tokens are rejected from then on
+func DeleteSnapshot(ctx context.Context, dashboardId, id string) *model.ApiError {
+	result, err := db.ExecContext(ctx, "DELETE FROM dashboard_snapshots WHERE id=$1 AND dashboard_id=$2", id, dashboardId)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no snapshot found with id: %s", id)}
+	}
+	return nil
+}
+
+// ValidateSnapshotToken verifies the token and returns its snapshot
+func ValidateSnapshotToken(ctx context.Context, token string) (*Snapshot, *model.ApiError) {
+	id, dashboardId, err := parseSnapshotToken(token)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorUnauthorized, Err: err}
+	}
+
+	snapshot := Snapshot{}
+	err = db.GetContext(ctx, &snapshot, "SELECT "+snapshotColumns+" FROM dashboard_snapshots WHERE id=$1", id)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("no snapshot found with id: %s", id)}
+	}
+	if snapshot.DashboardId != dashboardId {
+		return nil, &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("invalid snapshot token")}
+	}
+	if err := snapshot.Active(time.Now()); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorUnauthorized, Err: err}
+	}
+	return &snapshot, nil
+}
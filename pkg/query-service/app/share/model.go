@@ -0,0 +1,92 @@
+package share
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrMissingDashboard = errors.New("missing dashboard id")
+	ErrInvalidTimeRange = errors.New("either a fixed start and end or a relative time range is required")
+	ErrInvalidExpiry    = errors.New("expiry must be in the future")
+	ErrLinkRevoked      = errors.New("share link is revoked")
+	ErrLinkExpired      = errors.New("share link is expired")
+)
+
+// Link grants read only access to a dashboard over a time range
+// to anyone holding its token
+type Link struct {
+	Id          string `json:"id" db:"id"`
+	DashboardId string `json:"dashboardId" db:"dashboard_id"`
+	// StartTime and EndTime are the fixed time range in epoch millis
+	StartTime int64 `json:"startTime,omitempty" db:"start_time"`
+	EndTime   int64 `json:"endTime,omitempty" db:"end_time"`
+	// RelativeTime is the time range ending now, in minutes, used
+	// by the links embedded on wallboards
+	RelativeTime int64      `json:"relativeTime,omitempty" db:"relative_time"`
+	ExpiresAt    time.Time  `json:"expiresAt" db:"expires_at"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+	CreatedBy    string     `json:"createdBy" db:"created_by"`
+}
+
+// CreateLinkRequest creates a share link, the link expires after a day
+// unless ExpiresAt is set
+type CreateLinkRequest struct {
+	StartTime    int64      `json:"startTime,omitempty"`
+	EndTime      int64      `json:"endTime,omitempty"`
+	RelativeTime int64      `json:"relativeTime,omitempty"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+}
+
+// CreateLinkResponse holds the token of the link, the token is
+// only returned once
+type CreateLinkResponse struct {
+	Link
+	Token string `json:"token"`
+}
+
+const defaultExpiry = 24 * time.Hour
+
+func (r *CreateLinkRequest) Validate() error {
+	fixed := r.StartTime > 0 && r.EndTime > r.StartTime
+	if fixed == (r.RelativeTime > 0) {
+		return ErrInvalidTimeRange
+	}
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return ErrInvalidExpiry
+	}
+	return nil
+}
+
+// TimeRange returns the time range the link grants access to at ts, in epoch millis
+func (l *Link) TimeRange(ts time.Time) (int64, int64) {
+	if l.RelativeTime > 0 {
+		return ts.Add(-time.Duration(l.RelativeTime) * time.Minute).UnixMilli(), ts.UnixMilli()
+	}
+	return l.StartTime, l.EndTime
+}
+
+// Active checks the link is neither revoked nor expired at ts
+func (l *Link) Active(ts time.Time) error {
+	if l.RevokedAt != nil {
+		return ErrLinkRevoked
+	}
+	if !ts.Before(l.ExpiresAt) {
+		return ErrLinkExpired
+	}
+	return nil
+}
+
+// ClampTimeRange restricts the requested range to the range of the link
+func (l *Link) ClampTimeRange(ts time.Time, start, end int64) (int64, int64) {
+	minStart, maxEnd := l.TimeRange(ts)
+	if start < minStart || start >= maxEnd {
+		start = minStart
+	}
+	if end > maxEnd || end <= start {
+		end = maxEnd
+	}
+	return start, end
+}
@@ -0,0 +1,133 @@
+package share
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// widgetQuery is the query of a dashboard widget as saved by the frontend
+type widgetQuery struct {
+	QueryType string `json:"queryType"`
+	Builder   struct {
+		QueryData     []v3.BuilderQuery `json:"queryData"`
+		QueryFormulas []struct {
+			Expression string `json:"expression"`
+		} `json:"queryFormulas"`
+	} `json:"builder"`
+	PromQL []struct {
+		Query string `json:"query"`
+	} `json:"promql"`
+	ClickHouse []struct {
+		Query string `json:"query"`
+	} `json:"clickhouse_sql"`
+}
+
+func findWidgetQuery(data map[string]interface{}, widgetId string) (*widgetQuery, error) {
+	widgets, _ := data["widgets"].([]interface{})
+	for _, w := range widgets {
+		widget, ok := w.(map[string]interface{})
+		if !ok || widget["id"] != widgetId {
+			continue
+		}
+		raw, err := json.Marshal(widget["query"])
+		if err != nil {
+			return nil, err
+		}
+		query := &widgetQuery{}
+		if err := json.Unmarshal(raw, query); err != nil {
+			return nil, fmt.Errorf("invalid query of widget %s: %w", widgetId, err)
+		}
+		return query, nil
+	}
+	return nil, fmt.Errorf("widget %s not found in the dashboard", widgetId)
+}
+
+func hasVariable(v interface{}) bool {
+	s := fmt.Sprintf("%v", v)
+	return strings.Contains(s, "{{") || strings.Contains(s, "$")
+}
+
+// narrows checks the builder query applies all the filters of the saved
+// query, the filters depending on variables are left to the viewer
+func narrows(saved, requested v3.BuilderQuery) bool {
+	if saved.Filters == nil {
+		return true
+	}
+	for _, item := range saved.Filters.Items {
+		if hasVariable(item.Value) {
+			continue
+		}
+		found := false
+		if requested.Filters != nil {
+			for _, r := range requested.Filters.Items {
+				if r.Key.Key == item.Key.Key && r.Operator == item.Operator && fmt.Sprintf("%v", r.Value) == fmt.Sprintf("%v", item.Value) {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyQuery checks that the composite query only runs the queries of the
+// widget, the viewers of a shared dashboard cannot query other data
+func VerifyQuery(data map[string]interface{}, widgetId string, cq *v3.CompositeQuery) error {
+	saved, err := findWidgetQuery(data, widgetId)
+	if err != nil {
+		return err
+	}
+
+	switch cq.QueryType {
+	case v3.QueryTypePromQL:
+		for name, q := range cq.PromQueries {
+			found := false
+			for _, s := range saved.PromQL {
+				found = found || s.Query == q.Query
+			}
+			if !found {
+				return errors.Errorf("query %s is not part of the widget", name)
+			}
+		}
+	case v3.QueryTypeClickHouseSQL:
+		for name, q := range cq.ClickHouseQueries {
+			found := false
+			for _, s := range saved.ClickHouse {
+				found = found || s.Query == q.Query
+			}
+			if !found {
+				return errors.Errorf("query %s is not part of the widget", name)
+			}
+		}
+	case v3.QueryTypeBuilder:
+		for name, q := range cq.BuilderQueries {
+			found := false
+			if q.Expression != q.QueryName {
+				// formula
+				for _, f := range saved.Builder.QueryFormulas {
+					found = found || f.Expression == q.Expression
+				}
+			} else {
+				for _, s := range saved.Builder.QueryData {
+					if s.DataSource == q.DataSource && s.AggregateAttribute.Key == q.AggregateAttribute.Key && narrows(s, *q) {
+						found = true
+						break
+					}
+				}
+			}
+			if !found {
+				return errors.Errorf("query %s is not part of the widget", name)
+			}
+		}
+	default:
+		return errors.Errorf("unsupported query type: %s", cq.QueryType)
+	}
+	return nil
+}
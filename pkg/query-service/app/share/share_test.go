@@ -0,0 +1,195 @@
+package share
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestToken(t *testing.T) {
+	auth.JwtSecret = "secret"
+	link := &Link{Id: "link", DashboardId: "dashboard", ExpiresAt: time.Now().Add(time.Hour)}
+
+	token, err := signToken(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, dashboardId, err := parseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "link" || dashboardId != "dashboard" {
+		t.Errorf("unexpected claims %s %s", id, dashboardId)
+	}
+
+	// the share tokens are not user tokens
+	if _, err := auth.ParseJWT(token); err == nil {
+		t.Error("expected share token to be rejected as user token")
+	}
+
+	link.ExpiresAt = time.Now().Add(-time.Minute)
+	expired, _ := signToken(link)
+	if _, _, err := parseToken(expired); err == nil {
+		t.Error("expected expired token to be rejected")
+	}
+}
+
+func TestLinkTimeRange(t *testing.T) {
+	now := time.UnixMilli(10_000_000)
+	link := &Link{RelativeTime: 60, ExpiresAt: now.Add(time.Hour)}
+	start, end := link.TimeRange(now)
+	if start != now.Add(-time.Hour).UnixMilli() || end != now.UnixMilli() {
+		t.Errorf("unexpected range %d %d", start, end)
+	}
+
+	fixed := &Link{StartTime: 1000, EndTime: 2000}
+	start, end = fixed.ClampTimeRange(now, 0, 5000)
+	if start != 1000 || end != 2000 {
+		t.Errorf("expected clamped range, got %d %d", start, end)
+	}
+	start, end = fixed.ClampTimeRange(now, 1200, 1500)
+	if start != 1200 || end != 1500 {
+		t.Errorf("expected narrower range to be kept, got %d %d", start, end)
+	}
+
+	revoked := time.Now()
+	if err := (&Link{RevokedAt: &revoked, ExpiresAt: now.Add(time.Hour)}).Active(now); err != ErrLinkRevoked {
+		t.Errorf("expected revoked, got %v", err)
+	}
+	if err := link.Active(now.Add(2 * time.Hour)); err != ErrLinkExpired {
+		t.Errorf("expected expired, got %v", err)
+	}
+}
+
+const testDashboard = `{
+	"widgets": [
+		{
+			"id": "latency",
+			"query": {
+				"queryType": "builder",
+				"builder": {
+					"queryData": [
+						{
+							"queryName": "A",
+							"expression": "A",
+							"dataSource": "metrics",
+							"aggregateAttribute": {"key": "signoz_latency_bucket"},
+							"filters": {"op": "AND", "items": [
+								{"key": {"key": "deployment_environment"}, "op": "=", "value": "prod"},
+								{"key": {"key": "service_name"}, "op": "IN", "value": "{{.service}}"}
+							]}
+						}
+					],
+					"queryFormulas": [{"queryName": "F1", "expression": "A / 1000"}]
+				}
+			}
+		},
+		{
+			"id": "errors",
+			"query": {"queryType": "promql", "promql": [{"query": "sum(rate(signoz_calls_total[5m]))"}]}
+		}
+	]
+}`
+
+func TestVerifyQuery(t *testing.T) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(testDashboard), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	prod := v3.FilterItem{Key: v3.AttributeKey{Key: "deployment_environment"}, Operator: "=", Value: "prod"}
+	builder := func(metric string, filters ...v3.FilterItem) *v3.CompositeQuery {
+		return &v3.CompositeQuery{
+			QueryType: v3.QueryTypeBuilder,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {
+					QueryName:          "A",
+					Expression:         "A",
+					DataSource:         v3.DataSourceMetrics,
+					AggregateAttribute: v3.AttributeKey{Key: metric},
+					Filters:            &v3.FilterSet{Operator: "AND", Items: filters},
+				},
+				"F1": {QueryName: "F1", Expression: "A / 1000"},
+			},
+		}
+	}
+
+	if err := VerifyQuery(data, "latency", builder("signoz_latency_bucket", prod)); err != nil {
+		t.Errorf("expected widget query to be allowed, got %v", err)
+	}
+	if err := VerifyQuery(data, "latency", builder("signoz_latency_bucket")); err == nil {
+		t.Error("expected query without the saved filter to be rejected")
+	}
+	if err := VerifyQuery(data, "latency", builder("container_memory_usage", prod)); err == nil {
+		t.Error("expected query of another metric to be rejected")
+	}
+	if err := VerifyQuery(data, "missing", builder("signoz_latency_bucket", prod)); err == nil {
+		t.Error("expected unknown widget to be rejected")
+	}
+
+	prom := &v3.CompositeQuery{
+		QueryType:   v3.QueryTypePromQL,
+		PromQueries: map[string]*v3.PromQuery{"A": {Query: "sum(rate(signoz_calls_total[5m]))"}},
+	}
+	if err := VerifyQuery(data, "errors", prom); err != nil {
+		t.Errorf("expected promql query to be allowed, got %v", err)
+	}
+	prom.PromQueries["A"].Query = "up"
+	if err := VerifyQuery(data, "errors", prom); err == nil {
+		t.Error("expected other promql query to be rejected")
+	}
+}
+
+func TestSnapshotToken(t *testing.T) {
+	auth.JwtSecret = "secret"
+	snapshot := &Snapshot{Id: "snapshot", DashboardId: "dashboard", ExpiresAt: time.Now().Add(time.Hour)}
+
+	token, err := signSnapshotToken(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _, err := parseSnapshotToken(token); err != nil || id != "snapshot" {
+		t.Errorf("unexpected snapshot token claims %s %v", id, err)
+	}
+
+	// the snapshot tokens do not grant access to the live dashboard
+	if _, _, err := parseToken(token); err == nil {
+		t.Error("expected snapshot token to be rejected as share link token")
+	}
+	linkToken, _ := signToken(&Link{Id: "link", DashboardId: "dashboard", ExpiresAt: time.Now().Add(time.Hour)})
+	if _, _, err := parseSnapshotToken(linkToken); err == nil {
+		t.Error("expected share link token to be rejected as snapshot token")
+	}
+}
+
+func TestCreateSnapshotRequestValidate(t *testing.T) {
+	req := CreateSnapshotRequest{
+		StartTime: 1000,
+		EndTime:   2000,
+		Queries:   map[string]json.RawMessage{"latency": json.RawMessage(`{}`)},
+	}
+	if err := req.Validate(); err != nil {
+		t.Errorf("expected valid request, got %v", err)
+	}
+
+	req.EndTime = 500
+	if err := req.Validate(); err != ErrInvalidTimeRange {
+		t.Errorf("expected invalid time range, got %v", err)
+	}
+
+	req.EndTime = 2000
+	req.Queries = nil
+	if err := req.Validate(); err != ErrMissingSnapshotQueries {
+		t.Errorf("expected missing queries, got %v", err)
+	}
+
+	req.Queries = map[string]json.RawMessage{"latency": json.RawMessage(`{}`)}
+	tooLate := time.Now().Add(2 * maxSnapshotExpiry)
+	req.ExpiresAt = &tooLate
+	if err := req.Validate(); err == nil {
+		t.Error("expected expiry beyond the maximum to be rejected")
+	}
+}
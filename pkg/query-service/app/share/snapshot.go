@@ -0,0 +1,124 @@
+package share
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+var (
+	ErrMissingSnapshotQueries = errors.New("at least one widget query is required")
+	ErrSnapshotExpired        = errors.New("snapshot is expired")
+)
+
+const (
+	defaultSnapshotExpiry = 30 * 24 * time.Hour
+	maxSnapshotExpiry     = 365 * 24 * time.Hour
+)
+
+// WidgetResult is the materialized result of the query of a widget
+type WidgetResult struct {
+	Result []*v3.Result `json:"result"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// WidgetResults are the results of the snapshot by widget id
+type WidgetResults map[string]*WidgetResult
+
+func (r *WidgetResults) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	}
+	return json.Unmarshal(data, r)
+}
+
+func (r WidgetResults) Value() (driver.Value, error) {
+	b, err := json.Marshal(r)
+	return string(b), err
+}
+
+// SnapshotData is the dashboard as it was at capture time
+type SnapshotData map[string]interface{}
+
+func (d *SnapshotData) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	}
+	return json.Unmarshal(data, d)
+}
+
+func (d SnapshotData) Value() (driver.Value, error) {
+	b, err := json.Marshal(d)
+	return string(b), err
+}
+
+// Snapshot is a dashboard with the query results of its widgets frozen at
+// capture time, it stays readable after the retention of the data expires
+type Snapshot struct {
+	Id          string `json:"id" db:"id"`
+	DashboardId string `json:"dashboardId" db:"dashboard_id"`
+	Title       string `json:"title" db:"title"`
+	// StartTime and EndTime are the captured time range in epoch millis
+	StartTime int64         `json:"startTime" db:"start_time"`
+	EndTime   int64         `json:"endTime" db:"end_time"`
+	Data      SnapshotData  `json:"data,omitempty" db:"data"`
+	Results   WidgetResults `json:"results,omitempty" db:"results"`
+	ExpiresAt time.Time     `json:"expiresAt" db:"expires_at"`
+	CreatedAt time.Time     `json:"createdAt" db:"created_at"`
+	CreatedBy string        `json:"createdBy" db:"created_by"`
+}
+
+// CreateSnapshotRequest captures a snapshot of the dashboard, Queries holds the
+// query range request of each widget by widget id as sent by the dashboard
+type CreateSnapshotRequest struct {
+	Title     string                     `json:"title"`
+	StartTime int64                      `json:"startTime"`
+	EndTime   int64                      `json:"endTime"`
+	ExpiresAt *time.Time                 `json:"expiresAt,omitempty"`
+	Queries   map[string]json.RawMessage `json:"queries"`
+}
+
+// CreateSnapshotResponse holds the token of the snapshot, the token is
+// only returned once
+type CreateSnapshotResponse struct {
+	Snapshot
+	Token string `json:"token"`
+}
+
+func (r *CreateSnapshotRequest) Validate() error {
+	if r.StartTime <= 0 || r.EndTime <= r.StartTime {
+		return ErrInvalidTimeRange
+	}
+	if len(r.Queries) == 0 {
+		return ErrMissingSnapshotQueries
+	}
+	if r.ExpiresAt != nil {
+		if !r.ExpiresAt.After(time.Now()) {
+			return ErrInvalidExpiry
+		}
+		if r.ExpiresAt.After(time.Now().Add(maxSnapshotExpiry)) {
+			return fmt.Errorf("snapshot cannot expire after %s", maxSnapshotExpiry)
+		}
+	}
+	return nil
+}
+
+// Active checks the snapshot is not expired at ts
+func (s *Snapshot) Active(ts time.Time) error {
+	if !ts.Before(s.ExpiresAt) {
+		return ErrSnapshotExpired
+	}
+	return nil
+}
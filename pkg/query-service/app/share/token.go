@@ -0,0 +1,79 @@
+package share
+
+import (
+	"github.com/golang-jwt/jwt"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+)
+
+const (
+	linkTokenPurpose     = "dashboard-share"
+	snapshotTokenPurpose = "dashboard-snapshot"
+)
+
+// signingKey derives the key of the share tokens from the jwt secret so
+// that a share token is never accepted as a user token and vice versa,
+// the purpose keeps the link and snapshot tokens apart
+func signingKey(purpose string) []byte {
+	return []byte(auth.JwtSecret + ":" + purpose)
+}
+
+func signToken(link *Link) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sid": link.Id,
+		"did": link.DashboardId,
+		"exp": link.ExpiresAt.Unix(),
+	})
+	signed, err := token.SignedString(signingKey(linkTokenPurpose))
+	if err != nil {
+		return "", errors.Errorf("failed to encode share token: %v", err)
+	}
+	return signed, nil
+}
+
+func signSnapshotToken(snapshot *Snapshot) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sid": snapshot.Id,
+		"did": snapshot.DashboardId,
+		"exp": snapshot.ExpiresAt.Unix(),
+	})
+	signed, err := token.SignedString(signingKey(snapshotTokenPurpose))
+	if err != nil {
+		return "", errors.Errorf("failed to encode snapshot token: %v", err)
+	}
+	return signed, nil
+}
+
+// parseToken verifies the signature and expiry of the token and
+// returns the ids of the share link and of its dashboard
+func parseToken(tokenStr string) (string, string, error) {
+	return parseTokenFor(linkTokenPurpose, tokenStr)
+}
+
+// parseSnapshotToken returns the ids of the snapshot and of its dashboard
+func parseSnapshotToken(tokenStr string) (string, string, error) {
+	return parseTokenFor(snapshotTokenPurpose, tokenStr)
+}
+
+func parseTokenFor(purpose, tokenStr string) (string, string, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.Errorf("unknown signing algo: %v", token.Header["alg"])
+		}
+		return signingKey(purpose), nil
+	})
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to parse share token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return "", "", errors.Errorf("invalid share token")
+	}
+	id, _ := claims["sid"].(string)
+	dashboardId, _ := claims["did"].(string)
+	if id == "" || dashboardId == "" {
+		return "", "", errors.Errorf("invalid share token")
+	}
+	return id, dashboardId, nil
+}
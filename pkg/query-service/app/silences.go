@@ -0,0 +1,157 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.uber.org/zap"
+)
+
+func silenceApiError(id string, err error) *model.ApiError {
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no silence found with id: %s", id)}
+	}
+	return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+}
+
+// listSilences returns the silences, filtered by status when one is given
+func (aH *APIHandler) listSilences(w http.ResponseWriter, r *http.Request) {
+	silences, err := aH.ruleManager.ListSilences(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		filtered := make([]rules.Silence, 0)
+		for _, silence := range silences {
+			if silence.Status == status {
+				filtered = append(filtered, silence)
+			}
+		}
+		silences = filtered
+	}
+	aH.Respond(w, silences)
+}
+
+func (aH *APIHandler) getSilence(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	silence, err := aH.ruleManager.GetSilence(r.Context(), id)
+	if err != nil {
+		RespondError(w, silenceApiError(id, err), nil)
+		return
+	}
+	aH.Respond(w, silence)
+}
+
+func (aH *APIHandler) createSilence(w http.ResponseWriter, r *http.Request) {
+	var silence rules.Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := silence.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	created, err := aH.ruleManager.CreateSilence(r.Context(), &silence)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, created)
+}
+
+func (aH *APIHandler) editSilence(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var silence rules.Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := silence.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	edited, err := aH.ruleManager.EditSilence(r.Context(), &silence, id)
+	if err != nil {
+		RespondError(w, silenceApiError(id, err), nil)
+		return
+	}
+	aH.Respond(w, edited)
+}
+
+// expireSilence ends the silence now, the muted alerts are notified again
+func (aH *APIHandler) expireSilence(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	expired, err := aH.ruleManager.ExpireSilence(r.Context(), id)
+	if err != nil {
+		RespondError(w, silenceApiError(id, err), nil)
+		return
+	}
+	aH.Respond(w, expired)
+}
+
+func (aH *APIHandler) deleteSilence(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := aH.ruleManager.DeleteSilence(r.Context(), id); err != nil {
+		RespondError(w, silenceApiError(id, err), nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// withSilencedAlerts adds the alerts muted by the silences to the alerts listed by the
+// alertmanager, as the alertmanager never receives them. The listing is returned as is
+// when the silenced alerts are filtered out or can't be added
+func (aH *APIHandler) withSilencedAlerts(r *http.Request, body []byte) []byte {
+	if r.URL.Query().Get("silenced") == "false" {
+		return body
+	}
+
+	listing := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &listing); err != nil {
+		return body
+	}
+	alerts := []json.RawMessage{}
+	if data, ok := listing["data"]; ok && string(data) != "null" {
+		if err := json.Unmarshal(data, &alerts); err != nil {
+			return body
+		}
+	}
+
+	silenced, err := aH.ruleManager.SilencedAlerts(r.Context())
+	if err != nil {
+		zap.L().Error("failed to list the silenced alerts", zap.Error(err))
+		return body
+	}
+	if len(silenced) == 0 {
+		return body
+	}
+	for _, alert := range silenced {
+		data, err := json.Marshal(alert)
+		if err != nil {
+			continue
+		}
+		alerts = append(alerts, data)
+	}
+
+	data, err := json.Marshal(alerts)
+	if err != nil {
+		return body
+	}
+	listing["data"] = data
+	merged, err := json.Marshal(listing)
+	if err != nil {
+		return body
+	}
+	return merged
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/app/skipindexes"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// listSkipIndexes lists the skip indexes on the attributes with the progress of their materialization
+func (aH *APIHandler) listSkipIndexes(w http.ResponseWriter, r *http.Request) {
+	indexes, apiErr := aH.SkipIndexManager.List(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, indexes)
+}
+
+func (aH *APIHandler) getSkipIndex(w http.ResponseWriter, r *http.Request) {
+	idx, apiErr := aH.SkipIndexManager.Get(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, idx)
+}
+
+func (aH *APIHandler) createSkipIndex(w http.ResponseWriter, r *http.Request) {
+	var postable skipindexes.PostableIndex
+	if err := json.NewDecoder(r.Body).Decode(&postable); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	userId, err := auth.ExtractUserIdFromContext(r.Context())
+	if err != nil {
+		RespondError(w, model.UnauthorizedError(errors.Wrap(err, "failed to get userId from context")), nil)
+		return
+	}
+
+	idx, apiErr := aH.SkipIndexManager.Create(r.Context(), userId, &postable)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, idx)
+}
+
+// estimateSkipIndex estimates from the query history how many queries an index would speed up
+func (aH *APIHandler) estimateSkipIndex(w http.ResponseWriter, r *http.Request) {
+	var postable skipindexes.PostableIndex
+	if err := json.NewDecoder(r.Body).Decode(&postable); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	user := common.GetUserFromContext(r.Context())
+	if user == nil {
+		RespondError(w, model.UnauthorizedError(fmt.Errorf("failed to get user from context")), nil)
+		return
+	}
+
+	benefit, apiErr := aH.SkipIndexManager.Estimate(r.Context(), user.OrgId, &postable)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, benefit)
+}
+
+// materializeSkipIndex restarts the materialization of an index that failed
+func (aH *APIHandler) materializeSkipIndex(w http.ResponseWriter, r *http.Request) {
+	idx, apiErr := aH.SkipIndexManager.Materialize(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, idx)
+}
+
+func (aH *APIHandler) deleteSkipIndex(w http.ResponseWriter, r *http.Request) {
+	if apiErr := aH.SkipIndexManager.Delete(r.Context(), mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
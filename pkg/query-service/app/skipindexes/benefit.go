@@ -0,0 +1,65 @@
+package skipindexes
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/app/queryhistory"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// estimateBenefit looks at the recent queries of the history for those filtering on the
+// key of the index. Only the filters on the value in the attribute maps count, the
+// queries on a materialized column of the key use the index of the column
+func estimateBenefit(signal v3.DataSource, key v3.AttributeKey, entries []*queryhistory.Entry) *Benefit {
+	benefit := &Benefit{Operators: map[v3.FilterOperator]int{}}
+	for _, e := range entries {
+		var params v3.QueryRangeParamsV3
+		if err := json.Unmarshal([]byte(e.Params), &params); err != nil || params.CompositeQuery == nil {
+			continue
+		}
+		if benefit.Since == nil || e.CreatedAt.Before(*benefit.Since) {
+			createdAt := e.CreatedAt
+			benefit.Since = &createdAt
+		}
+		benefit.Analyzed++
+
+		helped := false
+		for _, query := range params.CompositeQuery.BuilderQueries {
+			if query.DataSource != signal || query.Filters == nil {
+				continue
+			}
+			for _, item := range query.Filters.Items {
+				if !sameKey(item.Key, key) {
+					continue
+				}
+				op := item.Operator
+				if op == "" {
+					op = v3.FilterOperatorEqual
+				}
+				benefit.Operators[op]++
+				// a filter only narrows the query down when all the filters are needed
+				if skippable(op) && !strings.EqualFold(query.Filters.Operator, "OR") {
+					helped = true
+				}
+			}
+		}
+		if helped {
+			benefit.Queries++
+			benefit.DurationMs += e.DurationMs
+			benefit.RowsRead += e.RowsRead
+			benefit.BytesRead += e.BytesRead
+		}
+	}
+	return benefit
+}
+
+// sameKey tells whether the filter key reads the value of the key from the attribute maps,
+// the filters of the older queries may lack the type of the key
+func sameKey(filterKey, key v3.AttributeKey) bool {
+	if filterKey.Key != key.Key || filterKey.IsColumn || filterKey.IsJSON {
+		return false
+	}
+	return (filterKey.Type == "" || filterKey.Type == key.Type) &&
+		(filterKey.DataType == "" || filterKey.DataType == key.DataType)
+}
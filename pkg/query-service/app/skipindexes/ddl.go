@@ -0,0 +1,66 @@
+package skipindexes
+
+import (
+	"fmt"
+
+	logsV3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
+	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// localTables are the database and the table of each shard the indexes are added to
+var localTables = map[v3.DataSource][2]string{
+	v3.DataSourceLogs:   {"signoz_logs", "logs"},
+	v3.DataSourceTraces: {"signoz_traces", "signoz_index_v2"},
+}
+
+func (idx *Index) table() string {
+	t := localTables[idx.Signal]
+	return t[0] + "." + t[1]
+}
+
+// expression returns the expression of the value of the key the index is built on
+func (idx *Index) expression() string {
+	key := v3.AttributeKey(idx.Key)
+	if idx.Signal == v3.DataSourceTraces {
+		return tracesV3.AttributeExpression(key)
+	}
+	return logsV3.ViewExpression(key)
+}
+
+func addStatement(idx *Index, cluster string) string {
+	return fmt.Sprintf("ALTER TABLE %s ON CLUSTER %s ADD INDEX IF NOT EXISTS %s (%s) TYPE %s GRANULARITY %d",
+		idx.table(), cluster, idx.Name, idx.expression(), idx.Type.expression(), idx.Granularity)
+}
+
+// materializeStatement returns the statement building the index for the parts written
+// before it was added, clickhouse runs it in the background as a mutation
+func materializeStatement(idx *Index, cluster string) string {
+	return fmt.Sprintf("ALTER TABLE %s ON CLUSTER %s MATERIALIZE INDEX %s", idx.table(), cluster, idx.Name)
+}
+
+// mutationCondition selects the mutations materializing the index
+func mutationCondition(idx *Index) string {
+	t := localTables[idx.Signal]
+	return fmt.Sprintf("database = '%s' AND table = '%s' AND match(command, 'MATERIALIZE INDEX `?%s`?(\\\\s|$)')", t[0], t[1], idx.Name)
+}
+
+// killStatement returns the statement stopping the materialization of the index
+func killStatement(idx *Index, cluster string) string {
+	return fmt.Sprintf("KILL MUTATION ON CLUSTER %s WHERE %s", cluster, mutationCondition(idx))
+}
+
+func dropStatement(idx *Index, cluster string) string {
+	return fmt.Sprintf("ALTER TABLE %s ON CLUSTER %s DROP INDEX IF EXISTS %s", idx.table(), cluster, idx.Name)
+}
+
+// materializationQuery returns the query getting the progress of the materialization of
+// the index on all the replicas
+func materializationQuery(idx *Index, cluster string) string {
+	t := localTables[idx.Signal]
+	return fmt.Sprintf("SELECT count() AS mutations, sum(parts_to_do) AS parts_to_do, countIf(is_done = 0) AS pending, "+
+		"anyIf(latest_fail_reason, latest_fail_reason != '') AS fail_reason, "+
+		"(SELECT count() FROM clusterAllReplicas('%s', system.parts) WHERE database = '%s' AND table = '%s' AND active) AS parts "+
+		"FROM clusterAllReplicas('%s', system.mutations) WHERE %s",
+		cluster, t[0], t[1], cluster, mutationCondition(idx))
+}
@@ -0,0 +1,101 @@
+package skipindexes
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/queryhistory"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestValidate(t *testing.T) {
+	method := Key{Key: "http.method", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag, IsColumn: true}
+	cases := []struct {
+		name    string
+		index   PostableIndex
+		wantErr bool
+	}{
+		{name: "defaults", index: PostableIndex{Signal: v3.DataSourceLogs, Key: method}},
+		{name: "tokenbf on traces", index: PostableIndex{Signal: v3.DataSourceTraces, Key: method, Type: TypeTokenBF, Granularity: 4}},
+		{name: "metrics", index: PostableIndex{Signal: v3.DataSourceMetrics, Key: method}, wantErr: true},
+		{name: "unknown type", index: PostableIndex{Signal: v3.DataSourceLogs, Key: method, Type: "minmax"}, wantErr: true},
+		{name: "granularity", index: PostableIndex{Signal: v3.DataSourceLogs, Key: method, Granularity: 2048}, wantErr: true},
+		{name: "static field", index: PostableIndex{Signal: v3.DataSourceLogs, Key: Key{Key: "body", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeUnspecified}}, wantErr: true},
+		{name: "tokenbf on numbers", index: PostableIndex{Signal: v3.DataSourceLogs, Key: Key{Key: "status", DataType: v3.AttributeKeyDataTypeInt64, Type: v3.AttributeKeyTypeTag}, Type: TypeTokenBF}, wantErr: true},
+		{name: "number resource of the spans", index: PostableIndex{Signal: v3.DataSourceTraces, Key: Key{Key: "pid", DataType: v3.AttributeKeyDataTypeInt64, Type: v3.AttributeKeyTypeResource}}, wantErr: true},
+		{name: "bool", index: PostableIndex{Signal: v3.DataSourceLogs, Key: Key{Key: "ok", DataType: v3.AttributeKeyDataTypeBool, Type: v3.AttributeKeyTypeTag}}, wantErr: true},
+		{name: "quoted key", index: PostableIndex{Signal: v3.DataSourceLogs, Key: Key{Key: "a'b", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}}, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.index.Validate()
+			if c.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.False(t, c.index.Key.IsColumn)
+			require.NotEmpty(t, c.index.Type)
+			require.NotZero(t, c.index.Granularity)
+		})
+	}
+}
+
+func TestStatements(t *testing.T) {
+	key := v3.AttributeKey{Key: "http.method", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}
+	idx := &Index{Name: indexName(key), Signal: v3.DataSourceLogs, Key: Key(key), Type: TypeTokenBF, Granularity: 64}
+	require.Equal(t, "skip_tag_string_http_method_idx", idx.Name)
+	require.Equal(t, "ALTER TABLE signoz_logs.logs ON CLUSTER cluster ADD INDEX IF NOT EXISTS skip_tag_string_http_method_idx "+
+		"(attributes_string_value[indexOf(attributes_string_key, 'http.method')]) TYPE tokenbf_v1(10240, 3, 0) GRANULARITY 64",
+		addStatement(idx, "cluster"))
+	require.Equal(t, "ALTER TABLE signoz_logs.logs ON CLUSTER cluster MATERIALIZE INDEX skip_tag_string_http_method_idx",
+		materializeStatement(idx, "cluster"))
+
+	idx.Signal, idx.Type = v3.DataSourceTraces, TypeBloomFilter
+	require.Equal(t, "ALTER TABLE signoz_traces.signoz_index_v2 ON CLUSTER cluster ADD INDEX IF NOT EXISTS skip_tag_string_http_method_idx "+
+		"(stringTagMap['http.method']) TYPE bloom_filter(0.01) GRANULARITY 64",
+		addStatement(idx, "cluster"))
+	require.Equal(t, "KILL MUTATION ON CLUSTER cluster WHERE database = 'signoz_traces' AND table = 'signoz_index_v2' "+
+		"AND match(command, 'MATERIALIZE INDEX `?skip_tag_string_http_method_idx`?(\\\\s|$)')",
+		killStatement(idx, "cluster"))
+	require.Equal(t, "ALTER TABLE signoz_traces.signoz_index_v2 ON CLUSTER cluster DROP INDEX IF EXISTS skip_tag_string_http_method_idx",
+		dropStatement(idx, "cluster"))
+}
+
+func TestEstimateBenefit(t *testing.T) {
+	key := v3.AttributeKey{Key: "http.method", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}
+	entry := func(signal v3.DataSource, op string, items ...v3.FilterItem) *queryhistory.Entry {
+		params, err := json.Marshal(v3.QueryRangeParamsV3{CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeBuilder,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {QueryName: "A", DataSource: signal, Filters: &v3.FilterSet{Operator: op, Items: items}},
+			},
+		}})
+		require.NoError(t, err)
+		return &queryhistory.Entry{Params: string(params), DurationMs: 100, RowsRead: 1000, BytesRead: 10000, CreatedAt: time.Unix(1700000000, 0)}
+	}
+	other := v3.AttributeKey{Key: "service.name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource}
+	materialized := key
+	materialized.IsColumn = true
+
+	benefit := estimateBenefit(v3.DataSourceLogs, key, []*queryhistory.Entry{
+		entry(v3.DataSourceLogs, "AND", v3.FilterItem{Key: key, Operator: v3.FilterOperatorEqual, Value: "GET"}),
+		entry(v3.DataSourceLogs, "AND", v3.FilterItem{Key: v3.AttributeKey{Key: "http.method"}, Operator: v3.FilterOperatorIn, Value: []string{"GET"}}),
+		entry(v3.DataSourceLogs, "AND", v3.FilterItem{Key: key, Operator: v3.FilterOperatorContains, Value: "GE"}),
+		entry(v3.DataSourceLogs, "OR", v3.FilterItem{Key: key, Operator: v3.FilterOperatorEqual, Value: "GET"}, v3.FilterItem{Key: other, Operator: v3.FilterOperatorEqual, Value: "api"}),
+		entry(v3.DataSourceLogs, "AND", v3.FilterItem{Key: materialized, Operator: v3.FilterOperatorEqual, Value: "GET"}),
+		entry(v3.DataSourceTraces, "AND", v3.FilterItem{Key: key, Operator: v3.FilterOperatorEqual, Value: "GET"}),
+		{Params: "not json"},
+	})
+	require.Equal(t, 6, benefit.Analyzed)
+	require.Equal(t, 2, benefit.Queries)
+	require.Equal(t, uint64(2000), benefit.RowsRead)
+	require.Equal(t, int64(200), benefit.DurationMs)
+	require.Equal(t, map[v3.FilterOperator]int{
+		v3.FilterOperatorEqual:    2,
+		v3.FilterOperatorIn:       1,
+		v3.FilterOperatorContains: 1,
+	}, benefit.Operators)
+}
@@ -0,0 +1,195 @@
+package skipindexes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/app/queryhistory"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+// benefitQueries is how many of the recent builder queries the benefit is estimated on
+const benefitQueries = 1000
+
+type ManagerOptions struct {
+	DB      *sqlx.DB
+	Conn    clickhouse.Conn
+	Cluster string
+	// QueryHistory has the recent queries the benefit of an index is estimated on
+	QueryHistory *queryhistory.Manager
+}
+
+// Manager adds and drops the skip indexes on the attributes of the logs and the spans,
+// and follows their materialization on the parts written before they were added
+type Manager struct {
+	repo    *repo
+	conn    clickhouse.Conn
+	cluster string
+	history *queryhistory.Manager
+}
+
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	if err := InitSqliteDBIfNeeded(opts.DB); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		repo:    &repo{db: opts.DB},
+		conn:    opts.Conn,
+		cluster: opts.Cluster,
+		history: opts.QueryHistory,
+	}, nil
+}
+
+// materialization gets the progress of the materialization of the index, the index is
+// listed without it when clickhouse can't be asked
+func (m *Manager) materialization(ctx context.Context, idx *Index) {
+	var progress struct {
+		Mutations  uint64 `ch:"mutations"`
+		PartsToDo  int64  `ch:"parts_to_do"`
+		Pending    uint64 `ch:"pending"`
+		FailReason string `ch:"fail_reason"`
+		Parts      uint64 `ch:"parts"`
+	}
+	if err := m.conn.QueryRow(ctx, materializationQuery(idx, m.cluster)).ScanStruct(&progress); err != nil {
+		zap.L().Error("failed to get the materialization of the skip index", zap.String("name", idx.Name), zap.Error(err))
+		return
+	}
+
+	mat := &Materialization{
+		Parts:     progress.Parts,
+		PartsToDo: progress.PartsToDo,
+		Done:      progress.Pending == 0,
+		Progress:  1,
+	}
+	// the failure of a mutation done since is of no matter
+	if !mat.Done {
+		mat.FailReason = progress.FailReason
+		if progress.Parts > 0 && uint64(progress.PartsToDo) < progress.Parts {
+			mat.Progress = 1 - float64(progress.PartsToDo)/float64(progress.Parts)
+		} else {
+			mat.Progress = 0
+		}
+	}
+	idx.Materialization = mat
+}
+
+func (m *Manager) List(ctx context.Context) ([]*Index, *model.ApiError) {
+	indexes, apiErr := m.repo.list(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for _, idx := range indexes {
+		m.materialization(ctx, idx)
+	}
+	return indexes, nil
+}
+
+func (m *Manager) Get(ctx context.Context, id string) (*Index, *model.ApiError) {
+	idx, apiErr := m.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	m.materialization(ctx, idx)
+	return idx, nil
+}
+
+// Create adds the index to the table and starts its materialization on the existing parts
+func (m *Manager) Create(ctx context.Context, userId string, postable *PostableIndex) (*Index, *model.ApiError) {
+	if err := postable.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	idx := &Index{
+		Id:          uuid.NewString(),
+		Name:        indexName(v3.AttributeKey(postable.Key)),
+		Signal:      postable.Signal,
+		Key:         postable.Key,
+		Type:        postable.Type,
+		Granularity: postable.Granularity,
+		CreatedBy:   userId,
+		CreatedAt:   time.Now(),
+	}
+	exists, apiErr := m.repo.exists(ctx, string(idx.Signal), idx.Name)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if exists {
+		return nil, model.BadRequest(fmt.Errorf("the %s key %s already has the index %s", idx.Signal, idx.Key.Key, idx.Name))
+	}
+
+	if err := m.conn.Exec(ctx, addStatement(idx, m.cluster)); err != nil {
+		zap.L().Error("failed to add the skip index", zap.String("name", idx.Name), zap.Error(err))
+		return nil, model.InternalError(fmt.Errorf("failed to add the skip index %s: %w", idx.Name, err))
+	}
+	if apiErr := m.repo.insert(ctx, idx); apiErr != nil {
+		if err := m.conn.Exec(context.Background(), dropStatement(idx, m.cluster)); err != nil {
+			zap.L().Error("failed to drop the skip index", zap.String("name", idx.Name), zap.Error(err))
+		}
+		return nil, apiErr
+	}
+	if apiErr := m.materialize(ctx, idx); apiErr != nil {
+		return nil, apiErr
+	}
+	m.materialization(ctx, idx)
+	return idx, nil
+}
+
+// Materialize starts the materialization of the index again, after it failed or was killed
+func (m *Manager) Materialize(ctx context.Context, id string) (*Index, *model.ApiError) {
+	idx, apiErr := m.repo.get(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := m.materialize(ctx, idx); apiErr != nil {
+		return nil, apiErr
+	}
+	m.materialization(ctx, idx)
+	return idx, nil
+}
+
+func (m *Manager) materialize(ctx context.Context, idx *Index) *model.ApiError {
+	if err := m.conn.Exec(ctx, materializeStatement(idx, m.cluster)); err != nil {
+		zap.L().Error("failed to materialize the skip index", zap.String("name", idx.Name), zap.Error(err))
+		return model.InternalError(fmt.Errorf("failed to materialize the skip index %s: %w", idx.Name, err))
+	}
+	return nil
+}
+
+// Delete stops the materialization of the index and drops it from the table
+func (m *Manager) Delete(ctx context.Context, id string) *model.ApiError {
+	idx, apiErr := m.repo.get(ctx, id)
+	if apiErr != nil {
+		return apiErr
+	}
+	if err := m.conn.Exec(ctx, killStatement(idx, m.cluster)); err != nil {
+		zap.L().Error("failed to kill the materialization of the skip index", zap.String("name", idx.Name), zap.Error(err))
+	}
+	if err := m.conn.Exec(ctx, dropStatement(idx, m.cluster)); err != nil {
+		return model.InternalError(fmt.Errorf("failed to drop the skip index %s: %w", idx.Name, err))
+	}
+	return m.repo.delete(ctx, id)
+}
+
+// Estimate estimates the benefit of an index from the recent builder queries of the org
+func (m *Manager) Estimate(ctx context.Context, orgId string, postable *PostableIndex) (*Benefit, *model.ApiError) {
+	if err := postable.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	if m.history == nil {
+		return nil, model.InternalError(fmt.Errorf("the query history is not available"))
+	}
+	entries, err := m.history.List(ctx, queryhistory.Filter{
+		OrgId:     orgId,
+		QueryType: string(v3.QueryTypeBuilder),
+		Limit:     benefitQueries,
+	})
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+	return estimateBenefit(postable.Signal, v3.AttributeKey(postable.Key), entries), nil
+}
@@ -0,0 +1,172 @@
+package skipindexes
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// Type is the kind of skip index built on the values of a key
+type Type string
+
+const (
+	// TypeTokenBF is a bloom filter of the tokens of the values, for the string keys
+	TypeTokenBF Type = "tokenbf"
+	// TypeBloomFilter is a bloom filter of the whole values
+	TypeBloomFilter Type = "bloom_filter"
+)
+
+// MaxGranularity is the max number of granules a single index entry covers
+const MaxGranularity = 1024
+
+var nonIdentifierRegex = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// expression returns the clickhouse type of the index
+func (t Type) expression() string {
+	if t == TypeTokenBF {
+		return "tokenbf_v1(10240, 3, 0)"
+	}
+	return constants.DefaultLogSkipIndexType
+}
+
+// skippable tells whether the indexes can skip the granules not matching the filter
+// operator, the query builders filter with ILIKE which neither of them skips on
+func skippable(op v3.FilterOperator) bool {
+	switch op {
+	case v3.FilterOperatorEqual, v3.FilterOperatorIn:
+		return true
+	}
+	return false
+}
+
+type PostableIndex struct {
+	// Signal is the data source of the key, logs or traces
+	Signal v3.DataSource `json:"signal"`
+	Key    Key           `json:"key"`
+	Type   Type          `json:"type"`
+	// Granularity is how many granules an entry of the index covers, it defaults to 64
+	Granularity int `json:"granularity"`
+}
+
+func (p *PostableIndex) Validate() error {
+	switch p.Signal {
+	case v3.DataSourceLogs, v3.DataSourceTraces:
+	default:
+		return fmt.Errorf("unsupported signal %q, expected %s or %s", p.Signal, v3.DataSourceLogs, v3.DataSourceTraces)
+	}
+	switch p.Type {
+	case TypeTokenBF, TypeBloomFilter:
+	case "":
+		p.Type = TypeBloomFilter
+	default:
+		return fmt.Errorf("unknown index type %q, expected %s or %s", p.Type, TypeTokenBF, TypeBloomFilter)
+	}
+	if p.Granularity == 0 {
+		p.Granularity = constants.DefaultLogSkipIndexGranularity
+	}
+	if p.Granularity < 0 || p.Granularity > MaxGranularity {
+		return fmt.Errorf("granularity must be between 1 and %d", MaxGranularity)
+	}
+
+	key := v3.AttributeKey(p.Key)
+	if key.IsJSON {
+		return fmt.Errorf("the JSON key %s can't be indexed", key.Key)
+	}
+	if err := key.Validate(); err != nil {
+		return err
+	}
+	if key.Type != v3.AttributeKeyTypeTag && key.Type != v3.AttributeKeyTypeResource {
+		return fmt.Errorf("only the attribute and resource keys can be indexed, %s has the type %q", key.Key, key.Type)
+	}
+	if strings.ContainsAny(key.Key, "'`\\") {
+		return fmt.Errorf("the key %s has quotes or backslashes", key.Key)
+	}
+	switch key.DataType {
+	case v3.AttributeKeyDataTypeString:
+	case v3.AttributeKeyDataTypeInt64, v3.AttributeKeyDataTypeFloat64:
+		if p.Type == TypeTokenBF {
+			return fmt.Errorf("the %s index is only for the string keys", TypeTokenBF)
+		}
+		// the resources of the spans are only strings
+		if p.Signal == v3.DataSourceTraces && key.Type == v3.AttributeKeyTypeResource {
+			return fmt.Errorf("the resource key %s of the traces is a string", key.Key)
+		}
+	default:
+		// the bool keys have too few values for an index to skip anything
+		return fmt.Errorf("the key %s has the unsupported data type %q", key.Key, key.DataType)
+	}
+	// the index is on the value in the attribute maps whether it is materialized or not
+	p.Key.IsColumn = false
+	return nil
+}
+
+// Index is a skip index on the values of a key of the logs or the spans
+type Index struct {
+	Id          string        `json:"id" db:"id"`
+	Name        string        `json:"name" db:"name"`
+	Signal      v3.DataSource `json:"signal" db:"signal"`
+	Key         Key           `json:"key" db:"key"`
+	Type        Type          `json:"type" db:"index_type"`
+	Granularity int           `json:"granularity" db:"granularity"`
+	CreatedBy   string        `json:"createdBy" db:"created_by"`
+	CreatedAt   time.Time     `json:"createdAt" db:"created_at"`
+
+	Materialization *Materialization `json:"materialization,omitempty" db:"-"`
+}
+
+// indexName returns the name of the index of the key, it doesn't collide with the indexes
+// of the materialized columns
+func indexName(key v3.AttributeKey) string {
+	return fmt.Sprintf("skip_%s_%s_%s_idx", key.Type, key.DataType, nonIdentifierRegex.ReplaceAllString(key.Key, "_"))
+}
+
+// Materialization is the progress of building the index for the parts written before it
+// was added, the parts written after have it from the start
+type Materialization struct {
+	// Parts is the number of active parts of the table on all the replicas
+	Parts uint64 `json:"parts"`
+	// PartsToDo is the number of parts still to be indexed
+	PartsToDo int64 `json:"partsToDo"`
+	// Progress is the share of the parts indexed, between 0 and 1
+	Progress   float64 `json:"progress"`
+	Done       bool    `json:"done"`
+	FailReason string  `json:"failReason,omitempty"`
+}
+
+// Benefit is how the recent queries would have been helped by an index on a key
+type Benefit struct {
+	// Analyzed is how many recent builder queries were looked at
+	Analyzed int `json:"analyzed"`
+	// Queries is how many of them filter on the key with an operator the index skips on
+	Queries int `json:"queries"`
+	// Operators counts the filters on the key by operator, whether the index skips on them or not
+	Operators  map[v3.FilterOperator]int `json:"operators"`
+	DurationMs int64                     `json:"durationMs"`
+	RowsRead   uint64                    `json:"rowsRead"`
+	BytesRead  uint64                    `json:"bytesRead"`
+	// Since is when the oldest analyzed query was run
+	Since *time.Time `json:"since,omitempty"`
+}
+
+type Key v3.AttributeKey
+
+func (k Key) Value() (driver.Value, error) {
+	return json.Marshal(k)
+}
+
+func (k *Key) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, k)
+	case string:
+		return json.Unmarshal([]byte(data), k)
+	default:
+		return fmt.Errorf("unsupported type %T for key", src)
+	}
+}
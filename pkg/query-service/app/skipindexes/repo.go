@@ -0,0 +1,90 @@
+package skipindexes
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS skip_indexes (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		signal TEXT NOT NULL,
+		key TEXT NOT NULL,
+		index_type TEXT NOT NULL,
+		granularity INTEGER NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at datetime NOT NULL,
+		UNIQUE(signal, name)
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating skip_indexes table: %s", err.Error())
+	}
+	return nil
+}
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func (r *repo) list(ctx context.Context) ([]*Index, *model.ApiError) {
+	indexes := []*Index{}
+	err := r.db.SelectContext(ctx, &indexes, "SELECT * FROM skip_indexes ORDER BY created_at")
+	if err != nil {
+		zap.L().Error("failed to get skip indexes from db", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to get skip indexes from db"))
+	}
+	return indexes, nil
+}
+
+func (r *repo) get(ctx context.Context, id string) (*Index, *model.ApiError) {
+	idx := &Index{}
+	err := r.db.GetContext(ctx, idx, "SELECT * FROM skip_indexes WHERE id = $1", id)
+	if err == sql.ErrNoRows {
+		return nil, model.NotFoundError(fmt.Errorf("no skip index found with id %s", id))
+	}
+	if err != nil {
+		zap.L().Error("failed to get skip index from db", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to get skip index from db"))
+	}
+	return idx, nil
+}
+
+func (r *repo) exists(ctx context.Context, signal, name string) (bool, *model.ApiError) {
+	var count int
+	err := r.db.GetContext(ctx, &count, "SELECT count(*) FROM skip_indexes WHERE signal = $1 AND name = $2", signal, name)
+	if err != nil {
+		zap.L().Error("failed to get skip index from db", zap.Error(err))
+		return false, model.InternalError(errors.Wrap(err, "failed to get skip index from db"))
+	}
+	return count > 0, nil
+}
+
+func (r *repo) insert(ctx context.Context, idx *Index) *model.ApiError {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO skip_indexes (id, name, signal, key, index_type, granularity, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		idx.Id, idx.Name, idx.Signal, idx.Key, idx.Type, idx.Granularity, idx.CreatedBy, idx.CreatedAt)
+	if err != nil {
+		zap.L().Error("failed to insert skip index in db", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to insert skip index in db"))
+	}
+	return nil
+}
+
+func (r *repo) delete(ctx context.Context, id string) *model.ApiError {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM skip_indexes WHERE id = $1", id); err != nil {
+		zap.L().Error("failed to delete skip index from db", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to delete skip index from db"))
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/spanmetrics"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// spanMetricsHistoryLimit is the number of config versions listed with the latest config
+const spanMetricsHistoryLimit = 10
+
+func (aH *APIHandler) getSpanMetricsConfig(w http.ResponseWriter, r *http.Request) {
+	var payload *spanmetrics.ConfigResponse
+	var apiErr *model.ApiError
+	if versionString, ok := mux.Vars(r)["version"]; ok {
+		version, err := strconv.Atoi(versionString)
+		if err != nil {
+			RespondError(w, model.BadRequest(fmt.Errorf("invalid version %s", versionString)), nil)
+			return
+		}
+		payload, apiErr = aH.SpanMetricsController.GetConfigByVersion(r.Context(), version)
+	} else {
+		payload, apiErr = aH.SpanMetricsController.GetLatestConfig(r.Context(), spanMetricsHistoryLimit)
+	}
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, payload)
+}
+
+func (aH *APIHandler) applySpanMetricsConfig(w http.ResponseWriter, r *http.Request) {
+	var config spanmetrics.Config
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	payload, apiErr := aH.SpanMetricsController.ApplyConfig(r.Context(), &config)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, payload)
+}
@@ -0,0 +1,84 @@
+package spanmetrics
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// spanMetricsProcessorPrefix matches the span metrics processors of the collector
+// such as signozspanmetrics/cumulative and signozspanmetrics/delta
+const spanMetricsProcessorPrefix = "signozspanmetrics"
+
+// GenerateCollectorConfigWithSpanMetrics updates the span metrics processors of the
+// collector config, the config is left as is when the collector has no such processor
+func GenerateCollectorConfigWithSpanMetrics(
+	config []byte,
+	spanMetricsConfig *Config,
+) ([]byte, *model.ApiError) {
+	var c map[string]interface{}
+	if err := yaml.Unmarshal(config, &c); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	processors, ok := c["processors"].(map[string]interface{})
+	if !ok {
+		return config, nil
+	}
+
+	updated := false
+	for name, processorConf := range processors {
+		if name != spanMetricsProcessorPrefix && !strings.HasPrefix(name, spanMetricsProcessorPrefix+"/") {
+			continue
+		}
+		conf, ok := processorConf.(map[string]interface{})
+		if !ok {
+			conf = map[string]interface{}{}
+		}
+		applyConfig(conf, spanMetricsConfig)
+		processors[name] = conf
+		updated = true
+	}
+	if !updated {
+		return config, nil
+	}
+
+	updatedConf, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, model.BadRequest(err)
+	}
+	return updatedConf, nil
+}
+
+func applyConfig(conf map[string]interface{}, config *Config) {
+	dimensions := make([]interface{}, 0, len(config.Dimensions))
+	for _, dimension := range config.Dimensions {
+		d := map[string]interface{}{"name": dimension.Name}
+		if dimension.Default != nil {
+			d["default"] = *dimension.Default
+		}
+		dimensions = append(dimensions, d)
+	}
+	conf["dimensions"] = dimensions
+
+	if len(config.LatencyHistogramBuckets) > 0 {
+		conf["latency_histogram_buckets"] = config.LatencyHistogramBuckets
+	}
+
+	// the buckets of the services fall back to the latency histogram buckets
+	if len(config.ServiceBuckets) > 0 {
+		serviceBuckets := map[string]interface{}{}
+		for _, service := range config.ServiceBuckets {
+			serviceBuckets[service.ServiceName] = service.Buckets
+		}
+		conf["service_latency_histogram_buckets"] = serviceBuckets
+	} else {
+		delete(conf, "service_latency_histogram_buckets")
+	}
+
+	if config.DimensionsCacheSize > 0 {
+		conf["dimensions_cache_size"] = config.DimensionsCacheSize
+	}
+}
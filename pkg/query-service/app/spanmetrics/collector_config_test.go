@@ -0,0 +1,113 @@
+package spanmetrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testCollectorConf = `
+processors:
+  batch: {}
+  signozspanmetrics/cumulative:
+    metrics_exporter: clickhousemetricswrite
+    latency_histogram_buckets: [100us, 1ms, 2ms]
+    dimensions_cache_size: 100000
+    dimensions:
+      - name: service.namespace
+        default: default
+  signozspanmetrics/delta:
+    metrics_exporter: clickhousemetricswrite
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [signozspanmetrics/cumulative, signozspanmetrics/delta, batch]
+      exporters: [clickhousetraces]
+`
+
+func TestGenerateCollectorConfigWithSpanMetrics(t *testing.T) {
+	region := "unknown"
+	config := &Config{
+		Dimensions: []Dimension{{Name: "tenant"}, {Name: "region", Default: &region}},
+		ServiceBuckets: []ServiceBuckets{
+			{ServiceName: "checkout", Buckets: []string{"10ms", "100ms", "1s"}},
+		},
+	}
+	require.Nil(t, config.Validate())
+
+	updated, apiErr := GenerateCollectorConfigWithSpanMetrics([]byte(testCollectorConf), config)
+	require.Nil(t, apiErr)
+
+	var c map[string]interface{}
+	require.Nil(t, yaml.Unmarshal(updated, &c))
+	processors := c["processors"].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{}, processors["batch"])
+
+	for _, name := range []string{"signozspanmetrics/cumulative", "signozspanmetrics/delta"} {
+		conf := processors[name].(map[string]interface{})
+		require.Equal(t, "clickhousemetricswrite", conf["metrics_exporter"])
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"name": "tenant"},
+			map[string]interface{}{"name": "region", "default": "unknown"},
+		}, conf["dimensions"], name)
+		require.Equal(t, map[string]interface{}{
+			"checkout": []interface{}{"10ms", "100ms", "1s"},
+		}, conf["service_latency_histogram_buckets"], name)
+	}
+
+	// the settings of the collector are kept when they are not configured
+	cumulative := processors["signozspanmetrics/cumulative"].(map[string]interface{})
+	require.Equal(t, []interface{}{"100us", "1ms", "2ms"}, cumulative["latency_histogram_buckets"])
+	require.Equal(t, 100000, cumulative["dimensions_cache_size"])
+}
+
+func TestGenerateCollectorConfigWithoutSpanMetrics(t *testing.T) {
+	conf := []byte("processors:\n  batch: {}\n")
+	updated, apiErr := GenerateCollectorConfigWithSpanMetrics(conf, &Config{Dimensions: []Dimension{{Name: "tenant"}}})
+	require.Nil(t, apiErr)
+	require.Equal(t, conf, updated)
+}
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  Config
+		isValid bool
+	}{
+		{
+			name:    "valid",
+			config:  Config{Dimensions: []Dimension{{Name: "tenant"}}, LatencyHistogramBuckets: []string{"1ms", "10ms", "1s"}},
+			isValid: true,
+		},
+		{
+			name:   "reserved dimension",
+			config: Config{Dimensions: []Dimension{{Name: "service.name"}}},
+		},
+		{
+			name:   "duplicate dimension",
+			config: Config{Dimensions: []Dimension{{Name: "tenant"}, {Name: "tenant"}}},
+		},
+		{
+			name:   "unordered buckets",
+			config: Config{LatencyHistogramBuckets: []string{"10ms", "1ms"}},
+		},
+		{
+			name:   "invalid bucket",
+			config: Config{LatencyHistogramBuckets: []string{"10"}},
+		},
+		{
+			name:   "service without buckets",
+			config: Config{ServiceBuckets: []ServiceBuckets{{ServiceName: "checkout"}}},
+		},
+	}
+	for _, tc := range testCases {
+		err := tc.config.Validate()
+		if tc.isValid {
+			require.Nil(t, err, tc.name)
+		} else {
+			require.NotNil(t, err, tc.name)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package spanmetrics
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// Controller takes care of the deployment cycle of the span metrics config
+type SpanMetricsController struct {
+	Repo
+}
+
+func NewSpanMetricsController(db *sqlx.DB, engine string) (*SpanMetricsController, error) {
+	repo := NewRepo(db)
+	err := repo.InitDB(engine)
+	return &SpanMetricsController{Repo: repo}, err
+}
+
+// ApplyConfig stores the config and initiates a new config update of the collectors
+func (sc *SpanMetricsController) ApplyConfig(ctx context.Context, config *Config) (*ConfigResponse, *model.ApiError) {
+	if err := config.Validate(); err != nil {
+		return nil, model.BadRequest(errors.Wrap(err, "span metrics config is not valid"))
+	}
+
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	id, apiErr := sc.insertConfig(ctx, userId, config)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	cfg, apiErr := agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeDef(SpanMetricsFeatureType), []string{id})
+	if apiErr != nil || cfg == nil {
+		return nil, apiErr
+	}
+	return sc.GetConfigByVersion(ctx, cfg.Version)
+}
+
+// GetConfigByVersion responds with version info and the associated config
+func (sc *SpanMetricsController) GetConfigByVersion(ctx context.Context, version int) (*ConfigResponse, *model.ApiError) {
+	configVersion, apiErr := agentConf.GetConfigVersion(ctx, agentConf.ElementTypeDef(SpanMetricsFeatureType), version)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to get config for given version")
+	}
+	config, apiErr := sc.getConfigByVersion(ctx, version)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return &ConfigResponse{ConfigVersion: configVersion, Config: config}, nil
+}
+
+// GetLatestConfig responds with the latest config along with the config version history,
+// the config is empty when no config was applied yet
+func (sc *SpanMetricsController) GetLatestConfig(ctx context.Context, limit int) (*ConfigResponse, *model.ApiError) {
+	response := &ConfigResponse{Config: &Config{Dimensions: []Dimension{}, LatencyHistogramBuckets: []string{}, ServiceBuckets: []ServiceBuckets{}}}
+
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeDef(SpanMetricsFeatureType))
+	if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+		return nil, model.WrapApiError(apiErr, "failed to get latest span metrics config version")
+	}
+	if latest != nil {
+		if response, apiErr = sc.GetConfigByVersion(ctx, latest.Version); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	history, apiErr := agentConf.GetConfigHistory(ctx, agentConf.ElementTypeDef(SpanMetricsFeatureType), limit)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to get span metrics config history")
+	}
+	response.History = history
+	return response, nil
+}
+
+// Implements agentConf.AgentFeature interface.
+func (sc *SpanMetricsController) AgentFeatureType() agentConf.AgentFeatureType {
+	return SpanMetricsFeatureType
+}
+
+// Implements agentConf.AgentFeature interface.
+func (sc *SpanMetricsController) RecommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	// the collectors keep their own span metrics config until one is applied
+	if configVersion == nil {
+		return currentConfYaml, "", nil
+	}
+
+	config, apiErr := sc.getConfigByVersion(context.Background(), configVersion.Version)
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+
+	updatedConf, apiErr := GenerateCollectorConfigWithSpanMetrics(currentConfYaml, config)
+	if apiErr != nil {
+		return nil, "", model.WrapApiError(apiErr, "could not marshal yaml for updated conf")
+	}
+
+	rawConfig, err := json.Marshal(config)
+	if err != nil {
+		return nil, "", model.BadRequest(errors.Wrap(err, "could not serialize span metrics config to JSON"))
+	}
+	return updatedConf, string(rawConfig), nil
+}
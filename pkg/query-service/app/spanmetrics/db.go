@@ -0,0 +1,100 @@
+package spanmetrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// Repo handles DDL and DML ops on span metrics configs
+type Repo struct {
+	db *sqlx.DB
+}
+
+type storedConfig struct {
+	Id        string    `db:"id"`
+	CreatedBy string    `db:"created_by"`
+	CreatedAt time.Time `db:"created_at"`
+	RawConfig string    `db:"config_json"`
+}
+
+func NewRepo(db *sqlx.DB) Repo {
+	return Repo{
+		db: db,
+	}
+}
+
+func (r *Repo) InitDB(engine string) error {
+	switch engine {
+	case "sqlite3", "sqlite":
+	default:
+		return fmt.Errorf("unsupported db")
+	}
+
+	tableSchema := `CREATE TABLE IF NOT EXISTS span_metrics_configs(
+		id TEXT PRIMARY KEY,
+		created_by TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		config_json TEXT NOT NULL
+	);`
+	if _, err := r.db.Exec(tableSchema); err != nil {
+		return errors.Wrap(err, "Error in creating span metrics configs table")
+	}
+	return nil
+}
+
+// insertConfig stores the config, every version gets its own copy of the config
+func (r *Repo) insertConfig(ctx context.Context, userId string, config *Config) (string, *model.ApiError) {
+	rawConfig, err := json.Marshal(config)
+	if err != nil {
+		return "", model.BadRequest(errors.Wrap(err, "failed to marshal span metrics config"))
+	}
+
+	id := uuid.NewString()
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO span_metrics_configs (id, created_by, created_at, config_json) VALUES ($1, $2, $3, $4)`,
+		id, userId, time.Now(), string(rawConfig))
+	if err != nil {
+		zap.L().Error("error in inserting span metrics config", zap.Error(err))
+		return "", model.InternalError(errors.Wrap(err, "failed to insert span metrics config"))
+	}
+	return id, nil
+}
+
+// getConfigByVersion returns the config associated with a given version
+func (r *Repo) getConfigByVersion(ctx context.Context, version int) (*Config, *model.ApiError) {
+	configs := []storedConfig{}
+
+	versionQuery := `SELECT c.id,
+		c.created_by,
+		c.created_at,
+		c.config_json
+		FROM span_metrics_configs c,
+			 agent_config_elements e,
+			 agent_config_versions v
+		WHERE c.id = e.element_id
+		AND v.id = e.version_id
+		AND e.element_type = $1
+		AND v.version = $2`
+
+	err := r.db.SelectContext(ctx, &configs, versionQuery, SpanMetricsFeatureType, version)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get span metrics config from db"))
+	}
+	if len(configs) == 0 {
+		return nil, model.NotFoundError(fmt.Errorf("no span metrics config found for version %d", version))
+	}
+
+	var config Config
+	if err := json.Unmarshal([]byte(configs[0].RawConfig), &config); err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "found an invalid span metrics config"))
+	}
+	return &config, nil
+}
@@ -0,0 +1,122 @@
+package spanmetrics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+)
+
+const SpanMetricsFeatureType agentConf.AgentFeatureType = "span_metrics"
+
+const (
+	maxDimensions = 20
+	maxBuckets    = 50
+)
+
+// reservedDimensions are always added to the span metrics by the processor
+var reservedDimensions = map[string]bool{
+	"service.name": true,
+	"operation":    true,
+	"span.kind":    true,
+	"status.code":  true,
+}
+
+// Dimension is a span or resource attribute added as a label to the span metrics,
+// the default value is used for the spans without the attribute
+type Dimension struct {
+	Name    string  `json:"name" yaml:"name"`
+	Default *string `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// ServiceBuckets overrides the latency histogram buckets of a service
+type ServiceBuckets struct {
+	ServiceName string   `json:"serviceName"`
+	Buckets     []string `json:"buckets"`
+}
+
+// Config is the span metrics configuration pushed to the collectors
+type Config struct {
+	Dimensions []Dimension `json:"dimensions"`
+	// LatencyHistogramBuckets are durations such as 100us or 2s, the
+	// buckets of the collector are kept when empty
+	LatencyHistogramBuckets []string         `json:"latencyHistogramBuckets"`
+	ServiceBuckets          []ServiceBuckets `json:"serviceBuckets"`
+	DimensionsCacheSize     int              `json:"dimensionsCacheSize,omitempty"`
+}
+
+// ConfigResponse is used to prepare the http response of the span metrics config requests
+type ConfigResponse struct {
+	*agentConf.ConfigVersion
+
+	Config  *Config                   `json:"config"`
+	History []agentConf.ConfigVersion `json:"history"`
+}
+
+func validateBuckets(buckets []string) error {
+	if len(buckets) > maxBuckets {
+		return fmt.Errorf("at most %d buckets are allowed", maxBuckets)
+	}
+	var previous time.Duration
+	for i, bucket := range buckets {
+		d, err := time.ParseDuration(bucket)
+		if err != nil {
+			return errors.Wrapf(err, "invalid bucket %s", bucket)
+		}
+		if d <= 0 {
+			return fmt.Errorf("bucket %s must be positive", bucket)
+		}
+		if i > 0 && d <= previous {
+			return fmt.Errorf("buckets must be in increasing order, %s is not greater than %s", bucket, buckets[i-1])
+		}
+		previous = d
+	}
+	return nil
+}
+
+func (c *Config) Validate() error {
+	if len(c.Dimensions) > maxDimensions {
+		return fmt.Errorf("at most %d dimensions are allowed", maxDimensions)
+	}
+	seen := map[string]bool{}
+	for _, dimension := range c.Dimensions {
+		name := strings.TrimSpace(dimension.Name)
+		if name == "" {
+			return fmt.Errorf("dimension name is required")
+		}
+		if reservedDimensions[name] {
+			return fmt.Errorf("dimension %s is always added to the span metrics", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate dimension %s", name)
+		}
+		seen[name] = true
+	}
+
+	if err := validateBuckets(c.LatencyHistogramBuckets); err != nil {
+		return errors.Wrap(err, "invalid latency histogram buckets")
+	}
+	services := map[string]bool{}
+	for _, service := range c.ServiceBuckets {
+		if strings.TrimSpace(service.ServiceName) == "" {
+			return fmt.Errorf("service name is required for the service buckets")
+		}
+		if services[service.ServiceName] {
+			return fmt.Errorf("duplicate buckets for service %s", service.ServiceName)
+		}
+		services[service.ServiceName] = true
+		if len(service.Buckets) == 0 {
+			return fmt.Errorf("buckets are required for service %s", service.ServiceName)
+		}
+		if err := validateBuckets(service.Buckets); err != nil {
+			return errors.Wrapf(err, "invalid latency histogram buckets for service %s", service.ServiceName)
+		}
+	}
+
+	if c.DimensionsCacheSize < 0 {
+		return fmt.Errorf("dimensions cache size must not be negative")
+	}
+	return nil
+}
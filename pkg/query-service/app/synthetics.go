@@ -0,0 +1,244 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/synthetics"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// syntheticsHistoryLimit is the number of config versions listed with the checks
+const syntheticsHistoryLimit = 10
+
+func (aH *APIHandler) listSyntheticChecks(w http.ResponseWriter, r *http.Request) {
+	payload, apiErr := aH.SyntheticsController.ListChecks(r.Context(), syntheticsHistoryLimit)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, payload)
+}
+
+func (aH *APIHandler) getSyntheticCheck(w http.ResponseWriter, r *http.Request) {
+	check, apiErr := aH.SyntheticsController.GetCheck(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, check)
+}
+
+func (aH *APIHandler) createSyntheticCheck(w http.ResponseWriter, r *http.Request) {
+	var postable synthetics.PostableCheck
+	if err := json.NewDecoder(r.Body).Decode(&postable); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	check, apiErr := aH.SyntheticsController.CreateCheck(r.Context(), &postable)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, check)
+}
+
+func (aH *APIHandler) updateSyntheticCheck(w http.ResponseWriter, r *http.Request) {
+	var postable synthetics.PostableCheck
+	if err := json.NewDecoder(r.Body).Decode(&postable); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	check, apiErr := aH.SyntheticsController.UpdateCheck(r.Context(), mux.Vars(r)["id"], &postable)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, check)
+}
+
+// deleteSyntheticCheck deletes the check along with its alert rule
+func (aH *APIHandler) deleteSyntheticCheck(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	check, apiErr := aH.SyntheticsController.GetCheck(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if apiErr := aH.SyntheticsController.DeleteCheck(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if check.AlertId != "" {
+		if err := aH.ruleManager.DeleteRule(r.Context(), check.AlertId); err != nil {
+			zap.L().Error("failed to delete the alert of the synthetic check", zap.String("check", id), zap.String("rule", check.AlertId), zap.Error(err))
+		}
+	}
+	aH.Respond(w, nil)
+}
+
+// createSyntheticCheckAlert creates the alert rule firing when the check is down, the
+// existing alert of the check is replaced
+func (aH *APIHandler) createSyntheticCheckAlert(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	check, apiErr := aH.SyntheticsController.GetCheck(r.Context(), id)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	data, err := json.Marshal(synthetics.AlertRule(check))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	rule, err := aH.ruleManager.CreateRule(r.Context(), string(data))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if apiErr := aH.SyntheticsController.SetAlertId(r.Context(), id, rule.Id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if check.AlertId != "" {
+		if err := aH.ruleManager.DeleteRule(r.Context(), check.AlertId); err != nil {
+			zap.L().Error("failed to delete the previous alert of the synthetic check", zap.String("check", id), zap.String("rule", check.AlertId), zap.Error(err))
+		}
+	}
+	aH.Respond(w, rule)
+}
+
+// parseUptimeParams reads the range of the uptime from the start and end params, in
+// milliseconds, and the step of the series in seconds
+func parseUptimeParams(r *http.Request) (*synthetics.UptimeParams, error) {
+	query := r.URL.Query()
+	params := &synthetics.UptimeParams{}
+	for param, value := range map[string]*int64{"start": &params.Start, "end": &params.End, "step": &params.Step} {
+		if s := query.Get(param); s != "" {
+			v, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s param is not a valid integer", param)
+			}
+			*value = v
+		}
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// syntheticsAverages returns the averages of the metric over the range by the label
+func (aH *APIHandler) syntheticsAverages(ctx context.Context, metric, checkId string, by []string, params *synthetics.UptimeParams) (map[string]float64, *model.ApiError) {
+	query, apiErr := scopePromQLToOrg(ctx, synthetics.AvgQuery(metric, checkId, by, params.Window()))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	res, _, apiErr := aH.reader.GetInstantQueryMetricsResult(ctx, &model.InstantQueryMetricsParams{
+		Time:  time.UnixMilli(params.End),
+		Query: query,
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	label := ""
+	if len(by) > 0 {
+		label = by[0]
+	}
+	values, err := synthetics.VectorByLabel(res, label)
+	if err != nil {
+		zap.L().Error("Error while reading the synthetics metrics", zap.String("query", query), zap.Error(err))
+		return nil, &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return values, nil
+}
+
+// getSyntheticsUptime returns the uptime and the average duration of all the checks
+func (aH *APIHandler) getSyntheticsUptime(w http.ResponseWriter, r *http.Request) {
+	params, err := parseUptimeParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	by := []string{"check_id"}
+	uptimes, apiErr := aH.syntheticsAverages(r.Context(), synthetics.UpMetric, "", by, params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	durations, apiErr := aH.syntheticsAverages(r.Context(), synthetics.DurationMetric, "", by, params)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, synthetics.MergeUptimes("check_id", uptimes, durations))
+}
+
+// getSyntheticCheckUptime returns the uptime of the check overall and per region, with the
+// uptime series of the regions
+func (aH *APIHandler) getSyntheticCheckUptime(w http.ResponseWriter, r *http.Request) {
+	check, apiErr := aH.SyntheticsController.GetCheck(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	params, err := parseUptimeParams(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+
+	result := synthetics.CheckUptime{}
+	for _, by := range [][]string{{}, {"region"}} {
+		uptimes, apiErr := aH.syntheticsAverages(r.Context(), synthetics.UpMetric, check.Id, by, params)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		durations, apiErr := aH.syntheticsAverages(r.Context(), synthetics.DurationMetric, check.Id, by, params)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		merged := synthetics.MergeUptimes("region", uptimes, durations)
+		if len(by) > 0 {
+			result.Regions = merged
+		} else if len(merged) > 0 {
+			result.Uptime = merged[0]
+		}
+	}
+	result.CheckId = check.Id
+
+	step := time.Duration(params.Step) * time.Second
+	query, apiErr := scopePromQLToOrg(r.Context(), synthetics.AvgQuery(synthetics.UpMetric, check.Id, []string{"region"}, step))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	res, _, apiErr := aH.reader.GetQueryRangeResult(r.Context(), &model.QueryRangeParams{
+		Start: time.UnixMilli(params.Start),
+		End:   time.UnixMilli(params.End),
+		Step:  step,
+		Query: query,
+	})
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if result.Series, err = synthetics.UptimeSeriesByRegion(res); err != nil {
+		zap.L().Error("Error while reading the uptime series", zap.Error(err))
+		RespondError(w, &model.ApiError{Typ: model.ErrorExec, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, result)
+}
@@ -0,0 +1,133 @@
+package synthetics
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// syntheticsReceiver runs the checks on the agents and reports their results as metrics.
+// The agents run the checks of their region, set in the receiver by the agent itself,
+// and the checks without region
+const syntheticsReceiver = "signoz_synthetics"
+
+// Metrics reported by the synthetics receiver, labelled with the check_id, check_name,
+// check_type and region of the result, and with the labels of the check
+const (
+	// UpMetric is 1 when the check succeeded and all its assertions passed, 0 otherwise
+	UpMetric = "signoz_synthetics_check_up"
+	// DurationMetric is the duration of the check in milliseconds
+	DurationMetric = "signoz_synthetics_check_duration_ms"
+)
+
+// checkConfig compiles the check into a check of the synthetics receiver
+func checkConfig(check Check) map[string]interface{} {
+	conf := map[string]interface{}{
+		"id":       check.Id,
+		"name":     check.Name,
+		"type":     string(check.Type),
+		"target":   check.Target,
+		"interval": fmt.Sprintf("%ds", check.FrequencySeconds),
+		"timeout":  fmt.Sprintf("%ds", check.TimeoutSeconds),
+	}
+	if check.Type == CheckTypeHTTP {
+		conf["method"] = check.Method
+		if len(check.Headers) > 0 {
+			headers := map[string]interface{}{}
+			for k, v := range check.Headers {
+				headers[k] = v
+			}
+			conf["headers"] = headers
+		}
+		if check.Body != "" {
+			conf["body"] = check.Body
+		}
+	}
+	if len(check.Assertions) > 0 {
+		assertions := []interface{}{}
+		for _, a := range check.Assertions {
+			assertion := map[string]interface{}{"type": string(a.Type), "operator": string(a.Operator), "value": a.Value}
+			if a.Property != "" {
+				assertion["property"] = a.Property
+			}
+			assertions = append(assertions, assertion)
+		}
+		conf["assertions"] = assertions
+	}
+	if len(check.Regions) > 0 {
+		regions := []interface{}{}
+		for _, r := range check.Regions {
+			regions = append(regions, r)
+		}
+		conf["regions"] = regions
+	}
+	// the metrics of the check are read in its org
+	if check.OrgId != "" {
+		conf["labels"] = map[string]interface{}{constants.OrgIdLabel: check.OrgId}
+	}
+	return conf
+}
+
+// ReceiverConfig compiles the enabled checks into the synthetics receiver config
+func ReceiverConfig(checks []Check) map[string]interface{} {
+	confs := []interface{}{}
+	for _, check := range checks {
+		if check.Enabled {
+			confs = append(confs, checkConfig(check))
+		}
+	}
+	if len(confs) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"checks": confs}
+}
+
+// GenerateCollectorConfigWithSynthetics adds the synthetics receiver compiled from the
+// checks to the metrics pipeline, the receiver is removed when no check is enabled
+func GenerateCollectorConfigWithSynthetics(config []byte, checks []Check) ([]byte, *model.ApiError) {
+	var c map[string]interface{}
+	if err := yaml.Unmarshal(config, &c); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	service, _ := c["service"].(map[string]interface{})
+	pipelines, _ := service["pipelines"].(map[string]interface{})
+	metrics, ok := pipelines["metrics"].(map[string]interface{})
+	if !ok {
+		// the collector does not export metrics
+		return config, nil
+	}
+
+	receivers, _ := c["receivers"].(map[string]interface{})
+	if receivers == nil {
+		receivers = map[string]interface{}{}
+	}
+	receiverConf := ReceiverConfig(checks)
+	if receiverConf != nil {
+		receivers[syntheticsReceiver] = receiverConf
+	} else {
+		delete(receivers, syntheticsReceiver)
+	}
+	c["receivers"] = receivers
+
+	current, _ := metrics["receivers"].([]interface{})
+	pipelineReceivers := []interface{}{}
+	for _, name := range current {
+		if name != syntheticsReceiver {
+			pipelineReceivers = append(pipelineReceivers, name)
+		}
+	}
+	if receiverConf != nil {
+		pipelineReceivers = append(pipelineReceivers, syntheticsReceiver)
+	}
+	metrics["receivers"] = pipelineReceivers
+
+	updatedConf, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, model.BadRequest(err)
+	}
+	return updatedConf, nil
+}
@@ -0,0 +1,141 @@
+package synthetics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"gopkg.in/yaml.v3"
+)
+
+const testCollectorConf = `
+receivers:
+  otlp: {}
+service:
+  pipelines:
+    metrics:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [clickhousemetricswrite]
+`
+
+func parseConf(t *testing.T, conf []byte) map[string]interface{} {
+	var c map[string]interface{}
+	require.Nil(t, yaml.Unmarshal(conf, &c))
+	return c
+}
+
+func metricsReceivers(c map[string]interface{}) []interface{} {
+	pipelines := c["service"].(map[string]interface{})["pipelines"].(map[string]interface{})
+	return pipelines["metrics"].(map[string]interface{})["receivers"].([]interface{})
+}
+
+func TestGenerateCollectorConfigWithSynthetics(t *testing.T) {
+	checks := []Check{
+		{
+			Id: "home", Name: "home page", Type: CheckTypeHTTP, Target: "https://example.com", Method: "GET",
+			Headers:          Headers{"Accept": "text/html"},
+			Assertions:       Assertions{{Type: AssertionStatusCode, Operator: OperatorEquals, Value: "200"}},
+			FrequencySeconds: 60, TimeoutSeconds: 10, Regions: Regions{"eu-west", "us-east"}, Enabled: true,
+		},
+		{Id: "db", Name: "db port", Type: CheckTypeTCP, Target: "db.internal:5432", FrequencySeconds: 30, TimeoutSeconds: 5, Enabled: true, OrgId: "org-2"},
+		{Id: "disabled", Name: "disabled", Type: CheckTypeICMP, Target: "example.com", FrequencySeconds: 60, TimeoutSeconds: 10},
+	}
+
+	updated, apiErr := GenerateCollectorConfigWithSynthetics([]byte(testCollectorConf), checks)
+	require.Nil(t, apiErr)
+	c := parseConf(t, updated)
+
+	require.Equal(t, []interface{}{"otlp", "signoz_synthetics"}, metricsReceivers(c))
+	receiver := c["receivers"].(map[string]interface{})["signoz_synthetics"].(map[string]interface{})
+	confs := receiver["checks"].([]interface{})
+	require.Len(t, confs, 2)
+
+	home := confs[0].(map[string]interface{})
+	require.Equal(t, "https://example.com", home["target"])
+	require.Equal(t, "60s", home["interval"])
+	require.Equal(t, map[string]interface{}{"Accept": "text/html"}, home["headers"])
+	require.Equal(t, []interface{}{"eu-west", "us-east"}, home["regions"])
+	require.Len(t, home["assertions"], 1)
+	require.Nil(t, home["labels"])
+	// the metrics of the checks are labelled with their org
+	require.Equal(t, map[string]interface{}{"signoz_org_id": "org-2"}, confs[1].(map[string]interface{})["labels"])
+
+	db := confs[1].(map[string]interface{})
+	require.Equal(t, "tcp", db["type"])
+	require.Equal(t, "5s", db["timeout"])
+	require.NotContains(t, db, "method")
+	require.NotContains(t, db, "regions")
+
+	// the receiver is removed once no check is enabled
+	updated, apiErr = GenerateCollectorConfigWithSynthetics(updated, checks[2:])
+	require.Nil(t, apiErr)
+	c = parseConf(t, updated)
+	require.Equal(t, []interface{}{"otlp"}, metricsReceivers(c))
+	require.NotContains(t, c["receivers"], "signoz_synthetics")
+}
+
+func TestGenerateCollectorConfigWithoutMetricsPipeline(t *testing.T) {
+	conf := []byte("service:\n  pipelines:\n    traces:\n      receivers: [otlp]\n")
+	updated, apiErr := GenerateCollectorConfigWithSynthetics(conf, []Check{{Id: "a", Type: CheckTypeICMP, Enabled: true}})
+	require.Nil(t, apiErr)
+	require.Equal(t, conf, updated)
+}
+
+func TestValidateCheck(t *testing.T) {
+	check := &PostableCheck{Name: "home", Type: CheckTypeHTTP, Target: "https://example.com/health", Method: "post"}
+	require.NoError(t, check.Validate())
+	require.Equal(t, "POST", check.Method)
+	require.Equal(t, DefaultFrequencySeconds, check.FrequencySeconds)
+	require.Equal(t, DefaultTimeoutSeconds, check.TimeoutSeconds)
+
+	for _, invalid := range []PostableCheck{
+		{Type: CheckTypeHTTP, Target: "https://example.com"},
+		{Name: "ftp", Type: CheckTypeHTTP, Target: "ftp://example.com"},
+		{Name: "tcp", Type: CheckTypeTCP, Target: "example.com"},
+		{Name: "icmp", Type: CheckTypeICMP, Target: "https://example.com"},
+		{Name: "icmp", Type: CheckTypeICMP, Target: "example.com", Method: "GET"},
+		{Name: "dns", Type: "dns", Target: "example.com"},
+		{Name: "fast", Type: CheckTypeICMP, Target: "example.com", FrequencySeconds: 10},
+		{Name: "slow", Type: CheckTypeTCP, Target: "example.com:443", FrequencySeconds: 30, TimeoutSeconds: 45},
+		{Name: "status", Type: CheckTypeTCP, Target: "example.com:443",
+			Assertions: Assertions{{Type: AssertionStatusCode, Operator: OperatorEquals, Value: "200"}}},
+		{Name: "header", Type: CheckTypeHTTP, Target: "https://example.com",
+			Assertions: Assertions{{Type: AssertionHeader, Operator: OperatorContains, Value: "json"}}},
+		{Name: "time", Type: CheckTypeHTTP, Target: "https://example.com",
+			Assertions: Assertions{{Type: AssertionResponseTime, Operator: OperatorLessThan, Value: "fast"}}},
+	} {
+		require.Error(t, invalid.Validate(), invalid.Name)
+	}
+}
+
+func TestUptimeQueries(t *testing.T) {
+	require.Equal(t,
+		`avg by (region) (avg_over_time(signoz_synthetics_check_up{check_id="home"}[3600s]))`,
+		AvgQuery(UpMetric, "home", []string{"region"}, time.Hour))
+	require.Equal(t,
+		`avg by (check_id) (avg_over_time(signoz_synthetics_check_duration_ms[60s]))`,
+		AvgQuery(DurationMetric, "", []string{"check_id"}, time.Minute))
+
+	params := &UptimeParams{Start: 1700000000000, End: 1700086400000}
+	require.NoError(t, params.Validate())
+	require.Equal(t, int64(87), params.Step)
+	require.Error(t, (&UptimeParams{Start: 1700000000000, End: 1700086400000, Step: 1}).Validate())
+
+	uptimes := MergeUptimes("region", map[string]float64{"us-east": 0.5, "eu-west": 1}, map[string]float64{"eu-west": 120})
+	require.Len(t, uptimes, 2)
+	require.Equal(t, "eu-west", uptimes[0].Region)
+	require.Equal(t, float64(100), *uptimes[0].UptimePercent)
+	require.Equal(t, float64(120), *uptimes[0].AvgDurationMs)
+	require.Nil(t, uptimes[1].AvgDurationMs)
+}
+
+func TestAlertRule(t *testing.T) {
+	rule := AlertRule(&Check{Id: "home", Name: "home page", Type: CheckTypeHTTP, Target: "https://example.com", FrequencySeconds: 600})
+	require.Equal(t, rules.Duration(20*time.Minute), rule.EvalWindow)
+	require.Equal(t, rules.ValueIsBelow, rule.RuleCondition.CompareOp)
+	require.Equal(t, `avg by (check_id, check_name, region) (signoz_synthetics_check_up{check_id="home"})`,
+		rule.RuleCondition.CompositeQuery.PromQueries["A"].Query)
+	require.Equal(t, "home", rule.Labels["check_id"])
+}
@@ -0,0 +1,143 @@
+package synthetics
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"gopkg.in/yaml.v3"
+)
+
+// SyntheticsController takes care of the deployment cycle of the synthetic checks,
+// every change of the checks starts a new config version which is pushed to the agents
+type SyntheticsController struct {
+	Repo
+}
+
+func NewSyntheticsController(db *sqlx.DB, engine string) (*SyntheticsController, error) {
+	repo := NewRepo(db)
+	err := repo.InitDB(engine)
+	return &SyntheticsController{Repo: repo}, err
+}
+
+// ListChecks responds with the checks along with the config version history
+func (sc *SyntheticsController) ListChecks(ctx context.Context, limit int) (*ChecksResponse, *model.ApiError) {
+	checks, apiErr := sc.getChecks(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	latest, apiErr := agentConf.GetLatestVersion(ctx, agentConf.ElementTypeSyntheticChecks)
+	if apiErr != nil && apiErr.Type() != model.ErrorNotFound {
+		return nil, model.WrapApiError(apiErr, "failed to get latest synthetic checks version")
+	}
+	history, apiErr := agentConf.GetConfigHistory(ctx, agentConf.ElementTypeSyntheticChecks, limit)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "failed to get synthetic checks history")
+	}
+	return &ChecksResponse{ConfigVersion: latest, Checks: checks, History: history}, nil
+}
+
+func (sc *SyntheticsController) CreateCheck(ctx context.Context, postable *PostableCheck) (*Check, *model.ApiError) {
+	if err := postable.Validate(); err != nil {
+		return nil, model.BadRequest(errors.Wrap(err, "synthetic check is not valid"))
+	}
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	check, apiErr := sc.insertCheck(ctx, userId, postable)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := sc.deploy(ctx, userId); apiErr != nil {
+		return nil, apiErr
+	}
+	return check, nil
+}
+
+func (sc *SyntheticsController) UpdateCheck(ctx context.Context, id string, postable *PostableCheck) (*Check, *model.ApiError) {
+	if err := postable.Validate(); err != nil {
+		return nil, model.BadRequest(errors.Wrap(err, "synthetic check is not valid"))
+	}
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return nil, model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	if apiErr := sc.updateCheck(ctx, userId, id, postable); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := sc.deploy(ctx, userId); apiErr != nil {
+		return nil, apiErr
+	}
+	return sc.GetCheck(ctx, id)
+}
+
+func (sc *SyntheticsController) DeleteCheck(ctx context.Context, id string) *model.ApiError {
+	userId, authErr := auth.ExtractUserIdFromContext(ctx)
+	if authErr != nil {
+		return model.UnauthorizedError(errors.Wrap(authErr, "failed to get userId from context"))
+	}
+
+	if apiErr := sc.deleteCheck(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	return sc.deploy(ctx, userId)
+}
+
+// deploy starts a new config version with the current checks, the agents run the checks of
+// all the orgs
+func (sc *SyntheticsController) deploy(ctx context.Context, userId string) *model.ApiError {
+	checks, apiErr := sc.getChecks(context.Background())
+	if apiErr != nil {
+		return apiErr
+	}
+	elements := make([]string, len(checks))
+	for i, check := range checks {
+		elements[i] = check.Id
+	}
+
+	_, apiErr = agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeSyntheticChecks, elements)
+	return apiErr
+}
+
+// Implements agentConf.AgentFeature interface.
+func (sc *SyntheticsController) AgentFeatureType() agentConf.AgentFeatureType {
+	return SyntheticsFeatureType
+}
+
+// Implements agentConf.AgentFeature interface.
+func (sc *SyntheticsController) RecommendAgentConfig(
+	currentConfYaml []byte,
+	configVersion *agentConf.ConfigVersion,
+) (
+	recommendedConfYaml []byte,
+	serializedSettingsUsed string,
+	apiErr *model.ApiError,
+) {
+	// the agents run no check until the first one is created
+	if configVersion == nil {
+		return currentConfYaml, "", nil
+	}
+
+	checks, apiErr := sc.getChecks(context.Background())
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+
+	updatedConf, apiErr := GenerateCollectorConfigWithSynthetics(currentConfYaml, checks)
+	if apiErr != nil {
+		return nil, "", model.WrapApiError(apiErr, "could not marshal yaml for updated conf")
+	}
+
+	receiverConf, err := yaml.Marshal(ReceiverConfig(checks))
+	if err != nil {
+		return nil, "", model.BadRequest(errors.Wrap(err, "could not serialize synthetics receiver config"))
+	}
+	return updatedConf, string(receiverConf), nil
+}
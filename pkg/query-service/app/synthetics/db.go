@@ -0,0 +1,187 @@
+package synthetics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// Repo handles DDL and DML ops on synthetic checks
+type Repo struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) Repo {
+	return Repo{
+		db: db,
+	}
+}
+
+func (r *Repo) InitDB(engine string) error {
+	switch engine {
+	case "sqlite3", "sqlite":
+	default:
+		return fmt.Errorf("unsupported db")
+	}
+
+	tableSchema := `CREATE TABLE IF NOT EXISTS synthetic_checks(
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		type TEXT NOT NULL,
+		target TEXT NOT NULL,
+		method TEXT NOT NULL DEFAULT '',
+		headers TEXT NOT NULL DEFAULT '{}',
+		body TEXT NOT NULL DEFAULT '',
+		assertions TEXT NOT NULL DEFAULT '[]',
+		frequency_seconds INTEGER NOT NULL,
+		timeout_seconds INTEGER NOT NULL,
+		regions TEXT NOT NULL DEFAULT '[]',
+		enabled BOOLEAN,
+		alert_id TEXT NOT NULL DEFAULT '',
+		created_by TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_by TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`
+	if _, err := r.db.Exec(tableSchema); err != nil {
+		return errors.Wrap(err, "Error in creating synthetic checks table")
+	}
+	_, err := r.db.Exec(`ALTER TABLE synthetic_checks ADD COLUMN org_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return errors.Wrap(err, "Error in adding column org_id to synthetic checks table")
+	}
+	return nil
+}
+
+// getChecks returns the checks of the org of the user of the context, all the checks for
+// the agents
+func (r *Repo) getChecks(ctx context.Context) ([]Check, *model.ApiError) {
+	checks := []Check{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.SelectContext(ctx, &checks, `SELECT * FROM synthetic_checks WHERE `+condition+` ORDER BY created_at`, args...)
+	if err != nil {
+		zap.L().Error("failed to get synthetic checks from db", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to get synthetic checks from db"))
+	}
+	return checks, nil
+}
+
+func (r *Repo) GetCheck(ctx context.Context, id string) (*Check, *model.ApiError) {
+	var check Check
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.GetContext(ctx, &check, `SELECT * FROM synthetic_checks WHERE id = ? AND `+condition, append([]interface{}{id}, args...)...)
+	if err == sql.ErrNoRows {
+		return nil, model.NotFoundError(fmt.Errorf("no synthetic check found with id %s", id))
+	}
+	if err != nil {
+		zap.L().Error("failed to get synthetic check from db", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to get synthetic check from db"))
+	}
+	return &check, nil
+}
+
+// normalize replaces the unset headers, assertions and regions with empty ones
+func normalize(postable *PostableCheck) {
+	if postable.Headers == nil {
+		postable.Headers = Headers{}
+	}
+	if postable.Assertions == nil {
+		postable.Assertions = Assertions{}
+	}
+	if postable.Regions == nil {
+		postable.Regions = Regions{}
+	}
+}
+
+func (r *Repo) insertCheck(ctx context.Context, userId string, postable *PostableCheck) (*Check, *model.ApiError) {
+	normalize(postable)
+	now := time.Now()
+	check := &Check{
+		Id:               uuid.NewString(),
+		Name:             postable.Name,
+		Type:             postable.Type,
+		Target:           postable.Target,
+		Method:           postable.Method,
+		Headers:          postable.Headers,
+		Body:             postable.Body,
+		Assertions:       postable.Assertions,
+		FrequencySeconds: postable.FrequencySeconds,
+		TimeoutSeconds:   postable.TimeoutSeconds,
+		Regions:          postable.Regions,
+		Enabled:          postable.Enabled,
+		CreatedBy:        userId,
+		CreatedAt:        now,
+		UpdatedBy:        userId,
+		UpdatedAt:        now,
+		OrgId:            common.GetOrgIdFromContext(ctx),
+	}
+
+	_, err := r.db.ExecContext(ctx, `INSERT INTO synthetic_checks
+		(id, name, type, target, method, headers, body, assertions, frequency_seconds, timeout_seconds, regions, enabled,
+		created_by, created_at, updated_by, updated_at, org_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`,
+		check.Id, check.Name, check.Type, check.Target, check.Method, check.Headers, check.Body, check.Assertions,
+		check.FrequencySeconds, check.TimeoutSeconds, check.Regions, check.Enabled,
+		check.CreatedBy, check.CreatedAt, check.UpdatedBy, check.UpdatedAt, check.OrgId)
+	if err != nil {
+		zap.L().Error("error in inserting synthetic check", zap.Error(err))
+		return nil, model.InternalError(errors.Wrap(err, "failed to insert synthetic check"))
+	}
+	return check, nil
+}
+
+func (r *Repo) updateCheck(ctx context.Context, userId string, id string, postable *PostableCheck) *model.ApiError {
+	normalize(postable)
+	condition, args := common.OrgCondition(ctx)
+	result, err := r.db.ExecContext(ctx, `UPDATE synthetic_checks SET
+		name = ?, type = ?, target = ?, method = ?, headers = ?, body = ?, assertions = ?,
+		frequency_seconds = ?, timeout_seconds = ?, regions = ?, enabled = ?,
+		updated_by = ?, updated_at = ? WHERE id = ? AND `+condition,
+		append([]interface{}{postable.Name, postable.Type, postable.Target, postable.Method, postable.Headers, postable.Body, postable.Assertions,
+			postable.FrequencySeconds, postable.TimeoutSeconds, postable.Regions, postable.Enabled,
+			userId, time.Now(), id}, args...)...)
+	if err != nil {
+		zap.L().Error("error in updating synthetic check", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to update synthetic check"))
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return model.NotFoundError(fmt.Errorf("no synthetic check found with id %s", id))
+	}
+	return nil
+}
+
+// SetAlertId links the check to its alert rule, the link is removed when alertId is empty
+func (r *Repo) SetAlertId(ctx context.Context, id string, alertId string) *model.ApiError {
+	condition, args := common.OrgCondition(ctx)
+	result, err := r.db.ExecContext(ctx, `UPDATE synthetic_checks SET alert_id = ? WHERE id = ? AND `+condition, append([]interface{}{alertId, id}, args...)...)
+	if err != nil {
+		zap.L().Error("error in updating the alert of synthetic check", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to update the alert of synthetic check"))
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return model.NotFoundError(fmt.Errorf("no synthetic check found with id %s", id))
+	}
+	return nil
+}
+
+func (r *Repo) deleteCheck(ctx context.Context, id string) *model.ApiError {
+	condition, args := common.OrgCondition(ctx)
+	result, err := r.db.ExecContext(ctx, `DELETE FROM synthetic_checks WHERE id = ? AND `+condition, append([]interface{}{id}, args...)...)
+	if err != nil {
+		zap.L().Error("error in deleting synthetic check", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to delete synthetic check"))
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return model.NotFoundError(fmt.Errorf("no synthetic check found with id %s", id))
+	}
+	return nil
+}
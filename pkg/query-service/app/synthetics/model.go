@@ -0,0 +1,259 @@
+package synthetics
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+)
+
+const SyntheticsFeatureType = agentConf.AgentFeatureType(agentConf.ElementTypeSyntheticChecks)
+
+const (
+	DefaultFrequencySeconds = 60
+	MinFrequencySeconds     = 30
+	MaxFrequencySeconds     = 24 * 60 * 60
+	DefaultTimeoutSeconds   = 10
+	MaxTimeoutSeconds       = 60
+)
+
+type CheckType string
+
+const (
+	// CheckTypeHTTP requests the url and runs the assertions on the response
+	CheckTypeHTTP CheckType = "http"
+	// CheckTypeTCP opens a connection to the host:port
+	CheckTypeTCP CheckType = "tcp"
+	// CheckTypeICMP pings the host
+	CheckTypeICMP CheckType = "icmp"
+)
+
+type AssertionType string
+
+const (
+	AssertionStatusCode   AssertionType = "status_code"
+	AssertionResponseTime AssertionType = "response_time_ms"
+	AssertionBodyContains AssertionType = "body_contains"
+	AssertionHeader       AssertionType = "header"
+)
+
+type AssertionOperator string
+
+const (
+	OperatorEquals      AssertionOperator = "equals"
+	OperatorNotEquals   AssertionOperator = "not_equals"
+	OperatorLessThan    AssertionOperator = "less_than"
+	OperatorGreaterThan AssertionOperator = "greater_than"
+	OperatorContains    AssertionOperator = "contains"
+	OperatorNotContains AssertionOperator = "not_contains"
+)
+
+// Assertion is a condition the result of a check must meet for the check to be up,
+// Property is the name of the header for the header assertions
+type Assertion struct {
+	Type     AssertionType     `json:"type" yaml:"type"`
+	Property string            `json:"property,omitempty" yaml:"property,omitempty"`
+	Operator AssertionOperator `json:"operator" yaml:"operator"`
+	Value    string            `json:"value" yaml:"value"`
+}
+
+var numericOperators = map[AssertionOperator]struct{}{
+	OperatorEquals: {}, OperatorNotEquals: {}, OperatorLessThan: {}, OperatorGreaterThan: {},
+}
+
+var textOperators = map[AssertionOperator]struct{}{
+	OperatorEquals: {}, OperatorNotEquals: {}, OperatorContains: {}, OperatorNotContains: {},
+}
+
+func (a *Assertion) Validate(checkType CheckType) error {
+	switch a.Type {
+	case AssertionStatusCode, AssertionResponseTime:
+		if _, ok := numericOperators[a.Operator]; !ok {
+			return fmt.Errorf("invalid operator %q for the %s assertion", a.Operator, a.Type)
+		}
+		if _, err := strconv.ParseFloat(a.Value, 64); err != nil {
+			return fmt.Errorf("the value of the %s assertion must be a number", a.Type)
+		}
+	case AssertionBodyContains:
+		if a.Operator != OperatorContains && a.Operator != OperatorNotContains {
+			return fmt.Errorf("invalid operator %q for the %s assertion", a.Operator, a.Type)
+		}
+	case AssertionHeader:
+		if _, ok := textOperators[a.Operator]; !ok {
+			return fmt.Errorf("invalid operator %q for the %s assertion", a.Operator, a.Type)
+		}
+		if a.Property == "" {
+			return fmt.Errorf("the header assertion requires the name of the header as property")
+		}
+	default:
+		return fmt.Errorf("unsupported assertion type: %s", a.Type)
+	}
+	// the tcp and the icmp checks have no response but their time
+	if checkType != CheckTypeHTTP && a.Type != AssertionResponseTime {
+		return fmt.Errorf("the %s checks only support the %s assertion", checkType, AssertionResponseTime)
+	}
+	return nil
+}
+
+// PostableCheck is a synthetic check as received from the API. The check runs on the
+// agents of its regions, or on all the agents when it has no region
+type PostableCheck struct {
+	Name             string     `json:"name"`
+	Type             CheckType  `json:"type"`
+	Target           string     `json:"target"`
+	Method           string     `json:"method,omitempty"`
+	Headers          Headers    `json:"headers,omitempty"`
+	Body             string     `json:"body,omitempty"`
+	Assertions       Assertions `json:"assertions"`
+	FrequencySeconds int        `json:"frequencySeconds"`
+	TimeoutSeconds   int        `json:"timeoutSeconds"`
+	Regions          Regions    `json:"regions"`
+	Enabled          bool       `json:"enabled"`
+}
+
+// Check is a stored synthetic check, AlertId is the id of the alert rule of the check
+type Check struct {
+	Id               string     `json:"id" db:"id"`
+	Name             string     `json:"name" db:"name"`
+	Type             CheckType  `json:"type" db:"type"`
+	Target           string     `json:"target" db:"target"`
+	Method           string     `json:"method,omitempty" db:"method"`
+	Headers          Headers    `json:"headers,omitempty" db:"headers"`
+	Body             string     `json:"body,omitempty" db:"body"`
+	Assertions       Assertions `json:"assertions" db:"assertions"`
+	FrequencySeconds int        `json:"frequencySeconds" db:"frequency_seconds"`
+	TimeoutSeconds   int        `json:"timeoutSeconds" db:"timeout_seconds"`
+	Regions          Regions    `json:"regions" db:"regions"`
+	Enabled          bool       `json:"enabled" db:"enabled"`
+	AlertId          string     `json:"alertId,omitempty" db:"alert_id"`
+	CreatedBy        string     `json:"createdBy" db:"created_by"`
+	CreatedAt        time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedBy        string     `json:"updatedBy" db:"updated_by"`
+	UpdatedAt        time.Time  `json:"updatedAt" db:"updated_at"`
+	// OrgId is the org of the check, its metrics are labelled with it
+	OrgId string `json:"-" db:"org_id"`
+}
+
+// ChecksResponse is used to prepare the http response of the synthetic checks requests
+type ChecksResponse struct {
+	*agentConf.ConfigVersion
+
+	Checks  []Check                   `json:"checks"`
+	History []agentConf.ConfigVersion `json:"history"`
+}
+
+var httpMethods = map[string]struct{}{
+	http.MethodGet: {}, http.MethodHead: {}, http.MethodPost: {}, http.MethodPut: {},
+	http.MethodPatch: {}, http.MethodDelete: {}, http.MethodOptions: {},
+}
+
+// Validate checks the check and sets the defaults of the method, the frequency and the timeout
+func (c *PostableCheck) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("check name is required")
+	}
+	switch c.Type {
+	case CheckTypeHTTP:
+		u, err := url.Parse(c.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("the target of the http checks must be an http or https url")
+		}
+		c.Method = strings.ToUpper(c.Method)
+		if c.Method == "" {
+			c.Method = http.MethodGet
+		}
+		if _, ok := httpMethods[c.Method]; !ok {
+			return fmt.Errorf("unsupported method: %s", c.Method)
+		}
+	case CheckTypeTCP:
+		host, port, err := net.SplitHostPort(c.Target)
+		if err != nil || host == "" || port == "" {
+			return fmt.Errorf("the target of the tcp checks must be host:port")
+		}
+	case CheckTypeICMP:
+		if c.Target == "" || strings.ContainsAny(c.Target, ":/ ") {
+			return fmt.Errorf("the target of the icmp checks must be a host")
+		}
+	default:
+		return fmt.Errorf("unsupported check type: %s", c.Type)
+	}
+	if c.Type != CheckTypeHTTP && (c.Method != "" || len(c.Headers) > 0 || c.Body != "") {
+		return fmt.Errorf("the method, the headers and the body are only supported by the http checks")
+	}
+	for i := range c.Assertions {
+		if err := c.Assertions[i].Validate(c.Type); err != nil {
+			return err
+		}
+	}
+
+	if c.FrequencySeconds == 0 {
+		c.FrequencySeconds = DefaultFrequencySeconds
+	}
+	if c.FrequencySeconds < MinFrequencySeconds || c.FrequencySeconds > MaxFrequencySeconds {
+		return fmt.Errorf("frequency must be between %d and %d seconds", MinFrequencySeconds, MaxFrequencySeconds)
+	}
+	if c.TimeoutSeconds == 0 {
+		c.TimeoutSeconds = min(DefaultTimeoutSeconds, c.FrequencySeconds)
+	}
+	if c.TimeoutSeconds < 1 || c.TimeoutSeconds > MaxTimeoutSeconds || c.TimeoutSeconds > c.FrequencySeconds {
+		return fmt.Errorf("timeout must be between 1 and %d seconds and not exceed the frequency", MaxTimeoutSeconds)
+	}
+	for _, region := range c.Regions {
+		if strings.TrimSpace(region) == "" {
+			return fmt.Errorf("regions can not be empty")
+		}
+	}
+	return nil
+}
+
+// Headers, Assertions and Regions are stored as JSON
+
+type Headers map[string]string
+
+func (h Headers) Value() (driver.Value, error) {
+	return json.Marshal(h)
+}
+
+func (h *Headers) Scan(src interface{}) error {
+	return scanJSON(src, h)
+}
+
+type Assertions []Assertion
+
+func (a Assertions) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+func (a *Assertions) Scan(src interface{}) error {
+	return scanJSON(src, a)
+}
+
+type Regions []string
+
+func (r Regions) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+func (r *Regions) Scan(src interface{}) error {
+	return scanJSON(src, r)
+}
+
+func scanJSON(src interface{}, dest interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, dest)
+	case string:
+		return json.Unmarshal([]byte(data), dest)
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("unsupported type %T", src)
+	}
+}
@@ -0,0 +1,199 @@
+package synthetics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/promql"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+)
+
+// MaxUptimePoints bounds the number of points of the uptime series
+const MaxUptimePoints = 1000
+
+// UptimeParams is the range of the uptime, in milliseconds, and the step of its series in seconds
+type UptimeParams struct {
+	Start int64
+	End   int64
+	Step  int64
+}
+
+func (p *UptimeParams) Validate() error {
+	if p.Start <= 0 || p.End <= 0 || p.Start >= p.End {
+		return fmt.Errorf("start and end are required and start must be before end")
+	}
+	if p.Step == 0 {
+		seconds := (p.End - p.Start) / 1000
+		p.Step = max(60, (seconds+MaxUptimePoints-1)/MaxUptimePoints)
+	}
+	if p.Step < 1 || (p.End-p.Start)/1000/p.Step > MaxUptimePoints {
+		return fmt.Errorf("step must be positive and give at most %d points", MaxUptimePoints)
+	}
+	return nil
+}
+
+// Window is the range of the uptime
+func (p *UptimeParams) Window() time.Duration {
+	return time.Duration(p.End-p.Start) * time.Millisecond
+}
+
+// selector returns the selector of the metric, restricted to the check when the id is set
+func selector(metric string, checkId string) string {
+	if checkId == "" {
+		return metric
+	}
+	return fmt.Sprintf("%s{check_id=%q}", metric, checkId)
+}
+
+// AvgQuery returns the promql expression of the average of the metric over the window by
+// the labels, the uptime is the average of the up metric
+func AvgQuery(metric string, checkId string, by []string, window time.Duration) string {
+	return fmt.Sprintf("avg by (%s) (avg_over_time(%s[%ds]))",
+		strings.Join(by, ", "), selector(metric, checkId), int64(window.Seconds()))
+}
+
+// UptimePoint is the uptime percentage over the step ending at the timestamp, in milliseconds
+type UptimePoint struct {
+	Timestamp     int64   `json:"timestamp"`
+	UptimePercent float64 `json:"uptimePercent"`
+}
+
+type UptimeSeries struct {
+	Region string        `json:"region"`
+	Points []UptimePoint `json:"points"`
+}
+
+// Uptime is the uptime percentage and the average duration of the check runs, they are
+// unset when the check did not run in the range
+type Uptime struct {
+	CheckId       string   `json:"checkId,omitempty"`
+	Region        string   `json:"region,omitempty"`
+	UptimePercent *float64 `json:"uptimePercent"`
+	AvgDurationMs *float64 `json:"avgDurationMs"`
+}
+
+// CheckUptime is the uptime of a check overall and per region
+type CheckUptime struct {
+	Uptime
+	Regions []Uptime       `json:"regions"`
+	Series  []UptimeSeries `json:"series"`
+}
+
+// VectorByLabel returns the values of the instant vector by the value of the label
+func VectorByLabel(res *promql.Result, label string) (map[string]float64, error) {
+	if res == nil {
+		return nil, fmt.Errorf("empty query result")
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	values := map[string]float64{}
+	vector, ok := res.Value.(promql.Vector)
+	if !ok {
+		return values, nil
+	}
+	for _, s := range vector {
+		if !math.IsNaN(s.F) && !math.IsInf(s.F, 0) {
+			values[s.Metric.Get(label)] = s.F
+		}
+	}
+	return values, nil
+}
+
+// UptimeSeriesByRegion converts the range result of the uptime by region into percentages
+func UptimeSeriesByRegion(res *promql.Result) ([]UptimeSeries, error) {
+	if res == nil {
+		return nil, fmt.Errorf("empty query result")
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	series := []UptimeSeries{}
+	matrix, ok := res.Value.(promql.Matrix)
+	if !ok {
+		return series, nil
+	}
+	for _, s := range matrix {
+		us := UptimeSeries{Region: s.Metric.Get("region"), Points: []UptimePoint{}}
+		for _, p := range s.Floats {
+			if !math.IsNaN(p.F) && !math.IsInf(p.F, 0) {
+				us.Points = append(us.Points, UptimePoint{Timestamp: p.T, UptimePercent: p.F * 100})
+			}
+		}
+		series = append(series, us)
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Region < series[j].Region })
+	return series, nil
+}
+
+// MergeUptimes joins the uptimes and the durations by the value of their label, check_id or
+// region. The up metric is a ratio and the uptimes are returned as percentages
+func MergeUptimes(label string, uptimes, durations map[string]float64) []Uptime {
+	keys := map[string]struct{}{}
+	for k := range uptimes {
+		keys[k] = struct{}{}
+	}
+	for k := range durations {
+		keys[k] = struct{}{}
+	}
+	result := make([]Uptime, 0, len(keys))
+	for k := range keys {
+		u := Uptime{Region: k}
+		if label == "check_id" {
+			u = Uptime{CheckId: k}
+		}
+		if v, ok := uptimes[k]; ok {
+			percent := v * 100
+			u.UptimePercent = &percent
+		}
+		if v, ok := durations[k]; ok {
+			duration := v
+			u.AvgDurationMs = &duration
+		}
+		result = append(result, u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CheckId+result[i].Region < result[j].CheckId+result[j].Region
+	})
+	return result
+}
+
+// AlertRule returns the alert rule of the check, the alert fires when the check failed in
+// a region on every run of the evaluation window
+func AlertRule(check *Check) *rules.PostableRule {
+	evalWindow := max(5*time.Minute, 2*time.Duration(check.FrequencySeconds)*time.Second)
+	target := float64(1)
+	query := fmt.Sprintf("avg by (check_id, check_name, region) (%s)", selector(UpMetric, check.Id))
+
+	return &rules.PostableRule{
+		AlertName:   fmt.Sprintf("Synthetic check %s is down", check.Name),
+		AlertType:   "METRIC_BASED_ALERT",
+		Description: fmt.Sprintf("The %s check of %s failed on every run of the last %s", check.Type, check.Target, evalWindow),
+		RuleType:    rules.RuleTypeProm,
+		EvalWindow:  rules.Duration(evalWindow),
+		Frequency:   rules.Duration(time.Minute),
+		RuleCondition: &rules.RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType:   v3.QueryTypePromQL,
+				PanelType:   v3.PanelTypeGraph,
+				PromQueries: map[string]*v3.PromQuery{"A": {Query: query}},
+			},
+			CompareOp:     rules.ValueIsBelow,
+			Target:        &target,
+			MatchType:     rules.AllTheTimes,
+			SelectedQuery: "A",
+		},
+		Labels: map[string]string{
+			"severity": "critical",
+			"check_id": check.Id,
+		},
+		Annotations: map[string]string{
+			"description": "Synthetic check {{$labels.check_name}} is failing in region {{$labels.region}}",
+		},
+		Version: "v4",
+	}
+}
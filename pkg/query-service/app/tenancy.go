@@ -0,0 +1,168 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// scopeQueryToOrg restricts the query of a user to the data of the org the user works in.
+// The data of the orgs is told apart by the OrgIdAttribute resource attribute, the data
+// without it belongs to the default org, the events overlaid on the charts are those of the
+// org. The promql queries get the matcher of the org label, the clickhouse queries can't be
+// scoped, they are only allowed in the default org
+func scopeQueryToOrg(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3) *model.ApiError {
+	scope, apiErr := common.OrgScope(ctx)
+	if apiErr != nil || scope == nil {
+		return apiErr
+	}
+	if queryRangeParams.Events != nil {
+		queryRangeParams.Events.OrgScope = scope
+	}
+	if queryRangeParams.CompositeQuery == nil {
+		return nil
+	}
+	if !scope.Exclude && queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeClickHouseSQL {
+		return &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("the clickhouse queries can't be scoped to the org %s, use the query builder instead", scope.OrgIds[0])}
+	}
+
+	for _, query := range queryRangeParams.CompositeQuery.BuilderQueries {
+		query.Filters = withOrgFilter(query.Filters, query.DataSource, scope)
+	}
+	for _, query := range queryRangeParams.CompositeQuery.PromQueries {
+		if query.Disabled {
+			continue
+		}
+		scoped, err := common.ScopePromQL(query.Query, scope)
+		if err != nil {
+			return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		}
+		query.Query = scoped
+	}
+	return nil
+}
+
+// scopePromQLToOrg adds the matcher of the org of the user to the selectors of a promql query
+func scopePromQLToOrg(ctx context.Context, query string) (string, *model.ApiError) {
+	scope, apiErr := common.OrgScope(ctx)
+	if apiErr != nil || scope == nil {
+		return query, apiErr
+	}
+	scoped, err := common.ScopePromQL(query, scope)
+	if err != nil {
+		return "", &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+	return scoped, nil
+}
+
+// scopePromMatchersToOrg adds the matcher of the org of the user to the matcher sets of the
+// prometheus series and labels APIs, a single set with the matcher when there is none
+func scopePromMatchersToOrg(ctx context.Context, matchers [][]*labels.Matcher) ([][]*labels.Matcher, *model.ApiError) {
+	scope, apiErr := common.OrgScope(ctx)
+	if apiErr != nil || scope == nil {
+		return matchers, apiErr
+	}
+	matcher := common.OrgMatcher(scope)
+	if len(matchers) == 0 {
+		return [][]*labels.Matcher{{matcher}}, nil
+	}
+	for i := range matchers {
+		matchers[i] = append(matchers[i], matcher)
+	}
+	return matchers, nil
+}
+
+// withOrgFilter adds the filter on the orgs of the scope to the filters of a builder query,
+// or of the APIs taking the query builder filters like the trace funnels
+func withOrgFilter(filters *v3.FilterSet, dataSource v3.DataSource, scope *model.OrgScope) *v3.FilterSet {
+	if scope == nil {
+		return filters
+	}
+	if filters == nil {
+		filters = &v3.FilterSet{Operator: "AND"}
+	}
+	filters.Items = append(filters.Items, common.OrgFilter(dataSource, scope))
+	return filters
+}
+
+// scopeTagsToOrg adds the org of the user to the tags of the traces and exceptions APIs
+func scopeTagsToOrg(ctx context.Context, tags []model.TagQueryParam) ([]model.TagQueryParam, *model.ApiError) {
+	scope, apiErr := common.OrgScope(ctx)
+	if apiErr != nil || scope == nil {
+		return tags, apiErr
+	}
+	operator := model.InOperator
+	if scope.Exclude {
+		operator = model.NotInOperator
+	}
+	return append(tags, model.TagQueryParam{
+		Key:          constants.OrgIdAttribute,
+		TagType:      model.ResourceAttributeTagType,
+		StringValues: scope.OrgIds,
+		Operator:     operator,
+	}), nil
+}
+
+// checkClickHouseQuery checks the clickhouse queries written by the user, e.g. those of the
+// dashboard variables, can run in the org of the user. They can't be scoped to an org so they
+// are only allowed in the default org, the query builder is scoped in the other orgs
+func checkClickHouseQuery(ctx context.Context) *model.ApiError {
+	scope, apiErr := common.OrgScope(ctx)
+	if apiErr != nil || scope == nil || scope.Exclude {
+		return apiErr
+	}
+	return &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("the clickhouse queries can't be scoped to the org %s, use the query builder instead", scope.OrgIds[0])}
+}
+
+// defaultOrgId returns the id of the default org, the first org created, it is empty
+// before the first user registers
+func defaultOrgId(ctx context.Context) (string, *model.ApiError) {
+	orgs, apiErr := dao.DB().GetOrgs(ctx)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	if len(orgs) == 0 {
+		return "", nil
+	}
+	return orgs[0].Id, nil
+}
+
+// checkDefaultOrg checks the user of the request works in the default org, the APIs
+// reading the data of all the orgs are only allowed in the default org
+func checkDefaultOrg(ctx context.Context) *model.ApiError {
+	user := common.GetUserFromContext(ctx)
+	if user == nil || user.OrgId == "" {
+		return nil
+	}
+	orgs, apiErr := dao.DB().GetOrgs(ctx)
+	if apiErr != nil {
+		return apiErr
+	}
+	if len(orgs) > 1 && user.OrgId != orgs[0].Id {
+		return &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("the API is not allowed in the org %s", user.OrgId)}
+	}
+	return nil
+}
+
+// defaultOrgOnly allows the handler in the default org only, for the APIs managing the
+// instance rather than the data of an org: the skip indexes, the logs views and fields, the
+// schema migrations, the backups, the disks and the storage tiers, the retention, apdex,
+// span metrics and sampling settings, the cardinality limits, the logs pipelines, the
+// integrations and the queries in flight. The APIs reading the data of the orgs are scoped
+// to the org of the user instead
+func defaultOrgOnly(f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if apiErr := checkDefaultOrg(r.Context()); apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		f(w, r)
+	}
+}
@@ -0,0 +1,254 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/access"
+	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+)
+
+func TestScopeQueryToOrg(t *testing.T) {
+	require.NoError(t, dao.InitDao("sqlite", filepath.Join(t.TempDir(), "signoz.db")))
+	ctx := context.Background()
+
+	newParams := func(queryType v3.QueryType) *v3.QueryRangeParamsV3 {
+		return &v3.QueryRangeParamsV3{CompositeQuery: &v3.CompositeQuery{
+			QueryType: queryType,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {QueryName: "A", DataSource: v3.DataSourceLogs},
+			},
+		}}
+	}
+	withUser := func(orgId string) context.Context {
+		return context.WithValue(ctx, constants.ContextUserKey, &model.UserPayload{User: model.User{Id: "alice", OrgId: orgId}})
+	}
+
+	defaultOrg, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "default"})
+	require.Nil(t, apiErr)
+
+	// the queries are not changed while there is a single org
+	params := newParams(v3.QueryTypeBuilder)
+	require.Nil(t, scopeQueryToOrg(withUser(defaultOrg.Id), params))
+	assert.Nil(t, params.CompositeQuery.BuilderQueries["A"].Filters)
+
+	other, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "other"})
+	require.Nil(t, apiErr)
+
+	// the default org sees the data which is not tagged with another org
+	params = newParams(v3.QueryTypeBuilder)
+	require.Nil(t, scopeQueryToOrg(withUser(defaultOrg.Id), params))
+	items := params.CompositeQuery.BuilderQueries["A"].Filters.Items
+	require.Len(t, items, 1)
+	assert.Equal(t, constants.OrgIdAttribute, items[0].Key.Key)
+	assert.Equal(t, v3.FilterOperatorNotIn, items[0].Operator)
+	assert.Equal(t, []interface{}{other.Id}, items[0].Value)
+	require.Nil(t, scopeQueryToOrg(withUser(defaultOrg.Id), newParams(v3.QueryTypeClickHouseSQL)))
+
+	// the other orgs only see their data, with the query builder
+	params = newParams(v3.QueryTypeBuilder)
+	require.Nil(t, scopeQueryToOrg(withUser(other.Id), params))
+	items = params.CompositeQuery.BuilderQueries["A"].Filters.Items
+	require.Len(t, items, 1)
+	assert.Equal(t, v3.FilterOperatorEqual, items[0].Operator)
+	assert.Equal(t, other.Id, items[0].Value)
+	// and the events of the org on their charts
+	params = newParams(v3.QueryTypeBuilder)
+	params.Events = &v3.EventFilter{}
+	require.Nil(t, scopeQueryToOrg(withUser(other.Id), params))
+	assert.Equal(t, &model.OrgScope{OrgIds: []string{other.Id}}, params.Events.OrgScope)
+	// the promql queries get the org label matcher, the clickhouse queries can't be scoped
+	params = newParams(v3.QueryTypePromQL)
+	params.CompositeQuery.PromQueries = map[string]*v3.PromQuery{
+		"A": {Query: `sum(rate(http_requests_total{code="500"}[5m])) / sum(rate(http_requests_total[5m]))`},
+	}
+	require.Nil(t, scopeQueryToOrg(withUser(other.Id), params))
+	assert.Equal(t, fmt.Sprintf(`sum(rate(http_requests_total{code="500",signoz_org_id="%[1]s"}[5m])) / sum(rate(http_requests_total{signoz_org_id="%[1]s"}[5m]))`, other.Id),
+		params.CompositeQuery.PromQueries["A"].Query)
+	query, apiErr := scopePromQLToOrg(withUser(defaultOrg.Id), "up")
+	require.Nil(t, apiErr)
+	assert.Equal(t, fmt.Sprintf(`up{signoz_org_id!~"%s"}`, other.Id), query)
+	_, apiErr = scopePromQLToOrg(withUser(other.Id), "up{")
+	require.NotNil(t, apiErr)
+	assert.Equal(t, model.ErrorBadData, apiErr.Typ)
+	apiErr = scopeQueryToOrg(withUser(other.Id), newParams(v3.QueryTypeClickHouseSQL))
+	require.NotNil(t, apiErr)
+	assert.Equal(t, model.ErrorForbidden, apiErr.Typ)
+
+	// the prometheus series and labels APIs get the matcher too
+	matchers, apiErr := scopePromMatchersToOrg(withUser(other.Id), nil)
+	require.Nil(t, apiErr)
+	require.Len(t, matchers, 1)
+	assert.Equal(t, fmt.Sprintf(`signoz_org_id="%s"`, other.Id), matchers[0][0].String())
+	matchers, apiErr = scopePromMatchersToOrg(ctx, nil)
+	require.Nil(t, apiErr)
+	assert.Empty(t, matchers)
+
+	// the queries run without user, e.g. by the alerts, are not scoped
+	params = newParams(v3.QueryTypeBuilder)
+	require.Nil(t, scopeQueryToOrg(ctx, params))
+	assert.Nil(t, params.CompositeQuery.BuilderQueries["A"].Filters)
+}
+
+func TestScopeReadsToOrg(t *testing.T) {
+	require.NoError(t, dao.InitDao("sqlite", filepath.Join(t.TempDir(), "signoz.db")))
+	ctx := context.Background()
+	defaultOrg, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "default"})
+	require.Nil(t, apiErr)
+	other, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "other"})
+	require.Nil(t, apiErr)
+
+	// the traces and the exceptions are filtered on the org resource attribute
+	tags, apiErr := scopeTagsToOrg(common.WithOrg(ctx, defaultOrg.Id), nil)
+	require.Nil(t, apiErr)
+	require.Len(t, tags, 1)
+	assert.Equal(t, constants.OrgIdAttribute, tags[0].Key)
+	assert.Equal(t, model.NotInOperator, tags[0].Operator)
+	assert.Equal(t, []string{other.Id}, tags[0].StringValues)
+	tags, apiErr = scopeTagsToOrg(common.WithOrg(ctx, other.Id), nil)
+	require.Nil(t, apiErr)
+	require.Len(t, tags, 1)
+	assert.Equal(t, model.InOperator, tags[0].Operator)
+	assert.Equal(t, []string{other.Id}, tags[0].StringValues)
+
+	// the spans of a trace are filtered by their org
+	scope, apiErr := common.OrgScope(common.WithOrg(ctx, defaultOrg.Id))
+	require.Nil(t, apiErr)
+	assert.True(t, scope.Allows(""))
+	assert.True(t, scope.Allows(defaultOrg.Id))
+	assert.False(t, scope.Allows(other.Id))
+	scope, apiErr = common.OrgScope(common.WithOrg(ctx, other.Id))
+	require.Nil(t, apiErr)
+	assert.False(t, scope.Allows(""))
+	assert.True(t, scope.Allows(other.Id))
+	scope, apiErr = common.OrgScope(ctx)
+	require.Nil(t, apiErr)
+	assert.True(t, scope.Allows(other.Id))
+
+	// the metrics carry the org as a label
+	params := &v3.QueryRangeParamsV3{CompositeQuery: &v3.CompositeQuery{
+		QueryType: v3.QueryTypeBuilder,
+		BuilderQueries: map[string]*v3.BuilderQuery{
+			"A": {QueryName: "A", DataSource: v3.DataSourceMetrics},
+		},
+	}}
+	require.Nil(t, scopeQueryToOrg(common.WithOrg(ctx, other.Id), params))
+	assert.Equal(t, constants.OrgIdLabel, params.CompositeQuery.BuilderQueries["A"].Filters.Items[0].Key.Key)
+
+	// the clickhouse variables can't be scoped
+	aH := &APIHandler{}
+	_, err := aH.variableOptions(common.WithOrg(ctx, other.Id), &dashboards.Variable{Type: dashboards.VariableTypeQuery, QueryValue: "SELECT 1"}, nil, 0, 0)
+	assert.Error(t, err)
+	assert.Nil(t, checkClickHouseQuery(common.WithOrg(ctx, defaultOrg.Id)))
+	assert.Nil(t, checkClickHouseQuery(ctx))
+
+	// the APIs taking the builder filters, e.g. the funnels, are filtered like the builder queries
+	scope, apiErr = common.OrgScope(common.WithOrg(ctx, other.Id))
+	require.Nil(t, apiErr)
+	filters := withOrgFilter(nil, v3.DataSourceTraces, scope)
+	require.Len(t, filters.Items, 1)
+	assert.Equal(t, constants.OrgIdAttribute, filters.Items[0].Key.Key)
+	assert.Equal(t, v3.AttributeKeyTypeResource, filters.Items[0].Key.Type)
+	assert.Nil(t, withOrgFilter(nil, v3.DataSourceTraces, nil))
+}
+
+func TestOrgResources(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "signoz.db")
+	require.NoError(t, dao.InitDao("sqlite", dsn))
+	db, err := dashboards.InitDB(dsn)
+	require.NoError(t, err)
+	require.NoError(t, access.InitWithDB(db))
+	ctx := context.Background()
+	withUser := func(orgId string) context.Context {
+		return context.WithValue(ctx, constants.ContextUserKey, &model.UserPayload{User: model.User{Id: "alice", OrgId: orgId}})
+	}
+
+	// the dashboards created before the orgs belong to the default org
+	legacy, apiErr := dashboards.CreateDashboard(ctx, map[string]interface{}{"title": "legacy"}, nil)
+	require.Nil(t, apiErr)
+	defaultOrg, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "default"})
+	require.Nil(t, apiErr)
+	other, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "other"})
+	require.Nil(t, apiErr)
+	dashboard, apiErr := dashboards.CreateDashboard(withUser(other.Id), map[string]interface{}{"title": "other"}, nil)
+	require.Nil(t, apiErr)
+
+	titles := func(ctx context.Context) []string {
+		all, apiErr := dashboards.GetDashboards(ctx)
+		require.Nil(t, apiErr)
+		titles := []string{}
+		for _, d := range all {
+			titles = append(titles, d.Data["title"].(string))
+		}
+		return titles
+	}
+	assert.Equal(t, []string{"legacy"}, titles(withUser(defaultOrg.Id)))
+	assert.Equal(t, []string{"other"}, titles(withUser(other.Id)))
+	assert.ElementsMatch(t, []string{"legacy", "other"}, titles(ctx))
+
+	_, apiErr = dashboards.GetDashboard(withUser(defaultOrg.Id), dashboard.Uuid)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, model.ErrorNotFound, apiErr.Typ)
+	_, apiErr = dashboards.GetDashboard(withUser(other.Id), legacy.Uuid)
+	require.NotNil(t, apiErr)
+	assert.NotNil(t, dashboards.LockUnlockDashboard(withUser(other.Id), legacy.Uuid, true))
+	assert.NotNil(t, dashboards.DeleteDashboard(withUser(defaultOrg.Id), dashboard.Uuid, nil))
+
+	// the rules are scoped the same way
+	ruleDB := rules.NewRuleDB(db)
+	id, tx, err := ruleDB.CreateRuleTx(withUser(other.Id), `{"alert": "other"}`)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	stored, err := ruleDB.GetStoredRules(withUser(defaultOrg.Id))
+	require.NoError(t, err)
+	assert.Empty(t, stored)
+	stored, err = ruleDB.GetStoredRules(withUser(other.Id))
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, other.Id, stored[0].OrgId)
+	_, err = ruleDB.GetStoredRule(withUser(defaultOrg.Id), fmt.Sprintf("%d", id))
+	assert.Error(t, err)
+
+	// as are the silences, the slos and the recording rules, whose names are unique per org
+	silenceId, err := ruleDB.CreateSilence(withUser(other.Id), &rules.Silence{Comment: "other", Schedule: &rules.Schedule{}})
+	require.NoError(t, err)
+	silences, err := ruleDB.GetAllSilences(withUser(defaultOrg.Id))
+	require.NoError(t, err)
+	assert.Empty(t, silences)
+	silences, err = ruleDB.GetAllSilences(withUser(other.Id))
+	require.NoError(t, err)
+	assert.Len(t, silences, 1)
+	_, err = ruleDB.GetSilence(withUser(defaultOrg.Id), fmt.Sprintf("%d", silenceId))
+	assert.Error(t, err)
+
+	sloId, err := ruleDB.CreateSLO(withUser(other.Id), &rules.SLO{Name: "other"})
+	require.NoError(t, err)
+	slos, err := ruleDB.GetAllSLOs(withUser(defaultOrg.Id))
+	require.NoError(t, err)
+	assert.Empty(t, slos)
+	_, err = ruleDB.GetSLO(withUser(other.Id), fmt.Sprintf("%d", sloId))
+	assert.NoError(t, err)
+
+	_, err = ruleDB.CreateRecordingRule(withUser(defaultOrg.Id), &rules.RecordingRule{Name: "calls"})
+	require.NoError(t, err)
+	recordingId, err := ruleDB.CreateRecordingRule(withUser(other.Id), &rules.RecordingRule{Name: "calls", OrgId: other.Id})
+	require.NoError(t, err)
+	recordingRules, err := ruleDB.GetAllRecordingRules(withUser(other.Id))
+	require.NoError(t, err)
+	require.Len(t, recordingRules, 1)
+	assert.Equal(t, recordingId, recordingRules[0].Id)
+	assert.Equal(t, other.Id, recordingRules[0].OrgId)
+	_, err = ruleDB.CreateRecordingRule(withUser(other.Id), &rules.RecordingRule{Name: "calls", OrgId: other.Id})
+	assert.Error(t, err)
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// checkSelf checks that the user of the request is the user of the route, the two-factor
+// authentication of a user can only be set up by the user
+func checkSelf(r *http.Request) (*model.UserPayload, *model.ApiError) {
+	user := common.GetUserFromContext(r.Context())
+	if user == nil {
+		return nil, &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("failed to get user from context")}
+	}
+	if !auth.IsSelfAccessRequest(user, mux.Vars(r)["id"]) {
+		return nil, &model.ApiError{Typ: model.ErrorForbidden, Err: fmt.Errorf("two-factor authentication can only be set up by the user")}
+	}
+	return user, nil
+}
+
+func parseTOTPCodeRequest(r *http.Request) (*model.TOTPCodeRequest, error) {
+	var req model.TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		return nil, fmt.Errorf("code is required")
+	}
+	return &req, nil
+}
+
+// totpApiError returns the errors of the codes as bad requests, and the lockouts as too many
+// requests
+func totpApiError(err error) *model.ApiError {
+	if err == model.ErrorInvalidTwoFactor || err == model.ErrorTwoFactorRequired {
+		return model.BadRequest(err)
+	}
+	if err == model.ErrorTwoFactorLocked {
+		return &model.ApiError{Typ: model.ErrorTooManyRequests, Err: err}
+	}
+	return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+}
+
+func (aH *APIHandler) getTOTPStatus(w http.ResponseWriter, r *http.Request) {
+	totp, apiErr := dao.DB().GetUserTOTP(r.Context(), mux.Vars(r)["id"])
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	status := model.TOTPStatusResponse{}
+	if totp != nil && totp.Enabled {
+		status.Enabled = true
+		if totp.RecoveryCodes != "" {
+			status.RecoveryCodesLeft = len(strings.Split(totp.RecoveryCodes, ","))
+		}
+	}
+	aH.Respond(w, status)
+}
+
+// enrollTOTP generates the secret to add to the authenticator app of the user
+func (aH *APIHandler) enrollTOTP(w http.ResponseWriter, r *http.Request) {
+	user, apiErr := checkSelf(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	resp, err := auth.EnrollTOTP(r.Context(), &user.User)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	aH.Respond(w, resp)
+}
+
+// verifyTOTP enables the two-factor authentication once the first code is verified
+func (aH *APIHandler) verifyTOTP(w http.ResponseWriter, r *http.Request) {
+	user, apiErr := checkSelf(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	req, err := parseTOTPCodeRequest(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	codes, err := auth.ConfirmTOTP(r.Context(), user.Id, req.Code)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	aH.Respond(w, model.TOTPRecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (aH *APIHandler) regenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	user, apiErr := checkSelf(r)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	req, err := parseTOTPCodeRequest(r)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	codes, err := auth.RegenerateRecoveryCodes(r.Context(), user.Id, req.Code)
+	if err != nil {
+		RespondError(w, totpApiError(err), nil)
+		return
+	}
+	aH.Respond(w, model.TOTPRecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// disableTOTP removes the two-factor authentication of the user, the users must send a code
+// while the admins can reset the two-factor authentication of the users who lost their device
+func (aH *APIHandler) disableTOTP(w http.ResponseWriter, r *http.Request) {
+	user := common.GetUserFromContext(r.Context())
+	id := mux.Vars(r)["id"]
+	if user == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnauthorized, Err: fmt.Errorf("failed to get user from context")}, nil)
+		return
+	}
+
+	if auth.IsSelfAccessRequest(user, id) {
+		enabled, err := auth.TwoFactorEnabled(r.Context(), id)
+		if err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+			return
+		}
+		if enabled {
+			if err := auth.VerifyTwoFactor(r.Context(), id, r.URL.Query().Get("code")); err != nil {
+				RespondError(w, totpApiError(err), nil)
+				return
+			}
+		}
+	} else if apiErr := checkUserAccount(r, id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	if apiErr := dao.DB().DeleteUserTOTP(r.Context(), id); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// setTwoFactorPreference sets if the org enforces two-factor authentication for the password logins
+func (aH *APIHandler) setTwoFactorPreference(w http.ResponseWriter, r *http.Request) {
+	if apiErr := CheckActiveOrg(r, mux.Vars(r)["id"]); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	var req model.TwoFactorPreferenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if apiErr := dao.DB().SetOrgEnforceTwoFactor(r.Context(), mux.Vars(r)["id"], req.Enforce); apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, req)
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/ee/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/app/traces/diff"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// getTraceDiff compares the trace with the baseline trace, or when baselineTraceId is not
+// set with the trace of the same root operation lasting the median duration around it
+func (aH *APIHandler) getTraceDiff(w http.ResponseWriter, r *http.Request) {
+	traceId := mux.Vars(r)["traceId"]
+	baselineId := r.URL.Query().Get("baselineTraceId")
+
+	limit := diff.DefaultLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed <= 0 || parsed > diff.MaxLimit {
+			RespondError(w, model.BadRequest(fmt.Errorf("limit must be between 1 and %d", diff.MaxLimit)), nil)
+			return
+		}
+		limit = parsed
+	}
+	maxSpans, err := strconv.Atoi(constants.MaxSpansInTraceStr)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	scope, apiErr := common.OrgScope(r.Context())
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	spans, apiErr := aH.reader.GetTracesSpans(r.Context(), []string{traceId}, maxSpans, scope)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if len(spans) == 0 {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("trace %s not found", traceId)}, nil)
+		return
+	}
+
+	if baselineId == "" {
+		root := diff.Root(spans)
+		if root == nil {
+			RespondError(w, model.BadRequest(fmt.Errorf("trace %s has no root span, a baselineTraceId is required", traceId)), nil)
+			return
+		}
+		at := time.Unix(0, int64(root.TimeUnixNano))
+		baselineId, apiErr = aH.reader.GetBaselineTraceID(r.Context(), root.ServiceName, root.Name, traceId,
+			at.Add(-diff.BaselineWindow), at.Add(diff.BaselineWindow), scope)
+		if apiErr != nil {
+			RespondError(w, apiErr, nil)
+			return
+		}
+		if baselineId == "" {
+			RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no baseline trace found for %s %s", root.ServiceName, root.Name)}, nil)
+			return
+		}
+	}
+
+	baselineSpans, apiErr := aH.reader.GetTracesSpans(r.Context(), []string{baselineId}, maxSpans, scope)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	if len(baselineSpans) == 0 {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("trace %s not found", baselineId)}, nil)
+		return
+	}
+
+	aH.Respond(w, diff.Compare(traceId, spans, baselineId, baselineSpans, limit))
+}
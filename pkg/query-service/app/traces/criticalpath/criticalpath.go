@@ -0,0 +1,91 @@
+package criticalpath
+
+import (
+	"sort"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+type node struct {
+	span       *model.SearchSpanResponseItem
+	start, end int64
+	children   []*node
+}
+
+// Compute returns the critical path of the trace of the spans, whose start times are in ns.
+// The path starts at the earliest root span and goes, from the end of each span backwards,
+// through the child which ends last before the time left in the span, the time not covered
+// by such a child is the self time of the span
+func Compute(spans []model.SearchSpanResponseItem) []model.CriticalPathSpan {
+	nodes := make(map[string]*node, len(spans))
+	for i := range spans {
+		start := int64(spans[i].TimeUnixNano)
+		nodes[spans[i].SpanID] = &node{span: &spans[i], start: start, end: start + spans[i].DurationNano}
+	}
+
+	var root *node
+	for i := range spans {
+		n := nodes[spans[i].SpanID]
+		if parent := parentOf(n.span, nodes); parent != nil {
+			parent.children = append(parent.children, n)
+		} else if root == nil || n.start < root.start {
+			root = n
+		}
+	}
+	if root == nil {
+		return []model.CriticalPathSpan{}
+	}
+
+	selfTimes := map[*node]int64{}
+	order := []*node{}
+	var walk func(n *node, end int64)
+	walk = func(n *node, end int64) {
+		order = append(order, n)
+		sort.Slice(n.children, func(i, j int) bool { return n.children[i].end > n.children[j].end })
+
+		cursor := min(n.end, end)
+		for _, child := range n.children {
+			if cursor <= n.start {
+				break
+			}
+			// the child starts after the time left, or ends after it while starting before
+			// the span, e.g. a follows-from span
+			if child.start >= cursor || child.start < n.start {
+				continue
+			}
+			childEnd := min(child.end, cursor)
+			selfTimes[n] += cursor - childEnd
+			walk(child, childEnd)
+			cursor = child.start
+		}
+		if cursor > n.start {
+			selfTimes[n] += cursor - n.start
+		}
+	}
+	walk(root, root.end)
+
+	sort.SliceStable(order, func(i, j int) bool { return order[i].start < order[j].start })
+	path := make([]model.CriticalPathSpan, 0, len(order))
+	for _, n := range order {
+		path = append(path, model.CriticalPathSpan{
+			SpanID:       n.span.SpanID,
+			ServiceName:  n.span.ServiceName,
+			Name:         n.span.Name,
+			DurationNano: n.span.DurationNano,
+			SelfTimeNano: selfTimes[n],
+		})
+	}
+	return path
+}
+
+func parentOf(span *model.SearchSpanResponseItem, nodes map[string]*node) *node {
+	for _, ref := range span.References {
+		if ref.SpanId == "" || ref.SpanId == span.SpanID {
+			continue
+		}
+		if parent, ok := nodes[ref.SpanId]; ok {
+			return parent
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package criticalpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func span(id, parent string, start uint64, duration int64) model.SearchSpanResponseItem {
+	s := model.SearchSpanResponseItem{SpanID: id, Name: id, TimeUnixNano: start, DurationNano: duration}
+	if parent != "" {
+		s.References = []model.OtelSpanRef{{SpanId: parent, RefType: "CHILD_OF"}}
+	}
+	return s
+}
+
+func selfTimes(path []model.CriticalPathSpan) map[string]int64 {
+	times := map[string]int64{}
+	for _, s := range path {
+		times[s.SpanID] = s.SelfTimeNano
+	}
+	return times
+}
+
+func TestCompute(t *testing.T) {
+	// root  [0, 100]
+	//   a   [10, 40]
+	//     c [15, 35]
+	//   b   [30, 90], parallel to a, ends last
+	//     d [50, 60]
+	//   e   [92, 95]
+	spans := []model.SearchSpanResponseItem{
+		span("root", "", 0, 100),
+		span("a", "root", 10, 30),
+		span("c", "a", 15, 20),
+		span("b", "root", 30, 60),
+		span("d", "b", 50, 10),
+		span("e", "root", 92, 3),
+	}
+
+	path := Compute(spans)
+	ids := []string{}
+	for _, s := range path {
+		ids = append(ids, s.SpanID)
+	}
+	require.Equal(t, []string{"root", "a", "c", "b", "d", "e"}, ids)
+	require.Equal(t, map[string]int64{
+		// [95, 100], [90, 92] and [0, 10]
+		"root": 17,
+		"e":    3,
+		// [60, 90] and [30, 50]
+		"b": 50,
+		"d": 10,
+		// a is on the path until b starts, [10, 15]
+		"a": 5,
+		// [15, 30]
+		"c": 15,
+	}, selfTimes(path))
+
+	var total int64
+	for _, s := range path {
+		total += s.SelfTimeNano
+	}
+	require.Equal(t, int64(100), total)
+}
+
+func TestComputeChildOutlivingParent(t *testing.T) {
+	spans := []model.SearchSpanResponseItem{
+		span("root", "", 0, 50),
+		span("async", "root", 40, 100),
+	}
+	require.Equal(t, map[string]int64{"root": 40, "async": 10}, selfTimes(Compute(spans)))
+}
+
+func TestComputeEmpty(t *testing.T) {
+	require.Empty(t, Compute(nil))
+}
@@ -0,0 +1,250 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 200
+
+	// BaselineWindow is the time before and after a trace in which its baseline is looked for
+	BaselineWindow = time.Hour
+)
+
+// Result is the comparison of a trace with a baseline trace, the spans are matched on their
+// path from the root span
+type Result struct {
+	Trace         Summary `json:"trace"`
+	Baseline      Summary `json:"baseline"`
+	DurationDelta int64   `json:"durationDelta"`
+	// Spans are the matched spans with the largest self time deltas first
+	Spans []SpanDiff `json:"spans"`
+	// Added are the subtrees of the trace which are not in the baseline and Removed the
+	// subtrees of the baseline which are not in the trace
+	Added   []Subtree `json:"added"`
+	Removed []Subtree `json:"removed"`
+}
+
+// Summary describes one of the compared traces
+type Summary struct {
+	TraceId      string `json:"traceId"`
+	ServiceName  string `json:"serviceName"`
+	Name         string `json:"name"`
+	DurationNano int64  `json:"durationNano"`
+	Spans        int    `json:"spans"`
+}
+
+// SpanDiff is a span of the trace and the matching span of the baseline, the self times
+// are the durations of the spans not covered by their children
+type SpanDiff struct {
+	Path                 string `json:"path"`
+	ServiceName          string `json:"serviceName"`
+	Name                 string `json:"name"`
+	SpanId               string `json:"spanId"`
+	BaselineSpanId       string `json:"baselineSpanId"`
+	DurationNano         int64  `json:"durationNano"`
+	BaselineDurationNano int64  `json:"baselineDurationNano"`
+	DurationDelta        int64  `json:"durationDelta"`
+	SelfTimeNano         int64  `json:"selfTimeNano"`
+	BaselineSelfTimeNano int64  `json:"baselineSelfTimeNano"`
+	SelfTimeDelta        int64  `json:"selfTimeDelta"`
+	HasError             bool   `json:"hasError"`
+	BaselineHasError     bool   `json:"baselineHasError"`
+}
+
+// Subtree is a span, with its descendants, which is only in one of the traces
+type Subtree struct {
+	Path         string `json:"path"`
+	ServiceName  string `json:"serviceName"`
+	Name         string `json:"name"`
+	SpanId       string `json:"spanId"`
+	DurationNano int64  `json:"durationNano"`
+	// Spans is the number of spans of the subtree, the root included
+	Spans int `json:"spans"`
+}
+
+type node struct {
+	span     *model.SearchSpanResponseItem
+	parent   *node
+	path     string
+	children []*node
+	selfTime int64
+	size     int
+}
+
+// tree indexes the spans of a trace on their path, the path of a span is the path of its
+// parent followed by its service, its name and its rank among the siblings of the same
+// service and name in the order of their start times
+type tree struct {
+	roots  []*node
+	byPath map[string]*node
+}
+
+func newTree(spans []model.SearchSpanResponseItem) *tree {
+	nodes := make(map[string]*node, len(spans))
+	for i := range spans {
+		nodes[spans[i].SpanID] = &node{span: &spans[i]}
+	}
+
+	t := &tree{byPath: make(map[string]*node, len(spans))}
+	for i := range spans {
+		n := nodes[spans[i].SpanID]
+		parent := parentOf(n.span, nodes)
+		if parent == nil {
+			t.roots = append(t.roots, n)
+		} else {
+			n.parent = parent
+			parent.children = append(parent.children, n)
+		}
+	}
+	t.index("", t.roots)
+	return t
+}
+
+func parentOf(span *model.SearchSpanResponseItem, nodes map[string]*node) *node {
+	for _, ref := range span.References {
+		if ref.SpanId == "" || ref.SpanId == span.SpanID {
+			continue
+		}
+		if parent, ok := nodes[ref.SpanId]; ok {
+			return parent
+		}
+	}
+	return nil
+}
+
+// index sets the paths, the self times and the sizes of the nodes and their descendants
+func (t *tree) index(prefix string, nodes []*node) {
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].span.TimeUnixNano < nodes[j].span.TimeUnixNano })
+	ranks := map[string]int{}
+	for _, n := range nodes {
+		operation := n.span.ServiceName + ":" + n.span.Name
+		n.path = fmt.Sprintf("%s/%s[%d]", prefix, operation, ranks[operation])
+		ranks[operation]++
+		t.byPath[n.path] = n
+
+		t.index(n.path, n.children)
+		n.size = 1
+		n.selfTime = n.span.DurationNano
+		for _, child := range n.children {
+			n.size += child.size
+			n.selfTime -= child.span.DurationNano
+		}
+		// the children of async calls can outlive their parent
+		if n.selfTime < 0 {
+			n.selfTime = 0
+		}
+	}
+}
+
+func (t *tree) summary(traceId string, spans int) Summary {
+	s := Summary{TraceId: traceId, Spans: spans}
+	if len(t.roots) > 0 {
+		root := t.roots[0]
+		s.ServiceName, s.Name, s.DurationNano = root.span.ServiceName, root.span.Name, root.span.DurationNano
+	}
+	return s
+}
+
+// Root returns the earliest span without parent in the spans, nil when there are none
+func Root(spans []model.SearchSpanResponseItem) *model.SearchSpanResponseItem {
+	t := newTree(spans)
+	if len(t.roots) == 0 {
+		return nil
+	}
+	return t.roots[0].span
+}
+
+// Compare compares the spans of the trace with the spans of the baseline trace, up to limit
+// matched spans are returned
+func Compare(traceId string, spans []model.SearchSpanResponseItem, baselineId string, baselineSpans []model.SearchSpanResponseItem, limit int) *Result {
+	trace, baseline := newTree(spans), newTree(baselineSpans)
+	result := &Result{
+		Trace:    trace.summary(traceId, len(spans)),
+		Baseline: baseline.summary(baselineId, len(baselineSpans)),
+		Spans:    []SpanDiff{},
+		Added:    []Subtree{},
+		Removed:  []Subtree{},
+	}
+	result.DurationDelta = result.Trace.DurationNano - result.Baseline.DurationNano
+
+	for path, n := range trace.byPath {
+		b, ok := baseline.byPath[path]
+		if !ok {
+			continue
+		}
+		result.Spans = append(result.Spans, SpanDiff{
+			Path:                 path,
+			ServiceName:          n.span.ServiceName,
+			Name:                 n.span.Name,
+			SpanId:               n.span.SpanID,
+			BaselineSpanId:       b.span.SpanID,
+			DurationNano:         n.span.DurationNano,
+			BaselineDurationNano: b.span.DurationNano,
+			DurationDelta:        n.span.DurationNano - b.span.DurationNano,
+			SelfTimeNano:         n.selfTime,
+			BaselineSelfTimeNano: b.selfTime,
+			SelfTimeDelta:        n.selfTime - b.selfTime,
+			HasError:             n.span.HasError,
+			BaselineHasError:     b.span.HasError,
+		})
+	}
+	// the self time deltas point at the spans where the time was spent, the duration deltas
+	// of their ancestors are only its consequence
+	sort.Slice(result.Spans, func(i, j int) bool {
+		di, dj := abs(result.Spans[i].SelfTimeDelta), abs(result.Spans[j].SelfTimeDelta)
+		if di != dj {
+			return di > dj
+		}
+		return result.Spans[i].Path < result.Spans[j].Path
+	})
+	if len(result.Spans) > limit {
+		result.Spans = result.Spans[:limit]
+	}
+
+	result.Added = subtrees(trace, baseline)
+	result.Removed = subtrees(baseline, trace)
+	return result
+}
+
+// subtrees returns the largest subtrees of the tree whose roots are not in the other tree
+func subtrees(t, other *tree) []Subtree {
+	result := []Subtree{}
+	for path, n := range t.byPath {
+		if _, ok := other.byPath[path]; ok {
+			continue
+		}
+		if n.parent != nil {
+			if _, ok := other.byPath[n.parent.path]; !ok {
+				continue
+			}
+		}
+		result = append(result, Subtree{
+			Path:         path,
+			ServiceName:  n.span.ServiceName,
+			Name:         n.span.Name,
+			SpanId:       n.span.SpanID,
+			DurationNano: n.span.DurationNano,
+			Spans:        n.size,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Spans != result[j].Spans {
+			return result[i].Spans > result[j].Spans
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,65 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func span(id, parent, service, name string, start uint64, duration int64) model.SearchSpanResponseItem {
+	s := model.SearchSpanResponseItem{SpanID: id, ServiceName: service, Name: name, TimeUnixNano: start, DurationNano: duration}
+	if parent != "" {
+		s.References = []model.OtelSpanRef{{SpanId: parent, RefType: "CHILD_OF"}}
+	}
+	return s
+}
+
+func TestCompare(t *testing.T) {
+	baseline := []model.SearchSpanResponseItem{
+		span("1", "", "frontend", "GET /checkout", 0, 100),
+		span("2", "1", "checkout", "SELECT cart", 10, 20),
+		span("3", "1", "checkout", "SELECT cart", 40, 20),
+		span("4", "1", "payment", "charge", 60, 30),
+		span("5", "4", "payment", "fraud check", 65, 10),
+	}
+	// the second query is slower, the fraud check is gone and the payment retries
+	trace := []model.SearchSpanResponseItem{
+		span("a", "", "frontend", "GET /checkout", 0, 400),
+		span("b", "a", "checkout", "SELECT cart", 10, 20),
+		span("c", "a", "checkout", "SELECT cart", 40, 250),
+		span("d", "a", "payment", "charge", 300, 30),
+		span("e", "d", "payment", "retry", 305, 20),
+		span("f", "e", "payment", "charge card", 306, 10),
+	}
+
+	result := Compare("t", trace, "b", baseline, 3)
+	require.Equal(t, Summary{TraceId: "t", ServiceName: "frontend", Name: "GET /checkout", DurationNano: 400, Spans: 6}, result.Trace)
+	require.Equal(t, int64(300), result.DurationDelta)
+
+	require.Len(t, result.Spans, 3)
+	require.Equal(t, "/frontend:GET /checkout[0]/checkout:SELECT cart[1]", result.Spans[0].Path)
+	require.Equal(t, "c", result.Spans[0].SpanId)
+	require.Equal(t, "3", result.Spans[0].BaselineSpanId)
+	require.Equal(t, int64(230), result.Spans[0].DurationDelta)
+	require.Equal(t, int64(230), result.Spans[0].SelfTimeDelta)
+	// the root: 400-20-250-30 = 100 against 100-20-20-30 = 30
+	require.Equal(t, "a", result.Spans[1].SpanId)
+	require.Equal(t, int64(70), result.Spans[1].SelfTimeDelta)
+
+	require.Equal(t, []Subtree{{
+		Path: "/frontend:GET /checkout[0]/payment:charge[0]/payment:retry[0]", ServiceName: "payment", Name: "retry",
+		SpanId: "e", DurationNano: 20, Spans: 2,
+	}}, result.Added)
+	require.Len(t, result.Removed, 1)
+	require.Equal(t, "5", result.Removed[0].SpanId)
+}
+
+func TestRoot(t *testing.T) {
+	require.Nil(t, Root(nil))
+	spans := []model.SearchSpanResponseItem{
+		span("2", "1", "checkout", "SELECT cart", 10, 20),
+		span("1", "", "frontend", "GET /checkout", 0, 100),
+	}
+	require.Equal(t, "1", Root(spans).SpanID)
+}
@@ -0,0 +1,143 @@
+package funnels
+
+import (
+	"sort"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// maxDroppedTraces is the number of traces dropping off at a step which are returned as examples
+const maxDroppedTraces = 10
+
+// Result is the progression of the traces through the steps of a funnel
+type Result struct {
+	// Traces is the number of traces entering the funnel, Completed of the traces going through
+	// all its steps
+	Traces     int     `json:"traces"`
+	Completed  int     `json:"completed"`
+	Conversion float64 `json:"conversion"`
+	// Truncated is set when more traces entered the funnel than the limit of the params
+	Truncated bool   `json:"truncated"`
+	Steps     []Step `json:"steps"`
+}
+
+// Step is the number of traces reaching a step of the funnel, the conversions are percentages
+// of the traces entering the funnel and of the traces reaching the previous step
+type Step struct {
+	Name           string  `json:"name"`
+	Traces         int     `json:"traces"`
+	Conversion     float64 `json:"conversion"`
+	StepConversion float64 `json:"stepConversion"`
+	// DropOff is the number of traces reaching the previous step but not this one
+	DropOff int `json:"dropOff"`
+	// Latency is the time from the previous step to this one, nil for the first step
+	Latency *Latency `json:"latency"`
+	// DroppedTraceIDs are examples of the traces dropping off before the step
+	DroppedTraceIDs []string `json:"droppedTraceIds"`
+}
+
+// Latency is the distribution of the times in ns between two steps
+type Latency struct {
+	Avg int64 `json:"avg"`
+	P50 int64 `json:"p50"`
+	P90 int64 `json:"p90"`
+	P99 int64 `json:"p99"`
+}
+
+// Compute returns the progression of the traces through the steps of the funnel. A trace
+// reaches a step when one of its spans matching the step starts after the span of the
+// previous step, the earliest of those spans is kept
+func Compute(params *v3.FunnelParams, traces []v3.FunnelTrace) *Result {
+	result := &Result{Steps: make([]Step, len(params.Steps))}
+	if len(traces) > params.Limit {
+		traces, result.Truncated = traces[:params.Limit], true
+	}
+
+	latencies := make([][]int64, len(params.Steps))
+	for _, trace := range traces {
+		reached := progress(trace)
+		if reached == nil {
+			continue
+		}
+		result.Traces++
+		for i := range params.Steps {
+			if i >= len(reached) {
+				step := &result.Steps[i]
+				if len(step.DroppedTraceIDs) < maxDroppedTraces {
+					step.DroppedTraceIDs = append(step.DroppedTraceIDs, trace.TraceID)
+				}
+				break
+			}
+			result.Steps[i].Traces++
+			if i > 0 {
+				latencies[i] = append(latencies[i], reached[i]-reached[i-1])
+			}
+		}
+	}
+
+	for i, step := range params.Steps {
+		s := &result.Steps[i]
+		s.Name = step.Name
+		s.Conversion = percentage(s.Traces, result.Traces)
+		if i == 0 {
+			s.StepConversion = percentage(s.Traces, result.Traces)
+		} else {
+			previous := result.Steps[i-1].Traces
+			s.StepConversion = percentage(s.Traces, previous)
+			s.DropOff = previous - s.Traces
+			s.Latency = distribution(latencies[i])
+		}
+		if s.DroppedTraceIDs == nil {
+			s.DroppedTraceIDs = []string{}
+		}
+	}
+	result.Completed = result.Steps[len(result.Steps)-1].Traces
+	result.Conversion = percentage(result.Completed, result.Traces)
+	return result
+}
+
+// progress returns the start times of the spans through which the trace reaches the steps,
+// nil when it does not reach the first step
+func progress(trace v3.FunnelTrace) []int64 {
+	if len(trace.Steps) == 0 || len(trace.Steps[0]) == 0 {
+		return nil
+	}
+	reached := []int64{trace.Steps[0][0]}
+	for _, starts := range trace.Steps[1:] {
+		previous := reached[len(reached)-1]
+		i := sort.Search(len(starts), func(i int) bool { return starts[i] >= previous })
+		if i == len(starts) {
+			break
+		}
+		reached = append(reached, starts[i])
+	}
+	return reached
+}
+
+func percentage(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+// distribution returns the average and the percentiles of the latencies, nil when empty
+func distribution(latencies []int64) *Latency {
+	if len(latencies) == 0 {
+		return nil
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var sum int64
+	for _, l := range latencies {
+		sum += l
+	}
+	quantile := func(q float64) int64 {
+		return latencies[int(q*float64(len(latencies)-1))]
+	}
+	return &Latency{
+		Avg: sum / int64(len(latencies)),
+		P50: quantile(0.5),
+		P90: quantile(0.9),
+		P99: quantile(0.99),
+	}
+}
@@ -0,0 +1,82 @@
+package funnels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func testParams(limit int) *v3.FunnelParams {
+	return &v3.FunnelParams{
+		Steps: []v3.FunnelStep{{Name: "checkout"}, {Name: "payment"}, {Name: "fulfillment"}},
+		Limit: limit,
+	}
+}
+
+func TestCompute(t *testing.T) {
+	traces := []v3.FunnelTrace{
+		// goes through the funnel, the payment span before the checkout is ignored
+		{TraceID: "a", Steps: [][]int64{{100}, {50, 300}, {700}}},
+		{TraceID: "b", Steps: [][]int64{{100, 200}, {150}, {250}}},
+		// the payment happens before the checkout
+		{TraceID: "c", Steps: [][]int64{{500}, {100}, {900}}},
+		// does not enter the funnel
+		{TraceID: "d", Steps: [][]int64{{}, {100}, {200}}},
+		{TraceID: "e", Steps: [][]int64{{0}, {100}, {}}},
+	}
+
+	result := Compute(testParams(10), traces)
+	require.False(t, result.Truncated)
+	require.Equal(t, 4, result.Traces)
+	require.Equal(t, 2, result.Completed)
+	require.Equal(t, 50.0, result.Conversion)
+
+	checkout, payment, fulfillment := result.Steps[0], result.Steps[1], result.Steps[2]
+	require.Equal(t, "checkout", checkout.Name)
+	require.Equal(t, 4, checkout.Traces)
+	require.Equal(t, 100.0, checkout.Conversion)
+	require.Nil(t, checkout.Latency)
+
+	require.Equal(t, 3, payment.Traces)
+	require.Equal(t, 1, payment.DropOff)
+	require.Equal(t, 75.0, payment.StepConversion)
+	require.Equal(t, []string{"c"}, payment.DroppedTraceIDs)
+	// a: 200, b: 50, e: 100
+	require.Equal(t, &Latency{Avg: 116, P50: 100, P90: 100, P99: 100}, payment.Latency)
+
+	require.Equal(t, 2, fulfillment.Traces)
+	require.Equal(t, 50.0, fulfillment.Conversion)
+	require.InDelta(t, 66.67, fulfillment.StepConversion, 0.01)
+	require.Equal(t, []string{"e"}, fulfillment.DroppedTraceIDs)
+	// a: 400, b: 100
+	require.Equal(t, int64(250), fulfillment.Latency.Avg)
+}
+
+func TestComputeTruncated(t *testing.T) {
+	traces := []v3.FunnelTrace{
+		{TraceID: "a", Steps: [][]int64{{1}, {2}, {3}}},
+		{TraceID: "b", Steps: [][]int64{{1}, {2}, {3}}},
+	}
+	result := Compute(testParams(1), traces)
+	require.True(t, result.Truncated)
+	require.Equal(t, 1, result.Traces)
+
+	empty := Compute(testParams(1), nil)
+	require.Zero(t, empty.Conversion)
+	require.Nil(t, empty.Steps[1].Latency)
+	require.Empty(t, empty.Steps[1].DroppedTraceIDs)
+}
+
+func TestFunnelParamsValidate(t *testing.T) {
+	filters := &v3.FilterSet{Items: []v3.FilterItem{{Key: v3.AttributeKey{Key: "serviceName"}, Operator: "=", Value: "checkout"}}}
+	params := &v3.FunnelParams{Start: 1, End: 2, Steps: []v3.FunnelStep{{Filters: filters}, {Filters: filters}}}
+	require.NoError(t, params.Validate())
+	require.Equal(t, v3.DefaultFunnelTraces, params.Limit)
+
+	params.Steps = params.Steps[:1]
+	require.Error(t, params.Validate())
+
+	params.Steps = []v3.FunnelStep{{Filters: filters}, {}}
+	require.Error(t, params.Validate())
+}
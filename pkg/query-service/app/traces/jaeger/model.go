@@ -0,0 +1,204 @@
+package jaeger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	refTypeChildOf = "CHILD_OF"
+	typeString     = "string"
+	typeBool       = "bool"
+)
+
+// spanKinds are the values of the span.kind tag of the OpenTelemetry span kinds
+var spanKinds = map[int32]string{
+	1: "internal",
+	2: "server",
+	3: "client",
+	4: "producer",
+	5: "consumer",
+}
+
+// Response is the envelope of the responses of the Jaeger query API
+type Response struct {
+	Data   interface{} `json:"data"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+	Errors []Error     `json:"errors"`
+}
+
+type Error struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+type Trace struct {
+	TraceID   string             `json:"traceID"`
+	Spans     []Span             `json:"spans"`
+	Processes map[string]Process `json:"processes"`
+	Warnings  []string           `json:"warnings"`
+}
+
+// Span is a span of the Jaeger API, the times are in microseconds
+type Span struct {
+	TraceID       string      `json:"traceID"`
+	SpanID        string      `json:"spanID"`
+	Flags         uint32      `json:"flags"`
+	OperationName string      `json:"operationName"`
+	References    []Reference `json:"references"`
+	StartTime     uint64      `json:"startTime"`
+	Duration      int64       `json:"duration"`
+	Tags          []KeyValue  `json:"tags"`
+	Logs          []Log       `json:"logs"`
+	ProcessID     string      `json:"processID"`
+	Warnings      []string    `json:"warnings"`
+}
+
+type Reference struct {
+	RefType string `json:"refType"`
+	TraceID string `json:"traceID"`
+	SpanID  string `json:"spanID"`
+}
+
+type KeyValue struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type Log struct {
+	Timestamp uint64     `json:"timestamp"`
+	Fields    []KeyValue `json:"fields"`
+}
+
+type Process struct {
+	ServiceName string     `json:"serviceName"`
+	Tags        []KeyValue `json:"tags"`
+}
+
+type Operation struct {
+	Name     string `json:"name"`
+	SpanKind string `json:"spanKind"`
+}
+
+type Dependency struct {
+	Parent    string `json:"parent"`
+	Child     string `json:"child"`
+	CallCount uint64 `json:"callCount"`
+}
+
+// SpanKind returns the span.kind tag value of an OpenTelemetry span kind
+func SpanKind(kind int32) string {
+	return spanKinds[kind]
+}
+
+// NormalizeTraceID pads the trace ids shortened by the Jaeger clients, which drop the
+// leading zeros, to the 32 hex digits of the stored trace ids
+func NormalizeTraceID(traceID string) string {
+	traceID = strings.ToLower(traceID)
+	if len(traceID) < 32 {
+		return strings.Repeat("0", 32-len(traceID)) + traceID
+	}
+	return traceID
+}
+
+// FromSpans converts the spans to the traces of the Jaeger API, in the order of the trace ids.
+// The spans of each service of a trace share a process
+func FromSpans(traceIDs []string, spans []model.SearchSpanResponseItem) []Trace {
+	byTrace := map[string][]model.SearchSpanResponseItem{}
+	for _, span := range spans {
+		byTrace[span.TraceID] = append(byTrace[span.TraceID], span)
+	}
+
+	traces := []Trace{}
+	for _, traceID := range traceIDs {
+		traceSpans, ok := byTrace[traceID]
+		if !ok {
+			continue
+		}
+		sort.SliceStable(traceSpans, func(i, j int) bool { return traceSpans[i].TimeUnixNano < traceSpans[j].TimeUnixNano })
+
+		trace := Trace{TraceID: traceID, Spans: []Span{}, Processes: map[string]Process{}}
+		processIDs := map[string]string{}
+		for _, span := range traceSpans {
+			processID, ok := processIDs[span.ServiceName]
+			if !ok {
+				processID = fmt.Sprintf("p%d", len(processIDs)+1)
+				processIDs[span.ServiceName] = processID
+				trace.Processes[processID] = Process{ServiceName: span.ServiceName, Tags: []KeyValue{}}
+			}
+			trace.Spans = append(trace.Spans, fromSpan(span, processID))
+		}
+		traces = append(traces, trace)
+	}
+	return traces
+}
+
+func fromSpan(span model.SearchSpanResponseItem, processID string) Span {
+	result := Span{
+		TraceID:       span.TraceID,
+		SpanID:        span.SpanID,
+		OperationName: span.Name,
+		References:    []Reference{},
+		StartTime:     span.TimeUnixNano / 1000,
+		Duration:      span.DurationNano / 1000,
+		Tags:          []KeyValue{},
+		Logs:          []Log{},
+		ProcessID:     processID,
+	}
+	for _, ref := range span.References {
+		if ref.SpanId == "" {
+			continue
+		}
+		traceID := ref.TraceId
+		if traceID == "" {
+			traceID = span.TraceID
+		}
+		result.References = append(result.References, Reference{RefType: refTypeChildOf, TraceID: traceID, SpanID: ref.SpanId})
+	}
+
+	keys := make([]string, 0, len(span.TagMap))
+	for key := range span.TagMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		result.Tags = append(result.Tags, KeyValue{Key: key, Type: typeString, Value: span.TagMap[key]})
+	}
+	if kind := SpanKind(span.Kind); kind != "" {
+		result.Tags = append(result.Tags, KeyValue{Key: "span.kind", Type: typeString, Value: kind})
+	}
+	if span.StatusCodeString != "" {
+		result.Tags = append(result.Tags, KeyValue{Key: "otel.status_code", Type: typeString, Value: span.StatusCodeString})
+	}
+	if span.StatusMessage != "" {
+		result.Tags = append(result.Tags, KeyValue{Key: "otel.status_description", Type: typeString, Value: span.StatusMessage})
+	}
+	if span.HasError {
+		result.Tags = append(result.Tags, KeyValue{Key: "error", Type: typeBool, Value: true})
+	}
+
+	for _, encoded := range span.Events {
+		var event model.Event
+		if err := json.Unmarshal([]byte(encoded), &event); err != nil {
+			continue
+		}
+		log := Log{Timestamp: event.TimeUnixNano / 1000, Fields: []KeyValue{{Key: "event", Type: typeString, Value: event.Name}}}
+		keys := make([]string, 0, len(event.AttributeMap))
+		for key := range event.AttributeMap {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			log.Fields = append(log.Fields, KeyValue{Key: key, Type: typeString, Value: fmt.Sprint(event.AttributeMap[key])})
+		}
+		result.Logs = append(result.Logs, log)
+	}
+	return result
+}
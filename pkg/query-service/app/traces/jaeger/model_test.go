@@ -0,0 +1,64 @@
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestFromSpans(t *testing.T) {
+	spans := []model.SearchSpanResponseItem{
+		{
+			TimeUnixNano: 1700000000002000000, DurationNano: 3000000, SpanID: "b", TraceID: "t1", Kind: 3,
+			ServiceName: "frontend", Name: "GET /api", TagMap: map[string]string{"http.method": "GET"},
+			References: []model.OtelSpanRef{{TraceId: "t1", SpanId: "a", RefType: "CHILD_OF"}},
+			HasError:   true, StatusCodeString: "STATUS_CODE_ERROR",
+			Events: []string{`{"name":"exception","timeUnixNano":1700000000003000000,"attributeMap":{"exception.type":"io"}}`},
+		},
+		{
+			TimeUnixNano: 1700000000000000000, DurationNano: 10000000, SpanID: "a", TraceID: "t1", Kind: 2,
+			ServiceName: "gateway", Name: "request", References: []model.OtelSpanRef{{TraceId: "t1", RefType: "CHILD_OF"}},
+		},
+		{TimeUnixNano: 1700000000000000000, SpanID: "c", TraceID: "t2", ServiceName: "gateway", Name: "request"},
+	}
+
+	traces := FromSpans([]string{"t2", "t1", "unknown"}, spans)
+	require.Len(t, traces, 2)
+	assert.Equal(t, "t2", traces[0].TraceID)
+
+	trace := traces[1]
+	assert.Equal(t, map[string]Process{
+		"p1": {ServiceName: "gateway", Tags: []KeyValue{}},
+		"p2": {ServiceName: "frontend", Tags: []KeyValue{}},
+	}, trace.Processes)
+	require.Len(t, trace.Spans, 2)
+
+	// the spans are ordered by start time and the empty references are dropped
+	root := trace.Spans[0]
+	assert.Equal(t, "a", root.SpanID)
+	assert.Equal(t, uint64(1700000000000000), root.StartTime)
+	assert.Equal(t, int64(10000), root.Duration)
+	assert.Empty(t, root.References)
+	assert.Equal(t, []KeyValue{{Key: "span.kind", Type: "string", Value: "server"}}, root.Tags)
+
+	child := trace.Spans[1]
+	assert.Equal(t, "p2", child.ProcessID)
+	assert.Equal(t, []Reference{{RefType: "CHILD_OF", TraceID: "t1", SpanID: "a"}}, child.References)
+	assert.Equal(t, []KeyValue{
+		{Key: "http.method", Type: "string", Value: "GET"},
+		{Key: "span.kind", Type: "string", Value: "client"},
+		{Key: "otel.status_code", Type: "string", Value: "STATUS_CODE_ERROR"},
+		{Key: "error", Type: "bool", Value: true},
+	}, child.Tags)
+	assert.Equal(t, []Log{{Timestamp: 1700000000003000, Fields: []KeyValue{
+		{Key: "event", Type: "string", Value: "exception"},
+		{Key: "exception.type", Type: "string", Value: "io"},
+	}}}, child.Logs)
+}
+
+func TestNormalizeTraceID(t *testing.T) {
+	assert.Equal(t, "00000000000000000123456789abcdef", NormalizeTraceID("123456789ABCDEF"))
+	assert.Equal(t, "0123456789abcdef0123456789abcdef", NormalizeTraceID("0123456789abcdef0123456789abcdef"))
+}
@@ -0,0 +1,245 @@
+package rum
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	tracesDB   = "signoz_traces"
+	indexTable = "distributed_signoz_index_v2"
+
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// GroupBy are the dimensions the page loads and the web vitals can be grouped by
+var GroupBy = map[string]string{
+	"":        "''",
+	"route":   fmt.Sprintf("stringTagMap['%s']", PageRouteKey),
+	"country": fmt.Sprintf("stringTagMap['%s']", CountryKey),
+}
+
+// thresholds are the limits of the good and the poor ratings of the web vitals, see web.dev/vitals
+var thresholds = map[string][2]float64{
+	"LCP":  {2500, 4000},
+	"INP":  {200, 500},
+	"FID":  {100, 300},
+	"CLS":  {0.1, 0.25},
+	"FCP":  {1800, 3000},
+	"TTFB": {800, 1800},
+}
+
+// Rating rates the value of a web vital as good, needs-improvement or poor
+func Rating(vital string, value float64) string {
+	t, ok := thresholds[vital]
+	switch {
+	case !ok:
+		return ""
+	case value <= t[0]:
+		return "good"
+	case value <= t[1]:
+		return "needs-improvement"
+	default:
+		return "poor"
+	}
+}
+
+// Params selects the page views of a web application, the range is in milliseconds
+type Params struct {
+	ServiceName string `json:"serviceName"`
+	Start       int64  `json:"start"`
+	End         int64  `json:"end"`
+	// GroupBy is route, country or empty
+	GroupBy string `json:"groupBy"`
+	// Route keeps the page views of the route
+	Route string `json:"route"`
+	Limit int    `json:"limit"`
+	// OrgScope restricts the page views to those sent with the ingestion keys of the orgs of the user
+	OrgScope *model.OrgScope `json:"-"`
+}
+
+func (p *Params) Validate() error {
+	if p.ServiceName == "" {
+		return fmt.Errorf("serviceName is required")
+	}
+	if p.Start <= 0 || p.End <= 0 || p.Start >= p.End {
+		return fmt.Errorf("start and end are required and start must be before end")
+	}
+	if _, ok := GroupBy[p.GroupBy]; !ok {
+		return fmt.Errorf("invalid groupBy %q, must be route or country", p.GroupBy)
+	}
+	if p.Limit < 0 || p.Limit > MaxLimit {
+		return fmt.Errorf("limit must be between 0 and %d", MaxLimit)
+	}
+	if p.Limit == 0 {
+		p.Limit = DefaultLimit
+	}
+	return nil
+}
+
+// where returns the conditions of the spans of the params with the name and their arguments
+func (p *Params) where(spanName string) (string, []interface{}) {
+	conditions := []string{"serviceName = $1", "name = $2", "timestamp >= $3", "timestamp <= $4"}
+	args := []interface{}{p.ServiceName, spanName, time.UnixMilli(p.Start), time.UnixMilli(p.End)}
+	if p.Route != "" {
+		args = append(args, p.Route)
+		conditions = append(conditions, fmt.Sprintf("stringTagMap['%s'] = $%d", PageRouteKey, len(args)))
+	}
+	if p.OrgScope != nil {
+		// the page views sent before the orgs were added have no org and belong to the default org
+		not := ""
+		if p.OrgScope.Exclude {
+			not = "NOT "
+		}
+		args = append(args, constants.OrgIdAttribute, p.OrgScope.OrgIds)
+		conditions = append(conditions, fmt.Sprintf("resourceTagsMap[$%d] %sIN $%d", len(args)-1, not, len(args)))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// WebVitals are the percentiles of a web vital of a group, the Core Web Vitals are rated on
+// their 75th percentile
+type WebVitals struct {
+	Group     string    `json:"group" ch:"grp"`
+	Name      string    `json:"name" ch:"vital"`
+	Count     uint64    `json:"count" ch:"count"`
+	Quantiles []float64 `json:"-" ch:"quantiles"`
+	P50       float64   `json:"p50"`
+	P75       float64   `json:"p75"`
+	P90       float64   `json:"p90"`
+	P99       float64   `json:"p99"`
+	Rating    string    `json:"rating"`
+}
+
+// PageLoads are the percentiles of the durations of the page loads of a group, in milliseconds
+type PageLoads struct {
+	Group     string    `json:"group" ch:"grp"`
+	Count     uint64    `json:"count" ch:"count"`
+	Quantiles []float64 `json:"-" ch:"quantiles"`
+	P50       float64   `json:"p50"`
+	P75       float64   `json:"p75"`
+	P95       float64   `json:"p95"`
+	AvgTTFB   float64   `json:"avgTtfb" ch:"avg_ttfb"`
+}
+
+// PageLoadTrace is a page load with the backend services of its trace, the page loads join
+// the trace of the backend request which rendered the page
+type PageLoadTrace struct {
+	Timestamp       time.Time `json:"timestamp" ch:"timestamp"`
+	TraceId         string    `json:"traceId" ch:"traceID"`
+	URL             string    `json:"url" ch:"url"`
+	Route           string    `json:"route" ch:"route"`
+	Country         string    `json:"country" ch:"country"`
+	DurationNano    uint64    `json:"durationNano" ch:"durationNano"`
+	BackendServices []string  `json:"backendServices"`
+}
+
+// Querier reads the page views of the browsers from the SigNoz traces tables
+type Querier struct {
+	conn clickhouse.Conn
+}
+
+func NewQuerier(conn clickhouse.Conn) *Querier {
+	return &Querier{conn: conn}
+}
+
+// WebVitals returns the percentiles of the web vitals per group, the most frequent first
+func (q *Querier) WebVitals(ctx context.Context, p *Params) ([]WebVitals, error) {
+	where, args := p.where(WebVitalSpanName)
+	query := fmt.Sprintf(`SELECT %s AS grp, stringTagMap['%s'] AS vital, count() AS count,
+		quantiles(0.5, 0.75, 0.9, 0.99)(numberTagMap['%s']) AS quantiles
+		FROM %s.%s WHERE %s GROUP BY grp, vital ORDER BY count DESC, grp, vital LIMIT %d`,
+		GroupBy[p.GroupBy], WebVitalNameKey, WebVitalValueKey, tracesDB, indexTable, where, p.Limit)
+
+	result := []WebVitals{}
+	if err := q.conn.Select(ctx, &result, query, args...); err != nil {
+		zap.L().Error("Error while reading the web vitals", zap.Error(err))
+		return nil, err
+	}
+	for i := range result {
+		v := &result[i]
+		if len(v.Quantiles) == 4 {
+			v.P50, v.P75, v.P90, v.P99 = v.Quantiles[0], v.Quantiles[1], v.Quantiles[2], v.Quantiles[3]
+		}
+		v.Rating = Rating(v.Name, v.P75)
+	}
+	return result, nil
+}
+
+// PageLoads returns the percentiles of the page loads per group, the most frequent first
+func (q *Querier) PageLoads(ctx context.Context, p *Params) ([]PageLoads, error) {
+	where, args := p.where(PageLoadSpanName)
+	query := fmt.Sprintf(`SELECT %s AS grp, count() AS count,
+		quantiles(0.5, 0.75, 0.95)(durationNano / 1e6) AS quantiles, avg(numberTagMap['%s']) AS avg_ttfb
+		FROM %s.%s WHERE %s GROUP BY grp ORDER BY count DESC, grp LIMIT %d`,
+		GroupBy[p.GroupBy], TTFBKey, tracesDB, indexTable, where, p.Limit)
+
+	result := []PageLoads{}
+	if err := q.conn.Select(ctx, &result, query, args...); err != nil {
+		zap.L().Error("Error while reading the page loads", zap.Error(err))
+		return nil, err
+	}
+	for i := range result {
+		l := &result[i]
+		if len(l.Quantiles) == 3 {
+			l.P50, l.P75, l.P95 = l.Quantiles[0], l.Quantiles[1], l.Quantiles[2]
+		}
+	}
+	return result, nil
+}
+
+// SlowestPageLoads returns the slowest page loads with the backend services of their traces
+func (q *Querier) SlowestPageLoads(ctx context.Context, p *Params) ([]PageLoadTrace, error) {
+	where, args := p.where(PageLoadSpanName)
+	query := fmt.Sprintf(`SELECT timestamp, traceID, stringTagMap['%s'] AS url, stringTagMap['%s'] AS route,
+		stringTagMap['%s'] AS country, durationNano
+		FROM %s.%s WHERE %s ORDER BY durationNano DESC LIMIT %d`,
+		PageURLKey, PageRouteKey, CountryKey, tracesDB, indexTable, where, p.Limit)
+
+	result := []PageLoadTrace{}
+	if err := q.conn.Select(ctx, &result, query, args...); err != nil {
+		zap.L().Error("Error while reading the slowest page loads", zap.Error(err))
+		return nil, err
+	}
+	if len(result) == 0 {
+		return result, nil
+	}
+
+	traceIDs := make([]string, 0, len(result))
+	for _, l := range result {
+		traceIDs = append(traceIDs, l.TraceId)
+	}
+	// the backend spans of a page load start at most a minute before it
+	var services []struct {
+		TraceID  string   `ch:"traceID"`
+		Services []string `ch:"services"`
+	}
+	query = fmt.Sprintf(`SELECT traceID, arraySort(groupUniqArray(serviceName)) AS services
+		FROM %s.%s WHERE traceID IN $1 AND serviceName != $2 AND timestamp >= $3 AND timestamp <= $4
+		GROUP BY traceID`, tracesDB, indexTable)
+	start, end := time.UnixMilli(p.Start).Add(-time.Minute), time.UnixMilli(p.End).Add(time.Minute)
+	if err := q.conn.Select(ctx, &services, query, traceIDs, p.ServiceName, start, end); err != nil {
+		zap.L().Error("Error while reading the backend services of the page loads", zap.Error(err))
+		return nil, err
+	}
+	byTrace := map[string][]string{}
+	for _, s := range services {
+		byTrace[s.TraceID] = s.Services
+	}
+	for i := range result {
+		l := &result[i]
+		l.BackendServices = byTrace[l.TraceId]
+		if l.BackendServices == nil {
+			l.BackendServices = []string{}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,279 @@
+package rum
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/app/traces/spans"
+)
+
+// MaxRequestSize is the largest payload accepted, before decompression
+const MaxRequestSize = 1 << 20
+
+// Names of the spans of the page loads and the web vitals
+const (
+	PageLoadSpanName = "documentLoad"
+	WebVitalSpanName = "webVital"
+)
+
+// Attributes of the RUM spans
+const (
+	PageURLKey       = "page.url"
+	PageRouteKey     = "page.route"
+	SessionIdKey     = "session.id"
+	CountryKey       = "geo.country"
+	UserAgentKey     = "user_agent.original"
+	TTFBKey          = "page_load.ttfb"
+	DOMContentKey    = "page_load.dom_content_loaded"
+	WebVitalNameKey  = "web_vital.name"
+	WebVitalValueKey = "web_vital.value"
+)
+
+// ErrInvalidPayload is returned for the payloads that can not be written
+var ErrInvalidPayload = errors.New("invalid payload")
+
+var (
+	traceIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	spanIDPattern  = regexp.MustCompile(`^[0-9a-f]{16}$`)
+)
+
+// Vitals are the web vitals accepted, the values are in milliseconds except CLS which is a score
+var Vitals = map[string]struct{}{"LCP": {}, "INP": {}, "FID": {}, "CLS": {}, "FCP": {}, "TTFB": {}}
+
+// Payload is the page view of a browser sent by the RUM script, the times are in milliseconds
+type Payload struct {
+	Service     string     `json:"service"`
+	Version     string     `json:"version"`
+	Environment string     `json:"environment"`
+	SessionId   string     `json:"sessionId"`
+	Page        Page       `json:"page"`
+	PageLoad    *PageLoad  `json:"pageLoad"`
+	Vitals      []WebVital `json:"vitals"`
+	Spans       []Span     `json:"spans"`
+}
+
+type Page struct {
+	URL   string `json:"url"`
+	Route string `json:"route"`
+}
+
+// PageLoad is the navigation of the page. Traceparent is the W3C trace context rendered by the
+// backend in the page, the page load joins the trace of the backend request when set
+type PageLoad struct {
+	Timestamp        int64   `json:"timestamp"`
+	Duration         float64 `json:"duration"`
+	TTFB             float64 `json:"ttfb"`
+	DOMContentLoaded float64 `json:"domContentLoaded"`
+	Traceparent      string  `json:"traceparent"`
+}
+
+type WebVital struct {
+	Name      string  `json:"name"`
+	Value     float64 `json:"value"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+// Span is a span of the browser, e.g. a fetch. The fetches propagate the trace and the span
+// ids in the traceparent header so the backend spans are its children
+type Span struct {
+	TraceId      string            `json:"traceId"`
+	SpanId       string            `json:"spanId"`
+	ParentSpanId string            `json:"parentSpanId"`
+	Name         string            `json:"name"`
+	Kind         string            `json:"kind"`
+	Timestamp    int64             `json:"timestamp"`
+	Duration     float64           `json:"duration"`
+	Error        bool              `json:"error"`
+	Attributes   map[string]string `json:"attributes"`
+}
+
+// Client is what the server knows of the browser, the country is from the headers of the CDN
+type Client struct {
+	Country   string
+	UserAgent string
+}
+
+// Decode decodes a payload in JSON, optionally gzip compressed
+func Decode(body []byte, contentEncoding string) (*Payload, error) {
+	if contentEncoding == "gzip" {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress payload: %v", err)
+		}
+		if body, err = io.ReadAll(io.LimitReader(reader, 8*MaxRequestSize)); err != nil {
+			return nil, fmt.Errorf("failed to decompress payload: %v", err)
+		}
+	}
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %v", err)
+	}
+	return &p, nil
+}
+
+// ParseTraceparent returns the trace id and the parent span id of a W3C traceparent
+func ParseTraceparent(traceparent string) (string, string, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(traceparent)), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || !traceIDPattern.MatchString(parts[1]) || !spanIDPattern.MatchString(parts[2]) {
+		return "", "", fmt.Errorf("%w: invalid traceparent %q", ErrInvalidPayload, traceparent)
+	}
+	if parts[1] == strings.Repeat("0", 32) || parts[2] == strings.Repeat("0", 16) {
+		return "", "", fmt.Errorf("%w: invalid traceparent %q", ErrInvalidPayload, traceparent)
+	}
+	return parts[1], parts[2], nil
+}
+
+func randomID(bytes int) string {
+	b := make([]byte, bytes)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+var kinds = map[string]struct {
+	kind int8
+	name string
+}{
+	"":         {1, "Internal"},
+	"internal": {1, "Internal"},
+	"client":   {3, "Client"},
+}
+
+// ToSpans converts the payload to the spans written in the traces tables. The web vitals are
+// children of the page load, the page attributes are set on all the spans
+func ToSpans(p *Payload, client Client) ([]*spans.Span, error) {
+	if p.Service == "" {
+		return nil, fmt.Errorf("%w: service is required", ErrInvalidPayload)
+	}
+	resources := map[string]string{"service.name": p.Service}
+	if p.Version != "" {
+		resources["service.version"] = p.Version
+	}
+	if p.Environment != "" {
+		resources["deployment.environment"] = p.Environment
+	}
+	page := map[string]string{}
+	for k, v := range map[string]string{PageURLKey: p.Page.URL, PageRouteKey: p.Page.Route, SessionIdKey: p.SessionId,
+		CountryKey: strings.ToUpper(client.Country), UserAgentKey: client.UserAgent} {
+		if v != "" {
+			page[k] = v
+		}
+	}
+
+	newSpan := func(traceID, spanID, parentID, name string, kind int8, spanKind string, timestamp int64, duration float64) *spans.Span {
+		s := &spans.Span{
+			TraceID:           traceID,
+			SpanID:            spanID,
+			ParentSpanID:      parentID,
+			Name:              name,
+			StartTimeUnixNano: uint64(timestamp) * 1e6,
+			DurationNano:      uint64(duration * 1e6),
+			ServiceName:       p.Service,
+			Kind:              kind,
+			SpanKind:          spanKind,
+			StatusCodeString:  "Unset",
+			StringTagMap:      map[string]string{},
+			NumberTagMap:      map[string]float64{},
+			BoolTagMap:        map[string]bool{},
+			ResourceTagsMap:   resources,
+			TagMap:            map[string]string{},
+			Events:            []string{},
+			References:        []spans.Ref{{TraceID: traceID, SpanID: parentID, RefType: "CHILD_OF"}},
+		}
+		// the resources are also stored with the attributes, as the SigNoz exporter
+		for _, m := range []map[string]string{page, resources} {
+			for k, v := range m {
+				s.StringTagMap[k] = v
+				s.TagMap[k] = v
+			}
+		}
+		return s
+	}
+
+	var result []*spans.Span
+	traceID, pageLoadID := randomID(16), randomID(8)
+	pageLoadTimestamp := int64(0)
+	if p.PageLoad != nil {
+		parentID := ""
+		if p.PageLoad.Traceparent != "" {
+			var err error
+			if traceID, parentID, err = ParseTraceparent(p.PageLoad.Traceparent); err != nil {
+				return nil, err
+			}
+		}
+		if p.PageLoad.Timestamp <= 0 || p.PageLoad.Duration < 0 {
+			return nil, fmt.Errorf("%w: the page load has no timestamp", ErrInvalidPayload)
+		}
+		s := newSpan(traceID, pageLoadID, parentID, PageLoadSpanName, 1, "Internal", p.PageLoad.Timestamp, p.PageLoad.Duration)
+		s.NumberTagMap[TTFBKey] = p.PageLoad.TTFB
+		s.NumberTagMap[DOMContentKey] = p.PageLoad.DOMContentLoaded
+		result = append(result, s)
+		pageLoadTimestamp = p.PageLoad.Timestamp
+	}
+
+	for _, vital := range p.Vitals {
+		if _, ok := Vitals[vital.Name]; !ok {
+			return nil, fmt.Errorf("%w: unknown web vital %q", ErrInvalidPayload, vital.Name)
+		}
+		if vital.Value < 0 {
+			return nil, fmt.Errorf("%w: the web vital %s is negative", ErrInvalidPayload, vital.Name)
+		}
+		timestamp := vital.Timestamp
+		if timestamp <= 0 {
+			timestamp = pageLoadTimestamp
+		}
+		if timestamp <= 0 {
+			return nil, fmt.Errorf("%w: the web vital %s has no timestamp", ErrInvalidPayload, vital.Name)
+		}
+		parentID := ""
+		if p.PageLoad != nil {
+			parentID = pageLoadID
+		}
+		s := newSpan(traceID, randomID(8), parentID, WebVitalSpanName, 1, "Internal", timestamp, 0)
+		s.StringTagMap[WebVitalNameKey] = vital.Name
+		s.TagMap[WebVitalNameKey] = vital.Name
+		s.NumberTagMap[WebVitalValueKey] = vital.Value
+		result = append(result, s)
+	}
+
+	for _, bs := range p.Spans {
+		bs.TraceId, bs.SpanId, bs.ParentSpanId = strings.ToLower(bs.TraceId), strings.ToLower(bs.SpanId), strings.ToLower(bs.ParentSpanId)
+		if !traceIDPattern.MatchString(bs.TraceId) || !spanIDPattern.MatchString(bs.SpanId) {
+			return nil, fmt.Errorf("%w: the span %q has invalid ids", ErrInvalidPayload, bs.Name)
+		}
+		if bs.ParentSpanId != "" && !spanIDPattern.MatchString(bs.ParentSpanId) {
+			return nil, fmt.Errorf("%w: the span %q has an invalid parent id", ErrInvalidPayload, bs.Name)
+		}
+		kind, ok := kinds[strings.ToLower(bs.Kind)]
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid kind %q", ErrInvalidPayload, bs.Kind)
+		}
+		if bs.Timestamp <= 0 || bs.Duration < 0 {
+			return nil, fmt.Errorf("%w: the span %q has no timestamp", ErrInvalidPayload, bs.Name)
+		}
+		s := newSpan(bs.TraceId, bs.SpanId, bs.ParentSpanId, bs.Name, kind.kind, kind.name, bs.Timestamp, bs.Duration)
+		for k, v := range bs.Attributes {
+			s.StringTagMap[k] = v
+			s.TagMap[k] = v
+		}
+		if bs.Error {
+			s.HasError = true
+			s.StatusCode = 2
+			s.StatusCodeString = "Error"
+		}
+		spans.PopulateDimensions(s, bs.Attributes)
+		result = append(result, s)
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%w: the payload has no page load, web vital or span", ErrInvalidPayload)
+	}
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package rum
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	traceID, parentID, err := ParseTraceparent("00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	require.Equal(t, "00f067aa0ba902b7", parentID)
+
+	for _, invalid := range []string{"", "00-4bf92f3577b34da6-00f067aa0ba902b7-01", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"} {
+		_, _, err := ParseTraceparent(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestToSpans(t *testing.T) {
+	payload, err := Decode([]byte(`{
+		"service": "shop-web", "version": "1.2.0", "sessionId": "s1",
+		"page": {"url": "https://shop.example.com/product/42", "route": "/product/:id"},
+		"pageLoad": {"timestamp": 1700000000000, "duration": 1250.5, "ttfb": 180, "traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		"vitals": [{"name": "LCP", "value": 2300}, {"name": "CLS", "value": 0.05, "timestamp": 1700000005000}],
+		"spans": [{"traceId": "0af7651916cd43dd8448eb211c80319c", "spanId": "b7ad6b7169203331", "name": "HTTP GET", "kind": "client",
+			"timestamp": 1700000002000, "duration": 85, "attributes": {"http.url": "https://api.example.com/cart", "http.method": "GET"}}]
+	}`), "")
+	require.NoError(t, err)
+
+	result, err := ToSpans(payload, Client{Country: "fr", UserAgent: "Mozilla/5.0"})
+	require.NoError(t, err)
+	require.Len(t, result, 4)
+
+	// the page load joins the trace of the backend request which rendered the page
+	pageLoad := result[0]
+	require.Equal(t, PageLoadSpanName, pageLoad.Name)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", pageLoad.TraceID)
+	require.Equal(t, "00f067aa0ba902b7", pageLoad.ParentSpanID)
+	require.Equal(t, uint64(1250500000), pageLoad.DurationNano)
+	require.Equal(t, float64(180), pageLoad.NumberTagMap[TTFBKey])
+	require.Equal(t, "/product/:id", pageLoad.StringTagMap[PageRouteKey])
+	require.Equal(t, "FR", pageLoad.StringTagMap[CountryKey])
+	require.Equal(t, "1.2.0", pageLoad.ResourceTagsMap["service.version"])
+
+	lcp := result[1]
+	require.Equal(t, WebVitalSpanName, lcp.Name)
+	require.Equal(t, pageLoad.TraceID, lcp.TraceID)
+	require.Equal(t, pageLoad.SpanID, lcp.ParentSpanID)
+	require.Equal(t, "LCP", lcp.StringTagMap[WebVitalNameKey])
+	require.Equal(t, float64(2300), lcp.NumberTagMap[WebVitalValueKey])
+	require.Equal(t, uint64(1700000000000)*1e6, lcp.StartTimeUnixNano)
+	require.Equal(t, uint64(1700000005000)*1e6, result[2].StartTimeUnixNano)
+
+	fetch := result[3]
+	require.Equal(t, int8(3), fetch.Kind)
+	require.Equal(t, "GET", fetch.ExternalHttpMethod)
+	require.Equal(t, "api.example.com", fetch.ExternalHttpUrl)
+	require.Equal(t, "s1", fetch.StringTagMap[SessionIdKey])
+
+	for _, invalid := range []*Payload{
+		{},
+		{Service: "shop-web"},
+		{Service: "shop-web", Vitals: []WebVital{{Name: "XYZ", Value: 1, Timestamp: 1}}},
+		{Service: "shop-web", Vitals: []WebVital{{Name: "LCP", Value: 1}}},
+		{Service: "shop-web", PageLoad: &PageLoad{Timestamp: 1, Traceparent: "invalid"}},
+		{Service: "shop-web", Spans: []Span{{TraceId: "abc", SpanId: "b7ad6b7169203331", Timestamp: 1}}},
+	} {
+		_, err := ToSpans(invalid, Client{})
+		require.True(t, errors.Is(err, ErrInvalidPayload), err)
+	}
+}
+
+func TestRating(t *testing.T) {
+	require.Equal(t, "good", Rating("LCP", 2500))
+	require.Equal(t, "needs-improvement", Rating("INP", 350))
+	require.Equal(t, "poor", Rating("CLS", 0.3))
+	require.Equal(t, "", Rating("XYZ", 1))
+
+	p := &Params{ServiceName: "shop-web", Start: 1, End: 2}
+	require.NoError(t, p.Validate())
+	require.Equal(t, DefaultLimit, p.Limit)
+	where, args := (&Params{ServiceName: "shop-web", Start: 1, End: 2, Route: "/cart"}).where(PageLoadSpanName)
+	require.Contains(t, where, "stringTagMap['page.route'] = $5")
+	require.Len(t, args, 5)
+	where, args = (&Params{ServiceName: "shop-web", Start: 1, End: 2,
+		OrgScope: &model.OrgScope{OrgIds: []string{"org1"}}}).where(PageLoadSpanName)
+	require.Contains(t, where, "resourceTagsMap[$5] IN $6")
+	require.Equal(t, []interface{}{"signoz.org.id", []string{"org1"}}, args[4:])
+	require.Error(t, (&Params{ServiceName: "shop-web", Start: 1, End: 2, GroupBy: "browser"}).Validate())
+}
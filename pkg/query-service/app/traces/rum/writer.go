@@ -0,0 +1,32 @@
+package rum
+
+import (
+	"context"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+
+	"go.signoz.io/signoz/pkg/query-service/app/traces/spans"
+)
+
+// Writer writes the page views of the browsers into the SigNoz traces tables, as spans of the
+// service of the web application
+type Writer struct {
+	spans *spans.Writer
+}
+
+func NewWriter(conn clickhouse.Conn) *Writer {
+	return &Writer{spans: spans.NewWriter(conn)}
+}
+
+// Write writes the spans of the payload with the resources set on all of them, e.g. the org
+// of the ingestion key
+func (w *Writer) Write(ctx context.Context, p *Payload, client Client, resources map[string]string) error {
+	converted, err := ToSpans(p, client)
+	if err != nil {
+		return err
+	}
+	for k, v := range resources {
+		spans.SetResource(converted, k, v)
+	}
+	return w.spans.Write(ctx, converted)
+}
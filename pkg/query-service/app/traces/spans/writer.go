@@ -0,0 +1,177 @@
+package spans
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+const (
+	tracesDB   = "signoz_traces"
+	indexTable = "distributed_signoz_index_v2"
+	spansTable = "distributed_signoz_spans"
+
+	// KindClient is the OpenTelemetry kind of the client spans
+	KindClient = 3
+)
+
+// Span is a span of the traces tables, its JSON is the model of the spans table
+type Span struct {
+	TraceID            string             `json:"traceId"`
+	SpanID             string             `json:"spanId"`
+	ParentSpanID       string             `json:"-"`
+	Name               string             `json:"name"`
+	DurationNano       uint64             `json:"durationNano"`
+	StartTimeUnixNano  uint64             `json:"startTimeUnixNano"`
+	ServiceName        string             `json:"serviceName"`
+	Kind               int8               `json:"kind"`
+	SpanKind           string             `json:"spanKind"`
+	References         []Ref              `json:"references"`
+	StatusCode         int16              `json:"statusCode,omitempty"`
+	TagMap             map[string]string  `json:"tagMap"`
+	StringTagMap       map[string]string  `json:"stringTagMap"`
+	NumberTagMap       map[string]float64 `json:"numberTagMap"`
+	BoolTagMap         map[string]bool    `json:"boolTagMap"`
+	Events             []string           `json:"event"`
+	HasError           bool               `json:"hasError,omitempty"`
+	StatusMessage      string             `json:"statusMessage,omitempty"`
+	StatusCodeString   string             `json:"statusCodeString"`
+	ResourceTagsMap    map[string]string  `json:"-"`
+	ExternalHttpMethod string             `json:"-"`
+	ExternalHttpUrl    string             `json:"-"`
+	HttpMethod         string             `json:"-"`
+	HttpUrl            string             `json:"-"`
+	HttpRoute          string             `json:"-"`
+	HttpHost           string             `json:"-"`
+	DBSystem           string             `json:"-"`
+	DBName             string             `json:"-"`
+	DBOperation        string             `json:"-"`
+	PeerService        string             `json:"-"`
+	RPCSystem          string             `json:"-"`
+	RPCService         string             `json:"-"`
+	RPCMethod          string             `json:"-"`
+	ResponseStatusCode string             `json:"-"`
+}
+
+type Ref struct {
+	TraceID string `json:"traceId,omitempty"`
+	SpanID  string `json:"spanId,omitempty"`
+	RefType string `json:"refType,omitempty"`
+}
+
+// Event is an event of a span, the events are stored as their JSON
+type Event struct {
+	Name         string                 `json:"name,omitempty"`
+	TimeUnixNano uint64                 `json:"timeUnixNano,omitempty"`
+	AttributeMap map[string]interface{} `json:"attributeMap,omitempty"`
+}
+
+// PopulateDimensions sets the columns of the index table read from the string attributes
+func PopulateDimensions(s *Span, attributes map[string]string) {
+	for k, v := range attributes {
+		switch k {
+		case "http.status_code", "rpc.grpc.status_code":
+			s.ResponseStatusCode = v
+		case "http.url":
+			s.HttpUrl = v
+			if s.Kind == KindClient {
+				s.ExternalHttpUrl = v
+				if u, err := url.Parse(v); err == nil {
+					s.ExternalHttpUrl = u.Hostname()
+				}
+			}
+		case "http.method":
+			s.HttpMethod = v
+			if s.Kind == KindClient {
+				s.ExternalHttpMethod = v
+			}
+		case "http.route":
+			s.HttpRoute = v
+		case "http.host":
+			s.HttpHost = v
+		case "db.system":
+			s.DBSystem = v
+		case "db.name":
+			s.DBName = v
+		case "db.operation":
+			s.DBOperation = v
+		case "peer.service":
+			s.PeerService = v
+		case "rpc.system":
+			s.RPCSystem = v
+		case "rpc.service":
+			s.RPCService = v
+		case "rpc.method":
+			s.RPCMethod = v
+		}
+	}
+}
+
+// SetResource sets a resource attribute of the spans, e.g. the org of the ingestion key. The
+// resources are also stored with the attributes, as the SigNoz exporter
+func SetResource(spans []*Span, key, value string) {
+	for _, s := range spans {
+		if s.ResourceTagsMap == nil {
+			s.ResourceTagsMap = map[string]string{}
+		}
+		s.ResourceTagsMap[key] = value
+		s.StringTagMap[key] = value
+		s.TagMap[key] = value
+	}
+}
+
+// Writer writes the spans into the SigNoz traces tables, as the SigNoz exporter of the collector
+type Writer struct {
+	conn clickhouse.Conn
+}
+
+func NewWriter(conn clickhouse.Conn) *Writer {
+	return &Writer{conn: conn}
+}
+
+func (w *Writer) Write(ctx context.Context, spans []*Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	// the spans are written in the spans table first, as the exporter, so the spans found
+	// with the index table can be read
+	statement, err := w.conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (timestamp, traceID, model)", tracesDB, spansTable))
+	if err != nil {
+		return err
+	}
+	for _, s := range spans {
+		model, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		if err := statement.Append(time.Unix(0, int64(s.StartTimeUnixNano)), s.TraceID, string(model)); err != nil {
+			return err
+		}
+	}
+	if err := statement.Send(); err != nil {
+		return err
+	}
+
+	statement, err = w.conn.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (timestamp, traceID, spanID, parentSpanID, serviceName, name, kind, "+
+		"durationNano, statusCode, externalHttpMethod, externalHttpUrl, dbSystem, dbName, dbOperation, peerService, events, httpMethod, "+
+		"httpUrl, httpRoute, httpHost, hasError, rpcSystem, rpcService, rpcMethod, responseStatusCode, stringTagMap, numberTagMap, "+
+		"boolTagMap, resourceTagsMap, statusMessage, statusCodeString, spanKind)", tracesDB, indexTable))
+	if err != nil {
+		return err
+	}
+	for _, s := range spans {
+		err := statement.Append(time.Unix(0, int64(s.StartTimeUnixNano)), s.TraceID, s.SpanID, s.ParentSpanID, s.ServiceName, s.Name, s.Kind,
+			s.DurationNano, s.StatusCode, s.ExternalHttpMethod, s.ExternalHttpUrl, s.DBSystem, s.DBName, s.DBOperation, s.PeerService, s.Events,
+			s.HttpMethod, s.HttpUrl, s.HttpRoute, s.HttpHost, s.HasError, s.RPCSystem, s.RPCService, s.RPCMethod, s.ResponseStatusCode,
+			s.StringTagMap, s.NumberTagMap, s.BoolTagMap, s.ResourceTagsMap, s.StatusMessage, s.StatusCodeString, s.SpanKind)
+		if err != nil {
+			return err
+		}
+	}
+	return statement.Send()
+}
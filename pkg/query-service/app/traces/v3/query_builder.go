@@ -67,6 +67,13 @@ func getColumnName(key v3.AttributeKey, keys map[string]v3.AttributeKey) string
 	return fmt.Sprintf("%s%s['%s']", filterDataType, filterType, key.Key)
 }
 
+// AttributeExpression returns the expression of the value of the attribute or resource key
+// in the spans table
+func AttributeExpression(key v3.AttributeKey) string {
+	key.IsColumn = false
+	return getColumnName(key, nil)
+}
+
 func getClickhouseTracesColumnDataTypeAndType(key v3.AttributeKey) (v3.AttributeKeyType, string) {
 	filterType := key.Type
 	filterDataType := "string"
@@ -161,7 +168,11 @@ func buildTracesFilterQuery(fs *v3.FilterSet, keys map[string]v3.AttributeKey) (
 			var fmtVal string
 			key := enrichKeyWithMetadata(item.Key, keys)
 			item.Operator = v3.FilterOperator(strings.ToLower(strings.TrimSpace(string(item.Operator))))
-			if item.Operator != v3.FilterOperatorExists && item.Operator != v3.FilterOperatorNotExists {
+			// the regex and contains operators match the values of any data type as strings
+			if item.Operator.IsStringMatch() && key.DataType != v3.AttributeKeyDataTypeString {
+				val = fmt.Sprintf("%v", val)
+				columnName = fmt.Sprintf("toString(%s)", columnName)
+			} else if item.Operator != v3.FilterOperatorExists && item.Operator != v3.FilterOperatorNotExists {
 				var err error
 				val, err = utils.ValidateAndCastValue(val, key.DataType)
 				if err != nil {
@@ -206,15 +217,36 @@ func buildTracesFilterQuery(fs *v3.FilterSet, keys map[string]v3.AttributeKey) (
 	return queryString, nil
 }
 
+// BuildFilterCondition returns the condition selecting the spans matching the filters, true
+// when there are none
+func BuildFilterCondition(fs *v3.FilterSet, keys map[string]v3.AttributeKey) (string, error) {
+	query, err := buildTracesFilterQuery(fs, keys)
+	if err != nil {
+		return "", err
+	}
+	if query == "" {
+		return "true", nil
+	}
+	return "(" + strings.TrimPrefix(query, " AND ") + ")", nil
+}
+
+// existsSubQueryForFixedColumn returns the condition of the exists operators on a column, the
+// string and number columns exist when they are not empty or zero, as the logs columns, and the
+// bool columns always exist
 func existsSubQueryForFixedColumn(key v3.AttributeKey, op v3.FilterOperator) (string, error) {
-	if key.DataType == v3.AttributeKeyDataTypeString {
-		if op == v3.FilterOperatorExists {
-			return fmt.Sprintf("%s %s ''", key.Key, tracesOperatorMappingV3[v3.FilterOperatorNotEqual]), nil
-		} else {
-			return fmt.Sprintf("%s %s ''", key.Key, tracesOperatorMappingV3[v3.FilterOperatorEqual]), nil
-		}
-	} else {
-		return "", fmt.Errorf("unsupported operation, exists and not exists can only be applied on custom attributes or string type columns")
+	operator := tracesOperatorMappingV3[v3.FilterOperatorNotEqual]
+	if op == v3.FilterOperatorNotExists {
+		operator = tracesOperatorMappingV3[v3.FilterOperatorEqual]
+	}
+	switch key.DataType {
+	case v3.AttributeKeyDataTypeString:
+		return fmt.Sprintf("%s %s ''", key.Key, operator), nil
+	case v3.AttributeKeyDataTypeInt64, v3.AttributeKeyDataTypeFloat64:
+		return fmt.Sprintf("%s %s 0", key.Key, operator), nil
+	case v3.AttributeKeyDataTypeBool:
+		return fmt.Sprintf("%v", op == v3.FilterOperatorExists), nil
+	default:
+		return "", fmt.Errorf("unsupported operation, exists and not exists can't be applied on %s columns", key.DataType)
 	}
 }
 
@@ -344,8 +376,9 @@ func buildTracesQuery(start, end, step int64, mq *v3.BuilderQuery, tableName str
 		if mq.AggregateAttribute.Key != "" {
 			key := enrichKeyWithMetadata(mq.AggregateAttribute, keys)
 			if key.IsColumn {
-				subQuery, err := existsSubQueryForFixedColumn(key, v3.FilterOperatorExists)
-				if err == nil {
+				// the number and bool columns are counted for all the spans
+				if key.DataType == v3.AttributeKeyDataTypeString {
+					subQuery, _ := existsSubQueryForFixedColumn(key, v3.FilterOperatorExists)
 					filterSubQuery = fmt.Sprintf("%s AND %s", filterSubQuery, subQuery)
 				}
 			} else {
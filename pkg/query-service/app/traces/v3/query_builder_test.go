@@ -93,6 +93,23 @@ var buildFilterQueryData = []struct {
 		}},
 		ExpectedFilter: " AND name = ''",
 	},
+	{
+		Name: "Test exists and not exists with number and bool columns",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "durationNano", DataType: v3.AttributeKeyDataTypeFloat64, Type: v3.AttributeKeyTypeTag, IsColumn: true}, Operator: "exists"},
+			{Key: v3.AttributeKey{Key: "statusCode", DataType: v3.AttributeKeyDataTypeInt64, Type: v3.AttributeKeyTypeTag, IsColumn: true}, Operator: "nexists"},
+			{Key: v3.AttributeKey{Key: "hasError", DataType: v3.AttributeKeyDataTypeBool, Type: v3.AttributeKeyTypeTag, IsColumn: true}, Operator: "nexists"},
+		}},
+		ExpectedFilter: " AND durationNano != 0 AND statusCode = 0 AND false",
+	},
+	{
+		Name: "Test regex and not contains on number attributes",
+		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "http.status_code", DataType: v3.AttributeKeyDataTypeInt64, Type: v3.AttributeKeyTypeTag}, Value: "^5", Operator: "regex"},
+			{Key: v3.AttributeKey{Key: "bytes", DataType: v3.AttributeKeyDataTypeFloat64, Type: v3.AttributeKeyTypeTag}, Value: 10, Operator: "ncontains"},
+		}},
+		ExpectedFilter: " AND match(toString(numberTagMap['http.status_code']), '^5') AND toString(numberTagMap['bytes']) NOT ILIKE '%10%'",
+	},
 	{
 		Name: "Test contains",
 		FilterSet: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
@@ -140,6 +157,21 @@ func TestBuildTracesFilterQuery(t *testing.T) {
 	}
 }
 
+func TestBuildFilterCondition(t *testing.T) {
+	Convey("TestBuildFilterCondition", t, func() {
+		condition, err := BuildFilterCondition(&v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "serviceName", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag, IsColumn: true}, Value: "checkout", Operator: "="},
+			{Key: v3.AttributeKey{Key: "http.route", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}, Value: "/pay", Operator: "="},
+		}}, map[string]v3.AttributeKey{})
+		So(err, ShouldBeNil)
+		So(condition, ShouldEqual, "(serviceName = 'checkout' AND stringTagMap['http.route'] = '/pay')")
+
+		condition, err = BuildFilterCondition(nil, map[string]v3.AttributeKey{})
+		So(err, ShouldBeNil)
+		So(condition, ShouldEqual, "true")
+	})
+}
+
 var handleEmptyValuesInGroupByData = []struct {
 	Name           string
 	GroupBy        []v3.AttributeKey
@@ -0,0 +1,45 @@
+package v3
+
+import (
+	"fmt"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+var spanGroupOrderColumns = map[string]string{
+	v3.SpanGroupOrderCount:     "spans",
+	v3.SpanGroupOrderErrorRate: "errorRate",
+	v3.SpanGroupOrderAvg:       "avgDuration",
+	v3.SpanGroupOrderP50:       "p50",
+	v3.SpanGroupOrderP90:       "p90",
+	v3.SpanGroupOrderP99:       "p99",
+}
+
+// PrepareSpanGroupsQuery returns the query grouping the spans of the params on the values of
+// their group by attributes, the spans without one of the attributes are left out
+func PrepareSpanGroupsQuery(params *v3.SpanGroupsParams, keys map[string]v3.AttributeKey) (string, error) {
+	filterSubQuery, err := buildTracesFilterQuery(params.Filters, keys)
+	if err != nil {
+		return "", err
+	}
+	emptyValuesSubQuery, err := handleEmptyValuesInGroupBy(keys, params.GroupBy)
+	if err != nil {
+		return "", err
+	}
+
+	values := make([]string, len(params.GroupBy))
+	for i, key := range params.GroupBy {
+		values[i] = fmt.Sprintf("toString(%s)", getColumnName(key, keys))
+	}
+
+	return fmt.Sprintf("SELECT [%s] AS groupValues, count() AS spans, countIf(hasError = true) AS errors, "+
+		"errors * 100 / spans AS errorRate, avg(durationNano) AS avgDuration, "+
+		"quantile(0.5)(durationNano) AS p50, quantile(0.9)(durationNano) AS p90, quantile(0.99)(durationNano) AS p99 "+
+		"FROM %s.%s WHERE (timestamp >= '%d' AND timestamp <= '%d')%s%s "+
+		"GROUP BY groupValues ORDER BY %s %s LIMIT %d",
+		strings.Join(values, ", "), constants.SIGNOZ_TRACE_DBNAME, constants.SIGNOZ_SPAN_INDEX_TABLENAME,
+		params.Start*1000000, params.End*1000000, filterSubQuery, emptyValuesSubQuery,
+		spanGroupOrderColumns[params.OrderBy], params.Order, params.Limit), nil
+}
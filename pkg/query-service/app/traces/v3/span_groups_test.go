@@ -0,0 +1,41 @@
+package v3
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestPrepareSpanGroupsQuery(t *testing.T) {
+	Convey("TestPrepareSpanGroupsQuery", t, func() {
+		params := &v3.SpanGroupsParams{
+			Start: 1680066360726,
+			End:   1680066458000,
+			Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "serviceName", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag, IsColumn: true}, Value: "checkout", Operator: "="},
+			}},
+			GroupBy: []v3.AttributeKey{
+				{Key: "db.statement", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+				{Key: "customer.tier", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource},
+			},
+			OrderBy: v3.SpanGroupOrderP99,
+		}
+		So(params.Validate(), ShouldBeNil)
+
+		query, err := PrepareSpanGroupsQuery(params, map[string]v3.AttributeKey{})
+		So(err, ShouldBeNil)
+		So(query, ShouldEqual, "SELECT [toString(stringTagMap['db.statement']), toString(resourceTagsMap['customer.tier'])] AS groupValues, "+
+			"count() AS spans, countIf(hasError = true) AS errors, errors * 100 / spans AS errorRate, avg(durationNano) AS avgDuration, "+
+			"quantile(0.5)(durationNano) AS p50, quantile(0.9)(durationNano) AS p90, quantile(0.99)(durationNano) AS p99 "+
+			"FROM signoz_traces.distributed_signoz_index_v2 WHERE (timestamp >= '1680066360726000000' AND timestamp <= '1680066458000000000') "+
+			"AND serviceName = 'checkout' AND has(stringTagMap, 'db.statement') AND has(resourceTagsMap, 'customer.tier') "+
+			"GROUP BY groupValues ORDER BY p99 desc LIMIT 100")
+	})
+
+	Convey("TestSpanGroupsParamsValidate", t, func() {
+		So((&v3.SpanGroupsParams{Start: 1, End: 2}).Validate(), ShouldNotBeNil)
+		So((&v3.SpanGroupsParams{Start: 1, End: 2, GroupBy: []v3.AttributeKey{{Key: "http.route"}}, OrderBy: "max"}).Validate(), ShouldNotBeNil)
+		So((&v3.SpanGroupsParams{Start: 1, End: 2, GroupBy: []v3.AttributeKey{{Key: "http.route"}}, Order: "up"}).Validate(), ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,35 @@
+package zipkin
+
+import (
+	"context"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+
+	"go.signoz.io/signoz/pkg/query-service/app/traces/spans"
+)
+
+// Writer writes the Zipkin spans into the SigNoz traces tables, the spans are converted
+// like the OpenTelemetry spans received from the Zipkin receiver of the collector
+type Writer struct {
+	spans *spans.Writer
+}
+
+func NewWriter(conn clickhouse.Conn) *Writer {
+	return &Writer{spans: spans.NewWriter(conn)}
+}
+
+// Write writes the spans with the resources set on all of them, e.g. the org of the ingestion key
+func (w *Writer) Write(ctx context.Context, zipkinSpans []Span, resources map[string]string) error {
+	converted := make([]*spans.Span, 0, len(zipkinSpans))
+	for _, s := range zipkinSpans {
+		span, err := toSpan(s)
+		if err != nil {
+			return err
+		}
+		converted = append(converted, span)
+	}
+	for k, v := range resources {
+		spans.SetResource(converted, k, v)
+	}
+	return w.spans.Write(ctx, converted)
+}
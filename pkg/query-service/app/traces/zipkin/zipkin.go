@@ -0,0 +1,177 @@
+package zipkin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/app/traces/spans"
+)
+
+// MaxRequestSize is the largest request of spans accepted, before decompression
+const MaxRequestSize = 32 << 20
+
+// ErrInvalidSpan is returned for the requests with spans that can not be written
+var ErrInvalidSpan = errors.New("invalid span")
+
+var hexID = regexp.MustCompile(`^[0-9a-f]+$`)
+
+// Span is a span of the Zipkin v2 API, the times are in microseconds
+type Span struct {
+	TraceID        string            `json:"traceId"`
+	ID             string            `json:"id"`
+	ParentID       string            `json:"parentId"`
+	Name           string            `json:"name"`
+	Kind           string            `json:"kind"`
+	Timestamp      uint64            `json:"timestamp"`
+	Duration       uint64            `json:"duration"`
+	LocalEndpoint  *Endpoint         `json:"localEndpoint"`
+	RemoteEndpoint *Endpoint         `json:"remoteEndpoint"`
+	Annotations    []Annotation      `json:"annotations"`
+	Tags           map[string]string `json:"tags"`
+}
+
+type Endpoint struct {
+	ServiceName string `json:"serviceName"`
+	IPv4        string `json:"ipv4"`
+	IPv6        string `json:"ipv6"`
+	Port        int    `json:"port"`
+}
+
+type Annotation struct {
+	Timestamp uint64 `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+// DecodeSpans decodes a list of spans in the Zipkin v2 JSON format, optionally gzip compressed
+func DecodeSpans(body []byte, contentEncoding string) ([]Span, error) {
+	if contentEncoding == "gzip" {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress spans: %v", err)
+		}
+		if body, err = io.ReadAll(io.LimitReader(reader, 4*MaxRequestSize)); err != nil {
+			return nil, fmt.Errorf("failed to decompress spans: %v", err)
+		}
+	}
+	var spans []Span
+	if err := json.Unmarshal(body, &spans); err != nil {
+		return nil, fmt.Errorf("failed to decode spans: %v", err)
+	}
+	return spans, nil
+}
+
+// kinds are the OpenTelemetry span kinds of the Zipkin kinds, the spans without kind are internal
+var kinds = map[string]struct {
+	kind int8
+	name string
+}{
+	"":         {1, "Internal"},
+	"SERVER":   {2, "Server"},
+	"CLIENT":   {3, "Client"},
+	"PRODUCER": {4, "Producer"},
+	"CONSUMER": {5, "Consumer"},
+}
+
+// normalizeID checks the id is hex and pads it to the length of the OpenTelemetry ids
+func normalizeID(id string, length int) (string, error) {
+	id = strings.ToLower(id)
+	if len(id) == 0 || len(id) > length || !hexID.MatchString(id) {
+		return "", fmt.Errorf("%w: invalid id %q", ErrInvalidSpan, id)
+	}
+	return strings.Repeat("0", length-len(id)) + id, nil
+}
+
+// toSpan converts a Zipkin span to the span written in the traces tables, as the SigNoz exporter
+// of the collector. The service is the resource and the tags the string attributes of the span
+func toSpan(s Span) (*spans.Span, error) {
+	traceID, err := normalizeID(s.TraceID, 32)
+	if err != nil {
+		return nil, err
+	}
+	spanID, err := normalizeID(s.ID, 16)
+	if err != nil {
+		return nil, err
+	}
+	parentID := ""
+	if s.ParentID != "" {
+		if parentID, err = normalizeID(s.ParentID, 16); err != nil {
+			return nil, err
+		}
+	}
+	kind, ok := kinds[strings.ToUpper(s.Kind)]
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid kind %q", ErrInvalidSpan, s.Kind)
+	}
+	if s.Timestamp == 0 {
+		return nil, fmt.Errorf("%w: the span %s has no timestamp", ErrInvalidSpan, s.ID)
+	}
+
+	serviceName := "unknown_service"
+	if s.LocalEndpoint != nil && s.LocalEndpoint.ServiceName != "" {
+		serviceName = s.LocalEndpoint.ServiceName
+	}
+	resources := map[string]string{"service.name": serviceName}
+
+	tags := map[string]string{}
+	for k, v := range s.Tags {
+		tags[k] = v
+	}
+	if s.RemoteEndpoint != nil && s.RemoteEndpoint.ServiceName != "" {
+		if _, ok := tags["peer.service"]; !ok {
+			tags["peer.service"] = s.RemoteEndpoint.ServiceName
+		}
+	}
+
+	result := &spans.Span{
+		TraceID:           traceID,
+		SpanID:            spanID,
+		ParentSpanID:      parentID,
+		Name:              s.Name,
+		StartTimeUnixNano: s.Timestamp * 1000,
+		DurationNano:      s.Duration * 1000,
+		ServiceName:       serviceName,
+		Kind:              kind.kind,
+		SpanKind:          kind.name,
+		StatusCodeString:  "Unset",
+		StringTagMap:      map[string]string{},
+		NumberTagMap:      map[string]float64{},
+		BoolTagMap:        map[string]bool{},
+		ResourceTagsMap:   resources,
+		TagMap:            map[string]string{},
+		Events:            []string{},
+		References:        []spans.Ref{{TraceID: traceID, SpanID: parentID, RefType: "CHILD_OF"}},
+	}
+	// the resources are also stored with the attributes, as the SigNoz exporter
+	for _, m := range []map[string]string{tags, resources} {
+		for k, v := range m {
+			result.StringTagMap[k] = v
+			result.TagMap[k] = v
+		}
+	}
+
+	// the Zipkin libraries tag the failed spans with their error
+	if message, ok := tags["error"]; ok {
+		result.HasError = true
+		result.StatusCode = 2
+		result.StatusCodeString = "Error"
+		if message != "true" {
+			result.StatusMessage = message
+		}
+	}
+	spans.PopulateDimensions(result, tags)
+
+	for _, annotation := range s.Annotations {
+		encoded, err := json.Marshal(spans.Event{Name: annotation.Value, TimeUnixNano: annotation.Timestamp * 1000})
+		if err != nil {
+			return nil, err
+		}
+		result.Events = append(result.Events, string(encoded))
+	}
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package zipkin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToSpan(t *testing.T) {
+	spans, err := DecodeSpans([]byte(`[{
+		"traceId": "5AF7183FB1D4CF5F", "id": "352bff9a74ca9ad2", "parentId": "6b221d5bc9e6496c",
+		"name": "get /api", "kind": "CLIENT", "timestamp": 1700000000000001, "duration": 2500,
+		"localEndpoint": {"serviceName": "frontend", "ipv4": "10.0.0.1"},
+		"remoteEndpoint": {"serviceName": "backend"},
+		"annotations": [{"timestamp": 1700000000000500, "value": "wire.send"}],
+		"tags": {"http.method": "GET", "http.url": "http://backend:8080/api", "http.status_code": "500", "error": "Internal Server Error"}
+	}]`), "")
+	require.NoError(t, err)
+	require.Len(t, spans, 1)
+
+	s, err := toSpan(spans[0])
+	require.NoError(t, err)
+	assert.Equal(t, "00000000000000005af7183fb1d4cf5f", s.TraceID)
+	assert.Equal(t, "6b221d5bc9e6496c", s.ParentSpanID)
+	assert.Equal(t, uint64(1700000000000001000), s.StartTimeUnixNano)
+	assert.Equal(t, uint64(2500000), s.DurationNano)
+	assert.Equal(t, int8(3), s.Kind)
+	assert.Equal(t, "Client", s.SpanKind)
+	assert.Equal(t, "frontend", s.ServiceName)
+	assert.Equal(t, map[string]string{"service.name": "frontend"}, s.ResourceTagsMap)
+	assert.Equal(t, "backend", s.StringTagMap["peer.service"])
+	assert.Equal(t, "frontend", s.TagMap["service.name"])
+
+	// the failed spans have the error status and the client spans the external call columns
+	assert.True(t, s.HasError)
+	assert.Equal(t, "Error", s.StatusCodeString)
+	assert.Equal(t, "Internal Server Error", s.StatusMessage)
+	assert.Equal(t, "GET", s.ExternalHttpMethod)
+	assert.Equal(t, "backend", s.ExternalHttpUrl)
+	assert.Equal(t, "500", s.ResponseStatusCode)
+	assert.Equal(t, []string{`{"name":"wire.send","timeUnixNano":1700000000000500000}`}, s.Events)
+
+	model, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.Contains(t, string(model), `"references":[{"traceId":"00000000000000005af7183fb1d4cf5f","spanId":"6b221d5bc9e6496c","refType":"CHILD_OF"}]`)
+	assert.NotContains(t, string(model), "resourceTagsMap")
+}
+
+func TestToSpanInvalid(t *testing.T) {
+	valid := Span{TraceID: "5af7183fb1d4cf5f", ID: "352bff9a74ca9ad2", Timestamp: 1700000000000000}
+	s, err := toSpan(valid)
+	require.NoError(t, err)
+	assert.Equal(t, "unknown_service", s.ServiceName)
+	assert.Equal(t, "Internal", s.SpanKind)
+
+	for _, span := range []Span{
+		{TraceID: "xyz", ID: valid.ID, Timestamp: valid.Timestamp},
+		{TraceID: valid.TraceID, ID: "", Timestamp: valid.Timestamp},
+		{TraceID: valid.TraceID, ID: valid.ID},
+		{TraceID: valid.TraceID, ID: valid.ID, Timestamp: valid.Timestamp, Kind: "OTHER"},
+	} {
+		_, err := toSpan(span)
+		assert.ErrorIs(t, err, ErrInvalidSpan)
+	}
+}
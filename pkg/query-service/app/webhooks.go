@@ -0,0 +1,159 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/app/webhooks"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func webhookNotFound(id string) *model.ApiError {
+	return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("webhook %s not found", id)}
+}
+
+func (aH *APIHandler) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := aH.WebhookManager.List(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, subscriptions)
+}
+
+func (aH *APIHandler) getWebhook(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	subscription, err := aH.WebhookManager.Get(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if subscription == nil {
+		RespondError(w, webhookNotFound(id), nil)
+		return
+	}
+	aH.Respond(w, subscription)
+}
+
+// createWebhook subscribes the URL to the events, the response has the secret the deliveries
+// are signed with, it is not returned afterwards
+func (aH *APIHandler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var subscription webhooks.Subscription
+	if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := subscription.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	created, err := aH.WebhookManager.Create(r.Context(), &subscription, webhookUser(r))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, created)
+}
+
+// editWebhook replaces the subscription, its secret is kept when the request has none
+func (aH *APIHandler) editWebhook(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var subscription webhooks.Subscription
+	if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := subscription.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	updated, err := aH.WebhookManager.Update(r.Context(), id, &subscription, webhookUser(r))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if updated == nil {
+		RespondError(w, webhookNotFound(id), nil)
+		return
+	}
+	aH.Respond(w, updated)
+}
+
+func (aH *APIHandler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := aH.WebhookManager.Delete(r.Context(), id); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// testWebhook sends a test event to the subscription and responds with the delivery
+func (aH *APIHandler) testWebhook(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	delivery, err := aH.WebhookManager.Test(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if delivery == nil {
+		RespondError(w, webhookNotFound(id), nil)
+		return
+	}
+	aH.Respond(w, delivery)
+}
+
+// listWebhookDeliveries lists the deliveries of the subscription, the newest first
+func (aH *APIHandler) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	query := r.URL.Query()
+	var limit, offset int
+	var err error
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			RespondError(w, model.BadRequest(fmt.Errorf("invalid limit %q", v)), nil)
+			return
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			RespondError(w, model.BadRequest(fmt.Errorf("invalid offset %q", v)), nil)
+			return
+		}
+	}
+
+	deliveries, err := aH.WebhookManager.Deliveries(r.Context(), id, limit, offset)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	if deliveries == nil {
+		RespondError(w, webhookNotFound(id), nil)
+		return
+	}
+	aH.Respond(w, deliveries)
+}
+
+// webhookUser returns the email of the user of the request, empty when unknown
+func webhookUser(r *http.Request) string {
+	if user := common.GetUserFromContext(r.Context()); user != nil {
+		return user.Email
+	}
+	return ""
+}
+
+// publishDashboardEvent publishes the change of the dashboard to the webhooks
+func (aH *APIHandler) publishDashboardEvent(r *http.Request, eventType string, dashboard *dashboards.Dashboard) {
+	event := webhooks.DashboardEvent{UUID: dashboard.Uuid, User: webhookUser(r)}
+	if title, ok := dashboard.Data["title"].(string); ok {
+		event.Title = title
+	}
+	aH.WebhookManager.Publish(r.Context(), eventType, event)
+}
@@ -0,0 +1,57 @@
+package webhooks
+
+import (
+	"context"
+
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// NotifyAlerts publishes the state changes of the alerts sent by the rules, it is a
+// rules.NotifyFunc. The rules resend the firing alerts, an alert.firing event is only
+// published when the alert starts firing and an alert.resolved event when it resolves
+func (m *Manager) NotifyAlerts(ctx context.Context, expr string, alerts ...*rules.Alert) {
+	if m == nil {
+		return
+	}
+	for _, alert := range alerts {
+		if alert.Labels == nil {
+			continue
+		}
+		hash := alert.Labels.Hash()
+		resolved := !alert.ResolvedAt.IsZero()
+
+		m.firingMtx.Lock()
+		_, firing := m.firing[hash]
+		switch {
+		case resolved && firing:
+			delete(m.firing, hash)
+		case !resolved && !firing && alert.State == rules.StateFiring:
+			m.firing[hash] = struct{}{}
+		default:
+			m.firingMtx.Unlock()
+			continue
+		}
+		m.firingMtx.Unlock()
+
+		event := AlertEvent{
+			RuleId:      alert.Labels.Get(labels.AlertRuleIdLabel),
+			AlertName:   alert.Labels.Get(labels.AlertNameLabel),
+			State:       alert.State.String(),
+			Labels:      alert.Labels.Map(),
+			Annotations: map[string]string{},
+			Value:       alert.Value,
+			FiredAt:     alert.FiredAt,
+		}
+		if alert.Annotations != nil {
+			event.Annotations = alert.Annotations.Map()
+		}
+		if resolved {
+			resolvedAt := alert.ResolvedAt
+			event.ResolvedAt = &resolvedAt
+			m.Publish(ctx, EventAlertResolved, event)
+		} else {
+			m.Publish(ctx, EventAlertFiring, event)
+		}
+	}
+}
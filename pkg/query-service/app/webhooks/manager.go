@@ -0,0 +1,434 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.uber.org/zap"
+)
+
+const (
+	// pruneInterval is the interval at which the deliveries past the retention are deleted
+	pruneInterval = time.Hour
+	// queueSize is the number of events, and of deliveries, waiting to be sent past which
+	// they are dropped
+	queueSize = 1000
+	// workers is the number of deliveries sent concurrently
+	workers = 4
+	// maxAttempts is the number of attempts of a delivery before it fails
+	maxAttempts = 5
+
+	defaultRetryBackoff = 10 * time.Second
+	maxRetryBackoff     = 10 * time.Minute
+	deliveryTimeout     = 10 * time.Second
+	// maxErrorLength truncates the errors, and the response bodies, of the delivery log
+	maxErrorLength = 1024
+
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+type ManagerOptions struct {
+	DB *sqlx.DB
+	// Retention is how long the deliveries are kept
+	Retention time.Duration
+	// RetryBackoff is the wait before the first retry of a failed delivery, it doubles
+	// with each retry
+	RetryBackoff time.Duration
+	HTTPClient   *http.Client
+}
+
+// job is a delivery waiting to be sent to its subscription
+type job struct {
+	subscription *Subscription
+	delivery     *Delivery
+}
+
+// Manager sends the events to the URLs of the subscriptions. The events are dispatched and
+// sent in the background so the requests publishing them don't wait on the subscribers
+type Manager struct {
+	repo         *repo
+	retention    time.Duration
+	retryBackoff time.Duration
+	client       *http.Client
+
+	events chan *Event
+	jobs   chan *job
+
+	// firing is the hashes of the labels of the firing alerts, an alert.resolved event
+	// is only sent for the alerts an alert.firing event was sent for
+	firing    map[uint64]struct{}
+	firingMtx sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewManager(opts ManagerOptions) (*Manager, error) {
+	if err := InitSqliteDBIfNeeded(opts.DB); err != nil {
+		return nil, err
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: deliveryTimeout}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		repo:         &repo{db: opts.DB},
+		retention:    opts.Retention,
+		retryBackoff: opts.RetryBackoff,
+		client:       opts.HTTPClient,
+		events:       make(chan *Event, queueSize),
+		jobs:         make(chan *job, queueSize),
+		firing:       map[uint64]struct{}{},
+		ctx:          ctx,
+		cancel:       cancel,
+	}, nil
+}
+
+// Start dispatches the published events to the subscriptions, sends the deliveries and
+// prunes the deliveries past the retention
+func (m *Manager) Start() error {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case e := <-m.events:
+				m.dispatch(e)
+			case <-ticker.C:
+				m.prune()
+			}
+		}
+	}()
+	for i := 0; i < workers; i++ {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			for {
+				select {
+				case <-m.ctx.Done():
+					return
+				case j := <-m.jobs:
+					m.deliver(j)
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// Stop stops the manager, the deliveries waiting to be sent are left pending in the log
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// Publish sends the event to the subscriptions of its type in the org of the user of the
+// context. The event is dropped, and logged, when the queue is full rather than slowing
+// down the requests
+func (m *Manager) Publish(ctx context.Context, eventType string, data interface{}) {
+	if m == nil {
+		return
+	}
+	e := &Event{
+		Id:        uuid.NewString(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+		orgId:     common.GetOrgIdFromContext(ctx),
+	}
+	select {
+	case m.events <- e:
+	default:
+		zap.L().Warn("webhook event queue is full, dropping the event", zap.String("type", eventType))
+	}
+}
+
+// dispatch records a delivery of the event for each of its subscriptions and queues them
+func (m *Manager) dispatch(e *Event) {
+	ctx := m.ctx
+	if e.orgId != "" {
+		ctx = common.WithOrg(ctx, e.orgId)
+	}
+	subscriptions, err := m.repo.listSubscriptions(ctx)
+	if err != nil {
+		zap.L().Error("failed to list the webhook subscriptions", zap.Error(err))
+		return
+	}
+	var payload []byte
+	for _, s := range subscriptions {
+		if !s.subscribes(e.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(e); err != nil {
+				zap.L().Error("failed to encode the webhook event", zap.String("type", e.Type), zap.Error(err))
+				return
+			}
+		}
+		d, err := m.newDelivery(m.ctx, s, e, payload)
+		if err != nil {
+			zap.L().Error("failed to record the webhook delivery", zap.String("subscription", s.Id), zap.Error(err))
+			continue
+		}
+		m.enqueue(&job{subscription: s, delivery: d})
+	}
+}
+
+func (m *Manager) newDelivery(ctx context.Context, s *Subscription, e *Event, payload []byte) (*Delivery, error) {
+	now := time.Now().UTC()
+	d := &Delivery{
+		Id:             uuid.NewString(),
+		SubscriptionId: s.Id,
+		EventId:        e.Id,
+		EventType:      e.Type,
+		Payload:        string(payload),
+		Status:         DeliveryPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := m.repo.insertDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (m *Manager) enqueue(j *job) {
+	select {
+	case m.jobs <- j:
+	case <-m.ctx.Done():
+	default:
+		zap.L().Warn("webhook delivery queue is full, dropping the delivery",
+			zap.String("subscription", j.subscription.Id),
+			zap.String("delivery", j.delivery.Id),
+		)
+	}
+}
+
+// deliver sends the delivery and schedules its retry when the attempt failed, the wait
+// between the attempts doubles with each attempt
+func (m *Manager) deliver(j *job) {
+	retry := m.attempt(m.ctx, j.subscription, j.delivery) && j.delivery.Attempts < maxAttempts
+	if retry {
+		j.delivery.Status = DeliveryPending
+	}
+	if err := m.repo.updateDelivery(context.Background(), j.delivery); err != nil {
+		zap.L().Error("failed to record the webhook delivery", zap.String("delivery", j.delivery.Id), zap.Error(err))
+	}
+	if retry {
+		backoff := m.retryBackoff << (j.delivery.Attempts - 1)
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+		time.AfterFunc(backoff, func() { m.enqueue(j) })
+	}
+}
+
+// attempt sends the delivery once and records the result, retry is set when the attempt
+// failed and can be retried
+func (m *Manager) attempt(ctx context.Context, s *Subscription, d *Delivery) (retry bool) {
+	d.Attempts++
+	d.UpdatedAt = time.Now().UTC()
+	d.StatusCode = 0
+	d.Error = ""
+
+	timestamp := strconv.FormatInt(d.UpdatedAt.Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader([]byte(d.Payload)))
+	if err != nil {
+		d.Status = DeliveryFailed
+		d.Error = truncate(err.Error())
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SigNoz-Webhooks")
+	req.Header.Set(HeaderEvent, d.EventType)
+	req.Header.Set(HeaderDelivery, d.Id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(s.Secret, timestamp, []byte(d.Payload)))
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		d.Status = DeliveryFailed
+		d.Error = truncate(err.Error())
+		return true
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+
+	d.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		d.Status = DeliverySucceeded
+		return false
+	}
+	d.Status = DeliveryFailed
+	d.Error = truncate(fmt.Sprintf("unexpected status %d: %s", resp.StatusCode, body))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+func (m *Manager) prune() {
+	if m.retention <= 0 {
+		return
+	}
+	if err := m.repo.pruneDeliveries(context.Background(), time.Now().Add(-m.retention)); err != nil {
+		zap.L().Error("failed to prune the webhook deliveries", zap.Error(err))
+	}
+}
+
+// Sign returns the signature of a delivery, the HMAC-SHA256 of the timestamp and the body
+// joined by a dot. The subscribers compare it to the X-SigNoz-Signature header
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func truncate(s string) string {
+	if len(s) > maxErrorLength {
+		return s[:maxErrorLength]
+	}
+	return s
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// List returns the subscriptions, without their secrets
+func (m *Manager) List(ctx context.Context) ([]*Subscription, error) {
+	subscriptions, err := m.repo.listSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range subscriptions {
+		s.Secret = ""
+	}
+	return subscriptions, nil
+}
+
+// Get returns the subscription with the id, without its secret, nil when it does not exist
+func (m *Manager) Get(ctx context.Context, id string) (*Subscription, error) {
+	s, err := m.repo.getSubscription(ctx, id)
+	if s != nil {
+		s.Secret = ""
+	}
+	return s, err
+}
+
+// Create saves the subscription, a secret is generated when it has none. The subscription
+// is returned with its secret, it is not returned afterwards
+func (m *Manager) Create(ctx context.Context, s *Subscription, by string) (*Subscription, error) {
+	if s.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return nil, err
+		}
+		s.Secret = secret
+	}
+	now := time.Now().UTC()
+	s.Id = uuid.NewString()
+	s.CreatedAt, s.CreatedBy = now, by
+	s.UpdatedAt, s.UpdatedBy = now, by
+	if err := m.repo.insertSubscription(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Update replaces the subscription with the id, the secret is kept when the subscription has
+// none. It returns nil when the subscription does not exist
+func (m *Manager) Update(ctx context.Context, id string, s *Subscription, by string) (*Subscription, error) {
+	existing, err := m.repo.getSubscription(ctx, id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if s.Secret == "" {
+		s.Secret = existing.Secret
+	}
+	s.Id = id
+	s.CreatedAt, s.CreatedBy = existing.CreatedAt, existing.CreatedBy
+	s.UpdatedAt, s.UpdatedBy = time.Now().UTC(), by
+	if err := m.repo.updateSubscription(ctx, s); err != nil {
+		return nil, err
+	}
+	s.Secret = ""
+	return s, nil
+}
+
+// Delete deletes the subscription and its delivery log
+func (m *Manager) Delete(ctx context.Context, id string) error {
+	return m.repo.deleteSubscription(ctx, id)
+}
+
+// Deliveries returns the delivery log of the subscription, the newest first. It returns nil
+// when the subscription does not exist
+func (m *Manager) Deliveries(ctx context.Context, id string, limit, offset int) ([]*Delivery, error) {
+	if s, err := m.repo.getSubscription(ctx, id); err != nil || s == nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return m.repo.listDeliveries(ctx, id, limit, offset)
+}
+
+// Test sends a webhook.test event to the subscription, whether or not it is enabled, and
+// returns the delivery once sent. The test deliveries are not retried
+func (m *Manager) Test(ctx context.Context, id string) (*Delivery, error) {
+	s, err := m.repo.getSubscription(ctx, id)
+	if err != nil || s == nil {
+		return nil, err
+	}
+	e := &Event{
+		Id:        uuid.NewString(),
+		Type:      EventTest,
+		CreatedAt: time.Now().UTC(),
+		Data:      map[string]string{"subscriptionId": s.Id, "name": s.Name},
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	d, err := m.newDelivery(ctx, s, e, payload)
+	if err != nil {
+		return nil, err
+	}
+	m.attempt(ctx, s, d)
+	if err := m.repo.updateDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
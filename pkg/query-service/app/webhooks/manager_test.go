@@ -0,0 +1,212 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/rules"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// receiver records the deliveries it receives, it fails the first failures requests
+type receiver struct {
+	mtx      sync.Mutex
+	failures int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mtx.Lock()
+	defer rc.mtx.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	if rc.failures > 0 {
+		rc.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rc *receiver) count() int {
+	rc.mtx.Lock()
+	defer rc.mtx.Unlock()
+	return len(rc.requests)
+}
+
+func newTestManager(t *testing.T) *Manager {
+	db := utils.NewQueryServiceDBForTests(t)
+	m, err := NewManager(ManagerOptions{DB: db, RetryBackoff: 10 * time.Millisecond})
+	require.NoError(t, err)
+	require.NoError(t, m.Start())
+	t.Cleanup(m.Stop)
+	return m
+}
+
+func subscribe(t *testing.T, m *Manager, url string, events ...string) *Subscription {
+	e := Events(events)
+	s := &Subscription{Name: "test", URL: url, Events: &e, Enabled: true}
+	require.NoError(t, s.Validate())
+	s, err := m.Create(context.Background(), s, "admin@signoz.io")
+	require.NoError(t, err)
+	require.NotEmpty(t, s.Secret)
+	return s
+}
+
+func TestManagerPublish(t *testing.T) {
+	m := newTestManager(t)
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	s := subscribe(t, m, server.URL, EventDashboardCreated)
+	other := &receiver{}
+	otherServer := httptest.NewServer(other)
+	defer otherServer.Close()
+	subscribe(t, m, otherServer.URL, EventUserInvited)
+
+	m.Publish(context.Background(), EventDashboardCreated, DashboardEvent{UUID: "uuid", Title: "title"})
+	require.Eventually(t, func() bool { return rc.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 0, other.count())
+
+	req, body := rc.requests[0], rc.bodies[0]
+	require.Equal(t, EventDashboardCreated, req.Header.Get(HeaderEvent))
+	require.Equal(t, Sign(s.Secret, req.Header.Get(HeaderTimestamp), body), req.Header.Get(HeaderSignature))
+	var event Event
+	require.NoError(t, json.Unmarshal(body, &event))
+	require.Equal(t, EventDashboardCreated, event.Type)
+	require.Equal(t, "title", event.Data.(map[string]interface{})["title"])
+
+	require.Eventually(t, func() bool {
+		deliveries, err := m.Deliveries(context.Background(), s.Id, 0, 0)
+		require.NoError(t, err)
+		return len(deliveries) == 1 && deliveries[0].Status == DeliverySucceeded
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the secret is not returned once created
+	got, err := m.Get(context.Background(), s.Id)
+	require.NoError(t, err)
+	require.Empty(t, got.Secret)
+}
+
+func TestManagerRetries(t *testing.T) {
+	m := newTestManager(t)
+	rc := &receiver{failures: 2}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	s := subscribe(t, m, server.URL, allEvents)
+
+	m.Publish(context.Background(), EventUserInvited, InviteEvent{Email: "bob@signoz.io"})
+	require.Eventually(t, func() bool {
+		deliveries, err := m.Deliveries(context.Background(), s.Id, 0, 0)
+		require.NoError(t, err)
+		return len(deliveries) == 1 && deliveries[0].Status == DeliverySucceeded
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 3, rc.count())
+	// the retries are the same delivery
+	require.Equal(t, rc.requests[0].Header.Get(HeaderDelivery), rc.requests[2].Header.Get(HeaderDelivery))
+
+	deliveries, err := m.Deliveries(context.Background(), s.Id, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, deliveries[0].Attempts)
+	require.Equal(t, http.StatusNoContent, deliveries[0].StatusCode)
+}
+
+func TestManagerGivesUp(t *testing.T) {
+	m := newTestManager(t)
+	rc := &receiver{failures: maxAttempts + 1}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	s := subscribe(t, m, server.URL, EventUserInvited)
+
+	m.Publish(context.Background(), EventUserInvited, InviteEvent{Email: "bob@signoz.io"})
+	require.Eventually(t, func() bool {
+		deliveries, err := m.Deliveries(context.Background(), s.Id, 0, 0)
+		require.NoError(t, err)
+		return len(deliveries) == 1 && deliveries[0].Status == DeliveryFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, maxAttempts, rc.count())
+}
+
+func TestManagerPublishOrg(t *testing.T) {
+	m := newTestManager(t)
+	orgA, orgB := common.WithOrg(context.Background(), "org-a"), common.WithOrg(context.Background(), "org-b")
+	rcA, rcB := &receiver{}, &receiver{}
+	serverA, serverB := httptest.NewServer(rcA), httptest.NewServer(rcB)
+	defer serverA.Close()
+	defer serverB.Close()
+	events := Events{allEvents}
+	a, err := m.Create(orgA, &Subscription{Name: "a", URL: serverA.URL, Events: &events, Enabled: true}, "admin@signoz.io")
+	require.NoError(t, err)
+	_, err = m.Create(orgB, &Subscription{Name: "b", URL: serverB.URL, Events: &events, Enabled: true}, "admin@signoz.io")
+	require.NoError(t, err)
+
+	// the events are sent to the subscriptions of their org
+	m.Publish(orgA, EventUserInvited, InviteEvent{Email: "bob@signoz.io"})
+	require.Eventually(t, func() bool { return rcA.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 0, rcB.count())
+
+	// the subscriptions of the other orgs are not found
+	got, err := m.Get(orgB, a.Id)
+	require.NoError(t, err)
+	require.Nil(t, got)
+	deliveries, err := m.Deliveries(orgB, a.Id, 0, 0)
+	require.NoError(t, err)
+	require.Nil(t, deliveries)
+	require.NoError(t, m.Delete(orgB, a.Id))
+	deliveries, err = m.Deliveries(orgA, a.Id, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+}
+
+func TestManagerNotifyAlerts(t *testing.T) {
+	m := newTestManager(t)
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	subscribe(t, m, server.URL, EventAlertFiring, EventAlertResolved)
+
+	lbls := labels.FromStrings(labels.AlertRuleIdLabel, "1", labels.AlertNameLabel, "high latency")
+	firing := &rules.Alert{State: rules.StateFiring, Labels: lbls, Annotations: labels.Labels{}, FiredAt: time.Now()}
+	// the firing alert is resent by the rule, it is published once
+	m.NotifyAlerts(context.Background(), "", firing)
+	m.NotifyAlerts(context.Background(), "", firing)
+	resolved := *firing
+	resolved.State = rules.StateInactive
+	resolved.ResolvedAt = time.Now()
+	m.NotifyAlerts(context.Background(), "", &resolved)
+	m.NotifyAlerts(context.Background(), "", &resolved)
+
+	require.Eventually(t, func() bool { return rc.count() == 2 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 2, rc.count())
+
+	types := map[string]bool{}
+	for _, req := range rc.requests {
+		types[req.Header.Get(HeaderEvent)] = true
+	}
+	require.True(t, types[EventAlertFiring])
+	require.True(t, types[EventAlertResolved])
+}
+
+func TestSubscriptionValidate(t *testing.T) {
+	events := Events{EventAlertFiring}
+	require.NoError(t, (&Subscription{Name: "a", URL: "https://example.com/hook", Events: &events}).Validate())
+	require.Error(t, (&Subscription{Name: "a", URL: "ftp://example.com", Events: &events}).Validate())
+	require.Error(t, (&Subscription{Name: "a", URL: "https://example.com"}).Validate())
+	unknown := Events{"alert.unknown"}
+	require.Error(t, (&Subscription{Name: "a", URL: "https://example.com", Events: &unknown}).Validate())
+}
@@ -0,0 +1,164 @@
+package webhooks
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Types of the events
+const (
+	EventAlertFiring      = "alert.firing"
+	EventAlertResolved    = "alert.resolved"
+	EventDashboardCreated = "dashboard.created"
+	EventDashboardUpdated = "dashboard.updated"
+	EventDashboardDeleted = "dashboard.deleted"
+	EventUserInvited      = "user.invited"
+	// EventTest is sent by the test deliveries, it is not subscribed to
+	EventTest = "webhook.test"
+
+	// allEvents subscribes to all the events
+	allEvents = "*"
+)
+
+var eventTypes = []string{
+	EventAlertFiring,
+	EventAlertResolved,
+	EventDashboardCreated,
+	EventDashboardUpdated,
+	EventDashboardDeleted,
+	EventUserInvited,
+}
+
+// Statuses of the deliveries
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Headers of the deliveries
+const (
+	HeaderEvent     = "X-SigNoz-Event"
+	HeaderDelivery  = "X-SigNoz-Delivery"
+	HeaderTimestamp = "X-SigNoz-Timestamp"
+	// HeaderSignature is the HMAC-SHA256 of the timestamp and the body, joined by a dot,
+	// keyed with the secret of the subscription, as sha256=<hex>
+	HeaderSignature = "X-SigNoz-Signature"
+)
+
+// Subscription sends the events of the subscribed types to the URL
+type Subscription struct {
+	Id   string `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	URL  string `json:"url" db:"url"`
+	// Secret signs the deliveries, it is only returned when the subscription is created
+	Secret    string    `json:"secret,omitempty" db:"secret"`
+	Events    *Events   `json:"events" db:"events"`
+	Enabled   bool      `json:"enabled" db:"enabled"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	CreatedBy string    `json:"createdBy" db:"created_by"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	UpdatedBy string    `json:"updatedBy" db:"updated_by"`
+	OrgId     string    `json:"-" db:"org_id"`
+}
+
+type Events []string
+
+func (e *Events) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, e)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), e)
+	}
+	return nil
+}
+
+func (e *Events) Value() (driver.Value, error) {
+	return json.Marshal(e)
+}
+
+func (s *Subscription) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %q, the url must be an http or https url", s.URL)
+	}
+	if s.Events == nil || len(*s.Events) == 0 {
+		return fmt.Errorf("at least one event is required")
+	}
+	for _, event := range *s.Events {
+		if event != allEvents && !slices.Contains(eventTypes, event) {
+			return fmt.Errorf("unknown event %q", event)
+		}
+	}
+	return nil
+}
+
+// subscribes checks if the subscription receives the events of the type
+func (s *Subscription) subscribes(eventType string) bool {
+	if !s.Enabled || s.Events == nil {
+		return false
+	}
+	return slices.Contains(*s.Events, allEvents) || slices.Contains(*s.Events, eventType)
+}
+
+// Event is the body of the deliveries
+type Event struct {
+	Id        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+
+	// orgId is the org of the event, it is sent to the subscriptions of the org
+	orgId string
+}
+
+// Delivery is an event sent to a subscription, with the result of the last attempt
+type Delivery struct {
+	Id             string    `json:"id" db:"id"`
+	SubscriptionId string    `json:"subscriptionId" db:"subscription_id"`
+	EventId        string    `json:"eventId" db:"event_id"`
+	EventType      string    `json:"eventType" db:"event_type"`
+	Payload        string    `json:"payload" db:"payload"`
+	Status         string    `json:"status" db:"status"`
+	Attempts       int       `json:"attempts" db:"attempts"`
+	StatusCode     int       `json:"statusCode" db:"status_code"`
+	Error          string    `json:"error" db:"error"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// DashboardEvent is the data of the dashboard events
+type DashboardEvent struct {
+	UUID  string `json:"uuid"`
+	Title string `json:"title,omitempty"`
+	// User is the email of the user who changed the dashboard
+	User string `json:"user,omitempty"`
+}
+
+// InviteEvent is the data of the user invite events
+type InviteEvent struct {
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	InvitedBy string `json:"invitedBy,omitempty"`
+}
+
+// AlertEvent is the data of the alert events
+type AlertEvent struct {
+	RuleId      string            `json:"ruleId"`
+	AlertName   string            `json:"alertName"`
+	State       string            `json:"state"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	Value       float64           `json:"value"`
+	FiredAt     time.Time         `json:"firedAt"`
+	ResolvedAt  *time.Time        `json:"resolvedAt,omitempty"`
+}
@@ -0,0 +1,192 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.uber.org/zap"
+)
+
+func InitSqliteDBIfNeeded(db *sqlx.DB) error {
+	if db == nil {
+		return fmt.Errorf("db is required")
+	}
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		events TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating webhook_subscriptions table: %s", err.Error())
+	}
+	_, err = db.Exec(`ALTER TABLE webhook_subscriptions ADD COLUMN org_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return fmt.Errorf("error in adding column org_id to webhook_subscriptions table: %s", err.Error())
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id TEXT PRIMARY KEY,
+		subscription_id TEXT NOT NULL,
+		event_id TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		created_at datetime NOT NULL,
+		updated_at datetime NOT NULL,
+		FOREIGN KEY(subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+	);`)
+	if err != nil {
+		return fmt.Errorf("error in creating webhook_deliveries table: %s", err.Error())
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, created_at);`)
+	if err != nil {
+		return fmt.Errorf("error in creating webhook_deliveries index: %s", err.Error())
+	}
+	return nil
+}
+
+const subscriptionColumns = "id, name, url, secret, events, enabled, created_at, created_by, updated_at, updated_by, org_id"
+
+const deliveryColumns = "id, subscription_id, event_id, event_type, payload, status, attempts, status_code, error, created_at, updated_at"
+
+type repo struct {
+	db *sqlx.DB
+}
+
+// listSubscriptions returns the subscriptions of the org of the user of the context
+func (r *repo) listSubscriptions(ctx context.Context) ([]*Subscription, error) {
+	subscriptions := []*Subscription{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.SelectContext(ctx, &subscriptions, "SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE "+condition+" ORDER BY created_at", args...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// getSubscription returns the subscription with the id, nil when it does not exist
+func (r *repo) getSubscription(ctx context.Context, id string) (*Subscription, error) {
+	s := &Subscription{}
+	condition, args := common.OrgCondition(ctx)
+	err := r.db.GetContext(ctx, s, "SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE id = ? AND "+condition, append([]interface{}{id}, args...)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return s, nil
+}
+
+// insertSubscription stores the subscription in the org of the user of the context
+func (r *repo) insertSubscription(ctx context.Context, s *Subscription) error {
+	s.OrgId = common.GetOrgIdFromContext(ctx)
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO webhook_subscriptions ("+subscriptionColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+		s.Id, s.Name, s.URL, s.Secret, s.Events, s.Enabled, s.CreatedAt, s.CreatedBy, s.UpdatedAt, s.UpdatedBy, s.OrgId)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *repo) updateSubscription(ctx context.Context, s *Subscription) error {
+	condition, args := common.OrgCondition(ctx)
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE webhook_subscriptions SET name = ?, url = ?, secret = ?, events = ?, enabled = ?, updated_at = ?, updated_by = ? WHERE id = ? AND "+condition,
+		append([]interface{}{s.Name, s.URL, s.Secret, s.Events, s.Enabled, s.UpdatedAt, s.UpdatedBy, s.Id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// deleteSubscription deletes the subscription and its deliveries, the deliveries are kept
+// when the subscription is not in the org of the user of the context
+func (r *repo) deleteSubscription(ctx context.Context, id string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	condition, args := common.OrgCondition(ctx)
+	result, err := tx.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = ? AND "+condition, append([]interface{}{id}, args...)...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE subscription_id = $1", id); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *repo) insertDelivery(ctx context.Context, d *Delivery) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO webhook_deliveries ("+deliveryColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+		d.Id, d.SubscriptionId, d.EventId, d.EventType, d.Payload, d.Status, d.Attempts, d.StatusCode, d.Error, d.CreatedAt, d.UpdatedAt)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// updateDelivery records the result of the last attempt of the delivery
+func (r *repo) updateDelivery(ctx context.Context, d *Delivery) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE webhook_deliveries SET status = $1, attempts = $2, status_code = $3, error = $4, updated_at = $5 WHERE id = $6",
+		d.Status, d.Attempts, d.StatusCode, d.Error, d.UpdatedAt, d.Id)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// listDeliveries returns the deliveries of the subscription, the newest first
+func (r *repo) listDeliveries(ctx context.Context, subscriptionId string, limit, offset int) ([]*Delivery, error) {
+	deliveries := []*Delivery{}
+	err := r.db.SelectContext(ctx, &deliveries,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3",
+		subscriptionId, limit, offset)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// pruneDeliveries deletes the deliveries older than before
+func (r *repo) pruneDeliveries(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE created_at < $1", before)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	return nil
+}
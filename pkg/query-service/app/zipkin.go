@@ -0,0 +1,52 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/app/traces/zipkin"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// zipkinSpans receives the spans of the services instrumented with the Zipkin libraries, in the
+// v2 JSON format. The services authenticate with an ingestion key allowed to send traces
+func (aH *APIHandler) zipkinSpans(w http.ResponseWriter, r *http.Request) {
+	if aH.zipkinWriter == nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnavailable, Err: fmt.Errorf("zipkin ingestion is not available")}, nil)
+		return
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "" && !strings.HasPrefix(contentType, "application/json") {
+		RespondError(w, model.BadRequest(fmt.Errorf("unsupported content type %q, only the v2 JSON format is supported", contentType)), nil)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, zipkin.MaxRequestSize))
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	key, apiErr := authorizeIngestion(r, model.IngestionSignalTraces, int64(len(body)))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	spans, err := zipkin.DecodeSpans(body, r.Header.Get("Content-Encoding"))
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	if err := aH.zipkinWriter.Write(r.Context(), spans, map[string]string{constants.OrgIdAttribute: key.OrgId}); err != nil {
+		if errors.Is(err, zipkin.ErrInvalidSpan) {
+			RespondError(w, model.BadRequest(err), nil)
+			return
+		}
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -61,7 +61,7 @@ func Invite(ctx context.Context, req *model.InviteRequest) (*model.InviteRespons
 		return nil, errors.New("User already exists with the same email")
 	}
 
-	if err := validateInviteRequest(req); err != nil {
+	if err := validateInviteRequest(ctx, req); err != nil {
 		return nil, errors.Wrap(err, "invalid invite request")
 	}
 
@@ -79,13 +79,18 @@ func Invite(ctx context.Context, req *model.InviteRequest) (*model.InviteRespons
 	if apiErr != nil {
 		return nil, errors.Wrap(err, "failed to query admin user from the DB")
 	}
+	// the user is invited to the org the admin works in
+	orgId := adminUser.OrgId
+	if orgId == "" {
+		orgId = au.OrgId
+	}
 	inv := &model.InvitationObject{
 		Name:      req.Name,
 		Email:     req.Email,
 		Token:     token,
 		CreatedAt: time.Now().Unix(),
 		Role:      req.Role,
-		OrgId:     au.OrgId,
+		OrgId:     orgId,
 	}
 
 	if err := dao.DB().CreateInviteEntry(ctx, inv); err != nil {
@@ -434,13 +439,19 @@ func Register(ctx context.Context, req *RegisterRequest) (*model.User, *model.Ap
 func Login(ctx context.Context, request *model.LoginRequest) (*model.LoginResponse, error) {
 	zap.L().Debug("Login method called for user", zap.String("email", request.Email))
 
-	user, err := authenticateLogin(ctx, request)
+	user, state, err := authenticateLogin(ctx, request)
 	if err != nil {
 		zap.L().Error("Failed to authenticate login request", zap.Error(err))
 		return nil, err
 	}
 
-	userjwt, err := GenerateJWTForUser(&user.User)
+	session, err := startSession(ctx, &user.User, state.sessionId, request)
+	if err != nil {
+		zap.L().Error("Failed to start session", zap.Error(err))
+		return nil, err
+	}
+
+	userjwt, err := generateJWT(&user.User, session.Id, state.twoFactorSetup)
 	if err != nil {
 		zap.L().Error("Failed to generate JWT against login creds", zap.Error(err))
 		return nil, err
@@ -452,32 +463,79 @@ func Login(ctx context.Context, request *model.LoginRequest) (*model.LoginRespon
 	}
 
 	return &model.LoginResponse{
-		UserJwtObject: userjwt,
-		UserId:        user.User.Id,
+		UserJwtObject:          userjwt,
+		UserId:                 user.User.Id,
+		SessionId:              session.Id,
+		TwoFactorSetupRequired: state.twoFactorSetup,
 	}, nil
 }
 
+// loginState is the state of an authenticated login
+type loginState struct {
+	// sessionId is the session of the refresh token, it is empty for a new login
+	sessionId string
+	// twoFactorSetup is set when the user must enroll in two-factor authentication
+	twoFactorSetup bool
+}
+
 // authenticateLogin is responsible for querying the DB and validating the credentials.
-func authenticateLogin(ctx context.Context, req *model.LoginRequest) (*model.UserPayload, error) {
+// The users enrolled in two-factor authentication must send a code with their password.
+func authenticateLogin(ctx context.Context, req *model.LoginRequest) (*model.UserPayload, *loginState, error) {
 
 	// If refresh token is valid, then simply authorize the login request.
 	if len(req.RefreshToken) > 0 {
 		user, err := validateUser(req.RefreshToken)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to validate refresh token")
+			return nil, nil, errors.Wrap(err, "failed to validate refresh token")
+		}
+		claims, err := ParseJWT(req.RefreshToken)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to validate refresh token")
+		}
+		if scope, _ := claims["scope"].(string); scope == RenderScope {
+			return nil, nil, model.ErrorRenderScope
+		}
+		state := &loginState{}
+		state.sessionId, _ = claims["sid"].(string)
+		// the tokens without session are issued for the internal requests, they are not refreshed
+		if state.sessionId == "" {
+			return nil, nil, errors.New("failed to validate refresh token: the token has no session")
+		}
+		// the restriction is lifted once the user is enrolled
+		if setup, _ := claims["tfa_setup"].(bool); setup {
+			enabled, err := TwoFactorEnabled(ctx, user.Id)
+			if err != nil {
+				return nil, nil, err
+			}
+			state.twoFactorSetup = !enabled
 		}
 
-		return user, nil
+		return user, state, nil
 	}
 
 	user, err := dao.DB().GetUserByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, errors.Wrap(err.Err, "user not found")
+		return nil, nil, errors.Wrap(err.Err, "user not found")
 	}
 	if user == nil || !passwordMatch(user.Password, req.Password) {
-		return nil, ErrorInvalidCreds
+		return nil, nil, ErrorInvalidCreds
 	}
-	return user, nil
+
+	enabled, tfaErr := TwoFactorEnabled(ctx, user.Id)
+	if tfaErr != nil {
+		return nil, nil, tfaErr
+	}
+	if enabled {
+		if err := VerifyTwoFactor(ctx, user.Id, req.TOTPCode); err != nil {
+			return nil, nil, err
+		}
+		return user, &loginState{}, nil
+	}
+	setup, tfaErr := twoFactorSetupRequired(ctx, user)
+	if tfaErr != nil {
+		return nil, nil, tfaErr
+	}
+	return user, &loginState{twoFactorSetup: setup}, nil
 }
 
 // Generate hash from the password.
@@ -495,17 +553,63 @@ func passwordMatch(hash, password string) bool {
 	return err == nil
 }
 
+// GenerateJWTForUser generates tokens without session, they can't be revoked
+// and are meant for the internal requests made on behalf of the user, their
+// refresh token is rejected. The logins start a session instead
 func GenerateJWTForUser(user *model.User) (model.UserJwtObject, error) {
+	return GenerateJWTForSession(user, "")
+}
+
+// GenerateJWTForSession generates the tokens of a session of the user, they are
+// valid as long as the session is not revoked
+func GenerateJWTForSession(user *model.User, sessionId string) (model.UserJwtObject, error) {
+	return generateJWT(user, sessionId, false)
+}
+
+// GenerateRenderJWT generates a short-lived access token, without refresh token, the dashboard
+// renderer loads the pages with on behalf of the user. The token only allows to read
+func GenerateRenderJWT(user *model.User, expiry time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"id":    user.Id,
+		"gid":   user.GroupId,
+		"email": user.Email,
+		"exp":   time.Now().Add(expiry).Unix(),
+		"scope": RenderScope,
+	}
+	if user.OrgId != "" {
+		claims["oid"] = user.OrgId
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(JwtSecret))
+	if err != nil {
+		return "", errors.Errorf("failed to encode jwt: %v", err)
+	}
+	return token, nil
+}
+
+// generateJWT generates the tokens of the user, the tokens of the users who must enroll
+// in two-factor authentication only allow to enroll
+func generateJWT(user *model.User, sessionId string, twoFactorSetup bool) (model.UserJwtObject, error) {
 	j := model.UserJwtObject{}
 	var err error
 	j.AccessJwtExpiry = time.Now().Add(JwtExpiry).Unix()
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	claims := jwt.MapClaims{
 		"id":    user.Id,
 		"gid":   user.GroupId,
 		"email": user.Email,
 		"exp":   j.AccessJwtExpiry,
-	})
+	}
+	if sessionId != "" {
+		claims["sid"] = sessionId
+	}
+	// the org the user is working in, the users can switch between their orgs
+	if user.OrgId != "" {
+		claims["oid"] = user.OrgId
+	}
+	if twoFactorSetup {
+		claims["tfa_setup"] = true
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	j.AccessJwt, err = token.SignedString([]byte(JwtSecret))
 	if err != nil {
@@ -513,12 +617,22 @@ func GenerateJWTForUser(user *model.User) (model.UserJwtObject, error) {
 	}
 
 	j.RefreshJwtExpiry = time.Now().Add(JwtRefresh).Unix()
-	token = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	refreshClaims := jwt.MapClaims{
 		"id":    user.Id,
 		"gid":   user.GroupId,
 		"email": user.Email,
 		"exp":   j.RefreshJwtExpiry,
-	})
+	}
+	if sessionId != "" {
+		refreshClaims["sid"] = sessionId
+	}
+	if user.OrgId != "" {
+		refreshClaims["oid"] = user.OrgId
+	}
+	if twoFactorSetup {
+		refreshClaims["tfa_setup"] = true
+	}
+	token = jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
 
 	j.RefreshJwt, err = token.SignedString([]byte(JwtSecret))
 	if err != nil {
@@ -47,11 +47,19 @@ func validateUser(tok string) (*model.UserPayload, error) {
 	if !claims.VerifyExpiresAt(now, true) {
 		return nil, model.ErrorTokenExpired
 	}
+	// the tokens of a session are rejected once the session is revoked
+	if sessionId, ok := claims["sid"].(string); ok && sessionId != "" {
+		if err := checkSession(context.Background(), claims["id"].(string), sessionId); err != nil {
+			return nil, err
+		}
+	}
+	orgId, _ := claims["oid"].(string)
 	return &model.UserPayload{
 		User: model.User{
 			Id:      claims["id"].(string),
 			GroupId: claims["gid"].(string),
 			Email:   claims["email"].(string),
+			OrgId:   orgId,
 		},
 	}, nil
 }
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"sync"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+func grant(resources []model.Resource, actions ...model.Action) []model.Permission {
+	permissions := []model.Permission{}
+	for _, resource := range resources {
+		for _, action := range actions {
+			permissions = append(permissions, model.Permission{Resource: resource, Action: action})
+		}
+	}
+	return permissions
+}
+
+// adminResources are only granted to the ADMIN role by default, the reports and the logs
+// exports send the data out of the instance
+var adminResources = map[model.Resource]bool{model.ResourceReports: true, model.ResourceLogExports: true}
+
+// sharedResources are the resources all the built in roles can read
+func sharedResources() []model.Resource {
+	resources := []model.Resource{}
+	for _, resource := range model.Resources {
+		if !adminResources[resource] {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
+// builtInPermissions are the permissions of the ADMIN, EDITOR and VIEWER roles, the editors
+// can only create channels as changing them affects the alerts of everyone
+var builtInPermissions = map[string][]model.Permission{
+	constants.AdminGroup: grant(model.Resources, model.Actions...),
+	constants.EditorGroup: append(append(
+		grant(sharedResources(), model.ActionRead),
+		grant([]model.Resource{model.ResourceDashboards, model.ResourceAlerts, model.ResourcePipelines, model.ResourceSavedViews, model.ResourceIssues, model.ResourceErrors, model.ResourceDeployments, model.ResourceSynthetics, model.ResourceLogMetrics, model.ResourceOnCall},
+			model.ActionCreate, model.ActionUpdate, model.ActionDelete)...),
+		model.Permission{Resource: model.ResourceChannels, Action: model.ActionCreate}),
+	constants.ViewerGroup: grant(sharedResources(), model.ActionRead),
+}
+
+// IsBuiltInRole checks if the role is one of ADMIN, EDITOR or VIEWER
+func IsBuiltInRole(name string) bool {
+	_, ok := builtInPermissions[name]
+	return ok
+}
+
+// customRoles caches the permissions of the custom roles by group id
+var customRoles = struct {
+	sync.RWMutex
+	permissions map[string]map[model.Permission]bool
+}{permissions: map[string]map[model.Permission]bool{}}
+
+// InvalidateRolePermissions drops the cached permissions of the role once changed or deleted
+func InvalidateRolePermissions(groupId string) {
+	customRoles.Lock()
+	defer customRoles.Unlock()
+	delete(customRoles.permissions, groupId)
+}
+
+func toSet(permissions []model.Permission) map[model.Permission]bool {
+	set := map[model.Permission]bool{}
+	for _, p := range permissions {
+		set[p] = true
+	}
+	return set
+}
+
+var builtInPermissionSets = map[string]map[model.Permission]bool{
+	constants.AdminGroup:  toSet(builtInPermissions[constants.AdminGroup]),
+	constants.EditorGroup: toSet(builtInPermissions[constants.EditorGroup]),
+	constants.ViewerGroup: toSet(builtInPermissions[constants.ViewerGroup]),
+}
+
+// rolePermissions returns the permissions of the role of the group, false is returned
+// when the group doesn't exist
+func rolePermissions(ctx context.Context, groupId string) (map[model.Permission]bool, bool) {
+	switch groupId {
+	case AuthCacheObj.AdminGroupId:
+		return builtInPermissionSets[constants.AdminGroup], true
+	case AuthCacheObj.EditorGroupId:
+		return builtInPermissionSets[constants.EditorGroup], true
+	case AuthCacheObj.ViewerGroupId:
+		return builtInPermissionSets[constants.ViewerGroup], true
+	}
+
+	customRoles.RLock()
+	permissions, ok := customRoles.permissions[groupId]
+	customRoles.RUnlock()
+	if ok {
+		return permissions, true
+	}
+
+	group, apiErr := dao.DB().GetGroup(ctx, groupId)
+	if apiErr != nil {
+		zap.L().Error("failed to get the group of the role", zap.String("groupId", groupId), zap.Error(apiErr.Err))
+		return nil, false
+	}
+	if group == nil {
+		return nil, false
+	}
+	list, apiErr := dao.DB().GetGroupPermissions(ctx, groupId)
+	if apiErr != nil {
+		zap.L().Error("failed to get the permissions of the role", zap.String("groupId", groupId), zap.Error(apiErr.Err))
+		return nil, false
+	}
+	permissions = toSet(list)
+
+	customRoles.Lock()
+	customRoles.permissions[groupId] = permissions
+	customRoles.Unlock()
+	return permissions, true
+}
+
+// HasRole checks if the user has a built in or a custom role
+func HasRole(ctx context.Context, user *model.UserPayload) bool {
+	_, ok := rolePermissions(ctx, user.GroupId)
+	return ok
+}
+
+// HasPermission checks if the role of the user grants the action on the resource
+func HasPermission(ctx context.Context, user *model.UserPayload, resource model.Resource, action model.Action) bool {
+	permissions, ok := rolePermissions(ctx, user.GroupId)
+	return ok && permissions[model.Permission{Resource: resource, Action: action}]
+}
+
+// IsValidRole checks if the role exists, be it built in or custom
+func IsValidRole(ctx context.Context, name string) bool {
+	if IsBuiltInRole(name) {
+		return true
+	}
+	group, apiErr := dao.DB().GetGroupByName(ctx, name)
+	return apiErr == nil && group != nil
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestBuiltInRolePermissions(t *testing.T) {
+	AuthCacheObj = AuthCache{AdminGroupId: "admin", EditorGroupId: "editor", ViewerGroupId: "viewer"}
+	t.Cleanup(func() { AuthCacheObj = AuthCache{} })
+
+	ctx := context.Background()
+	admin := &model.UserPayload{User: model.User{GroupId: "admin"}}
+	editor := &model.UserPayload{User: model.User{GroupId: "editor"}}
+	viewer := &model.UserPayload{User: model.User{GroupId: "viewer"}}
+
+	for _, resource := range model.Resources {
+		for _, action := range model.Actions {
+			assert.True(t, HasPermission(ctx, admin, resource, action), "admin %s %s", action, resource)
+		}
+		assert.Equal(t, !adminResources[resource], HasPermission(ctx, viewer, resource, model.ActionRead), "viewer read %s", resource)
+		assert.False(t, HasPermission(ctx, viewer, resource, model.ActionCreate))
+		assert.Equal(t, !adminResources[resource], HasPermission(ctx, editor, resource, model.ActionRead), "editor read %s", resource)
+	}
+
+	assert.True(t, HasPermission(ctx, editor, model.ResourceAlerts, model.ActionUpdate))
+	assert.True(t, HasPermission(ctx, editor, model.ResourceChannels, model.ActionCreate))
+	assert.True(t, HasPermission(ctx, editor, model.ResourceIssues, model.ActionCreate))
+	assert.True(t, HasPermission(ctx, editor, model.ResourceErrors, model.ActionUpdate))
+	assert.True(t, HasPermission(ctx, editor, model.ResourceDeployments, model.ActionCreate))
+	assert.True(t, HasPermission(ctx, editor, model.ResourceSynthetics, model.ActionUpdate))
+	assert.True(t, HasPermission(ctx, editor, model.ResourceLogMetrics, model.ActionDelete))
+	assert.False(t, HasPermission(ctx, editor, model.ResourceChannels, model.ActionDelete))
+	assert.True(t, HasPermission(ctx, editor, model.ResourceOnCall, model.ActionUpdate))
+	assert.False(t, HasPermission(ctx, editor, model.ResourceSettings, model.ActionUpdate))
+	assert.False(t, HasPermission(ctx, editor, model.ResourceReports, model.ActionCreate))
+	assert.False(t, HasPermission(ctx, editor, model.ResourceLogExports, model.ActionRead))
+}
+
+func TestPostableRoleValidate(t *testing.T) {
+	role := model.PostableRole{Name: "alert-manager", Permissions: []model.Permission{
+		{Resource: model.ResourceAlerts, Action: model.ActionUpdate},
+	}}
+	assert.NoError(t, role.Validate())
+
+	role.Permissions = append(role.Permissions, model.Permission{Resource: "retention", Action: model.ActionUpdate})
+	assert.Error(t, role.Validate())
+
+	role.Permissions = []model.Permission{{Resource: model.ResourceSettings, Action: "change"}}
+	assert.Error(t, role.Validate())
+
+	assert.Error(t, (&model.PostableRole{}).Validate())
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"net/http"
+	"regexp"
 
 	"github.com/pkg/errors"
 	"go.signoz.io/signoz/pkg/query-service/constants"
@@ -58,15 +59,69 @@ func GetUserFromRequest(r *http.Request) (*model.UserPayload, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	claims, err := ParseJWT(accessJwt)
+	if err != nil {
+		return nil, err
+	}
+	if setup, _ := claims["tfa_setup"].(bool); setup && !isTwoFactorSetupRequest(r, user) {
+		return nil, model.ErrorTwoFactorSetupRequired
+	}
+	if scope, _ := claims["scope"].(string); scope == RenderScope && !isRenderRequest(r) {
+		return nil, model.ErrorRenderScope
+	}
 	return user, nil
 }
 
+// twoFactorSetupRoute matches the routes of a user, the id and the path past it are captured
+var twoFactorSetupRoute = regexp.MustCompile(`^/api/v1/user/([^/]+)(/totp(?:/.*)?)?$`)
+
+// isTwoFactorSetupRequest reports whether the users who must enroll in two-factor
+// authentication can make the request, they can only read their own user and enroll
+func isTwoFactorSetupRequest(r *http.Request, user *model.UserPayload) bool {
+	match := twoFactorSetupRoute.FindStringSubmatch(r.URL.Path)
+	if match == nil || match[1] != user.Id {
+		return false
+	}
+	return match[2] != "" || r.Method == http.MethodGet
+}
+
+// RenderScope is the scope of the tokens the dashboard renderer loads the pages with
+const RenderScope = "render"
+
+// renderQueryRoute matches the routes that query the data of the panels with a POST request
+var renderQueryRoute = regexp.MustCompile(`^/api/v[0-9]+/(query_range|variables/query)(/.*)?$`)
+
+// isRenderRequest reports whether the request only reads, the render tokens are limited to them
+func isRenderRequest(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		return renderQueryRoute.MatchString(r.URL.Path)
+	}
+	return false
+}
+
 func IsSelfAccessRequest(user *model.UserPayload, id string) bool { return user.Id == id }
 
 func IsViewer(user *model.UserPayload) bool { return user.GroupId == AuthCacheObj.ViewerGroupId }
 func IsEditor(user *model.UserPayload) bool { return user.GroupId == AuthCacheObj.EditorGroupId }
 func IsAdmin(user *model.UserPayload) bool  { return user.GroupId == AuthCacheObj.AdminGroupId }
 
+// GetUserRole returns the name of the group of the user
+func GetUserRole(user *model.UserPayload) string {
+	switch {
+	case IsAdmin(user):
+		return constants.AdminGroup
+	case IsEditor(user):
+		return constants.EditorGroup
+	case IsViewer(user):
+		return constants.ViewerGroup
+	}
+	return ""
+}
+
 func ValidatePassword(password string) error {
 	if len(password) < minimumPasswordLength {
 		return errors.Errorf("Password should be atleast %d characters.", minimumPasswordLength)
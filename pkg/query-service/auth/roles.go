@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func getRole(ctx context.Context, group model.Group) (*model.Role, *model.ApiError) {
+	role := &model.Role{Id: group.Id, Name: group.Name, BuiltIn: IsBuiltInRole(group.Name)}
+	if role.BuiltIn {
+		role.Permissions = builtInPermissions[group.Name]
+		return role, nil
+	}
+	permissions, apiErr := dao.DB().GetGroupPermissions(ctx, group.Id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	role.Permissions = permissions
+	return role, nil
+}
+
+// ListRoles lists the built in and the custom roles along with their permissions
+func ListRoles(ctx context.Context) ([]*model.Role, *model.ApiError) {
+	groups, apiErr := dao.DB().GetGroups(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	roles := []*model.Role{}
+	for _, group := range groups {
+		role, apiErr := getRole(ctx, group)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		roles = append(roles, role)
+	}
+	sort.SliceStable(roles, func(i, j int) bool {
+		return roles[i].BuiltIn && !roles[j].BuiltIn
+	})
+	return roles, nil
+}
+
+func GetRole(ctx context.Context, id string) (*model.Role, *model.ApiError) {
+	group, apiErr := dao.DB().GetGroup(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if group == nil {
+		return nil, model.NotFoundError(fmt.Errorf("no role found with id %s", id))
+	}
+	return getRole(ctx, *group)
+}
+
+func CreateRole(ctx context.Context, req *model.PostableRole) (*model.Role, *model.ApiError) {
+	if err := req.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	existing, apiErr := dao.DB().GetGroupByName(ctx, req.Name)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if existing != nil || IsBuiltInRole(req.Name) {
+		return nil, model.BadRequest(fmt.Errorf("role %s already exists", req.Name))
+	}
+
+	group, apiErr := dao.DB().CreateGroup(ctx, &model.Group{Name: req.Name})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := dao.DB().SetGroupPermissions(ctx, group.Id, req.Permissions); apiErr != nil {
+		return nil, apiErr
+	}
+	InvalidateRolePermissions(group.Id)
+	return getRole(ctx, *group)
+}
+
+// EditRole renames the custom role and replaces its permissions
+func EditRole(ctx context.Context, id string, req *model.PostableRole) (*model.Role, *model.ApiError) {
+	if err := req.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	group, apiErr := dao.DB().GetGroup(ctx, id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if group == nil {
+		return nil, model.NotFoundError(fmt.Errorf("no role found with id %s", id))
+	}
+	if IsBuiltInRole(group.Name) {
+		return nil, model.BadRequest(fmt.Errorf("the built in role %s can't be changed", group.Name))
+	}
+	if req.Name != group.Name {
+		existing, apiErr := dao.DB().GetGroupByName(ctx, req.Name)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if existing != nil || IsBuiltInRole(req.Name) {
+			return nil, model.BadRequest(fmt.Errorf("role %s already exists", req.Name))
+		}
+	}
+
+	group.Name = req.Name
+	if apiErr := dao.DB().EditGroup(ctx, group); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := dao.DB().SetGroupPermissions(ctx, group.Id, req.Permissions); apiErr != nil {
+		return nil, apiErr
+	}
+	InvalidateRolePermissions(group.Id)
+	return getRole(ctx, *group)
+}
+
+// DeleteRole deletes the custom role, the role must not be assigned to any user
+func DeleteRole(ctx context.Context, id string) *model.ApiError {
+	group, apiErr := dao.DB().GetGroup(ctx, id)
+	if apiErr != nil {
+		return apiErr
+	}
+	if group == nil {
+		return model.NotFoundError(fmt.Errorf("no role found with id %s", id))
+	}
+	if IsBuiltInRole(group.Name) {
+		return model.BadRequest(fmt.Errorf("the built in role %s can't be deleted", group.Name))
+	}
+	users, apiErr := dao.DB().GetUsersByGroup(ctx, id)
+	if apiErr != nil {
+		return apiErr
+	}
+	if len(users) > 0 {
+		return model.BadRequest(fmt.Errorf("the role %s is assigned to %d users", group.Name, len(users)))
+	}
+
+	if apiErr := dao.DB().DeleteGroup(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	InvalidateRolePermissions(id)
+	return nil
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// sessionActivityInterval is the interval at which the last activity of a session is recorded,
+// so the requests don't write to the db each time
+const sessionActivityInterval = time.Minute
+
+// startSession creates the session of a login, or refreshes the session of the refresh token
+func startSession(ctx context.Context, user *model.User, sessionId string, request *model.LoginRequest) (*model.Session, error) {
+	now := time.Now()
+	session := &model.Session{
+		Id:             sessionId,
+		UserId:         user.Id,
+		UserAgent:      request.UserAgent,
+		IPAddress:      request.IPAddress,
+		CreatedAt:      now.Unix(),
+		LastActivityAt: now.Unix(),
+		ExpiresAt:      now.Add(JwtRefresh).Unix(),
+	}
+
+	if sessionId != "" {
+		if apiErr := dao.DB().UpdateSession(ctx, session); apiErr != nil {
+			return nil, errors.Wrap(apiErr.Err, "failed to refresh session")
+		}
+		return session, nil
+	}
+
+	session.Id = uuid.NewString()
+	if apiErr := dao.DB().CreateSession(ctx, session); apiErr != nil {
+		return nil, errors.Wrap(apiErr.Err, "failed to create session")
+	}
+	return session, nil
+}
+
+// StartSession starts a session of the user authenticated by other means than a password, like
+// an SSO provider, and generates its tokens
+func StartSession(ctx context.Context, user *model.User, request *model.LoginRequest) (*model.LoginResponse, error) {
+	session, err := startSession(ctx, user, "", request)
+	if err != nil {
+		return nil, err
+	}
+	userJwt, err := GenerateJWTForSession(user, session.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &model.LoginResponse{UserJwtObject: userJwt, UserId: user.Id, SessionId: session.Id}, nil
+}
+
+// checkSession checks that the session of a token has not been revoked, nor expired,
+// and records its activity
+func checkSession(ctx context.Context, userId, sessionId string) error {
+	session, apiErr := dao.DB().GetSession(ctx, sessionId)
+	if apiErr != nil {
+		return apiErr.Err
+	}
+	now := time.Now()
+	if session == nil || session.UserId != userId || session.ExpiresAt <= now.Unix() {
+		return model.ErrorSessionRevoked
+	}
+
+	if now.Unix()-session.LastActivityAt >= int64(sessionActivityInterval.Seconds()) {
+		if apiErr := dao.DB().TouchSession(ctx, sessionId, now.Unix()); apiErr != nil {
+			zap.L().Error("failed to record the activity of the session", zap.String("sessionId", sessionId), zap.Error(apiErr.Err))
+		}
+	}
+	return nil
+}
+
+// ExtractSessionIdFromRequest returns the id of the session of the token of the request,
+// it is empty for the tokens issued without session
+func ExtractSessionIdFromRequest(r *http.Request) string {
+	accessJwt, err := ExtractJwtFromRequest(r)
+	if err != nil {
+		return ""
+	}
+	claims, err := ParseJWT(accessJwt)
+	if err != nil {
+		return ""
+	}
+	sessionId, _ := claims["sid"].(string)
+	return sessionId
+}
+
+// ClientIP returns the address of the client of the request, the first address of the
+// X-Forwarded-For header is used when the request went through a proxy
+func ClientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestSessions(t *testing.T) {
+	require.NoError(t, dao.InitDao("sqlite", filepath.Join(t.TempDir(), "signoz.db")))
+	JwtSecret = "secret"
+	t.Cleanup(func() { JwtSecret = "" })
+
+	ctx := context.Background()
+	user := &model.User{Id: "alice", GroupId: "viewer", Email: "alice@signoz.io"}
+	session, err := startSession(ctx, user, "", &model.LoginRequest{UserAgent: "firefox", IPAddress: "10.0.0.1"})
+	require.NoError(t, err)
+	tokens, err := GenerateJWTForSession(user, session.Id)
+	require.NoError(t, err)
+
+	_, err = validateUser(tokens.AccessJwt)
+	require.NoError(t, err)
+	sessions, apiErr := dao.DB().GetUserSessions(ctx, user.Id)
+	require.Nil(t, apiErr)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "firefox", sessions[0].UserAgent)
+	assert.Equal(t, "10.0.0.1", sessions[0].IPAddress)
+
+	// refreshing the tokens keeps the session
+	_, state, err := authenticateLogin(ctx, &model.LoginRequest{RefreshToken: tokens.RefreshJwt})
+	require.NoError(t, err)
+	assert.Equal(t, session.Id, state.sessionId)
+
+	// the tokens of a revoked session are rejected, the tokens without session are not
+	require.Nil(t, dao.DB().DeleteSession(ctx, session.Id))
+	_, err = validateUser(tokens.AccessJwt)
+	assert.ErrorIs(t, err, model.ErrorSessionRevoked)
+	_, _, err = authenticateLogin(ctx, &model.LoginRequest{RefreshToken: tokens.RefreshJwt})
+	assert.Error(t, err)
+
+	internal, err := GenerateJWTForUser(user)
+	require.NoError(t, err)
+	_, err = validateUser(internal.AccessJwt)
+	assert.NoError(t, err)
+	// the tokens without session can't be refreshed
+	_, _, err = authenticateLogin(ctx, &model.LoginRequest{RefreshToken: internal.RefreshJwt})
+	assert.Error(t, err)
+
+	// the SSO logins start a session
+	resp, err := StartSession(ctx, user, &model.LoginRequest{UserAgent: "chrome", IPAddress: "10.0.0.2"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.SessionId)
+	_, state, err = authenticateLogin(ctx, &model.LoginRequest{RefreshToken: resp.RefreshJwt})
+	require.NoError(t, err)
+	assert.Equal(t, resp.SessionId, state.sessionId)
+}
+
+func TestRenderToken(t *testing.T) {
+	JwtSecret = "secret"
+	t.Cleanup(func() { JwtSecret = "" })
+
+	user := &model.User{Id: "alice", GroupId: "admin", Email: "alice@signoz.io"}
+	token, err := GenerateRenderJWT(user, time.Minute)
+	require.NoError(t, err)
+
+	request := func(method, path string) *http.Request {
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return r
+	}
+	_, err = GetUserFromRequest(request(http.MethodGet, "/api/v1/dashboards/1"))
+	assert.NoError(t, err)
+	_, err = GetUserFromRequest(request(http.MethodPost, "/api/v3/query_range"))
+	assert.NoError(t, err)
+	_, err = GetUserFromRequest(request(http.MethodDelete, "/api/v1/dashboards/1"))
+	assert.ErrorIs(t, err, model.ErrorRenderScope)
+	_, err = GetUserFromRequest(request(http.MethodPost, "/api/v1/invite"))
+	assert.ErrorIs(t, err, model.ErrorRenderScope)
+
+	// the render tokens can not be exchanged for tokens of the user
+	_, _, err = authenticateLogin(context.Background(), &model.LoginRequest{RefreshToken: token})
+	assert.ErrorIs(t, err, model.ErrorRenderScope)
+
+	expired, err := GenerateRenderJWT(user, -time.Minute)
+	require.NoError(t, err)
+	_, err = validateUser(expired)
+	assert.Error(t, err)
+}
@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	totpIssuer     = "SigNoz"
+	totpDigits     = 6
+	totpPeriod     = 30 * time.Second
+	totpSecretSize = 20
+	// totpSkew is the number of time steps before and after the current one the codes are
+	// accepted for, so the clocks of the phones may drift a little
+	totpSkew = 1
+
+	recoveryCodeCount = 10
+	recoveryCodeSize  = 5
+
+	// maxTwoFactorAttempts is the number of invalid codes in a row after which the user is
+	// locked out of the two-factor authentication for twoFactorLockout
+	maxTwoFactorAttempts = 5
+	twoFactorLockout     = 15 * time.Minute
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// totpCode returns the code of the time step of the secret, see RFC 6238
+func totpCode(secret []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// validateTOTPCode returns the time step the code is valid for, or false. The steps up to
+// lastUsedStep are rejected so a code can't be replayed
+func validateTOTPCode(encodedSecret, code string, now time.Time, lastUsedStep int64) (int64, bool) {
+	secret, err := totpEncoding.DecodeString(encodedSecret)
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	current := now.Unix() / int64(totpPeriod.Seconds())
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(secret, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.ReplaceAll(code, "-", ""))))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateRecoveryCodes returns the recovery codes to show to the user, and their hashes to store
+func generateRecoveryCodes() ([]string, string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(b); err != nil {
+			return nil, "", err
+		}
+		code := hex.EncodeToString(b)
+		code = code[:5] + "-" + code[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, strings.Join(hashes, ","), nil
+}
+
+// useRecoveryCode removes the code from the recovery codes, it returns false if the code is not one of them
+func useRecoveryCode(totp *model.UserTOTP, code string) bool {
+	if totp.RecoveryCodes == "" {
+		return false
+	}
+	hash := hashRecoveryCode(code)
+	hashes := strings.Split(totp.RecoveryCodes, ",")
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			totp.RecoveryCodes = strings.Join(append(hashes[:i], hashes[i+1:]...), ",")
+			return true
+		}
+	}
+	return false
+}
+
+// EnrollTOTP generates a new secret for the user, the two-factor authentication is
+// enabled once a code of the secret is verified
+func EnrollTOTP(ctx context.Context, user *model.User) (*model.TOTPEnrollResponse, error) {
+	existing, apiErr := dao.DB().GetUserTOTP(ctx, user.Id)
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	if existing != nil && existing.Enabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	b := make([]byte, totpSecretSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Wrap(err, "failed to generate secret")
+	}
+	secret := totpEncoding.EncodeToString(b)
+	totp := &model.UserTOTP{
+		UserId:    user.Id,
+		Secret:    secret,
+		CreatedAt: time.Now().Unix(),
+	}
+	if apiErr := dao.DB().SetUserTOTP(ctx, totp); apiErr != nil {
+		return nil, apiErr.Err
+	}
+
+	label := url.PathEscape(fmt.Sprintf("%s:%s", totpIssuer, user.Email))
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", totpIssuer)
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+	return &model.TOTPEnrollResponse{
+		Secret: secret,
+		URL:    fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode()),
+	}, nil
+}
+
+// ConfirmTOTP enables the two-factor authentication of the user once the code of the
+// enrolled secret is verified, the recovery codes are returned
+func ConfirmTOTP(ctx context.Context, userId, code string) ([]string, error) {
+	totp, apiErr := dao.DB().GetUserTOTP(ctx, userId)
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	if totp == nil {
+		return nil, errors.New("two-factor authentication is not set up")
+	}
+	if totp.Enabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	step, ok := validateTOTPCode(totp.Secret, code, time.Now(), totp.LastUsedStep)
+	if !ok {
+		return nil, model.ErrorInvalidTwoFactor
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate recovery codes")
+	}
+	totp.Enabled = true
+	totp.RecoveryCodes = hashes
+	totp.LastUsedStep = step
+	if apiErr := dao.DB().SetUserTOTP(ctx, totp); apiErr != nil {
+		return nil, apiErr.Err
+	}
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, the code is required
+func RegenerateRecoveryCodes(ctx context.Context, userId, code string) ([]string, error) {
+	if err := VerifyTwoFactor(ctx, userId, code); err != nil {
+		return nil, err
+	}
+	totp, apiErr := dao.DB().GetUserTOTP(ctx, userId)
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate recovery codes")
+	}
+	totp.RecoveryCodes = hashes
+	if apiErr := dao.DB().SetUserTOTP(ctx, totp); apiErr != nil {
+		return nil, apiErr.Err
+	}
+	return codes, nil
+}
+
+// VerifyTwoFactor checks the code of the authenticator app, or the recovery code, of a user
+// enrolled in two-factor authentication. The recovery codes can only be used once. The user
+// is locked out for a while after too many invalid codes in a row
+func VerifyTwoFactor(ctx context.Context, userId, code string) error {
+	totp, apiErr := dao.DB().GetUserTOTP(ctx, userId)
+	if apiErr != nil {
+		return apiErr.Err
+	}
+	if totp == nil || !totp.Enabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	if code == "" {
+		return model.ErrorTwoFactorRequired
+	}
+	now := time.Now()
+	if now.Unix() < totp.LockedUntil {
+		return model.ErrorTwoFactorLocked
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := validateTOTPCode(totp.Secret, code, now, totp.LastUsedStep); ok {
+		totp.LastUsedStep = step
+	} else if !useRecoveryCode(totp, code) {
+		totp.FailedAttempts++
+		if totp.FailedAttempts >= maxTwoFactorAttempts {
+			totp.FailedAttempts = 0
+			totp.LockedUntil = now.Add(twoFactorLockout).Unix()
+		}
+		if apiErr := dao.DB().SetUserTOTP(ctx, totp); apiErr != nil {
+			return apiErr.Err
+		}
+		return model.ErrorInvalidTwoFactor
+	}
+	totp.FailedAttempts, totp.LockedUntil = 0, 0
+	if apiErr := dao.DB().SetUserTOTP(ctx, totp); apiErr != nil {
+		return apiErr.Err
+	}
+	return nil
+}
+
+// TwoFactorEnabled returns true if the user is enrolled in two-factor authentication
+func TwoFactorEnabled(ctx context.Context, userId string) (bool, error) {
+	totp, apiErr := dao.DB().GetUserTOTP(ctx, userId)
+	if apiErr != nil {
+		return false, apiErr.Err
+	}
+	return totp != nil && totp.Enabled, nil
+}
+
+// twoFactorSetupRequired returns true if the org of the user enforces two-factor
+// authentication and the user is not enrolled
+func twoFactorSetupRequired(ctx context.Context, user *model.UserPayload) (bool, error) {
+	org, apiErr := dao.DB().GetOrg(ctx, user.OrgId)
+	if apiErr != nil {
+		return false, apiErr.Err
+	}
+	if org == nil || !org.EnforceTwoFactor {
+		return false, nil
+	}
+	enabled, err := TwoFactorEnabled(ctx, user.Id)
+	return !enabled, err
+}
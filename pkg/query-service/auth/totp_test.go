@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// the test vectors of RFC 6238, truncated to 6 digits
+	secret := []byte("12345678901234567890")
+	assert.Equal(t, "287082", totpCode(secret, 59/30))
+	assert.Equal(t, "081804", totpCode(secret, 1111111109/30))
+	assert.Equal(t, "005924", totpCode(secret, 1234567890/30))
+
+	encoded := totpEncoding.EncodeToString(secret)
+	now := time.Unix(1234567890, 0)
+	step, ok := validateTOTPCode(encoded, "005924", now, 0)
+	assert.True(t, ok)
+	// the previous code is accepted for the clock drift, the used codes are not
+	_, ok = validateTOTPCode(encoded, "005924", now.Add(totpPeriod), 0)
+	assert.True(t, ok)
+	_, ok = validateTOTPCode(encoded, "005924", now, step)
+	assert.False(t, ok)
+	_, ok = validateTOTPCode(encoded, "005924", now.Add(3*totpPeriod), 0)
+	assert.False(t, ok)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	require.NoError(t, dao.InitDao("sqlite", filepath.Join(t.TempDir(), "signoz.db")))
+	ctx := context.Background()
+
+	org, apiErr := dao.DB().CreateOrg(ctx, &model.Organization{Name: "signoz"})
+	require.Nil(t, apiErr)
+	group, apiErr := dao.DB().GetGroupByName(ctx, "VIEWER")
+	require.Nil(t, apiErr)
+	hash, err := PasswordHash("password")
+	require.NoError(t, err)
+	user, apiErr := dao.DB().CreateUser(ctx, &model.User{Id: "alice", Name: "alice", Email: "alice@signoz.io", Password: hash, GroupId: group.Id, OrgId: org.Id}, false)
+	require.Nil(t, apiErr)
+
+	// the users must enroll once the org enforces two-factor authentication
+	require.Nil(t, dao.DB().SetOrgEnforceTwoFactor(ctx, org.Id, true))
+	_, state, err := authenticateLogin(ctx, &model.LoginRequest{Email: user.Email, Password: "password"})
+	require.NoError(t, err)
+	assert.True(t, state.twoFactorSetup)
+
+	enroll, err := EnrollTOTP(ctx, user)
+	require.NoError(t, err)
+	assert.Contains(t, enroll.URL, "otpauth://totp/SigNoz:alice@signoz.io?")
+	secret, err := totpEncoding.DecodeString(enroll.Secret)
+	require.NoError(t, err)
+	step := time.Now().Unix() / int64(totpPeriod.Seconds())
+
+	_, err = ConfirmTOTP(ctx, user.Id, "000000")
+	assert.Error(t, err)
+	codes, err := ConfirmTOTP(ctx, user.Id, totpCode(secret, step))
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+
+	_, _, err = authenticateLogin(ctx, &model.LoginRequest{Email: user.Email, Password: "password"})
+	assert.ErrorIs(t, err, model.ErrorTwoFactorRequired)
+	// the code used to enroll can't be used again
+	_, _, err = authenticateLogin(ctx, &model.LoginRequest{Email: user.Email, Password: "password", TOTPCode: totpCode(secret, step)})
+	assert.ErrorIs(t, err, model.ErrorInvalidTwoFactor)
+	_, state, err = authenticateLogin(ctx, &model.LoginRequest{Email: user.Email, Password: "password", TOTPCode: totpCode(secret, step+1)})
+	require.NoError(t, err)
+	assert.False(t, state.twoFactorSetup)
+
+	// the recovery codes can be used once
+	_, _, err = authenticateLogin(ctx, &model.LoginRequest{Email: user.Email, Password: "password", TOTPCode: codes[0]})
+	require.NoError(t, err)
+	_, _, err = authenticateLogin(ctx, &model.LoginRequest{Email: user.Email, Password: "password", TOTPCode: codes[0]})
+	assert.ErrorIs(t, err, model.ErrorInvalidTwoFactor)
+}
+
+func TestTwoFactorLockout(t *testing.T) {
+	require.NoError(t, dao.InitDao("sqlite", filepath.Join(t.TempDir(), "signoz.db")))
+	ctx := context.Background()
+
+	user := &model.User{Id: "alice", Email: "alice@signoz.io"}
+	enroll, err := EnrollTOTP(ctx, user)
+	require.NoError(t, err)
+	secret, err := totpEncoding.DecodeString(enroll.Secret)
+	require.NoError(t, err)
+	step := time.Now().Unix() / int64(totpPeriod.Seconds())
+	_, err = ConfirmTOTP(ctx, user.Id, totpCode(secret, step))
+	require.NoError(t, err)
+
+	for i := 0; i < maxTwoFactorAttempts; i++ {
+		assert.ErrorIs(t, VerifyTwoFactor(ctx, user.Id, "000000"), model.ErrorInvalidTwoFactor)
+	}
+	// the valid codes are rejected while the user is locked out
+	assert.ErrorIs(t, VerifyTwoFactor(ctx, user.Id, totpCode(secret, step+1)), model.ErrorTwoFactorLocked)
+
+	totp, apiErr := dao.DB().GetUserTOTP(ctx, user.Id)
+	require.Nil(t, apiErr)
+	totp.LockedUntil = time.Now().Add(-time.Second).Unix()
+	require.Nil(t, dao.DB().SetUserTOTP(ctx, totp))
+	assert.NoError(t, VerifyTwoFactor(ctx, user.Id, totpCode(secret, step+1)))
+}
+
+func TestTwoFactorSetupRequest(t *testing.T) {
+	user := &model.UserPayload{User: model.User{Id: "alice"}}
+	for _, tc := range []struct {
+		method, path string
+		allowed      bool
+	}{
+		{http.MethodGet, "/api/v1/user/alice", true},
+		{http.MethodPut, "/api/v1/user/alice", false},
+		{http.MethodDelete, "/api/v1/user/alice", false},
+		{http.MethodPost, "/api/v1/user/alice/totp", true},
+		{http.MethodPost, "/api/v1/user/alice/totp/verify", true},
+		{http.MethodGet, "/api/v1/user/bob", false},
+		{http.MethodPost, "/api/v1/user/bob/totp", false},
+		{http.MethodGet, "/api/v1/user/alice/flags", false},
+		{http.MethodGet, "/api/v1/dashboards", false},
+	} {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		assert.Equal(t, tc.allowed, isTwoFactorSetupRequest(r, user), "%s %s", tc.method, tc.path)
+	}
+}
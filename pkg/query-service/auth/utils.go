@@ -1,8 +1,9 @@
 package auth
 
 import (
+	"context"
+
 	"github.com/pkg/errors"
-	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/model"
 )
 
@@ -15,15 +16,7 @@ var (
 	ErrorAskAdmin           = errors.New("An invitation is needed to create an account. Please ask your admin (the person who has first installed SIgNoz) to send an invite.")
 )
 
-func isValidRole(role string) bool {
-	switch role {
-	case constants.AdminGroup, constants.EditorGroup, constants.ViewerGroup:
-		return true
-	}
-	return false
-}
-
-func validateInviteRequest(req *model.InviteRequest) error {
+func validateInviteRequest(ctx context.Context, req *model.InviteRequest) error {
 	if req == nil {
 		return ErrorEmptyRequest
 	}
@@ -31,7 +24,7 @@ func validateInviteRequest(req *model.InviteRequest) error {
 		return ErrorInvalidEmail
 	}
 
-	if !isValidRole(req.Role) {
+	if !IsValidRole(ctx, req.Role) {
 		return ErrorInvalidRole
 	}
 	return nil
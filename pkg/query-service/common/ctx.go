@@ -1,5 +1,82 @@
 package common
 
+import (
+	"context"
+	"sync/atomic"
+)
+
 type LogCommentContextKeyType string
 
 const LogCommentKey LogCommentContextKeyType = "logComment"
+
+type QueryIdContextKeyType string
+
+// QueryIdKey is the context key of the id grouping the clickhouse queries of an API request
+const QueryIdKey QueryIdContextKeyType = "queryId"
+
+// QueryIdHeader is the header the clients set to cancel the queries of their requests later on,
+// it is echoed in the response and generated when not set
+const QueryIdHeader = "X-SigNoz-Query-Id"
+
+type QueryStatsContextKeyType string
+
+const QueryStatsKey QueryStatsContextKeyType = "queryStats"
+
+// QueryStats sums up what the clickhouse queries of an API request read
+type QueryStats struct {
+	rowsRead  atomic.Uint64
+	bytesRead atomic.Uint64
+}
+
+// WithQueryStats returns a context collecting the stats of the queries run with it
+func WithQueryStats(ctx context.Context) (context.Context, *QueryStats) {
+	stats := &QueryStats{}
+	return context.WithValue(ctx, QueryStatsKey, stats), stats
+}
+
+// GetQueryStats returns the stats collected for the context, nil when they are not collected
+func GetQueryStats(ctx context.Context) *QueryStats {
+	stats, _ := ctx.Value(QueryStatsKey).(*QueryStats)
+	return stats
+}
+
+func (s *QueryStats) Add(rows, bytes uint64) {
+	s.rowsRead.Add(rows)
+	s.bytesRead.Add(bytes)
+}
+
+func (s *QueryStats) RowsRead() uint64 {
+	return s.rowsRead.Load()
+}
+
+func (s *QueryStats) BytesRead() uint64 {
+	return s.bytesRead.Load()
+}
+
+type QueryClassContextKeyType string
+
+const QueryClassKey QueryClassContextKeyType = "queryClass"
+
+// QueryClass is the kind of workload a clickhouse query is run for, each class has its
+// own settings profile
+type QueryClass string
+
+const (
+	QueryClassInteractive QueryClass = "interactive"
+	QueryClassAlerts      QueryClass = "alerts"
+	QueryClassExport      QueryClass = "export"
+)
+
+// WithQueryClass returns a context running the clickhouse queries as the class
+func WithQueryClass(ctx context.Context, class QueryClass) context.Context {
+	return context.WithValue(ctx, QueryClassKey, class)
+}
+
+// GetQueryClass returns the class of the queries run with the context, the queries are
+// interactive unless told otherwise
+func GetQueryClass(ctx context.Context) QueryClass {
+	if class, ok := ctx.Value(QueryClassKey).(QueryClass); ok {
+		return class
+	}
+	return QueryClassInteractive
+}
@@ -0,0 +1,43 @@
+package common
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// OrgMatcher is the matcher of the series of the orgs of the scope, the series without the
+// org label belong to the default org
+func OrgMatcher(scope *model.OrgScope) *labels.Matcher {
+	if !scope.Exclude {
+		return labels.MustNewMatcher(labels.MatchEqual, constants.OrgIdLabel, scope.OrgIds[0])
+	}
+	others := make([]string, 0, len(scope.OrgIds))
+	for _, orgId := range scope.OrgIds {
+		others = append(others, regexp.QuoteMeta(orgId))
+	}
+	return labels.MustNewMatcher(labels.MatchNotRegexp, constants.OrgIdLabel, strings.Join(others, "|"))
+}
+
+// ScopePromQL adds the matcher of the orgs of the scope to the selectors of the promql query
+func ScopePromQL(query string, scope *model.OrgScope) (string, error) {
+	if scope == nil {
+		return query, nil
+	}
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return "", err
+	}
+	matcher := OrgMatcher(scope)
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		if selector, ok := node.(*parser.VectorSelector); ok {
+			selector.LabelMatchers = append(selector.LabelMatchers, matcher)
+		}
+		return nil
+	})
+	return expr.String(), nil
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 )
 
@@ -38,6 +39,29 @@ func MinAllowedStepInterval(start, end int64) int64 {
 	return step - step%60
 }
 
+// stepIntervals are the intervals the computed step is rounded up to, in seconds
+var stepIntervals = []int64{1, 5, 10, 15, 30, 60, 120, 300, 600, 900, 1800, 3600, 7200, 10800, 21600, 43200, 86400}
+
+// AutoStepInterval returns the step interval in seconds for the range so that no more than
+// maxPoints points are returned, the step is rounded up to one of the usual intervals.
+// maxPoints is capped to the allowed points, it is typically the width of the panel in pixels
+// start and end are in milliseconds
+func AutoStepInterval(start, end, maxPoints int64) int64 {
+	if maxPoints <= 0 || maxPoints > constants.MaxAllowedPointsInTimeSeries {
+		maxPoints = constants.MaxAllowedPointsInTimeSeries
+	}
+	rangeSeconds := (end - start) / 1000
+	minStep := (rangeSeconds + maxPoints - 1) / maxPoints
+	for _, step := range stepIntervals {
+		if step >= minStep {
+			return step
+		}
+	}
+	// return the nearest upper multiple of a day
+	day := stepIntervals[len(stepIntervals)-1]
+	return (minStep + day - 1) / day * day
+}
+
 func GCD(a, b int64) int64 {
 	for b != 0 {
 		a, b = b, a%b
@@ -60,3 +84,29 @@ func LCMList(nums []int64) int64 {
 	}
 	return result
 }
+
+// OrgFilter is the filter of the builder queries of the data source on the orgs of the scope,
+// the org id of the metrics is a sanitized label
+func OrgFilter(dataSource v3.DataSource, scope *model.OrgScope) v3.FilterItem {
+	key := constants.OrgIdAttribute
+	if dataSource == v3.DataSourceMetrics {
+		key = constants.OrgIdLabel
+	}
+	filter := v3.FilterItem{
+		Key: v3.AttributeKey{
+			Key:      key,
+			DataType: v3.AttributeKeyDataTypeString,
+			Type:     v3.AttributeKeyTypeResource,
+		},
+		Operator: v3.FilterOperatorEqual,
+		Value:    scope.OrgIds[0],
+	}
+	if scope.Exclude {
+		others := []interface{}{}
+		for _, orgId := range scope.OrgIds {
+			others = append(others, orgId)
+		}
+		filter.Operator, filter.Value = v3.FilterOperatorNotIn, others
+	}
+	return filter
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/dao"
 	"go.signoz.io/signoz/pkg/query-service/model"
 )
 
@@ -14,3 +15,56 @@ func GetUserFromContext(ctx context.Context) *model.UserPayload {
 	}
 	return user
 }
+
+// GetOrgIdFromContext returns the org the user of the context works in, the resources the
+// user creates belong to it. It is empty for the contexts without a user
+func GetOrgIdFromContext(ctx context.Context) string {
+	user := GetUserFromContext(ctx)
+	if user == nil {
+		return ""
+	}
+	return user.OrgId
+}
+
+// WithOrg returns a context reading the resources of the org, for the requests and the jobs
+// without user, like the public dashboards or the notifications of the rules
+func WithOrg(ctx context.Context, orgId string) context.Context {
+	return context.WithValue(ctx, constants.ContextUserKey, &model.UserPayload{User: model.User{OrgId: orgId}})
+}
+
+// OrgScope returns the orgs whose data the user of the context reads, it is nil when the
+// reads are not scoped, without user, e.g. for the alerts, or while there is a single org
+func OrgScope(ctx context.Context) (*model.OrgScope, *model.ApiError) {
+	user := GetUserFromContext(ctx)
+	if user == nil {
+		return nil, nil
+	}
+	orgs, apiErr := dao.DB().GetOrgs(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if len(orgs) <= 1 {
+		return nil, nil
+	}
+
+	if user.OrgId == "" || user.OrgId == orgs[0].Id {
+		scope := &model.OrgScope{Exclude: true}
+		for _, org := range orgs[1:] {
+			scope.OrgIds = append(scope.OrgIds, org.Id)
+		}
+		return scope, nil
+	}
+	return &model.OrgScope{OrgIds: []string{user.OrgId}}, nil
+}
+
+// OrgCondition returns the SQL condition, and its arguments, restricting the rows of a table
+// with an org_id column to the org the user of the context works in. The rows stored before
+// the orgs were added have no org and belong to the default org, the first org created. The
+// contexts without a user, e.g. of the background jobs, are not restricted
+func OrgCondition(ctx context.Context) (string, []interface{}) {
+	orgId := GetOrgIdFromContext(ctx)
+	if orgId == "" {
+		return "1 = 1", nil
+	}
+	return "(org_id = ? OR (org_id = '' AND ? = (SELECT id FROM organizations ORDER BY created_at, rowid LIMIT 1)))", []interface{}{orgId, orgId}
+}
@@ -27,6 +27,10 @@ var DEFAULT_TELEMETRY_ANONYMOUS = false
 
 const MaxAllowedPointsInTimeSeries = 300
 
+// MinMetricsStepInterval is the smallest step computed for the metrics queries,
+// the metrics are seldom collected more often
+const MinMetricsStepInterval = 60
+
 func IsTelemetryEnabled() bool {
 	if testing.Testing() {
 		return false
@@ -64,6 +68,13 @@ var InviteEmailTemplate = GetOrDefaultEnv("INVITE_EMAIL_TEMPLATE", "/root/templa
 // Alert manager channel subpath
 var AmChannelApiPath = GetOrDefaultEnv("ALERTMANAGER_API_CHANNEL_PATH", "v1/routes")
 
+// AlertEvalDelay is how far behind the evaluation time the queries of the builder and
+// clickhouse rules end by default, to account for the ingestion lag
+var AlertEvalDelay = GetOrDefaultEnv("ALERT_EVAL_DELAY", "2m")
+
+// ReportRendererURL is the headless browser service used to render the scheduled dashboard reports
+var ReportRendererURL = GetOrDefaultEnv("REPORT_RENDERER_URL", "")
+
 var OTLPTarget = GetOrDefaultEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
 var LogExportBatchSize = GetOrDefaultEnv("OTEL_BLRP_MAX_EXPORT_BATCH_SIZE", "512")
 
@@ -154,6 +165,34 @@ func GetContextTimeoutMaxAllowed() time.Duration {
 
 var ContextTimeoutMaxAllowed = GetContextTimeoutMaxAllowed()
 
+func GetSlowQueryThreshold() time.Duration {
+	slowQueryThresholdStr := GetOrDefaultEnv("SLOW_QUERY_THRESHOLD", "5")
+	slowQueryThreshold, err := time.ParseDuration(slowQueryThresholdStr + "s")
+	if err != nil {
+		return 5 * time.Second
+	}
+	return slowQueryThreshold
+}
+
+// SlowQueryThreshold is the duration from which the queries are logged and listed as slow in the query history
+var SlowQueryThreshold = GetSlowQueryThreshold()
+
+var QueryHistoryRetentionDays = GetOrDefaultEnvInt("QUERY_HISTORY_RETENTION_DAYS", 30)
+
+// OrgIdAttribute is the resource attribute the data of the orgs is tagged with at ingestion, with
+// the org of the ingestion key the data is sent with
+const OrgIdAttribute = "signoz.org.id"
+
+// OrgIdLabel is the OrgIdAttribute in the labels of the metrics, sanitized like the other
+// resource attributes
+const OrgIdLabel = "signoz_org_id"
+
+// AuditLogRetentionDays is how long the entries of the audit log are kept
+var AuditLogRetentionDays = GetOrDefaultEnvInt("AUDIT_LOG_RETENTION_DAYS", 365)
+
+// WebhookDeliveryRetentionDays is how long the delivery log of the webhooks is kept
+var WebhookDeliveryRetentionDays = GetOrDefaultEnvInt("WEBHOOK_DELIVERY_RETENTION_DAYS", 30)
+
 const (
 	TraceID                        = "traceID"
 	ServiceName                    = "serviceName"
@@ -208,12 +247,18 @@ var GroupByColMap = map[string]struct{}{
 const (
 	SIGNOZ_METRIC_DBNAME                      = "signoz_metrics"
 	SIGNOZ_SAMPLES_V4_TABLENAME               = "distributed_samples_v4"
+	SIGNOZ_EXEMPLARS_TABLENAME                = "distributed_exemplars"
 	SIGNOZ_TRACE_DBNAME                       = "signoz_traces"
 	SIGNOZ_SPAN_INDEX_TABLENAME               = "distributed_signoz_index_v2"
 	SIGNOZ_TIMESERIES_v4_LOCAL_TABLENAME      = "time_series_v4"
+	SIGNOZ_TIMESERIES_v4_TABLENAME            = "distributed_time_series_v4"
 	SIGNOZ_TIMESERIES_v4_6HRS_LOCAL_TABLENAME = "time_series_v4_6hrs"
 	SIGNOZ_TIMESERIES_v4_1DAY_LOCAL_TABLENAME = "time_series_v4_1day"
 	SIGNOZ_TIMESERIES_v4_1DAY_TABLENAME       = "distributed_time_series_v4_1day"
+	SIGNOZ_SAMPLES_V4_AGG_5M_LOCAL_TABLENAME  = "samples_v4_agg_5m"
+	SIGNOZ_SAMPLES_V4_AGG_5M_TABLENAME        = "distributed_samples_v4_agg_5m"
+	SIGNOZ_SAMPLES_V4_AGG_1H_LOCAL_TABLENAME  = "samples_v4_agg_1h"
+	SIGNOZ_SAMPLES_V4_AGG_1H_TABLENAME        = "distributed_samples_v4_agg_1h"
 )
 
 var TimeoutExcludedRoutes = map[string]bool{
@@ -24,6 +24,7 @@ type Queries interface {
 	GetGroup(ctx context.Context, id string) (*model.Group, *model.ApiError)
 	GetGroupByName(ctx context.Context, name string) (*model.Group, *model.ApiError)
 	GetGroups(ctx context.Context) ([]model.Group, *model.ApiError)
+	GetGroupPermissions(ctx context.Context, groupId string) ([]model.Permission, *model.ApiError)
 
 	GetOrgs(ctx context.Context) ([]model.Organization, *model.ApiError)
 	GetOrgByName(ctx context.Context, name string) (*model.Organization, *model.ApiError)
@@ -36,6 +37,16 @@ type Queries interface {
 	GetApdexSettings(ctx context.Context, services []string) ([]model.ApdexSettings, *model.ApiError)
 
 	GetIngestionKeys(ctx context.Context) ([]model.IngestionKey, *model.ApiError)
+	GetIngestionKey(ctx context.Context, keyId string) (*model.IngestionKey, *model.ApiError)
+	GetIngestionKeyByValue(ctx context.Context, key string) (*model.IngestionKey, *model.ApiError)
+	GetIngestionKeyUsage(ctx context.Context, keyId, since string) ([]model.IngestionKeyUsage, *model.ApiError)
+
+	GetSession(ctx context.Context, id string) (*model.Session, *model.ApiError)
+	GetUserSessions(ctx context.Context, userId string) ([]model.Session, *model.ApiError)
+
+	GetUserTOTP(ctx context.Context, userId string) (*model.UserTOTP, *model.ApiError)
+
+	GetUserOrgs(ctx context.Context, userId string) ([]model.UserOrg, *model.ApiError)
 
 	PrecheckLogin(ctx context.Context, email, sourceUrl string) (*model.PrecheckResponse, model.BaseApiError)
 }
@@ -51,7 +62,9 @@ type Mutations interface {
 	UpdateUserFlags(ctx context.Context, userId string, flags map[string]string) (model.UserFlag, *model.ApiError)
 
 	CreateGroup(ctx context.Context, group *model.Group) (*model.Group, *model.ApiError)
+	EditGroup(ctx context.Context, group *model.Group) *model.ApiError
 	DeleteGroup(ctx context.Context, id string) *model.ApiError
+	SetGroupPermissions(ctx context.Context, groupId string, permissions []model.Permission) *model.ApiError
 
 	CreateOrg(ctx context.Context, org *model.Organization) (*model.Organization, *model.ApiError)
 	EditOrg(ctx context.Context, org *model.Organization) *model.ApiError
@@ -66,4 +79,20 @@ type Mutations interface {
 	SetApdexSettings(ctx context.Context, set *model.ApdexSettings) *model.ApiError
 
 	InsertIngestionKey(ctx context.Context, ingestionKey *model.IngestionKey) *model.ApiError
+	UpdateIngestionKey(ctx context.Context, ingestionKey *model.IngestionKey) *model.ApiError
+	RevokeIngestionKey(ctx context.Context, keyId string) *model.ApiError
+	AddIngestionKeyUsage(ctx context.Context, ingestionKey *model.IngestionKey, day, signal string, bytes int64) (bool, *model.ApiError)
+
+	CreateSession(ctx context.Context, session *model.Session) *model.ApiError
+	UpdateSession(ctx context.Context, session *model.Session) *model.ApiError
+	TouchSession(ctx context.Context, id string, lastActivityAt int64) *model.ApiError
+	DeleteSession(ctx context.Context, id string) *model.ApiError
+	DeleteUserSessions(ctx context.Context, userId string) *model.ApiError
+
+	SetUserTOTP(ctx context.Context, totp *model.UserTOTP) *model.ApiError
+	DeleteUserTOTP(ctx context.Context, userId string) *model.ApiError
+	SetOrgEnforceTwoFactor(ctx context.Context, orgId string, enforce bool) *model.ApiError
+
+	AddOrgMember(ctx context.Context, member *model.OrgMember) *model.ApiError
+	RemoveOrgMember(ctx context.Context, orgId, userId string) *model.ApiError
 }
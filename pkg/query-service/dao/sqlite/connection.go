@@ -7,6 +7,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/migrate/schema"
 	"go.signoz.io/signoz/pkg/query-service/model"
 	"go.signoz.io/signoz/pkg/query-service/telemetry"
 	"go.uber.org/zap"
@@ -26,71 +27,18 @@ func InitDB(dataSourceName string) (*ModelDaoSqlite, error) {
 	}
 	db.SetMaxOpenConns(10)
 
-	table_schema := `
-		PRAGMA foreign_keys = ON;
-
-		CREATE TABLE IF NOT EXISTS invites (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			email TEXT NOT NULL UNIQUE,
-			token TEXT NOT NULL,
-			created_at INTEGER NOT NULL,
-			role TEXT NOT NULL,
-			org_id TEXT NOT NULL,
-			FOREIGN KEY(org_id) REFERENCES organizations(id)
-		);
-		CREATE TABLE IF NOT EXISTS organizations (
-			id TEXT PRIMARY KEY,
-			name TEXT NOT NULL,
-			created_at INTEGER NOT NULL,
-			is_anonymous INTEGER NOT NULL DEFAULT 0 CHECK(is_anonymous IN (0,1)),
-			has_opted_updates INTEGER NOT NULL DEFAULT 1 CHECK(has_opted_updates IN (0,1))
-		);
-		CREATE TABLE IF NOT EXISTS users (
-			id TEXT PRIMARY KEY,
-			name TEXT NOT NULL,
-			email TEXT NOT NULL UNIQUE,
-			password TEXT NOT NULL,
-			created_at INTEGER NOT NULL,
-			profile_picture_url TEXT,
-			group_id TEXT NOT NULL,
-			org_id TEXT NOT NULL,
-			FOREIGN KEY(group_id) REFERENCES groups(id),
-			FOREIGN KEY(org_id) REFERENCES organizations(id)
-		);
-		CREATE TABLE IF NOT EXISTS groups (
-			id TEXT PRIMARY KEY,
-			name TEXT NOT NULL UNIQUE
-		);
-		CREATE TABLE IF NOT EXISTS reset_password_request (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			user_id TEXT NOT NULL,
-			token TEXT NOT NULL,
-			FOREIGN KEY(user_id) REFERENCES users(id)
-		);
-		CREATE TABLE IF NOT EXISTS user_flags (
-			user_id TEXT PRIMARY KEY,
-			flags TEXT,
-			FOREIGN KEY(user_id) REFERENCES users(id)
-		);
-		CREATE TABLE IF NOT EXISTS apdex_settings (
-			service_name TEXT PRIMARY KEY,
-			threshold FLOAT NOT NULL,
-			exclude_status_codes TEXT NOT NULL
-		);
-		CREATE TABLE IF NOT EXISTS ingestion_keys (
-			key_id TEXT PRIMARY KEY,
-			name TEXT,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			ingestion_key TEXT NOT NULL,
-			ingestion_url TEXT NOT NULL,
-			data_region TEXT NOT NULL
-		);
-	`
-
-	_, err = db.Exec(table_schema)
+	_, err = db.Exec("PRAGMA foreign_keys = ON;")
 	if err != nil {
-		return nil, fmt.Errorf("error in creating tables: %v", err.Error())
+		return nil, fmt.Errorf("error in enabling foreign keys: %v", err.Error())
+	}
+
+	// the clickhouse schema is migrated by the server once it is connected to clickhouse
+	migrator, err := schema.NewMigrator(schema.Options{DB: db})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := migrator.Up(context.Background(), schema.DatabaseSQLite, false); err != nil {
+		return nil, fmt.Errorf("error in migrating the sqlite schema: %v", err.Error())
 	}
 
 	mds := &ModelDaoSqlite{db: db}
@@ -111,11 +59,9 @@ func (mds *ModelDaoSqlite) DB() *sqlx.DB {
 	return mds.db
 }
 
-// initializeOrgPreferences initializes in-memory telemetry settings. It is planned to have
-// multiple orgs in the system. In case of multiple orgs, there will be separate instance
-// of in-memory telemetry for each of the org, having their own settings. As of now, we only
-// have one org so this method relies on the settings of this org to initialize the telemetry etc.
-// TODO(Ahsan): Make it multi-tenant when we move to a system with multiple orgs.
+// initializeOrgPreferences initializes in-memory telemetry settings. The telemetry is sent
+// for the install, so this method relies on the settings of the default org, the first org
+// created, to initialize the telemetry etc.
 func (mds *ModelDaoSqlite) initializeOrgPreferences(ctx context.Context) error {
 
 	// set anonymous setting as default in case of any failures to fetch UserPreference in below section
@@ -126,12 +72,8 @@ func (mds *ModelDaoSqlite) initializeOrgPreferences(ctx context.Context) error {
 		return apiError.Err
 	}
 
-	if len(orgs) > 1 {
-		return errors.Errorf("Found %d organizations, expected one or none.", len(orgs))
-	}
-
 	var org model.Organization
-	if len(orgs) == 1 {
+	if len(orgs) > 0 {
 		org = orgs[0]
 	}
 
@@ -2,6 +2,8 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 
 	"go.signoz.io/signoz/pkg/query-service/model"
 )
@@ -16,6 +18,31 @@ func (mds *ModelDaoSqlite) GetIngestionKeys(ctx context.Context) ([]model.Ingest
 	return ingestion_keys, nil
 }
 
+func (mds *ModelDaoSqlite) GetIngestionKey(ctx context.Context, keyId string) (*model.IngestionKey, *model.ApiError) {
+	ingestion_key := model.IngestionKey{}
+	err := mds.db.GetContext(ctx, &ingestion_key, `SELECT * FROM ingestion_keys WHERE key_id = ?`, keyId)
+	if err == sql.ErrNoRows {
+		return nil, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("ingestion key not found")}
+	}
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return &ingestion_key, nil
+}
+
+// GetIngestionKeyByValue returns the key with the value sent by the ingestion gateway, nil when unknown
+func (mds *ModelDaoSqlite) GetIngestionKeyByValue(ctx context.Context, key string) (*model.IngestionKey, *model.ApiError) {
+	ingestion_key := model.IngestionKey{}
+	err := mds.db.GetContext(ctx, &ingestion_key, `SELECT * FROM ingestion_keys WHERE ingestion_key = ?`, key)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return &ingestion_key, nil
+}
+
 func (mds *ModelDaoSqlite) InsertIngestionKey(ctx context.Context, ingestion_key *model.IngestionKey) *model.ApiError {
 	_, err := mds.db.ExecContext(ctx, `
 	INSERT INTO ingestion_keys (
@@ -23,17 +50,74 @@ func (mds *ModelDaoSqlite) InsertIngestionKey(ctx context.Context, ingestion_key
 		name,
 		key_id,
 		ingestion_url,
-		data_region
+		data_region,
+		signals,
+		daily_limit_bytes,
+		org_id
 	) VALUES (
+		?,
+		?,
+		?,
 		?,
 		?,
 		?,
 		?,
 		?
-	)`, ingestion_key.IngestionKey, ingestion_key.Name, ingestion_key.KeyId, ingestion_key.IngestionURL, ingestion_key.DataRegion)
+	)`, ingestion_key.IngestionKey, ingestion_key.Name, ingestion_key.KeyId, ingestion_key.IngestionURL, ingestion_key.DataRegion,
+		ingestion_key.Signals, ingestion_key.DailyLimitBytes, ingestion_key.OrgId)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	return nil
+}
+
+func (mds *ModelDaoSqlite) UpdateIngestionKey(ctx context.Context, ingestion_key *model.IngestionKey) *model.ApiError {
+	_, err := mds.db.ExecContext(ctx, `UPDATE ingestion_keys SET name = ?, signals = ?, daily_limit_bytes = ? WHERE key_id = ?`,
+		ingestion_key.Name, ingestion_key.Signals, ingestion_key.DailyLimitBytes, ingestion_key.KeyId)
 	if err != nil {
 		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
 	}
+	return nil
+}
 
+// RevokeIngestionKey rejects the data sent with the key, the key is kept for its usage
+func (mds *ModelDaoSqlite) RevokeIngestionKey(ctx context.Context, keyId string) *model.ApiError {
+	_, err := mds.db.ExecContext(ctx, `UPDATE ingestion_keys SET revoked = 1 WHERE key_id = ?`, keyId)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
 	return nil
 }
+
+// AddIngestionKeyUsage counts the bytes sent with the key for the signal in the day, ok is
+// false, and nothing is counted, when the bytes exceed the daily limit of the key. The limit
+// is checked in the same statement to hold with concurrent batches
+func (mds *ModelDaoSqlite) AddIngestionKeyUsage(ctx context.Context, ingestion_key *model.IngestionKey, day, signal string, bytes int64) (bool, *model.ApiError) {
+	res, err := mds.db.ExecContext(ctx, `
+	INSERT INTO ingestion_key_usage (key_id, day, signal, bytes, count)
+	SELECT ?, ?, ?, ?, 1
+	WHERE ? = 0 OR (SELECT COALESCE(SUM(bytes), 0) FROM ingestion_key_usage WHERE key_id = ? AND day = ?) + ? <= ?
+	ON CONFLICT(key_id, day, signal) DO UPDATE SET bytes = bytes + excluded.bytes, count = count + 1`,
+		ingestion_key.KeyId, day, signal, bytes,
+		ingestion_key.DailyLimitBytes, ingestion_key.KeyId, day, bytes, ingestion_key.DailyLimitBytes)
+	if err != nil {
+		return false, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return n > 0, nil
+}
+
+// GetIngestionKeyUsage returns the usage of the key from the day on, the key is not filtered when empty
+func (mds *ModelDaoSqlite) GetIngestionKeyUsage(ctx context.Context, keyId, since string) ([]model.IngestionKeyUsage, *model.ApiError) {
+	usage := []model.IngestionKeyUsage{}
+	err := mds.db.SelectContext(ctx, &usage, `SELECT key_id, day, signal, bytes, count FROM ingestion_key_usage
+		WHERE (? = '' OR key_id = ?) AND day >= ? ORDER BY day, key_id, signal`, keyId, keyId, since)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return usage, nil
+}
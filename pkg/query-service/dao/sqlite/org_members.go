@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// GetUserOrgs returns the org of the user and the orgs the user is a member of
+func (mds *ModelDaoSqlite) GetUserOrgs(ctx context.Context, userId string) ([]model.UserOrg, *model.ApiError) {
+	orgs := []model.UserOrg{}
+	query := `select o.id as org_id, o.name, u.group_id, g.name as role, 1 as home
+			from users u, organizations o, groups g
+			where u.org_id = o.id and u.group_id = g.id and u.id = ?
+			union all
+			select o.id as org_id, o.name, m.group_id, g.name as role, 0 as home
+			from org_members m, organizations o, groups g
+			where m.org_id = o.id and m.group_id = g.id and m.user_id = ?;`
+	if err := mds.db.SelectContext(ctx, &orgs, query, userId, userId); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return orgs, nil
+}
+
+// AddOrgMember adds the user to the org, or updates the role of the member
+func (mds *ModelDaoSqlite) AddOrgMember(ctx context.Context, member *model.OrgMember) *model.ApiError {
+	_, err := mds.db.ExecContext(ctx, `
+	INSERT INTO org_members (org_id, user_id, group_id, created_at) VALUES (?, ?, ?, ?)
+	ON CONFLICT(org_id, user_id) DO UPDATE SET group_id = excluded.group_id`,
+		member.OrgId, member.UserId, member.GroupId, member.CreatedAt)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return nil
+}
+
+func (mds *ModelDaoSqlite) RemoveOrgMember(ctx context.Context, orgId, userId string) *model.ApiError {
+	result, err := mds.db.ExecContext(ctx, `DELETE FROM org_members WHERE org_id = ? AND user_id = ?`, orgId, userId)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	if affectedRows == 0 {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("user %s is not a member of the org %s", userId, orgId)}
+	}
+	return nil
+}
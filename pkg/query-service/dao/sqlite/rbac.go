@@ -144,9 +144,10 @@ func (mds *ModelDaoSqlite) GetOrgByName(ctx context.Context,
 	return &orgs[0], nil
 }
 
+// GetOrgs returns the orgs in the order they were created, the first one is the default org
 func (mds *ModelDaoSqlite) GetOrgs(ctx context.Context) ([]model.Organization, *model.ApiError) {
 	orgs := []model.Organization{}
-	err := mds.db.Select(&orgs, `SELECT * FROM organizations`)
+	err := mds.db.Select(&orgs, `SELECT * FROM organizations ORDER BY created_at, rowid`)
 
 	if err != nil {
 		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
@@ -171,6 +172,9 @@ func (mds *ModelDaoSqlite) EditOrg(ctx context.Context, org *model.Organization)
 
 func (mds *ModelDaoSqlite) DeleteOrg(ctx context.Context, id string) *model.ApiError {
 
+	if _, err := mds.db.ExecContext(ctx, `DELETE FROM org_members WHERE org_id = ?`, id); err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
 	_, err := mds.db.ExecContext(ctx, `DELETE from organizations where id=?;`, id)
 	if err != nil {
 		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
@@ -241,6 +245,17 @@ func (mds *ModelDaoSqlite) UpdateUserGroup(ctx context.Context, userId, groupId
 
 func (mds *ModelDaoSqlite) DeleteUser(ctx context.Context, id string) *model.ApiError {
 
+	// the user is logged out of all its sessions
+	if apiErr := mds.DeleteUserSessions(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	if apiErr := mds.DeleteUserTOTP(ctx, id); apiErr != nil {
+		return apiErr
+	}
+	if _, err := mds.db.ExecContext(ctx, `DELETE FROM org_members WHERE user_id = ?`, id); err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
 	result, err := mds.db.ExecContext(ctx, `DELETE from users where id=?;`, id)
 	if err != nil {
 		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
@@ -400,9 +415,27 @@ func (mds *ModelDaoSqlite) GetUsersByOrg(ctx context.Context,
 			where
 				u.group_id = g.id and
 				u.org_id = o.id and
-				u.org_id=?;`
+				u.org_id=?
+			union all
+			select
+				u.id,
+				u.name,
+				u.email,
+				u.password,
+				u.created_at,
+				u.profile_picture_url,
+				m.org_id,
+				m.group_id,
+				g.name as role,
+				o.name as organization
+			from org_members m, users u, groups g, organizations o
+			where
+				m.user_id = u.id and
+				m.group_id = g.id and
+				m.org_id = o.id and
+				m.org_id=?;`
 
-	if err := mds.db.Select(&users, query, orgId); err != nil {
+	if err := mds.db.Select(&users, query, orgId, orgId); err != nil {
 		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
 	}
 	return users, nil
@@ -448,14 +481,62 @@ func (mds *ModelDaoSqlite) CreateGroup(ctx context.Context,
 	return group, nil
 }
 
+func (mds *ModelDaoSqlite) EditGroup(ctx context.Context, group *model.Group) *model.ApiError {
+
+	if _, err := mds.db.ExecContext(ctx, `UPDATE groups SET name=? WHERE id=?;`, group.Name, group.Id); err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return nil
+}
+
 func (mds *ModelDaoSqlite) DeleteGroup(ctx context.Context, id string) *model.ApiError {
 
+	if _, err := mds.db.ExecContext(ctx, `DELETE from group_permissions where group_id=?;`, id); err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
 	if _, err := mds.db.ExecContext(ctx, `DELETE from groups where id=?;`, id); err != nil {
 		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
 	}
 	return nil
 }
 
+func (mds *ModelDaoSqlite) GetGroupPermissions(ctx context.Context,
+	groupId string) ([]model.Permission, *model.ApiError) {
+
+	permissions := []model.Permission{}
+	if err := mds.db.Select(&permissions,
+		`SELECT resource, action FROM group_permissions WHERE group_id=? ORDER BY resource, action`, groupId); err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return permissions, nil
+}
+
+// SetGroupPermissions replaces the permissions of the group
+func (mds *ModelDaoSqlite) SetGroupPermissions(ctx context.Context,
+	groupId string, permissions []model.Permission) *model.ApiError {
+
+	tx, err := mds.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE from group_permissions where group_id=?;`, groupId); err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	for _, p := range permissions {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO group_permissions (group_id, resource, action) VALUES (?, ?, ?);`,
+			groupId, p.Resource, p.Action); err != nil {
+			return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return nil
+}
+
 func (mds *ModelDaoSqlite) GetGroup(ctx context.Context,
 	id string) (*model.Group, *model.ApiError) {
 
@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// GetSession returns the session, nil when it does not exist
+func (mds *ModelDaoSqlite) GetSession(ctx context.Context, id string) (*model.Session, *model.ApiError) {
+	sessions := []model.Session{}
+	err := mds.db.SelectContext(ctx, &sessions, `SELECT * FROM user_sessions WHERE id = ?`, id)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	return &sessions[0], nil
+}
+
+// GetUserSessions returns the sessions of the user which have not expired, the most recently active first
+func (mds *ModelDaoSqlite) GetUserSessions(ctx context.Context, userId string) ([]model.Session, *model.ApiError) {
+	sessions := []model.Session{}
+	err := mds.db.SelectContext(ctx, &sessions,
+		`SELECT * FROM user_sessions WHERE user_id = ? AND expires_at > ? ORDER BY last_activity_at DESC`,
+		userId, time.Now().Unix())
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return sessions, nil
+}
+
+// CreateSession adds the session, the expired sessions are deleted at the same time
+func (mds *ModelDaoSqlite) CreateSession(ctx context.Context, session *model.Session) *model.ApiError {
+	if _, err := mds.db.ExecContext(ctx, `DELETE FROM user_sessions WHERE expires_at <= ?`, time.Now().Unix()); err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	_, err := mds.db.ExecContext(ctx, `
+	INSERT INTO user_sessions (
+		id,
+		user_id,
+		user_agent,
+		ip_address,
+		created_at,
+		last_activity_at,
+		expires_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		session.Id, session.UserId, session.UserAgent, session.IPAddress,
+		session.CreatedAt, session.LastActivityAt, session.ExpiresAt)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return nil
+}
+
+// UpdateSession updates the client, the activity and the expiry of the session when its tokens are refreshed
+func (mds *ModelDaoSqlite) UpdateSession(ctx context.Context, session *model.Session) *model.ApiError {
+	result, err := mds.db.ExecContext(ctx,
+		`UPDATE user_sessions SET user_agent = ?, ip_address = ?, last_activity_at = ?, expires_at = ? WHERE id = ?`,
+		session.UserAgent, session.IPAddress, session.LastActivityAt, session.ExpiresAt, session.Id)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	if affectedRows == 0 {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no session found with id: %s", session.Id)}
+	}
+	return nil
+}
+
+// TouchSession records the last activity of the session
+func (mds *ModelDaoSqlite) TouchSession(ctx context.Context, id string, lastActivityAt int64) *model.ApiError {
+	_, err := mds.db.ExecContext(ctx, `UPDATE user_sessions SET last_activity_at = ? WHERE id = ?`, lastActivityAt, id)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return nil
+}
+
+func (mds *ModelDaoSqlite) DeleteSession(ctx context.Context, id string) *model.ApiError {
+	result, err := mds.db.ExecContext(ctx, `DELETE FROM user_sessions WHERE id = ?`, id)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	if affectedRows == 0 {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no session found with id: %s", id)}
+	}
+	return nil
+}
+
+func (mds *ModelDaoSqlite) DeleteUserSessions(ctx context.Context, userId string) *model.ApiError {
+	_, err := mds.db.ExecContext(ctx, `DELETE FROM user_sessions WHERE user_id = ?`, userId)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// GetUserTOTP returns the two-factor authentication of the user, nil when the user is not enrolled
+func (mds *ModelDaoSqlite) GetUserTOTP(ctx context.Context, userId string) (*model.UserTOTP, *model.ApiError) {
+	totps := []model.UserTOTP{}
+	err := mds.db.SelectContext(ctx, &totps, `SELECT * FROM user_totp WHERE user_id = ?`, userId)
+	if err != nil {
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	if len(totps) == 0 {
+		return nil, nil
+	}
+	return &totps[0], nil
+}
+
+// SetUserTOTP creates, or replaces, the two-factor authentication of the user
+func (mds *ModelDaoSqlite) SetUserTOTP(ctx context.Context, totp *model.UserTOTP) *model.ApiError {
+	_, err := mds.db.ExecContext(ctx, `
+	INSERT INTO user_totp (
+		user_id,
+		secret,
+		enabled,
+		recovery_codes,
+		last_used_step,
+		created_at,
+		failed_attempts,
+		locked_until
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(user_id) DO UPDATE SET
+		secret = excluded.secret,
+		enabled = excluded.enabled,
+		recovery_codes = excluded.recovery_codes,
+		last_used_step = excluded.last_used_step,
+		created_at = excluded.created_at,
+		failed_attempts = excluded.failed_attempts,
+		locked_until = excluded.locked_until`,
+		totp.UserId, totp.Secret, totp.Enabled, totp.RecoveryCodes, totp.LastUsedStep, totp.CreatedAt, totp.FailedAttempts, totp.LockedUntil)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return nil
+}
+
+func (mds *ModelDaoSqlite) DeleteUserTOTP(ctx context.Context, userId string) *model.ApiError {
+	_, err := mds.db.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = ?`, userId)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	return nil
+}
+
+func (mds *ModelDaoSqlite) SetOrgEnforceTwoFactor(ctx context.Context, orgId string, enforce bool) *model.ApiError {
+	result, err := mds.db.ExecContext(ctx, `UPDATE organizations SET enforce_two_factor = ? WHERE id = ?`, enforce, orgId)
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	if affectedRows == 0 {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("no org found with id: %s", orgId)}
+	}
+	return nil
+}
@@ -7,6 +7,8 @@ var (
 	ErrResourceBytesLimitExceeded = NewResourceLimitError(errors.New("resource bytes limit exceeded, try applying filters such as service.name, etc. to reduce the data size"))
 	// ErrResourceTimeLimitExceeded is returned when the resource time limit is exceeded
 	ErrResourceTimeLimitExceeded = NewResourceLimitError(errors.New("resource time limit exceeded, try applying filters such as service.name, etc. to reduce the data size"))
+	// ErrQueryQueueTimeout is returned when a query waits too long for the queries running concurrently
+	ErrQueryQueueTimeout = NewResourceLimitError(errors.New("too many queries are running concurrently, try again later"))
 )
 
 type ResourceLimitError struct {
@@ -0,0 +1,335 @@
+package alertManager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// receiverRefreshInterval is how long the receivers are cached by the direct notifier
+	receiverRefreshInterval = time.Minute
+	// silenceRefreshInterval is how long the alertmanager silences are cached by the direct notifier
+	silenceRefreshInterval = 15 * time.Second
+	directNotifyTimeout    = 10 * time.Second
+)
+
+// directConfig is a channel config delivered by the query service
+// instead of alertmanager
+type directConfig interface {
+	// destination identifies the chat or webhook the messages are sent to
+	destination() string
+	// rateLimit is the maximum number of messages per minute, 0 disables the limit
+	rateLimit() int
+	send(ctx context.Context, client *http.Client, alerts ...*Alert) error
+}
+
+// hasDirectConfigs returns true if the receiver has configs that are
+// delivered by the query service instead of alertmanager
+func (r *Receiver) hasDirectConfigs() bool {
+	return r.MSTeamsConfigs != nil || r.TelegramConfigs != nil || r.GoogleChatConfigs != nil ||
+		r.PagerDutyEventsConfigs != nil || r.OpsgenieAlertConfigs != nil || r.OnCallConfigs != nil
+}
+
+// alertmanagerReceiver returns the receiver registered with alertmanager,
+// the direct configs are left out so the notifications are not sent twice
+func (r *Receiver) alertmanagerReceiver() *Receiver {
+	res := *r
+	res.MSTeamsConfigs = nil
+	res.TelegramConfigs = nil
+	res.GoogleChatConfigs = nil
+	res.PagerDutyEventsConfigs = nil
+	res.OpsgenieAlertConfigs = nil
+	res.OnCallConfigs = nil
+	return &res
+}
+
+// directConfigs parses and validates the direct configs of the receiver
+func (r *Receiver) directConfigs() ([]directConfig, error) {
+	var configs []directConfig
+
+	teams, err := ParseMSTeamsConfigs(r.MSTeamsConfigs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range teams {
+		configs = append(configs, &teams[i])
+	}
+
+	telegram, err := ParseTelegramConfigs(r.TelegramConfigs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range telegram {
+		configs = append(configs, &telegram[i])
+	}
+
+	googleChat, err := ParseGoogleChatConfigs(r.GoogleChatConfigs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range googleChat {
+		configs = append(configs, &googleChat[i])
+	}
+
+	pagerDuty, err := ParsePagerDutyEventsConfigs(r.PagerDutyEventsConfigs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pagerDuty {
+		configs = append(configs, &pagerDuty[i])
+	}
+
+	opsgenie, err := ParseOpsgenieAlertConfigs(r.OpsgenieAlertConfigs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range opsgenie {
+		configs = append(configs, &opsgenie[i])
+	}
+
+	onCall, err := ParseOnCallConfigs(r.OnCallConfigs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range onCall {
+		configs = append(configs, &onCall[i])
+	}
+
+	return configs, nil
+}
+
+// sendDirect delivers the alerts to the direct configs of the receiver
+func (r *Receiver) sendDirect(ctx context.Context, client *http.Client, alerts ...*Alert) error {
+	configs, err := r.directConfigs()
+	if err != nil {
+		return err
+	}
+	for _, c := range configs {
+		if err := c.send(ctx, client, alerts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterResolved drops the resolved alerts unless they should be sent
+func filterResolved(sendResolved bool, alerts []*Alert) []*Alert {
+	if sendResolved {
+		return alerts
+	}
+	var firing []*Alert
+	for _, a := range alerts {
+		if !a.Resolved() {
+			firing = append(firing, a)
+		}
+	}
+	return firing
+}
+
+// DirectNotifier delivers the notifications of the receivers that are
+// formatted by the query service, such as the MS Teams adaptive cards.
+// A notification is sent when an alert starts firing and when it resolves,
+// failed or rate limited notifications are retried with the next evaluation.
+// The state is kept per config of the receivers so a failing config does not
+// make the others notify again, and the alertmanager silences are honored.
+type DirectNotifier struct {
+	mtx    sync.Mutex
+	lookup func() ([]Receiver, error)
+	client *http.Client
+
+	// configs are the direct configs of the receivers, keyed by receiver name
+	configs  map[string][]directConfig
+	loadedAt time.Time
+
+	silencesMtx      sync.Mutex
+	silencesLookup   func() ([]Silence, error)
+	silences         []Silence
+	silencesLoadedAt time.Time
+
+	// config key -> hash of the alerts notified as firing
+	firing map[string]map[uint64]struct{}
+	// destination -> limiter
+	limiters map[string]*rate.Limiter
+}
+
+func NewDirectNotifier(lookup func() ([]Receiver, error)) *DirectNotifier {
+	n := &DirectNotifier{
+		lookup:   lookup,
+		client:   &http.Client{Timeout: directNotifyTimeout},
+		firing:   map[string]map[uint64]struct{}{},
+		limiters: map[string]*rate.Limiter{},
+	}
+	n.silencesLookup = func() ([]Silence, error) {
+		return fetchSilences(n.client)
+	}
+	return n
+}
+
+// configKey identifies the state of a config of the receiver
+func configKey(name string, c directConfig) string {
+	return name + "/" + c.destination()
+}
+
+func (n *DirectNotifier) refreshReceivers(now time.Time) {
+	if n.configs != nil && now.Sub(n.loadedAt) < receiverRefreshInterval {
+		return
+	}
+	receivers, err := n.lookup()
+	if err != nil {
+		zap.L().Error("failed to load receivers for direct notifications", zap.Error(err))
+		return
+	}
+	n.configs = map[string][]directConfig{}
+	for _, r := range receivers {
+		if !r.hasDirectConfigs() {
+			continue
+		}
+		configs, err := r.directConfigs()
+		if err != nil {
+			zap.L().Error("failed to parse the direct configs of the receiver", zap.String("receiver", r.Name), zap.Error(err))
+			continue
+		}
+		n.configs[r.Name] = configs
+	}
+	n.loadedAt = now
+}
+
+// activeSilences returns the alertmanager silences, the last known silences are
+// used when alertmanager is not available
+func (n *DirectNotifier) activeSilences(now time.Time) []Silence {
+	n.silencesMtx.Lock()
+	defer n.silencesMtx.Unlock()
+	if n.silencesLoadedAt.IsZero() || now.Sub(n.silencesLoadedAt) >= silenceRefreshInterval {
+		silences, err := n.silencesLookup()
+		if err != nil {
+			zap.L().Error("failed to load the alertmanager silences for direct notifications", zap.Error(err))
+		} else {
+			n.silences = silences
+		}
+		n.silencesLoadedAt = now
+	}
+	return n.silences
+}
+
+func silenced(silences []Silence, a *Alert) bool {
+	for i := range silences {
+		if silences[i].Mutes(a.Labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// allow reports whether a message can be sent to the destination
+func (n *DirectNotifier) allow(c directConfig) bool {
+	if c.rateLimit() <= 0 {
+		return true
+	}
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	limiter, ok := n.limiters[c.destination()]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(c.rateLimit())), c.rateLimit())
+		n.limiters[c.destination()] = limiter
+	}
+	return limiter.Allow()
+}
+
+// directBatch are the alerts notified to a config of a receiver
+type directBatch struct {
+	receiver string
+	config   directConfig
+	alerts   []*Alert
+}
+
+// Notify sends the alerts whose state changed since the last notification
+func (n *DirectNotifier) Notify(ctx context.Context, alerts ...*Alert) {
+	now := time.Now()
+	silences := n.activeSilences(now)
+	batches := map[string]*directBatch{}
+
+	n.mtx.Lock()
+	n.refreshReceivers(now)
+	for _, a := range alerts {
+		// the silenced alerts are notified when the silence expires, the resolution of
+		// the alerts already notified is sent so the channels do not keep them firing
+		if !a.ResolvedAt(now) && silenced(silences, a) {
+			continue
+		}
+		// the rules address the alerts without preferred channels to the channels of their
+		// org, the alerts without receivers are not sent
+		for _, name := range a.Receivers {
+			for _, c := range n.configs[name] {
+				key := configKey(name, c)
+				if !n.markNotified(key, a, now) {
+					continue
+				}
+				if _, ok := batches[key]; !ok {
+					batches[key] = &directBatch{receiver: name, config: c}
+				}
+				batches[key].alerts = append(batches[key].alerts, a)
+			}
+		}
+	}
+	n.mtx.Unlock()
+
+	for key, batch := range batches {
+		go func(key string, batch *directBatch) {
+			ctx, cancel := context.WithTimeout(context.Background(), directNotifyTimeout)
+			defer cancel()
+			if err := n.send(ctx, batch.config, batch.alerts...); err != nil {
+				zap.L().Error("failed to send notification", zap.String("receiver", batch.receiver), zap.Error(err))
+				n.revert(key, now, batch.alerts...)
+			}
+		}(key, batch)
+	}
+}
+
+// markNotified records the state of the alert for the config and returns
+// true if the state changed since the last notification
+func (n *DirectNotifier) markNotified(key string, a *Alert, now time.Time) bool {
+	if _, ok := n.firing[key]; !ok {
+		n.firing[key] = map[uint64]struct{}{}
+	}
+	_, notified := n.firing[key][a.Hash()]
+	if a.ResolvedAt(now) {
+		if !notified {
+			return false
+		}
+		delete(n.firing[key], a.Hash())
+		return true
+	}
+	if notified {
+		return false
+	}
+	n.firing[key][a.Hash()] = struct{}{}
+	return true
+}
+
+// revert restores the state of the alerts that could not be notified to the config
+func (n *DirectNotifier) revert(key string, now time.Time, alerts ...*Alert) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	for _, a := range alerts {
+		if a.ResolvedAt(now) {
+			n.firing[key][a.Hash()] = struct{}{}
+		} else {
+			delete(n.firing[key], a.Hash())
+		}
+	}
+}
+
+func (n *DirectNotifier) send(ctx context.Context, c directConfig, alerts ...*Alert) error {
+	if !n.allow(c) {
+		// the destination may hold credentials, do not leak it in the logs
+		return fmt.Errorf("rate limit of %d messages per minute exceeded", c.rateLimit())
+	}
+	return c.send(ctx, n.client, alerts...)
+}
@@ -0,0 +1,214 @@
+package alertManager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestRenderMessage(t *testing.T) {
+	alert := &Alert{
+		Labels:      labels.FromMap(map[string]string{labels.AlertNameLabel: "High latency", "service": "frontend"}),
+		Annotations: labels.FromMap(map[string]string{labels.AlertSummaryLabel: "p99 latency is above 1s"}),
+		StartsAt:    time.Now(),
+		EndsAt:      time.Now().Add(time.Hour),
+	}
+
+	text, err := RenderMessage("", alert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[FIRING:1] High latency\n\np99 latency is above 1s\n- service: frontend"
+	if text != expected {
+		t.Errorf("expected %q, got %q", expected, text)
+	}
+
+	text, err = RenderMessage(`{{ .AlertName }} is {{ .Status }} for {{ range .Alerts }}{{ .Labels.service }}{{ end }}`, alert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "High latency is firing for frontend" {
+		t.Errorf("unexpected custom message %q", text)
+	}
+
+	if _, err := ParseGoogleChatConfigs([]GoogleChatConfig{{WebhookURL: "http://chat", Message: "{{ .Status"}}); err == nil {
+		t.Errorf("expected invalid template to fail validation")
+	}
+}
+
+func TestDirectNotifierRateLimit(t *testing.T) {
+	var mtx sync.Mutex
+	var texts []string
+	done := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]string
+		json.NewDecoder(r.Body).Decode(&msg)
+		mtx.Lock()
+		texts = append(texts, msg["text"])
+		mtx.Unlock()
+		done <- struct{}{}
+	}))
+	defer server.Close()
+
+	n := NewDirectNotifier(func() ([]Receiver, error) {
+		return []Receiver{
+			{Name: "chat", GoogleChatConfigs: []GoogleChatConfig{{WebhookURL: server.URL, RateLimit: 1}}},
+		}, nil
+	})
+	n.silencesLookup = noSilences
+
+	first := &Alert{Labels: labels.FromMap(map[string]string{labels.AlertNameLabel: "first"}), EndsAt: time.Now().Add(time.Hour), Receivers: []string{"chat"}}
+	second := &Alert{Labels: labels.FromMap(map[string]string{labels.AlertNameLabel: "second"}), EndsAt: time.Now().Add(time.Hour), Receivers: []string{"chat"}}
+
+	n.Notify(context.Background(), first)
+	<-done
+	n.Notify(context.Background(), second)
+
+	// the rate limited alert is not recorded as notified and is retried
+	time.Sleep(100 * time.Millisecond)
+	n.mtx.Lock()
+	_, notified := n.firing["chat/"+server.URL][second.Hash()]
+	n.mtx.Unlock()
+	if notified {
+		t.Errorf("expected rate limited alert to be retried")
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(texts) != 1 || texts[0] != "[FIRING:1] first" {
+		t.Errorf("unexpected notifications %v", texts)
+	}
+}
+
+func noSilences() ([]Silence, error) {
+	return nil, nil
+}
+
+func TestDirectNotifierPerConfig(t *testing.T) {
+	var mtx sync.Mutex
+	received := map[string]int{}
+	failing := true
+	done := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		defer func() { done <- struct{}{} }()
+		if r.URL.Path == "/failing" && failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		received[r.URL.Path]++
+	}))
+	defer server.Close()
+
+	n := NewDirectNotifier(func() ([]Receiver, error) {
+		return []Receiver{
+			{Name: "chat", GoogleChatConfigs: []GoogleChatConfig{{WebhookURL: server.URL + "/ok"}, {WebhookURL: server.URL + "/failing"}}},
+		}, nil
+	})
+	n.silencesLookup = noSilences
+
+	alert := &Alert{Labels: labels.FromMap(map[string]string{labels.AlertNameLabel: "latency"}), EndsAt: time.Now().Add(time.Hour), Receivers: []string{"chat"}}
+	n.Notify(context.Background(), alert)
+	<-done
+	<-done
+	time.Sleep(100 * time.Millisecond)
+
+	// only the failing config is retried
+	mtx.Lock()
+	failing = false
+	mtx.Unlock()
+	n.Notify(context.Background(), alert)
+	<-done
+	time.Sleep(100 * time.Millisecond)
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if received["/ok"] != 1 || received["/failing"] != 1 {
+		t.Errorf("unexpected notifications %v", received)
+	}
+}
+
+func TestDirectNotifierSilences(t *testing.T) {
+	var mtx sync.Mutex
+	var texts []string
+	done := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]string
+		json.NewDecoder(r.Body).Decode(&msg)
+		mtx.Lock()
+		texts = append(texts, msg["text"])
+		mtx.Unlock()
+		done <- struct{}{}
+	}))
+	defer server.Close()
+
+	n := NewDirectNotifier(func() ([]Receiver, error) {
+		return []Receiver{
+			{Name: "chat", GoogleChatConfigs: []GoogleChatConfig{{WebhookURL: server.URL}}},
+		}, nil
+	})
+	notEqual := false
+	silences := []Silence{{Id: "maintenance", Matchers: []SilenceMatcher{
+		{Name: "service", Value: "front.*", IsRegex: true},
+		{Name: "env", Value: "staging", IsEqual: &notEqual},
+	}}}
+	silences[0].Status.State = "active"
+	n.silencesLookup = func() ([]Silence, error) {
+		return silences, nil
+	}
+
+	muted := &Alert{Labels: labels.FromMap(map[string]string{labels.AlertNameLabel: "muted", "service": "frontend", "env": "prod"}), EndsAt: time.Now().Add(time.Hour), Receivers: []string{"chat"}}
+	staging := &Alert{Labels: labels.FromMap(map[string]string{labels.AlertNameLabel: "staging", "service": "frontend", "env": "staging"}), EndsAt: time.Now().Add(time.Hour), Receivers: []string{"chat"}}
+	n.Notify(context.Background(), muted, staging)
+	<-done
+	time.Sleep(100 * time.Millisecond)
+
+	// the muted alert is notified once the silence expires
+	silences[0].Status.State = "expired"
+	n.silencesLoadedAt = time.Time{}
+	n.Notify(context.Background(), muted, staging)
+	<-done
+	time.Sleep(100 * time.Millisecond)
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(texts) != 2 || !strings.HasPrefix(texts[0], "[FIRING:1] staging") || !strings.HasPrefix(texts[1], "[FIRING:1] muted") {
+		t.Errorf("unexpected notifications %v", texts)
+	}
+}
+
+func TestPreviewMessage(t *testing.T) {
+	resp, err := PreviewMessage(&TemplatePreviewRequest{
+		Template:     `{{ range .Alerts }}{{ .Labels.service }} latency is {{ printf "%.2f" .Value }}s, see {{ .GeneratorURL }}{{ end }}`,
+		Labels:       map[string]string{labels.AlertNameLabel: "High latency", "service": "frontend"},
+		Value:        1.234,
+		GeneratorURL: "http://localhost:3301/alerts/overview?ruleId=1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != "frontend latency is 1.23s, see http://localhost:3301/alerts/overview?ruleId=1" {
+		t.Errorf("unexpected message %q", resp.Message)
+	}
+
+	// the rendered rule message replaces the summary in the default format
+	resp, err = PreviewMessage(&TemplatePreviewRequest{
+		Labels:      map[string]string{labels.AlertNameLabel: "High latency"},
+		Annotations: map[string]string{labels.AlertMessageLabel: "custom message", labels.AlertSummaryLabel: "summary"},
+		Resolved:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != "[RESOLVED] High latency\n\ncustom message" {
+		t.Errorf("unexpected message %q", resp.Message)
+	}
+}
@@ -0,0 +1,91 @@
+package alertManager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// google chat allows one message per second in a space
+const defaultGoogleChatRateLimit = 60
+
+// GoogleChatConfig configures the delivery of alerts to a google chat space webhook
+type GoogleChatConfig struct {
+	SendResolved bool   `yaml:"send_resolved" json:"send_resolved"`
+	WebhookURL   string `yaml:"webhook_url" json:"webhook_url"`
+	// Message is a go template executed with the notified alerts
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+	// RateLimit is the maximum number of messages sent per minute
+	RateLimit int `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"`
+}
+
+// ParseGoogleChatConfigs reads the google chat configs of a receiver
+func ParseGoogleChatConfigs(configs interface{}) ([]GoogleChatConfig, error) {
+	if configs == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return nil, err
+	}
+	var res []GoogleChatConfig
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("invalid google chat config: %w", err)
+	}
+	for _, c := range res {
+		if c.WebhookURL == "" {
+			return nil, fmt.Errorf("invalid google chat config: webhook_url is required")
+		}
+		if _, err := parseMessageTemplate(c.Message); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (cfg *GoogleChatConfig) destination() string {
+	return cfg.WebhookURL
+}
+
+func (cfg *GoogleChatConfig) rateLimit() int {
+	if cfg.RateLimit > 0 {
+		return cfg.RateLimit
+	}
+	return defaultGoogleChatRateLimit
+}
+
+func (cfg *GoogleChatConfig) send(ctx context.Context, client *http.Client, alerts ...*Alert) error {
+	alerts = filterResolved(cfg.SendResolved, alerts)
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	text, err := RenderMessage(cfg.Message, alerts...)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.WebhookURL, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", contentType)
+
+	response, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode > 299 {
+		return fmt.Errorf("google chat webhook returned %s", response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package alertManager
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+var (
+	ErrInvalidIncidentKey      = errors.New("invalid incident key")
+	ErrInvalidCallbackSecret   = errors.New("invalid callback signature or token")
+	ErrMissingIncidentCallback = errors.New("the channel has no incident callback configured")
+)
+
+const incidentKeyPrefix = "signoz"
+
+// Actions of the incident callbacks
+const (
+	IncidentAcknowledged = "acknowledged"
+	IncidentResolved     = "resolved"
+)
+
+// IncidentKey returns the key the alert is sent to the incident management tools with, the
+// PagerDuty dedup key or the Opsgenie alias. The callbacks of the tools are matched to the
+// alert with it
+func IncidentKey(a *Alert) string {
+	return fmt.Sprintf("%s-%s-%016x", incidentKeyPrefix, a.Labels.Get(labels.AlertRuleIdLabel), a.Hash())
+}
+
+// ParseIncidentKey returns the rule id and the hash of the labels of the alert of the key
+func ParseIncidentKey(key string) (ruleId string, hash uint64, err error) {
+	parts := strings.Split(key, "-")
+	if len(parts) != 3 || parts[0] != incidentKeyPrefix || parts[1] == "" {
+		return "", 0, ErrInvalidIncidentKey
+	}
+	hash, err = strconv.ParseUint(parts[2], 16, 64)
+	if err != nil {
+		return "", 0, ErrInvalidIncidentKey
+	}
+	return parts[1], hash, nil
+}
+
+// IncidentUpdate is a change of an incident, the acknowledgement or the resolution, sent
+// back by the incident management tool
+type IncidentUpdate struct {
+	Key    string
+	Action string
+	// By is the user who changed the incident in the tool
+	By string
+}
+
+// verifyPagerDutySignature checks the X-PagerDuty-Signature header of a webhook, it holds
+// the signatures of the body with each of the secrets of the webhook subscription
+func verifyPagerDutySignature(secret, header string, body []byte) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := "v1=" + hex.EncodeToString(mac.Sum(nil))
+	for _, signature := range strings.Split(header, ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(signature)), []byte(expected)) {
+			return true
+		}
+	}
+	return false
+}
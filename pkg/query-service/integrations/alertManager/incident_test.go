@@ -0,0 +1,114 @@
+package alertManager
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func incidentAlert(resolved bool) *Alert {
+	a := &Alert{
+		Labels:      labels.FromMap(map[string]string{labels.AlertNameLabel: "High latency", labels.AlertRuleIdLabel: "42", "severity": "warning"}),
+		Annotations: labels.FromMap(map[string]string{labels.AlertSummaryLabel: "p99 latency is above 1s"}),
+		StartsAt:    time.Now().Add(-time.Minute),
+		EndsAt:      time.Now().Add(time.Hour),
+	}
+	if resolved {
+		a.EndsAt = time.Now().Add(-time.Second)
+	}
+	return a
+}
+
+func TestIncidentKey(t *testing.T) {
+	a := incidentAlert(false)
+	ruleId, hash, err := ParseIncidentKey(IncidentKey(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ruleId != "42" || hash != a.Hash() {
+		t.Errorf("unexpected rule id %s and hash %x", ruleId, hash)
+	}
+	for _, key := range []string{"", "other-42-1", "signoz--1", "signoz-42-xyz"} {
+		if _, _, err := ParseIncidentKey(key); err == nil {
+			t.Errorf("expected key %q to be invalid", key)
+		}
+	}
+}
+
+func TestPagerDutyEventsSend(t *testing.T) {
+	var events []pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	cfg := PagerDutyEventsConfig{URL: server.URL, RoutingKey: "key", SendResolved: true}
+	if err := cfg.send(context.Background(), server.Client(), incidentAlert(false), incidentAlert(true)); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].EventAction != "trigger" || events[0].Payload.Severity != "warning" || events[0].Payload.Summary != "High latency: p99 latency is above 1s" {
+		t.Errorf("unexpected trigger event %+v %+v", events[0], events[0].Payload)
+	}
+	if events[1].EventAction != "resolve" || events[1].DedupKey != events[0].DedupKey {
+		t.Errorf("expected the resolve event to have the dedup key of the trigger event, got %+v", events[1])
+	}
+}
+
+func TestPagerDutyIncidentUpdate(t *testing.T) {
+	receiver := Receiver{PagerDutyEventsConfigs: []PagerDutyEventsConfig{{RoutingKey: "key", WebhookSecret: "secret"}}}
+	key := IncidentKey(incidentAlert(false))
+	body := []byte(`{"event":{"event_type":"incident.acknowledged","agent":{"summary":"Jane"},"data":{"incident_key":"` + key + `"}}}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	signature := "v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	update, err := receiver.PagerDutyIncidentUpdate("v1=other,"+signature, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update == nil || update.Action != IncidentAcknowledged || update.Key != key || update.By != "pagerduty: Jane" {
+		t.Errorf("unexpected update %+v", update)
+	}
+
+	if _, err := receiver.PagerDutyIncidentUpdate("v1=other", body); err != ErrInvalidCallbackSecret {
+		t.Errorf("expected invalid signature to be rejected, got %v", err)
+	}
+	if _, err := (&Receiver{}).PagerDutyIncidentUpdate(signature, body); err != ErrMissingIncidentCallback {
+		t.Errorf("expected callback without secret to be rejected, got %v", err)
+	}
+}
+
+func TestOpsgenieIncidentUpdate(t *testing.T) {
+	receiver := Receiver{OpsgenieAlertConfigs: []OpsgenieAlertConfig{{APIKey: "key", CallbackToken: "token"}}}
+	key := IncidentKey(incidentAlert(false))
+
+	update, err := receiver.OpsgenieIncidentUpdate("token", []byte(`{"action":"Close","alert":{"alias":"`+key+`","username":"jane@example.com"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update == nil || update.Action != IncidentResolved || update.Key != key {
+		t.Errorf("unexpected update %+v", update)
+	}
+
+	update, err = receiver.OpsgenieIncidentUpdate("token", []byte(`{"action":"AddNote","alert":{"alias":"`+key+`"}}`))
+	if err != nil || update != nil {
+		t.Errorf("expected other actions to be ignored, got %+v %v", update, err)
+	}
+	if _, err := receiver.OpsgenieIncidentUpdate("other", []byte(`{}`)); err != ErrInvalidCallbackSecret {
+		t.Errorf("expected invalid token to be rejected, got %v", err)
+	}
+}
@@ -3,18 +3,24 @@ package alertManager
 // Wrapper to connect and process alert manager functions
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	neturl "net/url"
+	"time"
 
 	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 	"go.uber.org/zap"
 )
 
 const contentType = "application/json"
 
+// requestTimeout bounds the API calls to alertmanager
+const requestTimeout = 10 * time.Second
+
 type Manager interface {
 	URL() *neturl.URL
 	URLPath(path string) *neturl.URL
@@ -38,12 +44,14 @@ func New(url string) (Manager, error) {
 	return &manager{
 		url:       url,
 		parsedURL: urlParsed,
+		client:    &http.Client{Timeout: requestTimeout},
 	}, nil
 }
 
 type manager struct {
 	url       string
 	parsedURL *neturl.URL
+	client    *http.Client
 }
 
 func prepareAmChannelApiURL() string {
@@ -77,10 +85,14 @@ func (m *manager) URLPath(path string) *neturl.URL {
 
 func (m *manager) AddRoute(receiver *Receiver) *model.ApiError {
 
-	receiverString, _ := json.Marshal(receiver)
+	if _, err := receiver.directConfigs(); err != nil {
+		return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+
+	receiverString, _ := json.Marshal(receiver.alertmanagerReceiver())
 
 	amURL := prepareAmChannelApiURL()
-	response, err := http.Post(amURL, contentType, bytes.NewBuffer(receiverString))
+	response, err := m.client.Post(amURL, contentType, bytes.NewBuffer(receiverString))
 
 	if err != nil {
 		zap.L().Error("Error in getting response of API call to alertmanager", zap.String("url", amURL), zap.Error(err))
@@ -88,6 +100,7 @@ func (m *manager) AddRoute(receiver *Receiver) *model.ApiError {
 	}
 
 	if response.StatusCode > 299 {
+		err := fmt.Errorf("error in getting 2xx response in API call to alertmanager(POST %s): %s", amURL, response.Status)
 		zap.L().Error("Error in getting 2xx response in API call to alertmanager", zap.String("url", amURL), zap.String("status", response.Status))
 		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
 	}
@@ -95,7 +108,11 @@ func (m *manager) AddRoute(receiver *Receiver) *model.ApiError {
 }
 
 func (m *manager) EditRoute(receiver *Receiver) *model.ApiError {
-	receiverString, _ := json.Marshal(receiver)
+	if _, err := receiver.directConfigs(); err != nil {
+		return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+
+	receiverString, _ := json.Marshal(receiver.alertmanagerReceiver())
 
 	amURL := prepareAmChannelApiURL()
 	req, err := http.NewRequest(http.MethodPut, amURL, bytes.NewBuffer(receiverString))
@@ -107,8 +124,7 @@ func (m *manager) EditRoute(receiver *Receiver) *model.ApiError {
 
 	req.Header.Add("Content-Type", contentType)
 
-	client := &http.Client{}
-	response, err := client.Do(req)
+	response, err := m.client.Do(req)
 
 	if err != nil {
 		zap.L().Error("Error in getting response of API call to alertmanager", zap.String("url", amURL), zap.Error(err))
@@ -116,6 +132,7 @@ func (m *manager) EditRoute(receiver *Receiver) *model.ApiError {
 	}
 
 	if response.StatusCode > 299 {
+		err := fmt.Errorf("error in getting 2xx response in PUT API call to alertmanager(PUT %s): %s", amURL, response.Status)
 		zap.L().Error("Error in getting 2xx response in PUT API call to alertmanager", zap.String("url", amURL), zap.String("status", response.Status))
 		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
 	}
@@ -136,8 +153,7 @@ func (m *manager) DeleteRoute(name string) *model.ApiError {
 
 	req.Header.Add("Content-Type", contentType)
 
-	client := &http.Client{}
-	response, err := client.Do(req)
+	response, err := m.client.Do(req)
 
 	if err != nil {
 		zap.L().Error("Error in getting response of API call to alertmanager", zap.String("url", amURL), zap.Error(err))
@@ -154,10 +170,21 @@ func (m *manager) DeleteRoute(name string) *model.ApiError {
 
 func (m *manager) TestReceiver(receiver *Receiver) *model.ApiError {
 
+	// the direct configs are not known to alertmanager, send the test alert from here
+	if receiver.hasDirectConfigs() {
+		ctx, cancel := context.WithTimeout(context.Background(), directNotifyTimeout)
+		defer cancel()
+		if err := receiver.sendDirect(ctx, &http.Client{Timeout: directNotifyTimeout}, testAlert()); err != nil {
+			zap.L().Error("Error in sending test alert", zap.String("receiver", receiver.Name), zap.Error(err))
+			return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		}
+		return nil
+	}
+
 	receiverBytes, _ := json.Marshal(receiver)
 
 	amTestURL := prepareTestApiURL()
-	response, err := http.Post(amTestURL, contentType, bytes.NewBuffer(receiverBytes))
+	response, err := m.client.Post(amTestURL, contentType, bytes.NewBuffer(receiverBytes))
 
 	if err != nil {
 		zap.L().Error("Error in getting response of API call to alertmanager", zap.String("url", amTestURL), zap.Error(err))
@@ -178,3 +205,19 @@ func (m *manager) TestReceiver(receiver *Receiver) *model.ApiError {
 
 	return nil
 }
+
+// testAlert is the alert sent when testing a receiver
+func testAlert() *Alert {
+	return &Alert{
+		Labels: labels.FromMap(map[string]string{
+			labels.AlertNameLabel: "Test Alert",
+			"severity":            "critical",
+		}),
+		Annotations: labels.FromMap(map[string]string{
+			labels.AlertSummaryLabel:     "Test Alert",
+			labels.AlertDescriptionLabel: "This is a test alert sent from SigNoz to verify the notification channel.",
+		}),
+		StartsAt: time.Now(),
+		EndsAt:   time.Now().Add(time.Hour),
+	}
+}
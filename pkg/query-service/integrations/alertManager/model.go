@@ -22,6 +22,21 @@ type Receiver struct {
 	VictorOpsConfigs interface{} `yaml:"victorops_configs,omitempty" json:"victorops_configs,omitempty"`
 	SNSConfigs       interface{} `yaml:"sns_configs,omitempty" json:"sns_configs,omitempty"`
 	MSTeamsConfigs   interface{} `yaml:"msteams_configs,omitempty" json:"msteams_configs,omitempty"`
+
+	TelegramConfigs   interface{} `yaml:"telegram_configs,omitempty" json:"telegram_configs,omitempty"`
+	GoogleChatConfigs interface{} `yaml:"googlechat_configs,omitempty" json:"googlechat_configs,omitempty"`
+
+	// PagerDutyEventsConfigs and OpsgenieAlertConfigs are delivered by the query service,
+	// the acknowledgements and resolutions of the incidents are sent back to the channel
+	PagerDutyEventsConfigs interface{} `yaml:"pagerduty_events_configs,omitempty" json:"pagerduty_events_configs,omitempty"`
+	OpsgenieAlertConfigs   interface{} `yaml:"opsgenie_alert_configs,omitempty" json:"opsgenie_alert_configs,omitempty"`
+
+	// OnCallConfigs email the members of a team on call
+	OnCallConfigs interface{} `yaml:"oncall_configs,omitempty" json:"oncall_configs,omitempty"`
+
+	// Route is the route of the receiver with its grouping options, it is only set on the
+	// receivers generated for the rules
+	Route *Route `yaml:"route,omitempty" json:"route,omitempty"`
 }
 
 type ReceiverResponse struct {
@@ -44,6 +59,10 @@ type Alert struct {
 	GeneratorURL string    `json:"generatorURL,omitempty"`
 
 	Receivers []string `json:"receivers,omitempty"`
+
+	// Value is the query value of the alert, it is only used to render
+	// the message templates and is not sent to alertmanager
+	Value float64 `json:"-"`
 }
 
 // Name returns the name of the alert. It is equivalent to the "alertname" label.
@@ -0,0 +1,229 @@
+package alertManager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+const (
+	adaptiveCardSchema      = "http://adaptivecards.io/schemas/adaptive-card.json"
+	adaptiveCardVersion     = "1.4"
+	adaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+)
+
+// MSTeamsConfig configures the delivery of alerts to a MS Teams
+// incoming webhook (or workflow) as adaptive cards
+type MSTeamsConfig struct {
+	SendResolved bool   `yaml:"send_resolved" json:"send_resolved"`
+	WebhookURL   string `yaml:"webhook_url" json:"webhook_url"`
+	// Title overrides the card title, defaults to the alert name and status
+	Title string `yaml:"title,omitempty" json:"title,omitempty"`
+	// Text is a go template shown below the title of the card
+	Text string `yaml:"text,omitempty" json:"text,omitempty"`
+}
+
+// ParseMSTeamsConfigs reads the msteams configs of a receiver
+func ParseMSTeamsConfigs(configs interface{}) ([]MSTeamsConfig, error) {
+	if configs == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return nil, err
+	}
+	var res []MSTeamsConfig
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("invalid msteams config: %w", err)
+	}
+	for _, c := range res {
+		if c.WebhookURL == "" {
+			return nil, fmt.Errorf("invalid msteams config: webhook_url is required")
+		}
+		if c.Text != "" {
+			if err := ValidateMessageTemplate(c.Text); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+type MSTeamsMessage struct {
+	Type        string              `json:"type"`
+	Attachments []MSTeamsAttachment `json:"attachments"`
+}
+
+type MSTeamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	Content     AdaptiveCard `json:"content"`
+}
+
+type AdaptiveCard struct {
+	Schema  string                 `json:"$schema"`
+	Type    string                 `json:"type"`
+	Version string                 `json:"version"`
+	Body    []interface{}          `json:"body"`
+	Actions []AdaptiveCardAction   `json:"actions,omitempty"`
+	MSTeams map[string]interface{} `json:"msteams,omitempty"`
+}
+
+type AdaptiveCardTextBlock struct {
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Weight string `json:"weight,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Color  string `json:"color,omitempty"`
+	Wrap   bool   `json:"wrap"`
+}
+
+type AdaptiveCardFactSet struct {
+	Type  string             `json:"type"`
+	Facts []AdaptiveCardFact `json:"facts"`
+}
+
+type AdaptiveCardFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type AdaptiveCardAction struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// NewMSTeamsMessage formats the alerts as an adaptive card, each alert is
+// rendered with its summary, description and labels
+func NewMSTeamsMessage(cfg *MSTeamsConfig, alerts ...*Alert) *MSTeamsMessage {
+	firing := 0
+	for _, a := range alerts {
+		if !a.Resolved() {
+			firing++
+		}
+	}
+
+	status, color := "FIRING", "attention"
+	if firing == 0 {
+		status, color = "RESOLVED", "good"
+	}
+
+	title := cfg.Title
+	if title == "" && len(alerts) > 0 {
+		title = fmt.Sprintf("[%s:%d] %s", status, len(alerts), alerts[0].Name())
+	}
+
+	body := []interface{}{
+		AdaptiveCardTextBlock{Type: "TextBlock", Text: title, Weight: "Bolder", Size: "Large", Color: color, Wrap: true},
+	}
+	if cfg.Text != "" {
+		text, err := RenderMessage(cfg.Text, alerts...)
+		if err != nil {
+			text = fmt.Sprintf("<error expanding template: %s>", err)
+		}
+		body = append(body, AdaptiveCardTextBlock{Type: "TextBlock", Text: text, Wrap: true})
+	}
+
+	var actions []AdaptiveCardAction
+	for _, a := range alerts {
+		if a.Annotations != nil {
+			if message := a.Annotations.Get(labels.AlertMessageLabel); message != "" {
+				body = append(body, AdaptiveCardTextBlock{Type: "TextBlock", Text: message, Wrap: true})
+			}
+			if summary := a.Annotations.Get(labels.AlertSummaryLabel); summary != "" {
+				body = append(body, AdaptiveCardTextBlock{Type: "TextBlock", Text: summary, Weight: "Bolder", Wrap: true})
+			}
+			if description := a.Annotations.Get(labels.AlertDescriptionLabel); description != "" {
+				body = append(body, AdaptiveCardTextBlock{Type: "TextBlock", Text: description, Wrap: true})
+			}
+		}
+		body = append(body, AdaptiveCardFactSet{Type: "FactSet", Facts: labelFacts(a.Labels)})
+		if a.GeneratorURL != "" && len(actions) == 0 {
+			actions = append(actions, AdaptiveCardAction{Type: "Action.OpenUrl", Title: "View in SigNoz", URL: a.GeneratorURL})
+		}
+	}
+
+	return &MSTeamsMessage{
+		Type: "message",
+		Attachments: []MSTeamsAttachment{
+			{
+				ContentType: adaptiveCardContentType,
+				Content: AdaptiveCard{
+					Schema:  adaptiveCardSchema,
+					Type:    "AdaptiveCard",
+					Version: adaptiveCardVersion,
+					Body:    body,
+					Actions: actions,
+					MSTeams: map[string]interface{}{"width": "Full"},
+				},
+			},
+		},
+	}
+}
+
+func labelFacts(lbls labels.BaseLabels) []AdaptiveCardFact {
+	if lbls == nil {
+		return []AdaptiveCardFact{}
+	}
+	m := lbls.Map()
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		// internal labels are not useful in the card
+		if strings.HasPrefix(k, "__") {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	facts := make([]AdaptiveCardFact, 0, len(keys))
+	for _, k := range keys {
+		facts = append(facts, AdaptiveCardFact{Title: k, Value: m[k]})
+	}
+	return facts
+}
+
+func (cfg *MSTeamsConfig) destination() string {
+	return cfg.WebhookURL
+}
+
+// rateLimit is not enforced for teams, the cards are already grouped per rule
+func (cfg *MSTeamsConfig) rateLimit() int {
+	return 0
+}
+
+// send posts the adaptive card of the alerts to the webhook
+func (cfg *MSTeamsConfig) send(ctx context.Context, client *http.Client, alerts ...*Alert) error {
+	alerts = filterResolved(cfg.SendResolved, alerts)
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(NewMSTeamsMessage(cfg, alerts...))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.WebhookURL, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", contentType)
+
+	response, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode > 299 {
+		return fmt.Errorf("msteams webhook returned %s", response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package alertManager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestNewMSTeamsMessage(t *testing.T) {
+	alert := &Alert{
+		Labels:       labels.FromMap(map[string]string{labels.AlertNameLabel: "High latency", "service": "frontend", "__name__": "latency"}),
+		Annotations:  labels.FromMap(map[string]string{labels.AlertSummaryLabel: "p99 latency is above 1s"}),
+		StartsAt:     time.Now(),
+		EndsAt:       time.Now().Add(time.Hour),
+		GeneratorURL: "http://localhost:3301/alerts/edit?ruleId=1",
+	}
+
+	msg := NewMSTeamsMessage(&MSTeamsConfig{}, alert)
+	if len(msg.Attachments) != 1 || msg.Attachments[0].ContentType != adaptiveCardContentType {
+		t.Fatalf("expected one adaptive card attachment, got %+v", msg.Attachments)
+	}
+
+	card := msg.Attachments[0].Content
+	title := card.Body[0].(AdaptiveCardTextBlock)
+	if title.Text != "[FIRING:1] High latency" || title.Color != "attention" {
+		t.Errorf("unexpected title %+v", title)
+	}
+	facts := card.Body[2].(AdaptiveCardFactSet).Facts
+	if len(facts) != 2 || facts[0].Title != "alertname" || facts[1].Title != "service" {
+		t.Errorf("unexpected facts %+v", facts)
+	}
+	if len(card.Actions) != 1 || card.Actions[0].URL != alert.GeneratorURL {
+		t.Errorf("unexpected actions %+v", card.Actions)
+	}
+}
+
+func TestDirectNotifierStateChanges(t *testing.T) {
+	var mtx sync.Mutex
+	var received []MSTeamsMessage
+	done := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg MSTeamsMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		mtx.Lock()
+		received = append(received, msg)
+		mtx.Unlock()
+		done <- struct{}{}
+	}))
+	defer server.Close()
+
+	n := NewDirectNotifier(func() ([]Receiver, error) {
+		return []Receiver{
+			{Name: "teams", MSTeamsConfigs: []MSTeamsConfig{{WebhookURL: server.URL, SendResolved: true}}},
+			{Name: "slack", SlackConfigs: []interface{}{}},
+		}, nil
+	})
+
+	alert := &Alert{
+		Labels:    labels.FromMap(map[string]string{labels.AlertNameLabel: "High latency"}),
+		StartsAt:  time.Now(),
+		EndsAt:    time.Now().Add(time.Hour),
+		Receivers: []string{"teams", "slack"},
+	}
+
+	n.Notify(context.Background(), alert)
+	<-done
+	// still firing, no new notification
+	n.Notify(context.Background(), alert)
+
+	resolved := *alert
+	resolved.EndsAt = time.Now().Add(-time.Minute)
+	n.Notify(context.Background(), &resolved)
+	<-done
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("expected a firing and a resolved notification, got %d", len(received))
+	}
+	if title := received[1].Attachments[0].Content.Body[0].(map[string]interface{})["text"]; title != "[RESOLVED:1] High latency" {
+		t.Errorf("unexpected resolved title %v", title)
+	}
+}
@@ -0,0 +1,121 @@
+package alertManager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	smtpservice "go.signoz.io/signoz/pkg/query-service/utils/smtpService"
+)
+
+// a flapping rule emails the members on call 20 times a minute at most
+const defaultOnCallRateLimit = 20
+
+// OnCallLookup returns the emails of the members of the team on call at t
+type OnCallLookup func(ctx context.Context, teamId string, t time.Time) ([]string, error)
+
+var (
+	onCallMtx    sync.RWMutex
+	onCallLookup OnCallLookup
+)
+
+// SetOnCallLookup registers how the on-call configs find the members on call, the query
+// service registers the on-call schedules of the teams
+func SetOnCallLookup(lookup OnCallLookup) {
+	onCallMtx.Lock()
+	defer onCallMtx.Unlock()
+	onCallLookup = lookup
+}
+
+func getOnCallLookup() OnCallLookup {
+	onCallMtx.RLock()
+	defer onCallMtx.RUnlock()
+	return onCallLookup
+}
+
+// OnCallConfig configures the delivery of alerts by email to the members of a team on call
+// when the alerts are notified, following the on-call schedules of the team
+type OnCallConfig struct {
+	SendResolved bool   `yaml:"send_resolved" json:"send_resolved"`
+	TeamId       string `yaml:"team_id" json:"team_id"`
+	// Message is a go template executed with the notified alerts, its first line is the
+	// subject of the email
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+	// RateLimit is the maximum number of emails sent per minute
+	RateLimit int `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"`
+}
+
+// ParseOnCallConfigs reads the on-call configs of a receiver
+func ParseOnCallConfigs(configs interface{}) ([]OnCallConfig, error) {
+	if configs == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return nil, err
+	}
+	var res []OnCallConfig
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("invalid on-call config: %w", err)
+	}
+	for _, c := range res {
+		if c.TeamId == "" {
+			return nil, fmt.Errorf("invalid on-call config: team_id is required")
+		}
+		if _, err := parseMessageTemplate(c.Message); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (cfg *OnCallConfig) destination() string {
+	return "oncall/" + cfg.TeamId
+}
+
+func (cfg *OnCallConfig) rateLimit() int {
+	if cfg.RateLimit > 0 {
+		return cfg.RateLimit
+	}
+	return defaultOnCallRateLimit
+}
+
+// onCallEmail splits the rendered message into the subject and the html body of the email
+func onCallEmail(text string) (string, string) {
+	subject := strings.TrimSpace(strings.SplitN(text, "\n", 2)[0])
+	return subject, "<pre>" + html.EscapeString(text) + "</pre>"
+}
+
+func (cfg *OnCallConfig) send(ctx context.Context, client *http.Client, alerts ...*Alert) error {
+	alerts = filterResolved(cfg.SendResolved, alerts)
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	lookup := getOnCallLookup()
+	if lookup == nil {
+		return fmt.Errorf("on-call schedules are not available")
+	}
+	emails, err := lookup(ctx, cfg.TeamId, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to find the members on call: %w", err)
+	}
+	if len(emails) == 0 {
+		return fmt.Errorf("no member of team %s is on call", cfg.TeamId)
+	}
+
+	text, err := RenderMessage(cfg.Message, alerts...)
+	if err != nil {
+		return err
+	}
+	subject, body := onCallEmail(text)
+	if err := smtpservice.GetInstance().SendEmail(strings.Join(emails, ","), subject, body); err != nil {
+		return fmt.Errorf("failed to email the members on call: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package alertManager
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultOpsgenieAPIURL = "https://api.opsgenie.com"
+	// opsgenie rejects the messages longer than 130 characters
+	maxOpsgenieMessageLength = 130
+	defaultOpsgeniePriority  = "P3"
+)
+
+var opsgeniePriorities = []string{"P1", "P2", "P3", "P4", "P5"}
+
+// OpsgenieAlertConfig configures the delivery of alerts to Opsgenie through the Alert API.
+// The alerts are created with the incident key of the alert as the alias so the
+// acknowledgements and the closings of the Opsgenie alerts are sent back to the alerts
+type OpsgenieAlertConfig struct {
+	SendResolved bool   `yaml:"send_resolved" json:"send_resolved"`
+	APIURL       string `yaml:"api_url,omitempty" json:"api_url,omitempty"`
+	APIKey       string `yaml:"api_key" json:"api_key"`
+	// Priority is one of P1 to P5, P3 by default
+	Priority string `yaml:"priority,omitempty" json:"priority,omitempty"`
+	// CallbackToken is the token of the token query parameter of the Opsgenie webhook calling
+	// back the channel, the callbacks are rejected when it is not set
+	CallbackToken string `yaml:"callback_token,omitempty" json:"callback_token,omitempty"`
+	// RateLimit is the maximum number of requests sent per minute, 0 disables the limit
+	RateLimit int `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"`
+}
+
+// ParseOpsgenieAlertConfigs reads the opsgenie alert configs of a receiver
+func ParseOpsgenieAlertConfigs(configs interface{}) ([]OpsgenieAlertConfig, error) {
+	if configs == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return nil, err
+	}
+	var res []OpsgenieAlertConfig
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("invalid opsgenie config: %w", err)
+	}
+	for _, c := range res {
+		if c.APIKey == "" {
+			return nil, fmt.Errorf("invalid opsgenie config: api_key is required")
+		}
+		if c.Priority != "" && !contains(opsgeniePriorities, c.Priority) {
+			return nil, fmt.Errorf("invalid opsgenie config: unsupported priority %s", c.Priority)
+		}
+	}
+	return res, nil
+}
+
+func (cfg *OpsgenieAlertConfig) destination() string {
+	return "opsgenie/" + cfg.APIKey
+}
+
+func (cfg *OpsgenieAlertConfig) rateLimit() int {
+	return cfg.RateLimit
+}
+
+// send creates an Opsgenie alert for each firing alert and closes the Opsgenie alerts of
+// the resolved alerts
+func (cfg *OpsgenieAlertConfig) send(ctx context.Context, client *http.Client, alerts ...*Alert) error {
+	apiURL := strings.TrimSuffix(cfg.APIURL, "/")
+	if apiURL == "" {
+		apiURL = defaultOpsgenieAPIURL
+	}
+	for _, a := range filterResolved(cfg.SendResolved, alerts) {
+		alias := IncidentKey(a)
+		if a.Resolved() {
+			u := fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", apiURL, url.PathEscape(alias))
+			if err := cfg.post(ctx, client, u, map[string]string{"source": "SigNoz"}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		message := alertSummary(a)
+		if len(message) > maxOpsgenieMessageLength {
+			message = message[:maxOpsgenieMessageLength-3] + "..."
+		}
+		priority := cfg.Priority
+		if priority == "" {
+			priority = defaultOpsgeniePriority
+		}
+		description := ""
+		if a.Annotations != nil {
+			description = a.Annotations.Get("description")
+		}
+		payload := map[string]interface{}{
+			"message":     message,
+			"alias":       alias,
+			"description": description,
+			"details":     alertDetails(a),
+			"priority":    priority,
+			"source":      "SigNoz",
+		}
+		if err := cfg.post(ctx, client, apiURL+"/v2/alerts", payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *OpsgenieAlertConfig) post(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", contentType)
+	req.Header.Add("Authorization", "GenieKey "+cfg.APIKey)
+
+	response, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call opsgenie api: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode > 299 {
+		return fmt.Errorf("opsgenie api returned %s", response.Status)
+	}
+	return nil
+}
+
+// opsgenieWebhook is the payload of the Opsgenie outgoing webhooks
+type opsgenieWebhook struct {
+	Action string `json:"action"`
+	Alert  struct {
+		Alias    string `json:"alias"`
+		Username string `json:"username"`
+	} `json:"alert"`
+}
+
+// OpsgenieIncidentUpdate reads an Opsgenie webhook sent to the channel of the receiver. The
+// token of the webhook is checked with the callback tokens of the opsgenie configs of the
+// receiver. It returns nil for the Opsgenie alerts which are not SigNoz alerts and for the
+// actions other than the acknowledgements and the closings
+func (r *Receiver) OpsgenieIncidentUpdate(token string, body []byte) (*IncidentUpdate, error) {
+	configs, err := ParseOpsgenieAlertConfigs(r.OpsgenieAlertConfigs)
+	if err != nil {
+		return nil, err
+	}
+	configured, verified := false, false
+	for _, c := range configs {
+		if c.CallbackToken == "" {
+			continue
+		}
+		configured = true
+		if subtle.ConstantTimeCompare([]byte(c.CallbackToken), []byte(token)) == 1 {
+			verified = true
+			break
+		}
+	}
+	if !configured {
+		return nil, ErrMissingIncidentCallback
+	}
+	if !verified {
+		return nil, ErrInvalidCallbackSecret
+	}
+
+	var webhook opsgenieWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, fmt.Errorf("invalid opsgenie webhook: %w", err)
+	}
+	update := &IncidentUpdate{Key: webhook.Alert.Alias, By: "opsgenie"}
+	if webhook.Alert.Username != "" {
+		update.By = "opsgenie: " + webhook.Alert.Username
+	}
+	switch webhook.Action {
+	case "Acknowledge":
+		update.Action = IncidentAcknowledged
+	case "Close":
+		update.Action = IncidentResolved
+	default:
+		return nil, nil
+	}
+	if !strings.HasPrefix(update.Key, incidentKeyPrefix+"-") {
+		return nil, nil
+	}
+	return update, nil
+}
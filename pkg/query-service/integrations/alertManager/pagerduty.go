@@ -0,0 +1,254 @@
+package alertManager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+const (
+	defaultPagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	// pagerduty accepts 120 events per minute per routing key
+	defaultPagerDutyRateLimit = 120
+	// pagerduty truncates the summaries longer than 1024 characters
+	maxPagerDutySummaryLength = 1024
+	defaultPagerDutySeverity  = "critical"
+)
+
+var pagerDutySeverities = []string{"critical", "error", "warning", "info"}
+
+// PagerDutyEventsConfig configures the delivery of alerts to a PagerDuty service through the
+// Events API v2. The events are sent with the incident key of the alert as the dedup key so
+// the acknowledgements and resolutions of the incidents are sent back to the alerts
+type PagerDutyEventsConfig struct {
+	SendResolved bool   `yaml:"send_resolved" json:"send_resolved"`
+	URL          string `yaml:"url,omitempty" json:"url,omitempty"`
+	RoutingKey   string `yaml:"routing_key" json:"routing_key"`
+	// Severity is the severity of the events, the severity label of the alert when it is a
+	// PagerDuty severity otherwise
+	Severity string `yaml:"severity,omitempty" json:"severity,omitempty"`
+	// WebhookSecret is the secret of the PagerDuty webhook subscription calling back the
+	// channel, the callbacks are rejected when it is not set
+	WebhookSecret string `yaml:"webhook_secret,omitempty" json:"webhook_secret,omitempty"`
+	// RateLimit is the maximum number of events sent per minute
+	RateLimit int `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"`
+}
+
+// ParsePagerDutyEventsConfigs reads the pagerduty events configs of a receiver
+func ParsePagerDutyEventsConfigs(configs interface{}) ([]PagerDutyEventsConfig, error) {
+	if configs == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return nil, err
+	}
+	var res []PagerDutyEventsConfig
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("invalid pagerduty config: %w", err)
+	}
+	for _, c := range res {
+		if c.RoutingKey == "" {
+			return nil, fmt.Errorf("invalid pagerduty config: routing_key is required")
+		}
+		if c.Severity != "" && !contains(pagerDutySeverities, c.Severity) {
+			return nil, fmt.Errorf("invalid pagerduty config: unsupported severity %s", c.Severity)
+		}
+	}
+	return res, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg *PagerDutyEventsConfig) destination() string {
+	return "pagerduty/" + cfg.RoutingKey
+}
+
+func (cfg *PagerDutyEventsConfig) rateLimit() int {
+	if cfg.RateLimit > 0 {
+		return cfg.RateLimit
+	}
+	return defaultPagerDutyRateLimit
+}
+
+func (cfg *PagerDutyEventsConfig) severity(a *Alert) string {
+	if cfg.Severity != "" {
+		return cfg.Severity
+	}
+	if severity := a.Labels.Get("severity"); contains(pagerDutySeverities, severity) {
+		return severity
+	}
+	return defaultPagerDutySeverity
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Client      string            `json:"client,omitempty"`
+	ClientURL   string            `json:"client_url,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+// alertSummary returns the summary annotation of the alert, or its name
+func alertSummary(a *Alert) string {
+	if a.Annotations != nil {
+		if summary := a.Annotations.Get(labels.AlertSummaryLabel); summary != "" {
+			return fmt.Sprintf("%s: %s", a.Name(), summary)
+		}
+	}
+	return a.Name()
+}
+
+// alertDetails returns the labels and the annotations of the alert
+func alertDetails(a *Alert) map[string]string {
+	details := a.Labels.Map()
+	if a.Annotations != nil {
+		for k, v := range a.Annotations.Map() {
+			details[k] = v
+		}
+	}
+	return details
+}
+
+// send triggers an event for each firing alert and resolves the incidents of the resolved
+// alerts, the alerts are not grouped so each incident is the incident of one alert
+func (cfg *PagerDutyEventsConfig) send(ctx context.Context, client *http.Client, alerts ...*Alert) error {
+	for _, a := range filterResolved(cfg.SendResolved, alerts) {
+		event := pagerDutyEvent{
+			RoutingKey:  cfg.RoutingKey,
+			EventAction: "trigger",
+			DedupKey:    IncidentKey(a),
+			Client:      "SigNoz",
+			ClientURL:   a.GeneratorURL,
+		}
+		if a.Resolved() {
+			event.EventAction = "resolve"
+		} else {
+			summary := alertSummary(a)
+			if len(summary) > maxPagerDutySummaryLength {
+				summary = summary[:maxPagerDutySummaryLength-3] + "..."
+			}
+			event.Payload = &pagerDutyPayload{
+				Summary:       summary,
+				Source:        "SigNoz",
+				Severity:      cfg.severity(a),
+				Timestamp:     a.StartsAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+				CustomDetails: alertDetails(a),
+			}
+		}
+		if err := cfg.post(ctx, client, &event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *PagerDutyEventsConfig) post(ctx context.Context, client *http.Client, event *pagerDutyEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	url := cfg.URL
+	if url == "" {
+		url = defaultPagerDutyEventsURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", contentType)
+
+	response, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call pagerduty events api: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode > 299 {
+		return fmt.Errorf("pagerduty events api returned %s", response.Status)
+	}
+	return nil
+}
+
+// pagerDutyWebhook is the payload of the PagerDuty V3 webhooks
+type pagerDutyWebhook struct {
+	Event struct {
+		EventType string `json:"event_type"`
+		Agent     *struct {
+			Summary string `json:"summary"`
+		} `json:"agent"`
+		Data struct {
+			IncidentKey string `json:"incident_key"`
+		} `json:"data"`
+	} `json:"event"`
+}
+
+// PagerDutyIncidentUpdate reads a PagerDuty webhook sent to the channel of the receiver. The
+// signature of the webhook is checked with the webhook secrets of the pagerduty configs of
+// the receiver. It returns nil for the events of the incidents which are not SigNoz alerts
+// and for the events other than the acknowledgements and the resolutions
+func (r *Receiver) PagerDutyIncidentUpdate(signature string, body []byte) (*IncidentUpdate, error) {
+	configs, err := ParsePagerDutyEventsConfigs(r.PagerDutyEventsConfigs)
+	if err != nil {
+		return nil, err
+	}
+	configured, verified := false, false
+	for _, c := range configs {
+		if c.WebhookSecret == "" {
+			continue
+		}
+		configured = true
+		if verifyPagerDutySignature(c.WebhookSecret, signature, body) {
+			verified = true
+			break
+		}
+	}
+	if !configured {
+		return nil, ErrMissingIncidentCallback
+	}
+	if !verified {
+		return nil, ErrInvalidCallbackSecret
+	}
+
+	var webhook pagerDutyWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, fmt.Errorf("invalid pagerduty webhook: %w", err)
+	}
+	update := &IncidentUpdate{Key: webhook.Event.Data.IncidentKey, By: "pagerduty"}
+	if webhook.Event.Agent != nil && webhook.Event.Agent.Summary != "" {
+		update.By = "pagerduty: " + webhook.Event.Agent.Summary
+	}
+	switch webhook.Event.EventType {
+	case "incident.acknowledged":
+		update.Action = IncidentAcknowledged
+	case "incident.resolved":
+		update.Action = IncidentResolved
+	default:
+		return nil, nil
+	}
+	if !strings.HasPrefix(update.Key, incidentKeyPrefix+"-") {
+		return nil, nil
+	}
+	return update, nil
+}
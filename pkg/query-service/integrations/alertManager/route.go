@@ -0,0 +1,82 @@
+package alertManager
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// ruleReceiverPrefix names the receivers generated for the notification settings of the rules
+const ruleReceiverPrefix = "signoz-rule-"
+
+// RuleReceiverName returns the name of the receiver generated for the rule
+func RuleReceiverName(ruleId string) string {
+	return ruleReceiverPrefix + ruleId
+}
+
+// Route is a route of the alertmanager config, in the format of the routes of the config
+// file. The routes of the receivers generated for the rules match the alerts of their rule
+// and group them with the notification settings of the rule
+type Route struct {
+	Receiver       string   `yaml:"receiver" json:"receiver"`
+	Matchers       []string `yaml:"matchers,omitempty" json:"matchers,omitempty"`
+	GroupBy        []string `yaml:"group_by,omitempty" json:"group_by,omitempty"`
+	GroupWait      string   `yaml:"group_wait,omitempty" json:"group_wait,omitempty"`
+	GroupInterval  string   `yaml:"group_interval,omitempty" json:"group_interval,omitempty"`
+	RepeatInterval string   `yaml:"repeat_interval,omitempty" json:"repeat_interval,omitempty"`
+}
+
+// RuleRoute returns the route of the receiver generated for the rule, it matches the alerts
+// of the rule
+func RuleRoute(ruleId string) *Route {
+	return &Route{
+		Receiver: RuleReceiverName(ruleId),
+		Matchers: []string{fmt.Sprintf("%s=%q", labels.AlertRuleIdLabel, ruleId)},
+	}
+}
+
+// MergeReceivers returns a receiver with the configs delivered by alertmanager of all the
+// receivers, it is nil when none of the receivers has such a config. The direct configs
+// are left out, the direct notifier delivers them to the channels themselves
+func MergeReceivers(name string, receivers []Receiver) (*Receiver, error) {
+	merged := map[string][]interface{}{}
+	for i := range receivers {
+		data, err := json.Marshal(receivers[i].alertmanagerReceiver())
+		if err != nil {
+			return nil, err
+		}
+		fields := map[string]json.RawMessage{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, err
+		}
+		for key, raw := range fields {
+			if !strings.HasSuffix(key, "_configs") {
+				continue
+			}
+			var configs []interface{}
+			if err := json.Unmarshal(raw, &configs); err != nil {
+				return nil, fmt.Errorf("invalid %s of receiver %s: %w", key, receivers[i].Name, err)
+			}
+			merged[key] = append(merged[key], configs...)
+		}
+	}
+	if len(merged) == 0 {
+		return nil, nil
+	}
+
+	fields := map[string]interface{}{"name": name}
+	for key, configs := range merged {
+		fields[key] = configs
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	receiver := &Receiver{}
+	if err := json.Unmarshal(data, receiver); err != nil {
+		return nil, err
+	}
+	return receiver, nil
+}
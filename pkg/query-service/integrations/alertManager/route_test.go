@@ -0,0 +1,69 @@
+package alertManager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestMergeReceivers(t *testing.T) {
+	var receivers []Receiver
+	channels := `[
+		{"name": "slack", "slack_configs": [{"channel": "#alerts"}]},
+		{"name": "email", "email_configs": [{"to": "oncall@signoz.io"}], "slack_configs": [{"channel": "#oncall"}]},
+		{"name": "telegram", "telegram_configs": [{"bot_token": "token", "chat_id": 1}]}
+	]`
+	if err := json.Unmarshal([]byte(channels), &receivers); err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := MergeReceivers(RuleReceiverName("7"), receivers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(merged)
+	expected := `{"name":"signoz-rule-7","email_configs":[{"to":"oncall@signoz.io"}],"slack_configs":[{"channel":"#alerts"},{"channel":"#oncall"}]}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+
+	// the direct configs are delivered by the query service
+	merged, err = MergeReceivers(RuleReceiverName("7"), receivers[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged != nil {
+		t.Errorf("expected no receiver for the direct configs, got %v", merged)
+	}
+
+	if _, err := MergeReceivers(RuleReceiverName("7"), []Receiver{{Name: "invalid", SlackConfigs: "#alerts"}}); err == nil {
+		t.Errorf("expected an error for the invalid configs")
+	}
+}
+
+func TestRuleRoute(t *testing.T) {
+	route := RuleRoute("7")
+	route.GroupBy = []string{"service"}
+	route.GroupWait = "30s"
+	route.RepeatInterval = "4h"
+
+	// the route is in the format of the routes of the alertmanager config file
+	data, err := yaml.Marshal(&Receiver{Name: RuleReceiverName("7"), Route: route})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `name: signoz-rule-7
+route:
+  receiver: signoz-rule-7
+  matchers:
+  - ruleId="7"
+  group_by:
+  - service
+  group_wait: 30s
+  repeat_interval: 4h
+`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
@@ -0,0 +1,83 @@
+package alertManager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// Silence is an alertmanager silence, the direct notifier honors them like
+// alertmanager does for the channels it delivers
+type Silence struct {
+	Id       string           `json:"id"`
+	Matchers []SilenceMatcher `json:"matchers"`
+	Status   struct {
+		State string `json:"state"`
+	} `json:"status"`
+}
+
+type SilenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	// IsEqual is missing from the silences created with the older versions of alertmanager
+	IsEqual *bool `json:"isEqual,omitempty"`
+}
+
+func (m *SilenceMatcher) matches(lbls labels.BaseLabels) bool {
+	value := lbls.Get(m.Name)
+	var matched bool
+	if m.IsRegex {
+		re, err := regexp.Compile("^(?:" + m.Value + ")$")
+		if err != nil {
+			return false
+		}
+		matched = re.MatchString(value)
+	} else {
+		matched = value == m.Value
+	}
+	if m.IsEqual != nil && !*m.IsEqual {
+		return !matched
+	}
+	return matched
+}
+
+// Mutes returns true if the silence is active and all its matchers match the labels
+func (s *Silence) Mutes(lbls labels.BaseLabels) bool {
+	if s.Status.State != "active" || len(s.Matchers) == 0 {
+		return false
+	}
+	for i := range s.Matchers {
+		if !s.Matchers[i].matches(lbls) {
+			return false
+		}
+	}
+	return true
+}
+
+func prepareSilencesApiURL() string {
+	return fmt.Sprintf("%s%s", constants.GetAlertManagerApiPrefix(), "v2/silences")
+}
+
+// fetchSilences reads the silences of alertmanager
+func fetchSilences(client *http.Client) ([]Silence, error) {
+	amURL := prepareSilencesApiURL()
+	response, err := client.Get(amURL)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode > 299 {
+		return nil, fmt.Errorf("error in getting 2xx response in API call to alertmanager(GET %s): %s", amURL, response.Status)
+	}
+	var silences []Silence
+	if err := json.NewDecoder(response.Body).Decode(&silences); err != nil {
+		return nil, err
+	}
+	return silences, nil
+}
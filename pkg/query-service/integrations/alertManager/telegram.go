@@ -0,0 +1,121 @@
+package alertManager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultTelegramAPIURL = "https://api.telegram.org"
+	// telegram allows about 20 messages per minute in a group
+	defaultTelegramRateLimit = 20
+	// telegram rejects messages longer than 4096 characters
+	maxTelegramMessageLength = 4096
+)
+
+// TelegramConfig configures the delivery of alerts through a telegram bot
+type TelegramConfig struct {
+	SendResolved bool   `yaml:"send_resolved" json:"send_resolved"`
+	APIURL       string `yaml:"api_url,omitempty" json:"api_url,omitempty"`
+	BotToken     string `yaml:"bot_token" json:"bot_token"`
+	ChatID       int64  `yaml:"chat_id" json:"chat_id"`
+	// ParseMode is one of "", "HTML", "Markdown" or "MarkdownV2"
+	ParseMode string `yaml:"parse_mode,omitempty" json:"parse_mode,omitempty"`
+	// Message is a go template executed with the notified alerts
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+	// RateLimit is the maximum number of messages sent per minute
+	RateLimit int `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"`
+}
+
+// ParseTelegramConfigs reads the telegram configs of a receiver
+func ParseTelegramConfigs(configs interface{}) ([]TelegramConfig, error) {
+	if configs == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return nil, err
+	}
+	var res []TelegramConfig
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("invalid telegram config: %w", err)
+	}
+	for _, c := range res {
+		if c.BotToken == "" || c.ChatID == 0 {
+			return nil, fmt.Errorf("invalid telegram config: bot_token and chat_id are required")
+		}
+		switch c.ParseMode {
+		case "", "HTML", "Markdown", "MarkdownV2":
+		default:
+			return nil, fmt.Errorf("invalid telegram config: unsupported parse_mode %s", c.ParseMode)
+		}
+		if _, err := parseMessageTemplate(c.Message); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (cfg *TelegramConfig) destination() string {
+	return fmt.Sprintf("telegram/%d", cfg.ChatID)
+}
+
+func (cfg *TelegramConfig) rateLimit() int {
+	if cfg.RateLimit > 0 {
+		return cfg.RateLimit
+	}
+	return defaultTelegramRateLimit
+}
+
+func (cfg *TelegramConfig) send(ctx context.Context, client *http.Client, alerts ...*Alert) error {
+	alerts = filterResolved(cfg.SendResolved, alerts)
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	text, err := RenderMessage(cfg.Message, alerts...)
+	if err != nil {
+		return err
+	}
+	if len(text) > maxTelegramMessageLength {
+		text = text[:maxTelegramMessageLength-3] + "..."
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  cfg.ChatID,
+		"text":                     text,
+		"parse_mode":               cfg.ParseMode,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	apiURL := cfg.APIURL
+	if apiURL == "" {
+		apiURL = defaultTelegramAPIURL
+	}
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(apiURL, "/"), cfg.BotToken)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", contentType)
+
+	response, err := client.Do(req)
+	if err != nil {
+		// the url holds the bot token, do not leak it in the logs
+		return fmt.Errorf("failed to call telegram api")
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode > 299 {
+		return fmt.Errorf("telegram api returned %s", response.Status)
+	}
+	return nil
+}
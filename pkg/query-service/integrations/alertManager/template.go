@@ -0,0 +1,142 @@
+package alertManager
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// defaultMessageTemplate is used by the channels when no message is configured
+const defaultMessageTemplate = `[{{ .Status | toUpper }}{{ if eq .Status "firing" }}:{{ len .Alerts }}{{ end }}] {{ .AlertName }}
+{{ range .Alerts }}
+{{ with .Annotations.message }}{{ . }}
+{{ else }}{{ with .Annotations.summary }}{{ . }}
+{{ end }}{{ with .Annotations.description }}{{ . }}
+{{ end }}{{ range $k, $v := .Labels }}{{ if ne $k "alertname" }}- {{ $k }}: {{ $v }}
+{{ end }}{{ end }}{{ end }}{{ with .GeneratorURL }}{{ . }}
+{{ end }}{{ end }}`
+
+var templateFuncs = template.FuncMap{
+	"toUpper": strings.ToUpper,
+	"toLower": strings.ToLower,
+	"join":    strings.Join,
+}
+
+// TemplateData is the data the channel message templates are executed with
+type TemplateData struct {
+	Status    string
+	AlertName string
+	Alerts    []TemplateAlert
+}
+
+type TemplateAlert struct {
+	Status      string
+	Labels      map[string]string
+	Annotations map[string]string
+	// Value is the query value that triggered the alert
+	Value        float64
+	StartsAt     time.Time
+	EndsAt       time.Time
+	GeneratorURL string
+}
+
+func newTemplateData(alerts ...*Alert) *TemplateData {
+	data := &TemplateData{Status: "resolved"}
+	for _, a := range alerts {
+		ta := TemplateAlert{
+			Status:       "firing",
+			Labels:       map[string]string{},
+			Annotations:  map[string]string{},
+			Value:        a.Value,
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+		}
+		if a.Resolved() {
+			ta.Status = "resolved"
+		} else {
+			data.Status = "firing"
+		}
+		if a.Labels != nil {
+			ta.Labels = a.Labels.Map()
+		}
+		if a.Annotations != nil {
+			ta.Annotations = a.Annotations.Map()
+		}
+		if data.AlertName == "" {
+			data.AlertName = a.Name()
+		}
+		data.Alerts = append(data.Alerts, ta)
+	}
+	return data
+}
+
+func parseMessageTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = defaultMessageTemplate
+	}
+	tmpl, err := template.New("message").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// ValidateMessageTemplate checks the message template can be parsed
+func ValidateMessageTemplate(text string) error {
+	_, err := parseMessageTemplate(text)
+	return err
+}
+
+// RenderMessage executes the message template, the default template is used when empty
+func RenderMessage(text string, alerts ...*Alert) (string, error) {
+	tmpl, err := parseMessageTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, newTemplateData(alerts...)); err != nil {
+		return "", fmt.Errorf("failed to render message template: %w", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// TemplatePreviewRequest renders a message template with a sample alert,
+// the test alert is used when no labels are given
+type TemplatePreviewRequest struct {
+	Template     string            `json:"template"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Value        float64           `json:"value,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+	Resolved     bool              `json:"resolved,omitempty"`
+}
+
+type TemplatePreviewResponse struct {
+	Message string `json:"message"`
+}
+
+func PreviewMessage(req *TemplatePreviewRequest) (*TemplatePreviewResponse, error) {
+	alert := testAlert()
+	if len(req.Labels) > 0 {
+		alert.Labels = labels.FromMap(req.Labels)
+	}
+	if len(req.Annotations) > 0 {
+		alert.Annotations = labels.FromMap(req.Annotations)
+	}
+	alert.Value = req.Value
+	alert.GeneratorURL = req.GeneratorURL
+	if req.Resolved {
+		alert.EndsAt = time.Now()
+	}
+
+	message, err := RenderMessage(req.Template, alert)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplatePreviewResponse{Message: message}, nil
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/util/stats"
@@ -14,27 +15,41 @@ import (
 )
 
 type Reader interface {
-	GetChannel(id string) (*model.ChannelItem, *model.ApiError)
-	GetChannels() (*[]model.ChannelItem, *model.ApiError)
-	DeleteChannel(id string) *model.ApiError
-	CreateChannel(receiver *am.Receiver) (*am.Receiver, *model.ApiError)
-	EditChannel(receiver *am.Receiver, id string) (*am.Receiver, *model.ApiError)
+	// the channels belong to the org of the user of the context
+	GetChannel(ctx context.Context, id string) (*model.ChannelItem, *model.ApiError)
+	GetChannels(ctx context.Context) (*[]model.ChannelItem, *model.ApiError)
+	DeleteChannel(ctx context.Context, id string) *model.ApiError
+	CreateChannel(ctx context.Context, receiver *am.Receiver) (*am.Receiver, *model.ApiError)
+	EditChannel(ctx context.Context, receiver *am.Receiver, id string) (*am.Receiver, *model.ApiError)
 
 	GetInstantQueryMetricsResult(ctx context.Context, query *model.InstantQueryMetricsParams) (*promql.Result, *stats.QueryStats, *model.ApiError)
 	GetQueryRangeResult(ctx context.Context, query *model.QueryRangeParams) (*promql.Result, *stats.QueryStats, *model.ApiError)
+	GetPromLabelNames(ctx context.Context, params *model.PromSeriesParams) ([]string, *model.ApiError)
+	GetPromLabelValues(ctx context.Context, name string, params *model.PromSeriesParams) ([]string, *model.ApiError)
+	GetPromSeries(ctx context.Context, params *model.PromSeriesParams) ([]map[string]string, *model.ApiError)
+	GetPromMetricMetadata(ctx context.Context, metric string, matchers []*labels.Matcher, limit int) (map[string][]model.PromMetricMetadata, *model.ApiError)
 	GetServiceOverview(ctx context.Context, query *model.GetServiceOverviewParams, skipConfig *model.SkipConfig) (*[]model.ServiceOverviewItem, *model.ApiError)
 	GetTopLevelOperations(ctx context.Context, skipConfig *model.SkipConfig, start, end time.Time) (*map[string][]string, *map[string][]string, *model.ApiError)
 	GetServices(ctx context.Context, query *model.GetServicesParams, skipConfig *model.SkipConfig) (*[]model.ServiceItem, *model.ApiError)
 	GetTopOperations(ctx context.Context, query *model.GetTopOperationsParams) (*[]model.TopOperationsItem, *model.ApiError)
 	GetUsage(ctx context.Context, query *model.GetUsageParams) (*[]model.UsageItem, error)
-	GetServicesList(ctx context.Context) (*[]string, error)
+	GetServicesList(ctx context.Context, scope *model.OrgScope) (*[]string, error)
 	GetDependencyGraph(ctx context.Context, query *model.GetServicesParams) (*[]model.ServiceMapDependencyResponseItem, error)
+	GetSpanOperations(ctx context.Context, service string, start, end time.Time, scope *model.OrgScope) ([]model.SpanOperation, *model.ApiError)
+	FindTraceIDs(ctx context.Context, params *model.FindTracesParams) ([]string, *model.ApiError)
+	GetTracesSpans(ctx context.Context, traceIDs []string, maxSpans int, scope *model.OrgScope) ([]model.SearchSpanResponseItem, *model.ApiError)
+	GetFunnelTraces(ctx context.Context, params *v3.FunnelParams) ([]v3.FunnelTrace, *model.ApiError)
+	GetSpanGroups(ctx context.Context, params *v3.SpanGroupsParams) ([]v3.SpanGroup, *model.ApiError)
+	GetBaselineTraceID(ctx context.Context, service, operation, excluded string, start, end time.Time, scope *model.OrgScope) (string, *model.ApiError)
+	GetServiceVersions(ctx context.Context, start, end time.Time) ([]model.ServiceVersion, *model.ApiError)
 
 	GetTTL(ctx context.Context, ttlParams *model.GetTTLParams) (*model.GetTTLResponseItem, *model.ApiError)
 
 	// GetDisks returns a list of disks configured in the underlying DB. It is supported by
 	// clickhouse only.
 	GetDisks(ctx context.Context) (*[]model.DiskItem, *model.ApiError)
+	// GetStorageTiers returns the move TTL and the usage of each disk of the tables of every signal.
+	GetStorageTiers(ctx context.Context) ([]model.StorageTierTable, *model.ApiError)
 	GetSpanFilters(ctx context.Context, query *model.SpanFilterParams) (*model.SpanFiltersResponse, *model.ApiError)
 	GetTraceAggregateAttributes(ctx context.Context, req *v3.AggregateAttributeRequest) (*v3.AggregateAttributeResponse, error)
 	GetTraceAttributeKeys(ctx context.Context, req *v3.FilterAttributeKeyRequest) (*v3.FilterAttributeKeyResponse, error)
@@ -53,14 +68,22 @@ type Reader interface {
 
 	// Search Interfaces
 	SearchTraces(ctx context.Context, params *model.SearchTracesParams, smartTraceAlgorithm func(payload []model.SearchSpanResponseItem, targetSpanId string, levelUp int, levelDown int, spanLimit int) ([]model.SearchSpansResult, error)) (*[]model.SearchSpansResult, error)
+	// GetTraceServices summarises the spans of the trace by service, only the
+	// given span is summarised when the span id is not empty
+	GetTraceServices(ctx context.Context, traceID string, spanID string, scope *model.OrgScope) ([]model.TraceServiceSummary, *model.ApiError)
 
 	// Setter Interfaces
 	SetTTL(ctx context.Context, ttlParams *model.TTLParams) (*model.SetTTLResponseItem, *model.ApiError)
+	// PreviewTTL returns the data the TTL would delete if it was set now.
+	PreviewTTL(ctx context.Context, ttlParams *model.TTLParams) (*model.TTLPreviewResponse, *model.ApiError)
+	SetStorageTierPolicy(ctx context.Context, params *model.StorageTierParams) (*model.SetTTLResponseItem, *model.ApiError)
 
 	FetchTemporality(ctx context.Context, metricNames []string) (map[string]map[v3.Temporality]bool, error)
 	GetMetricResult(ctx context.Context, query string) ([]*model.Series, error)
 	GetMetricResultEE(ctx context.Context, query string) ([]*model.Series, string, error)
 	GetMetricAggregateAttributes(ctx context.Context, req *v3.AggregateAttributeRequest) (*v3.AggregateAttributeResponse, error)
+	GetMetricsCardinality(ctx context.Context, params *model.MetricsCardinalityParams) (*model.MetricsCardinalityResponse, *model.ApiError)
+	GetMetricsSeriesCount(ctx context.Context, metricNames []string, start, end time.Time) (map[string]uint64, *model.ApiError)
 	GetMetricAttributeKeys(ctx context.Context, req *v3.FilterAttributeKeyRequest) (*v3.FilterAttributeKeyResponse, error)
 	GetMetricAttributeValues(ctx context.Context, req *v3.FilterAttributeValueRequest) (*v3.FilterAttributeValueResponse, error)
 
@@ -70,6 +93,7 @@ type Reader interface {
 	// QB V3 metrics/traces/logs
 	GetTimeSeriesResultV3(ctx context.Context, query string) ([]*v3.Series, error)
 	GetListResultV3(ctx context.Context, query string) ([]*v3.Row, error)
+	GetExemplars(ctx context.Context, query string) ([]v3.Exemplar, error)
 	LiveTailLogsV3(ctx context.Context, query string, timestampStart uint64, idStart string, client *v3.LogsLiveTailClient)
 
 	GetDashboardsInfo(ctx context.Context) (*model.DashboardsInfo, error)
@@ -88,12 +112,17 @@ type Reader interface {
 	UpdateLogField(ctx context.Context, field *model.UpdateField) *model.ApiError
 	GetLogs(ctx context.Context, params *model.LogsFilterParams) (*[]model.SignozLog, *model.ApiError)
 	TailLogs(ctx context.Context, client *model.LogsTailClient)
+	GetLogsSample(ctx context.Context, params *model.LogsFilterParams) ([]model.LogSample, *model.ApiError)
 	AggregateLogs(ctx context.Context, params *model.LogsAggregateParams) (*model.GetLogsAggregatesResponse, *model.ApiError)
 	GetLogAttributeKeys(ctx context.Context, req *v3.FilterAttributeKeyRequest) (*v3.FilterAttributeKeyResponse, error)
 	GetLogAttributeValues(ctx context.Context, req *v3.FilterAttributeValueRequest) (*v3.FilterAttributeValueResponse, error)
 	GetLogAggregateAttributes(ctx context.Context, req *v3.AggregateAttributeRequest) (*v3.AggregateAttributeResponse, error)
 	GetUsers(ctx context.Context) ([]model.UserPayload, error)
 
+	GetQueriesInFlight(ctx context.Context, orgId string) *model.QueriesInFlightResponse
+	CancelQuery(ctx context.Context, user *model.UserPayload, id string) ([]model.QueryInFlight, *model.ApiError)
+	EstimateQueryCost(ctx context.Context, query string) (*model.QueryCostEstimate, *model.ApiError)
+
 	// Connection needed for rules, not ideal but required
 	GetConn() clickhouse.Conn
 	GetQueryEngine() *promql.Engine
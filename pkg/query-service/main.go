@@ -3,12 +3,14 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"go.signoz.io/signoz/pkg/query-service/app"
+	"go.signoz.io/signoz/pkg/query-service/app/backup"
 	"go.signoz.io/signoz/pkg/query-service/auth"
 	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/migrate"
@@ -35,9 +37,11 @@ func main() {
 
 	// the url used to build link in the alert messages in slack and other systems
 	var ruleRepoURL, cacheConfigPath, fluxInterval string
+	var clickhouseSettingsPath string
 	var cluster string
 
 	var preferSpanMetrics bool
+	var otlpReceiver bool
 
 	var maxIdleConns int
 	var maxOpenConns int
@@ -47,8 +51,10 @@ func main() {
 	flag.StringVar(&skipTopLvlOpsPath, "skip-top-level-ops", "", "(config file to skip top level operations)")
 	flag.BoolVar(&disableRules, "rules.disable", false, "(disable rule evaluation)")
 	flag.BoolVar(&preferSpanMetrics, "prefer-span-metrics", false, "(prefer span metrics for service level metrics)")
+	flag.BoolVar(&otlpReceiver, "otlp-receiver", false, "(receive the OTLP/HTTP traces, logs and metrics on /v1/traces, /v1/logs and /v1/metrics, for the installs without a collector)")
 	flag.StringVar(&ruleRepoURL, "rules.repo-url", constants.AlertHelpPage, "(host address used to build rule link in alert messages)")
 	flag.StringVar(&cacheConfigPath, "experimental.cache-config", "", "(cache config to use)")
+	flag.StringVar(&clickhouseSettingsPath, "clickhouse-settings-config", "", "(clickhouse connection pool and query settings profiles config to use)")
 	flag.StringVar(&fluxInterval, "flux-interval", "5m", "(the interval to exclude data from being cached to avoid incorrect cache for data in motion)")
 	flag.StringVar(&cluster, "cluster", "cluster", "(cluster name - defaults to 'cluster')")
 	// Allow using the consistent naming with the signoz collector
@@ -58,6 +64,15 @@ func main() {
 	flag.DurationVar(&dialTimeout, "dial-timeout", 5*time.Second, "(the maximum time to establish a connection, only used with clickhouse if not set in ClickHouseUrl env var DSN.)")
 	flag.Parse()
 
+	// the backup and restore commands run on the metadata store without the server
+	if backup.IsCommand(flag.Args()) {
+		if err := backup.RunCommand(flag.Args(), constants.RELATIONAL_DATASOURCE_PATH, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	loggerMgr := initZapLog()
 	zap.ReplaceGlobals(loggerMgr)
 	defer loggerMgr.Sync() // flushes buffer, if any
@@ -66,19 +81,21 @@ func main() {
 	version.PrintVersion()
 
 	serverOptions := &app.ServerOptions{
-		HTTPHostPort:      constants.HTTPHostPort,
-		PromConfigPath:    promConfigPath,
-		SkipTopLvlOpsPath: skipTopLvlOpsPath,
-		PreferSpanMetrics: preferSpanMetrics,
-		PrivateHostPort:   constants.PrivateHostPort,
-		DisableRules:      disableRules,
-		RuleRepoURL:       ruleRepoURL,
-		MaxIdleConns:      maxIdleConns,
-		MaxOpenConns:      maxOpenConns,
-		DialTimeout:       dialTimeout,
-		CacheConfigPath:   cacheConfigPath,
-		FluxInterval:      fluxInterval,
-		Cluster:           cluster,
+		HTTPHostPort:           constants.HTTPHostPort,
+		PromConfigPath:         promConfigPath,
+		SkipTopLvlOpsPath:      skipTopLvlOpsPath,
+		PreferSpanMetrics:      preferSpanMetrics,
+		OTLPReceiver:           otlpReceiver,
+		PrivateHostPort:        constants.PrivateHostPort,
+		DisableRules:           disableRules,
+		RuleRepoURL:            ruleRepoURL,
+		MaxIdleConns:           maxIdleConns,
+		MaxOpenConns:           maxOpenConns,
+		DialTimeout:            dialTimeout,
+		CacheConfigPath:        cacheConfigPath,
+		ClickHouseSettingsPath: clickhouseSettingsPath,
+		FluxInterval:           fluxInterval,
+		Cluster:                cluster,
 	}
 
 	// Read the jwt secret key
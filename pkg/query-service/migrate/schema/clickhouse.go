@@ -0,0 +1,132 @@
+package schema
+
+// clickhouseMigrations are the migrations of the tables the query service owns in
+// clickhouse, ordered by version. The tables of the signals are migrated by the collector
+var clickhouseMigrations = []*Migration{
+	{
+		Version: 1,
+		Name:    "profiles_tables",
+		Up: []string{
+			"CREATE DATABASE IF NOT EXISTS signoz_profiles ON CLUSTER {cluster}",
+			`CREATE TABLE IF NOT EXISTS signoz_profiles.profile_samples ON CLUSTER {cluster} (
+				timestamp DateTime64(9) CODEC(DoubleDelta, LZ4),
+				profile_id String CODEC(ZSTD(1)),
+				service_name LowCardinality(String) CODEC(ZSTD(1)),
+				version LowCardinality(String) CODEC(ZSTD(1)),
+				environment LowCardinality(String) CODEC(ZSTD(1)),
+				sample_type LowCardinality(String) CODEC(ZSTD(1)),
+				sample_unit LowCardinality(String) CODEC(ZSTD(1)),
+				labels Map(LowCardinality(String), String) CODEC(ZSTD(1)),
+				stack Array(String) CODEC(ZSTD(1)),
+				value Int64 CODEC(ZSTD(1))
+			) ENGINE = MergeTree
+			PARTITION BY toDate(timestamp)
+			ORDER BY (service_name, sample_type, timestamp)
+			TTL toDateTime(timestamp) + INTERVAL 15 DAY`,
+			`CREATE TABLE IF NOT EXISTS signoz_profiles.distributed_profile_samples ON CLUSTER {cluster} AS signoz_profiles.profile_samples
+			ENGINE = Distributed('{cluster}', 'signoz_profiles', 'profile_samples', cityHash64(service_name, profile_id))`,
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS signoz_profiles.distributed_profile_samples ON CLUSTER {cluster}",
+			"DROP TABLE IF EXISTS signoz_profiles.profile_samples ON CLUSTER {cluster}",
+		},
+	},
+	{
+		Version: 2,
+		Name:    "rule_state_history_tables",
+		// the state changes of the alerts, duration_ms is how long the alert was in the
+		// state it left
+		Up: []string{
+			"CREATE DATABASE IF NOT EXISTS signoz_analytics ON CLUSTER {cluster}",
+			`CREATE TABLE IF NOT EXISTS signoz_analytics.rule_state_history ON CLUSTER {cluster} (
+				unix_milli Int64 CODEC(DoubleDelta, LZ4),
+				rule_id LowCardinality(String) CODEC(ZSTD(1)),
+				rule_name String CODEC(ZSTD(1)),
+				fingerprint UInt64 CODEC(ZSTD(1)),
+				labels Map(LowCardinality(String), String) CODEC(ZSTD(1)),
+				state_from LowCardinality(String) CODEC(ZSTD(1)),
+				state LowCardinality(String) CODEC(ZSTD(1)),
+				value Float64 CODEC(Gorilla, ZSTD(1)),
+				duration_ms Int64 CODEC(ZSTD(1))
+			) ENGINE = MergeTree
+			PARTITION BY toDate(intDiv(unix_milli, 1000))
+			ORDER BY (rule_id, unix_milli)
+			TTL toDateTime(intDiv(unix_milli, 1000)) + INTERVAL 90 DAY`,
+			`CREATE TABLE IF NOT EXISTS signoz_analytics.distributed_rule_state_history ON CLUSTER {cluster} AS signoz_analytics.rule_state_history
+			ENGINE = Distributed('{cluster}', 'signoz_analytics', 'rule_state_history', cityHash64(rule_id, fingerprint))`,
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS signoz_analytics.distributed_rule_state_history ON CLUSTER {cluster}",
+			"DROP TABLE IF EXISTS signoz_analytics.rule_state_history ON CLUSTER {cluster}",
+		},
+	},
+	{
+		Version: 3,
+		Name:    "events_tables",
+		// the structured events, e.g. the deploys, the feature releases and the business
+		// events sent by the services
+		Up: []string{
+			"CREATE DATABASE IF NOT EXISTS signoz_events ON CLUSTER {cluster}",
+			`CREATE TABLE IF NOT EXISTS signoz_events.events ON CLUSTER {cluster} (
+				timestamp DateTime64(3) CODEC(DoubleDelta, LZ4),
+				id String CODEC(ZSTD(1)),
+				name LowCardinality(String) CODEC(ZSTD(1)),
+				category LowCardinality(String) CODEC(ZSTD(1)),
+				service_name LowCardinality(String) CODEC(ZSTD(1)),
+				environment LowCardinality(String) CODEC(ZSTD(1)),
+				message String CODEC(ZSTD(1)),
+				attributes Map(LowCardinality(String), String) CODEC(ZSTD(1))
+			) ENGINE = MergeTree
+			PARTITION BY toDate(timestamp)
+			ORDER BY (category, name, timestamp)
+			TTL toDateTime(timestamp) + INTERVAL 90 DAY`,
+			`CREATE TABLE IF NOT EXISTS signoz_events.distributed_events ON CLUSTER {cluster} AS signoz_events.events
+			ENGINE = Distributed('{cluster}', 'signoz_events', 'events', cityHash64(id))`,
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS signoz_events.distributed_events ON CLUSTER {cluster}",
+			"DROP TABLE IF EXISTS signoz_events.events ON CLUSTER {cluster}",
+		},
+	},
+	{
+		Version: 4,
+		Name:    "attribute_metadata_tables",
+		// the keys and the values of the attributes of the logs and the spans, with how often
+		// and when they were last seen, the autocomplete is served from them
+		Up: []string{
+			"CREATE DATABASE IF NOT EXISTS signoz_metadata ON CLUSTER {cluster}",
+			`CREATE TABLE IF NOT EXISTS signoz_metadata.attribute_keys ON CLUSTER {cluster} (
+				signal LowCardinality(String) CODEC(ZSTD(1)),
+				key String CODEC(ZSTD(1)),
+				type LowCardinality(String) CODEC(ZSTD(1)),
+				data_type LowCardinality(String) CODEC(ZSTD(1)),
+				is_column SimpleAggregateFunction(max, UInt8),
+				count SimpleAggregateFunction(sum, UInt64),
+				last_seen SimpleAggregateFunction(max, DateTime)
+			) ENGINE = AggregatingMergeTree
+			ORDER BY (signal, key, type, data_type)
+			TTL last_seen + INTERVAL 30 DAY`,
+			`CREATE TABLE IF NOT EXISTS signoz_metadata.distributed_attribute_keys ON CLUSTER {cluster} AS signoz_metadata.attribute_keys
+			ENGINE = Distributed('{cluster}', 'signoz_metadata', 'attribute_keys', cityHash64(signal, key))`,
+			`CREATE TABLE IF NOT EXISTS signoz_metadata.attribute_values ON CLUSTER {cluster} (
+				signal LowCardinality(String) CODEC(ZSTD(1)),
+				key String CODEC(ZSTD(1)),
+				type LowCardinality(String) CODEC(ZSTD(1)),
+				data_type LowCardinality(String) CODEC(ZSTD(1)),
+				value String CODEC(ZSTD(1)),
+				count SimpleAggregateFunction(sum, UInt64),
+				last_seen SimpleAggregateFunction(max, DateTime)
+			) ENGINE = AggregatingMergeTree
+			ORDER BY (signal, key, type, data_type, value)
+			TTL last_seen + INTERVAL 30 DAY`,
+			`CREATE TABLE IF NOT EXISTS signoz_metadata.distributed_attribute_values ON CLUSTER {cluster} AS signoz_metadata.attribute_values
+			ENGINE = Distributed('{cluster}', 'signoz_metadata', 'attribute_values', cityHash64(signal, key))`,
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS signoz_metadata.distributed_attribute_values ON CLUSTER {cluster}",
+			"DROP TABLE IF EXISTS signoz_metadata.attribute_values ON CLUSTER {cluster}",
+			"DROP TABLE IF EXISTS signoz_metadata.distributed_attribute_keys ON CLUSTER {cluster}",
+			"DROP TABLE IF EXISTS signoz_metadata.attribute_keys ON CLUSTER {cluster}",
+		},
+	},
+}
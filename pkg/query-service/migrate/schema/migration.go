@@ -0,0 +1,87 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Database is the database a migration changes the schema of
+type Database string
+
+const (
+	DatabaseSQLite     Database = "sqlite"
+	DatabaseClickHouse Database = "clickhouse"
+)
+
+func (d Database) Validate() error {
+	switch d {
+	case DatabaseSQLite, DatabaseClickHouse:
+		return nil
+	}
+	return fmt.Errorf("unknown database %q, expected %s or %s", d, DatabaseSQLite, DatabaseClickHouse)
+}
+
+// clusterPlaceholder is replaced in the statements of the clickhouse migrations by the
+// cluster the query service is configured with
+const clusterPlaceholder = "{cluster}"
+
+// Migration is a versioned change of the schema of a database. The versions of a database
+// are applied in increasing order and rolled back in decreasing order. The clickhouse
+// databases have no transactions, their statements are expected to be idempotent so that
+// a migration failing halfway can be run again
+type Migration struct {
+	Version int
+	Name    string
+	Up      []string
+	// Down rolls the migration back, a migration without it can't be rolled back
+	Down []string
+	// Applied tells whether the schema already has the migration, for the installs that
+	// got it from the ad-hoc statements it replaces. The migration is then recorded
+	// without being run
+	Applied func(ctx context.Context, db *sqlx.DB) (bool, error)
+}
+
+func (m *Migration) reversible() bool {
+	return len(m.Down) > 0
+}
+
+// Direction is whether a step applies or rolls back a migration
+type Direction string
+
+const (
+	DirectionUp   Direction = "up"
+	DirectionDown Direction = "down"
+)
+
+// Step is a migration applied or rolled back, or to be in a dry run
+type Step struct {
+	Database   Database  `json:"database"`
+	Version    int       `json:"version"`
+	Name       string    `json:"name"`
+	Direction  Direction `json:"direction"`
+	Statements []string  `json:"statements"`
+	// Baseline is set for the migrations the schema already had, they are recorded
+	// without running their statements
+	Baseline bool `json:"baseline,omitempty"`
+}
+
+// Status is a migration with whether it was applied
+type Status struct {
+	Database   Database   `json:"database"`
+	Version    int        `json:"version"`
+	Name       string     `json:"name"`
+	Reversible bool       `json:"reversible"`
+	Applied    bool       `json:"applied"`
+	AppliedAt  *time.Time `json:"appliedAt,omitempty"`
+}
+
+// migrations returns the migrations of the database, ordered by version
+func migrations(db Database) []*Migration {
+	if db == DatabaseClickHouse {
+		return clickhouseMigrations
+	}
+	return sqliteMigrations
+}
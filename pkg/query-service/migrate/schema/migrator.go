@@ -0,0 +1,209 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type Options struct {
+	// DB is the sqlite database migrated, it records the migrations of both databases
+	DB *sqlx.DB
+	// Conn is the clickhouse connection, the clickhouse migrations can't be run without it
+	Conn    clickhouse.Conn
+	Cluster string
+}
+
+// Migrator applies and rolls back the migrations of the sqlite and clickhouse schemas
+type Migrator struct {
+	db      *sqlx.DB
+	conn    clickhouse.Conn
+	cluster string
+}
+
+func NewMigrator(opts Options) (*Migrator, error) {
+	if opts.DB == nil {
+		return nil, fmt.Errorf("db is required")
+	}
+	_, err := opts.DB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		db TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		applied_at datetime NOT NULL,
+		PRIMARY KEY(db, version)
+	);`)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating schema_migrations table: %s", err.Error())
+	}
+	return &Migrator{db: opts.DB, conn: opts.Conn, cluster: opts.Cluster}, nil
+}
+
+type applied struct {
+	Version   int       `db:"version"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+func (m *Migrator) applied(ctx context.Context, db Database) (map[int]time.Time, error) {
+	rows := []applied{}
+	if err := m.db.SelectContext(ctx, &rows, "SELECT version, applied_at FROM schema_migrations WHERE db = $1", db); err != nil {
+		return nil, errors.Wrap(err, "failed to get the applied migrations")
+	}
+	versions := map[int]time.Time{}
+	for _, r := range rows {
+		versions[r.Version] = r.AppliedAt
+	}
+	return versions, nil
+}
+
+// Status lists the migrations of both databases with whether they were applied
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	statuses := []*Status{}
+	for _, db := range []Database{DatabaseSQLite, DatabaseClickHouse} {
+		versions, err := m.applied(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		for _, mig := range migrations(db) {
+			status := &Status{Database: db, Version: mig.Version, Name: mig.Name, Reversible: mig.reversible()}
+			if at, ok := versions[mig.Version]; ok {
+				status.Applied = true
+				status.AppliedAt = &at
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) checkDatabase(db Database) error {
+	if err := db.Validate(); err != nil {
+		return err
+	}
+	if db == DatabaseClickHouse && m.conn == nil {
+		return fmt.Errorf("no clickhouse connection to migrate")
+	}
+	return nil
+}
+
+// Up applies the pending migrations of the database in order, it stops at the first one
+// failing. In a dry run the steps are returned without being run
+func (m *Migrator) Up(ctx context.Context, db Database, dryRun bool) ([]*Step, error) {
+	if err := m.checkDatabase(db); err != nil {
+		return nil, err
+	}
+	versions, err := m.applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []*Step{}
+	for _, mig := range migrations(db) {
+		if _, ok := versions[mig.Version]; ok {
+			continue
+		}
+		step := &Step{Database: db, Version: mig.Version, Name: mig.Name, Direction: DirectionUp, Statements: m.statements(db, mig.Up)}
+		if mig.Applied != nil {
+			if step.Baseline, err = mig.Applied(ctx, m.db); err != nil {
+				return steps, errors.Wrapf(err, "failed to check migration %d %s", mig.Version, mig.Name)
+			}
+		}
+		steps = append(steps, step)
+		if dryRun {
+			continue
+		}
+		if err := m.run(ctx, step); err != nil {
+			return steps, err
+		}
+		zap.L().Info("applied schema migration", zap.String("db", string(db)), zap.Int("version", mig.Version), zap.String("name", mig.Name), zap.Bool("baseline", step.Baseline))
+	}
+	return steps, nil
+}
+
+// Down rolls back the applied migrations of the database newer than the version, the
+// newest first. Nothing is rolled back when one of them isn't reversible
+func (m *Migrator) Down(ctx context.Context, db Database, version int, dryRun bool) ([]*Step, error) {
+	if err := m.checkDatabase(db); err != nil {
+		return nil, err
+	}
+	versions, err := m.applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []*Step{}
+	all := migrations(db)
+	for i := len(all) - 1; i >= 0; i-- {
+		mig := all[i]
+		if mig.Version <= version {
+			break
+		}
+		if _, ok := versions[mig.Version]; !ok {
+			continue
+		}
+		if !mig.reversible() {
+			return nil, fmt.Errorf("migration %d %s can't be rolled back", mig.Version, mig.Name)
+		}
+		steps = append(steps, &Step{Database: db, Version: mig.Version, Name: mig.Name, Direction: DirectionDown, Statements: m.statements(db, mig.Down)})
+	}
+	if dryRun {
+		return steps, nil
+	}
+	for i, step := range steps {
+		if err := m.run(ctx, step); err != nil {
+			return steps[:i+1], err
+		}
+		zap.L().Info("rolled back schema migration", zap.String("db", string(db)), zap.Int("version", step.Version), zap.String("name", step.Name))
+	}
+	return steps, nil
+}
+
+func (m *Migrator) statements(db Database, stmts []string) []string {
+	if db != DatabaseClickHouse {
+		return stmts
+	}
+	result := make([]string, 0, len(stmts))
+	for _, stmt := range stmts {
+		result = append(result, strings.ReplaceAll(stmt, clusterPlaceholder, m.cluster))
+	}
+	return result
+}
+
+// run runs the statements of the step and records it. The sqlite statements are run in
+// the transaction recording the step
+func (m *Migrator) run(ctx context.Context, step *Step) error {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin the migration")
+	}
+	defer tx.Rollback()
+
+	if !step.Baseline {
+		for _, stmt := range step.Statements {
+			if step.Database == DatabaseClickHouse {
+				err = m.conn.Exec(ctx, stmt)
+			} else {
+				_, err = tx.ExecContext(ctx, stmt)
+			}
+			if err != nil {
+				return errors.Wrapf(err, "failed to run migration %d %s %s", step.Version, step.Name, step.Direction)
+			}
+		}
+	}
+
+	if step.Direction == DirectionUp {
+		_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (db, version, name, applied_at) VALUES ($1, $2, $3, $4)",
+			step.Database, step.Version, step.Name, time.Now())
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE db = $1 AND version = $2", step.Database, step.Version)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to record migration %d %s", step.Version, step.Name)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,122 @@
+package schema
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMigrator(t *testing.T) (*Migrator, *sqlx.DB) {
+	db, err := sqlx.Open("sqlite3", filepath.Join(t.TempDir(), "signoz.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	m, err := NewMigrator(Options{DB: db, Cluster: "cluster"})
+	require.NoError(t, err)
+	return m, db
+}
+
+func versions(steps []*Step) []int {
+	result := []int{}
+	for _, step := range steps {
+		result = append(result, step.Version)
+	}
+	return result
+}
+
+func TestMigrateSQLite(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t)
+
+	steps, err := m.Up(ctx, DatabaseSQLite, true)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3, 4, 5}, versions(steps))
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		require.False(t, status.Applied)
+	}
+
+	steps, err = m.Up(ctx, DatabaseSQLite, false)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3, 4, 5}, versions(steps))
+	_, err = db.Exec("INSERT INTO ingestion_keys (key_id, ingestion_key, ingestion_url, data_region, daily_limit_bytes) VALUES ('k', 'key', 'url', 'us', 10)")
+	require.NoError(t, err)
+
+	steps, err = m.Up(ctx, DatabaseSQLite, false)
+	require.NoError(t, err)
+	require.Empty(t, steps)
+
+	// the users keep referring to their org once it is rebuilt
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec("PRAGMA foreign_keys = ON")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO organizations (id, name, created_at) VALUES ('org', 'org', 0)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO groups (id, name) VALUES ('admin', 'ADMIN')")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO users (id, name, email, password, created_at, group_id, org_id) VALUES ('u', 'u', 'u@signoz.io', '', 0, 'admin', 'org')")
+	require.NoError(t, err)
+
+	steps, err = m.Down(ctx, DatabaseSQLite, 1, false)
+	require.NoError(t, err)
+	require.Equal(t, []int{5, 4, 3, 2}, versions(steps))
+	_, err = db.Exec("SELECT daily_limit_bytes FROM ingestion_keys")
+	require.Error(t, err)
+	var keys, users int
+	require.NoError(t, db.Get(&keys, "SELECT count(*) FROM ingestion_keys"))
+	require.NoError(t, db.Get(&users, "SELECT count(*) FROM users JOIN organizations ON users.org_id = organizations.id"))
+	require.Equal(t, 1, keys)
+	require.Equal(t, 1, users)
+
+	_, err = m.Down(ctx, DatabaseSQLite, 0, true)
+	require.ErrorContains(t, err, "can't be rolled back")
+
+	statuses, err = m.Status(ctx)
+	require.NoError(t, err)
+	applied := []int{}
+	for _, status := range statuses {
+		if status.Applied {
+			applied = append(applied, status.Version)
+		}
+	}
+	require.Equal(t, []int{1}, applied)
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestMigrator(t)
+
+	// an install that got the column from the statements the migration replaces
+	_, err := db.Exec("CREATE TABLE organizations (id TEXT PRIMARY KEY, name TEXT NOT NULL, created_at INTEGER NOT NULL, " +
+		"is_anonymous INTEGER NOT NULL DEFAULT 0, has_opted_updates INTEGER NOT NULL DEFAULT 1, enforce_two_factor INTEGER NOT NULL DEFAULT 0)")
+	require.NoError(t, err)
+
+	steps, err := m.Up(ctx, DatabaseSQLite, false)
+	require.NoError(t, err)
+	require.Len(t, steps, 5)
+	require.False(t, steps[0].Baseline)
+	require.True(t, steps[1].Baseline)
+	require.False(t, steps[2].Baseline)
+	require.False(t, steps[3].Baseline)
+	require.False(t, steps[4].Baseline)
+}
+
+func TestMigrateClickHouse(t *testing.T) {
+	m, _ := newTestMigrator(t)
+
+	_, err := m.Up(context.Background(), DatabaseClickHouse, true)
+	require.ErrorContains(t, err, "no clickhouse connection")
+
+	require.Equal(t, []string{
+		"DROP TABLE IF EXISTS signoz_profiles.distributed_profile_samples ON CLUSTER cluster",
+		"DROP TABLE IF EXISTS signoz_profiles.profile_samples ON CLUSTER cluster",
+	}, m.statements(DatabaseClickHouse, clickhouseMigrations[0].Down))
+	require.Contains(t, m.statements(DatabaseClickHouse, clickhouseMigrations[0].Up)[2], "ENGINE = Distributed('cluster', 'signoz_profiles'")
+
+	_, err = m.Up(context.Background(), "postgres", true)
+	require.Error(t, err)
+}
@@ -0,0 +1,222 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// sqliteMigrations are the migrations of the metadata database, ordered by version
+var sqliteMigrations = []*Migration{
+	{
+		Version: 1,
+		Name:    "core_tables",
+		// the orgs, users and their settings the rest of the metadata refers to
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS invites (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				email TEXT NOT NULL UNIQUE,
+				token TEXT NOT NULL,
+				created_at INTEGER NOT NULL,
+				role TEXT NOT NULL,
+				org_id TEXT NOT NULL,
+				FOREIGN KEY(org_id) REFERENCES organizations(id)
+			)`,
+			`CREATE TABLE IF NOT EXISTS organizations (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL,
+				created_at INTEGER NOT NULL,
+				is_anonymous INTEGER NOT NULL DEFAULT 0 CHECK(is_anonymous IN (0,1)),
+				has_opted_updates INTEGER NOT NULL DEFAULT 1 CHECK(has_opted_updates IN (0,1))
+			)`,
+			`CREATE TABLE IF NOT EXISTS users (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL,
+				email TEXT NOT NULL UNIQUE,
+				password TEXT NOT NULL,
+				created_at INTEGER NOT NULL,
+				profile_picture_url TEXT,
+				group_id TEXT NOT NULL,
+				org_id TEXT NOT NULL,
+				FOREIGN KEY(group_id) REFERENCES groups(id),
+				FOREIGN KEY(org_id) REFERENCES organizations(id)
+			)`,
+			`CREATE TABLE IF NOT EXISTS groups (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL UNIQUE
+			)`,
+			`CREATE TABLE IF NOT EXISTS group_permissions (
+				group_id TEXT NOT NULL,
+				resource TEXT NOT NULL,
+				action TEXT NOT NULL,
+				PRIMARY KEY(group_id, resource, action),
+				FOREIGN KEY(group_id) REFERENCES groups(id)
+			)`,
+			`CREATE TABLE IF NOT EXISTS reset_password_request (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id TEXT NOT NULL,
+				token TEXT NOT NULL,
+				FOREIGN KEY(user_id) REFERENCES users(id)
+			)`,
+			`CREATE TABLE IF NOT EXISTS user_flags (
+				user_id TEXT PRIMARY KEY,
+				flags TEXT,
+				FOREIGN KEY(user_id) REFERENCES users(id)
+			)`,
+			`CREATE TABLE IF NOT EXISTS apdex_settings (
+				service_name TEXT PRIMARY KEY,
+				threshold FLOAT NOT NULL,
+				exclude_status_codes TEXT NOT NULL
+			)`,
+			`CREATE TABLE IF NOT EXISTS ingestion_keys (
+				key_id TEXT PRIMARY KEY,
+				name TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				ingestion_key TEXT NOT NULL,
+				ingestion_url TEXT NOT NULL,
+				data_region TEXT NOT NULL
+			)`,
+			`CREATE TABLE IF NOT EXISTS user_sessions (
+				id TEXT PRIMARY KEY,
+				user_id TEXT NOT NULL,
+				user_agent TEXT NOT NULL,
+				ip_address TEXT NOT NULL,
+				created_at INTEGER NOT NULL,
+				last_activity_at INTEGER NOT NULL,
+				expires_at INTEGER NOT NULL
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions (user_id)`,
+			`CREATE TABLE IF NOT EXISTS org_members (
+				org_id TEXT NOT NULL,
+				user_id TEXT NOT NULL,
+				group_id TEXT NOT NULL,
+				created_at INTEGER NOT NULL,
+				PRIMARY KEY(org_id, user_id)
+			)`,
+			`CREATE TABLE IF NOT EXISTS user_totp (
+				user_id TEXT PRIMARY KEY,
+				secret TEXT NOT NULL,
+				enabled INTEGER NOT NULL DEFAULT 0,
+				recovery_codes TEXT NOT NULL DEFAULT '',
+				last_used_step INTEGER NOT NULL DEFAULT 0,
+				created_at INTEGER NOT NULL
+			)`,
+			`CREATE TABLE IF NOT EXISTS ingestion_key_usage (
+				key_id TEXT NOT NULL,
+				day TEXT NOT NULL,
+				signal TEXT NOT NULL,
+				bytes INTEGER NOT NULL DEFAULT 0,
+				count INTEGER NOT NULL DEFAULT 0,
+				PRIMARY KEY(key_id, day, signal)
+			)`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "organizations_enforce_two_factor",
+		Up:      []string{"ALTER TABLE organizations ADD COLUMN enforce_two_factor INTEGER NOT NULL DEFAULT 0"},
+		Down: rebuildTable("organizations", `(
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			is_anonymous INTEGER NOT NULL DEFAULT 0 CHECK(is_anonymous IN (0,1)),
+			has_opted_updates INTEGER NOT NULL DEFAULT 1 CHECK(has_opted_updates IN (0,1))
+		)`, "id", "name", "created_at", "is_anonymous", "has_opted_updates"),
+		Applied: columnsExist("organizations", "enforce_two_factor"),
+	},
+	{
+		Version: 3,
+		Name:    "ingestion_keys_limits",
+		Up: []string{
+			"ALTER TABLE ingestion_keys ADD COLUMN signals TEXT NOT NULL DEFAULT ''",
+			"ALTER TABLE ingestion_keys ADD COLUMN daily_limit_bytes INTEGER NOT NULL DEFAULT 0",
+			"ALTER TABLE ingestion_keys ADD COLUMN revoked INTEGER NOT NULL DEFAULT 0",
+		},
+		Down: rebuildTable("ingestion_keys", `(
+			key_id TEXT PRIMARY KEY,
+			name TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			ingestion_key TEXT NOT NULL,
+			ingestion_url TEXT NOT NULL,
+			data_region TEXT NOT NULL
+		)`, "key_id", "name", "created_at", "ingestion_key", "ingestion_url", "data_region"),
+		Applied: columnsExist("ingestion_keys", "signals", "daily_limit_bytes", "revoked"),
+	},
+	{
+		Version: 4,
+		Name:    "ingestion_keys_org",
+		// the data sent with a key is tagged with the org of the key, the keys created
+		// before the orgs have none and belong to the default org
+		Up: []string{
+			"ALTER TABLE ingestion_keys ADD COLUMN org_id TEXT NOT NULL DEFAULT ''",
+		},
+		Down: rebuildTable("ingestion_keys", `(
+			key_id TEXT PRIMARY KEY,
+			name TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			ingestion_key TEXT NOT NULL,
+			ingestion_url TEXT NOT NULL,
+			data_region TEXT NOT NULL,
+			signals TEXT NOT NULL DEFAULT '',
+			daily_limit_bytes INTEGER NOT NULL DEFAULT 0,
+			revoked INTEGER NOT NULL DEFAULT 0
+		)`, "key_id", "name", "created_at", "ingestion_key", "ingestion_url", "data_region", "signals", "daily_limit_bytes", "revoked"),
+		Applied: columnsExist("ingestion_keys", "org_id"),
+	},
+	{
+		Version: 5,
+		Name:    "user_totp_lockout",
+		// the users are locked out of the two-factor authentication after too many invalid codes
+		Up: []string{
+			"ALTER TABLE user_totp ADD COLUMN failed_attempts INTEGER NOT NULL DEFAULT 0",
+			"ALTER TABLE user_totp ADD COLUMN locked_until INTEGER NOT NULL DEFAULT 0",
+		},
+		Down: rebuildTable("user_totp", `(
+			user_id TEXT PRIMARY KEY,
+			secret TEXT NOT NULL,
+			enabled INTEGER NOT NULL DEFAULT 0,
+			recovery_codes TEXT NOT NULL DEFAULT '',
+			last_used_step INTEGER NOT NULL DEFAULT 0,
+			created_at INTEGER NOT NULL
+		)`, "user_id", "secret", "enabled", "recovery_codes", "last_used_step", "created_at"),
+		Applied: columnsExist("user_totp", "failed_attempts", "locked_until"),
+	},
+}
+
+// rebuildTable returns the statements rebuilding the table with only the columns, the
+// sqlite the query service is built with can't drop columns. The rows are inserted back
+// in the table under its name so that the rows referring to them still do
+func rebuildTable(table, definition string, columns ...string) []string {
+	list := strings.Join(columns, ", ")
+	return []string{
+		"PRAGMA defer_foreign_keys = ON",
+		fmt.Sprintf("CREATE TEMP TABLE %s_backup AS SELECT %s FROM %s", table, list, table),
+		fmt.Sprintf("DROP TABLE %s", table),
+		fmt.Sprintf("CREATE TABLE %s %s", table, definition),
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s_backup", table, list, list, table),
+		fmt.Sprintf("DROP TABLE %s_backup", table),
+	}
+}
+
+// columnsExist tells whether the table has all the columns
+func columnsExist(table string, columns ...string) func(context.Context, *sqlx.DB) (bool, error) {
+	return func(ctx context.Context, db *sqlx.DB) (bool, error) {
+		existing := []string{}
+		if err := db.SelectContext(ctx, &existing, fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table)); err != nil {
+			return false, err
+		}
+		found := map[string]struct{}{}
+		for _, name := range existing {
+			found[name] = struct{}{}
+		}
+		for _, column := range columns {
+			if _, ok := found[column]; !ok {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
@@ -3,7 +3,21 @@ package model
 import "github.com/pkg/errors"
 
 var (
-	ErrorTokenExpired = errors.New("Token is expired")
+	ErrorTokenExpired   = errors.New("Token is expired")
+	ErrorSessionRevoked = errors.New("Session is revoked")
+	// ErrorTwoFactorRequired is returned for the logins of the users enrolled in two-factor
+	// authentication made without code, the login is then sent again with the code
+	ErrorTwoFactorRequired = errors.New("two-factor authentication code is required")
+	ErrorInvalidTwoFactor  = errors.New("invalid two-factor authentication code")
+	// ErrorTwoFactorLocked is returned for the codes of the users locked out after too many
+	// invalid codes, until the lockout ends
+	ErrorTwoFactorLocked = errors.New("too many invalid two-factor authentication codes, try again later")
+	// ErrorTwoFactorSetupRequired is returned for the requests of the users who must enroll in
+	// two-factor authentication before using SigNoz
+	ErrorTwoFactorSetupRequired = errors.New("two-factor authentication must be set up")
+	// ErrorRenderScope is returned for the requests made with a render token that do not
+	// read the dashboards
+	ErrorRenderScope = errors.New("render tokens only allow to read the dashboards")
 )
 
 type InviteRequest struct {
@@ -31,6 +45,13 @@ type LoginRequest struct {
 	Email        string `json:"email"`
 	Password     string `json:"password"`
 	RefreshToken string `json:"refreshToken"`
+	// TOTPCode is the code of the authenticator app, or a recovery code, of the users
+	// enrolled in two-factor authentication
+	TOTPCode string `json:"totpCode"`
+
+	// UserAgent and IPAddress describe the client of the session, they are set from the request
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
 }
 
 // PrecheckResponse contains login precheck response
@@ -51,7 +72,63 @@ type UserJwtObject struct {
 
 type LoginResponse struct {
 	UserJwtObject
-	UserId string `json:"userId"`
+	UserId    string `json:"userId"`
+	SessionId string `json:"sessionId"`
+	// TwoFactorSetupRequired is set when the org enforces two-factor authentication and the
+	// user is not enrolled, the tokens only allow to enroll until then
+	TwoFactorSetupRequired bool `json:"twoFactorSetupRequired"`
+}
+
+// UserTOTP is the two-factor authentication of a user, it is enabled once the first code is verified
+type UserTOTP struct {
+	UserId  string `json:"userId" db:"user_id"`
+	Secret  string `json:"-" db:"secret"`
+	Enabled bool   `json:"enabled" db:"enabled"`
+	// RecoveryCodes are the hashes of the unused recovery codes, comma-separated
+	RecoveryCodes string `json:"-" db:"recovery_codes"`
+	// LastUsedStep is the time step of the last code used, a code can't be used twice
+	LastUsedStep int64 `json:"-" db:"last_used_step"`
+	CreatedAt    int64 `json:"createdAt" db:"created_at"`
+	// FailedAttempts is the number of invalid codes since the last valid one, the user is
+	// locked out until LockedUntil, in unix seconds, once there are too many
+	FailedAttempts int   `json:"-" db:"failed_attempts"`
+	LockedUntil    int64 `json:"-" db:"locked_until"`
+}
+
+type TOTPEnrollResponse struct {
+	Secret string `json:"secret"`
+	// URL is the otpauth:// url to show as a QR code to the authenticator apps
+	URL string `json:"url"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type TOTPStatusResponse struct {
+	Enabled           bool `json:"enabled"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+type TOTPRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type TwoFactorPreferenceRequest struct {
+	Enforce bool `json:"enforce"`
+}
+
+// Session is a login of a user, the tokens of a session are valid until it expires or is revoked
+type Session struct {
+	Id             string `json:"id" db:"id"`
+	UserId         string `json:"userId" db:"user_id"`
+	UserAgent      string `json:"userAgent" db:"user_agent"`
+	IPAddress      string `json:"ipAddress" db:"ip_address"`
+	CreatedAt      int64  `json:"createdAt" db:"created_at"`
+	LastActivityAt int64  `json:"lastActivityAt" db:"last_activity_at"`
+	ExpiresAt      int64  `json:"expiresAt" db:"expires_at"`
+	// Current is set for the session the request is made with
+	Current bool `json:"current" db:"-"`
 }
 
 type ChangePasswordRequest struct {
@@ -69,3 +146,85 @@ type UserRole struct {
 	UserId    string `json:"user_id"`
 	GroupName string `json:"group_name"`
 }
+
+// Resource is what the permissions of the roles are granted on
+type Resource string
+
+const (
+	ResourceDashboards  Resource = "dashboards"
+	ResourceAlerts      Resource = "alerts"
+	ResourceChannels    Resource = "channels"
+	ResourcePipelines   Resource = "pipelines"
+	ResourceSavedViews  Resource = "saved_views"
+	ResourceSettings    Resource = "settings"
+	ResourceDeployments Resource = "deployments"
+	ResourceErrors      Resource = "errors"
+	ResourceIssues      Resource = "issues"
+	ResourceSynthetics  Resource = "synthetics"
+	ResourceLogMetrics  Resource = "log_metrics"
+	ResourceOnCall      Resource = "oncall"
+	ResourceReports     Resource = "reports"
+	ResourceLogExports  Resource = "log_exports"
+)
+
+var Resources = []Resource{ResourceDashboards, ResourceAlerts, ResourceChannels, ResourcePipelines, ResourceSavedViews, ResourceSettings, ResourceIssues, ResourceErrors, ResourceDeployments, ResourceSynthetics, ResourceLogMetrics, ResourceOnCall, ResourceReports, ResourceLogExports}
+
+type Action string
+
+const (
+	ActionRead   Action = "read"
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+var Actions = []Action{ActionRead, ActionCreate, ActionUpdate, ActionDelete}
+
+type Permission struct {
+	Resource Resource `json:"resource" db:"resource"`
+	Action   Action   `json:"action" db:"action"`
+}
+
+func (p Permission) Validate() error {
+	validResource := false
+	for _, r := range Resources {
+		validResource = validResource || r == p.Resource
+	}
+	if !validResource {
+		return errors.Errorf("invalid resource %q", p.Resource)
+	}
+	validAction := false
+	for _, a := range Actions {
+		validAction = validAction || a == p.Action
+	}
+	if !validAction {
+		return errors.Errorf("invalid action %q", p.Action)
+	}
+	return nil
+}
+
+// Role is a group of users along with the permissions granted to them,
+// the permissions of the built in roles can't be changed
+type Role struct {
+	Id          string       `json:"id"`
+	Name        string       `json:"name"`
+	BuiltIn     bool         `json:"builtIn"`
+	Permissions []Permission `json:"permissions"`
+}
+
+type PostableRole struct {
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+}
+
+func (r *PostableRole) Validate() error {
+	if r.Name == "" {
+		return errors.New("role name is required")
+	}
+	for _, p := range r.Permissions {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
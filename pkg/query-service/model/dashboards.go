@@ -62,6 +62,7 @@ type Panels struct {
 	MaxDataPoints    int      `json:"maxDataPoints,omitempty"`
 	Collapsed        bool     `json:"collapsed,omitempty"`
 	Panels           []Panels `json:"panels,omitempty"`
+	Repeat           string   `json:"repeat,omitempty"`
 }
 
 type GrafanaJSON struct {
@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,26 @@ type Organization struct {
 	CreatedAt       int64  `json:"createdAt" db:"created_at"`
 	IsAnonymous     bool   `json:"isAnonymous" db:"is_anonymous"`
 	HasOptedUpdates bool   `json:"hasOptedUpdates" db:"has_opted_updates"`
+	// EnforceTwoFactor requires the users logging in with a password to enroll in two-factor authentication
+	EnforceTwoFactor bool `json:"enforceTwoFactor" db:"enforce_two_factor"`
+}
+
+// OrgMember is a user who is a member of an org besides the org of the user
+type OrgMember struct {
+	OrgId     string `json:"orgId" db:"org_id"`
+	UserId    string `json:"userId" db:"user_id"`
+	GroupId   string `json:"groupId" db:"group_id"`
+	CreatedAt int64  `json:"createdAt" db:"created_at"`
+}
+
+// UserOrg is an org a user can switch to, with the role of the user in the org
+type UserOrg struct {
+	OrgId   string `json:"orgId" db:"org_id"`
+	Name    string `json:"name" db:"name"`
+	GroupId string `json:"groupId" db:"group_id"`
+	Role    string `json:"role" db:"role"`
+	// Home is set for the org the user was created in
+	Home bool `json:"home" db:"home"`
 }
 
 // InvitationObject represents the token object stored in the db
@@ -50,6 +71,108 @@ type IngestionKey struct {
 	IngestionKey string    `json:"ingestionKey" db:"ingestion_key"`
 	IngestionURL string    `json:"ingestionURL" db:"ingestion_url"`
 	DataRegion   string    `json:"dataRegion" db:"data_region"`
+	// Signals restricts the signals the key can send, any signal is allowed when empty
+	Signals IngestionSignals `json:"signals" db:"signals"`
+	// DailyLimitBytes is the volume the key can send per day (UTC), no limit when 0
+	DailyLimitBytes int64 `json:"dailyLimitBytes" db:"daily_limit_bytes"`
+	Revoked         bool  `json:"revoked" db:"revoked"`
+	// OrgId is the org the data sent with the key is tagged with, the keys without org
+	// belong to the default org
+	OrgId string `json:"orgId" db:"org_id"`
+}
+
+func (k *IngestionKey) Validate() error {
+	if k.DailyLimitBytes < 0 {
+		return fmt.Errorf("daily limit can not be negative")
+	}
+	return k.Signals.Validate()
+}
+
+// Allows checks if the key can send the signal
+func (k *IngestionKey) Allows(signal string) bool {
+	if len(k.Signals) == 0 {
+		return true
+	}
+	for _, s := range k.Signals {
+		if s == signal {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	IngestionSignalLogs     = "logs"
+	IngestionSignalMetrics  = "metrics"
+	IngestionSignalTraces   = "traces"
+	IngestionSignalProfiles = "profiles"
+	IngestionSignalEvents   = "events"
+)
+
+// IngestionSignals is stored as a comma separated list
+type IngestionSignals []string
+
+func (s IngestionSignals) Validate() error {
+	for _, signal := range s {
+		switch signal {
+		case IngestionSignalLogs, IngestionSignalMetrics, IngestionSignalTraces, IngestionSignalProfiles, IngestionSignalEvents:
+		default:
+			return fmt.Errorf("invalid signal %q, expected one of logs, metrics, traces, profiles and events", signal)
+		}
+	}
+	return nil
+}
+
+func (s IngestionSignals) Value() (driver.Value, error) {
+	return strings.Join(s, ","), nil
+}
+
+func (s *IngestionSignals) Scan(value interface{}) error {
+	var str string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	default:
+		return fmt.Errorf("type assertion failed while scanning ingestion signals")
+	}
+	*s = IngestionSignals{}
+	if str != "" {
+		*s = strings.Split(str, ",")
+	}
+	return nil
+}
+
+// IngestionKeyUsage is the volume sent by a key for a signal in a day (UTC)
+type IngestionKeyUsage struct {
+	KeyId  string `json:"keyId" db:"key_id"`
+	Day    string `json:"day" db:"day"`
+	Signal string `json:"signal" db:"signal"`
+	Bytes  int64  `json:"bytes" db:"bytes"`
+	Count  int64  `json:"count" db:"count"`
+}
+
+// UpdateIngestionKeyRequest changes the name and the restrictions of a key
+type UpdateIngestionKeyRequest struct {
+	Name            string           `json:"name"`
+	Signals         IngestionSignals `json:"signals"`
+	DailyLimitBytes int64            `json:"dailyLimitBytes"`
+}
+
+// CheckIngestionKeyRequest is sent by the ingestion gateway before accepting a batch
+// sent with a key, the batch is counted in the usage of the key when accepted
+type CheckIngestionKeyRequest struct {
+	Signal string `json:"signal"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// CheckIngestionKeyResponse tells the ingestion gateway the org the accepted batch must be
+// tagged with, the gateway overwrites the org set by the senders
+type CheckIngestionKeyResponse struct {
+	KeyId string `json:"keyId"`
+	OrgId string `json:"orgId"`
 }
 
 type UserFlag map[string]string
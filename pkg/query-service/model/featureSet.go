@@ -22,6 +22,8 @@ const AlertChannelPagerduty = "ALERT_CHANNEL_PAGERDUTY"
 const AlertChannelMsTeams = "ALERT_CHANNEL_MSTEAMS"
 const AlertChannelOpsgenie = "ALERT_CHANNEL_OPSGENIE"
 const AlertChannelEmail = "ALERT_CHANNEL_EMAIL"
+const AlertChannelTelegram = "ALERT_CHANNEL_TELEGRAM"
+const AlertChannelGoogleChat = "ALERT_CHANNEL_GOOGLECHAT"
 
 var BasicPlan = FeatureSet{
 	Feature{
@@ -115,4 +117,18 @@ var BasicPlan = FeatureSet{
 		UsageLimit: -1,
 		Route:      "",
 	},
+	Feature{
+		Name:       AlertChannelTelegram,
+		Active:     true,
+		Usage:      0,
+		UsageLimit: -1,
+		Route:      "",
+	},
+	Feature{
+		Name:       AlertChannelGoogleChat,
+		Active:     true,
+		Usage:      0,
+		UsageLimit: -1,
+		Route:      "",
+	},
 }
@@ -1,7 +1,10 @@
 package model
 
 import (
+	"slices"
 	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
 )
 
 type InstantQueryMetricsParams struct {
@@ -18,6 +21,15 @@ type QueryRangeParams struct {
 	Stats string
 }
 
+// PromSeriesParams selects the series of the Prometheus API, a series matches when it
+// matches all the matchers of any of the match[] selectors, or any series without selector
+type PromSeriesParams struct {
+	Start    time.Time
+	End      time.Time
+	Matchers [][]*labels.Matcher
+	Limit    int
+}
+
 type MetricQuery struct {
 	QueryName         string            `json:"queryName"`
 	MetricName        string            `json:"metricName"`
@@ -178,6 +190,22 @@ type GetUsageParams struct {
 	StepHour    int
 	Start       *time.Time
 	End         *time.Time
+	// OrgScope restricts the usage to the services of the orgs of the user
+	OrgScope *OrgScope
+}
+
+// FindTracesParams selects the traces of the Jaeger API search, the traces with a span
+// matching all the params are returned
+type FindTracesParams struct {
+	Service     string
+	Operation   string
+	Tags        map[string]string
+	Start       time.Time
+	End         time.Time
+	MinDuration time.Duration
+	MaxDuration time.Duration
+	Limit       int
+	OrgScope    *OrgScope
 }
 
 type GetServicesParams struct {
@@ -187,6 +215,22 @@ type GetServicesParams struct {
 	Start     *time.Time
 	End       *time.Time
 	Tags      []TagQueryParam `json:"tags"`
+	// OrgScope restricts the service map to the services of the orgs of the user
+	OrgScope *OrgScope `json:"-"`
+}
+
+// GetDependencyGraphParams selects the edges of the service map, the tags filter the edges on
+// the environment, the cluster and the namespace
+type GetDependencyGraphParams struct {
+	GetServicesParams
+	// Service is the focal service, the map has the services up to Depth hops from it
+	Service string `json:"service"`
+	Depth   int    `json:"depth"`
+	// CompareStartTime and CompareEndTime are the range the edges are compared with, if any
+	CompareStartTime string `json:"compareStart"`
+	CompareEndTime   string `json:"compareEnd"`
+	CompareStart     *time.Time
+	CompareEnd       *time.Time
 }
 
 type GetServiceOverviewParams struct {
@@ -427,6 +471,26 @@ type SearchTracesParams struct {
 	SpanID           string `json:"spanId"`
 	SpansRenderLimit int    `json:"spansRenderLimit"`
 	MaxSpansInTrace  int    `json:"maxSpansInTrace"`
+	// OrgScope restricts the spans of the trace to the orgs of the user
+	OrgScope *OrgScope `json:"-"`
+}
+
+// OrgScope is the orgs whose data a user reads. The data of the orgs is told apart by the
+// org id resource attribute, the default org reads the data which is not tagged with
+// another org, the other orgs read their data only
+type OrgScope struct {
+	OrgIds []string
+	// Exclude is set for the default org, the OrgIds are the other orgs
+	Exclude bool
+}
+
+// Allows returns true if the data tagged with the org id is in the scope, a nil scope
+// allows all the data
+func (s *OrgScope) Allows(orgId string) bool {
+	if s == nil {
+		return true
+	}
+	return slices.Contains(s.OrgIds, orgId) != s.Exclude
 }
 
 type SpanFilterParams struct {
@@ -449,6 +513,8 @@ type SpanFilterParams struct {
 	EndStr             string   `json:"end"`
 	Start              *time.Time
 	End                *time.Time
+	// OrgScope restricts the spans to the orgs of the user
+	OrgScope *OrgScope `json:"-"`
 }
 
 type TagFilterParams struct {
@@ -472,6 +538,8 @@ type TagFilterParams struct {
 	Limit              int      `json:"limit"`
 	Start              *time.Time
 	End                *time.Time
+	// OrgScope restricts the spans to the orgs of the user
+	OrgScope *OrgScope `json:"-"`
 }
 
 type TagDataType string
@@ -492,12 +560,51 @@ type TTLParams struct {
 	ColdStorageVolume     string // Name of the cold storage volume.
 	ToColdStorageDuration int64  // Seconds after which data will be moved to cold storage.
 	DelDuration           int64  // Seconds after which data will be deleted.
+	// Overrides of the retention of some resources, nil keeps the stored overrides.
+	Overrides *[]TTLOverride
+}
+
+// TTLOverride deletes the data of the resources whose attribute has one of
+// the values, e.g. a team or a namespace, after its own duration
+type TTLOverride struct {
+	Key         string   `json:"key"`
+	Values      []string `json:"values"`
+	DurationHrs int      `json:"ttl_duration_hrs"`
+}
+
+// DelDuration returns the seconds after which the data will be deleted.
+func (o TTLOverride) DelDuration() int64 {
+	return int64(o.DurationHrs) * 3600
 }
 
 type GetTTLParams struct {
 	Type string
 }
 
+type MetricsCardinalityParams struct {
+	Start *time.Time
+	End   *time.Time
+	Limit int
+	// MetricNames restricts the series to the metrics, all the metrics by default.
+	MetricNames []string
+	// OrgScope restricts the series to the orgs of the user
+	OrgScope *OrgScope
+}
+
+// StorageTierParams moves the data of the tables of a signal older than
+// MoveAfterDays to the volume, the delete TTL of the tables is kept as is
+type StorageTierParams struct {
+	Signal string `json:"signal"` // It can be one of {traces, metrics, logs}.
+	// Table restricts the policy to a single local table of the signal.
+	Table  string `json:"table,omitempty"`
+	Volume string `json:"volume"`
+	// MoveAfterDays of 0 removes the move TTL of the tables.
+	MoveAfterDays int `json:"moveAfterDays"`
+	// MoveExisting moves the partitions that are already older than
+	// MoveAfterDays right away instead of waiting for the merges.
+	MoveExisting bool `json:"moveExisting"`
+}
+
 type ListErrorsParams struct {
 	StartStr      string `json:"start"`
 	EndStr        string `json:"end"`
@@ -510,6 +617,9 @@ type ListErrorsParams struct {
 	ServiceName   string          `json:"serviceName"`
 	ExceptionType string          `json:"exceptionType"`
 	Tags          []TagQueryParam `json:"tags"`
+	// ByOrg splits the groups by the org of the exceptions, for the error tracking which
+	// keeps the groups of the orgs apart
+	ByOrg bool `json:"-"`
 }
 
 type CountErrorsParams struct {
@@ -526,6 +636,8 @@ type GetErrorParams struct {
 	GroupID   string
 	ErrorID   string
 	Timestamp *time.Time
+	// OrgScope restricts the errors to the orgs of the user
+	OrgScope *OrgScope
 }
 
 type FilterItem struct {
@@ -557,6 +669,20 @@ type LogsFilterParams struct {
 	TimestampEnd   uint64 `json:"timestampEnd"`
 	IdGt           string `json:"idGt"`
 	IdLT           string `json:"idLt"`
+	// OrgScope restricts the logs to the orgs of the user
+	OrgScope *OrgScope `json:"-"`
+}
+
+// LogPatternsParams is used to mine the patterns of a sample of the logs matching
+// the query, the patterns are compared with the previous window when Compare is set
+type LogPatternsParams struct {
+	Query          string `json:"q"`
+	TimestampStart uint64 `json:"timestampStart"`
+	TimestampEnd   uint64 `json:"timestampEnd"`
+	Limit          int    `json:"limit"`
+	SampleSize     int    `json:"sampleSize"`
+	StepSeconds    int    `json:"step"`
+	Compare        bool   `json:"compare"`
 }
 
 type LogsAggregateParams struct {
@@ -566,4 +692,6 @@ type LogsAggregateParams struct {
 	GroupBy        string `json:"groupBy"`
 	Function       string `json:"function"`
 	StepSeconds    int    `json:"step"`
+	// OrgScope restricts the logs to the orgs of the user
+	OrgScope *OrgScope `json:"-"`
 }
@@ -63,6 +63,8 @@ const (
 	ErrorUnauthorized             ErrorType = "unauthorized"
 	ErrorForbidden                ErrorType = "forbidden"
 	ErrorConflict                 ErrorType = "conflict"
+	ErrorPreconditionFailed       ErrorType = "precondition_failed"
+	ErrorTooManyRequests          ErrorType = "too_many_requests"
 	ErrorStreamingNotSupported    ErrorType = "streaming is not supported"
 	ErrorStatusServiceUnavailable ErrorType = "service unavailable"
 )
@@ -131,6 +133,13 @@ type QueryDataV2 struct {
 	Result     parser.Value     `json:"result"`
 }
 
+// PromMetricMetadata is the metadata of a metric in the Prometheus API
+type PromMetricMetadata struct {
+	Type string `json:"type"`
+	Help string `json:"help"`
+	Unit string `json:"unit"`
+}
+
 type QueryData struct {
 	ResultType parser.ValueType  `json:"resultType"`
 	Result     parser.Value      `json:"result"`
@@ -160,6 +169,9 @@ type ChannelItem struct {
 	Name      string    `json:"name" db:"name"`
 	Type      string    `json:"type" db:"type"`
 	Data      string    `json:"data" db:"data"`
+	// OrgId is the org of the channel, empty for the channels of the default org created
+	// before the orgs were added
+	OrgId string `json:"-" db:"org_id"`
 }
 
 // AlertDiscovery has info for all active alerts.
@@ -217,6 +229,45 @@ type SearchSpansResult struct {
 	Columns              []string        `json:"columns"`
 	Events               [][]interface{} `json:"events"`
 	IsSubTree            bool            `json:"isSubTree"`
+	// CriticalPath is the chain of spans determining the duration of the trace, in the
+	// order of their start times
+	CriticalPath []CriticalPathSpan `json:"criticalPath"`
+}
+
+// CriticalPathSpan is a span of the critical path of a trace, the self time is the time of
+// the critical path spent in the span and not in its children
+type CriticalPathSpan struct {
+	SpanID       string `json:"spanId"`
+	ServiceName  string `json:"serviceName"`
+	Name         string `json:"name"`
+	DurationNano int64  `json:"durationNano"`
+	SelfTimeNano int64  `json:"selfTimeNano"`
+}
+
+// ServiceVersion is a version of a service, from the service.version resource attribute of
+// its spans, with the time of its first span
+type ServiceVersion struct {
+	ServiceName string    `json:"serviceName" ch:"serviceName"`
+	Version     string    `json:"version" ch:"version"`
+	Environment string    `json:"environment" ch:"environment"`
+	FirstSeen   time.Time `json:"firstSeen" ch:"firstSeen"`
+	// OrgId is the org the spans of the version were sent for, empty before the orgs were added
+	OrgId string `json:"-" ch:"orgId"`
+}
+
+// TraceServiceSummary summarises the spans of a service in a trace, the slowest
+// span is the one which is linked from the latency metrics of the service
+type TraceServiceSummary struct {
+	ServiceName         string            `json:"serviceName" ch:"serviceName"`
+	StartTimeUnixNano   uint64            `json:"startTimeUnixNano" ch:"startTimeUnixNano"`
+	EndTimeUnixNano     uint64            `json:"endTimeUnixNano" ch:"endTimeUnixNano"`
+	SpanCount           uint64            `json:"spanCount" ch:"spanCount"`
+	ErrorCount          uint64            `json:"errorCount" ch:"errorCount"`
+	Resources           map[string]string `json:"resources" ch:"resources"`
+	SlowestSpanID       string            `json:"slowestSpanId" ch:"slowestSpanID"`
+	SlowestOperation    string            `json:"slowestOperation" ch:"slowestOperation"`
+	SlowestDurationNano uint64            `json:"slowestDurationNano" ch:"slowestDurationNano"`
+	SlowestTimeUnixNano uint64            `json:"slowestTimeUnixNano" ch:"slowestTimeUnixNano"`
 }
 
 type GetFilterSpansResponseItem struct {
@@ -237,6 +288,12 @@ type GetFilterSpansResponse struct {
 	TotalSpans uint64                       `json:"totalSpans"`
 }
 
+// SpanOperation is an operation of a service with the kind of its spans
+type SpanOperation struct {
+	Name string `ch:"name"`
+	Kind int8   `ch:"kind"`
+}
+
 type SearchSpanDBResponseItem struct {
 	Timestamp time.Time `ch:"timestamp"`
 	TraceID   string    `ch:"traceID"`
@@ -349,6 +406,28 @@ type ServiceMapDependencyResponseItem struct {
 	P50       float64 `json:"p50" ch:"p50"`
 }
 
+// Edge statuses of the service map comparisons
+const (
+	EdgeAdded    = "added"
+	EdgeRemoved  = "removed"
+	EdgeExisting = "existing"
+)
+
+// ServiceMapEdgeComparison is an edge of the service map in the range and in the compared
+// range, Current is nil when the edge was removed and Previous when it was added
+type ServiceMapEdgeComparison struct {
+	Parent   string                            `json:"parent"`
+	Child    string                            `json:"child"`
+	Status   string                            `json:"status"`
+	Current  *ServiceMapDependencyResponseItem `json:"current"`
+	Previous *ServiceMapDependencyResponseItem `json:"previous"`
+	// CallRateChange, ErrorRateChange and P99Change are the differences between the ranges,
+	// they are zero when the edge is in one of them only
+	CallRateChange  float64 `json:"callRateChange"`
+	ErrorRateChange float64 `json:"errorRateChange"`
+	P99Change       float64 `json:"p99Change"`
+}
+
 type GetFilteredSpansAggregatesResponse struct {
 	Items map[int64]SpanAggregatesResponseItem `json:"items"`
 }
@@ -379,20 +458,146 @@ type DBResponseTTL struct {
 	EngineFull string `ch:"engine_full"`
 }
 
+type MetricCardinality struct {
+	MetricName string `json:"metricName" ch:"metric_name"`
+	Series     uint64 `json:"series" ch:"series"`
+}
+
+// LabelCardinality is the number of values of a label key over all the metrics having it
+type LabelCardinality struct {
+	Key     string `json:"key" ch:"key"`
+	Values  uint64 `json:"values" ch:"value_count"`
+	Metrics uint64 `json:"metrics" ch:"metric_count"`
+}
+
+type MetricsCardinalityResponse struct {
+	Metrics []MetricCardinality `json:"metrics"`
+	Labels  []LabelCardinality  `json:"labels"`
+}
+
+const (
+	QueryStatusQueued  = "queued"
+	QueryStatusRunning = "running"
+)
+
+type QueryInFlight struct {
+	Id string `json:"id"`
+	// RequestId is the id of the API request the query was made for
+	RequestId string     `json:"requestId,omitempty"`
+	OrgId     string     `json:"orgId"`
+	UserId    string     `json:"userId"`
+	UserEmail string     `json:"userEmail"`
+	Query     string     `json:"query"`
+	Status    string     `json:"status"`
+	QueuedAt  time.Time  `json:"queuedAt"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+}
+
+type QueriesInFlightResponse struct {
+	MaxConcurrentPerOrg  int             `json:"maxConcurrentPerOrg"`
+	MaxConcurrentPerUser int             `json:"maxConcurrentPerUser"`
+	QueueTimeoutMs       int64           `json:"queueTimeoutMs"`
+	Queries              []QueryInFlight `json:"queries"`
+}
+
+type QueryCostClass string
+
+const (
+	QueryCostLow      QueryCostClass = "low"
+	QueryCostMedium   QueryCostClass = "medium"
+	QueryCostHigh     QueryCostClass = "high"
+	QueryCostVeryHigh QueryCostClass = "very_high"
+)
+
+// GetQueryCostClass classifies the cost of a query by the bytes it scans
+func GetQueryCostClass(bytes uint64) QueryCostClass {
+	const gb = uint64(1) << 30
+	switch {
+	case bytes < 10*gb:
+		return QueryCostLow
+	case bytes < 100*gb:
+		return QueryCostMedium
+	case bytes < 1024*gb:
+		return QueryCostHigh
+	}
+	return QueryCostVeryHigh
+}
+
+// QueryCostEstimate is what a query is estimated to scan from the tables it reads, the bytes are
+// an upper bound as the estimate assumes all the columns of the rows are read
+type QueryCostEstimate struct {
+	QueryName string         `json:"queryName,omitempty"`
+	Parts     uint64         `json:"parts"`
+	Marks     uint64         `json:"marks"`
+	Rows      uint64         `json:"rows"`
+	Bytes     uint64         `json:"bytes"`
+	CostClass QueryCostClass `json:"costClass"`
+}
+
+type QueryRangeCostEstimate struct {
+	Total   QueryCostEstimate   `json:"total"`
+	Queries []QueryCostEstimate `json:"queries"`
+}
+
+type StorageTierMove struct {
+	AfterDays int    `json:"afterDays"`
+	Volume    string `json:"volume"`
+}
+
+// StorageTierUsage is the data of a table stored on a disk
+type StorageTierUsage struct {
+	Volume     string    `json:"volume"`
+	Disk       string    `json:"disk"`
+	DiskType   string    `json:"diskType"`
+	Bytes      uint64    `json:"bytes"`
+	Rows       uint64    `json:"rows"`
+	Parts      uint64    `json:"parts"`
+	OldestDate time.Time `json:"oldestDate"`
+	NewestDate time.Time `json:"newestDate"`
+}
+
+type StorageTierTable struct {
+	Signal        string             `json:"signal"`
+	Database      string             `json:"database"`
+	Table         string             `json:"table"`
+	StoragePolicy string             `json:"storagePolicy"`
+	Move          *StorageTierMove   `json:"move,omitempty"`
+	Tiers         []StorageTierUsage `json:"tiers"`
+}
+
 type GetTTLResponseItem struct {
-	MetricsTime             int    `json:"metrics_ttl_duration_hrs,omitempty"`
-	MetricsMoveTime         int    `json:"metrics_move_ttl_duration_hrs,omitempty"`
-	TracesTime              int    `json:"traces_ttl_duration_hrs,omitempty"`
-	TracesMoveTime          int    `json:"traces_move_ttl_duration_hrs,omitempty"`
-	LogsTime                int    `json:"logs_ttl_duration_hrs,omitempty"`
-	LogsMoveTime            int    `json:"logs_move_ttl_duration_hrs,omitempty"`
-	ExpectedMetricsTime     int    `json:"expected_metrics_ttl_duration_hrs,omitempty"`
-	ExpectedMetricsMoveTime int    `json:"expected_metrics_move_ttl_duration_hrs,omitempty"`
-	ExpectedTracesTime      int    `json:"expected_traces_ttl_duration_hrs,omitempty"`
-	ExpectedTracesMoveTime  int    `json:"expected_traces_move_ttl_duration_hrs,omitempty"`
-	ExpectedLogsTime        int    `json:"expected_logs_ttl_duration_hrs,omitempty"`
-	ExpectedLogsMoveTime    int    `json:"expected_logs_move_ttl_duration_hrs,omitempty"`
-	Status                  string `json:"status"`
+	MetricsTime             int           `json:"metrics_ttl_duration_hrs,omitempty"`
+	MetricsMoveTime         int           `json:"metrics_move_ttl_duration_hrs,omitempty"`
+	TracesTime              int           `json:"traces_ttl_duration_hrs,omitempty"`
+	TracesMoveTime          int           `json:"traces_move_ttl_duration_hrs,omitempty"`
+	LogsTime                int           `json:"logs_ttl_duration_hrs,omitempty"`
+	LogsMoveTime            int           `json:"logs_move_ttl_duration_hrs,omitempty"`
+	ExpectedMetricsTime     int           `json:"expected_metrics_ttl_duration_hrs,omitempty"`
+	ExpectedMetricsMoveTime int           `json:"expected_metrics_move_ttl_duration_hrs,omitempty"`
+	ExpectedTracesTime      int           `json:"expected_traces_ttl_duration_hrs,omitempty"`
+	ExpectedTracesMoveTime  int           `json:"expected_traces_move_ttl_duration_hrs,omitempty"`
+	ExpectedLogsTime        int           `json:"expected_logs_ttl_duration_hrs,omitempty"`
+	ExpectedLogsMoveTime    int           `json:"expected_logs_move_ttl_duration_hrs,omitempty"`
+	Status                  string        `json:"status"`
+	Overrides               []TTLOverride `json:"overrides,omitempty"`
+}
+
+// TTLPreviewRule is the data a rule of the TTL would delete, the rule
+// without key is the default retention of the signal
+type TTLPreviewRule struct {
+	Key          string   `json:"key,omitempty"`
+	Values       []string `json:"values,omitempty"`
+	DurationHrs  int      `json:"ttl_duration_hrs"`
+	ExpiredRows  uint64   `json:"expired_rows"`
+	ExpiredBytes uint64   `json:"expired_bytes"`
+}
+
+type TTLPreviewResponse struct {
+	TotalRows    uint64           `json:"total_rows"`
+	TotalBytes   uint64           `json:"total_bytes"`
+	ExpiredRows  uint64           `json:"expired_rows"`
+	ExpiredBytes uint64           `json:"expired_bytes"`
+	Rules        []TTLPreviewRule `json:"rules"`
 }
 
 type DBResponseServiceName struct {
@@ -469,6 +674,8 @@ type Error struct {
 	FirstSeen      time.Time `json:"firstSeen" ch:"firstSeen"`
 	ServiceName    string    `json:"serviceName" ch:"serviceName"`
 	GroupID        string    `json:"groupID" ch:"groupID"`
+	// OrgId is the org the exceptions were sent for, only set when the groups are split by org
+	OrgId string `json:"-" ch:"orgId"`
 }
 
 type ErrorWithSpan struct {
@@ -590,6 +797,11 @@ type LogsAggregatesResponseItem struct {
 	GroupBy   map[string]interface{} `json:"groupBy,omitempty"`
 }
 
+type LogSample struct {
+	Timestamp uint64 `ch:"timestamp"`
+	Body      string `ch:"body"`
+}
+
 type LogsAggregatesDBResponseItem struct {
 	Timestamp int64   `ch:"ts_start_interval"`
 	Value     float64 `ch:"value"`
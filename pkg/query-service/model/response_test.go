@@ -0,0 +1,24 @@
+package model
+
+import "testing"
+
+func TestGetQueryCostClass(t *testing.T) {
+	const gb = uint64(1) << 30
+	cases := []struct {
+		bytes    uint64
+		expected QueryCostClass
+	}{
+		{0, QueryCostLow},
+		{10*gb - 1, QueryCostLow},
+		{10 * gb, QueryCostMedium},
+		{100*gb - 1, QueryCostMedium},
+		{100 * gb, QueryCostHigh},
+		{1024*gb - 1, QueryCostHigh},
+		{1024 * gb, QueryCostVeryHigh},
+	}
+	for _, c := range cases {
+		if class := GetQueryCostClass(c.bytes); class != c.expected {
+			t.Errorf("expected %s for %d bytes, got %s", c.expected, c.bytes, class)
+		}
+	}
+}
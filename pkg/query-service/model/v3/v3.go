@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	promModel "github.com/prometheus/common/model"
 	"go.signoz.io/signoz/pkg/query-service/model"
 )
 
@@ -287,6 +289,8 @@ type FilterAttributeValueRequest struct {
 	TagType                    TagType              `json:"tagType"`
 	SearchText                 string               `json:"searchText"`
 	Limit                      int                  `json:"limit"`
+	// OrgScope restricts the values to the series of the orgs of the user
+	OrgScope *model.OrgScope `json:"-"`
 }
 
 type AggregateAttributeResponse struct {
@@ -356,6 +360,43 @@ type QueryRangeParamsV3 struct {
 	NoCache        bool                   `json:"noCache"`
 	Version        string                 `json:"-"`
 	FormatForWeb   bool                   `json:"formatForWeb,omitempty"`
+	// Exemplars attaches the exemplars of the metrics builder queries to their series
+	Exemplars bool `json:"exemplars,omitempty"`
+	// PanelWidth is the width of the panel in pixels, it bounds the points
+	// returned when the step is computed from the time range
+	PanelWidth int64 `json:"panelWidth,omitempty"`
+	// Comparisons are offsets, e.g. 1d or 1w, the queries are evaluated again over the
+	// range shifted back by each of them
+	Comparisons []string `json:"comparisons,omitempty"`
+	// Events selects the structured events overlaid on the charts, none when nil
+	Events *EventFilter `json:"events,omitempty"`
+}
+
+// EventFilter selects the structured events, e.g. the releases of a service. The values of
+// a field are or'ed, the fields and the attributes are and'ed
+type EventFilter struct {
+	Names        []string          `json:"names,omitempty"`
+	Categories   []string          `json:"categories,omitempty"`
+	ServiceNames []string          `json:"serviceNames,omitempty"`
+	Environment  string            `json:"environment,omitempty"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+	// OrgScope restricts the events to those sent with the ingestion keys of the orgs of the user
+	OrgScope *model.OrgScope `json:"-"`
+}
+
+// MaxComparisons is the most comparisons of a query range request
+const MaxComparisons = 4
+
+// ParseComparisonOffset returns the offset of a comparison, e.g. 1d or 1w
+func ParseComparisonOffset(offset string) (time.Duration, error) {
+	duration, err := promModel.ParseDuration(offset)
+	if err != nil {
+		return 0, fmt.Errorf("invalid comparison offset %s: %w", offset, err)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("comparison offset %s should be positive", offset)
+	}
+	return time.Duration(duration), nil
 }
 
 type PromQuery struct {
@@ -545,6 +586,9 @@ const (
 	MetricTypeHistogram            MetricType = "Histogram"
 	MetricTypeSummary              MetricType = "Summary"
 	MetricTypeExponentialHistogram MetricType = "ExponentialHistogram"
+	// MetricTypeExponentialBuckets is the type of the buckets of the exponential histograms
+	// received over OTLP, the series of each bucket has its count at its upper bound in le
+	MetricTypeExponentialBuckets MetricType = "ExponentialBuckets"
 )
 
 type SpaceAggregation string
@@ -630,6 +674,10 @@ const (
 	FunctionNameMedian5   FunctionName = "median5"
 	FunctionNameMedian7   FunctionName = "median7"
 	FunctionNameTimeShift FunctionName = "timeShift"
+	// FunctionNameDerivative is the per second rate of change between consecutive points
+	FunctionNameDerivative FunctionName = "derivative"
+	// FunctionNameAbsent returns a series of 1 when the query has no series
+	FunctionNameAbsent FunctionName = "absent"
 )
 
 func (f FunctionName) Validate() error {
@@ -648,13 +696,31 @@ func (f FunctionName) Validate() error {
 		FunctionNameMedian3,
 		FunctionNameMedian5,
 		FunctionNameMedian7,
-		FunctionNameTimeShift:
+		FunctionNameTimeShift,
+		FunctionNameDerivative,
+		FunctionNameAbsent:
 		return nil
 	default:
 		return fmt.Errorf("invalid function name: %s", f)
 	}
 }
 
+// parseTimeShift returns the seconds of the time shift, given as seconds or as a duration
+func parseTimeShift(arg interface{}) (float64, error) {
+	str, ok := arg.(string)
+	if !ok {
+		return 0, fmt.Errorf("time shift is not a string")
+	}
+	if seconds, err := strconv.ParseFloat(str, 64); err == nil {
+		return seconds, nil
+	}
+	duration, err := promModel.ParseDuration(str)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(duration).Seconds(), nil
+}
+
 type Function struct {
 	Name FunctionName  `json:"name"`
 	Args []interface{} `json:"args,omitempty"`
@@ -682,7 +748,162 @@ type BuilderQuery struct {
 	TimeAggregation    TimeAggregation   `json:"timeAggregation,omitempty"`
 	SpaceAggregation   SpaceAggregation  `json:"spaceAggregation,omitempty"`
 	Functions          []Function        `json:"functions,omitempty"`
-	ShiftBy            int64
+	// Join matches the series of the queries of a formula on the given labels instead of
+	// their whole label sets
+	Join *FormulaJoin `json:"join,omitempty"`
+	// NestedAggregation aggregates the result of the query a second time
+	NestedAggregation *NestedAggregation `json:"nestedAggregation,omitempty"`
+	// SeriesLimit keeps the top or the bottom series of the group by in ClickHouse
+	SeriesLimit *SeriesLimit `json:"seriesLimit,omitempty"`
+	ShiftBy     int64
+}
+
+type SeriesLimitDirection string
+
+const (
+	SeriesLimitTop    SeriesLimitDirection = "top"
+	SeriesLimitBottom SeriesLimitDirection = "bottom"
+)
+
+// SeriesLimitOtherValue is the value of the group by labels of the other series
+const SeriesLimitOtherValue = "__other__"
+
+// MaxSeriesLimit is the most series a series limit keeps
+const MaxSeriesLimit = 100
+
+// SeriesLimit keeps the K series of a metrics query with the highest or the lowest average
+// value over the range. The other series are dropped, or aggregated together into a series
+// with the labels of the group by set to SeriesLimitOtherValue
+type SeriesLimit struct {
+	Direction SeriesLimitDirection `json:"direction"`
+	K         int                  `json:"k"`
+	Other     bool                 `json:"other,omitempty"`
+}
+
+func (l *SeriesLimit) Validate(query *BuilderQuery) error {
+	switch l.Direction {
+	case SeriesLimitTop, SeriesLimitBottom:
+	default:
+		return fmt.Errorf("direction must be top or bottom")
+	}
+	if l.K <= 0 || l.K > MaxSeriesLimit {
+		return fmt.Errorf("k must be between 1 and %d", MaxSeriesLimit)
+	}
+	if l.Other && IsPercentileOperator(query.ResultOperator()) {
+		return fmt.Errorf("the other series can't be aggregated by a percentile")
+	}
+	return nil
+}
+
+// ResultOperator returns the last space aggregation of the query, the one of its nested
+// aggregation when any
+func (b *BuilderQuery) ResultOperator() SpaceAggregation {
+	if b.NestedAggregation != nil {
+		return b.NestedAggregation.Operator
+	}
+	return b.SpaceAggregation
+}
+
+// NestedAggregation aggregates the values of a metrics query a second time, as the PromQL
+// subqueries do, e.g. the max over an hour of a per minute rate or the count of the series
+// above a value
+type NestedAggregation struct {
+	// Operator is a space aggregation, percentiles included
+	Operator SpaceAggregation `json:"operator"`
+	// StepInterval, in seconds, aggregates the points of each series over time. It is a
+	// multiple of the step interval of the query
+	StepInterval int64 `json:"stepInterval,omitempty"`
+	// GroupBy aggregates the series at each timestamp by a subset of the group by of the
+	// query when no step interval is set, all the series when empty
+	GroupBy []AttributeKey `json:"groupBy,omitempty"`
+	// Having filters the values of the query before they are aggregated
+	Having []Having `json:"having,omitempty"`
+}
+
+// OverTime tells whether the points of each series are aggregated, the series are
+// aggregated together otherwise
+func (n *NestedAggregation) OverTime() bool {
+	return n.StepInterval > 0
+}
+
+func (n *NestedAggregation) Validate(query *BuilderQuery) error {
+	if err := n.Operator.Validate(); err != nil {
+		return err
+	}
+	if n.StepInterval < 0 {
+		return fmt.Errorf("step interval must not be negative")
+	}
+	if n.OverTime() {
+		if len(n.GroupBy) > 0 {
+			return fmt.Errorf("group by is not supported over time, the series keep their labels")
+		}
+		if query.StepInterval > 0 && n.StepInterval%query.StepInterval != 0 {
+			return fmt.Errorf("step interval must be a multiple of the step interval of the query")
+		}
+	}
+	for _, groupBy := range n.GroupBy {
+		found := false
+		for _, tag := range query.GroupBy {
+			if tag.Key == groupBy.Key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("group by %s is not in the group by of the query", groupBy.Key)
+		}
+	}
+	for _, having := range n.Having {
+		switch having.Operator {
+		case HavingOperatorEqual, HavingOperatorNotEqual,
+			HavingOperatorGreaterThan, HavingOperatorGreaterThanOrEq,
+			HavingOperatorLessThan, HavingOperatorLessThanOrEq:
+		default:
+			return fmt.Errorf("having operator %s is not supported", having.Operator)
+		}
+		if _, ok := having.Value.(float64); !ok {
+			return fmt.Errorf("having value must be a number")
+		}
+	}
+	return nil
+}
+
+// ResultStepInterval returns the interval of the points of the query result in seconds
+func (b *BuilderQuery) ResultStepInterval() int64 {
+	if b.NestedAggregation != nil && b.NestedAggregation.OverTime() {
+		return b.NestedAggregation.StepInterval
+	}
+	return b.StepInterval
+}
+
+// FormulaJoin joins the series of the queries of a formula grouped by different labels,
+// e.g. errors by service and endpoint divided by requests by service. The series of one
+// query at most can match several series of the others, the result keeps their labels
+type FormulaJoin struct {
+	// On lists the labels the series are matched on
+	On []string `json:"on"`
+	// Labels renames the labels of the queries to the join labels, keyed by query name,
+	// for queries grouping by differently named attributes
+	Labels map[string]map[string]string `json:"labels,omitempty"`
+}
+
+func (j *FormulaJoin) Validate() error {
+	if len(j.On) == 0 {
+		return fmt.Errorf("join labels are required")
+	}
+	for _, l := range j.On {
+		if l == "" {
+			return fmt.Errorf("join label can't be empty")
+		}
+	}
+	for queryName, renames := range j.Labels {
+		for from, to := range renames {
+			if from == "" || to == "" {
+				return fmt.Errorf("join label renames of query %s can't be empty", queryName)
+			}
+		}
+	}
+	return nil
 }
 
 // CanDefaultZero returns true if the missing value can be substituted by zero
@@ -797,6 +1018,33 @@ func (b *BuilderQuery) Validate(panelType PanelType) error {
 		return fmt.Errorf("expression is required")
 	}
 
+	if b.NestedAggregation != nil {
+		if b.QueryName != b.Expression || b.DataSource != DataSourceMetrics {
+			return fmt.Errorf("nested aggregation is only supported for metrics queries")
+		}
+		if err := b.NestedAggregation.Validate(b); err != nil {
+			return fmt.Errorf("nested aggregation is invalid: %w", err)
+		}
+	}
+
+	if b.SeriesLimit != nil {
+		if b.QueryName != b.Expression || b.DataSource != DataSourceMetrics {
+			return fmt.Errorf("series limit is only supported for metrics queries")
+		}
+		if err := b.SeriesLimit.Validate(b); err != nil {
+			return fmt.Errorf("series limit is invalid: %w", err)
+		}
+	}
+
+	if b.Join != nil {
+		if b.QueryName == b.Expression {
+			return fmt.Errorf("join is only supported for formulas")
+		}
+		if err := b.Join.Validate(); err != nil {
+			return fmt.Errorf("join is invalid: %w", err)
+		}
+	}
+
 	if len(b.Functions) > 0 {
 		for _, function := range b.Functions {
 			if err := function.Name.Validate(); err != nil {
@@ -808,10 +1056,10 @@ func (b *BuilderQuery) Validate(panelType PanelType) error {
 				}
 				_, ok := function.Args[0].(float64)
 				if !ok {
-					// if string, attempt to convert to float
-					timeShiftBy, err := strconv.ParseFloat(function.Args[0].(string), 64)
+					// if string, attempt to convert to float or to a duration such as 1d or 1w
+					timeShiftBy, err := parseTimeShift(function.Args[0])
 					if err != nil {
-						return fmt.Errorf("timeShiftBy param should be a number")
+						return fmt.Errorf("timeShiftBy param should be a number of seconds or a duration")
 					}
 					function.Args[0] = timeShiftBy
 				}
@@ -916,8 +1164,62 @@ const (
 
 	FilterOperatorHas    FilterOperator = "has"
 	FilterOperatorNotHas FilterOperator = "nhas"
+
+	// the full text operators of the logs: the words of the phrase in order, the words
+	// matching a wildcard pattern such as conn* and the words within an edit distance of a
+	// term such as conection~1
+	FilterOperatorPhrase      FilterOperator = "phrase"
+	FilterOperatorNotPhrase   FilterOperator = "nphrase"
+	FilterOperatorWildcard    FilterOperator = "wildcard"
+	FilterOperatorNotWildcard FilterOperator = "nwildcard"
+	FilterOperatorFuzzy       FilterOperator = "fuzzy"
+	FilterOperatorNotFuzzy    FilterOperator = "nfuzzy"
 )
 
+// IsStringMatch tells whether the operator matches the text of the values, the values of
+// the keys of the other data types are matched as strings
+func (op FilterOperator) IsStringMatch() bool {
+	switch op {
+	case FilterOperatorRegex, FilterOperatorNotRegex, FilterOperatorContains, FilterOperatorNotContains:
+		return true
+	}
+	return false
+}
+
+// FilterOperators returns the operators the filters of the data source support on the keys
+// of the data type, the same regex, contains and exists operators for all the signals. None
+// are returned for an invalid data type
+func FilterOperators(dataSource DataSource, dataType AttributeKeyDataType) []FilterOperator {
+	switch dataType {
+	case AttributeKeyDataTypeArrayString, AttributeKeyDataTypeArrayInt64, AttributeKeyDataTypeArrayFloat64, AttributeKeyDataTypeArrayBool:
+		return []FilterOperator{FilterOperatorHas, FilterOperatorNotHas, FilterOperatorExists, FilterOperatorNotExists}
+	case AttributeKeyDataTypeUnspecified, AttributeKeyDataTypeString, AttributeKeyDataTypeInt64, AttributeKeyDataTypeFloat64, AttributeKeyDataTypeBool:
+	default:
+		return nil
+	}
+	operators := []FilterOperator{
+		FilterOperatorEqual, FilterOperatorNotEqual, FilterOperatorIn, FilterOperatorNotIn,
+		FilterOperatorRegex, FilterOperatorNotRegex, FilterOperatorContains, FilterOperatorNotContains,
+		FilterOperatorExists, FilterOperatorNotExists,
+	}
+	if dataType != AttributeKeyDataTypeBool {
+		operators = append(operators, FilterOperatorLessThan, FilterOperatorLessThanOrEq,
+			FilterOperatorGreaterThan, FilterOperatorGreaterThanOrEq)
+	}
+	if dataType == AttributeKeyDataTypeString || dataType == AttributeKeyDataTypeUnspecified {
+		operators = append(operators, FilterOperatorLike, FilterOperatorNotLike)
+		if dataSource == DataSourceLogs {
+			operators = append(operators, FilterOperatorPhrase, FilterOperatorNotPhrase, FilterOperatorWildcard,
+				FilterOperatorNotWildcard, FilterOperatorFuzzy, FilterOperatorNotFuzzy)
+		}
+	}
+	return operators
+}
+
+type FilterOperatorsResponse struct {
+	Operators []FilterOperator `json:"operators"`
+}
+
 type FilterItem struct {
 	Key      AttributeKey   `json:"key"`
 	Value    interface{}    `json:"value"`
@@ -989,6 +1291,44 @@ type QueryRangeResponse struct {
 	ContextTimeoutMessage string    `json:"contextTimeoutMessage,omitempty"`
 	ResultType            string    `json:"resultType"`
 	Result                []*Result `json:"result"`
+	// Deployments are the deployments of the services of the queries in the range, the
+	// charts overlay them
+	Deployments []DeploymentMarker `json:"deployments,omitempty"`
+	// Comparisons are the results of the queries over the shifted ranges, with their
+	// timestamps moved forward onto the range
+	Comparisons []ComparisonResult `json:"comparisons,omitempty"`
+	// Events are the structured events selected by the events filter of the request in the
+	// range, the charts overlay them
+	Events []EventMarker `json:"events,omitempty"`
+}
+
+// ComparisonResult is the result of the queries over the range shifted back by the offset
+type ComparisonResult struct {
+	Offset string    `json:"offset"`
+	Result []*Result `json:"result"`
+}
+
+// DeploymentMarker is a deployment of a version of a service, the timestamp is in
+// milliseconds
+type DeploymentMarker struct {
+	Id          string `json:"id"`
+	ServiceName string `json:"serviceName"`
+	Version     string `json:"version"`
+	Environment string `json:"environment,omitempty"`
+	Timestamp   int64  `json:"timestamp"`
+	Source      string `json:"source"`
+}
+
+// EventMarker is a structured event, the timestamp is in milliseconds
+type EventMarker struct {
+	Id          string            `json:"id"`
+	Name        string            `json:"name"`
+	Category    string            `json:"category"`
+	ServiceName string            `json:"serviceName,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	Timestamp   int64             `json:"timestamp"`
 }
 
 type TableColumn struct {
@@ -1028,6 +1368,16 @@ type Series struct {
 	Labels      map[string]string   `json:"labels"`
 	LabelsArray []map[string]string `json:"labelsArray"`
 	Points      []Point             `json:"values"`
+	Exemplars   []Exemplar          `json:"exemplars,omitempty"`
+}
+
+// Exemplar is a sample of a metric point which links it to the span it was recorded in
+type Exemplar struct {
+	Timestamp int64             `json:"timestamp"`
+	Value     float64           `json:"value"`
+	TraceId   string            `json:"traceId"`
+	SpanId    string            `json:"spanId"`
+	Labels    map[string]string `json:"labels,omitempty"`
 }
 
 func (s *Series) SortPoints() {
@@ -1113,6 +1463,56 @@ type SavedView struct {
 	CompositeQuery *CompositeQuery `json:"compositeQuery"`
 	// ExtraData is JSON encoded data used by frontend to store additional data
 	ExtraData string `json:"extraData"`
+	// Visibility is either private to the creator or shared with the org
+	Visibility SavedViewVisibility `json:"visibility"`
+	// Columns are the attributes shown in the list view of the explorer
+	Columns   []AttributeKey      `json:"columns"`
+	TimeRange *SavedViewTimeRange `json:"timeRange,omitempty"`
+	// IsDefault is set when the view is the default view of the user for the source page
+	IsDefault bool `json:"isDefault"`
+}
+
+type SavedViewVisibility string
+
+const (
+	SavedViewVisibilityPrivate SavedViewVisibility = "private"
+	SavedViewVisibilityOrg     SavedViewVisibility = "org"
+)
+
+type SavedViewTimeRangeType string
+
+const (
+	// SavedViewTimeRangeRelative is a range ending now, e.g. the last 15m
+	SavedViewTimeRangeRelative SavedViewTimeRangeType = "relative"
+	// SavedViewTimeRangeAbsolute is a fixed range, start and end are in ms
+	SavedViewTimeRangeAbsolute SavedViewTimeRangeType = "absolute"
+)
+
+var relativeTimeRangeRegex = regexp.MustCompile(`^[1-9][0-9]*[mhdw]$`)
+
+// SavedViewTimeRange is the time range the explorer is opened with, the
+// relative ranges are durations like 15m, 6h, 1d or 2w
+type SavedViewTimeRange struct {
+	Type     SavedViewTimeRangeType `json:"type"`
+	Relative string                 `json:"relative,omitempty"`
+	Start    int64                  `json:"start,omitempty"`
+	End      int64                  `json:"end,omitempty"`
+}
+
+func (tr *SavedViewTimeRange) Validate() error {
+	switch tr.Type {
+	case SavedViewTimeRangeRelative:
+		if !relativeTimeRangeRegex.MatchString(tr.Relative) {
+			return fmt.Errorf("invalid relative time range %s, use a duration like 15m, 6h, 1d or 2w", tr.Relative)
+		}
+	case SavedViewTimeRangeAbsolute:
+		if tr.Start <= 0 || tr.End <= tr.Start {
+			return fmt.Errorf("start must be before end for an absolute time range")
+		}
+	default:
+		return fmt.Errorf("invalid time range type %s", tr.Type)
+	}
+	return nil
 }
 
 func (eq *SavedView) Validate() error {
@@ -1121,6 +1521,20 @@ func (eq *SavedView) Validate() error {
 		return fmt.Errorf("composite query is required")
 	}
 
+	switch eq.Visibility {
+	case "":
+		eq.Visibility = SavedViewVisibilityOrg
+	case SavedViewVisibilityPrivate, SavedViewVisibilityOrg:
+	default:
+		return fmt.Errorf("invalid visibility %s", eq.Visibility)
+	}
+
+	if eq.TimeRange != nil {
+		if err := eq.TimeRange.Validate(); err != nil {
+			return err
+		}
+	}
+
 	if eq.UUID == "" {
 		eq.UUID = uuid.New().String()
 	}
@@ -1141,3 +1555,139 @@ type MetricMetadataResponse struct {
 	IsMonotonic bool      `json:"isMonotonic"`
 	Temporality string    `json:"temporality"`
 }
+
+const (
+	MinFunnelSteps = 2
+	MaxFunnelSteps = 10
+
+	DefaultFunnelTraces = 10000
+	MaxFunnelTraces     = 100000
+)
+
+// FunnelStep is a step of a funnel, the spans matching the filters complete the step
+type FunnelStep struct {
+	Name    string     `json:"name"`
+	Filters *FilterSet `json:"filters"`
+}
+
+// FunnelParams are the ordered steps of a funnel and the range, in ms, of the spans of the
+// traces going through it
+type FunnelParams struct {
+	Start int64        `json:"start"`
+	End   int64        `json:"end"`
+	Steps []FunnelStep `json:"steps"`
+	// Limit is the number of traces entering the funnel past which the traces are ignored
+	Limit int `json:"limit"`
+}
+
+func (p *FunnelParams) Validate() error {
+	if p.Start <= 0 || p.End <= p.Start {
+		return fmt.Errorf("start and end are required and end must be after start")
+	}
+	if len(p.Steps) < MinFunnelSteps || len(p.Steps) > MaxFunnelSteps {
+		return fmt.Errorf("a funnel has between %d and %d steps", MinFunnelSteps, MaxFunnelSteps)
+	}
+	for i, step := range p.Steps {
+		if step.Filters == nil || len(step.Filters.Items) == 0 {
+			return fmt.Errorf("step %d has no filters", i+1)
+		}
+		if err := step.Filters.Validate(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	if p.Limit == 0 {
+		p.Limit = DefaultFunnelTraces
+	}
+	if p.Limit < 0 || p.Limit > MaxFunnelTraces {
+		return fmt.Errorf("limit must be between 1 and %d", MaxFunnelTraces)
+	}
+	return nil
+}
+
+// FunnelTrace has the start times in ns, in ascending order, of the spans of a trace
+// matching each step of a funnel
+type FunnelTrace struct {
+	TraceID string    `ch:"traceID"`
+	Steps   [][]int64 `ch:"steps"`
+}
+
+// Orders of the span groups
+const (
+	SpanGroupOrderCount     = "count"
+	SpanGroupOrderErrorRate = "errorRate"
+	SpanGroupOrderAvg       = "avg"
+	SpanGroupOrderP50       = "p50"
+	SpanGroupOrderP90       = "p90"
+	SpanGroupOrderP99       = "p99"
+
+	MaxSpanGroupByKeys     = 3
+	DefaultSpanGroupsLimit = 100
+	MaxSpanGroupsLimit     = 1000
+)
+
+// SpanGroupsParams select the spans of the range, in ms, matching the filters and group them
+// on the values of the attributes
+type SpanGroupsParams struct {
+	Start   int64          `json:"start"`
+	End     int64          `json:"end"`
+	Filters *FilterSet     `json:"filters"`
+	GroupBy []AttributeKey `json:"groupBy"`
+	// OrderBy is one of the span group orders, the groups with the most spans come first
+	// by default
+	OrderBy string `json:"orderBy"`
+	Order   string `json:"order"`
+	Limit   int    `json:"limit"`
+}
+
+func (p *SpanGroupsParams) Validate() error {
+	if p.Start <= 0 || p.End <= p.Start {
+		return fmt.Errorf("start and end are required and end must be after start")
+	}
+	if len(p.GroupBy) == 0 || len(p.GroupBy) > MaxSpanGroupByKeys {
+		return fmt.Errorf("groupBy must have between 1 and %d keys", MaxSpanGroupByKeys)
+	}
+	for _, key := range p.GroupBy {
+		if key.Key == "" {
+			return fmt.Errorf("groupBy key is required")
+		}
+	}
+	if err := p.Filters.Validate(); err != nil {
+		return err
+	}
+	switch p.OrderBy {
+	case "":
+		p.OrderBy = SpanGroupOrderCount
+	case SpanGroupOrderCount, SpanGroupOrderErrorRate, SpanGroupOrderAvg, SpanGroupOrderP50, SpanGroupOrderP90, SpanGroupOrderP99:
+	default:
+		return fmt.Errorf("invalid orderBy %q", p.OrderBy)
+	}
+	switch strings.ToLower(p.Order) {
+	case "":
+		p.Order = "desc"
+	case "asc", "desc":
+		p.Order = strings.ToLower(p.Order)
+	default:
+		return fmt.Errorf("order must be asc or desc")
+	}
+	if p.Limit == 0 {
+		p.Limit = DefaultSpanGroupsLimit
+	}
+	if p.Limit < 0 || p.Limit > MaxSpanGroupsLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxSpanGroupsLimit)
+	}
+	return nil
+}
+
+// SpanGroup is the number of spans, the errors and the latency percentiles, in ns, of the
+// spans with the same values of the group by attributes
+type SpanGroup struct {
+	Values    []string          `json:"-" ch:"groupValues"`
+	Labels    map[string]string `json:"labels"`
+	Count     uint64            `json:"count" ch:"spans"`
+	Errors    uint64            `json:"errors" ch:"errors"`
+	ErrorRate float64           `json:"errorRate" ch:"errorRate"`
+	Avg       float64           `json:"avg" ch:"avgDuration"`
+	P50       float64           `json:"p50" ch:"p50"`
+	P90       float64           `json:"p90" ch:"p90"`
+	P99       float64           `json:"p99" ch:"p99"`
+}
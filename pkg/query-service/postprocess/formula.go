@@ -70,34 +70,44 @@ func joinAndCalculate(
 	canDefaultZero map[string]bool,
 ) (*v3.Series, error) {
 
-	uniqueTimestamps := make(map[int64]struct{})
-	// map[queryName]map[timestamp]value
-	seriesMap := make(map[string]map[int64]float64)
+	matching := make(map[string]*v3.Series)
 	for _, result := range results {
-		var matchingSeries *v3.Series
 		// We try to find a series that matches the label set from the current query result
 		for _, series := range result.Series {
 			if isSubset(uniqueLabelSet, series.Labels) {
-				matchingSeries = series
+				matching[result.QueryName] = series
 				break
 			}
 		}
+	}
+	return calculate(uniqueLabelSet, matching, expression, canDefaultZero)
+}
+
+// calculate evaluates the expression at each timestamp of the series matched for each query
+func calculate(
+	labelSet map[string]string,
+	matching map[string]*v3.Series,
+	expression *govaluate.EvaluableExpression,
+	canDefaultZero map[string]bool,
+) (*v3.Series, error) {
 
+	uniqueTimestamps := make(map[int64]struct{})
+	// map[queryName]map[timestamp]value
+	seriesMap := make(map[string]map[int64]float64)
+	for queryName, matchingSeries := range matching {
 		// Prepare the seriesMap for quick lookup during evaluation
 		// seriesMap[queryName][timestamp]value contains the value of the series with the given queryName at the given timestamp
-		if matchingSeries != nil {
-			for _, point := range matchingSeries.Points {
-				if _, ok := seriesMap[result.QueryName]; !ok {
-					seriesMap[result.QueryName] = make(map[int64]float64)
-				}
-				seriesMap[result.QueryName][point.Timestamp] = point.Value
-				uniqueTimestamps[point.Timestamp] = struct{}{}
+		for _, point := range matchingSeries.Points {
+			if _, ok := seriesMap[queryName]; !ok {
+				seriesMap[queryName] = make(map[int64]float64)
 			}
+			seriesMap[queryName][point.Timestamp] = point.Value
+			uniqueTimestamps[point.Timestamp] = struct{}{}
 		}
 	}
 
 	resultSeries := &v3.Series{
-		Labels: uniqueLabelSet,
+		Labels: labelSet,
 		Points: make([]v3.Point, 0),
 	}
 	timestamps := make([]int64, 0)
@@ -12,11 +12,11 @@ func StepIntervalForFunction(params *v3.QueryRangeParamsV3, query string) int64
 		expression, _ := govaluate.NewEvaluableExpressionWithFunctions(q.Expression, EvalFuncs())
 		steps := []int64{}
 		for _, v := range expression.Vars() {
-			steps = append(steps, params.CompositeQuery.BuilderQueries[v].StepInterval)
+			steps = append(steps, params.CompositeQuery.BuilderQueries[v].ResultStepInterval())
 		}
 		return common.LCMList(steps)
 	}
-	return q.StepInterval
+	return q.ResultStepInterval()
 }
 
 func fillGap(series *v3.Series, start, end, step int64) *v3.Series {
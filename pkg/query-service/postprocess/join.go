@@ -0,0 +1,131 @@
+package postprocess
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/SigNoz/govaluate"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// joinKey returns the values of the join labels of the series, after renaming its labels
+// for the query, and its labels renamed
+func joinKey(series *v3.Series, on []string, renames map[string]string) (string, map[string]string) {
+	labels := make(map[string]string, len(series.Labels))
+	for k, v := range series.Labels {
+		if name, ok := renames[k]; ok {
+			k = name
+		}
+		labels[k] = v
+	}
+	values := make([]string, len(on))
+	for i, l := range on {
+		values[i] = l + "=" + labels[l]
+	}
+	return strings.Join(values, ","), labels
+}
+
+// processJoinResults evaluates a formula on the series of its queries matched on the join
+// labels. The series match when their join labels are equal, the series of one query can
+// match several series of the other queries, a result series is made for each of them
+// with its labels. The series without a match in the queries that can't default to zero
+// are dropped, as with an inner join
+func processJoinResults(
+	results []*v3.Result,
+	expression *govaluate.EvaluableExpression,
+	canDefaultZero map[string]bool,
+	join *v3.FormulaJoin,
+) (*v3.Result, error) {
+
+	queriesInExpression := make(map[string]struct{})
+	for _, v := range expression.Vars() {
+		queriesInExpression[v] = struct{}{}
+	}
+
+	type joined struct {
+		labels map[string]string
+		// series of each query
+		series map[string][]*v3.Series
+		// labels of each series, renamed
+		seriesLabels map[*v3.Series]map[string]string
+	}
+	groups := make(map[string]*joined)
+	keys := make([]string, 0)
+	for _, result := range results {
+		if _, ok := queriesInExpression[result.QueryName]; !ok {
+			continue
+		}
+		for _, series := range result.Series {
+			key, labels := joinKey(series, join.On, join.Labels[result.QueryName])
+			group, ok := groups[key]
+			if !ok {
+				group = &joined{
+					labels:       make(map[string]string),
+					series:       make(map[string][]*v3.Series),
+					seriesLabels: make(map[*v3.Series]map[string]string),
+				}
+				for _, l := range join.On {
+					if v, ok := labels[l]; ok {
+						group.labels[l] = v
+					}
+				}
+				groups[key] = group
+				keys = append(keys, key)
+			}
+			group.series[result.QueryName] = append(group.series[result.QueryName], series)
+			group.seriesLabels[series] = labels
+		}
+	}
+	sort.Strings(keys)
+
+	newSeries := make([]*v3.Series, 0)
+	for _, key := range keys {
+		group := groups[key]
+		// the query with several series for the join labels, one at most
+		many := ""
+		for queryName, series := range group.series {
+			if len(series) < 2 {
+				continue
+			}
+			if many != "" {
+				return nil, fmt.Errorf("queries %s and %s both have several series for the join labels %s, group one of them by the join labels only", many, queryName, key)
+			}
+			many = queryName
+		}
+
+		matchings := []map[string]*v3.Series{{}}
+		labelSets := []map[string]string{group.labels}
+		if many != "" {
+			matchings, labelSets = matchings[:0], labelSets[:0]
+			for _, series := range group.series[many] {
+				matchings = append(matchings, map[string]*v3.Series{many: series})
+				labelSets = append(labelSets, group.seriesLabels[series])
+			}
+		}
+		for i, matching := range matchings {
+			for queryName, series := range group.series {
+				if queryName != many {
+					matching[queryName] = series[0]
+				}
+			}
+			series, err := calculate(labelSets[i], matching, expression, canDefaultZero)
+			if err != nil {
+				return nil, err
+			}
+			if len(series.Points) == 0 {
+				continue
+			}
+			labelsArray := make([]map[string]string, 0)
+			for k, v := range series.Labels {
+				labelsArray = append(labelsArray, map[string]string{k: v})
+			}
+			series.LabelsArray = labelsArray
+			newSeries = append(newSeries, series)
+		}
+	}
+
+	return &v3.Result{
+		Series: newSeries,
+	}, nil
+}
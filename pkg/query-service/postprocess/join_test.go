@@ -0,0 +1,104 @@
+package postprocess
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/SigNoz/govaluate"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestProcessJoinResults(t *testing.T) {
+	// errors by service and endpoint, requests by service under another attribute name
+	results := []*v3.Result{
+		{
+			QueryName: "A",
+			Series: []*v3.Series{
+				{
+					Labels: map[string]string{"service_name": "frontend", "endpoint": "/cart"},
+					Points: []v3.Point{{Timestamp: 1, Value: 5}, {Timestamp: 2, Value: 10}},
+				},
+				{
+					Labels: map[string]string{"service_name": "frontend", "endpoint": "/checkout"},
+					Points: []v3.Point{{Timestamp: 1, Value: 20}},
+				},
+				{
+					Labels: map[string]string{"service_name": "redis", "endpoint": "GET"},
+					Points: []v3.Point{{Timestamp: 1, Value: 1}},
+				},
+			},
+		},
+		{
+			QueryName: "B",
+			Series: []*v3.Series{
+				{
+					Labels: map[string]string{"service": "frontend"},
+					Points: []v3.Point{{Timestamp: 1, Value: 100}, {Timestamp: 2, Value: 50}},
+				},
+				{
+					Labels: map[string]string{"service": "mysql"},
+					Points: []v3.Point{{Timestamp: 1, Value: 10}},
+				},
+			},
+		},
+	}
+	join := &v3.FormulaJoin{
+		On:     []string{"service_name"},
+		Labels: map[string]map[string]string{"B": {"service": "service_name"}},
+	}
+	if err := join.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	expression, err := govaluate.NewEvaluableExpression("A/B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := processJoinResults(results, expression, map[string]bool{}, join)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*v3.Series{
+		{
+			Labels: map[string]string{"service_name": "frontend", "endpoint": "/cart"},
+			Points: []v3.Point{{Timestamp: 1, Value: 0.05}, {Timestamp: 2, Value: 0.2}},
+		},
+		{
+			Labels: map[string]string{"service_name": "frontend", "endpoint": "/checkout"},
+			Points: []v3.Point{{Timestamp: 1, Value: 0.2}},
+		},
+	}
+	if len(got.Series) != len(want) {
+		t.Fatalf("processJoinResults(): number of series - got = %v, want %v", len(got.Series), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got.Series[i].Labels, want[i].Labels) {
+			t.Errorf("processJoinResults(): labels - got = %v, want %v", got.Series[i].Labels, want[i].Labels)
+		}
+		if !reflect.DeepEqual(got.Series[i].Points, want[i].Points) {
+			t.Errorf("processJoinResults(): points - got = %v, want %v", got.Series[i].Points, want[i].Points)
+		}
+	}
+
+	// the missing requests of redis default to zero for a count
+	expression, err = govaluate.NewEvaluableExpression("A+B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = processJoinResults(results, expression, map[string]bool{"B": true}, join)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Series) != 3 {
+		t.Errorf("processJoinResults(): number of series - got = %v, want 3", len(got.Series))
+	}
+
+	// both queries have several series for a service
+	results[1].Series = append(results[1].Series, &v3.Series{
+		Labels: map[string]string{"service": "frontend", "method": "POST"},
+		Points: []v3.Point{{Timestamp: 1, Value: 1}},
+	})
+	if _, err = processJoinResults(results, expression, map[string]bool{}, join); err == nil {
+		t.Errorf("processJoinResults(): expected a many to many join error")
+	}
+}
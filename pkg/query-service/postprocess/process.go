@@ -58,7 +58,12 @@ func PostProcessResult(result []*v3.Result, queryRangeParams *v3.QueryRangeParam
 				zap.L().Error("error in expression", zap.Error(err))
 				return nil, err
 			}
-			formulaResult, err := processResults(result, expression, canDefaultZero)
+			var formulaResult *v3.Result
+			if query.Join != nil {
+				formulaResult, err = processJoinResults(result, expression, canDefaultZero, query.Join)
+			} else {
+				formulaResult, err = processResults(result, expression, canDefaultZero)
+			}
 			if err != nil {
 				zap.L().Error("error in expression", zap.Error(err))
 				return nil, err
@@ -104,9 +109,10 @@ func ApplyFunctions(results []*v3.Result, queryRangeParams *v3.QueryRangeParamsV
 
 		if builderQueries != nil {
 			functions := builderQueries[result.QueryName].Functions
+			step := StepIntervalForFunction(queryRangeParams, result.QueryName)
 
 			for _, function := range functions {
-				results[idx] = queryBuilder.ApplyFunction(function, result)
+				results[idx] = queryBuilder.ApplyFunction(function, result, queryRangeParams.Start, queryRangeParams.End, step)
 			}
 		}
 	}
@@ -27,6 +27,7 @@ type RuleType string
 const (
 	RuleTypeThreshold = "threshold_rule"
 	RuleTypeProm      = "promql_rule"
+	RuleTypeComposite = "composite_rule"
 )
 
 type RuleHealth string
@@ -130,8 +131,32 @@ const (
 	AllTheTimes   MatchType = "2"
 	OnAverage     MatchType = "3"
 	InTotal       MatchType = "4"
+	// InForecast matches the trend of the series extended by the forecast window
+	InForecast MatchType = "5"
+	// AsOutlier matches the series whose mean deviates from the means of the other series of
+	// the query by more than the target, in standard deviations
+	AsOutlier MatchType = "6"
 )
 
+// ConditionOp is the boolean operator used to combine
+// the conditions of a multi-condition rule
+type ConditionOp string
+
+const (
+	ConditionOpAnd ConditionOp = "AND"
+	ConditionOpOr  ConditionOp = "OR"
+)
+
+// QueryCondition is a threshold condition evaluated against
+// a single query (or formula) of the rule's composite query
+type QueryCondition struct {
+	SelectedQuery string    `yaml:"selectedQueryName,omitempty" json:"selectedQueryName,omitempty"`
+	CompareOp     CompareOp `yaml:"op,omitempty" json:"op,omitempty"`
+	Target        *float64  `yaml:"target,omitempty" json:"target,omitempty"`
+	MatchType     MatchType `yaml:"matchType,omitempty" json:"matchType,omitempty"`
+	TargetUnit    string    `yaml:"targetUnit,omitempty" json:"targetUnit,omitempty"`
+}
+
 type RuleCondition struct {
 	CompositeQuery *v3.CompositeQuery `json:"compositeQuery,omitempty" yaml:"compositeQuery,omitempty"`
 	CompareOp      CompareOp          `yaml:"op,omitempty" json:"op,omitempty"`
@@ -141,15 +166,44 @@ type RuleCondition struct {
 	MatchType      MatchType          `json:"matchType,omitempty"`
 	TargetUnit     string             `json:"targetUnit,omitempty"`
 	SelectedQuery  string             `json:"selectedQueryName,omitempty"`
+	// ForecastWindow is how far the trend of the series is extended past the evaluation
+	// window by the forecast match type, e.g. 6h fires when the threshold is crossed within
+	// six hours
+	ForecastWindow Duration `yaml:"forecastWindow,omitempty" json:"forecastWindow,omitempty"`
+
+	// Conditions, when set, replace the single op/target/matchType above.
+	// Each condition is evaluated against its own query and the outcomes
+	// are combined per label set using ConditionOp (AND by default).
+	Conditions  []*QueryCondition `yaml:"conditions,omitempty" json:"conditions,omitempty"`
+	ConditionOp ConditionOp       `yaml:"conditionOp,omitempty" json:"conditionOp,omitempty"`
+
+	// Composite, when set, fires on the states of other rules instead of a query
+	Composite *CompositeCondition `yaml:"composite,omitempty" json:"composite,omitempty"`
+}
+
+// IsMultiCondition returns true when the rule combines
+// more than one query condition
+func (rc *RuleCondition) IsMultiCondition() bool {
+	return rc != nil && len(rc.Conditions) > 0
 }
 
 func (rc *RuleCondition) IsValid() bool {
 
+	if rc.IsComposite() {
+		return len(rc.Composite.validate()) == 0
+	}
+
 	if rc.CompositeQuery == nil {
 		return false
 	}
 
-	if rc.QueryType() == v3.QueryTypeBuilder {
+	if rc.IsMultiCondition() {
+		for _, c := range rc.Conditions {
+			if c == nil || c.Target == nil || c.CompareOp == "" {
+				return false
+			}
+		}
+	} else if rc.QueryType() == v3.QueryTypeBuilder {
 		if rc.Target == nil {
 			return false
 		}
@@ -157,6 +211,9 @@ func (rc *RuleCondition) IsValid() bool {
 			return false
 		}
 	}
+	if !rc.validForecast() || !rc.validOutlier() {
+		return false
+	}
 	if rc.QueryType() == v3.QueryTypePromQL {
 
 		if len(rc.CompositeQuery.PromQueries) == 0 {
@@ -8,6 +8,8 @@ import (
 	"unicode/utf8"
 
 	"github.com/pkg/errors"
+	promModel "github.com/prometheus/common/model"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
 	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 
@@ -37,6 +39,9 @@ type PostableRule struct {
 	RuleType    RuleType `yaml:"ruleType,omitempty" json:"ruleType,omitempty"`
 	EvalWindow  Duration `yaml:"evalWindow,omitempty" json:"evalWindow,omitempty"`
 	Frequency   Duration `yaml:"frequency,omitempty" json:"frequency,omitempty"`
+	// EvalDelay is how far behind the evaluation time the queries of the rule end, to
+	// account for the ingestion lag. The default delay is used when it isn't set
+	EvalDelay *Duration `yaml:"evalDelay,omitempty" json:"evalDelay,omitempty"`
 
 	RuleCondition *RuleCondition    `yaml:"condition,omitempty" json:"condition,omitempty"`
 	Labels        map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
@@ -47,8 +52,24 @@ type PostableRule struct {
 	// Source captures the source url where rule has been created
 	Source string `json:"source,omitempty"`
 
+	// ExternalId is the id given to the rule by the client, e.g. an infrastructure as code
+	// tool, creating a rule again with the external id of a rule of the org replaces it
+	ExternalId string `yaml:"externalId,omitempty" json:"externalId,omitempty"`
+
+	// OrgId is the org the rule belongs to, the queries of the rules of the orgs other
+	// than the default org are scoped to their org
+	OrgId string `yaml:"-" json:"-"`
+
 	PreferredChannels []string `json:"preferredChannels,omitempty"`
 
+	// NotificationSettings controls how the alerts of the rule are
+	// grouped and repeated by the alertmanager
+	NotificationSettings *NotificationSettings `yaml:"notificationSettings,omitempty" json:"notificationSettings,omitempty"`
+
+	// NotificationTemplate is a go template rendered into the message
+	// annotation of the alerts, it replaces the default channel format
+	NotificationTemplate string `yaml:"notificationTemplate,omitempty" json:"notificationTemplate,omitempty"`
+
 	Version string `json:"version,omitempty"`
 
 	// legacy
@@ -108,7 +129,12 @@ func parseIntoRule(initRule PostableRule, content []byte, kind string) (*Postabl
 		rule.Frequency = Duration(1 * time.Minute)
 	}
 
-	if rule.RuleCondition != nil {
+	if rule.RuleCondition.IsComposite() {
+		rule.RuleType = RuleTypeComposite
+		if rule.RuleCondition.Composite.Op == "" {
+			rule.RuleCondition.Composite.Op = ConditionOpAnd
+		}
+	} else if rule.RuleCondition != nil && rule.RuleCondition.CompositeQuery != nil {
 		if rule.RuleCondition.CompositeQuery.QueryType == v3.QueryTypeBuilder {
 			rule.RuleType = RuleTypeThreshold
 		} else if rule.RuleCondition.CompositeQuery.QueryType == v3.QueryTypePromQL {
@@ -120,6 +146,10 @@ func parseIntoRule(initRule PostableRule, content []byte, kind string) (*Postabl
 				q.Expression = qLabel
 			}
 		}
+
+		if rule.RuleCondition.IsMultiCondition() && rule.RuleCondition.ConditionOp == "" {
+			rule.RuleCondition.ConditionOp = ConditionOpAnd
+		}
 	}
 
 	if errs := rule.Validate(); len(errs) > 0 {
@@ -149,13 +179,17 @@ func (r *PostableRule) Validate() (errs []error) {
 
 	if r.RuleCondition == nil {
 		errs = append(errs, errors.Errorf("rule condition is required"))
+	} else if r.RuleCondition.IsComposite() {
+		errs = append(errs, r.RuleCondition.Composite.validate()...)
 	} else {
 		if r.RuleCondition.CompositeQuery == nil {
 			errs = append(errs, errors.Errorf("composite metric query is required"))
 		}
 	}
 
-	if r.RuleType == RuleTypeThreshold {
+	if r.RuleCondition != nil && r.RuleCondition.IsMultiCondition() {
+		errs = append(errs, r.validateConditions()...)
+	} else if r.RuleType == RuleTypeThreshold {
 		if r.RuleCondition.Target == nil {
 			errs = append(errs, errors.Errorf("rule condition missing the threshold"))
 		}
@@ -167,6 +201,23 @@ func (r *PostableRule) Validate() (errs []error) {
 		}
 	}
 
+	if r.RuleType == RuleTypeThreshold && r.Version != "v4" && r.RuleCondition.CompositeQuery != nil {
+		for _, q := range r.RuleCondition.CompositeQuery.BuilderQueries {
+			if q.NestedAggregation != nil {
+				errs = append(errs, errors.Errorf("nested aggregations require the v4 queries"))
+				break
+			}
+			if q.SeriesLimit != nil {
+				errs = append(errs, errors.Errorf("series limits require the v4 queries"))
+				break
+			}
+		}
+	}
+
+	if r.EvalDelay != nil && (*r.EvalDelay < 0 || time.Duration(*r.EvalDelay) > maxEvalDelay) {
+		errs = append(errs, errors.Errorf("the evaluation delay must be between 0 and %s", maxEvalDelay))
+	}
+
 	for k, v := range r.Labels {
 		if !isValidLabelName(k) {
 			errs = append(errs, errors.Errorf("invalid label name: %s", k))
@@ -183,10 +234,122 @@ func (r *PostableRule) Validate() (errs []error) {
 		}
 	}
 
+	if r.NotificationSettings != nil {
+		errs = append(errs, r.NotificationSettings.Validate()...)
+	}
+
+	if r.NotificationTemplate != "" {
+		if err := am.ValidateMessageTemplate(r.NotificationTemplate); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	errs = append(errs, testTemplateParsing(r)...)
 	return errs
 }
 
+// NotificationSettings holds the alertmanager grouping options of a rule,
+// empty values fall back to the alertmanager defaults
+type NotificationSettings struct {
+	GroupBy        []string `yaml:"groupBy,omitempty" json:"groupBy,omitempty"`
+	GroupWait      Duration `yaml:"groupWait,omitempty" json:"groupWait,omitempty"`
+	GroupInterval  Duration `yaml:"groupInterval,omitempty" json:"groupInterval,omitempty"`
+	RepeatInterval Duration `yaml:"repeatInterval,omitempty" json:"repeatInterval,omitempty"`
+}
+
+func (ns *NotificationSettings) IsEmpty() bool {
+	return ns == nil || (len(ns.GroupBy) == 0 && ns.GroupWait == 0 && ns.GroupInterval == 0 && ns.RepeatInterval == 0)
+}
+
+func (ns *NotificationSettings) Validate() (errs []error) {
+	for _, key := range ns.GroupBy {
+		// "..." disables aggregation in alertmanager
+		if key != "..." && !isValidLabelName(key) {
+			errs = append(errs, errors.Errorf("invalid group by label: %s", key))
+		}
+	}
+	if ns.GroupWait < 0 {
+		errs = append(errs, errors.Errorf("group wait must not be negative"))
+	}
+	if ns.GroupInterval < 0 {
+		errs = append(errs, errors.Errorf("group interval must not be negative"))
+	}
+	if ns.RepeatInterval < 0 {
+		errs = append(errs, errors.Errorf("repeat interval must not be negative"))
+	}
+	return errs
+}
+
+// ruleReceiver prepares the alertmanager receiver of the rule from the channels the
+// alerts of the rule are sent to, it is nil when alertmanager delivers none of them
+func (ns *NotificationSettings) ruleReceiver(ruleId string, channels []am.Receiver) (*am.Receiver, error) {
+	receiver, err := am.MergeReceivers(am.RuleReceiverName(ruleId), channels)
+	if err != nil || receiver == nil {
+		return nil, err
+	}
+	// the durations are formatted as the durations of the alertmanager config
+	route := am.RuleRoute(ruleId)
+	route.GroupBy = ns.GroupBy
+	if ns.GroupWait > 0 {
+		route.GroupWait = promModel.Duration(ns.GroupWait).String()
+	}
+	if ns.GroupInterval > 0 {
+		route.GroupInterval = promModel.Duration(ns.GroupInterval).String()
+	}
+	if ns.RepeatInterval > 0 {
+		route.RepeatInterval = promModel.Duration(ns.RepeatInterval).String()
+	}
+	receiver.Route = route
+	return receiver, nil
+}
+
+// validateConditions checks the conditions of a multi-condition rule
+func (r *PostableRule) validateConditions() (errs []error) {
+	if r.RuleType != RuleTypeThreshold {
+		return append(errs, errors.Errorf("multiple conditions are only supported for builder and clickhouse queries"))
+	}
+
+	rc := r.RuleCondition
+	if rc.ConditionOp != ConditionOpAnd && rc.ConditionOp != ConditionOpOr {
+		errs = append(errs, errors.Errorf("invalid condition operator: %s", rc.ConditionOp))
+	}
+
+	for i, c := range rc.Conditions {
+		if c == nil {
+			errs = append(errs, errors.Errorf("condition %d is empty", i))
+			continue
+		}
+		if c.SelectedQuery == "" {
+			errs = append(errs, errors.Errorf("condition %d missing the query name", i))
+		} else if rc.CompositeQuery != nil && !hasQuery(rc.CompositeQuery, c.SelectedQuery) {
+			errs = append(errs, errors.Errorf("condition %d refers to unknown query: %s", i, c.SelectedQuery))
+		}
+		if c.Target == nil {
+			errs = append(errs, errors.Errorf("condition %d missing the threshold", i))
+		}
+		if c.CompareOp == "" {
+			errs = append(errs, errors.Errorf("condition %d missing the compare op", i))
+		}
+		if c.MatchType == "" {
+			errs = append(errs, errors.Errorf("condition %d missing the match option", i))
+		}
+	}
+	return errs
+}
+
+// hasQuery checks if the composite query has a query with the given name
+func hasQuery(cq *v3.CompositeQuery, name string) bool {
+	switch cq.QueryType {
+	case v3.QueryTypeBuilder:
+		_, ok := cq.BuilderQueries[name]
+		return ok
+	case v3.QueryTypeClickHouseSQL:
+		_, ok := cq.ClickHouseQueries[name]
+		return ok
+	}
+	return false
+}
+
 func testTemplateParsing(rl *PostableRule) (errs []error) {
 	if rl.AlertName == "" {
 		// Not an alerting rule.
@@ -264,3 +427,34 @@ type Options struct {
 	Format        string            `json:"format"`
 	SelectColumns []v3.AttributeKey `json:"selectColumns"`
 }
+
+// PreviewRuleRequest is used to evaluate a draft rule over
+// a historical window without saving it
+type PreviewRuleRequest struct {
+	Rule json.RawMessage `json:"rule"`
+	// Start and End of the window in epoch milliseconds
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// FiringInterval is a period during which an alert would have been firing
+type FiringInterval struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+// PreviewSeries is an alert series that would have fired
+// during the preview window
+type PreviewSeries struct {
+	Labels    map[string]string `json:"labels"`
+	MaxValue  float64           `json:"maxValue"`
+	Intervals []FiringInterval  `json:"intervals"`
+}
+
+// PreviewRuleResponse has the outcome of a rule preview
+type PreviewRuleResponse struct {
+	Evaluations        int             `json:"evaluations"`
+	Interval           Duration        `json:"interval"`
+	Series             []PreviewSeries `json:"series"`
+	SampleNotification *am.Alert       `json:"sampleNotification,omitempty"`
+}
@@ -0,0 +1,448 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.signoz.io/signoz/pkg/query-service/utils/times"
+	"go.signoz.io/signoz/pkg/query-service/utils/timestamp"
+	"go.uber.org/zap"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	ErrCompositeRulesMissing = errors.New("a composite rule must combine two rules at least")
+	ErrCompositeSelfRef      = errors.New("a composite rule can't combine itself")
+)
+
+// firingRulesAnnotation lists the names of the rules firing for a composite alert
+const firingRulesAnnotation = "firing_rules"
+
+// CompositeCondition combines the firing states of other rules, e.g. page once when both
+// the latency rule and the error rate rule of a service are firing
+type CompositeCondition struct {
+	RuleIds []string `yaml:"ruleIds" json:"ruleIds"`
+	// Op is AND to fire when all the rules are firing, OR when any of them is
+	Op ConditionOp `yaml:"op,omitempty" json:"op,omitempty"`
+	// GroupBy matches the alerts of the rules on these labels, a composite alert fires for
+	// each value of the labels. A rule is firing as soon as one of its alerts is otherwise
+	GroupBy []string `yaml:"groupBy,omitempty" json:"groupBy,omitempty"`
+}
+
+func (c *CompositeCondition) validate() (errs []error) {
+	seen := map[string]bool{}
+	for _, id := range c.RuleIds {
+		if id == "" || seen[id] {
+			errs = append(errs, errors.Errorf("invalid or duplicate rule id %q in the composite condition", id))
+		}
+		seen[id] = true
+	}
+	if len(seen) < 2 {
+		errs = append(errs, ErrCompositeRulesMissing)
+	}
+	if c.Op != ConditionOpAnd && c.Op != ConditionOpOr {
+		errs = append(errs, errors.Errorf("invalid condition operator: %s", c.Op))
+	}
+	for _, l := range c.GroupBy {
+		if !isValidLabelName(l) {
+			errs = append(errs, errors.Errorf("invalid group by label: %s", l))
+		}
+	}
+	return errs
+}
+
+// IsComposite tells whether the rule combines the states of other rules
+func (rc *RuleCondition) IsComposite() bool {
+	return rc != nil && rc.Composite != nil
+}
+
+// FiringLookup returns the name of the rule and the labels of its firing alerts
+type FiringLookup func(ruleId string) (string, []labels.BaseLabels)
+
+// firingAlerts keeps the firing alerts of the rules evaluated by the manager from their
+// state changes, the composite rules are evaluated against them
+type firingAlerts struct {
+	mtx sync.RWMutex
+	// rule id -> hash of the labels -> labels
+	alerts map[string]map[uint64]labels.BaseLabels
+	names  map[string]string
+}
+
+func newFiringAlerts() *firingAlerts {
+	return &firingAlerts{
+		alerts: map[string]map[uint64]labels.BaseLabels{},
+		names:  map[string]string{},
+	}
+}
+
+// record is a state listener of the manager
+func (f *firingAlerts) record(ctx context.Context, changes ...StateChange) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, c := range changes {
+		f.names[c.RuleId] = c.RuleName
+		switch {
+		case c.To == StateFiring:
+			if _, ok := f.alerts[c.RuleId]; !ok {
+				f.alerts[c.RuleId] = map[uint64]labels.BaseLabels{}
+			}
+			f.alerts[c.RuleId][c.Labels.Hash()] = c.Labels
+		case c.From == StateFiring:
+			delete(f.alerts[c.RuleId], c.Labels.Hash())
+		}
+	}
+}
+
+func (f *firingAlerts) firing(ruleId string) (string, []labels.BaseLabels) {
+	f.mtx.RLock()
+	defer f.mtx.RUnlock()
+	res := make([]labels.BaseLabels, 0, len(f.alerts[ruleId]))
+	for _, l := range f.alerts[ruleId] {
+		res = append(res, l)
+	}
+	return f.names[ruleId], res
+}
+
+// forget drops the alerts of a deleted rule
+func (f *firingAlerts) forget(ruleId string) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	delete(f.alerts, ruleId)
+	delete(f.names, ruleId)
+}
+
+// CompositeRule fires when the rules it combines are firing, it queries no data
+type CompositeRule struct {
+	id            string
+	name          string
+	source        string
+	ruleCondition *RuleCondition
+	labels        labels.Labels
+	annotations   labels.Labels
+
+	preferredChannels []string
+
+	mtx                 sync.Mutex
+	evaluationDuration  time.Duration
+	evaluationTimestamp time.Time
+
+	health    RuleHealth
+	lastError error
+
+	// map of active alerts
+	active map[uint64]*Alert
+
+	lookup FiringLookup
+}
+
+func NewCompositeRule(id string, p *PostableRule, lookup FiringLookup) (*CompositeRule, error) {
+	if !p.RuleCondition.IsComposite() {
+		return nil, fmt.Errorf("no composite condition")
+	}
+	for _, ruleId := range p.RuleCondition.Composite.RuleIds {
+		if ruleId == id {
+			return nil, ErrCompositeSelfRef
+		}
+	}
+
+	r := &CompositeRule{
+		id:                id,
+		name:              p.AlertName,
+		source:            p.Source,
+		ruleCondition:     p.RuleCondition,
+		labels:            labels.FromMap(p.Labels),
+		annotations:       labels.FromMap(p.Annotations),
+		preferredChannels: p.PreferredChannels,
+		health:            HealthUnknown,
+		active:            map[uint64]*Alert{},
+		lookup:            lookup,
+	}
+	zap.L().Info("creating new CompositeRule", zap.String("name", r.name), zap.String("id", r.id))
+	return r, nil
+}
+
+func (r *CompositeRule) ID() string {
+	return r.id
+}
+
+func (r *CompositeRule) Name() string {
+	return r.name
+}
+
+func (r *CompositeRule) Type() RuleType {
+	return RuleTypeComposite
+}
+
+func (r *CompositeRule) Condition() *RuleCondition {
+	return r.ruleCondition
+}
+
+func (r *CompositeRule) GeneratorURL() string {
+	return prepareRuleGeneratorURL(r.ID(), r.source)
+}
+
+func (r *CompositeRule) PreferredChannels() []string {
+	return r.preferredChannels
+}
+
+func (r *CompositeRule) Labels() labels.BaseLabels {
+	return r.labels
+}
+
+func (r *CompositeRule) Annotations() labels.BaseLabels {
+	return r.annotations
+}
+
+func (r *CompositeRule) SetLastError(err error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.lastError = err
+}
+
+func (r *CompositeRule) LastError() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.lastError
+}
+
+func (r *CompositeRule) SetHealth(health RuleHealth) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.health = health
+}
+
+func (r *CompositeRule) Health() RuleHealth {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.health
+}
+
+func (r *CompositeRule) SetEvaluationDuration(dur time.Duration) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.evaluationDuration = dur
+}
+
+func (r *CompositeRule) GetEvaluationDuration() time.Duration {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.evaluationDuration
+}
+
+func (r *CompositeRule) SetEvaluationTimestamp(ts time.Time) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.evaluationTimestamp = ts
+}
+
+func (r *CompositeRule) GetEvaluationTimestamp() time.Time {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.evaluationTimestamp
+}
+
+// State returns the maximum state of alert instances for this rule.
+func (r *CompositeRule) State() AlertState {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	maxState := StateInactive
+	for _, a := range r.active {
+		if a.State > maxState {
+			maxState = a.State
+		}
+	}
+	return maxState
+}
+
+func (r *CompositeRule) ActiveAlerts() []*Alert {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	var res []*Alert
+	for _, a := range r.active {
+		if a.ResolvedAt.IsZero() {
+			anew := *a
+			res = append(res, &anew)
+		}
+	}
+	return res
+}
+
+// ForEachActiveAlert runs the given function on each alert.
+func (r *CompositeRule) ForEachActiveAlert(f func(*Alert)) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for _, a := range r.active {
+		f(a)
+	}
+}
+
+func (r *CompositeRule) SendAlerts(ctx context.Context, ts time.Time, resendDelay time.Duration, interval time.Duration, notifyFunc NotifyFunc) {
+	alerts := []*Alert{}
+	r.ForEachActiveAlert(func(alert *Alert) {
+		if alert.needsSending(ts, resendDelay) {
+			alert.LastSentAt = ts
+			// Allow for two Eval or Alertmanager send failures.
+			delta := resendDelay
+			if interval > resendDelay {
+				delta = interval
+			}
+			alert.ValidUntil = ts.Add(4 * delta)
+			anew := *alert
+			alerts = append(alerts, &anew)
+		}
+	})
+	notifyFunc(ctx, "", alerts...)
+}
+
+// compositeMatch is a label set of the group by labels and the rules firing for it
+type compositeMatch struct {
+	labels labels.Labels
+	rules  []string
+}
+
+// match groups the firing alerts of each rule by the group by labels and combines the
+// rules firing for each group with the op
+func (r *CompositeRule) match() []*compositeMatch {
+	cond := r.ruleCondition.Composite
+	groups := map[uint64]*compositeMatch{}
+	var order []uint64
+	for _, ruleId := range cond.RuleIds {
+		name, firing := r.lookup(ruleId)
+		if name == "" {
+			name = ruleId
+		}
+		seen := map[uint64]bool{}
+		for _, alert := range firing {
+			var lbls labels.Labels
+			for _, l := range cond.GroupBy {
+				if v := alert.Get(l); v != "" {
+					lbls = append(lbls, labels.Label{Name: l, Value: v})
+				}
+			}
+			h := lbls.Hash()
+			if seen[h] {
+				continue
+			}
+			seen[h] = true
+			if _, ok := groups[h]; !ok {
+				groups[h] = &compositeMatch{labels: lbls}
+				order = append(order, h)
+			}
+			groups[h].rules = append(groups[h].rules, name)
+		}
+	}
+
+	var res []*compositeMatch
+	for _, h := range order {
+		g := groups[h]
+		if cond.Op == ConditionOpAnd && len(g.rules) < len(cond.RuleIds) {
+			continue
+		}
+		res = append(res, g)
+	}
+	return res
+}
+
+// Eval combines the firing alerts of the rules, the alerts of the composite rule follow
+// the states of the rules with the delay of their evaluations
+func (r *CompositeRule) Eval(ctx context.Context, ts time.Time, queriers *Queriers) (interface{}, error) {
+	matches := r.match()
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	resultFPs := map[uint64]struct{}{}
+	for _, m := range matches {
+		l := m.labels.Map()
+		sort.Strings(m.rules)
+		value := fmt.Sprintf("%d", len(m.rules))
+		tmplData := AlertTemplateData(l, value, fmt.Sprintf("%d", len(r.ruleCondition.Composite.RuleIds)))
+		defs := "{{$labels := .Labels}}{{$value := .Value}}{{$threshold := .Threshold}}"
+		expand := func(text string) string {
+			tmpl := NewTemplateExpander(ctx, defs+text, "__alert_"+r.Name(), tmplData, times.Time(timestamp.FromTime(ts)), nil)
+			result, err := tmpl.Expand()
+			if err != nil {
+				result = fmt.Sprintf("<error expanding template: %s>", err)
+				zap.L().Error("Expanding alert template failed", zap.Error(err), zap.Any("data", tmplData))
+			}
+			return result
+		}
+
+		lb := labels.NewBuilder(m.labels)
+		for _, l := range r.labels {
+			lb.Set(l.Name, expand(l.Value))
+		}
+		lb.Set(labels.AlertNameLabel, r.Name())
+		lb.Set(labels.AlertRuleIdLabel, r.ID())
+		lb.Set(labels.RuleSourceLabel, r.GeneratorURL())
+
+		annotations := make(labels.Labels, 0, len(r.annotations)+1)
+		for _, a := range r.annotations {
+			annotations = append(annotations, labels.Label{Name: normalizeLabelName(a.Name), Value: expand(a.Value)})
+		}
+		annotations = append(annotations, labels.Label{Name: firingRulesAnnotation, Value: strings.Join(m.rules, ", ")})
+
+		lbs := lb.Labels()
+		h := lbs.Hash()
+		resultFPs[h] = struct{}{}
+
+		if alert, ok := r.active[h]; ok && alert.State != StateInactive {
+			alert.Value = float64(len(m.rules))
+			alert.Annotations = annotations
+			alert.Receivers = r.preferredChannels
+			continue
+		}
+		// the rules are already firing, the composite alert fires right away
+		r.active[h] = &Alert{
+			Labels:       lbs,
+			Annotations:  annotations,
+			ActiveAt:     ts,
+			FiredAt:      ts,
+			State:        StateFiring,
+			Value:        float64(len(m.rules)),
+			GeneratorURL: r.GeneratorURL(),
+			Receivers:    r.preferredChannels,
+		}
+	}
+
+	for fp, a := range r.active {
+		if _, ok := resultFPs[fp]; ok {
+			continue
+		}
+		if !a.ResolvedAt.IsZero() && ts.Sub(a.ResolvedAt) > resolvedRetention {
+			delete(r.active, fp)
+		}
+		if a.State != StateInactive {
+			a.State = StateInactive
+			a.ResolvedAt = ts
+		}
+	}
+	r.health = HealthGood
+	r.lastError = nil
+
+	return len(r.active), nil
+}
+
+func (r *CompositeRule) String() string {
+	ar := PostableRule{
+		AlertName:         r.name,
+		RuleType:          RuleTypeComposite,
+		RuleCondition:     r.ruleCondition,
+		Labels:            r.labels.Map(),
+		Annotations:       r.annotations.Map(),
+		PreferredChannels: r.preferredChannels,
+	}
+
+	byt, err := yaml.Marshal(ar)
+	if err != nil {
+		return fmt.Sprintf("error marshaling alerting rule: %s", err.Error())
+	}
+	return string(byt)
+}
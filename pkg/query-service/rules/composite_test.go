@@ -0,0 +1,107 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestCompositeRule(t *testing.T) {
+	firing := newFiringAlerts()
+	alert := func(ruleId, ruleName, service string, from, to AlertState) StateChange {
+		return StateChange{
+			RuleId:   ruleId,
+			RuleName: ruleName,
+			Labels:   labels.FromMap(map[string]string{"service_name": service, labels.AlertNameLabel: ruleName}),
+			From:     from,
+			To:       to,
+		}
+	}
+
+	postableRule := PostableRule{
+		AlertName: "Checkout Incident",
+		RuleType:  RuleTypeComposite,
+		Frequency: Duration(time.Minute),
+		RuleCondition: &RuleCondition{
+			Composite: &CompositeCondition{
+				RuleIds: []string{"1", "2"},
+				Op:      ConditionOpAnd,
+				GroupBy: []string{"service_name"},
+			},
+		},
+		Annotations: map[string]string{"summary": "{{$labels.service_name}} is failing"},
+	}
+	require.True(t, postableRule.RuleCondition.IsValid())
+	require.Empty(t, postableRule.Validate())
+
+	_, err := NewCompositeRule("1", &postableRule, firing.firing)
+	require.ErrorIs(t, err, ErrCompositeSelfRef)
+	rule, err := NewCompositeRule("3", &postableRule, firing.firing)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	ts := time.Now()
+	firing.record(ctx,
+		alert("1", "High Latency", "checkout", StateInactive, StateFiring),
+		alert("1", "High Latency", "cart", StateInactive, StateFiring),
+		alert("2", "Error Rate", "checkout", StateInactive, StateFiring),
+		// pending alerts are not firing yet
+		alert("2", "Error Rate", "cart", StateInactive, StatePending),
+	)
+
+	_, err = rule.Eval(ctx, ts, nil)
+	require.NoError(t, err)
+	alerts := rule.ActiveAlerts()
+	require.Len(t, alerts, 1)
+	require.Equal(t, StateFiring, alerts[0].State)
+	require.Equal(t, "checkout", alerts[0].Labels.Get("service_name"))
+	require.Equal(t, "3", alerts[0].Labels.Get(labels.AlertRuleIdLabel))
+	require.Equal(t, "checkout is failing", alerts[0].Annotations.Get("summary"))
+	require.Equal(t, "Error Rate, High Latency", alerts[0].Annotations.Get(firingRulesAnnotation))
+
+	// the error rate of checkout resolves
+	firing.record(ctx, alert("2", "Error Rate", "checkout", StateFiring, StateInactive))
+	_, err = rule.Eval(ctx, ts.Add(time.Minute), nil)
+	require.NoError(t, err)
+	require.Empty(t, rule.ActiveAlerts())
+
+	// any rule firing is enough with OR
+	postableRule.RuleCondition.Composite.Op = ConditionOpOr
+	rule, err = NewCompositeRule("3", &postableRule, firing.firing)
+	require.NoError(t, err)
+	_, err = rule.Eval(ctx, ts, nil)
+	require.NoError(t, err)
+	require.Len(t, rule.ActiveAlerts(), 2)
+
+	// the deleted rules are not firing anymore
+	firing.forget("1")
+	_, err = rule.Eval(ctx, ts.Add(time.Minute), nil)
+	require.NoError(t, err)
+	require.Empty(t, rule.ActiveAlerts())
+
+	invalid := *postableRule.RuleCondition.Composite
+	invalid.RuleIds = []string{"1", "1"}
+	require.NotEmpty(t, invalid.validate())
+	invalid = *postableRule.RuleCondition.Composite
+	invalid.Op = "XOR"
+	require.NotEmpty(t, invalid.validate())
+}
+
+func TestParseCompositeRule(t *testing.T) {
+	rule, errs := ParsePostableRule([]byte(`{
+		"alert": "Checkout Incident",
+		"condition": {"composite": {"ruleIds": ["1", "2"]}}
+	}`))
+	require.Empty(t, errs)
+	require.Equal(t, RuleType(RuleTypeComposite), rule.RuleType)
+	require.Equal(t, ConditionOpAnd, rule.RuleCondition.Composite.Op)
+
+	_, errs = ParsePostableRule([]byte(`{
+		"alert": "Checkout Incident",
+		"condition": {"composite": {"ruleIds": ["1"]}}
+	}`))
+	require.NotEmpty(t, errs)
+}
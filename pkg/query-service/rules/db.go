@@ -46,6 +46,75 @@ type RuleDB interface {
 	// GetAllPlannedMaintenance fetches the maintenance definitions from db
 	GetAllPlannedMaintenance(ctx context.Context) ([]PlannedMaintenance, error)
 
+	// CreateEscalationPolicy stores a given escalation policy in db
+	CreateEscalationPolicy(ctx context.Context, policy EscalationPolicy) (int64, error)
+
+	// DeleteEscalationPolicy deletes the given escalation policy in the db
+	DeleteEscalationPolicy(ctx context.Context, id string) error
+
+	// GetEscalationPolicyByID fetches the escalation policy from db by id
+	GetEscalationPolicyByID(ctx context.Context, id string) (*EscalationPolicy, error)
+
+	// EditEscalationPolicy updates the given escalation policy in the db
+	EditEscalationPolicy(ctx context.Context, policy EscalationPolicy, id string) error
+
+	// GetAllEscalationPolicies fetches the escalation policies from db
+	GetAllEscalationPolicies(ctx context.Context) ([]EscalationPolicy, error)
+
+	// GetEscalationStates fetches the escalations of the firing alerts from db
+	GetEscalationStates(ctx context.Context) ([]StoredEscalationState, error)
+
+	// SaveEscalationState stores or updates the escalation of a firing alert in db
+	SaveEscalationState(ctx context.Context, state StoredEscalationState) error
+
+	// DeleteEscalationState deletes the escalation of a firing alert in db
+	DeleteEscalationState(ctx context.Context, ruleId string, labelsHash string) error
+
+	// CreateRecordingRule stores a given recording rule in db
+	CreateRecordingRule(ctx context.Context, rule *RecordingRule) (int64, error)
+
+	// EditRecordingRule updates the given recording rule in the db
+	EditRecordingRule(ctx context.Context, rule *RecordingRule, id string) error
+
+	// DeleteRecordingRule deletes the given recording rule in the db
+	DeleteRecordingRule(ctx context.Context, id string) error
+
+	// GetRecordingRule fetches the recording rule from db by id
+	GetRecordingRule(ctx context.Context, id string) (*RecordingRule, error)
+
+	// GetAllRecordingRules fetches the recording rules from db
+	GetAllRecordingRules(ctx context.Context) ([]*RecordingRule, error)
+
+	// CreateSLO stores a given slo in db
+	CreateSLO(ctx context.Context, slo *SLO) (int64, error)
+
+	// EditSLO updates the given slo in the db
+	EditSLO(ctx context.Context, slo *SLO, id string) error
+
+	// DeleteSLO deletes the given slo in the db
+	DeleteSLO(ctx context.Context, id string) error
+
+	// GetSLO fetches the slo from db by id
+	GetSLO(ctx context.Context, id string) (*SLO, error)
+
+	// GetAllSLOs fetches the slos from db
+	GetAllSLOs(ctx context.Context) ([]*SLO, error)
+
+	// CreateSilence stores a given silence in db
+	CreateSilence(ctx context.Context, silence *Silence) (int64, error)
+
+	// EditSilence updates the given silence in the db
+	EditSilence(ctx context.Context, silence *Silence, id string) error
+
+	// DeleteSilence deletes the given silence in the db
+	DeleteSilence(ctx context.Context, id string) error
+
+	// GetSilence fetches the silence from db by id
+	GetSilence(ctx context.Context, id string) (*Silence, error)
+
+	// GetAllSilences fetches the silences from db
+	GetAllSilences(ctx context.Context) ([]Silence, error)
+
 	// used for internal telemetry
 	GetAlertsInfo(ctx context.Context) (*model.AlertsInfo, error)
 }
@@ -57,6 +126,9 @@ type StoredRule struct {
 	UpdatedAt *time.Time `json:"updated_at" db:"updated_at"`
 	UpdatedBy *string    `json:"updated_by" db:"updated_by"`
 	Data      string     `json:"data" db:"data"`
+	// OrgId is the org of the rule, empty for the rules of the default org created before
+	// the orgs were added
+	OrgId string `json:"org_id" db:"org_id"`
 }
 
 type Tx interface {
@@ -92,7 +164,7 @@ func (r *ruleDB) CreateRuleTx(ctx context.Context, rule string) (int64, Tx, erro
 		return lastInsertId, nil, err
 	}
 
-	stmt, err := tx.Prepare(`INSERT into rules (created_at, created_by, updated_at, updated_by, data) VALUES($1,$2,$3,$4,$5);`)
+	stmt, err := tx.Prepare(`INSERT into rules (created_at, created_by, updated_at, updated_by, data, org_id) VALUES($1,$2,$3,$4,$5,$6);`)
 	if err != nil {
 		zap.L().Error("Error in preparing statement for INSERT to rules", zap.Error(err))
 		tx.Rollback()
@@ -101,7 +173,7 @@ func (r *ruleDB) CreateRuleTx(ctx context.Context, rule string) (int64, Tx, erro
 
 	defer stmt.Close()
 
-	result, err := stmt.Exec(createdAt, userEmail, updatedAt, userEmail, rule)
+	result, err := stmt.Exec(createdAt, userEmail, updatedAt, userEmail, rule, common.GetOrgIdFromContext(ctx))
 	if err != nil {
 		zap.L().Error("Error in Executing prepared statement for INSERT to rules", zap.Error(err))
 		tx.Rollback() // return an error too, we may want to wrap them
@@ -187,13 +259,16 @@ func (r *ruleDB) DeleteRuleTx(ctx context.Context, id string) (string, Tx, error
 	return groupName, nil, nil
 }
 
+// GetStoredRules returns the rules of the org of the user of the context, all the rules for
+// the contexts without a user
 func (r *ruleDB) GetStoredRules(ctx context.Context) ([]StoredRule, error) {
 
 	rules := []StoredRule{}
 
-	query := "SELECT id, created_at, created_by, updated_at, updated_by, data FROM rules"
+	condition, args := common.OrgCondition(ctx)
+	query := "SELECT id, created_at, created_by, updated_at, updated_by, data, org_id FROM rules WHERE " + condition
 
-	err := r.Select(&rules, query)
+	err := r.Select(&rules, query, args...)
 
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
@@ -203,6 +278,7 @@ func (r *ruleDB) GetStoredRules(ctx context.Context) ([]StoredRule, error) {
 	return rules, nil
 }
 
+// GetStoredRule returns the rule when it belongs to the org of the user of the context
 func (r *ruleDB) GetStoredRule(ctx context.Context, id string) (*StoredRule, error) {
 	intId, err := strconv.Atoi(id)
 	if err != nil {
@@ -211,8 +287,9 @@ func (r *ruleDB) GetStoredRule(ctx context.Context, id string) (*StoredRule, err
 
 	rule := &StoredRule{}
 
-	query := fmt.Sprintf("SELECT id, created_at, created_by, updated_at, updated_by, data FROM rules WHERE id=%d", intId)
-	err = r.Get(rule, query)
+	condition, args := common.OrgCondition(ctx)
+	query := fmt.Sprintf("SELECT id, created_at, created_by, updated_at, updated_by, data, org_id FROM rules WHERE id=%d AND %s", intId, condition)
+	err = r.Get(rule, query, args...)
 
 	// zap.L().Info(query)
 
@@ -227,7 +304,7 @@ func (r *ruleDB) GetStoredRule(ctx context.Context, id string) (*StoredRule, err
 func (r *ruleDB) GetAllPlannedMaintenance(ctx context.Context) ([]PlannedMaintenance, error) {
 	maintenances := []PlannedMaintenance{}
 
-	query := "SELECT id, name, description, schedule, alert_ids, created_at, created_by, updated_at, updated_by FROM planned_maintenance"
+	query := "SELECT id, name, description, schedule, alert_ids, matchers, created_at, created_by, updated_at, updated_by FROM planned_maintenance"
 
 	err := r.Select(&maintenances, query)
 
@@ -242,7 +319,7 @@ func (r *ruleDB) GetAllPlannedMaintenance(ctx context.Context) ([]PlannedMainten
 func (r *ruleDB) GetPlannedMaintenanceByID(ctx context.Context, id string) (*PlannedMaintenance, error) {
 	maintenance := &PlannedMaintenance{}
 
-	query := "SELECT id, name, description, schedule, alert_ids, created_at, created_by, updated_at, updated_by FROM planned_maintenance WHERE id=$1"
+	query := "SELECT id, name, description, schedule, alert_ids, matchers, created_at, created_by, updated_at, updated_by FROM planned_maintenance WHERE id=$1"
 	err := r.Get(maintenance, query, id)
 
 	if err != nil {
@@ -261,9 +338,9 @@ func (r *ruleDB) CreatePlannedMaintenance(ctx context.Context, maintenance Plann
 	maintenance.UpdatedBy = email
 	maintenance.UpdatedAt = time.Now()
 
-	query := "INSERT INTO planned_maintenance (name, description, schedule, alert_ids, created_at, created_by, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+	query := "INSERT INTO planned_maintenance (name, description, schedule, alert_ids, matchers, created_at, created_by, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
 
-	result, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.CreatedAt, maintenance.CreatedBy, maintenance.UpdatedAt, maintenance.UpdatedBy)
+	result, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.Matchers, maintenance.CreatedAt, maintenance.CreatedBy, maintenance.UpdatedAt, maintenance.UpdatedBy)
 
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
@@ -290,8 +367,8 @@ func (r *ruleDB) EditPlannedMaintenance(ctx context.Context, maintenance Planned
 	maintenance.UpdatedBy = email
 	maintenance.UpdatedAt = time.Now()
 
-	query := "UPDATE planned_maintenance SET name=$1, description=$2, schedule=$3, alert_ids=$4, updated_at=$5, updated_by=$6 WHERE id=$7"
-	_, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.UpdatedAt, maintenance.UpdatedBy, id)
+	query := "UPDATE planned_maintenance SET name=$1, description=$2, schedule=$3, alert_ids=$4, matchers=$5, updated_at=$6, updated_by=$7 WHERE id=$8"
+	_, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.Matchers, maintenance.UpdatedAt, maintenance.UpdatedBy, id)
 
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
@@ -301,6 +378,413 @@ func (r *ruleDB) EditPlannedMaintenance(ctx context.Context, maintenance Planned
 	return "", nil
 }
 
+func (r *ruleDB) GetAllEscalationPolicies(ctx context.Context) ([]EscalationPolicy, error) {
+	policies := []EscalationPolicy{}
+
+	query := "SELECT id, name, description, steps, alert_ids, created_at, created_by, updated_at, updated_by FROM escalation_policies"
+
+	err := r.Select(&policies, query)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+func (r *ruleDB) GetEscalationPolicyByID(ctx context.Context, id string) (*EscalationPolicy, error) {
+	policy := &EscalationPolicy{}
+
+	query := "SELECT id, name, description, steps, alert_ids, created_at, created_by, updated_at, updated_by FROM escalation_policies WHERE id=$1"
+	err := r.Get(policy, query, id)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (r *ruleDB) CreateEscalationPolicy(ctx context.Context, policy EscalationPolicy) (int64, error) {
+
+	email, _ := auth.GetEmailFromJwt(ctx)
+	policy.CreatedBy = email
+	policy.CreatedAt = time.Now()
+	policy.UpdatedBy = email
+	policy.UpdatedAt = time.Now()
+
+	query := "INSERT INTO escalation_policies (name, description, steps, alert_ids, created_at, created_by, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+
+	result, err := r.Exec(query, policy.Name, policy.Description, policy.Steps, policy.AlertIds, policy.CreatedAt, policy.CreatedBy, policy.UpdatedAt, policy.UpdatedBy)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *ruleDB) DeleteEscalationPolicy(ctx context.Context, id string) error {
+	query := "DELETE FROM escalation_policies WHERE id=$1"
+	_, err := r.Exec(query, id)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *ruleDB) EditEscalationPolicy(ctx context.Context, policy EscalationPolicy, id string) error {
+	email, _ := auth.GetEmailFromJwt(ctx)
+	policy.UpdatedBy = email
+	policy.UpdatedAt = time.Now()
+
+	query := "UPDATE escalation_policies SET name=$1, description=$2, steps=$3, alert_ids=$4, updated_at=$5, updated_by=$6 WHERE id=$7"
+	_, err := r.Exec(query, policy.Name, policy.Description, policy.Steps, policy.AlertIds, policy.UpdatedAt, policy.UpdatedBy, id)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *ruleDB) GetEscalationStates(ctx context.Context) ([]StoredEscalationState, error) {
+	states := []StoredEscalationState{}
+
+	query := "SELECT rule_id, labels_hash, step, acked_at, acked_by FROM escalation_states"
+
+	err := r.Select(&states, query)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return states, nil
+}
+
+func (r *ruleDB) SaveEscalationState(ctx context.Context, state StoredEscalationState) error {
+	query := `INSERT INTO escalation_states (rule_id, labels_hash, step, acked_at, acked_by) VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT(rule_id, labels_hash) DO UPDATE SET step = excluded.step, acked_at = excluded.acked_at, acked_by = excluded.acked_by`
+	_, err := r.Exec(query, state.RuleId, state.LabelsHash, state.Step, state.AckedAt, state.AckedBy)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *ruleDB) DeleteEscalationState(ctx context.Context, ruleId string, labelsHash string) error {
+	query := "DELETE FROM escalation_states WHERE rule_id=$1 AND labels_hash=$2"
+	_, err := r.Exec(query, ruleId, labelsHash)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetAllRecordingRules returns the recording rules of the org of the user of the context, all
+// the recording rules for the contexts without a user
+func (r *ruleDB) GetAllRecordingRules(ctx context.Context) ([]*RecordingRule, error) {
+	stored := []StoredRecordingRule{}
+
+	condition, args := common.OrgCondition(ctx)
+	query := "SELECT id, name, data, created_at, created_by, updated_at, updated_by, org_id FROM recording_rules WHERE " + condition
+
+	err := r.Select(&stored, query, args...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	rules := make([]*RecordingRule, 0, len(stored))
+	for i := range stored {
+		rule, err := stored[i].parse()
+		if err != nil {
+			zap.L().Error("invalid recording rule data", zap.Int64("id", stored[i].Id), zap.Error(err))
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (r *ruleDB) GetRecordingRule(ctx context.Context, id string) (*RecordingRule, error) {
+	stored := StoredRecordingRule{}
+
+	condition, args := common.OrgCondition(ctx)
+	query := "SELECT id, name, data, created_at, created_by, updated_at, updated_by, org_id FROM recording_rules WHERE id=? AND " + condition
+	err := r.Get(&stored, query, append([]interface{}{id}, args...)...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return stored.parse()
+}
+
+func (r *ruleDB) CreateRecordingRule(ctx context.Context, rule *RecordingRule) (int64, error) {
+
+	email, _ := auth.GetEmailFromJwt(ctx)
+	rule.CreatedBy = email
+	rule.CreatedAt = time.Now()
+	rule.UpdatedBy = email
+	rule.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "INSERT INTO recording_rules (name, data, created_at, created_by, updated_at, updated_by, org_id) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+
+	result, err := r.Exec(query, rule.Name, string(data), rule.CreatedAt, rule.CreatedBy, rule.UpdatedAt, rule.UpdatedBy, rule.OrgId)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *ruleDB) EditRecordingRule(ctx context.Context, rule *RecordingRule, id string) error {
+	email, _ := auth.GetEmailFromJwt(ctx)
+	rule.UpdatedBy = email
+	rule.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+
+	condition, args := common.OrgCondition(ctx)
+	query := "UPDATE recording_rules SET name=?, data=?, updated_at=?, updated_by=? WHERE id=? AND " + condition
+	_, err = r.Exec(query, append([]interface{}{rule.Name, string(data), rule.UpdatedAt, rule.UpdatedBy, id}, args...)...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *ruleDB) DeleteRecordingRule(ctx context.Context, id string) error {
+	condition, args := common.OrgCondition(ctx)
+	query := "DELETE FROM recording_rules WHERE id=? AND " + condition
+	_, err := r.Exec(query, append([]interface{}{id}, args...)...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetAllSLOs returns the slos of the org of the user of the context, all the slos for the
+// contexts without a user
+func (r *ruleDB) GetAllSLOs(ctx context.Context) ([]*SLO, error) {
+	stored := []StoredSLO{}
+
+	condition, args := common.OrgCondition(ctx)
+	query := "SELECT id, name, data, created_at, created_by, updated_at, updated_by, org_id FROM slos WHERE " + condition
+
+	err := r.Select(&stored, query, args...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	slos := make([]*SLO, 0, len(stored))
+	for i := range stored {
+		slo, err := stored[i].parse()
+		if err != nil {
+			zap.L().Error("invalid slo data", zap.Int64("id", stored[i].Id), zap.Error(err))
+			continue
+		}
+		slos = append(slos, slo)
+	}
+
+	return slos, nil
+}
+
+func (r *ruleDB) GetSLO(ctx context.Context, id string) (*SLO, error) {
+	stored := StoredSLO{}
+
+	condition, args := common.OrgCondition(ctx)
+	query := "SELECT id, name, data, created_at, created_by, updated_at, updated_by, org_id FROM slos WHERE id=? AND " + condition
+	err := r.Get(&stored, query, append([]interface{}{id}, args...)...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return stored.parse()
+}
+
+func (r *ruleDB) CreateSLO(ctx context.Context, slo *SLO) (int64, error) {
+
+	email, _ := auth.GetEmailFromJwt(ctx)
+	slo.CreatedBy = email
+	slo.CreatedAt = time.Now()
+	slo.UpdatedBy = email
+	slo.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(slo)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "INSERT INTO slos (name, data, created_at, created_by, updated_at, updated_by, org_id) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+
+	result, err := r.Exec(query, slo.Name, string(data), slo.CreatedAt, slo.CreatedBy, slo.UpdatedAt, slo.UpdatedBy, common.GetOrgIdFromContext(ctx))
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *ruleDB) EditSLO(ctx context.Context, slo *SLO, id string) error {
+	email, _ := auth.GetEmailFromJwt(ctx)
+	slo.UpdatedBy = email
+	slo.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(slo)
+	if err != nil {
+		return err
+	}
+
+	condition, args := common.OrgCondition(ctx)
+	query := "UPDATE slos SET name=?, data=?, updated_at=?, updated_by=? WHERE id=? AND " + condition
+	_, err = r.Exec(query, append([]interface{}{slo.Name, string(data), slo.UpdatedAt, slo.UpdatedBy, id}, args...)...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *ruleDB) DeleteSLO(ctx context.Context, id string) error {
+	condition, args := common.OrgCondition(ctx)
+	query := "DELETE FROM slos WHERE id=? AND " + condition
+	_, err := r.Exec(query, append([]interface{}{id}, args...)...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetAllSilences returns the silences of the org of the user of the context, all the silences
+// for the contexts without a user
+func (r *ruleDB) GetAllSilences(ctx context.Context) ([]Silence, error) {
+	silences := []Silence{}
+
+	condition, args := common.OrgCondition(ctx)
+	query := "SELECT id, matchers, comment, schedule, created_at, created_by, updated_at, updated_by, org_id FROM silences WHERE " + condition
+
+	err := r.Select(&silences, query, args...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return silences, nil
+}
+
+func (r *ruleDB) GetSilence(ctx context.Context, id string) (*Silence, error) {
+	silence := &Silence{}
+
+	condition, args := common.OrgCondition(ctx)
+	query := "SELECT id, matchers, comment, schedule, created_at, created_by, updated_at, updated_by, org_id FROM silences WHERE id=? AND " + condition
+	err := r.Get(silence, query, append([]interface{}{id}, args...)...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return silence, nil
+}
+
+func (r *ruleDB) CreateSilence(ctx context.Context, silence *Silence) (int64, error) {
+
+	email, _ := auth.GetEmailFromJwt(ctx)
+	silence.CreatedBy = email
+	silence.CreatedAt = time.Now()
+	silence.UpdatedBy = email
+	silence.UpdatedAt = time.Now()
+	silence.OrgId = common.GetOrgIdFromContext(ctx)
+
+	query := "INSERT INTO silences (matchers, comment, schedule, created_at, created_by, updated_at, updated_by, org_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+
+	result, err := r.Exec(query, &silence.Matchers, silence.Comment, silence.Schedule, silence.CreatedAt, silence.CreatedBy, silence.UpdatedAt, silence.UpdatedBy, silence.OrgId)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *ruleDB) EditSilence(ctx context.Context, silence *Silence, id string) error {
+	email, _ := auth.GetEmailFromJwt(ctx)
+	silence.UpdatedBy = email
+	silence.UpdatedAt = time.Now()
+
+	condition, args := common.OrgCondition(ctx)
+	query := "UPDATE silences SET matchers=?, comment=?, schedule=?, updated_at=?, updated_by=? WHERE id=? AND " + condition
+	_, err := r.Exec(query, append([]interface{}{&silence.Matchers, silence.Comment, silence.Schedule, silence.UpdatedAt, silence.UpdatedBy, id}, args...)...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *ruleDB) DeleteSilence(ctx context.Context, id string) error {
+	condition, args := common.OrgCondition(ctx)
+	query := "DELETE FROM silences WHERE id=? AND " + condition
+	_, err := r.Exec(query, append([]interface{}{id}, args...)...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 func (r *ruleDB) GetAlertsInfo(ctx context.Context) (*model.AlertsInfo, error) {
 	alertsInfo := model.AlertsInfo{}
 	// fetch alerts from rules db
@@ -0,0 +1,370 @@
+package rules
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrMissingSteps    = errors.New("missing escalation steps")
+	ErrMissingChannels = errors.New("missing escalation step channels")
+	ErrInvalidDelay    = errors.New("escalation step delay must not be negative")
+)
+
+// escalationInterval is how often the unacknowledged alerts are checked
+const escalationInterval = 30 * time.Second
+
+// restoreGrace is how long the escalations restored from the db wait for their alerts to
+// be sent again by the rules, the alerts resolved while the service was down are not
+const restoreGrace = 10 * time.Minute
+
+// EscalationPolicy re-notifies the alerts of the given rules through the
+// channels of the next step while they stay unacknowledged
+type EscalationPolicy struct {
+	Id          int64            `json:"id" db:"id"`
+	Name        string           `json:"name" db:"name"`
+	Description string           `json:"description" db:"description"`
+	Steps       *EscalationSteps `json:"steps" db:"steps"`
+	AlertIds    *AlertIds        `json:"alertIds" db:"alert_ids"`
+	CreatedAt   time.Time        `json:"createdAt" db:"created_at"`
+	CreatedBy   string           `json:"createdBy" db:"created_by"`
+	UpdatedAt   time.Time        `json:"updatedAt" db:"updated_at"`
+	UpdatedBy   string           `json:"updatedBy" db:"updated_by"`
+}
+
+// EscalationStep notifies the channels once the delay has elapsed
+// since the previous step, the first step is relative to the time
+// the alert started firing
+type EscalationStep struct {
+	Delay    Duration `json:"delay"`
+	Channels []string `json:"channels"`
+}
+
+type EscalationSteps []EscalationStep
+
+func (s *EscalationSteps) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, s)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), s)
+	}
+	return nil
+}
+
+func (s *EscalationSteps) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (p *EscalationPolicy) Validate() error {
+	if p.Name == "" {
+		return ErrMissingName
+	}
+	if p.Steps == nil || len(*p.Steps) == 0 {
+		return ErrMissingSteps
+	}
+	for _, step := range *p.Steps {
+		if step.Delay < 0 {
+			return ErrInvalidDelay
+		}
+		if len(step.Channels) == 0 {
+			return ErrMissingChannels
+		}
+	}
+	return nil
+}
+
+func (p *EscalationPolicy) appliesTo(ruleID string) bool {
+	return p.AlertIds != nil && slices.Contains(*p.AlertIds, ruleID)
+}
+
+// dueAt returns the time the step at the given index should be notified
+func (p *EscalationPolicy) dueAt(firedAt time.Time, step int) time.Time {
+	due := firedAt
+	for i := 0; i <= step; i++ {
+		due = due.Add(time.Duration((*p.Steps)[i].Delay))
+	}
+	return due
+}
+
+// AckAlertsRequest acknowledges the firing alerts of a rule, when labels
+// are given only the alerts with matching labels are acknowledged
+type AckAlertsRequest struct {
+	RuleId string            `json:"ruleId"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type AckAlertsResponse struct {
+	Acknowledged int `json:"acknowledged"`
+}
+
+// StoredEscalationState is the escalation of a firing alert kept in the db, so that the
+// steps already notified and the acknowledgements survive the restarts
+type StoredEscalationState struct {
+	RuleId     string    `db:"rule_id"`
+	LabelsHash string    `db:"labels_hash"`
+	Step       int       `db:"step"`
+	AckedAt    time.Time `db:"acked_at"`
+	AckedBy    string    `db:"acked_by"`
+}
+
+type escalationState struct {
+	alert   Alert
+	step    int
+	ackedAt time.Time
+	ackedBy string
+	// restored is set for the states loaded from the db until the rule sends the alert again
+	restored bool
+}
+
+func (s *escalationState) stored(ruleID string, hash uint64) StoredEscalationState {
+	return StoredEscalationState{
+		RuleId:     ruleID,
+		LabelsHash: strconv.FormatUint(hash, 10),
+		Step:       s.step,
+		AckedAt:    s.ackedAt,
+		AckedBy:    s.ackedBy,
+	}
+}
+
+// Escalator tracks the firing alerts and escalates the unacknowledged ones
+// according to the escalation policies
+type Escalator struct {
+	mtx    sync.Mutex
+	ruleDB RuleDB
+	notify func(ctx context.Context, alerts ...*Alert)
+	// rule id -> labels hash -> state
+	states map[string]map[uint64]*escalationState
+	// restoredUntil is when the restored states not sent again are dropped
+	restoredUntil time.Time
+}
+
+func NewEscalator(ruleDB RuleDB, notify func(ctx context.Context, alerts ...*Alert)) *Escalator {
+	return &Escalator{
+		ruleDB: ruleDB,
+		notify: notify,
+		states: map[string]map[uint64]*escalationState{},
+	}
+}
+
+// Restore loads the escalations of the firing alerts saved in the db, they are resumed once
+// the rules send the alerts again
+func (e *Escalator) Restore(ctx context.Context, now time.Time) error {
+	stored, err := e.ruleDB.GetEscalationStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.restoredUntil = now.Add(restoreGrace)
+	for _, s := range stored {
+		hash, err := strconv.ParseUint(s.LabelsHash, 10, 64)
+		if err != nil {
+			zap.L().Error("invalid labels hash of the escalation state", zap.String("ruleId", s.RuleId), zap.String("hash", s.LabelsHash))
+			continue
+		}
+		if _, ok := e.states[s.RuleId]; !ok {
+			e.states[s.RuleId] = map[uint64]*escalationState{}
+		}
+		if _, ok := e.states[s.RuleId][hash]; ok {
+			continue
+		}
+		e.states[s.RuleId][hash] = &escalationState{step: s.Step, ackedAt: s.AckedAt, ackedBy: s.AckedBy, restored: true}
+	}
+	return nil
+}
+
+// Track records the alerts sent to alertmanager, resolved alerts stop being tracked
+func (e *Escalator) Track(ctx context.Context, alerts ...*Alert) {
+	var saved []StoredEscalationState
+	var deleted []StoredEscalationState
+
+	e.mtx.Lock()
+	for _, alert := range alerts {
+		ruleID := alert.Labels.Get(labels.AlertRuleIdLabel)
+		if ruleID == "" {
+			continue
+		}
+		hash := alert.Labels.Hash()
+		if !alert.ResolvedAt.IsZero() {
+			if state, ok := e.states[ruleID][hash]; ok {
+				deleted = append(deleted, state.stored(ruleID, hash))
+				delete(e.states[ruleID], hash)
+			}
+			continue
+		}
+		if _, ok := e.states[ruleID]; !ok {
+			e.states[ruleID] = map[uint64]*escalationState{}
+		}
+		if state, ok := e.states[ruleID][hash]; ok {
+			state.alert = *alert
+			state.restored = false
+			continue
+		}
+		state := &escalationState{alert: *alert, step: -1}
+		e.states[ruleID][hash] = state
+		saved = append(saved, state.stored(ruleID, hash))
+	}
+	e.mtx.Unlock()
+
+	e.persist(ctx, saved, deleted)
+}
+
+// Acknowledge stops the escalation of the matching firing alerts and returns their count
+func (e *Escalator) Acknowledge(ctx context.Context, req *AckAlertsRequest, by string, now time.Time) int {
+	var saved []StoredEscalationState
+
+	e.mtx.Lock()
+	for hash, state := range e.states[req.RuleId] {
+		if state.restored || !state.ackedAt.IsZero() || !matchesAll(state.alert.Labels, req.Labels) {
+			continue
+		}
+		state.ackedAt = now
+		state.ackedBy = by
+		saved = append(saved, state.stored(req.RuleId, hash))
+	}
+	e.mtx.Unlock()
+
+	e.persist(ctx, saved, nil)
+	return len(saved)
+}
+
+// AcknowledgeAlert stops the escalation of the firing alert of the rule with the hash of
+// labels, e.g. when its incident is acknowledged in the incident management tool. It returns
+// false when the alert is not firing or already acknowledged
+func (e *Escalator) AcknowledgeAlert(ctx context.Context, ruleID string, hash uint64, by string, now time.Time) bool {
+	e.mtx.Lock()
+	state, ok := e.states[ruleID][hash]
+	if !ok || !state.ackedAt.IsZero() {
+		e.mtx.Unlock()
+		return false
+	}
+	state.ackedAt = now
+	state.ackedBy = by
+	saved := state.stored(ruleID, hash)
+	e.mtx.Unlock()
+
+	e.persist(ctx, []StoredEscalationState{saved}, nil)
+	return true
+}
+
+// persist saves the changed escalation states in the db, the failures are only logged as
+// the escalations go on in memory
+func (e *Escalator) persist(ctx context.Context, saved, deleted []StoredEscalationState) {
+	for _, s := range saved {
+		if err := e.ruleDB.SaveEscalationState(ctx, s); err != nil {
+			zap.L().Error("failed to save the escalation state", zap.String("ruleId", s.RuleId), zap.Error(err))
+		}
+	}
+	for _, s := range deleted {
+		if err := e.ruleDB.DeleteEscalationState(ctx, s.RuleId, s.LabelsHash); err != nil {
+			zap.L().Error("failed to delete the escalation state", zap.String("ruleId", s.RuleId), zap.Error(err))
+		}
+	}
+}
+
+func matchesAll(lbls labels.BaseLabels, want map[string]string) bool {
+	for k, v := range want {
+		if lbls.Get(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Run checks the unacknowledged alerts periodically until the context is done
+func (e *Escalator) Run(ctx context.Context) {
+	if err := e.Restore(ctx, time.Now()); err != nil {
+		zap.L().Error("failed to restore the escalation states", zap.Error(err))
+	}
+
+	ticker := time.NewTicker(escalationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.escalate(ctx, time.Now())
+		}
+	}
+}
+
+func (e *Escalator) escalate(ctx context.Context, now time.Time) {
+	policies, err := e.ruleDB.GetAllEscalationPolicies(ctx)
+	if err != nil {
+		zap.L().Error("failed to get escalation policies", zap.Error(err))
+		return
+	}
+	if len(policies) == 0 {
+		return
+	}
+
+	var saved []StoredEscalationState
+	var deleted []StoredEscalationState
+
+	e.mtx.Lock()
+	var alerts []*Alert
+	for ruleID, states := range e.states {
+		var policy *EscalationPolicy
+		for i := range policies {
+			if policies[i].appliesTo(ruleID) && policies[i].Steps != nil {
+				policy = &policies[i]
+				break
+			}
+		}
+
+		for hash, state := range states {
+			// the rule stopped sending the alert, e.g. it was deleted or disabled, or the
+			// alert of a restored state resolved while the service was down
+			if (!state.alert.ValidUntil.IsZero() && state.alert.ValidUntil.Before(now)) || (state.restored && e.restoredUntil.Before(now)) {
+				deleted = append(deleted, state.stored(ruleID, hash))
+				delete(states, hash)
+				continue
+			}
+			if policy == nil || state.restored || !state.ackedAt.IsZero() {
+				continue
+			}
+			step := state.step
+			for state.step+1 < len(*policy.Steps) && !policy.dueAt(state.alert.FiredAt, state.step+1).After(now) {
+				state.step++
+				alerts = append(alerts, escalatedAlert(state.alert, policy, state.step))
+			}
+			if state.step != step {
+				saved = append(saved, state.stored(ruleID, hash))
+			}
+		}
+	}
+	e.mtx.Unlock()
+
+	e.persist(ctx, saved, deleted)
+
+	if len(alerts) > 0 {
+		zap.L().Info("escalating unacknowledged alerts", zap.Int("count", len(alerts)))
+		e.notify(ctx, alerts...)
+	}
+}
+
+func escalatedAlert(alert Alert, policy *EscalationPolicy, step int) *Alert {
+	annotations := map[string]string{}
+	if alert.Annotations != nil {
+		annotations = alert.Annotations.Map()
+	}
+	annotations[labels.AlertEscalationStepLabel] = fmt.Sprintf("%d", step+1)
+	alert.Annotations = labels.FromMap(annotations)
+	alert.Receivers = (*policy.Steps)[step].Channels
+	return &alert
+}
@@ -0,0 +1,201 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+type escalationRuleDB struct {
+	RuleDB
+	policies []EscalationPolicy
+	states   map[string]StoredEscalationState
+}
+
+func (db *escalationRuleDB) GetAllEscalationPolicies(ctx context.Context) ([]EscalationPolicy, error) {
+	return db.policies, nil
+}
+
+func (db *escalationRuleDB) GetEscalationStates(ctx context.Context) ([]StoredEscalationState, error) {
+	states := []StoredEscalationState{}
+	for _, state := range db.states {
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func (db *escalationRuleDB) SaveEscalationState(ctx context.Context, state StoredEscalationState) error {
+	if db.states == nil {
+		db.states = map[string]StoredEscalationState{}
+	}
+	db.states[state.RuleId+"/"+state.LabelsHash] = state
+	return nil
+}
+
+func (db *escalationRuleDB) DeleteEscalationState(ctx context.Context, ruleId string, labelsHash string) error {
+	delete(db.states, ruleId+"/"+labelsHash)
+	return nil
+}
+
+func TestEscalatorEscalate(t *testing.T) {
+	steps := EscalationSteps{
+		{Delay: Duration(5 * time.Minute), Channels: []string{"team-slack"}},
+		{Delay: Duration(10 * time.Minute), Channels: []string{"oncall-pagerduty"}},
+	}
+	db := &escalationRuleDB{policies: []EscalationPolicy{{Name: "default", Steps: &steps, AlertIds: &AlertIds{"1"}}}}
+
+	var sent []*Alert
+	e := NewEscalator(db, func(ctx context.Context, alerts ...*Alert) {
+		sent = append(sent, alerts...)
+	})
+
+	firedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	frontend := &Alert{
+		Labels:     labels.Labels{{Name: labels.AlertRuleIdLabel, Value: "1"}, {Name: "service", Value: "frontend"}},
+		FiredAt:    firedAt,
+		ValidUntil: firedAt.Add(time.Hour),
+	}
+	backend := &Alert{
+		Labels:     labels.Labels{{Name: labels.AlertRuleIdLabel, Value: "1"}, {Name: "service", Value: "backend"}},
+		FiredAt:    firedAt,
+		ValidUntil: firedAt.Add(time.Hour),
+	}
+	e.Track(context.Background(), frontend, backend)
+
+	e.escalate(context.Background(), firedAt.Add(time.Minute))
+	if len(sent) != 0 {
+		t.Fatalf("expected no escalation before the first delay, got %d", len(sent))
+	}
+
+	e.escalate(context.Background(), firedAt.Add(6*time.Minute))
+	if len(sent) != 2 {
+		t.Fatalf("expected both alerts to be escalated to the first step, got %d", len(sent))
+	}
+	for _, alert := range sent {
+		if alert.Receivers[0] != "team-slack" || alert.Annotations.Get(labels.AlertEscalationStepLabel) != "1" {
+			t.Errorf("unexpected escalation %v %v", alert.Receivers, alert.Annotations)
+		}
+	}
+
+	acked := e.Acknowledge(context.Background(), &AckAlertsRequest{RuleId: "1", Labels: map[string]string{"service": "frontend"}}, "user@signoz.io", firedAt.Add(7*time.Minute))
+	if acked != 1 {
+		t.Fatalf("expected one alert to be acknowledged, got %d", acked)
+	}
+
+	sent = nil
+	e.escalate(context.Background(), firedAt.Add(16*time.Minute))
+	if len(sent) != 1 || sent[0].Labels.Get("service") != "backend" || sent[0].Receivers[0] != "oncall-pagerduty" {
+		t.Fatalf("expected only the unacknowledged alert to be escalated to the second step, got %v", sent)
+	}
+
+	resolved := *backend
+	resolved.ResolvedAt = firedAt.Add(20 * time.Minute)
+	e.Track(context.Background(), &resolved)
+	if len(e.states["1"]) != 1 {
+		t.Errorf("expected resolved alert to stop being tracked")
+	}
+}
+
+func TestEscalatorAcknowledgeAlert(t *testing.T) {
+	e := NewEscalator(&escalationRuleDB{}, func(ctx context.Context, alerts ...*Alert) {})
+	alert := &Alert{
+		Labels:  labels.Labels{{Name: labels.AlertRuleIdLabel, Value: "1"}, {Name: "service", Value: "frontend"}},
+		FiredAt: time.Now(),
+	}
+	e.Track(context.Background(), alert)
+
+	if e.AcknowledgeAlert(context.Background(), "1", alert.Labels.Hash()+1, "pagerduty", time.Now()) {
+		t.Errorf("expected unknown alert not to be acknowledged")
+	}
+	if !e.AcknowledgeAlert(context.Background(), "1", alert.Labels.Hash(), "pagerduty", time.Now()) {
+		t.Fatalf("expected alert to be acknowledged")
+	}
+	if e.states["1"][alert.Labels.Hash()].ackedBy != "pagerduty" {
+		t.Errorf("expected acknowledgement to be recorded")
+	}
+	if e.AcknowledgeAlert(context.Background(), "1", alert.Labels.Hash(), "opsgenie", time.Now()) {
+		t.Errorf("expected acknowledged alert not to be acknowledged again")
+	}
+}
+
+func TestEscalatorRestore(t *testing.T) {
+	steps := EscalationSteps{
+		{Delay: Duration(5 * time.Minute), Channels: []string{"team-slack"}},
+		{Delay: Duration(10 * time.Minute), Channels: []string{"oncall-pagerduty"}},
+	}
+	db := &escalationRuleDB{policies: []EscalationPolicy{{Name: "default", Steps: &steps, AlertIds: &AlertIds{"1"}}}}
+
+	firedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	frontend := &Alert{
+		Labels:     labels.Labels{{Name: labels.AlertRuleIdLabel, Value: "1"}, {Name: "service", Value: "frontend"}},
+		FiredAt:    firedAt,
+		ValidUntil: firedAt.Add(time.Hour),
+	}
+	backend := &Alert{
+		Labels:     labels.Labels{{Name: labels.AlertRuleIdLabel, Value: "1"}, {Name: "service", Value: "backend"}},
+		FiredAt:    firedAt,
+		ValidUntil: firedAt.Add(time.Hour),
+	}
+
+	e := NewEscalator(db, func(ctx context.Context, alerts ...*Alert) {})
+	e.Track(context.Background(), frontend, backend)
+	e.escalate(context.Background(), firedAt.Add(6*time.Minute))
+	e.AcknowledgeAlert(context.Background(), "1", backend.Labels.Hash(), "pagerduty", firedAt.Add(7*time.Minute))
+	if len(db.states) != 2 {
+		t.Fatalf("expected the escalations to be saved, got %d", len(db.states))
+	}
+
+	// the service restarts, the first step is not notified again and the acknowledged
+	// alert is not escalated
+	var sent []*Alert
+	restarted := NewEscalator(db, func(ctx context.Context, alerts ...*Alert) {
+		sent = append(sent, alerts...)
+	})
+	if err := restarted.Restore(context.Background(), firedAt.Add(8*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	restarted.escalate(context.Background(), firedAt.Add(9*time.Minute))
+	if len(sent) != 0 {
+		t.Fatalf("expected no escalation before the alerts are sent again, got %d", len(sent))
+	}
+
+	restarted.Track(context.Background(), frontend, backend)
+	restarted.escalate(context.Background(), firedAt.Add(10*time.Minute))
+	if len(sent) != 0 {
+		t.Fatalf("expected the restored step not to be notified again, got %d", len(sent))
+	}
+	restarted.escalate(context.Background(), firedAt.Add(16*time.Minute))
+	if len(sent) != 1 || sent[0].Labels.Get("service") != "frontend" || sent[0].Receivers[0] != "oncall-pagerduty" {
+		t.Fatalf("expected only the unacknowledged alert to be escalated to the second step, got %v", sent)
+	}
+
+	resolved := *frontend
+	resolved.ResolvedAt = firedAt.Add(20 * time.Minute)
+	restarted.Track(context.Background(), &resolved)
+	if len(db.states) != 1 {
+		t.Errorf("expected the escalation of the resolved alert to be deleted, got %d", len(db.states))
+	}
+}
+
+func TestEscalatorRestoreResolved(t *testing.T) {
+	db := &escalationRuleDB{states: map[string]StoredEscalationState{
+		"1/42": {RuleId: "1", LabelsHash: "42", Step: 0},
+	}}
+	now := time.Now()
+	e := NewEscalator(db, func(ctx context.Context, alerts ...*Alert) {})
+	if err := e.Restore(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+	db.policies = []EscalationPolicy{{Name: "default", Steps: &EscalationSteps{{Channels: []string{"team-slack"}}}, AlertIds: &AlertIds{"1"}}}
+
+	e.escalate(context.Background(), now.Add(time.Minute))
+	if len(db.states) != 1 {
+		t.Fatalf("expected the restored escalation to wait for its alert")
+	}
+	e.escalate(context.Background(), now.Add(restoreGrace+time.Minute))
+	if len(db.states) != 0 || len(e.states["1"]) != 0 {
+		t.Errorf("expected the escalation of the alert not sent again to be dropped")
+	}
+}
@@ -0,0 +1,168 @@
+package rules
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.uber.org/zap"
+)
+
+// maxEvalDelay is the longest evaluation delay of a rule
+const maxEvalDelay = time.Hour
+
+// defaultThresholdEvalDelay is the evaluation delay of the builder and clickhouse rules
+// without one, the promql rules have none by default
+var defaultThresholdEvalDelay = func() time.Duration {
+	delay, err := time.ParseDuration(constants.AlertEvalDelay)
+	if err != nil || delay < 0 || delay > maxEvalDelay {
+		zap.L().Error("invalid ALERT_EVAL_DELAY, the default delay is used", zap.String("delay", constants.AlertEvalDelay))
+		return 2 * time.Minute
+	}
+	return delay
+}()
+
+// evalDelay returns the evaluation delay of the rule, or the default one when it has none
+func evalDelay(p *PostableRule, defaultDelay time.Duration) time.Duration {
+	if p.EvalDelay == nil {
+		return defaultDelay
+	}
+	return time.Duration(*p.EvalDelay)
+}
+
+// the reasons an evaluation is skipped for
+const (
+	// skipReasonMissed is an evaluation missed since the previous one took longer than
+	// the frequency of the rule
+	skipReasonMissed = "missed"
+	// skipReasonMaintenance is an evaluation skipped during a planned maintenance
+	skipReasonMaintenance = "maintenance"
+)
+
+var (
+	ruleEvaluations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "signoz_rule_evaluations_total",
+		Help: "The evaluations of the rule",
+	}, []string{"rule_id"})
+	ruleEvaluationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "signoz_rule_evaluation_failures_total",
+		Help: "The evaluations of the rule that failed",
+	}, []string{"rule_id"})
+	ruleEvaluationsSkipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "signoz_rule_evaluations_skipped_total",
+		Help: "The evaluations of the rule skipped, by reason",
+	}, []string{"rule_id", "reason"})
+	ruleEvaluationDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "signoz_rule_last_evaluation_duration_seconds",
+		Help: "How long the last evaluation of the rule took",
+	}, []string{"rule_id"})
+	ruleLastEvaluation = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "signoz_rule_last_evaluation_timestamp_seconds",
+		Help: "When the rule was last evaluated",
+	}, []string{"rule_id"})
+	ruleEvaluationDelay = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "signoz_rule_evaluation_delay_seconds",
+		Help: "How far behind the evaluation time the queries of the rule end",
+	}, []string{"rule_id"})
+)
+
+func init() {
+	prometheus.MustRegister(ruleEvaluations, ruleEvaluationFailures, ruleEvaluationsSkipped,
+		ruleEvaluationDuration, ruleLastEvaluation, ruleEvaluationDelay)
+}
+
+// evaluationStats counts the evaluations of a rule since the query service started
+type evaluationStats struct {
+	Evaluations int64
+	Failures    int64
+	// Skipped are the evaluations skipped by reason
+	Skipped     map[string]int64
+	LastSkipped time.Time
+}
+
+// evaluationTracker keeps the stats of the rules evaluated by the replica, keyed by rule id
+type evaluationTracker struct {
+	mtx   sync.Mutex
+	stats map[string]*evaluationStats
+}
+
+var tracker = &evaluationTracker{stats: map[string]*evaluationStats{}}
+
+// get returns the stats of the rule, the caller must hold the lock
+func (t *evaluationTracker) get(ruleId string) *evaluationStats {
+	stats, ok := t.stats[ruleId]
+	if !ok {
+		stats = &evaluationStats{Skipped: map[string]int64{}}
+		t.stats[ruleId] = stats
+	}
+	return stats
+}
+
+// recordEvaluation records an evaluation of the rule, started at and lasting duration
+func recordEvaluation(rule Rule, at time.Time, duration time.Duration, err error) {
+	t := tracker
+	t.mtx.Lock()
+	stats := t.get(rule.ID())
+	stats.Evaluations++
+	if err != nil {
+		stats.Failures++
+	}
+	t.mtx.Unlock()
+
+	ruleEvaluations.WithLabelValues(rule.ID()).Inc()
+	if err != nil {
+		ruleEvaluationFailures.WithLabelValues(rule.ID()).Inc()
+	}
+	ruleEvaluationDuration.WithLabelValues(rule.ID()).Set(duration.Seconds())
+	ruleLastEvaluation.WithLabelValues(rule.ID()).Set(float64(at.Unix()))
+	if d, ok := rule.(evalDelayer); ok {
+		ruleEvaluationDelay.WithLabelValues(rule.ID()).Set(d.EvalDelay().Seconds())
+	}
+}
+
+// recordSkipped records count evaluations of the rule skipped for reason
+func recordSkipped(ruleId string, reason string, count int64, now time.Time) {
+	t := tracker
+	t.mtx.Lock()
+	stats := t.get(ruleId)
+	stats.Skipped[reason] += count
+	stats.LastSkipped = now
+	t.mtx.Unlock()
+
+	ruleEvaluationsSkipped.WithLabelValues(ruleId, reason).Add(float64(count))
+}
+
+// forgetRule drops the stats and the series of the rule deleted
+func forgetRule(ruleId string) {
+	t := tracker
+	t.mtx.Lock()
+	delete(t.stats, ruleId)
+	t.mtx.Unlock()
+
+	for _, vec := range []*prometheus.CounterVec{ruleEvaluations, ruleEvaluationFailures} {
+		vec.DeleteLabelValues(ruleId)
+	}
+	ruleEvaluationsSkipped.DeletePartialMatch(prometheus.Labels{"rule_id": ruleId})
+	for _, vec := range []*prometheus.GaugeVec{ruleEvaluationDuration, ruleLastEvaluation, ruleEvaluationDelay} {
+		vec.DeleteLabelValues(ruleId)
+	}
+}
+
+// statsOf returns a copy of the stats of the rule
+func statsOf(ruleId string) evaluationStats {
+	t := tracker
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	stats := t.get(ruleId)
+	skipped := make(map[string]int64, len(stats.Skipped))
+	for reason, count := range stats.Skipped {
+		skipped[reason] = count
+	}
+	return evaluationStats{Evaluations: stats.Evaluations, Failures: stats.Failures, Skipped: skipped, LastSkipped: stats.LastSkipped}
+}
+
+// evalDelayer is a rule whose queries end behind the evaluation time
+type evalDelayer interface {
+	EvalDelay() time.Duration
+}
@@ -0,0 +1,52 @@
+package rules
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestEvaluationHealth(t *testing.T) {
+	delay := Duration(3 * time.Minute)
+	postableRule := PostableRule{
+		AlertName:  "Evaluation Health",
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		EvalDelay:  &delay,
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeClickHouseSQL,
+				ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+					"A": {Query: "SELECT 1"},
+				},
+			},
+		},
+	}
+	rule, err := NewThresholdRule("health", &postableRule, ThresholdRuleOpts{}, featureManager.StartManager(), nil)
+	require.NoError(t, err)
+	require.Equal(t, 3*time.Minute, rule.EvalDelay())
+
+	now := time.Now()
+	recordEvaluation(rule, now, time.Second, nil)
+	recordEvaluation(rule, now.Add(time.Minute), time.Second, errors.New("timeout"))
+	recordSkipped(rule.ID(), skipReasonMissed, 2, now)
+	recordSkipped(rule.ID(), skipReasonMaintenance, 1, now.Add(time.Minute))
+
+	stats := statsOf(rule.ID())
+	require.Equal(t, int64(2), stats.Evaluations)
+	require.Equal(t, int64(1), stats.Failures)
+	require.Equal(t, map[string]int64{skipReasonMissed: 2, skipReasonMaintenance: 1}, stats.Skipped)
+	require.Equal(t, now.Add(time.Minute), stats.LastSkipped)
+
+	forgetRule(rule.ID())
+	require.Zero(t, statsOf(rule.ID()).Evaluations)
+
+	invalid := Duration(2 * time.Hour)
+	postableRule.EvalDelay = &invalid
+	require.NotEmpty(t, postableRule.Validate())
+}
@@ -0,0 +1,61 @@
+package rules
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// RuleEvaluation is the last evaluation of a rule
+type RuleEvaluation struct {
+	RuleId         string    `json:"ruleId"`
+	Name           string    `json:"name"`
+	LastEvaluation time.Time `json:"lastEvaluation"`
+	LatencyMs      float64   `json:"latencyMs"`
+
+	Health    RuleHealth `json:"health"`
+	LastError string     `json:"lastError,omitempty"`
+	// EvalDelayMs is how far behind the evaluation time the queries of the rule end, to
+	// account for the ingestion lag
+	EvalDelayMs int64 `json:"evalDelayMs"`
+
+	// the counts are of the evaluations run since the query service started
+	Evaluations int64 `json:"evaluations"`
+	Failures    int64 `json:"failures"`
+	// Skipped are the evaluations skipped by reason, missed when the previous evaluation
+	// took longer than the frequency of the rule or during a planned maintenance
+	Skipped     map[string]int64 `json:"skipped"`
+	LastSkipped *time.Time       `json:"lastSkipped,omitempty"`
+}
+
+type RuleEvaluations struct {
+	Rules []*RuleEvaluation `json:"rules"`
+}
+
+// ListRuleEvaluations returns the last evaluation and the evaluation health of each rule
+func (m *Manager) ListRuleEvaluations(ctx context.Context) *RuleEvaluations {
+	result := &RuleEvaluations{Rules: []*RuleEvaluation{}}
+	for _, rule := range m.Rules() {
+		evaluation := &RuleEvaluation{
+			RuleId:         rule.ID(),
+			Name:           rule.Name(),
+			LastEvaluation: rule.GetEvaluationTimestamp(),
+			LatencyMs:      float64(rule.GetEvaluationDuration()) / float64(time.Millisecond),
+			Health:         rule.Health(),
+		}
+		if err := rule.LastError(); err != nil {
+			evaluation.LastError = err.Error()
+		}
+		if d, ok := rule.(evalDelayer); ok {
+			evaluation.EvalDelayMs = d.EvalDelay().Milliseconds()
+		}
+		stats := statsOf(rule.ID())
+		evaluation.Evaluations, evaluation.Failures, evaluation.Skipped = stats.Evaluations, stats.Failures, stats.Skipped
+		if !stats.LastSkipped.IsZero() {
+			evaluation.LastSkipped = &stats.LastSkipped
+		}
+		result.Rules = append(result.Rules, evaluation)
+	}
+	sort.Slice(result.Rules, func(i, j int) bool { return result.Rules[i].RuleId < result.Rules[j].RuleId })
+	return result
+}
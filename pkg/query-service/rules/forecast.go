@@ -0,0 +1,143 @@
+package rules
+
+import (
+	"math"
+	"time"
+)
+
+// forecastCrossingAnnotation tells when the trend of a forecast alert crosses the threshold
+const forecastCrossingAnnotation = "forecast_crossing_at"
+
+// usesForecast tells whether the rule or one of its conditions matches on a forecast
+func (rc *RuleCondition) usesForecast() bool {
+	if rc.IsMultiCondition() {
+		for _, c := range rc.Conditions {
+			if c != nil && c.MatchType == InForecast {
+				return true
+			}
+		}
+		return false
+	}
+	return rc.MatchType == InForecast
+}
+
+// validForecast checks the forecast window and that the forecasts are compared with an
+// above or below op, a trend never equals a target exactly
+func (rc *RuleCondition) validForecast() bool {
+	if !rc.usesForecast() {
+		return true
+	}
+	if rc.ForecastWindow <= 0 {
+		return false
+	}
+	ops := []CompareOp{rc.CompareOp}
+	if rc.IsMultiCondition() {
+		ops = ops[:0]
+		for _, c := range rc.Conditions {
+			if c != nil && c.MatchType == InForecast {
+				ops = append(ops, c.CompareOp)
+			}
+		}
+	}
+	for _, op := range ops {
+		if op != ValueIsAbove && op != ValueIsBelow {
+			return false
+		}
+	}
+	return true
+}
+
+// trend is the least squares line through the points of a series, in value per millisecond
+type trend struct {
+	slope float64
+	// mean is the point the line goes through, the timestamps are centered on it to keep
+	// the precision of the fit
+	meanT float64
+	meanV float64
+	// last is the timestamp of the latest point
+	last int64
+}
+
+// fitTrend fits a line through the points of the series, skipping the NaN and infinite
+// values. It needs two points at different times at least
+func fitTrend(ts []int64, vs []float64) (*trend, bool) {
+	var n, sumT, sumV float64
+	var last int64
+	for i := range ts {
+		if math.IsNaN(vs[i]) || math.IsInf(vs[i], 0) {
+			continue
+		}
+		n++
+		sumT += float64(ts[i])
+		sumV += vs[i]
+		if ts[i] > last {
+			last = ts[i]
+		}
+	}
+	if n < 2 {
+		return nil, false
+	}
+
+	t := &trend{meanT: sumT / n, meanV: sumV / n, last: last}
+	var covariance, variance float64
+	for i := range ts {
+		if math.IsNaN(vs[i]) || math.IsInf(vs[i], 0) {
+			continue
+		}
+		dt := float64(ts[i]) - t.meanT
+		covariance += dt * (vs[i] - t.meanV)
+		variance += dt * dt
+	}
+	if variance == 0 {
+		return nil, false
+	}
+	t.slope = covariance / variance
+	return t, true
+}
+
+// at returns the value of the trend at the timestamp in milliseconds
+func (t *trend) at(ts int64) float64 {
+	return t.meanV + t.slope*(float64(ts)-t.meanT)
+}
+
+// crossing returns when the trend crosses the target after its latest point, the latest
+// point when it is already past the target
+func (t *trend) crossing(compareOp CompareOp, target float64) (int64, bool) {
+	current := t.at(t.last)
+	if (compareOp == ValueIsAbove && current > target) || (compareOp == ValueIsBelow && current < target) {
+		return t.last, true
+	}
+	if t.slope == 0 {
+		return 0, false
+	}
+	after := (target - current) / t.slope
+	if after < 0 {
+		return 0, false
+	}
+	return t.last + int64(math.Ceil(after)), true
+}
+
+// matchForecast extends the trend of the series by the window past its latest point and
+// matches the forecast value with the target. It returns the forecast value and when the
+// trend crosses the target
+func matchForecast(ts []int64, vs []float64, compareOp CompareOp, target float64, window time.Duration) (float64, int64, bool) {
+	t, ok := fitTrend(ts, vs)
+	if !ok {
+		return 0, 0, false
+	}
+	forecast := t.at(t.last + window.Milliseconds())
+	switch compareOp {
+	case ValueIsAbove:
+		if forecast <= target {
+			return forecast, 0, false
+		}
+	case ValueIsBelow:
+		if forecast >= target {
+			return forecast, 0, false
+		}
+	default:
+		return forecast, 0, false
+	}
+	crossAt, _ := t.crossing(compareOp, target)
+	return forecast, crossAt, true
+}
@@ -0,0 +1,107 @@
+package rules
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestMatchForecast(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC).UnixMilli()
+	minute := time.Minute.Milliseconds()
+
+	// a disk filling up by 1% a minute from 50%
+	var ts []int64
+	var vs []float64
+	for i := int64(0); i <= 30; i++ {
+		ts = append(ts, start+i*minute)
+		vs = append(vs, 50+float64(i))
+	}
+	vs[10] = math.NaN()
+
+	forecast, crossAt, ok := matchForecast(ts, vs, ValueIsAbove, 90, time.Hour)
+	require.True(t, ok)
+	require.InDelta(t, 140, forecast, 1e-6)
+	// 80% at the latest point, 90% ten minutes later
+	require.Equal(t, start+40*minute, crossAt)
+
+	_, _, ok = matchForecast(ts, vs, ValueIsAbove, 90, 5*time.Minute)
+	require.False(t, ok, "the threshold is crossed after the window")
+
+	_, _, ok = matchForecast(ts, vs, ValueIsBelow, 10, time.Hour)
+	require.False(t, ok, "the trend goes up")
+
+	_, crossAt, ok = matchForecast(ts, vs, ValueIsAbove, 70, time.Minute)
+	require.True(t, ok)
+	require.Equal(t, ts[len(ts)-1], crossAt, "the threshold is already crossed")
+
+	_, _, ok = matchForecast(ts[:1], vs[:1], ValueIsAbove, 0, time.Hour)
+	require.False(t, ok, "a single point has no trend")
+
+	_, _, ok = matchForecast(ts, vs, ValueIsEq, 140, time.Hour)
+	require.False(t, ok)
+}
+
+func TestThresholdRuleForecast(t *testing.T) {
+	target := 90.0
+	postableRule := PostableRule{
+		AlertName:  "Disk Full Soon",
+		AlertType:  "METRIC_BASED_ALERT",
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(time.Hour),
+		Frequency:  Duration(time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:          "A",
+						StepInterval:       60,
+						AggregateAttribute: v3.AttributeKey{Key: "disk_usage_percent"},
+						AggregateOperator:  v3.AggregateOperatorMax,
+						DataSource:         v3.DataSourceMetrics,
+						Expression:         "A",
+					},
+				},
+			},
+			CompareOp:      ValueIsAbove,
+			MatchType:      InForecast,
+			Target:         &target,
+			ForecastWindow: Duration(6 * time.Hour),
+		},
+	}
+	require.True(t, postableRule.RuleCondition.IsValid())
+
+	rule, err := NewThresholdRule("forecast", &postableRule, ThresholdRuleOpts{}, featureManager.StartManager(), nil)
+	require.NoError(t, err)
+
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	series := func(perHour float64) v3.Series {
+		s := v3.Series{Labels: map[string]string{"host": "db-1"}}
+		for i := 0; i <= 60; i++ {
+			s.Points = append(s.Points, v3.Point{Timestamp: start.Add(time.Duration(i) * time.Minute).UnixMilli(), Value: 60 + perHour*float64(i)/60})
+		}
+		return s
+	}
+
+	// 70% at the latest point and 10% more an hour, 90% two hours later
+	smpl, shouldAlert := rule.shouldAlert(series(10))
+	require.True(t, shouldAlert)
+	require.InDelta(t, 130, smpl.V, 1e-6)
+	require.Equal(t, start.Add(3*time.Hour).UnixMilli(), smpl.T)
+
+	// 61% at the latest point and 1% more an hour, 90% in 29 hours
+	_, shouldAlert = rule.shouldAlert(series(1))
+	require.False(t, shouldAlert)
+
+	invalid := *postableRule.RuleCondition
+	invalid.ForecastWindow = 0
+	require.False(t, invalid.IsValid())
+	invalid = *postableRule.RuleCondition
+	invalid.CompareOp = ValueIsEq
+	require.False(t, invalid.IsValid())
+}
@@ -1,13 +1,16 @@
 package rules
 
 import (
+	"context"
 	"database/sql/driver"
 	"encoding/json"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 	"go.uber.org/zap"
 )
 
@@ -17,6 +20,7 @@ var (
 	ErrMissingTimezone   = errors.New("missing timezone")
 	ErrMissingRepeatType = errors.New("missing repeat type")
 	ErrMissingDuration   = errors.New("missing duration")
+	ErrInvalidMatcher    = errors.New("invalid label matcher")
 )
 
 type PlannedMaintenance struct {
@@ -25,12 +29,15 @@ type PlannedMaintenance struct {
 	Description string    `json:"description" db:"description"`
 	Schedule    *Schedule `json:"schedule" db:"schedule"`
 	AlertIds    *AlertIds `json:"alertIds" db:"alert_ids"`
-	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
-	CreatedBy   string    `json:"createdBy" db:"created_by"`
-	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
-	UpdatedBy   string    `json:"updatedBy" db:"updated_by"`
-	Status      string    `json:"status"`
-	Kind        string    `json:"kind"`
+	// Matchers, when set, limit the maintenance to the alerts with matching labels.
+	// The rules are still evaluated but the matching alerts are annotated and not notified.
+	Matchers  *LabelMatchers `json:"matchers,omitempty" db:"matchers"`
+	CreatedAt time.Time      `json:"createdAt" db:"created_at"`
+	CreatedBy string         `json:"createdBy" db:"created_by"`
+	UpdatedAt time.Time      `json:"updatedAt" db:"updated_at"`
+	UpdatedBy string         `json:"updatedBy" db:"updated_by"`
+	Status    string         `json:"status"`
+	Kind      string         `json:"kind"`
 }
 
 type AlertIds []string
@@ -46,6 +53,98 @@ func (a *AlertIds) Value() (driver.Value, error) {
 	return json.Marshal(a)
 }
 
+type MatchOp string
+
+const (
+	MatchOpEqual    MatchOp = "="
+	MatchOpNotEqual MatchOp = "!="
+	MatchOpRegexp   MatchOp = "=~"
+	MatchOpNotRegex MatchOp = "!~"
+)
+
+// LabelMatcher matches the value of an alert label
+type LabelMatcher struct {
+	Key   string  `json:"key"`
+	Op    MatchOp `json:"op"`
+	Value string  `json:"value"`
+
+	// re is the compiled regex of the regex matchers, it is compiled when the
+	// matcher is decoded so the alerts are not matched against a new regex each time
+	re *regexp.Regexp
+}
+
+func (lm *LabelMatcher) UnmarshalJSON(data []byte) error {
+	type matcher LabelMatcher
+	if err := json.Unmarshal(data, (*matcher)(lm)); err != nil {
+		return err
+	}
+	lm.re = nil
+	if lm.Op == MatchOpRegexp || lm.Op == MatchOpNotRegex {
+		// an invalid regex is reported by Validate
+		lm.re, _ = lm.compile()
+	}
+	return nil
+}
+
+// compile compiles the regex of the matcher, anchored to the whole label value
+func (lm LabelMatcher) compile() (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + lm.Value + ")$")
+}
+
+func (lm LabelMatcher) Validate() error {
+	if lm.Key == "" {
+		return ErrInvalidMatcher
+	}
+	switch lm.Op {
+	case MatchOpEqual, MatchOpNotEqual:
+	case MatchOpRegexp, MatchOpNotRegex:
+		if _, err := lm.compile(); err != nil {
+			return errors.Wrap(ErrInvalidMatcher, err.Error())
+		}
+	default:
+		return ErrInvalidMatcher
+	}
+	return nil
+}
+
+// Matches returns true if the label value satisfies the matcher,
+// a missing label has an empty value
+func (lm LabelMatcher) Matches(lbls labels.BaseLabels) bool {
+	value := lbls.Get(lm.Key)
+	switch lm.Op {
+	case MatchOpEqual:
+		return value == lm.Value
+	case MatchOpNotEqual:
+		return value != lm.Value
+	case MatchOpRegexp, MatchOpNotRegex:
+		re := lm.re
+		if re == nil {
+			var err error
+			if re, err = lm.compile(); err != nil {
+				return false
+			}
+		}
+		return re.MatchString(value) == (lm.Op == MatchOpRegexp)
+	}
+	return false
+}
+
+type LabelMatchers []LabelMatcher
+
+func (lm *LabelMatchers) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, lm)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), lm)
+	}
+	return nil
+}
+
+func (lm *LabelMatchers) Value() (driver.Value, error) {
+	return json.Marshal(lm)
+}
+
 type Schedule struct {
 	Timezone   string      `json:"timezone"`
 	StartTime  time.Time   `json:"startTime,omitempty"`
@@ -210,7 +309,45 @@ func (s *Schedule) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// shouldSkip returns true if the rule should not be evaluated at the given
+// time, maintenances with label matchers never skip the evaluation
+// and suppress the matching alerts instead
 func (m *PlannedMaintenance) shouldSkip(ruleID string, now time.Time) bool {
+	if m.hasMatchers() {
+		return false
+	}
+	return m.isActiveFor(ruleID, now)
+}
+
+// shouldSuppress returns true if the maintenance has label matchers
+// and is active for the rule at the given time
+func (m *PlannedMaintenance) shouldSuppress(ruleID string, now time.Time) bool {
+	if !m.hasMatchers() {
+		return false
+	}
+	return m.isActiveFor(ruleID, now)
+}
+
+func (m *PlannedMaintenance) hasMatchers() bool {
+	return m.Matchers != nil && len(*m.Matchers) > 0
+}
+
+// suppresses returns true if all the label matchers match the alert labels
+func (m *PlannedMaintenance) suppresses(lbls labels.BaseLabels) bool {
+	if !m.hasMatchers() {
+		return false
+	}
+	for _, matcher := range *m.Matchers {
+		if !matcher.Matches(lbls) {
+			return false
+		}
+	}
+	return true
+}
+
+// isActiveFor returns true if the maintenance applies to the rule
+// and the given time is within the schedule
+func (m *PlannedMaintenance) isActiveFor(ruleID string, now time.Time) bool {
 
 	found := false
 	if m.AlertIds != nil {
@@ -228,93 +365,94 @@ func (m *PlannedMaintenance) shouldSkip(ruleID string, now time.Time) bool {
 	}
 
 	if found {
-
 		zap.L().Info("alert found in maintenance", zap.String("alert", ruleID), zap.Any("maintenance", m.Name))
+		return m.Schedule.isActive(now)
+	}
+	// If alert is not found, we return false
+	return false
+}
 
-		// If alert is found, we check if it should be skipped based on the schedule
-		// If it should be skipped, we return true
-		// If it should not be skipped, we return false
-
-		// fixed schedule
-		if !m.Schedule.StartTime.IsZero() && !m.Schedule.EndTime.IsZero() {
-			// if the current time in the timezone is between the start and end time
-			loc, err := time.LoadLocation(m.Schedule.Timezone)
-			if err != nil {
-				zap.L().Error("Error loading location", zap.String("timezone", m.Schedule.Timezone), zap.Error(err))
-				return false
-			}
+// isActive returns true if the given time is within the fixed schedule
+// or within one of the windows of the recurrence
+func (s *Schedule) isActive(now time.Time) bool {
+	// fixed schedule
+	if !s.StartTime.IsZero() && !s.EndTime.IsZero() {
+		// if the current time in the timezone is between the start and end time
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			zap.L().Error("Error loading location", zap.String("timezone", s.Timezone), zap.Error(err))
+			return false
+		}
 
-			currentTime := now.In(loc)
-			zap.L().Info("checking fixed schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", m.Schedule.StartTime), zap.Time("endTime", m.Schedule.EndTime))
-			if currentTime.After(m.Schedule.StartTime) && currentTime.Before(m.Schedule.EndTime) {
-				return true
-			}
+		currentTime := now.In(loc)
+		zap.L().Info("checking fixed schedule", zap.Time("currentTime", currentTime), zap.Time("startTime", s.StartTime), zap.Time("endTime", s.EndTime))
+		if currentTime.After(s.StartTime) && currentTime.Before(s.EndTime) {
+			return true
 		}
+	}
 
-		// recurring schedule
-		if m.Schedule.Recurrence != nil {
-			zap.L().Info("evaluating recurrence schedule")
-			start := m.Schedule.Recurrence.StartTime
-			end := m.Schedule.Recurrence.StartTime.Add(time.Duration(m.Schedule.Recurrence.Duration))
-			// if the current time in the timezone is between the start and end time
-			loc, err := time.LoadLocation(m.Schedule.Timezone)
-			if err != nil {
-				zap.L().Error("Error loading location", zap.String("timezone", m.Schedule.Timezone), zap.Error(err))
-				return false
-			}
-			currentTime := now.In(loc)
+	// recurring schedule
+	if s.Recurrence != nil {
+		zap.L().Info("evaluating recurrence schedule")
+		start := s.Recurrence.StartTime
+		end := s.Recurrence.StartTime.Add(time.Duration(s.Recurrence.Duration))
+		// if the current time in the timezone is between the start and end time
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			zap.L().Error("Error loading location", zap.String("timezone", s.Timezone), zap.Error(err))
+			return false
+		}
+		currentTime := now.In(loc)
 
-			zap.L().Info("checking recurring schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", start), zap.Time("endTime", end))
+		zap.L().Info("checking recurring schedule", zap.Time("currentTime", currentTime), zap.Time("startTime", start), zap.Time("endTime", end))
 
-			// make sure the start time is not after the current time
-			if currentTime.Before(start.In(loc)) {
-				zap.L().Info("current time is before start time", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", start.In(loc)))
-				return false
-			}
+		// make sure the start time is not after the current time
+		if currentTime.Before(start.In(loc)) {
+			zap.L().Info("current time is before start time", zap.Time("currentTime", currentTime), zap.Time("startTime", start.In(loc)))
+			return false
+		}
 
-			var endTime time.Time
-			if m.Schedule.Recurrence.EndTime != nil {
-				endTime = *m.Schedule.Recurrence.EndTime
-			}
-			if !endTime.IsZero() && currentTime.After(endTime.In(loc)) {
-				zap.L().Info("current time is after end time", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("endTime", end.In(loc)))
-				return false
-			}
+		var endTime time.Time
+		if s.Recurrence.EndTime != nil {
+			endTime = *s.Recurrence.EndTime
+		}
+		if !endTime.IsZero() && currentTime.After(endTime.In(loc)) {
+			zap.L().Info("current time is after end time", zap.Time("currentTime", currentTime), zap.Time("endTime", end.In(loc)))
+			return false
+		}
 
-			switch m.Schedule.Recurrence.RepeatType {
-			case RepeatTypeDaily:
-				// take the hours and minutes from the start time and add them to the current time
-				startTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), start.Hour(), start.Minute(), 0, 0, loc)
-				endTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), end.Hour(), end.Minute(), 0, 0, loc)
-				zap.L().Info("checking daily schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
+		switch s.Recurrence.RepeatType {
+		case RepeatTypeDaily:
+			// take the hours and minutes from the start time and add them to the current time
+			startTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+			endTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+			zap.L().Info("checking daily schedule", zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
 
-				if currentTime.After(startTime) && currentTime.Before(endTime) {
+			if currentTime.After(startTime) && currentTime.Before(endTime) {
+				return true
+			}
+		case RepeatTypeWeekly:
+			// if the current time in the timezone is between the start and end time on the RepeatOn day
+			startTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+			endTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+			zap.L().Info("checking weekly schedule", zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
+			if currentTime.After(startTime) && currentTime.Before(endTime) {
+				if len(s.Recurrence.RepeatOn) == 0 {
 					return true
-				}
-			case RepeatTypeWeekly:
-				// if the current time in the timezone is between the start and end time on the RepeatOn day
-				startTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), start.Hour(), start.Minute(), 0, 0, loc)
-				endTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), end.Hour(), end.Minute(), 0, 0, loc)
-				zap.L().Info("checking weekly schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
-				if currentTime.After(startTime) && currentTime.Before(endTime) {
-					if len(m.Schedule.Recurrence.RepeatOn) == 0 {
-						return true
-					} else if slices.Contains(m.Schedule.Recurrence.RepeatOn, RepeatOn(strings.ToLower(currentTime.Weekday().String()))) {
-						return true
-					}
-				}
-			case RepeatTypeMonthly:
-				// if the current time in the timezone is between the start and end time on the day of the current month
-				startTime := time.Date(currentTime.Year(), currentTime.Month(), start.Day(), start.Hour(), start.Minute(), 0, 0, loc)
-				endTime := time.Date(currentTime.Year(), currentTime.Month(), end.Day(), end.Hour(), end.Minute(), 0, 0, loc)
-				zap.L().Info("checking monthly schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
-				if currentTime.After(startTime) && currentTime.Before(endTime) && currentTime.Day() == start.Day() {
+				} else if slices.Contains(s.Recurrence.RepeatOn, RepeatOn(strings.ToLower(currentTime.Weekday().String()))) {
 					return true
 				}
 			}
+		case RepeatTypeMonthly:
+			// if the current time in the timezone is between the start and end time on the day of the current month
+			startTime := time.Date(currentTime.Year(), currentTime.Month(), start.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+			endTime := time.Date(currentTime.Year(), currentTime.Month(), end.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+			zap.L().Info("checking monthly schedule", zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
+			if currentTime.After(startTime) && currentTime.Before(endTime) && currentTime.Day() == start.Day() {
+				return true
+			}
 		}
 	}
-	// If alert is not found, we return false
 	return false
 }
 
@@ -323,7 +461,7 @@ func (m *PlannedMaintenance) IsActive(now time.Time) bool {
 	if m.AlertIds != nil && len(*m.AlertIds) > 0 {
 		ruleID = (*m.AlertIds)[0]
 	}
-	return m.shouldSkip(ruleID, now)
+	return m.isActiveFor(ruleID, now)
 }
 
 func (m *PlannedMaintenance) IsUpcoming() bool {
@@ -363,6 +501,14 @@ func (m *PlannedMaintenance) Validate() error {
 		}
 	}
 
+	if m.Matchers != nil {
+		for _, matcher := range *m.Matchers {
+			if err := matcher.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
 	if m.Schedule.Recurrence != nil {
 		if m.Schedule.Recurrence.RepeatType == "" {
 			return ErrMissingRepeatType
@@ -396,23 +542,25 @@ func (m PlannedMaintenance) MarshalJSON() ([]byte, error) {
 	}
 
 	return json.Marshal(struct {
-		Id          int64     `json:"id" db:"id"`
-		Name        string    `json:"name" db:"name"`
-		Description string    `json:"description" db:"description"`
-		Schedule    *Schedule `json:"schedule" db:"schedule"`
-		AlertIds    *AlertIds `json:"alertIds" db:"alert_ids"`
-		CreatedAt   time.Time `json:"createdAt" db:"created_at"`
-		CreatedBy   string    `json:"createdBy" db:"created_by"`
-		UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
-		UpdatedBy   string    `json:"updatedBy" db:"updated_by"`
-		Status      string    `json:"status"`
-		Kind        string    `json:"kind"`
+		Id          int64          `json:"id" db:"id"`
+		Name        string         `json:"name" db:"name"`
+		Description string         `json:"description" db:"description"`
+		Schedule    *Schedule      `json:"schedule" db:"schedule"`
+		AlertIds    *AlertIds      `json:"alertIds" db:"alert_ids"`
+		Matchers    *LabelMatchers `json:"matchers,omitempty" db:"matchers"`
+		CreatedAt   time.Time      `json:"createdAt" db:"created_at"`
+		CreatedBy   string         `json:"createdBy" db:"created_by"`
+		UpdatedAt   time.Time      `json:"updatedAt" db:"updated_at"`
+		UpdatedBy   string         `json:"updatedBy" db:"updated_by"`
+		Status      string         `json:"status"`
+		Kind        string         `json:"kind"`
 	}{
 		Id:          m.Id,
 		Name:        m.Name,
 		Description: m.Description,
 		Schedule:    m.Schedule,
 		AlertIds:    m.AlertIds,
+		Matchers:    m.Matchers,
 		CreatedAt:   m.CreatedAt,
 		CreatedBy:   m.CreatedBy,
 		UpdatedAt:   m.UpdatedAt,
@@ -421,3 +569,45 @@ func (m PlannedMaintenance) MarshalJSON() ([]byte, error) {
 		Kind:        kind,
 	})
 }
+
+// suppressAlerts annotates the active alerts of the rule that are matched by
+// the given maintenances and returns a notify func that drops them
+func suppressAlerts(rule Rule, maintenances []PlannedMaintenance, notify NotifyFunc) NotifyFunc {
+	iter, ok := rule.(interface{ ForEachActiveAlert(func(*Alert)) })
+	if !ok {
+		return notify
+	}
+
+	suppressed := map[uint64]struct{}{}
+	iter.ForEachActiveAlert(func(alert *Alert) {
+		annotations := map[string]string{}
+		if alert.Annotations != nil {
+			annotations = alert.Annotations.Map()
+		}
+		delete(annotations, labels.AlertMaintenanceLabel)
+		for _, m := range maintenances {
+			if m.suppresses(alert.Labels) {
+				annotations[labels.AlertMaintenanceLabel] = m.Name
+				suppressed[alert.Labels.Hash()] = struct{}{}
+				break
+			}
+		}
+		alert.Annotations = labels.FromMap(annotations)
+	})
+
+	if len(suppressed) == 0 {
+		return notify
+	}
+
+	return func(ctx context.Context, expr string, alerts ...*Alert) {
+		var res []*Alert
+		for _, alert := range alerts {
+			if _, ok := suppressed[alert.Labels.Hash()]; ok {
+				zap.L().Info("alert suppressed by maintenance", zap.String("rule", rule.ID()), zap.Any("labels", alert.Labels.Map()))
+				continue
+			}
+			res = append(res, alert)
+		}
+		notify(ctx, expr, res...)
+	}
+}
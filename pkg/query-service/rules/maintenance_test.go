@@ -3,6 +3,8 @@ package rules
 import (
 	"testing"
 	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 )
 
 func TestShouldSkipMaintenance(t *testing.T) {
@@ -228,3 +230,80 @@ func TestShouldSkipMaintenance(t *testing.T) {
 		}
 	}
 }
+
+func TestMaintenanceMatchers(t *testing.T) {
+
+	schedule := &Schedule{
+		Timezone:  "UTC",
+		StartTime: time.Now().UTC().Add(-time.Hour),
+		EndTime:   time.Now().UTC().Add(time.Hour),
+	}
+
+	cases := []struct {
+		name       string
+		matchers   LabelMatchers
+		labels     labels.Labels
+		suppresses bool
+	}{
+		{
+			name:       "equal matcher matches",
+			matchers:   LabelMatchers{{Key: "service", Op: MatchOpEqual, Value: "frontend"}},
+			labels:     labels.Labels{{Name: "service", Value: "frontend"}},
+			suppresses: true,
+		},
+		{
+			name:       "equal matcher does not match",
+			matchers:   LabelMatchers{{Key: "service", Op: MatchOpEqual, Value: "frontend"}},
+			labels:     labels.Labels{{Name: "service", Value: "backend"}},
+			suppresses: false,
+		},
+		{
+			name:       "not equal matcher on missing label",
+			matchers:   LabelMatchers{{Key: "env", Op: MatchOpNotEqual, Value: "prod"}},
+			labels:     labels.Labels{{Name: "service", Value: "backend"}},
+			suppresses: true,
+		},
+		{
+			name: "all matchers must match",
+			matchers: LabelMatchers{
+				{Key: "service", Op: MatchOpRegexp, Value: "front.*"},
+				{Key: "env", Op: MatchOpNotRegex, Value: "prod|staging"},
+			},
+			labels:     labels.Labels{{Name: "env", Value: "staging"}, {Name: "service", Value: "frontend"}},
+			suppresses: false,
+		},
+	}
+
+	for _, c := range cases {
+		matchers := c.matchers
+		m := &PlannedMaintenance{Schedule: schedule, Matchers: &matchers}
+		if m.shouldSkip("1", time.Now().UTC()) {
+			t.Errorf("%s: maintenance with matchers should not skip the rule", c.name)
+		}
+		if !m.shouldSuppress("1", time.Now().UTC()) {
+			t.Errorf("%s: expected maintenance to be active", c.name)
+		}
+		if result := m.suppresses(c.labels); result != c.suppresses {
+			t.Errorf("%s: expected %v, got %v", c.name, c.suppresses, result)
+		}
+	}
+
+	invalid := LabelMatchers{{Key: "service", Op: MatchOpRegexp, Value: "("}}
+	m := &PlannedMaintenance{Name: "invalid", Schedule: schedule, Matchers: &invalid}
+	if err := m.Validate(); err == nil {
+		t.Errorf("expected invalid regex matcher to fail validation")
+	}
+
+	// the regex of the stored matchers is compiled once
+	var stored LabelMatchers
+	if err := stored.Scan([]byte(`[{"key":"service","op":"=~","value":"front.*"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	if stored[0].re == nil {
+		t.Errorf("expected the regex of the matcher to be compiled")
+	}
+	m = &PlannedMaintenance{Schedule: schedule, Matchers: &stored}
+	if !m.suppresses(labels.Labels{{Name: "service", Value: "frontend"}}) {
+		t.Errorf("expected the stored matcher to match")
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"github.com/jmoiron/sqlx"
 
 	// opentracing "github.com/opentracing/opentracing-go"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/dao"
 	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
@@ -63,6 +65,10 @@ type ManagerOptions struct {
 	DisableRules bool
 	FeatureFlags interfaces.FeatureLookup
 	Reader       interfaces.Reader
+
+	// onStateChange is set by the manager, its tasks call it with the state changes
+	// of the alerts after each evaluation
+	onStateChange StateListener
 }
 
 // The Manager manages recording and alerting rules.
@@ -74,6 +80,24 @@ type Manager struct {
 	block chan struct{}
 	// Notifier sends messages through alert manager
 	notifier *am.Notifier
+	// routes syncs the receivers of the rules with notification settings with alert manager
+	routes *ruleRoutes
+	// escalator re-notifies the unacknowledged alerts
+	escalator *Escalator
+	// directNotifier delivers the notifications formatted by the query service
+	directNotifier *am.DirectNotifier
+	// recordingTasks evaluates the recording rules, keyed by rule id
+	recordingTasks map[int64]*recordingTask
+	// listeners are called with the alerts sent by the rules
+	listeners []NotifyFunc
+	// annotators are called with the alerts before they are sent
+	annotators []NotifyFunc
+	// firing keeps the firing alerts of the rules for the composite rules
+	firing *firingAlerts
+
+	// stateListeners are called with the state changes of the alerts
+	stateListeners []StateListener
+	listenersMtx   sync.RWMutex
 
 	// datastore to store alert definitions
 	ruleDB RuleDB
@@ -111,21 +135,35 @@ func NewManager(o *ManagerOptions) (*Manager, error) {
 		return nil, err
 	}
 
+	alertManager, err := am.New("")
+	if err != nil {
+		return nil, err
+	}
+
 	db := NewRuleDB(o.DBConn)
 
 	telemetry.GetInstance().SetAlertsInfoCallback(db.GetAlertsInfo)
 
 	m := &Manager{
-		tasks:        map[string]Task{},
-		rules:        map[string]Rule{},
-		notifier:     notifier,
-		ruleDB:       db,
-		opts:         o,
-		block:        make(chan struct{}),
-		logger:       o.Logger,
-		featureFlags: o.FeatureFlags,
-		reader:       o.Reader,
-	}
+		tasks:          map[string]Task{},
+		rules:          map[string]Rule{},
+		recordingTasks: map[int64]*recordingTask{},
+		notifier:       notifier,
+		ruleDB:         db,
+		opts:           o,
+		block:          make(chan struct{}),
+		logger:         o.Logger,
+		featureFlags:   o.FeatureFlags,
+		reader:         o.Reader,
+		firing:         newFiringAlerts(),
+	}
+	o.onStateChange = m.notifyStateChanges
+	m.AddStateListener(m.firing.record)
+	m.escalator = NewEscalator(db, m.sendAlerts)
+	m.directNotifier = am.NewDirectNotifier(func() ([]am.Receiver, error) {
+		return m.loadReceivers(context.Background())
+	})
+	m.routes = newRuleRoutes(alertManager, db.GetStoredRules, m.orgReceivers)
 	return m, nil
 }
 
@@ -149,6 +187,10 @@ func (m *Manager) Pause(b bool) {
 }
 
 func (m *Manager) initiate() error {
+	if err := m.initiateRecordingRules(); err != nil {
+		zap.L().Error("failed to load recording rules", zap.Error(err))
+	}
+
 	storedRules, err := m.ruleDB.GetStoredRules(context.Background())
 	if err != nil {
 		return err
@@ -194,6 +236,7 @@ func (m *Manager) initiate() error {
 				continue
 			}
 		}
+		parsedRule.OrgId = rec.OrgId
 		if !parsedRule.Disabled {
 			err := m.addTask(parsedRule, taskName)
 			if err != nil {
@@ -214,6 +257,13 @@ func (m *Manager) run() {
 	// initiate notifier
 	go m.notifier.Run()
 
+	// initiate escalations of unacknowledged alerts
+	go m.escalator.Run(m.opts.Context)
+
+	// push the receivers of the rules to alert manager in the background, the
+	// alerts are sent to their channels until then
+	go m.routes.run(m.opts.Context)
+
 	// initiate blocked tasks
 	close(m.block)
 }
@@ -229,6 +279,10 @@ func (m *Manager) Stop() {
 		t.Stop()
 	}
 
+	for _, t := range m.recordingTasks {
+		t.Stop()
+	}
+
 	zap.L().Info("Rule manager stopped")
 }
 
@@ -258,6 +312,14 @@ func (m *Manager) EditRule(ctx context.Context, ruleStr string, id string) error
 		return errs[0]
 	}
 
+	if err := m.checkCompositeRules(ctx, parsedRule, id); err != nil {
+		return err
+	}
+	parsedRule.OrgId = currentRule.OrgId
+	if _, err := m.ruleOrgScope(ctx, parsedRule); err != nil {
+		return err
+	}
+
 	taskName, _, err := m.ruleDB.EditRuleTx(ctx, ruleStr, id)
 	if err != nil {
 		return err
@@ -270,6 +332,10 @@ func (m *Manager) EditRule(ctx context.Context, ruleStr string, id string) error
 		}
 	}
 
+	if !currentRule.NotificationSettings.IsEmpty() || !parsedRule.NotificationSettings.IsEmpty() {
+		m.routes.sync()
+	}
+
 	// update feature usage if the current rule is not a trace or log query builder
 	if !checkIfTraceOrLogQB(&currentRule.PostableRule) {
 		err = m.updateFeatureUsage(parsedRule, 1)
@@ -350,6 +416,10 @@ func (m *Manager) DeleteRule(ctx context.Context, id string) error {
 		return err
 	}
 
+	if !rule.NotificationSettings.IsEmpty() {
+		m.routes.sync()
+	}
+
 	err = m.updateFeatureUsage(&rule.PostableRule, -1)
 	if err != nil {
 		zap.L().Error("error updating feature usage", zap.Error(err))
@@ -368,6 +438,8 @@ func (m *Manager) deleteTask(taskName string) {
 		oldg.Stop()
 		delete(m.tasks, taskName)
 		delete(m.rules, ruleIdFromTaskName(taskName))
+		forgetRule(ruleIdFromTaskName(taskName))
+		m.firing.forget(ruleIdFromTaskName(taskName))
 		zap.L().Debug("rule task deleted", zap.String("name", taskName))
 	} else {
 		zap.L().Info("rule not found for deletion", zap.String("name", taskName))
@@ -391,6 +463,14 @@ func (m *Manager) CreateRule(ctx context.Context, ruleStr string) (*GettableRule
 		return nil, errs[0]
 	}
 
+	if err := m.checkCompositeRules(ctx, parsedRule, ""); err != nil {
+		return nil, err
+	}
+	parsedRule.OrgId = common.GetOrgIdFromContext(ctx)
+	if _, err := m.ruleOrgScope(ctx, parsedRule); err != nil {
+		return nil, err
+	}
+
 	lastInsertId, tx, err := m.ruleDB.CreateRuleTx(ctx, ruleStr)
 	taskName := prepareTaskName(lastInsertId)
 	if err != nil {
@@ -407,6 +487,10 @@ func (m *Manager) CreateRule(ctx context.Context, ruleStr string) (*GettableRule
 		return nil, err
 	}
 
+	if !parsedRule.NotificationSettings.IsEmpty() {
+		m.routes.sync()
+	}
+
 	// update feature usage
 	err = m.updateFeatureUsage(parsedRule, 1)
 	if err != nil {
@@ -459,6 +543,78 @@ func (m *Manager) checkFeatureUsage(parsedRule *PostableRule) error {
 	return nil
 }
 
+// checkCompositeRules checks that the rules combined by a composite rule exist and that
+// the rule with the given id, empty for a new rule, doesn't combine itself
+func (m *Manager) checkCompositeRules(ctx context.Context, parsedRule *PostableRule, id string) error {
+	if !parsedRule.RuleCondition.IsComposite() {
+		return nil
+	}
+	for _, ruleId := range parsedRule.RuleCondition.Composite.RuleIds {
+		if ruleId == id {
+			return ErrCompositeSelfRef
+		}
+		if _, err := m.ruleDB.GetStoredRule(ctx, ruleId); err != nil {
+			return fmt.Errorf("rule %s of the composite condition not found", ruleId)
+		}
+	}
+	return nil
+}
+
+// ruleOrgScope returns the scope of the queries of the rule. The rules of the default org
+// read all the data, in the other orgs the builder queries and the queries of the promql
+// rules are scoped, the clickhouse queries can't be so they are refused
+func (m *Manager) ruleOrgScope(ctx context.Context, r *PostableRule) (*model.OrgScope, error) {
+	if r.RuleType == RuleTypeComposite {
+		return nil, nil
+	}
+	scope, err := orgScopeOf(ctx, r.OrgId)
+	if err != nil || scope == nil {
+		return nil, err
+	}
+	if r.RuleCondition == nil {
+		return nil, fmt.Errorf("no rule condition")
+	}
+	switch r.RuleCondition.QueryType() {
+	case v3.QueryTypeBuilder:
+	case v3.QueryTypePromQL:
+		if r.RuleType != RuleTypeProm {
+			return nil, fmt.Errorf("the promql queries are only allowed in the promql rules in the org %s", r.OrgId)
+		}
+	default:
+		return nil, fmt.Errorf("the clickhouse queries can't be scoped to the org %s, use the query builder instead", r.OrgId)
+	}
+	return scope, nil
+}
+
+// orgScopeOf returns the scope of the queries run for an org, e.g. by its rules, the queries
+// of the default org and of the resources without org are not scoped
+func orgScopeOf(ctx context.Context, orgId string) (*model.OrgScope, error) {
+	if orgId == "" {
+		return nil, nil
+	}
+	orgs, apiErr := dao.DB().GetOrgs(ctx)
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	if len(orgs) == 0 || orgs[0].Id == orgId {
+		return nil, nil
+	}
+	return &model.OrgScope{OrgIds: []string{orgId}}, nil
+}
+
+// orgContext returns the context of the org of a rule, e.g. to read its silences, the rules
+// without org belong to the default org
+func orgContext(ctx context.Context, orgId string) context.Context {
+	if orgId == "" {
+		orgs, apiErr := dao.DB().GetOrgs(ctx)
+		if apiErr != nil || len(orgs) == 0 {
+			return ctx
+		}
+		orgId = orgs[0].Id
+	}
+	return common.WithOrg(ctx, orgId)
+}
+
 func checkIfTraceOrLogQB(parsedRule *PostableRule) bool {
 	if parsedRule != nil {
 		if parsedRule.RuleCondition.QueryType() == v3.QueryTypeBuilder {
@@ -518,13 +674,18 @@ func (m *Manager) prepareTask(acquireLock bool, r *PostableRule, taskName string
 		return task, fmt.Errorf("task load failed, at least one rule must be set")
 	}
 
+	scope, err := m.ruleOrgScope(context.Background(), r)
+	if err != nil {
+		return task, err
+	}
+
 	ruleId := ruleIdFromTaskName(taskName)
 	if r.RuleType == RuleTypeThreshold {
 		// create a threshold rule
 		tr, err := NewThresholdRule(
 			ruleId,
 			r,
-			ThresholdRuleOpts{},
+			ThresholdRuleOpts{OrgScope: scope},
 			m.featureFlags,
 			m.reader,
 		)
@@ -536,7 +697,7 @@ func (m *Manager) prepareTask(acquireLock bool, r *PostableRule, taskName string
 		rules = append(rules, tr)
 
 		// create ch rule task for evalution
-		task = newTask(TaskTypeCh, taskName, taskNamesuffix, time.Duration(r.Frequency), rules, m.opts, m.prepareNotifyFunc(), m.ruleDB)
+		task = newTask(TaskTypeCh, taskName, taskNamesuffix, time.Duration(r.Frequency), rules, m.opts, m.prepareNotifyFunc(r.NotificationTemplate, r.OrgId), m.ruleDB)
 
 		// add rule to memory
 		m.rules[ruleId] = tr
@@ -548,7 +709,7 @@ func (m *Manager) prepareTask(acquireLock bool, r *PostableRule, taskName string
 			ruleId,
 			r,
 			log.With(m.logger, "alert", r.AlertName),
-			PromRuleOpts{},
+			PromRuleOpts{OrgScope: scope},
 		)
 
 		if err != nil {
@@ -558,13 +719,29 @@ func (m *Manager) prepareTask(acquireLock bool, r *PostableRule, taskName string
 		rules = append(rules, pr)
 
 		// create promql rule task for evalution
-		task = newTask(TaskTypeProm, taskName, taskNamesuffix, time.Duration(r.Frequency), rules, m.opts, m.prepareNotifyFunc(), m.ruleDB)
+		task = newTask(TaskTypeProm, taskName, taskNamesuffix, time.Duration(r.Frequency), rules, m.opts, m.prepareNotifyFunc(r.NotificationTemplate, r.OrgId), m.ruleDB)
 
 		// add rule to memory
 		m.rules[ruleId] = pr
 
+	} else if r.RuleType == RuleTypeComposite {
+
+		// create composite rule
+		cr, err := NewCompositeRule(ruleId, r, m.firing.firing)
+		if err != nil {
+			return task, err
+		}
+
+		rules = append(rules, cr)
+
+		// the composite rules query no data, they run in a ch rule task
+		task = newTask(TaskTypeCh, taskName, taskNamesuffix, time.Duration(r.Frequency), rules, m.opts, m.prepareNotifyFunc(r.NotificationTemplate, r.OrgId), m.ruleDB)
+
+		// add rule to memory
+		m.rules[ruleId] = cr
+
 	} else {
-		return nil, fmt.Errorf(fmt.Sprintf("unsupported rule type. Supported types: %s, %s", RuleTypeProm, RuleTypeThreshold))
+		return nil, fmt.Errorf(fmt.Sprintf("unsupported rule type. Supported types: %s, %s, %s", RuleTypeProm, RuleTypeThreshold, RuleTypeComposite))
 	}
 
 	return task, nil
@@ -640,36 +817,198 @@ func (m *Manager) TriggeredAlerts() []*NamedAlert {
 // NotifyFunc sends notifications about a set of alerts generated by the given expression.
 type NotifyFunc func(ctx context.Context, expr string, alerts ...*Alert)
 
-// prepareNotifyFunc implements the NotifyFunc for a Notifier.
-func (m *Manager) prepareNotifyFunc() NotifyFunc {
+// prepareNotifyFunc implements the NotifyFunc for a Notifier. The alerts of the rules
+// without preferred channels are sent to the channels of the org of the rule
+func (m *Manager) prepareNotifyFunc(notificationTemplate string, orgId string) NotifyFunc {
 	return func(ctx context.Context, expr string, alerts ...*Alert) {
-		var res []*am.Alert
+		m.listenersMtx.RLock()
+		defer m.listenersMtx.RUnlock()
+		// the annotators, the silences and the listeners read the resources of the org of
+		// the rule
+		orgCtx := orgContext(ctx, orgId)
+		for _, annotator := range m.annotators {
+			annotator(orgCtx, expr, alerts...)
+		}
 
 		for _, alert := range alerts {
-			generatorURL := alert.GeneratorURL
-			if generatorURL == "" {
-				generatorURL = m.opts.RepoURL
-			}
+			m.renderNotificationTemplate(notificationTemplate, alert)
+		}
+		m.sendAlerts(orgCtx, m.addressAlerts(orgId, alerts)...)
+		m.escalator.Track(orgCtx, alerts...)
 
-			a := &am.Alert{
-				StartsAt:     alert.FiredAt,
-				Labels:       alert.Labels,
-				Annotations:  alert.Annotations,
-				GeneratorURL: generatorURL,
-				Receivers:    alert.Receivers,
-			}
-			if !alert.ResolvedAt.IsZero() {
-				a.EndsAt = alert.ResolvedAt
-			} else {
-				a.EndsAt = alert.ValidUntil
-			}
-			res = append(res, a)
+		for _, listener := range m.listeners {
+			listener(orgCtx, expr, alerts...)
+		}
+	}
+}
+
+// AddNotifyListener calls the listener with the alerts sent by the rules, e.g. to publish
+// their state changes. The listener must not block the evaluation of the rules
+func (m *Manager) AddNotifyListener(listener NotifyFunc) {
+	m.listenersMtx.Lock()
+	defer m.listenersMtx.Unlock()
+	m.listeners = append(m.listeners, listener)
+}
+
+// AddAnnotator calls the annotator with the alerts of the rules before they are sent, so it
+// can add annotations the notification templates use. It blocks the evaluation of the rules
+func (m *Manager) AddAnnotator(annotator NotifyFunc) {
+	m.listenersMtx.Lock()
+	defer m.listenersMtx.Unlock()
+	m.annotators = append(m.annotators, annotator)
+}
+
+// AddStateListener calls the listener with the state changes of the alerts after each
+// evaluation of their rule, e.g. to keep their history
+func (m *Manager) AddStateListener(listener StateListener) {
+	m.listenersMtx.Lock()
+	defer m.listenersMtx.Unlock()
+	m.stateListeners = append(m.stateListeners, listener)
+}
+
+func (m *Manager) notifyStateChanges(ctx context.Context, changes ...StateChange) {
+	m.listenersMtx.RLock()
+	defer m.listenersMtx.RUnlock()
+	for _, listener := range m.stateListeners {
+		listener(ctx, changes...)
+	}
+}
+
+func (m *Manager) sendAlerts(ctx context.Context, alerts ...*Alert) {
+	alerts = m.silenceAlerts(ctx, alerts)
+
+	var res []*am.Alert
+
+	for _, alert := range alerts {
+		res = append(res, m.prepareAmAlert(alert))
+	}
+
+	if len(alerts) > 0 {
+		m.notifier.Send(m.routes.route(res)...)
+		m.directNotifier.Notify(ctx, res...)
+	}
+}
+
+// addressAlerts sends the alerts without receivers to the channels of the org
+func (m *Manager) addressAlerts(orgId string, alerts []*Alert) []*Alert {
+	var orgChannels []string
+	res := make([]*Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		if len(alert.Receivers) > 0 {
+			res = append(res, alert)
+			continue
+		}
+		if orgChannels == nil {
+			orgChannels = m.orgChannelNames(orgId)
+		}
+		a := *alert
+		a.Receivers = orgChannels
+		res = append(res, &a)
+	}
+	return res
+}
+
+// orgChannelNames returns the names of the notification channels of the org, the alerts
+// are not sent to any channel when they can't be read
+func (m *Manager) orgChannelNames(orgId string) []string {
+	receivers, err := m.orgReceivers(context.Background(), orgId)
+	if err != nil {
+		zap.L().Error("failed to load the notification channels of the org", zap.String("org", orgId), zap.Error(err))
+		return []string{}
+	}
+	names := make([]string, 0, len(receivers))
+	for _, receiver := range receivers {
+		names = append(names, receiver.Name)
+	}
+	return names
+}
+
+// orgReceivers reads the notification channels of the org, the rules without org belong
+// to the default org
+func (m *Manager) orgReceivers(ctx context.Context, orgId string) ([]am.Receiver, error) {
+	if m.reader == nil {
+		return nil, nil
+	}
+	if orgId == "" {
+		orgs, apiErr := dao.DB().GetOrgs(ctx)
+		if apiErr != nil {
+			return nil, apiErr.Err
+		}
+		if len(orgs) == 0 {
+			return m.loadReceivers(ctx)
 		}
+		orgId = orgs[0].Id
+	}
+	return m.loadReceivers(common.WithOrg(ctx, orgId))
+}
 
-		if len(alerts) > 0 {
-			m.notifier.Send(res...)
+// loadReceivers reads the notification channels the user of the context sees, all the
+// channels without user, e.g. for the direct notifier
+func (m *Manager) loadReceivers(ctx context.Context) ([]am.Receiver, error) {
+	if m.reader == nil {
+		return nil, nil
+	}
+	channels, apiErr := m.reader.GetChannels(ctx)
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	receivers := make([]am.Receiver, 0, len(*channels))
+	for _, channel := range *channels {
+		receiver := am.Receiver{}
+		if err := json.Unmarshal([]byte(channel.Data), &receiver); err != nil {
+			zap.L().Error("failed to parse notification channel", zap.String("name", channel.Name), zap.Error(err))
+			continue
 		}
+		receivers = append(receivers, receiver)
+	}
+	return receivers, nil
+}
+
+// Escalator returns the escalation engine of the manager
+func (m *Manager) Escalator() *Escalator {
+	return m.escalator
+}
+
+// renderNotificationTemplate sets the message annotation of the alert from the
+// notification template of the rule
+func (m *Manager) renderNotificationTemplate(notificationTemplate string, alert *Alert) {
+	if notificationTemplate == "" {
+		return
+	}
+	message, err := am.RenderMessage(notificationTemplate, m.prepareAmAlert(alert))
+	if err != nil {
+		zap.L().Error("failed to render notification template", zap.String("alert", alert.Labels.Get(labels.AlertNameLabel)), zap.Error(err))
+		message = fmt.Sprintf("<error expanding template: %s>", err)
+	}
+	annotations := map[string]string{}
+	if alert.Annotations != nil {
+		annotations = alert.Annotations.Map()
+	}
+	annotations[labels.AlertMessageLabel] = message
+	alert.Annotations = labels.FromMap(annotations)
+}
+
+// prepareAmAlert converts the rule alert into the alert manager payload
+func (m *Manager) prepareAmAlert(alert *Alert) *am.Alert {
+	generatorURL := alert.GeneratorURL
+	if generatorURL == "" {
+		generatorURL = m.opts.RepoURL
 	}
+
+	a := &am.Alert{
+		StartsAt:     alert.FiredAt,
+		Labels:       alert.Labels,
+		Annotations:  alert.Annotations,
+		GeneratorURL: generatorURL,
+		Receivers:    alert.Receivers,
+		Value:        alert.Value,
+	}
+	if !alert.ResolvedAt.IsZero() {
+		a.EndsAt = alert.ResolvedAt
+	} else {
+		a.EndsAt = alert.ValidUntil
+	}
+	return a
 }
 
 func (m *Manager) ListActiveRules() ([]Rule, error) {
@@ -720,6 +1059,25 @@ func (m *Manager) ListRuleStates(ctx context.Context) (*GettableRules, error) {
 	return &GettableRules{Rules: resp}, nil
 }
 
+// GetRuleByExternalId returns the rule of the org of the context with the external id, nil
+// when there is none
+func (m *Manager) GetRuleByExternalId(ctx context.Context, externalId string) (*GettableRule, error) {
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range storedRules {
+		r := &PostableRule{}
+		if err := json.Unmarshal([]byte(s.Data), r); err != nil {
+			continue
+		}
+		if r.ExternalId == externalId {
+			return m.GetRule(ctx, fmt.Sprintf("%d", s.Id))
+		}
+	}
+	return nil, nil
+}
+
 func (m *Manager) GetRule(ctx context.Context, id string) (*GettableRule, error) {
 	s, err := m.ruleDB.GetStoredRule(ctx, id)
 	if err != nil {
@@ -730,6 +1088,7 @@ func (m *Manager) GetRule(ctx context.Context, id string) (*GettableRule, error)
 		return nil, err
 	}
 	r.Id = fmt.Sprintf("%d", s.Id)
+	r.OrgId = s.OrgId
 	// fetch state of rule from memory
 	if rm, ok := m.rules[r.Id]; !ok {
 		r.State = StateDisabled.String()
@@ -801,6 +1160,8 @@ func (m *Manager) PatchRule(ctx context.Context, ruleStr string, ruleId string)
 		return nil, err
 	}
 
+	storedRule.OrgId = storedJSON.OrgId
+
 	// patchedRule is combo of stored rule and patch received in the request
 	patchedRule, errs := parseIntoRule(storedRule, []byte(ruleStr), "json")
 	if len(errs) > 0 {
@@ -808,6 +1169,9 @@ func (m *Manager) PatchRule(ctx context.Context, ruleStr string, ruleId string)
 		// just one rule is being parsed so expect just one error
 		return nil, errs[0]
 	}
+	if _, err := m.ruleOrgScope(ctx, patchedRule); err != nil {
+		return nil, err
+	}
 
 	// deploy or un-deploy task according to patched (new) rule state
 	if err := m.syncRuleStateWithTask(taskName, patchedRule); err != nil {
@@ -833,6 +1197,10 @@ func (m *Manager) PatchRule(ctx context.Context, ruleStr string, ruleId string)
 		return nil, err
 	}
 
+	if !storedRule.NotificationSettings.IsEmpty() || !patchedRule.NotificationSettings.IsEmpty() {
+		m.routes.sync()
+	}
+
 	// prepare http response
 	response := GettableRule{
 		Id:           ruleId,
@@ -860,6 +1228,11 @@ func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *m
 		zap.L().Error("failed to parse rule from request", zap.Errors("errors", errs))
 		return 0, newApiErrorBadData(errs[0])
 	}
+	parsedRule.OrgId = common.GetOrgIdFromContext(ctx)
+	scope, err := m.ruleOrgScope(ctx, parsedRule)
+	if err != nil {
+		return 0, newApiErrorBadData(err)
+	}
 
 	var alertname = parsedRule.AlertName
 	if alertname == "" {
@@ -872,7 +1245,6 @@ func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *m
 	parsedRule.AlertName = fmt.Sprintf("%s%s", alertname, TestAlertPostFix)
 
 	var rule Rule
-	var err error
 
 	if parsedRule.RuleType == RuleTypeThreshold {
 
@@ -888,6 +1260,7 @@ func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *m
 			ThresholdRuleOpts{
 				SendUnmatched: true,
 				SendAlways:    true,
+				OrgScope:      scope,
 			},
 			m.featureFlags,
 			m.reader,
@@ -907,6 +1280,7 @@ func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *m
 			log.With(m.logger, "alert", alertname),
 			PromRuleOpts{
 				SendAlways: true,
+				OrgScope:   scope,
 			},
 		)
 
@@ -914,6 +1288,14 @@ func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *m
 			zap.L().Error("failed to prepare a new promql rule for test", zap.String("name", rule.Name()), zap.Error(err))
 			return 0, newApiErrorBadData(err)
 		}
+	} else if parsedRule.RuleType == RuleTypeComposite {
+
+		// the composite rule fires on the rules firing now
+		rule, err = NewCompositeRule(alertname, parsedRule, m.firing.firing)
+		if err != nil {
+			zap.L().Error("failed to prepare a new composite rule for test", zap.String("name", alertname), zap.Error(err))
+			return 0, newApiErrorBadData(err)
+		}
 	} else {
 		return 0, newApiErrorBadData(fmt.Errorf("failed to derive ruletype with given information"))
 	}
@@ -930,7 +1312,266 @@ func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *m
 	if !ok {
 		return 0, newApiErrorInternal(fmt.Errorf("something went wrong"))
 	}
-	rule.SendAlerts(ctx, ts, 0, time.Duration(1*time.Minute), m.prepareNotifyFunc())
+	rule.SendAlerts(ctx, ts, 0, time.Duration(1*time.Minute), m.prepareNotifyFunc(parsedRule.NotificationTemplate, parsedRule.OrgId))
 
 	return alertsFound, nil
 }
+
+// maxPreviewEvaluations caps the number of evaluations run by a rule preview,
+// the evaluation interval is widened to fit the window when necessary
+const maxPreviewEvaluations = 500
+
+// PreviewRule evaluates a draft rule at each evaluation interval of the
+// requested window and reports which series would have fired and when
+func (m *Manager) PreviewRule(ctx context.Context, req *PreviewRuleRequest) (*PreviewRuleResponse, *model.ApiError) {
+
+	parsedRule, errs := ParsePostableRule(req.Rule)
+	if len(errs) > 0 {
+		zap.L().Error("failed to parse rule from request", zap.Errors("errors", errs))
+		return nil, newApiErrorBadData(errs[0])
+	}
+	parsedRule.OrgId = common.GetOrgIdFromContext(ctx)
+	scope, err := m.ruleOrgScope(ctx, parsedRule)
+	if err != nil {
+		return nil, newApiErrorBadData(err)
+	}
+
+	if req.Start <= 0 || req.End <= req.Start {
+		return nil, newApiErrorBadData(fmt.Errorf("invalid preview window, start must be before end"))
+	}
+	start := time.UnixMilli(req.Start).UTC()
+	end := time.UnixMilli(req.End).UTC()
+
+	if parsedRule.AlertName == "" {
+		parsedRule.AlertName = uuid.New().String()
+	}
+
+	var rule Rule
+
+	if parsedRule.RuleType == RuleTypeThreshold {
+		rule, err = NewThresholdRule(
+			parsedRule.AlertName,
+			parsedRule,
+			ThresholdRuleOpts{OrgScope: scope},
+			m.featureFlags,
+			m.reader,
+		)
+	} else if parsedRule.RuleType == RuleTypeProm {
+		rule, err = NewPromRule(
+			parsedRule.AlertName,
+			parsedRule,
+			log.With(m.logger, "alert", parsedRule.AlertName),
+			PromRuleOpts{OrgScope: scope},
+		)
+	} else if parsedRule.RuleType == RuleTypeComposite {
+		return nil, newApiErrorBadData(fmt.Errorf("composite rules can't be previewed, they fire on the current states of other rules"))
+	} else {
+		return nil, newApiErrorBadData(fmt.Errorf("failed to derive ruletype with given information"))
+	}
+	if err != nil {
+		zap.L().Error("failed to prepare a new rule for preview", zap.String("name", parsedRule.AlertName), zap.Error(err))
+		return nil, newApiErrorBadData(err)
+	}
+
+	interval := time.Duration(parsedRule.Frequency)
+	if interval <= 0 {
+		interval = DefaultFrequency
+	}
+	if evaluations := end.Sub(start) / interval; evaluations > maxPreviewEvaluations {
+		interval = end.Sub(start) / maxPreviewEvaluations
+	}
+
+	resp := &PreviewRuleResponse{
+		Interval: Duration(interval),
+		Series:   []PreviewSeries{},
+	}
+	// firing series by label set hash
+	seriesByHash := map[uint64]*PreviewSeries{}
+	var order []uint64
+
+	for ts := start; !ts.After(end); ts = ts.Add(interval) {
+		if _, err := rule.Eval(ctx, ts, m.opts.Queriers); err != nil {
+			zap.L().Error("evaluating rule failed", zap.String("rule", rule.Name()), zap.Error(err))
+			return nil, newApiErrorInternal(fmt.Errorf("rule evaluation failed at %s", ts.Format(time.RFC3339)))
+		}
+		resp.Evaluations++
+
+		firing := map[uint64]struct{}{}
+		for _, alert := range rule.ActiveAlerts() {
+			if alert.State != StateFiring {
+				continue
+			}
+			h := alert.Labels.Hash()
+			firing[h] = struct{}{}
+
+			s, ok := seriesByHash[h]
+			if !ok {
+				s = &PreviewSeries{Labels: alert.Labels.Map(), MaxValue: alert.Value}
+				seriesByHash[h] = s
+				order = append(order, h)
+			}
+			if alert.Value > s.MaxValue {
+				s.MaxValue = alert.Value
+			}
+			if n := len(s.Intervals); n == 0 || s.Intervals[n-1].End != nil {
+				s.Intervals = append(s.Intervals, FiringInterval{Start: ts})
+			}
+
+			if resp.SampleNotification == nil {
+				sample := *alert
+				sample.ValidUntil = ts.Add(4 * interval)
+				m.renderNotificationTemplate(parsedRule.NotificationTemplate, &sample)
+				resp.SampleNotification = m.prepareAmAlert(&sample)
+			}
+		}
+
+		// close the intervals of series that stopped firing
+		for h, s := range seriesByHash {
+			if _, ok := firing[h]; ok {
+				continue
+			}
+			if n := len(s.Intervals); n > 0 && s.Intervals[n-1].End == nil {
+				resolvedAt := ts
+				s.Intervals[n-1].End = &resolvedAt
+			}
+		}
+	}
+
+	for _, h := range order {
+		resp.Series = append(resp.Series, *seriesByHash[h])
+	}
+
+	return resp, nil
+}
+
+func (m *Manager) initiateRecordingRules() error {
+	recordingRules, err := m.ruleDB.GetAllRecordingRules(context.Background())
+	if err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for _, rule := range recordingRules {
+		if !rule.Disabled {
+			m.startRecordingTask(rule)
+		}
+	}
+	return nil
+}
+
+// startRecordingTask starts the evaluation of the recording rule, the
+// caller must hold the lock
+func (m *Manager) startRecordingTask(rule *RecordingRule) {
+	if old, ok := m.recordingTasks[rule.Id]; ok {
+		old.Stop()
+		delete(m.recordingTasks, rule.Id)
+	}
+	if rule.Disabled || m.opts.Queriers == nil || m.opts.Queriers.Ch == nil {
+		return
+	}
+
+	scope, err := orgScopeOf(context.Background(), rule.OrgId)
+	if err != nil {
+		zap.L().Error("failed to get the org scope of the recording rule", zap.String("name", rule.Name), zap.Error(err))
+		return
+	}
+	task, err := newRecordingTask(rule, scope, m.opts.Queriers.Ch, m.featureFlags, m.reader)
+	if err != nil {
+		zap.L().Error("failed to prepare the recording rule", zap.String("name", rule.Name), zap.Error(err))
+		return
+	}
+	m.recordingTasks[rule.Id] = task
+
+	go func() {
+		// wait with the evaluation until the rule manager is told to run
+		select {
+		case <-m.block:
+			task.Run(m.opts.Context)
+		case <-task.done:
+			close(task.terminated)
+		}
+	}()
+}
+
+// checkRecordingRuleOrg checks the queries of the recording rule can be scoped to the org,
+// the clickhouse queries can't be so they are refused outside the default org
+func checkRecordingRuleOrg(ctx context.Context, rule *RecordingRule) error {
+	scope, err := orgScopeOf(ctx, rule.OrgId)
+	if err != nil || scope == nil {
+		return err
+	}
+	if rule.Query.QueryType == v3.QueryTypeClickHouseSQL {
+		return fmt.Errorf("the clickhouse queries can't be scoped to the org %s, use the query builder instead", rule.OrgId)
+	}
+	return nil
+}
+
+// CreateRecordingRule stores the recording rule and starts its evaluation
+func (m *Manager) CreateRecordingRule(ctx context.Context, rule *RecordingRule) (*RecordingRule, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	rule.OrgId = common.GetOrgIdFromContext(ctx)
+	if err := checkRecordingRuleOrg(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	id, err := m.ruleDB.CreateRecordingRule(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+	rule.Id = id
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.startRecordingTask(rule)
+	return rule, nil
+}
+
+// EditRecordingRule updates the recording rule and restarts its evaluation
+func (m *Manager) EditRecordingRule(ctx context.Context, rule *RecordingRule, id string) (*RecordingRule, error) {
+	existing, err := m.ruleDB.GetRecordingRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	rule.Id = existing.Id
+	rule.CreatedAt = existing.CreatedAt
+	rule.CreatedBy = existing.CreatedBy
+	rule.OrgId = existing.OrgId
+	if err := checkRecordingRuleOrg(ctx, rule); err != nil {
+		return nil, err
+	}
+	if err := m.ruleDB.EditRecordingRule(ctx, rule, id); err != nil {
+		return nil, err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.startRecordingTask(rule)
+	return rule, nil
+}
+
+// DeleteRecordingRule stops the evaluation of the recording rule and removes it,
+// the series already written are kept
+func (m *Manager) DeleteRecordingRule(ctx context.Context, id string) error {
+	existing, err := m.ruleDB.GetRecordingRule(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := m.ruleDB.DeleteRecordingRule(ctx, id); err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if task, ok := m.recordingTasks[existing.Id]; ok {
+		task.Stop()
+		delete(m.recordingTasks, existing.Id)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// previewReader returns the series of the end of the window of the query, the queries of
+// the rules are SELECT {{.end_timestamp_ms}}
+type previewReader struct {
+	interfaces.Reader
+	series func(end time.Time) []*v3.Series
+}
+
+func (r *previewReader) GetTimeSeriesResultV3(ctx context.Context, query string) ([]*v3.Series, error) {
+	end, err := strconv.ParseInt(strings.TrimPrefix(query, "SELECT "), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return r.series(time.UnixMilli(end).UTC()), nil
+}
+
+func TestPreviewRule(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	target := 10.0
+	noDelay := Duration(0)
+
+	value := func(v float64) []*v3.Series {
+		return []*v3.Series{{Labels: map[string]string{"service.name": "frontend"}, Points: []v3.Point{{Timestamp: 1, Value: v}}}}
+	}
+
+	cases := []struct {
+		name          string
+		alertOnAbsent bool
+		series        func(end time.Time) []*v3.Series
+		intervals     []FiringInterval
+	}{
+		{
+			name: "threshold",
+			series: func(end time.Time) []*v3.Series {
+				if !end.Before(at(3)) && end.Before(at(6)) {
+					return value(20)
+				}
+				return value(5)
+			},
+			intervals: []FiringInterval{{Start: at(3), End: timePtr(at(6))}},
+		},
+		{
+			name: "never above the threshold",
+			series: func(end time.Time) []*v3.Series {
+				return value(5)
+			},
+		},
+		{
+			// the data stops after 2 minutes, the rule fires when it has been absent for
+			// more than 3 minutes of the past window, not of now
+			name:          "absent data",
+			alertOnAbsent: true,
+			series: func(end time.Time) []*v3.Series {
+				if end.Before(at(2)) {
+					return value(5)
+				}
+				return nil
+			},
+			intervals: []FiringInterval{{Start: at(5)}},
+		},
+		{
+			name:          "data present",
+			alertOnAbsent: true,
+			series: func(end time.Time) []*v3.Series {
+				return value(5)
+			},
+		},
+	}
+
+	fm := featureManager.StartManager()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rule := PostableRule{
+				AlertName: "Preview",
+				AlertType: "METRIC_BASED_ALERT",
+				RuleType:  RuleTypeThreshold,
+				Frequency: Duration(time.Minute),
+				EvalDelay: &noDelay,
+				RuleCondition: &RuleCondition{
+					CompositeQuery: &v3.CompositeQuery{
+						QueryType: v3.QueryTypeClickHouseSQL,
+						PanelType: v3.PanelTypeGraph,
+						ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+							"A": {Query: "SELECT {{.end_timestamp_ms}}"},
+						},
+					},
+					CompareOp:     ValueIsAbove,
+					MatchType:     AtleastOnce,
+					Target:        &target,
+					SelectedQuery: "A",
+					AlertOnAbsent: c.alertOnAbsent,
+					AbsentFor:     3,
+				},
+			}
+			body, err := json.Marshal(rule)
+			assert.NoError(t, err)
+
+			m := &Manager{
+				opts:         &ManagerOptions{Queriers: &Queriers{}},
+				reader:       &previewReader{series: c.series},
+				featureFlags: fm,
+			}
+			resp, apiErr := m.PreviewRule(context.Background(), &PreviewRuleRequest{
+				Rule:  body,
+				Start: at(0).UnixMilli(),
+				End:   at(9).UnixMilli(),
+			})
+			assert.Nil(t, apiErr)
+			assert.Equal(t, 10, resp.Evaluations)
+
+			if len(c.intervals) == 0 {
+				assert.Empty(t, resp.Series)
+				assert.Nil(t, resp.SampleNotification)
+				return
+			}
+			assert.Len(t, resp.Series, 1)
+			assert.Equal(t, c.intervals, resp.Series[0].Intervals)
+			assert.NotNil(t, resp.SampleNotification)
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// channelsReader returns the notification channels of the org of the context
+type channelsReader struct {
+	interfaces.Reader
+	channels []model.ChannelItem
+}
+
+func (r *channelsReader) GetChannels(ctx context.Context) (*[]model.ChannelItem, *model.ApiError) {
+	orgId := common.GetOrgIdFromContext(ctx)
+	channels := []model.ChannelItem{}
+	for _, c := range r.channels {
+		if orgId == "" || c.OrgId == orgId {
+			channels = append(channels, c)
+		}
+	}
+	return &channels, nil
+}
+
+func TestAddressAlerts(t *testing.T) {
+	m := &Manager{reader: &channelsReader{channels: []model.ChannelItem{
+		{Name: "slack-a", Data: `{"name": "slack-a"}`, OrgId: "org-a"},
+		{Name: "email-a", Data: `{"name": "email-a"}`, OrgId: "org-a"},
+		{Name: "slack-b", Data: `{"name": "slack-b"}`, OrgId: "org-b"},
+	}}}
+
+	preferred := &Alert{Receivers: []string{"slack-a"}}
+	all := &Alert{}
+	alerts := m.addressAlerts("org-a", []*Alert{preferred, all})
+
+	assert.Equal(t, []string{"slack-a"}, alerts[0].Receivers)
+	// the alerts without preferred channels are only sent to the channels of the org
+	assert.ElementsMatch(t, []string{"slack-a", "email-a"}, alerts[1].Receivers)
+	assert.Empty(t, all.Receivers)
+
+	alerts = m.addressAlerts("org-c", []*Alert{{}})
+	assert.NotNil(t, alerts[0].Receivers)
+	assert.Empty(t, alerts[0].Receivers)
+}
@@ -0,0 +1,179 @@
+package rules
+
+import (
+	"math"
+
+	pql "github.com/prometheus/prometheus/promql"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+const (
+	// outlierDeviationAnnotation is how many standard deviations of its peers an outlier is
+	// away from their mean
+	outlierDeviationAnnotation = "outlier_deviation"
+	outlierPeersMeanAnnotation = "outlier_peers_mean"
+
+	// minOutlierPeers is how many other series a series is compared with at least, fewer
+	// peers have no meaningful spread
+	minOutlierPeers = 2
+)
+
+// outlierScore compares the mean of a series with the means of the other series of the
+// same query
+type outlierScore struct {
+	// deviation is how many standard deviations of the peers the series is away from their
+	// mean, positive above and negative below. It is infinite when the peers are all equal
+	deviation float64
+	peersMean float64
+}
+
+// validOutlier checks the outlier conditions, the target is the number of standard
+// deviations and the op is above, below or not equal for both directions
+func (rc *RuleCondition) validOutlier() bool {
+	type condition struct {
+		op     CompareOp
+		target *float64
+	}
+	var conditions []condition
+	if rc.IsMultiCondition() {
+		for _, c := range rc.Conditions {
+			if c != nil && c.MatchType == AsOutlier {
+				conditions = append(conditions, condition{c.CompareOp, c.Target})
+			}
+		}
+	} else if rc.MatchType == AsOutlier {
+		conditions = append(conditions, condition{rc.CompareOp, rc.Target})
+	}
+	for _, c := range conditions {
+		if c.target == nil || *c.target <= 0 {
+			return false
+		}
+		if c.op != ValueIsAbove && c.op != ValueIsBelow && c.op != ValueIsNotEq {
+			return false
+		}
+	}
+	return true
+}
+
+// outlierScores scores each value against the other values, leaving the value out of its
+// peers keeps a single outlier from widening the spread it is compared with. The NaN
+// values, the series without points, are neither scored nor peers
+func outlierScores(values []float64) []*outlierScore {
+	scores := make([]*outlierScore, len(values))
+	var n, sum float64
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			n++
+			sum += v
+		}
+	}
+	if n-1 < minOutlierPeers {
+		return scores
+	}
+	// the squares are summed around the mean of all the values to keep the precision
+	mean := sum / n
+	var squares float64
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			squares += (v - mean) * (v - mean)
+		}
+	}
+
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		peers := n - 1
+		peersMean := (sum - v) / peers
+		variance := (squares - (v-mean)*(v-mean)*n/peers) / peers
+		score := &outlierScore{peersMean: peersMean}
+		// the variances that small are rounding errors of peers all equal
+		switch {
+		case variance > 1e-12*peersMean*peersMean:
+			score.deviation = (v - peersMean) / math.Sqrt(variance)
+		case v > peersMean:
+			score.deviation = math.Inf(1)
+		case v < peersMean:
+			score.deviation = math.Inf(-1)
+		}
+		scores[i] = score
+	}
+	return scores
+}
+
+// matches tells whether the series is further than the number of deviations from its peers
+func (s *outlierScore) matches(compareOp CompareOp, deviations float64) bool {
+	if s == nil {
+		return false
+	}
+	switch compareOp {
+	case ValueIsAbove:
+		return s.deviation > deviations
+	case ValueIsBelow:
+		return s.deviation < -deviations
+	case ValueIsNotEq:
+		return math.Abs(s.deviation) > deviations
+	}
+	return false
+}
+
+// seriesMean returns the mean of the points of the series, NaN when it has none
+func seriesMean(points []v3.Point) float64 {
+	var sum, count float64
+	for _, p := range points {
+		if p.Timestamp < 0 || math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			continue
+		}
+		sum += p.Value
+		count++
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / count
+}
+
+// matchOutliers compares the mean of each series over the evaluation window with the
+// means of the other series and returns the series deviating from them
+func (r *ThresholdRule) matchOutliers(series []*v3.Series, compareOp CompareOp, deviations float64) Vector {
+	values := make([]float64, len(series))
+	for i, s := range series {
+		values[i] = seriesMean(s.Points)
+	}
+
+	var resultVector Vector
+	for i, score := range outlierScores(values) {
+		if !score.matches(compareOp, deviations) {
+			continue
+		}
+		var lbls, lblsNormalized labels.Labels
+		for name, value := range series[i].Labels {
+			lbls = append(lbls, labels.Label{Name: name, Value: value})
+			lblsNormalized = append(lblsNormalized, labels.Label{Name: normalizeLabelName(name), Value: value})
+		}
+		resultVector = append(resultVector, Sample{Point: Point{V: values[i]}, Metric: lblsNormalized, MetricOrig: lbls, outlier: score})
+	}
+	return resultVector
+}
+
+// promOutlierScores returns the mean of each series of a promql result and its score
+// against the other series
+func promOutlierScores(res pql.Matrix) ([]float64, []*outlierScore) {
+	means := make([]float64, len(res))
+	for i, series := range res {
+		var sum, count float64
+		for _, p := range series.Floats {
+			if math.IsNaN(p.F) || math.IsInf(p.F, 0) {
+				continue
+			}
+			sum += p.F
+			count++
+		}
+		means[i] = math.NaN()
+		if count > 0 {
+			means[i] = sum / count
+		}
+	}
+	return means, outlierScores(means)
+}
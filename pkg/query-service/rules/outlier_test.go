@@ -0,0 +1,94 @@
+package rules
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestOutlierScores(t *testing.T) {
+	scores := outlierScores([]float64{100, 102, 98, 101, 99, 250, math.NaN()})
+	require.Nil(t, scores[6], "the series without points are not scored")
+
+	// the peers of the outlier are 98 to 102, their standard deviation is about 1.41
+	require.InDelta(t, 100, scores[5].peersMean, 1e-9)
+	require.InDelta(t, 150/math.Sqrt(2), scores[5].deviation, 1e-9)
+	require.True(t, scores[5].matches(ValueIsAbove, 3))
+	require.True(t, scores[5].matches(ValueIsNotEq, 3))
+	require.False(t, scores[5].matches(ValueIsBelow, 3))
+	for _, s := range scores[:5] {
+		require.False(t, s.matches(ValueIsNotEq, 3))
+	}
+
+	scores = outlierScores([]float64{10, 10, 10, 4})
+	require.True(t, math.IsInf(scores[3].deviation, -1), "the peers are all equal")
+	require.True(t, scores[3].matches(ValueIsBelow, 3))
+	require.False(t, scores[0].matches(ValueIsNotEq, 3))
+
+	scores = outlierScores([]float64{1, 1000})
+	require.Nil(t, scores[1], "two series have a single peer each")
+}
+
+func TestThresholdRuleOutliers(t *testing.T) {
+	deviations := 3.0
+	postableRule := PostableRule{
+		AlertName:  "Slow Pod",
+		AlertType:  "METRIC_BASED_ALERT",
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:          "A",
+						StepInterval:       60,
+						AggregateAttribute: v3.AttributeKey{Key: "http_latency"},
+						AggregateOperator:  v3.AggregateOperatorAvg,
+						DataSource:         v3.DataSourceMetrics,
+						Expression:         "A",
+					},
+				},
+			},
+			CompareOp: ValueIsAbove,
+			MatchType: AsOutlier,
+			Target:    &deviations,
+		},
+	}
+	require.True(t, postableRule.RuleCondition.IsValid())
+
+	rule, err := NewThresholdRule("outliers", &postableRule, ThresholdRuleOpts{}, featureManager.StartManager(), nil)
+	require.NoError(t, err)
+
+	series := func(pod string, values ...float64) *v3.Series {
+		s := &v3.Series{Labels: map[string]string{"k8s.pod.name": pod}}
+		for i, v := range values {
+			s.Points = append(s.Points, v3.Point{Timestamp: int64(i) * 60000, Value: v})
+		}
+		return s
+	}
+	res := rule.matchOutliers([]*v3.Series{
+		series("api-1", 100, 110, 90),
+		series("api-2", 105, 95, 100),
+		series("api-3", 98, 102, 97),
+		series("api-4", 400, 500, 450),
+		series("api-5", 101, 99, 103),
+	}, ValueIsAbove, deviations)
+	require.Len(t, res, 1)
+	require.Equal(t, "api-4", res[0].Metric.Get("k8s_pod_name"))
+	require.Equal(t, "api-4", res[0].MetricOrig.Get("k8s.pod.name"))
+	require.InDelta(t, 450, res[0].V, 1e-9)
+	require.NotNil(t, res[0].outlier)
+
+	invalid := *postableRule.RuleCondition
+	invalid.CompareOp = ValueIsEq
+	require.False(t, invalid.IsValid())
+	invalid = *postableRule.RuleCondition
+	invalid.Target = nil
+	require.False(t, invalid.IsValid())
+}
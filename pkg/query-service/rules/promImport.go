@@ -0,0 +1,351 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	promModel "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	importKindAlert  = "alert"
+	importKindRecord = "record"
+)
+
+// PromRuleFile is the content of a prometheus rules file, the
+// PrometheusRule resource of the prometheus operator is accepted as well
+type PromRuleFile struct {
+	Groups []PromRuleGroup `yaml:"groups"`
+	Spec   struct {
+		Groups []PromRuleGroup `yaml:"groups"`
+	} `yaml:"spec"`
+}
+
+type PromRuleGroup struct {
+	Name     string           `yaml:"name"`
+	Interval string           `yaml:"interval,omitempty"`
+	Rules    []PromRuleConfig `yaml:"rules"`
+}
+
+type PromRuleConfig struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// ImportedRule reports the outcome of the import of a prometheus rule
+type ImportedRule struct {
+	Group    string   `json:"group"`
+	Name     string   `json:"name"`
+	Kind     string   `json:"kind"`
+	Expr     string   `json:"expr"`
+	Id       string   `json:"id,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+type ImportRulesResponse struct {
+	Imported int            `json:"imported"`
+	Failed   int            `json:"failed"`
+	DryRun   bool           `json:"dryRun"`
+	Rules    []ImportedRule `json:"rules"`
+}
+
+// convertedRule is a prometheus rule converted into a signoz rule,
+// either alert or recording is set unless the conversion failed
+type convertedRule struct {
+	ImportedRule
+	alert     *PostableRule
+	recording *RecordingRule
+}
+
+func parsePromDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := promModel.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(d), nil
+}
+
+// convertPromRules converts the groups of a prometheus rules file, the
+// rules that cannot be converted are reported with an error
+func convertPromRules(content []byte) ([]*convertedRule, error) {
+	var file PromRuleFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file: %w", err)
+	}
+
+	groups := file.Groups
+	if len(groups) == 0 {
+		groups = file.Spec.Groups
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("rules file has no groups")
+	}
+
+	var converted []*convertedRule
+	for _, group := range groups {
+		interval, err := parsePromDuration(group.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval of group %s: %w", group.Name, err)
+		}
+		for _, rule := range group.Rules {
+			converted = append(converted, convertPromRule(group.Name, interval, rule))
+		}
+	}
+	return converted, nil
+}
+
+func convertPromRule(group string, interval time.Duration, rule PromRuleConfig) *convertedRule {
+	c := &convertedRule{ImportedRule: ImportedRule{Group: group, Expr: rule.Expr}}
+
+	switch {
+	case rule.Alert != "" && rule.Record != "":
+		c.Name = rule.Alert
+		c.Error = "rule sets both alert and record"
+	case rule.Alert != "":
+		c.Kind = importKindAlert
+		c.Name = rule.Alert
+		c.alert, c.Warnings, c.Error = convertPromAlert(interval, rule)
+	case rule.Record != "":
+		c.Kind = importKindRecord
+		c.Name = rule.Record
+		c.recording, c.Warnings, c.Error = convertPromRecord(interval, rule)
+	default:
+		c.Error = "rule sets neither alert nor record"
+	}
+	return c
+}
+
+func promCompositeQuery(expr string) *v3.CompositeQuery {
+	return &v3.CompositeQuery{
+		QueryType: v3.QueryTypePromQL,
+		PanelType: v3.PanelTypeGraph,
+		PromQueries: map[string]*v3.PromQuery{
+			"A": {Query: expr},
+		},
+	}
+}
+
+// splitThreshold splits the comparison of the alert expression with a
+// scalar into the query and the threshold of the rule condition
+func splitThreshold(expr parser.Expr) (query string, op CompareOp, target float64, warnings []string, err error) {
+	for {
+		paren, ok := expr.(*parser.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.Expr
+	}
+
+	switch e := expr.(type) {
+	case *parser.BinaryExpr:
+		if e.Op.IsSetOperator() {
+			return "", "", 0, nil, fmt.Errorf("set operator %s is not supported, split the expression into separate rules", e.Op)
+		}
+		if !e.Op.IsComparisonOperator() || e.ReturnBool {
+			break
+		}
+
+		vector, scalar, flipped := e.LHS, e.RHS, false
+		if _, ok := e.LHS.(*parser.NumberLiteral); ok {
+			vector, scalar, flipped = e.RHS, e.LHS, true
+		}
+		number, ok := scalar.(*parser.NumberLiteral)
+		if !ok {
+			return "", "", 0, nil, fmt.Errorf("comparison between two vectors is not supported")
+		}
+
+		cmp := e.Op
+		if flipped {
+			switch cmp {
+			case parser.GTR:
+				cmp = parser.LSS
+			case parser.LSS:
+				cmp = parser.GTR
+			case parser.GTE:
+				cmp = parser.LTE
+			case parser.LTE:
+				cmp = parser.GTE
+			}
+		}
+		switch cmp {
+		case parser.GTR:
+			op = ValueIsAbove
+		case parser.LSS:
+			op = ValueIsBelow
+		case parser.EQLC:
+			op = ValueIsEq
+		case parser.NEQ:
+			op = ValueIsNotEq
+		case parser.GTE:
+			op = ValueIsAbove
+			warnings = append(warnings, fmt.Sprintf("%s is converted to >, values equal to %v do not fire", cmp, number.Val))
+		case parser.LTE:
+			op = ValueIsBelow
+			warnings = append(warnings, fmt.Sprintf("%s is converted to <, values equal to %v do not fire", cmp, number.Val))
+		}
+		if err := checkNoFilter(vector); err != nil {
+			return "", "", 0, nil, err
+		}
+		return vector.String(), op, number.Val, warnings, nil
+
+	case *parser.Call:
+		// absent returns 1 for the missing series
+		if e.Func.Name == "absent" || e.Func.Name == "absent_over_time" {
+			return e.String(), ValueIsAbove, 0, nil, nil
+		}
+	}
+
+	return "", "", 0, nil, fmt.Errorf("expression has no threshold, compare the query with a number")
+}
+
+// checkNoFilter rejects the queries with nested filtering, they cannot
+// be expressed with a single threshold
+func checkNoFilter(expr parser.Expr) error {
+	var err error
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		if b, ok := node.(*parser.BinaryExpr); ok && err == nil {
+			if b.Op.IsSetOperator() {
+				err = fmt.Errorf("set operator %s is not supported, split the expression into separate rules", b.Op)
+			} else if b.Op.IsComparisonOperator() && !b.ReturnBool {
+				err = fmt.Errorf("nested comparison %s is not supported", b.Op)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+func convertPromAlert(interval time.Duration, rule PromRuleConfig) (*PostableRule, []string, string) {
+	expr, err := parser.ParseExpr(rule.Expr)
+	if err != nil {
+		return nil, nil, fmt.Sprintf("invalid expression: %s", err)
+	}
+	query, op, target, warnings, err := splitThreshold(expr)
+	if err != nil {
+		return nil, nil, err.Error()
+	}
+
+	holdFor, err := parsePromDuration(rule.For)
+	if err != nil {
+		return nil, nil, fmt.Sprintf("invalid for duration: %s", err)
+	}
+
+	frequency := interval
+	if frequency == 0 {
+		frequency = time.Minute
+	}
+
+	// the condition must hold for the whole window, without a for
+	// duration the alert fires on the latest evaluation
+	matchType, evalWindow := AllTheTimes, holdFor
+	if holdFor == 0 {
+		matchType, evalWindow = AtleastOnce, frequency
+	}
+
+	postable := &PostableRule{
+		AlertName:   rule.Alert,
+		AlertType:   "METRIC_BASED_ALERT",
+		Description: rule.Annotations["description"],
+		RuleType:    RuleTypeProm,
+		EvalWindow:  Duration(evalWindow),
+		Frequency:   Duration(frequency),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: promCompositeQuery(query),
+			CompareOp:      op,
+			Target:         &target,
+			MatchType:      matchType,
+			SelectedQuery:  "A",
+		},
+		Labels:      rule.Labels,
+		Annotations: rule.Annotations,
+		Version:     "v4",
+	}
+	if errs := postable.Validate(); len(errs) > 0 {
+		return nil, nil, errs[0].Error()
+	}
+	return postable, warnings, ""
+}
+
+func convertPromRecord(interval time.Duration, rule PromRuleConfig) (*RecordingRule, []string, string) {
+	if _, err := parser.ParseExpr(rule.Expr); err != nil {
+		return nil, nil, fmt.Sprintf("invalid expression: %s", err)
+	}
+
+	var warnings []string
+	frequency := Duration(interval)
+	if frequency != 0 && frequency < defaultRecordingFrequency {
+		warnings = append(warnings, fmt.Sprintf("interval %s is raised to %s", interval, time.Duration(defaultRecordingFrequency)))
+		frequency = defaultRecordingFrequency
+	}
+
+	recording := &RecordingRule{
+		Name:          rule.Record,
+		Labels:        rule.Labels,
+		Query:         promCompositeQuery(rule.Expr),
+		SelectedQuery: "A",
+		Frequency:     frequency,
+	}
+	if err := recording.Validate(); err != nil {
+		return nil, nil, err.Error()
+	}
+	return recording, warnings, ""
+}
+
+// ImportPromRules converts the prometheus rules file and creates the converted
+// alerting and recording rules, nothing is created when dryRun is set
+func (m *Manager) ImportPromRules(ctx context.Context, content []byte, dryRun bool) (*ImportRulesResponse, error) {
+	converted, err := convertPromRules(content)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ImportRulesResponse{DryRun: dryRun, Rules: make([]ImportedRule, 0, len(converted))}
+	for _, c := range converted {
+		if c.Error == "" && !dryRun {
+			c.Id, err = m.createImportedRule(ctx, c)
+			if err != nil {
+				c.Error = err.Error()
+			}
+		}
+		if c.Error != "" {
+			res.Failed++
+		} else {
+			res.Imported++
+		}
+		res.Rules = append(res.Rules, c.ImportedRule)
+	}
+	return res, nil
+}
+
+func (m *Manager) createImportedRule(ctx context.Context, c *convertedRule) (string, error) {
+	if c.recording != nil {
+		created, err := m.CreateRecordingRule(ctx, c.recording)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d", created.Id), nil
+	}
+
+	data, err := json.Marshal(c.alert)
+	if err != nil {
+		return "", err
+	}
+	created, err := m.CreateRule(ctx, string(data))
+	if err != nil {
+		return "", err
+	}
+	return created.Id, nil
+}
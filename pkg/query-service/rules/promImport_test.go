@@ -0,0 +1,104 @@
+package rules
+
+import (
+	"testing"
+	"time"
+)
+
+const testPromRulesFile = `
+groups:
+  - name: node
+    interval: 30s
+    rules:
+      - alert: HighCPU
+        expr: avg by (instance) (rate(node_cpu_seconds_total{mode!="idle"}[5m])) > 0.9
+        for: 10m
+        labels:
+          severity: critical
+        annotations:
+          description: cpu usage is high
+      - alert: LowDisk
+        expr: 10 >= node_filesystem_avail_bytes
+      - alert: TargetMissing
+        expr: absent(up{job="node"})
+      - alert: Unconvertible
+        expr: up == 0 and on (instance) node_load1 > 1
+      - alert: NoThreshold
+        expr: rate(node_cpu_seconds_total[5m])
+      - record: instance:node_cpu:rate5m
+        expr: sum by (instance) (rate(node_cpu_seconds_total[5m]))
+`
+
+func TestConvertPromRules(t *testing.T) {
+	converted, err := convertPromRules([]byte(testPromRulesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(converted) != 6 {
+		t.Fatalf("expected 6 rules, got %d", len(converted))
+	}
+
+	highCPU := converted[0]
+	if highCPU.Error != "" || highCPU.alert == nil {
+		t.Fatalf("expected HighCPU to be converted, got %q", highCPU.Error)
+	}
+	cond := highCPU.alert.RuleCondition
+	if cond.CompareOp != ValueIsAbove || *cond.Target != 0.9 || cond.MatchType != AllTheTimes {
+		t.Errorf("unexpected condition %v %v %v", cond.CompareOp, *cond.Target, cond.MatchType)
+	}
+	if highCPU.alert.EvalWindow != Duration(10*time.Minute) || highCPU.alert.Frequency != Duration(30*time.Second) {
+		t.Errorf("unexpected window %v frequency %v", highCPU.alert.EvalWindow, highCPU.alert.Frequency)
+	}
+	if highCPU.alert.Labels["severity"] != "critical" {
+		t.Errorf("expected labels to be kept, got %v", highCPU.alert.Labels)
+	}
+
+	lowDisk := converted[1]
+	if lowDisk.Error != "" || lowDisk.alert.RuleCondition.CompareOp != ValueIsBelow || len(lowDisk.Warnings) != 1 {
+		t.Errorf("expected flipped comparison with a warning, got %v %v", lowDisk.Error, lowDisk.Warnings)
+	}
+	if lowDisk.alert.RuleCondition.MatchType != AtleastOnce {
+		t.Errorf("expected at least once without for, got %v", lowDisk.alert.RuleCondition.MatchType)
+	}
+
+	if converted[2].Error != "" {
+		t.Errorf("expected absent to be converted, got %q", converted[2].Error)
+	}
+	for _, c := range converted[3:5] {
+		if c.Error == "" {
+			t.Errorf("expected %s to fail", c.Name)
+		}
+	}
+
+	record := converted[5]
+	if record.Error != "" || record.recording == nil || record.Kind != importKindRecord {
+		t.Fatalf("expected recording rule, got %q", record.Error)
+	}
+	if record.recording.Frequency != defaultRecordingFrequency || len(record.Warnings) != 1 {
+		t.Errorf("expected interval to be raised, got %v %v", record.recording.Frequency, record.Warnings)
+	}
+}
+
+func TestConvertPromRulesOperatorResource(t *testing.T) {
+	content := `
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+spec:
+  groups:
+    - name: up
+      rules:
+        - alert: InstanceDown
+          expr: up == 0
+`
+	converted, err := convertPromRules([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(converted) != 1 || converted[0].Error != "" {
+		t.Fatalf("expected one converted rule, got %v", converted)
+	}
+
+	if _, err := convertPromRules([]byte("groups: []")); err == nil {
+		t.Error("expected error for file without groups")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strconv"
 	"sync"
 	"time"
 
@@ -13,8 +14,11 @@ import (
 
 	plabels "github.com/prometheus/prometheus/model/labels"
 	pql "github.com/prometheus/prometheus/promql"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/converter"
 	"go.signoz.io/signoz/pkg/query-service/formatter"
+	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	qslabels "go.signoz.io/signoz/pkg/query-service/utils/labels"
 	"go.signoz.io/signoz/pkg/query-service/utils/times"
@@ -26,6 +30,8 @@ type PromRuleOpts struct {
 	// SendAlways will send alert irresepective of resendDelay
 	// or other params
 	SendAlways bool
+	// OrgScope restricts the promql query of the rule to the series of its org
+	OrgScope *model.OrgScope
 }
 
 type PromRule struct {
@@ -35,6 +41,7 @@ type PromRule struct {
 	ruleCondition *RuleCondition
 
 	evalWindow   time.Duration
+	evalDelay    time.Duration
 	holdDuration time.Duration
 	labels       plabels.Labels
 	annotations  plabels.Labels
@@ -75,6 +82,7 @@ func NewPromRule(
 		source:            postableRule.Source,
 		ruleCondition:     postableRule.RuleCondition,
 		evalWindow:        time.Duration(postableRule.EvalWindow),
+		evalDelay:         evalDelay(postableRule, 0),
 		labels:            plabels.FromMap(postableRule.Labels),
 		annotations:       plabels.FromMap(postableRule.Annotations),
 		preferredChannels: postableRule.PreferredChannels,
@@ -314,7 +322,7 @@ func (r *PromRule) getPqlQuery() (string, error) {
 				if query == "" {
 					return query, fmt.Errorf("a promquery needs to be set for this rule to function")
 				}
-				return query, nil
+				return common.ScopePromQL(query, r.opts.OrgScope)
 			}
 		}
 	}
@@ -336,10 +344,15 @@ func (r *PromRule) compareOp() CompareOp {
 	return r.ruleCondition.CompareOp
 }
 
+// EvalDelay is how far behind the evaluation time the query of the rule ends
+func (r *PromRule) EvalDelay() time.Duration {
+	return r.evalDelay
+}
+
 func (r *PromRule) Eval(ctx context.Context, ts time.Time, queriers *Queriers) (interface{}, error) {
 
-	start := ts.Add(-r.evalWindow)
-	end := ts
+	start := ts.Add(-r.evalWindow - r.evalDelay)
+	end := ts.Add(-r.evalDelay)
 	interval := 60 * time.Second // TODO(srikanthccv): this should be configurable
 
 	valueFormatter := formatter.FromUnit(r.Unit())
@@ -363,7 +376,14 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time, queriers *Queriers) (
 
 	var alerts = make(map[uint64]*Alert, len(res))
 
-	for _, series := range res {
+	// the outlier condition compares the series with each other
+	var means []float64
+	var outliers []*outlierScore
+	if r.matchType() == AsOutlier {
+		means, outliers = promOutlierScores(res)
+	}
+
+	for i, series := range res {
 		l := make(map[string]string, len(series.Metric))
 		for _, lbl := range series.Metric {
 			l[lbl.Name] = lbl.Value
@@ -373,7 +393,14 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time, queriers *Queriers) (
 			continue
 		}
 
-		alertSmpl, shouldAlert := r.shouldAlert(series)
+		var alertSmpl pql.Sample
+		var shouldAlert bool
+		if outliers != nil {
+			alertSmpl = pql.Sample{F: means[i], Metric: series.Metric}
+			shouldAlert = outliers[i].matches(r.compareOp(), *r.ruleCondition.Target)
+		} else {
+			alertSmpl, shouldAlert = r.shouldAlert(series)
+		}
 		if !shouldAlert {
 			continue
 		}
@@ -418,6 +445,14 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time, queriers *Queriers) (
 		for _, a := range r.annotations {
 			annotations = append(annotations, plabels.Label{Name: a.Name, Value: expand(a.Value)})
 		}
+		if outliers != nil {
+			annotations = append(annotations,
+				plabels.Label{Name: outlierDeviationAnnotation, Value: strconv.FormatFloat(outliers[i].deviation, 'f', 2, 64)},
+				plabels.Label{Name: outlierPeersMeanAnnotation, Value: valueFormatter.Format(outliers[i].peersMean, r.Unit())})
+		}
+		if r.matchType() == InForecast {
+			annotations = append(annotations, plabels.Label{Name: forecastCrossingAnnotation, Value: time.UnixMilli(alertSmpl.T).UTC().Format(constants.AlertTimeFormat)})
+		}
 
 		lbs := lb.Labels()
 		h := lbs.Hash()
@@ -586,6 +621,17 @@ func (r *PromRule) shouldAlert(series pql.Series) (pql.Sample, bool) {
 				shouldAlert = true
 			}
 		}
+	case InForecast:
+		// If the trend of the samples crosses the target within the forecast window, the
+		// rule is firing. The sample holds the forecast value and the time of the crossing
+		ts := make([]int64, len(series.Floats))
+		vs := make([]float64, len(series.Floats))
+		for i, smpl := range series.Floats {
+			ts[i], vs[i] = smpl.T, smpl.F
+		}
+		forecast, crossAt, ok := matchForecast(ts, vs, r.compareOp(), r.targetVal(), time.Duration(r.ruleCondition.ForecastWindow))
+		alertSmpl = pql.Sample{F: forecast, T: crossAt, Metric: series.Metric}
+		shouldAlert = ok
 	case InTotal:
 		// If the sum of all samples matches the condition, the rule is firing.
 		var sum float64
@@ -623,6 +669,7 @@ func (r *PromRule) String() string {
 		AlertName:         r.name,
 		RuleCondition:     r.ruleCondition,
 		EvalWindow:        Duration(r.evalWindow),
+		EvalDelay:         (*Duration)(&r.evalDelay),
 		Labels:            r.labels.Map(),
 		Annotations:       r.annotations.Map(),
 		PreferredChannels: r.preferredChannels,
@@ -150,6 +150,11 @@ func (g *PromRuleTask) Run(ctx context.Context) {
 				return
 			case <-tick.C:
 				missed := (time.Since(evalTimestamp) / g.frequency) - 1
+				if missed > 0 {
+					for _, rule := range g.rules {
+						recordSkipped(rule.ID(), skipReasonMissed, int64(missed), time.Now())
+					}
+				}
 				evalTimestamp = evalTimestamp.Add((missed + 1) * g.frequency)
 				iter()
 			}
@@ -329,16 +334,21 @@ func (g *PromRuleTask) Eval(ctx context.Context, ts time.Time) {
 		}
 
 		shouldSkip := false
+		var suppressing []PlannedMaintenance
 		for _, m := range maintenance {
 			zap.L().Info("checking if rule should be skipped", zap.String("rule", rule.ID()), zap.Any("maintenance", m))
 			if m.shouldSkip(rule.ID(), ts) {
 				shouldSkip = true
 				break
 			}
+			if m.shouldSuppress(rule.ID(), ts) {
+				suppressing = append(suppressing, m)
+			}
 		}
 
 		if shouldSkip {
 			zap.L().Info("rule should be skipped", zap.String("rule", rule.ID()))
+			recordSkipped(rule.ID(), skipReasonMaintenance, 1, ts)
 			continue
 		}
 
@@ -352,12 +362,14 @@ func (g *PromRuleTask) Eval(ctx context.Context, ts time.Time) {
 			sp, ctx := opentracing.StartSpanFromContext(ctx, "rule")
 
 			sp.SetTag("name", rule.Name())
+			var err error
 			defer func(t time.Time) {
 				sp.Finish()
 
 				since := time.Since(t)
 				rule.SetEvaluationDuration(since)
 				rule.SetEvaluationTimestamp(t)
+				recordEvaluation(rule, t, since, err)
 			}(time.Now())
 
 			kvs := map[string]string{
@@ -366,8 +378,10 @@ func (g *PromRuleTask) Eval(ctx context.Context, ts time.Time) {
 				"client":  "query-service",
 			}
 			ctx = context.WithValue(ctx, common.LogCommentKey, kvs)
+			ctx = common.WithQueryClass(ctx, common.QueryClassAlerts)
 
-			_, err := rule.Eval(ctx, ts, g.opts.Queriers)
+			before := snapshotAlerts(rule.ActiveAlerts())
+			_, err = rule.Eval(ctx, ts, g.opts.Queriers)
 			if err != nil {
 				rule.SetHealth(HealthBad)
 				rule.SetLastError(err)
@@ -381,7 +395,10 @@ func (g *PromRuleTask) Eval(ctx context.Context, ts time.Time) {
 				//}
 				return
 			}
-			rule.SendAlerts(ctx, ts, g.opts.ResendDelay, g.frequency, g.notify)
+			if changes := stateChanges(rule, before, rule.ActiveAlerts(), ts); len(changes) > 0 && g.opts.onStateChange != nil {
+				g.opts.onStateChange(ctx, changes...)
+			}
+			rule.SendAlerts(ctx, ts, g.opts.ResendDelay, g.frequency, suppressAlerts(rule, suppressing, g.notify))
 
 		}(i, rule)
 	}
@@ -6,6 +6,7 @@ import (
 
 	pql "github.com/prometheus/prometheus/promql"
 	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 )
 
@@ -620,3 +621,30 @@ func TestPromRuleShouldAlert(t *testing.T) {
 		assert.Equal(t, c.expectAlert, shoulAlert, "Test case %d", idx)
 	}
 }
+
+func TestPromRuleOrgScope(t *testing.T) {
+	postableRule := PostableRule{
+		AlertName: "Org scope",
+		AlertType: "METRIC_BASED_ALERT",
+		RuleType:  RuleTypeProm,
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypePromQL,
+				PromQueries: map[string]*v3.PromQuery{
+					"A": {Query: `rate(http_requests_total{code="500"}[5m]) > on(service) group_left up`},
+				},
+			},
+			SelectedQuery: "A",
+		},
+	}
+
+	rule, err := NewPromRule("69", &postableRule, testLogger{t}, PromRuleOpts{OrgScope: &model.OrgScope{OrgIds: []string{"org-b"}}})
+	assert.NoError(t, err)
+
+	// every selector of the query reads the series of the org of the rule
+	query, err := rule.getPqlQuery()
+	assert.NoError(t, err)
+	assert.Equal(t, `rate(http_requests_total{code="500",signoz_org_id="org-b"}[5m]) > on (service) group_left () up{signoz_org_id="org-b"}`, query)
+	// the query of the rule is not changed
+	assert.Equal(t, `rate(http_requests_total{code="500"}[5m]) > on(service) group_left up`, postableRule.RuleCondition.CompositeQuery.PromQueries["A"].Query)
+}
@@ -0,0 +1,349 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidMetricName = errors.New("invalid metric name")
+	ErrMissingQuery      = errors.New("missing query")
+	ErrInvalidFrequency  = errors.New("frequency must be at least 1m")
+)
+
+var metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:.]*$`)
+
+const (
+	defaultRecordingFrequency = Duration(time.Minute)
+
+	// recordedMetricTemporality is the temporality of the recorded series,
+	// the recorded values are gauges
+	recordedMetricTemporality = "Unspecified"
+	recordedMetricType        = "Gauge"
+)
+
+// RecordingRule periodically evaluates a query and writes the result
+// of the selected query as a new metric
+type RecordingRule struct {
+	Id int64 `json:"id"`
+	// Name is the name of the recorded metric
+	Name          string             `json:"name"`
+	Description   string             `json:"description,omitempty"`
+	Labels        map[string]string  `json:"labels,omitempty"`
+	Query         *v3.CompositeQuery `json:"query"`
+	SelectedQuery string             `json:"selectedQuery,omitempty"`
+	Frequency     Duration           `json:"frequency,omitempty"`
+	Disabled      bool               `json:"disabled"`
+	CreatedAt     time.Time          `json:"createdAt"`
+	CreatedBy     string             `json:"createdBy"`
+	UpdatedAt     time.Time          `json:"updatedAt"`
+	UpdatedBy     string             `json:"updatedBy"`
+	// OrgId is the org of the rule, its queries read the data of the org
+	OrgId string `json:"-"`
+}
+
+// StoredRecordingRule is the recording rule as stored in the db
+type StoredRecordingRule struct {
+	Id        int64     `db:"id"`
+	Name      string    `db:"name"`
+	Data      string    `db:"data"`
+	CreatedAt time.Time `db:"created_at"`
+	CreatedBy string    `db:"created_by"`
+	UpdatedAt time.Time `db:"updated_at"`
+	UpdatedBy string    `db:"updated_by"`
+	OrgId     string    `db:"org_id"`
+}
+
+func (s *StoredRecordingRule) parse() (*RecordingRule, error) {
+	rule := &RecordingRule{}
+	if err := json.Unmarshal([]byte(s.Data), rule); err != nil {
+		return nil, err
+	}
+	rule.Id = s.Id
+	rule.Name = s.Name
+	rule.CreatedAt = s.CreatedAt
+	rule.CreatedBy = s.CreatedBy
+	rule.UpdatedAt = s.UpdatedAt
+	rule.UpdatedBy = s.UpdatedBy
+	rule.OrgId = s.OrgId
+	return rule, nil
+}
+
+func (r *RecordingRule) Validate() error {
+	if !metricNameRE.MatchString(r.Name) {
+		return ErrInvalidMetricName
+	}
+	if r.Query == nil {
+		return ErrMissingQuery
+	}
+	if err := r.Query.Validate(); err != nil {
+		return err
+	}
+	if r.Frequency == 0 {
+		r.Frequency = defaultRecordingFrequency
+	}
+	if r.Frequency < defaultRecordingFrequency {
+		return ErrInvalidFrequency
+	}
+	for k, v := range r.Labels {
+		if !isValidLabelName(k) {
+			return errors.Errorf("invalid label name: %s", k)
+		}
+		if !isValidLabelValue(v) {
+			return errors.Errorf("invalid label value: %s", v)
+		}
+	}
+	return nil
+}
+
+// recordingEvaluator runs the query of the recording rule, it reuses the
+// query machinery of the threshold rule without any alerting condition.
+// The queries are scoped to the org of the rule, the builder queries by the
+// threshold rule and the promql queries here on a copy of the query
+func newRecordingEvaluator(rule *RecordingRule, scope *model.OrgScope, featureFlags interfaces.FeatureLookup, reader interfaces.Reader) (*ThresholdRule, error) {
+	query := rule.Query
+	if scope != nil && len(query.PromQueries) > 0 {
+		scoped := *query
+		scoped.PromQueries = make(map[string]*v3.PromQuery, len(query.PromQueries))
+		for name, q := range query.PromQueries {
+			promQuery := *q
+			expr, err := common.ScopePromQL(q.Query, scope)
+			if err != nil {
+				return nil, err
+			}
+			promQuery.Query = expr
+			scoped.PromQueries[name] = &promQuery
+		}
+		query = &scoped
+	}
+
+	t := &ThresholdRule{
+		id:   fmt.Sprintf("recording-%d", rule.Id),
+		name: rule.Name,
+		ruleCondition: &RuleCondition{
+			CompositeQuery: query,
+			SelectedQuery:  rule.SelectedQuery,
+		},
+		opts:           ThresholdRuleOpts{OrgScope: scope},
+		evalWindow:     time.Duration(rule.Frequency),
+		health:         HealthUnknown,
+		active:         map[uint64]*Alert{},
+		version:        "v4",
+		temporalityMap: make(map[string]map[v3.Temporality]bool),
+	}
+	t.querier, t.querierV2 = newRuleQueriers(featureFlags, reader)
+	return t, nil
+}
+
+// recordedSample is the latest value of a recorded series
+type recordedSample struct {
+	fingerprint uint64
+	labels      string
+	unixMilli   int64
+	value       float64
+}
+
+// prepareSamples takes the latest point of each series of the result, the
+// series recorded for an org other than the default one get the label of the
+// org so that they are read by its queries only
+func prepareSamples(rule *RecordingRule, scope *model.OrgScope, result *v3.Result) ([]recordedSample, error) {
+	var samples []recordedSample
+	for _, series := range result.Series {
+		if len(series.Points) == 0 {
+			continue
+		}
+		series.SortPoints()
+		point := series.Points[len(series.Points)-1]
+		if math.IsNaN(point.Value) || math.IsInf(point.Value, 0) {
+			continue
+		}
+
+		lbls := map[string]string{}
+		for k, v := range series.Labels {
+			lbls[k] = v
+		}
+		for k, v := range rule.Labels {
+			lbls[k] = v
+		}
+		if scope != nil && !scope.Exclude {
+			lbls[constants.OrgIdLabel] = scope.OrgIds[0]
+		}
+		lbls[labels.MetricNameLabel] = rule.Name
+		lbls[labels.TemporalityLabel] = recordedMetricTemporality
+
+		data, err := json.Marshal(lbls)
+		if err != nil {
+			return nil, err
+		}
+
+		samples = append(samples, recordedSample{
+			fingerprint: labels.FromMap(lbls).Hash(),
+			labels:      string(data),
+			unixMilli:   point.Timestamp,
+			value:       point.Value,
+		})
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].fingerprint < samples[j].fingerprint })
+	return samples, nil
+}
+
+// writeSamples inserts the recorded series and samples in the metrics tables
+func writeSamples(ctx context.Context, conn clickhouse.Conn, rule *RecordingRule, samples []recordedSample) error {
+	tsBatch, err := conn.PrepareBatch(ctx, fmt.Sprintf(
+		"INSERT INTO %s.%s (env, temporality, metric_name, description, unit, type, is_monotonic, fingerprint, unix_milli, labels)",
+		constants.SIGNOZ_METRIC_DBNAME, constants.SIGNOZ_TIMESERIES_v4_TABLENAME))
+	if err != nil {
+		return err
+	}
+	defer tsBatch.Abort()
+
+	unit := ""
+	if rule.Query != nil {
+		unit = rule.Query.Unit
+	}
+	for _, s := range samples {
+		// the time series are stored once per hour
+		hour := s.unixMilli - s.unixMilli%time.Hour.Milliseconds()
+		if err := tsBatch.Append("default", recordedMetricTemporality, rule.Name, rule.Description, unit, recordedMetricType, false, s.fingerprint, hour, s.labels); err != nil {
+			return err
+		}
+	}
+	if err := tsBatch.Send(); err != nil {
+		return err
+	}
+
+	samplesBatch, err := conn.PrepareBatch(ctx, fmt.Sprintf(
+		"INSERT INTO %s.%s (env, temporality, metric_name, fingerprint, unix_milli, value)",
+		constants.SIGNOZ_METRIC_DBNAME, constants.SIGNOZ_SAMPLES_V4_TABLENAME))
+	if err != nil {
+		return err
+	}
+	defer samplesBatch.Abort()
+
+	for _, s := range samples {
+		if err := samplesBatch.Append("default", recordedMetricTemporality, rule.Name, s.fingerprint, s.unixMilli, s.value); err != nil {
+			return err
+		}
+	}
+	return samplesBatch.Send()
+}
+
+// recordingTask evaluates a recording rule at its frequency
+type recordingTask struct {
+	rule      *RecordingRule
+	scope     *model.OrgScope
+	evaluator *ThresholdRule
+	conn      clickhouse.Conn
+
+	// fingerprint -> timestamp of the last written sample
+	written map[uint64]int64
+
+	done       chan struct{}
+	terminated chan struct{}
+	stopOnce   sync.Once
+}
+
+func newRecordingTask(rule *RecordingRule, scope *model.OrgScope, conn clickhouse.Conn, featureFlags interfaces.FeatureLookup, reader interfaces.Reader) (*recordingTask, error) {
+	evaluator, err := newRecordingEvaluator(rule, scope, featureFlags, reader)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingTask{
+		rule:       rule,
+		scope:      scope,
+		evaluator:  evaluator,
+		conn:       conn,
+		written:    map[uint64]int64{},
+		done:       make(chan struct{}),
+		terminated: make(chan struct{}),
+	}, nil
+}
+
+func (t *recordingTask) Run(ctx context.Context) {
+	defer close(t.terminated)
+
+	ticker := time.NewTicker(time.Duration(t.rule.Frequency))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ctx.Done():
+			return
+		case ts := <-ticker.C:
+			if err := t.eval(ctx, ts); err != nil {
+				zap.L().Error("failed to evaluate recording rule", zap.String("name", t.rule.Name), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (t *recordingTask) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+	<-t.terminated
+}
+
+func (t *recordingTask) eval(ctx context.Context, ts time.Time) error {
+	results, err := t.evaluator.runQuery(ctx, ts, t.conn)
+	if err != nil {
+		return err
+	}
+
+	selectedQuery := t.evaluator.GetSelectedQuery()
+	var result *v3.Result
+	for _, res := range results {
+		if res.QueryName == selectedQuery {
+			result = res
+			break
+		}
+	}
+	if result == nil {
+		return nil
+	}
+
+	samples, err := prepareSamples(t.rule, t.scope, result)
+	if err != nil {
+		return err
+	}
+
+	// the evaluation windows can overlap when the step of the
+	// query is larger than the frequency of the rule
+	fresh := samples[:0]
+	for _, s := range samples {
+		if t.written[s.fingerprint] >= s.unixMilli {
+			continue
+		}
+		fresh = append(fresh, s)
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+
+	if err := writeSamples(ctx, t.conn, t.rule, fresh); err != nil {
+		return err
+	}
+	for _, s := range fresh {
+		t.written[s.fingerprint] = s.unixMilli
+	}
+	zap.L().Debug("recorded samples", zap.String("name", t.rule.Name), zap.Int("count", len(fresh)))
+	return nil
+}
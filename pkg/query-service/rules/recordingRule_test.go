@@ -0,0 +1,138 @@
+package rules
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func testRecordingQuery() *v3.CompositeQuery {
+	return &v3.CompositeQuery{
+		QueryType: v3.QueryTypePromQL,
+		PanelType: v3.PanelTypeGraph,
+		PromQueries: map[string]*v3.PromQuery{
+			"A": {Query: "sum by (service_name) (rate(signoz_calls_total[5m]))"},
+		},
+	}
+}
+
+func TestRecordingRuleValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		rule RecordingRule
+		err  bool
+	}{
+		{name: "valid", rule: RecordingRule{Name: "service:calls:rate5m", Query: testRecordingQuery()}},
+		{name: "invalid name", rule: RecordingRule{Name: "5xx-rate", Query: testRecordingQuery()}, err: true},
+		{name: "missing query", rule: RecordingRule{Name: "calls"}, err: true},
+		{name: "frequency too low", rule: RecordingRule{Name: "calls", Query: testRecordingQuery(), Frequency: Duration(10 * time.Second)}, err: true},
+		{name: "invalid label", rule: RecordingRule{Name: "calls", Query: testRecordingQuery(), Labels: map[string]string{"1team": "a"}}, err: true},
+	}
+
+	for _, c := range cases {
+		err := c.rule.Validate()
+		if c.err != (err != nil) {
+			t.Errorf("%s: expected error %v, got %v", c.name, c.err, err)
+		}
+	}
+
+	rule := RecordingRule{Name: "calls", Query: testRecordingQuery()}
+	if err := rule.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if rule.Frequency != defaultRecordingFrequency {
+		t.Errorf("expected default frequency, got %v", rule.Frequency)
+	}
+}
+
+func TestPrepareSamples(t *testing.T) {
+	rule := &RecordingRule{Name: "service:calls:rate5m", Labels: map[string]string{"team": "payments"}}
+	result := &v3.Result{
+		QueryName: "A",
+		Series: []*v3.Series{
+			{
+				Labels: map[string]string{"service_name": "frontend"},
+				Points: []v3.Point{{Timestamp: 2000, Value: 2}, {Timestamp: 1000, Value: 1}},
+			},
+			{
+				Labels: map[string]string{"service_name": "cart"},
+				Points: []v3.Point{{Timestamp: 2000, Value: math.NaN()}},
+			},
+			{
+				Labels: map[string]string{"service_name": "empty"},
+			},
+		},
+	}
+
+	samples, err := prepareSamples(rule, nil, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1 {
+		t.Fatalf("expected 1 sample, got %d", len(samples))
+	}
+	if samples[0].unixMilli != 2000 || samples[0].value != 2 {
+		t.Errorf("expected the latest point, got %d %v", samples[0].unixMilli, samples[0].value)
+	}
+
+	var lbls map[string]string
+	if err := json.Unmarshal([]byte(samples[0].labels), &lbls); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"__name__":        "service:calls:rate5m",
+		"__temporality__": "Unspecified",
+		"service_name":    "frontend",
+		"team":            "payments",
+	}
+	for k, v := range expected {
+		if lbls[k] != v {
+			t.Errorf("expected label %s=%s, got %s", k, v, lbls[k])
+		}
+	}
+	if len(lbls) != len(expected) {
+		t.Errorf("expected %d labels, got %v", len(expected), lbls)
+	}
+}
+
+func TestPrepareSamplesOrg(t *testing.T) {
+	rule := &RecordingRule{Name: "service:calls:rate5m"}
+	result := &v3.Result{
+		QueryName: "A",
+		Series: []*v3.Series{
+			{
+				Labels: map[string]string{"service_name": "frontend"},
+				Points: []v3.Point{{Timestamp: 1000, Value: 1}},
+			},
+		},
+	}
+
+	// the series recorded for the default org are not labelled
+	samples, err := prepareSamples(rule, nil, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lbls map[string]string
+	if err := json.Unmarshal([]byte(samples[0].labels), &lbls); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lbls[constants.OrgIdLabel]; ok {
+		t.Errorf("expected no org label, got %v", lbls)
+	}
+
+	samples, err = prepareSamples(rule, &model.OrgScope{OrgIds: []string{"org-2"}}, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(samples[0].labels), &lbls); err != nil {
+		t.Fatal(err)
+	}
+	if lbls[constants.OrgIdLabel] != "org-2" {
+		t.Errorf("expected the label of the org, got %v", lbls)
+	}
+}
@@ -22,6 +22,9 @@ type Sample struct {
 	MetricOrig labels.Labels
 
 	IsMissing bool
+
+	// outlier compares the series of the sample with its peers for the outlier conditions
+	outlier *outlierScore
 }
 
 func (s Sample) String() string {
@@ -0,0 +1,232 @@
+package rules
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+// ruleRoutesSyncInterval is how often the rule receivers are reconciled with alertmanager,
+// it also picks up the changes of the notification channels
+const ruleRoutesSyncInterval = time.Minute
+
+// ruleRoute is a receiver pushed to alertmanager for the notification settings of a rule
+type ruleRoute struct {
+	receiver *am.Receiver
+	// channels are the channels of the alerts of the rule, its preferred channels or the
+	// channels of its org, the alerts sent to other channels, like the escalations, keep
+	// their receivers
+	channels []string
+	hash     string
+}
+
+// ruleRoutes keeps the alertmanager receivers generated for the notification settings of
+// the rules in sync with the rules. The receivers are pushed in the background so the rule
+// APIs and the start of the manager do not depend on alertmanager being available
+type ruleRoutes struct {
+	alertManager am.Manager
+	rules        func(ctx context.Context) ([]StoredRule, error)
+	// channels reads the notification channels of an org
+	channels func(ctx context.Context, orgId string) ([]am.Receiver, error)
+
+	mtx sync.RWMutex
+	// pushed are the receivers alertmanager has, keyed by rule id
+	pushed map[string]*ruleRoute
+
+	trigger chan struct{}
+}
+
+func newRuleRoutes(alertManager am.Manager, rules func(ctx context.Context) ([]StoredRule, error), channels func(ctx context.Context, orgId string) ([]am.Receiver, error)) *ruleRoutes {
+	return &ruleRoutes{
+		alertManager: alertManager,
+		rules:        rules,
+		channels:     channels,
+		pushed:       map[string]*ruleRoute{},
+		trigger:      make(chan struct{}, 1),
+	}
+}
+
+// run reconciles the receivers until the context is done
+func (r *ruleRoutes) run(ctx context.Context) {
+	ticker := time.NewTicker(ruleRoutesSyncInterval)
+	defer ticker.Stop()
+	for {
+		if err := r.reconcile(ctx); err != nil {
+			zap.L().Error("failed to sync the rule routes with alertmanager", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.trigger:
+		}
+	}
+}
+
+// sync requests a reconciliation of the receivers, it does not wait for it
+func (r *ruleRoutes) sync() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// desired prepares the receivers of the stored rules with notification settings
+func (r *ruleRoutes) desired(ctx context.Context) (map[string]*ruleRoute, error) {
+	storedRules, err := r.rules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the channels of the orgs, the rules only send their alerts to the channels of their org
+	orgChannels := map[string][]am.Receiver{}
+	routes := map[string]*ruleRoute{}
+	for _, rec := range storedRules {
+		rule, errs := ParsePostableRule([]byte(rec.Data))
+		if len(errs) > 0 || rule.NotificationSettings.IsEmpty() {
+			continue
+		}
+		ruleId := fmt.Sprintf("%d", rec.Id)
+
+		channels, ok := orgChannels[rec.OrgId]
+		if !ok {
+			channels, err = r.channels(ctx, rec.OrgId)
+			if err != nil {
+				return nil, err
+			}
+			orgChannels[rec.OrgId] = channels
+		}
+
+		// the alerts without preferred channels are sent to all the channels of the org
+		selected := channels
+		if len(rule.PreferredChannels) > 0 {
+			selected = nil
+			for _, channel := range channels {
+				if slices.Contains(rule.PreferredChannels, channel.Name) {
+					selected = append(selected, channel)
+				}
+			}
+		}
+		receiver, err := rule.NotificationSettings.ruleReceiver(ruleId, selected)
+		if err != nil {
+			zap.L().Error("failed to prepare the receiver of the rule", zap.String("id", ruleId), zap.Error(err))
+			continue
+		}
+		if receiver == nil {
+			continue
+		}
+		data, err := json.Marshal(receiver)
+		if err != nil {
+			return nil, err
+		}
+		hash := sha256.Sum256(data)
+		names := make([]string, 0, len(selected))
+		for _, channel := range selected {
+			names = append(names, channel.Name)
+		}
+		routes[ruleId] = &ruleRoute{
+			receiver: receiver,
+			channels: names,
+			hash:     hex.EncodeToString(hash[:]),
+		}
+	}
+	return routes, nil
+}
+
+// reconcile pushes the receivers of the rules that changed and deletes the receivers of
+// the rules removed or without notification settings
+func (r *ruleRoutes) reconcile(ctx context.Context) error {
+	desired, err := r.desired(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mtx.RLock()
+	pushed := make(map[string]*ruleRoute, len(r.pushed))
+	for ruleId, route := range r.pushed {
+		pushed[ruleId] = route
+	}
+	r.mtx.RUnlock()
+
+	var errs []error
+	for ruleId, route := range desired {
+		current, ok := pushed[ruleId]
+		if ok && current.hash == route.hash {
+			continue
+		}
+		var apiErr *model.ApiError
+		if ok {
+			apiErr = r.alertManager.EditRoute(route.receiver)
+		} else if apiErr = r.alertManager.AddRoute(route.receiver); apiErr != nil {
+			// the receiver remains in alertmanager when the query service restarts
+			apiErr = r.alertManager.EditRoute(route.receiver)
+		}
+		if apiErr != nil {
+			errs = append(errs, fmt.Errorf("failed to push the receiver of rule %s: %w", ruleId, apiErr.Err))
+			continue
+		}
+		r.mtx.Lock()
+		r.pushed[ruleId] = route
+		r.mtx.Unlock()
+	}
+
+	for ruleId, route := range pushed {
+		if _, ok := desired[ruleId]; ok {
+			continue
+		}
+		if apiErr := r.alertManager.DeleteRoute(route.receiver.Name); apiErr != nil {
+			errs = append(errs, fmt.Errorf("failed to delete the receiver of rule %s: %w", ruleId, apiErr.Err))
+			continue
+		}
+		r.mtx.Lock()
+		delete(r.pushed, ruleId)
+		r.mtx.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// route addresses the alerts of the rules with a receiver in alertmanager to it, the
+// other alerts are sent to their channels
+func (r *ruleRoutes) route(alerts []*am.Alert) []*am.Alert {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	if len(r.pushed) == 0 {
+		return alerts
+	}
+
+	routed := make([]*am.Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		route, ok := r.pushed[alert.Labels.Get(labels.AlertRuleIdLabel)]
+		if !ok || !sameChannels(alert.Receivers, route.channels) {
+			routed = append(routed, alert)
+			continue
+		}
+		a := *alert
+		a.Receivers = []string{route.receiver.Name}
+		routed = append(routed, &a)
+	}
+	return routed
+}
+
+func sameChannels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, channel := range a {
+		if !slices.Contains(b, channel) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// fakeAlertManager records the receivers pushed to alertmanager
+type fakeAlertManager struct {
+	am.Manager
+	receivers map[string]*am.Receiver
+	calls     []string
+	down      bool
+}
+
+func (f *fakeAlertManager) AddRoute(receiver *am.Receiver) *model.ApiError {
+	f.calls = append(f.calls, "add "+receiver.Name)
+	if f.down {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: errors.New("alertmanager is down")}
+	}
+	if _, ok := f.receivers[receiver.Name]; ok {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: errors.New("receiver already exists")}
+	}
+	f.receivers[receiver.Name] = receiver
+	return nil
+}
+
+func (f *fakeAlertManager) EditRoute(receiver *am.Receiver) *model.ApiError {
+	f.calls = append(f.calls, "edit "+receiver.Name)
+	if f.down {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: errors.New("alertmanager is down")}
+	}
+	f.receivers[receiver.Name] = receiver
+	return nil
+}
+
+func (f *fakeAlertManager) DeleteRoute(name string) *model.ApiError {
+	f.calls = append(f.calls, "delete "+name)
+	if f.down {
+		return &model.ApiError{Typ: model.ErrorInternal, Err: errors.New("alertmanager is down")}
+	}
+	delete(f.receivers, name)
+	return nil
+}
+
+func storedRule(t *testing.T, id int, channels []string, settings *NotificationSettings) StoredRule {
+	target := 10.0
+	rule := PostableRule{
+		AlertName:         "High latency",
+		AlertType:         "METRIC_BASED_ALERT",
+		RuleType:          RuleTypeThreshold,
+		PreferredChannels: channels,
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeClickHouseSQL,
+				PanelType: v3.PanelTypeGraph,
+				ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+					"A": {Query: "SELECT 1"},
+				},
+			},
+			CompareOp:     ValueIsAbove,
+			MatchType:     AtleastOnce,
+			Target:        &target,
+			SelectedQuery: "A",
+		},
+		NotificationSettings: settings,
+	}
+	data, err := json.Marshal(rule)
+	require.NoError(t, err)
+	return StoredRule{Id: id, Data: string(data)}
+}
+
+func TestRuleRoutesReconcile(t *testing.T) {
+	channels := []am.Receiver{
+		{Name: "slack", SlackConfigs: []interface{}{map[string]interface{}{"channel": "#alerts"}}},
+		{Name: "email", EmailConfigs: []interface{}{map[string]interface{}{"to": "oncall@signoz.io"}}},
+		{Name: "telegram", TelegramConfigs: []interface{}{map[string]interface{}{"bot_token": "token", "chat_id": 1}}},
+	}
+	settings := &NotificationSettings{GroupBy: []string{"service"}, GroupWait: Duration(30 * time.Second), RepeatInterval: Duration(4 * time.Hour)}
+
+	stored := []StoredRule{
+		storedRule(t, 1, []string{"slack", "telegram"}, settings),
+		storedRule(t, 2, []string{"slack"}, nil),
+		// the direct channels are delivered by the query service
+		storedRule(t, 3, []string{"telegram"}, settings),
+		storedRule(t, 4, nil, settings),
+	}
+	fake := &fakeAlertManager{receivers: map[string]*am.Receiver{}}
+	routes := newRuleRoutes(fake,
+		func(ctx context.Context) ([]StoredRule, error) { return stored, nil },
+		func(ctx context.Context, orgId string) ([]am.Receiver, error) { return channels, nil },
+	)
+	ctx := context.Background()
+
+	require.NoError(t, routes.reconcile(ctx))
+	assert.ElementsMatch(t, []string{"add signoz-rule-1", "add signoz-rule-4"}, fake.calls)
+
+	receiver := fake.receivers["signoz-rule-1"]
+	require.NotNil(t, receiver)
+	data, _ := json.Marshal(receiver)
+	assert.JSONEq(t, `{"name":"signoz-rule-1","slack_configs":[{"channel":"#alerts"}],"route":{"receiver":"signoz-rule-1","matchers":["ruleId=\"1\""],"group_by":["service"],"group_wait":"30s","repeat_interval":"4h"}}`, string(data))
+	data, _ = json.Marshal(fake.receivers["signoz-rule-4"])
+	assert.JSONEq(t, `{"name":"signoz-rule-4","email_configs":[{"to":"oncall@signoz.io"}],"slack_configs":[{"channel":"#alerts"}],"route":{"receiver":"signoz-rule-4","matchers":["ruleId=\"4\""],"group_by":["service"],"group_wait":"30s","repeat_interval":"4h"}}`, string(data))
+
+	// the unchanged receivers are not pushed again
+	fake.calls = nil
+	require.NoError(t, routes.reconcile(ctx))
+	assert.Empty(t, fake.calls)
+
+	// the receivers follow the changes of the rules
+	stored = []StoredRule{
+		storedRule(t, 1, []string{"slack", "email"}, settings),
+		storedRule(t, 4, nil, nil),
+	}
+	require.NoError(t, routes.reconcile(ctx))
+	assert.ElementsMatch(t, []string{"edit signoz-rule-1", "delete signoz-rule-4"}, fake.calls)
+	assert.NotNil(t, fake.receivers["signoz-rule-1"].EmailConfigs)
+	assert.NotContains(t, fake.receivers, "signoz-rule-4")
+}
+
+func TestRuleRoutesRestart(t *testing.T) {
+	stored := []StoredRule{storedRule(t, 1, []string{"slack"}, &NotificationSettings{GroupBy: []string{"service"}})}
+	channels := []am.Receiver{{Name: "slack", SlackConfigs: []interface{}{map[string]interface{}{"channel": "#alerts"}}}}
+
+	// the receiver pushed before the restart is updated
+	fake := &fakeAlertManager{receivers: map[string]*am.Receiver{"signoz-rule-1": {Name: "signoz-rule-1"}}}
+	routes := newRuleRoutes(fake,
+		func(ctx context.Context) ([]StoredRule, error) { return stored, nil },
+		func(ctx context.Context, orgId string) ([]am.Receiver, error) { return channels, nil },
+	)
+	require.NoError(t, routes.reconcile(context.Background()))
+	assert.Equal(t, []string{"add signoz-rule-1", "edit signoz-rule-1"}, fake.calls)
+	assert.Equal(t, []string{"service"}, fake.receivers["signoz-rule-1"].Route.GroupBy)
+}
+
+func TestRuleRoutesRoute(t *testing.T) {
+	stored := []StoredRule{storedRule(t, 1, []string{"slack"}, &NotificationSettings{GroupBy: []string{"service"}})}
+	channels := []am.Receiver{{Name: "slack", SlackConfigs: []interface{}{map[string]interface{}{"channel": "#alerts"}}}}
+	fake := &fakeAlertManager{receivers: map[string]*am.Receiver{}, down: true}
+	routes := newRuleRoutes(fake,
+		func(ctx context.Context) ([]StoredRule, error) { return stored, nil },
+		func(ctx context.Context, orgId string) ([]am.Receiver, error) { return channels, nil },
+	)
+
+	alert := func(ruleId string, receivers ...string) *am.Alert {
+		return &am.Alert{Labels: labels.FromMap(map[string]string{labels.AlertRuleIdLabel: ruleId}), Receivers: receivers}
+	}
+	alerts := []*am.Alert{
+		alert("1", "slack"),
+		// escalated to another channel
+		alert("1", "pagerduty"),
+		alert("2", "slack"),
+	}
+
+	// the alerts are sent to their channels while alertmanager is unavailable
+	assert.Error(t, routes.reconcile(context.Background()))
+	assert.Equal(t, alerts, routes.route(alerts))
+
+	fake.down = false
+	require.NoError(t, routes.reconcile(context.Background()))
+	routed := routes.route(alerts)
+	assert.Equal(t, []string{"signoz-rule-1"}, routed[0].Receivers)
+	assert.Equal(t, []string{"pagerduty"}, routed[1].Receivers)
+	assert.Equal(t, []string{"slack"}, routed[2].Receivers)
+	// the direct notifier keeps the channels of the alerts
+	assert.Equal(t, []string{"slack"}, alerts[0].Receivers)
+}
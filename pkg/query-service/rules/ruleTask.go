@@ -139,6 +139,11 @@ func (g *RuleTask) Run(ctx context.Context) {
 				return
 			case <-tick.C:
 				missed := (time.Since(evalTimestamp) / g.frequency) - 1
+				if missed > 0 {
+					for _, rule := range g.rules {
+						recordSkipped(rule.ID(), skipReasonMissed, int64(missed), time.Now())
+					}
+				}
 				evalTimestamp = evalTimestamp.Add((missed + 1) * g.frequency)
 				iter()
 			}
@@ -276,6 +281,15 @@ func (g *RuleTask) CopyState(fromTask Task) error {
 		fi := indexes[0]
 		ruleMap[nameAndLabels] = indexes[1:]
 
+		if cr, ok := rule.(*CompositeRule); ok {
+			if fcr, ok := from.rules[fi].(*CompositeRule); ok {
+				for fp, a := range fcr.active {
+					cr.active[fp] = a
+				}
+			}
+			continue
+		}
+
 		ar, ok := rule.(*ThresholdRule)
 		if !ok {
 			continue
@@ -310,16 +324,21 @@ func (g *RuleTask) Eval(ctx context.Context, ts time.Time) {
 		}
 
 		shouldSkip := false
+		var suppressing []PlannedMaintenance
 		for _, m := range maintenance {
 			zap.L().Info("checking if rule should be skipped", zap.String("rule", rule.ID()), zap.Any("maintenance", m))
 			if m.shouldSkip(rule.ID(), ts) {
 				shouldSkip = true
 				break
 			}
+			if m.shouldSuppress(rule.ID(), ts) {
+				suppressing = append(suppressing, m)
+			}
 		}
 
 		if shouldSkip {
 			zap.L().Info("rule should be skipped", zap.String("rule", rule.ID()))
+			recordSkipped(rule.ID(), skipReasonMaintenance, 1, ts)
 			continue
 		}
 
@@ -333,12 +352,14 @@ func (g *RuleTask) Eval(ctx context.Context, ts time.Time) {
 			sp, ctx := opentracing.StartSpanFromContext(ctx, "rule")
 
 			sp.SetTag("name", rule.Name())
+			var err error
 			defer func(t time.Time) {
 				sp.Finish()
 
 				since := time.Since(t)
 				rule.SetEvaluationDuration(since)
 				rule.SetEvaluationTimestamp(t)
+				recordEvaluation(rule, t, since, err)
 			}(time.Now())
 
 			kvs := map[string]string{
@@ -347,8 +368,10 @@ func (g *RuleTask) Eval(ctx context.Context, ts time.Time) {
 				"client":  "query-service",
 			}
 			ctx = context.WithValue(ctx, common.LogCommentKey, kvs)
+			ctx = common.WithQueryClass(ctx, common.QueryClassAlerts)
 
-			_, err := rule.Eval(ctx, ts, g.opts.Queriers)
+			before := snapshotAlerts(rule.ActiveAlerts())
+			_, err = rule.Eval(ctx, ts, g.opts.Queriers)
 			if err != nil {
 				rule.SetHealth(HealthBad)
 				rule.SetLastError(err)
@@ -362,8 +385,11 @@ func (g *RuleTask) Eval(ctx context.Context, ts time.Time) {
 				//}
 				return
 			}
+			if changes := stateChanges(rule, before, rule.ActiveAlerts(), ts); len(changes) > 0 && g.opts.onStateChange != nil {
+				g.opts.onStateChange(ctx, changes...)
+			}
 
-			rule.SendAlerts(ctx, ts, g.opts.ResendDelay, g.frequency, g.notify)
+			rule.SendAlerts(ctx, ts, g.opts.ResendDelay, g.frequency, suppressAlerts(rule, suppressing, g.notify))
 
 		}(i, rule)
 	}
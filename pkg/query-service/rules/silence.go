@@ -0,0 +1,373 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrMissingMatchers = errors.New("missing label matchers")
+	ErrMissingComment  = errors.New("missing comment")
+	ErrMissingExpiry   = errors.New("a silence must expire, set the end time of the schedule or of its recurrence")
+)
+
+const (
+	SilenceStatusActive  = "active"
+	SilenceStatusPending = "pending"
+	SilenceStatusExpired = "expired"
+)
+
+// Silence mutes the notifications of the alerts whose labels match all its matchers
+// while its schedule is active. The rules are still evaluated and the muted alerts
+// stay on the alert list, annotated with the silences muting them
+type Silence struct {
+	Id       int64         `json:"id" db:"id"`
+	Matchers LabelMatchers `json:"matchers" db:"matchers"`
+	Comment  string        `json:"comment" db:"comment"`
+	// Schedule is either a fixed window the silence expires at the end of, or a
+	// recurrence muting the alerts in its windows until its end time
+	Schedule  *Schedule `json:"schedule" db:"schedule"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	CreatedBy string    `json:"createdBy" db:"created_by"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+	UpdatedBy string    `json:"updatedBy" db:"updated_by"`
+	Status    string    `json:"status" db:"-"`
+	// OrgId is the org of the silence, it only mutes the alerts of the rules of its org
+	OrgId string `json:"-" db:"org_id"`
+}
+
+func (s *Silence) Validate() error {
+	if len(s.Matchers) == 0 {
+		return ErrMissingMatchers
+	}
+	for _, matcher := range s.Matchers {
+		if err := matcher.Validate(); err != nil {
+			return err
+		}
+	}
+	if strings.TrimSpace(s.Comment) == "" {
+		return ErrMissingComment
+	}
+	if s.Schedule == nil {
+		return ErrMissingSchedule
+	}
+	if s.Schedule.Timezone == "" {
+		return ErrMissingTimezone
+	}
+	if _, err := time.LoadLocation(s.Schedule.Timezone); err != nil {
+		return errors.New("invalid timezone")
+	}
+
+	if recurrence := s.Schedule.Recurrence; recurrence != nil {
+		if recurrence.RepeatType == "" {
+			return ErrMissingRepeatType
+		}
+		if recurrence.Duration == 0 {
+			return ErrMissingDuration
+		}
+		if recurrence.EndTime == nil {
+			return ErrMissingExpiry
+		}
+		if recurrence.EndTime.Before(recurrence.StartTime) {
+			return errors.New("end time cannot be before start time")
+		}
+		return nil
+	}
+
+	if s.Schedule.EndTime.IsZero() {
+		return ErrMissingExpiry
+	}
+	if s.Schedule.StartTime.IsZero() {
+		return errors.New("missing start time")
+	}
+	if s.Schedule.StartTime.After(s.Schedule.EndTime) {
+		return errors.New("start time cannot be after end time")
+	}
+	return nil
+}
+
+// expiresAt returns when the silence stops muting the alerts for good
+func (s *Silence) expiresAt() time.Time {
+	if s.Schedule.Recurrence != nil {
+		if s.Schedule.Recurrence.EndTime == nil {
+			return time.Time{}
+		}
+		return *s.Schedule.Recurrence.EndTime
+	}
+	return s.Schedule.EndTime
+}
+
+// isActive returns true if the silence mutes the alerts at the given time
+func (s *Silence) isActive(now time.Time) bool {
+	if s.Schedule == nil {
+		return false
+	}
+	return s.Schedule.isActive(now)
+}
+
+// state returns the status of the silence at the given time, a recurring silence
+// between two of its windows is pending
+func (s *Silence) state(now time.Time) string {
+	if s.isActive(now) {
+		return SilenceStatusActive
+	}
+	if expiry := s.expiresAt(); !expiry.IsZero() && !now.Before(expiry) {
+		return SilenceStatusExpired
+	}
+	return SilenceStatusPending
+}
+
+// expire makes the silence expire at the given time
+func (s *Silence) expire(now time.Time) {
+	if s.Schedule.Recurrence != nil {
+		s.Schedule.Recurrence.EndTime = &now
+		return
+	}
+	if s.Schedule.StartTime.After(now) {
+		s.Schedule.StartTime = now
+	}
+	s.Schedule.EndTime = now
+}
+
+// mutes returns true if all the label matchers match the alert labels
+func (s *Silence) mutes(lbls labels.BaseLabels) bool {
+	if len(s.Matchers) == 0 {
+		return false
+	}
+	for _, matcher := range s.Matchers {
+		if !matcher.Matches(lbls) {
+			return false
+		}
+	}
+	return true
+}
+
+// activeSilences returns the silences active at the given time
+func activeSilences(silences []Silence, now time.Time) []Silence {
+	active := []Silence{}
+	for _, s := range silences {
+		if s.isActive(now) {
+			active = append(active, s)
+		}
+	}
+	return active
+}
+
+// silencedBy returns the ids of the silences muting the alert
+func silencedBy(silences []Silence, lbls labels.BaseLabels) []string {
+	ids := []string{}
+	for _, s := range silences {
+		if s.mutes(lbls) {
+			ids = append(ids, fmt.Sprintf("%d", s.Id))
+		}
+	}
+	return ids
+}
+
+// silenceAlerts returns the alerts not muted by the active silences, the muted
+// alerts are annotated with the silences muting them
+func silenceAlerts(silences []Silence, alerts []*Alert) []*Alert {
+	if len(silences) == 0 {
+		return alerts
+	}
+
+	var res []*Alert
+	for _, alert := range alerts {
+		ids := silencedBy(silences, alert.Labels)
+		if len(ids) == 0 {
+			res = append(res, alert)
+			continue
+		}
+		annotations := map[string]string{}
+		if alert.Annotations != nil {
+			annotations = alert.Annotations.Map()
+		}
+		annotations[labels.AlertSilencedByLabel] = strings.Join(ids, ",")
+		alert.Annotations = labels.FromMap(annotations)
+		zap.L().Info("alert muted by silences", zap.Strings("silences", ids), zap.Any("labels", alert.Labels.Map()))
+	}
+	return res
+}
+
+// SilencedAlert is a firing alert muted by silences, shaped like the alerts the
+// alertmanager lists so that it shows on the same alert list
+type SilencedAlert struct {
+	Labels       map[string]string   `json:"labels"`
+	Annotations  map[string]string   `json:"annotations"`
+	StartsAt     time.Time           `json:"startsAt"`
+	EndsAt       time.Time           `json:"endsAt"`
+	GeneratorURL string              `json:"generatorURL"`
+	Receivers    []string            `json:"receivers"`
+	Fingerprint  string              `json:"fingerprint"`
+	Status       SilencedAlertStatus `json:"status"`
+}
+
+type SilencedAlertStatus struct {
+	State       string   `json:"state"`
+	SilencedBy  []string `json:"silencedBy"`
+	InhibitedBy []string `json:"inhibitedBy"`
+}
+
+// ListSilences returns the silences with their status
+func (m *Manager) ListSilences(ctx context.Context) ([]Silence, error) {
+	silences, err := m.ruleDB.GetAllSilences(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range silences {
+		silences[i].Status = silences[i].state(now)
+	}
+	return silences, nil
+}
+
+func (m *Manager) GetSilence(ctx context.Context, id string) (*Silence, error) {
+	silence, err := m.ruleDB.GetSilence(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	silence.Status = silence.state(time.Now())
+	return silence, nil
+}
+
+func (m *Manager) CreateSilence(ctx context.Context, silence *Silence) (*Silence, error) {
+	if err := silence.Validate(); err != nil {
+		return nil, err
+	}
+	id, err := m.ruleDB.CreateSilence(ctx, silence)
+	if err != nil {
+		return nil, err
+	}
+	return m.GetSilence(ctx, fmt.Sprintf("%d", id))
+}
+
+func (m *Manager) EditSilence(ctx context.Context, silence *Silence, id string) (*Silence, error) {
+	if _, err := m.ruleDB.GetSilence(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := silence.Validate(); err != nil {
+		return nil, err
+	}
+	if err := m.ruleDB.EditSilence(ctx, silence, id); err != nil {
+		return nil, err
+	}
+	return m.GetSilence(ctx, id)
+}
+
+// ExpireSilence ends the silence now, it stays listed as expired
+func (m *Manager) ExpireSilence(ctx context.Context, id string) (*Silence, error) {
+	silence, err := m.ruleDB.GetSilence(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	silence.expire(time.Now())
+	if err := m.ruleDB.EditSilence(ctx, silence, id); err != nil {
+		return nil, err
+	}
+	return m.GetSilence(ctx, id)
+}
+
+func (m *Manager) DeleteSilence(ctx context.Context, id string) error {
+	if _, err := m.ruleDB.GetSilence(ctx, id); err != nil {
+		return err
+	}
+	return m.ruleDB.DeleteSilence(ctx, id)
+}
+
+// silenceAlerts drops the alerts muted by the active silences of the org of their rule
+// before they are notified, the alerts are notified when the silences can't be read
+func (m *Manager) silenceAlerts(ctx context.Context, alerts []*Alert) []*Alert {
+	if len(alerts) == 0 {
+		return alerts
+	}
+	if common.GetOrgIdFromContext(ctx) != "" {
+		return m.silenceOrgAlerts(ctx, alerts)
+	}
+
+	// the alerts sent without the context of the org of their rule, e.g. the escalations,
+	// are grouped by the org of their rule
+	ruleOrgs := map[string]string{}
+	byOrg := map[string][]*Alert{}
+	for _, alert := range alerts {
+		ruleId := alert.Labels.Get(labels.AlertRuleIdLabel)
+		orgId, ok := ruleOrgs[ruleId]
+		if !ok {
+			if rule, err := m.ruleDB.GetStoredRule(ctx, ruleId); err == nil {
+				orgId = rule.OrgId
+			}
+			ruleOrgs[ruleId] = orgId
+		}
+		byOrg[orgId] = append(byOrg[orgId], alert)
+	}
+	res := make([]*Alert, 0, len(alerts))
+	for orgId, orgAlerts := range byOrg {
+		res = append(res, m.silenceOrgAlerts(orgContext(ctx, orgId), orgAlerts)...)
+	}
+	return res
+}
+
+// silenceOrgAlerts drops the alerts muted by the active silences of the org of the context
+func (m *Manager) silenceOrgAlerts(ctx context.Context, alerts []*Alert) []*Alert {
+	silences, err := m.ruleDB.GetAllSilences(ctx)
+	if err != nil {
+		zap.L().Error("failed to get the silences, the alerts are notified", zap.Error(err))
+		return alerts
+	}
+	return silenceAlerts(activeSilences(silences, time.Now()), alerts)
+}
+
+// SilencedAlerts returns the firing alerts of the rules evaluated by the replica that
+// are muted by the active silences
+func (m *Manager) SilencedAlerts(ctx context.Context) ([]*SilencedAlert, error) {
+	silences, err := m.ruleDB.GetAllSilences(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	silences = activeSilences(silences, now)
+
+	res := []*SilencedAlert{}
+	if len(silences) == 0 {
+		return res, nil
+	}
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	for _, r := range m.rules {
+		for _, alert := range r.ActiveAlerts() {
+			if alert.State != StateFiring {
+				continue
+			}
+			ids := silencedBy(silences, alert.Labels)
+			if len(ids) == 0 {
+				continue
+			}
+			a := m.prepareAmAlert(alert)
+			annotations := map[string]string{}
+			if a.Annotations != nil {
+				annotations = a.Annotations.Map()
+			}
+			annotations[labels.AlertSilencedByLabel] = strings.Join(ids, ",")
+			res = append(res, &SilencedAlert{
+				Labels:       a.Labels.Map(),
+				Annotations:  annotations,
+				StartsAt:     a.StartsAt,
+				EndsAt:       a.EndsAt,
+				GeneratorURL: a.GeneratorURL,
+				Receivers:    a.Receivers,
+				Fingerprint:  fmt.Sprintf("%016x", alert.Labels.Hash()),
+				Status:       SilencedAlertStatus{State: "suppressed", SilencedBy: ids, InhibitedBy: []string{}},
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].StartsAt.Before(res[j].StartsAt) })
+	return res, nil
+}
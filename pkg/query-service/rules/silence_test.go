@@ -0,0 +1,107 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestSilenceState(t *testing.T) {
+	now := time.Date(2024, 3, 4, 13, 0, 0, 0, time.UTC)
+	recurrenceEnd := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	daily := &Recurrence{
+		StartTime:  time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		EndTime:    &recurrenceEnd,
+		Duration:   Duration(2 * time.Hour),
+		RepeatType: RepeatTypeDaily,
+	}
+
+	cases := []struct {
+		name     string
+		schedule *Schedule
+		ts       time.Time
+		expected string
+	}{
+		{
+			name:     "fixed silence start <= ts <= end",
+			schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)},
+			ts:       now,
+			expected: SilenceStatusActive,
+		},
+		{
+			name:     "fixed silence ts < start",
+			schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour)},
+			ts:       now,
+			expected: SilenceStatusPending,
+		},
+		{
+			name:     "fixed silence ts > end",
+			schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(-2 * time.Hour), EndTime: now.Add(-time.Hour)},
+			ts:       now,
+			expected: SilenceStatusExpired,
+		},
+		{
+			name:     "recurring silence in a window",
+			schedule: &Schedule{Timezone: "UTC", Recurrence: daily},
+			ts:       now,
+			expected: SilenceStatusActive,
+		},
+		{
+			name:     "recurring silence between two windows",
+			schedule: &Schedule{Timezone: "UTC", Recurrence: daily},
+			ts:       now.Add(3 * time.Hour),
+			expected: SilenceStatusPending,
+		},
+		{
+			name:     "recurring silence after its end",
+			schedule: &Schedule{Timezone: "UTC", Recurrence: daily},
+			ts:       now.AddDate(0, 1, 0),
+			expected: SilenceStatusExpired,
+		},
+	}
+
+	for _, c := range cases {
+		silence := &Silence{Matchers: LabelMatchers{{Key: "service", Op: MatchOpEqual, Value: "api"}}, Comment: "known issue", Schedule: c.schedule}
+		require.NoError(t, silence.Validate(), c.name)
+		require.Equal(t, c.expected, silence.state(c.ts), c.name)
+	}
+}
+
+func TestSilenceValidate(t *testing.T) {
+	now := time.Now()
+	matchers := LabelMatchers{{Key: "service", Op: MatchOpEqual, Value: "api"}}
+
+	require.ErrorIs(t, (&Silence{Comment: "known issue", Schedule: &Schedule{Timezone: "UTC", StartTime: now, EndTime: now.Add(time.Hour)}}).Validate(), ErrMissingMatchers)
+	require.ErrorIs(t, (&Silence{Matchers: matchers, Schedule: &Schedule{Timezone: "UTC", StartTime: now, EndTime: now.Add(time.Hour)}}).Validate(), ErrMissingComment)
+	require.ErrorIs(t, (&Silence{Matchers: matchers, Comment: "known issue", Schedule: &Schedule{Timezone: "UTC", StartTime: now}}).Validate(), ErrMissingExpiry)
+	require.ErrorIs(t, (&Silence{Matchers: matchers, Comment: "known issue", Schedule: &Schedule{Timezone: "UTC", Recurrence: &Recurrence{
+		StartTime: now, Duration: Duration(time.Hour), RepeatType: RepeatTypeDaily,
+	}}}).Validate(), ErrMissingExpiry)
+	require.Error(t, (&Silence{Matchers: LabelMatchers{{Key: "service", Op: MatchOpRegexp, Value: "("}}, Comment: "known issue", Schedule: &Schedule{Timezone: "UTC", StartTime: now, EndTime: now.Add(time.Hour)}}).Validate())
+}
+
+func TestSilenceAlerts(t *testing.T) {
+	now := time.Now()
+	silences := []Silence{
+		{Id: 1, Matchers: LabelMatchers{{Key: "service", Op: MatchOpEqual, Value: "api"}}, Comment: "known issue", Schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}},
+		{Id: 2, Matchers: LabelMatchers{{Key: "env", Op: MatchOpRegexp, Value: "stag.*"}}, Comment: "staging", Schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}},
+		{Id: 3, Matchers: LabelMatchers{{Key: "service", Op: MatchOpEqual, Value: "web"}}, Comment: "expired", Schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(-2 * time.Hour), EndTime: now.Add(-time.Hour)}},
+	}
+	active := activeSilences(silences, now)
+	require.Len(t, active, 2)
+
+	api := &Alert{Labels: labels.FromMap(map[string]string{"service": "api", "env": "staging"})}
+	web := &Alert{Labels: labels.FromMap(map[string]string{"service": "web", "env": "production"})}
+	sent := silenceAlerts(active, []*Alert{api, web})
+	require.Equal(t, []*Alert{web}, sent)
+	require.Equal(t, "1,2", api.Annotations.Get(labels.AlertSilencedByLabel))
+	require.Nil(t, web.Annotations)
+
+	// an expired silence mutes nothing
+	silences[0].expire(now)
+	silences[1].expire(now)
+	require.Equal(t, SilenceStatusExpired, silences[0].state(now.Add(time.Second)))
+	require.Empty(t, activeSilences(silences, now.Add(time.Second)))
+}
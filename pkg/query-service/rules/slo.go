@@ -0,0 +1,424 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidObjective  = errors.New("objective must be between 0 and 100 (exclusive)")
+	ErrInvalidSLOWindow  = errors.New("window must be between 1 and 90 days")
+	ErrMissingSLIQuery   = errors.New("missing sli good or total query")
+	ErrMissingSLIWindow  = errors.New("sli queries must use the {{.window}} placeholder")
+	ErrSLOQueriesMissing = errors.New("promql engine is not available")
+)
+
+const (
+	// sliWindowPlaceholder is replaced with the range of the burn rate
+	// window in the sli queries, e.g. rate(signoz_calls_total[{{.window}}])
+	sliWindowPlaceholder = "{{.window}}"
+
+	defaultSLOWindowDays = 30
+	maxSLOWindowDays     = 90
+)
+
+// SLI is the ratio of the good events to the total events, both
+// queries are promql expressions returning a single series
+type SLI struct {
+	GoodQuery  string `json:"goodQuery"`
+	TotalQuery string `json:"totalQuery"`
+}
+
+// SLO tracks the share of good events against the objective over
+// a rolling window
+type SLO struct {
+	Id          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Objective is the target percentage of good events, e.g. 99.9
+	Objective  float64           `json:"objective"`
+	WindowDays int               `json:"windowDays"`
+	SLI        SLI               `json:"sli"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	// AlertIds are the ids of the burn rate alerts generated for the slo
+	AlertIds  []string  `json:"alertIds,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	CreatedBy string    `json:"createdBy"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	UpdatedBy string    `json:"updatedBy"`
+}
+
+// StoredSLO is the slo as stored in the db
+type StoredSLO struct {
+	Id        int64     `db:"id"`
+	Name      string    `db:"name"`
+	Data      string    `db:"data"`
+	CreatedAt time.Time `db:"created_at"`
+	CreatedBy string    `db:"created_by"`
+	UpdatedAt time.Time `db:"updated_at"`
+	UpdatedBy string    `db:"updated_by"`
+	OrgId     string    `db:"org_id"`
+}
+
+func (s *StoredSLO) parse() (*SLO, error) {
+	slo := &SLO{}
+	if err := json.Unmarshal([]byte(s.Data), slo); err != nil {
+		return nil, err
+	}
+	slo.Id = s.Id
+	slo.Name = s.Name
+	slo.CreatedAt = s.CreatedAt
+	slo.CreatedBy = s.CreatedBy
+	slo.UpdatedAt = s.UpdatedAt
+	slo.UpdatedBy = s.UpdatedBy
+	return slo, nil
+}
+
+func (s *SLO) Validate() error {
+	if s.Name == "" {
+		return ErrMissingName
+	}
+	if s.Objective <= 0 || s.Objective >= 100 {
+		return ErrInvalidObjective
+	}
+	if s.WindowDays == 0 {
+		s.WindowDays = defaultSLOWindowDays
+	}
+	if s.WindowDays < 0 || s.WindowDays > maxSLOWindowDays {
+		return ErrInvalidSLOWindow
+	}
+	if s.SLI.GoodQuery == "" || s.SLI.TotalQuery == "" {
+		return ErrMissingSLIQuery
+	}
+	if !strings.Contains(s.SLI.GoodQuery, sliWindowPlaceholder) || !strings.Contains(s.SLI.TotalQuery, sliWindowPlaceholder) {
+		return ErrMissingSLIWindow
+	}
+	for k, v := range s.Labels {
+		if !isValidLabelName(k) {
+			return errors.Errorf("invalid label name: %s", k)
+		}
+		if !isValidLabelValue(v) {
+			return errors.Errorf("invalid label value: %s", v)
+		}
+	}
+	return nil
+}
+
+// Window returns the rolling window of the slo
+func (s *SLO) Window() time.Duration {
+	return time.Duration(s.WindowDays) * 24 * time.Hour
+}
+
+// ErrorBudget is the allowed ratio of bad events
+func (s *SLO) ErrorBudget() float64 {
+	return 1 - s.Objective/100
+}
+
+// errorRatioQuery returns the promql expression of the ratio of bad
+// events over the given window
+func (s *SLO) errorRatioQuery(window time.Duration) string {
+	w := formatPromDuration(window)
+	good := strings.ReplaceAll(s.SLI.GoodQuery, sliWindowPlaceholder, w)
+	total := strings.ReplaceAll(s.SLI.TotalQuery, sliWindowPlaceholder, w)
+	return fmt.Sprintf("(1 - ((%s) / (%s)))", good, total)
+}
+
+// burnRateQuery returns the promql expression of the burn rate over the
+// given window, a burn rate of 1 consumes the budget exactly at the end
+// of the slo window
+func (s *SLO) burnRateQuery(window time.Duration) string {
+	return fmt.Sprintf("(%s / %v)", s.errorRatioQuery(window), s.ErrorBudget())
+}
+
+func formatPromDuration(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
+
+// burnRateAlertWindow is a multi-window burn rate alert, the alert fires
+// when the burn rate is above the threshold over both windows
+type burnRateAlertWindow struct {
+	severity    string
+	longWindow  time.Duration
+	shortWindow time.Duration
+	// burnRate is the threshold for a 30 day window, it is scaled
+	// to the window of the slo
+	burnRate float64
+}
+
+// burnRateAlertWindows are the multi-window multi-burn-rate alerts
+// recommended by the google sre workbook
+var burnRateAlertWindows = []burnRateAlertWindow{
+	{severity: "critical", longWindow: time.Hour, shortWindow: 5 * time.Minute, burnRate: 14.4},
+	{severity: "critical", longWindow: 6 * time.Hour, shortWindow: 30 * time.Minute, burnRate: 6},
+	{severity: "warning", longWindow: 24 * time.Hour, shortWindow: 2 * time.Hour, burnRate: 3},
+	{severity: "warning", longWindow: 3 * 24 * time.Hour, shortWindow: 6 * time.Hour, burnRate: 1},
+}
+
+// burnRateAlertRules returns the burn rate alerts of the slo, the burn
+// rate thresholds are scaled so that each alert fires on the same share
+// of the error budget regardless of the slo window
+func (s *SLO) burnRateAlertRules() []*PostableRule {
+	scale := float64(s.WindowDays) / defaultSLOWindowDays
+
+	alerts := make([]*PostableRule, 0, len(burnRateAlertWindows))
+	for _, w := range burnRateAlertWindows {
+		threshold := w.burnRate * scale
+		query := fmt.Sprintf("%s and (%s > %v)", s.burnRateQuery(w.longWindow), s.burnRateQuery(w.shortWindow), threshold)
+
+		labels := map[string]string{}
+		for k, v := range s.Labels {
+			labels[k] = v
+		}
+		labels["severity"] = w.severity
+		labels["slo_id"] = fmt.Sprintf("%d", s.Id)
+
+		alerts = append(alerts, &PostableRule{
+			AlertName:   fmt.Sprintf("%s burn rate over %s", s.Name, formatPromDuration(w.longWindow)),
+			AlertType:   "METRIC_BASED_ALERT",
+			Description: fmt.Sprintf("Error budget of SLO %s is burning %v times faster than allowed over the last %s and %s", s.Name, threshold, formatPromDuration(w.longWindow), formatPromDuration(w.shortWindow)),
+			RuleType:    RuleTypeProm,
+			EvalWindow:  Duration(5 * time.Minute),
+			Frequency:   Duration(time.Minute),
+			RuleCondition: &RuleCondition{
+				CompositeQuery: promCompositeQuery(query),
+				CompareOp:      ValueIsAbove,
+				Target:         &threshold,
+				MatchType:      AtleastOnce,
+				SelectedQuery:  "A",
+			},
+			Labels: labels,
+			Annotations: map[string]string{
+				"description": "Burn rate of SLO {{$labels.slo_id}} is {{$value}}",
+			},
+			Version: "v4",
+		})
+	}
+	return alerts
+}
+
+// BurnRate is the burn rate of the error budget over a window
+type BurnRate struct {
+	Window   string   `json:"window"`
+	BurnRate *float64 `json:"burnRate"`
+}
+
+// SLOStatus is the state of the error budget over the slo window
+type SLOStatus struct {
+	SLOId     int64   `json:"sloId"`
+	Objective float64 `json:"objective"`
+	Window    string  `json:"window"`
+	// SLI is the percentage of good events over the window, it is
+	// unset when there were no events
+	SLI *float64 `json:"sli"`
+	// ErrorBudget is the allowed ratio of bad events
+	ErrorBudget float64 `json:"errorBudget"`
+	// BudgetConsumed is the consumed share of the error budget,
+	// it exceeds 1 once the slo is breached
+	BudgetConsumed  *float64   `json:"budgetConsumed"`
+	BudgetRemaining *float64   `json:"budgetRemaining"`
+	Breached        bool       `json:"breached"`
+	BurnRates       []BurnRate `json:"burnRates"`
+}
+
+// evalInstant evaluates the promql expression at ts, on the data of the org
+// of the user, and returns the value of the single series of the result
+func (m *Manager) evalInstant(ctx context.Context, expr string, ts time.Time) (*float64, error) {
+	if m.opts.Queriers == nil || m.opts.Queriers.PqlEngine == nil {
+		return nil, ErrSLOQueriesMissing
+	}
+	scope, apiErr := common.OrgScope(ctx)
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	expr, err := common.ScopePromQL(expr, scope)
+	if err != nil {
+		return nil, err
+	}
+	res, err := m.opts.Queriers.PqlEngine.RunAlertQuery(ctx, expr, ts, ts, time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	for _, series := range res {
+		if len(series.Floats) == 0 {
+			continue
+		}
+		v := series.Floats[len(series.Floats)-1].F
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, nil
+		}
+		return &v, nil
+	}
+	return nil, nil
+}
+
+// SLOBurnRates computes the burn rates of the slo over the given windows
+func (m *Manager) SLOBurnRates(ctx context.Context, slo *SLO, windows []time.Duration, ts time.Time) ([]BurnRate, error) {
+	burnRates := make([]BurnRate, 0, len(windows))
+	for _, w := range windows {
+		v, err := m.evalInstant(ctx, slo.burnRateQuery(w), ts)
+		if err != nil {
+			return nil, err
+		}
+		burnRates = append(burnRates, BurnRate{Window: formatPromDuration(w), BurnRate: v})
+	}
+	return burnRates, nil
+}
+
+// SLOStatus computes the error budget of the slo over its window
+func (m *Manager) SLOStatus(ctx context.Context, slo *SLO, ts time.Time) (*SLOStatus, error) {
+	status := &SLOStatus{
+		SLOId:       slo.Id,
+		Objective:   slo.Objective,
+		Window:      formatPromDuration(slo.Window()),
+		ErrorBudget: slo.ErrorBudget(),
+	}
+
+	errorRatio, err := m.evalInstant(ctx, slo.errorRatioQuery(slo.Window()), ts)
+	if err != nil {
+		return nil, err
+	}
+	if errorRatio != nil {
+		sli := (1 - *errorRatio) * 100
+		consumed := *errorRatio / status.ErrorBudget
+		remaining := 1 - consumed
+		status.SLI = &sli
+		status.BudgetConsumed = &consumed
+		status.BudgetRemaining = &remaining
+		status.Breached = consumed > 1
+	}
+
+	windows, _ := ParseBurnRateWindows("")
+	status.BurnRates, err = m.SLOBurnRates(ctx, slo, windows, ts)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// CreateSLO stores the slo
+func (m *Manager) CreateSLO(ctx context.Context, slo *SLO) (*SLO, error) {
+	if err := slo.Validate(); err != nil {
+		return nil, err
+	}
+	slo.AlertIds = nil
+
+	id, err := m.ruleDB.CreateSLO(ctx, slo)
+	if err != nil {
+		return nil, err
+	}
+	slo.Id = id
+	return slo, nil
+}
+
+// EditSLO updates the slo, the generated burn rate alerts
+// are regenerated to follow the new objective
+func (m *Manager) EditSLO(ctx context.Context, slo *SLO, id string) (*SLO, error) {
+	existing, err := m.ruleDB.GetSLO(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := slo.Validate(); err != nil {
+		return nil, err
+	}
+
+	slo.Id = existing.Id
+	slo.CreatedAt = existing.CreatedAt
+	slo.CreatedBy = existing.CreatedBy
+	slo.AlertIds = existing.AlertIds
+	if err := m.ruleDB.EditSLO(ctx, slo, id); err != nil {
+		return nil, err
+	}
+
+	if len(slo.AlertIds) > 0 {
+		return m.GenerateSLOAlerts(ctx, id)
+	}
+	return slo, nil
+}
+
+// DeleteSLO deletes the slo along with its burn rate alerts
+func (m *Manager) DeleteSLO(ctx context.Context, id string) error {
+	existing, err := m.ruleDB.GetSLO(ctx, id)
+	if err != nil {
+		return err
+	}
+	m.deleteSLOAlerts(ctx, existing)
+	return m.ruleDB.DeleteSLO(ctx, id)
+}
+
+func (m *Manager) deleteSLOAlerts(ctx context.Context, slo *SLO) {
+	for _, alertId := range slo.AlertIds {
+		if err := m.DeleteRule(ctx, alertId); err != nil {
+			zap.L().Error("failed to delete slo burn rate alert", zap.Int64("slo", slo.Id), zap.String("rule", alertId), zap.Error(err))
+		}
+	}
+	slo.AlertIds = nil
+}
+
+// GenerateSLOAlerts replaces the burn rate alerts of the slo with
+// the multi-window multi-burn-rate alerts for its current objective
+func (m *Manager) GenerateSLOAlerts(ctx context.Context, id string) (*SLO, error) {
+	slo, err := m.ruleDB.GetSLO(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	m.deleteSLOAlerts(ctx, slo)
+
+	for _, alert := range slo.burnRateAlertRules() {
+		data, err := json.Marshal(alert)
+		if err != nil {
+			return nil, err
+		}
+		created, err := m.CreateRule(ctx, string(data))
+		if err != nil {
+			// keep track of the alerts created so far
+			if editErr := m.ruleDB.EditSLO(ctx, slo, id); editErr != nil {
+				zap.L().Error("failed to update slo alerts", zap.Int64("slo", slo.Id), zap.Error(editErr))
+			}
+			return nil, err
+		}
+		slo.AlertIds = append(slo.AlertIds, created.Id)
+	}
+
+	if err := m.ruleDB.EditSLO(ctx, slo, id); err != nil {
+		return nil, err
+	}
+	return slo, nil
+}
+
+// ParseBurnRateWindows parses the comma separated prometheus durations,
+// the windows of the burn rate alerts are returned when s is empty
+func ParseBurnRateWindows(s string) ([]time.Duration, error) {
+	var windows []time.Duration
+	if s == "" {
+		for _, w := range burnRateAlertWindows {
+			windows = append(windows, w.shortWindow, w.longWindow)
+		}
+		return windows, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		w, err := parsePromDuration(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		if w < time.Minute {
+			return nil, errors.Errorf("burn rate window must be at least 1m: %s", part)
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
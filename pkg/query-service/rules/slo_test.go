@@ -0,0 +1,111 @@
+package rules
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+func testSLO() *SLO {
+	return &SLO{
+		Id:        7,
+		Name:      "checkout availability",
+		Objective: 99.9,
+		SLI: SLI{
+			GoodQuery:  `sum(rate(signoz_calls_total{service_name="checkout",status_code!="STATUS_CODE_ERROR"}[{{.window}}]))`,
+			TotalQuery: `sum(rate(signoz_calls_total{service_name="checkout"}[{{.window}}]))`,
+		},
+	}
+}
+
+func TestSLOValidate(t *testing.T) {
+	slo := testSLO()
+	if err := slo.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if slo.WindowDays != defaultSLOWindowDays {
+		t.Errorf("expected default window, got %d", slo.WindowDays)
+	}
+
+	invalid := testSLO()
+	invalid.Objective = 100
+	if err := invalid.Validate(); err != ErrInvalidObjective {
+		t.Errorf("expected invalid objective, got %v", err)
+	}
+
+	invalid = testSLO()
+	invalid.SLI.GoodQuery = "sum(rate(signoz_calls_total[5m]))"
+	if err := invalid.Validate(); err != ErrMissingSLIWindow {
+		t.Errorf("expected missing window placeholder, got %v", err)
+	}
+}
+
+func TestSLOBurnRateQuery(t *testing.T) {
+	slo := testSLO()
+	if math.Abs(slo.ErrorBudget()-0.001) > 1e-9 {
+		t.Errorf("unexpected error budget %v", slo.ErrorBudget())
+	}
+
+	query := slo.burnRateQuery(time.Hour)
+	if !strings.Contains(query, "[1h]") || strings.Contains(query, sliWindowPlaceholder) {
+		t.Errorf("expected the window to be replaced, got %s", query)
+	}
+	if _, err := parser.ParseExpr(query); err != nil {
+		t.Errorf("invalid burn rate query %s: %v", query, err)
+	}
+}
+
+func TestSLOBurnRateAlertRules(t *testing.T) {
+	slo := testSLO()
+	slo.WindowDays = 30
+
+	alerts := slo.burnRateAlertRules()
+	if len(alerts) != len(burnRateAlertWindows) {
+		t.Fatalf("expected %d alerts, got %d", len(burnRateAlertWindows), len(alerts))
+	}
+	for _, alert := range alerts {
+		if errs := alert.Validate(); len(errs) > 0 {
+			t.Errorf("invalid alert %s: %v", alert.AlertName, errs)
+		}
+		query := alert.RuleCondition.CompositeQuery.PromQueries["A"].Query
+		if _, err := parser.ParseExpr(query); err != nil {
+			t.Errorf("invalid alert query %s: %v", query, err)
+		}
+		if alert.Labels["slo_id"] != "7" {
+			t.Errorf("expected slo id label, got %v", alert.Labels)
+		}
+	}
+	if *alerts[0].RuleCondition.Target != 14.4 {
+		t.Errorf("expected fast burn threshold, got %v", *alerts[0].RuleCondition.Target)
+	}
+
+	// the thresholds are scaled with the window of the slo
+	slo.WindowDays = 7
+	alerts = slo.burnRateAlertRules()
+	if math.Abs(*alerts[0].RuleCondition.Target-14.4*7/30) > 1e-9 {
+		t.Errorf("expected scaled threshold, got %v", *alerts[0].RuleCondition.Target)
+	}
+}
+
+func TestParseBurnRateWindows(t *testing.T) {
+	windows, err := ParseBurnRateWindows("5m, 1h,1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []time.Duration{5 * time.Minute, time.Hour, 24 * time.Hour}
+	for i, w := range expected {
+		if windows[i] != w {
+			t.Errorf("expected %v, got %v", w, windows[i])
+		}
+	}
+
+	if _, err := ParseBurnRateWindows("30s"); err == nil {
+		t.Error("expected error for window below 1m")
+	}
+	if windows, _ := ParseBurnRateWindows(""); len(windows) != 2*len(burnRateAlertWindows) {
+		t.Errorf("expected default windows, got %v", windows)
+	}
+}
@@ -0,0 +1,86 @@
+package rules
+
+import (
+	"context"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// StateChange is a transition of an alert of a rule, an alert leaving the active alerts
+// of its rule goes to the inactive state
+type StateChange struct {
+	RuleId   string
+	RuleName string
+	Labels   labels.BaseLabels
+	From     AlertState
+	To       AlertState
+	Value    float64
+	// Duration is how long the alert was in the state it leaves
+	Duration time.Duration
+	At       time.Time
+}
+
+// Resolved tells whether the alert stopped firing
+func (c StateChange) Resolved() bool {
+	return c.From == StateFiring && c.To == StateInactive
+}
+
+// StateListener is called with the state changes of the alerts of a rule after each of
+// its evaluations. The listener must not block the evaluation of the rules
+type StateListener func(ctx context.Context, changes ...StateChange)
+
+// snapshotAlerts copies the active alerts of a rule, the evaluation updates the state and
+// the timestamps of the alerts it keeps in place so the alerts before an evaluation are
+// compared by value
+func snapshotAlerts(alerts []*Alert) []*Alert {
+	out := make([]*Alert, 0, len(alerts))
+	for _, a := range alerts {
+		c := *a
+		out = append(out, &c)
+	}
+	return out
+}
+
+// stateChanges compares the active alerts of the rule before and after an evaluation at ts
+func stateChanges(rule Rule, before, after []*Alert, ts time.Time) []StateChange {
+	previous := make(map[uint64]*Alert, len(before))
+	for _, a := range before {
+		previous[a.Labels.Hash()] = a
+	}
+
+	var changes []StateChange
+	change := func(a *Alert, from, to AlertState, since time.Time) {
+		c := StateChange{RuleId: rule.ID(), RuleName: rule.Name(), Labels: a.Labels, From: from, To: to, Value: a.Value, At: ts}
+		if !since.IsZero() && ts.After(since) {
+			c.Duration = ts.Sub(since)
+		}
+		changes = append(changes, c)
+	}
+
+	for _, a := range after {
+		h := a.Labels.Hash()
+		prev, ok := previous[h]
+		delete(previous, h)
+		switch {
+		case !ok:
+			change(a, StateInactive, a.State, time.Time{})
+		case prev.State != a.State:
+			change(a, prev.State, a.State, sinceState(prev))
+		}
+	}
+	for _, prev := range previous {
+		if prev.State != StateInactive {
+			change(prev, prev.State, StateInactive, sinceState(prev))
+		}
+	}
+	return changes
+}
+
+// sinceState returns when the alert entered its current state
+func sinceState(a *Alert) time.Time {
+	if a.State == StateFiring && !a.FiredAt.IsZero() {
+		return a.FiredAt
+	}
+	return a.ActiveAt
+}
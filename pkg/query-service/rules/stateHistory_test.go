@@ -0,0 +1,90 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+type maintenanceRuleDB struct {
+	RuleDB
+}
+
+func (db *maintenanceRuleDB) GetAllPlannedMaintenance(ctx context.Context) ([]PlannedMaintenance, error) {
+	return nil, nil
+}
+
+func TestRuleTaskStateChanges(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	target := 10.0
+	noDelay := Duration(0)
+
+	// the value is above the threshold for the first 3 minutes
+	reader := &previewReader{series: func(end time.Time) []*v3.Series {
+		v := 5.0
+		if end.Before(at(3)) {
+			v = 20
+		}
+		return []*v3.Series{{Labels: map[string]string{"service.name": "frontend"}, Points: []v3.Point{{Timestamp: 1, Value: v}}}}
+	}}
+
+	postableRule := PostableRule{
+		AlertName: "State Changes",
+		AlertType: "METRIC_BASED_ALERT",
+		RuleType:  RuleTypeThreshold,
+		Frequency: Duration(time.Minute),
+		EvalDelay: &noDelay,
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeClickHouseSQL,
+				PanelType: v3.PanelTypeGraph,
+				ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+					"A": {Query: "SELECT {{.end_timestamp_ms}}"},
+				},
+			},
+			CompareOp:     ValueIsAbove,
+			MatchType:     AtleastOnce,
+			Target:        &target,
+			SelectedQuery: "A",
+		},
+	}
+	rule, err := NewThresholdRule("history", &postableRule, ThresholdRuleOpts{}, featureManager.StartManager(), reader)
+	require.NoError(t, err)
+	rule.holdDuration = 2 * time.Minute
+
+	var changes []StateChange
+	opts := &ManagerOptions{Queriers: &Queriers{}}
+	opts.onStateChange = func(ctx context.Context, c ...StateChange) {
+		changes = append(changes, c...)
+	}
+	task := newRuleTask("history", "", time.Minute, []Rule{rule}, opts, func(ctx context.Context, expr string, alerts ...*Alert) {}, &maintenanceRuleDB{})
+
+	for m := 0; m <= 4; m++ {
+		task.Eval(context.Background(), at(m))
+	}
+
+	require.Len(t, changes, 3)
+	for _, c := range changes {
+		require.Equal(t, "history", c.RuleId)
+		require.Equal(t, "State Changes", c.RuleName)
+		require.Equal(t, "frontend", c.Labels.Get("service_name"))
+	}
+
+	require.Equal(t, StateInactive, changes[0].From)
+	require.Equal(t, StatePending, changes[0].To)
+	require.Equal(t, at(0), changes[0].At)
+
+	require.Equal(t, StatePending, changes[1].From)
+	require.Equal(t, StateFiring, changes[1].To)
+	require.Equal(t, at(2), changes[1].At)
+	require.Equal(t, 2*time.Minute, changes[1].Duration)
+
+	require.True(t, changes[2].Resolved())
+	require.Equal(t, at(3), changes[2].At)
+	require.Equal(t, time.Minute, changes[2].Duration)
+}
@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"regexp"
 	"sort"
+	"strconv"
 	"sync"
 	"text/template"
 	"time"
@@ -27,6 +28,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
 	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 	querytemplate "go.signoz.io/signoz/pkg/query-service/utils/queryTemplate"
@@ -45,6 +47,7 @@ type ThresholdRule struct {
 	source        string
 	ruleCondition *RuleCondition
 	evalWindow    time.Duration
+	evalDelay     time.Duration
 	holdDuration  time.Duration
 	labels        labels.Labels
 	annotations   labels.Labels
@@ -86,6 +89,9 @@ type ThresholdRuleOpts struct {
 	// sendAlways will send alert irresepective of resendDelay
 	// or other params
 	SendAlways bool
+
+	// OrgScope restricts the builder queries of the rule to the data of its org
+	OrgScope *model.OrgScope
 }
 
 func NewThresholdRule(
@@ -108,6 +114,7 @@ func NewThresholdRule(
 		source:            p.Source,
 		ruleCondition:     p.RuleCondition,
 		evalWindow:        time.Duration(p.EvalWindow),
+		evalDelay:         evalDelay(p, defaultThresholdEvalDelay),
 		labels:            labels.FromMap(p.Labels),
 		annotations:       labels.FromMap(p.Annotations),
 		preferredChannels: p.PreferredChannels,
@@ -123,6 +130,15 @@ func NewThresholdRule(
 		t.evalWindow = 5 * time.Minute
 	}
 
+	t.querier, t.querierV2 = newRuleQueriers(featureFlags, reader)
+
+	zap.L().Info("creating new ThresholdRule", zap.String("name", t.name), zap.String("id", t.id))
+
+	return &t, nil
+}
+
+// newRuleQueriers creates the uncached queriers used to evaluate the rule queries
+func newRuleQueriers(featureFlags interfaces.FeatureLookup, reader interfaces.Reader) (interfaces.Querier, interfaces.Querier) {
 	querierOption := querier.QuerierOptions{
 		Reader:        reader,
 		Cache:         nil,
@@ -137,12 +153,7 @@ func NewThresholdRule(
 		FeatureLookup: featureFlags,
 	}
 
-	t.querier = querier.NewQuerier(querierOption)
-	t.querierV2 = querierV2.NewQuerier(querierOptsV2)
-
-	zap.L().Info("creating new ThresholdRule", zap.String("name", t.name), zap.String("id", t.id))
-
-	return &t, nil
+	return querier.NewQuerier(querierOption), querierV2.NewQuerier(querierOptsV2)
 }
 
 func (r *ThresholdRule) Name() string {
@@ -169,16 +180,27 @@ func (r *ThresholdRule) PreferredChannels() []string {
 // when the y-axis and target units are non-empty, it
 // converts the target value to the y-axis unit
 func (r *ThresholdRule) targetVal() float64 {
+	// the threshold of a multi-condition rule is
+	// the threshold of its first condition
+	if r.ruleCondition.IsMultiCondition() && r.ruleCondition.Conditions[0].Target != nil {
+		first := r.ruleCondition.Conditions[0]
+		return r.convertTarget(*first.Target, first.TargetUnit)
+	}
 	if r.ruleCondition == nil || r.ruleCondition.Target == nil {
 		return 0
 	}
+	return r.convertTarget(*r.ruleCondition.Target, r.ruleCondition.TargetUnit)
+}
 
+// convertTarget converts the target value from the target unit
+// to the y-axis unit
+func (r *ThresholdRule) convertTarget(target float64, targetUnit string) float64 {
 	// get the converter for the target unit
-	unitConverter := converter.FromUnit(converter.Unit(r.ruleCondition.TargetUnit))
+	unitConverter := converter.FromUnit(converter.Unit(targetUnit))
 	// convert the target value to the y-axis unit
 	value := unitConverter.Convert(converter.Value{
-		F: *r.ruleCondition.Target,
-		U: converter.Unit(r.ruleCondition.TargetUnit),
+		F: target,
+		U: converter.Unit(targetUnit),
 	}, converter.Unit(r.Unit()))
 
 	return value.F
@@ -237,6 +259,11 @@ func (r *ThresholdRule) HoldDuration() time.Duration {
 	return r.holdDuration
 }
 
+// EvalDelay is how far behind the evaluation time the queries of the rule end
+func (r *ThresholdRule) EvalDelay() time.Duration {
+	return r.evalDelay
+}
+
 func (r *ThresholdRule) EvalWindow() time.Duration {
 	return r.evalWindow
 }
@@ -313,6 +340,9 @@ func (r *ThresholdRule) ActiveAlerts() []*Alert {
 func (r *ThresholdRule) FetchTemporality(ctx context.Context, metricNames []string, ch driver.Conn) (map[string]map[v3.Temporality]bool, error) {
 
 	metricNameToTemporality := make(map[string]map[v3.Temporality]bool)
+	if len(metricNames) == 0 {
+		return metricNameToTemporality, nil
+	}
 
 	query := fmt.Sprintf(`SELECT DISTINCT metric_name, temporality FROM %s.%s WHERE metric_name IN $1`, constants.SIGNOZ_METRIC_DBNAME, constants.SIGNOZ_TIMESERIES_v4_1DAY_TABLENAME)
 
@@ -431,11 +461,10 @@ func (r *ThresholdRule) Unit() string {
 
 func (r *ThresholdRule) prepareQueryRange(ts time.Time) *v3.QueryRangeParamsV3 {
 
-	// todo(srikanthccv): make this configurable
-	// 2 minutes is reasonable time to wait for data to be available
+	// the data of the last minutes may not be available yet, the default 2 minutes are
 	// 60 seconds (SDK) + 10 seconds (batch) + rest for n/w + serialization + write to disk etc..
-	start := ts.Add(-time.Duration(r.evalWindow)).UnixMilli() - 2*60*1000
-	end := ts.UnixMilli() - 2*60*1000
+	start := ts.Add(-time.Duration(r.evalWindow)).UnixMilli() - r.evalDelay.Milliseconds()
+	end := ts.UnixMilli() - r.evalDelay.Milliseconds()
 	// round to minute otherwise we could potentially miss data
 	start = start - (start % (60 * 1000))
 	end = end - (end % (60 * 1000))
@@ -497,12 +526,31 @@ func (r *ThresholdRule) prepareQueryRange(ts time.Time) *v3.QueryRangeParamsV3 {
 		r.ruleCondition.CompositeQuery.PanelType = v3.PanelTypeGraph
 	}
 
+	compositeQuery := r.ruleCondition.CompositeQuery
+	if r.opts.OrgScope != nil {
+		// the filter on the org is added to copies of the queries, the rule keeps its queries
+		scoped := *compositeQuery
+		scoped.BuilderQueries = make(map[string]*v3.BuilderQuery, len(compositeQuery.BuilderQueries))
+		for name, q := range compositeQuery.BuilderQueries {
+			query := *q
+			filters := &v3.FilterSet{Operator: "AND"}
+			if q.Filters != nil {
+				filters.Operator = q.Filters.Operator
+				filters.Items = append(filters.Items, q.Filters.Items...)
+			}
+			filters.Items = append(filters.Items, common.OrgFilter(q.DataSource, r.opts.OrgScope))
+			query.Filters = filters
+			scoped.BuilderQueries[name] = &query
+		}
+		compositeQuery = &scoped
+	}
+
 	// default mode
 	return &v3.QueryRangeParamsV3{
 		Start:          start,
 		End:            end,
 		Step:           int64(math.Max(float64(common.MinAllowedStepInterval(start, end)), 60)),
-		CompositeQuery: r.ruleCondition.CompositeQuery,
+		CompositeQuery: compositeQuery,
 		Variables:      make(map[string]interface{}, 0),
 		NoCache:        true,
 	}
@@ -719,6 +767,12 @@ func (r *ThresholdRule) GetSelectedQuery() string {
 			return r.ruleCondition.SelectedQuery
 		}
 
+		// for multi-condition rules the first condition drives
+		// the absent data check and the related links
+		if r.ruleCondition.IsMultiCondition() && r.ruleCondition.Conditions[0].SelectedQuery != "" {
+			return r.ruleCondition.Conditions[0].SelectedQuery
+		}
+
 		queryNames := map[string]struct{}{}
 
 		if r.ruleCondition.CompositeQuery != nil {
@@ -754,7 +808,9 @@ func (r *ThresholdRule) GetSelectedQuery() string {
 	return ""
 }
 
-func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time, ch clickhouse.Conn) (Vector, error) {
+// runQuery runs the composite query of the rule over the evaluation window
+// ending at ts and returns the post processed results
+func (r *ThresholdRule) runQuery(ctx context.Context, ts time.Time, ch clickhouse.Conn) ([]*v3.Result, error) {
 	if r.ruleCondition == nil || r.ruleCondition.CompositeQuery == nil {
 		r.SetHealth(HealthBad)
 		r.SetLastError(fmt.Errorf("no rule condition"))
@@ -802,6 +858,15 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time, ch c
 		}
 	}
 
+	return results, nil
+}
+
+func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time, ch clickhouse.Conn) (Vector, error) {
+	results, err := r.runQuery(ctx, ts, ch)
+	if err != nil {
+		return nil, err
+	}
+
 	selectedQuery := r.GetSelectedQuery()
 
 	var queryResult *v3.Result
@@ -813,13 +878,14 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time, ch c
 	}
 
 	if queryResult != nil && len(queryResult.Series) > 0 {
-		r.lastTimestampWithDatapoints = time.Now()
+		r.lastTimestampWithDatapoints = ts
 	}
 
 	var resultVector Vector
 
-	// if the data is missing for `For` duration then we should send alert
-	if r.ruleCondition.AlertOnAbsent && r.lastTimestampWithDatapoints.Add(time.Duration(r.Condition().AbsentFor)*time.Minute).Before(time.Now()) {
+	// if the data is missing for `For` duration then we should send alert, the evaluation
+	// timestamp is used rather than now for the previews over past windows
+	if r.ruleCondition.AlertOnAbsent && r.lastTimestampWithDatapoints.Add(time.Duration(r.Condition().AbsentFor)*time.Minute).Before(ts) {
 		zap.L().Info("no data found for rule condition", zap.String("ruleid", r.ID()))
 		lbls := labels.NewBuilder(labels.Labels{})
 		if !r.lastTimestampWithDatapoints.IsZero() {
@@ -832,6 +898,18 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time, ch c
 		return resultVector, nil
 	}
 
+	if r.ruleCondition.IsMultiCondition() {
+		return r.matchConditions(results), nil
+	}
+
+	if queryResult == nil {
+		return resultVector, nil
+	}
+
+	if r.matchType() == AsOutlier {
+		return r.matchOutliers(queryResult.Series, r.compareOp(), *r.ruleCondition.Target), nil
+	}
+
 	for _, series := range queryResult.Series {
 		smpl, shouldAlert := r.shouldAlert(*series)
 		if shouldAlert {
@@ -841,6 +919,104 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time, ch c
 	return resultVector, nil
 }
 
+// matchConditions evaluates each condition of a multi-condition rule against
+// the series of its query and combines the matches by label set.
+// A condition on a query that returns a single series without labels (no group by)
+// applies to every label set of the other conditions.
+func (r *ThresholdRule) matchConditions(results []*v3.Result) Vector {
+	resultsByName := make(map[string]*v3.Result, len(results))
+	for _, res := range results {
+		resultsByName[res.QueryName] = res
+	}
+
+	conditions := r.ruleCondition.Conditions
+	// matches holds the matching samples of each condition by label set hash
+	matches := make([]map[uint64]Sample, len(conditions))
+	// ungrouped tells whether the query of the condition has no group by
+	ungrouped := make([]bool, len(conditions))
+	for i, c := range conditions {
+		matches[i] = make(map[uint64]Sample)
+		res, ok := resultsByName[c.SelectedQuery]
+		if !ok || res == nil {
+			continue
+		}
+		ungrouped[i] = len(res.Series) == 1 && len(res.Series[0].Labels) == 0
+		if c.MatchType == AsOutlier {
+			for _, smpl := range r.matchOutliers(res.Series, c.CompareOp, *c.Target) {
+				matches[i][labelSetHash(smpl.Metric)] = smpl
+			}
+			continue
+		}
+		target := r.convertTarget(*c.Target, c.TargetUnit)
+		for _, series := range res.Series {
+			smpl, shouldAlert := r.matchSeries(*series, c.MatchType, c.CompareOp, target)
+			if !shouldAlert {
+				continue
+			}
+			matches[i][labelSetHash(smpl.Metric)] = smpl
+		}
+	}
+
+	// collect the candidate label sets, they are sorted by labels below so the alerts come
+	// in a stable order
+	var keys []uint64
+	samples := make(map[uint64]Sample)
+	for i := range conditions {
+		if ungrouped[i] && r.ruleCondition.ConditionOp != ConditionOpOr {
+			continue
+		}
+		for h, smpl := range matches[i] {
+			if _, ok := samples[h]; !ok {
+				samples[h] = smpl
+				keys = append(keys, h)
+			}
+		}
+	}
+	// every condition is ungrouped, evaluate them as a single label set
+	if len(keys) == 0 && r.ruleCondition.ConditionOp != ConditionOpOr {
+		for h, smpl := range matches[0] {
+			samples[h] = smpl
+			keys = append(keys, h)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if c := labels.Compare(samples[keys[i]].Metric, samples[keys[j]].Metric); c != 0 {
+			return c < 0
+		}
+		return keys[i] < keys[j]
+	})
+
+	var resultVector Vector
+	for _, h := range keys {
+		if r.ruleCondition.ConditionOp == ConditionOpOr {
+			resultVector = append(resultVector, samples[h])
+			continue
+		}
+		all := true
+		for i := range conditions {
+			if _, ok := matches[i][h]; ok {
+				continue
+			}
+			if ungrouped[i] && len(matches[i]) > 0 {
+				continue
+			}
+			all = false
+			break
+		}
+		if all {
+			resultVector = append(resultVector, samples[h])
+		}
+	}
+	return resultVector
+}
+
+// labelSetHash returns the hash of the label set independent of label order
+func labelSetHash(lbls labels.Labels) uint64 {
+	sorted := lbls.Copy()
+	sort.Sort(sorted)
+	return sorted.Hash()
+}
+
 func normalizeLabelName(name string) string {
 	// See https://prometheus.io/docs/concepts/data_model/#metric-names-and-labels
 
@@ -930,6 +1106,16 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time, queriers *Querie
 			annotations = append(annotations, labels.Label{Name: normalizeLabelName(a.Name), Value: expand(a.Value)})
 		}
 
+		if smpl.outlier != nil {
+			annotations = append(annotations,
+				labels.Label{Name: outlierDeviationAnnotation, Value: strconv.FormatFloat(smpl.outlier.deviation, 'f', 2, 64)},
+				labels.Label{Name: outlierPeersMeanAnnotation, Value: valueFormatter.Format(smpl.outlier.peersMean, r.Unit())})
+		}
+		// only the forecast matches carry a time, when the trend crosses the threshold
+		if smpl.T > 0 {
+			annotations = append(annotations, labels.Label{Name: forecastCrossingAnnotation, Value: time.UnixMilli(smpl.T).UTC().Format(constants.AlertTimeFormat)})
+		}
+
 		// Links with timestamps should go in annotations since labels
 		// is used alert grouping, and we want to group alerts with the same
 		// label set, but different timestamps, together.
@@ -1021,6 +1207,7 @@ func (r *ThresholdRule) String() string {
 		AlertName:         r.name,
 		RuleCondition:     r.ruleCondition,
 		EvalWindow:        Duration(r.evalWindow),
+		EvalDelay:         (*Duration)(&r.evalDelay),
 		Labels:            r.labels.Map(),
 		Annotations:       r.annotations.Map(),
 		PreferredChannels: r.preferredChannels,
@@ -1045,6 +1232,12 @@ func removeGroupinSetPoints(series v3.Series) []v3.Point {
 }
 
 func (r *ThresholdRule) shouldAlert(series v3.Series) (Sample, bool) {
+	return r.matchSeries(series, r.matchType(), r.compareOp(), r.targetVal())
+}
+
+// matchSeries evaluates the series against the given match type,
+// compare op and target
+func (r *ThresholdRule) matchSeries(series v3.Series, matchType MatchType, compareOp CompareOp, target float64) (Sample, bool) {
 	var alertSmpl Sample
 	var shouldAlert bool
 	var lbls labels.Labels
@@ -1062,36 +1255,36 @@ func (r *ThresholdRule) shouldAlert(series v3.Series) (Sample, bool) {
 		return alertSmpl, false
 	}
 
-	switch r.matchType() {
+	switch matchType {
 	case AtleastOnce:
 		// If any sample matches the condition, the rule is firing.
-		if r.compareOp() == ValueIsAbove {
+		if compareOp == ValueIsAbove {
 			for _, smpl := range series.Points {
-				if smpl.Value > r.targetVal() {
+				if smpl.Value > target {
 					alertSmpl = Sample{Point: Point{V: smpl.Value}, Metric: lblsNormalized, MetricOrig: lbls}
 					shouldAlert = true
 					break
 				}
 			}
-		} else if r.compareOp() == ValueIsBelow {
+		} else if compareOp == ValueIsBelow {
 			for _, smpl := range series.Points {
-				if smpl.Value < r.targetVal() {
+				if smpl.Value < target {
 					alertSmpl = Sample{Point: Point{V: smpl.Value}, Metric: lblsNormalized, MetricOrig: lbls}
 					shouldAlert = true
 					break
 				}
 			}
-		} else if r.compareOp() == ValueIsEq {
+		} else if compareOp == ValueIsEq {
 			for _, smpl := range series.Points {
-				if smpl.Value == r.targetVal() {
+				if smpl.Value == target {
 					alertSmpl = Sample{Point: Point{V: smpl.Value}, Metric: lblsNormalized, MetricOrig: lbls}
 					shouldAlert = true
 					break
 				}
 			}
-		} else if r.compareOp() == ValueIsNotEq {
+		} else if compareOp == ValueIsNotEq {
 			for _, smpl := range series.Points {
-				if smpl.Value != r.targetVal() {
+				if smpl.Value != target {
 					alertSmpl = Sample{Point: Point{V: smpl.Value}, Metric: lblsNormalized, MetricOrig: lbls}
 					shouldAlert = true
 					break
@@ -1101,31 +1294,31 @@ func (r *ThresholdRule) shouldAlert(series v3.Series) (Sample, bool) {
 	case AllTheTimes:
 		// If all samples match the condition, the rule is firing.
 		shouldAlert = true
-		alertSmpl = Sample{Point: Point{V: r.targetVal()}, Metric: lblsNormalized, MetricOrig: lbls}
-		if r.compareOp() == ValueIsAbove {
+		alertSmpl = Sample{Point: Point{V: target}, Metric: lblsNormalized, MetricOrig: lbls}
+		if compareOp == ValueIsAbove {
 			for _, smpl := range series.Points {
-				if smpl.Value <= r.targetVal() {
+				if smpl.Value <= target {
 					shouldAlert = false
 					break
 				}
 			}
-		} else if r.compareOp() == ValueIsBelow {
+		} else if compareOp == ValueIsBelow {
 			for _, smpl := range series.Points {
-				if smpl.Value >= r.targetVal() {
+				if smpl.Value >= target {
 					shouldAlert = false
 					break
 				}
 			}
-		} else if r.compareOp() == ValueIsEq {
+		} else if compareOp == ValueIsEq {
 			for _, smpl := range series.Points {
-				if smpl.Value != r.targetVal() {
+				if smpl.Value != target {
 					shouldAlert = false
 					break
 				}
 			}
-		} else if r.compareOp() == ValueIsNotEq {
+		} else if compareOp == ValueIsNotEq {
 			for _, smpl := range series.Points {
-				if smpl.Value == r.targetVal() {
+				if smpl.Value == target {
 					shouldAlert = false
 					break
 				}
@@ -1143,23 +1336,35 @@ func (r *ThresholdRule) shouldAlert(series v3.Series) (Sample, bool) {
 		}
 		avg := sum / count
 		alertSmpl = Sample{Point: Point{V: avg}, Metric: lblsNormalized, MetricOrig: lbls}
-		if r.compareOp() == ValueIsAbove {
-			if avg > r.targetVal() {
+		if compareOp == ValueIsAbove {
+			if avg > target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsBelow {
-			if avg < r.targetVal() {
+		} else if compareOp == ValueIsBelow {
+			if avg < target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsEq {
-			if avg == r.targetVal() {
+		} else if compareOp == ValueIsEq {
+			if avg == target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsNotEq {
-			if avg != r.targetVal() {
+		} else if compareOp == ValueIsNotEq {
+			if avg != target {
 				shouldAlert = true
 			}
 		}
+	case InForecast:
+		// If the trend of the samples crosses the target within the forecast window, the
+		// rule is firing. The sample holds the forecast value and the time of the crossing
+		ts := make([]int64, len(series.Points))
+		vs := make([]float64, len(series.Points))
+		for i, smpl := range series.Points {
+			ts[i], vs[i] = smpl.Timestamp, smpl.Value
+		}
+		var forecast float64
+		var crossAt int64
+		forecast, crossAt, shouldAlert = matchForecast(ts, vs, compareOp, target, time.Duration(r.ruleCondition.ForecastWindow))
+		alertSmpl = Sample{Point: Point{T: crossAt, V: forecast}, Metric: lblsNormalized, MetricOrig: lbls}
 	case InTotal:
 		// If the sum of all samples matches the condition, the rule is firing.
 		var sum float64
@@ -1171,20 +1376,20 @@ func (r *ThresholdRule) shouldAlert(series v3.Series) (Sample, bool) {
 			sum += smpl.Value
 		}
 		alertSmpl = Sample{Point: Point{V: sum}, Metric: lblsNormalized, MetricOrig: lbls}
-		if r.compareOp() == ValueIsAbove {
-			if sum > r.targetVal() {
+		if compareOp == ValueIsAbove {
+			if sum > target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsBelow {
-			if sum < r.targetVal() {
+		} else if compareOp == ValueIsBelow {
+			if sum < target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsEq {
-			if sum == r.targetVal() {
+		} else if compareOp == ValueIsEq {
+			if sum == target {
 				shouldAlert = true
 			}
-		} else if r.compareOp() == ValueIsNotEq {
-			if sum != r.targetVal() {
+		} else if compareOp == ValueIsNotEq {
+			if sum != target {
 				shouldAlert = true
 			}
 		}
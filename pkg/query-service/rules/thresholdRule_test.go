@@ -5,7 +5,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 )
@@ -856,7 +858,9 @@ func TestThresholdRuleClickHouseTmpl(t *testing.T) {
 	// 01:39:47
 	ts := time.Unix(1717205987, 0)
 
+	noDelay, longDelay := Duration(0), Duration(5*time.Minute)
 	cases := []struct {
+		evalDelay     *Duration
 		expectedQuery string
 	}{
 		// Test cases for Equals Always
@@ -864,10 +868,21 @@ func TestThresholdRuleClickHouseTmpl(t *testing.T) {
 			// 01:32:00 - 01:37:00
 			expectedQuery: "SELECT 1 >= 1717205520000 AND 1 <= 1717205820000",
 		},
+		{
+			// 01:34:00 - 01:39:00
+			evalDelay:     &noDelay,
+			expectedQuery: "SELECT 1 >= 1717205640000 AND 1 <= 1717205940000",
+		},
+		{
+			// 01:29:00 - 01:34:00
+			evalDelay:     &longDelay,
+			expectedQuery: "SELECT 1 >= 1717205340000 AND 1 <= 1717205640000",
+		},
 	}
 
 	fm := featureManager.StartManager()
 	for idx, c := range cases {
+		postableRule.EvalDelay = c.evalDelay
 		rule, err := NewThresholdRule("69", &postableRule, ThresholdRuleOpts{}, fm, nil)
 		if err != nil {
 			assert.NoError(t, err)
@@ -882,3 +897,135 @@ func TestThresholdRuleClickHouseTmpl(t *testing.T) {
 		assert.Equal(t, c.expectedQuery, secondTimeParams.CompositeQuery.ClickHouseQueries["A"].Query, "Test case %d", idx)
 	}
 }
+
+func TestThresholdRuleOrgScope(t *testing.T) {
+	target := 10.0
+	postableRule := PostableRule{
+		AlertName: "Org scope",
+		AlertType: "METRIC_BASED_ALERT",
+		RuleType:  RuleTypeThreshold,
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:  "A",
+						DataSource: v3.DataSourceMetrics,
+						Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+							{Key: v3.AttributeKey{Key: "service.name"}, Operator: v3.FilterOperatorEqual, Value: "frontend"},
+						}},
+					},
+				},
+			},
+			CompareOp:     ValueIsAbove,
+			MatchType:     AtleastOnce,
+			Target:        &target,
+			SelectedQuery: "A",
+		},
+	}
+
+	fm := featureManager.StartManager()
+	rule, err := NewThresholdRule("69", &postableRule, ThresholdRuleOpts{OrgScope: &model.OrgScope{OrgIds: []string{"org-b"}}}, fm, nil)
+	assert.NoError(t, err)
+
+	// the queries run by the rule read the data of its org only
+	for i := 0; i < 2; i++ {
+		params := rule.prepareQueryRange(time.Now())
+		items := params.CompositeQuery.BuilderQueries["A"].Filters.Items
+		assert.Len(t, items, 2)
+		assert.Equal(t, constants.OrgIdLabel, items[1].Key.Key)
+		assert.Equal(t, "org-b", items[1].Value)
+	}
+	// the query of the rule is not changed
+	assert.Len(t, postableRule.RuleCondition.CompositeQuery.BuilderQueries["A"].Filters.Items, 1)
+}
+
+func TestThresholdRuleMultiCondition(t *testing.T) {
+	errorRate := 5.0
+	requestRate := 100.0
+	postableRule := PostableRule{
+		AlertName:  "Multi Condition Tests",
+		AlertType:  "METRIC_BASED_ALERT",
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:          "A",
+						StepInterval:       60,
+						AggregateAttribute: v3.AttributeKey{Key: "error_rate"},
+						AggregateOperator:  v3.AggregateOperatorNoOp,
+						DataSource:         v3.DataSourceMetrics,
+						Expression:         "A",
+					},
+					"B": {
+						QueryName:          "B",
+						StepInterval:       60,
+						AggregateAttribute: v3.AttributeKey{Key: "request_rate"},
+						AggregateOperator:  v3.AggregateOperatorNoOp,
+						DataSource:         v3.DataSourceMetrics,
+						Expression:         "B",
+					},
+				},
+			},
+			Conditions: []*QueryCondition{
+				{SelectedQuery: "A", CompareOp: ValueIsAbove, MatchType: AtleastOnce, Target: &errorRate},
+				{SelectedQuery: "B", CompareOp: ValueIsAbove, MatchType: AtleastOnce, Target: &requestRate},
+			},
+		},
+	}
+
+	series := func(service string, value float64) *v3.Series {
+		return &v3.Series{
+			Labels: map[string]string{"service.name": service},
+			Points: []v3.Point{{Timestamp: time.Now().UnixMilli(), Value: value}},
+		}
+	}
+
+	results := []*v3.Result{
+		{QueryName: "A", Series: []*v3.Series{series("frontend", 10), series("cart", 10), series("payment", 1)}},
+		{QueryName: "B", Series: []*v3.Series{series("frontend", 500), series("cart", 10), series("payment", 500)}},
+	}
+
+	cases := []struct {
+		op       ConditionOp
+		expected []string
+	}{
+		{op: ConditionOpAnd, expected: []string{"frontend"}},
+		{op: ConditionOpOr, expected: []string{"cart", "frontend", "payment"}},
+	}
+
+	fm := featureManager.StartManager()
+	for idx, c := range cases {
+		postableRule.RuleCondition.ConditionOp = c.op
+		assert.Empty(t, postableRule.Validate(), "Test case %d", idx)
+
+		rule, err := NewThresholdRule("69", &postableRule, ThresholdRuleOpts{}, fm, nil)
+		assert.NoError(t, err)
+
+		vector := rule.matchConditions(results)
+		services := []string{}
+		for _, smpl := range vector {
+			services = append(services, smpl.Metric.Get("service_name"))
+		}
+		// the alerts are sorted by labels
+		assert.Equal(t, c.expected, services, "Test case %d", idx)
+	}
+
+	// a condition without group by applies to every label set
+	results = []*v3.Result{
+		{QueryName: "A", Series: []*v3.Series{series("frontend", 10), series("cart", 10)}},
+		{QueryName: "B", Series: []*v3.Series{{Points: []v3.Point{{Timestamp: time.Now().UnixMilli(), Value: 500}}}}},
+	}
+	postableRule.RuleCondition.ConditionOp = ConditionOpAnd
+	rule, err := NewThresholdRule("69", &postableRule, ThresholdRuleOpts{}, fm, nil)
+	assert.NoError(t, err)
+	assert.Len(t, rule.matchConditions(results), 2)
+
+	// conditions must refer to known queries
+	postableRule.RuleCondition.Conditions[1].SelectedQuery = "C"
+	assert.NotEmpty(t, postableRule.Validate())
+}
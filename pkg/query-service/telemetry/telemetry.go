@@ -291,7 +291,7 @@ func createTelemetry() {
 		if err == nil {
 			dashboardsInfo, err := telemetry.reader.GetDashboardsInfo(ctx)
 			if err == nil {
-				channels, err := telemetry.reader.GetChannels()
+				channels, err := telemetry.reader.GetChannels(ctx)
 				if err == nil {
 					savedViewsInfo, err := telemetry.reader.GetSavedViewsInfo(ctx)
 					if err == nil {
@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/client"
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/app"
+	"go.signoz.io/signoz/pkg/query-service/app/access"
+	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
+	"go.signoz.io/signoz/pkg/query-service/app/integrations"
+	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/app/share"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/dao"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+// NewClientTestBed serves the routes of the query service and returns a client of the test
+// user of the API
+func NewClientTestBed(t *testing.T) *client.Client {
+	url, token := NewQueryServiceTestServer(t)
+	return client.New(url, client.WithToken(token))
+}
+
+// NewQueryServiceTestServer serves the routes of the query service, it returns the URL of
+// the server and the access token of the test user
+func NewQueryServiceTestServer(t *testing.T) (string, string) {
+	testDB := utils.NewQueryServiceDBForTests(t)
+	require.NoError(t, share.InitWithDB(testDB), "could not init the share links")
+	require.NoError(t, access.InitWithDB(testDB), "could not init the access lists")
+
+	ic, err := integrations.NewController(testDB)
+	require.NoError(t, err, "could not create integrations controller")
+	controller, err := logparsingpipeline.NewLogParsingPipelinesController(
+		testDB, "sqlite", ic.GetPipelinesForInstalledIntegrations,
+	)
+	require.NoError(t, err, "could not create a logparsingpipelines controller")
+
+	apiHandler, err := app.NewAPIHandler(app.APIHandlerOpts{
+		AppDao:                        dao.DB(),
+		IntegrationsController:        ic,
+		LogsParsingPipelineController: controller,
+	})
+	require.NoError(t, err, "could not create a new ApiHandler")
+
+	testDB, err = opampModel.InitDB(testDB)
+	require.NoError(t, err, "failed to init opamp model")
+	_, err = agentConf.Initiate(&agentConf.ManagerOptions{
+		DB:            testDB,
+		DBEngine:      "sqlite",
+		AgentFeatures: []agentConf.AgentFeature{controller},
+	})
+	require.NoError(t, err, "failed to init agentConf")
+
+	router := app.NewRouter()
+	// the handlers get the jwt from the context, as attached by the middlewares of the server
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(auth.AttachJwtToContext(r.Context(), r)))
+		})
+	})
+	am := app.NewAuthMiddleware(auth.GetUserFromRequest)
+	apiHandler.RegisterRoutes(router, am)
+	apiHandler.RegisterLogsRoutes(router, am)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	user, apiErr := createTestUser()
+	require.Nil(t, apiErr, "could not create a test user")
+	userJwt, err := auth.GenerateJWTForUser(user)
+	require.NoError(t, err)
+
+	return server.URL, userJwt.AccessJwt
+}
+
+func TestClientDashboards(t *testing.T) {
+	c := NewClientTestBed(t)
+	ctx := context.Background()
+
+	created, err := c.CreateDashboard(ctx, dashboards.Data{"title": "API", "tags": []string{"api"}})
+	require.NoError(t, err)
+	require.Equal(t, "API", created.Data["title"])
+
+	_, err = c.CreateDashboard(ctx, dashboards.Data{"title": "Hosts", "tags": []string{"infra"}})
+	require.NoError(t, err)
+
+	list, err := c.ListDashboards(ctx, "api")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, created.Uuid, list[0].Uuid)
+
+	updated, err := c.UpdateDashboard(ctx, created.Uuid, dashboards.Data{"title": "API v2"})
+	require.NoError(t, err)
+	require.Equal(t, "API v2", updated.Data["title"])
+
+	got, err := c.GetDashboard(ctx, created.Uuid)
+	require.NoError(t, err)
+	require.Equal(t, "API v2", got.Data["title"])
+
+	require.NoError(t, c.DeleteDashboard(ctx, created.Uuid))
+	list, err = c.ListDashboards(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "Hosts", list[0].Data["title"])
+}
+
+func TestClientPipelines(t *testing.T) {
+	c := NewClientTestBed(t)
+	ctx := context.Background()
+
+	resp, err := c.GetPipelines(ctx, -1)
+	require.NoError(t, err)
+	require.Empty(t, resp.Pipelines)
+
+	saved, err := c.SavePipelines(ctx, logparsingpipeline.PostablePipelines{
+		Pipelines: []logparsingpipeline.PostablePipeline{{
+			OrderId: 1,
+			Name:    "pipeline1",
+			Alias:   "pipeline1",
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{{
+					Key:      v3.AttributeKey{Key: "method", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+					Operator: "=",
+					Value:    "GET",
+				}},
+			},
+			Config: []logparsingpipeline.PipelineOperator{{
+				OrderId: 1,
+				ID:      "add",
+				Type:    "add",
+				Field:   "attributes.test",
+				Value:   "val",
+				Enabled: true,
+				Name:    "test add",
+			}},
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, saved.Pipelines, 1)
+
+	resp, err = c.GetPipelines(ctx, saved.Version)
+	require.NoError(t, err)
+	require.Len(t, resp.Pipelines, 1)
+	require.Equal(t, "pipeline1", resp.Pipelines[0].Name)
+	require.Len(t, resp.History, 1)
+
+	_, err = c.SavePipelines(ctx, logparsingpipeline.PostablePipelines{
+		Pipelines: []logparsingpipeline.PostablePipeline{{OrderId: 1, Name: "invalid"}},
+	})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, 400, apiErr.StatusCode)
+}
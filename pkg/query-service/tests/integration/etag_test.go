@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/app/logparsingpipeline"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// conditionalRequest sends the request with the headers and returns the status, the ETag
+// and the body of the response
+func conditionalRequest(
+	t *testing.T, url, token, method, path string, body interface{}, headers map[string]string,
+) (int, string, string) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, url+path, reader)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, resp.Header.Get("ETag"), string(b)
+}
+
+func TestDashboardsConditionalRequests(t *testing.T) {
+	url, token := NewQueryServiceTestServer(t)
+	path := "/api/v1/dashboards/external-id"
+
+	// PUT creates the missing dashboard with the uuid of the path
+	status, etag, body := conditionalRequest(t, url, token, http.MethodPut, path,
+		map[string]interface{}{"title": "API"}, map[string]string{"If-None-Match": "*"})
+	require.Equal(t, http.StatusOK, status, body)
+	require.NotEmpty(t, etag)
+
+	status, _, _ = conditionalRequest(t, url, token, http.MethodPut, path,
+		map[string]interface{}{"title": "API"}, map[string]string{"If-None-Match": "*"})
+	require.Equal(t, http.StatusPreconditionFailed, status)
+
+	status, getETag, body := conditionalRequest(t, url, token, http.MethodGet, path, nil, nil)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, etag, getETag)
+	require.Contains(t, body, `"uuid":"external-id"`)
+
+	// the same PUT is repeated with the same result
+	status, repeatedETag, body := conditionalRequest(t, url, token, http.MethodPut, path,
+		map[string]interface{}{"title": "API"}, map[string]string{"If-Match": etag})
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, etag, repeatedETag)
+
+	status, updatedETag, body := conditionalRequest(t, url, token, http.MethodPut, path,
+		map[string]interface{}{"title": "API v2"}, map[string]string{"If-Match": etag})
+	require.Equal(t, http.StatusOK, status, body)
+	require.NotEqual(t, etag, updatedETag)
+
+	// the stale tag does not overwrite the update
+	status, _, body = conditionalRequest(t, url, token, http.MethodPut, path,
+		map[string]interface{}{"title": "API v3"}, map[string]string{"If-Match": etag})
+	require.Equal(t, http.StatusPreconditionFailed, status, body)
+	status, _, _ = conditionalRequest(t, url, token, http.MethodDelete, path, nil, map[string]string{"If-Match": etag})
+	require.Equal(t, http.StatusPreconditionFailed, status)
+
+	status, _, body = conditionalRequest(t, url, token, http.MethodPost, "/api/v1/dashboards",
+		map[string]interface{}{"uuid": "external-id", "title": "API"}, nil)
+	require.Equal(t, http.StatusConflict, status, body)
+
+	status, _, body = conditionalRequest(t, url, token, http.MethodDelete, path, nil, map[string]string{"If-Match": updatedETag})
+	require.Equal(t, http.StatusOK, status, body)
+	status, _, _ = conditionalRequest(t, url, token, http.MethodGet, path, nil, nil)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestPipelinesConditionalRequests(t *testing.T) {
+	url, token := NewQueryServiceTestServer(t)
+	path := "/api/v1/logs/pipelines"
+
+	status, etag, body := conditionalRequest(t, url, token, http.MethodGet, path+"/latest", nil, nil)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, `"0"`, etag)
+
+	pipelines := logparsingpipeline.PostablePipelines{
+		Pipelines: []logparsingpipeline.PostablePipeline{{
+			OrderId: 1,
+			Name:    "pipeline1",
+			Alias:   "pipeline1",
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{{
+					Key:      v3.AttributeKey{Key: "method", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+					Operator: "=",
+					Value:    "GET",
+				}},
+			},
+			Config: []logparsingpipeline.PipelineOperator{{
+				OrderId: 1,
+				ID:      "add",
+				Type:    "add",
+				Field:   "attributes.test",
+				Value:   "val",
+				Enabled: true,
+				Name:    "test add",
+			}},
+		}},
+	}
+	status, savedETag, body := conditionalRequest(t, url, token, http.MethodPost, path, pipelines, map[string]string{"If-Match": etag})
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, `"1"`, savedETag)
+
+	// saving the same pipelines again does not start a new version
+	status, repeatedETag, body := conditionalRequest(t, url, token, http.MethodPost, path, pipelines, nil)
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, savedETag, repeatedETag)
+
+	pipelines.Pipelines[0].Enabled = false
+	status, _, body = conditionalRequest(t, url, token, http.MethodPost, path, pipelines, map[string]string{"If-Match": etag})
+	require.Equal(t, http.StatusPreconditionFailed, status, body)
+	status, changedETag, body := conditionalRequest(t, url, token, http.MethodPost, path, pipelines, map[string]string{"If-Match": savedETag})
+	require.Equal(t, http.StatusOK, status, body)
+	require.Equal(t, `"2"`, changedETag)
+
+	// the aliases are the stable ids of the pipelines
+	pipelines.Pipelines = append(pipelines.Pipelines, pipelines.Pipelines[0])
+	pipelines.Pipelines[1].OrderId = 2
+	status, _, body = conditionalRequest(t, url, token, http.MethodPost, path, pipelines, nil)
+	require.Equal(t, http.StatusBadRequest, status, body)
+}
@@ -0,0 +1,17 @@
+package cronschedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Parser accepts the standard five field cron expressions
+// along with descriptors such as @daily
+var Parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Parse parses the cron expression in the timezone
+func Parse(expr string, loc *time.Location) (cron.Schedule, error) {
+	return Parser.Parse(fmt.Sprintf("CRON_TZ=%s %s", loc.String(), expr))
+}
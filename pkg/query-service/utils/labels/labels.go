@@ -28,6 +28,30 @@ const (
 	AlertSummaryLabel     = "summary"
 	AlertDescriptionLabel = "description"
 
+	// AlertMaintenanceLabel is the annotation set on alerts
+	// suppressed by a planned maintenance
+	AlertMaintenanceLabel = "maintenance"
+
+	// AlertMessageLabel is the annotation holding the message
+	// rendered from the notification template of the rule
+	AlertMessageLabel = "message"
+
+	// AlertEscalationStepLabel is the annotation set on
+	// notifications sent by an escalation policy step
+	AlertEscalationStepLabel = "escalationStep"
+
+	// AlertDeploymentLabel is the annotation describing the deployment
+	// of the service of an alert shortly before it fired
+	AlertDeploymentLabel = "deployment"
+
+	// AlertEventLabel is the annotation describing the structured
+	// event of the service of an alert shortly before it fired
+	AlertEventLabel = "event"
+
+	// AlertSilencedByLabel is the annotation listing the ids
+	// of the silences muting an alert
+	AlertSilencedByLabel = "silencedBy"
+
 	AlertMissingData = "Missing data"
 )
 
@@ -1,5 +1,7 @@
 package utils
 
+import "sort"
+
 // Map as in map-reduce.
 func MapSlice[Slice ~[]Elem, Elem any, Output any](
 	slice Slice, mapper func(Elem) Output,
@@ -27,3 +29,25 @@ func FilterSlice[Slice ~[]Elem, Elem any](
 
 	return result
 }
+
+// SortByValue sorts the items on the value of the column, the highest first unless ascending,
+// the items without value last. The ties are sorted on the keys of the items, e.g. the rows
+// of the infra monitoring lists
+func SortByValue[Slice ~[]Elem, Elem any](
+	slice Slice, values func(Elem) map[string]float64, key func(Elem) string, column string, ascending bool,
+) {
+	sort.Slice(slice, func(i, j int) bool {
+		vi, iok := values(slice[i])[column]
+		vj, jok := values(slice[j])[column]
+		if iok != jok {
+			return iok
+		}
+		if vi != vj {
+			if ascending {
+				return vi < vj
+			}
+			return vi > vj
+		}
+		return key(slice[i]) < key(slice[j])
+	})
+}
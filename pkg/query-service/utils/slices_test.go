@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortByValue(t *testing.T) {
+	type row struct {
+		name   string
+		values map[string]float64
+	}
+	rows := []row{
+		{name: "b", values: map[string]float64{"cpu": 1}},
+		{name: "c", values: map[string]float64{}},
+		{name: "d", values: map[string]float64{"cpu": 2}},
+		{name: "a", values: map[string]float64{"cpu": 1}},
+	}
+	names := func() []string {
+		return MapSlice(rows, func(r row) string { return r.name })
+	}
+	values := func(r row) map[string]float64 { return r.values }
+	key := func(r row) string { return r.name }
+
+	// the highest first, the rows without value last and the ties on the keys
+	SortByValue(rows, values, key, "cpu", false)
+	if expected := []string{"d", "a", "b", "c"}; !reflect.DeepEqual(names(), expected) {
+		t.Errorf("expected %v, got %v", expected, names())
+	}
+	SortByValue(rows, values, key, "cpu", true)
+	if expected := []string{"a", "b", "d", "c"}; !reflect.DeepEqual(names(), expected) {
+		t.Errorf("expected %v, got %v", expected, names())
+	}
+}
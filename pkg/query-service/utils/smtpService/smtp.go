@@ -1,7 +1,13 @@
 package smtpservice
 
 import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
 	"net/smtp"
+	"net/textproto"
 	"os"
 	"strings"
 	"sync"
@@ -35,23 +41,80 @@ func GetInstance() *SMTP {
 	return smtpInstance
 }
 
+// Attachment is a file attached to the email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+func (s *SMTP) send(to string, msg []byte) error {
+	addr := s.Host + ":" + s.Port
+	if s.Password == "" || s.Username == "" {
+		return smtp.SendMail(addr, nil, s.From, strings.Split(to, ","), msg)
+	}
+	auth := smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	return smtp.SendMail(addr, auth, s.From, strings.Split(to, ","), msg)
+}
+
+// SendEmailWithAttachments sends a multipart email with the html body
+// followed by the attachments
+func (s *SMTP) SendEmailWithAttachments(to, subject, body string, attachments ...Attachment) error {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	buf.WriteString("From: " + s.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=" + writer.Boundary() + "\r\n" +
+		"\r\n")
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/html; charset=UTF-8"}})
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write([]byte(body)); err != nil {
+		return err
+	}
+
+	for _, a := range attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", a.Filename)},
+		})
+		if err != nil {
+			return err
+		}
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		// the lines of the encoded data must not exceed 76 characters
+		for len(encoded) > 76 {
+			if _, err := part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+				return err
+			}
+			encoded = encoded[76:]
+		}
+		if _, err := part.Write([]byte(encoded)); err != nil {
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return s.send(to, buf.Bytes())
+}
+
 func (s *SMTP) SendEmail(to, subject, body string) error {
 
 	msgString := "From: " + s.From + "\r\n" +
 		"To: " + to + "\r\n" +
-		"Subject: " + subject + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n" +
 		"MIME-Version: 1.0\r\n" +
 		"Content-Type: text/html; charset=UTF-8\r\n" +
 		"\r\n" +
 		body
 
-	msg := []byte(msgString)
-
-	addr := s.Host + ":" + s.Port
-	if s.Password == "" || s.Username == "" {
-		return smtp.SendMail(addr, nil, s.From, strings.Split(to, ","), msg)
-	} else {
-		auth := smtp.PlainAuth("", s.Username, s.Password, s.Host)
-		return smtp.SendMail(addr, auth, s.From, strings.Split(to, ","), msg)
-	}
+	return s.send(to, []byte(msgString))
 }